@@ -0,0 +1,83 @@
+package bulkhead
+
+import (
+	"context"
+	"time"
+)
+
+// FullError is returned when a bulkhead has no free slot within its wait time
+type FullError struct {
+	Name string
+}
+
+func (e *FullError) Error() string {
+	return e.Name + " is busy, please try again"
+}
+
+// Bulkhead bounds the calls in flight to one external dependency, so a slow dependency
+// ties up its own slots instead of every request handler
+type Bulkhead struct {
+	Name  string
+	slots chan struct{}
+	wait  time.Duration
+}
+
+// New returns a bulkhead of size slots where callers wait at most wait for a free one.
+// A size of zero or less does not bound the calls.
+func New(name string, size int, wait time.Duration) *Bulkhead {
+	b := &Bulkhead{Name: name, wait: wait}
+	if size > 0 {
+		b.slots = make(chan struct{}, size)
+	}
+	return b
+}
+
+// Acquire takes a slot, the caller must call release once its call returns
+func (b *Bulkhead) Acquire(ctx context.Context) (release func(), err error) {
+	if b.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		return b.release, nil
+	default:
+	}
+
+	timer := time.NewTimer(b.wait)
+	defer timer.Stop()
+
+	select {
+	case b.slots <- struct{}{}:
+		return b.release, nil
+	case <-timer.C:
+		return nil, &FullError{Name: b.Name}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Do runs fn in a slot of the bulkhead
+func (b *Bulkhead) Do(ctx context.Context, fn func() error) error {
+	release, err := b.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return fn()
+}
+
+// InUse is the number of calls in flight
+func (b *Bulkhead) InUse() int {
+	return len(b.slots)
+}
+
+// Size is the number of slots, zero when unbounded
+func (b *Bulkhead) Size() int {
+	return cap(b.slots)
+}
+
+func (b *Bulkhead) release() {
+	<-b.slots
+}
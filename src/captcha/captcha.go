@@ -0,0 +1,120 @@
+// Package captcha verifies the CAPTCHA tokens browsers get from Cloudflare Turnstile or Google reCAPTCHA
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Providers New knows, both verify tokens with the same siteverify API
+const (
+	ProviderTurnstile = "turnstile"
+	ProviderRecaptcha = "recaptcha" // v2 and v3, v3 answers are held to Settings.MinScore
+)
+
+var siteverifyURLs = map[string]string{
+	ProviderTurnstile: "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+	ProviderRecaptcha: "https://www.google.com/recaptcha/api/siteverify",
+}
+
+// ErrRejected is returned for tokens the provider did not accept: solved wrong, expired, used before or, for
+// reCAPTCHA v3, scored too low
+var ErrRejected = errors.New("captcha: token rejected")
+
+// Verifier verifies tokens with one provider. Errors other than ErrRejected mean the provider could not be asked.
+type Verifier interface {
+	Name() string
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// Settings of the providers
+type Settings struct {
+	Secret   string
+	Endpoint string  // replaces the siteverify URL of the provider when set
+	MinScore float64 // lowest reCAPTCHA v3 score accepted
+	Timeout  time.Duration
+}
+
+// New returns the verifier of provider, nil when CAPTCHA is off
+func New(provider string, settings Settings) (Verifier, error) {
+	switch provider {
+	case "":
+		return nil, nil
+	case ProviderTurnstile, ProviderRecaptcha:
+		if settings.Secret == "" {
+			return nil, fmt.Errorf("captcha: CAPTCHA_SECRET is required for %s", provider)
+		}
+		endpoint := settings.Endpoint
+		if endpoint == "" {
+			endpoint = siteverifyURLs[provider]
+		}
+		return &siteverify{
+			provider: provider,
+			endpoint: endpoint,
+			secret:   settings.Secret,
+			minScore: settings.MinScore,
+			client:   &http.Client{Timeout: settings.Timeout},
+		}, nil
+	}
+	return nil, fmt.Errorf("captcha: unknown provider %q", provider)
+}
+
+type siteverify struct {
+	provider string
+	endpoint string
+	secret   string
+	minScore float64
+	client   *http.Client
+}
+
+type siteverifyResponse struct {
+	Success    bool     `json:"success"`
+	Score      *float64 `json:"score"` // reCAPTCHA v3 only
+	ErrorCodes []string `json:"error-codes"`
+}
+
+func (v *siteverify) Name() string {
+	return v.provider
+}
+
+func (v *siteverify) Verify(ctx context.Context, token, remoteIP string) error {
+	form := url.Values{"secret": {v.secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("%s: %w", v.provider, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", v.provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: siteverify answered %d", v.provider, resp.StatusCode)
+	}
+
+	var answer siteverifyResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&answer); err != nil {
+		return fmt.Errorf("%s: %w", v.provider, err)
+	}
+	if !answer.Success {
+		return fmt.Errorf("%w by %s: %s", ErrRejected, v.provider, strings.Join(answer.ErrorCodes, ", "))
+	}
+	if answer.Score != nil && *answer.Score < v.minScore {
+		return fmt.Errorf("%w by %s: score %.1f", ErrRejected, v.provider, *answer.Score)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+type contextKey struct{}
+
+// WithOffset returns a context whose clock runs offset ahead of the real one, or behind when negative.
+// It is only set by the test clock middleware, which is disabled in production.
+func WithOffset(ctx context.Context, offset time.Duration) context.Context {
+	return context.WithValue(ctx, contextKey{}, offset)
+}
+
+// Offset returns the clock offset of ctx, zero when the real clock is used
+func Offset(ctx context.Context) time.Duration {
+	offset, _ := ctx.Value(contextKey{}).(time.Duration)
+	return offset
+}
+
+// Now returns the current time as seen by ctx. Code deciding whether a subscription is active, expired
+// or due uses it instead of time.Now so QA can move a request to the renewal day.
+func Now(ctx context.Context) time.Time {
+	if ctx == nil {
+		return time.Now()
+	}
+	return time.Now().Add(Offset(ctx))
+}
+
+// ParseOverride reads a test clock header value, either the time to move to (RFC 3339) or an offset
+// such as 720h or -24h
+func ParseOverride(value string, now time.Time) (time.Duration, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at.Sub(now), nil
+	}
+	return time.ParseDuration(value)
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"log"
+	"net/url"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -10,6 +12,7 @@ var (
 	IsProd              bool
 	AppHost             string
 	AppPort             int
+	AppURL              string // public base URL of the API, for links that call it directly
 	FrontendURL         string
 	DBHost              string
 	DBUser              string
@@ -38,6 +41,364 @@ var (
 	GRPC_PORT           string
 )
 
+// Email providers: mail goes through EmailProvider and fails over to EmailFallbackProvider when the error or
+// bounce rate of the primary over EmailHealthWindow exceeds its limit, once it made EmailFailoverMinAttempts
+// attempts in the window
+var (
+	EmailProvider            string
+	EmailFallbackProvider    string
+	EmailTimeout             time.Duration
+	SESRegion                string
+	SESAccessKey             string
+	SESSecretKey             string
+	SESEndpoint              string
+	EmailHealthWindow        time.Duration
+	EmailFailoverMinAttempts int
+	EmailFailoverErrorRate   float64
+	EmailFailoverBounceRate  float64
+	EmailAttemptRetention    time.Duration
+	// Topic SES publishes bounces and complaints to, notifications of other topics are refused
+	SESNotificationTopicArn string
+)
+
+// Phone messages: codes and notifications go by SMS or WhatsApp through PhoneProvider. A phone gets a new code
+// at most every OTPResendInterval and OTPMaxPerHour times an hour, a code expires after OTPTTL or
+// OTPMaxAttempts wrong guesses. A user gets OTPMaxPerUserPerHour verification codes an hour whatever the phones,
+// an IP address asks for OTPMaxPerIPPerHour codes an hour. Numbers without a country code are in
+// PhoneDefaultCountryCode.
+var (
+	PhoneProvider           string
+	PhoneTimeout            time.Duration
+	PhoneDefaultCountryCode string
+	TwilioAccountSID        string
+	TwilioAuthToken         string
+	TwilioSMSFrom           string
+	TwilioWhatsAppFrom      string
+	TwilioStatusCallbackURL string
+	TwilioEndpoint          string
+	OTPTTL                  time.Duration
+	OTPMaxAttempts          int
+	OTPResendInterval       time.Duration
+	OTPMaxPerHour           int
+	OTPMaxPerUserPerHour    int
+	OTPMaxPerIPPerHour      int
+)
+
+// Magic links sign in from an email, once and until MagicLinkTTL. A user gets a new link at most every
+// MagicLinkResendInterval and MagicLinkMaxPerHour times an hour, an IP address asks for MagicLinkMaxPerIPPerHour
+// links an hour.
+var (
+	MagicLinkTTL             time.Duration
+	MagicLinkResendInterval  time.Duration
+	MagicLinkMaxPerHour      int
+	MagicLinkMaxPerIPPerHour int
+)
+
+// Sandbox checkout configuration
+var (
+	// MidtransSandboxServerKey pays the checkouts of sandbox users when MIDTRANS_STATUS is PRODUCTION
+	MidtransSandboxServerKey string
+)
+
+// TimeTravelEnabled lets QA shift the clock of a request with the X-Test-Clock header, it is ignored in production
+var TimeTravelEnabled bool
+
+// Database pool and query deadline configuration
+var (
+	DBMaxOpenConns      int
+	DBMaxIdleConns      int
+	DBConnMaxLifetime   time.Duration
+	DBConnMaxIdleTime   time.Duration
+	DBQueryTimeout      time.Duration // deadline of the queries of one request
+	DBAdminQueryTimeout time.Duration // longer deadline of admin requests, which are also cancelled when the admin disconnects
+	DBExportTimeout     time.Duration // deadline of a streamed export, which outlives the request handler
+)
+
+// Billing configuration
+var (
+	InstallmentGraceDays Flag[int]
+	// A failed installment is billed again every InstallmentRetryInterval, up to InstallmentMaxAttempts links
+	InstallmentMaxAttempts   int
+	InstallmentRetryInterval time.Duration
+
+	FraudMaxFailedPayments Flag[int]
+	FraudFailedWindowHours int
+	FraudMaxPurchases      Flag[int]
+	FraudChurnWindowDays   int
+
+	CheckoutMaxAttemptsPerUser Flag[int]
+	CheckoutMaxAttemptsPerIP   Flag[int]
+	CheckoutWindowMinutes      int
+	CheckoutBlockMinutes       int
+	CheckoutSessionTTLMinutes  int
+
+	RenewalLeadHours   int
+	RenewalRetryHours  int
+	RenewalMaxAttempts int
+
+	AdminUndoWindowMinutes int
+)
+
+// Profanity filter of names, recipe titles and chat, actions per severity: allow, mask or reject
+var (
+	ProfanityMildAction   string
+	ProfanitySevereAction string
+)
+
+// In-app purchase configuration
+var (
+	AppleIAPSharedSecret         string
+	AppleIAPBundleID             string
+	AppleIAPRootCAPath           string
+	GooglePlayPackageName        string
+	GooglePlayServiceAccountPath string
+	GooglePlayNotificationToken  string
+)
+
+// Alerting configuration
+var (
+	TelegramBotToken string
+)
+
+// Ops bot configuration
+var (
+	OpsBotSlackSigningSecret    string
+	OpsBotTelegramWebhookSecret string
+	OpsBotReportSlackWebhookURL string
+	OpsBotReportTelegramChatID  string
+	OpsBotReportHour            Flag[int]
+)
+
+// Background job configuration
+var (
+	UserCounterReconcileHour Flag[int]
+	DailyTipHour             Flag[int]
+	CohortAggregateHour      Flag[int]
+	ArchiveAfterMonths       int
+	ArchiveTablespace        string
+	ScanQuotaFlushInterval   time.Duration
+	ScanQuotaCacheTTL        time.Duration
+)
+
+// Data retention: how long scan images, logs, inactive accounts, the activity audit trail and where activities
+// came from (their IP address, the location of logins) are kept, 0 keeps them forever.
+// The retention job only reports what it would change until RETENTION_DRY_RUN is turned off.
+var (
+	RetentionScanImageMonths       int
+	RetentionLogDays               int
+	RetentionInactiveAccountMonths int
+	RetentionActivityLogMonths     int
+	RetentionLoginLocationDays     int
+	RetentionDryRun                Flag[bool]
+)
+
+// Redis configuration
+var (
+	RedisURL string
+)
+
+// Search index: the Elasticsearch or OpenSearch cluster foods, articles and recipes are searched in, the
+// prefix of its indexes and how often they are rebuilt from the sources
+var (
+	SearchIndexURL        string
+	SearchIndexPrefix     string
+	SearchReindexInterval time.Duration
+)
+
+// Bulkheads of the external providers: calls in flight per provider and how long a call waits for a free slot
+var (
+	BulkheadPaymentSize int
+	BulkheadAISize      int
+	BulkheadEmailSize   int
+	BulkheadLLMSize     int
+	BulkheadSpeechSize  int
+	BulkheadWait        time.Duration
+)
+
+// Database backups: pg_dump binary, the S3 compatible bucket dumps are uploaded to and how long a dump may take
+var (
+	BackupPgDumpPath string
+	BackupS3Endpoint string
+	BackupS3Region   string
+	BackupS3Bucket   string
+	BackupS3Prefix   string
+	BackupS3Access   string
+	BackupS3Secret   string
+	BackupTimeout    time.Duration
+)
+
+// Deep links: the domain the app claims for universal links, where tracked links redirect to, and how long
+// a tracked link keeps opening its screen
+var (
+	DeepLinkBaseURL string
+	DeepLinkTTL     time.Duration
+)
+
+// Parental consent: users younger than this need the consent of a guardian, who has this long to answer
+var (
+	ParentalConsentAge     int
+	ParentalConsentLinkTTL time.Duration
+)
+
+// Handles: how long a user waits between renames of their public handle, picking the first one is free
+var HandleRenameCooldown time.Duration
+
+// Media: the CDN in front of /uploads that media addresses in responses are signed for, the signing key and its
+// version (bumped to invalidate every URL the CDN cached), how long each kind of media stays valid, and the hosts
+// media used to be served from directly, comma separated
+var (
+	MediaCDNURL     string
+	MediaSigningKey string
+	MediaKeyVersion int
+	MediaScanTTL    time.Duration
+	MediaAvatarTTL  time.Duration
+	MediaContentTTL time.Duration
+	MediaOrigins    string
+)
+
+// Orphaned media: the daily janitor removes the files under /uploads no record refers to anymore, once they are
+// older than the grace period. It archives them under the prefix of the backup bucket or deletes them, and only
+// reports what it would remove until MEDIA_CLEANUP_DRY_RUN is turned off.
+var (
+	MediaCleanupAction string
+	MediaCleanupGrace  time.Duration
+	MediaArchivePrefix string
+	MediaCleanupDryRun Flag[bool]
+)
+
+// Outbound webhooks: how long an endpoint has to answer, and how often a failed delivery is retried, waiting
+// from WEBHOOK_RETRY_BASE and doubling up to WEBHOOK_RETRY_MAX between attempts
+var (
+	WebhookTimeout     time.Duration
+	WebhookMaxAttempts int
+	WebhookRetryBase   time.Duration
+	WebhookRetryMax    time.Duration
+)
+
+// Idempotency keys: how long the response of a request sent with an Idempotency-Key is returned to its retries
+var IdempotencyKeyTTL time.Duration
+
+// Storage usage: what storage costs a month per TB, in STORAGE_PRICE_CURRENCY, to estimate the cost of the
+// measured usage. Backups count for as long as the lifecycle rule of the backup bucket keeps them.
+var (
+	StoragePricePerTB    string
+	StoragePriceCurrency string
+	BackupRetention      time.Duration
+)
+
+// Public API for the marketing website: how long an instance serves its copy of the plans and of the
+// published content, the requests per minute an IP address may send, the website links to content point to
+// and how many of the newest items the feeds carry
+var (
+	PublicPlansCacheTTL   time.Duration
+	PublicContentCacheTTL time.Duration
+	PublicRateLimit       int
+	PublicSiteURL         string
+	PublicFeedSize        int
+)
+
+// Nutrition assistant: the LLM provider (openai or gemini) and model answering chats, how many earlier messages
+// and days of the diary a chat sends along, and the messages per day of users without a subscription
+var (
+	AssistantProvider          string
+	AssistantAPIKey            string
+	AssistantBaseURL           string
+	AssistantModel             string
+	AssistantTimeout           time.Duration
+	AssistantMaxTokens         int
+	AssistantHistoryMessages   int
+	AssistantContextDays       int
+	AssistantFreeDailyMessages Flag[int]
+)
+
+// Voice logging: the speech to text provider (openai or any API compatible with its transcriptions) and model
+// transcribing voice logs, and the largest audio clip accepted
+var (
+	SpeechProvider     string
+	SpeechAPIKey       string
+	SpeechBaseURL      string
+	SpeechModel        string
+	SpeechTimeout      time.Duration
+	VoiceLogMaxBytes   int64
+	VoiceLogMaxEntries int
+)
+
+// Diary exports: ranges up to DiaryExportSyncDays are answered at once, longer ones are produced in the
+// background and kept for DiaryExportTTL
+var (
+	DiaryExportSyncDays int
+	DiaryExportMaxDays  int
+	DiaryExportTTL      time.Duration
+)
+
+// CAPTCHA: CaptchaProvider (turnstile, recaptcha, or empty to turn it off) verifies the tokens of signups,
+// password resets and logins of an email or an IP address with CaptchaLoginFailures failures within
+// CaptchaLoginWindow. The IP address is the client's as ProxyHeader gives it, requests of trusted proxies
+// without it only count per email. Requests with one of CaptchaBypassKeys, comma separated, skip it.
+var (
+	CaptchaProvider      string
+	CaptchaSecret        string
+	CaptchaEndpoint      string
+	CaptchaMinScore      float64
+	CaptchaTimeout       time.Duration
+	CaptchaLoginFailures int
+	CaptchaLoginWindow   time.Duration
+	CaptchaBypassKeys    string
+)
+
+// IP geolocation: the CDN sends the location of the client in GeoIPCountryHeader, GeoIPRegionHeader and
+// GeoIPCityHeader, empty to not trust a header. GeoIPProvider (ipinfo, ipapi, or empty to read the headers only)
+// looks up the public addresses the headers do not locate over HTTPS, answers are kept in memory for
+// GeoIPCacheTTL and never stored.
+var (
+	GeoIPCountryHeader string
+	GeoIPRegionHeader  string
+	GeoIPCityHeader    string
+	GeoIPProvider      string
+	GeoIPToken         string
+	GeoIPEndpoint      string
+	GeoIPTimeout       time.Duration
+	GeoIPCacheTTL      time.Duration
+)
+
+// Client addresses: behind a proxy or CDN the address of the client is read from ProxyHeader, only on requests
+// coming from TrustedProxies (comma separated addresses and CIDR ranges). Other requests, and all of them
+// without a header, get the address of the connection.
+var (
+	ProxyHeader    string
+	TrustedProxies string
+)
+
+// Passkeys are bound to the domain WebAuthnRPID and used from the pages of WebAuthnOrigins, comma separated.
+// A registration or sign-in must be answered within WebAuthnTimeout, a user has at most PasskeyMaxPerUser.
+var (
+	WebAuthnRPID      string
+	WebAuthnRPName    string
+	WebAuthnOrigins   string
+	WebAuthnTimeout   time.Duration
+	PasskeyMaxPerUser int
+)
+
+// RoleCacheTTL is how often every instance reloads the custom roles, a role changed on another instance applies
+// within it
+var RoleCacheTTL time.Duration
+
+// DownloadLinkTTL is how long a one-time download link of an export can be used
+var DownloadLinkTTL time.Duration
+
+// DiaryShareMaxTTL is the longest a diary share link can stay open, DiaryShareMaxActive the number of open
+// links a user can have
+var (
+	DiaryShareMaxTTL    time.Duration
+	DiaryShareMaxActive int
+)
+
+// PartnerTermsVersion is the version of the partner terms, partner keys need it accepted for premium scopes
+var PartnerTermsVersion string
+
+// PartnerEnrollmentCodeTTL is how long the code a user hands a partner to be enrolled by it stays valid
+var PartnerEnrollmentCodeTTL time.Duration
+
 func init() {
 	loadConfig()
 
@@ -45,6 +406,7 @@ func init() {
 	IsProd = viper.GetString("APP_ENV") == "prod"
 	AppHost = viper.GetString("APP_HOST")
 	AppPort = viper.GetInt("APP_PORT")
+	AppURL = viper.GetString("APP_URL")
 
 	FrontendURL = viper.GetString("FRONTEND_URL")
 
@@ -54,6 +416,20 @@ func init() {
 	DBPassword = viper.GetString("DB_PASSWORD")
 	DBName = viper.GetString("DB_NAME")
 	DBPort = viper.GetInt("DB_PORT")
+	viper.SetDefault("DB_MAX_OPEN_CONNS", 100)
+	viper.SetDefault("DB_MAX_IDLE_CONNS", 10)
+	viper.SetDefault("DB_CONN_MAX_LIFETIME", "60m")
+	viper.SetDefault("DB_CONN_MAX_IDLE_TIME", "10m")
+	viper.SetDefault("DB_QUERY_TIMEOUT", "10s")
+	viper.SetDefault("DB_ADMIN_QUERY_TIMEOUT", "60s")
+	viper.SetDefault("DB_EXPORT_TIMEOUT", "10m")
+	DBMaxOpenConns = viper.GetInt("DB_MAX_OPEN_CONNS")
+	DBMaxIdleConns = viper.GetInt("DB_MAX_IDLE_CONNS")
+	DBConnMaxLifetime = viper.GetDuration("DB_CONN_MAX_LIFETIME")
+	DBConnMaxIdleTime = viper.GetDuration("DB_CONN_MAX_IDLE_TIME")
+	DBQueryTimeout = viper.GetDuration("DB_QUERY_TIMEOUT")
+	DBAdminQueryTimeout = viper.GetDuration("DB_ADMIN_QUERY_TIMEOUT")
+	DBExportTimeout = viper.GetDuration("DB_EXPORT_TIMEOUT")
 
 	// product token
 	ProductTokenExpDays = viper.GetString("PRODUCT_TOKEN_EXP_DAYS")
@@ -76,6 +452,64 @@ func init() {
 	SMTPPassword = viper.GetString("SMTP_PASSWORD")
 	EmailFrom = viper.GetString("EMAIL_FROM")
 
+	// email provider configuration
+	viper.SetDefault("EMAIL_PROVIDER", "smtp")
+	viper.SetDefault("EMAIL_TIMEOUT", "10s")
+	viper.SetDefault("EMAIL_HEALTH_WINDOW", "15m")
+	viper.SetDefault("EMAIL_FAILOVER_MIN_ATTEMPTS", 20)
+	viper.SetDefault("EMAIL_FAILOVER_ERROR_RATE", 0.2)
+	viper.SetDefault("EMAIL_FAILOVER_BOUNCE_RATE", 0.1)
+	viper.SetDefault("EMAIL_ATTEMPT_RETENTION", "720h")
+	EmailProvider = viper.GetString("EMAIL_PROVIDER")
+	EmailFallbackProvider = viper.GetString("EMAIL_FALLBACK_PROVIDER")
+	EmailTimeout = viper.GetDuration("EMAIL_TIMEOUT")
+	SESRegion = viper.GetString("SES_REGION")
+	SESAccessKey = viper.GetString("SES_ACCESS_KEY_ID")
+	SESSecretKey = viper.GetString("SES_SECRET_ACCESS_KEY")
+	SESEndpoint = viper.GetString("SES_ENDPOINT")
+	SESNotificationTopicArn = viper.GetString("SES_NOTIFICATION_TOPIC_ARN")
+	EmailHealthWindow = viper.GetDuration("EMAIL_HEALTH_WINDOW")
+	EmailFailoverMinAttempts = viper.GetInt("EMAIL_FAILOVER_MIN_ATTEMPTS")
+	EmailFailoverErrorRate = viper.GetFloat64("EMAIL_FAILOVER_ERROR_RATE")
+	EmailFailoverBounceRate = viper.GetFloat64("EMAIL_FAILOVER_BOUNCE_RATE")
+	EmailAttemptRetention = viper.GetDuration("EMAIL_ATTEMPT_RETENTION")
+
+	// phone provider configuration
+	viper.SetDefault("PHONE_PROVIDER", "twilio")
+	viper.SetDefault("PHONE_TIMEOUT", "10s")
+	viper.SetDefault("PHONE_DEFAULT_COUNTRY_CODE", "62")
+	viper.SetDefault("OTP_TTL", "5m")
+	viper.SetDefault("OTP_MAX_ATTEMPTS", 5)
+	viper.SetDefault("OTP_RESEND_INTERVAL", "60s")
+	viper.SetDefault("OTP_MAX_PER_HOUR", 5)
+	viper.SetDefault("OTP_MAX_PER_USER_PER_HOUR", 10)
+	viper.SetDefault("OTP_MAX_PER_IP_PER_HOUR", 20)
+	PhoneProvider = viper.GetString("PHONE_PROVIDER")
+	PhoneTimeout = viper.GetDuration("PHONE_TIMEOUT")
+	PhoneDefaultCountryCode = viper.GetString("PHONE_DEFAULT_COUNTRY_CODE")
+	TwilioAccountSID = viper.GetString("TWILIO_ACCOUNT_SID")
+	TwilioAuthToken = viper.GetString("TWILIO_AUTH_TOKEN")
+	TwilioSMSFrom = viper.GetString("TWILIO_SMS_FROM")
+	TwilioWhatsAppFrom = viper.GetString("TWILIO_WHATSAPP_FROM")
+	TwilioStatusCallbackURL = viper.GetString("TWILIO_STATUS_CALLBACK_URL")
+	TwilioEndpoint = viper.GetString("TWILIO_ENDPOINT")
+	OTPTTL = viper.GetDuration("OTP_TTL")
+	OTPMaxAttempts = viper.GetInt("OTP_MAX_ATTEMPTS")
+	OTPResendInterval = viper.GetDuration("OTP_RESEND_INTERVAL")
+	OTPMaxPerHour = viper.GetInt("OTP_MAX_PER_HOUR")
+	OTPMaxPerUserPerHour = viper.GetInt("OTP_MAX_PER_USER_PER_HOUR")
+	OTPMaxPerIPPerHour = viper.GetInt("OTP_MAX_PER_IP_PER_HOUR")
+
+	// magic link configuration
+	viper.SetDefault("MAGIC_LINK_TTL", "15m")
+	viper.SetDefault("MAGIC_LINK_RESEND_INTERVAL", "60s")
+	viper.SetDefault("MAGIC_LINK_MAX_PER_HOUR", 5)
+	viper.SetDefault("MAGIC_LINK_MAX_PER_IP_PER_HOUR", 20)
+	MagicLinkTTL = viper.GetDuration("MAGIC_LINK_TTL")
+	MagicLinkResendInterval = viper.GetDuration("MAGIC_LINK_RESEND_INTERVAL")
+	MagicLinkMaxPerHour = viper.GetInt("MAGIC_LINK_MAX_PER_HOUR")
+	MagicLinkMaxPerIPPerHour = viper.GetInt("MAGIC_LINK_MAX_PER_IP_PER_HOUR")
+
 	// oauth2 configuration
 	GoogleClientID = viper.GetString("GOOGLE_CLIENT_ID")
 	GoogleClientSecret = viper.GetString("GOOGLE_CLIENT_SECRET")
@@ -84,10 +518,341 @@ func init() {
 	// Midtrans configuration
 	MidtransServerKey = viper.GetString("MIDTRANS_SERVER_KEY")
 	MidtransStatus = viper.GetString("MIDTRANS_STATUS")
+	MidtransSandboxServerKey = viper.GetString("MIDTRANS_SANDBOX_SERVER_KEY")
+
+	// test clock configuration
+	viper.SetDefault("TIME_TRAVEL_ENABLED", false)
+	TimeTravelEnabled = viper.GetBool("TIME_TRAVEL_ENABLED") && !IsProd
+
+	// installment configuration
+	viper.SetDefault("INSTALLMENT_GRACE_DAYS", 7)
+	InstallmentGraceDays.Set(viper.GetInt("INSTALLMENT_GRACE_DAYS"))
+	viper.SetDefault("INSTALLMENT_MAX_ATTEMPTS", 3)
+	viper.SetDefault("INSTALLMENT_RETRY_INTERVAL", "24h")
+	InstallmentMaxAttempts = viper.GetInt("INSTALLMENT_MAX_ATTEMPTS")
+	InstallmentRetryInterval = viper.GetDuration("INSTALLMENT_RETRY_INTERVAL")
+
+	// fraud detection configuration
+	viper.SetDefault("FRAUD_MAX_FAILED_PAYMENTS", 3)
+	viper.SetDefault("FRAUD_FAILED_WINDOW_HOURS", 24)
+	viper.SetDefault("FRAUD_MAX_PURCHASES", 3)
+	viper.SetDefault("FRAUD_CHURN_WINDOW_DAYS", 7)
+	FraudMaxFailedPayments.Set(viper.GetInt("FRAUD_MAX_FAILED_PAYMENTS"))
+	FraudFailedWindowHours = viper.GetInt("FRAUD_FAILED_WINDOW_HOURS")
+	FraudMaxPurchases.Set(viper.GetInt("FRAUD_MAX_PURCHASES"))
+	FraudChurnWindowDays = viper.GetInt("FRAUD_CHURN_WINDOW_DAYS")
+
+	// checkout velocity configuration
+	viper.SetDefault("CHECKOUT_MAX_ATTEMPTS_PER_USER", 5)
+	viper.SetDefault("CHECKOUT_MAX_ATTEMPTS_PER_IP", 10)
+	viper.SetDefault("CHECKOUT_WINDOW_MINUTES", 10)
+	viper.SetDefault("CHECKOUT_BLOCK_MINUTES", 60)
+	CheckoutMaxAttemptsPerUser.Set(viper.GetInt("CHECKOUT_MAX_ATTEMPTS_PER_USER"))
+	CheckoutMaxAttemptsPerIP.Set(viper.GetInt("CHECKOUT_MAX_ATTEMPTS_PER_IP"))
+	CheckoutWindowMinutes = viper.GetInt("CHECKOUT_WINDOW_MINUTES")
+	CheckoutBlockMinutes = viper.GetInt("CHECKOUT_BLOCK_MINUTES")
+
+	// checkout session configuration
+	viper.SetDefault("CHECKOUT_SESSION_TTL_MINUTES", 1440)
+	CheckoutSessionTTLMinutes = viper.GetInt("CHECKOUT_SESSION_TTL_MINUTES")
+
+	// auto-renewal configuration
+	viper.SetDefault("RENEWAL_LEAD_HOURS", 72)
+	viper.SetDefault("RENEWAL_RETRY_HOURS", 24)
+	viper.SetDefault("RENEWAL_MAX_ATTEMPTS", 3)
+	RenewalLeadHours = viper.GetInt("RENEWAL_LEAD_HOURS")
+	RenewalRetryHours = viper.GetInt("RENEWAL_RETRY_HOURS")
+	RenewalMaxAttempts = viper.GetInt("RENEWAL_MAX_ATTEMPTS")
+
+	// undo window of destructive admin actions
+	viper.SetDefault("ADMIN_UNDO_WINDOW_MINUTES", 10)
+	AdminUndoWindowMinutes = viper.GetInt("ADMIN_UNDO_WINDOW_MINUTES")
+
+	// profanity filter configuration
+	viper.SetDefault("PROFANITY_MILD_ACTION", "mask")
+	viper.SetDefault("PROFANITY_SEVERE_ACTION", "reject")
+	ProfanityMildAction = viper.GetString("PROFANITY_MILD_ACTION")
+	ProfanitySevereAction = viper.GetString("PROFANITY_SEVERE_ACTION")
+
+	// in-app purchase configuration
+	AppleIAPSharedSecret = viper.GetString("APPLE_IAP_SHARED_SECRET")
+	AppleIAPBundleID = viper.GetString("APPLE_IAP_BUNDLE_ID")
+	AppleIAPRootCAPath = viper.GetString("APPLE_IAP_ROOT_CA_PATH")
+	GooglePlayPackageName = viper.GetString("GOOGLE_PLAY_PACKAGE_NAME")
+	GooglePlayServiceAccountPath = viper.GetString("GOOGLE_PLAY_SERVICE_ACCOUNT_PATH")
+	GooglePlayNotificationToken = viper.GetString("GOOGLE_PLAY_NOTIFICATION_TOKEN")
+
+	// alerting configuration
+	TelegramBotToken = viper.GetString("TELEGRAM_BOT_TOKEN")
+
+	// ops bot configuration
+	viper.SetDefault("OPS_BOT_REPORT_HOUR", 8)
+	OpsBotSlackSigningSecret = viper.GetString("OPS_BOT_SLACK_SIGNING_SECRET")
+	OpsBotTelegramWebhookSecret = viper.GetString("OPS_BOT_TELEGRAM_WEBHOOK_SECRET")
+	OpsBotReportSlackWebhookURL = viper.GetString("OPS_BOT_REPORT_SLACK_WEBHOOK_URL")
+	OpsBotReportTelegramChatID = viper.GetString("OPS_BOT_REPORT_TELEGRAM_CHAT_ID")
+	OpsBotReportHour.Set(viper.GetInt("OPS_BOT_REPORT_HOUR"))
+
+	// background job configuration
+	viper.SetDefault("USER_COUNTER_RECONCILE_HOUR", 3)
+	UserCounterReconcileHour.Set(viper.GetInt("USER_COUNTER_RECONCILE_HOUR"))
+	viper.SetDefault("DAILY_TIP_HOUR", 7)
+	DailyTipHour.Set(viper.GetInt("DAILY_TIP_HOUR"))
+	viper.SetDefault("COHORT_AGGREGATE_HOUR", 4)
+	CohortAggregateHour.Set(viper.GetInt("COHORT_AGGREGATE_HOUR"))
+	viper.SetDefault("ARCHIVE_AFTER_MONTHS", 24)
+	ArchiveAfterMonths = viper.GetInt("ARCHIVE_AFTER_MONTHS")
+	ArchiveTablespace = viper.GetString("ARCHIVE_TABLESPACE")
+	viper.SetDefault("SCAN_QUOTA_FLUSH_INTERVAL", "30s")
+	ScanQuotaFlushInterval = viper.GetDuration("SCAN_QUOTA_FLUSH_INTERVAL")
+	viper.SetDefault("SCAN_QUOTA_CACHE_TTL", "5m")
+	ScanQuotaCacheTTL = viper.GetDuration("SCAN_QUOTA_CACHE_TTL")
+
+	// data retention configuration
+	viper.SetDefault("RETENTION_SCAN_IMAGE_MONTHS", 12)
+	viper.SetDefault("RETENTION_LOG_DAYS", 90)
+	viper.SetDefault("RETENTION_INACTIVE_ACCOUNT_MONTHS", 36)
+	viper.SetDefault("RETENTION_ACTIVITY_LOG_MONTHS", 24)
+	viper.SetDefault("RETENTION_LOGIN_LOCATION_DAYS", 90)
+	viper.SetDefault("RETENTION_DRY_RUN", true)
+	RetentionScanImageMonths = viper.GetInt("RETENTION_SCAN_IMAGE_MONTHS")
+	RetentionLogDays = viper.GetInt("RETENTION_LOG_DAYS")
+	RetentionInactiveAccountMonths = viper.GetInt("RETENTION_INACTIVE_ACCOUNT_MONTHS")
+	RetentionActivityLogMonths = viper.GetInt("RETENTION_ACTIVITY_LOG_MONTHS")
+	RetentionLoginLocationDays = viper.GetInt("RETENTION_LOGIN_LOCATION_DAYS")
+	RetentionDryRun.Set(viper.GetBool("RETENTION_DRY_RUN"))
+
+	// redis configuration
+	RedisURL = viper.GetString("REDIS_URL")
+
+	// search index configuration
+	viper.SetDefault("SEARCH_INDEX_PREFIX", "nutri")
+	viper.SetDefault("SEARCH_REINDEX_INTERVAL", "6h")
+	SearchIndexURL = viper.GetString("SEARCH_INDEX_URL")
+	SearchIndexPrefix = viper.GetString("SEARCH_INDEX_PREFIX")
+	SearchReindexInterval = viper.GetDuration("SEARCH_REINDEX_INTERVAL")
+
+	// bulkhead configuration
+	viper.SetDefault("BULKHEAD_PAYMENT_SIZE", 20)
+	viper.SetDefault("BULKHEAD_AI_SIZE", 10)
+	viper.SetDefault("BULKHEAD_EMAIL_SIZE", 5)
+	viper.SetDefault("BULKHEAD_LLM_SIZE", 10)
+	viper.SetDefault("BULKHEAD_SPEECH_SIZE", 5)
+	viper.SetDefault("BULKHEAD_WAIT", "2s")
+	BulkheadPaymentSize = viper.GetInt("BULKHEAD_PAYMENT_SIZE")
+	BulkheadAISize = viper.GetInt("BULKHEAD_AI_SIZE")
+	BulkheadEmailSize = viper.GetInt("BULKHEAD_EMAIL_SIZE")
+	BulkheadLLMSize = viper.GetInt("BULKHEAD_LLM_SIZE")
+	BulkheadSpeechSize = viper.GetInt("BULKHEAD_SPEECH_SIZE")
+	BulkheadWait = viper.GetDuration("BULKHEAD_WAIT")
+
+	// backup configuration
+	viper.SetDefault("BACKUP_PG_DUMP_PATH", "pg_dump")
+	viper.SetDefault("BACKUP_S3_ENDPOINT", "https://s3.amazonaws.com")
+	viper.SetDefault("BACKUP_S3_REGION", "us-east-1")
+	viper.SetDefault("BACKUP_S3_PREFIX", "backups/")
+	viper.SetDefault("BACKUP_TIMEOUT", "2h")
+	BackupPgDumpPath = viper.GetString("BACKUP_PG_DUMP_PATH")
+	BackupS3Endpoint = viper.GetString("BACKUP_S3_ENDPOINT")
+	BackupS3Region = viper.GetString("BACKUP_S3_REGION")
+	BackupS3Bucket = viper.GetString("BACKUP_S3_BUCKET")
+	BackupS3Prefix = viper.GetString("BACKUP_S3_PREFIX")
+	BackupS3Access = viper.GetString("BACKUP_S3_ACCESS_KEY")
+	BackupS3Secret = viper.GetString("BACKUP_S3_SECRET_KEY")
+	BackupTimeout = viper.GetDuration("BACKUP_TIMEOUT")
+
+	// deep link configuration
+	viper.SetDefault("DEEP_LINK_BASE_URL", FrontendURL)
+	viper.SetDefault("DEEP_LINK_TTL", "2160h")
+	DeepLinkBaseURL = viper.GetString("DEEP_LINK_BASE_URL")
+	DeepLinkTTL = viper.GetDuration("DEEP_LINK_TTL")
+
+	// parental consent configuration
+	viper.SetDefault("PARENTAL_CONSENT_AGE", 18)
+	viper.SetDefault("PARENTAL_CONSENT_LINK_TTL", "168h")
+	ParentalConsentAge = viper.GetInt("PARENTAL_CONSENT_AGE")
+	ParentalConsentLinkTTL = viper.GetDuration("PARENTAL_CONSENT_LINK_TTL")
+
+	// handle configuration
+	viper.SetDefault("HANDLE_RENAME_COOLDOWN", "720h")
+	HandleRenameCooldown = viper.GetDuration("HANDLE_RENAME_COOLDOWN")
+
+	// media configuration
+	viper.SetDefault("MEDIA_KEY_VERSION", 1)
+	viper.SetDefault("MEDIA_SCAN_TTL", "1h")
+	viper.SetDefault("MEDIA_AVATAR_TTL", "24h")
+	viper.SetDefault("MEDIA_CONTENT_TTL", "168h")
+	viper.SetDefault("MEDIA_ORIGINS", AppURL)
+	MediaCDNURL = viper.GetString("MEDIA_CDN_URL")
+	MediaSigningKey = viper.GetString("MEDIA_SIGNING_KEY")
+	MediaKeyVersion = viper.GetInt("MEDIA_KEY_VERSION")
+	MediaScanTTL = viper.GetDuration("MEDIA_SCAN_TTL")
+	MediaAvatarTTL = viper.GetDuration("MEDIA_AVATAR_TTL")
+	MediaContentTTL = viper.GetDuration("MEDIA_CONTENT_TTL")
+	MediaOrigins = viper.GetString("MEDIA_ORIGINS")
+
+	// orphaned media configuration
+	viper.SetDefault("MEDIA_CLEANUP_ACTION", "archive")
+	viper.SetDefault("MEDIA_CLEANUP_GRACE", "72h")
+	viper.SetDefault("MEDIA_ARCHIVE_PREFIX", "orphaned-media/")
+	viper.SetDefault("MEDIA_CLEANUP_DRY_RUN", true)
+	MediaCleanupAction = viper.GetString("MEDIA_CLEANUP_ACTION")
+	MediaCleanupGrace = viper.GetDuration("MEDIA_CLEANUP_GRACE")
+	MediaArchivePrefix = viper.GetString("MEDIA_ARCHIVE_PREFIX")
+	MediaCleanupDryRun.Set(viper.GetBool("MEDIA_CLEANUP_DRY_RUN"))
+
+	// outbound webhook configuration
+	viper.SetDefault("WEBHOOK_TIMEOUT", "10s")
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 8)
+	viper.SetDefault("WEBHOOK_RETRY_BASE", "30s")
+	viper.SetDefault("WEBHOOK_RETRY_MAX", "6h")
+	WebhookTimeout = viper.GetDuration("WEBHOOK_TIMEOUT")
+	WebhookMaxAttempts = viper.GetInt("WEBHOOK_MAX_ATTEMPTS")
+	WebhookRetryBase = viper.GetDuration("WEBHOOK_RETRY_BASE")
+	WebhookRetryMax = viper.GetDuration("WEBHOOK_RETRY_MAX")
+
+	// idempotency key configuration
+	viper.SetDefault("IDEMPOTENCY_KEY_TTL", "24h")
+	IdempotencyKeyTTL = viper.GetDuration("IDEMPOTENCY_KEY_TTL")
+
+	// storage usage report configuration
+	viper.SetDefault("STORAGE_PRICE_PER_TB", "23.00")
+	viper.SetDefault("STORAGE_PRICE_CURRENCY", "USD")
+	viper.SetDefault("BACKUP_RETENTION", "720h")
+	StoragePricePerTB = viper.GetString("STORAGE_PRICE_PER_TB")
+	StoragePriceCurrency = viper.GetString("STORAGE_PRICE_CURRENCY")
+	BackupRetention = viper.GetDuration("BACKUP_RETENTION")
+
+	// public API configuration
+	viper.SetDefault("PUBLIC_PLANS_CACHE_TTL", "5m")
+	viper.SetDefault("PUBLIC_CONTENT_CACHE_TTL", "10m")
+	viper.SetDefault("PUBLIC_RATE_LIMIT", 60)
+	viper.SetDefault("PUBLIC_SITE_URL", FrontendURL)
+	viper.SetDefault("PUBLIC_FEED_SIZE", 20)
+	PublicPlansCacheTTL = viper.GetDuration("PUBLIC_PLANS_CACHE_TTL")
+	PublicContentCacheTTL = viper.GetDuration("PUBLIC_CONTENT_CACHE_TTL")
+	PublicRateLimit = viper.GetInt("PUBLIC_RATE_LIMIT")
+	PublicSiteURL = viper.GetString("PUBLIC_SITE_URL")
+	PublicFeedSize = viper.GetInt("PUBLIC_FEED_SIZE")
+
+	// nutrition assistant configuration
+	viper.SetDefault("ASSISTANT_PROVIDER", "openai")
+	viper.SetDefault("ASSISTANT_MODEL", "gpt-4o-mini")
+	viper.SetDefault("ASSISTANT_TIMEOUT", "30s")
+	viper.SetDefault("ASSISTANT_MAX_TOKENS", 600)
+	viper.SetDefault("ASSISTANT_HISTORY_MESSAGES", 10)
+	viper.SetDefault("ASSISTANT_CONTEXT_DAYS", 7)
+	viper.SetDefault("ASSISTANT_FREE_DAILY_MESSAGES", 5)
+	AssistantProvider = viper.GetString("ASSISTANT_PROVIDER")
+	AssistantAPIKey = viper.GetString("ASSISTANT_API_KEY")
+	AssistantBaseURL = viper.GetString("ASSISTANT_BASE_URL")
+	AssistantModel = viper.GetString("ASSISTANT_MODEL")
+	AssistantTimeout = viper.GetDuration("ASSISTANT_TIMEOUT")
+	AssistantMaxTokens = viper.GetInt("ASSISTANT_MAX_TOKENS")
+	AssistantHistoryMessages = viper.GetInt("ASSISTANT_HISTORY_MESSAGES")
+	AssistantContextDays = viper.GetInt("ASSISTANT_CONTEXT_DAYS")
+	AssistantFreeDailyMessages.Set(viper.GetInt("ASSISTANT_FREE_DAILY_MESSAGES"))
+
+	// voice logging configuration
+	viper.SetDefault("SPEECH_PROVIDER", "openai")
+	viper.SetDefault("SPEECH_MODEL", "whisper-1")
+	viper.SetDefault("SPEECH_TIMEOUT", "30s")
+	viper.SetDefault("VOICE_LOG_MAX_BYTES", 2<<20)
+	viper.SetDefault("VOICE_LOG_MAX_ENTRIES", 10)
+	SpeechProvider = viper.GetString("SPEECH_PROVIDER")
+	SpeechAPIKey = viper.GetString("SPEECH_API_KEY")
+	SpeechBaseURL = viper.GetString("SPEECH_BASE_URL")
+	SpeechModel = viper.GetString("SPEECH_MODEL")
+	SpeechTimeout = viper.GetDuration("SPEECH_TIMEOUT")
+	VoiceLogMaxBytes = viper.GetInt64("VOICE_LOG_MAX_BYTES")
+	VoiceLogMaxEntries = viper.GetInt("VOICE_LOG_MAX_ENTRIES")
+
+	// diary export configuration
+	viper.SetDefault("DIARY_EXPORT_SYNC_DAYS", 31)
+	viper.SetDefault("DIARY_EXPORT_MAX_DAYS", 366)
+	viper.SetDefault("DIARY_EXPORT_TTL", "168h")
+	DiaryExportSyncDays = viper.GetInt("DIARY_EXPORT_SYNC_DAYS")
+	DiaryExportMaxDays = viper.GetInt("DIARY_EXPORT_MAX_DAYS")
+	DiaryExportTTL = viper.GetDuration("DIARY_EXPORT_TTL")
+
+	// captcha configuration
+	viper.SetDefault("CAPTCHA_MIN_SCORE", 0.5)
+	viper.SetDefault("CAPTCHA_TIMEOUT", "5s")
+	viper.SetDefault("CAPTCHA_LOGIN_FAILURES", 3)
+	viper.SetDefault("CAPTCHA_LOGIN_WINDOW", "15m")
+	CaptchaProvider = viper.GetString("CAPTCHA_PROVIDER")
+	CaptchaSecret = viper.GetString("CAPTCHA_SECRET")
+	CaptchaEndpoint = viper.GetString("CAPTCHA_ENDPOINT")
+	CaptchaMinScore = viper.GetFloat64("CAPTCHA_MIN_SCORE")
+	CaptchaTimeout = viper.GetDuration("CAPTCHA_TIMEOUT")
+	CaptchaLoginFailures = viper.GetInt("CAPTCHA_LOGIN_FAILURES")
+	CaptchaLoginWindow = viper.GetDuration("CAPTCHA_LOGIN_WINDOW")
+	CaptchaBypassKeys = viper.GetString("CAPTCHA_BYPASS_KEYS")
+
+	// geolocation configuration, FRAUD_COUNTRY_HEADER is the former name of the country header
+	viper.SetDefault("FRAUD_COUNTRY_HEADER", "CF-IPCountry")
+	viper.SetDefault("GEOIP_COUNTRY_HEADER", viper.GetString("FRAUD_COUNTRY_HEADER"))
+	viper.SetDefault("GEOIP_TIMEOUT", "2s")
+	viper.SetDefault("GEOIP_CACHE_TTL", "6h")
+	GeoIPCountryHeader = viper.GetString("GEOIP_COUNTRY_HEADER")
+	GeoIPRegionHeader = viper.GetString("GEOIP_REGION_HEADER")
+	GeoIPCityHeader = viper.GetString("GEOIP_CITY_HEADER")
+	GeoIPProvider = viper.GetString("GEOIP_PROVIDER")
+	GeoIPToken = viper.GetString("GEOIP_TOKEN")
+	GeoIPEndpoint = viper.GetString("GEOIP_ENDPOINT")
+	GeoIPTimeout = viper.GetDuration("GEOIP_TIMEOUT")
+	GeoIPCacheTTL = viper.GetDuration("GEOIP_CACHE_TTL")
+
+	// client address configuration
+	ProxyHeader = viper.GetString("PROXY_HEADER")
+	TrustedProxies = viper.GetString("TRUSTED_PROXIES")
+
+	// passkey configuration
+	viper.SetDefault("WEBAUTHN_RP_ID", "localhost")
+	viper.SetDefault("WEBAUTHN_RP_NAME", "Nutribox")
+	viper.SetDefault("WEBAUTHN_ORIGINS", originOf(FrontendURL))
+	viper.SetDefault("WEBAUTHN_TIMEOUT", "5m")
+	viper.SetDefault("PASSKEY_MAX_PER_USER", 10)
+	WebAuthnRPID = viper.GetString("WEBAUTHN_RP_ID")
+	WebAuthnRPName = viper.GetString("WEBAUTHN_RP_NAME")
+	WebAuthnOrigins = viper.GetString("WEBAUTHN_ORIGINS")
+	WebAuthnTimeout = viper.GetDuration("WEBAUTHN_TIMEOUT")
+	PasskeyMaxPerUser = viper.GetInt("PASSKEY_MAX_PER_USER")
+
+	// role cache configuration
+	viper.SetDefault("ROLE_CACHE_TTL", "1m")
+	RoleCacheTTL = viper.GetDuration("ROLE_CACHE_TTL")
+
+	// download link configuration
+	viper.SetDefault("DOWNLOAD_LINK_TTL", "5m")
+	DownloadLinkTTL = viper.GetDuration("DOWNLOAD_LINK_TTL")
+
+	// diary share configuration
+	viper.SetDefault("DIARY_SHARE_MAX_TTL", "720h")
+	viper.SetDefault("DIARY_SHARE_MAX_ACTIVE", 10)
+	DiaryShareMaxTTL = viper.GetDuration("DIARY_SHARE_MAX_TTL")
+	DiaryShareMaxActive = viper.GetInt("DIARY_SHARE_MAX_ACTIVE")
+
+	// partner API configuration
+	viper.SetDefault("PARTNER_TERMS_VERSION", "2026-10")
+	PartnerTermsVersion = viper.GetString("PARTNER_TERMS_VERSION")
+	viper.SetDefault("PARTNER_ENROLLMENT_CODE_TTL", "24h")
+	PartnerEnrollmentCodeTTL = viper.GetDuration("PARTNER_ENROLLMENT_CODE_TTL")
 
 	// gRPC configuration
 	GRPC_HOST = viper.GetString("GRPC_HOST")
 	GRPC_PORT = viper.GetString("GRPC_PORT")
+
+	captureRuntimeDefaults()
+}
+
+// originOf returns the scheme, host and port of rawURL, the origin browsers report for its pages
+func originOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return rawURL
+	}
+	return u.Scheme + "://" + u.Host
 }
 
 func loadConfig() {
@@ -2,6 +2,7 @@ package config
 
 import (
 	"app/src/utils"
+	"strings"
 
 	"github.com/bytedance/sonic"
 	"github.com/gofiber/fiber/v2"
@@ -17,5 +18,21 @@ func FiberConfig() fiber.Config {
 		ErrorHandler:  utils.ErrorHandler,
 		JSONEncoder:   sonic.Marshal,
 		JSONDecoder:   sonic.Unmarshal,
+		// c.IP() is the address of the connection unless it is a trusted proxy
+		ProxyHeader:             ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          trustedProxies(),
+		EnableIPValidation:      true,
 	}
 }
+
+// trustedProxies splits TrustedProxies
+func trustedProxies() []string {
+	proxies := []string{}
+	for _, proxy := range strings.Split(TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
@@ -1,19 +1,74 @@
 package config
 
+import (
+	"slices"
+	"sync/atomic"
+)
+
 var allRoles = map[string][]string{
 	"user": {},
 	"admin": {
 		"getUsers", "manageUsers",
-		"getProductTokens", "createProductToken", "deleteProductToken",
+		"getProductTokens", "createProductToken", "updateProductToken", "deleteProductToken",
 		"getUserDetails", "updateUser",
 		"getSubscriptions", "manageSubscriptions", "viewTransactions", "updatePaymentStatus",
-		"getSubscriptionPlans",
+		"getSubscriptionPlans", "manageSubscriptionPlans",
+		"createManualTransaction", "verifyPaymentProofs", "manageWallets",
+		"manageFraud", "manageStoreProducts", "manageCoupons", "viewRevenueReports", "manageAlerts",
+		"useOpsBot", "manageMaintenance", "replayEvents", "manageBackups",
+		"manageNotificationTemplates", "manageDeepLinks", "manageExperiments",
+		"viewOnboardingFunnel", "manageRetention", "rectifyPersonalData", "managePartnerKeys",
+		"manageConfig", "viewSearchAnalytics", "viewCohortAnalytics", "viewCheckoutFunnel", "importFoods", "manageFoodGrading", "manageTipRules",
+		"manageAdminActions", "moderateUsers", "manageWebhooks", "viewStorageUsage", "viewEmailHealth",
+		"viewAuditLogs", "manageRoles",
 	},
 }
 
 var Roles = getKeys(allRoles)
 var RoleRights = allRoles
 
+// Rights are every right a role can grant, the admin role grants them all
+var Rights = allRoles["admin"]
+
+// customRoles are the roles admins created, by name, as the roles service last loaded them
+var customRoles atomic.Pointer[map[string][]string]
+
+// SetCustomRoles replaces the custom roles, the built-in roles of RoleRights cannot be replaced
+func SetCustomRoles(roles map[string][]string) {
+	customRoles.Store(&roles)
+}
+
+// RightsOf returns the rights of a built-in or custom role, and whether the role exists
+func RightsOf(role string) ([]string, bool) {
+	if rights, ok := RoleRights[role]; ok {
+		return rights, true
+	}
+	if roles := customRoles.Load(); roles != nil {
+		rights, ok := (*roles)[role]
+		return rights, ok
+	}
+	return nil, false
+}
+
+// HasRights reports whether role grants every right of required
+func HasRights(role string, required ...string) bool {
+	rights, ok := RightsOf(role)
+	if !ok {
+		return false
+	}
+	for _, right := range required {
+		if !slices.Contains(rights, right) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsRight reports whether right is one a role can grant
+func IsRight(right string) bool {
+	return slices.Contains(Rights, right)
+}
+
 func getKeys(m map[string][]string) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+)
+
+// Flag is a tunable that admins can change while the app runs, it is safe to read and set from any goroutine
+type Flag[T int | bool] struct {
+	value atomic.Pointer[T]
+}
+
+// Get returns the current value, the zero value before it is set
+func (f *Flag[T]) Get() T {
+	if value := f.value.Load(); value != nil {
+		return *value
+	}
+	var zero T
+	return zero
+}
+
+func (f *Flag[T]) Set(value T) {
+	f.value.Store(&value)
+}
+
+// Kinds of runtime flags
+const (
+	RuntimeFlagInt  = "int"
+	RuntimeFlagBool = "bool"
+)
+
+// RuntimeFlag describes a tunable of PATCH /admin/config. Values are sent and stored as strings,
+// ints must lie within Min and Max.
+type RuntimeFlag struct {
+	Key         string
+	Description string
+	Kind        string
+	Min, Max    int
+	// Default is the value of the environment, the flag returns to it when its override is removed
+	Default string
+
+	get func() string
+	set func(string) error
+}
+
+// Value is the current value of the flag
+func (flag *RuntimeFlag) Value() string {
+	return flag.get()
+}
+
+// Check reports whether value is valid for the flag
+func (flag *RuntimeFlag) Check(value string) error {
+	switch flag.Kind {
+	case RuntimeFlagInt:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a whole number", flag.Key)
+		}
+		if number < flag.Min || number > flag.Max {
+			return fmt.Errorf("%s must be between %d and %d", flag.Key, flag.Min, flag.Max)
+		}
+	case RuntimeFlagBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s must be true or false", flag.Key)
+		}
+	}
+	return nil
+}
+
+// Apply sets the flag to value after checking it
+func (flag *RuntimeFlag) Apply(value string) error {
+	if err := flag.Check(value); err != nil {
+		return err
+	}
+	return flag.set(value)
+}
+
+func intFlag(key, description string, flag *Flag[int], low, high int) *RuntimeFlag {
+	return &RuntimeFlag{
+		Key:         key,
+		Description: description,
+		Kind:        RuntimeFlagInt,
+		Min:         low,
+		Max:         high,
+		get:         func() string { return strconv.Itoa(flag.Get()) },
+		set: func(value string) error {
+			number, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+			flag.Set(number)
+			return nil
+		},
+	}
+}
+
+func boolFlag(key, description string, flag *Flag[bool]) *RuntimeFlag {
+	return &RuntimeFlag{
+		Key:         key,
+		Description: description,
+		Kind:        RuntimeFlagBool,
+		get:         func() string { return strconv.FormatBool(flag.Get()) },
+		set: func(value string) error {
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			flag.Set(enabled)
+			return nil
+		},
+	}
+}
+
+// RuntimeFlags are the tunables that take effect without a restart
+var RuntimeFlags = []*RuntimeFlag{
+	intFlag("ops_bot_report_hour", "Hour of the day the daily KPI report is sent", &OpsBotReportHour, 0, 23),
+	intFlag("user_counter_reconcile_hour", "Hour of the day the user counters are reconciled", &UserCounterReconcileHour, 0, 23),
+	intFlag("daily_tip_hour", "Hour of the day the daily tips are emailed", &DailyTipHour, 0, 23),
+	intFlag("cohort_aggregate_hour", "Hour of the day the cohort analytics are aggregated", &CohortAggregateHour, 0, 23),
+	intFlag("checkout_max_attempts_per_user", "Checkout attempts of a user in the checkout window before they are blocked",
+		&CheckoutMaxAttemptsPerUser, 1, 1000),
+	intFlag("checkout_max_attempts_per_ip", "Checkout attempts of an IP address in the checkout window before it is blocked",
+		&CheckoutMaxAttemptsPerIP, 1, 10000),
+	intFlag("fraud_max_failed_payments", "Failed payments in the fraud window that flag a purchase", &FraudMaxFailedPayments, 1, 100),
+	intFlag("fraud_max_purchases", "Purchases in the churn window that flag a purchase", &FraudMaxPurchases, 1, 100),
+	intFlag("installment_grace_days", "Days an installment may stay unpaid before premium access is suspended",
+		&InstallmentGraceDays, 0, 60),
+	intFlag("assistant_free_daily_messages", "Messages a user without a subscription may send the assistant per day",
+		&AssistantFreeDailyMessages, 0, 1000),
+	boolFlag("retention_dry_run", "Whether the retention job only reports what it would change", &RetentionDryRun),
+	boolFlag("media_cleanup_dry_run", "Whether the orphaned media janitor only reports what it would remove",
+		&MediaCleanupDryRun),
+}
+
+// LookupRuntimeFlag returns the runtime flag with key
+func LookupRuntimeFlag(key string) (*RuntimeFlag, bool) {
+	for _, flag := range RuntimeFlags {
+		if flag.Key == key {
+			return flag, true
+		}
+	}
+	return nil, false
+}
+
+// captureRuntimeDefaults remembers the values the environment set, once it is loaded
+func captureRuntimeDefaults() {
+	for _, flag := range RuntimeFlags {
+		flag.Default = flag.Value()
+	}
+}
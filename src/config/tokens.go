@@ -5,4 +5,11 @@ const (
 	TokenTypeRefresh       = "refresh"
 	TokenTypeResetPassword = "resetPassword"
 	TokenTypeVerifyEmail   = "verifyEmail"
+	TokenTypeOpsBotLink    = "opsBotLink"
+	TokenTypeUnsubscribe   = "unsubscribe"
+	TokenTypeDeepLink      = "deepLink"
+	TokenTypeConsent       = "parentalConsent"
+	TokenTypeDiaryShare    = "diaryShare"
+	TokenTypeDownload      = "download"
+	TokenTypeMagicLink     = "magicLink"
 )
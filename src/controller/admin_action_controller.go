@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/pagination"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminActionController struct {
+	AdminActionService service.AdminActionService
+}
+
+func NewAdminActionController(adminActionService service.AdminActionService) *AdminActionController {
+	return &AdminActionController{
+		AdminActionService: adminActionService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Delete subscription plan
+// @Description  Stages the deletion of a subscription plan, it takes effect when the undo window (ADMIN_UNDO_WINDOW_MINUTES) is over and can be undone until then. A plan nothing refers to is deleted. A plan with subscriptions (active or past), checkouts, store products, product tokens or coupons is archived instead: it becomes inactive and hidden for good, subscribers keep their subscription until it ends.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        plan_id   path  string  true  "Plan ID"
+// @Router       /admin/subscription-plans/{plan_id} [delete]
+// @Success      202  {object}  response.SuccessWithAdminAction
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminActionController) DeleteSubscriptionPlan(ctx *fiber.Ctx) error {
+	planID, err := uuid.Parse(ctx.Params("plan_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid plan ID format")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	action, err := c.AdminActionService.StagePlanDeletion(ctx, admin.ID, planID)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "stage_delete_subscription_plan",
+		Resource:   "subscription_plan",
+		ResourceID: planID.String(),
+		Details: map[string]interface{}{
+			"action_id": action.ID.String(),
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusAccepted,
+	})
+
+	return ctx.Status(fiber.StatusAccepted).JSON(response.SuccessWithAdminAction{
+		Status:  "success",
+		Message: "Subscription plan deletion staged, it can be undone until it is finalized",
+		Data:    *action,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Cancel subscriptions
+// @Description  Stages the cancellation of up to 100 subscriptions, it takes effect when the undo window (ADMIN_UNDO_WINDOW_MINUTES) is over and can be undone until then. Cancelled subscriptions end right away and stop renewing, payments are not refunded. App store subscriptions are cancelled at the store.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.CancelSubscriptions  true  "Subscriptions"
+// @Router       /admin/subscriptions/cancel [post]
+// @Success      202  {object}  response.SuccessWithAdminAction
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminActionController) CancelSubscriptions(ctx *fiber.Ctx) error {
+	req := new(validation.CancelSubscriptions)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	action, err := c.AdminActionService.StageCancellation(ctx, admin.ID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:   admin.ID.String(),
+		Action:   "stage_cancel_subscriptions",
+		Resource: "subscription",
+		Details: map[string]interface{}{
+			"action_id":        action.ID.String(),
+			"subscription_ids": req.SubscriptionIDs,
+			"reason":           req.Reason,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusAccepted,
+	})
+
+	return ctx.Status(fiber.StatusAccepted).JSON(response.SuccessWithAdminAction{
+		Status:  "success",
+		Message: "Subscription cancellation staged, it can be undone until it is finalized",
+		Data:    *action,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Delete user subscription
+// @Description  Stages the soft deletion of a user subscription, it takes effect when the undo window (ADMIN_UNDO_WINDOW_MINUTES) is over and can be undone until then. A deleted subscription stops granting access and leaves reports, its status history and transactions are kept and it can be restored.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        subscription_id   path  string  true  "Subscription ID"
+// @Router       /admin/subscriptions/{subscription_id} [delete]
+// @Success      202  {object}  response.SuccessWithAdminAction
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminActionController) DeleteUserSubscription(ctx *fiber.Ctx) error {
+	subscriptionID, err := uuid.Parse(ctx.Params("subscription_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid subscription ID format")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	action, err := c.AdminActionService.StageSubscriptionDeletion(ctx, admin.ID, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "stage_delete_user_subscription",
+		Resource:   "user_subscription",
+		ResourceID: subscriptionID.String(),
+		Details: map[string]interface{}{
+			"action_id": action.ID.String(),
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusAccepted,
+	})
+
+	return ctx.Status(fiber.StatusAccepted).JSON(response.SuccessWithAdminAction{
+		Status:  "success",
+		Message: "User subscription deletion staged, it can be undone until it is finalized",
+		Data:    *action,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get admin actions
+// @Description  Lists the destructive admin actions, newest first: staged ones waiting for their undo window to end, finalized, undone and failed ones
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status  query  string  false  "Status"  Enums(staged, finalized, undone, failed)
+// @Param        page    query  int     false  "Page number"  default(1)
+// @Param        limit   query  int     false  "Maximum number of actions"  default(10)
+// @Router       /admin/actions [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.AdminAction]
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminActionController) GetActions(ctx *fiber.Ctx) error {
+	query := &validation.AdminActionQuery{
+		Status: ctx.Query("status"),
+		Page:   ctx.QueryInt("page", 1),
+		Limit:  ctx.QueryInt("limit", 10),
+	}
+
+	actions, totalResults, err := c.AdminActionService.GetActions(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.AdminAction]{
+		Status:     "success",
+		Message:    "Admin actions retrieved successfully",
+		Results:    actions,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
+
+// @Tags         Admin
+// @Summary      Undo admin action
+// @Description  Drops a staged destructive action before its undo window is over, nothing it would have changed is touched
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  string  true  "Action ID"
+// @Router       /admin/actions/{id}/undo [post]
+// @Success      200  {object}  response.SuccessWithAdminAction
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminActionController) Undo(ctx *fiber.Ctx) error {
+	actionID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid action ID format")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	action, err := c.AdminActionService.Undo(ctx, admin.ID, actionID)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "undo_admin_action",
+		Resource:   "admin_action",
+		ResourceID: action.ID.String(),
+		Details: map[string]interface{}{
+			"kind": action.Kind,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithAdminAction{
+		Status:  "success",
+		Message: "Admin action undone successfully",
+		Data:    *action,
+	})
+}
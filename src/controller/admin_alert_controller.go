@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminAlertController struct {
+	AlertService service.AlertService
+}
+
+func NewAdminAlertController(alertService service.AlertService) *AdminAlertController {
+	return &AdminAlertController{
+		AlertService: alertService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get alert rules
+// @Description  Returns the business event alerts admins are subscribed to
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/alerts [get]
+// @Success      200  {object}  response.SuccessWithAlertRules
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminAlertController) GetAlertRules(ctx *fiber.Ctx) error {
+	rules, err := c.AlertService.GetRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithAlertRules{
+		Status:  "success",
+		Message: "Alert rules retrieved successfully",
+		Data:    rules,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Create alert rule
+// @Description  Subscribes an email address, Slack webhook or Telegram chat to a business event.
+// @Description  Payment failure spikes and AI provider errors fire once threshold occurrences happen within window_minutes,
+// @Description  large refunds and reconciliation mismatches fire when a single amount reaches the threshold.
+// @Description  A rule does not fire again until cooldown_minutes have passed.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.CreateAlertRule  true  "Alert rule"
+// @Router       /admin/alerts [post]
+// @Success      201  {object}  response.SuccessWithAlertRule
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminAlertController) CreateAlertRule(ctx *fiber.Ctx) error {
+	req := new(validation.CreateAlertRule)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	rule, err := c.AlertService.CreateRule(ctx, admin.ID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "create_alert_rule",
+		Resource:   "alert_rule",
+		ResourceID: rule.ID.String(),
+		Details: map[string]interface{}{
+			"event":     rule.Event,
+			"channel":   rule.Channel,
+			"threshold": rule.Threshold,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusCreated,
+	})
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithAlertRule{
+		Status:  "success",
+		Message: "Alert rule created successfully",
+		Data:    *rule,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Update alert rule
+// @Description  Changes the target, threshold, window or cooldown of an alert rule, or mutes it
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path  string                      true  "Alert rule ID"
+// @Param        request  body  validation.UpdateAlertRule  true  "Alert rule changes"
+// @Router       /admin/alerts/{id} [patch]
+// @Success      200  {object}  response.SuccessWithAlertRule
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminAlertController) UpdateAlertRule(ctx *fiber.Ctx) error {
+	ruleID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid alert rule ID format")
+	}
+
+	req := new(validation.UpdateAlertRule)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	rule, err := c.AlertService.UpdateRule(ctx, ruleID, req)
+	if err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "update_alert_rule",
+		Resource:   "alert_rule",
+		ResourceID: rule.ID.String(),
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithAlertRule{
+		Status:  "success",
+		Message: "Alert rule updated successfully",
+		Data:    *rule,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Delete alert rule
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Alert rule ID"
+// @Router       /admin/alerts/{id} [delete]
+// @Success      200  {object}  response.Common
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminAlertController) DeleteAlertRule(ctx *fiber.Ctx) error {
+	ruleID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid alert rule ID format")
+	}
+
+	if err := c.AlertService.DeleteRule(ctx, ruleID); err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "delete_alert_rule",
+		Resource:   "alert_rule",
+		ResourceID: ruleID.String(),
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "Alert rule deleted successfully",
+	})
+}
+
+// @Tags         Admin
+// @Summary      Send test alert
+// @Description  Delivers a sample message through the channel of an alert rule, ignoring its threshold and cooldown
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Alert rule ID"
+// @Router       /admin/alerts/{id}/test [post]
+// @Success      200  {object}  response.Common
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      502  {object}  response.ErrorResponse
+func (c *AdminAlertController) TestAlertRule(ctx *fiber.Ctx) error {
+	ruleID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid alert rule ID format")
+	}
+
+	if err := c.AlertService.TestRule(ctx, ruleID); err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "Test alert sent successfully",
+	})
+}
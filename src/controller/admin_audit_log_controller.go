@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/pagination"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminAuditLogController struct {
+	ActivityLogService service.ActivityLogService
+}
+
+func NewAdminAuditLogController(activityLogService service.ActivityLogService) *AdminAuditLogController {
+	return &AdminAuditLogController{
+		ActivityLogService: activityLogService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      List audit logs
+// @Description  Lists the activities users and admins performed, latest first, such as payment status changes (action update_payment_status on resource subscription). Activities are kept for RETENTION_ACTIVITY_LOG_MONTHS.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page         query  int     false  "Page number"  default(1)
+// @Param        limit        query  int     false  "Maximum number of logs"  default(10)
+// @Param        admin_id     query  string  false  "ID of the admin who acted"
+// @Param        action       query  string  false  "Action, e.g. update_payment_status"
+// @Param        resource     query  string  false  "Resource, e.g. subscription"
+// @Param        resource_id  query  string  false  "ID of the resource"
+// @Param        from         query  string  false  "First day, YYYY-MM-DD"
+// @Param        to           query  string  false  "Last day, YYYY-MM-DD"
+// @Router       /admin/audit-logs [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.ActivityLog]
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminAuditLogController) GetAuditLogs(ctx *fiber.Ctx) error {
+	query := &validation.ActivityLogQuery{Page: 1, Limit: 10}
+	if err := ctx.QueryParser(query); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
+
+	logs, totalResults, err := c.ActivityLogService.GetLogs(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.ActivityLog]{
+		Status:     "success",
+		Message:    "Audit logs retrieved successfully",
+		Results:    logs,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get an audit log
+// @Description  Returns one activity with its details, such as the status a payment was set to
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  string  true  "Audit log ID"
+// @Router       /admin/audit-logs/{id} [get]
+// @Success      200  {object}  response.SuccessWithActivityLog
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminAuditLogController) GetAuditLog(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid audit log ID")
+	}
+
+	log, err := c.ActivityLogService.GetLog(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithActivityLog{
+		Status:  "success",
+		Message: "Audit log retrieved successfully",
+		Data:    *log,
+	})
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminBackupController struct {
+	BackupService service.BackupService
+}
+
+func NewAdminBackupController(backupService service.BackupService) *AdminBackupController {
+	return &AdminBackupController{
+		BackupService: backupService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get database backups
+// @Description  Returns the requested backups, newest first. Completed backups have the object storage URL of their dump, see the README for the restore command.
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/backups [get]
+// @Success      200  {object}  response.SuccessWithBackups
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminBackupController) GetBackups(ctx *fiber.Ctx) error {
+	backups, err := c.BackupService.GetBackups(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithBackups{
+		Status:  "success",
+		Message: "Backups retrieved successfully",
+		Data:    backups,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Request a database backup
+// @Description  Queues a logical backup of the whole database. The backup job picks it up within a minute, dumps it with pg_dump and uploads it to object storage. Only one backup runs at a time. Take one before deleting anything in bulk.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.CreateBackup  true  "Why the backup is taken"
+// @Router       /admin/backups [post]
+// @Success      202  {object}  response.SuccessWithBackup
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+// @Failure      503  {object}  response.ErrorResponse
+func (c *AdminBackupController) CreateBackup(ctx *fiber.Ctx) error {
+	req := new(validation.CreateBackup)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	backup, err := c.BackupService.Request(ctx, admin.ID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "request_backup",
+		Resource:   "backup",
+		ResourceID: backup.ID.String(),
+		Details: map[string]interface{}{
+			"reason": backup.Reason,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusAccepted,
+	})
+
+	return ctx.Status(fiber.StatusAccepted).JSON(response.SuccessWithBackup{
+		Status:  "success",
+		Message: "Backup requested successfully",
+		Data:    *backup,
+	})
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminCheckoutController struct {
+	CheckoutService service.CheckoutService
+}
+
+func NewAdminCheckoutController(checkoutService service.CheckoutService) *AdminCheckoutController {
+	return &AdminCheckoutController{
+		CheckoutService: checkoutService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Send payment reminder
+// @Description  Extends or regenerates the checkout link of an unpaid subscription and emails it to the user
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        subscription_id  path  string                          true   "Subscription ID"
+// @Param        request          body  validation.SendPaymentReminder  false  "Optional note for the user"
+// @Router       /admin/subscriptions/{subscription_id}/send-payment-reminder [post]
+// @Success      201  {object}  response.SuccessWithPaymentReminder
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+// @Failure      502  {object}  response.ErrorResponse
+func (c *AdminCheckoutController) SendPaymentReminder(ctx *fiber.Ctx) error {
+	subscriptionID, err := uuid.Parse(ctx.Params("subscription_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid subscription ID format")
+	}
+
+	req := new(validation.SendPaymentReminder)
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	reminder, err := c.CheckoutService.SendPaymentReminder(ctx, admin.ID, subscriptionID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "send_payment_reminder",
+		Resource:   "subscription",
+		ResourceID: subscriptionID.String(),
+		Details: map[string]interface{}{
+			"channel":             reminder.Channel,
+			"checkout_session_id": reminder.CheckoutSessionID.String(),
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusCreated,
+	})
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithPaymentReminder{
+		Status:  "success",
+		Message: "Payment reminder sent successfully",
+		Data:    *reminder,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get payment reminders
+// @Description  Returns the payment reminders sent for a subscription, newest first
+// @Produce      json
+// @Security     BearerAuth
+// @Param        subscription_id  path  string  true  "Subscription ID"
+// @Router       /admin/subscriptions/{subscription_id}/payment-reminders [get]
+// @Success      200  {object}  response.SuccessWithPaymentReminders
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminCheckoutController) GetPaymentReminders(ctx *fiber.Ctx) error {
+	subscriptionID, err := uuid.Parse(ctx.Params("subscription_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid subscription ID format")
+	}
+
+	reminders, err := c.CheckoutService.GetPaymentReminders(ctx, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPaymentReminders{
+		Status:  "success",
+		Message: "Payment reminders retrieved successfully",
+		Data:    reminders,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Checkout funnel
+// @Description  Follows the users through the checkout of each plan between two days: plan viewed (by a signed in user), checkout created, payment attempted and paid. Per step: the users who did it, those who reached it having done every step before it, the drop-off from the previous step in users and percent, and the conversion from the first step in percent. A user counts once per plan, the steps of every plan together come first. QA accounts are left out.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from     query  string  true   "First day (YYYY-MM-DD)"
+// @Param        to       query  string  true   "Last day (YYYY-MM-DD)"
+// @Param        plan_id  query  string  false  "Only this plan"
+// @Router       /admin/analytics/checkout-funnel [get]
+// @Success      200  {object}  response.SuccessWithCheckoutFunnel
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminCheckoutController) GetCheckoutFunnel(ctx *fiber.Ctx) error {
+	query := &validation.CheckoutFunnelQuery{
+		From:   ctx.Query("from"),
+		To:     ctx.Query("to"),
+		PlanID: ctx.Query("plan_id"),
+	}
+
+	funnel, err := c.CheckoutService.GetFunnel(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithCheckoutFunnel{
+		Status:  "success",
+		Message: "Checkout funnel retrieved successfully",
+		Data:    *funnel,
+	})
+}
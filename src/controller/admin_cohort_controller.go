@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminCohortController struct {
+	CohortService service.CohortService
+}
+
+func NewAdminCohortController(cohortService service.CohortService) *AdminCohortController {
+	return &AdminCohortController{
+		CohortService: cohortService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Compare user cohorts
+// @Description  Compares the users who signed up between two months by signup month, plan (of the first paid subscription in their first 13 weeks, free without one) or acquisition source (organic without one). Per cohort: the share of users who logged a meal in weeks 1, 2, 4, 8 and 12 after sign up, among those whose week is over, and the share of days they logged a meal on in their first 28 days. The numbers are aggregated every night, computed_at tells when. QA accounts are left out.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        dimension  query  string  false  "Dimension to group the users by"  Enums(signup_month, plan, source)  default(signup_month)
+// @Param        from       query  string  true   "First signup month (YYYY-MM)"
+// @Param        to         query  string  true   "Last signup month (YYYY-MM)"
+// @Param        plan       query  string  false  "Only the users of this plan"
+// @Param        source     query  string  false  "Only the users of this acquisition source"
+// @Router       /admin/analytics/cohorts [get]
+// @Success      200  {object}  response.SuccessWithCohortComparison
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminCohortController) GetCohorts(ctx *fiber.Ctx) error {
+	query := &validation.CohortQuery{
+		Dimension: ctx.Query("dimension", "signup_month"),
+		From:      ctx.Query("from"),
+		To:        ctx.Query("to"),
+		Plan:      ctx.Query("plan"),
+		Source:    ctx.Query("source"),
+	}
+
+	comparison, err := c.CohortService.GetComparison(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithCohortComparison{
+		Status:  "success",
+		Message: "Cohort comparison retrieved successfully",
+		Data:    *comparison,
+	})
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/pagination"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminConfigController struct {
+	RuntimeConfigService service.RuntimeConfigService
+}
+
+func NewAdminConfigController(runtimeConfigService service.RuntimeConfigService) *AdminConfigController {
+	return &AdminConfigController{
+		RuntimeConfigService: runtimeConfigService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get runtime config
+// @Description  Lists the flags that can be changed without a restart, with the value in use, the value of the environment and the range ints must lie within
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/config [get]
+// @Success      200  {object}  response.SuccessWithRuntimeConfig
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminConfigController) GetConfig(ctx *fiber.Ctx) error {
+	flags, err := c.RuntimeConfigService.GetConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithRuntimeConfig{
+		Status:  "success",
+		Message: "Runtime config retrieved successfully",
+		Data:    flags,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Update runtime config
+// @Description  Overrides flags by key, values are strings ("true", "3"). A null value removes the override and the flag returns to the value of the environment. Every change is kept in the history with its reason. This instance applies it right away, the other instances within a minute.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.UpdateRuntimeConfig  true  "Changes"
+// @Router       /admin/config [patch]
+// @Success      200  {object}  response.SuccessWithRuntimeConfig
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminConfigController) UpdateConfig(ctx *fiber.Ctx) error {
+	req := new(validation.UpdateRuntimeConfig)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	flags, err := c.RuntimeConfigService.UpdateConfig(ctx, admin.ID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:   admin.ID.String(),
+		Action:   "update_runtime_config",
+		Resource: "runtime_config",
+		Details: map[string]interface{}{
+			"values": req.Values,
+			"reason": req.Reason,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithRuntimeConfig{
+		Status:  "success",
+		Message: "Runtime config updated successfully",
+		Data:    flags,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get runtime config history
+// @Description  Returns the changes of runtime flags, newest first, with who made them and why
+// @Produce      json
+// @Security     BearerAuth
+// @Param        key      query     string  false   "Flag key"
+// @Param        page     query     int     false   "Page number"  default(1)
+// @Param        limit    query     int     false   "Maximum number of changes"    default(10)
+// @Router       /admin/config/history [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.ConfigChange]
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminConfigController) GetHistory(ctx *fiber.Ctx) error {
+	query := &validation.ConfigChangeQuery{
+		Key:   ctx.Query("key"),
+		Page:  ctx.QueryInt("page", 1),
+		Limit: ctx.QueryInt("limit", 10),
+	}
+
+	changes, totalResults, err := c.RuntimeConfigService.GetHistory(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.ConfigChange]{
+		Status:     "success",
+		Message:    "Runtime config history retrieved successfully",
+		Results:    changes,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
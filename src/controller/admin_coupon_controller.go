@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/pagination"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminCouponController struct {
+	CouponService service.CouponService
+}
+
+func NewAdminCouponController(couponService service.CouponService) *AdminCouponController {
+	return &AdminCouponController{
+		CouponService: couponService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get coupons
+// @Description  Returns checkout coupons, newest first
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page     query     int     false   "Page number"  default(1)
+// @Param        limit    query     int     false   "Maximum number of coupons"    default(10)
+// @Router       /admin/coupons [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.Coupon]
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminCouponController) GetCoupons(ctx *fiber.Ctx) error {
+	query := &validation.CouponQuery{
+		Page:  ctx.QueryInt("page", 1),
+		Limit: ctx.QueryInt("limit", 10),
+	}
+
+	coupons, totalResults, err := c.CouponService.GetCoupons(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.Coupon]{
+		Status:     "success",
+		Message:    "Coupons retrieved successfully",
+		Results:    coupons,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
+
+// @Tags         Admin
+// @Summary      Create coupon
+// @Description  Creates a percentage or fixed amount coupon, optionally limited to one plan, a validity window or a number of redemptions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.CreateCoupon  true  "Coupon"
+// @Router       /admin/coupons [post]
+// @Success      201  {object}  response.SuccessWithCoupon
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminCouponController) CreateCoupon(ctx *fiber.Ctx) error {
+	req := new(validation.CreateCoupon)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	coupon, err := c.CouponService.CreateCoupon(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "create_coupon",
+		Resource:   "coupon",
+		ResourceID: coupon.ID.String(),
+		Details: map[string]interface{}{
+			"code":             coupon.Code,
+			"discount_percent": coupon.DiscountPercent,
+			"discount_amount":  coupon.DiscountAmount,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusCreated,
+	})
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithCoupon{
+		Status:  "success",
+		Message: "Coupon created successfully",
+		Data:    *coupon,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Update coupon
+// @Description  Updates the description, expiry or redemption limit of a coupon, or deactivates it
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path  string                   true  "Coupon ID"
+// @Param        request  body  validation.UpdateCoupon  true  "Coupon changes"
+// @Router       /admin/coupons/{id} [patch]
+// @Success      200  {object}  response.SuccessWithCoupon
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminCouponController) UpdateCoupon(ctx *fiber.Ctx) error {
+	couponID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid coupon ID format")
+	}
+
+	req := new(validation.UpdateCoupon)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	coupon, err := c.CouponService.UpdateCoupon(ctx, couponID, req)
+	if err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "update_coupon",
+		Resource:   "coupon",
+		ResourceID: coupon.ID.String(),
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithCoupon{
+		Status:  "success",
+		Message: "Coupon updated successfully",
+		Data:    *coupon,
+	})
+}
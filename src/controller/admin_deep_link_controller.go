@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminDeepLinkController struct {
+	DeepLinkService service.DeepLinkService
+}
+
+func NewAdminDeepLinkController(deepLinkService service.DeepLinkService) *AdminDeepLinkController {
+	return &AdminDeepLinkController{
+		DeepLinkService: deepLinkService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Create deep link
+// @Description  Signs a tracked link that opens a screen of the app for a user, for support replies and campaigns. renew_subscription takes an optional plan ID as target, diary_day a day as 2006-01-02 and scan_result a meal ID.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.CreateDeepLink  true  "Screen to open"
+// @Router       /admin/deep-links [post]
+// @Success      201  {object}  response.SuccessWithDeepLink
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminDeepLinkController) CreateDeepLink(ctx *fiber.Ctx) error {
+	req := new(validation.CreateDeepLink)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	link, err := c.DeepLinkService.CreateLink(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "create_deep_link",
+		Resource:   "deep_link",
+		ResourceID: req.UserID,
+		Details: map[string]interface{}{
+			"kind":   req.Kind,
+			"target": req.Target,
+			"source": req.Source,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusCreated,
+	})
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithDeepLink{
+		Status:  "success",
+		Message: "Deep link created successfully",
+		Data:    *link,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get deep link clicks
+// @Description  Counts the clicks on tracked links between two days (inclusive) per screen and source
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from  query  string  true  "First day (2006-01-02)"
+// @Param        to    query  string  true  "Last day (2006-01-02)"
+// @Router       /admin/deep-links/clicks [get]
+// @Success      200  {object}  response.SuccessWithDeepLinkClickStats
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminDeepLinkController) GetClickStats(ctx *fiber.Ctx) error {
+	query := &validation.DeepLinkClickQuery{
+		From: ctx.Query("from"),
+		To:   ctx.Query("to"),
+	}
+
+	stats, err := c.DeepLinkService.GetClickStats(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithDeepLinkClickStats{
+		Status:  "success",
+		Message: "Deep link clicks retrieved successfully",
+		Data:    stats,
+	})
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminEmailController struct {
+	EmailDeliveryService    service.EmailDeliveryService
+	EmailSuppressionService service.EmailSuppressionService
+}
+
+func NewAdminEmailController(
+	emailDeliveryService service.EmailDeliveryService, emailSuppressionService service.EmailSuppressionService,
+) *AdminEmailController {
+	return &AdminEmailController{
+		EmailDeliveryService:    emailDeliveryService,
+		EmailSuppressionService: emailSuppressionService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get email provider health
+// @Description  Reports the attempts to send email through the primary (EMAIL_PROVIDER) and the fallback provider (EMAIL_FALLBACK_PROVIDER) over EMAIL_HEALTH_WINDOW, with their error and bounce rates, the provider mail goes through now and the latest bounces. A provider is unhealthy once it made EMAIL_FAILOVER_MIN_ATTEMPTS attempts in the window and its error rate exceeds EMAIL_FAILOVER_ERROR_RATE or its bounce rate EMAIL_FAILOVER_BOUNCE_RATE, mail then goes through the fallback while it is healthy.
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/diagnostics/email [get]
+// @Success      200  {object}  response.SuccessWithEmailHealthReport
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminEmailController) GetEmailHealth(ctx *fiber.Ctx) error {
+	report, err := c.EmailDeliveryService.GetHealth(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithEmailHealthReport{
+		Status:  "success",
+		Message: "Email provider health retrieved successfully",
+		Data:    *report,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Lift an email suppression
+// @Description  Sends email to the address of the user again after it bounced for good or its owner complained, and clears the undeliverable flag the app prompts the user with. Lift it once the address is known to receive mail, a complaint lifted without the consent of the user may be reported again.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  string  true  "User ID"
+// @Router       /admin/users/{id}/email-suppression [delete]
+// @Success      200  {object}  response.SuccessWithUser
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminEmailController) LiftSuppression(ctx *fiber.Ctx) error {
+	userID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	user, err := c.EmailSuppressionService.Lift(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "lift_email_suppression",
+		Resource:   "user",
+		ResourceID: user.ID.String(),
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithUser{
+		Status:  "success",
+		Message: "Email suppression lifted successfully",
+		User:    *user,
+	})
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"app/src/response"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminEntitlementController struct {
+	EntitlementService service.EntitlementService
+}
+
+func NewAdminEntitlementController(entitlementService service.EntitlementService) *AdminEntitlementController {
+	return &AdminEntitlementController{
+		EntitlementService: entitlementService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Debug a user's entitlements
+// @Description  Walks the checks a scan request of the user goes through: maintenance mode, product token, every subscription with the reasons it does not qualify, the plan of the selected one and the scan quota including the scans still pending in Redis. Nothing is changed.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id  path  string  true  "User id"
+// @Router       /admin/users/{id}/entitlements/debug [get]
+// @Success      200  {object}  response.SuccessWithEntitlementDiagnosis
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminEntitlementController) DebugEntitlements(ctx *fiber.Ctx) error {
+	userID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	diagnosis, err := c.EntitlementService.Diagnose(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithEntitlementDiagnosis{
+		Status:  "success",
+		Message: "Entitlements resolved successfully",
+		Data:    *diagnosis,
+	})
+}
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"app/src/response"
+	"app/src/service"
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminEventController struct {
+	EventLogService service.EventLogService
+}
+
+func NewAdminEventController(eventLogService service.EventLogService) *AdminEventController {
+	return &AdminEventController{
+		EventLogService: eventLogService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Rebuild a derived table from the event log
+// @Description  Replays the domain events in order and replaces the content of the projection, user_counters rebuilds the scan, logged day, streak and lifetime spend counters of every user, onboarding rebuilds when every user completed their onboarding steps. Changes made during the replay can be counted twice, run it when traffic is low.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        projection  path  string  true  "Projection to rebuild"  Enums(user_counters)
+// @Router       /admin/events/replay/{projection} [post]
+// @Success      200  {object}  response.SuccessWithEventReplay
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminEventController) ReplayEvents(ctx *fiber.Ctx) error {
+	// The rebuild runs in one transaction that outlasts the query deadline of admin requests
+	replay, err := c.EventLogService.Replay(context.WithoutCancel(ctx.UserContext()), ctx.Params("projection"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithEventReplay{
+		Status:  "success",
+		Message: "Events replayed successfully",
+		Data:    *replay,
+	})
+}
@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminExperimentController struct {
+	ExperimentService service.ExperimentService
+}
+
+func NewAdminExperimentController(experimentService service.ExperimentService) *AdminExperimentController {
+	return &AdminExperimentController{
+		ExperimentService: experimentService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get experiments
+// @Description  Returns the A/B experiments on notifications and the paywall, newest first
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/experiments [get]
+// @Success      200  {object}  response.SuccessWithExperiments
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminExperimentController) GetExperiments(ctx *fiber.Ctx) error {
+	experiments, err := c.ExperimentService.GetExperiments(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithExperiments{
+		Status:  "success",
+		Message: "Experiments retrieved successfully",
+		Data:    experiments,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Create experiment
+// @Description  Creates a draft experiment. Notification experiments target a template key and their variants may override subject and body. Paywall experiments change the copy returned with the plan comparison. A variant without content is the control.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.CreateExperiment  true  "Experiment"
+// @Router       /admin/experiments [post]
+// @Success      201  {object}  response.SuccessWithExperiment
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminExperimentController) CreateExperiment(ctx *fiber.Ctx) error {
+	req := new(validation.CreateExperiment)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	experiment, err := c.ExperimentService.CreateExperiment(ctx, admin.ID, req)
+	if err != nil {
+		return err
+	}
+
+	logExperimentActivity(ctx, admin, "create_experiment", experiment, fiber.StatusCreated)
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithExperiment{
+		Status:  "success",
+		Message: "Experiment created successfully",
+		Data:    *experiment,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Start experiment
+// @Description  Starts assigning the variants of a draft experiment. Only one experiment runs at a time on a notification or the paywall.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Experiment ID"
+// @Router       /admin/experiments/{id}/start [post]
+// @Success      200  {object}  response.SuccessWithExperiment
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminExperimentController) StartExperiment(ctx *fiber.Ctx) error {
+	experimentID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid experiment ID format")
+	}
+
+	experiment, err := c.ExperimentService.StartExperiment(ctx, experimentID)
+	if err != nil {
+		return err
+	}
+
+	logExperimentActivity(ctx, ctx.Locals("user").(*model.User), "start_experiment", experiment, fiber.StatusOK)
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithExperiment{
+		Status:  "success",
+		Message: "Experiment started successfully",
+		Data:    *experiment,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Stop experiment
+// @Description  Stops a running experiment, everyone gets the regular content again. Its results stay available.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Experiment ID"
+// @Router       /admin/experiments/{id}/stop [post]
+// @Success      200  {object}  response.SuccessWithExperiment
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminExperimentController) StopExperiment(ctx *fiber.Ctx) error {
+	experimentID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid experiment ID format")
+	}
+
+	experiment, err := c.ExperimentService.StopExperiment(ctx, experimentID)
+	if err != nil {
+		return err
+	}
+
+	logExperimentActivity(ctx, ctx.Locals("user").(*model.User), "stop_experiment", experiment, fiber.StatusOK)
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithExperiment{
+		Status:  "success",
+		Message: "Experiment stopped successfully",
+		Data:    *experiment,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get experiment results
+// @Description  Compares the variants of an experiment: exposed users, exposures, and per conversion event (purchase, link_click) the users who converted, the events, their value and the conversion rate
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Experiment ID"
+// @Router       /admin/experiments/{id}/results [get]
+// @Success      200  {object}  response.SuccessWithExperimentResults
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminExperimentController) GetResults(ctx *fiber.Ctx) error {
+	experimentID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid experiment ID format")
+	}
+
+	results, err := c.ExperimentService.GetResults(ctx, experimentID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithExperimentResults{
+		Status:  "success",
+		Message: "Experiment results retrieved successfully",
+		Data:    *results,
+	})
+}
+
+func logExperimentActivity(ctx *fiber.Ctx, admin *model.User, action string, experiment *model.Experiment, status int) {
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     action,
+		Resource:   "experiment",
+		ResourceID: experiment.ID.String(),
+		Details: map[string]interface{}{
+			"key":     experiment.Key,
+			"surface": experiment.Surface,
+			"target":  experiment.Target,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: status,
+	})
+}
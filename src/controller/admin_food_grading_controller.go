@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminFoodGradingController struct {
+	FoodGradeService service.FoodGradeService
+}
+
+func NewAdminFoodGradingController(foodGradeService service.FoodGradeService) *AdminFoodGradingController {
+	return &AdminFoodGradingController{
+		FoodGradeService: foodGradeService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get food grading
+// @Description  Returns the health grading of foods (per 100 g) and meals (per meal). Every nutrient scores a point for each threshold its value is above, added for a nutrient to limit and taken off for one to encourage. The score gives the grade of the first band it does not exceed, nutrients to limit get a traffic light: green up to low, amber up to high, red above.
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/food-grading [get]
+// @Success      200  {object}  response.SuccessWithGradingConfig
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminFoodGradingController) GetGrading(ctx *fiber.Ctx) error {
+	config, err := c.FoodGradeService.GetConfig(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithGradingConfig{
+		Status:  "success",
+		Message: "Food grading retrieved successfully",
+		Data:    *config,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Update food grading
+// @Description  Replaces the grading of foods and meals under a new version. Food nutrients are the fields of a food, meal nutrients calories, protein, carbs and fat. Grades are A to E in order with ascending max scores. Stored grades are recomputed when next read, other instances apply the change within a minute.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.UpdateFoodGrading  true  "Grading"
+// @Router       /admin/food-grading [put]
+// @Success      200  {object}  response.SuccessWithGradingConfig
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminFoodGradingController) UpdateGrading(ctx *fiber.Ctx) error {
+	req := new(validation.UpdateFoodGrading)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	config, err := c.FoodGradeService.UpdateConfig(ctx, admin.ID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:   admin.ID.String(),
+		Action:   "update_food_grading",
+		Resource: "food_grading",
+		Details: map[string]interface{}{
+			"version": config.Version,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithGradingConfig{
+		Status:  "success",
+		Message: "Food grading updated successfully",
+		Data:    *config,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Reset food grading
+// @Description  Restores the default grading under a new version
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/food-grading [delete]
+// @Success      200  {object}  response.SuccessWithGradingConfig
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminFoodGradingController) ResetGrading(ctx *fiber.Ctx) error {
+	admin := ctx.Locals("user").(*model.User)
+
+	config, err := c.FoodGradeService.ResetConfig(ctx, admin.ID)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:   admin.ID.String(),
+		Action:   "reset_food_grading",
+		Resource: "food_grading",
+		Details: map[string]interface{}{
+			"version": config.Version,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithGradingConfig{
+		Status:  "success",
+		Message: "Food grading reset successfully",
+		Data:    *config,
+	})
+}
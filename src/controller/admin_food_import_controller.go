@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/pagination"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminFoodImportController struct {
+	FoodImportService service.FoodImportService
+}
+
+func NewAdminFoodImportController(foodImportService service.FoodImportService) *AdminFoodImportController {
+	return &AdminFoodImportController{
+		FoodImportService: foodImportService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Import a nutrition dataset
+// @Description  Reads a CSV dataset (USDA FoodData Central, the BPOM food composition table or any dataset with a column mapping) and merges it into the food catalog in the background. Values are converted to the units of the catalog (g, mg, mcg, kcal). Rows are matched with foods by code, then by name or a close name; matched foods get the values they are missing, or every value with overwrite. Foods that match nothing are listed as new in the report, the food service has to add them. A dry run only writes the report.
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file       formData  file    true   "CSV dataset, separated by commas or semicolons"
+// @Param        source     formData  string  true   "Dataset"  Enums(usda, bpom, custom)
+// @Param        mapping    formData  string  false  "JSON mapping of food fields to columns, replacing those of the dataset, e.g. {\"energi_kal\": {\"column\": \"Energy\", \"unit\": \"kJ\"}}"
+// @Param        dry_run    formData  bool    false  "Only report what would change"
+// @Param        overwrite  formData  bool    false  "Replace values the catalog already has"
+// @Router       /admin/food-imports [post]
+// @Success      202  {object}  response.SuccessWithFoodImport
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      413  {object}  response.ErrorResponse
+func (c *AdminFoodImportController) ImportFoods(ctx *fiber.Ctx) error {
+	req := new(validation.ImportFoods)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Dataset file is required")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	foodImport, err := c.FoodImportService.Import(ctx, admin.ID, req, file)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "import_foods",
+		Resource:   "food_import",
+		ResourceID: foodImport.ID.String(),
+		Details: map[string]interface{}{
+			"source":    req.Source,
+			"file_name": foodImport.FileName,
+			"rows":      foodImport.Rows,
+			"dry_run":   req.DryRun,
+			"overwrite": req.Overwrite,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusAccepted,
+	})
+
+	return ctx.Status(fiber.StatusAccepted).JSON(response.SuccessWithFoodImport{
+		Status:  "success",
+		Message: "Food import started successfully",
+		Data:    *foodImport,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get food imports
+// @Description  Returns the imports of nutrition datasets, newest first, with their counts
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page   query  int  false  "Page number"  default(1)
+// @Param        limit  query  int  false  "Maximum number of imports"  default(10)
+// @Router       /admin/food-imports [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.FoodImport]
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminFoodImportController) GetImports(ctx *fiber.Ctx) error {
+	query := &validation.FoodImportQuery{
+		Page:  ctx.QueryInt("page", 1),
+		Limit: ctx.QueryInt("limit", 10),
+	}
+
+	imports, totalResults, err := c.FoodImportService.GetImports(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.FoodImport]{
+		Status:     "success",
+		Message:    "Food imports retrieved successfully",
+		Results:    imports,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get a food import
+// @Description  Returns an import with its status and counts: rows, matched, updated, new, skipped and failed
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  string  true  "Food import ID"
+// @Router       /admin/food-imports/{id} [get]
+// @Success      200  {object}  response.SuccessWithFoodImport
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminFoodImportController) GetImport(ctx *fiber.Ctx) error {
+	importID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid food import ID format")
+	}
+
+	foodImport, err := c.FoodImportService.GetImport(ctx, importID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithFoodImport{
+		Status:  "success",
+		Message: "Food import retrieved successfully",
+		Data:    *foodImport,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get the report of a food import
+// @Description  Returns the rows of an import in file order with what was done with each: the food it matched and how, the fields it changed, and the food to add for new rows
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path   string  true   "Food import ID"
+// @Param        action  query  string  false  "Action"  Enums(update, unchanged, new, duplicate, invalid, failed)
+// @Param        page    query  int     false  "Page number"  default(1)
+// @Param        limit   query  int     false  "Maximum number of rows"  default(50)
+// @Router       /admin/food-imports/{id}/items [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.FoodImportItem]
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminFoodImportController) GetItems(ctx *fiber.Ctx) error {
+	importID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid food import ID format")
+	}
+
+	query := &validation.FoodImportItemQuery{
+		Action: ctx.Query("action"),
+		Page:   ctx.QueryInt("page", 1),
+		Limit:  ctx.QueryInt("limit", 50),
+	}
+
+	items, totalResults, err := c.FoodImportService.GetItems(ctx, importID, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.FoodImportItem]{
+		Status:     "success",
+		Message:    "Food import report retrieved successfully",
+		Results:    items,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
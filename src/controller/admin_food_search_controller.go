@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminFoodSearchController struct {
+	FoodNameService service.FoodNameService
+}
+
+func NewAdminFoodSearchController(foodNameService service.FoodNameService) *AdminFoodSearchController {
+	return &AdminFoodSearchController{
+		FoodNameService: foodNameService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get food search gaps
+// @Description  Groups the food searches without results between two days (inclusive) by search and language, the most searched first. They point at foods, names and synonyms the catalog is missing.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from   query  string  true   "First day (2006-01-02)"
+// @Param        to     query  string  true   "Last day (2006-01-02)"
+// @Param        limit  query  int     false  "Maximum number of searches"  default(50)
+// @Router       /admin/food-search/gaps [get]
+// @Success      200  {object}  response.SuccessWithFoodSearchGaps
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminFoodSearchController) GetSearchGaps(ctx *fiber.Ctx) error {
+	query := &validation.FoodSearchGapQuery{
+		From:  ctx.Query("from"),
+		To:    ctx.Query("to"),
+		Limit: ctx.QueryInt("limit", 50),
+	}
+
+	gaps, err := c.FoodNameService.GetSearchGaps(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithFoodSearchGaps{
+		Status:  "success",
+		Message: "Food search gaps retrieved successfully",
+		Data:    gaps,
+	})
+}
@@ -0,0 +1,248 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/pagination"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminFraudController struct {
+	FraudService       service.FraudService
+	FraudReviewService service.FraudReviewService
+}
+
+func NewAdminFraudController(fraudService service.FraudService, fraudReviewService service.FraudReviewService) *AdminFraudController {
+	return &AdminFraudController{
+		FraudService:       fraudService,
+		FraudReviewService: fraudReviewService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get fraud review queue
+// @Description  Returns checkouts flagged by the fraud rules, oldest first. Defaults to pending reviews.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page     query     int     false   "Page number"  default(1)
+// @Param        limit    query     int     false   "Maximum number of reviews"    default(10)
+// @Param        status   query     string  false   "Filter by status (pending, approved, rejected)"  default(pending)
+// @Router       /admin/fraud/reviews [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.FraudReview]
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminFraudController) GetReviews(ctx *fiber.Ctx) error {
+	query := &validation.FraudReviewQuery{
+		Page:   ctx.QueryInt("page", 1),
+		Limit:  ctx.QueryInt("limit", 10),
+		Status: ctx.Query("status", model.FraudReviewPending),
+	}
+
+	reviews, totalResults, err := c.FraudReviewService.GetReviews(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.FraudReview]{
+		Status:     "success",
+		Message:    "Fraud reviews retrieved successfully",
+		Results:    reviews,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
+
+// @Tags         Admin
+// @Summary      Approve held transaction
+// @Description  Marks a flagged checkout as legitimate and activates the subscription if it was already paid
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path  string                  true   "Fraud review ID"
+// @Param        request  body  validation.ReviewFraud  false  "Review notes"
+// @Router       /admin/fraud/reviews/{id}/approve [patch]
+// @Success      200  {object}  response.SuccessWithFraudReview
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminFraudController) ApproveReview(ctx *fiber.Ctx) error {
+	return c.decide(ctx, true)
+}
+
+// @Tags         Admin
+// @Summary      Reject held transaction
+// @Description  Marks a flagged checkout as fraudulent, refunds it if it was paid and cancels it otherwise
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path  string                  true   "Fraud review ID"
+// @Param        request  body  validation.ReviewFraud  false  "Review notes"
+// @Router       /admin/fraud/reviews/{id}/reject [patch]
+// @Success      200  {object}  response.SuccessWithFraudReview
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminFraudController) RejectReview(ctx *fiber.Ctx) error {
+	return c.decide(ctx, false)
+}
+
+func (c *AdminFraudController) decide(ctx *fiber.Ctx, approved bool) error {
+	reviewID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid fraud review ID format")
+	}
+
+	req := new(validation.ReviewFraud)
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	var review *model.FraudReview
+	action, message := "approve_fraud_review", "Transaction approved successfully"
+	if approved {
+		review, err = c.FraudReviewService.ApproveReview(ctx, admin.ID, reviewID, req)
+	} else {
+		action, message = "reject_fraud_review", "Transaction rejected successfully"
+		review, err = c.FraudReviewService.RejectReview(ctx, admin.ID, reviewID, req)
+	}
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     action,
+		Resource:   "fraud_review",
+		ResourceID: review.ID.String(),
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithFraudReview{
+		Status:  "success",
+		Message: message,
+		Data:    *review,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get fraud allow/deny list
+// @Description  Returns users, email addresses and IP addresses that bypass or are blocked by the fraud rules
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page       query     int     false   "Page number"  default(1)
+// @Param        limit      query     int     false   "Maximum number of entries"    default(10)
+// @Param        list_type  query     string  false   "Filter by list (allow, deny)"
+// @Router       /admin/fraud/lists [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.FraudListEntry]
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminFraudController) GetListEntries(ctx *fiber.Ctx) error {
+	query := &validation.FraudListQuery{
+		Page:     ctx.QueryInt("page", 1),
+		Limit:    ctx.QueryInt("limit", 10),
+		ListType: ctx.Query("list_type"),
+	}
+
+	entries, totalResults, err := c.FraudService.GetListEntries(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.FraudListEntry]{
+		Status:     "success",
+		Message:    "Fraud list entries retrieved successfully",
+		Results:    entries,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
+
+// @Tags         Admin
+// @Summary      Add fraud allow/deny list entry
+// @Description  Allow-listed checkouts skip the fraud rules, deny-listed checkouts are refused
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.CreateFraudListEntry  true  "List entry"
+// @Router       /admin/fraud/lists [post]
+// @Success      201  {object}  response.SuccessWithFraudListEntry
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminFraudController) CreateListEntry(ctx *fiber.Ctx) error {
+	req := new(validation.CreateFraudListEntry)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	entry, err := c.FraudService.CreateListEntry(ctx, admin.ID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "create_fraud_list_entry",
+		Resource:   "fraud_list_entry",
+		ResourceID: entry.ID.String(),
+		Details: map[string]interface{}{
+			"list_type":  entry.ListType,
+			"entry_type": entry.EntryType,
+			"value":      entry.Value,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusCreated,
+	})
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithFraudListEntry{
+		Status:  "success",
+		Message: "Fraud list entry created successfully",
+		Data:    *entry,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Delete fraud allow/deny list entry
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "List entry ID"
+// @Router       /admin/fraud/lists/{id} [delete]
+// @Success      200  {object}  response.Common
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminFraudController) DeleteListEntry(ctx *fiber.Ctx) error {
+	entryID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid list entry ID format")
+	}
+
+	if err := c.FraudService.DeleteListEntry(ctx, entryID); err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "delete_fraud_list_entry",
+		Resource:   "fraud_list_entry",
+		ResourceID: entryID.String(),
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "Fraud list entry deleted successfully",
+	})
+}
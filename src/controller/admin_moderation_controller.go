@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/pagination"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminModerationController struct {
+	ModerationService service.ModerationService
+}
+
+func NewAdminModerationController(moderationService service.ModerationService) *AdminModerationController {
+	return &AdminModerationController{
+		ModerationService: moderationService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get user reports
+// @Description  Lists the reports users filed about other users with the reported user. Open reports come oldest first, the moderation queue, resolved ones newest first.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status  query  string  false  "Status"  Enums(open, dismissed, warned, suspended)
+// @Param        page    query  int     false  "Page number"  default(1)
+// @Param        limit   query  int     false  "Maximum number of reports"  default(10)
+// @Router       /admin/user-reports [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.UserReport]
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminModerationController) GetReports(ctx *fiber.Ctx) error {
+	query := &validation.UserReportQuery{
+		Status: ctx.Query("status"),
+		Page:   ctx.QueryInt("page", 1),
+		Limit:  ctx.QueryInt("limit", 10),
+	}
+
+	reports, totalResults, err := c.ModerationService.GetReports(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.UserReport]{
+		Status:     "success",
+		Message:    "User reports retrieved successfully",
+		Results:    reports,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
+
+// @Tags         Admin
+// @Summary      Resolve a user report
+// @Description  Closes an open report. dismiss takes no action, warn emails the note to the reported user, suspend also keeps them from signing in for suspend_days days. A running longer suspension is kept.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path  string                        true  "Report ID"
+// @Param        request  body  validation.ResolveUserReport  true  "Resolution"
+// @Router       /admin/user-reports/{id}/resolve [post]
+// @Success      200  {object}  response.SuccessWithUserReport
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminModerationController) ResolveReport(ctx *fiber.Ctx) error {
+	reportID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid report ID format")
+	}
+
+	req := new(validation.ResolveUserReport)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	report, err := c.ModerationService.ResolveReport(ctx, admin.ID, reportID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "resolve_user_report",
+		Resource:   "user_report",
+		ResourceID: report.ID.String(),
+		Details: map[string]interface{}{
+			"reported_id":  report.ReportedID.String(),
+			"action":       req.Action,
+			"suspend_days": req.SuspendDays,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithUserReport{
+		Status:  "success",
+		Message: "User report resolved successfully",
+		Data:    *report,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Lift a suspension
+// @Description  Lets a suspended user sign in again before the suspension ends
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  string  true  "User ID"
+// @Router       /admin/users/{id}/suspension [delete]
+// @Success      200  {object}  example.GetUserResponse
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminModerationController) LiftSuspension(ctx *fiber.Ctx) error {
+	userID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	user, err := c.ModerationService.LiftSuspension(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "lift_suspension",
+		Resource:   "user",
+		ResourceID: user.ID.String(),
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithUser{
+		Status:  "success",
+		Message: "Suspension lifted successfully",
+		User:    *user,
+	})
+}
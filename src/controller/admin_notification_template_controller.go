@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminNotificationTemplateController struct {
+	NotificationTemplateService service.NotificationTemplateService
+}
+
+func NewAdminNotificationTemplateController(notificationTemplateService service.NotificationTemplateService) *AdminNotificationTemplateController {
+	return &AdminNotificationTemplateController{
+		NotificationTemplateService: notificationTemplateService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get notification templates
+// @Description  Returns the version in use of every saved notification template. Notifications without one are sent with their built-in copy.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        key     query     string  false  "Notification key"
+// @Param        locale  query     string  false  "Locale"
+// @Router       /admin/notification-templates [get]
+// @Success      200  {object}  response.SuccessWithNotificationTemplates
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminNotificationTemplateController) GetTemplates(ctx *fiber.Ctx) error {
+	query := &validation.NotificationTemplateQuery{
+		Key:    ctx.Query("key"),
+		Locale: ctx.Query("locale"),
+	}
+
+	templates, err := c.NotificationTemplateService.GetTemplates(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithNotificationTemplates{
+		Status:  "success",
+		Message: "Notification templates retrieved successfully",
+		Data:    templates,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get notification template versions
+// @Description  Returns every saved version of a notification template, newest first
+// @Produce      json
+// @Security     BearerAuth
+// @Param        key     path  string  true  "Notification key"
+// @Param        locale  path  string  true  "Locale"
+// @Router       /admin/notification-templates/{key}/{locale}/versions [get]
+// @Success      200  {object}  response.SuccessWithNotificationTemplates
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminNotificationTemplateController) GetVersions(ctx *fiber.Ctx) error {
+	templates, err := c.NotificationTemplateService.GetVersions(ctx, ctx.Params("key"), ctx.Params("locale"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithNotificationTemplates{
+		Status:  "success",
+		Message: "Notification template versions retrieved successfully",
+		Data:    templates,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Save notification template
+// @Description  Saves the subject and body of a notification as its next version, sent from then on. Variables are written as {{.plan_name}}, the template is rejected if it uses a variable the notification does not have.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.SaveNotificationTemplate  true  "Notification copy"
+// @Router       /admin/notification-templates [post]
+// @Success      201  {object}  response.SuccessWithNotificationTemplate
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminNotificationTemplateController) SaveTemplate(ctx *fiber.Ctx) error {
+	req := new(validation.SaveNotificationTemplate)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	template, err := c.NotificationTemplateService.SaveTemplate(ctx, admin.ID, req)
+	if err != nil {
+		return err
+	}
+
+	logTemplateActivity(ctx, admin, "save_notification_template", template)
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithNotificationTemplate{
+		Status:  "success",
+		Message: "Notification template saved successfully",
+		Data:    *template,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Restore notification template version
+// @Description  Saves an earlier version of a notification template again as its next version
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Notification template version ID"
+// @Router       /admin/notification-templates/versions/{id}/restore [post]
+// @Success      201  {object}  response.SuccessWithNotificationTemplate
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminNotificationTemplateController) RestoreVersion(ctx *fiber.Ctx) error {
+	templateID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid notification template ID format")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	template, err := c.NotificationTemplateService.RestoreVersion(ctx, admin.ID, templateID)
+	if err != nil {
+		return err
+	}
+
+	logTemplateActivity(ctx, admin, "restore_notification_template", template)
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithNotificationTemplate{
+		Status:  "success",
+		Message: "Notification template restored successfully",
+		Data:    *template,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Delete notification template
+// @Description  Deletes every version of a notification template, the notification is sent with its built-in copy again
+// @Produce      json
+// @Security     BearerAuth
+// @Param        key     path  string  true  "Notification key"
+// @Param        locale  path  string  true  "Locale"
+// @Router       /admin/notification-templates/{key}/{locale} [delete]
+// @Success      200  {object}  response.Common
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminNotificationTemplateController) DeleteTemplate(ctx *fiber.Ctx) error {
+	key, locale := ctx.Params("key"), ctx.Params("locale")
+
+	if err := c.NotificationTemplateService.DeleteTemplate(ctx, key, locale); err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "delete_notification_template",
+		Resource:   "notification_template",
+		ResourceID: key + "/" + locale,
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "Notification template deleted successfully",
+	})
+}
+
+// @Tags         Admin
+// @Summary      Preview notification template
+// @Description  Renders a notification without sending it. Without subject and body the template in use is rendered. Variables left out are filled with sample values.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.PreviewNotificationTemplate  true  "Copy and variables to render"
+// @Router       /admin/notification-templates/preview [post]
+// @Success      200  {object}  response.SuccessWithRenderedNotification
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminNotificationTemplateController) PreviewTemplate(ctx *fiber.Ctx) error {
+	req := new(validation.PreviewNotificationTemplate)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	rendered, err := c.NotificationTemplateService.Preview(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithRenderedNotification{
+		Status:  "success",
+		Message: "Notification template rendered successfully",
+		Data:    *rendered,
+	})
+}
+
+func logTemplateActivity(ctx *fiber.Ctx, admin *model.User, action string, template *model.NotificationTemplate) {
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     action,
+		Resource:   "notification_template",
+		ResourceID: template.ID.String(),
+		Details: map[string]interface{}{
+			"key":     template.Key,
+			"locale":  template.Locale,
+			"version": template.Version,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusCreated,
+	})
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminOnboardingController struct {
+	OnboardingService service.OnboardingService
+}
+
+func NewAdminOnboardingController(onboardingService service.OnboardingService) *AdminOnboardingController {
+	return &AdminOnboardingController{
+		OnboardingService: onboardingService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Onboarding funnel
+// @Description  Follows the users who signed up between two days through the onboarding steps. Per step: the users who completed it, those who reached it having completed every step before it, the drop-off from the previous step and the share of sign ups who reached it. Users active before onboarding was tracked are counted after replaying the onboarding projection.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from  query  string  true  "First sign up day (YYYY-MM-DD)"
+// @Param        to    query  string  true  "Last sign up day (YYYY-MM-DD)"
+// @Router       /admin/onboarding/funnel [get]
+// @Success      200  {object}  response.SuccessWithOnboardingFunnel
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminOnboardingController) GetFunnel(ctx *fiber.Ctx) error {
+	query := &validation.OnboardingFunnelQuery{
+		From: ctx.Query("from"),
+		To:   ctx.Query("to"),
+	}
+
+	funnel, err := c.OnboardingService.GetFunnel(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithOnboardingFunnel{
+		Status:  "success",
+		Message: "Onboarding funnel retrieved successfully",
+		Data:    *funnel,
+	})
+}
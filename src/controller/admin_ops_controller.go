@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminOpsController struct {
+	OpsBotService      service.OpsBotService
+	MaintenanceService service.MaintenanceService
+}
+
+func NewAdminOpsController(opsBotService service.OpsBotService, maintenanceService service.MaintenanceService) *AdminOpsController {
+	return &AdminOpsController{
+		OpsBotService:      opsBotService,
+		MaintenanceService: maintenanceService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Create ops bot link code
+// @Description  Returns a one-time code valid for 10 minutes. Send "link <code>" to the Slack command or the Telegram bot to run ops commands with your admin rights.
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/ops-bot/link-code [post]
+// @Success      201  {object}  response.SuccessWithOpsBotLinkCode
+// @Failure      403  {object}  response.ErrorResponse
+func (a *AdminOpsController) CreateLinkCode(c *fiber.Ctx) error {
+	admin := c.Locals("user").(*model.User)
+
+	code, err := a.OpsBotService.CreateLinkCode(c, admin.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response.SuccessWithOpsBotLinkCode{
+		Status:  "success",
+		Message: "Link code created successfully",
+		Data:    *code,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get linked ops bot accounts
+// @Description  Returns the Slack and Telegram accounts linked to the current admin
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/ops-bot/identities [get]
+// @Success      200  {object}  response.SuccessWithOpsBotIdentities
+// @Failure      403  {object}  response.ErrorResponse
+func (a *AdminOpsController) GetIdentities(c *fiber.Ctx) error {
+	admin := c.Locals("user").(*model.User)
+
+	identities, err := a.OpsBotService.GetIdentities(c, admin.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithOpsBotIdentities{
+		Status:  "success",
+		Message: "Linked accounts retrieved successfully",
+		Data:    identities,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Unlink ops bot account
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Linked account ID"
+// @Router       /admin/ops-bot/identities/{id} [delete]
+// @Success      200  {object}  response.Common
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (a *AdminOpsController) DeleteIdentity(c *fiber.Ctx) error {
+	identityID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid linked account ID format")
+	}
+
+	admin := c.Locals("user").(*model.User)
+	if err := a.OpsBotService.DeleteIdentity(c, admin.ID, identityID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "Linked account removed successfully",
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get maintenance mode
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/maintenance [get]
+// @Success      200  {object}  response.SuccessWithMaintenanceStatus
+// @Failure      403  {object}  response.ErrorResponse
+func (a *AdminOpsController) GetMaintenance(c *fiber.Ctx) error {
+	status, err := a.MaintenanceService.GetStatus(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithMaintenanceStatus{
+		Status:  "success",
+		Message: "Maintenance status retrieved successfully",
+		Data:    *status,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Toggle maintenance mode
+// @Description  While enabled, user facing endpoints answer 503 with code "maintenance". Admin, auth and payment webhook endpoints keep working.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.UpdateMaintenance  true  "Maintenance mode"
+// @Router       /admin/maintenance [put]
+// @Success      200  {object}  response.SuccessWithMaintenanceStatus
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (a *AdminOpsController) UpdateMaintenance(c *fiber.Ctx) error {
+	req := new(validation.UpdateMaintenance)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := c.Locals("user").(*model.User)
+	status, err := a.MaintenanceService.UpdateStatus(c, admin.ID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:   admin.ID.String(),
+		Action:   "update_maintenance",
+		Resource: "maintenance",
+		Details: map[string]interface{}{
+			"enabled": status.Enabled,
+			"message": status.Message,
+		},
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithMaintenanceStatus{
+		Status:  "success",
+		Message: "Maintenance status updated successfully",
+		Data:    *status,
+	})
+}
@@ -0,0 +1,360 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminPartnerKeyController struct {
+	PartnerService service.PartnerService
+}
+
+func NewAdminPartnerKeyController(partnerService service.PartnerService) *AdminPartnerKeyController {
+	return &AdminPartnerKeyController{
+		PartnerService: partnerService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get partner keys
+// @Description  Lists the API keys of partners with their scopes, rate limits and accepted partner terms
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/partner-keys [get]
+// @Success      200  {object}  response.SuccessWithPartnerKeys
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminPartnerKeyController) GetKeys(ctx *fiber.Ctx) error {
+	keys, err := c.PartnerService.GetKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPartnerKeys{
+		Status:  "success",
+		Message: "Partner keys retrieved successfully",
+		Data:    keys,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Create partner key
+// @Description  Issues an API key to a partner with the scopes it needs (read:foods, write:members, read:entitlements, read:observations). Rate limits are requests per minute per scope and default to the limit of the scope. write:members, read:entitlements and read:observations are premium scopes, they need terms_version to be the current partner terms. The key is only returned here.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.CreatePartnerKey  true  "Partner key"
+// @Router       /admin/partner-keys [post]
+// @Success      201  {object}  response.SuccessWithCreatedPartnerKey
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminPartnerKeyController) CreateKey(ctx *fiber.Ctx) error {
+	req := new(validation.CreatePartnerKey)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	key, err := c.PartnerService.CreateKey(ctx, admin.ID, req)
+	if err != nil {
+		return err
+	}
+
+	logPartnerKeyActivity(ctx, admin, "create_partner_key", &key.PartnerKey, fiber.StatusCreated)
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithCreatedPartnerKey{
+		Status:  "success",
+		Message: "Partner key created successfully",
+		Data:    *key,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Update partner key
+// @Description  Changes the scopes or rate limits of a partner key, or records that the partner accepted another version of the partner terms
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path  string                       true  "Partner key ID"
+// @Param        request  body  validation.UpdatePartnerKey  true  "Changes"
+// @Router       /admin/partner-keys/{id} [patch]
+// @Success      200  {object}  response.SuccessWithPartnerKey
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminPartnerKeyController) UpdateKey(ctx *fiber.Ctx) error {
+	keyID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid partner key ID format")
+	}
+
+	req := new(validation.UpdatePartnerKey)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	key, err := c.PartnerService.UpdateKey(ctx, keyID, req)
+	if err != nil {
+		return err
+	}
+
+	logPartnerKeyActivity(ctx, ctx.Locals("user").(*model.User), "update_partner_key", key, fiber.StatusOK)
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPartnerKey{
+		Status:  "success",
+		Message: "Partner key updated successfully",
+		Data:    *key,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Revoke partner key
+// @Description  Revokes a partner key, requests with it are refused from then on
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Partner key ID"
+// @Router       /admin/partner-keys/{id} [delete]
+// @Success      200  {object}  response.SuccessWithPartnerKey
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminPartnerKeyController) RevokeKey(ctx *fiber.Ctx) error {
+	keyID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid partner key ID format")
+	}
+
+	key, err := c.PartnerService.RevokeKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+
+	logPartnerKeyActivity(ctx, ctx.Locals("user").(*model.User), "revoke_partner_key", key, fiber.StatusOK)
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPartnerKey{
+		Status:  "success",
+		Message: "Partner key revoked successfully",
+		Data:    *key,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get partner key usage
+// @Description  Counts the requests of a partner key per day and scope between two days (inclusive), with the requests refused by the rate limit
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path   string  true  "Partner key ID"
+// @Param        from  query  string  true  "First day (2006-01-02)"
+// @Param        to    query  string  true  "Last day (2006-01-02)"
+// @Router       /admin/partner-keys/{id}/usage [get]
+// @Success      200  {object}  response.SuccessWithPartnerKeyUsage
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminPartnerKeyController) GetUsage(ctx *fiber.Ctx) error {
+	keyID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid partner key ID format")
+	}
+
+	query := &validation.PartnerKeyUsageQuery{
+		From: ctx.Query("from"),
+		To:   ctx.Query("to"),
+	}
+
+	usage, err := c.PartnerService.GetUsage(ctx, keyID, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPartnerKeyUsage{
+		Status:  "success",
+		Message: "Partner key usage retrieved successfully",
+		Data:    usage,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get partner tiers
+// @Description  Lists the commercial tiers of the partner API with their rate limits, included monthly requests and overage price
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/partner-tiers [get]
+// @Success      200  {object}  response.SuccessWithPartnerTiers
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminPartnerKeyController) GetTiers(ctx *fiber.Ctx) error {
+	tiers, err := c.PartnerService.GetTiers(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPartnerTiers{
+		Status:  "success",
+		Message: "Partner tiers retrieved successfully",
+		Data:    tiers,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Create partner tier
+// @Description  Adds a commercial tier of the partner API. Rate limits are requests per minute per scope, scopes without one keep their default, and limits set on a key override them. monthly_requests are the requests the keys of a partner make each month within its plan, 0 for no cap, and each started 1000 requests over it cost overage_price in the minor unit of currency.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.CreatePartnerTier  true  "Partner tier"
+// @Router       /admin/partner-tiers [post]
+// @Success      201  {object}  response.SuccessWithPartnerTier
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminPartnerKeyController) CreateTier(ctx *fiber.Ctx) error {
+	req := new(validation.CreatePartnerTier)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	tier, err := c.PartnerService.CreateTier(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	logPartnerTierActivity(ctx, "create_partner_tier", tier.Key, fiber.StatusCreated)
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithPartnerTier{
+		Status:  "success",
+		Message: "Partner tier created successfully",
+		Data:    *tier,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Update partner tier
+// @Description  Changes the rate limits, included monthly requests or overage price of a tier, the keys of its partners get the new limits on their next request
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        key      path  string                        true  "Partner tier key"
+// @Param        request  body  validation.UpdatePartnerTier  true  "Partner tier changes"
+// @Router       /admin/partner-tiers/{key} [patch]
+// @Success      200  {object}  response.SuccessWithPartnerTier
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminPartnerKeyController) UpdateTier(ctx *fiber.Ctx) error {
+	req := new(validation.UpdatePartnerTier)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	tier, err := c.PartnerService.UpdateTier(ctx, ctx.Params("key"), req)
+	if err != nil {
+		return err
+	}
+
+	logPartnerTierActivity(ctx, "update_partner_tier", tier.Key, fiber.StatusOK)
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPartnerTier{
+		Status:  "success",
+		Message: "Partner tier updated successfully",
+		Data:    *tier,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Set partner tier
+// @Description  Puts a partner on a commercial tier, all its keys get the rate limits of the tier. An empty tier takes the partner off its tier, back to the scope defaults without a monthly cap.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        partner  path  string                     true  "Partner"
+// @Param        request  body  validation.SetPartnerTier  true  "Tier"
+// @Router       /admin/partner-tiers/partners/{partner} [put]
+// @Success      200  {object}  response.SuccessWithPartnerAccount
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminPartnerKeyController) SetTier(ctx *fiber.Ctx) error {
+	req := new(validation.SetPartnerTier)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	account, err := c.PartnerService.SetTier(ctx, ctx.Params("partner"), req)
+	if err != nil {
+		return err
+	}
+
+	logPartnerTierActivity(ctx, "set_partner_tier", account.Partner+":"+account.Tier, fiber.StatusOK)
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPartnerAccount{
+		Status:  "success",
+		Message: "Partner tier set successfully",
+		Data:    *account,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get partner API overage
+// @Description  Adds up the requests of the keys of every partner in a month, per key, and bills the requests over what the current tier of the partner includes. Requests refused by the rate limit are counted apart and not billed.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        month    query  string  true   "Month (2006-01)"
+// @Param        partner  query  string  false  "Only this partner"
+// @Router       /admin/reports/partner-overage [get]
+// @Success      200  {object}  response.SuccessWithPartnerOverage
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminPartnerKeyController) GetOverage(ctx *fiber.Ctx) error {
+	query := &validation.PartnerOverageQuery{
+		Month:   ctx.Query("month"),
+		Partner: ctx.Query("partner"),
+	}
+
+	overage, err := c.PartnerService.GetOverage(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPartnerOverage{
+		Status:  "success",
+		Message: "Partner overage retrieved successfully",
+		Data:    overage,
+	})
+}
+
+func logPartnerTierActivity(ctx *fiber.Ctx, action, resourceID string, status int) {
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     action,
+		Resource:   "partner_tier",
+		ResourceID: resourceID,
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: status,
+	})
+}
+
+func logPartnerKeyActivity(ctx *fiber.Ctx, admin *model.User, action string, key *model.PartnerKey, status int) {
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     action,
+		Resource:   "partner_key",
+		ResourceID: key.ID.String(),
+		Details: map[string]interface{}{
+			"partner":       key.Partner,
+			"scopes":        key.Scopes,
+			"rate_limits":   key.RateLimits,
+			"terms_version": key.TermsVersion,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: status,
+	})
+}
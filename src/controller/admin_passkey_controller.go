@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminPasskeyController struct {
+	PasskeyService service.PasskeyService
+}
+
+func NewAdminPasskeyController(passkeyService service.PasskeyService) *AdminPasskeyController {
+	return &AdminPasskeyController{
+		PasskeyService: passkeyService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      List the passkeys of a user
+// @Description  Lists the passkeys the user registered, latest first, with the authenticator model (aaguid), whether they are synced and when they were last used.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  string  true  "User ID"
+// @Router       /admin/users/{id}/passkeys [get]
+// @Success      200  {object}  response.SuccessWithPasskeys
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminPasskeyController) GetUserPasskeys(ctx *fiber.Ctx) error {
+	userID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	passkeys, err := c.PasskeyService.GetPasskeys(ctx.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPasskeys{
+		Status:  "success",
+		Message: "Passkeys retrieved successfully",
+		Data:    passkeys,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Reset the passkeys of a user
+// @Description  Deletes every passkey of a user who lost their devices, they sign in with their password or a magic link and register new ones.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  string  true  "User ID"
+// @Router       /admin/users/{id}/passkeys [delete]
+// @Success      200  {object}  response.Common
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminPasskeyController) ResetUserPasskeys(ctx *fiber.Ctx) error {
+	userID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	deleted, err := c.PasskeyService.ResetPasskeys(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "reset_passkeys",
+		Resource:   "user",
+		ResourceID: userID.String(),
+		Details:    map[string]interface{}{"deleted": deleted},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "Passkeys reset successfully",
+	})
+}
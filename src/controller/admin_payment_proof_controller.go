@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/pagination"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminPaymentProofController struct {
+	PaymentProofService service.PaymentProofService
+}
+
+func NewAdminPaymentProofController(paymentProofService service.PaymentProofService) *AdminPaymentProofController {
+	return &AdminPaymentProofController{
+		PaymentProofService: paymentProofService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get payment proof verification queue
+// @Description  Returns uploaded proofs of payment, oldest first. Defaults to pending proofs.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page     query     int     false   "Page number"  default(1)
+// @Param        limit    query     int     false   "Maximum number of proofs"    default(10)
+// @Param        status   query     string  false   "Filter by status (pending, approved, rejected)"  default(pending)
+// @Router       /admin/payment-proofs [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.PaymentProof]
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminPaymentProofController) GetPaymentProofs(ctx *fiber.Ctx) error {
+	query := &validation.PaymentProofQuery{
+		Page:   ctx.QueryInt("page", 1),
+		Limit:  ctx.QueryInt("limit", 10),
+		Status: ctx.Query("status", model.PaymentProofPending),
+	}
+
+	proofs, totalResults, err := c.PaymentProofService.GetPaymentProofs(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.PaymentProof]{
+		Status:     "success",
+		Message:    "Payment proofs retrieved successfully",
+		Results:    proofs,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
+
+// @Tags         Admin
+// @Summary      Approve payment proof
+// @Description  Approves a pending proof of payment, activates the subscription and notifies the user
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Payment proof ID"
+// @Router       /admin/payment-proofs/{id}/approve [patch]
+// @Success      200  {object}  response.SuccessWithPaymentProof
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminPaymentProofController) ApprovePaymentProof(ctx *fiber.Ctx) error {
+	proofID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid payment proof ID format")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	proof, err := c.PaymentProofService.ApprovePaymentProof(ctx, admin.ID, proofID)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "approve_payment_proof",
+		Resource:   "payment_proof",
+		ResourceID: proof.ID.String(),
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPaymentProof{
+		Status:  "success",
+		Message: "Payment proof approved successfully",
+		Data:    *proof,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Reject payment proof
+// @Description  Rejects a pending proof of payment and notifies the user so they can upload a new one
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path  string                         true  "Payment proof ID"
+// @Param        request  body  validation.RejectPaymentProof  true  "Rejection reason"
+// @Router       /admin/payment-proofs/{id}/reject [patch]
+// @Success      200  {object}  response.SuccessWithPaymentProof
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminPaymentProofController) RejectPaymentProof(ctx *fiber.Ctx) error {
+	proofID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid payment proof ID format")
+	}
+
+	req := new(validation.RejectPaymentProof)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	proof, err := c.PaymentProofService.RejectPaymentProof(ctx, admin.ID, proofID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "reject_payment_proof",
+		Resource:   "payment_proof",
+		ResourceID: proof.ID.String(),
+		Details: map[string]interface{}{
+			"reason": req.Reason,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPaymentProof{
+		Status:  "success",
+		Message: "Payment proof rejected successfully",
+		Data:    *proof,
+	})
+}
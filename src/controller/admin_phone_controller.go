@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/pagination"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminPhoneController struct {
+	PhoneService service.PhoneService
+}
+
+func NewAdminPhoneController(phoneService service.PhoneService) *AdminPhoneController {
+	return &AdminPhoneController{
+		PhoneService: phoneService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      List the phone messages of a user
+// @Description  Lists the codes and notifications texted to the user by SMS or WhatsApp, latest first, with the delivery status the provider reported and the error of failed messages. Messages are kept for RETENTION_LOG_DAYS.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path   string  true   "User ID"
+// @Param        page   query  int     false  "Page number"  default(1)
+// @Param        limit  query  int     false  "Maximum number of messages"  default(10)
+// @Router       /admin/users/{id}/phone-messages [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.PhoneMessage]
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminPhoneController) GetUserMessages(ctx *fiber.Ctx) error {
+	userID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	query := &validation.PhoneMessageQuery{Page: 1, Limit: 10}
+	if err := ctx.QueryParser(query); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
+
+	messages, totalResults, err := c.PhoneService.GetMessages(ctx, userID, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.PhoneMessage]{
+		Status:     "success",
+		Message:    "Phone messages retrieved successfully",
+		Results:    messages,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminPlanSunsetController struct {
+	PlanSunsetService service.PlanSunsetService
+}
+
+func NewAdminPlanSunsetController(planSunsetService service.PlanSunsetService) *AdminPlanSunsetController {
+	return &AdminPlanSunsetController{
+		PlanSunsetService: planSunsetService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Sunset subscription plan
+// @Description  Retires a plan in favour of a replacement plan with the same currency that can be bought. The plan cannot be bought anymore, subscribers keep it until their subscription ends and are emailed about the replacement. Auto-renewals charge the price of the replacement and renew on it. Store subscriptions are left to the store.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        plan_id  path  string                 true  "Plan ID"
+// @Param        request  body  validation.SunsetPlan  true  "Replacement plan"
+// @Router       /admin/subscription-plans/{plan_id}/sunset [post]
+// @Success      200  {object}  response.SuccessWithSubscriptionPlan
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminPlanSunsetController) SunsetPlan(ctx *fiber.Ctx) error {
+	planID, err := uuid.Parse(ctx.Params("plan_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid plan ID format")
+	}
+
+	req := new(validation.SunsetPlan)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	plan, err := c.PlanSunsetService.Sunset(ctx, admin.ID, planID, req)
+	if err != nil {
+		return err
+	}
+
+	data := toAdminPlanResponse(plan)
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithSubscriptionPlan{
+		Status:  "success",
+		Message: "Subscription plan sunset successfully",
+		Data:    *data,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get plan sunset progress
+// @Description  Reports the migration of the subscribers of a sunset plan: not picked up yet, notified and waiting for their renewal, migrated to the replacement (by renewal or purchase) and lapsed without renewing. The migration runs every hour.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        plan_id  path  string  true  "Plan ID"
+// @Router       /admin/subscription-plans/{plan_id}/sunset [get]
+// @Success      200  {object}  response.SuccessWithPlanSunsetReport
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminPlanSunsetController) GetSunsetProgress(ctx *fiber.Ctx) error {
+	planID, err := uuid.Parse(ctx.Params("plan_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid plan ID format")
+	}
+
+	report, err := c.PlanSunsetService.GetProgress(ctx, planID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPlanSunsetReport{
+		Status:  "success",
+		Message: "Plan sunset progress retrieved successfully",
+		Data:    *report,
+	})
+}
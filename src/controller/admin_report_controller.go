@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminReportController struct {
+	RevenueService service.RevenueService
+}
+
+func NewAdminReportController(revenueService service.RevenueService) *AdminReportController {
+	return &AdminReportController{
+		RevenueService: revenueService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Revenue recognition report
+// @Description  Returns per month the cash collected, the revenue recognized and the deferred revenue balance at month end. Payments are spread over their service period, months are calendar months in UTC.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from  query  string  true  "First month (YYYY-MM)"
+// @Param        to    query  string  true  "Last month (YYYY-MM)"
+// @Router       /admin/reports/revenue [get]
+// @Success      200  {object}  response.SuccessWithRevenueReport
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminReportController) GetRevenueReport(ctx *fiber.Ctx) error {
+	query := &validation.RevenueReportQuery{
+		From: ctx.Query("from"),
+		To:   ctx.Query("to"),
+	}
+
+	report, err := c.RevenueService.GetRevenueReport(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithRevenueReport{
+		Status:  "success",
+		Message: "Revenue report retrieved successfully",
+		Data:    *report,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Revenue analytics
+// @Description  Returns the cash collected between two days (inclusive) per day, week (starting on Monday) or month and per plan, the subscribers won and lost in each period and the monthly recurring revenue of the subscriptions active now. A subscriber is new in the period their first paid subscription started in and churns when a paid subscription ends without another paid one running on. Trials, sandbox payments and payments in other currencies than Rupiah are left out, periods are in UTC.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from      query  string  true   "First day (YYYY-MM-DD)"
+// @Param        to        query  string  true   "Last day (YYYY-MM-DD)"
+// @Param        interval  query  string  false  "Period"  Enums(day, week, month)  default(day)
+// @Router       /admin/analytics/revenue [get]
+// @Success      200  {object}  response.SuccessWithRevenueAnalytics
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminReportController) GetRevenueAnalytics(ctx *fiber.Ctx) error {
+	query := &validation.RevenueAnalyticsQuery{
+		From:     ctx.Query("from"),
+		To:       ctx.Query("to"),
+		Interval: ctx.Query("interval", model.RevenueIntervalDay),
+	}
+
+	analytics, err := c.RevenueService.GetAnalytics(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithRevenueAnalytics{
+		Status:  "success",
+		Message: "Revenue analytics retrieved successfully",
+		Data:    *analytics,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Subscription retention analytics
+// @Description  Follows the users whose first paid subscription started in each month of a range: how many of them still had a paid subscription running in each of the next months, up to months months and the current one. Rates the churn per plan of the paid subscriptions that ended in the range: the share that ended without another paid subscription running on, and how many days of paid subscriptions the churned subscribers had in all. Trials and sandbox purchases are left out, months are in UTC.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from    query  string  true   "First cohort month (YYYY-MM)"
+// @Param        to      query  string  true   "Last cohort month (YYYY-MM)"
+// @Param        months  query  int     false  "Months to follow each cohort for"  default(12)
+// @Router       /admin/analytics/retention [get]
+// @Success      200  {object}  response.SuccessWithSubscriptionRetention
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminReportController) GetRetentionAnalytics(ctx *fiber.Ctx) error {
+	query := &validation.SubscriptionRetentionQuery{
+		From:   ctx.Query("from"),
+		To:     ctx.Query("to"),
+		Months: ctx.QueryInt("months", 12),
+	}
+
+	retention, err := c.RevenueService.GetRetention(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithSubscriptionRetention{
+		Status:  "success",
+		Message: "Subscription retention retrieved successfully",
+		Data:    *retention,
+	})
+}
@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminRetentionController struct {
+	RetentionService    service.RetentionService
+	MediaCleanupService service.MediaCleanupService
+}
+
+func NewAdminRetentionController(
+	retentionService service.RetentionService, mediaCleanupService service.MediaCleanupService,
+) *AdminRetentionController {
+	return &AdminRetentionController{
+		RetentionService:    retentionService,
+		MediaCleanupService: mediaCleanupService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get data retention policies
+// @Description  Returns the retention rules with the period configured for them and the cutoff they apply today. Rules without a period keep the data forever.
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/retention/policies [get]
+// @Success      200  {object}  response.SuccessWithRetentionPolicies
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminRetentionController) GetPolicies(ctx *fiber.Ctx) error {
+	policies, err := c.RetentionService.GetPolicies(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithRetentionPolicies{
+		Status:  "success",
+		Message: "Retention policies retrieved successfully",
+		Data:    policies,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Dry run the data retention policies
+// @Description  Counts the records every enabled rule would delete or anonymize now without changing them. The counts are kept in the audit log.
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/retention/dry-run [post]
+// @Success      200  {object}  response.SuccessWithRetentionReport
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminRetentionController) DryRun(ctx *fiber.Ctx) error {
+	admin := ctx.Locals("user").(*model.User)
+
+	report, err := c.RetentionService.DryRun(ctx, admin.ID)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "dry_run_retention",
+		Resource:   "retention",
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithRetentionReport{
+		Status:  "success",
+		Message: "Retention dry run completed successfully",
+		Data:    *report,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get data retention audit log
+// @Description  Returns the runs of the retention rules, newest first: the daily job and the dry runs of admins, with the records each rule matched and changed
+// @Produce      json
+// @Security     BearerAuth
+// @Param        rule   query  string  false  "Rule"  Enums(scan_images, logs, inactive_accounts, activity_logs, login_locations)
+// @Param        limit  query  int     false  "Maximum number of runs"  default(50)
+// @Router       /admin/retention/runs [get]
+// @Success      200  {object}  response.SuccessWithRetentionRuns
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminRetentionController) GetRuns(ctx *fiber.Ctx) error {
+	query := &validation.RetentionRunQuery{
+		Rule:  ctx.Query("rule"),
+		Limit: ctx.QueryInt("limit", 50),
+	}
+
+	runs, err := c.RetentionService.GetRuns(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithRetentionRuns{
+		Status:  "success",
+		Message: "Retention runs retrieved successfully",
+		Data:    runs,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Dry run the orphaned media cleanup
+// @Description  Counts the files under /uploads that no record refers to anymore, like deleted scans and replaced avatars, and the space they take, without removing them. Files newer than the grace period are kept. The counts are kept in the audit log.
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/retention/media/dry-run [post]
+// @Success      200  {object}  response.SuccessWithMediaCleanupRun
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminRetentionController) DryRunMediaCleanup(ctx *fiber.Ctx) error {
+	admin := ctx.Locals("user").(*model.User)
+
+	run, err := c.MediaCleanupService.DryRun(ctx, admin.ID)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "dry_run_media_cleanup",
+		Resource:   "retention",
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithMediaCleanupRun{
+		Status:  "success",
+		Message: "Media cleanup dry run completed successfully",
+		Data:    *run,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get orphaned media cleanup audit log
+// @Description  Returns the runs of the orphaned media janitor, newest first: the daily job and the dry runs of admins, with the files each one found, removed and the space it reclaimed
+// @Produce      json
+// @Security     BearerAuth
+// @Param        limit  query  int  false  "Maximum number of runs"  default(50)
+// @Router       /admin/retention/media/runs [get]
+// @Success      200  {object}  response.SuccessWithMediaCleanupRuns
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminRetentionController) GetMediaCleanupRuns(ctx *fiber.Ctx) error {
+	query := &validation.MediaCleanupRunQuery{
+		Limit: ctx.QueryInt("limit", 50),
+	}
+
+	runs, err := c.MediaCleanupService.GetRuns(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithMediaCleanupRuns{
+		Status:  "success",
+		Message: "Media cleanup runs retrieved successfully",
+		Data:    runs,
+	})
+}
@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminRoleController struct {
+	RoleService service.RoleService
+}
+
+func NewAdminRoleController(roleService service.RoleService) *AdminRoleController {
+	return &AdminRoleController{
+		RoleService: roleService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      List roles
+// @Description  Lists the built-in roles (user and admin), which cannot be changed, then the custom roles admins created, with the rights each grants and how many users hold it. The admin role grants every right.
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/roles [get]
+// @Success      200  {object}  response.SuccessWithRoles
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminRoleController) GetRoles(ctx *fiber.Ctx) error {
+	roles, err := c.RoleService.GetRoles(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithRoles{
+		Status:  "success",
+		Message: "Roles retrieved successfully",
+		Data:    roles,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get a role
+// @Description  Returns a built-in or custom role with the rights it grants and how many users hold it.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        name  path  string  true  "Role name"
+// @Router       /admin/roles/{name} [get]
+// @Success      200  {object}  response.SuccessWithRoleView
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminRoleController) GetRole(ctx *fiber.Ctx) error {
+	role, err := c.RoleService.GetRole(ctx, ctx.Params("name"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithRoleView{
+		Status:  "success",
+		Message: "Role retrieved successfully",
+		Data:    *role,
+	})
+}
+
+// @Tags         Admin
+// @Summary      List the rights a role can grant
+// @Description  Lists every right of the admin role in alphabetical order, the rights custom roles are made of.
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/roles/rights [get]
+// @Success      200  {object}  response.SuccessWithRights
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminRoleController) GetRights(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithRights{
+		Status:  "success",
+		Message: "Rights retrieved successfully",
+		Data:    c.RoleService.GetRights(),
+	})
+}
+
+// @Tags         Admin
+// @Summary      Create a custom role
+// @Description  Creates a role granting some of the rights of the admin role, such as getUsers and getUserDetails for customer support. Users get it through PUT /admin/users/{id}/role. Other instances apply it within ROLE_CACHE_TTL.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.CreateRole  true  "Role"
+// @Router       /admin/roles [post]
+// @Success      201  {object}  response.SuccessWithRole
+// @Failure      400  {object}  response.ErrorResponse  "Invalid name or unknown rights"
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse  "The role exists"
+func (c *AdminRoleController) CreateRole(ctx *fiber.Ctx) error {
+	req := new(validation.CreateRole)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	role, err := c.RoleService.CreateRole(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	logRoleActivity(ctx, "create_role", role.Name, fiber.StatusCreated)
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithRole{
+		Status:  "success",
+		Message: "Role created successfully",
+		Data:    *role,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Update a custom role
+// @Description  Changes the description or the rights of a custom role, rights replace the ones it granted. Built-in roles cannot be changed.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        name     path  string                  true  "Role name"
+// @Param        request  body  validation.UpdateRole  true  "Changes"
+// @Router       /admin/roles/{name} [patch]
+// @Success      200  {object}  response.SuccessWithRole
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminRoleController) UpdateRole(ctx *fiber.Ctx) error {
+	req := new(validation.UpdateRole)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	role, err := c.RoleService.UpdateRole(ctx, ctx.Params("name"), req)
+	if err != nil {
+		return err
+	}
+
+	logRoleActivity(ctx, "update_role", role.Name, fiber.StatusOK)
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithRole{
+		Status:  "success",
+		Message: "Role updated successfully",
+		Data:    *role,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Delete a custom role
+// @Description  Deletes a custom role no user holds. Built-in roles cannot be deleted.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        name  path  string  true  "Role name"
+// @Router       /admin/roles/{name} [delete]
+// @Success      200  {object}  response.Common
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse  "Users hold the role"
+func (c *AdminRoleController) DeleteRole(ctx *fiber.Ctx) error {
+	name := ctx.Params("name")
+	if err := c.RoleService.DeleteRole(ctx, name); err != nil {
+		return err
+	}
+
+	logRoleActivity(ctx, "delete_role", name, fiber.StatusOK)
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "Role deleted successfully",
+	})
+}
+
+// @Tags         Admin
+// @Summary      Assign a role to a user
+// @Description  Gives the user a built-in or custom role, the rights apply from their next request. Admins cannot change their own role.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path  string                  true  "User ID"
+// @Param        request  body  validation.AssignRole  true  "Role"
+// @Router       /admin/users/{id}/role [put]
+// @Success      200  {object}  response.SuccessWithUser
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminRoleController) AssignRole(ctx *fiber.Ctx) error {
+	userID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	req := new(validation.AssignRole)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	user, err := c.RoleService.AssignRole(ctx, admin.ID, userID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "assign_role",
+		Resource:   "user",
+		ResourceID: user.ID.String(),
+		Details:    map[string]interface{}{"role": user.Role},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithUser{
+		Status:  "success",
+		Message: "Role assigned successfully",
+		User:    *user,
+	})
+}
+
+func logRoleActivity(ctx *fiber.Ctx, action, name string, status int) {
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     action,
+		Resource:   "role",
+		ResourceID: name,
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: status,
+	})
+}
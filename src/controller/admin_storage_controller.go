@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminStorageController struct {
+	StorageUsageService service.StorageUsageService
+}
+
+func NewAdminStorageController(storageUsageService service.StorageUsageService) *AdminStorageController {
+	return &AdminStorageController{
+		StorageUsageService: storageUsageService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get storage usage
+// @Description  Reports the objects and bytes stored per category as of the last daily measurement: scan images, avatars, content images and payment proofs under /uploads, the uploads no record refers to anymore, the diary exports kept in the database and the backups kept in object storage. The trend lists the total of every measured day, the forecast extends the average daily growth of the trend and prices it at STORAGE_PRICE_PER_TB a month.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        days           query  int  false  "Days of trend"  default(30)
+// @Param        forecast_days  query  int  false  "Days to forecast"  default(30)
+// @Router       /admin/diagnostics/storage [get]
+// @Success      200  {object}  response.SuccessWithStorageUsageReport
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminStorageController) GetStorageUsage(ctx *fiber.Ctx) error {
+	query := &validation.StorageUsageQuery{
+		Days:         ctx.QueryInt("days", 30),
+		ForecastDays: ctx.QueryInt("forecast_days", 30),
+	}
+
+	report, err := c.StorageUsageService.GetReport(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithStorageUsageReport{
+		Status:  "success",
+		Message: "Storage usage retrieved successfully",
+		Data:    *report,
+	})
+}
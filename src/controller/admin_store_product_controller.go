@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminStoreProductController struct {
+	IAPService service.IAPService
+}
+
+func NewAdminStoreProductController(iapService service.IAPService) *AdminStoreProductController {
+	return &AdminStoreProductController{
+		IAPService: iapService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get store products
+// @Description  Returns the App Store and Google Play products and the plans they unlock
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/store-products [get]
+// @Success      200  {object}  response.SuccessWithStoreProducts
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminStoreProductController) GetStoreProducts(ctx *fiber.Ctx) error {
+	products, err := c.IAPService.GetStoreProducts(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithStoreProducts{
+		Status:  "success",
+		Message: "Store products retrieved successfully",
+		Data:    products,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Link store product to plan
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.CreateStoreProduct  true  "Store product"
+// @Router       /admin/store-products [post]
+// @Success      201  {object}  response.SuccessWithStoreProduct
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminStoreProductController) CreateStoreProduct(ctx *fiber.Ctx) error {
+	req := new(validation.CreateStoreProduct)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	product, err := c.IAPService.CreateStoreProduct(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "create_store_product",
+		Resource:   "store_product",
+		ResourceID: product.ID.String(),
+		Details: map[string]interface{}{
+			"store":      product.Store,
+			"product_id": product.ProductID,
+			"plan_id":    product.PlanID.String(),
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusCreated,
+	})
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithStoreProduct{
+		Status:  "success",
+		Message: "Store product created successfully",
+		Data:    *product,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Unlink store product
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Store product ID"
+// @Router       /admin/store-products/{id} [delete]
+// @Success      200  {object}  response.Common
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminStoreProductController) DeleteStoreProduct(ctx *fiber.Ctx) error {
+	productID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid store product ID format")
+	}
+
+	if err := c.IAPService.DeleteStoreProduct(ctx, productID); err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "delete_store_product",
+		Resource:   "store_product",
+		ResourceID: productID.String(),
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "Store product deleted successfully",
+	})
+}
@@ -2,13 +2,17 @@ package controller
 
 import (
 	"app/src/model"
+	"app/src/pagination"
 	"app/src/response"
 	"app/src/service"
 	"app/src/utils"
 	"app/src/validation"
+	"app/src/xlsx"
 
-	"encoding/json"
+	"bufio"
+	"encoding/csv"
 	"fmt"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -18,11 +22,6 @@ type AdminSubscriptionController struct {
 	SubscriptionService service.SubscriptionService
 }
 
-// Helper function to format currency
-func formatCurrency(amount int) string {
-	return fmt.Sprintf("Rp %d", amount)
-}
-
 func NewAdminSubscriptionController(
 	subscriptionService service.SubscriptionService,
 ) *AdminSubscriptionController {
@@ -39,14 +38,22 @@ func NewAdminSubscriptionController(
 // @Param        page     query     int     false   "Page number"  default(1)
 // @Param        limit    query     int     false   "Maximum number of subscriptions"    default(10)
 // @Param        status   query     string  false   "Filter by status (active, expired, pending)"
+// @Param        payment_method   query     string  false   "Filter by payment method (e.g. bank_transfer, credit_card)"
+// @Param        source   query     string  false   "Filter by source (midtrans, apple_iap, google_play, product_token, admin_comp, manual_transfer, trial)"
+// @Param        trial    query     string  false   "Filter free trials by state (active, converted, expired)"
+// @Param        include_deleted  query  bool  false  "List deleted subscriptions too, they carry deleted_at"
 // @Router       /admin/subscriptions [get]
-// @Success      200  {object}  response.SuccessWithPaginateSubscriptions
+// @Success      200  {object}  pagination.PaginatedResponse[model.UserSubscriptionResponse]
 // @Failure      403  {object}  response.ErrorResponse
 func (c *AdminSubscriptionController) GetAllUserSubscriptions(ctx *fiber.Ctx) error {
 	query := &validation.SubscriptionQuery{
-		Page:   ctx.QueryInt("page", 1),
-		Limit:  ctx.QueryInt("limit", 10),
-		Status: ctx.Query("status", ""),
+		Page:           ctx.QueryInt("page", 1),
+		Limit:          ctx.QueryInt("limit", 10),
+		Status:         ctx.Query("status", ""),
+		PaymentMethod:  ctx.Query("payment_method", ""),
+		Source:         ctx.Query("source", ""),
+		Trial:          ctx.Query("trial", ""),
+		IncludeDeleted: ctx.QueryBool("include_deleted", false),
 	}
 
 	subscriptions, totalResults, err := c.SubscriptionService.GetAllUserSubscriptions(ctx, query)
@@ -54,14 +61,11 @@ func (c *AdminSubscriptionController) GetAllUserSubscriptions(ctx *fiber.Ctx) er
 		return err
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPaginateSubscriptions{
-		Status:       "success",
-		Message:      "User subscriptions retrieved successfully",
-		Results:      subscriptions,
-		Page:         query.Page,
-		Limit:        query.Limit,
-		TotalPages:   totalResults/int64(query.Limit) + 1,
-		TotalResults: totalResults,
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.UserSubscriptionResponse]{
+		Status:     "success",
+		Message:    "User subscriptions retrieved successfully",
+		Results:    subscriptions,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
 	})
 }
 
@@ -100,22 +104,39 @@ func (c *AdminSubscriptionController) GetUserSubscriptionDetails(ctx *fiber.Ctx)
 
 // @Tags         Admin
 // @Summary      Get all subscription plans
-// @Description  Returns a list of all subscription plans with their users
+// @Description  Returns the subscription plans with their number of active subscribers, filtered, searched, sorted and paginated. Prices are in the minor unit of the currency of each plan, so a price range needs a currency and sorting by price groups the plans by currency. The search matches the name and description literally, % and _ included.
 // @Produce      json
 // @Security     BearerAuth
+// @Param        is_active    query  boolean  false  "Only active or inactive plans"
+// @Param        currency     query  string   false  "Only plans priced in this currency, required with min_price or max_price"  Enums(IDR, USD, SGD, MYR)
+// @Param        min_price    query  int      false  "Lowest price, in the minor unit of currency"
+// @Param        max_price    query  int      false  "Highest price, in the minor unit of currency"
+// @Param        search       query  string   false  "Text in the name or description"
+// @Param        sort_by      query  string   false  "Sort by"  Enums(price, name, user_count, created_at)  default(created_at)
+// @Param        order        query  string   false  "Sort order"  Enums(asc, desc)  default(asc)
+// @Param        page         query  int      false  "Page number"  default(1)
+// @Param        limit        query  int      false  "Maximum number of plans"  default(50)
 // @Param        with_users   query  boolean  false  "Include users for each plan"
 // @Router       /admin/subscription-plans [get]
 // @Success      200  {object}  example.AdminSubscriptionPlansResponse
+// @Failure      400  {object}  response.ErrorResponse
 // @Failure      403  {object}  response.ErrorResponse
 func (c *AdminSubscriptionController) GetAllSubscriptionPlans(ctx *fiber.Ctx) error {
-	withUsers := ctx.QueryBool("with_users", false)
-	modelPlans, err := c.SubscriptionService.GetAllSubscriptionPlansWithUsers(ctx, withUsers)
+	query := &validation.SubscriptionPlanQuery{
+		Page:  1,
+		Limit: 50,
+	}
+	if err := ctx.QueryParser(query); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
+
+	modelPlans, totalResults, err := c.SubscriptionService.GetAllSubscriptionPlansWithUsers(ctx, query)
 	if err != nil {
 		return err
 	}
 
 	// Convert model.SubscriptionPlanWithUsers to response.SubscriptionPlanWithUsers
-	var responsePlans []response.SubscriptionPlanWithUsers
+	responsePlans := []response.SubscriptionPlanWithUsers{}
 	for _, plan := range modelPlans {
 		responsePlans = append(responsePlans, response.SubscriptionPlanWithUsers{
 			ID:             plan.ID.String(),
@@ -133,15 +154,16 @@ func (c *AdminSubscriptionController) GetAllSubscriptionPlans(ctx *fiber.Ctx) er
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithSubscriptionPlans{
-		Status:  "success",
-		Message: "All subscription plans retrieved successfully",
-		Data:    responsePlans,
+		Status:     "success",
+		Message:    "All subscription plans retrieved successfully",
+		Data:       responsePlans,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
 	})
 }
 
 // @Tags         Admin
 // @Summary      Update user subscription
-// @Description  Updates a user subscription (plan, status, etc.)
+// @Description  Updates a user subscription (plan, status, etc.). Moving a current subscription paid in one payment to another plan is prorated unless prorate is false: the unused days of the current period are credited against the price of the new plan, which starts a period of its own. The adjustment is recorded as a proration transaction, credit left over goes to the wallet. A change that leaves an amount due is refused with 409 unless comp is true: subscribers pay for upgrades through checkout.
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
@@ -152,6 +174,7 @@ func (c *AdminSubscriptionController) GetAllSubscriptionPlans(ctx *fiber.Ctx) er
 // @Failure      400  {object}  response.ErrorResponse
 // @Failure      403  {object}  response.ErrorResponse
 // @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
 func (c *AdminSubscriptionController) UpdateUserSubscription(ctx *fiber.Ctx) error {
 	id := ctx.Params("subscription_id")
 	if id == "" {
@@ -181,33 +204,82 @@ func (c *AdminSubscriptionController) UpdateUserSubscription(ctx *fiber.Ctx) err
 }
 
 // @Tags         Admin
-// @Summary      Delete user subscription
-// @Description  Deletes a user subscription
+// @Summary      Preview plan change proration
+// @Description  Computes the proration of moving a subscription to another plan now, without changing anything
 // @Produce      json
 // @Security     BearerAuth
-// @Param        subscription_id   path  string  true  "Subscription ID"
-// @Router       /admin/subscriptions/{subscription_id} [delete]
-// @Success      200  {object}  response.Common
+// @Param        subscription_id  path   string  true  "Subscription ID"
+// @Param        plan_id          query  string  true  "Plan to move to"
+// @Router       /admin/subscriptions/{subscription_id}/proration [get]
+// @Success      200  {object}  response.SuccessWithProration
+// @Failure      400  {object}  response.ErrorResponse
 // @Failure      403  {object}  response.ErrorResponse
 // @Failure      404  {object}  response.ErrorResponse
-func (c *AdminSubscriptionController) DeleteUserSubscription(ctx *fiber.Ctx) error {
-	id := ctx.Params("subscription_id")
-	if id == "" {
-		return fiber.NewError(fiber.StatusBadRequest, "Subscription ID is required")
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminSubscriptionController) PreviewProration(ctx *fiber.Ctx) error {
+	subscriptionID, err := uuid.Parse(ctx.Params("subscription_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid subscription ID format")
 	}
 
-	subscriptionID, err := uuid.Parse(id)
+	planID, err := uuid.Parse(ctx.Query("plan_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid plan ID format")
+	}
+
+	proration, err := c.SubscriptionService.PreviewProration(ctx, subscriptionID, planID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithProration{
+		Status:  "success",
+		Message: "Proration computed successfully",
+		Data:    *proration,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Restore user subscription
+// @Description  Brings back a deleted user subscription as it was when it was deleted. Refused with 409 when the user has an active subscription created after it.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        subscription_id   path  string  true  "Subscription ID"
+// @Router       /admin/subscriptions/{subscription_id}/restore [post]
+// @Success      200  {object}  response.SuccessWithSubscription
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminSubscriptionController) RestoreUserSubscription(ctx *fiber.Ctx) error {
+	subscriptionID, err := uuid.Parse(ctx.Params("subscription_id"))
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid subscription ID format")
 	}
 
-	if err := c.SubscriptionService.DeleteUserSubscription(ctx, subscriptionID); err != nil {
+	subscription, err := c.SubscriptionService.RestoreUserSubscription(ctx, subscriptionID)
+	if err != nil {
 		return err
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(response.Common{
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "restore_user_subscription",
+		Resource:   "user_subscription",
+		ResourceID: subscriptionID.String(),
+		Details: map[string]interface{}{
+			"user_id": subscription.UserID.String(),
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithSubscription{
 		Status:  "success",
-		Message: "User subscription deleted successfully",
+		Message: "User subscription restored successfully",
+		Data:    *subscription,
 	})
 }
 
@@ -244,6 +316,34 @@ func (c *AdminSubscriptionController) GetTransactionLogs(ctx *fiber.Ctx) error {
 	})
 }
 
+// @Tags         Admin
+// @Summary      Get subscription history
+// @Description  Returns the status moves of a user subscription, oldest first
+// @Produce      json
+// @Security     BearerAuth
+// @Param        subscription_id   path  string  true  "Subscription ID"
+// @Router       /admin/subscriptions/{subscription_id}/history [get]
+// @Success      200  {object}  response.SuccessWithSubscriptionEvents
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminSubscriptionController) GetSubscriptionHistory(ctx *fiber.Ctx) error {
+	subscriptionID, err := uuid.Parse(ctx.Params("subscription_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid subscription ID format")
+	}
+
+	events, err := c.SubscriptionService.GetSubscriptionHistory(ctx, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithSubscriptionEvents{
+		Status:  "success",
+		Message: "Subscription history retrieved successfully",
+		Data:    events,
+	})
+}
+
 // @Tags         Admin
 // @Summary      Update payment status
 // @Description  Updates the payment status of a user subscription
@@ -252,6 +352,7 @@ func (c *AdminSubscriptionController) GetTransactionLogs(ctx *fiber.Ctx) error {
 // @Security     BearerAuth
 // @Param        subscription_id       path  string  true  "Subscription ID"
 // @Param        request  body  validation.UpdatePaymentStatus  true  "Update payment status data"
+// @Param        Idempotency-Key  header  string  false  "Key to retry the request with safely, its response is returned to retries"
 // @Router       /admin/subscriptions/{subscription_id}/payment-status [patch]
 // @Success      200  {object}  response.SuccessWithSubscription
 // @Failure      400  {object}  response.ErrorResponse
@@ -319,34 +420,183 @@ func (c *AdminSubscriptionController) UpdatePaymentStatus(ctx *fiber.Ctx) error
 
 // @Tags         Admin
 // @Summary      Get all transaction logs
-// @Description  Returns a list of all transaction logs with pagination
+// @Description  Returns the transaction logs matching the filters with pagination, newest first. Dates are of the transaction time and both inclusive.
 // @Produce      json
 // @Security     BearerAuth
-// @Param        page     query     int     false   "Page number"  default(1)
-// @Param        limit    query     int     false   "Maximum number of transactions"    default(10)
+// @Param        page        query     int     false   "Page number"  default(1)
+// @Param        limit       query     int     false   "Maximum number of transactions"    default(10)
+// @Param        start_date  query     string  false   "First day, YYYY-MM-DD"
+// @Param        end_date    query     string  false   "Last day, YYYY-MM-DD"
+// @Param        status      query     string  false   "Transaction status (e.g. settlement, pending, expire)"
+// @Param        plan_id     query     string  false   "Subscription plan ID"
+// @Param        user_id     query     string  false   "User ID"
 // @Router       /admin/transactions [get]
 // @Success      200  {object}  example.TransactionsResponse
+// @Failure      400  {object}  response.ErrorResponse
 // @Failure      403  {object}  response.ErrorResponse
 func (c *AdminSubscriptionController) GetAllTransactions(ctx *fiber.Ctx) error {
-	page := ctx.QueryInt("page", 1)
-	limit := ctx.QueryInt("limit", 10)
+	query := &validation.TransactionQuery{Page: 1, Limit: 10}
+	if err := ctx.QueryParser(query); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
 
-	transactions, totalResults, err := c.SubscriptionService.GetAllTransactions(ctx, page, limit)
+	transactions, totalResults, err := c.SubscriptionService.GetAllTransactions(ctx, query)
 	if err != nil {
 		return err
 	}
 
+	page := pagination.Paginate(query.Page, query.Limit, totalResults)
 	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithTransactions{
-		Status:       "success",
-		Message:      "All transaction logs retrieved successfully",
-		Data:         transactions,
-		Page:         page,
-		Limit:        limit,
-		TotalPages:   int64(totalResults)/int64(limit) + 1,
-		TotalResults: totalResults,
+		Status:     "success",
+		Message:    "All transaction logs retrieved successfully",
+		Data:       transactions,
+		Pagination: &page,
 	})
 }
 
+// transactionExportFlushRows is how many rows an export buffers before sending them to the client
+const transactionExportFlushRows = 500
+
+// @Tags         Admin
+// @Summary      Export transaction logs
+// @Description  Streams every transaction log matching the filters, live and archived, newest first, as CSV or XLSX. Rows are sent as they are read, a file cut short means the export failed midway.
+// @Produce      text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,json
+// @Security     BearerAuth
+// @Param        from     query  string  false  "First day of the transaction time, YYYY-MM-DD"
+// @Param        to       query  string  false  "Last day of the transaction time, YYYY-MM-DD"
+// @Param        status   query  string  false  "Transaction status (e.g. settlement, pending, expire)"
+// @Param        plan_id  query  string  false  "Subscription plan ID"
+// @Param        format   query  string  false  "File format"  Enums(csv, xlsx)  default(csv)
+// @Router       /admin/transactions/export [get]
+// @Success      200  {file}    file  "The export"
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminSubscriptionController) ExportTransactions(ctx *fiber.Ctx) error {
+	query := new(validation.TransactionExportQuery)
+	if err := ctx.QueryParser(query); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
+	if query.Format == "" {
+		query.Format = model.TransactionExportCSV
+	}
+
+	export, err := c.SubscriptionService.ExportTransactions(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:   admin.ID.String(),
+		Action:   "export_transactions",
+		Resource: "transaction",
+		Details: map[string]interface{}{
+			"from":    query.From,
+			"to":      query.To,
+			"status":  query.Status,
+			"plan_id": query.PlanID,
+			"format":  query.Format,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	ctx.Set(fiber.HeaderContentType, model.TransactionExportContentType(query.Format))
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", model.TransactionExportFilename(query.Format, time.Now())))
+	ctx.Set(fiber.HeaderCacheControl, "private, no-store")
+
+	// The body is written after the handler returns, so the stream must not touch ctx
+	format := query.Format
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		var err error
+		if format == model.TransactionExportXLSX {
+			err = writeTransactionsXLSX(w, export)
+		} else {
+			err = writeTransactionsCSV(w, export)
+		}
+		if err != nil {
+			utils.Log.Errorf("Transaction export failed: %v", err)
+		}
+	})
+	return nil
+}
+
+func writeTransactionsCSV(w *bufio.Writer, export service.TransactionExport) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(model.TransactionExportHeader); err != nil {
+		return err
+	}
+
+	rows := 0
+	if err := export(func(row *model.TransactionExportRow) error {
+		if err := writer.Write(row.CSVRecord()); err != nil {
+			return err
+		}
+		if rows++; rows%transactionExportFlushRows == 0 {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return err
+			}
+			return w.Flush()
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func writeTransactionsXLSX(w *bufio.Writer, export service.TransactionExport) error {
+	writer, err := xlsx.NewWriter(w, "Transactions")
+	if err != nil {
+		return err
+	}
+
+	header := make([]xlsx.Cell, len(model.TransactionExportHeader))
+	for i, column := range model.TransactionExportHeader {
+		header[i] = xlsx.Text(column)
+	}
+	if err := writer.WriteRow(header...); err != nil {
+		return err
+	}
+
+	rows := 0
+	if err := export(func(row *model.TransactionExportRow) error {
+		record := row.Record()
+		cells := make([]xlsx.Cell, len(record))
+		for i, value := range record {
+			if i == model.TransactionExportAmountColumn {
+				cells[i] = xlsx.Number(value)
+			} else {
+				cells[i] = xlsx.Text(value)
+			}
+		}
+		if err := writer.WriteRow(cells...); err != nil {
+			return err
+		}
+		if rows++; rows%transactionExportFlushRows == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			return w.Flush()
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
 // @Tags         Admin
 // @Summary      Get transaction details
 // @Description  Returns details of a specific transaction
@@ -406,26 +656,12 @@ func (c *AdminSubscriptionController) GetSubscriptionPlanByID(ctx *fiber.Ctx) er
 		return err
 	}
 
-	// Parse features
-	var features map[string]bool
-	if err := json.Unmarshal([]byte(plan.Features), &features); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Error parsing plan features")
-	}
+	data := toAdminPlanResponse(plan)
 
 	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithSubscriptionPlan{
 		Status:  "success",
 		Message: "Subscription plan details retrieved successfully",
-		Data: response.SubscriptionPlanResponse{
-			ID:             plan.ID.String(),
-			Name:           plan.Name,
-			Price:          plan.Price,
-			PriceFormatted: formatCurrency(plan.Price),
-			Description:    plan.Description,
-			AIscanLimit:    plan.AIscanLimit,
-			ValidityDays:   plan.ValidityDays,
-			Features:       features,
-			IsActive:       plan.IsActive,
-		},
+		Data:    *data,
 	})
 }
 
@@ -458,30 +694,277 @@ func (c *AdminSubscriptionController) UpdateSubscriptionPlan(ctx *fiber.Ctx) err
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	plan, err := c.SubscriptionService.UpdateSubscriptionPlan(ctx, planID, req)
+	admin := ctx.Locals("user").(*model.User)
+
+	plan, err := c.SubscriptionService.UpdateSubscriptionPlan(ctx, admin.ID, planID, req)
 	if err != nil {
 		return err
 	}
 
-	// Parse features
-	var features map[string]bool
-	if err := json.Unmarshal([]byte(plan.Features), &features); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Error parsing plan features")
-	}
+	data := toAdminPlanResponse(plan)
 
 	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithSubscriptionPlan{
 		Status:  "success",
 		Message: "Subscription plan updated successfully",
-		Data: response.SubscriptionPlanResponse{
-			ID:             plan.ID.String(),
-			Name:           plan.Name,
-			Price:          plan.Price,
-			PriceFormatted: formatCurrency(plan.Price),
-			Description:    plan.Description,
-			AIscanLimit:    plan.AIscanLimit,
-			ValidityDays:   plan.ValidityDays,
-			Features:       features,
-			IsActive:       plan.IsActive,
+		Data:    *data,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Create subscription plan
+// @Description  Creates a subscription plan. Plans are active unless is_active is false, priced in IDR unless a currency is given, and allow 30 assistant messages a day and 30 voice logs unless limits are given.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.CreateSubscriptionPlan  true  "Plan"
+// @Router       /admin/subscription-plans [post]
+// @Success      201  {object}  response.SuccessWithSubscriptionPlan
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminSubscriptionController) CreateSubscriptionPlan(ctx *fiber.Ctx) error {
+	req := new(validation.CreateSubscriptionPlan)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	plan, err := c.SubscriptionService.CreateSubscriptionPlan(ctx, admin.ID, req)
+	if err != nil {
+		return err
+	}
+
+	data := toAdminPlanResponse(plan)
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithSubscriptionPlan{
+		Status:  "success",
+		Message: "Subscription plan created successfully",
+		Data:    *data,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get subscription plan history
+// @Description  Lists every change admins made to a plan, newest first: who made it, when, and the value of each changed term before and after. Creating, editing, archiving, deleting, sunsetting and rolling back are recorded. The history of a deleted plan is kept.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        plan_id  path   string  true   "Plan ID"
+// @Param        page     query  int     false  "Page number"  default(1)
+// @Param        limit    query  int     false  "Maximum number of changes"  default(10)
+// @Router       /admin/subscription-plans/{plan_id}/history [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.PlanChange]
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminSubscriptionController) GetPlanHistory(ctx *fiber.Ctx) error {
+	planID, err := uuid.Parse(ctx.Params("plan_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid plan ID format")
+	}
+
+	query := &validation.PlanChangeQuery{
+		Page:  ctx.QueryInt("page", 1),
+		Limit: ctx.QueryInt("limit", 10),
+	}
+
+	changes, totalResults, err := c.SubscriptionService.GetPlanHistory(ctx, planID, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.PlanChange]{
+		Status:     "success",
+		Message:    "Subscription plan history retrieved successfully",
+		Results:    changes,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
+
+// @Tags         Admin
+// @Summary      Roll back subscription plan change
+// @Description  Sets the terms an edit of a plan changed back to their value before the edit, the rollback is recorded in the history as a change of its own. Terms changed again since are not overwritten, roll back the later change first. Creating, archiving, deleting and sunsetting a plan cannot be rolled back.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        plan_id    path  string  true  "Plan ID"
+// @Param        change_id  path  string  true  "Change ID"
+// @Router       /admin/subscription-plans/{plan_id}/history/{change_id}/rollback [post]
+// @Success      200  {object}  response.SuccessWithSubscriptionPlan
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminSubscriptionController) RollbackPlanChange(ctx *fiber.Ctx) error {
+	planID, err := uuid.Parse(ctx.Params("plan_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid plan ID format")
+	}
+	changeID, err := uuid.Parse(ctx.Params("change_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid change ID format")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	plan, err := c.SubscriptionService.RollbackPlanChange(ctx, admin.ID, planID, changeID)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "rollback_subscription_plan",
+		Resource:   "subscription_plan",
+		ResourceID: planID.String(),
+		Details: map[string]interface{}{
+			"change_id": changeID.String(),
 		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
 	})
+
+	data := toAdminPlanResponse(plan)
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithSubscriptionPlan{
+		Status:  "success",
+		Message: "Subscription plan change rolled back successfully",
+		Data:    *data,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Record manual bank transfer
+// @Description  Records an offline bank transfer payment with its proof of transfer and activates the subscription
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        user_id           formData  string  true   "User ID"
+// @Param        plan_id           formData  string  true   "Subscription plan ID"
+// @Param        amount            formData  int     false  "Transferred amount, defaults to the plan price"
+// @Param        bank_name         formData  string  false  "Sender bank"
+// @Param        reference_number  formData  string  false  "Bank transfer reference number"
+// @Param        transferred_at    formData  string  false  "Transfer date (YYYY-MM-DD)"
+// @Param        notes             formData  string  false  "Admin notes"
+// @Param        proof             formData  file    true   "Proof of transfer image"
+// @Param        Idempotency-Key  header  string  false  "Key to retry the request with safely, its response is returned to retries"
+// @Router       /admin/transactions/manual [post]
+// @Success      201  {object}  example.TransactionDetailResponse
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminSubscriptionController) CreateManualTransaction(ctx *fiber.Ctx) error {
+	req := new(validation.CreateManualTransaction)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	proof, err := ctx.FormFile("proof")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Proof of transfer image is required")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	transaction, err := c.SubscriptionService.CreateManualTransaction(ctx, admin.ID, req, proof)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "create_manual_transaction",
+		Resource:   "transaction",
+		ResourceID: transaction.ID.String(),
+		Details: map[string]interface{}{
+			"user_id": req.UserID,
+			"plan_id": req.PlanID,
+			"amount":  transaction.GrossAmount,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusCreated,
+	})
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithTransaction{
+		Status:  "success",
+		Message: "Manual transaction recorded successfully",
+		Data:    *transaction,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Grant complimentary subscription
+// @Description  Gives a user a subscription without payment, e.g. for partners or support cases
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.CompSubscription  true  "Complimentary subscription"
+// @Router       /admin/subscriptions/comp [post]
+// @Success      201  {object}  response.SuccessWithSubscription
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminSubscriptionController) CompSubscription(ctx *fiber.Ctx) error {
+	req := new(validation.CompSubscription)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	subscription, err := c.SubscriptionService.CompSubscription(ctx, admin.ID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "comp_subscription",
+		Resource:   "subscription",
+		ResourceID: subscription.ID.String(),
+		Details: map[string]interface{}{
+			"user_id": req.UserID,
+			"plan_id": req.PlanID,
+			"reason":  req.Reason,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusCreated,
+	})
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithSubscription{
+		Status:  "success",
+		Message: "Complimentary subscription granted successfully",
+		Data:    *subscription,
+	})
+}
+
+// toAdminPlanResponse is a plan with everything admins manage of it
+func toAdminPlanResponse(plan *model.SubscriptionPlan) *response.SubscriptionPlanResponse {
+	return &response.SubscriptionPlanResponse{
+		ID:             plan.ID.String(),
+		Name:           plan.Name,
+		Price:          plan.Price,
+		Currency:       plan.Currency,
+		PriceFormatted: plan.PriceMoney().String(),
+		Description:    plan.Description,
+		AIscanLimit:    plan.AIscanLimit,
+		ValidityDays:   plan.ValidityDays,
+		Features:       plan.Features.Map(),
+		IsActive:       plan.IsActive,
+
+		ChatMessageLimit:  plan.ChatMessageLimit,
+		VoiceLogLimit:     plan.VoiceLogLimit,
+		AllowInstallments: plan.AllowInstallments,
+		InstallmentCount:  plan.InstallmentCount,
+
+		Hidden:         plan.Hidden,
+		AvailableFrom:  plan.AvailableFrom,
+		AvailableUntil: plan.AvailableUntil,
+		ArchivedAt:     plan.ArchivedAt,
+
+		SunsetAt:          plan.SunsetAt,
+		ReplacementPlanID: plan.ReplacementPlanID,
+		TrialDays:         plan.TrialDays,
+	}
 }
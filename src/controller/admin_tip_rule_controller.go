@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminTipRuleController struct {
+	DailyTipService service.DailyTipService
+}
+
+func NewAdminTipRuleController(dailyTipService service.DailyTipService) *AdminTipRuleController {
+	return &AdminTipRuleController{
+		DailyTipService: dailyTipService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get tip rules
+// @Description  Returns the catalog of daily tips, highest priority first. A user gets one tip a day: the enabled rule of the highest priority whose metric is below or above its threshold on at least min_days of the last window_days days, then the one matching on most days. A rule given to a user waits cooldown_days before it is given again.
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/tip-rules [get]
+// @Success      200  {object}  response.SuccessWithTipRules
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminTipRuleController) GetTipRules(ctx *fiber.Ctx) error {
+	rules, err := c.DailyTipService.GetRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithTipRules{
+		Status:  "success",
+		Message: "Tip rules retrieved successfully",
+		Data:    rules,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Create tip rule
+// @Description  Adds a tip to the catalog. Metrics are calories, protein, carbs and fat of the days with meals, meal_count of every day, or always for a general tip. Title and message are templates with the variables days, window, threshold and average, e.g. "you were low on protein {{.days}} of the last {{.window}} days".
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.CreateTipRule  true  "Tip rule"
+// @Router       /admin/tip-rules [post]
+// @Success      201  {object}  response.SuccessWithTipRule
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminTipRuleController) CreateTipRule(ctx *fiber.Ctx) error {
+	req := new(validation.CreateTipRule)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	rule, err := c.DailyTipService.CreateRule(ctx, admin.ID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "create_tip_rule",
+		Resource:   "tip_rule",
+		ResourceID: rule.ID.String(),
+		Details: map[string]interface{}{
+			"key": rule.Key,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusCreated,
+	})
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithTipRule{
+		Status:  "success",
+		Message: "Tip rule created successfully",
+		Data:    *rule,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Update tip rule
+// @Description  Updates a tip rule or disables it, tips already given today keep their text
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path  string                    true  "Tip rule ID"
+// @Param        request  body  validation.UpdateTipRule  true  "Tip rule changes"
+// @Router       /admin/tip-rules/{id} [patch]
+// @Success      200  {object}  response.SuccessWithTipRule
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminTipRuleController) UpdateTipRule(ctx *fiber.Ctx) error {
+	ruleID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid tip rule ID format")
+	}
+
+	req := new(validation.UpdateTipRule)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	rule, err := c.DailyTipService.UpdateRule(ctx, admin.ID, ruleID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "update_tip_rule",
+		Resource:   "tip_rule",
+		ResourceID: rule.ID.String(),
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithTipRule{
+		Status:  "success",
+		Message: "Tip rule updated successfully",
+		Data:    *rule,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Delete tip rule
+// @Description  Removes a tip from the catalog, tips already given keep their text
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Tip rule ID"
+// @Router       /admin/tip-rules/{id} [delete]
+// @Success      200  {object}  response.Common
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminTipRuleController) DeleteTipRule(ctx *fiber.Ctx) error {
+	ruleID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid tip rule ID format")
+	}
+
+	if err := c.DailyTipService.DeleteRule(ctx, ruleID); err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "delete_tip_rule",
+		Resource:   "tip_rule",
+		ResourceID: ruleID.String(),
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "Tip rule deleted successfully",
+	})
+}
@@ -2,10 +2,10 @@ package controller
 
 import (
 	"app/src/model"
+	"app/src/pagination"
 	"app/src/response"
 	"app/src/service"
 	"app/src/validation"
-	"math"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -34,6 +34,7 @@ func NewAdminUserController(
 // @Param        page     query     int     false   "Page number"  default(1)
 // @Param        limit    query     int     false   "Maximum number of users"    default(10)
 // @Param        search   query     string  false  "Search by name or email or role"
+// @Param        sort_by  query     string  false  "Sort by a usage counter, highest first"  Enums(total_scans, total_logged_days, current_streak, lifetime_spend)
 // @Router       /admin/users [get]
 // @Success      200  {object}  example.SuccessWithPaginateUsers
 // @Failure      403  {object}  response.ErrorResponse
@@ -42,6 +43,7 @@ func (c *AdminUserController) GetAllUsers(ctx *fiber.Ctx) error {
 		Page:   ctx.QueryInt("page", 1),
 		Limit:  ctx.QueryInt("limit", 10),
 		Search: ctx.Query("search", ""),
+		SortBy: ctx.Query("sort_by", ""),
 	}
 
 	users, totalResults, err := c.UserService.GetUsers(ctx, query)
@@ -50,14 +52,11 @@ func (c *AdminUserController) GetAllUsers(ctx *fiber.Ctx) error {
 	}
 
 	return ctx.Status(fiber.StatusOK).
-		JSON(response.SuccessWithPaginate[model.User]{
-			Status:       "success",
-			Message:      "Get all users successfully",
-			Results:      users,
-			Page:         query.Page,
-			Limit:        query.Limit,
-			TotalPages:   int64(math.Ceil(float64(totalResults) / float64(query.Limit))),
-			TotalResults: totalResults,
+		JSON(pagination.PaginatedResponse[model.User]{
+			Status:     "success",
+			Message:    "Get all users successfully",
+			Results:    users,
+			Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
 		})
 }
 
@@ -91,6 +90,44 @@ func (c *AdminUserController) GetUserDetails(ctx *fiber.Ctx) error {
 		})
 }
 
+// @Tags         Admin
+// @Summary      Mark a user as sandbox
+// @Description  Sandbox users are QA accounts. Their checkouts are paid through the Midtrans sandbox, their orders are prefixed with SBX- and their transactions are left out of revenue reports and KPIs. Subscriptions bought before the change keep their flag.
+// @Security     BearerAuth
+// @Accept       application/json
+// @Produce      json
+// @Param        id       path      string                        true   "User ID"
+// @Param        request  body      validation.UpdateUserSandbox  true   "Sandbox flag"
+// @Router       /admin/users/{id}/sandbox [patch]
+// @Success      200  {object}  response.SuccessWithUser
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminUserController) UpdateUserSandbox(ctx *fiber.Ctx) error {
+	req := new(validation.UpdateUserSandbox)
+	userID := ctx.Params("id")
+
+	if _, err := uuid.Parse(userID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user, err := c.UserService.UpdateSandbox(ctx, req, userID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).
+		JSON(response.SuccessWithUser{
+			Status:  "success",
+			Message: "Update user sandbox flag successfully",
+			User:    *user,
+		})
+}
+
 // @Tags         Admin
 // @Summary      Update user
 // @Description  Admin endpoint to update user information
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/pagination"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminWalletController struct {
+	WalletService service.WalletService
+}
+
+func NewAdminWalletController(walletService service.WalletService) *AdminWalletController {
+	return &AdminWalletController{
+		WalletService: walletService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get user wallet
+// @Description  Returns the wallet balance and latest transactions of a user
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string  true    "User ID"
+// @Param        page     query     int     false   "Page number"  default(1)
+// @Param        limit    query     int     false   "Maximum number of transactions"    default(10)
+// @Router       /admin/users/{id}/wallet [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.WalletTransaction]
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminWalletController) GetUserWallet(ctx *fiber.Ctx) error {
+	userID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID format")
+	}
+
+	query := &validation.WalletQuery{
+		Page:  ctx.QueryInt("page", 1),
+		Limit: ctx.QueryInt("limit", 10),
+	}
+
+	transactions, totalResults, err := c.WalletService.GetTransactions(ctx, userID, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.WalletTransaction]{
+		Status:     "success",
+		Message:    "Wallet transactions retrieved successfully",
+		Results:    transactions,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
+
+// @Tags         Admin
+// @Summary      Adjust user wallet
+// @Description  Credits or debits a user's wallet, e.g. for refunds, referral rewards or promos. Negative amounts debit the wallet.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path  string                   true  "User ID"
+// @Param        request  body  validation.AdjustWallet  true  "Adjustment"
+// @Router       /admin/users/{id}/wallet/adjustments [post]
+// @Success      201  {object}  response.SuccessWithWalletTransaction
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminWalletController) AdjustUserWallet(ctx *fiber.Ctx) error {
+	userID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID format")
+	}
+
+	req := new(validation.AdjustWallet)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	entry, err := c.WalletService.AdjustBalance(ctx, admin.ID, userID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "adjust_wallet",
+		Resource:   "wallet",
+		ResourceID: entry.WalletID.String(),
+		Details: map[string]interface{}{
+			"user_id": userID.String(),
+			"amount":  req.Amount,
+			"type":    req.Type,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusCreated,
+	})
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithWalletTransaction{
+		Status:  "success",
+		Message: "Wallet adjusted successfully",
+		Data:    *entry,
+	})
+}
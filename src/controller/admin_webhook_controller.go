@@ -0,0 +1,328 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/pagination"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminWebhookController struct {
+	WebhookService service.WebhookService
+}
+
+func NewAdminWebhookController(webhookService service.WebhookService) *AdminWebhookController {
+	return &AdminWebhookController{
+		WebhookService: webhookService,
+	}
+}
+
+// @Tags         Admin
+// @Summary      Get webhook endpoints
+// @Description  Returns the endpoints subscription and payment events are posted to, without their secrets
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /admin/webhooks [get]
+// @Success      200  {object}  response.SuccessWithWebhookEndpoints
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminWebhookController) GetWebhookEndpoints(ctx *fiber.Ctx) error {
+	endpoints, err := c.WebhookService.GetEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithWebhookEndpoints{
+		Status:  "success",
+		Message: "Webhook endpoints retrieved successfully",
+		Data:    endpoints,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Create webhook endpoint
+// @Description  Registers an https endpoint to be posted the events it subscribes to as JSON. Every request carries
+// @Description  the X-Nutribox-Signature header, t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>"> with the
+// @Description  secret of the endpoint, and the event and delivery IDs. The secret is generated unless one is given
+// @Description  and is only returned here.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.CreateWebhookEndpoint  true  "Webhook endpoint"
+// @Router       /admin/webhooks [post]
+// @Success      201  {object}  response.SuccessWithCreatedWebhookEndpoint
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminWebhookController) CreateWebhookEndpoint(ctx *fiber.Ctx) error {
+	req := new(validation.CreateWebhookEndpoint)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	endpoint, err := c.WebhookService.CreateEndpoint(ctx, admin.ID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "create_webhook_endpoint",
+		Resource:   "webhook_endpoint",
+		ResourceID: endpoint.ID.String(),
+		Details: map[string]interface{}{
+			"url":    endpoint.URL,
+			"events": endpoint.Events,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusCreated,
+	})
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithCreatedWebhookEndpoint{
+		Status:  "success",
+		Message: "Webhook endpoint created successfully, store its secret now as it is not shown again",
+		Data:    *endpoint,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Update webhook endpoint
+// @Description  Changes the URL, description or events of a webhook endpoint, or pauses it
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path  string                            true  "Webhook endpoint ID"
+// @Param        request  body  validation.UpdateWebhookEndpoint  true  "Webhook endpoint changes"
+// @Router       /admin/webhooks/{id} [patch]
+// @Success      200  {object}  response.SuccessWithWebhookEndpoint
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminWebhookController) UpdateWebhookEndpoint(ctx *fiber.Ctx) error {
+	endpointID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid webhook endpoint ID format")
+	}
+
+	req := new(validation.UpdateWebhookEndpoint)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	endpoint, err := c.WebhookService.UpdateEndpoint(ctx, endpointID, req)
+	if err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "update_webhook_endpoint",
+		Resource:   "webhook_endpoint",
+		ResourceID: endpoint.ID.String(),
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithWebhookEndpoint{
+		Status:  "success",
+		Message: "Webhook endpoint updated successfully",
+		Data:    *endpoint,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Delete webhook endpoint
+// @Description  Removes a webhook endpoint together with its delivery log
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Webhook endpoint ID"
+// @Router       /admin/webhooks/{id} [delete]
+// @Success      200  {object}  response.Common
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminWebhookController) DeleteWebhookEndpoint(ctx *fiber.Ctx) error {
+	endpointID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid webhook endpoint ID format")
+	}
+
+	if err := c.WebhookService.DeleteEndpoint(ctx, endpointID); err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "delete_webhook_endpoint",
+		Resource:   "webhook_endpoint",
+		ResourceID: endpointID.String(),
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "Webhook endpoint deleted successfully",
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get webhook deliveries
+// @Description  Lists the events posted, or waiting to be posted, to the webhook endpoints, newest first, with the outcome of their last attempt
+// @Produce      json
+// @Security     BearerAuth
+// @Param        endpoint_id  query  string  false  "Webhook endpoint ID"
+// @Param        replay_id    query  string  false  "Webhook replay ID"
+// @Param        event        query  string  false  "Event"  Enums(subscription.activated, subscription.expired, subscription.cancelled, subscription.suspended, payment.failed)
+// @Param        status       query  string  false  "Status"  Enums(pending, delivered, failed)
+// @Param        page         query  int     false  "Page number"  default(1)
+// @Param        limit        query  int     false  "Maximum number of deliveries"  default(10)
+// @Router       /admin/webhooks/deliveries [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.WebhookDelivery]
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (c *AdminWebhookController) GetWebhookDeliveries(ctx *fiber.Ctx) error {
+	query := &validation.WebhookDeliveryQuery{
+		EndpointID: ctx.Query("endpoint_id"),
+		ReplayID:   ctx.Query("replay_id"),
+		Event:      ctx.Query("event"),
+		Status:     ctx.Query("status"),
+		Page:       ctx.QueryInt("page", 1),
+		Limit:      ctx.QueryInt("limit", 10),
+	}
+
+	deliveries, totalResults, err := c.WebhookService.GetDeliveries(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.WebhookDelivery]{
+		Status:     "success",
+		Message:    "Webhook deliveries retrieved successfully",
+		Results:    deliveries,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
+
+// @Tags         Admin
+// @Summary      Retry webhook delivery
+// @Description  Posts a pending or failed delivery again within a minute, with all its attempts
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Webhook delivery ID"
+// @Router       /admin/webhooks/deliveries/{id}/retry [post]
+// @Success      200  {object}  response.SuccessWithWebhookDelivery
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminWebhookController) RetryWebhookDelivery(ctx *fiber.Ctx) error {
+	deliveryID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid webhook delivery ID format")
+	}
+
+	delivery, err := c.WebhookService.RetryDelivery(ctx, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithWebhookDelivery{
+		Status:  "success",
+		Message: "Webhook delivery queued for retry",
+		Data:    *delivery,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Replay webhook events
+// @Description  Sends the events created between from and to again to an endpoint, e.g. after it was down, oldest first within a minute. Each one is a new delivery whose payload keeps the ID and the creation time of the event and carries a replay object with the replay ID, so receivers can skip the events they processed already. Ranges are limited to 31 days, an endpoint runs one replay at a time.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path   string  true   "Webhook endpoint ID"
+// @Param        from   query  string  true   "Events created from (RFC 3339)"
+// @Param        to     query  string  true   "Events created until (RFC 3339)"
+// @Param        event  query  string  false  "Only replay this event"  Enums(subscription.activated, subscription.expired, subscription.cancelled, subscription.suspended, payment.failed)
+// @Router       /admin/webhooks/{id}/replay [post]
+// @Success      202  {object}  response.SuccessWithWebhookReplay
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *AdminWebhookController) ReplayWebhookEvents(ctx *fiber.Ctx) error {
+	endpointID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid webhook endpoint ID format")
+	}
+
+	query := &validation.ReplayWebhookEvents{
+		From:  ctx.Query("from"),
+		To:    ctx.Query("to"),
+		Event: ctx.Query("event"),
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+
+	replay, err := c.WebhookService.ReplayEvents(ctx, admin.ID, endpointID, query)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "replay_webhook_events",
+		Resource:   "webhook_endpoint",
+		ResourceID: endpointID.String(),
+		Details: map[string]interface{}{
+			"replay_id": replay.ID,
+			"from":      query.From,
+			"to":        query.To,
+			"total":     replay.Total,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusAccepted,
+	})
+
+	return ctx.Status(fiber.StatusAccepted).JSON(response.SuccessWithWebhookReplay{
+		Status:  "success",
+		Message: "Webhook events queued for replay",
+		Data:    *replay,
+	})
+}
+
+// @Tags         Admin
+// @Summary      Get webhook replay
+// @Description  Returns a replay with how many of its deliveries are pending, delivered or failed. It is running until none is pending, the deliveries themselves are listed with their replay_id.
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Webhook replay ID"
+// @Router       /admin/webhooks/replays/{id} [get]
+// @Success      200  {object}  response.SuccessWithWebhookReplay
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AdminWebhookController) GetWebhookReplay(ctx *fiber.Ctx) error {
+	replayID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid webhook replay ID format")
+	}
+
+	replay, err := c.WebhookService.GetReplay(ctx, replayID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithWebhookReplay{
+		Status:  "success",
+		Message: "Webhook replay retrieved successfully",
+		Data:    *replay,
+	})
+}
@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/pagination"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AssistantController struct {
+	AssistantService service.AssistantService
+}
+
+func NewAssistantController(assistantService service.AssistantService) *AssistantController {
+	return &AssistantController{
+		AssistantService: assistantService,
+	}
+}
+
+// @Tags         Assistant
+// @Summary      Chat with the nutrition assistant
+// @Description  Answers a nutrition question with the profile, weight target, dietary restrictions and food diary of the last days of the user as context. Leave conversation_id empty to start a conversation. Subscribers get the daily message limit of their plan, other users a smaller free one. The assistant does not diagnose or advise on medicines: answers about them carry a disclaimer, and messages about an emergency get emergency numbers instead of an answer, without counting against the limit.
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  validation.AssistantChat  true  "Message"
+// @Router       /assistant/chat [post]
+// @Success      200  {object}  response.SuccessWithAssistantReply
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse
+// @Failure      502  {object}  response.ErrorResponse
+// @Failure      503  {object}  response.ErrorResponse
+func (c *AssistantController) Chat(ctx *fiber.Ctx) error {
+	req := new(validation.AssistantChat)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	reply, err := c.AssistantService.Chat(ctx, user.ID, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithAssistantReply{
+		Status:  "success",
+		Message: "Assistant answered successfully",
+		Data:    *reply,
+	})
+}
+
+// @Tags         Assistant
+// @Summary      Get assistant quota
+// @Description  Returns the messages the user may send the assistant today and those they sent, a limit of -1 is unlimited
+// @Produce      json
+// @Security     BearerAuth
+// @Router       /assistant/quota [get]
+// @Success      200  {object}  response.SuccessWithAssistantQuota
+func (c *AssistantController) GetQuota(ctx *fiber.Ctx) error {
+	user := ctx.Locals("user").(*model.User)
+
+	quota, err := c.AssistantService.GetQuota(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithAssistantQuota{
+		Status:  "success",
+		Message: "Assistant quota retrieved successfully",
+		Data:    *quota,
+	})
+}
+
+// @Tags         Assistant
+// @Summary      Get assistant conversations
+// @Description  Returns the conversations of the user with the assistant, latest first, without their messages
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page     query     int     false   "Page number"  default(1)
+// @Param        limit    query     int     false   "Maximum number of conversations"    default(10)
+// @Router       /assistant/conversations [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.AssistantConversation]
+func (c *AssistantController) GetConversations(ctx *fiber.Ctx) error {
+	query := &validation.AssistantConversationQuery{
+		Page:  ctx.QueryInt("page", 1),
+		Limit: ctx.QueryInt("limit", 10),
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	conversations, totalResults, err := c.AssistantService.GetConversations(ctx, user.ID, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.AssistantConversation]{
+		Status:     "success",
+		Message:    "Conversations retrieved successfully",
+		Results:    conversations,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
+
+// @Tags         Assistant
+// @Summary      Get assistant conversation
+// @Description  Returns a conversation of the user with its messages, oldest first
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Conversation ID"
+// @Router       /assistant/conversations/{id} [get]
+// @Success      200  {object}  response.SuccessWithAssistantConversation
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AssistantController) GetConversation(ctx *fiber.Ctx) error {
+	conversationID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid conversation ID format")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	conversation, err := c.AssistantService.GetConversation(ctx, user.ID, conversationID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithAssistantConversation{
+		Status:  "success",
+		Message: "Conversation retrieved successfully",
+		Data:    *conversation,
+	})
+}
+
+// @Tags         Assistant
+// @Summary      Delete assistant conversation
+// @Description  Deletes a conversation with its messages, they still count against the limit of their day
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Conversation ID"
+// @Router       /assistant/conversations/{id} [delete]
+// @Success      200  {object}  response.Common
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *AssistantController) DeleteConversation(ctx *fiber.Ctx) error {
+	conversationID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid conversation ID format")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	if err := c.AssistantService.DeleteConversation(ctx, user.ID, conversationID); err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "Conversation deleted successfully",
+	})
+}
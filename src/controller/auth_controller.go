@@ -21,28 +21,36 @@ type AuthController struct {
 	UserService  service.UserService
 	TokenService service.TokenService
 	EmailService service.EmailService
+
+	ParentalConsentService service.ParentalConsentService
 }
 
 func NewAuthController(
 	authService service.AuthService, userService service.UserService,
 	tokenService service.TokenService, emailService service.EmailService,
+	parentalConsentService service.ParentalConsentService,
 ) *AuthController {
 	return &AuthController{
-		AuthService:  authService,
-		UserService:  userService,
-		TokenService: tokenService,
-		EmailService: emailService,
+		AuthService:            authService,
+		UserService:            userService,
+		TokenService:           tokenService,
+		EmailService:           emailService,
+		ParentalConsentService: parentalConsentService,
 	}
 }
 
 // @Tags         Auth
 // @Summary      Register as user
+// @Description  Users younger than the parental consent age sign up with parental_consent pending and cannot scan meals or pay until their guardian consents. With guardian_email the consent request is emailed right away.
 // @Accept       json
 // @Produce      json
-// @Param        request  body  validation.Register  true  "Request body"
+// @Param        request          body    validation.Register  true   "Request body"
+// @Param        X-Captcha-Token  header  string               false  "CAPTCHA token, required when CAPTCHA_PROVIDER is set"
 // @Router       /auth/register [post]
 // @Success      201  {object}  example.RegisterResponse
+// @Failure      400  {object}  response.ErrorResponse  "The CAPTCHA was rejected"
 // @Failure      409  {object}  example.DuplicateEmail  "Email already taken"
+// @Failure      428  {object}  response.ErrorResponse  "The CAPTCHA token is missing"
 func (a *AuthController) Register(c *fiber.Ctx) error {
 	req := new(validation.Register)
 
@@ -63,6 +71,14 @@ func (a *AuthController) Register(c *fiber.Ctx) error {
 	// Log user registration activity
 	utils.LogRegistration(c, user.ID.String())
 
+	// The account exists either way, a failed request can be sent again from /users/me/parental-consent
+	if user.ParentalConsent == model.ParentalConsentPending && req.GuardianEmail != "" {
+		if _, err := a.ParentalConsentService.RequestConsent(c, user,
+			&validation.RequestParentalConsent{GuardianEmail: req.GuardianEmail}); err != nil {
+			utils.Log.Warnf("Failed to request parental consent for user %s: %v", user.ID, err)
+		}
+	}
+
 	return c.Status(fiber.StatusCreated).
 		JSON(response.SuccessWithTokens{
 			Status:  "success",
@@ -74,12 +90,16 @@ func (a *AuthController) Register(c *fiber.Ctx) error {
 
 // @Tags         Auth
 // @Summary      Login
+// @Description  Once an email or an IP address failed CAPTCHA_LOGIN_FAILURES times within CAPTCHA_LOGIN_WINDOW, logins of it must pass the CAPTCHA: send the token of the widget in X-Captcha-Token.
 // @Accept       json
 // @Produce      json
-// @Param        request  body  validation.Login  true  "Request body"
+// @Param        request          body    validation.Login  true   "Request body"
+// @Param        X-Captcha-Token  header  string            false  "CAPTCHA token, after failed logins"
 // @Router       /auth/login [post]
 // @Success      200  {object}  example.LoginResponse
+// @Failure      400  {object}  response.ErrorResponse  "The CAPTCHA was rejected"
 // @Failure      401  {object}  example.FailedLogin  "Invalid email or password"
+// @Failure      428  {object}  response.ErrorResponse  "The CAPTCHA token is missing"
 func (a *AuthController) Login(c *fiber.Ctx) error {
 	req := new(validation.Login)
 
@@ -169,10 +189,13 @@ func (a *AuthController) RefreshTokens(c *fiber.Ctx) error {
 // @Description  An email will be sent to reset password.
 // @Accept       json
 // @Produce      json
-// @Param        request  body  validation.ForgotPassword  true  "Request body"
+// @Param        request          body    validation.ForgotPassword  true   "Request body"
+// @Param        X-Captcha-Token  header  string                     false  "CAPTCHA token, required when CAPTCHA_PROVIDER is set"
 // @Router       /auth/forgot-password [post]
 // @Success      200  {object}  example.ForgotPasswordResponse
+// @Failure      400  {object}  response.ErrorResponse  "The CAPTCHA was rejected"
 // @Failure      404  {object}  example.NotFound  "Not found"
+// @Failure      428  {object}  response.ErrorResponse  "The CAPTCHA token is missing"
 func (a *AuthController) ForgotPassword(c *fiber.Ctx) error {
 	req := new(validation.ForgotPassword)
 
@@ -282,6 +305,7 @@ func (a *AuthController) VerifyEmail(c *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Param        id_token   query  string  true  "Google ID Token"
+// @Param        source     query  string  false  "Campaign or channel a new user signed up from"
 // @Router       /auth/google [get]
 // @Success      200  {object}  example.GoogleLoginResponse
 func (a *AuthController) Google(c *fiber.Ctx) error {
@@ -316,6 +340,8 @@ func (a *AuthController) Google(c *fiber.Ctx) error {
 		Name:           claims.Name,
 		ProfilePicture: claims.ProfilePicture,
 		GoogleIDToken:  idToken,
+		// Kept only when the Google account signs up
+		AcquisitionSource: c.Query("source"),
 	}
 
 	user, err := a.UserService.CreateGoogleUser(c, googleUser)
@@ -11,17 +11,19 @@ import (
 
 type BahanMakananController struct {
 	BahanMakananService service.BahanMakananService
+	FoodGradeService    service.FoodGradeService
 }
 
-func NewBahanMakananController(service service.BahanMakananService) *BahanMakananController {
+func NewBahanMakananController(service service.BahanMakananService, foodGradeService service.FoodGradeService) *BahanMakananController {
 	return &BahanMakananController{
 		BahanMakananService: service,
+		FoodGradeService:    foodGradeService,
 	}
 }
 
 // @Tags         BahanMakanan
 // @Summary      Get all bahan makanan
-// @Description  Get all bahan makanan
+// @Description  Get all bahan makanan. Every food has its health grade, A to E per 100 g with traffic lights for the nutrients to limit.
 // @Security     BearerAuth
 // @Produce      json
 // @Router       /bahan-makanan [get]
@@ -31,6 +33,7 @@ func (c *BahanMakananController) GetAllBahanMakanan(ctx *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
+	c.FoodGradeService.GradeFoods(ctx.UserContext(), bahanMakananList)
 
 	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithBahanMakananList{
 		Status:  "success",
@@ -60,6 +63,7 @@ func (c *BahanMakananController) GetBahanMakananByKode(ctx *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
+	c.FoodGradeService.GradeFood(ctx.UserContext(), bahanMakanan)
 
 	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithBahanMakanan{
 		Status:  "success",
@@ -90,6 +94,7 @@ func (c *BahanMakananController) GetBahanMakananById(ctx *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
+	c.FoodGradeService.GradeFood(ctx.UserContext(), bahanMakanan)
 
 	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithBahanMakanan{
 		Status:  "success",
@@ -119,6 +124,7 @@ func (c *BahanMakananController) GetBahanMakananByMentahOlahan(ctx *fiber.Ctx) e
 	if err != nil {
 		return err
 	}
+	c.FoodGradeService.GradeFoods(ctx.UserContext(), bahanMakananList)
 
 	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithBahanMakananList{
 		Status:  "success",
@@ -148,6 +154,7 @@ func (c *BahanMakananController) GetBahanMakananByKelompok(ctx *fiber.Ctx) error
 	if err != nil {
 		return err
 	}
+	c.FoodGradeService.GradeFoods(ctx.UserContext(), bahanMakananList)
 
 	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithBahanMakananList{
 		Status:  "success",
@@ -189,6 +196,7 @@ func (c *BahanMakananController) UpdateBahanMakanan(ctx *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
+	c.FoodGradeService.GradeFood(ctx.UserContext(), bahanMakanan)
 
 	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithBahanMakanan{
 		Status:  "success",
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CheckoutController struct {
+	CheckoutService service.CheckoutService
+}
+
+func NewCheckoutController(checkoutService service.CheckoutService) *CheckoutController {
+	return &CheckoutController{
+		CheckoutService: checkoutService,
+	}
+}
+
+// @Tags         Checkout
+// @Summary      Create checkout session
+// @Description  Prices a plan with an optional coupon and returns a shareable payment link that expires
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.CreateCheckout  true  "Checkout data"
+// @Param        Idempotency-Key  header  string  false  "Key to retry the request with safely, its response is returned to retries"
+// @Router       /checkout [post]
+// @Success      201  {object}  response.SuccessWithCheckoutSession
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse
+func (c *CheckoutController) CreateCheckout(ctx *fiber.Ctx) error {
+	req := new(validation.CreateCheckout)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	session, err := c.CheckoutService.CreateSession(ctx, user.ID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     user.ID.String(),
+		Action:     "create_checkout",
+		Resource:   "checkout",
+		ResourceID: session.ID.String(),
+		Details: map[string]interface{}{
+			"plan_id":     session.PlanID.String(),
+			"coupon_code": session.CouponCode,
+			"total":       session.Total,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusCreated,
+	})
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithCheckoutSession{
+		Status:  "success",
+		Message: "Checkout session created successfully",
+		Data:    *session,
+	})
+}
+
+// @Tags         Checkout
+// @Summary      Get checkout session
+// @Description  Returns the plan and totals behind a shareable payment link
+// @Produce      json
+// @Param        token  path  string  true  "Checkout token"
+// @Router       /checkout/{token} [get]
+// @Success      200  {object}  response.SuccessWithCheckoutSession
+// @Failure      404  {object}  response.ErrorResponse
+func (c *CheckoutController) GetCheckout(ctx *fiber.Ctx) error {
+	session, err := c.CheckoutService.GetSession(ctx, ctx.Params("token"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithCheckoutSession{
+		Status:  "success",
+		Message: "Checkout session retrieved successfully",
+		Data:    *session,
+	})
+}
+
+// @Tags         Checkout
+// @Summary      Pay checkout session
+// @Description  Starts the gateway payment of a checkout session, anyone holding the link can pay until it expires
+// @Produce      json
+// @Param        token  path  string  true  "Checkout token"
+// @Param        Idempotency-Key  header  string  false  "Key to retry the request with safely, its response is returned to retries"
+// @Router       /checkout/{token}/pay [post]
+// @Success      200  {object}  response.PaymentResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+// @Failure      410  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse
+func (c *CheckoutController) PayCheckout(ctx *fiber.Ctx) error {
+	payment, err := c.CheckoutService.PaySession(ctx, ctx.Params("token"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.PaymentResponse{
+		Status:  "success",
+		Message: "Payment initiated successfully",
+		Data:    payment,
+	})
+}
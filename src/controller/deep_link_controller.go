@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DeepLinkController struct {
+	DeepLinkService service.DeepLinkService
+}
+
+func NewDeepLinkController(deepLinkService service.DeepLinkService) *DeepLinkController {
+	return &DeepLinkController{
+		DeepLinkService: deepLinkService,
+	}
+}
+
+// @Tags         Deep Links
+// @Summary      Open tracked link
+// @Description  Records the click on a link sent in a notification and redirects to its screen under the app's link domain. Invalid or expired links redirect to the app home.
+// @Param        token  path  string  true  "Signed link"
+// @Router       /links/{token} [get]
+// @Success      302
+func (d *DeepLinkController) Open(c *fiber.Ctx) error {
+	return c.Redirect(d.DeepLinkService.Open(c, c.Params("token")), fiber.StatusFound)
+}
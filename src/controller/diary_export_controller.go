@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type DiaryExportController struct {
+	DiaryExportService service.DiaryExportService
+}
+
+func NewDiaryExportController(diaryExportService service.DiaryExportService) *DiaryExportController {
+	return &DiaryExportController{
+		DiaryExportService: diaryExportService,
+	}
+}
+
+// @Tags         Diary
+// @Summary      Export my food diary
+// @Description  Exports the meals logged from one day to another, both inclusive, as CSV or as a PDF to hand to a doctor or dietitian. Ranges up to DIARY_EXPORT_SYNC_DAYS days (31 by default) answer with the file. Longer ranges are produced in the background and answer 202 with the export: poll it until it is completed, then open its download_url, which works once and for a few minutes. Exports can be downloaded for a week.
+// @Security     BearerAuth
+// @Produce      text/csv,application/pdf,json
+// @Param        from    query  string  true  "First day, YYYY-MM-DD"
+// @Param        to      query  string  true  "Last day, YYYY-MM-DD"
+// @Param        format  query  string  true  "File format"  Enums(csv, pdf)
+// @Router       /users/me/diary/export [get]
+// @Success      200  {file}    file  "The export"
+// @Success      202  {object}  response.SuccessWithDiaryExport
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse  "An export is already in progress"
+func (c *DiaryExportController) Export(ctx *fiber.Ctx) error {
+	query := new(validation.DiaryExportQuery)
+	if err := ctx.QueryParser(query); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	export, err := c.DiaryExportService.Export(ctx, user, query)
+	if err != nil {
+		return err
+	}
+
+	if export.Status == model.DiaryExportCompleted {
+		return sendDiaryExport(ctx, export)
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(response.SuccessWithDiaryExport{
+		Status:  "success",
+		Message: "Export queued, it will be ready in a few minutes",
+		Data:    *export,
+	})
+}
+
+// @Tags         Diary
+// @Summary      Get my diary export
+// @Description  Returns the status of an export. Once it is completed, every read issues a new download_url that works once until download_expires_at.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path  string  true  "Export ID"
+// @Router       /users/me/diary/exports/{id} [get]
+// @Success      200  {object}  response.SuccessWithDiaryExport
+// @Failure      404  {object}  response.ErrorResponse
+func (c *DiaryExportController) GetExport(ctx *fiber.Ctx) error {
+	exportID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid export ID format")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	export, err := c.DiaryExportService.GetExport(ctx, user.ID, exportID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithDiaryExport{
+		Status:  "success",
+		Message: "Export retrieved successfully",
+		Data:    *export,
+	})
+}
+
+// @Tags         Diary
+// @Summary      Download my diary export
+// @Description  Downloads a completed export with the token of the user, for apps that fetch it themselves. Browsers and other people open the one-time download_url instead.
+// @Security     BearerAuth
+// @Produce      text/csv,application/pdf,json
+// @Param        id   path  string  true  "Export ID"
+// @Router       /users/me/diary/exports/{id}/download [get]
+// @Success      200  {file}    file  "The export"
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse  "Export not ready or failed"
+// @Failure      410  {object}  response.ErrorResponse  "Export expired"
+func (c *DiaryExportController) Download(ctx *fiber.Ctx) error {
+	exportID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid export ID format")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	export, err := c.DiaryExportService.Download(ctx, user.ID, exportID)
+	if err != nil {
+		return err
+	}
+
+	return sendDiaryExport(ctx, export)
+}
+
+// @Tags         Diary
+// @Summary      Get the downloads of my diary export
+// @Description  Returns every attempt to download the export through one of its links, newest first, with the address and browser it came from. Attempts through a used or expired link are listed as refused, and attempts while the export could not be served as unavailable. Downloads with the token of the user are not listed.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path  string  true  "Export ID"
+// @Router       /users/me/diary/exports/{id}/downloads [get]
+// @Success      200  {object}  response.SuccessWithDownloadAttempts
+// @Failure      404  {object}  response.ErrorResponse
+func (c *DiaryExportController) GetDownloads(ctx *fiber.Ctx) error {
+	exportID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid export ID format")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	attempts, err := c.DiaryExportService.GetDownloads(ctx, user.ID, exportID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithDownloadAttempts{
+		Status:  "success",
+		Message: "Downloads retrieved successfully",
+		Data:    attempts,
+	})
+}
+
+func sendDiaryExport(ctx *fiber.Ctx, export *model.DiaryExport) error {
+	ctx.Set(fiber.HeaderContentType, export.ContentType())
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", export.Filename()))
+	ctx.Set(fiber.HeaderCacheControl, "private, no-store")
+	return ctx.Status(fiber.StatusOK).Send(export.Content)
+}
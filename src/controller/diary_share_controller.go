@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type DiaryShareController struct {
+	DiaryShareService service.DiaryShareService
+}
+
+func NewDiaryShareController(diaryShareService service.DiaryShareService) *DiaryShareController {
+	return &DiaryShareController{
+		DiaryShareService: diaryShareService,
+	}
+}
+
+// @Tags         Diary
+// @Summary      Share my diary with a doctor
+// @Description  Opens a read-only link to the diary, the vitals (recorded weights and heights) or both over a date range, for a doctor or dietitian who has no account. The diary needs coach data sharing summary or full in the privacy settings, the vitals full; with summary the link shows daily totals without the meals. The link is returned only once, it stops working when it expires or is revoked.
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.CreateDiaryShare  true  "What to share and for how long"
+// @Router       /users/me/diary/shares [post]
+// @Success      201  {object}  response.SuccessWithDiaryShare
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse  "Not shared by the privacy settings"
+// @Failure      409  {object}  response.ErrorResponse  "Too many open links"
+func (c *DiaryShareController) CreateShare(ctx *fiber.Ctx) error {
+	req := new(validation.CreateDiaryShare)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	share, err := c.DiaryShareService.CreateShare(ctx, user.ID, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithDiaryShare{
+		Status:  "success",
+		Message: "Share link created successfully",
+		Data:    *share,
+	})
+}
+
+// @Tags         Diary
+// @Summary      Get my diary share links
+// @Description  Returns the share links of the logged in user with how often they were opened, newest first
+// @Security     BearerAuth
+// @Produce      json
+// @Router       /users/me/diary/shares [get]
+// @Success      200  {object}  response.SuccessWithDiaryShares
+func (c *DiaryShareController) GetShares(ctx *fiber.Ctx) error {
+	user := ctx.Locals("user").(*model.User)
+
+	shares, err := c.DiaryShareService.GetShares(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithDiaryShares{
+		Status:  "success",
+		Message: "Share links retrieved successfully",
+		Data:    shares,
+	})
+}
+
+// @Tags         Diary
+// @Summary      Revoke a diary share link
+// @Description  Closes a share link at once
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path  string  true  "Share ID"
+// @Router       /users/me/diary/shares/{id} [delete]
+// @Success      200  {object}  response.SuccessWithDiaryShare
+// @Failure      404  {object}  response.ErrorResponse
+func (c *DiaryShareController) RevokeShare(ctx *fiber.Ctx) error {
+	shareID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid share ID format")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	share, err := c.DiaryShareService.RevokeShare(ctx, user.ID, shareID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithDiaryShare{
+		Status:  "success",
+		Message: "Share link revoked successfully",
+		Data:    *share,
+	})
+}
+
+// @Tags         Diary
+// @Summary      View a shared diary
+// @Description  Returns the diary and vitals a share link shows, for the read-only page the link opens, as much as the privacy settings of the user share at the time. The link is the only credential. Revoked, expired and unknown links answer 404.
+// @Produce      json
+// @Param        token  query  string  true  "Token of the share link"
+// @Router       /shared/diary [get]
+// @Success      200  {object}  response.SuccessWithSharedDiary
+// @Failure      404  {object}  response.ErrorResponse
+func (c *DiaryShareController) ViewShare(ctx *fiber.Ctx) error {
+	req := &validation.Token{Token: ctx.Query("token")}
+
+	shared, err := c.DiaryShareService.ViewShare(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	// Health data, neither the browser nor a proxy keeps it once the link is closed
+	ctx.Set(fiber.HeaderCacheControl, "private, no-store")
+	ctx.Set("X-Robots-Tag", "noindex")
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithSharedDiary{
+		Status:  "success",
+		Message: "Shared diary retrieved successfully",
+		Data:    *shared,
+	})
+}
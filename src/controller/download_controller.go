@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DownloadController struct {
+	DownloadLinkService service.DownloadLinkService
+	DiaryExportService  service.DiaryExportService
+}
+
+func NewDownloadController(
+	downloadLinkService service.DownloadLinkService, diaryExportService service.DiaryExportService,
+) *DownloadController {
+	return &DownloadController{
+		DownloadLinkService: downloadLinkService,
+		DiaryExportService:  diaryExportService,
+	}
+}
+
+// @Tags         Downloads
+// @Summary      Download a file through a link
+// @Description  Downloads the file of a download_url, such as the one of a completed diary export. The link is the only credential and works once, within DOWNLOAD_LINK_TTL (5 minutes by default) of being issued: read the export again for a new one. A link is only used up once its file is served, a link of an export that failed or expired stays unused. Every attempt is audited.
+// @Produce      text/csv,application/pdf,json
+// @Param        token  query  string  true  "Token of the download link"
+// @Router       /downloads [get]
+// @Success      200  {file}    file  "The file"
+// @Failure      404  {object}  response.ErrorResponse  "Invalid link"
+// @Failure      409  {object}  response.ErrorResponse  "Export failed"
+// @Failure      410  {object}  response.ErrorResponse  "Link already used or expired, or export expired"
+func (c *DownloadController) Download(ctx *fiber.Ctx) error {
+	req := &validation.Token{Token: ctx.Query("token")}
+
+	// The file is read before the link is used up, and sent once it is
+	var export *model.DiaryExport
+	if err := c.DownloadLinkService.Redeem(ctx, req, func(link *model.DownloadLink) (err error) {
+		switch link.Kind {
+		case model.DownloadDiaryExport:
+			export, err = c.DiaryExportService.Download(ctx, link.UserID, link.ResourceID)
+			return err
+		}
+		return fiber.NewError(fiber.StatusNotFound, "This download link is invalid")
+	}); err != nil {
+		return err
+	}
+
+	return sendDiaryExport(ctx, export)
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"app/src/response"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type EmailController struct {
+	EmailSuppressionService service.EmailSuppressionService
+}
+
+func NewEmailController(emailSuppressionService service.EmailSuppressionService) *EmailController {
+	return &EmailController{
+		EmailSuppressionService: emailSuppressionService,
+	}
+}
+
+// @Tags         Email
+// @Summary      SES bounce and complaint webhook
+// @Description  Takes the SNS messages of SES_NOTIFICATION_TOPIC_ARN, signed by SNS. Confirms the subscription of the topic, and suppresses the addresses that bounced for good or whose owner complained: no email is sent to them and their users are flagged with email_undeliverable_at, so the app asks them for another email. Transient bounces are only logged.
+// @Accept       plain
+// @Produce      json
+// @Router       /email/ses/notifications [post]
+// @Success      200  {object}  response.Common
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (e *EmailController) HandleSESNotification(c *fiber.Ctx) error {
+	// SNS posts JSON as text/plain, the body is read as it is
+	if err := e.EmailSuppressionService.HandleSESNotification(c, c.Body()); err != nil {
+		return err
+	}
+
+	return c.JSON(response.Common{
+		Status:  "success",
+		Message: "Notification processed successfully",
+	})
+}
@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FoodAlternativeController struct {
+	FoodAlternativeService service.FoodAlternativeService
+}
+
+func NewFoodAlternativeController(foodAlternativeService service.FoodAlternativeService) *FoodAlternativeController {
+	return &FoodAlternativeController{
+		FoodAlternativeService: foodAlternativeService,
+	}
+}
+
+// @Tags         BahanMakanan
+// @Summary      Get healthier alternatives to a food
+// @Description  Suggests foods of the same group and preparation with less energy or carbohydrate, the catalog has no sugar values. They are ranked by the goal of the user (from their weight target: less energy to lose weight, more protein to gain), their dietary restrictions and the feedback of users. Alternatives the user found unhelpful are left out.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id     path   int  true   "ID Bahan Makanan"
+// @Param        limit  query  int  false  "Maximum number of alternatives"  default(10)
+// @Router       /foods/{id}/alternatives [get]
+// @Success      200  {object}  response.SuccessWithFoodAlternatives
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *FoodAlternativeController) GetAlternatives(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid ID parameter")
+	}
+
+	query := &validation.FoodAlternativeQuery{
+		Limit: ctx.QueryInt("limit", 10),
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	alternatives, err := c.FoodAlternativeService.GetAlternatives(ctx, user.ID, uint32(id), query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithFoodAlternatives{
+		Status:  "success",
+		Message: "Food alternatives fetched successfully",
+		Data:    alternatives,
+	})
+}
+
+// @Tags         BahanMakanan
+// @Summary      Send feedback on an alternative
+// @Description  Records whether the user found an alternative to a food helpful, replacing their earlier feedback on it
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path  int                                 true  "ID Bahan Makanan"
+// @Param        request  body  validation.FoodAlternativeFeedback  true  "Feedback"
+// @Router       /foods/{id}/alternatives/feedback [post]
+// @Success      200  {object}  response.SuccessWithFoodAlternativeFeedback
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *FoodAlternativeController) SendFeedback(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid ID parameter")
+	}
+
+	req := new(validation.FoodAlternativeFeedback)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	feedback, err := c.FoodAlternativeService.SendFeedback(ctx, user.ID, uint32(id), req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithFoodAlternativeFeedback{
+		Status:  "success",
+		Message: "Feedback sent successfully",
+		Data:    *feedback,
+	})
+}
+
+// @Tags         BahanMakanan
+// @Summary      Get dietary restrictions
+// @Description  Returns the dietary restrictions of the user, alternatives leave out the food groups they exclude
+// @Security     BearerAuth
+// @Produce      json
+// @Router       /foods/diet-preferences [get]
+// @Success      200  {object}  response.SuccessWithDietPreference
+func (c *FoodAlternativeController) GetDietPreference(ctx *fiber.Ctx) error {
+	user := ctx.Locals("user").(*model.User)
+
+	preference, err := c.FoodAlternativeService.GetDietPreference(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithDietPreference{
+		Status:       "success",
+		Message:      "Diet preferences fetched successfully",
+		Data:         *preference,
+		Restrictions: model.DietaryRestrictions,
+	})
+}
+
+// @Tags         BahanMakanan
+// @Summary      Update dietary restrictions
+// @Description  Replaces the dietary restrictions of the user
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.UpdateDietPreference  true  "Restrictions"
+// @Router       /foods/diet-preferences [put]
+// @Success      200  {object}  response.SuccessWithDietPreference
+// @Failure      400  {object}  response.ErrorResponse
+func (c *FoodAlternativeController) UpdateDietPreference(ctx *fiber.Ctx) error {
+	req := new(validation.UpdateDietPreference)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	preference, err := c.FoodAlternativeService.UpdateDietPreference(ctx, user.ID, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithDietPreference{
+		Status:       "success",
+		Message:      "Diet preferences updated successfully",
+		Data:         *preference,
+		Restrictions: model.DietaryRestrictions,
+	})
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FoodComparisonController struct {
+	FoodComparisonService service.FoodComparisonService
+}
+
+func NewFoodComparisonController(foodComparisonService service.FoodComparisonService) *FoodComparisonController {
+	return &FoodComparisonController{
+		FoodComparisonService: foodComparisonService,
+	}
+}
+
+// @Tags         BahanMakanan
+// @Summary      Compare foods
+// @Description  Compares the nutrients of 2 to 4 foods per 100 g and per serving (the first household serving size of a food, else 100 g), names the food with the healthiest amount of each nutrient and suggests healthier foods of the same group. The health score adds the share of the daily value of nutrients to encourage and subtracts that of nutrients to limit.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        ids  query  string  true  "Comma separated IDs of 2 to 4 foods"  example(12,34)
+// @Router       /foods/compare [get]
+// @Success      200  {object}  response.SuccessWithFoodComparison
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *FoodComparisonController) Compare(ctx *fiber.Ctx) error {
+	query := &validation.FoodCompareQuery{
+		IDs: ctx.Query("ids"),
+	}
+
+	comparison, err := c.FoodComparisonService.Compare(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithFoodComparison{
+		Status:  "success",
+		Message: "Foods compared successfully",
+		Data:    *comparison,
+	})
+}
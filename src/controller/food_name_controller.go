@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FoodNameController struct {
+	FoodNameService service.FoodNameService
+}
+
+func NewFoodNameController(foodNameService service.FoodNameService) *FoodNameController {
+	return &FoodNameController{
+		FoodNameService: foodNameService,
+	}
+}
+
+// @Tags         BahanMakanan
+// @Summary      Search bahan makanan
+// @Description  Searches foods by their Indonesian and English names and synonyms, so "telur" and "egg" find the same food, and tolerates typos ("telr"). Matches in the language of the search rank first, then foods logged often and those the user logged before. Results are named in that language, or in Indonesian when a food has no name in it. The language is lang, else the Accept-Language header, else Indonesian. Send search_id when logging the food picked.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        q      query  string  true   "Search"
+// @Param        lang   query  string  false  "Language"  Enums(id, en)
+// @Param        limit  query  int     false  "Maximum number of foods"  default(20)
+// @Router       /bahan-makanan/search [get]
+// @Success      200  {object}  response.SuccessWithFoodSearchResults
+// @Failure      400  {object}  response.ErrorResponse
+func (c *FoodNameController) Search(ctx *fiber.Ctx) error {
+	query := &validation.FoodSearchQuery{
+		Q:     ctx.Query("q"),
+		Lang:  ctx.Query("lang"),
+		Limit: ctx.QueryInt("limit", 20),
+	}
+	if query.Lang == "" {
+		query.Lang = ctx.AcceptsLanguages(model.FoodLanguages...)
+	}
+	if !model.IsFoodLanguage(query.Lang) {
+		query.Lang = model.FoodLanguageIndonesian
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	results, searchID, err := c.FoodNameService.Search(ctx, user.ID, query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithFoodSearchResults{
+		Status:   "success",
+		Message:  "Bahan makanan searched successfully",
+		SearchID: searchID,
+		Data:     results,
+	})
+}
+
+// @Tags         BahanMakanan
+// @Summary      Log bahan makanan
+// @Description  Counts a food the user picked. Foods logged often rank higher in search, for the user above all. search_id marks the food as the choice of that search. A portion in grams, ml or a household measure (quantity and unit) is converted to grams and offered again next time.
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        kode     path  string              true   "Kode Bahan Makanan"
+// @Param        request  body  validation.LogFood  false  "Search and portion"
+// @Router       /bahan-makanan/kode/{kode}/log [post]
+// @Success      200  {object}  response.SuccessWithFoodLog
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *FoodNameController) LogFood(ctx *fiber.Ctx) error {
+	req := new(validation.LogFood)
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+
+	user := ctx.Locals("user").(*model.User)
+
+	log, err := c.FoodNameService.LogFood(ctx, user.ID, ctx.Params("kode"), req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithFoodLog{
+		Status:  "success",
+		Message: "Bahan makanan logged successfully",
+		Data:    *log,
+	})
+}
+
+// @Tags         BahanMakanan
+// @Summary      Get names of bahan makanan
+// @Description  Lists the localized names and synonyms of a food
+// @Security     BearerAuth
+// @Produce      json
+// @Param        kode  path  string  true  "Kode Bahan Makanan"
+// @Router       /bahan-makanan/kode/{kode}/names [get]
+// @Success      200  {object}  response.SuccessWithFoodNames
+// @Failure      404  {object}  response.ErrorResponse
+func (c *FoodNameController) GetNames(ctx *fiber.Ctx) error {
+	names, err := c.FoodNameService.GetNames(ctx, ctx.Params("kode"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithFoodNames{
+		Status:  "success",
+		Message: "Bahan makanan names fetched successfully",
+		Data:    names,
+	})
+}
+
+// @Tags         BahanMakanan
+// @Summary      Set names of bahan makanan
+// @Description  Replaces the localized names and synonyms of a food. The primary name of a language is the one shown, a language has at most one. The Indonesian name of the food composition table is always searched.
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        kode     path  string                   true  "Kode Bahan Makanan"
+// @Param        request  body  validation.SetFoodNames  true  "Names"
+// @Router       /bahan-makanan/kode/{kode}/names [put]
+// @Success      200  {object}  response.SuccessWithFoodNames
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *FoodNameController) SetNames(ctx *fiber.Ctx) error {
+	req := new(validation.SetFoodNames)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	kode := ctx.Params("kode")
+	names, err := c.FoodNameService.SetNames(ctx, kode, req)
+	if err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "set_food_names",
+		Resource:   "bahan_makanan",
+		ResourceID: kode,
+		Details: map[string]interface{}{
+			"names": req.Names,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithFoodNames{
+		Status:  "success",
+		Message: "Bahan makanan names updated successfully",
+		Data:    names,
+	})
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FoodPortionController struct {
+	FoodPortionService service.FoodPortionService
+}
+
+func NewFoodPortionController(foodPortionService service.FoodPortionService) *FoodPortionController {
+	return &FoodPortionController{
+		FoodPortionService: foodPortionService,
+	}
+}
+
+// @Tags         BahanMakanan
+// @Summary      Get portion units
+// @Description  Lists the units foods can be logged in: grams, milliliters and household measures. Sizes are grams for a mass and milliliters for a volume, counts like piring and potong have a size per food.
+// @Security     BearerAuth
+// @Produce      json
+// @Router       /bahan-makanan/units [get]
+// @Success      200  {object}  response.SuccessWithPortionUnits
+func (c *FoodPortionController) GetUnits(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPortionUnits{
+		Status:  "success",
+		Message: "Portion units fetched successfully",
+		Data:    model.PortionUnits,
+	})
+}
+
+// @Tags         BahanMakanan
+// @Summary      Get portions of bahan makanan
+// @Description  Lists the units a food can be logged in with the weight of one unit. Volumes use the density of the food, or of water when it has none (approximate). Counts are listed when the food has a serving size for them.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        kode  path  string  true  "Kode Bahan Makanan"
+// @Router       /bahan-makanan/kode/{kode}/portions [get]
+// @Success      200  {object}  response.SuccessWithPortionOptions
+// @Failure      404  {object}  response.ErrorResponse
+func (c *FoodPortionController) GetPortions(ctx *fiber.Ctx) error {
+	options, err := c.FoodPortionService.GetPortions(ctx, ctx.Params("kode"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPortionOptions{
+		Status:  "success",
+		Message: "Bahan makanan portions fetched successfully",
+		Data:    options,
+	})
+}
+
+// @Tags         BahanMakanan
+// @Summary      Set portions of bahan makanan
+// @Description  Replaces the serving sizes of a food: the grams of one unit of a household measure, and for ml the grams per milliliter (density)
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        kode     path  string                      true  "Kode Bahan Makanan"
+// @Param        request  body  validation.SetFoodPortions  true  "Portions"
+// @Router       /bahan-makanan/kode/{kode}/portions [put]
+// @Success      200  {object}  response.SuccessWithPortionOptions
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *FoodPortionController) SetPortions(ctx *fiber.Ctx) error {
+	req := new(validation.SetFoodPortions)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	kode := ctx.Params("kode")
+	options, err := c.FoodPortionService.SetPortions(ctx, kode, req)
+	if err != nil {
+		return err
+	}
+
+	admin := ctx.Locals("user").(*model.User)
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     admin.ID.String(),
+		Action:     "set_food_portions",
+		Resource:   "bahan_makanan",
+		ResourceID: kode,
+		Details: map[string]interface{}{
+			"portions": req.Portions,
+		},
+		IPAddress:  ctx.IP(),
+		UserAgent:  ctx.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPortionOptions{
+		Status:  "success",
+		Message: "Bahan makanan portions updated successfully",
+		Data:    options,
+	})
+}
+
+// @Tags         BahanMakanan
+// @Summary      Convert a portion of bahan makanan
+// @Description  Converts a quantity of a food in a unit, e.g. 1.5 piring, to grams with the nutrition of that portion
+// @Security     BearerAuth
+// @Produce      json
+// @Param        kode      path   string  true  "Kode Bahan Makanan"
+// @Param        quantity  query  number  true  "Quantity"
+// @Param        unit      query  string  true  "Unit"  Enums(g, kg, ml, l, sendok_teh, sendok_makan, gelas, mangkok, centong, piring, potong, butir, buah, bungkus)
+// @Router       /bahan-makanan/kode/{kode}/convert [get]
+// @Success      200  {object}  response.SuccessWithPortionConversion
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *FoodPortionController) Convert(ctx *fiber.Ctx) error {
+	query := &validation.PortionQuery{
+		Quantity: ctx.QueryFloat("quantity"),
+		Unit:     ctx.Query("unit"),
+	}
+
+	conversion, err := c.FoodPortionService.Convert(ctx, ctx.Params("kode"), query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPortionConversion{
+		Status:  "success",
+		Message: "Bahan makanan portion converted successfully",
+		Data:    *conversion,
+	})
+}
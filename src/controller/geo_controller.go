@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"app/src/response"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type GeoController struct {
+	GeoService service.GeoService
+}
+
+func NewGeoController(geoService service.GeoService) *GeoController {
+	return &GeoController{
+		GeoService: geoService,
+	}
+}
+
+// @Tags         Public
+// @Summary      Regional defaults of the visitor
+// @Description  Returns the locale and currency the website and the app start with, from the country of the visitor's IP address. Visitors who cannot be located get those of Indonesia. Only the country is returned, the address and the location are not kept. Send the locale as lang to /public/plans.
+// @Produce      json
+// @Router       /public/locale [get]
+// @Success      200  {object}  response.SuccessWithRegionalDefaults
+// @Failure      429  {object}  response.ErrorResponse
+func (g *GeoController) GetDefaults(c *fiber.Ctx) error {
+	defaults := g.GeoService.Defaults(c)
+
+	// The answer depends on the address, shared caches must not keep it
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithRegionalDefaults{
+		Status:  "success",
+		Message: "Regional defaults retrieved successfully",
+		Data:    defaults,
+	})
+}
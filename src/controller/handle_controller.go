@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HandleController struct {
+	HandleService service.HandleService
+}
+
+func NewHandleController(handleService service.HandleService) *HandleController {
+	return &HandleController{
+		HandleService: handleService,
+	}
+}
+
+// @Tags         Users
+// @Summary      Check whether a handle is available
+// @Description  Tells whether the logged in user can take a public handle, and why not. Handles are 3 to 30 letters, digits and underscores starting with a letter, case-insensitive, a leading @ is ignored. Reserved words and profanity are refused. The own handle of the user counts as available.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        name  query  string  true  "Handle to check"
+// @Router       /users/handle-available [get]
+// @Success      200  {object}  response.SuccessWithHandleAvailability
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse
+func (h *HandleController) CheckAvailability(c *fiber.Ctx) error {
+	query := new(validation.HandleQuery)
+	if err := c.QueryParser(query); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	availability, err := h.HandleService.CheckAvailability(c, user.ID, query)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithHandleAvailability{
+		Status:  "success",
+		Message: "Handle availability checked successfully",
+		Data:    *availability,
+	})
+}
+
+// @Tags         Users
+// @Summary      Set my handle
+// @Description  Picks the public handle of the logged in user, shown on social features in place of the email. Renaming waits HANDLE_RENAME_COOLDOWN (30 days by default) after the last change.
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.UpdateHandle  true  "Handle"
+// @Router       /users/me/handle [put]
+// @Success      200  {object}  example.GetUserResponse
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse  "The handle is taken"
+// @Failure      429  {object}  response.ErrorResponse  "The cooldown since the last rename is not over"
+func (h *HandleController) SetHandle(c *fiber.Ctx) error {
+	req := new(validation.UpdateHandle)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	updated, err := h.HandleService.SetHandle(c, user.ID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithUser{
+		Status:  "success",
+		Message: "Handle updated successfully",
+		User:    *updated,
+	})
+}
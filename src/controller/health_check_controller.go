@@ -63,6 +63,14 @@ func (h *HealthCheckController) Check(c *fiber.Ctx) error {
 		h.addServiceStatus(&serviceList, "Memory", true, nil)
 	}
 
+	// Searches fall back to the database while the index is down, it does not make the app unhealthy
+	if enabled, err := h.HealthCheckService.SearchIndexCheck(c.UserContext()); err != nil {
+		errMsg := err.Error()
+		h.addServiceStatus(&serviceList, "SearchIndex", false, &errMsg)
+	} else if enabled {
+		h.addServiceStatus(&serviceList, "SearchIndex", true, nil)
+	}
+
 	// Return the response based on health check result
 	statusCode := fiber.StatusOK
 	status := "success"
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type IAPController struct {
+	IAPService service.IAPService
+}
+
+func NewIAPController(iapService service.IAPService) *IAPController {
+	return &IAPController{
+		IAPService: iapService,
+	}
+}
+
+// @Tags         In-App Purchase
+// @Summary      Verify App Store receipt
+// @Description  Validates an App Store receipt with Apple and activates the plan linked to the purchased product
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.VerifyAppleReceipt  true  "App receipt"
+// @Router       /iap/apple/verify [post]
+// @Success      200  {object}  response.SuccessWithSubscription
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (i *IAPController) VerifyAppleReceipt(c *fiber.Ctx) error {
+	req := new(validation.VerifyAppleReceipt)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	subscription, err := i.IAPService.VerifyAppleReceipt(c, user.ID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogSubscriptionPurchase(c, user.ID.String(), subscription.Plan.ID.String(), subscription.PaymentMethod)
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithSubscription{
+		Status:  "success",
+		Message: "Purchase verified successfully",
+		Data:    *subscription,
+	})
+}
+
+// @Tags         In-App Purchase
+// @Summary      Verify Google Play purchase
+// @Description  Validates a Google Play purchase token and activates the plan linked to the purchased product
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.VerifyGooglePurchase  true  "Purchase"
+// @Router       /iap/google/verify [post]
+// @Success      200  {object}  response.SuccessWithSubscription
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (i *IAPController) VerifyGooglePurchase(c *fiber.Ctx) error {
+	req := new(validation.VerifyGooglePurchase)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	subscription, err := i.IAPService.VerifyGooglePurchase(c, user.ID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogSubscriptionPurchase(c, user.ID.String(), subscription.Plan.ID.String(), subscription.PaymentMethod)
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithSubscription{
+		Status:  "success",
+		Message: "Purchase verified successfully",
+		Data:    *subscription,
+	})
+}
+
+// @Tags         In-App Purchase
+// @Summary      App Store server notification webhook
+// @Description  Handles App Store Server Notifications V2 for renewals, expirations and refunds
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.AppleNotification  true  "Signed notification"
+// @Router       /iap/apple/notifications [post]
+// @Success      200  {object}  response.Common
+func (i *IAPController) HandleAppleNotification(c *fiber.Ctx) error {
+	req := new(validation.AppleNotification)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := i.IAPService.HandleAppleNotification(c, req); err != nil {
+		return err
+	}
+
+	return c.JSON(response.Common{
+		Status:  "success",
+		Message: "Notification processed successfully",
+	})
+}
+
+// @Tags         In-App Purchase
+// @Summary      Google Play developer notification webhook
+// @Description  Handles Real-time Developer Notifications pushed by Pub/Sub for renewals, cancellations and refunds
+// @Accept       json
+// @Produce      json
+// @Param        token  query  string  true  "Notification token"
+// @Router       /iap/google/notifications [post]
+// @Success      200  {object}  response.Common
+func (i *IAPController) HandleGoogleNotification(c *fiber.Ctx) error {
+	if err := i.IAPService.HandleGoogleNotification(c, c.Query("token"), c.Body()); err != nil {
+		return err
+	}
+
+	return c.JSON(response.Common{
+		Status:  "success",
+		Message: "Notification processed successfully",
+	})
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type InstallmentController struct {
+	InstallmentService service.InstallmentService
+}
+
+func NewInstallmentController(installmentService service.InstallmentService) *InstallmentController {
+	return &InstallmentController{
+		InstallmentService: installmentService,
+	}
+}
+
+// @Tags         Subscription
+// @Summary      Get installment schedule
+// @Description  Returns the installment schedule of a subscription paid in monthly installments
+// @Security     BearerAuth
+// @Produce      json
+// @Param        subscriptionID  path  string  true  "Subscription ID"
+// @Router       /subscriptions/{subscriptionID}/installments [get]
+// @Success      200  {object}  response.SuccessWithInstallments
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (i *InstallmentController) GetInstallments(c *fiber.Ctx) error {
+	subscriptionID, err := uuid.Parse(c.Params("subscriptionID"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid subscription ID format")
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	installments, err := i.InstallmentService.GetInstallments(c, user.ID, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithInstallments{
+		Status:  "success",
+		Message: "Installment schedule retrieved successfully",
+		Data:    installments,
+	})
+}
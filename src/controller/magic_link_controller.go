@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MagicLinkController struct {
+	MagicLinkService service.MagicLinkService
+	TokenService     service.TokenService
+}
+
+func NewMagicLinkController(magicLinkService service.MagicLinkService, tokenService service.TokenService) *MagicLinkController {
+	return &MagicLinkController{
+		MagicLinkService: magicLinkService,
+		TokenService:     tokenService,
+	}
+}
+
+// @Tags         Auth
+// @Summary      Send a magic link
+// @Description  Emails a link that signs in without a password. The link opens {FRONTEND_URL}/magic-link?token=..., it works once, within MAGIC_LINK_TTL, and only with the device_code of the answer, which the device keeps to sign in. The answer is the same whether or not the email belongs to a user, links the limits of the user hold back or emails that cannot be sent are not reported. A user gets a new link at most every MAGIC_LINK_RESEND_INTERVAL and MAGIC_LINK_MAX_PER_HOUR times an hour, an IP address asks MAGIC_LINK_MAX_PER_IP_PER_HOUR times an hour.
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.RequestMagicLink  true  "Email"
+// @Router       /auth/magic-link [post]
+// @Success      200  {object}  response.MagicLinkRequested
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse  "The IP address asked too often"
+func (m *MagicLinkController) Request(c *fiber.Ctx) error {
+	req := new(validation.RequestMagicLink)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	deviceCode, err := m.MagicLinkService.Request(c, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.MagicLinkRequested{
+		Status:     "success",
+		Message:    "A login link was sent if the email belongs to an account",
+		DeviceCode: deviceCode,
+	})
+}
+
+// @Tags         Auth
+// @Summary      Login with a magic link
+// @Description  Signs in the user of the link with its token and the device_code the device got when it asked for the link. A link works once and verifies the email of the user.
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.MagicLinkLogin  true  "Token of the link and device code"
+// @Router       /auth/magic-link/login [post]
+// @Success      200  {object}  example.LoginResponse
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse  "Invalid, used or expired link"
+// @Failure      403  {object}  response.ErrorResponse  "The link was asked for from another device"
+func (m *MagicLinkController) Login(c *fiber.Ctx) error {
+	req := new(validation.MagicLinkLogin)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user, err := m.MagicLinkService.Login(c, req)
+	if err != nil {
+		utils.LogLogin(c, "", false)
+		return err
+	}
+
+	tokens, err := m.TokenService.GenerateAuthTokens(c, user)
+	if err != nil {
+		return err
+	}
+
+	utils.LogLogin(c, user.ID.String(), true)
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.SuccessWithTokens{
+			Status:  "success",
+			Message: "Login successfully",
+			User:    *user,
+			Tokens:  *tokens,
+		})
+}
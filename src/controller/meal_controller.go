@@ -1,10 +1,13 @@
 package controller
 
 import (
+	"app/src/bulkhead"
 	"app/src/model"
+	"app/src/pagination"
 	"app/src/response"
 	"app/src/service"
-	"math"
+	"app/src/validation"
+	"errors"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -28,7 +31,11 @@ func NewMealController(ms service.MealService) *MealController {
 // @Produce      json
 // @Param        image  formData  file      true  "Meal's image"
 // @Router       /meals/scan [post]
+// @Router       /scan/food [post]
 // @Success      200  {object}  example.MealScanResponse
+// @Header       200  {int}     X-Scans-Remaining  "AI scans left on the plan, absent when unlimited"
+// @Failure      429  {object}  response.ErrorResponse  "AI scan quota exhausted"
+// @Failure      503  {object}  response.ErrorResponse  "Food recognition is busy"
 func (mc *MealController) ScanMeal(c *fiber.Ctx) error {
 	file, err := c.FormFile("image")
 	if err != nil {
@@ -43,6 +50,10 @@ func (mc *MealController) ScanMeal(c *fiber.Ctx) error {
 
 	result, err := mc.MealService.ScanMeal(c, file, userData.ID)
 	if err != nil {
+		var busy *bulkhead.FullError
+		if errors.As(err, &busy) {
+			return err
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(response.Common{
 			Status:  "error",
 			Message: err.Error(),
@@ -70,20 +81,79 @@ func (mc *MealController) GetMeals(c *fiber.Ctx) error {
 
 	page := c.QueryInt("page", 1)
 	limit := c.QueryInt("limit", 10)
-	totalPages := int64(math.Ceil(float64(totalResults) / float64(limit)))
 
 	return c.Status(fiber.StatusOK).
-		JSON(response.SuccessWithPaginate[model.MealHistory]{
-			Status:       "success",
-			Message:      "Get user's meals successfully",
-			Results:      meals,
-			Page:         page,
-			Limit:        limit,
-			TotalPages:   totalPages,
-			TotalResults: totalResults,
+		JSON(pagination.PaginatedResponse[model.MealHistory]{
+			Status:     "success",
+			Message:    "Get user's meals successfully",
+			Results:    meals,
+			Pagination: pagination.Paginate(page, limit, totalResults),
 		})
 }
 
+// @Tags         Meals
+// @Summary      Get a user's scan history
+// @Description  Returns the AI scans of the user, latest first, with the recognized foods and the estimated nutrition. Scans stay listed when the meal they created is edited or deleted.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        page   query  int  false  "Page number"  default(1)
+// @Param        limit  query  int  false  "Maximum number of scans per page"  default(10)
+// @Router       /scan/history [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.ScanHistory]
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  example.Unauthorized  "Unauthorized"
+func (mc *MealController) GetScanHistory(c *fiber.Ctx) error {
+	query := &validation.ScanHistoryQuery{
+		Page:  c.QueryInt("page", 1),
+		Limit: c.QueryInt("limit", 10),
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	scans, totalResults, err := mc.MealService.GetScanHistory(c, user.ID, query)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.ScanHistory]{
+		Status:     "success",
+		Message:    "Get user's scan history successfully",
+		Results:    scans,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
+
+// @Tags         Meals
+// @Summary      Meal diary
+// @Description  Returns the user's meals of a day, or of the Monday to Sunday week holding the day, grouped per day in the order they were eaten. Daily totals and the total of the period are computed by the server.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        date    query  string  true   "Day (YYYY-MM-DD)"
+// @Param        period  query  string  false  "Period"  Enums(day, week)  default(day)
+// @Router       /meals/diary [get]
+// @Router       /food-logs [get]
+// @Success      200  {object}  response.SuccessWithMealDiary
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  example.Unauthorized  "Unauthorized"
+func (mc *MealController) GetDiary(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+	query := &validation.MealDiaryQuery{
+		Date:   c.Query("date"),
+		Period: c.Query("period"),
+	}
+
+	diary, err := mc.MealService.GetDiary(c, user.ID, query)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithMealDiary{
+		Status:  "success",
+		Message: "Meal diary retrieved successfully",
+		Data:    *diary,
+	})
+}
+
 // @Tags         Meals
 // @Summary      Get a meal
 // @Description  Logged in users can fetch only their own meal detail information. Only admins can fetch other user's meal.
@@ -91,6 +161,7 @@ func (mc *MealController) GetMeals(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        id  path  string  true  "Meal id"
 // @Router       /meals/{id} [get]
+// @Router       /food-logs/{id} [get]
 // @Success      200  {object}  example.GetMealResponse
 // @Failure      401  {object}  example.Unauthorized  "Unauthorized"
 // @Failure      403  {object}  example.Forbidden  "Forbidden"
@@ -196,6 +267,7 @@ func (mc *MealController) AddMealScanDetail(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        request  body      example.AddMealRequest  true  "Meal data"
 // @Router       /meals [post]
+// @Router       /food-logs [post]
 // @Success      201  {object}  example.AddMealResponse
 // @Failure      401  {object}  example.Unauthorized  "Unauthorized"
 func (mc *MealController) AddMeal(c *fiber.Ctx) error {
@@ -235,6 +307,7 @@ func (mc *MealController) AddMeal(c *fiber.Ctx) error {
 // @Param        id       path      string                  true  "Meal ID"
 // @Param        request  body      example.UpdateMealRequest  true  "Meal data"
 // @Router       /meals/{id} [put]
+// @Router       /food-logs/{id} [put]
 // @Success      200  {object}  example.UpdateMealResponse
 // @Failure      401  {object}  example.Unauthorized  "Unauthorized"
 // @Failure      403  {object}  example.Forbidden  "Forbidden"
@@ -273,6 +346,7 @@ func (mc *MealController) UpdateMeal(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        id  path  string  true  "Meal ID"
 // @Router       /meals/{id} [delete]
+// @Router       /food-logs/{id} [delete]
 // @Success      200  {object}  example.DeleteMealResponse
 // @Failure      401  {object}  example.Unauthorized  "Unauthorized"
 // @Failure      403  {object}  example.Forbidden  "Forbidden"
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ModerationController struct {
+	ModerationService service.ModerationService
+}
+
+func NewModerationController(moderationService service.ModerationService) *ModerationController {
+	return &ModerationController{
+		ModerationService: moderationService,
+	}
+}
+
+// @Tags         Users
+// @Summary      Block a user
+// @Description  Hides the user behind the handle and the logged in user from each other on social features. Blocking twice keeps the first block. Profiles the logged in user cannot see, and handles nobody has, come back with the handle alone.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        handle  path  string  true  "Handle"
+// @Router       /profiles/{handle}/block [post]
+// @Success      200  {object}  response.SuccessWithBlockedUser
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse
+func (m *ModerationController) Block(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+
+	blocked, err := m.ModerationService.Block(c, user.ID, c.Params("handle"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithBlockedUser{
+		Status:  "success",
+		Message: "User blocked successfully",
+		Data:    *blocked,
+	})
+}
+
+// @Tags         Users
+// @Summary      Unblock a user
+// @Description  Lifts a block of the logged in user
+// @Security     BearerAuth
+// @Produce      json
+// @Param        handle  path  string  true  "Handle"
+// @Router       /profiles/{handle}/block [delete]
+// @Success      200  {object}  response.Common
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (m *ModerationController) Unblock(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+
+	if err := m.ModerationService.Unblock(c, user.ID, c.Params("handle")); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "User unblocked successfully",
+	})
+}
+
+// @Tags         Users
+// @Summary      Get my blocked users
+// @Description  Lists the users the logged in user blocked, latest first. Profiles they cannot see show the handle alone.
+// @Security     BearerAuth
+// @Produce      json
+// @Router       /users/me/blocks [get]
+// @Success      200  {object}  response.SuccessWithBlockedUsers
+// @Failure      401  {object}  response.ErrorResponse
+func (m *ModerationController) GetBlocks(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+
+	blocks, err := m.ModerationService.GetBlocks(c, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithBlockedUsers{
+		Status:  "success",
+		Message: "Blocked users retrieved successfully",
+		Data:    blocks,
+	})
+}
+
+// @Tags         Users
+// @Summary      Report a user
+// @Description  Sends a report of the user behind the handle to the moderators. A user has one open report per reported user, reporting again while it is open keeps it, it can be filed again once a moderator resolved it. The answer is the same whether or not anyone has the handle.
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        handle   path  string                 true  "Handle"
+// @Param        request  body  validation.ReportUser  true  "Report"
+// @Router       /profiles/{handle}/report [post]
+// @Success      201  {object}  response.SuccessWithSentReport
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse
+func (m *ModerationController) Report(c *fiber.Ctx) error {
+	req := new(validation.ReportUser)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	report, err := m.ModerationService.Report(c, user.ID, c.Params("handle"), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response.SuccessWithSentReport{
+		Status:  "success",
+		Message: "Report sent, a moderator will review it",
+		Data:    *report,
+	})
+}
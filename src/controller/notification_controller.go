@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationController struct {
+	NotificationPreferenceService service.NotificationPreferenceService
+}
+
+func NewNotificationController(notificationPreferenceService service.NotificationPreferenceService) *NotificationController {
+	return &NotificationController{
+		NotificationPreferenceService: notificationPreferenceService,
+	}
+}
+
+// @Tags         Notifications
+// @Summary      Get my notification preferences
+// @Description  Returns every notification category with whether the logged in user receives it. Required categories cannot be turned off.
+// @Security     BearerAuth
+// @Produce      json
+// @Router       /notifications/preferences [get]
+// @Success      200  {object}  response.SuccessWithNotificationPreferences
+// @Failure      401  {object}  response.ErrorResponse
+func (n *NotificationController) GetPreferences(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+
+	preferences, err := n.NotificationPreferenceService.GetPreferences(c, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithNotificationPreferences{
+		Status:  "success",
+		Message: "Notification preferences retrieved successfully",
+		Data:    preferences,
+	})
+}
+
+// @Tags         Notifications
+// @Summary      Update my notification preferences
+// @Description  Subscribes or unsubscribes the logged in user from notification categories, categories left out are unchanged
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.UpdateNotificationPreferences  true  "Category to subscribed"
+// @Router       /notifications/preferences [put]
+// @Success      200  {object}  response.SuccessWithNotificationPreferences
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse
+func (n *NotificationController) UpdatePreferences(c *fiber.Ctx) error {
+	req := new(validation.UpdateNotificationPreferences)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	preferences, err := n.NotificationPreferenceService.UpdatePreferences(c, user.ID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithNotificationPreferences{
+		Status:  "success",
+		Message: "Notification preferences updated successfully",
+		Data:    preferences,
+	})
+}
+
+// @Tags         Notifications
+// @Summary      Unsubscribe
+// @Description  Unsubscribes from the category of the email the link came from, without signing in. Mail clients post here for one-click unsubscribes (RFC 8058) with the token in the query, the unsubscribe page may send it in the body instead.
+// @Accept       json
+// @Produce      json
+// @Param        token    query  string                  false  "Token of the unsubscribe link"
+// @Param        request  body   validation.Unsubscribe  false  "Token of the unsubscribe link"
+// @Router       /notifications/unsubscribe [post]
+// @Success      200  {object}  response.SuccessWithNotificationPreference
+// @Failure      400  {object}  response.ErrorResponse
+func (n *NotificationController) Unsubscribe(c *fiber.Ctx) error {
+	req := &validation.Unsubscribe{Token: c.Query("token")}
+	if req.Token == "" {
+		if err := c.BodyParser(req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+
+	preference, err := n.NotificationPreferenceService.Unsubscribe(c, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithNotificationPreference{
+		Status:  "success",
+		Message: "Unsubscribed successfully",
+		Data:    *preference,
+	})
+}
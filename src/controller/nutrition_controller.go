@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type NutritionController struct {
+	NutritionSummaryService service.NutritionSummaryService
+}
+
+func NewNutritionController(nutritionSummaryService service.NutritionSummaryService) *NutritionController {
+	return &NutritionController{
+		NutritionSummaryService: nutritionSummaryService,
+	}
+}
+
+// @Tags         Meals
+// @Summary      Nutrition report
+// @Description  Returns the user's nutrition per day over a date range, for weekly and monthly reports. Days without meals are zero, the daily average only counts days with meals. The range is limited to 366 days.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        from  query  string  true  "First day (YYYY-MM-DD)"
+// @Param        to    query  string  true  "Last day (YYYY-MM-DD)"
+// @Router       /meals/nutrition-report [get]
+// @Success      200  {object}  response.SuccessWithNutritionReport
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  example.Unauthorized  "Unauthorized"
+func (nc *NutritionController) GetNutritionReport(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+	query := &validation.NutritionReportQuery{
+		From: c.Query("from"),
+		To:   c.Query("to"),
+	}
+
+	report, err := nc.NutritionSummaryService.GetReport(c, user.ID, query)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithNutritionReport{
+		Status:  "success",
+		Message: "Nutrition report retrieved successfully",
+		Data:    *report,
+	})
+}
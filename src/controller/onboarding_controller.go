@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type OnboardingController struct {
+	OnboardingService service.OnboardingService
+}
+
+func NewOnboardingController(onboardingService service.OnboardingService) *OnboardingController {
+	return &OnboardingController{
+		OnboardingService: onboardingService,
+	}
+}
+
+// @Tags         Users
+// @Summary      Get my onboarding checklist
+// @Description  Returns the onboarding steps of the logged in user (profile_completed, first_scan, first_log, notification_permission) with when each one was completed, the first open step and the overall status (not_started, in_progress, completed). Steps complete as the user does them and are never undone.
+// @Security     BearerAuth
+// @Produce      json
+// @Router       /users/me/onboarding [get]
+// @Success      200  {object}  response.SuccessWithOnboardingChecklist
+// @Failure      401  {object}  response.ErrorResponse
+func (o *OnboardingController) GetOnboarding(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+
+	checklist, err := o.OnboardingService.GetOnboarding(c, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithOnboardingChecklist{
+		Status:  "success",
+		Message: "Onboarding retrieved successfully",
+		Data:    *checklist,
+	})
+}
+
+// @Tags         Users
+// @Summary      Report the notification permission
+// @Description  The app reports the answer of the logged in user to the notification permission prompt. Granting it completes the notification_permission step.
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.NotificationPermission  true  "Permission"
+// @Router       /users/me/onboarding/notification-permission [post]
+// @Success      200  {object}  response.SuccessWithOnboardingChecklist
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse
+func (o *OnboardingController) ReportNotificationPermission(c *fiber.Ctx) error {
+	req := new(validation.NotificationPermission)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	checklist, err := o.OnboardingService.ReportNotificationPermission(c, user.ID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithOnboardingChecklist{
+		Status:  "success",
+		Message: "Notification permission recorded successfully",
+		Data:    *checklist,
+	})
+}
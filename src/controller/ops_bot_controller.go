@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/notify"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+	"crypto/subtle"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type OpsBotController struct {
+	OpsBotService service.OpsBotService
+}
+
+func NewOpsBotController(opsBotService service.OpsBotService) *OpsBotController {
+	return &OpsBotController{
+		OpsBotService: opsBotService,
+	}
+}
+
+// @Tags         Ops Bot
+// @Summary      Slack slash command
+// @Description  Runs an ops command sent from Slack as the admin linked to the Slack user. Requests must carry a valid Slack signature.
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Router       /ops-bot/slack [post]
+// @Success      200  {object}  response.SlackCommandReply
+// @Failure      401  {object}  response.ErrorResponse
+func (o *OpsBotController) HandleSlackCommand(c *fiber.Ctx) error {
+	if !notify.VerifySlackSignature(
+		config.OpsBotSlackSigningSecret, c.Get("X-Slack-Request-Timestamp"), c.Get("X-Slack-Signature"), c.Body(), time.Now(),
+	) {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid Slack signature")
+	}
+
+	form, err := url.ParseQuery(string(c.Body()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	reply := o.OpsBotService.HandleCommand(c.UserContext(), model.OpsPlatformSlack, form.Get("user_id"), form.Get("text"))
+
+	return c.JSON(response.SlackCommandReply{
+		ResponseType: "ephemeral",
+		Text:         reply,
+	})
+}
+
+// @Tags         Ops Bot
+// @Summary      Telegram webhook
+// @Description  Runs an ops command sent to the Telegram bot in a private chat as the admin linked to the sender
+// @Accept       json
+// @Produce      json
+// @Param        X-Telegram-Bot-Api-Secret-Token  header  string  true  "Webhook secret token"
+// @Router       /ops-bot/telegram [post]
+// @Success      200  {object}  response.TelegramReply
+// @Failure      401  {object}  response.ErrorResponse
+func (o *OpsBotController) HandleTelegramUpdate(c *fiber.Ctx) error {
+	secret := config.OpsBotTelegramWebhookSecret
+	if secret == "" || subtle.ConstantTimeCompare([]byte(c.Get("X-Telegram-Bot-Api-Secret-Token")), []byte(secret)) != 1 {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid webhook secret")
+	}
+
+	update := new(validation.TelegramUpdate)
+	if err := c.BodyParser(update); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	// Replies can contain user details, so group chats are ignored
+	if update.Message == nil || update.Message.Chat.Type != "private" || update.Message.Text == "" {
+		return c.SendStatus(fiber.StatusOK)
+	}
+
+	reply := o.OpsBotService.HandleCommand(
+		c.UserContext(), model.OpsPlatformTelegram, strconv.FormatInt(update.Message.From.ID, 10), update.Message.Text,
+	)
+
+	return c.JSON(response.TelegramReply{
+		Method: "sendMessage",
+		ChatID: update.Message.Chat.ID,
+		Text:   reply,
+	})
+}
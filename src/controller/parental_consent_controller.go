@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ParentalConsentController struct {
+	ParentalConsentService service.ParentalConsentService
+}
+
+func NewParentalConsentController(parentalConsentService service.ParentalConsentService) *ParentalConsentController {
+	return &ParentalConsentController{
+		ParentalConsentService: parentalConsentService,
+	}
+}
+
+// @Tags         Users
+// @Summary      Get my parental consent status
+// @Description  Returns whether the logged in user needs the consent of a guardian (not_required, pending, granted) and the latest consent request. Users younger than the consent age cannot scan meals or pay until their guardian consents.
+// @Security     BearerAuth
+// @Produce      json
+// @Router       /users/me/parental-consent [get]
+// @Success      200  {object}  response.SuccessWithParentalConsentStatus
+// @Failure      401  {object}  response.ErrorResponse
+func (p *ParentalConsentController) GetStatus(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+
+	status, err := p.ParentalConsentService.GetStatus(c, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithParentalConsentStatus{
+		Status:  "success",
+		Message: "Parental consent status retrieved successfully",
+		Data:    *status,
+	})
+}
+
+// @Tags         Users
+// @Summary      Ask a guardian for consent
+// @Description  Emails a consent link to the guardian of the logged in under-age user. Links sent before stop working.
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.RequestParentalConsent  true  "Guardian"
+// @Router       /users/me/parental-consent [post]
+// @Success      201  {object}  response.SuccessWithParentalConsentRequest
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse  "Consent is not needed"
+func (p *ParentalConsentController) RequestConsent(c *fiber.Ctx) error {
+	req := new(validation.RequestParentalConsent)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	request, err := p.ParentalConsentService.RequestConsent(c, user, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response.SuccessWithParentalConsentRequest{
+		Status:  "success",
+		Message: "Consent request sent to the guardian",
+		Data:    *request,
+	})
+}
+
+// @Tags         Users
+// @Summary      Get a parental consent prompt
+// @Description  Returns what the guardian is asked to agree to, for the consent page the emailed link opens. The link is the only credential.
+// @Produce      json
+// @Param        token  query  string  true  "Token of the consent link"
+// @Router       /parental-consent [get]
+// @Success      200  {object}  response.SuccessWithParentalConsentPrompt
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse  "Already answered"
+func (p *ParentalConsentController) GetPrompt(c *fiber.Ctx) error {
+	req := &validation.Token{Token: c.Query("token")}
+
+	prompt, err := p.ParentalConsentService.GetPrompt(c, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithParentalConsentPrompt{
+		Status:  "success",
+		Message: "Parental consent prompt retrieved successfully",
+		Data:    *prompt,
+	})
+}
+
+// @Tags         Users
+// @Summary      Answer a parental consent request
+// @Description  Records the answer of the guardian with the consent artifact (consent text and version, guardian, IP address, user agent and time, with its SHA-256). Granting lifts the restrictions of the under-age user.
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.RespondParentalConsent  true  "Answer"
+// @Router       /parental-consent [post]
+// @Success      200  {object}  response.SuccessWithParentalConsentRequest
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse  "Already answered"
+func (p *ParentalConsentController) Respond(c *fiber.Ctx) error {
+	req := new(validation.RespondParentalConsent)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	request, err := p.ParentalConsentService.Respond(c, req)
+	if err != nil {
+		return err
+	}
+
+	// The consent artifact is stored with the request, the audit log points to it
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     request.UserID.String(),
+		Action:     "parental_consent_" + request.Status,
+		Resource:   "parental_consent_request",
+		ResourceID: request.ID.String(),
+		Details: map[string]interface{}{
+			"guardian_email":  request.GuardianEmail,
+			"artifact_sha256": request.ArtifactSHA256,
+		},
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithParentalConsentRequest{
+		Status:  "success",
+		Message: "Parental consent answer recorded",
+		Data:    *request,
+	})
+}
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PartnerConsentController struct {
+	PartnerService service.PartnerService
+}
+
+func NewPartnerConsentController(partnerService service.PartnerService) *PartnerConsentController {
+	return &PartnerConsentController{
+		PartnerService: partnerService,
+	}
+}
+
+// @Tags         Users
+// @Summary      Get my partners
+// @Description  Returns the partners (clinics, hospitals, employers) that enrolled the logged in user, with whether the user consented to them reading their health data
+// @Security     BearerAuth
+// @Produce      json
+// @Router       /users/me/partners [get]
+// @Success      200  {object}  response.SuccessWithPartnerMemberships
+func (p *PartnerConsentController) GetMemberships(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+
+	memberships, err := p.PartnerService.GetMemberships(c, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithPartnerMemberships{
+		Status:  "success",
+		Message: "Partners retrieved successfully",
+		Data:    memberships,
+	})
+}
+
+// @Tags         Users
+// @Summary      Get a code to be enrolled by a partner
+// @Description  Returns a code the logged in user hands a partner (a clinic, a hospital, an employer) so it can enroll them with their email. Partners cannot enroll a user without it. The code works once, until it expires, and asking again replaces it.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        partner  path  string  true  "Partner"
+// @Router       /users/me/partners/{partner}/enrollment-code [post]
+// @Success      201  {object}  response.SuccessWithPartnerEnrollmentCode
+// @Failure      403  {object}  response.ErrorResponse  "Under-age user without parental consent"
+// @Failure      404  {object}  response.ErrorResponse  "Unknown partner"
+func (p *PartnerConsentController) CreateEnrollmentCode(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+	partner := strings.ToLower(c.Params("partner"))
+
+	code, err := p.PartnerService.CreateEnrollmentCode(c, user.ID, partner)
+	if err != nil {
+		return err
+	}
+
+	logPartnerConsentActivity(c, user, "create_partner_enrollment_code", partner)
+
+	return c.Status(fiber.StatusCreated).JSON(response.SuccessWithPartnerEnrollmentCode{
+		Status:  "success",
+		Message: "Enrollment code created successfully",
+		Data:    *code,
+	})
+}
+
+// @Tags         Users
+// @Summary      Consent to a partner reading my health data
+// @Description  Lets a partner the logged in user is a member of read their weights, heights and daily nutrition intake, for example the hospital treating them
+// @Security     BearerAuth
+// @Produce      json
+// @Param        partner  path  string  true  "Partner"
+// @Router       /users/me/partners/{partner}/consent [put]
+// @Success      200  {object}  response.SuccessWithPartnerConsent
+// @Failure      403  {object}  response.ErrorResponse  "Under-age user without parental consent"
+// @Failure      404  {object}  response.ErrorResponse  "Not a member of the partner"
+func (p *PartnerConsentController) GrantConsent(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+	partner := strings.ToLower(c.Params("partner"))
+
+	consent, err := p.PartnerService.GrantConsent(c, user.ID, partner)
+	if err != nil {
+		return err
+	}
+
+	logPartnerConsentActivity(c, user, "grant_partner_consent", partner)
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithPartnerConsent{
+		Status:  "success",
+		Message: "Consent given successfully",
+		Data:    *consent,
+	})
+}
+
+// @Tags         Users
+// @Summary      Revoke my consent to a partner
+// @Description  Stops a partner from reading the health data of the logged in user at once
+// @Security     BearerAuth
+// @Produce      json
+// @Param        partner  path  string  true  "Partner"
+// @Router       /users/me/partners/{partner}/consent [delete]
+// @Success      200  {object}  response.SuccessWithPartnerConsent
+// @Failure      404  {object}  response.ErrorResponse
+func (p *PartnerConsentController) RevokeConsent(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+	partner := strings.ToLower(c.Params("partner"))
+
+	consent, err := p.PartnerService.RevokeConsent(c, user.ID, partner)
+	if err != nil {
+		return err
+	}
+
+	logPartnerConsentActivity(c, user, "revoke_partner_consent", partner)
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithPartnerConsent{
+		Status:  "success",
+		Message: "Consent revoked successfully",
+		Data:    *consent,
+	})
+}
+
+func logPartnerConsentActivity(c *fiber.Ctx, user *model.User, action, partner string) {
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     user.ID.String(),
+		Action:     action,
+		Resource:   "partner_consent",
+		ResourceID: partner,
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+}
@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"app/src/fhir"
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PartnerController struct {
+	PartnerService      service.PartnerService
+	BahanMakananService service.BahanMakananService
+	FHIRService         service.FHIRService
+}
+
+func NewPartnerController(
+	partnerService service.PartnerService, bahanMakananService service.BahanMakananService, fhirService service.FHIRService,
+) *PartnerController {
+	return &PartnerController{
+		PartnerService:      partnerService,
+		BahanMakananService: bahanMakananService,
+		FHIRService:         fhirService,
+	}
+}
+
+// @Tags         Partner
+// @Summary      Get foods
+// @Description  Lists the food composition table. Needs the read:foods scope.
+// @Security     PartnerKeyAuth
+// @Produce      json
+// @Router       /partner/foods [get]
+// @Success      200  {object}  response.SuccessWithBahanMakananList
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse
+func (p *PartnerController) GetFoods(c *fiber.Ctx) error {
+	foods, err := p.BahanMakananService.GetAllBahanMakanan(c)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithBahanMakananList{
+		Status:  "success",
+		Message: "Foods fetched successfully",
+		Data:    foods,
+	})
+}
+
+// @Tags         Partner
+// @Summary      Get food by kode
+// @Description  Returns a food of the food composition table. Needs the read:foods scope.
+// @Security     PartnerKeyAuth
+// @Produce      json
+// @Param        kode  path  string  true  "Kode Bahan Makanan"
+// @Router       /partner/foods/{kode} [get]
+// @Success      200  {object}  response.SuccessWithBahanMakanan
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse
+func (p *PartnerController) GetFood(c *fiber.Ctx) error {
+	food, err := p.BahanMakananService.GetBahanMakananByKode(c, c.Params("kode"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithBahanMakanan{
+		Status:  "success",
+		Message: "Food fetched successfully",
+		Data:    *food,
+	})
+}
+
+// @Tags         Partner
+// @Summary      Get usage
+// @Description  Returns the requests the keys of the partner made per day or month, with how many were throttled or answered with an error, and what is left of the monthly requests of its tier at the end of each period. Also returns the quota of the current month and the rate limits of the key. Any key of the partner reads it, the request is not counted on the usage. Days default to the last 30, months to the last 12, 366 days at most.
+// @Security     PartnerKeyAuth
+// @Produce      json
+// @Param        interval  query  string  false  "Period of the usage"  Enums(day, month) default(day)
+// @Param        from      query  string  false  "First day (YYYY-MM-DD)"
+// @Param        to        query  string  false  "Last day (YYYY-MM-DD), today by default"
+// @Router       /partner/usage [get]
+// @Success      200  {object}  response.SuccessWithPartnerUsageReport
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse
+func (p *PartnerController) GetUsage(c *fiber.Ctx) error {
+	key := c.Locals("partnerKey").(*model.PartnerKey)
+	query := &validation.PartnerUsageQuery{
+		Interval: c.Query("interval"),
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+	}
+
+	report, err := p.PartnerService.GetPartnerUsage(c, key, query)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithPartnerUsageReport{
+		Status:  "success",
+		Message: "Usage fetched successfully",
+		Data:    *report,
+	})
+}
+
+// @Tags         Partner
+// @Summary      Enroll member
+// @Description  Enrolls a user as a member of the partner with their email and the enrollment code they got in the app, which lets the partner read their entitlements. Unknown emails and wrong, expired or used codes all answer 403. Needs the write:members scope.
+// @Security     PartnerKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.EnrollPartnerMember  true  "Member"
+// @Router       /partner/members [post]
+// @Success      201  {object}  response.SuccessWithPartnerMember
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse  "Scope missing, or invalid email or enrollment code"
+// @Failure      409  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse
+func (p *PartnerController) EnrollMember(c *fiber.Ctx) error {
+	req := new(validation.EnrollPartnerMember)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	key := c.Locals("partnerKey").(*model.PartnerKey)
+
+	member, err := p.PartnerService.EnrollMember(c, key, req)
+	if err != nil {
+		return err
+	}
+
+	logPartnerMemberActivity(c, key, "enroll_partner_member", member.UserID, fiber.StatusCreated)
+
+	return c.Status(fiber.StatusCreated).JSON(response.SuccessWithPartnerMember{
+		Status:  "success",
+		Message: "Member enrolled successfully",
+		Data:    *member,
+	})
+}
+
+// @Tags         Partner
+// @Summary      Remove member
+// @Description  Removes a member of the partner. Needs the write:members scope.
+// @Security     PartnerKeyAuth
+// @Produce      json
+// @Param        userId  path  string  true  "User ID"
+// @Router       /partner/members/{userId} [delete]
+// @Success      200  {object}  response.Common
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse
+func (p *PartnerController) RemoveMember(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID format")
+	}
+
+	key := c.Locals("partnerKey").(*model.PartnerKey)
+
+	if err := p.PartnerService.RemoveMember(c, key, userID); err != nil {
+		return err
+	}
+
+	logPartnerMemberActivity(c, key, "remove_partner_member", userID, fiber.StatusOK)
+
+	return c.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "Member removed successfully",
+	})
+}
+
+// @Tags         Partner
+// @Summary      Get member entitlements
+// @Description  Returns whether a member of the partner has premium access, with the plan, its features and when it ends. Needs the read:entitlements scope.
+// @Security     PartnerKeyAuth
+// @Produce      json
+// @Param        userId  path  string  true  "User ID"
+// @Router       /partner/members/{userId}/entitlements [get]
+// @Success      200  {object}  response.SuccessWithPartnerEntitlement
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse
+func (p *PartnerController) GetEntitlement(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID format")
+	}
+
+	entitlement, err := p.PartnerService.GetEntitlement(c, c.Locals("partnerKey").(*model.PartnerKey), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithPartnerEntitlement{
+		Status:  "success",
+		Message: "Entitlements retrieved successfully",
+		Data:    *entitlement,
+	})
+}
+
+// @Tags         Partner
+// @Summary      Get member observations as FHIR
+// @Description  Returns the observations of a member from one day to another, both inclusive, as a FHIR R4 searchset Bundle of Observation resources: body weight (LOINC 29463-7), body height (8302-2) and BMI (39156-5) in the vital-signs category, and the daily nutrition intake reported in the food diary (calorie intake total, 9052-2, with protein, carbohydrate and fat components) in the survey category. The member must have consented to sharing their health data with the partner in the app, and their privacy settings must share the observations: the daily intake with coach data sharing summary or full, the vital signs with full only. Needs the read:observations scope.
+// @Security     PartnerKeyAuth
+// @Produce      json
+// @Param        userId    path   string  true   "User ID"
+// @Param        from      query  string  true   "First day, YYYY-MM-DD"
+// @Param        to        query  string  true   "Last day, YYYY-MM-DD"
+// @Param        category  query  string  false  "Only observations of a category"  Enums(vital-signs, survey)
+// @Router       /partner/members/{userId}/fhir/Observation [get]
+// @Success      200  {object}  fhir.Bundle
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse  "Scope missing, no consent of the member or observations not shared"
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse
+func (p *PartnerController) GetObservations(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID format")
+	}
+
+	query := new(validation.PartnerObservationQuery)
+	if err := c.QueryParser(query); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
+
+	key := c.Locals("partnerKey").(*model.PartnerKey)
+
+	bundle, err := p.FHIRService.GetObservations(c, key, userID, query)
+	if err != nil {
+		return err
+	}
+
+	// Health data leaves Nutribox, the member can see in their activity which partner read it and when
+	logPartnerMemberActivity(c, key, "read_partner_observations", userID, fiber.StatusOK)
+
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.Status(fiber.StatusOK).JSON(bundle, fhir.ContentType)
+}
+
+// logPartnerMemberActivity writes changes a partner makes to its members to the activity log of the member
+func logPartnerMemberActivity(c *fiber.Ctx, key *model.PartnerKey, action string, userID uuid.UUID, status int) {
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     userID.String(),
+		Action:     action,
+		Resource:   "partner_member",
+		ResourceID: key.Partner,
+		Details: map[string]interface{}{
+			"partner": key.Partner,
+			"key_id":  key.ID,
+		},
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+		StatusCode: status,
+	})
+}
@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PasskeyController struct {
+	PasskeyService service.PasskeyService
+	TokenService   service.TokenService
+}
+
+func NewPasskeyController(passkeyService service.PasskeyService, tokenService service.TokenService) *PasskeyController {
+	return &PasskeyController{
+		PasskeyService: passkeyService,
+		TokenService:   tokenService,
+	}
+}
+
+// @Tags         Auth
+// @Summary      Start a sign-in with a passkey
+// @Description  Issues a challenge to sign in with any passkey of the site, the browser lets the user pick one. Pass public_key to navigator.credentials.get, then the answer to POST /auth/passkey/login within WEBAUTHN_TIMEOUT. Users without a passkey sign in with their password.
+// @Produce      json
+// @Router       /auth/passkey/options [post]
+// @Success      200  {object}  response.PasskeyLoginOptions
+func (p *PasskeyController) LoginOptions(c *fiber.Ctx) error {
+	challengeID, options, err := p.PasskeyService.LoginOptions(c)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.PasskeyLoginOptions{
+		Status:      "success",
+		Message:     "Passkey challenge issued successfully",
+		ChallengeID: challengeID,
+		PublicKey:   *options,
+	})
+}
+
+// @Tags         Auth
+// @Summary      Login with a passkey
+// @Description  Signs in the user of the passkey that answered the challenge. A challenge is answered once.
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.PasskeyLogin  true  "Challenge and the PublicKeyCredential of navigator.credentials.get"
+// @Router       /auth/passkey/login [post]
+// @Success      200  {object}  example.LoginResponse
+// @Failure      400  {object}  response.ErrorResponse  "Invalid request or expired challenge"
+// @Failure      401  {object}  response.ErrorResponse  "Unknown passkey or invalid signature"
+func (p *PasskeyController) Login(c *fiber.Ctx) error {
+	req := new(validation.PasskeyLogin)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user, err := p.PasskeyService.Login(c, req)
+	if err != nil {
+		utils.LogLogin(c, "", false)
+		return err
+	}
+
+	tokens, err := p.TokenService.GenerateAuthTokens(c, user)
+	if err != nil {
+		return err
+	}
+
+	utils.LogLogin(c, user.ID.String(), true)
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.SuccessWithTokens{
+			Status:  "success",
+			Message: "Login successfully",
+			User:    *user,
+			Tokens:  *tokens,
+		})
+}
+
+// @Tags         Users
+// @Summary      Start registering a passkey
+// @Description  Issues a challenge to register a passkey of the logged in user. Pass public_key to navigator.credentials.create, then the answer to POST /users/me/passkeys within WEBAUTHN_TIMEOUT. A user has at most PASSKEY_MAX_PER_USER passkeys.
+// @Security     BearerAuth
+// @Produce      json
+// @Router       /users/me/passkeys/options [post]
+// @Success      200  {object}  response.PasskeyRegistrationOptions
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse  "The user has too many passkeys"
+func (p *PasskeyController) RegistrationOptions(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+
+	challengeID, options, err := p.PasskeyService.RegistrationOptions(c, user)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.PasskeyRegistrationOptions{
+		Status:      "success",
+		Message:     "Passkey challenge issued successfully",
+		ChallengeID: challengeID,
+		PublicKey:   *options,
+	})
+}
+
+// @Tags         Users
+// @Summary      Register a passkey
+// @Description  Registers the passkey that answered the challenge. The password keeps working.
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.RegisterPasskey  true  "Challenge, name and the PublicKeyCredential of navigator.credentials.create"
+// @Router       /users/me/passkeys [post]
+// @Success      201  {object}  response.SuccessWithPasskey
+// @Failure      400  {object}  response.ErrorResponse  "Invalid passkey or expired challenge"
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse  "The passkey is registered or the user has too many"
+func (p *PasskeyController) Register(c *fiber.Ctx) error {
+	req := new(validation.RegisterPasskey)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	passkey, err := p.PasskeyService.Register(c, user, req)
+	if err != nil {
+		return err
+	}
+
+	logPasskeyActivity(c, user.ID, "register_passkey", passkey.ID.String(), fiber.StatusCreated)
+
+	return c.Status(fiber.StatusCreated).JSON(response.SuccessWithPasskey{
+		Status:  "success",
+		Message: "Passkey registered successfully",
+		Data:    *passkey,
+	})
+}
+
+// @Tags         Users
+// @Summary      List my passkeys
+// @Security     BearerAuth
+// @Produce      json
+// @Router       /users/me/passkeys [get]
+// @Success      200  {object}  response.SuccessWithPasskeys
+// @Failure      401  {object}  response.ErrorResponse
+func (p *PasskeyController) GetPasskeys(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+
+	passkeys, err := p.PasskeyService.GetPasskeys(c.UserContext(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithPasskeys{
+		Status:  "success",
+		Message: "Passkeys retrieved successfully",
+		Data:    passkeys,
+	})
+}
+
+// @Tags         Users
+// @Summary      Delete one of my passkeys
+// @Description  The passkey stops working here, the user also removes it from their device.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id  path  string  true  "Passkey ID"
+// @Router       /users/me/passkeys/{id} [delete]
+// @Success      200  {object}  response.Common
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (p *PasskeyController) DeletePasskey(c *fiber.Ctx) error {
+	passkeyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid passkey ID")
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	if err := p.PasskeyService.DeletePasskey(c, user.ID, passkeyID); err != nil {
+		return err
+	}
+
+	logPasskeyActivity(c, user.ID, "delete_passkey", passkeyID.String(), fiber.StatusOK)
+
+	return c.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "Passkey deleted successfully",
+	})
+}
+
+func logPasskeyActivity(c *fiber.Ctx, userID uuid.UUID, action, resourceID string, status int) {
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     userID.String(),
+		Action:     action,
+		Resource:   "passkey",
+		ResourceID: resourceID,
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+		StatusCode: status,
+	})
+}
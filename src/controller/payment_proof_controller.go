@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PaymentProofController struct {
+	PaymentProofService service.PaymentProofService
+}
+
+func NewPaymentProofController(paymentProofService service.PaymentProofService) *PaymentProofController {
+	return &PaymentProofController{
+		PaymentProofService: paymentProofService,
+	}
+}
+
+// @Tags         Subscription
+// @Summary      Upload proof of payment
+// @Description  Upload a bank transfer receipt for a pending bank transfer checkout. The proof is queued for admin verification.
+// @Security     BearerAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        subscriptionID    path      string  true   "Subscription ID"
+// @Param        bank_name         formData  string  true   "Sender bank"
+// @Param        account_name      formData  string  true   "Sender account holder name"
+// @Param        reference_number  formData  string  false  "Transfer reference number"
+// @Param        amount            formData  int     true   "Transferred amount"
+// @Param        proof             formData  file    true   "Proof of transfer image"
+// @Param        Idempotency-Key  header  string  false  "Key to retry the request with safely, its response is returned to retries"
+// @Router       /subscriptions/{subscriptionID}/payment-proof [post]
+// @Success      201  {object}  response.SuccessWithPaymentProof
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (p *PaymentProofController) UploadPaymentProof(c *fiber.Ctx) error {
+	subscriptionID, err := uuid.Parse(c.Params("subscriptionID"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid subscription ID format")
+	}
+
+	req := new(validation.UploadPaymentProof)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	file, err := c.FormFile("proof")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Proof of transfer image is required")
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	proof, err := p.PaymentProofService.UploadPaymentProof(c, user.ID, subscriptionID, req, file)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response.SuccessWithPaymentProof{
+		Status:  "success",
+		Message: "Proof of payment uploaded and awaiting verification",
+		Data:    *proof,
+	})
+}
@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PhoneController struct {
+	PhoneService service.PhoneService
+	TokenService service.TokenService
+}
+
+func NewPhoneController(phoneService service.PhoneService, tokenService service.TokenService) *PhoneController {
+	return &PhoneController{
+		PhoneService: phoneService,
+		TokenService: tokenService,
+	}
+}
+
+// @Tags         Auth
+// @Summary      Send a login code to a phone
+// @Description  Texts a 6 digit login code by SMS, or by WhatsApp with channel whatsapp, to a phone a user verified. Numbers without a country code are Indonesian (PHONE_DEFAULT_COUNTRY_CODE). The answer is the same whether or not the phone belongs to a user, codes the limits of the phone hold back or the provider fails to send are not reported. A phone gets a new code at most every OTP_RESEND_INTERVAL and OTP_MAX_PER_HOUR times an hour, an IP address asks OTP_MAX_PER_IP_PER_HOUR times an hour, codes expire after OTP_TTL.
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.SendPhoneCode  true  "Phone and channel"
+// @Router       /auth/otp/send [post]
+// @Success      200  {object}  response.Common
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse  "The IP address asked too often"
+// @Failure      503  {object}  response.ErrorResponse  "Phone codes are not configured"
+func (p *PhoneController) SendLoginCode(c *fiber.Ctx) error {
+	req := new(validation.SendPhoneCode)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := p.PhoneService.SendLoginCode(c, req); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "A login code was sent if the phone belongs to an account",
+	})
+}
+
+// @Tags         Auth
+// @Summary      Login with a phone code
+// @Description  Signs in the user of the verified phone with the latest code sent to it. A code works once and is locked after OTP_MAX_ATTEMPTS wrong guesses.
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.CheckPhoneCode  true  "Phone and code"
+// @Router       /auth/otp/login [post]
+// @Success      200  {object}  example.LoginResponse
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse  "Invalid or expired code"
+func (p *PhoneController) Login(c *fiber.Ctx) error {
+	req := new(validation.CheckPhoneCode)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user, err := p.PhoneService.Login(c, req)
+	if err != nil {
+		utils.LogLogin(c, req.Phone, false)
+		return err
+	}
+
+	tokens, err := p.TokenService.GenerateAuthTokens(c, user)
+	if err != nil {
+		return err
+	}
+
+	utils.LogLogin(c, user.ID.String(), true)
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.SuccessWithTokens{
+			Status:  "success",
+			Message: "Login successfully",
+			User:    *user,
+			Tokens:  *tokens,
+		})
+}
+
+// @Tags         Users
+// @Summary      Send a code to verify my phone
+// @Description  Texts a 6 digit code by SMS, or by WhatsApp with channel whatsapp, to the phone the logged in user wants to add. The phone is set once the code is verified. The limits of the login codes apply, and a user gets OTP_MAX_PER_USER_PER_HOUR codes an hour whatever the phones.
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.SendPhoneCode  true  "Phone and channel"
+// @Router       /users/me/phone/otp [post]
+// @Success      200  {object}  response.Common
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse  "The phone is verified by this or another user"
+// @Failure      429  {object}  response.ErrorResponse  "A code was sent too recently or too often, to the phone, the user or from the IP address"
+// @Failure      502  {object}  response.ErrorResponse  "The provider failed to send the code"
+// @Failure      503  {object}  response.ErrorResponse  "Phone codes are not configured"
+func (p *PhoneController) SendVerificationCode(c *fiber.Ctx) error {
+	req := new(validation.SendPhoneCode)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	if err := p.PhoneService.SendVerificationCode(c, user, req); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Common{
+		Status:  "success",
+		Message: "Verification code sent successfully",
+	})
+}
+
+// @Tags         Users
+// @Summary      Verify my phone
+// @Description  Sets the phone of the logged in user, verified, with the code sent to it. A verified phone logs in with codes and can receive notifications by SMS or WhatsApp, see the channels of the notification preferences.
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.CheckPhoneCode  true  "Phone and code"
+// @Router       /users/me/phone/verify [post]
+// @Success      200  {object}  example.GetUserResponse
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse  "Invalid or expired code"
+// @Failure      409  {object}  response.ErrorResponse  "Another user verified the phone"
+func (p *PhoneController) VerifyPhone(c *fiber.Ctx) error {
+	req := new(validation.CheckPhoneCode)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	updated, err := p.PhoneService.VerifyPhone(c, user, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithUser{
+		Status:  "success",
+		Message: "Phone verified successfully",
+		User:    *updated,
+	})
+}
+
+// @Tags         Messaging
+// @Summary      Twilio message status webhook
+// @Description  Takes the status callbacks Twilio posts to TWILIO_STATUS_CALLBACK_URL for the messages it sent, signed with TWILIO_AUTH_TOKEN in X-Twilio-Signature. The status of a message only moves forward: queued, sent, delivered, read (WhatsApp), or failed.
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        X-Twilio-Signature  header    string  true   "Signature of the callback"
+// @Param        MessageSid          formData  string  true   "ID of the message"
+// @Param        MessageStatus       formData  string  true   "Status of the message"
+// @Param        ErrorCode           formData  string  false  "Error code of a failed message"
+// @Router       /messaging/twilio/status [post]
+// @Success      200  {object}  response.Common
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+func (p *PhoneController) HandleTwilioStatus(c *fiber.Ctx) error {
+	// Every param is signed, not only the ones the status is read from
+	params := make(map[string]string)
+	c.Request().PostArgs().VisitAll(func(key, value []byte) {
+		params[string(key)] = string(value)
+	})
+
+	if err := p.PhoneService.HandleTwilioStatus(c, params, c.Get("X-Twilio-Signature")); err != nil {
+		return err
+	}
+
+	return c.JSON(response.Common{
+		Status:  "success",
+		Message: "Status processed successfully",
+	})
+}
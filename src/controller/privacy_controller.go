@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PrivacyController struct {
+	PrivacyService service.PrivacyService
+}
+
+func NewPrivacyController(privacyService service.PrivacyService) *PrivacyController {
+	return &PrivacyController{
+		PrivacyService: privacyService,
+	}
+}
+
+// @Tags         Users
+// @Summary      Get my privacy settings
+// @Description  Returns who sees the profile of the logged in user (private, public) and how much of their diary and vitals coaches, doctor share links and partners see (none, summary, full). Everything is private until the user changes it.
+// @Security     BearerAuth
+// @Produce      json
+// @Router       /users/me/privacy [get]
+// @Success      200  {object}  response.SuccessWithPrivacySettings
+// @Failure      401  {object}  response.ErrorResponse
+func (p *PrivacyController) GetSettings(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+
+	settings, err := p.PrivacyService.GetSettings(c, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithPrivacySettings{
+		Status:  "success",
+		Message: "Privacy settings retrieved successfully",
+		Data:    *settings,
+	})
+}
+
+// @Tags         Users
+// @Summary      Update my privacy settings
+// @Description  Changes the privacy settings of the logged in user, fields left out keep their value
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.UpdatePrivacySettings  true  "Settings to change"
+// @Router       /users/me/privacy [patch]
+// @Success      200  {object}  response.SuccessWithPrivacySettings
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse
+func (p *PrivacyController) UpdateSettings(c *fiber.Ctx) error {
+	req := new(validation.UpdatePrivacySettings)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	settings, err := p.PrivacyService.UpdateSettings(c, user.ID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithPrivacySettings{
+		Status:  "success",
+		Message: "Privacy settings updated successfully",
+		Data:    *settings,
+	})
+}
+
+// @Tags         Users
+// @Summary      Get a public profile
+// @Description  Returns the public profile behind a handle: name, picture and streak, never the email. Profiles their owner keeps private are not found.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        handle  path  string  true  "Handle"
+// @Router       /profiles/{handle} [get]
+// @Success      200  {object}  response.SuccessWithPublicProfile
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (p *PrivacyController) GetPublicProfile(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+
+	profile, err := p.PrivacyService.GetPublicProfile(c, user.ID, c.Params("handle"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithPublicProfile{
+		Status:  "success",
+		Message: "Profile retrieved successfully",
+		Data:    *profile,
+	})
+}
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/pagination"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const publicFeedTitle = "Nutribox"
+
+type PublicContentController struct {
+	PublicContentService service.PublicContentService
+}
+
+func NewPublicContentController(publicContentService service.PublicContentService) *PublicContentController {
+	return &PublicContentController{
+		PublicContentService: publicContentService,
+	}
+}
+
+// cachePublicContent lets the website and its CDN keep a response as long as this instance does
+func cachePublicContent(c *fiber.Ctx) {
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(config.PublicContentCacheTTL.Seconds())))
+}
+
+func sendXML(c *fiber.Ctx, contentType string, v any) error {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.Status(fiber.StatusOK).Send(append([]byte(xml.Header), body...))
+}
+
+// @Tags         Public
+// @Summary      List published articles for the website
+// @Description  Lists the published articles without authentication, newest first, with an excerpt instead of the content. Responses are cached for PUBLIC_CONTENT_CACHE_TTL and each IP address may send PUBLIC_RATE_LIMIT requests per minute.
+// @Produce      json
+// @Param        page   query  int  false  "Page number"  default(1)
+// @Param        limit  query  int  false  "Articles per page, at most 50"  default(10)
+// @Router       /public/articles [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.PublicArticle]
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse
+func (p *PublicContentController) GetArticles(c *fiber.Ctx) error {
+	query := &validation.PublicContentQuery{
+		Page:  c.QueryInt("page", 1),
+		Limit: c.QueryInt("limit", 10),
+	}
+
+	articles, totalResults, err := p.PublicContentService.GetArticles(c, query)
+	if err != nil {
+		return err
+	}
+
+	cachePublicContent(c)
+	return c.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.PublicArticle]{
+		Status:     "success",
+		Message:    "Articles retrieved successfully",
+		Results:    articles,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
+
+// @Tags         Public
+// @Summary      Get a published article for the website
+// @Description  Returns a published article with its content without authentication. Drafts and articles scheduled for later are not found.
+// @Produce      json
+// @Param        slug  path  string  true  "Article slug"
+// @Router       /public/articles/{slug} [get]
+// @Success      200  {object}  response.SuccessWithPublicArticle
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse
+func (p *PublicContentController) GetArticle(c *fiber.Ctx) error {
+	article, err := p.PublicContentService.GetArticle(c, c.Params("slug"))
+	if err != nil {
+		return err
+	}
+
+	cachePublicContent(c)
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithPublicArticle{
+		Status:  "success",
+		Message: "Article retrieved successfully",
+		Data:    *article,
+	})
+}
+
+// @Tags         Public
+// @Summary      List curated recipes for the website
+// @Description  Lists the recipes published for the website without authentication, newest first, with an excerpt of their description. Responses are cached for PUBLIC_CONTENT_CACHE_TTL and each IP address may send PUBLIC_RATE_LIMIT requests per minute.
+// @Produce      json
+// @Param        page   query  int  false  "Page number"  default(1)
+// @Param        limit  query  int  false  "Recipes per page, at most 50"  default(10)
+// @Router       /public/recipes [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.PublicRecipe]
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse
+func (p *PublicContentController) GetRecipes(c *fiber.Ctx) error {
+	query := &validation.PublicContentQuery{
+		Page:  c.QueryInt("page", 1),
+		Limit: c.QueryInt("limit", 10),
+	}
+
+	recipes, totalResults, err := p.PublicContentService.GetRecipes(c, query)
+	if err != nil {
+		return err
+	}
+
+	cachePublicContent(c)
+	return c.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.PublicRecipe]{
+		Status:     "success",
+		Message:    "Recipes retrieved successfully",
+		Results:    recipes,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
+
+// @Tags         Public
+// @Summary      Get a curated recipe for the website
+// @Description  Returns a recipe published for the website with its ingredients and instructions without authentication.
+// @Produce      json
+// @Param        slug  path  string  true  "Recipe slug"
+// @Router       /public/recipes/{slug} [get]
+// @Success      200  {object}  response.SuccessWithPublicRecipe
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse
+func (p *PublicContentController) GetRecipe(c *fiber.Ctx) error {
+	recipe, err := p.PublicContentService.GetRecipe(c, c.Params("slug"))
+	if err != nil {
+		return err
+	}
+
+	cachePublicContent(c)
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithPublicRecipe{
+		Status:  "success",
+		Message: "Recipe retrieved successfully",
+		Data:    *recipe,
+	})
+}
+
+// @Tags         Public
+// @Summary      JSON feed of the website
+// @Description  The PUBLIC_FEED_SIZE newest published articles and recipes as a JSON Feed 1.1, linking to their pages on PUBLIC_SITE_URL.
+// @Produce      json
+// @Router       /public/feed.json [get]
+// @Success      200  {object}  model.JSONFeed
+// @Failure      429  {object}  response.ErrorResponse
+func (p *PublicContentController) GetJSONFeed(c *fiber.Ctx) error {
+	items, err := p.PublicContentService.GetFeed(c)
+	if err != nil {
+		return err
+	}
+
+	feedURL := strings.TrimRight(config.AppURL, "/") + "/v1/public/feed.json"
+	cachePublicContent(c)
+	return c.Status(fiber.StatusOK).JSON(model.NewJSONFeed(publicFeedTitle, config.PublicSiteURL, feedURL, items),
+		"application/feed+json; charset=utf-8")
+}
+
+// @Tags         Public
+// @Summary      RSS feed of the website
+// @Description  The PUBLIC_FEED_SIZE newest published articles and recipes as an RSS 2.0 channel, linking to their pages on PUBLIC_SITE_URL.
+// @Produce      xml
+// @Router       /public/feed.xml [get]
+// @Success      200  {object}  model.RSSFeed
+// @Failure      429  {object}  response.ErrorResponse
+func (p *PublicContentController) GetRSSFeed(c *fiber.Ctx) error {
+	items, err := p.PublicContentService.GetFeed(c)
+	if err != nil {
+		return err
+	}
+
+	cachePublicContent(c)
+	feed := model.NewRSSFeed(publicFeedTitle, "Nutrition articles and recipes from "+publicFeedTitle, config.PublicSiteURL, items)
+	return sendXML(c, "application/rss+xml; charset=utf-8", feed)
+}
+
+// @Tags         Public
+// @Summary      Sitemap of the website content
+// @Description  The pages of every published article and recipe on PUBLIC_SITE_URL, for search engines.
+// @Produce      xml
+// @Router       /public/sitemap.xml [get]
+// @Success      200  {object}  model.Sitemap
+// @Failure      429  {object}  response.ErrorResponse
+func (p *PublicContentController) GetSitemap(c *fiber.Ctx) error {
+	items, err := p.PublicContentService.GetSitemap(c)
+	if err != nil {
+		return err
+	}
+
+	cachePublicContent(c)
+	return sendXML(c, fiber.MIMEApplicationXMLCharsetUTF8, model.NewSitemap(items))
+}
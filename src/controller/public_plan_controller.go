@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"app/src/config"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PublicPlanController struct {
+	PublicPlanService service.PublicPlanService
+}
+
+func NewPublicPlanController(publicPlanService service.PublicPlanService) *PublicPlanController {
+	return &PublicPlanController{
+		PublicPlanService: publicPlanService,
+	}
+}
+
+// @Tags         Public
+// @Summary      List plans for the website
+// @Description  Lists the plans for sale without authentication, cheapest first, with prices formatted for the language (id by default). Usage limits are left out of plans that keep them private. Responses are cached for PUBLIC_PLANS_CACHE_TTL and each IP address may send PUBLIC_RATE_LIMIT requests per minute.
+// @Produce      json
+// @Param        lang  query  string  false  "Language of the formatted prices"  Enums(id, en)
+// @Router       /public/plans [get]
+// @Success      200  {object}  response.SuccessWithPublicPlans
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      429  {object}  response.ErrorResponse
+func (p *PublicPlanController) GetPlans(c *fiber.Ctx) error {
+	query := new(validation.PublicPlanQuery)
+	if err := c.QueryParser(query); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
+
+	plans, err := p.PublicPlanService.GetPlans(c, query)
+	if err != nil {
+		return err
+	}
+
+	// The website and its CDN may keep the list as long as this instance does
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(config.PublicPlansCacheTTL.Seconds())))
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithPublicPlans{
+		Status:  "success",
+		Message: "Plans retrieved successfully",
+		Data:    plans,
+	})
+}
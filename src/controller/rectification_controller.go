@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type RectificationController struct {
+	RectificationService service.RectificationService
+}
+
+func NewRectificationController(rectificationService service.RectificationService) *RectificationController {
+	return &RectificationController{
+		RectificationService: rectificationService,
+	}
+}
+
+// @Tags         Users
+// @Summary      Rectify my personal data
+// @Description  Corrects the personal data of the logged in user in one transaction: profile fields, recorded weights and heights, and the account name on payment proofs. Correcting the current weight or height corrects the latest measurement too. Every replaced value is returned and kept in the audit log. A corrected email has to be verified again.
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.RectifyPersonalData  true  "Corrections"
+// @Router       /users/me/rectification [post]
+// @Success      200  {object}  response.SuccessWithRectification
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (r *RectificationController) RectifyMine(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+	return r.rectify(c, user.ID, user.ID)
+}
+
+// @Tags         Admin
+// @Summary      Rectify the personal data of a user
+// @Description  Corrects the personal data of a user on their behalf, like POST /users/me/rectification. The reason should reference the request of the user.
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string                          true  "User ID"
+// @Param        request  body  validation.RectifyPersonalData  true  "Corrections"
+// @Router       /admin/users/{id}/rectification [post]
+// @Success      200  {object}  response.SuccessWithRectification
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (r *RectificationController) RectifyUser(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	admin := c.Locals("user").(*model.User)
+	return r.rectify(c, userID, admin.ID)
+}
+
+func (r *RectificationController) rectify(c *fiber.Ctx, userID, rectifiedByID uuid.UUID) error {
+	req := new(validation.RectifyPersonalData)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	rectification, err := r.RectificationService.Rectify(c, userID, rectifiedByID, req)
+	if err != nil {
+		return err
+	}
+
+	// The audit log keeps what was replaced, the DPO answers rectification requests from it
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:     rectifiedByID.String(),
+		Action:     "rectify_personal_data",
+		Resource:   "user",
+		ResourceID: userID.String(),
+		Details: map[string]interface{}{
+			"reason":  rectification.Reason,
+			"changes": rectification.Changes,
+		},
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+		StatusCode: fiber.StatusOK,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithRectification{
+		Status:  "success",
+		Message: "Personal data rectified successfully",
+		Data:    *rectification,
+	})
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SearchController struct {
+	ContentSearchService service.ContentSearchService
+}
+
+func NewSearchController(contentSearchService service.ContentSearchService) *SearchController {
+	return &SearchController{
+		ContentSearchService: contentSearchService,
+	}
+}
+
+// @Tags         Search
+// @Summary      Search articles and recipes
+// @Description  Searches published articles and recipes by title and text, best matches first. Uses the search index, which tolerates typos, and the database while the index is down.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        q      query  string  true   "Search"
+// @Param        type   query  string  false  "Type of content"  Enums(article, recipe)
+// @Param        limit  query  int     false  "Maximum number of results"  default(20)
+// @Router       /search [get]
+// @Success      200  {object}  response.SuccessWithContentSearchResults
+// @Failure      400  {object}  response.ErrorResponse
+func (s *SearchController) SearchContent(c *fiber.Ctx) error {
+	query := &validation.ContentSearchQuery{
+		Q:     c.Query("q"),
+		Type:  c.Query("type"),
+		Limit: c.QueryInt("limit", 20),
+	}
+
+	results, err := s.ContentSearchService.Search(c, query)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithContentSearchResults{
+		Status:  "success",
+		Message: "Content searched successfully",
+		Data:    results,
+	})
+}
@@ -5,26 +5,37 @@ import (
 	"app/src/response"
 	"app/src/service"
 	"app/src/utils"
+	"app/src/validation"
 
 	"encoding/json"
+	"errors"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
 type SubscriptionController struct {
-	Service service.SubscriptionService
+	Service       service.SubscriptionService
+	Experiments   service.ExperimentService
+	ScanQuota     service.ScanQuotaService
+	FeatureAccess service.FeatureAccessService
 }
 
-func NewSubscriptionController(service service.SubscriptionService) *SubscriptionController {
+func NewSubscriptionController(
+	service service.SubscriptionService, experiments service.ExperimentService, scanQuota service.ScanQuotaService,
+	featureAccess service.FeatureAccessService,
+) *SubscriptionController {
 	return &SubscriptionController{
-		Service: service,
+		Service:       service,
+		Experiments:   experiments,
+		ScanQuota:     scanQuota,
+		FeatureAccess: featureAccess,
 	}
 }
 
 // @Tags         Subscription
 // @Summary      Get all subscription plans
-// @Description  Get available subscription plans
+// @Description  Get available subscription plans. Signed in users (optional bearer token) in a running paywall experiment also get the copy of their variant.
 // @Produce      json
 // @Router       /subscriptions/plans [get]
 // @Success      200  {object}  response.SubscriptionPlansResponse
@@ -35,10 +46,48 @@ func (c *SubscriptionController) GetPlans(ctx *fiber.Ctx) error {
 		return utils.APIError(ctx, fiber.StatusInternalServerError, "server_error", err.Error())
 	}
 
+	// The plans are shown without an experiment when the variant cannot be assigned
+	paywall, err := c.Experiments.ExposePaywall(ctx)
+	if err != nil {
+		utils.Log.Errorf("Failed to assign the paywall experiment variant: %v", err)
+	}
+
 	return ctx.JSON(response.SubscriptionPlansResponse{
 		Status:  "success",
 		Message: "Subscription plans retrieved",
 		Data:    plans,
+		Paywall: paywall,
+	})
+}
+
+// @Tags         Subscription
+// @Summary      Get a subscription plan
+// @Description  Get a purchasable plan by ID, including hidden plans shared through a direct or promo link. Views of signed in users (optional bearer token) count in the checkout funnel.
+// @Produce      json
+// @Param        planID  path  string  true  "Plan ID"
+// @Router       /subscriptions/plans/{planID} [get]
+// @Success      200  {object}  response.SubscriptionPlanDetailResponse
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+func (c *SubscriptionController) GetPlan(ctx *fiber.Ctx) error {
+	planID, err := uuid.Parse(ctx.Params("planID"))
+	if err != nil {
+		return utils.APIError(ctx, fiber.StatusBadRequest, "invalid_id", "Invalid plan ID")
+	}
+
+	plan, err := c.Service.GetPlan(ctx, planID)
+	if err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			return utils.APIError(ctx, fiberErr.Code, "plan_not_found", fiberErr.Message)
+		}
+		return utils.APIError(ctx, fiber.StatusInternalServerError, "server_error", err.Error())
+	}
+
+	return ctx.JSON(response.SubscriptionPlanDetailResponse{
+		Status:  "success",
+		Message: "Subscription plan retrieved",
+		Data:    *plan,
 	})
 }
 
@@ -50,6 +99,7 @@ func (c *SubscriptionController) GetPlans(ctx *fiber.Ctx) error {
 // @Produce      json
 // @Param        planID  path  string  true  "Plan ID"
 // @Param        request  body  model.PurchaseSubscriptionRequest  false  "Payment data (optional)"
+// @Param        Idempotency-Key  header  string  false  "Key to retry the request with safely, its response is returned to retries"
 // @Router       /subscriptions/purchase/{planID} [post]
 // @Success      200  {object}  response.PaymentResponse
 func (c *SubscriptionController) PurchasePlan(ctx *fiber.Ctx) error {
@@ -64,8 +114,12 @@ func (c *SubscriptionController) PurchasePlan(ctx *fiber.Ctx) error {
 	}
 
 	user := ctx.Locals("user").(*model.User)
-	paymentResponse, err := c.Service.PurchasePlan(ctx, user.ID, uuid.MustParse(planID), req.PaymentMethod)
+	paymentResponse, err := c.Service.PurchasePlan(ctx, user.ID, uuid.MustParse(planID), req.PaymentMethod, req.Installment)
 	if err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			return utils.APIError(ctx, fiberErr.Code, "purchase_failed", fiberErr.Message)
+		}
 		return utils.APIError(ctx, fiber.StatusInternalServerError, "purchase_failed", err.Error())
 	}
 
@@ -79,6 +133,37 @@ func (c *SubscriptionController) PurchasePlan(ctx *fiber.Ctx) error {
 	})
 }
 
+// @Tags         Subscription
+// @Summary      Start a free trial
+// @Description  Starts the free trial of a plan with trial days, without a payment. The features of the plan are available right away. Only users who never subscribed nor had a trial can start one.
+// @Security     BearerAuth
+// @Produce      json
+// @Param        planID  path  string  true  "Plan ID"
+// @Param        Idempotency-Key  header  string  false  "Key to retry the request with safely, its response is returned to retries"
+// @Router       /subscriptions/trial/{planID} [post]
+// @Success      201  {object}  response.SuccessWithSubscription
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *SubscriptionController) StartTrial(ctx *fiber.Ctx) error {
+	planID, err := uuid.Parse(ctx.Params("planID"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid plan ID")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+	subscription, err := c.Service.StartTrial(ctx, user.ID, planID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.SuccessWithSubscription{
+		Status:  "success",
+		Message: "Free trial started successfully",
+		Data:    *subscription,
+	})
+}
+
 // @Tags         Subscription
 // @Summary      Get current subscription
 // @Description  Get user's active subscription
@@ -101,12 +186,152 @@ func (c *SubscriptionController) GetMySubscription(ctx *fiber.Ctx) error {
 	})
 }
 
+// @Tags         Subscription
+// @Summary      Get AI scan usage
+// @Description  Get the AI scans the user used of their active subscription and when the count resets. A limit and remaining of -1 mean unlimited scans.
+// @Security     BearerAuth
+// @Produce      json
+// @Router       /subscriptions/me/usage [get]
+// @Success      200  {object}  response.SuccessWithScanUsage
+// @Failure      404  {object}  response.ErrorResponse
+func (c *SubscriptionController) GetMyUsage(ctx *fiber.Ctx) error {
+	user := ctx.Locals("user").(*model.User)
+
+	usage, err := c.ScanQuota.Usage(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithScanUsage{
+		Status:  "success",
+		Message: "Scan usage retrieved successfully",
+		Data:    *usage,
+	})
+}
+
+// @Tags         Subscription
+// @Summary      Set auto-renewal
+// @Description  Opts a subscription in or out of auto-renewal. Renewals charge the card the subscription was paid with, before it ends.
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        subscriptionID  path  string  true  "Subscription ID"
+// @Param        request  body  validation.UpdateAutoRenew  true  "Auto-renewal"
+// @Router       /subscriptions/{subscriptionID}/auto-renew [put]
+// @Success      200  {object}  response.SuccessWithSubscription
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *SubscriptionController) SetAutoRenew(ctx *fiber.Ctx) error {
+	subscriptionID, err := uuid.Parse(ctx.Params("subscriptionID"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid subscription ID format")
+	}
+
+	req := new(validation.UpdateAutoRenew)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+	subscription, err := c.Service.SetAutoRenew(ctx, user.ID, subscriptionID, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithSubscription{
+		Status:  "success",
+		Message: "Auto-renewal updated successfully",
+		Data:    *subscription,
+	})
+}
+
+// @Tags         Subscription
+// @Summary      Cancel my auto-renewal
+// @Description  Opts the active subscription out of auto-renewal, it runs until its end date. Renewals already paid ahead follow.
+// @Security     BearerAuth
+// @Produce      json
+// @Router       /subscriptions/me/auto-renew [delete]
+// @Success      200  {object}  response.SuccessWithSubscription
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *SubscriptionController) CancelMyAutoRenew(ctx *fiber.Ctx) error {
+	user := ctx.Locals("user").(*model.User)
+
+	subscription, err := c.Service.CancelMyAutoRenew(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithSubscription{
+		Status:  "success",
+		Message: "Auto-renewal cancelled successfully",
+		Data:    *subscription,
+	})
+}
+
+// @Tags         Subscription
+// @Summary      List my plan options
+// @Description  Lists the plans for sale, cheapest first, marking the plan of the active subscription. Plans the user can upgrade to now carry the proration: the unused days of the active subscription are credited against the price of the plan, which starts a period of its own.
+// @Security     BearerAuth
+// @Produce      json
+// @Router       /subscriptions/me/plans [get]
+// @Success      200  {object}  response.SuccessWithPlanOptions
+func (c *SubscriptionController) GetMyPlans(ctx *fiber.Ctx) error {
+	user := ctx.Locals("user").(*model.User)
+
+	plans, err := c.Service.GetMyPlans(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithPlanOptions{
+		Status:  "success",
+		Message: "Plans retrieved successfully",
+		Data:    plans,
+	})
+}
+
+// @Tags         Subscription
+// @Summary      Upgrade my subscription
+// @Description  Starts the checkout of a pricier plan for the active subscription, less the credit for its unused days. The active subscription is cancelled once the upgrade is paid, until then it keeps running. Only subscriptions paid in one payment through this backend can be upgraded.
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.UpgradeSubscription  true  "Plan to upgrade to"
+// @Param        Idempotency-Key  header  string  false  "Key to retry the request with safely, its response is returned to retries"
+// @Router       /subscriptions/me/upgrade [post]
+// @Success      200  {object}  response.PaymentResponse
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Failure      409  {object}  response.ErrorResponse
+func (c *SubscriptionController) UpgradeMySubscription(ctx *fiber.Ctx) error {
+	req := new(validation.UpgradeSubscription)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+	payment, err := c.Service.UpgradeMySubscription(ctx, user.ID, req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogSubscriptionPurchase(ctx, user.ID.String(), req.PlanID, req.PaymentMethod)
+
+	return ctx.Status(fiber.StatusOK).JSON(response.PaymentResponse{
+		Status:  "success",
+		Message: "Upgrade payment initiated successfully",
+		Data:    payment,
+	})
+}
+
 // @Tags         Subscription
 // @Summary      Check feature access
-// @Description  Check if user has access to a feature
+// @Description  Check if user has access to a feature of their plan, false for features that do not exist
 // @Security     BearerAuth
 // @Produce      json
-// @Param        feature  query  string  true  "Feature name"
+// @Param        feature  query  string  true  "Feature name"  Enums(scan_ai, scan_calorie, chatbot, bmi_check, weight_tracking, health_info)
 // @Router       /subscriptions/check-feature [get]
 // @Success      200  {object}  response.FeatureAccessResponse
 func (c *SubscriptionController) CheckFeatureAccess(ctx *fiber.Ctx) error {
@@ -116,7 +341,7 @@ func (c *SubscriptionController) CheckFeatureAccess(ctx *fiber.Ctx) error {
 	}
 
 	user := ctx.Locals("user").(*model.User)
-	hasAccess, err := c.Service.CheckFeatureAccess(ctx, user.ID, feature)
+	hasAccess, err := c.FeatureAccess.HasFeature(ctx.UserContext(), user.ID, feature)
 	if err != nil {
 		return utils.APIError(ctx, fiber.StatusInternalServerError, "check_failed", err.Error())
 	}
@@ -2,10 +2,10 @@ package controller
 
 import (
 	"app/src/model"
+	"app/src/pagination"
 	"app/src/response"
 	"app/src/service"
 	"app/src/validation"
-	"math"
 
 	// "mime/multipart"
 
@@ -50,14 +50,11 @@ func (u *UserController) GetUsers(c *fiber.Ctx) error {
 	}
 
 	return c.Status(fiber.StatusOK).
-		JSON(response.SuccessWithPaginate[model.User]{
-			Status:       "success",
-			Message:      "Get all users successfully",
-			Results:      users,
-			Page:         query.Page,
-			Limit:        query.Limit,
-			TotalPages:   int64(math.Ceil(float64(totalResults) / float64(query.Limit))),
-			TotalResults: totalResults,
+		JSON(pagination.PaginatedResponse[model.User]{
+			Status:     "success",
+			Message:    "Get all users successfully",
+			Results:    users,
+			Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
 		})
 }
 
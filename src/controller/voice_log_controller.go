@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type VoiceLogController struct {
+	VoiceLogService service.VoiceLogService
+}
+
+func NewVoiceLogController(voiceLogService service.VoiceLogService) *VoiceLogController {
+	return &VoiceLogController{
+		VoiceLogService: voiceLogService,
+	}
+}
+
+// @Tags         Diary
+// @Summary      Log food by voice
+// @Description  Transcribes an audio clip such as "dua butir telur rebus dan segelas susu", finds the foods it names and weighs the portions said, and proposes diary entries. Nothing is logged: the user confirms the entries with POST /meals and POST /bahan-makanan/kode/{kode}/log, passing the search_id of an entry. A food said without a unit it has a serving for is weighed at 100 g per portion and marked approximate. Each clip counts against the voice log quota of the plan, failed clips are given back.
+// @Security     BearerAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        audio  formData  file    true   "Audio clip: flac, m4a, mp3, mp4, mpeg, ogg, wav or webm"
+// @Param        lang   formData  string  false  "Language of the clip, detected when empty"  Enums(id, en)
+// @Router       /diary/voice [post]
+// @Success      200  {object}  response.SuccessWithVoiceLogProposal
+// @Header       200  {int}     X-Voice-Logs-Remaining  "Voice logs left on the plan, absent when unlimited"
+// @Failure      400  {object}  response.ErrorResponse
+// @Failure      403  {object}  response.ErrorResponse  "No premium plan or voice log quota exhausted"
+// @Failure      413  {object}  response.ErrorResponse  "Audio clip too large"
+// @Failure      415  {object}  response.ErrorResponse  "Unsupported audio format"
+// @Failure      422  {object}  response.ErrorResponse  "No food heard or found"
+// @Failure      502  {object}  response.ErrorResponse  "Transcription failed"
+// @Failure      503  {object}  response.ErrorResponse  "Voice logging is not available or busy"
+func (c *VoiceLogController) LogVoice(ctx *fiber.Ctx) error {
+	audio, err := ctx.FormFile("audio")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Audio file is required")
+	}
+
+	req := new(validation.VoiceLog)
+	if err := ctx.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user := ctx.Locals("user").(*model.User)
+	quota := ctx.Locals("voiceLogQuota").(*model.VoiceLogQuota)
+
+	proposal, err := c.VoiceLogService.Propose(ctx, user.ID, *quota, audio, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.SuccessWithVoiceLogProposal{
+		Status:  "success",
+		Message: "Voice log understood, confirm the entries to log them",
+		Data:    *proposal,
+	})
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/pagination"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WalletController struct {
+	WalletService service.WalletService
+}
+
+func NewWalletController(walletService service.WalletService) *WalletController {
+	return &WalletController{
+		WalletService: walletService,
+	}
+}
+
+// @Tags         Wallet
+// @Summary      Get my wallet
+// @Description  Returns the credit balance of the logged in user
+// @Security     BearerAuth
+// @Produce      json
+// @Router       /wallet [get]
+// @Success      200  {object}  response.SuccessWithWallet
+// @Failure      401  {object}  response.ErrorResponse
+func (w *WalletController) GetMyWallet(c *fiber.Ctx) error {
+	user := c.Locals("user").(*model.User)
+
+	wallet, err := w.WalletService.GetWallet(c, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccessWithWallet{
+		Status:  "success",
+		Message: "Wallet retrieved successfully",
+		Data:    *wallet,
+	})
+}
+
+// @Tags         Wallet
+// @Summary      Get my wallet transactions
+// @Description  Returns the credit and debit history of the logged in user, newest first
+// @Security     BearerAuth
+// @Produce      json
+// @Param        page     query     int     false   "Page number"  default(1)
+// @Param        limit    query     int     false   "Maximum number of transactions"    default(10)
+// @Router       /wallet/transactions [get]
+// @Success      200  {object}  pagination.PaginatedResponse[model.WalletTransaction]
+// @Failure      401  {object}  response.ErrorResponse
+func (w *WalletController) GetMyTransactions(c *fiber.Ctx) error {
+	query := &validation.WalletQuery{
+		Page:  c.QueryInt("page", 1),
+		Limit: c.QueryInt("limit", 10),
+	}
+
+	user := c.Locals("user").(*model.User)
+
+	transactions, totalResults, err := w.WalletService.GetTransactions(c, user.ID, query)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(pagination.PaginatedResponse[model.WalletTransaction]{
+		Status:     "success",
+		Message:    "Wallet transactions retrieved successfully",
+		Results:    transactions,
+		Pagination: pagination.Paginate(query.Page, query.Limit, totalResults),
+	})
+}
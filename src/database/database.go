@@ -1,6 +1,7 @@
 package database
 
 import (
+	"app/src/config"
 	"app/src/database/migrations"
 	"app/src/database/seeders"
 	"app/src/model"
@@ -10,6 +11,20 @@ import (
 	"gorm.io/gorm"
 )
 
+// ConfigurePool sizes the connection pool of db from the DB_* settings
+func ConfigurePool(db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		utils.Log.Errorf("Failed to configure the connection pool: %+v", err)
+		return
+	}
+
+	sqlDB.SetMaxOpenConns(config.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(config.DBConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(config.DBConnMaxIdleTime)
+}
+
 func MigrateAndSeed(db *gorm.DB) {
 	// Run our custom migrations first to fix any data issues
 	runCustomMigrations(db)
@@ -21,7 +36,9 @@ func MigrateAndSeed(db *gorm.DB) {
 		&model.Article{},
 		&model.ArticleCategory{},
 		&model.MealHistory{},
+		&model.DailyNutritionSummary{},
 		&model.MealHistoryDetail{},
+		&model.ScanHistory{},
 		&model.ProductToken{},
 		&model.Recipe{},
 		&model.UsersStar{},
@@ -29,12 +46,125 @@ func MigrateAndSeed(db *gorm.DB) {
 		&model.UsersWeightHeightTarget{},
 		&model.SubscriptionPlan{},
 		&model.UserSubscription{},
+		&model.SubscriptionEvent{},
 		&model.TransactionDetail{},
 		&model.LoginStreak{},
+		&model.PaymentProof{},
+		&model.InstallmentSchedule{},
+		&model.Wallet{},
+		&model.WalletTransaction{},
+		&model.FraudReview{},
+		&model.FraudListEntry{},
+		&model.StoreProduct{},
+		&model.StorePurchase{},
+		&model.Coupon{},
+		&model.CheckoutSession{},
+		&model.PaymentReminder{},
+		&model.RevenueRecognition{},
+		&model.AlertRule{},
+		&model.SystemSetting{},
+		&model.OpsBotIdentity{},
+		&model.DomainEvent{},
+		&model.Backup{},
+		&model.NotificationTemplate{},
+		&model.NotificationPreference{},
+		&model.PrivacySettings{},
+		&model.UserBlock{},
+		&model.UserReport{},
+		&model.DeepLinkClick{},
+		&model.Experiment{},
+		&model.ExperimentAssignment{},
+		&model.ExperimentConversion{},
+		&model.UserOnboarding{},
+		&model.RetentionRun{},
+		&model.MediaCleanupRun{},
+		&model.WebhookEndpoint{},
+		&model.WebhookDelivery{},
+		&model.WebhookReplay{},
+		&model.IdempotencyKey{},
+		&model.StorageUsageSnapshot{},
+		&model.ParentalConsentRequest{},
+		&model.PartnerKey{},
+		&model.PartnerKeyUsage{},
+		&model.PartnerTier{},
+		&model.PartnerAccount{},
+		&model.PartnerMember{},
+		&model.PartnerConsent{},
+		&model.PartnerEnrollmentCode{},
+		&model.ConfigChange{},
+		&model.FoodName{},
+		&model.FoodLog{},
+		&model.FoodPopularity{},
+		&model.FoodCatalogEntry{},
+		&model.FoodSearch{},
+		&model.FoodImport{},
+		&model.FoodImportItem{},
+		&model.FoodPortion{},
+		&model.DietPreference{},
+		&model.FoodAlternativeFeedback{},
+		&model.FoodGrade{},
+		&model.TipRule{},
+		&model.DailyTip{},
+		&model.AssistantConversation{},
+		&model.AssistantMessage{},
+		&model.AssistantUsage{},
+		&model.DiaryExport{},
+		&model.DiaryShare{},
+		&model.DownloadLink{},
+		&model.DownloadAttempt{},
+		&model.EmailAttempt{},
+		&model.EmailSuppression{},
+		&model.ActivityLog{},
+		&model.PhoneOTP{},
+		&model.PhoneMessage{},
+		&model.Role{},
+		&model.MagicLink{},
+		&model.Passkey{},
+		&model.PasskeyChallenge{},
+		&model.LoginFailure{},
+		&model.UserCohortStats{},
+		&model.CohortAggregate{},
+		&model.PlanMigration{},
+		&model.PlanChange{},
+		&model.AdminAction{},
 	); err != nil {
 		log.Fatalf("Failed to auto-migrate database: %v", err)
 	}
 
+	// Needs the source column added by the auto-migration
+	if err := migrations.BackfillSubscriptionSource(db); err != nil {
+		utils.Log.Warnf("Failed to backfill subscription source: %v", err)
+	}
+
+	// Needs the status column and the events table added by the auto-migration
+	if err := migrations.BackfillSubscriptionStatus(db); err != nil {
+		utils.Log.Warnf("Failed to backfill subscription status: %v", err)
+	}
+
+	if err := migrations.BackfillRevenueRecognition(db); err != nil {
+		utils.Log.Warnf("Failed to backfill revenue recognition: %v", err)
+	}
+
+	// Lookups and counters read the archives, they must exist before the app serves
+	if err := migrations.CreateArchiveTables(db); err != nil {
+		log.Fatalf("Failed to create archive tables: %v", err)
+	}
+
+	// Counts the scans kept in the archives
+	if err := migrations.BackfillDomainEvents(db); err != nil {
+		utils.Log.Warnf("Failed to backfill domain events: %v", err)
+	}
+
+	// Food search matches names with trigrams while the search index is down
+	if err := migrations.CreateFoodNameTrigramIndexes(db); err != nil {
+		utils.Log.Warnf("Failed to create food name trigram indexes: %v", err)
+	}
+
+	// Counts the logs made before popularity was kept
+	if err := migrations.BackfillFoodPopularity(db); err != nil {
+		utils.Log.Warnf("Failed to backfill food popularity: %v", err)
+	}
+
 	// Run seeders
 	seeders.RunSeeder(db)
 }
@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// BackfillDomainEvents seeds an empty event log with the meals, logins and recognized payments recorded before it
+// existed, so replaying it rebuilds the whole history
+func BackfillDomainEvents(db *gorm.DB) error {
+	var existing int64
+	if err := db.Model(&model.DomainEvent{}).Limit(1).Count(&existing).Error; err != nil {
+		return fmt.Errorf("failed to check domain events: %w", err)
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	utils.Log.Info("Running migration: Backfill domain_events")
+
+	result := db.Exec(`
+		INSERT INTO domain_events (type, user_id, subject_id, payload, request_id, occurred_at)
+		SELECT type, user_id, subject_id, payload, '', occurred_at FROM (
+			SELECT ? AS type, meal_histories.user_id, meal_histories.id AS subject_id,
+				jsonb_build_object(
+					'day', TO_CHAR(meal_histories.meal_time, 'YYYY-MM-DD'),
+					'scans', (SELECT COUNT(*) FROM (
+						SELECT meal_history_id FROM meal_history_details
+						UNION ALL
+						SELECT meal_history_id FROM meal_history_details_archive
+					) AS scans WHERE scans.meal_history_id = meal_histories.id)
+				) AS payload,
+				meal_histories.created_at AS occurred_at
+			FROM meal_histories
+			UNION ALL
+			SELECT ?, login_streaks.user_id, login_streaks.id,
+				jsonb_build_object('day', TO_CHAR(login_streaks.login_date, 'YYYY-MM-DD'), 'streak', login_streaks.current_streak),
+				login_streaks.login_date
+			FROM login_streaks
+			UNION ALL
+			SELECT ?, user_subscriptions.user_id, revenue_recognitions.transaction_detail_id,
+				jsonb_build_object('amount', SUM(revenue_recognitions.amount)),
+				MIN(revenue_recognitions.paid_at)
+			FROM revenue_recognitions
+			JOIN user_subscriptions ON user_subscriptions.id = revenue_recognitions.user_subscription_id
+			GROUP BY user_subscriptions.user_id, revenue_recognitions.transaction_detail_id
+		) AS history
+		ORDER BY occurred_at
+	`, model.EventMealLogged, model.EventUserLoggedIn, model.EventRevenueRecognized)
+	if result.Error != nil {
+		return fmt.Errorf("failed to backfill domain events: %w", result.Error)
+	}
+
+	utils.Log.Infof("Backfilled %d domain events", result.RowsAffected)
+	return nil
+}
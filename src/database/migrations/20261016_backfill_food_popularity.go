@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"app/src/utils"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// BackfillFoodPopularity counts the logs of the foods logged before their popularity was kept. Foods
+// already counted are left alone, LogFood has counted every log of them.
+func BackfillFoodPopularity(db *gorm.DB) error {
+	utils.Log.Info("Running migration: Backfill food_popularities")
+
+	result := db.Exec(`
+		INSERT INTO food_popularities (food_kode, count)
+		SELECT food_kode, SUM(count) FROM food_logs GROUP BY food_kode
+		ON CONFLICT (food_kode) DO NOTHING
+	`)
+	if result.Error != nil {
+		return fmt.Errorf("failed to backfill food popularity: %w", result.Error)
+	}
+
+	utils.Log.Infof("Backfilled popularity of %d foods", result.RowsAffected)
+	return nil
+}
@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BackfillRevenueRecognition schedules revenue for paid transactions recorded before recognition existed
+func BackfillRevenueRecognition(db *gorm.DB) error {
+	utils.Log.Info("Running migration: Backfill revenue_recognitions")
+
+	var details []model.TransactionDetail
+	if err := db.
+		Preload("UserSubscription").
+		Where("transaction_status IN ?", []string{"capture", "settlement", "success"}).
+		Where("NOT EXISTS (SELECT 1 FROM revenue_recognitions WHERE revenue_recognitions.transaction_detail_id = transaction_details.id)").
+		Order("transaction_time ASC").
+		Find(&details).Error; err != nil {
+		return fmt.Errorf("failed to load paid transactions: %w", err)
+	}
+
+	// Gateways report some payments twice (capture then settlement), keep the first one per order
+	seen := make(map[string]bool)
+	var scheduled int
+	for i := range details {
+		detail := &details[i]
+		if detail.OrderID != "" {
+			if seen[detail.OrderID] {
+				continue
+			}
+			seen[detail.OrderID] = true
+		}
+
+		schedule := model.BuildRecognitionSchedule(detail, &detail.UserSubscription)
+		if len(schedule) == 0 {
+			continue
+		}
+
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&schedule).Error; err != nil {
+			return fmt.Errorf("failed to schedule revenue for transaction %s: %w", detail.ID, err)
+		}
+		scheduled++
+	}
+
+	utils.Log.Infof("Backfilled revenue recognition of %d transactions", scheduled)
+	return nil
+}
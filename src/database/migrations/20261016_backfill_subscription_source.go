@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"app/src/utils"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// BackfillSubscriptionSource sets the acquisition source of subscriptions created before it was stored
+func BackfillSubscriptionSource(db *gorm.DB) error {
+	utils.Log.Info("Running migration: Backfill user_subscriptions source")
+
+	result := db.Exec(`
+		UPDATE user_subscriptions
+		SET source = CASE
+			WHEN payment_method IN ('product_token', 'apple_iap', 'google_play') THEN payment_method
+			WHEN transaction_id LIKE 'MANUAL-%' THEN 'manual_transfer'
+			ELSE 'midtrans'
+		END
+		WHERE source IS NULL OR source = ''
+	`)
+	if result.Error != nil {
+		return fmt.Errorf("failed to backfill subscription source: %w", result.Error)
+	}
+
+	utils.Log.Infof("Backfilled source of %d subscriptions", result.RowsAffected)
+	return nil
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"app/src/utils"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// BackfillSubscriptionStatus derives the lifecycle status of subscriptions created before it was stored from
+// their payment, and records it as their first event
+func BackfillSubscriptionStatus(db *gorm.DB) error {
+	utils.Log.Info("Running migration: Backfill user_subscriptions status")
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`
+			UPDATE user_subscriptions
+			SET status = CASE
+				WHEN payment_status = 'success' AND NOT is_active THEN 'cancelled'
+				WHEN payment_status = 'success' AND end_date <= NOW() THEN 'expired'
+				WHEN payment_status = 'success' THEN 'active'
+				WHEN payment_status = 'suspended' AND end_date <= NOW() THEN 'expired'
+				WHEN payment_status = 'suspended' THEN 'suspended'
+				WHEN payment_status = 'refunded' THEN 'cancelled'
+				WHEN payment_status = 'expired' THEN 'expired'
+				ELSE 'pending'
+			END,
+			status_changed_at = created_at
+			WHERE status_changed_at IS NULL
+		`)
+		if result.Error != nil {
+			return fmt.Errorf("failed to backfill subscription status: %w", result.Error)
+		}
+
+		if err := tx.Exec(`
+			INSERT INTO subscription_events (id, user_subscription_id, from_status, to_status, reason, occurred_at)
+			SELECT uuid_generate_v4(), s.id, '', s.status, 'backfilled', s.created_at
+			FROM user_subscriptions s
+			WHERE NOT EXISTS (SELECT 1 FROM subscription_events e WHERE e.user_subscription_id = s.id)
+		`).Error; err != nil {
+			return fmt.Errorf("failed to backfill subscription events: %w", err)
+		}
+
+		utils.Log.Infof("Backfilled status of %d subscriptions", result.RowsAffected)
+		return nil
+	})
+}
@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// archiveIndexes are the lookup columns indexed on each archive besides the ID
+var archiveIndexes = map[string][]string{
+	model.TransactionDetailsTable: {"order_id", "user_subscription_id"},
+	model.MealHistoryDetailsTable: {"meal_history_id"},
+}
+
+// CreateArchiveTables creates the archive of every archived table, partitioned by year of creation,
+// and adds the columns the live tables gained since. The archival job creates the partitions.
+func CreateArchiveTables(db *gorm.DB) error {
+	utils.Log.Info("Running migration: Create archive tables")
+
+	for _, table := range model.ArchivedTables {
+		archive := model.ArchiveTable(table)
+
+		if err := db.Exec(fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s (LIKE %s INCLUDING DEFAULTS) PARTITION BY RANGE (created_at)`,
+			archive, table,
+		)).Error; err != nil {
+			return fmt.Errorf("failed to create %s: %w", archive, err)
+		}
+
+		var missing []struct {
+			Name string
+			Type string
+		}
+		if err := db.Raw(`
+			SELECT live.attname AS name, format_type(live.atttypid, live.atttypmod) AS type
+			FROM pg_attribute live
+			WHERE live.attrelid = ?::regclass AND live.attnum > 0 AND NOT live.attisdropped
+				AND NOT EXISTS (
+					SELECT 1 FROM pg_attribute archived
+					WHERE archived.attrelid = ?::regclass AND archived.attname = live.attname AND NOT archived.attisdropped
+				)
+			ORDER BY live.attnum
+		`, table, archive).Scan(&missing).Error; err != nil {
+			return fmt.Errorf("failed to compare the columns of %s: %w", archive, err)
+		}
+
+		for _, column := range missing {
+			if err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %q %s`, archive, column.Name, column.Type)).Error; err != nil {
+				return fmt.Errorf("failed to add %s to %s: %w", column.Name, archive, err)
+			}
+		}
+
+		for _, column := range append([]string{"id"}, archiveIndexes[table]...) {
+			if err := db.Exec(fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_%s ON %s (%s)`, archive, column, archive, column)).Error; err != nil {
+				return fmt.Errorf("failed to index %s: %w", archive, err)
+			}
+		}
+	}
+
+	return nil
+}
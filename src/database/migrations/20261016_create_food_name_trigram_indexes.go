@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"app/src/utils"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// CreateFoodNameTrigramIndexes enables pg_trgm and indexes the names food search matches in the database,
+// for ILIKE and similarity alike
+func CreateFoodNameTrigramIndexes(db *gorm.DB) error {
+	utils.Log.Info("Running migration: Create food name trigram indexes")
+
+	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`).Error; err != nil {
+		return fmt.Errorf("failed to enable pg_trgm: %w", err)
+	}
+
+	for _, table := range []string{"food_catalog_entries", "food_names"} {
+		if err := db.Exec(fmt.Sprintf(
+			`CREATE INDEX IF NOT EXISTS idx_%s_name_trgm ON %s USING GIN (name gin_trgm_ops)`, table, table,
+		)).Error; err != nil {
+			return fmt.Errorf("failed to index %s names: %w", table, err)
+		}
+	}
+
+	return nil
+}
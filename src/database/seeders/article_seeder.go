@@ -311,7 +311,7 @@ Healthy living involves making conscious choices that promote overall well-being
 			Title:       title,
 			CategoryID:  &categoryID,
 			Slug:        slug,
-			Image:       &imageURL,
+			Image:       (*model.ContentImage)(&imageURL),
 			Content:     content,
 			PublishedAt: &publishedAt,
 			CreatedAt:   publishedAt,
@@ -329,7 +329,7 @@ Healthy living involves making conscious choices that promote overall well-being
 				Title:       modifiedTitle,
 				CategoryID:  &categoryID,
 				Slug:        utils.Slugify(modifiedTitle),
-				Image:       &imageURL,
+				Image:       (*model.ContentImage)(&imageURL),
 				Content:     content,
 				PublishedAt: &publishedAt,
 				CreatedAt:   publishedAt,
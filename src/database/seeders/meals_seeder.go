@@ -109,7 +109,7 @@ func SeedMeals(db *gorm.DB) {
 				Protein:        float64(protein),
 				Carbs:          float64(carbs),
 				Fat:            float64(fat),
-				MealImage:      mealImage,
+				MealImage:      model.ScanImage(mealImage),
 				Recommendation: nil,
 				CreatedAt:      mealTime,
 				UpdatedAt:      mealTime,
@@ -74,5 +74,6 @@ func RunSeeder(db *gorm.DB) {
 	SeedArticles(db)
 	SeedRecipes(db)
 	SeedSubscriptionPlans(db)
+	SeedTipRules(db)
 	log.Println("🎉 Database seeding completed!")
 }
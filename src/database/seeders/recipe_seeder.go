@@ -60,7 +60,7 @@ func SeedRecipes(db *gorm.DB) {
 			Instructions: instructions,
 			Label:        &labels[rand.Intn(len(labels))],
 			Day:          days[rand.Intn(len(days))],
-			Image:        &imageURL,
+			Image:        (*model.ContentImage)(&imageURL),
 		})
 	}
 
@@ -2,7 +2,6 @@ package seeders
 
 import (
 	"app/src/model"
-	"encoding/json"
 	"log"
 
 	"github.com/google/uuid"
@@ -24,13 +23,13 @@ func SeedSubscriptionPlans(db *gorm.DB) {
 			15000,
 			10,
 			30,
-			map[string]bool{
-				"scan_ai":         true,
-				"scan_calorie":    true,
-				"chatbot":         true,
-				"bmi_check":       false,
-				"weight_tracking": false,
-				"health_info":     false,
+			model.PlanFeatures{
+				ScanAI:         true,
+				ScanCalorie:    true,
+				Chatbot:        true,
+				BMICheck:       false,
+				WeightTracking: false,
+				HealthInfo:     false,
 			},
 			"Paket dasar untuk pemula",
 		),
@@ -39,13 +38,13 @@ func SeedSubscriptionPlans(db *gorm.DB) {
 			99000,
 			60,
 			90,
-			map[string]bool{
-				"scan_ai":         true,
-				"scan_calorie":    true,
-				"chatbot":         true,
-				"bmi_check":       true,
-				"weight_tracking": true,
-				"health_info":     true,
+			model.PlanFeatures{
+				ScanAI:         true,
+				ScanCalorie:    true,
+				Chatbot:        true,
+				BMICheck:       true,
+				WeightTracking: true,
+				HealthInfo:     true,
 			},
 			"Paket premium dengan semua fitur",
 			true, // Mark as best seller
@@ -55,13 +54,13 @@ func SeedSubscriptionPlans(db *gorm.DB) {
 			30000,
 			10,
 			30,
-			map[string]bool{
-				"scan_ai":         true,
-				"scan_calorie":    true,
-				"chatbot":         true,
-				"bmi_check":       true,
-				"weight_tracking": false,
-				"health_info":     false,
+			model.PlanFeatures{
+				ScanAI:         true,
+				ScanCalorie:    true,
+				Chatbot:        true,
+				BMICheck:       true,
+				WeightTracking: false,
+				HealthInfo:     false,
 			},
 			"Paket best seller dengan fitur lengkap",
 		),
@@ -70,13 +69,13 @@ func SeedSubscriptionPlans(db *gorm.DB) {
 			120000,
 			30,
 			90,
-			map[string]bool{
-				"scan_ai":         true,
-				"scan_calorie":    true,
-				"chatbot":         true,
-				"bmi_check":       true,
-				"weight_tracking": true,
-				"health_info":     true,
+			model.PlanFeatures{
+				ScanAI:         true,
+				ScanCalorie:    true,
+				Chatbot:        true,
+				BMICheck:       true,
+				WeightTracking: true,
+				HealthInfo:     true,
 			},
 			"Paket premium dengan semua fitur",
 		),
@@ -89,18 +88,16 @@ func SeedSubscriptionPlans(db *gorm.DB) {
 	log.Printf("✅ %d subscription plans seeded successfully", len(plans))
 }
 
-// Helper function to create plan with JSON features
+// Helper function to create plan with its features
 func createPlan(
 	name string,
 	price int,
 	scanLimit int,
 	validityDays int,
-	features map[string]bool,
+	features model.PlanFeatures,
 	description string,
 	isBestSeller ...bool,
 ) model.SubscriptionPlan {
-	featuresJSON, _ := json.Marshal(features)
-
 	plan := model.SubscriptionPlan{
 		ID:           uuid.New(),
 		Name:         name,
@@ -108,7 +105,7 @@ func createPlan(
 		Description:  description,
 		AIscanLimit:  scanLimit,
 		ValidityDays: validityDays,
-		Features:     string(featuresJSON),
+		Features:     features,
 		IsActive:     true,
 	}
 
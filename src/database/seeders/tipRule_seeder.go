@@ -0,0 +1,26 @@
+package seeders
+
+import (
+	"app/src/model"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+func SeedTipRules(db *gorm.DB) {
+	var count int64
+	db.Model(&model.TipRule{}).Count(&count)
+
+	if count > 0 {
+		log.Println("✅ Tip rules already seeded, skipping...")
+		return
+	}
+
+	rules := make([]model.TipRule, len(model.DefaultTipRules))
+	copy(rules, model.DefaultTipRules)
+	if err := db.Create(&rules).Error; err != nil {
+		log.Fatalf("Failed to seed tip rules: %v", err)
+	}
+
+	log.Printf("✅ %d tip rules seeded successfully", len(rules))
+}
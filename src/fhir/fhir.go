@@ -0,0 +1,190 @@
+// Package fhir maps nutrition data to HL7 FHIR R4 resources for clinics and hospitals. Only the parts of
+// Observation and Bundle the export fills in are modelled.
+package fhir
+
+import (
+	"math"
+	"time"
+)
+
+// ContentType is the media type of FHIR JSON
+const ContentType = "application/fhir+json; charset=utf-8"
+
+// Code systems
+const (
+	SystemLOINC               = "http://loinc.org"
+	SystemUCUM                = "http://unitsofmeasure.org"
+	SystemObservationCategory = "http://terminology.hl7.org/CodeSystem/observation-category"
+	SystemNutriboxNutrient    = "https://nutribox.id/fhir/CodeSystem/nutrient"
+)
+
+// Observation categories
+const (
+	CategoryVitalSigns = "vital-signs"
+	// CategorySurvey is used for intake, which the patient reports in their food diary
+	CategorySurvey = "survey"
+)
+
+// LOINC codes of the observations
+const (
+	LOINCBodyWeight  = "29463-7"
+	LOINCBodyHeight  = "8302-2"
+	LOINCBMI         = "39156-5"
+	LOINCEnergyTotal = "9052-2"
+)
+
+// Coding is a code in a code system
+type Coding struct {
+	System  string `json:"system"`
+	Code    string `json:"code"`
+	Display string `json:"display,omitempty"`
+}
+
+// CodeableConcept is a concept given by codings and text
+type CodeableConcept struct {
+	Coding []Coding `json:"coding"`
+	Text   string   `json:"text,omitempty"`
+}
+
+// Quantity is a measured amount with its UCUM unit
+type Quantity struct {
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit"`
+	System string  `json:"system"`
+	Code   string  `json:"code"`
+}
+
+// Reference points to another resource
+type Reference struct {
+	Reference string `json:"reference"`
+}
+
+// Period is a time range, both ends inclusive
+type Period struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Component is a part of an observation with its own code and value
+type Component struct {
+	Code          CodeableConcept `json:"code"`
+	ValueQuantity Quantity        `json:"valueQuantity"`
+}
+
+// Observation is a measurement or a reported amount about a patient
+type Observation struct {
+	ResourceType      string            `json:"resourceType"`
+	ID                string            `json:"id"`
+	Status            string            `json:"status"`
+	Category          []CodeableConcept `json:"category"`
+	Code              CodeableConcept   `json:"code"`
+	Subject           Reference         `json:"subject"`
+	EffectiveDateTime string            `json:"effectiveDateTime,omitempty"`
+	EffectivePeriod   *Period           `json:"effectivePeriod,omitempty"`
+	ValueQuantity     *Quantity         `json:"valueQuantity,omitempty"`
+	Component         []Component       `json:"component,omitempty"`
+}
+
+// BundleEntry is a resource of a bundle
+type BundleEntry struct {
+	Resource Observation `json:"resource"`
+}
+
+// Bundle is a set of observations answering a search. FHIR JSON has no empty arrays, a bundle without
+// results has no entry.
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Type         string        `json:"type"`
+	Timestamp    string        `json:"timestamp"`
+	Total        int           `json:"total"`
+	Entry        []BundleEntry `json:"entry,omitempty"`
+}
+
+// Patient is the reference to the patient an observation is about
+func Patient(id string) Reference {
+	return Reference{Reference: "Patient/" + id}
+}
+
+// SearchSet bundles observations as the result of a search
+func SearchSet(observations []Observation, now time.Time) Bundle {
+	bundle := Bundle{
+		ResourceType: "Bundle",
+		Type:         "searchset",
+		Timestamp:    now.Format(time.RFC3339),
+		Total:        len(observations),
+		Entry:        make([]BundleEntry, len(observations)),
+	}
+	for i, observation := range observations {
+		bundle.Entry[i] = BundleEntry{Resource: observation}
+	}
+	return bundle
+}
+
+// BodyWeight is a weight in kilograms measured at
+func BodyWeight(id string, patient Reference, at time.Time, kg float64) Observation {
+	return vitalSign(id, patient, at, LOINCBodyWeight, "Body weight", quantity(kg, "kg", "kg"))
+}
+
+// BodyHeight is a height in centimetres measured at
+func BodyHeight(id string, patient Reference, at time.Time, cm float64) Observation {
+	return vitalSign(id, patient, at, LOINCBodyHeight, "Body height", quantity(cm, "cm", "cm"))
+}
+
+// BMI is a body mass index computed from a weight and height measured at
+func BMI(id string, patient Reference, at time.Time, bmi float64) Observation {
+	return vitalSign(id, patient, at, LOINCBMI, "Body mass index (BMI) [Ratio]", quantity(bmi, "kg/m2", "kg/m2"))
+}
+
+// DailyIntake is the energy and macronutrients a patient reported eating on day, a date of the zone of
+// the diary
+func DailyIntake(id string, patient Reference, day time.Time, kcal, proteinG, carbsG, fatG float64) Observation {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	energy := quantity(kcal, "kcal", "kcal")
+	return Observation{
+		ResourceType: "Observation",
+		ID:           id,
+		Status:       "final",
+		Category:     []CodeableConcept{category(CategorySurvey, "Survey")},
+		Code: CodeableConcept{
+			Coding: []Coding{{System: SystemLOINC, Code: LOINCEnergyTotal, Display: "Calorie intake total"}},
+			Text:   "Daily nutrition intake",
+		},
+		Subject:         patient,
+		EffectivePeriod: &Period{Start: start.Format(time.RFC3339), End: start.AddDate(0, 0, 1).Add(-time.Second).Format(time.RFC3339)},
+		ValueQuantity:   &energy,
+		Component: []Component{
+			nutrient("protein", "Protein", proteinG),
+			nutrient("carbohydrate", "Carbohydrate", carbsG),
+			nutrient("fat", "Total fat", fatG),
+		},
+	}
+}
+
+func vitalSign(id string, patient Reference, at time.Time, code, display string, value Quantity) Observation {
+	return Observation{
+		ResourceType:      "Observation",
+		ID:                id,
+		Status:            "final",
+		Category:          []CodeableConcept{category(CategoryVitalSigns, "Vital Signs")},
+		Code:              CodeableConcept{Coding: []Coding{{System: SystemLOINC, Code: code, Display: display}}, Text: display},
+		Subject:           patient,
+		EffectiveDateTime: at.Format(time.RFC3339),
+		ValueQuantity:     &value,
+	}
+}
+
+func category(code, display string) CodeableConcept {
+	return CodeableConcept{Coding: []Coding{{System: SystemObservationCategory, Code: code, Display: display}}}
+}
+
+func nutrient(code, display string, grams float64) Component {
+	return Component{
+		Code:          CodeableConcept{Coding: []Coding{{System: SystemNutriboxNutrient, Code: code, Display: display}}, Text: display},
+		ValueQuantity: quantity(grams, "g", "g"),
+	}
+}
+
+// quantity rounds value to two decimals, as the amounts are stored
+func quantity(value float64, unit, code string) Quantity {
+	return Quantity{Value: math.Round(value*100) / 100, Unit: unit, System: SystemUCUM, Code: code}
+}
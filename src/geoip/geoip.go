@@ -0,0 +1,197 @@
+// Package geoip locates the IP addresses of requests, from the headers a CDN adds or with an IP lookup service
+package geoip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// LocalsKey is the key the location of a request is kept under in its locals
+const LocalsKey = "geo"
+
+// Lookup services New knows
+const (
+	ProviderIPinfo = "ipinfo" // ipinfo.io, the token goes in Settings.Token
+	ProviderIPAPI  = "ipapi"  // ip-api.com, only its paid service answers over HTTPS, the key goes in Settings.Token
+)
+
+// lookupURLs are the URLs of the services, {ip} is replaced by the address and {token} by the token
+var lookupURLs = map[string]string{
+	ProviderIPinfo: "https://ipinfo.io/{ip}/json",
+	ProviderIPAPI:  "https://pro.ip-api.com/json/{ip}?fields=status,countryCode,regionName,city&key={token}",
+}
+
+// Location is where an IP address is, as precise as a city. Country is an ISO 3166-1 alpha-2 code, empty when
+// the address could not be located.
+type Location struct {
+	Country string `json:"country,omitempty"`
+	Region  string `json:"region,omitempty"`
+	City    string `json:"city,omitempty"`
+}
+
+// Known tells whether the country was found
+func (l Location) Known() bool {
+	return l.Country != ""
+}
+
+// NormalizeCountry returns country as an upper case alpha-2 code. The codes CDNs send for unknown addresses and
+// Tor ("XX", "T1") and anything else that is not a code come back empty.
+func NormalizeCountry(country string) string {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if len(country) != 2 || country == "XX" || country == "T1" {
+		return ""
+	}
+	for _, r := range country {
+		if r < 'A' || r > 'Z' {
+			return ""
+		}
+	}
+	return country
+}
+
+// IsPublic tells whether ip is a public address a lookup can locate, private, loopback and link local ones
+// cannot be
+func IsPublic(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	return addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
+
+// Locator looks IP addresses up with one service
+type Locator interface {
+	Name() string
+	Locate(ctx context.Context, ip string) (Location, error)
+}
+
+// Settings of the lookup services
+type Settings struct {
+	Token    string
+	Endpoint string // replaces the lookup URL of the service when set, {ip} is replaced by the address and {token} by the token
+	Timeout  time.Duration
+}
+
+// New returns the locator of provider, nil when lookups are off and only the CDN headers are read
+func New(provider string, settings Settings) (Locator, error) {
+	switch provider {
+	case "":
+		return nil, nil
+	case ProviderIPinfo, ProviderIPAPI:
+		endpoint := settings.Endpoint
+		if endpoint == "" {
+			endpoint = lookupURLs[provider]
+		}
+		if !strings.Contains(endpoint, "{ip}") {
+			return nil, fmt.Errorf("geoip: the endpoint of %s has no {ip}", provider)
+		}
+		if strings.Contains(endpoint, "{token}") && settings.Token == "" {
+			return nil, fmt.Errorf("geoip: %s needs a token", provider)
+		}
+		if !isSecure(endpoint) {
+			return nil, fmt.Errorf("geoip: the endpoint of %s is not HTTPS, the addresses of users would go in clear", provider)
+		}
+		return &lookup{
+			provider: provider,
+			endpoint: endpoint,
+			token:    settings.Token,
+			client:   &http.Client{Timeout: settings.Timeout},
+		}, nil
+	}
+	return nil, fmt.Errorf("geoip: unknown provider %q", provider)
+}
+
+type lookup struct {
+	provider string
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+// lookupResponse holds the fields of both services, ipinfo names them country and region, ip-api countryCode
+// and regionName
+type lookupResponse struct {
+	Status      string `json:"status"` // ip-api only, "fail" for addresses it cannot locate
+	Country     string `json:"country"`
+	CountryCode string `json:"countryCode"`
+	Region      string `json:"region"`
+	RegionName  string `json:"regionName"`
+	City        string `json:"city"`
+	Bogon       bool   `json:"bogon"` // ipinfo only, set for reserved addresses
+}
+
+func (l *lookup) Name() string {
+	return l.provider
+}
+
+// isSecure tells whether the lookups to endpoint are encrypted, plain HTTP is only allowed on the loopback
+func isSecure(endpoint string) bool {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	if parsed.Scheme == "https" {
+		return true
+	}
+	if parsed.Scheme != "http" {
+		return false
+	}
+	if parsed.Hostname() == "localhost" {
+		return true
+	}
+	addr, err := netip.ParseAddr(parsed.Hostname())
+	return err == nil && addr.IsLoopback()
+}
+
+func (l *lookup) Locate(ctx context.Context, ip string) (Location, error) {
+	target := strings.NewReplacer("{ip}", url.PathEscape(ip), "{token}", url.QueryEscape(l.token)).Replace(l.endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return Location{}, fmt.Errorf("%s: %w", l.provider, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if l.token != "" && l.provider == ProviderIPinfo {
+		req.Header.Set("Authorization", "Bearer "+l.token)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return Location{}, fmt.Errorf("%s: %w", l.provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Location{}, fmt.Errorf("%s: lookup answered %d", l.provider, resp.StatusCode)
+	}
+
+	var answer lookupResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&answer); err != nil {
+		return Location{}, fmt.Errorf("%s: %w", l.provider, err)
+	}
+	if answer.Status == "fail" || answer.Bogon {
+		return Location{}, nil
+	}
+
+	country := answer.Country
+	if answer.CountryCode != "" {
+		country = answer.CountryCode
+	}
+	region := answer.Region
+	if answer.RegionName != "" {
+		region = answer.RegionName
+	}
+
+	location := Location{Country: NormalizeCountry(country)}
+	if location.Known() {
+		location.Region = strings.TrimSpace(region)
+		location.City = strings.TrimSpace(answer.City)
+	}
+	return location, nil
+}
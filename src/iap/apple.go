@@ -0,0 +1,313 @@
+package iap
+
+import (
+	"app/src/model"
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	appleProductionURL = "https://buy.itunes.apple.com/verifyReceipt"
+	appleSandboxURL    = "https://sandbox.itunes.apple.com/verifyReceipt"
+
+	// appleStatusSandboxReceipt is returned by production when a sandbox receipt is sent to it
+	appleStatusSandboxReceipt = 21007
+)
+
+// AppleClient validates App Store receipts and App Store Server Notifications V2
+type AppleClient struct {
+	SharedSecret string
+	BundleID     string
+	RootCAs      *x509.CertPool
+	HTTP         *http.Client
+}
+
+// AppleNotification is a verified App Store Server Notification
+type AppleNotification struct {
+	NotificationType string
+	Subtype          string
+	Receipt          *Receipt
+}
+
+type appleVerifyResponse struct {
+	Status      int    `json:"status"`
+	Environment string `json:"environment"`
+	Receipt     struct {
+		BundleID string `json:"bundle_id"`
+	} `json:"receipt"`
+	LatestReceiptInfo []struct {
+		ProductID             string `json:"product_id"`
+		TransactionID         string `json:"transaction_id"`
+		OriginalTransactionID string `json:"original_transaction_id"`
+		ExpiresDateMs         string `json:"expires_date_ms"`
+		CancellationDateMs    string `json:"cancellation_date_ms"`
+	} `json:"latest_receipt_info"`
+	PendingRenewalInfo []struct {
+		OriginalTransactionID string `json:"original_transaction_id"`
+		AutoRenewStatus       string `json:"auto_renew_status"`
+	} `json:"pending_renewal_info"`
+}
+
+type appleNotificationPayload struct {
+	jwt.RegisteredClaims
+	NotificationType string `json:"notificationType"`
+	Subtype          string `json:"subtype"`
+	Data             struct {
+		BundleID              string `json:"bundleId"`
+		Environment           string `json:"environment"`
+		SignedTransactionInfo string `json:"signedTransactionInfo"`
+		SignedRenewalInfo     string `json:"signedRenewalInfo"`
+	} `json:"data"`
+}
+
+type appleTransactionInfo struct {
+	jwt.RegisteredClaims
+	OriginalTransactionID string `json:"originalTransactionId"`
+	TransactionID         string `json:"transactionId"`
+	ProductID             string `json:"productId"`
+	BundleID              string `json:"bundleId"`
+	ExpiresDate           int64  `json:"expiresDate"`
+	RevocationDate        int64  `json:"revocationDate"`
+}
+
+type appleRenewalInfo struct {
+	jwt.RegisteredClaims
+	AutoRenewStatus int `json:"autoRenewStatus"`
+}
+
+// NewAppleClient creates an App Store client, rootCAPath points to the Apple Root CA - G3 certificate in PEM format
+func NewAppleClient(sharedSecret, bundleID, rootCAPath string) (*AppleClient, error) {
+	if sharedSecret == "" || bundleID == "" {
+		return nil, errors.New("apple in-app purchases are not configured")
+	}
+
+	client := &AppleClient{
+		SharedSecret: sharedSecret,
+		BundleID:     bundleID,
+		HTTP:         newHTTPClient(),
+	}
+
+	if rootCAPath != "" {
+		pem, err := os.ReadFile(rootCAPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read apple root certificate: %w", err)
+		}
+
+		client.RootCAs = x509.NewCertPool()
+		if !client.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("invalid apple root certificate")
+		}
+	}
+
+	return client, nil
+}
+
+// VerifyReceipt validates a base64 encoded app receipt and returns its most recent subscription period. Sandbox
+// receipts are validated against the sandbox, the receipt tells its environment so callers can refuse them.
+func (c *AppleClient) VerifyReceipt(ctx context.Context, receiptData string) (*Receipt, error) {
+	raw, result, err := c.postReceipt(ctx, appleProductionURL, receiptData)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Status == appleStatusSandboxReceipt {
+		raw, result, err = c.postReceipt(ctx, appleSandboxURL, receiptData)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if result.Status != 0 {
+		return nil, fmt.Errorf("apple rejected receipt with status %d", result.Status)
+	}
+
+	if result.Receipt.BundleID != c.BundleID {
+		return nil, fmt.Errorf("receipt belongs to another app: %s", result.Receipt.BundleID)
+	}
+
+	if len(result.LatestReceiptInfo) == 0 {
+		return nil, errors.New("receipt contains no subscription")
+	}
+
+	latest := result.LatestReceiptInfo[0]
+	latestExpiry := parseMillis(latest.ExpiresDateMs)
+	for _, info := range result.LatestReceiptInfo[1:] {
+		if expiry := parseMillis(info.ExpiresDateMs); expiry.After(latestExpiry) {
+			latest, latestExpiry = info, expiry
+		}
+	}
+
+	receipt := &Receipt{
+		Store:                 model.StoreApple,
+		ProductID:             latest.ProductID,
+		OriginalTransactionID: latest.OriginalTransactionID,
+		TransactionID:         latest.TransactionID,
+		ExpiresAt:             latestExpiry,
+		Revoked:               latest.CancellationDateMs != "",
+		Environment:           result.Environment,
+		Raw:                   raw,
+	}
+	receipt.Active = !receipt.Revoked && latestExpiry.After(time.Now())
+
+	for _, renewal := range result.PendingRenewalInfo {
+		if renewal.OriginalTransactionID == latest.OriginalTransactionID {
+			receipt.AutoRenew = renewal.AutoRenewStatus == "1"
+		}
+	}
+
+	return receipt, nil
+}
+
+// ParseNotification verifies the signature of an App Store Server Notification V2 and decodes it
+func (c *AppleClient) ParseNotification(signedPayload string) (*AppleNotification, error) {
+	payload := new(appleNotificationPayload)
+	if err := c.parseSigned(signedPayload, payload); err != nil {
+		return nil, err
+	}
+
+	if payload.Data.BundleID != c.BundleID {
+		return nil, fmt.Errorf("notification belongs to another app: %s", payload.Data.BundleID)
+	}
+
+	notification := &AppleNotification{
+		NotificationType: payload.NotificationType,
+		Subtype:          payload.Subtype,
+	}
+
+	// Notifications such as TEST carry no transaction
+	if payload.Data.SignedTransactionInfo == "" {
+		return notification, nil
+	}
+
+	transaction := new(appleTransactionInfo)
+	if err := c.parseSigned(payload.Data.SignedTransactionInfo, transaction); err != nil {
+		return nil, err
+	}
+
+	raw, _ := json.Marshal(transaction)
+	expiresAt := time.UnixMilli(transaction.ExpiresDate)
+	receipt := &Receipt{
+		Store:                 model.StoreApple,
+		ProductID:             transaction.ProductID,
+		OriginalTransactionID: transaction.OriginalTransactionID,
+		TransactionID:         transaction.TransactionID,
+		ExpiresAt:             expiresAt,
+		Revoked:               transaction.RevocationDate > 0,
+		Environment:           payload.Data.Environment,
+		Raw:                   raw,
+	}
+	receipt.Active = !receipt.Revoked && expiresAt.After(time.Now())
+
+	if payload.Data.SignedRenewalInfo != "" {
+		renewal := new(appleRenewalInfo)
+		if err := c.parseSigned(payload.Data.SignedRenewalInfo, renewal); err != nil {
+			return nil, err
+		}
+		receipt.AutoRenew = renewal.AutoRenewStatus == 1
+	}
+
+	notification.Receipt = receipt
+	return notification, nil
+}
+
+func (c *AppleClient) postReceipt(ctx context.Context, url, receiptData string) ([]byte, *appleVerifyResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"receipt-data":             receiptData,
+		"password":                 c.SharedSecret,
+		"exclude-old-transactions": true,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("apple receipt validation failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid apple response: %w", err)
+	}
+
+	result := new(appleVerifyResponse)
+	if err := json.Unmarshal(raw, result); err != nil {
+		return nil, nil, fmt.Errorf("invalid apple response: %w", err)
+	}
+
+	return raw, result, nil
+}
+
+// parseSigned verifies a JWS signed by Apple, the signing certificate chain in the x5c header must lead to the Apple root
+func (c *AppleClient) parseSigned(signed string, claims jwt.Claims) error {
+	if c.RootCAs == nil {
+		return errors.New("apple root certificate is not configured")
+	}
+
+	_, err := jwt.ParseWithClaims(signed, claims, func(token *jwt.Token) (interface{}, error) {
+		chain, ok := token.Header["x5c"].([]interface{})
+		if !ok || len(chain) == 0 {
+			return nil, errors.New("missing x5c header")
+		}
+
+		certs := make([]*x509.Certificate, 0, len(chain))
+		for _, item := range chain {
+			encoded, ok := item.(string)
+			if !ok {
+				return nil, errors.New("invalid x5c header")
+			}
+			der, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, err
+			}
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		if _, err := certs[0].Verify(x509.VerifyOptions{
+			Roots:         c.RootCAs,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}); err != nil {
+			return nil, fmt.Errorf("untrusted signing certificate: %w", err)
+		}
+
+		return certs[0].PublicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg()}))
+
+	return err
+}
+
+func parseMillis(value string) time.Time {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
@@ -0,0 +1,203 @@
+package iap
+
+import (
+	"app/src/model"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	googlePublisherScope = "https://www.googleapis.com/auth/androidpublisher"
+	googlePublisherURL   = "https://androidpublisher.googleapis.com/androidpublisher/v3/applications"
+
+	// GoogleNotificationRevoked is sent when a subscription is refunded and revoked
+	GoogleNotificationRevoked = 12
+)
+
+// GoogleClient validates Google Play subscription purchases through the Play Developer API
+type GoogleClient struct {
+	PackageName string
+	HTTP        *http.Client
+}
+
+// GoogleNotification is a Real-time Developer Notification delivered through Pub/Sub
+type GoogleNotification struct {
+	PackageName      string
+	NotificationType int
+	PurchaseToken    string
+	ProductID        string
+	IsTest           bool
+}
+
+type googleSubscription struct {
+	SubscriptionState    string          `json:"subscriptionState"`
+	LatestOrderID        string          `json:"latestOrderId"`
+	AcknowledgementState string          `json:"acknowledgementState"`
+	TestPurchase         json.RawMessage `json:"testPurchase"`
+	LineItems            []struct {
+		ProductID        string    `json:"productId"`
+		ExpiryTime       time.Time `json:"expiryTime"`
+		AutoRenewingPlan *struct {
+			AutoRenewEnabled bool `json:"autoRenewEnabled"`
+		} `json:"autoRenewingPlan"`
+	} `json:"lineItems"`
+}
+
+type googlePubSubMessage struct {
+	Message struct {
+		Data string `json:"data"`
+	} `json:"message"`
+}
+
+type googleDeveloperNotification struct {
+	PackageName              string `json:"packageName"`
+	SubscriptionNotification *struct {
+		NotificationType int    `json:"notificationType"`
+		PurchaseToken    string `json:"purchaseToken"`
+		SubscriptionID   string `json:"subscriptionId"`
+	} `json:"subscriptionNotification"`
+	TestNotification json.RawMessage `json:"testNotification"`
+}
+
+// NewGoogleClient creates a Play Developer API client authenticated with a service account key file
+func NewGoogleClient(ctx context.Context, packageName, serviceAccountPath string) (*GoogleClient, error) {
+	if packageName == "" || serviceAccountPath == "" {
+		return nil, errors.New("google play purchases are not configured")
+	}
+
+	key, err := os.ReadFile(serviceAccountPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read google service account: %w", err)
+	}
+
+	conf, err := google.JWTConfigFromJSON(key, googlePublisherScope)
+	if err != nil {
+		return nil, fmt.Errorf("invalid google service account: %w", err)
+	}
+
+	httpClient := conf.Client(ctx)
+	httpClient.Timeout = httpTimeout
+
+	return &GoogleClient{
+		PackageName: packageName,
+		HTTP:        httpClient,
+	}, nil
+}
+
+// GetSubscription fetches the current state of a subscription purchase
+func (c *GoogleClient) GetSubscription(ctx context.Context, purchaseToken string) (*Receipt, bool, error) {
+	endpoint := fmt.Sprintf("%s/%s/purchases/subscriptionsv2/tokens/%s",
+		googlePublisherURL, url.PathEscape(c.PackageName), url.PathEscape(purchaseToken))
+
+	raw, err := c.do(ctx, http.MethodGet, endpoint)
+	if err != nil {
+		return nil, false, err
+	}
+
+	subscription := new(googleSubscription)
+	if err := json.Unmarshal(raw, subscription); err != nil {
+		return nil, false, fmt.Errorf("invalid google response: %w", err)
+	}
+
+	if len(subscription.LineItems) == 0 {
+		return nil, false, errors.New("purchase contains no subscription")
+	}
+
+	item := subscription.LineItems[0]
+	receipt := &Receipt{
+		Store:                 model.StoreGoogle,
+		ProductID:             item.ProductID,
+		OriginalTransactionID: purchaseToken,
+		TransactionID:         subscription.LatestOrderID,
+		ExpiresAt:             item.ExpiryTime,
+		AutoRenew:             item.AutoRenewingPlan != nil && item.AutoRenewingPlan.AutoRenewEnabled,
+		Environment:           "production",
+		Raw:                   raw,
+	}
+	if len(subscription.TestPurchase) > 0 {
+		receipt.Environment = "test"
+	}
+
+	switch subscription.SubscriptionState {
+	case "SUBSCRIPTION_STATE_ACTIVE", "SUBSCRIPTION_STATE_IN_GRACE_PERIOD", "SUBSCRIPTION_STATE_CANCELED":
+		// Canceled subscriptions stay usable until the end of the paid period
+		receipt.Active = item.ExpiryTime.After(time.Now())
+	}
+
+	needsAck := subscription.AcknowledgementState == "ACKNOWLEDGEMENT_STATE_PENDING"
+	return receipt, needsAck, nil
+}
+
+// Acknowledge confirms a purchase to Google Play, unacknowledged purchases are refunded after three days
+func (c *GoogleClient) Acknowledge(ctx context.Context, productID, purchaseToken string) error {
+	endpoint := fmt.Sprintf("%s/%s/purchases/subscriptions/%s/tokens/%s:acknowledge",
+		googlePublisherURL, url.PathEscape(c.PackageName), url.PathEscape(productID), url.PathEscape(purchaseToken))
+
+	_, err := c.do(ctx, http.MethodPost, endpoint)
+	return err
+}
+
+// ParseGoogleNotification decodes the Pub/Sub push envelope of a Real-time Developer Notification
+func ParseGoogleNotification(body []byte) (*GoogleNotification, error) {
+	var envelope googlePubSubMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid pub/sub message: %w", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(envelope.Message.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pub/sub message data: %w", err)
+	}
+
+	var payload googleDeveloperNotification
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("invalid developer notification: %w", err)
+	}
+
+	notification := &GoogleNotification{
+		PackageName: payload.PackageName,
+		IsTest:      len(payload.TestNotification) > 0,
+	}
+
+	if payload.SubscriptionNotification != nil {
+		notification.NotificationType = payload.SubscriptionNotification.NotificationType
+		notification.PurchaseToken = payload.SubscriptionNotification.PurchaseToken
+		notification.ProductID = payload.SubscriptionNotification.SubscriptionID
+	}
+
+	return notification, nil
+}
+
+func (c *GoogleClient) do(ctx context.Context, method, endpoint string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("google play request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("google play returned status %d: %s", resp.StatusCode, string(raw))
+	}
+
+	return raw, nil
+}
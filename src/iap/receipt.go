@@ -0,0 +1,35 @@
+package iap
+
+import (
+	"net/http"
+	"time"
+)
+
+// httpTimeout bounds every call to the store APIs
+const httpTimeout = 15 * time.Second
+
+// Receipt is a store subscription purchase normalized across App Store and Google Play
+type Receipt struct {
+	Store     string
+	ProductID string
+	// OriginalTransactionID identifies the subscription for its whole lifetime,
+	// it is the original transaction ID on the App Store and the purchase token on Google Play
+	OriginalTransactionID string
+	// TransactionID identifies the latest billing period, the order ID on Google Play
+	TransactionID string
+	ExpiresAt     time.Time
+	AutoRenew     bool
+	Active        bool
+	Revoked       bool
+	Environment   string
+	Raw           []byte
+}
+
+// IsSandbox reports whether the purchase was made with a store test account, it is not revenue
+func (r *Receipt) IsSandbox() bool {
+	return r.Environment == "Sandbox" || r.Environment == "test"
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: httpTimeout}
+}
@@ -0,0 +1,239 @@
+package job
+
+import (
+	"app/src/config"
+	"app/src/grpc"
+	"app/src/redis"
+	"app/src/searchindex"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RegisterJobs wires every background job of the application into the scheduler
+func RegisterJobs(scheduler *Scheduler, db *gorm.DB) {
+	paymentService := service.NewMidtransPaymentService()
+	validate := validation.Validator()
+	emailDeliveryService := service.NewEmailDeliveryService(db)
+	phoneService := service.NewPhoneService(db, validate)
+	emailService := service.NewEmailService(service.NewNotificationTemplateService(db, validate),
+		service.NewNotificationPreferenceService(db, validate), service.NewExperimentService(db, validate), emailDeliveryService,
+		phoneService)
+	sandboxPaymentService := service.NewMidtransSandboxPaymentService()
+	installmentService := service.NewInstallmentService(db, paymentService, sandboxPaymentService, emailService)
+	renewalService := service.NewRenewalService(db, paymentService, sandboxPaymentService, emailService)
+	opsBotService := service.NewOpsBotService(db, emailService, service.NewMaintenanceService(db, validate))
+	userCounterService := service.NewUserCounterService(db, service.NewAlertService(db, validate, emailService))
+	nutritionSummaryService := service.NewNutritionSummaryService(db, validate)
+	archiveService := service.NewArchiveService(db)
+	backupService := service.NewBackupService(db, validate)
+	retentionService := service.NewRetentionService(db, validate)
+	mediaCleanupService := service.NewMediaCleanupService(db, validate)
+	webhookService := service.NewWebhookService(db, validate)
+	storageUsageService := service.NewStorageUsageService(db, validate)
+	idempotencyService := service.NewIdempotencyService(db)
+	runtimeConfigService := service.NewRuntimeConfigService(db, validate)
+	roleService := service.NewRoleService(db, validate)
+	dailyTipService := service.NewDailyTipService(db, validate, emailService)
+	downloadLinkService := service.NewDownloadLinkService(db, validate)
+	magicLinkService := service.NewMagicLinkService(db, validate, emailService)
+	passkeyService := service.NewPasskeyService(db, validate)
+	captchaService, err := service.NewCaptchaService(db)
+	if err != nil {
+		utils.Log.Fatalf("Failed to set up CAPTCHA: %v", err)
+	}
+	diaryExportService := service.NewDiaryExportService(db, validate, downloadLinkService)
+	cohortService := service.NewCohortService(db, validate)
+	planSunsetService := service.NewPlanSunsetService(db, validate, emailService)
+	adminActionService := service.NewAdminActionService(db, validate)
+
+	redisClient, err := redis.New(config.RedisURL)
+	if err != nil {
+		utils.Log.Warnf("Redis disabled: %v", err)
+	}
+	scanQuotaService := service.NewScanQuotaService(db, redisClient)
+	// The scan limits cached in Redis are dropped when a subscription changes
+	service.OnSubscriptionChange(scanQuotaService.Invalidate)
+
+	searchClient, err := searchindex.New(config.SearchIndexURL, config.SearchIndexPrefix)
+	if err != nil {
+		utils.Log.Warnf("Search index disabled: %v", err)
+	}
+	foodClient, err := grpc.NewBahanMakananClient(fmt.Sprintf("%s:%s", config.GRPC_HOST, config.GRPC_PORT))
+	if err != nil {
+		utils.Log.Warnf("Foods are left out of the search index and the food catalog: %v", err)
+	}
+	searchIndexService := service.NewSearchIndexService(db, searchClient, foodClient)
+	if foodClient != nil {
+		// Food search matches the catalog in the database while the index is down
+		scheduler.Register(Job{
+			Name:       "refresh-food-catalog",
+			Interval:   config.SearchReindexInterval,
+			Run:        searchIndexService.RefreshFoodCatalog,
+			RunOnStart: true,
+		})
+	}
+	if searchClient != nil {
+		scheduler.Register(Job{
+			Name:       "reindex-search",
+			Interval:   config.SearchReindexInterval,
+			Run:        searchIndexService.Reindex,
+			RunOnStart: true,
+		})
+	}
+
+	// Every instance runs it, it picks up the flags an admin changed on another one
+	scheduler.Register(Job{
+		Name:       "reload-runtime-config",
+		Interval:   time.Minute,
+		Run:        runtimeConfigService.Reload,
+		RunOnStart: true,
+	})
+	// Every instance runs it, it picks up the roles an admin changed on another one
+	scheduler.Register(Job{
+		Name:       "reload-roles",
+		Interval:   config.RoleCacheTTL,
+		Run:        roleService.Reload,
+		RunOnStart: true,
+	})
+	scheduler.Register(Job{
+		Name:     "bill-due-installments",
+		Interval: time.Hour,
+		Run:      installmentService.BillDueInstallments,
+	})
+	scheduler.Register(Job{
+		Name:     "suspend-overdue-installments",
+		Interval: time.Hour,
+		Run:      installmentService.SuspendOverdueSubscriptions,
+	})
+	scheduler.Register(Job{
+		Name:     "renew-subscriptions",
+		Interval: time.Hour,
+		Run:      renewalService.RenewDue,
+	})
+	scheduler.Register(Job{
+		Name:     "expire-subscriptions",
+		Interval: time.Hour,
+		Run:      renewalService.ExpireEnded,
+	})
+	scheduler.Register(Job{
+		Name:     "end-trials",
+		Interval: time.Hour,
+		Run:      renewalService.EndTrials,
+	})
+	scheduler.Register(Job{
+		Name:     "finalize-admin-actions",
+		Interval: time.Minute,
+		Run:      adminActionService.FinalizeDue,
+	})
+	scheduler.Register(Job{
+		Name:     "migrate-sunset-plans",
+		Interval: time.Hour,
+		Run:      planSunsetService.Migrate,
+	})
+	scheduler.Register(Job{
+		Name:     "report-daily-kpis",
+		Interval: time.Hour,
+		Run:      opsBotService.ReportDailyKPIs,
+	})
+	scheduler.Register(Job{
+		Name:     "email-daily-tips",
+		Interval: time.Hour,
+		Run:      dailyTipService.EmailDailyTips,
+	})
+	scheduler.Register(Job{
+		Name:     "reconcile-user-counters",
+		Interval: time.Hour,
+		Run:      userCounterService.Reconcile,
+	})
+	scheduler.Register(Job{
+		Name:     "aggregate-cohorts",
+		Interval: time.Hour,
+		Run:      cohortService.Aggregate,
+	})
+	scheduler.Register(Job{
+		Name:     "backfill-nutrition-summaries",
+		Interval: time.Hour,
+		Run:      nutritionSummaryService.Backfill,
+	})
+	scheduler.Register(Job{
+		Name:     "archive-old-records",
+		Interval: time.Hour,
+		Run:      archiveService.ArchiveOldRecords,
+	})
+	scheduler.Register(Job{
+		Name:     "apply-retention-policies",
+		Interval: time.Hour,
+		Run:      retentionService.ApplyPolicies,
+	})
+	scheduler.Register(Job{
+		Name:     "clean-orphaned-media",
+		Interval: time.Hour,
+		Run:      mediaCleanupService.CleanOrphans,
+	})
+	scheduler.Register(Job{
+		Name:     "measure-storage",
+		Interval: time.Hour,
+		Run:      storageUsageService.Measure,
+	})
+	scheduler.Register(Job{
+		Name:     "purge-idempotency-keys",
+		Interval: time.Hour,
+		Run:      idempotencyService.PurgeExpired,
+	})
+	scheduler.Register(Job{
+		Name:     "purge-email-attempts",
+		Interval: time.Hour,
+		Run:      emailDeliveryService.PurgeAttempts,
+	})
+	scheduler.Register(Job{
+		Name:     "purge-phone-codes",
+		Interval: time.Hour,
+		Run:      phoneService.PurgeCodes,
+	})
+	scheduler.Register(Job{
+		Name:     "purge-download-links",
+		Interval: time.Hour,
+		Run:      downloadLinkService.PurgeExpired,
+	})
+	scheduler.Register(Job{
+		Name:     "purge-magic-links",
+		Interval: time.Hour,
+		Run:      magicLinkService.PurgeExpired,
+	})
+	scheduler.Register(Job{
+		Name:     "purge-passkey-challenges",
+		Interval: time.Hour,
+		Run:      passkeyService.PurgeChallenges,
+	})
+	scheduler.Register(Job{
+		Name:     "purge-login-failures",
+		Interval: time.Hour,
+		Run:      captchaService.PurgeLoginFailures,
+	})
+	scheduler.Register(Job{
+		Name:     "deliver-webhooks",
+		Interval: time.Minute,
+		Run:      webhookService.DeliverDue,
+	})
+	scheduler.Register(Job{
+		Name:     "run-requested-backups",
+		Interval: time.Minute,
+		Run:      backupService.RunPending,
+	})
+	scheduler.Register(Job{
+		Name:     "run-diary-exports",
+		Interval: time.Minute,
+		Run:      diaryExportService.RunPending,
+	})
+	scheduler.Register(Job{
+		Name:       "flush-scan-counters",
+		Interval:   config.ScanQuotaFlushInterval,
+		Run:        scanQuotaService.Flush,
+		RunOnStart: true,
+	})
+}
@@ -0,0 +1,77 @@
+package job
+
+import (
+	"app/src/requestid"
+	"app/src/utils"
+	"context"
+	"time"
+)
+
+// Job is a background task that runs on a fixed interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+
+	// RunOnStart also runs the job when the scheduler starts instead of waiting a full interval
+	RunOnStart bool
+}
+
+// Scheduler runs registered jobs until its context is cancelled
+type Scheduler struct {
+	jobs []Job
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Register adds a job to the scheduler. Jobs must be registered before Start is called.
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start launches every registered job in its own goroutine
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	utils.Log.Infof("Starting background job %s (every %s)", job.Name, job.Interval)
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	if job.RunOnStart {
+		s.run(ctx, job)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			utils.Log.Infof("Stopping background job %s", job.Name)
+			return
+		case <-ticker.C:
+			s.run(ctx, job)
+		}
+	}
+}
+
+// run gives every run its own request ID, so the alerts and webhooks it sends can be traced back to it
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	id := requestid.New("job")
+	defer func() {
+		if r := recover(); r != nil {
+			utils.Log.Errorf("Background job %s (%s) panicked: %v", job.Name, id, r)
+		}
+	}()
+
+	start := time.Now()
+	if err := job.Run(requestid.With(ctx, id)); err != nil {
+		utils.Log.Errorf("Background job %s (%s) failed: %v", job.Name, id, err)
+		return
+	}
+	utils.Log.Debugf("Background job %s (%s) finished in %s", job.Name, id, time.Since(start))
+}
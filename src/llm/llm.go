@@ -0,0 +1,237 @@
+package llm
+
+import (
+	"app/src/requestid"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Roles of the messages of a chat
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Providers New knows
+const (
+	ProviderOpenAI = "openai" // OpenAI and the APIs compatible with its chat completions
+	ProviderGemini = "gemini"
+)
+
+// Message is a message of a chat
+type Message struct {
+	Role    string
+	Content string
+}
+
+// Reply is the answer of a model with the tokens it cost
+type Reply struct {
+	Content          string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Options tune the answers of a provider
+type Options struct {
+	Model       string
+	MaxTokens   int
+	Temperature float64
+}
+
+// Provider answers a chat, the first message may be a system prompt
+type Provider interface {
+	Name() string
+	Chat(ctx context.Context, messages []Message) (*Reply, error)
+}
+
+// New returns the provider by name, nil when no API key is configured. baseURL replaces the API endpoint
+// of the provider when set.
+func New(provider, apiKey, baseURL string, timeout time.Duration, options Options) (Provider, error) {
+	if apiKey == "" {
+		return nil, nil
+	}
+
+	client := &http.Client{Timeout: timeout}
+	baseURL = strings.TrimRight(baseURL, "/")
+	switch provider {
+	case ProviderOpenAI:
+		if baseURL == "" {
+			baseURL = "https://api.openai.com/v1"
+		}
+		return &openAI{apiKey: apiKey, baseURL: baseURL, options: options, http: client}, nil
+	case ProviderGemini:
+		if baseURL == "" {
+			baseURL = "https://generativelanguage.googleapis.com/v1beta"
+		}
+		return &gemini{apiKey: apiKey, baseURL: baseURL, options: options, http: client}, nil
+	}
+	return nil, fmt.Errorf("llm: unknown provider %q", provider)
+}
+
+// postJSON sends payload and decodes the answer into out
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, payload, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if id := requestid.From(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("llm: unexpected status %d: %s", resp.StatusCode, detail)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+type openAI struct {
+	apiKey  string
+	baseURL string
+	options Options
+	http    *http.Client
+}
+
+func (p *openAI) Name() string {
+	return ProviderOpenAI
+}
+
+func (p *openAI) Chat(ctx context.Context, messages []Message) (*Reply, error) {
+	type message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	payload := struct {
+		Model       string    `json:"model"`
+		Messages    []message `json:"messages"`
+		MaxTokens   int       `json:"max_tokens,omitempty"`
+		Temperature float64   `json:"temperature"`
+	}{Model: p.options.Model, MaxTokens: p.options.MaxTokens, Temperature: p.options.Temperature}
+	for _, m := range messages {
+		payload.Messages = append(payload.Messages, message{Role: m.Role, Content: m.Content})
+	}
+
+	var out struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message message `json:"message"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	header := http.Header{"Authorization": {"Bearer " + p.apiKey}}
+	if err := postJSON(ctx, p.http, p.baseURL+"/chat/completions", header, payload, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Choices) == 0 {
+		return nil, fmt.Errorf("llm: %s answered no choice", ProviderOpenAI)
+	}
+
+	return &Reply{
+		Content:          out.Choices[0].Message.Content,
+		Model:            out.Model,
+		PromptTokens:     out.Usage.PromptTokens,
+		CompletionTokens: out.Usage.CompletionTokens,
+	}, nil
+}
+
+type gemini struct {
+	apiKey  string
+	baseURL string
+	options Options
+	http    *http.Client
+}
+
+func (p *gemini) Name() string {
+	return ProviderGemini
+}
+
+func (p *gemini) Chat(ctx context.Context, messages []Message) (*Reply, error) {
+	type part struct {
+		Text string `json:"text"`
+	}
+	type content struct {
+		Role  string `json:"role,omitempty"`
+		Parts []part `json:"parts"`
+	}
+	type generationConfig struct {
+		MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
+		Temperature     float64 `json:"temperature"`
+	}
+	payload := struct {
+		SystemInstruction *content         `json:"systemInstruction,omitempty"`
+		Contents          []content        `json:"contents"`
+		GenerationConfig  generationConfig `json:"generationConfig"`
+	}{GenerationConfig: generationConfig{MaxOutputTokens: p.options.MaxTokens, Temperature: p.options.Temperature}}
+	for _, m := range messages {
+		switch m.Role {
+		case RoleSystem:
+			payload.SystemInstruction = &content{Parts: []part{{Text: m.Content}}}
+		case RoleAssistant:
+			payload.Contents = append(payload.Contents, content{Role: "model", Parts: []part{{Text: m.Content}}})
+		default:
+			payload.Contents = append(payload.Contents, content{Role: "user", Parts: []part{{Text: m.Content}}})
+		}
+	}
+
+	var out struct {
+		Candidates []struct {
+			Content content `json:"content"`
+		} `json:"candidates"`
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+		} `json:"usageMetadata"`
+		ModelVersion string `json:"modelVersion"`
+	}
+	header := http.Header{"X-Goog-Api-Key": {p.apiKey}}
+	url := fmt.Sprintf("%s/models/%s:generateContent", p.baseURL, p.options.Model)
+	if err := postJSON(ctx, p.http, url, header, payload, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Candidates) == 0 {
+		return nil, fmt.Errorf("llm: %s answered no candidate", ProviderGemini)
+	}
+
+	var text strings.Builder
+	for _, piece := range out.Candidates[0].Content.Parts {
+		text.WriteString(piece.Text)
+	}
+	model := out.ModelVersion
+	if model == "" {
+		model = p.options.Model
+	}
+
+	return &Reply{
+		Content:          text.String(),
+		Model:            model,
+		PromptTokens:     out.UsageMetadata.PromptTokenCount,
+		CompletionTokens: out.UsageMetadata.CandidatesTokenCount,
+	}, nil
+}
@@ -0,0 +1,127 @@
+// Package logfile writes log files that roll over every day. The lines of the days before are kept next to the
+// file as name-YYYY-MM-DD.ext until they are purged.
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const dayLayout = "2006-01-02"
+
+// File is a log file that rolls over on the first write of a new day, it is safe for concurrent writes
+type File struct {
+	mu   sync.Mutex
+	path string
+	day  string
+	file *os.File
+	now  func() time.Time
+}
+
+// Open opens or creates the log file at path, now tells the day and is time.Now outside of tests. A file left
+// from an earlier day is rolled over right away.
+func Open(path string, now func() time.Time) (*File, error) {
+	f := &File{path: path, now: now}
+	if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+		f.day = info.ModTime().Format(dayLayout)
+	}
+	if err := f.roll(now().Format(dayLayout)); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if today := f.now().Format(dayLayout); today != f.day {
+		if err := f.roll(today); err != nil {
+			return 0, err
+		}
+	}
+	return f.file.Write(p)
+}
+
+// Close closes the file
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+// roll moves the lines of the current day aside and opens a new file for today
+func (f *File) roll(today string) error {
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return err
+		}
+		f.file = nil
+	}
+
+	if f.day != "" && f.day != today {
+		if err := os.Rename(f.path, rolledPath(f.path, f.day)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("logfile: rolling over %s: %w", f.path, err)
+		}
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	f.file, f.day = file, today
+	return nil
+}
+
+// rolledPath is the path the lines of day are moved to. A day rolled over twice, after a restart with the clock
+// turned back, gets a numbered file rather than replacing the first one.
+func rolledPath(path, day string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext) + "-" + day
+	rolled := base + ext
+	for i := 1; ; i++ {
+		if _, err := os.Stat(rolled); os.IsNotExist(err) {
+			return rolled
+		}
+		rolled = fmt.Sprintf("%s.%d%s", base, i, ext)
+	}
+}
+
+// Expired lists the rolled over files of the log file at path holding days before cutoff
+func Expired(path string, cutoff time.Time) ([]string, error) {
+	ext := filepath.Ext(path)
+	prefix := strings.TrimSuffix(filepath.Base(path), ext) + "-"
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	limit := cutoff.Format(dayLayout)
+	expired := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		day := strings.TrimPrefix(name, prefix)
+		if len(day) < len(dayLayout) {
+			continue
+		}
+		day = day[:len(dayLayout)]
+		if _, err := time.Parse(dayLayout, day); err != nil {
+			continue
+		}
+		// Days compare as strings
+		if day < limit {
+			expired = append(expired, filepath.Join(filepath.Dir(path), name))
+		}
+	}
+	return expired, nil
+}
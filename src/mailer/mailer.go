@@ -0,0 +1,139 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+// Providers New knows
+const (
+	ProviderSES  = "ses"  // the Amazon SES v2 API
+	ProviderSMTP = "smtp" // any SMTP relay
+)
+
+// Message is a plain text email to one recipient
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+	Headers map[string]string
+}
+
+// mime is the message as gomail writes it
+func (m *Message) mime() *gomail.Message {
+	message := gomail.NewMessage()
+	message.SetHeader("From", m.From)
+	message.SetHeader("To", m.To)
+	message.SetHeader("Subject", m.Subject)
+	for name, value := range m.Headers {
+		message.SetHeader(name, value)
+	}
+	message.SetBody("text/plain", m.Body)
+	return message
+}
+
+// Sender sends email through one provider
+type Sender interface {
+	Name() string
+	Send(ctx context.Context, message *Message) error
+}
+
+// Error is a message a provider refused. Permanent errors are bounces: the recipient or the message is refused
+// for good, sending it again through another provider would not help.
+type Error struct {
+	Provider  string
+	Code      int // the SMTP reply or HTTP status code, 0 when the provider did not answer
+	Permanent bool
+	Err       error
+}
+
+func (e *Error) Error() string {
+	if e.Code == 0 {
+		return fmt.Sprintf("%s: %v", e.Provider, e.Err)
+	}
+	return fmt.Sprintf("%s: %d %v", e.Provider, e.Code, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// IsBounce reports whether err is a permanent refusal of the message
+func IsBounce(err error) bool {
+	var sendErr *Error
+	return errors.As(err, &sendErr) && sendErr.Permanent
+}
+
+// Settings of the providers, each only reads its own
+type Settings struct {
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	SESRegion    string
+	SESAccessKey string
+	SESSecretKey string
+	SESEndpoint  string // replaces https://email.<region>.amazonaws.com when set
+
+	Timeout time.Duration
+}
+
+// New returns the sender of provider, nil when the provider is not configured
+func New(provider string, settings Settings) (Sender, error) {
+	switch provider {
+	case ProviderSMTP:
+		if settings.SMTPHost == "" {
+			return nil, nil
+		}
+		return newSMTP(settings), nil
+	case ProviderSES:
+		if settings.SESAccessKey == "" {
+			return nil, nil
+		}
+		if settings.SESRegion == "" {
+			return nil, errors.New("mailer: SES_REGION is required for ses")
+		}
+		return newSES(settings), nil
+	}
+	return nil, fmt.Errorf("mailer: unknown provider %q", provider)
+}
+
+type smtpSender struct {
+	dialer *gomail.Dialer
+}
+
+func newSMTP(settings Settings) *smtpSender {
+	return &smtpSender{dialer: gomail.NewDialer(settings.SMTPHost, settings.SMTPPort, settings.SMTPUsername, settings.SMTPPassword)}
+}
+
+func (p *smtpSender) Name() string {
+	return ProviderSMTP
+}
+
+// Send dials the relay for each message, as the relay closes idle connections. SMTP does not take a context, the
+// dial and the dialogue are bounded by the timeouts of the relay.
+func (p *smtpSender) Send(_ context.Context, message *Message) error {
+	if err := p.dialer.DialAndSend(message.mime()); err != nil {
+		return smtpError(err)
+	}
+	return nil
+}
+
+// smtpError marks the replies refusing the recipient or the mailbox for good as bounces: 550 to 553. Other 5xx
+// replies, like a refused login, are faults of the relay.
+func smtpError(err error) error {
+	sendErr := &Error{Provider: ProviderSMTP, Err: err}
+	var reply *textproto.Error
+	if errors.As(err, &reply) {
+		sendErr.Code = reply.Code
+		sendErr.Permanent = reply.Code >= 550 && reply.Code <= 553
+	}
+	return sendErr
+}
@@ -0,0 +1,224 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type sesSender struct {
+	endpoint  string
+	region    string
+	accessKey string
+	secretKey string
+	http      *http.Client
+}
+
+func newSES(settings Settings) *sesSender {
+	endpoint := strings.TrimRight(settings.SESEndpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://email." + settings.SESRegion + ".amazonaws.com"
+	}
+	return &sesSender{
+		endpoint:  endpoint,
+		region:    settings.SESRegion,
+		accessKey: settings.SESAccessKey,
+		secretKey: settings.SESSecretKey,
+		http:      &http.Client{Timeout: settings.Timeout},
+	}
+}
+
+func (p *sesSender) Name() string {
+	return ProviderSES
+}
+
+// Send sends the message raw, so the headers like List-Unsubscribe reach the recipient as they were set
+func (p *sesSender) Send(ctx context.Context, message *Message) error {
+	var raw bytes.Buffer
+	if _, err := message.mime().WriteTo(&raw); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"FromEmailAddress": message.From,
+		"Destination":      map[string]any{"ToAddresses": []string{message.To}},
+		"Content":          map[string]any{"Raw": map[string]any{"Data": raw.Bytes()}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/v2/email/outbound-emails", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	p.sign(req, body, time.Now().UTC())
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return &Error{Provider: ProviderSES, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	var reply struct {
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(detail, &reply)
+	if reply.Message == "" {
+		reply.Message = strings.TrimSpace(string(detail))
+	}
+	errorType := resp.Header.Get("X-Amzn-Errortype")
+	if i := strings.IndexByte(errorType, ':'); i >= 0 {
+		errorType = errorType[:i]
+	}
+	// SES reports bounces later through its notifications. What it refuses here, MessageRejected included, is a
+	// matter of the account or the sender, such as an unverified address, so another provider is tried.
+	return &Error{
+		Provider: ProviderSES,
+		Code:     resp.StatusCode,
+		Err:      errors.New(strings.TrimSpace(errorType + " " + reply.Message)),
+	}
+}
+
+// sign adds the Signature Version 4 authorization of req with its body, signing the host, content type and date
+// headers
+func (p *sesSender) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	payloadHash := sha256.Sum256(body)
+	signedHeaders := "content-type;host;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"content-type:" + req.Header.Get("Content-Type") + "\n" +
+			"host:" + req.URL.Host + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := date + "/" + p.region + "/ses/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+p.secretKey), date)
+	key = hmacSHA256(key, p.region)
+	key = hmacSHA256(key, "ses")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		p.accessKey, scope, signedHeaders, signature))
+}
+
+// Kinds of the feedback SES publishes about a sent message
+const (
+	FeedbackBounce    = "bounce"
+	FeedbackComplaint = "complaint"
+)
+
+// Feedback is a bounce or a complaint SES received about a recipient. Permanent bounces and complaints mean the
+// address should not be sent to again, transient bounces like a full mailbox do not.
+type Feedback struct {
+	Email     string
+	Kind      string
+	Permanent bool
+	Detail    string
+	At        time.Time
+}
+
+// sesNotification is the part of a bounce or complaint notification SES publishes to SNS that Feedback reads. Event
+// publishing names the type eventType instead of notificationType.
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	EventType        string `json:"eventType"`
+	Bounce           *struct {
+		BounceType        string    `json:"bounceType"`
+		BounceSubType     string    `json:"bounceSubType"`
+		Timestamp         time.Time `json:"timestamp"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint *struct {
+		ComplaintFeedbackType string    `json:"complaintFeedbackType"`
+		Timestamp             time.Time `json:"timestamp"`
+		ComplainedRecipients  []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+	} `json:"complaint"`
+}
+
+// ParseSESNotification reads the feedback of an SES notification, the Message of an SNS notification. Other
+// notifications, like deliveries, have none.
+func ParseSESNotification(message string) ([]Feedback, error) {
+	var notification sesNotification
+	if err := json.Unmarshal([]byte(message), &notification); err != nil {
+		return nil, fmt.Errorf("ses: invalid notification: %w", err)
+	}
+	kind := notification.NotificationType
+	if kind == "" {
+		kind = notification.EventType
+	}
+
+	var feedback []Feedback
+	switch {
+	case kind == "Bounce" && notification.Bounce != nil:
+		bounce := notification.Bounce
+		for _, recipient := range bounce.BouncedRecipients {
+			detail := strings.TrimSpace(bounce.BounceType + " " + bounce.BounceSubType)
+			if recipient.DiagnosticCode != "" {
+				detail += ": " + recipient.DiagnosticCode
+			}
+			feedback = append(feedback, Feedback{
+				Email:     recipient.EmailAddress,
+				Kind:      FeedbackBounce,
+				Permanent: bounce.BounceType == "Permanent",
+				Detail:    detail,
+				At:        bounce.Timestamp,
+			})
+		}
+	case kind == "Complaint" && notification.Complaint != nil:
+		complaint := notification.Complaint
+		for _, recipient := range complaint.ComplainedRecipients {
+			feedback = append(feedback, Feedback{
+				Email:     recipient.EmailAddress,
+				Kind:      FeedbackComplaint,
+				Permanent: true,
+				Detail:    complaint.ComplaintFeedbackType,
+				At:        complaint.Timestamp,
+			})
+		}
+	}
+	return feedback, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
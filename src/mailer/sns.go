@@ -0,0 +1,179 @@
+package mailer
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Types of the messages SNS posts to an HTTPS subscription
+const (
+	SNSSubscriptionConfirmation = "SubscriptionConfirmation"
+	SNSNotification             = "Notification"
+	SNSUnsubscribeConfirmation  = "UnsubscribeConfirmation"
+)
+
+// snsHost matches the hosts SNS serves its signing certificates and subscription links from
+var snsHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// SNSMessage is a message Amazon SNS posts to an HTTPS subscription, such as the SES feedback of a topic
+type SNSMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	SubscribeURL     string `json:"SubscribeURL"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+}
+
+// signedString is the text SNS signs for the type of the message
+func (m *SNSMessage) signedString() string {
+	fields := [][2]string{{"Message", m.Message}, {"MessageId", m.MessageID}}
+	if m.Type == SNSNotification {
+		if m.Subject != "" {
+			fields = append(fields, [2]string{"Subject", m.Subject})
+		}
+	} else {
+		fields = append(fields, [2]string{"SubscribeURL", m.SubscribeURL})
+	}
+	fields = append(fields, [2]string{"Timestamp", m.Timestamp})
+	if m.Type != SNSNotification {
+		fields = append(fields, [2]string{"Token", m.Token})
+	}
+	fields = append(fields, [2]string{"TopicArn", m.TopicArn}, [2]string{"Type", m.Type})
+
+	var signed strings.Builder
+	for _, field := range fields {
+		signed.WriteString(field[0] + "\n" + field[1] + "\n")
+	}
+	return signed.String()
+}
+
+// SNSVerifier checks that messages were signed by SNS, with the signing certificates it caches
+type SNSVerifier struct {
+	http  *http.Client
+	mu    sync.Mutex
+	certs map[string]*x509.Certificate
+}
+
+func NewSNSVerifier(client *http.Client) *SNSVerifier {
+	return &SNSVerifier{http: client, certs: make(map[string]*x509.Certificate)}
+}
+
+// Verify checks the signature of the message and that its certificate and subscription links are those of SNS
+func (v *SNSVerifier) Verify(ctx context.Context, message *SNSMessage) error {
+	if message.SubscribeURL != "" {
+		if err := checkSNSURL(message.SubscribeURL, ""); err != nil {
+			return err
+		}
+	}
+
+	var hash crypto.Hash
+	switch message.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("sns: unknown signature version %q", message.SignatureVersion)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(message.Signature)
+	if err != nil {
+		return fmt.Errorf("sns: invalid signature: %w", err)
+	}
+	cert, err := v.cert(ctx, message.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("sns: the signing certificate has no RSA key")
+	}
+
+	var digest []byte
+	if hash == crypto.SHA1 {
+		sum := sha1.Sum([]byte(message.signedString()))
+		digest = sum[:]
+	} else {
+		sum := sha256.Sum256([]byte(message.signedString()))
+		digest = sum[:]
+	}
+	if err := rsa.VerifyPKCS1v15(key, hash, digest, signature); err != nil {
+		return errors.New("sns: the signature does not match")
+	}
+	return nil
+}
+
+// cert returns the signing certificate at certURL, fetched once
+func (v *SNSVerifier) cert(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	if err := checkSNSURL(certURL, ".pem"); err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	cert := v.certs[certURL]
+	v.mu.Unlock()
+	if cert != nil {
+		return cert, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, certURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sns: failed to fetch the signing certificate: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sns: the signing certificate returned %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(body)
+	if block == nil {
+		return nil, errors.New("sns: the signing certificate is not PEM")
+	}
+	if cert, err = x509.ParseCertificate(block.Bytes); err != nil {
+		return nil, fmt.Errorf("sns: invalid signing certificate: %w", err)
+	}
+
+	v.mu.Lock()
+	v.certs[certURL] = cert
+	v.mu.Unlock()
+	return cert, nil
+}
+
+// checkSNSURL refuses links that are not HTTPS links of SNS, so a forged message cannot make the server fetch
+// another address
+func checkSNSURL(link, suffix string) error {
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Scheme != "https" || !snsHost.MatchString(parsed.Hostname()) ||
+		!strings.HasSuffix(parsed.Path, suffix) {
+		return fmt.Errorf("sns: %q is not a link of SNS", link)
+	}
+	return nil
+}
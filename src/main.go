@@ -3,7 +3,10 @@ package main
 import (
 	"app/src/config"
 	"app/src/database"
+	"app/src/job"
+	"app/src/media"
 	"app/src/middleware"
+	"app/src/requestid"
 	"app/src/router"
 	"app/src/utils"
 	"context"
@@ -12,6 +15,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -32,6 +36,10 @@ import (
 // @in header
 // @name Authorization
 // @description Example Value: Bearer eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
+// @securityDefinitions.apikey PartnerKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key of a partner, issued by an admin with the scopes the partner needs
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -69,6 +77,10 @@ func main() {
 	utils.Log.Info("Setting up API routes...")
 	setupRoutes(app, db)
 
+	// Start background jobs
+	utils.Log.Info("Starting background jobs...")
+	setupJobs(ctx, db)
+
 	address := fmt.Sprintf("%s:%d", config.AppHost, config.AppPort)
 	utils.Log.Infof("Starting server on %s", address)
 
@@ -76,6 +88,9 @@ func main() {
 	serverErrors := make(chan error, 1)
 	go startServer(app, address, serverErrors)
 	handleGracefulShutdown(ctx, app, serverErrors)
+
+	// The activities still queued are written before the database closes
+	utils.CloseActivityStore()
 }
 
 func setupFiberApp() *fiber.App {
@@ -83,15 +98,26 @@ func setupFiberApp() *fiber.App {
 	app := fiber.New(config.FiberConfig())
 
 	// Middleware setup
+	app.Use(middleware.RequestID())
 	app.Use("/v1/auth", middleware.LimiterConfig())
 	app.Use(middleware.LoggerConfig())
 	app.Use(middleware.APILoggerConfig())
 	app.Use(middleware.RequestBodyLoggerConfig())
 	app.Use(helmet.New())
 	app.Use(compress.New())
-	app.Use(cors.New())
+	app.Use(cors.New(cors.Config{ExposeHeaders: requestid.Header}))
 	app.Use(middleware.RecoverConfig())
 
+	// Media addresses in responses are signed for the CDN in front of /uploads, when one is configured. The files
+	// are only served for signed URLs, and not at all without a signing key.
+	media.SetDefault(media.New(config.MediaCDNURL, config.MediaSigningKey, config.MediaKeyVersion, map[string]time.Duration{
+		media.KindScan:    config.MediaScanTTL,
+		media.KindAvatar:  config.MediaAvatarTTL,
+		media.KindContent: config.MediaContentTTL,
+		media.KindProof:   config.MediaScanTTL,
+	}, strings.Split(config.MediaOrigins, ",")))
+	app.Use(middleware.SignMedia())
+	app.Use("/uploads", middleware.VerifyMedia())
 	app.Static("/uploads", "./uploads")
 
 	return app
@@ -99,6 +125,7 @@ func setupFiberApp() *fiber.App {
 
 func setupDatabase() *gorm.DB {
 	db := database.Connect(config.DBHost, config.DBName)
+	database.ConfigurePool(db)
 	return db
 }
 
@@ -107,6 +134,12 @@ func setupRoutes(app *fiber.App, db *gorm.DB) {
 	app.Use(utils.NotFoundHandler)
 }
 
+func setupJobs(ctx context.Context, db *gorm.DB) {
+	scheduler := job.NewScheduler()
+	job.RegisterJobs(scheduler, db)
+	scheduler.Start(ctx)
+}
+
 func startServer(app *fiber.App, address string, errs chan<- error) {
 	log.Printf("Starting server on %s", address)
 
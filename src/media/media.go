@@ -0,0 +1,254 @@
+package media
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Kinds of media, each signed for a TTL of its own
+const (
+	KindScan    = "scan"    // meal scan images, private to their user
+	KindAvatar  = "avatar"  // profile pictures
+	KindContent = "content" // article and recipe images, public
+	KindProof   = "proof"   // payment proofs, private to their user and the admins
+)
+
+// folderKinds are the kinds of the files under /uploads/<folder>, the files of other folders are content
+var folderKinds = map[string]string{
+	"scans":          KindScan,
+	"avatars":        KindAvatar,
+	"payment-proofs": KindProof,
+}
+
+var (
+	ErrUnsigned = errors.New("media URL is not signed")
+	ErrExpired  = errors.New("media URL expired")
+	ErrVersion  = errors.New("media URL has an old key version")
+	ErrInvalid  = errors.New("media URL signature is invalid")
+)
+
+// Signer writes the stored address of a media file as a signed URL of the CDN in front of the storage. The CDN
+// checks the signature at its edge, see Verify, and caches each URL as its own key: bumping Version changes every
+// URL, which invalidates what the CDN holds without purging it.
+type Signer struct {
+	BaseURL string
+	Key     []byte
+	Version int
+	TTLs    map[string]time.Duration
+	// Origins are the hosts media used to be served from directly, their URLs are signed for the CDN instead.
+	// Addresses on any other host, like avatars of identity providers, are left as they are.
+	Origins []string
+}
+
+// New returns nil when no CDN or signing key is configured, media addresses are then served as stored
+func New(baseURL, key string, version int, ttls map[string]time.Duration, origins []string) *Signer {
+	if baseURL == "" || key == "" {
+		return nil
+	}
+
+	hosts := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		if parsed, err := url.Parse(strings.TrimSpace(origin)); err == nil && parsed.Host != "" {
+			hosts = append(hosts, parsed.Host)
+		}
+	}
+
+	return &Signer{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Key:     []byte(key),
+		Version: version,
+		TTLs:    ttls,
+		Origins: hosts,
+	}
+}
+
+var defaultSigner atomic.Pointer[Signer]
+
+// SetDefault sets the signer used by Sign, nil serves media addresses as stored
+func SetDefault(signer *Signer) {
+	defaultSigner.Store(signer)
+}
+
+// Default returns the signer set by SetDefault, nil when media addresses are served as stored
+func Default() *Signer {
+	return defaultSigner.Load()
+}
+
+// Sign signs ref for kind with the default signer
+func Sign(kind, ref string) string {
+	return defaultSigner.Load().Sign(kind, ref, time.Now())
+}
+
+// KindOf returns the kind of the file at path, from its folder under /uploads
+func KindOf(path string) string {
+	folder, _, _ := strings.Cut(strings.TrimPrefix(path, "/uploads/"), "/")
+	if kind, ok := folderKinds[folder]; ok && strings.HasPrefix(path, "/uploads/") {
+		return kind
+	}
+	return KindContent
+}
+
+// Sign returns the signed CDN URL of the stored address ref, a path like /uploads/scans/a.jpg or a URL on one of
+// the origins or the CDN itself. The expiry is rounded to the TTL of kind so the URL stays the same, and
+// cacheable, for a whole TTL: it is valid for at least one TTL and at most two.
+func (s *Signer) Sign(kind, ref string, now time.Time) string {
+	if s == nil || ref == "" {
+		return ref
+	}
+	path, ok := s.pathOf(ref)
+	if !ok {
+		return ref
+	}
+
+	ttl := s.TTLs[kind]
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	expires := now.Truncate(ttl).Add(2 * ttl).Unix()
+
+	query := url.Values{}
+	query.Set("v", strconv.Itoa(s.Version))
+	query.Set("exp", strconv.FormatInt(expires, 10))
+	query.Set("sig", s.signature(path, s.Version, expires))
+	return s.BaseURL + path + "?" + query.Encode()
+}
+
+// SignJSON signs the media addresses among the string values of the JSON document body at now: paths under
+// /uploads and URLs of the origins or the CDN, each for the kind of its folder. Records keep the addresses as
+// stored, only responses carry URLs that expire.
+func (s *Signer) SignJSON(body []byte, now time.Time) []byte {
+	if s == nil {
+		return body
+	}
+
+	var signed []byte
+	last := 0
+	for i := 0; i < len(body); i++ {
+		if body[i] != '"' {
+			continue
+		}
+		end := i + 1
+		for end < len(body) && body[end] != '"' {
+			if body[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(body) {
+			break
+		}
+
+		if replacement, ok := s.signString(body[i:end+1], now); ok {
+			signed = append(signed, body[last:i]...)
+			signed = append(signed, replacement...)
+			last = end + 1
+		}
+		i = end
+	}
+
+	if signed == nil {
+		return body
+	}
+	return append(signed, body[last:]...)
+}
+
+// signString returns the signed URL of the quoted JSON string raw, false when it is not a media address
+func (s *Signer) signString(raw []byte, now time.Time) ([]byte, bool) {
+	if !bytes.HasPrefix(raw, []byte(`"/uploads/`)) && !bytes.HasPrefix(raw, []byte(`"http`)) {
+		return nil, false
+	}
+
+	var ref string
+	if err := json.Unmarshal(raw, &ref); err != nil {
+		return nil, false
+	}
+	path, ok := s.pathOf(ref)
+	if !ok {
+		return nil, false
+	}
+
+	encoded, err := json.Marshal(s.Sign(KindOf(path), ref, now))
+	if err != nil {
+		return nil, false
+	}
+	return encoded, true
+}
+
+// Verify checks the signature of a request for path with query at now
+func (s *Signer) Verify(path string, query url.Values, now time.Time) error {
+	if query.Get("sig") == "" {
+		return ErrUnsigned
+	}
+	version, err := strconv.Atoi(query.Get("v"))
+	if err != nil {
+		return ErrInvalid
+	}
+	expires, err := strconv.ParseInt(query.Get("exp"), 10, 64)
+	if err != nil {
+		return ErrInvalid
+	}
+
+	if !hmac.Equal([]byte(query.Get("sig")), []byte(s.signature(path, version, expires))) {
+		return ErrInvalid
+	}
+	if version != s.Version {
+		return ErrVersion
+	}
+	if now.Unix() >= expires {
+		return ErrExpired
+	}
+	return nil
+}
+
+// pathOf returns the path of the file ref addresses, false when it is not served by the CDN
+func (s *Signer) pathOf(ref string) (string, bool) {
+	if strings.HasPrefix(ref, s.BaseURL+"/") {
+		ref = strings.TrimPrefix(ref, s.BaseURL)
+	} else {
+		parsed, err := url.Parse(ref)
+		if err != nil {
+			return "", false
+		}
+		if parsed.Scheme != "" || parsed.Host != "" {
+			if !s.isOrigin(parsed.Host) {
+				return "", false
+			}
+			ref = parsed.Path
+		}
+	}
+
+	// Addresses signed earlier and sent back by clients are signed again
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		ref = ref[:i]
+	}
+	if ref == "" || ref == "/" {
+		return "", false
+	}
+	return "/" + strings.TrimLeft(ref, "/"), true
+}
+
+func (s *Signer) isOrigin(host string) bool {
+	for _, origin := range s.Origins {
+		if strings.EqualFold(origin, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// signature is the unpadded base64url HMAC-SHA256 of the path, the key version and the expiry
+func (s *Signer) signature(path string, version int, expires int64) string {
+	mac := hmac.New(sha256.New, s.Key)
+	fmt.Fprintf(mac, "%s\n%d\n%d", path, version, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,138 @@
+// Package messaging sends text messages to phones, by SMS or WhatsApp, through a provider
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Providers New knows
+const (
+	ProviderTwilio = "twilio" // SMS and WhatsApp through the Twilio Programmable Messaging API
+)
+
+// Channels a message goes through
+const (
+	ChannelSMS      = "sms"
+	ChannelWhatsApp = "whatsapp"
+)
+
+// Delivery statuses of a message, in the order they are reached. Failed covers the messages the provider or the
+// carrier could not deliver.
+const (
+	StatusQueued    = "queued"
+	StatusSent      = "sent"
+	StatusDelivered = "delivered"
+	StatusRead      = "read" // WhatsApp only
+	StatusFailed    = "failed"
+)
+
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// Message is a text to one phone
+type Message struct {
+	To      string // E.164, see NormalizePhone
+	Channel string
+	Body    string
+}
+
+// Sender sends text messages through one provider. Send returns the ID the provider gave the message, its status
+// callbacks carry it.
+type Sender interface {
+	Name() string
+	Send(ctx context.Context, message *Message) (string, error)
+}
+
+// Error is a message a provider refused. Permanent errors are refusals of the recipient, such as a number that
+// does not exist or opted out, sending again would not help.
+type Error struct {
+	Provider  string
+	Code      int // the error code of the provider, 0 when it did not answer
+	Permanent bool
+	Err       error
+}
+
+func (e *Error) Error() string {
+	if e.Code == 0 {
+		return fmt.Sprintf("%s: %v", e.Provider, e.Err)
+	}
+	return fmt.Sprintf("%s: %d %v", e.Provider, e.Code, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanent reports whether err is a refusal of the recipient
+func IsPermanent(err error) bool {
+	var sendErr *Error
+	return errors.As(err, &sendErr) && sendErr.Permanent
+}
+
+// Settings of the providers
+type Settings struct {
+	TwilioAccountSID     string
+	TwilioAuthToken      string
+	TwilioSMSFrom        string // number or messaging service SID SMS are sent from
+	TwilioWhatsAppFrom   string // WhatsApp sender number, WhatsApp is off without it
+	TwilioStatusCallback string // public URL of the status webhook, statuses are not tracked without it
+	TwilioEndpoint       string // replaces https://api.twilio.com when set
+
+	Timeout time.Duration
+}
+
+// New returns the sender of provider, nil when the provider is not configured
+func New(provider string, settings Settings) (Sender, error) {
+	switch provider {
+	case "":
+		return nil, nil
+	case ProviderTwilio:
+		if settings.TwilioAccountSID == "" {
+			return nil, nil
+		}
+		if settings.TwilioAuthToken == "" {
+			return nil, errors.New("messaging: TWILIO_AUTH_TOKEN is required for twilio")
+		}
+		return newTwilio(settings), nil
+	}
+	return nil, fmt.Errorf("messaging: unknown provider %q", provider)
+}
+
+// NormalizePhone returns phone in E.164, +62812... for Indonesia. Numbers without a country code, such as
+// 0812..., are in countryCode.
+func NormalizePhone(phone, countryCode string) (string, error) {
+	var digits strings.Builder
+	for i, r := range strings.TrimSpace(phone) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+
+	number := digits.String()
+	switch {
+	case strings.HasPrefix(number, "+"):
+	case strings.HasPrefix(number, "00"):
+		number = "+" + number[2:]
+	case strings.HasPrefix(number, "0"):
+		number = "+" + countryCode + number[1:]
+	case strings.HasPrefix(number, countryCode):
+		number = "+" + number
+	default:
+		number = "+" + countryCode + number
+	}
+
+	// E.164 numbers have at most 15 digits, the shortest mobile numbers 8
+	if len(number) < 9 || len(number) > 16 || number[1] == '0' {
+		return "", ErrInvalidPhone
+	}
+	return number, nil
+}
@@ -0,0 +1,158 @@
+package messaging
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// twilioRecipientErrors are the error codes of Twilio refusing the recipient: an invalid number, a number that
+// cannot receive the channel and one that opted out with STOP
+var twilioRecipientErrors = map[int]bool{21211: true, 21408: true, 21610: true, 21612: true, 21614: true, 63003: true}
+
+type twilioSender struct {
+	endpoint       string
+	accountSID     string
+	authToken      string
+	smsFrom        string
+	whatsAppFrom   string
+	statusCallback string
+	http           *http.Client
+}
+
+func newTwilio(settings Settings) *twilioSender {
+	endpoint := strings.TrimRight(settings.TwilioEndpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://api.twilio.com"
+	}
+	return &twilioSender{
+		endpoint:       endpoint,
+		accountSID:     settings.TwilioAccountSID,
+		authToken:      settings.TwilioAuthToken,
+		smsFrom:        settings.TwilioSMSFrom,
+		whatsAppFrom:   settings.TwilioWhatsAppFrom,
+		statusCallback: settings.TwilioStatusCallback,
+		http:           &http.Client{Timeout: settings.Timeout},
+	}
+}
+
+func (p *twilioSender) Name() string {
+	return ProviderTwilio
+}
+
+func (p *twilioSender) Send(ctx context.Context, message *Message) (string, error) {
+	form := url.Values{"Body": {message.Body}}
+	switch message.Channel {
+	case ChannelSMS:
+		form.Set("To", message.To)
+		form.Set("From", p.smsFrom)
+		if strings.HasPrefix(p.smsFrom, "MG") {
+			form.Del("From")
+			form.Set("MessagingServiceSid", p.smsFrom)
+		}
+	case ChannelWhatsApp:
+		if p.whatsAppFrom == "" {
+			return "", &Error{Provider: ProviderTwilio, Err: errors.New("WhatsApp is not configured")}
+		}
+		form.Set("To", "whatsapp:"+message.To)
+		form.Set("From", "whatsapp:"+p.whatsAppFrom)
+	default:
+		return "", &Error{Provider: ProviderTwilio, Err: errors.New("unknown channel " + message.Channel)}
+	}
+	if p.statusCallback != "" {
+		form.Set("StatusCallback", p.statusCallback)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		p.endpoint+"/2010-04-01/Accounts/"+url.PathEscape(p.accountSID)+"/Messages.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(p.accountSID, p.authToken)
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return "", &Error{Provider: ProviderTwilio, Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var reply struct {
+		SID     string `json:"sid"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &reply)
+
+	if resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusOK {
+		return reply.SID, nil
+	}
+	if reply.Message == "" {
+		reply.Message = strings.TrimSpace(string(body))
+	}
+	return "", &Error{
+		Provider:  ProviderTwilio,
+		Code:      reply.Code,
+		Permanent: twilioRecipientErrors[reply.Code],
+		Err:       errors.New(reply.Message),
+	}
+}
+
+// StatusUpdate is a status callback of a provider about a message it sent
+type StatusUpdate struct {
+	MessageID string
+	Status    string // one of the Status constants
+	ErrorCode string
+}
+
+// ParseTwilioStatus reads the status callback Twilio posts, the form params of the request
+func ParseTwilioStatus(params map[string]string) (*StatusUpdate, error) {
+	update := &StatusUpdate{MessageID: params["MessageSid"], ErrorCode: params["ErrorCode"]}
+	if update.MessageID == "" {
+		return nil, errors.New("twilio: the callback has no MessageSid")
+	}
+
+	switch status := params["MessageStatus"]; status {
+	case "accepted", "scheduled", "queued", "sending":
+		update.Status = StatusQueued
+	case "sent":
+		update.Status = StatusSent
+	case "delivered":
+		update.Status = StatusDelivered
+	case "read":
+		update.Status = StatusRead
+	case "undelivered", "failed", "canceled":
+		update.Status = StatusFailed
+	default:
+		return nil, errors.New("twilio: unknown status " + status)
+	}
+	return update, nil
+}
+
+// VerifyTwilioSignature checks the X-Twilio-Signature of a request Twilio posted to callbackURL with params: the
+// HMAC-SHA1 with the auth token of the URL followed by each param name and value, sorted by name
+func VerifyTwilioSignature(authToken, callbackURL string, params map[string]string, signature string) bool {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(callbackURL))
+	for _, name := range names {
+		mac.Write([]byte(name + params[name]))
+	}
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) == 1
+}
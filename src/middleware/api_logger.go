@@ -8,15 +8,13 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
 // APILoggerConfig creates middleware that logs API requests and responses
 func APILoggerConfig() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Generate a unique request ID
-		requestID := fmt.Sprintf("req-%s", uuid.New().String()[:8])
-		c.Locals("requestID", requestID)
+		// The request ID is assigned by the RequestID middleware
+		requestID, _ := c.Locals("requestID").(string)
 
 		// Start timer
 		start := time.Now()
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"app/src/clock"
 	"app/src/config"
 	"app/src/service"
 	"app/src/utils"
@@ -32,6 +33,11 @@ func Auth(userService service.UserService, productTokenService service.ProductTo
 			return fiber.NewError(fiber.StatusUnauthorized, "Please authenticate")
 		}
 
+		if user.IsSuspendedAt(clock.Now(c.UserContext())) {
+			return fiber.NewError(fiber.StatusForbidden,
+				fmt.Sprintf("Your account is suspended until %s", user.SuspendedUntil.Format("02 January 2006 15:04 MST")))
+		}
+
 		productToken, err := productTokenService.GetProductTokenByUserID(c, user.ID)
 		if err != nil && err != gorm.ErrRecordNotFound {
 			return fiber.ErrInternalServerError
@@ -64,27 +70,10 @@ func Auth(userService service.UserService, productTokenService service.ProductTo
 
 		c.Locals("user", user)
 
-		if len(requiredRights) > 0 {
-			userRights, hasRights := config.RoleRights[user.Role]
-			if (!hasRights || !hasAllRights(userRights, requiredRights)) && c.Params("userId") != userID {
-				return fiber.NewError(fiber.StatusForbidden, "You don't have permission to access this resource")
-			}
+		if len(requiredRights) > 0 && !config.HasRights(user.Role, requiredRights...) && c.Params("userId") != userID {
+			return fiber.NewError(fiber.StatusForbidden, "You don't have permission to access this resource")
 		}
 
 		return c.Next()
 	}
 }
-
-func hasAllRights(userRights, requiredRights []string) bool {
-	rightSet := make(map[string]struct{}, len(userRights))
-	for _, right := range userRights {
-		rightSet[right] = struct{}{}
-	}
-
-	for _, right := range requiredRights {
-		if _, exists := rightSet[right]; !exists {
-			return false
-		}
-	}
-	return true
-}
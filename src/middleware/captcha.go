@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Captcha lets requests through once they passed the CAPTCHA, see CaptchaService.Check
+func Captcha(captchaService service.CaptchaService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := captchaService.Check(c); err != nil {
+			return err
+		}
+		return c.Next()
+	}
+}
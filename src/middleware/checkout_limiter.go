@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// velocityTracker counts checkout attempts per key inside a sliding window and remembers temporary blocks
+type velocityTracker struct {
+	mu        sync.Mutex
+	attempts  map[string][]time.Time
+	blocked   map[string]time.Time
+	lastSweep time.Time
+}
+
+func newVelocityTracker() *velocityTracker {
+	return &velocityTracker{
+		attempts: make(map[string][]time.Time),
+		blocked:  make(map[string]time.Time),
+	}
+}
+
+// hit records an attempt for key and returns when its block ends, newlyBlocked is true for the attempt that triggered it
+func (t *velocityTracker) hit(key string, limit int, window, blockFor time.Duration, now time.Time) (blockedUntil time.Time, newlyBlocked bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sweep(window, now)
+
+	if until, ok := t.blocked[key]; ok {
+		if now.Before(until) {
+			return until, false
+		}
+		delete(t.blocked, key)
+	}
+
+	recent := t.attempts[key][:0]
+	for _, at := range t.attempts[key] {
+		if now.Sub(at) < window {
+			recent = append(recent, at)
+		}
+	}
+	recent = append(recent, now)
+
+	if len(recent) > limit {
+		delete(t.attempts, key)
+		until := now.Add(blockFor)
+		t.blocked[key] = until
+		return until, true
+	}
+
+	t.attempts[key] = recent
+	return time.Time{}, false
+}
+
+// sweep drops keys without recent attempts so the maps don't grow unbounded
+func (t *velocityTracker) sweep(window time.Duration, now time.Time) {
+	if now.Sub(t.lastSweep) < window {
+		return
+	}
+	t.lastSweep = now
+
+	for key, attempts := range t.attempts {
+		if len(attempts) == 0 || now.Sub(attempts[len(attempts)-1]) >= window {
+			delete(t.attempts, key)
+		}
+	}
+	for key, until := range t.blocked {
+		if !now.Before(until) {
+			delete(t.blocked, key)
+		}
+	}
+}
+
+// CheckoutVelocity limits checkout attempts per user and per IP address to stop card testing.
+// Keys exceeding the limit are blocked for a while and the block is written to the activity log.
+func CheckoutVelocity() fiber.Handler {
+	tracker := newVelocityTracker()
+	window := time.Duration(config.CheckoutWindowMinutes) * time.Minute
+	blockFor := time.Duration(config.CheckoutBlockMinutes) * time.Minute
+
+	return func(c *fiber.Ctx) error {
+		userID := ""
+		limits := map[string]int{"ip:" + c.IP(): config.CheckoutMaxAttemptsPerIP.Get()}
+		if user, ok := c.Locals("user").(*model.User); ok {
+			userID = user.ID.String()
+			limits["user:"+userID] = config.CheckoutMaxAttemptsPerUser.Get()
+		}
+
+		now := time.Now()
+		for key, limit := range limits {
+			blockedUntil, newlyBlocked := tracker.hit(key, limit, window, blockFor, now)
+			if blockedUntil.IsZero() {
+				continue
+			}
+
+			if newlyBlocked {
+				utils.LogUserActivity(utils.ActivityData{
+					UserID:   userID,
+					Action:   "checkout_blocked",
+					Resource: "checkout",
+					Details: map[string]interface{}{
+						"key":           key,
+						"limit":         limit,
+						"window":        window.String(),
+						"blocked_until": blockedUntil.Format(time.RFC3339),
+					},
+					IPAddress:  c.IP(),
+					UserAgent:  c.Get("User-Agent"),
+					StatusCode: fiber.StatusTooManyRequests,
+				})
+			}
+
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(blockedUntil.Sub(now).Seconds())+1))
+			return utils.APIError(c, fiber.StatusTooManyRequests,
+				"checkout_blocked",
+				"Too many checkout attempts, please try again later",
+				map[string]interface{}{
+					"blocked_until": blockedUntil,
+				})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package middleware
+
+import (
+	"context"
+	"net"
+)
+
+// watchDisconnect is not supported on this platform, admin requests only end at their deadline
+func watchDisconnect(_ net.Conn, _ context.CancelFunc) (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,66 @@
+//go:build linux || darwin
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+)
+
+// disconnectPollInterval is how often a running admin request checks that its client is still connected
+const disconnectPollInterval = 500 * time.Millisecond
+
+// watchDisconnect calls onDisconnect once the client closes the connection while the request runs.
+// It peeks at the socket without reading from it, so bytes of a pipelined request stay for the server.
+func watchDisconnect(conn net.Conn, onDisconnect context.CancelFunc) (stop func()) {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return func() {}
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(disconnectPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if peerClosed(raw) {
+					onDisconnect()
+					return
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// peerClosed reports an orderly shutdown (a zero byte peek) or a reset of the connection
+func peerClosed(raw syscall.RawConn) bool {
+	closed := false
+	buf := make([]byte, 1)
+
+	err := raw.Control(func(fd uintptr) {
+		n, _, err := syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		switch {
+		case err == nil:
+			closed = n == 0
+		case errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.EINTR):
+		default:
+			closed = true
+		}
+	})
+
+	return err == nil && closed
+}
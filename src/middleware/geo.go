@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Geo locates the request, its handlers read the location from the locals under geoip.LocalsKey
+func Geo(geoService service.GeoService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		geoService.Locate(c)
+		return c.Next()
+	}
+}
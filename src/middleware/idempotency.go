@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"app/src/utils"
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Idempotent lets clients retry a mutation safely by sending an Idempotency-Key header: the response of the first
+// request with the key is stored and returned to every retry with it, marked with Idempotent-Replayed, instead of
+// running the request again. A key is only good for the same method, path and body of the same user. Requests
+// without the header run as usual, and so do retries of requests that failed with an error or a server error.
+func Idempotent(idempotencyService service.IdempotencyService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(model.IdempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > model.IdempotencyKeyMaxLength {
+			return utils.APIError(c, fiber.StatusBadRequest,
+				"idempotency_key_invalid",
+				"The Idempotency-Key header is too long",
+				map[string]interface{}{
+					"max_length": model.IdempotencyKeyMaxLength,
+				})
+		}
+
+		scope := "path:" + c.Path()
+		if user, ok := c.Locals("user").(*model.User); ok {
+			scope = "user:" + user.ID.String()
+		}
+		fingerprint := model.IdempotencyFingerprint(c.Method(), c.Path(), c.Body())
+
+		record, claimed, err := idempotencyService.Begin(c.UserContext(), scope, key, fingerprint)
+		if err != nil {
+			return err
+		}
+
+		if !claimed {
+			switch {
+			case record.Fingerprint != fingerprint:
+				return utils.APIError(c, fiber.StatusUnprocessableEntity,
+					"idempotency_key_reused",
+					"This Idempotency-Key was already used for another request")
+			case !record.Completed():
+				c.Set(fiber.HeaderRetryAfter, "1")
+				return utils.APIError(c, fiber.StatusConflict,
+					"idempotency_key_in_use",
+					"A request with this Idempotency-Key is still being processed, please retry shortly")
+			}
+
+			c.Set(model.IdempotentReplayedHeader, "true")
+			if record.ContentType != "" {
+				c.Set(fiber.HeaderContentType, record.ContentType)
+			}
+			return c.Status(record.StatusCode).Send(record.Body)
+		}
+
+		err = c.Next()
+
+		// The request context may have timed out, the key must still be completed or freed
+		ctx := context.WithoutCancel(c.UserContext())
+		status := c.Response().StatusCode()
+		if err != nil || !model.IdempotentResponseStorable(status) {
+			if releaseErr := idempotencyService.Release(ctx, record); releaseErr != nil {
+				utils.Log.Errorf("Failed to release idempotency key %s: %v", key, releaseErr)
+			}
+			return err
+		}
+
+		body := append([]byte(nil), c.Response().Body()...)
+		if completeErr := idempotencyService.Complete(ctx, record, status, string(c.Response().Header.ContentType()), body); completeErr != nil {
+			utils.Log.Errorf("Failed to store the response of idempotency key %s: %v", key, completeErr)
+		}
+		return nil
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"app/src/config"
 	"app/src/response"
 	"time"
 
@@ -22,3 +23,33 @@ func LimiterConfig() fiber.Handler {
 		SkipSuccessfulRequests: true,
 	})
 }
+
+// PublicLimiter caps the requests an IP address sends to the unauthenticated public API at PUBLIC_RATE_LIMIT per minute
+func PublicLimiter() fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        config.PublicRateLimit,
+		Expiration: time.Minute,
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).
+				JSON(response.Common{
+					Status:  "error",
+					Message: "Too many requests, please try again later",
+				})
+		},
+	})
+}
+
+// HourlyLimiter caps the requests an IP address sends to a route at max an hour, whatever they answer
+func HourlyLimiter(max int) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: time.Hour,
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).
+				JSON(response.Common{
+					Status:  "error",
+					Message: "Too many requests, please try again later",
+				})
+		},
+	})
+}
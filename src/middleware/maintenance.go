@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"app/src/service"
+	"app/src/utils"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maintenanceExemptPaths stay reachable during maintenance so admins can work and payments keep settling
+var maintenanceExemptPaths = []string{
+	"/v1/admin",
+	"/v1/auth",
+	"/v1/health-check",
+	"/v1/docs",
+	"/v1/ops-bot",
+	"/v1/subscriptions/notification",
+	"/v1/iap/apple/notifications",
+	"/v1/iap/google/notifications",
+}
+
+// Maintenance answers 503 to user facing routes while maintenance mode is on
+func Maintenance(maintenanceService service.MaintenanceService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		for _, prefix := range maintenanceExemptPaths {
+			if strings.HasPrefix(c.Path(), prefix) {
+				return c.Next()
+			}
+		}
+
+		enabled, message := maintenanceService.IsEnabled(c.UserContext())
+		if !enabled {
+			return c.Next()
+		}
+
+		if message == "" {
+			message = "Nutribox is under maintenance, please try again later"
+		}
+
+		c.Set(fiber.HeaderRetryAfter, "300")
+		return utils.APIError(c, fiber.StatusServiceUnavailable, "maintenance", message)
+	}
+}
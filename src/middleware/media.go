@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"app/src/media"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SignMedia signs the media addresses of JSON responses for the CDN. Handlers and the records they save keep the
+// addresses as stored, so no expiring URL is persisted.
+func SignMedia() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		signer := media.Default()
+		if signer == nil || !strings.HasPrefix(string(c.Response().Header.ContentType()), fiber.MIMEApplicationJSON) {
+			return nil
+		}
+		c.Response().SetBodyRaw(signer.SignJSON(c.Response().Body(), time.Now()))
+		return nil
+	}
+}
+
+// VerifyMedia lets through the requests for files under /uploads that carry a valid signature, see
+// media.Signer.Verify. Without a signer the files are not served: payment proofs are among them.
+func VerifyMedia() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		signer := media.Default()
+		if signer == nil {
+			return fiber.ErrNotFound
+		}
+
+		query, err := url.ParseQuery(string(c.Request().URI().QueryString()))
+		if err != nil {
+			return fiber.NewError(fiber.StatusForbidden, "Media URL signature is invalid")
+		}
+		if err := signer.Verify(c.Path(), query, time.Now()); err != nil {
+			return fiber.NewError(fiber.StatusForbidden, "Media URL is not signed or has expired")
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"app/src/model"
+	"app/src/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ParentalConsentRequired keeps under-age users out of a feature until their guardian consented
+func ParentalConsentRequired() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := c.Locals("user").(*model.User)
+
+		if user.ParentalConsent == model.ParentalConsentPending {
+			return utils.APIError(c, fiber.StatusForbidden,
+				"parental_consent_required",
+				"Your parent or guardian has to consent before you can use this feature",
+				map[string]interface{}{
+					"consent_url": "/v1/users/me/parental-consent",
+				})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/service"
+	"app/src/utils"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// partnerWindow is the window of the per-scope rate limits of partner keys
+const partnerWindow = time.Minute
+
+// windowCounter counts requests per key in fixed windows
+type windowCounter struct {
+	mu      sync.Mutex
+	windows map[string]counterWindow
+}
+
+type counterWindow struct {
+	start time.Time
+	count int
+}
+
+func newWindowCounter() *windowCounter {
+	return &windowCounter{windows: make(map[string]counterWindow)}
+}
+
+// hit counts a request for key and returns when its window resets when the request is over limit
+func (w *windowCounter) hit(key string, limit int, now time.Time) (resetAt time.Time, allowed bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	current := w.windows[key]
+	if now.Sub(current.start) >= partnerWindow {
+		// Windows of other keys end as well, drop them so the map doesn't grow unbounded
+		for other, window := range w.windows {
+			if now.Sub(window.start) >= partnerWindow {
+				delete(w.windows, other)
+			}
+		}
+		current = counterWindow{start: now}
+	}
+	current.count++
+	w.windows[key] = current
+
+	return current.start.Add(partnerWindow), current.count <= limit
+}
+
+// partnerRequests is shared by every route, so a scope has one limit however many routes it guards
+var partnerRequests = newWindowCounter()
+
+// partnerSelfRateLimit is the number of requests a key makes per minute to the routes about its own partner
+const partnerSelfRateLimit = 30
+
+// PartnerKey lets a partner API key with scope through, within the rate limit of the key in the scope.
+// Premium scopes also need the partner to have accepted the current partner terms. Every request is
+// counted on the usage of the key, as an error when it is answered 4xx or 5xx.
+func PartnerKey(partnerService service.PartnerService, scope string) fiber.Handler {
+	definition, ok := model.LookupPartnerScope(scope)
+	if !ok {
+		panic("unknown partner scope " + scope)
+	}
+
+	return func(c *fiber.Ctx) error {
+		key, err := authenticatePartnerKey(c, partnerService)
+		if err != nil {
+			return err
+		}
+
+		if !key.HasScope(scope) {
+			return utils.APIError(c, fiber.StatusForbidden,
+				"scope_required",
+				"Your API key does not have the scope of this resource",
+				map[string]interface{}{
+					"scope": scope,
+				})
+		}
+		if definition.Premium && !key.AcceptedTerms(config.PartnerTermsVersion) {
+			return utils.APIError(c, fiber.StatusForbidden,
+				"partner_terms_required",
+				"Accept the current partner terms to use this scope",
+				map[string]interface{}{
+					"scope":         scope,
+					"terms_version": config.PartnerTermsVersion,
+				})
+		}
+
+		now := time.Now()
+		limit := key.RateLimit(scope)
+		resetAt, allowed := partnerRequests.hit(key.ID.String()+":"+scope, limit, now)
+		if !allowed {
+			if err := partnerService.RecordUsage(c.UserContext(), key.ID, scope, true, false); err != nil {
+				utils.Log.Errorf("Failed to record the usage of partner key %s: %v", key.ID, err)
+			}
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(resetAt.Sub(now).Seconds())+1))
+			return utils.APIError(c, fiber.StatusTooManyRequests,
+				"rate_limited",
+				"Too many requests in this scope, please try again later",
+				map[string]interface{}{
+					"scope": scope,
+					"limit": limit,
+				})
+		}
+
+		c.Locals("partnerKey", key)
+		err = c.Next()
+
+		failed := err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest
+		if err := partnerService.RecordUsage(c.UserContext(), key.ID, scope, false, failed); err != nil {
+			utils.Log.Errorf("Failed to record the usage of partner key %s: %v", key.ID, err)
+		}
+
+		return err
+	}
+}
+
+// PartnerSelf lets any partner API key through to the routes about its own partner, such as its usage. They
+// are not counted on the usage nor billed, but limited to partnerSelfRateLimit requests a minute.
+func PartnerSelf(partnerService service.PartnerService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key, err := authenticatePartnerKey(c, partnerService)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		resetAt, allowed := partnerRequests.hit(key.ID.String()+":self", partnerSelfRateLimit, now)
+		if !allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(resetAt.Sub(now).Seconds())+1))
+			return utils.APIError(c, fiber.StatusTooManyRequests,
+				"rate_limited",
+				"Too many requests, please try again later",
+				map[string]interface{}{
+					"limit": partnerSelfRateLimit,
+				})
+		}
+
+		c.Locals("partnerKey", key)
+		return c.Next()
+	}
+}
+
+// authenticatePartnerKey returns the active partner key sent in the X-API-Key header
+func authenticatePartnerKey(c *fiber.Ctx, partnerService service.PartnerService) (*model.PartnerKey, error) {
+	secret := c.Get("X-API-Key")
+	if secret == "" {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Please send your API key in the X-API-Key header")
+	}
+
+	key, err := partnerService.Authenticate(c.UserContext(), secret)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid API key")
+	}
+	return key, nil
+}
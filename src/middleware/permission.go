@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePermission lets through the users whose role, built-in or custom, grants every right. It runs after
+// Auth, which sets the user. Rights no role can grant are a typo and panic when the route is registered.
+func RequirePermission(rights ...string) fiber.Handler {
+	for _, right := range rights {
+		if !config.IsRight(right) {
+			panic(fmt.Sprintf("middleware: unknown right %q", right))
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		user, ok := c.Locals("user").(*model.User)
+		if !ok || user == nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "Please authenticate")
+		}
+
+		if !config.HasRights(user.Role, rights...) {
+			return fiber.NewError(fiber.StatusForbidden, "You don't have permission to access this resource")
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// adminPathPrefix marks the admin routes, whose reports can run long
+const adminPathPrefix = "/v1/admin"
+
+// RequestDeadline gives every request a context with a deadline, services pass c.UserContext() to their queries
+// so they are cancelled when it passes. Admin requests get adminTimeout and are also cancelled when the admin
+// disconnects, so an abandoned report stops holding a database connection. A zero timeout disables the deadline.
+func RequestDeadline(timeout, adminTimeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		admin := strings.HasPrefix(c.Path(), adminPathPrefix)
+		limit := timeout
+		if admin {
+			limit = adminTimeout
+		}
+
+		var ctx context.Context
+		var cancel context.CancelFunc
+		if limit > 0 {
+			ctx, cancel = context.WithTimeout(c.UserContext(), limit)
+		} else {
+			ctx, cancel = context.WithCancel(c.UserContext())
+		}
+		defer cancel()
+
+		if admin {
+			stop := watchDisconnect(c.Context().Conn(), cancel)
+			defer stop()
+		}
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
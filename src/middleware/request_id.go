@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"app/src/requestid"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestID keeps the X-Request-ID sent by the client or generates one, and returns it on every response.
+// The ID is stored in c.Locals("requestID") for the loggers and in c.UserContext() for the services,
+// which pass it on to background work, webhooks and gateway calls.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New("req")
+		}
+
+		c.Locals("requestID", id)
+		c.Set(requestid.Header, id)
+		c.SetUserContext(requestid.With(c.UserContext(), id))
+
+		return c.Next()
+	}
+}
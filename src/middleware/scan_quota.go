@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"app/src/utils"
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ScanQuota takes one AI scan from the user's subscription before the scan runs and gives it back when the scan fails
+func ScanQuota(scanQuotaService service.ScanQuotaService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := c.Locals("user").(*model.User)
+
+		quota, consumed, err := scanQuotaService.Consume(c, user.ID)
+		if err != nil {
+			return err
+		}
+		if quota == nil {
+			return c.Next()
+		}
+		if !consumed {
+			return utils.APIError(c, fiber.StatusTooManyRequests,
+				"scan_quota_exceeded",
+				"You have used all AI scans of your plan",
+				map[string]interface{}{
+					"upgrade_url": "/v1/subscriptions/plans",
+				})
+		}
+
+		if remaining := quota.Remaining(); remaining >= 0 {
+			c.Set("X-Scans-Remaining", strconv.Itoa(remaining))
+		}
+
+		err = c.Next()
+		if err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest {
+			// The request context may have timed out, which is a reason to refund
+			scanQuotaService.Refund(context.WithoutCancel(c.UserContext()), quota)
+		}
+		return err
+	}
+}
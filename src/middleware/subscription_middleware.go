@@ -4,11 +4,12 @@ import (
 	"app/src/model"
 	"app/src/service"
 	"app/src/utils"
+	"slices"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SubscriptionRequired(subService service.SubscriptionService, feature_name string) fiber.Handler {
+func SubscriptionRequired(subService service.SubscriptionService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		user := c.Locals("user").(*model.User)
 
@@ -21,25 +22,32 @@ func SubscriptionRequired(subService service.SubscriptionService, feature_name s
 					"upgrade_url": "/v1/subscriptions/plans",
 				})
 		}
-		if feature_name != "" {
-			// Check if the user has access to the requested feature
-			hasAccess, err := subService.CheckFeatureAccess(c, user.ID, feature_name)
-			if err != nil {
-				return utils.APIError(c, fiber.StatusForbidden,
-					"subscription_required",
-					"Active subscription required",
-					map[string]interface{}{
-						"upgrade_url": "/v1/subscriptions/plans",
-					})
-			}
-			if !hasAccess {
-				return utils.APIError(c, fiber.StatusForbidden,
-					"access",
-					"You don't have access to this feature",
-					map[string]interface{}{
-						"upgrade_url": "/v1/subscriptions/plans",
-					})
-			}
+
+		return c.Next()
+	}
+}
+
+// FeatureRequired lets a user through whose plan includes feature
+func FeatureRequired(featureAccessService service.FeatureAccessService, feature string) fiber.Handler {
+	if !slices.Contains(model.PlanFeatureKeys, feature) {
+		panic("unknown plan feature " + feature)
+	}
+
+	return func(c *fiber.Ctx) error {
+		user := c.Locals("user").(*model.User)
+
+		hasAccess, err := featureAccessService.HasFeature(c.UserContext(), user.ID, feature)
+		if err != nil {
+			return err
+		}
+		if !hasAccess {
+			return utils.APIError(c, fiber.StatusForbidden,
+				"access",
+				"You don't have access to this feature",
+				map[string]interface{}{
+					"feature":     feature,
+					"upgrade_url": "/v1/subscriptions/plans",
+				})
 		}
 
 		return c.Next()
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"app/src/clock"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// testClockHeader moves a request in time, the effective time is returned in the same header
+const testClockHeader = "X-Test-Clock"
+
+// TestClock lets QA run a request at another time, for instance the renewal day of a subscription, without
+// editing the database. The clock only shifts what the request reads through clock.Now, timestamps written by
+// the database stay real. Nothing happens unless enabled, which the configuration never is in production.
+func TestClock(enabled bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		value := c.Get(testClockHeader)
+		if !enabled || value == "" {
+			return c.Next()
+		}
+
+		now := time.Now()
+		offset, err := clock.ParseOverride(value, now)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "X-Test-Clock must be an RFC 3339 time or a duration such as 720h")
+		}
+
+		c.SetUserContext(clock.WithOffset(c.UserContext(), offset))
+		c.Set(testClockHeader, now.Add(offset).Format(time.RFC3339))
+		return c.Next()
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"app/src/utils"
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// VoiceLogQuota takes one voice log from the user's subscription before the voice log runs and gives it back
+// when it fails. Voice logging is for subscribers only, the quota is in c.Locals("voiceLogQuota").
+func VoiceLogQuota(voiceLogService service.VoiceLogService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := c.Locals("user").(*model.User)
+
+		quota, consumed, err := voiceLogService.ConsumeQuota(c, user.ID)
+		if err != nil {
+			return err
+		}
+		if quota == nil || quota.Limit == 0 {
+			return utils.APIError(c, fiber.StatusForbidden,
+				"voice_log_premium_required",
+				"Voice logging is part of premium plans",
+				map[string]interface{}{
+					"upgrade_url": "/v1/subscriptions/plans",
+				})
+		}
+		if !consumed {
+			return utils.APIError(c, fiber.StatusForbidden,
+				"voice_log_quota_exceeded",
+				"You have used all voice logs of your plan",
+				map[string]interface{}{
+					"upgrade_url": "/v1/subscriptions/plans",
+				})
+		}
+
+		if remaining := quota.Remaining(); remaining >= 0 {
+			c.Set("X-Voice-Logs-Remaining", strconv.Itoa(remaining))
+		}
+		c.Locals("voiceLogQuota", quota)
+
+		err = c.Next()
+		if err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest {
+			// The request context may have timed out, which is a reason to refund
+			voiceLogService.RefundQuota(context.WithoutCancel(c.UserContext()), quota)
+		}
+		return err
+	}
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ActivityLog is an activity utils.LogUserActivity logged, kept so admin changes can be traced
+type ActivityLog struct {
+	ID         uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID     string    `gorm:"size:64;index" json:"user_id"` // who acted, an admin for admin changes
+	Action     string    `gorm:"size:64;not null;index" json:"action"`
+	Resource   string    `gorm:"size:50;index:idx_activity_logs_resource" json:"resource,omitempty"`
+	ResourceID string    `gorm:"size:100;index:idx_activity_logs_resource" json:"resource_id,omitempty"`
+	Details    JSON      `gorm:"type:jsonb" json:"details,omitempty" swaggertype:"object"`
+	RequestID  string    `gorm:"size:64" json:"request_id"`
+	IPAddress  string    `gorm:"size:45" json:"ip_address,omitempty"`
+	UserAgent  string    `gorm:"size:255" json:"user_agent,omitempty"`
+	StatusCode int       `gorm:"not null;default:0" json:"status_code,omitempty"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (log *ActivityLog) BeforeCreate(_ *gorm.DB) error {
+	log.ID = uuid.New()
+	return nil
+}
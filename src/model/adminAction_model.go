@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Kinds of destructive admin actions that are staged before they take effect
+const (
+	AdminActionDeletePlan          = "delete_subscription_plan" // targets one plan
+	AdminActionCancelSubscriptions = "cancel_subscriptions"     // targets the subscriptions to cancel
+	AdminActionDeleteSubscription  = "delete_user_subscription" // targets one subscription, soft deleted
+)
+
+// Statuses of a staged admin action
+const (
+	AdminActionStaged    = "staged"
+	AdminActionFinalized = "finalized"
+	AdminActionUndone    = "undone"
+	AdminActionFailed    = "failed"
+)
+
+// AdminAction is a destructive admin action held for the undo window. Nothing changes until it is finalized,
+// undoing it within the window drops it.
+type AdminAction struct {
+	ID            uuid.UUID   `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Kind          string      `gorm:"size:50;not null" json:"kind"`
+	TargetIDs     []uuid.UUID `gorm:"type:jsonb;serializer:json;not null" json:"target_ids"`
+	Reason        string      `gorm:"size:255" json:"reason,omitempty"`
+	Status        string      `gorm:"size:20;not null;index" json:"status"`
+	RequestedByID uuid.UUID   `gorm:"type:uuid;not null" json:"requested_by_id"`
+	FinalizeAt    time.Time   `gorm:"not null;index" json:"finalize_at"`
+	FinalizedAt   *time.Time  `json:"finalized_at,omitempty"`
+	UndoneByID    *uuid.UUID  `gorm:"type:uuid" json:"undone_by_id,omitempty"`
+	UndoneAt      *time.Time  `json:"undone_at,omitempty"`
+	Error         string      `gorm:"type:text" json:"error,omitempty"` // why finalizing failed
+	CreatedAt     time.Time   `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (action *AdminAction) BeforeCreate(_ *gorm.DB) error {
+	action.ID = uuid.New()
+	return nil
+}
+
+// CanUndo reports whether the action is still within its undo window at now
+func (action *AdminAction) CanUndo(now time.Time) bool {
+	return action.Status == AdminActionStaged && now.Before(action.FinalizeAt)
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Business events admins can subscribe to
+const (
+	AlertPaymentFailureSpike    = "payment_failure_spike"
+	AlertLargeRefund            = "large_refund"
+	AlertAIProviderError        = "ai_provider_error"
+	AlertReconciliationMismatch = "reconciliation_mismatch" // valued by the number of records a reconciliation job corrected
+)
+
+// Alert delivery channels
+const (
+	AlertChannelEmail    = "email"
+	AlertChannelSlack    = "slack"
+	AlertChannelTelegram = "telegram"
+)
+
+// AlertRule subscribes a channel to a business event.
+// Counted events fire once Threshold occurrences happen within WindowMinutes,
+// valued events fire when a single occurrence is worth at least Threshold.
+type AlertRule struct {
+	ID              uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Event           string     `gorm:"size:50;not null;index" json:"event"`
+	Channel         string     `gorm:"size:20;not null" json:"channel"`
+	Target          string     `gorm:"size:512;not null" json:"target"` // email address, Slack webhook URL or Telegram chat ID
+	Threshold       int        `gorm:"not null;default:1" json:"threshold"`
+	WindowMinutes   int        `gorm:"not null;default:0" json:"window_minutes"`
+	CooldownMinutes int        `gorm:"not null;default:60" json:"cooldown_minutes"`
+	IsActive        bool       `gorm:"default:true" json:"is_active"`
+	CreatedByID     uuid.UUID  `gorm:"not null" json:"created_by_id"`
+	LastTriggeredAt *time.Time `gorm:"default:null" json:"last_triggered_at,omitempty"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (alertRule *AlertRule) BeforeCreate(_ *gorm.DB) error {
+	alertRule.ID = uuid.New()
+	return nil
+}
+
+// IsCountedAlertEvent reports whether an event is measured by how often it happens rather than by its value
+func IsCountedAlertEvent(event string) bool {
+	return event == AlertPaymentFailureSpike || event == AlertAIProviderError
+}
+
+// Matches reports whether an occurrence crosses the rule threshold, value is the number of
+// occurrences within the window for counted events and the occurrence value otherwise
+func (alertRule *AlertRule) Matches(value int) bool {
+	return alertRule.IsActive && value >= alertRule.Threshold
+}
+
+// InCooldown reports whether the rule fired too recently to fire again at the given time
+func (alertRule *AlertRule) InCooldown(now time.Time) bool {
+	if alertRule.LastTriggeredAt == nil {
+		return false
+	}
+	return now.Before(alertRule.LastTriggeredAt.Add(time.Duration(alertRule.CooldownMinutes) * time.Minute))
+}
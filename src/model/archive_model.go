@@ -0,0 +1,34 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Live tables that the archival job moves old rows out of, both archive on created_at
+const (
+	TransactionDetailsTable = "transaction_details"
+	MealHistoryDetailsTable = "meal_history_details"
+)
+
+// ArchivedTables lists the live tables that have an archive
+var ArchivedTables = []string{TransactionDetailsTable, MealHistoryDetailsTable}
+
+// ArchiveTable is the partitioned table holding the archived rows of a live table
+func ArchiveTable(table string) string {
+	return table + "_archive"
+}
+
+// ArchivePartition is the yearly partition of the archive that holds rows created at t, with its bounds
+func ArchivePartition(table string, t time.Time) (name string, from, to time.Time) {
+	t = t.UTC()
+	from = time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	to = from.AddDate(1, 0, 0)
+	return fmt.Sprintf("%s_%d", ArchiveTable(table), t.Year()), from, to
+}
+
+// ArchiveCutoff is the creation time before which rows are archived, the start of the month months ago
+func ArchiveCutoff(now time.Time, months int) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -months, 0)
+}
@@ -14,7 +14,7 @@ type Article struct {
 	CategoryID  *uuid.UUID       `json:"category_id,omitempty"`
 	Category    *ArticleCategory `gorm:"foreignKey:CategoryID" json:"-"` // Add relationship to ArticleCategory
 	Slug        string           `gorm:"unique;not null" json:"slug"`
-	Image       *string          `json:"image,omitempty"`
+	Image       *ContentImage    `json:"image,omitempty"`
 	Content     string           `gorm:"type:text;not null" json:"content"`
 	PublishedAt *time.Time       `json:"published_at,omitempty"`
 	CreatedAt   time.Time        `gorm:"autoCreateTime:milli" json:"-"`
@@ -23,17 +23,17 @@ type Article struct {
 
 // ArticleResponse is used for API responses with category name
 type ArticleResponse struct {
-	ID           uuid.UUID  `json:"id"`
-	UserID       uuid.UUID  `json:"user_id"`
-	Title        string     `json:"title"`
-	CategoryID   *uuid.UUID `json:"category_id,omitempty"`
-	CategoryName string     `json:"category_name,omitempty"`
-	Slug         string     `json:"slug"`
-	Image        *string    `json:"image,omitempty"`
-	Content      string     `json:"content"`
-	PublishedAt  *time.Time `json:"published_at,omitempty"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	ID           uuid.UUID     `json:"id"`
+	UserID       uuid.UUID     `json:"user_id"`
+	Title        string        `json:"title"`
+	CategoryID   *uuid.UUID    `json:"category_id,omitempty"`
+	CategoryName string        `json:"category_name,omitempty"`
+	Slug         string        `json:"slug"`
+	Image        *ContentImage `json:"image,omitempty"`
+	Content      string        `json:"content"`
+	PublishedAt  *time.Time    `json:"published_at,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
 }
 
 func (article *Article) BeforeCreate(_ *gorm.DB) error {
@@ -0,0 +1,190 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Why the guardrails stepped into a message
+const (
+	// AssistantGuardEmergency messages get the emergency reply instead of an answer of the model
+	AssistantGuardEmergency = "emergency"
+	// AssistantGuardMedical answers get the medical disclaimer
+	AssistantGuardMedical = "medical"
+)
+
+// assistantEmergencyTerms and assistantMedicalTerms are matched as parts of the lowercased message
+var (
+	assistantEmergencyTerms = []string{
+		"bunuh diri", "ingin mati", "suicide", "kill myself", "overdosis", "overdose", "nyeri dada", "chest pain",
+		"sesak napas", "sesak nafas", "can't breathe", "cannot breathe", "pingsan", "keracunan", "poisoning",
+	}
+	assistantMedicalTerms = []string{
+		"obat", "dosis", "dose", "medication", "medicine", "insulin", "diagnos", "resep dokter", "prescription",
+		"penyakit", "disease", "diabetes", "hipertensi", "hypertension", "kolesterol", "cholesterol", "kanker",
+		"cancer", "ginjal", "kidney", "hamil", "pregnan", "menyusui", "breastfeed", "alergi", "allerg",
+	}
+)
+
+// AssistantEmergencyReply is sent instead of an answer of the model to messages about an emergency
+const AssistantEmergencyReply = "Sepertinya Anda sedang dalam keadaan darurat. Segera hubungi layanan darurat 119 atau 112, " +
+	"atau datangi IGD rumah sakit terdekat. Asisten gizi ini tidak dapat membantu dalam keadaan darurat."
+
+// AssistantMedicalDisclaimer is added to answers about medicines, diseases and pregnancy
+const AssistantMedicalDisclaimer = "Informasi ini bersifat umum dan bukan pengganti saran medis. " +
+	"Konsultasikan dengan dokter atau ahli gizi untuk kondisi kesehatan, obat atau dosis Anda."
+
+// AssistantGuardrail returns why the guardrails step into a message of a user, empty when they do not
+func AssistantGuardrail(message string) string {
+	message = strings.ToLower(message)
+	for _, term := range assistantEmergencyTerms {
+		if strings.Contains(message, term) {
+			return AssistantGuardEmergency
+		}
+	}
+	for _, term := range assistantMedicalTerms {
+		if strings.Contains(message, term) {
+			return AssistantGuardMedical
+		}
+	}
+	return ""
+}
+
+// AssistantConversation is a chat of a user with the nutrition assistant
+type AssistantConversation struct {
+	ID        uuid.UUID          `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID    uuid.UUID          `gorm:"type:uuid;not null;index" json:"-"`
+	Title     string             `gorm:"size:100;not null" json:"title"`
+	CreatedAt time.Time          `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
+	Messages  []AssistantMessage `gorm:"foreignKey:ConversationID" json:"messages,omitempty"`
+}
+
+func (conversation *AssistantConversation) BeforeCreate(_ *gorm.DB) error {
+	conversation.ID = uuid.New()
+	return nil
+}
+
+// AssistantMessage is a message of a conversation, of the user or of the assistant. The provider, model and
+// tokens of answers are kept for cost reports.
+type AssistantMessage struct {
+	ID               uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	ConversationID   uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	UserID           uuid.UUID `gorm:"type:uuid;not null;index:idx_assistant_message_user_created" json:"-"`
+	Role             string    `gorm:"size:10;not null" json:"role"`
+	Content          string    `gorm:"type:text;not null" json:"content"`
+	Guardrail        string    `gorm:"size:20;not null;default:''" json:"guardrail,omitempty"`
+	Provider         string    `gorm:"size:20;not null;default:''" json:"-"`
+	Model            string    `gorm:"size:100;not null;default:''" json:"-"`
+	PromptTokens     int       `gorm:"not null;default:0" json:"-"`
+	CompletionTokens int       `gorm:"not null;default:0" json:"-"`
+	CreatedAt        time.Time `gorm:"autoCreateTime;index:idx_assistant_message_user_created" json:"created_at"`
+}
+
+func (message *AssistantMessage) BeforeCreate(_ *gorm.DB) error {
+	message.ID = uuid.New()
+	return nil
+}
+
+// AssistantUsage counts the messages of a user to the assistant on a day, it stays when conversations are deleted
+type AssistantUsage struct {
+	UserID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Date     time.Time `gorm:"primaryKey;type:date"`
+	Messages int       `gorm:"not null;default:0"`
+}
+
+// AssistantQuota is the messages a user may send today and those they sent, a limit of -1 is unlimited
+type AssistantQuota struct {
+	Limit int `json:"limit"`
+	Used  int `json:"used"`
+}
+
+// Exhausted reports whether no message is left today
+func (quota AssistantQuota) Exhausted() bool {
+	return quota.Limit >= 0 && quota.Used >= quota.Limit
+}
+
+// AssistantReply is the answer to a message with the quota left
+type AssistantReply struct {
+	ConversationID uuid.UUID        `json:"conversation_id"`
+	Message        AssistantMessage `json:"message"`
+	Quota          AssistantQuota   `json:"quota"`
+}
+
+// AssistantContext is what the assistant knows of the user: profile, targets and the diary of the last days
+type AssistantContext struct {
+	Name          string
+	Age           *int
+	Gender        string
+	ActivityLevel string
+	Height        *float64
+	Weight        *float64
+	TargetWeight  *float64
+	Restrictions  []string
+	// Days are the daily summaries of the last days, oldest first, and TodayMeals the meals logged today
+	Days       []DailyNutritionSummary
+	TodayMeals []MealHistory
+}
+
+// assistantRules keep the model on nutrition and out of medical care
+const assistantRules = `Anda adalah asisten gizi aplikasi Nutribox. Jawab dalam bahasa yang dipakai pengguna, singkat dan praktis.
+Aturan:
+- Jawab hanya pertanyaan tentang gizi, makanan, pola makan, aktivitas fisik dan kebiasaan sehat. Tolak topik lain dengan sopan.
+- Jangan mendiagnosis penyakit, jangan menyarankan, mengubah atau menghentikan obat, dosis atau terapi. Sarankan pengguna ke dokter untuk hal itu.
+- Jangan menyarankan asupan di bawah 1200 kkal per hari, puasa ekstrem atau penurunan berat lebih dari 1 kg per minggu.
+- Untuk ibu hamil, menyusui, anak dan penderita penyakit kronis, sarankan konsultasi dengan dokter atau ahli gizi.
+- Gunakan data pengguna di bawah bila relevan, jangan mengarang data yang tidak ada.`
+
+// AssistantSystemPrompt is the system prompt of a chat with the rules and the context of the user
+func AssistantSystemPrompt(user AssistantContext, now time.Time) string {
+	var prompt strings.Builder
+	prompt.WriteString(assistantRules)
+	prompt.WriteString("\n\nData pengguna (" + now.Format("2006-01-02") + "):\n")
+
+	if user.Name != "" {
+		fmt.Fprintf(&prompt, "- Nama: %s\n", user.Name)
+	}
+	if user.Age != nil {
+		fmt.Fprintf(&prompt, "- Umur: %d tahun\n", *user.Age)
+	}
+	if user.Gender != "" {
+		fmt.Fprintf(&prompt, "- Jenis kelamin: %s\n", user.Gender)
+	}
+	if user.ActivityLevel != "" {
+		fmt.Fprintf(&prompt, "- Tingkat aktivitas: %s\n", user.ActivityLevel)
+	}
+	if user.Height != nil {
+		fmt.Fprintf(&prompt, "- Tinggi: %g cm\n", *user.Height)
+	}
+	if user.Weight != nil {
+		fmt.Fprintf(&prompt, "- Berat: %g kg\n", *user.Weight)
+	}
+	if user.TargetWeight != nil {
+		fmt.Fprintf(&prompt, "- Target berat: %g kg (%s)\n", *user.TargetWeight, DietGoal(user.Weight, user.TargetWeight))
+	}
+	if len(user.Restrictions) > 0 {
+		fmt.Fprintf(&prompt, "- Pantangan makan: %s\n", strings.Join(user.Restrictions, ", "))
+	}
+
+	if len(user.Days) == 0 {
+		prompt.WriteString("- Belum ada catatan makan pada hari-hari terakhir\n")
+	} else {
+		prompt.WriteString("- Catatan makan harian (kalori kkal, protein g, karbohidrat g, lemak g):\n")
+		for _, day := range user.Days {
+			fmt.Fprintf(&prompt, "  %s: %g kkal, protein %g, karbohidrat %g, lemak %g, %d kali makan\n",
+				day.Date.Format("2006-01-02"), day.Calories, day.Protein, day.Carbs, day.Fat, day.MealCount)
+		}
+	}
+	if len(user.TodayMeals) > 0 {
+		prompt.WriteString("- Makanan hari ini:\n")
+		for _, meal := range user.TodayMeals {
+			fmt.Fprintf(&prompt, "  %s %s: %g kkal\n", meal.MealTime.Format("15:04"), meal.Title, meal.Calories)
+		}
+	}
+
+	return prompt.String()
+}
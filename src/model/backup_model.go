@@ -0,0 +1,41 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Backup statuses, a requested backup is picked up by the backup job
+const (
+	BackupPending   = "pending"
+	BackupRunning   = "running"
+	BackupCompleted = "completed"
+	BackupFailed    = "failed"
+)
+
+// Backup is a logical dump of the database (pg_dump custom format) uploaded to object storage
+type Backup struct {
+	ID            uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Status        string     `gorm:"size:20;not null;index" json:"status"`
+	Reason        string     `gorm:"size:255" json:"reason"`
+	ObjectURL     string     `gorm:"size:512" json:"object_url"`
+	SizeBytes     int64      `gorm:"not null;default:0" json:"size_bytes"`
+	Error         string     `gorm:"type:text" json:"error,omitempty"`
+	RequestedByID uuid.UUID  `gorm:"not null" json:"requested_by_id"`
+	StartedAt     *time.Time `gorm:"default:null" json:"started_at"`
+	CompletedAt   *time.Time `gorm:"default:null" json:"completed_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (backup *Backup) BeforeCreate(_ *gorm.DB) error {
+	backup.ID = uuid.New()
+	return nil
+}
+
+// BackupObjectKey is where the dump of a backup started at t is stored, sorted by time under prefix
+func BackupObjectKey(prefix string, id uuid.UUID, t time.Time) string {
+	return fmt.Sprintf("%s%s-%s.dump", prefix, t.UTC().Format("20060102T150405Z"), id.String()[:8])
+}
@@ -28,4 +28,7 @@ type BahanMakanan struct {
 	BddPersen         float64  `json:"bdd_persen"`
 	MentahOlahan      string   `json:"mentah_olahan"`
 	KelompokMakanan   string   `json:"kelompok_makanan"`
+
+	// Grade is set on the foods the API returns, the food service has none
+	Grade *HealthGrade `json:"grade,omitempty" gorm:"-"`
 }
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	CheckoutOpen    = "open"
+	CheckoutPending = "pending" // a gateway transaction was created and waits for payment
+	CheckoutPaid    = "paid"
+	CheckoutFailed  = "failed"
+	CheckoutExpired = "expired"
+)
+
+// CheckoutSession is a priced checkout of a plan, gateway transactions are created from it and reported back to it
+type CheckoutSession struct {
+	ID                  uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Token               string     `gorm:"size:64;not null;uniqueIndex" json:"token"`
+	UserID              uuid.UUID  `gorm:"not null;index" json:"user_id"`
+	PlanID              uuid.UUID  `gorm:"not null" json:"plan_id"`
+	CouponID            *uuid.UUID `gorm:"default:null" json:"coupon_id,omitempty"`
+	CouponCode          string     `gorm:"size:50" json:"coupon_code,omitempty"`
+	PaymentMethod       string     `json:"payment_method"`
+	Installment         bool       `json:"installment"`
+	AutoRenew           bool       `gorm:"not null;default:false" json:"auto_renew"`
+	Currency            string     `gorm:"size:3;not null;default:IDR" json:"currency"` // of every amount of the session
+	Subtotal            int        `gorm:"not null" json:"subtotal"`
+	Discount            int        `gorm:"not null;default:0" json:"discount"`
+	ProrationCredit     int        `gorm:"not null;default:0" json:"proration_credit"` // unused part of the subscription an upgrade replaces
+	Total               int        `gorm:"not null" json:"total"`
+	UpgradeFromID       *uuid.UUID `gorm:"type:uuid;default:null" json:"upgrade_from_id,omitempty"` // cancelled once the upgrade is paid
+	Status              string     `gorm:"size:20;not null;default:open;index" json:"status"`
+	OrderID             *string    `gorm:"size:100;uniqueIndex" json:"order_id,omitempty"`
+	PaymentToken        *string    `json:"-"`
+	PaymentURL          *string    `json:"payment_url,omitempty"`
+	WalletAmountApplied int        `gorm:"default:0" json:"wallet_amount_applied"`
+	AmountDue           int        `gorm:"default:0" json:"amount_due"`
+	UserSubscriptionID  *uuid.UUID `gorm:"default:null;index" json:"user_subscription_id,omitempty"`
+	ExpiresAt           time.Time  `gorm:"not null" json:"expires_at"`
+	PaidAt              *time.Time `gorm:"default:null" json:"paid_at,omitempty"`
+	CreatedAt           time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Filled by the service for responses
+	PaymentLink string                    `gorm:"-" json:"payment_link"`
+	Plan        *SubscriptionPlanResponse `gorm:"-" json:"plan,omitempty"`
+}
+
+// TotalMoney is the total to pay with the currency of the session
+func (checkoutSession *CheckoutSession) TotalMoney() Money {
+	return Money{Amount: int64(checkoutSession.Total), Currency: checkoutSession.Currency}
+}
+
+func (checkoutSession *CheckoutSession) BeforeCreate(_ *gorm.DB) error {
+	checkoutSession.ID = uuid.New()
+	return nil
+}
+
+// IsPayable reports whether a payment can still be started from the session
+func (checkoutSession *CheckoutSession) IsPayable(now time.Time) bool {
+	switch checkoutSession.Status {
+	case CheckoutOpen, CheckoutPending, CheckoutFailed:
+		return now.Before(checkoutSession.ExpiresAt)
+	}
+	return false
+}
+
+// CheckoutFunnelSteps are the events a purchase goes through, in order
+var CheckoutFunnelSteps = []string{EventPlanViewed, EventCheckoutCreated, EventPaymentAttempted, EventCheckoutPaid}
+
+// CheckoutFunnel follows the users who went through the checkout of a plan in a period, a user counts once
+// per plan
+type CheckoutFunnel struct {
+	From  string               `json:"from"`
+	To    string               `json:"to"`
+	Steps []CheckoutFunnelStep `json:"steps"` // of every plan together
+	Plans []CheckoutFunnelPlan `json:"plans"`
+}
+
+type CheckoutFunnelPlan struct {
+	PlanID   uuid.UUID            `json:"plan_id"`
+	PlanName string               `json:"plan_name"`
+	Steps    []CheckoutFunnelStep `json:"steps"`
+}
+
+type CheckoutFunnelStep struct {
+	Step string `json:"step"`
+	// Users who did the step, in any order
+	Users int64 `json:"users"`
+	// Users who did the step and every step before it, and those the previous step lost
+	Reached        int64   `json:"reached"`
+	DropOff        int64   `json:"drop_off"`
+	DropOffPercent float64 `json:"drop_off_percent"` // of the users who reached the previous step
+	Conversion     float64 `json:"conversion"`       // percent of the users who reached the first step
+}
+
+// NewCheckoutFunnelSteps builds the steps of a funnel from the users who did each step and who reached it,
+// having done every step before it too
+func NewCheckoutFunnelSteps(users, reached map[string]int64) []CheckoutFunnelStep {
+	steps := make([]CheckoutFunnelStep, len(CheckoutFunnelSteps))
+	first := reached[CheckoutFunnelSteps[0]]
+	previous := first
+	for i, step := range CheckoutFunnelSteps {
+		steps[i] = CheckoutFunnelStep{
+			Step:    step,
+			Users:   users[step],
+			Reached: reached[step],
+			DropOff: previous - reached[step],
+		}
+		if previous > 0 {
+			steps[i].DropOffPercent = percent(steps[i].DropOff, previous)
+		}
+		if first > 0 {
+			steps[i].Conversion = percent(reached[step], first)
+		}
+		previous = reached[step]
+	}
+	return steps
+}
+
+// percent is part of whole in percent, rounded to one decimal
+func percent(part, whole int64) float64 {
+	return math.Round(float64(part)*1000/float64(whole)) / 10
+}
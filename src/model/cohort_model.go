@@ -0,0 +1,206 @@
+package model
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Dimensions users are grouped into cohorts by
+const (
+	CohortSignupMonth = "signup_month"
+	CohortPlan        = "plan"
+	CohortSource      = "source"
+)
+
+// Cohorts of users without a paid subscription and without an acquisition source
+const (
+	CohortFreePlan      = "free"
+	CohortOrganicSource = "organic"
+)
+
+// CohortRetentionWeeks are the weeks after sign up retention is reported for, week 0 is the week of sign up
+var CohortRetentionWeeks = []int{1, 2, 4, 8, 12}
+
+// CohortAdherenceDays is the period after sign up adherence is measured over
+const CohortAdherenceDays = 28
+
+// CohortWindowDays is how long after sign up the activity of a user counts, up to the end of the last
+// retention week
+func CohortWindowDays() int {
+	return (CohortRetentionWeeks[len(CohortRetentionWeeks)-1] + 1) * 7
+}
+
+// NormalizeAcquisitionSource is the source a user signed up from as stored: lowercase, spaces as underscores
+func NormalizeAcquisitionSource(source string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(source)), " ", "_")
+}
+
+// UserCohortStats is the cohort of a user and their activity after sign up, refreshed every night
+type UserCohortStats struct {
+	UserID      uuid.UUID `gorm:"type:uuid;primaryKey"`
+	SignupDate  time.Time `gorm:"type:date;not null"`
+	SignupMonth time.Time `gorm:"type:date;not null;index"`
+	Plan        string    `gorm:"size:100;not null"` // name of the plan of the first paid subscription, free without one
+	Source      string    `gorm:"size:50;not null"`
+	// ActiveWeeks has bit N set when the user logged a meal in week N after sign up
+	ActiveWeeks int64 `gorm:"not null;default:0"`
+	// LoggedDays is the number of days with a logged meal in the first CohortAdherenceDays days
+	LoggedDays int       `gorm:"not null;default:0"`
+	ComputedAt time.Time `gorm:"not null"`
+}
+
+// CohortAggregate is the activity of the users who signed up in a month on a plan from a source. Every
+// count adds up, so cohorts of any dimension are sums of aggregates.
+type CohortAggregate struct {
+	SignupMonth time.Time `gorm:"type:date;primaryKey" json:"signup_month"`
+	Plan        string    `gorm:"size:100;primaryKey" json:"plan"`
+	Source      string    `gorm:"size:50;primaryKey" json:"source"`
+	Users       int64     `gorm:"not null" json:"users"`
+	// Eligible and Retained are per CohortRetentionWeeks: the users whose week is over, and those of them who logged in it
+	Eligible []int64 `gorm:"type:jsonb;serializer:json;not null" json:"eligible"`
+	Retained []int64 `gorm:"type:jsonb;serializer:json;not null" json:"retained"`
+	// AdherenceUsers are the users whose first CohortAdherenceDays days are over, LoggedDays the days they logged on
+	AdherenceUsers int64     `gorm:"not null" json:"adherence_users"`
+	LoggedDays     int64     `gorm:"not null" json:"logged_days"`
+	ComputedAt     time.Time `gorm:"not null" json:"computed_at"`
+}
+
+// Add counts the stats of a user on today
+func (aggregate *CohortAggregate) Add(stats UserCohortStats, today time.Time) {
+	if aggregate.Eligible == nil {
+		aggregate.Eligible = make([]int64, len(CohortRetentionWeeks))
+		aggregate.Retained = make([]int64, len(CohortRetentionWeeks))
+	}
+
+	aggregate.Users++
+	elapsed := daysBetween(stats.SignupDate, today)
+	for i, week := range CohortRetentionWeeks {
+		if elapsed < (week+1)*7 {
+			continue
+		}
+		aggregate.Eligible[i]++
+		if stats.ActiveWeeks&(1<<week) != 0 {
+			aggregate.Retained[i]++
+		}
+	}
+	if elapsed >= CohortAdherenceDays {
+		aggregate.AdherenceUsers++
+		aggregate.LoggedDays += int64(stats.LoggedDays)
+	}
+}
+
+// daysBetween counts the calendar days from one date to another
+func daysBetween(from, to time.Time) int {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// CohortRetention is the share of the users of a cohort who logged a meal in a week after sign up. Rate is
+// nil while no user of the cohort reached the end of the week.
+type CohortRetention struct {
+	Week     int      `json:"week"`
+	Eligible int64    `json:"eligible"`
+	Retained int64    `json:"retained"`
+	Rate     *float64 `json:"rate"`
+}
+
+// CohortAdherence is the share of days users of a cohort logged a meal on in their first CohortAdherenceDays days
+type CohortAdherence struct {
+	Users int64    `json:"users"`
+	Rate  *float64 `json:"rate"`
+	// AverageLoggedDays is out of CohortAdherenceDays
+	AverageLoggedDays *float64 `json:"average_logged_days"`
+}
+
+// Cohort is a cohort of a comparison with its retention and adherence
+type Cohort struct {
+	Cohort    string            `json:"cohort"`
+	Users     int64             `json:"users"`
+	Retention []CohortRetention `json:"retention"`
+	Adherence CohortAdherence   `json:"adherence"`
+}
+
+// CohortComparison compares the cohorts of a dimension among the users who signed up in a range of months
+type CohortComparison struct {
+	Dimension     string     `json:"dimension"`
+	From          string     `json:"from"` // 2006-01
+	To            string     `json:"to"`   // 2006-01
+	AdherenceDays int        `json:"adherence_days"`
+	ComputedAt    *time.Time `json:"computed_at"` // last aggregation, nil without any cohort
+	Cohorts       []Cohort   `json:"cohorts"`
+}
+
+// CompareCohorts sums aggregates into the cohorts of dimension, ordered by signup month or by size
+func CompareCohorts(aggregates []CohortAggregate, dimension string) []Cohort {
+	type sum struct {
+		users, adherenceUsers, loggedDays int64
+		eligible, retained                []int64
+	}
+	sums := map[string]*sum{}
+	for _, aggregate := range aggregates {
+		var key string
+		switch dimension {
+		case CohortPlan:
+			key = aggregate.Plan
+		case CohortSource:
+			key = aggregate.Source
+		default:
+			key = aggregate.SignupMonth.Format("2006-01")
+		}
+
+		total, ok := sums[key]
+		if !ok {
+			total = &sum{eligible: make([]int64, len(CohortRetentionWeeks)), retained: make([]int64, len(CohortRetentionWeeks))}
+			sums[key] = total
+		}
+		total.users += aggregate.Users
+		total.adherenceUsers += aggregate.AdherenceUsers
+		total.loggedDays += aggregate.LoggedDays
+		// Aggregates computed with other retention weeks are left out of the weeks
+		if len(aggregate.Eligible) == len(CohortRetentionWeeks) && len(aggregate.Retained) == len(CohortRetentionWeeks) {
+			for i := range CohortRetentionWeeks {
+				total.eligible[i] += aggregate.Eligible[i]
+				total.retained[i] += aggregate.Retained[i]
+			}
+		}
+	}
+
+	cohorts := make([]Cohort, 0, len(sums))
+	for key, total := range sums {
+		cohort := Cohort{
+			Cohort:    key,
+			Users:     total.users,
+			Retention: make([]CohortRetention, len(CohortRetentionWeeks)),
+			Adherence: CohortAdherence{Users: total.adherenceUsers},
+		}
+		for i, week := range CohortRetentionWeeks {
+			cohort.Retention[i] = CohortRetention{Week: week, Eligible: total.eligible[i], Retained: total.retained[i]}
+			if total.eligible[i] > 0 {
+				rate := float64(total.retained[i]) / float64(total.eligible[i])
+				cohort.Retention[i].Rate = &rate
+			}
+		}
+		if total.adherenceUsers > 0 {
+			average := float64(total.loggedDays) / float64(total.adherenceUsers)
+			rate := average / CohortAdherenceDays
+			cohort.Adherence.AverageLoggedDays = &average
+			cohort.Adherence.Rate = &rate
+		}
+		cohorts = append(cohorts, cohort)
+	}
+
+	sort.Slice(cohorts, func(i, j int) bool {
+		if dimension == CohortSignupMonth {
+			return cohorts[i].Cohort < cohorts[j].Cohort
+		}
+		if cohorts[i].Users != cohorts[j].Users {
+			return cohorts[i].Users > cohorts[j].Users
+		}
+		return cohorts[i].Cohort < cohorts[j].Cohort
+	})
+	return cohorts
+}
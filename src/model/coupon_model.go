@@ -0,0 +1,62 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Coupon gives a percentage or fixed discount on a checkout
+type Coupon struct {
+	ID              uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Code            string     `gorm:"size:50;not null;uniqueIndex" json:"code"`
+	Description     string     `json:"description"`
+	DiscountPercent int        `gorm:"not null;default:0" json:"discount_percent"`
+	DiscountAmount  int        `gorm:"not null;default:0" json:"discount_amount"` // in the minor unit of Currency
+	Currency        string     `gorm:"size:3;not null;default:IDR" json:"currency"`
+	PlanID          *uuid.UUID `gorm:"default:null" json:"plan_id,omitempty"` // nil applies to every plan
+	ValidFrom       *time.Time `gorm:"default:null" json:"valid_from,omitempty"`
+	ValidUntil      *time.Time `gorm:"default:null" json:"valid_until,omitempty"`
+	MaxRedemptions  int        `gorm:"not null;default:0" json:"max_redemptions"` // 0 for unlimited
+	Redemptions     int        `gorm:"not null;default:0" json:"redemptions"`
+	IsActive        bool       `gorm:"default:true" json:"is_active"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (coupon *Coupon) BeforeCreate(_ *gorm.DB) error {
+	coupon.ID = uuid.New()
+	return nil
+}
+
+// IsRedeemableAt reports whether the coupon can be applied to the plan at the given time
+func (coupon *Coupon) IsRedeemableAt(now time.Time, planID uuid.UUID) bool {
+	if !coupon.IsActive {
+		return false
+	}
+	if coupon.PlanID != nil && *coupon.PlanID != planID {
+		return false
+	}
+	if coupon.ValidFrom != nil && now.Before(*coupon.ValidFrom) {
+		return false
+	}
+	if coupon.ValidUntil != nil && !now.Before(*coupon.ValidUntil) {
+		return false
+	}
+	if coupon.MaxRedemptions > 0 && coupon.Redemptions >= coupon.MaxRedemptions {
+		return false
+	}
+	return true
+}
+
+// DiscountFor returns the discount on price, never more than the price itself. The percentage is rounded
+// to the minor unit, a fixed amount only applies to prices in the coupon's currency.
+func (coupon *Coupon) DiscountFor(price Money) Money {
+	discount := price.Percent(coupon.DiscountPercent)
+	if coupon.Currency == price.Currency {
+		discount = discount.Add(Money{Amount: int64(coupon.DiscountAmount), Currency: price.Currency})
+	}
+	discount.Amount = min(discount.Amount, price.Amount)
+	return discount
+}
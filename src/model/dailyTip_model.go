@@ -0,0 +1,236 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Metrics a tip rule reads from the daily nutrition summaries
+const (
+	TipMetricCalories  = "calories"
+	TipMetricProtein   = "protein"
+	TipMetricCarbs     = "carbs"
+	TipMetricFat       = "fat"
+	TipMetricMealCount = "meal_count"
+	// TipMetricAlways matches every user, for general tips on days no other rule does
+	TipMetricAlways = "always"
+)
+
+// How a rule compares the metric of a day with its threshold
+const (
+	TipBelow = "below"
+	TipAbove = "above"
+)
+
+// TipMaxWindowDays bounds the days a rule looks back on
+const TipMaxWindowDays = 30
+
+// TipRule is a tip of the catalog admins manage with the diary pattern it is given for: the metric is
+// below or above the threshold on at least MinDays of the last WindowDays days. Nutrient metrics count the
+// days with meals only, meal_count every day. Title and message are Go templates with the variables days,
+// window, threshold and average (of the metric over the days with meals).
+type TipRule struct {
+	ID           uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Key          string     `gorm:"size:50;not null;uniqueIndex" json:"key"`
+	Metric       string     `gorm:"size:20;not null" json:"metric"`
+	Comparison   string     `gorm:"size:10;not null" json:"comparison"`
+	Threshold    float64    `gorm:"not null;default:0" json:"threshold"`
+	MinDays      int        `gorm:"not null;default:1" json:"min_days"`
+	WindowDays   int        `gorm:"not null;default:7" json:"window_days"`
+	Title        string     `gorm:"size:255;not null" json:"title"`
+	Message      string     `gorm:"type:text;not null" json:"message"`
+	Priority     int        `gorm:"not null;default:0" json:"priority"`      // the highest matching rule gives the tip
+	CooldownDays int        `gorm:"not null;default:0" json:"cooldown_days"` // days before a user gets the tip again
+	Enabled      bool       `gorm:"not null;default:true" json:"enabled"`
+	UpdatedByID  *uuid.UUID `gorm:"type:uuid" json:"updated_by_id,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (rule *TipRule) BeforeCreate(_ *gorm.DB) error {
+	rule.ID = uuid.New()
+	return nil
+}
+
+// DailyTip is the tip of a user for a day. It is built once from the days before, so it stays the same all day.
+type DailyTip struct {
+	UserID    uuid.UUID  `gorm:"type:uuid;primaryKey" json:"-"`
+	Date      time.Time  `gorm:"primaryKey;type:date" json:"date"`
+	RuleKey   string     `gorm:"size:50;not null;index" json:"rule_key"`
+	Title     string     `gorm:"size:255;not null" json:"title"`
+	Message   string     `gorm:"type:text;not null" json:"message"`
+	EmailedAt *time.Time `json:"-"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"-"`
+}
+
+// TipMatch is a rule matching the diary of a user
+type TipMatch struct {
+	Rule    TipRule
+	Days    int
+	Average float64
+}
+
+// tipMetric returns the metric of a day, false for a rule that reads none
+func tipMetric(summary DailyNutritionSummary, metric string) (float64, bool) {
+	switch metric {
+	case TipMetricCalories:
+		return summary.Calories, true
+	case TipMetricProtein:
+		return summary.Protein, true
+	case TipMetricCarbs:
+		return summary.Carbs, true
+	case TipMetricFat:
+		return summary.Fat, true
+	case TipMetricMealCount:
+		return float64(summary.MealCount), true
+	}
+	return 0, false
+}
+
+// IsTipMetric reports whether a rule can read a metric
+func IsTipMetric(metric string) bool {
+	_, ok := tipMetric(DailyNutritionSummary{}, metric)
+	return ok || metric == TipMetricAlways
+}
+
+// MatchTipRule checks a rule against the summaries of the days before today, days without a summary had no meals
+func MatchTipRule(rule TipRule, summaries []DailyNutritionSummary, today time.Time) (TipMatch, bool) {
+	match := TipMatch{Rule: rule}
+	if rule.Metric == TipMetricAlways {
+		return match, true
+	}
+
+	byDay := make(map[string]DailyNutritionSummary, len(summaries))
+	for _, summary := range summaries {
+		byDay[summary.Date.Format("2006-01-02")] = summary
+	}
+
+	logged, total := 0, 0.0
+	for i := 1; i <= rule.WindowDays; i++ {
+		summary := byDay[today.AddDate(0, 0, -i).Format("2006-01-02")]
+		if summary.MealCount == 0 && rule.Metric != TipMetricMealCount {
+			continue
+		}
+		value, ok := tipMetric(summary, rule.Metric)
+		if !ok {
+			return match, false
+		}
+		if summary.MealCount > 0 {
+			logged++
+			total += value
+		}
+
+		if (rule.Comparison == TipBelow && value < rule.Threshold) || (rule.Comparison == TipAbove && value > rule.Threshold) {
+			match.Days++
+		}
+	}
+	if logged > 0 {
+		match.Average = roundNutrition(total / float64(logged))
+	}
+
+	return match, match.Days >= rule.MinDays
+}
+
+// PickTip picks the tip of a day: the matching enabled rule of the highest priority, then of the most days,
+// leaving out those shown within their cooldown. lastShown holds the day each rule was last shown by key.
+func PickTip(rules []TipRule, summaries []DailyNutritionSummary, lastShown map[string]time.Time, today time.Time) (*TipMatch, bool) {
+	matches := []TipMatch{}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		if shown, ok := lastShown[rule.Key]; ok && today.Before(shown.AddDate(0, 0, rule.CooldownDays+1)) {
+			continue
+		}
+		if match, ok := MatchTipRule(rule, summaries, today); ok {
+			matches = append(matches, match)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, false
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Rule.Priority != matches[j].Rule.Priority {
+			return matches[i].Rule.Priority > matches[j].Rule.Priority
+		}
+		if matches[i].Days != matches[j].Days {
+			return matches[i].Days > matches[j].Days
+		}
+		return matches[i].Rule.Key < matches[j].Rule.Key
+	})
+	return &matches[0], true
+}
+
+// Render fills the title and message of the rule
+func (match TipMatch) Render() (string, string, error) {
+	return RenderTemplate(match.Rule.Title, match.Rule.Message, map[string]string{
+		"days":      strconv.Itoa(match.Days),
+		"window":    strconv.Itoa(match.Rule.WindowDays),
+		"threshold": strconv.FormatFloat(match.Rule.Threshold, 'f', -1, 64),
+		"average":   strconv.FormatFloat(match.Average, 'f', -1, 64),
+	})
+}
+
+// CheckTipRule reports the first mistake of a rule, rendering it with sample values
+func CheckTipRule(rule TipRule) error {
+	if !IsTipMetric(rule.Metric) {
+		return fmt.Errorf("unknown metric %q", rule.Metric)
+	}
+	if rule.Metric != TipMetricAlways && rule.MinDays > rule.WindowDays {
+		return fmt.Errorf("min_days must not be more than window_days")
+	}
+	_, _, err := TipMatch{Rule: rule, Days: rule.MinDays, Average: rule.Threshold}.Render()
+	return err
+}
+
+// DefaultTipRules seed the catalog
+var DefaultTipRules = []TipRule{
+	{
+		Key: "low_protein", Metric: TipMetricProtein, Comparison: TipBelow, Threshold: 50, MinDays: 4, WindowDays: 7,
+		Priority: 50, CooldownDays: 3, Enabled: true,
+		Title:   "Protein Anda masih kurang",
+		Message: "Asupan protein Anda kurang dari {{.threshold}} g pada {{.days}} dari {{.window}} hari terakhir. Tambahkan telur, tempe, tahu, ikan atau ayam pada makan Anda hari ini.",
+	},
+	{
+		Key: "high_calories", Metric: TipMetricCalories, Comparison: TipAbove, Threshold: 2500, MinDays: 4, WindowDays: 7,
+		Priority: 45, CooldownDays: 3, Enabled: true,
+		Title:   "Kalori harian Anda tinggi",
+		Message: "Anda makan lebih dari {{.threshold}} kkal pada {{.days}} dari {{.window}} hari terakhir, rata-rata {{.average}} kkal. Coba porsi nasi yang lebih kecil dan ganti camilan dengan buah.",
+	},
+	{
+		Key: "high_fat", Metric: TipMetricFat, Comparison: TipAbove, Threshold: 80, MinDays: 4, WindowDays: 7,
+		Priority: 40, CooldownDays: 3, Enabled: true,
+		Title:   "Kurangi makanan berlemak",
+		Message: "Lemak Anda lebih dari {{.threshold}} g pada {{.days}} dari {{.window}} hari terakhir. Pilih makanan yang direbus, dikukus atau dipanggang daripada yang digoreng.",
+	},
+	{
+		Key: "high_carbs", Metric: TipMetricCarbs, Comparison: TipAbove, Threshold: 400, MinDays: 4, WindowDays: 7,
+		Priority: 35, CooldownDays: 3, Enabled: true,
+		Title:   "Karbohidrat Anda berlebih",
+		Message: "Karbohidrat Anda lebih dari {{.threshold}} g pada {{.days}} dari {{.window}} hari terakhir. Ganti sebagian nasi putih dengan sayur atau sumber protein.",
+	},
+	{
+		Key: "low_calories", Metric: TipMetricCalories, Comparison: TipBelow, Threshold: 1200, MinDays: 4, WindowDays: 7,
+		Priority: 30, CooldownDays: 3, Enabled: true,
+		Title:   "Makan Anda mungkin kurang",
+		Message: "Anda makan kurang dari {{.threshold}} kkal pada {{.days}} dari {{.window}} hari terakhir. Pastikan Anda tidak melewatkan waktu makan, atau catat semua makanan Anda.",
+	},
+	{
+		Key: "missed_logging", Metric: TipMetricMealCount, Comparison: TipBelow, Threshold: 1, MinDays: 3, WindowDays: 7,
+		Priority: 20, CooldownDays: 6, Enabled: true,
+		Title:   "Jangan lupa mencatat makanan",
+		Message: "Anda tidak mencatat makanan pada {{.days}} dari {{.window}} hari terakhir. Mencatat setiap hari membuat saran kami lebih tepat.",
+	},
+	{
+		Key: "drink_water", Metric: TipMetricAlways, Comparison: TipAbove, MinDays: 0, WindowDays: 7,
+		Priority: 0, CooldownDays: 0, Enabled: true,
+		Title:   "Tetap terhidrasi",
+		Message: "Minum setidaknya 8 gelas air putih hari ini dan batasi minuman manis.",
+	},
+}
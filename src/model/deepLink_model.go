@@ -0,0 +1,82 @@
+package model
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Screens of the app a deep link opens
+const (
+	DeepLinkRenewSubscription = "renew_subscription" // target is the plan to renew to, optional
+	DeepLinkDiaryDay          = "diary_day"          // target is the day, 2006-01-02
+	DeepLinkScanResult        = "scan_result"        // target is the meal of the scan
+)
+
+// DeepLink is what a tracked link opens and who it was sent to
+type DeepLink struct {
+	Kind   string    `json:"kind"`
+	UserID uuid.UUID `json:"user_id"`
+	Target string    `json:"target,omitempty"`
+	Source string    `json:"source,omitempty"` // the notification or campaign the link is in
+}
+
+// Path is the path of the screen under the app's link domain, which the app opens through universal links
+// and the web app serves when it is not installed
+func (link DeepLink) Path() (string, error) {
+	switch link.Kind {
+	case DeepLinkRenewSubscription:
+		if link.Target == "" {
+			return "subscription/renew", nil
+		}
+		if _, err := uuid.Parse(link.Target); err != nil {
+			return "", errors.New("the target of a renew_subscription link is a plan ID")
+		}
+		return "subscription/renew?" + url.Values{"plan_id": {link.Target}}.Encode(), nil
+	case DeepLinkDiaryDay:
+		if _, err := time.Parse("2006-01-02", link.Target); err != nil {
+			return "", errors.New("the target of a diary_day link is a day as 2006-01-02")
+		}
+		return "diary/" + link.Target, nil
+	case DeepLinkScanResult:
+		if _, err := uuid.Parse(link.Target); err != nil {
+			return "", errors.New("the target of a scan_result link is a meal ID")
+		}
+		return "meals/" + link.Target, nil
+	default:
+		return "", errors.New("unknown deep link kind")
+	}
+}
+
+// DeepLinkClick records a tracked link being opened
+type DeepLinkClick struct {
+	ID        uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Kind      string    `gorm:"size:30;not null;index:idx_deep_link_click_kind" json:"kind"`
+	Target    string    `gorm:"size:64" json:"target"`
+	Source    string    `gorm:"size:50;index" json:"source"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	UserAgent string    `gorm:"size:255" json:"user_agent"`
+	ClickedAt time.Time `gorm:"not null;index:idx_deep_link_click_kind" json:"clicked_at"`
+}
+
+func (click *DeepLinkClick) BeforeCreate(_ *gorm.DB) error {
+	click.ID = uuid.New()
+	return nil
+}
+
+// DeepLinkClickStats counts the clicks on the links of a kind sent from a source
+type DeepLinkClickStats struct {
+	Kind        string `json:"kind"`
+	Source      string `json:"source"`
+	Clicks      int64  `json:"clicks"`
+	UniqueUsers int64  `json:"unique_users"`
+}
+
+// BuiltDeepLink is a tracked link ready to be sent
+type BuiltDeepLink struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
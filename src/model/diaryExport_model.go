@@ -0,0 +1,143 @@
+package model
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Formats a diary can be exported in
+const (
+	DiaryExportCSV = "csv"
+	DiaryExportPDF = "pdf"
+)
+
+// Statuses of an export produced in the background
+const (
+	DiaryExportPending   = "pending"
+	DiaryExportRunning   = "running"
+	DiaryExportCompleted = "completed"
+	DiaryExportFailed    = "failed"
+	DiaryExportExpired   = "expired"
+)
+
+// DiaryExport is an export of the food diary of a user over a date range, produced in the background for
+// large ranges. The file is kept in the database until it expires, so any instance can serve it.
+type DiaryExport struct {
+	ID          uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"-"`
+	Format      string     `gorm:"size:10;not null" json:"format"`
+	From        time.Time  `gorm:"type:date;not null" json:"from"`
+	To          time.Time  `gorm:"type:date;not null" json:"to"`
+	Status      string     `gorm:"size:20;not null;index" json:"status"`
+	Meals       int        `gorm:"not null;default:0" json:"meals"`
+	SizeBytes   int        `gorm:"not null;default:0" json:"size_bytes"`
+	Content     []byte     `gorm:"type:bytea" json:"-"`
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	StartedAt   *time.Time `gorm:"default:null" json:"-"`
+	CompletedAt *time.Time `gorm:"default:null" json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `gorm:"default:null;index" json:"expires_at,omitempty"`
+	// DownloadURL is a link downloading the file once without signing in, issued each time a completed export
+	// is read
+	DownloadURL       string     `gorm:"-" json:"download_url,omitempty"`
+	DownloadExpiresAt *time.Time `gorm:"-" json:"download_expires_at,omitempty"`
+}
+
+func (export *DiaryExport) BeforeCreate(_ *gorm.DB) error {
+	export.ID = uuid.New()
+	return nil
+}
+
+// Filename is the name the file is downloaded as
+func (export *DiaryExport) Filename() string {
+	return fmt.Sprintf("nutribox-diary-%s-%s.%s", export.From.Format("2006-01-02"), export.To.Format("2006-01-02"), export.Format)
+}
+
+// ContentType is the media type of the file
+func (export *DiaryExport) ContentType() string {
+	if export.Format == DiaryExportPDF {
+		return "application/pdf"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// DiaryDay is the meals of a day of a diary with their total
+type DiaryDay struct {
+	Date  time.Time
+	Meals []MealHistory
+	Total NutritionAmounts
+}
+
+// DiaryDays groups meals ordered by time into the days they were eaten, days without meals are left out
+func DiaryDays(meals []MealHistory) []DiaryDay {
+	var days []DiaryDay
+	for _, meal := range meals {
+		date := time.Date(meal.MealTime.Year(), meal.MealTime.Month(), meal.MealTime.Day(), 0, 0, 0, 0, meal.MealTime.Location())
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(date) {
+			days = append(days, DiaryDay{Date: date})
+		}
+		day := &days[len(days)-1]
+		day.Meals = append(day.Meals, meal)
+		day.Total.Calories += meal.Calories
+		day.Total.Protein += meal.Protein
+		day.Total.Carbs += meal.Carbs
+		day.Total.Fat += meal.Fat
+	}
+	return days
+}
+
+// diaryCSVHeader are the columns of a CSV export
+var diaryCSVHeader = []string{"date", "time", "meal", "label", "calories_kcal", "protein_g", "carbs_g", "fat_g", "comment"}
+
+// DiaryCSV writes meals ordered by time as CSV, one meal per row
+func DiaryCSV(meals []MealHistory) ([]byte, error) {
+	var out bytes.Buffer
+	writer := csv.NewWriter(&out)
+	if err := writer.Write(diaryCSVHeader); err != nil {
+		return nil, err
+	}
+
+	amount := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	for _, meal := range meals {
+		var label, comment string
+		if meal.Label != nil {
+			label = *meal.Label
+		}
+		if meal.Comment != nil {
+			comment = *meal.Comment
+		}
+		if err := writer.Write([]string{
+			meal.MealTime.Format("2006-01-02"),
+			meal.MealTime.Format("15:04"),
+			csvText(meal.Title),
+			csvText(label),
+			amount(meal.Calories),
+			amount(meal.Protein),
+			amount(meal.Carbs),
+			amount(meal.Fat),
+			csvText(comment),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	return out.Bytes(), writer.Error()
+}
+
+// csvText keeps spreadsheets from running text typed by the user as a formula
+func csvText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
@@ -0,0 +1,144 @@
+package model
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DiaryShare is a read-only link to the diary and vitals of a user over a date range, for a doctor or
+// dietitian who has no account. The link stops working when it expires or the user revokes it.
+type DiaryShare struct {
+	ID        uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"-"`
+	Label     string     `gorm:"size:100;not null;default:''" json:"label"`
+	From      time.Time  `gorm:"type:date;not null" json:"from"`
+	To        time.Time  `gorm:"type:date;not null" json:"to"`
+	Diary     bool       `gorm:"not null" json:"diary"`
+	Vitals    bool       `gorm:"not null" json:"vitals"`
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	RevokedAt *time.Time `gorm:"default:null" json:"revoked_at,omitempty"`
+	// Views counts the times the link was opened
+	Views        int        `gorm:"not null;default:0" json:"views"`
+	LastViewedAt *time.Time `gorm:"default:null" json:"last_viewed_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	// URL is the link to hand out, only returned when the share is created
+	URL string `gorm:"-" json:"url,omitempty"`
+}
+
+func (share *DiaryShare) BeforeCreate(_ *gorm.DB) error {
+	share.ID = uuid.New()
+	return nil
+}
+
+// Active reports whether the link still opens on now
+func (share *DiaryShare) Active(now time.Time) bool {
+	return share.RevokedAt == nil && now.Before(share.ExpiresAt)
+}
+
+// SharedDiary is what a share link shows: who the user is, the meals of every day of the range and the
+// weights recorded in it, each when the user shared it
+type SharedDiary struct {
+	Label     string        `json:"label,omitempty"`
+	From      string        `json:"from"` // 2006-01-02
+	To        string        `json:"to"`   // 2006-01-02
+	ExpiresAt time.Time     `json:"expires_at"`
+	Patient   SharedPatient `json:"patient"`
+	Diary     *SharedFood   `json:"diary,omitempty"`
+	Vitals    []SharedVital `json:"vitals,omitempty"`
+}
+
+// SharedPatient is the user a diary is shared by, without contact details
+type SharedPatient struct {
+	Name   string      `json:"name"`
+	Age    *int        `json:"age,omitempty"`
+	Gender *GenderType `json:"gender,omitempty"`
+}
+
+// SharedFood is the diary part of a share: the logged days and their average
+type SharedFood struct {
+	LoggedDays int              `json:"logged_days"`
+	TotalDays  int              `json:"total_days"`
+	Average    NutritionAmounts `json:"average"` // per logged day
+	Days       []SharedDay      `json:"days"`
+}
+
+// SharedDay is a logged day of a shared diary
+type SharedDay struct {
+	Date  string           `json:"date"` // 2006-01-02
+	Total NutritionAmounts `json:"total"`
+	Meals []SharedMeal     `json:"meals,omitempty"` // left out when the user shares daily totals only
+}
+
+// SharedMeal is a meal of a shared diary, without its photo or comment
+type SharedMeal struct {
+	Time     string  `json:"time"` // 15:04
+	Title    string  `json:"title"`
+	Label    *string `json:"label,omitempty"`
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"`
+	Carbs    float64 `json:"carbs"`
+	Fat      float64 `json:"fat"`
+}
+
+// SharedVital is a weight and height recorded by the user
+type SharedVital struct {
+	RecordedAt time.Time `json:"recorded_at"`
+	Weight     float64   `json:"weight"` // kg
+	Height     float64   `json:"height"` // cm
+	BMI        float64   `json:"bmi"`
+}
+
+// NewSharedFood lays out meals ordered by time over a range of totalDays days
+func NewSharedFood(meals []MealHistory, totalDays int) *SharedFood {
+	food := &SharedFood{TotalDays: totalDays, Days: []SharedDay{}}
+	for _, day := range DiaryDays(meals) {
+		shared := SharedDay{Date: day.Date.Format("2006-01-02"), Total: day.Total, Meals: make([]SharedMeal, len(day.Meals))}
+		for i, meal := range day.Meals {
+			shared.Meals[i] = SharedMeal{
+				Time:     meal.MealTime.Format("15:04"),
+				Title:    meal.Title,
+				Label:    meal.Label,
+				Calories: meal.Calories,
+				Protein:  meal.Protein,
+				Carbs:    meal.Carbs,
+				Fat:      meal.Fat,
+			}
+		}
+		food.Days = append(food.Days, shared)
+
+		food.Average.Calories += day.Total.Calories
+		food.Average.Protein += day.Total.Protein
+		food.Average.Carbs += day.Total.Carbs
+		food.Average.Fat += day.Total.Fat
+	}
+
+	food.LoggedDays = len(food.Days)
+	if food.LoggedDays > 0 {
+		count := float64(food.LoggedDays)
+		food.Average.Calories /= count
+		food.Average.Protein /= count
+		food.Average.Carbs /= count
+		food.Average.Fat /= count
+	}
+	return food
+}
+
+// OnlyTotals leaves the daily totals of the diary and drops its meals, for users who share a summary only
+func (food *SharedFood) OnlyTotals() {
+	for i := range food.Days {
+		food.Days[i].Meals = nil
+	}
+}
+
+// NewSharedVital is a recorded weight and height with its body mass index, rounded to one decimal
+func NewSharedVital(record UsersWeightHeightHistory) SharedVital {
+	vital := SharedVital{RecordedAt: record.RecordedAt, Weight: record.Weight, Height: record.Height}
+	if record.Height > 0 {
+		meters := record.Height / 100
+		vital.BMI = math.Round(record.Weight/(meters*meters)*10) / 10
+	}
+	return vital
+}
@@ -0,0 +1,166 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Types of the domain events, each one is appended with the change it describes
+const (
+	EventMealLogged        = "meal_logged"     // subject is the meal, payload day and scans
+	EventMealMoved         = "meal_moved"      // subject is the meal, payload the new day
+	EventMealDeleted       = "meal_deleted"    // subject is the meal
+	EventMealScanAdded     = "meal_scan_added" // subject is the meal that got one more scan
+	EventUserLoggedIn      = "user_logged_in"  // subject is the login streak row, payload day and streak
+	EventRevenueRecognized = "revenue_recognized"
+	// Onboarding milestones without a change of their own, subject is the user
+	EventProfileCompleted       = "profile_completed"
+	EventNotificationPermission = "notification_permission" // payload whether the app was allowed to notify
+	// Checkout funnel, payload the plan: subject is the plan for views, the checkout session for the rest
+	EventPlanViewed       = "plan_viewed"
+	EventCheckoutCreated  = "checkout_created"
+	EventPaymentAttempted = "payment_attempted"
+	EventCheckoutPaid     = "checkout_paid"
+	// An admin cancelled the subscription, subject is the subscription and payload its plan
+	EventSubscriptionCancelled = "subscription_cancelled"
+)
+
+// Derived tables the event log can rebuild
+const (
+	ProjectionUserCounters = "user_counters"
+	ProjectionOnboarding   = "onboarding"
+)
+
+var Projections = []string{ProjectionUserCounters, ProjectionOnboarding}
+
+// DomainEvent is an entry of the append-only event log. Replaying the log in sequence order rebuilds
+// the derived tables after a bug corrupted them.
+type DomainEvent struct {
+	Sequence   int64     `gorm:"primaryKey;autoIncrement" json:"sequence"`
+	Type       string    `gorm:"not null;index" json:"type"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	SubjectID  uuid.UUID `gorm:"type:uuid;not null" json:"subject_id"`
+	Payload    string    `gorm:"type:jsonb;not null;default:'{}'" json:"payload"`
+	RequestID  string    `json:"request_id"`
+	OccurredAt time.Time `gorm:"not null;index" json:"occurred_at"`
+}
+
+// EventPayload holds the data of every event type, each type only fills its own fields
+type EventPayload struct {
+	Day      string `json:"day,omitempty"` // 2006-01-02
+	Scans    int    `json:"scans,omitempty"`
+	Streak   int    `json:"streak,omitempty"`
+	Amount   int    `json:"amount,omitempty"`   // in the minor unit of Currency
+	Currency string `json:"currency,omitempty"` // empty for Rupiah
+	Granted  bool   `json:"granted,omitempty"`
+	PlanID   string `json:"plan_id,omitempty"`
+}
+
+// EventDay is the calendar day of t as events store it
+func EventDay(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// NewDomainEvent builds an event of eventType about subjectID
+func NewDomainEvent(eventType string, userID, subjectID uuid.UUID, payload EventPayload, occurredAt time.Time) (*DomainEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DomainEvent{
+		Type:       eventType,
+		UserID:     userID,
+		SubjectID:  subjectID,
+		Payload:    string(data),
+		OccurredAt: occurredAt,
+	}, nil
+}
+
+// EventReplay reports a rebuild of a derived table
+type EventReplay struct {
+	Projection string    `json:"projection"`
+	Events     int       `json:"events"`
+	Users      int       `json:"users"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// UserCounters are the denormalized counters stored on users
+type UserCounters struct {
+	TotalScans      int
+	TotalLoggedDays int
+	CurrentStreak   int
+	LifetimeSpend   int64
+}
+
+type projectedMeal struct {
+	day   string
+	scans int
+}
+
+// UserCounterProjection folds the events of one user into their counters
+type UserCounterProjection struct {
+	meals     map[uuid.UUID]*projectedMeal
+	lastLogin string
+	streak    int
+	spend     int64
+}
+
+func NewUserCounterProjection() *UserCounterProjection {
+	return &UserCounterProjection{meals: make(map[uuid.UUID]*projectedMeal)}
+}
+
+// Apply adds an event, events must come in sequence order
+func (p *UserCounterProjection) Apply(event *DomainEvent) error {
+	var payload EventPayload
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+		return err
+	}
+
+	switch event.Type {
+	case EventMealLogged:
+		p.meals[event.SubjectID] = &projectedMeal{day: payload.Day, scans: payload.Scans}
+	case EventMealMoved:
+		if meal, ok := p.meals[event.SubjectID]; ok {
+			meal.day = payload.Day
+		}
+	case EventMealDeleted:
+		delete(p.meals, event.SubjectID)
+	case EventMealScanAdded:
+		if meal, ok := p.meals[event.SubjectID]; ok {
+			meal.scans++
+		}
+	case EventUserLoggedIn:
+		if payload.Day >= p.lastLogin {
+			p.lastLogin = payload.Day
+			p.streak = payload.Streak
+		}
+	case EventRevenueRecognized:
+		// Lifetime spend is in Rupiah
+		if payload.Currency == "" || payload.Currency == CurrencyIDR {
+			p.spend += int64(payload.Amount)
+		}
+	}
+	return nil
+}
+
+// Counters returns the counters after the events applied so far, a streak only counts while the
+// last login is on or after streakSince
+func (p *UserCounterProjection) Counters(streakSince time.Time) UserCounters {
+	counters := UserCounters{LifetimeSpend: p.spend}
+
+	days := make(map[string]bool)
+	for _, meal := range p.meals {
+		counters.TotalScans += meal.scans
+		days[meal.day] = true
+	}
+	counters.TotalLoggedDays = len(days)
+
+	if p.lastLogin != "" && p.lastLogin >= EventDay(streakSince) {
+		counters.CurrentStreak = p.streak
+	}
+	return counters
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Files a download link can be issued for
+const (
+	DownloadDiaryExport = "diary_export"
+)
+
+// Outcomes of an attempt to download through a link
+const (
+	DownloadServed      = "served"
+	DownloadAlreadyUsed = "already_used" // the link was used before, the file was not served again
+	DownloadExpired     = "expired"
+	DownloadUnavailable = "unavailable" // the file could not be served, e.g. it expired, the link stays usable
+)
+
+// DownloadLink lets whoever holds it download a file of a user once, without signing in, until it expires.
+// Links are issued every time the file is read, a used or expired one is never served again.
+type DownloadLink struct {
+	ID         uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Kind       string     `gorm:"size:30;not null" json:"kind"`
+	ResourceID uuid.UUID  `gorm:"type:uuid;not null;index" json:"resource_id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"-"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	UsedAt     *time.Time `gorm:"default:null" json:"used_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (link *DownloadLink) BeforeCreate(_ *gorm.DB) error {
+	link.ID = uuid.New()
+	return nil
+}
+
+// Refusal is the outcome of a download through the link on now that is refused, empty when it is served
+func (link *DownloadLink) Refusal(now time.Time) string {
+	switch {
+	case link.UsedAt != nil:
+		return DownloadAlreadyUsed
+	case !now.Before(link.ExpiresAt):
+		return DownloadExpired
+	}
+	return ""
+}
+
+// DownloadAttempt audits a download through a link, served or refused. Attempts are kept after their link is
+// purged.
+type DownloadAttempt struct {
+	ID         uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	LinkID     uuid.UUID `gorm:"type:uuid;not null" json:"link_id"`
+	Kind       string    `gorm:"size:30;not null" json:"kind"`
+	ResourceID uuid.UUID `gorm:"type:uuid;not null;index" json:"resource_id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	Outcome    string    `gorm:"size:20;not null" json:"outcome"`
+	IPAddress  string    `gorm:"size:45" json:"ip_address"`
+	UserAgent  string    `gorm:"size:255" json:"user_agent"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (attempt *DownloadAttempt) BeforeCreate(_ *gorm.DB) error {
+	attempt.ID = uuid.New()
+	return nil
+}
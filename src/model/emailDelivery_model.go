@@ -0,0 +1,101 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Outcomes of an attempt to send an email through a provider
+const (
+	EmailSent    = "sent"
+	EmailBounced = "bounced" // the provider refused the recipient or the message for good
+	EmailFailed  = "failed"  // the provider did not take the message, another one may
+)
+
+// Roles of the email providers
+const (
+	EmailProviderPrimary  = "primary"
+	EmailProviderFallback = "fallback"
+)
+
+// EmailAttempt is an attempt to send an email through a provider, the health of the providers is read from them
+type EmailAttempt struct {
+	ID        uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Provider  string    `gorm:"size:20;not null" json:"provider"`
+	Recipient string    `gorm:"size:255;not null" json:"recipient"`
+	Outcome   string    `gorm:"size:20;not null" json:"outcome"`
+	Code      int       `gorm:"not null;default:0" json:"code,omitempty"` // the SMTP reply or HTTP status code of a refusal
+	Error     string    `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (attempt *EmailAttempt) BeforeCreate(_ *gorm.DB) error {
+	attempt.ID = uuid.New()
+	return nil
+}
+
+// EmailFailoverThresholds are the rates over which a provider that made at least MinAttempts attempts is unhealthy
+type EmailFailoverThresholds struct {
+	MinAttempts   int64
+	MaxErrorRate  float64
+	MaxBounceRate float64
+}
+
+// EmailProviderHealth is how a provider fared over the health window
+type EmailProviderHealth struct {
+	Provider   string   `json:"provider"`
+	Role       string   `json:"role"`
+	Configured bool     `json:"configured"`
+	Attempts   int64    `json:"attempts"`
+	Sent       int64    `json:"sent"`
+	Bounced    int64    `json:"bounced"`
+	Failed     int64    `json:"failed"`
+	ErrorRate  *float64 `json:"error_rate"`  // failed over attempts, nil without attempts
+	BounceRate *float64 `json:"bounce_rate"` // bounced over attempts, nil without attempts
+	Healthy    bool     `json:"healthy"`
+}
+
+// NewEmailProviderHealth rates a provider from the number of its attempts per outcome. A configured provider with
+// too few attempts to tell is healthy.
+func NewEmailProviderHealth(provider, role string, configured bool, outcomes map[string]int64, thresholds EmailFailoverThresholds) EmailProviderHealth {
+	health := EmailProviderHealth{
+		Provider:   provider,
+		Role:       role,
+		Configured: configured,
+		Sent:       outcomes[EmailSent],
+		Bounced:    outcomes[EmailBounced],
+		Failed:     outcomes[EmailFailed],
+	}
+	health.Attempts = health.Sent + health.Bounced + health.Failed
+	health.ErrorRate = share(float64(health.Failed), health.Attempts)
+	health.BounceRate = share(float64(health.Bounced), health.Attempts)
+	health.Healthy = configured && (health.Attempts < thresholds.MinAttempts ||
+		(*health.ErrorRate <= thresholds.MaxErrorRate && *health.BounceRate <= thresholds.MaxBounceRate))
+	return health
+}
+
+// ActiveEmailProvider is the provider mail goes through: the primary unless it is unhealthy and the fallback is
+// healthy. Empty when neither is configured.
+func ActiveEmailProvider(primary, fallback EmailProviderHealth) string {
+	switch {
+	case primary.Healthy:
+		return primary.Provider
+	case fallback.Healthy:
+		return fallback.Provider
+	case primary.Configured:
+		return primary.Provider
+	case fallback.Configured:
+		return fallback.Provider
+	}
+	return ""
+}
+
+// EmailHealthReport is the health of the email providers since the start of the window, and the latest bounces
+type EmailHealthReport struct {
+	Since         time.Time             `json:"since"`
+	Active        string                `json:"active"`
+	Providers     []EmailProviderHealth `json:"providers"`
+	RecentBounces []EmailAttempt        `json:"recent_bounces"`
+}
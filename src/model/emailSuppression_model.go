@@ -0,0 +1,37 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Reasons an address is suppressed
+const (
+	SuppressionBounce    = "bounce"    // mail to it bounced for good
+	SuppressionComplaint = "complaint" // its owner reported our mail as spam
+)
+
+// EmailSuppression is an address no email is sent to, as the provider reported it bounces or complains. A
+// complaint is kept over a later bounce, it is the reason the user has to act on.
+type EmailSuppression struct {
+	Email     string    `gorm:"primaryKey;size:255" json:"email"` // lowercase, see NormalizeSuppressedEmail
+	Reason    string    `gorm:"size:20;not null" json:"reason"`
+	Detail    string    `gorm:"type:text" json:"detail,omitempty"`
+	Provider  string    `gorm:"size:20;not null" json:"provider"`
+	Count     int       `gorm:"not null;default:1" json:"count"` // reports received for the address
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// NormalizeSuppressedEmail is the key of an address, mailbox providers do not tell case apart
+func NormalizeSuppressedEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// SuppressionReason is the reason kept when an address already suppressed for current is reported for reported
+func SuppressionReason(current, reported string) string {
+	if current == SuppressionComplaint {
+		return current
+	}
+	return reported
+}
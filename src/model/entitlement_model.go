@@ -0,0 +1,110 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entitlement checks, in the order a scan request meets them
+const (
+	EntitlementMaintenance        = "maintenance"
+	EntitlementProductToken       = "product_token"
+	EntitlementActiveSubscription = "active_subscription"
+	EntitlementScanQuota          = "scan_quota"
+)
+
+// EntitlementCheck is one step of the resolution, Detail explains why it passed or failed
+type EntitlementCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+// ProductTokenEntitlement is the product token the auth middleware requires on every user route
+type ProductTokenEntitlement struct {
+	TokenID     uuid.UUID  `json:"token_id"`
+	ActivatedAt *time.Time `json:"activated_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	Expired     bool       `json:"expired"`
+}
+
+// SubscriptionCandidate is one subscription of the user and the reasons it is not the active one
+type SubscriptionCandidate struct {
+	ID            uuid.UUID `json:"id"`
+	PlanID        uuid.UUID `json:"plan_id"`
+	PlanName      string    `json:"plan_name"`
+	Source        string    `json:"source"`
+	PaymentStatus string    `json:"payment_status"`
+	IsActive      bool      `json:"is_active"`
+	StartDate     time.Time `json:"start_date"`
+	EndDate       time.Time `json:"end_date"`
+	AIscansUsed   int       `json:"ai_scans_used"`
+	Selected      bool      `json:"selected"`
+	Rejections    []string  `json:"rejections"`
+}
+
+// PlanEntitlement is what the plan of the selected subscription grants
+type PlanEntitlement struct {
+	ID          uuid.UUID       `json:"id"`
+	Name        string          `json:"name"`
+	IsActive    bool            `json:"is_active"` // an inactive plan can no longer be bought but keeps its subscribers
+	AIscanLimit int             `json:"ai_scan_limit"`
+	Features    map[string]bool `json:"features"`
+}
+
+// ScanQuotaEntitlement is the scan allowance split into what the database and the Redis counter hold
+type ScanQuotaEntitlement struct {
+	Limit          int    `json:"limit"` // -1 for unlimited
+	UsedInDatabase int    `json:"used_in_database"`
+	PendingInCache int64  `json:"pending_in_cache"` // counted in Redis, not yet flushed to the database
+	CacheState     string `json:"cache_state"`
+	Used           int    `json:"used"`
+	Remaining      int    `json:"remaining"` // -1 when unlimited
+	Exhausted      bool   `json:"exhausted"`
+}
+
+// Cache states of the scan counter
+const (
+	ScanCacheDisabled    = "disabled"
+	ScanCacheOK          = "ok"
+	ScanCacheUnavailable = "unavailable"
+)
+
+// EntitlementDiagnosis is the full resolution of what a user may do, for support tickets
+type EntitlementDiagnosis struct {
+	UserID        uuid.UUID                `json:"user_id"`
+	CheckedAt     time.Time                `json:"checked_at"`
+	CanScan       bool                     `json:"can_scan"`
+	Checks        []EntitlementCheck       `json:"checks"`
+	ProductToken  *ProductTokenEntitlement `json:"product_token"`
+	Subscriptions []SubscriptionCandidate  `json:"subscriptions"`
+	Plan          *PlanEntitlement         `json:"plan"`
+	Quota         *ScanQuotaEntitlement    `json:"quota"`
+}
+
+// SubscriptionRejections lists why a subscription does not count as active at now, the same conditions
+// the active subscription lookup applies. An empty list means it qualifies.
+func SubscriptionRejections(sub *UserSubscription, now time.Time) []string {
+	rejections := []string{}
+	if sub.PaymentStatus != "success" {
+		rejections = append(rejections, fmt.Sprintf("payment status is %s", sub.PaymentStatus))
+	}
+	if !sub.IsActive {
+		rejections = append(rejections, "subscription is deactivated")
+	}
+	if !sub.EndDate.After(now) {
+		rejections = append(rejections, fmt.Sprintf("ended on %s", sub.EndDate.Format(time.RFC3339)))
+	}
+	return rejections
+}
+
+// AddCheck records a step and keeps CanScan true only while every step passed
+func (d *EntitlementDiagnosis) AddCheck(name string, passed bool, detail string) {
+	if len(d.Checks) == 0 {
+		d.CanScan = true
+	}
+	d.Checks = append(d.Checks, EntitlementCheck{Name: name, Passed: passed, Detail: detail})
+	d.CanScan = d.CanScan && passed
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"hash/fnv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Where an experiment changes content. Notification experiments target a template key,
+// paywall experiments the plan comparison.
+const (
+	ExperimentSurfaceNotification = "notification"
+	ExperimentSurfacePaywall      = "paywall"
+)
+
+// ExperimentPaywallTarget is the target of paywall experiments, the plan comparison of GET /subscriptions/plans
+const ExperimentPaywallTarget = "plans"
+
+// Experiment statuses, only running experiments assign variants
+const (
+	ExperimentDraft   = "draft"
+	ExperimentRunning = "running"
+	ExperimentStopped = "stopped"
+)
+
+// Conversion events recorded for the experiments a user is exposed to
+const (
+	ConversionPurchase  = "purchase"   // value is the amount paid
+	ConversionLinkClick = "link_click" // a tracked link of a notification was opened
+)
+
+// ExperimentVariant is a version of the content, users are split between variants in proportion to their weight.
+// Notification variants override "subject" and "body", paywall variants carry the copy the app shows.
+// A variant without content is the control and keeps the regular content.
+type ExperimentVariant struct {
+	Key     string            `json:"key"`
+	Weight  int               `json:"weight"`
+	Content map[string]string `json:"content,omitempty"`
+}
+
+type Experiment struct {
+	ID          uuid.UUID           `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Key         string              `gorm:"size:50;not null;uniqueIndex" json:"key"`
+	Description string              `gorm:"size:255" json:"description"`
+	Surface     string              `gorm:"size:20;not null;index:idx_experiment_surface_target" json:"surface"`
+	Target      string              `gorm:"size:50;not null;index:idx_experiment_surface_target" json:"target"`
+	Variants    []ExperimentVariant `gorm:"type:jsonb;serializer:json;not null" json:"variants"`
+	Status      string              `gorm:"size:20;not null;default:draft" json:"status"`
+	StartedAt   *time.Time          `gorm:"default:null" json:"started_at"`
+	StoppedAt   *time.Time          `gorm:"default:null" json:"stopped_at"`
+	CreatedByID uuid.UUID           `gorm:"not null" json:"created_by_id"`
+	CreatedAt   time.Time           `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (experiment *Experiment) BeforeCreate(_ *gorm.DB) error {
+	experiment.ID = uuid.New()
+	return nil
+}
+
+// AssignVariant picks the variant of the user. The same user always gets the same variant of an experiment,
+// and the split of different experiments is independent.
+func (experiment *Experiment) AssignVariant(userID uuid.UUID) *ExperimentVariant {
+	total := 0
+	for _, variant := range experiment.Variants {
+		total += max(variant.Weight, 0)
+	}
+	if total == 0 {
+		return nil
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(experiment.Key))
+	hash.Write(userID[:])
+	bucket := int(hash.Sum32() % uint32(total))
+
+	for i := range experiment.Variants {
+		bucket -= max(experiment.Variants[i].Weight, 0)
+		if bucket < 0 {
+			return &experiment.Variants[i]
+		}
+	}
+	return nil
+}
+
+// Variant returns the variant with key
+func (experiment *Experiment) Variant(key string) *ExperimentVariant {
+	for i := range experiment.Variants {
+		if experiment.Variants[i].Key == key {
+			return &experiment.Variants[i]
+		}
+	}
+	return nil
+}
+
+// ExperimentAssignment is the variant a user was exposed to, kept so they keep seeing it
+type ExperimentAssignment struct {
+	ExperimentID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"experiment_id"`
+	UserID         uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"user_id"`
+	Variant        string    `gorm:"size:50;not null" json:"variant"`
+	Exposures      int       `gorm:"not null;default:1" json:"exposures"`
+	FirstExposedAt time.Time `gorm:"not null" json:"first_exposed_at"`
+	LastExposedAt  time.Time `gorm:"not null" json:"last_exposed_at"`
+}
+
+// ExperimentConversion is an event of a user after their exposure to an experiment
+type ExperimentConversion struct {
+	ID           uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	ExperimentID uuid.UUID `gorm:"type:uuid;not null;index" json:"experiment_id"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	Variant      string    `gorm:"size:50;not null" json:"variant"`
+	Event        string    `gorm:"size:30;not null" json:"event"`
+	Value        int64     `gorm:"not null;default:0" json:"value"`
+	OccurredAt   time.Time `gorm:"not null" json:"occurred_at"`
+}
+
+func (conversion *ExperimentConversion) BeforeCreate(_ *gorm.DB) error {
+	conversion.ID = uuid.New()
+	return nil
+}
+
+// ExposedVariant is the content a surface shows for an experiment
+type ExposedVariant struct {
+	Experiment string            `json:"experiment"`
+	Variant    string            `json:"variant"`
+	Content    map[string]string `json:"content,omitempty"`
+}
+
+// ExperimentResults compares the variants of an experiment
+type ExperimentResults struct {
+	Experiment Experiment              `json:"experiment"`
+	Variants   []ExperimentVariantStat `json:"variants"`
+}
+
+type ExperimentVariantStat struct {
+	Variant     string                       `json:"variant"`
+	Users       int64                        `json:"users"`
+	Exposures   int64                        `json:"exposures"`
+	Conversions map[string]ConversionSummary `json:"conversions"`
+}
+
+// ConversionSummary counts the users of a variant who converted with an event
+type ConversionSummary struct {
+	Users int64   `json:"users"`
+	Count int64   `json:"count"`
+	Value int64   `json:"value"`
+	Rate  float64 `json:"rate"` // converted users over exposed users
+}
@@ -0,0 +1,253 @@
+package model
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Goals of a user, from their weight and their weight target
+const (
+	GoalLoseWeight = "lose_weight"
+	GoalMaintain   = "maintain"
+	GoalGainWeight = "gain_weight"
+)
+
+// goalWeightTolerance is how far in kg a target may be from the weight and still count as maintaining
+const goalWeightTolerance = 0.5
+
+// DietGoal returns the goal of a user with a weight and a weight target, maintaining without either
+func DietGoal(weight, target *float64) string {
+	switch {
+	case weight == nil || target == nil:
+		return GoalMaintain
+	case *target < *weight-goalWeightTolerance:
+		return GoalLoseWeight
+	case *target > *weight+goalWeightTolerance:
+		return GoalGainWeight
+	}
+	return GoalMaintain
+}
+
+// Dietary restrictions a user can set
+const (
+	DietVegetarian  = "vegetarian"
+	DietVegan       = "vegan"
+	DietLactoseFree = "lactose_free"
+	DietNutFree     = "nut_free"
+	DietLowSugar    = "low_sugar"
+	DietLowSodium   = "low_sodium"
+)
+
+// DietaryRestriction is a restriction with the food groups it excludes, matched as parts of the group
+// names of the food composition table
+type DietaryRestriction struct {
+	Key            string   `json:"key"`
+	Description    string   `json:"description"`
+	ExcludedGroups []string `json:"-"`
+}
+
+var DietaryRestrictions = []DietaryRestriction{
+	{Key: DietVegetarian, Description: "No meat, fish or seafood", ExcludedGroups: []string{"daging", "ikan", "kerang", "udang", "unggas", "jeroan"}},
+	{Key: DietVegan, Description: "No food of animal origin", ExcludedGroups: []string{"daging", "ikan", "kerang", "udang", "unggas", "jeroan", "telur", "susu"}},
+	{Key: DietLactoseFree, Description: "No milk and dairy", ExcludedGroups: []string{"susu"}},
+	{Key: DietNutFree, Description: "No nuts and seeds", ExcludedGroups: []string{"kacang", "biji"}},
+	{Key: DietLowSugar, Description: "Fewer carbohydrates and no sugar or confectionery", ExcludedGroups: []string{"gula"}},
+	{Key: DietLowSodium, Description: "Less sodium"},
+}
+
+// DietPreference holds the dietary restrictions of a user
+type DietPreference struct {
+	UserID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	Restrictions []string  `gorm:"type:jsonb;serializer:json;not null" json:"restrictions"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// AllowedByDiet reports whether a food is in none of the groups the restrictions exclude
+func AllowedByDiet(food BahanMakanan, restrictions []string) bool {
+	group := strings.ToLower(food.KelompokMakanan)
+	for _, key := range restrictions {
+		for _, restriction := range DietaryRestrictions {
+			if restriction.Key != key {
+				continue
+			}
+			for _, excluded := range restriction.ExcludedGroups {
+				if strings.Contains(group, excluded) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// FoodAlternativeFeedback is whether a user found an alternative to a food helpful
+type FoodAlternativeFeedback struct {
+	UserID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	FoodKode        string    `gorm:"size:20;primaryKey;index:idx_food_alternative_feedback" json:"food_kode"`
+	AlternativeKode string    `gorm:"size:20;primaryKey;index:idx_food_alternative_feedback" json:"alternative_kode"`
+	Helpful         bool      `gorm:"not null" json:"helpful"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// AlternativeVotes counts the feedback of every user on an alternative
+type AlternativeVotes struct {
+	Helpful    int
+	NotHelpful int
+}
+
+// AlternativeSignals is the feedback on the alternatives of a food: of every user by kode, and of the
+// user asking, helpful or not by kode
+type AlternativeSignals struct {
+	Votes map[string]AlternativeVotes
+	User  map[string]bool
+}
+
+// Why an alternative is suggested
+const (
+	AlternativeLowerEnergy       = "lower_energy"
+	AlternativeLowerCarbohydrate = "lower_carbohydrate"
+	AlternativeLowerFat          = "lower_fat"
+	AlternativeLowerSodium       = "lower_sodium"
+	AlternativeHigherProtein     = "higher_protein"
+	AlternativeHigherFiber       = "higher_fiber"
+)
+
+// FoodAlternativeSuggestion is a healthier food to eat instead of another
+type FoodAlternativeSuggestion struct {
+	Food    BahanMakanan `json:"food"`
+	Score   float64      `json:"score"`
+	Reasons []string     `json:"reasons"`
+	// EnergyDiff and CarbohydrateDiff are per 100 g, negative when the alternative has less
+	EnergyDiff       float64 `json:"energy_diff"`
+	CarbohydrateDiff float64 `json:"carbohydrate_diff"`
+}
+
+const (
+	// Weight of a percent less of energy, carbohydrate or sodium and of a percent more protein, by goal
+	// and restriction
+	alternativeEnergyWeight       = 0.5
+	alternativeEnergyWeightLose   = 1.0
+	alternativeCarbohydrateWeight = 0.3
+	alternativeCarbohydrateStrong = 0.8
+	alternativeSodiumWeight       = 0.6
+	alternativeProteinWeightGain  = 0.6
+
+	// Feedback of every user moves a score up to this much, the user's own more
+	alternativeVotesMaxBonus = 20
+	alternativeUserHelpful   = 25
+
+	// alternativeMaxChange caps the percent a single nutrient counts for, so one tiny value does not win
+	alternativeMaxChange = 100
+)
+
+// percentChange is how many percent less (positive) b has than a, capped at alternativeMaxChange
+func percentChange(a, b float64) float64 {
+	if a <= 0 {
+		return 0
+	}
+	return math.Max(math.Min((a-b)/a*100, alternativeMaxChange), -alternativeMaxChange)
+}
+
+// RankFoodAlternatives suggests foods of the same group and preparation as food with less energy or
+// carbohydrate, allowed by the restrictions and not rejected by the user. A goal to lose weight favors
+// less energy, one to gain weight more protein; low sugar and low sodium restrictions favor less of those.
+func RankFoodAlternatives(
+	food BahanMakanan, candidates []BahanMakanan, goal string, restrictions []string, signals AlternativeSignals, limit int,
+) []FoodAlternativeSuggestion {
+	lowSugar, lowSodium := false, false
+	for _, restriction := range restrictions {
+		lowSugar = lowSugar || restriction == DietLowSugar
+		lowSodium = lowSodium || restriction == DietLowSodium
+	}
+
+	suggestions := []FoodAlternativeSuggestion{}
+	for _, candidate := range candidates {
+		if candidate.Kode == food.Kode || candidate.KelompokMakanan != food.KelompokMakanan ||
+			candidate.MentahOlahan != food.MentahOlahan || !AllowedByDiet(candidate, restrictions) {
+			continue
+		}
+		if helpful, ok := signals.User[candidate.Kode]; ok && !helpful {
+			continue
+		}
+
+		lessEnergy := percentChange(food.EnergiKal, candidate.EnergiKal)
+		lessCarbohydrate := percentChange(food.KarbohidratG, candidate.KarbohidratG)
+		if lessEnergy <= 0 && lessCarbohydrate <= 0 {
+			continue
+		}
+		if goal == GoalLoseWeight && lessEnergy < 0 {
+			continue
+		}
+
+		suggestion := FoodAlternativeSuggestion{
+			Food:             candidate,
+			Reasons:          []string{},
+			EnergyDiff:       roundPortion(candidate.EnergiKal - food.EnergiKal),
+			CarbohydrateDiff: roundPortion(candidate.KarbohidratG - food.KarbohidratG),
+		}
+
+		score := FoodHealthScore(candidate) - FoodHealthScore(food)
+		energyWeight := alternativeEnergyWeight
+		if goal == GoalLoseWeight {
+			energyWeight = alternativeEnergyWeightLose
+		}
+		score += lessEnergy * energyWeight
+		carbohydrateWeight := alternativeCarbohydrateWeight
+		if lowSugar {
+			carbohydrateWeight = alternativeCarbohydrateStrong
+		}
+		score += lessCarbohydrate * carbohydrateWeight
+
+		if goal == GoalGainWeight {
+			score -= percentChange(food.ProteinG, candidate.ProteinG) * alternativeProteinWeightGain
+		}
+		sodium, candidateSodium := NutrientValue(food, "natrium_na_mg"), NutrientValue(candidate, "natrium_na_mg")
+		if lowSodium && sodium != nil && candidateSodium != nil {
+			score += percentChange(*sodium, *candidateSodium) * alternativeSodiumWeight
+		}
+
+		if votes, ok := signals.Votes[candidate.Kode]; ok {
+			// Smoothed so a single vote moves little
+			score += alternativeVotesMaxBonus * float64(votes.Helpful-votes.NotHelpful) / float64(votes.Helpful+votes.NotHelpful+2)
+		}
+		if signals.User[candidate.Kode] {
+			score += alternativeUserHelpful
+		}
+		suggestion.Score = roundPortion(score)
+
+		if lessEnergy > 0 {
+			suggestion.Reasons = append(suggestion.Reasons, AlternativeLowerEnergy)
+		}
+		if lessCarbohydrate > 0 {
+			suggestion.Reasons = append(suggestion.Reasons, AlternativeLowerCarbohydrate)
+		}
+		if candidate.LemakG < food.LemakG {
+			suggestion.Reasons = append(suggestion.Reasons, AlternativeLowerFat)
+		}
+		if sodium != nil && candidateSodium != nil && *candidateSodium < *sodium {
+			suggestion.Reasons = append(suggestion.Reasons, AlternativeLowerSodium)
+		}
+		if candidate.ProteinG > food.ProteinG {
+			suggestion.Reasons = append(suggestion.Reasons, AlternativeHigherProtein)
+		}
+		if fiber, candidateFiber := NutrientValue(food, "serat_g"), NutrientValue(candidate, "serat_g"); fiber != nil &&
+			candidateFiber != nil && *candidateFiber > *fiber {
+			suggestion.Reasons = append(suggestion.Reasons, AlternativeHigherFiber)
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Score > suggestions[j].Score
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
@@ -0,0 +1,246 @@
+package model
+
+import (
+	"math"
+	"sort"
+)
+
+// Whether less or more of a nutrient is healthier
+const (
+	NutrientLimit     = "limit"
+	NutrientEncourage = "encourage"
+	NutrientNeutral   = "neutral"
+)
+
+// ComparedNutrient is a nutrient of a food comparison with its daily value, the adult AKG (Angka
+// Kecukupan Gizi) Indonesian labels use
+type ComparedNutrient struct {
+	Key        string  `json:"key"`
+	Unit       string  `json:"unit"`
+	Direction  string  `json:"direction"`
+	DailyValue float64 `json:"daily_value,omitempty"`
+}
+
+// ComparedNutrients are the nutrients a comparison shows, in order
+var ComparedNutrients = []ComparedNutrient{
+	{Key: "energi_kal", Unit: "kcal", Direction: NutrientLimit, DailyValue: 2150},
+	{Key: "protein_g", Unit: "g", Direction: NutrientEncourage, DailyValue: 60},
+	{Key: "lemak_g", Unit: "g", Direction: NutrientLimit, DailyValue: 67},
+	{Key: "karbohidrat_g", Unit: "g", Direction: NutrientNeutral, DailyValue: 325},
+	{Key: "serat_g", Unit: "g", Direction: NutrientEncourage, DailyValue: 30},
+	{Key: "natrium_na_mg", Unit: "mg", Direction: NutrientLimit, DailyValue: 1500},
+	{Key: "kalium_ka_mg", Unit: "mg", Direction: NutrientEncourage, DailyValue: 4700},
+	{Key: "kalsium_ca_mg", Unit: "mg", Direction: NutrientEncourage, DailyValue: 1100},
+	{Key: "besi_fe_mg", Unit: "mg", Direction: NutrientEncourage, DailyValue: 18},
+	{Key: "vitamin_c_mg", Unit: "mg", Direction: NutrientEncourage, DailyValue: 90},
+}
+
+// FoodServing is the portion a food is compared per, its first count serving size or else 100 g
+type FoodServing struct {
+	Unit  string  `json:"unit"`
+	Label string  `json:"label"`
+	Grams float64 `json:"grams"`
+}
+
+// ComparedFood is a food of a comparison with its nutrients per 100 g and per serving. A nutrient the food
+// composition table has no value for is null.
+type ComparedFood struct {
+	Food        BahanMakanan        `json:"food"`
+	Serving     FoodServing         `json:"serving"`
+	Per100g     map[string]*float64 `json:"per_100g"`
+	PerServing  map[string]*float64 `json:"per_serving"`
+	HealthScore float64             `json:"health_score"`
+}
+
+// NutrientWinner is the compared food with the healthiest amount of a nutrient per 100 g
+type NutrientWinner struct {
+	Nutrient string `json:"nutrient"`
+	Kode     string `json:"kode"`
+}
+
+// FoodAlternative is a food of the same group with a healthier nutrient profile than a compared food
+type FoodAlternative struct {
+	Food        BahanMakanan `json:"food"`
+	Instead     string       `json:"instead"` // kode of the compared food it replaces
+	HealthScore float64      `json:"health_score"`
+	// Better are the nutrients it has a healthier amount of
+	Better []string `json:"better"`
+}
+
+// FoodComparison compares foods nutrient by nutrient
+type FoodComparison struct {
+	Nutrients    []ComparedNutrient `json:"nutrients"`
+	Foods        []ComparedFood     `json:"foods"`
+	Winners      []NutrientWinner   `json:"winners"`
+	Alternatives []FoodAlternative  `json:"alternatives"`
+}
+
+// NutrientValue returns a nutrient of a food per 100 g, nil when the food has no value for it
+func NutrientValue(food BahanMakanan, key string) *float64 {
+	nutrient, ok := foodNutrients[key]
+	if !ok {
+		return nil
+	}
+	if value := nutrient.Get(&food); value != nil {
+		copied := *value
+		return &copied
+	}
+	if nutrient.Required {
+		// A nutrient every food has is zero, not missing
+		zero := 0.0
+		return &zero
+	}
+	return nil
+}
+
+// FoodServingOf returns the portion a food is compared per
+func FoodServingOf(portions []FoodPortion) FoodServing {
+	for _, unit := range PortionUnits {
+		if unit.Kind != PortionKindCount {
+			continue
+		}
+		for _, portion := range portions {
+			if portion.Unit == unit.Unit {
+				return FoodServing{Unit: unit.Unit, Label: unit.Label, Grams: portion.Grams}
+			}
+		}
+	}
+	return FoodServing{Unit: PortionGram, Label: "100 gram", Grams: 100}
+}
+
+// FoodHealthScore rates the nutrient profile of 100 g of a food: the share of the daily value of the
+// nutrients to encourage, each capped at one, minus the share of those to limit. Higher is healthier.
+func FoodHealthScore(food BahanMakanan) float64 {
+	score := 0.0
+	for _, nutrient := range ComparedNutrients {
+		value := NutrientValue(food, nutrient.Key)
+		if value == nil || nutrient.DailyValue == 0 {
+			continue
+		}
+		share := *value / nutrient.DailyValue
+		switch nutrient.Direction {
+		case NutrientEncourage:
+			score += math.Min(share, 1)
+		case NutrientLimit:
+			score -= share
+		}
+	}
+	return roundPortion(score * 100)
+}
+
+// CompareFoods compares foods per 100 g and per serving. portions holds the portions of the foods by kode.
+func CompareFoods(foods []BahanMakanan, portions map[string][]FoodPortion) FoodComparison {
+	comparison := FoodComparison{
+		Nutrients:    ComparedNutrients,
+		Foods:        make([]ComparedFood, 0, len(foods)),
+		Winners:      []NutrientWinner{},
+		Alternatives: []FoodAlternative{},
+	}
+
+	for _, food := range foods {
+		serving := FoodServingOf(portions[food.Kode])
+		compared := ComparedFood{
+			Food:        food,
+			Serving:     serving,
+			Per100g:     make(map[string]*float64, len(ComparedNutrients)),
+			PerServing:  make(map[string]*float64, len(ComparedNutrients)),
+			HealthScore: FoodHealthScore(food),
+		}
+		for _, nutrient := range ComparedNutrients {
+			value := NutrientValue(food, nutrient.Key)
+			compared.Per100g[nutrient.Key] = value
+			if value != nil {
+				perServing := roundPortion(*value * serving.Grams / 100)
+				compared.PerServing[nutrient.Key] = &perServing
+			} else {
+				compared.PerServing[nutrient.Key] = nil
+			}
+		}
+		comparison.Foods = append(comparison.Foods, compared)
+	}
+
+	for _, nutrient := range ComparedNutrients {
+		if nutrient.Direction == NutrientNeutral {
+			continue
+		}
+		winner, best, tie := "", 0.0, false
+		for _, food := range foods {
+			value := NutrientValue(food, nutrient.Key)
+			if value == nil {
+				continue
+			}
+			switch {
+			case winner == "" || healthier(nutrient, *value, best):
+				winner, best, tie = food.Kode, *value, false
+			case *value == best:
+				tie = true
+			}
+		}
+		if winner != "" && !tie {
+			comparison.Winners = append(comparison.Winners, NutrientWinner{Nutrient: nutrient.Key, Kode: winner})
+		}
+	}
+
+	return comparison
+}
+
+// healthier reports whether a is a healthier amount of a nutrient than b
+func healthier(nutrient ComparedNutrient, a, b float64) bool {
+	if nutrient.Direction == NutrientLimit {
+		return a < b
+	}
+	return a > b
+}
+
+// SuggestAlternatives picks up to limit foods of the same group as a compared food with a better health
+// score and more nutrients healthier than not, the healthiest first. A candidate is suggested once, instead
+// of the first food it beats.
+func SuggestAlternatives(compared, candidates []BahanMakanan, limit int) []FoodAlternative {
+	skip := make(map[string]bool, len(compared))
+	for _, food := range compared {
+		skip[food.Kode] = true
+	}
+
+	alternatives := []FoodAlternative{}
+	for _, candidate := range candidates {
+		if skip[candidate.Kode] {
+			continue
+		}
+		score := FoodHealthScore(candidate)
+		for _, food := range compared {
+			if candidate.KelompokMakanan == "" || candidate.KelompokMakanan != food.KelompokMakanan ||
+				candidate.MentahOlahan != food.MentahOlahan || score <= FoodHealthScore(food) {
+				continue
+			}
+
+			alternative := FoodAlternative{Food: candidate, Instead: food.Kode, HealthScore: score, Better: []string{}}
+			worse := 0
+			for _, nutrient := range ComparedNutrients {
+				a, b := NutrientValue(candidate, nutrient.Key), NutrientValue(food, nutrient.Key)
+				if nutrient.Direction == NutrientNeutral || a == nil || b == nil {
+					continue
+				}
+				if healthier(nutrient, *a, *b) {
+					alternative.Better = append(alternative.Better, nutrient.Key)
+				} else if healthier(nutrient, *b, *a) {
+					worse++
+				}
+			}
+			// A score raised by values the table lacks is no reason to swap
+			if len(alternative.Better) <= worse {
+				continue
+			}
+			alternatives = append(alternatives, alternative)
+			skip[candidate.Kode] = true
+			break
+		}
+	}
+
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		return alternatives[i].HealthScore > alternatives[j].HealthScore
+	})
+	if len(alternatives) > limit {
+		alternatives = alternatives[:limit]
+	}
+	return alternatives
+}
@@ -0,0 +1,219 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Health grades, from the healthiest
+var HealthGrades = []string{"A", "B", "C", "D", "E"}
+
+// Traffic lights of a nutrient to limit
+const (
+	LightGreen = "green"
+	LightAmber = "amber"
+	LightRed   = "red"
+)
+
+// Nutrients of a meal a grading can count, the totals a scan or the user gives
+const (
+	MealCalories = "calories"
+	MealProtein  = "protein"
+	MealCarbs    = "carbs"
+	MealFat      = "fat"
+)
+
+// GradedNutrient is a nutrient a grade counts. A value scores a point for every threshold it is above,
+// added for a nutrient to limit and taken off for one to encourage. A nutrient to limit also gets a traffic
+// light: green up to Low, amber up to High and red above.
+type GradedNutrient struct {
+	Key        string    `json:"key"`
+	Direction  string    `json:"direction"` // NutrientLimit or NutrientEncourage
+	Thresholds []float64 `json:"thresholds"`
+	Low        float64   `json:"low,omitempty"`
+	High       float64   `json:"high,omitempty"`
+}
+
+// GradeBand is a grade with the highest score that gets it. The last band takes every higher score.
+type GradeBand struct {
+	Grade    string `json:"grade"`
+	MaxScore int    `json:"max_score"`
+}
+
+// GradingProfile grades the nutrients of a food or a meal
+type GradingProfile struct {
+	Nutrients []GradedNutrient `json:"nutrients"`
+	Grades    []GradeBand      `json:"grades"`
+}
+
+// GradingConfig is the grading nutritionists tune: foods per 100 g and meals per meal, as scanned or logged.
+// Version goes up with every change so stored grades are recomputed.
+type GradingConfig struct {
+	Version     int            `json:"version"`
+	Food        GradingProfile `json:"food"`
+	Meal        GradingProfile `json:"meal"`
+	UpdatedByID *uuid.UUID     `json:"updated_by_id,omitempty"`
+	UpdatedAt   *time.Time     `json:"updated_at,omitempty"`
+}
+
+// DefaultGradingConfig follows the Nutri-Score point steps and the UK traffic light thresholds for the
+// nutrients the food composition table has, it lists no sugar or saturated fat. Meal thresholds are per
+// meal, a third of the AKG daily values.
+var DefaultGradingConfig = GradingConfig{
+	Food: GradingProfile{
+		Nutrients: []GradedNutrient{
+			{Key: "energi_kal", Direction: NutrientLimit, Thresholds: []float64{80, 160, 240, 320, 400, 480, 560, 640, 720, 800}, Low: 150, High: 400},
+			{Key: "lemak_g", Direction: NutrientLimit, Thresholds: []float64{3, 10, 17.5, 25, 35}, Low: 3, High: 17.5},
+			{Key: "natrium_na_mg", Direction: NutrientLimit, Thresholds: []float64{90, 180, 270, 360, 450, 540, 630, 720, 810, 900}, Low: 120, High: 600},
+			{Key: "protein_g", Direction: NutrientEncourage, Thresholds: []float64{1.6, 3.2, 4.8, 6.4, 8}},
+			{Key: "serat_g", Direction: NutrientEncourage, Thresholds: []float64{0.9, 1.9, 2.8, 3.7, 4.7}},
+		},
+		Grades: []GradeBand{{Grade: "A", MaxScore: -1}, {Grade: "B", MaxScore: 2}, {Grade: "C", MaxScore: 10}, {Grade: "D", MaxScore: 18}, {Grade: "E", MaxScore: 40}},
+	},
+	Meal: GradingProfile{
+		Nutrients: []GradedNutrient{
+			{Key: MealCalories, Direction: NutrientLimit, Thresholds: []float64{300, 450, 600, 750, 900}, Low: 450, High: 720},
+			{Key: MealFat, Direction: NutrientLimit, Thresholds: []float64{10, 15, 20, 25, 30}, Low: 10, High: 22},
+			{Key: MealCarbs, Direction: NutrientLimit, Thresholds: []float64{75, 100, 125}, Low: 75, High: 110},
+			{Key: MealProtein, Direction: NutrientEncourage, Thresholds: []float64{10, 15, 20, 25, 30}},
+		},
+		Grades: []GradeBand{{Grade: "A", MaxScore: 0}, {Grade: "B", MaxScore: 3}, {Grade: "C", MaxScore: 6}, {Grade: "D", MaxScore: 9}, {Grade: "E", MaxScore: 20}},
+	},
+}
+
+// NutrientLight is the traffic light of a nutrient to limit
+type NutrientLight struct {
+	Nutrient string  `json:"nutrient"`
+	Value    float64 `json:"value"`
+	Light    string  `json:"light"`
+}
+
+// HealthGrade is the grade of a food or a meal, with the version of the grading that gave it
+type HealthGrade struct {
+	Grade   string          `json:"grade"`
+	Score   int             `json:"score"`
+	Lights  []NutrientLight `json:"lights"`
+	Version int             `json:"version"`
+}
+
+// FoodGrade stores the grade of a food of the catalog
+type FoodGrade struct {
+	FoodKode  string          `gorm:"size:20;primaryKey" json:"food_kode"`
+	Grade     string          `gorm:"size:1;not null;index" json:"grade"`
+	Score     int             `gorm:"not null" json:"score"`
+	Lights    []NutrientLight `gorm:"type:jsonb;serializer:json" json:"lights"`
+	Version   int             `gorm:"not null" json:"version"`
+	UpdatedAt time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Check reports the first mistake of a profile, keys tells whether a nutrient key can be graded
+func (p GradingProfile) Check(keys func(string) bool) error {
+	seen := make(map[string]bool, len(p.Nutrients))
+	for _, nutrient := range p.Nutrients {
+		if !keys(nutrient.Key) {
+			return fmt.Errorf("unknown nutrient %q", nutrient.Key)
+		}
+		if seen[nutrient.Key] {
+			return fmt.Errorf("nutrient %q is graded twice", nutrient.Key)
+		}
+		seen[nutrient.Key] = true
+
+		for i := 1; i < len(nutrient.Thresholds); i++ {
+			if nutrient.Thresholds[i] <= nutrient.Thresholds[i-1] {
+				return fmt.Errorf("thresholds of %q must be ascending", nutrient.Key)
+			}
+		}
+		if nutrient.Direction == NutrientLimit && nutrient.High < nutrient.Low {
+			return fmt.Errorf("high of %q must not be below low", nutrient.Key)
+		}
+	}
+
+	if len(p.Grades) != len(HealthGrades) {
+		return fmt.Errorf("grades must be %v", HealthGrades)
+	}
+	for i, band := range p.Grades {
+		if band.Grade != HealthGrades[i] {
+			return fmt.Errorf("grades must be %v in order", HealthGrades)
+		}
+		if i > 0 && band.MaxScore <= p.Grades[i-1].MaxScore {
+			return fmt.Errorf("max score of grade %s must be above that of %s", band.Grade, p.Grades[i-1].Grade)
+		}
+	}
+	return nil
+}
+
+// IsFoodGradingKey reports whether a food nutrient can be graded
+func IsFoodGradingKey(key string) bool {
+	_, ok := foodNutrients[key]
+	return ok
+}
+
+// IsMealGradingKey reports whether a meal nutrient can be graded
+func IsMealGradingKey(key string) bool {
+	switch key {
+	case MealCalories, MealProtein, MealCarbs, MealFat:
+		return true
+	}
+	return false
+}
+
+// Grade grades the nutrient values. A nutrient without a value scores nothing and gets no light.
+func (p GradingProfile) Grade(values map[string]*float64, version int) HealthGrade {
+	grade := HealthGrade{Lights: []NutrientLight{}, Version: version}
+	for _, nutrient := range p.Nutrients {
+		value := values[nutrient.Key]
+		if value == nil {
+			continue
+		}
+
+		points := 0
+		for _, threshold := range nutrient.Thresholds {
+			if *value > threshold {
+				points++
+			}
+		}
+		if nutrient.Direction == NutrientEncourage {
+			grade.Score -= points
+			continue
+		}
+		grade.Score += points
+
+		light := LightRed
+		switch {
+		case *value <= nutrient.Low:
+			light = LightGreen
+		case *value <= nutrient.High:
+			light = LightAmber
+		}
+		grade.Lights = append(grade.Lights, NutrientLight{Nutrient: nutrient.Key, Value: roundPortion(*value), Light: light})
+	}
+
+	for _, band := range p.Grades {
+		grade.Grade = band.Grade
+		if grade.Score <= band.MaxScore {
+			break
+		}
+	}
+	return grade
+}
+
+// GradeFood grades a food per 100 g
+func (config GradingConfig) GradeFood(food BahanMakanan) HealthGrade {
+	values := make(map[string]*float64, len(config.Food.Nutrients))
+	for _, nutrient := range config.Food.Nutrients {
+		values[nutrient.Key] = NutrientValue(food, nutrient.Key)
+	}
+	return config.Food.Grade(values, config.Version)
+}
+
+// GradeMeal grades a meal by its totals
+func (config GradingConfig) GradeMeal(meal MealHistory) HealthGrade {
+	return config.Meal.Grade(map[string]*float64{
+		MealCalories: &meal.Calories,
+		MealProtein:  &meal.Protein,
+		MealCarbs:    &meal.Carbs,
+		MealFat:      &meal.Fat,
+	}, config.Version)
+}
@@ -0,0 +1,546 @@
+package model
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Datasets an import reads, custom ones need a mapping of their columns
+const (
+	FoodImportUSDA   = "usda"
+	FoodImportBPOM   = "bpom"
+	FoodImportCustom = "custom"
+)
+
+// Food import statuses, an import runs in the background after its file is read
+const (
+	FoodImportRunning   = "running"
+	FoodImportCompleted = "completed"
+	FoodImportFailed    = "failed"
+)
+
+// What an import did with a row of the dataset
+const (
+	FoodImportActionUpdate    = "update"    // matched a food and filled or replaced its values
+	FoodImportActionUnchanged = "unchanged" // matched a food that already has every value of the row
+	FoodImportActionNew       = "new"       // matched no food, staged for the food service
+	FoodImportActionDuplicate = "duplicate" // matched the same food or new name as an earlier row
+	FoodImportActionInvalid   = "invalid"   // could not be read
+	FoodImportActionFailed    = "failed"    // the food service refused the update
+)
+
+// How a row matched a food of the catalog
+const (
+	FoodImportMatchKode    = "kode"
+	FoodImportMatchName    = "name"
+	FoodImportMatchSimilar = "similar"
+)
+
+// FoodImportMatchThreshold is the name similarity a row needs to match a food without the same code or name.
+// It is higher than the search threshold, an import must not merge two foods.
+const FoodImportMatchThreshold = 0.6
+
+// Columns an import maps to the fields of a food, nutrients are per 100 g of the edible part
+const (
+	FoodImportKode            = "kode"
+	FoodImportName            = "nama_bahan_makanan"
+	FoodImportMentahOlahan    = "mentah_olahan"
+	FoodImportKelompokMakanan = "kelompok_makanan"
+)
+
+// FoodImport is an import of a nutrition dataset into the food catalog with its report
+type FoodImport struct {
+	ID        uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Source    string    `gorm:"size:20;not null" json:"source"`
+	FileName  string    `gorm:"size:255;not null" json:"file_name"`
+	DryRun    bool      `gorm:"not null;default:false" json:"dry_run"`
+	Overwrite bool      `gorm:"not null;default:false" json:"overwrite"` // replace values the catalog already has
+	Status    string    `gorm:"size:20;not null;index" json:"status"`
+	Rows      int       `gorm:"not null;default:0" json:"rows"`
+	Matched   int       `gorm:"not null;default:0" json:"matched"`
+	Updated   int       `gorm:"not null;default:0" json:"updated"`
+	New       int       `gorm:"not null;default:0" json:"new"`
+	Skipped   int       `gorm:"not null;default:0" json:"skipped"`
+	Failed    int       `gorm:"not null;default:0" json:"failed"`
+	// IgnoredColumns are the mapped columns the file does not have
+	IgnoredColumns []string   `gorm:"type:jsonb;serializer:json" json:"ignored_columns"`
+	Error          string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedByID    uuid.UUID  `gorm:"type:uuid;not null" json:"created_by_id"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+	FinishedAt     *time.Time `gorm:"default:null" json:"finished_at"`
+}
+
+func (foodImport *FoodImport) BeforeCreate(_ *gorm.DB) error {
+	foodImport.ID = uuid.New()
+	return nil
+}
+
+// FoodImportItem is a row of an import with what was done with it
+type FoodImportItem struct {
+	ID          uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	ImportID    uuid.UUID `gorm:"type:uuid;not null;index" json:"import_id"`
+	Line        int       `gorm:"not null" json:"line"` // of the file, the header is line 1
+	Name        string    `gorm:"size:255" json:"name"`
+	Action      string    `gorm:"size:20;not null;index" json:"action"`
+	MatchedKode *string   `gorm:"size:20" json:"matched_kode,omitempty"`
+	MatchedBy   string    `gorm:"size:20" json:"matched_by,omitempty"`
+	MatchScore  float64   `gorm:"not null;default:0" json:"match_score"`
+	// Changes are the fields an update fills or replaces
+	Changes []string `gorm:"type:jsonb;serializer:json" json:"changes,omitempty"`
+	// Food is the food after the update, or the food to create for a new row
+	Food  *BahanMakanan `gorm:"type:jsonb;serializer:json" json:"food,omitempty"`
+	Error string        `gorm:"type:text" json:"error,omitempty"`
+}
+
+func (item *FoodImportItem) BeforeCreate(_ *gorm.DB) error {
+	item.ID = uuid.New()
+	return nil
+}
+
+// FoodImportColumn is the column of a dataset holding a field and the unit of its values. Without a unit
+// the one in the header, as in "Energy (kJ)", is used, else the unit of the field.
+type FoodImportColumn struct {
+	Column string `json:"column"`
+	Unit   string `json:"unit,omitempty"`
+}
+
+// FoodImportMapping maps fields of a food to the columns of a dataset
+type FoodImportMapping map[string]FoodImportColumn
+
+// foodNutrient is a nutrient of a food with the unit the catalog stores it in
+type foodNutrient struct {
+	Unit     string
+	Required bool
+	Get      func(*BahanMakanan) *float64
+	Set      func(*BahanMakanan, float64)
+}
+
+// requiredNutrient returns a nutrient every food has, zero counts as missing
+func requiredNutrient(field func(*BahanMakanan) *float64, unit string) foodNutrient {
+	return foodNutrient{
+		Unit:     unit,
+		Required: true,
+		Get: func(food *BahanMakanan) *float64 {
+			if value := field(food); *value != 0 {
+				return value
+			}
+			return nil
+		},
+		Set: func(food *BahanMakanan, value float64) { *field(food) = value },
+	}
+}
+
+// optionalNutrient returns a nutrient a food may lack
+func optionalNutrient(field func(*BahanMakanan) **float64, unit string) foodNutrient {
+	return foodNutrient{
+		Unit: unit,
+		Get:  func(food *BahanMakanan) *float64 { return *field(food) },
+		Set:  func(food *BahanMakanan, value float64) { *field(food) = &value },
+	}
+}
+
+// foodNutrients are the nutrient fields of a food an import can set
+var foodNutrients = map[string]foodNutrient{
+	"air_g":                requiredNutrient(func(f *BahanMakanan) *float64 { return &f.AirG }, "g"),
+	"energi_kal":           requiredNutrient(func(f *BahanMakanan) *float64 { return &f.EnergiKal }, "kcal"),
+	"protein_g":            requiredNutrient(func(f *BahanMakanan) *float64 { return &f.ProteinG }, "g"),
+	"lemak_g":              requiredNutrient(func(f *BahanMakanan) *float64 { return &f.LemakG }, "g"),
+	"karbohidrat_g":        requiredNutrient(func(f *BahanMakanan) *float64 { return &f.KarbohidratG }, "g"),
+	"serat_g":              optionalNutrient(func(f *BahanMakanan) **float64 { return &f.SeratG }, "g"),
+	"abu_g":                requiredNutrient(func(f *BahanMakanan) *float64 { return &f.AbuG }, "g"),
+	"kalsium_ca_mg":        optionalNutrient(func(f *BahanMakanan) **float64 { return &f.KalsiumCaMg }, "mg"),
+	"fosfor_p_mg":          optionalNutrient(func(f *BahanMakanan) **float64 { return &f.FosforPMg }, "mg"),
+	"besi_fe_mg":           optionalNutrient(func(f *BahanMakanan) **float64 { return &f.BesiFeMg }, "mg"),
+	"natrium_na_mg":        optionalNutrient(func(f *BahanMakanan) **float64 { return &f.NatriumNaMg }, "mg"),
+	"kalium_ka_mg":         optionalNutrient(func(f *BahanMakanan) **float64 { return &f.KaliumKaMg }, "mg"),
+	"tembaga_cu_mg":        optionalNutrient(func(f *BahanMakanan) **float64 { return &f.TembagaCuMg }, "mg"),
+	"seng_zn_mg":           optionalNutrient(func(f *BahanMakanan) **float64 { return &f.SengZnMg }, "mg"),
+	"retinol_vit_a_mcg":    optionalNutrient(func(f *BahanMakanan) **float64 { return &f.RetinolVitAMcg }, "mcg"),
+	"beta_karoten_mcg":     optionalNutrient(func(f *BahanMakanan) **float64 { return &f.BetaKarotenMcg }, "mcg"),
+	"karoten_total_mcg":    optionalNutrient(func(f *BahanMakanan) **float64 { return &f.KarotenTotalMcg }, "mcg"),
+	"thiamin_vit_b1_mg":    optionalNutrient(func(f *BahanMakanan) **float64 { return &f.ThiaminVitB1Mg }, "mg"),
+	"riboflavin_vit_b2_mg": optionalNutrient(func(f *BahanMakanan) **float64 { return &f.RiboflavinVitB2Mg }, "mg"),
+	"niasin_mg":            optionalNutrient(func(f *BahanMakanan) **float64 { return &f.NiasinMg }, "mg"),
+	"vitamin_c_mg":         optionalNutrient(func(f *BahanMakanan) **float64 { return &f.VitaminCMg }, "mg"),
+	"bdd_persen":           requiredNutrient(func(f *BahanMakanan) *float64 { return &f.BddPersen }, "%"),
+}
+
+// IsFoodImportField reports whether a mapping may set field
+func IsFoodImportField(field string) bool {
+	switch field {
+	case FoodImportKode, FoodImportName, FoodImportMentahOlahan, FoodImportKelompokMakanan:
+		return true
+	}
+	_, ok := foodNutrients[field]
+	return ok
+}
+
+// FoodImportMappings are the column mappings of the known datasets: the wide CSV export of USDA FoodData
+// Central and the Tabel Komposisi Pangan Indonesia published with BPOM
+var FoodImportMappings = map[string]FoodImportMapping{
+	FoodImportUSDA: {
+		FoodImportName:         {Column: "description"},
+		"air_g":                {Column: "Water (g)"},
+		"energi_kal":           {Column: "Energy (kcal)"},
+		"protein_g":            {Column: "Protein (g)"},
+		"lemak_g":              {Column: "Total lipid (fat) (g)"},
+		"karbohidrat_g":        {Column: "Carbohydrate, by difference (g)"},
+		"serat_g":              {Column: "Fiber, total dietary (g)"},
+		"abu_g":                {Column: "Ash (g)"},
+		"kalsium_ca_mg":        {Column: "Calcium, Ca (mg)"},
+		"fosfor_p_mg":          {Column: "Phosphorus, P (mg)"},
+		"besi_fe_mg":           {Column: "Iron, Fe (mg)"},
+		"natrium_na_mg":        {Column: "Sodium, Na (mg)"},
+		"kalium_ka_mg":         {Column: "Potassium, K (mg)"},
+		"tembaga_cu_mg":        {Column: "Copper, Cu (mg)"},
+		"seng_zn_mg":           {Column: "Zinc, Zn (mg)"},
+		"retinol_vit_a_mcg":    {Column: "Retinol (µg)"},
+		"beta_karoten_mcg":     {Column: "Carotene, beta (µg)"},
+		"thiamin_vit_b1_mg":    {Column: "Thiamin (mg)"},
+		"riboflavin_vit_b2_mg": {Column: "Riboflavin (mg)"},
+		"niasin_mg":            {Column: "Niacin (mg)"},
+		"vitamin_c_mg":         {Column: "Vitamin C, total ascorbic acid (mg)"},
+	},
+	FoodImportBPOM: {
+		FoodImportKode:            {Column: "KODE"},
+		FoodImportName:            {Column: "NAMA BAHAN"},
+		FoodImportMentahOlahan:    {Column: "MENTAH/OLAHAN"},
+		FoodImportKelompokMakanan: {Column: "KELOMPOK MAKANAN"},
+		"air_g":                   {Column: "AIR (g)"},
+		"energi_kal":              {Column: "ENERGI (Kal)"},
+		"protein_g":               {Column: "PROTEIN (g)"},
+		"lemak_g":                 {Column: "LEMAK (g)"},
+		"karbohidrat_g":           {Column: "KH (g)"},
+		"serat_g":                 {Column: "SERAT (g)"},
+		"abu_g":                   {Column: "ABU (g)"},
+		"kalsium_ca_mg":           {Column: "KALSIUM (mg)"},
+		"fosfor_p_mg":             {Column: "FOSFOR (mg)"},
+		"besi_fe_mg":              {Column: "BESI (mg)"},
+		"natrium_na_mg":           {Column: "NATRIUM (mg)"},
+		"kalium_ka_mg":            {Column: "KALIUM (mg)"},
+		"tembaga_cu_mg":           {Column: "TEMBAGA (mg)"},
+		"seng_zn_mg":              {Column: "SENG (mg)"},
+		"retinol_vit_a_mcg":       {Column: "RETINOL (mcg)"},
+		"beta_karoten_mcg":        {Column: "B-KAR (mcg)"},
+		"karoten_total_mcg":       {Column: "KAR-TOTAL (mcg)"},
+		"thiamin_vit_b1_mg":       {Column: "THIAMIN (mg)"},
+		"riboflavin_vit_b2_mg":    {Column: "RIBOFLAVIN (mg)"},
+		"niasin_mg":               {Column: "NIASIN (mg)"},
+		"vitamin_c_mg":            {Column: "VIT C (mg)"},
+		"bdd_persen":              {Column: "BDD (%)"},
+	},
+}
+
+// Merge returns the mapping with the columns of override replacing its own
+func (mapping FoodImportMapping) Merge(override FoodImportMapping) FoodImportMapping {
+	merged := make(FoodImportMapping, len(mapping)+len(override))
+	for field, column := range mapping {
+		merged[field] = column
+	}
+	for field, column := range override {
+		merged[field] = column
+	}
+	return merged
+}
+
+// normalizeFoodUnit returns the canonical name of a unit, empty when it is not one
+func normalizeFoodUnit(unit string) string {
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "g", "gr", "gram":
+		return "g"
+	case "mg":
+		return "mg"
+	case "mcg", "µg", "μg", "ug":
+		return "mcg"
+	case "kcal", "kal", "kkal":
+		return "kcal"
+	case "kj":
+		return "kJ"
+	case "%", "persen":
+		return "%"
+	}
+	return ""
+}
+
+// foodUnitFactors are the units a value can be converted between, as a factor to the first unit of its kind
+var foodUnitFactors = map[string]struct {
+	Kind   string
+	Factor float64
+}{
+	"g":    {"mass", 1},
+	"mg":   {"mass", 1e-3},
+	"mcg":  {"mass", 1e-6},
+	"kcal": {"energy", 1},
+	"kJ":   {"energy", 1 / 4.184},
+	"%":    {"percent", 1},
+}
+
+// ConvertFoodUnit converts a value between units of mass or of energy
+func ConvertFoodUnit(value float64, from, to string) (float64, error) {
+	fromUnit, ok := foodUnitFactors[normalizeFoodUnit(from)]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", from)
+	}
+	toUnit, ok := foodUnitFactors[normalizeFoodUnit(to)]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", to)
+	}
+	if fromUnit.Kind != toUnit.Kind {
+		return 0, fmt.Errorf("cannot convert %s to %s", from, to)
+	}
+	return value * fromUnit.Factor / toUnit.Factor, nil
+}
+
+// headerFoodUnit returns the unit at the end of a header in parentheses, empty when it has none
+func headerFoodUnit(header string) string {
+	header = strings.TrimSpace(header)
+	if !strings.HasSuffix(header, ")") {
+		return ""
+	}
+	open := strings.LastIndex(header, "(")
+	if open < 0 {
+		return ""
+	}
+	return normalizeFoodUnit(header[open+1 : len(header)-1])
+}
+
+// parseFoodValue reads a nutrient value, with a decimal comma or point. It returns nil for an empty value
+// and zero for a trace.
+func parseFoodValue(raw string) (*float64, error) {
+	raw = strings.TrimSpace(raw)
+	switch strings.ToLower(raw) {
+	case "", "-", "n/a", "na":
+		return nil, nil
+	case "tr", "trace":
+		zero := 0.0
+		return &zero, nil
+	}
+	value, err := strconv.ParseFloat(strings.Replace(raw, ",", ".", 1), 64)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a number", raw)
+	}
+	if value < 0 {
+		return nil, fmt.Errorf("%q is negative", raw)
+	}
+	return &value, nil
+}
+
+// FoodImportRow is a row of a dataset read with a mapping, nutrients converted to the units of the catalog
+type FoodImportRow struct {
+	Line            int
+	Kode            string
+	Name            string
+	MentahOlahan    string
+	KelompokMakanan string
+	Values          map[string]float64
+	Error           string
+}
+
+// Food returns the food the row describes
+func (row FoodImportRow) Food() BahanMakanan {
+	food := BahanMakanan{
+		Kode:             row.Kode,
+		NamaBahanMakanan: row.Name,
+		MentahOlahan:     row.MentahOlahan,
+		KelompokMakanan:  row.KelompokMakanan,
+	}
+	for field, value := range row.Values {
+		foodNutrients[field].Set(&food, value)
+	}
+	return food
+}
+
+// ErrFoodImportNoName is returned when the file has no column for the name of the foods
+var ErrFoodImportNoName = errors.New("the file has no column for the food name")
+
+// ParseFoodImport reads a CSV dataset, separated by commas or semicolons, with a mapping. Rows that cannot
+// be read carry their error. It also returns the mapped columns the file does not have.
+func ParseFoodImport(r io.Reader, mapping FoodImportMapping) ([]FoodImportRow, []string, error) {
+	reader := bufio.NewReader(r)
+	firstLine, err := reader.Peek(4096)
+	if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, nil, err
+	}
+	if end := strings.IndexByte(string(firstLine), '\n'); end >= 0 {
+		firstLine = firstLine[:end]
+	}
+
+	records := csv.NewReader(reader)
+	if strings.Count(string(firstLine), ";") > strings.Count(string(firstLine), ",") {
+		records.Comma = ';'
+	}
+	records.FieldsPerRecord = -1
+	records.TrimLeadingSpace = true
+
+	header, err := records.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading the header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	// index and unit of every mapped column the file has
+	type source struct {
+		Index int
+		Unit  string
+	}
+	sources := make(map[string]source, len(mapping))
+	var ignored []string
+	for field, column := range mapping {
+		index, ok := columns[strings.ToLower(strings.TrimSpace(column.Column))]
+		if !ok {
+			ignored = append(ignored, column.Column)
+			continue
+		}
+		unit := column.Unit
+		if unit == "" {
+			unit = headerFoodUnit(header[index])
+		}
+		if nutrient, ok := foodNutrients[field]; ok && unit == "" {
+			unit = nutrient.Unit
+		}
+		sources[field] = source{Index: index, Unit: unit}
+	}
+	sort.Strings(ignored)
+	if _, ok := sources[FoodImportName]; !ok {
+		return nil, ignored, ErrFoodImportNoName
+	}
+
+	var rows []FoodImportRow
+	for line := 2; ; line++ {
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		row := FoodImportRow{Line: line, Values: make(map[string]float64)}
+		if err != nil {
+			row.Error = err.Error()
+			rows = append(rows, row)
+			continue
+		}
+
+		cell := func(field string) (string, bool) {
+			src, ok := sources[field]
+			if !ok || src.Index >= len(record) {
+				return "", false
+			}
+			return strings.TrimSpace(record[src.Index]), true
+		}
+
+		row.Name, _ = cell(FoodImportName)
+		row.Kode, _ = cell(FoodImportKode)
+		row.MentahOlahan, _ = cell(FoodImportMentahOlahan)
+		row.KelompokMakanan, _ = cell(FoodImportKelompokMakanan)
+		if row.Name == "" {
+			row.Error = "the food has no name"
+			rows = append(rows, row)
+			continue
+		}
+
+		var problems []string
+		for field, nutrient := range foodNutrients {
+			raw, ok := cell(field)
+			if !ok {
+				continue
+			}
+			value, err := parseFoodValue(raw)
+			if err == nil && value != nil {
+				var converted float64
+				converted, err = ConvertFoodUnit(*value, sources[field].Unit, nutrient.Unit)
+				row.Values[field] = converted
+			}
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", field, err))
+			}
+		}
+		if len(problems) > 0 {
+			row.Error = strings.Join(problems, "; ")
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, ignored, nil
+}
+
+// FoodImportMatch is the food of the catalog a row is the same food as
+type FoodImportMatch struct {
+	Food  *BahanMakanan
+	By    string
+	Score float64
+}
+
+// MatchFoodImport finds the food of the catalog a row describes: the food with its code, else one of whose
+// names is the name of the row, else the food with the closest name above FoodImportMatchThreshold
+func MatchFoodImport(row FoodImportRow, foods []BahanMakanan, names map[string][]FoodName) *FoodImportMatch {
+	if row.Kode != "" {
+		for i := range foods {
+			if strings.EqualFold(foods[i].Kode, row.Kode) {
+				return &FoodImportMatch{Food: &foods[i], By: FoodImportMatchKode, Score: 1}
+			}
+		}
+	}
+
+	name := NormalizeFoodName(row.Name)
+	var best *FoodImportMatch
+	for i := range foods {
+		candidates := []string{foods[i].NamaBahanMakanan}
+		for _, foodName := range names[foods[i].Kode] {
+			candidates = append(candidates, foodName.Name)
+		}
+		for _, candidate := range candidates {
+			if NormalizeFoodName(candidate) == name {
+				return &FoodImportMatch{Food: &foods[i], By: FoodImportMatchName, Score: 1}
+			}
+			// Both ways, so a name does not match a longer one only because it is part of it
+			similarity := min(FoodNameSimilarity(row.Name, candidate), FoodNameSimilarity(candidate, row.Name))
+			if similarity >= FoodImportMatchThreshold && (best == nil || similarity > best.Score) {
+				best = &FoodImportMatch{Food: &foods[i], By: FoodImportMatchSimilar, Score: similarity}
+			}
+		}
+	}
+	return best
+}
+
+// MergeFoodImport sets the values of a row on a copy of food: the ones it is missing, or every one with
+// overwrite. It returns the fields that changed, sorted. Its code and name stay.
+func MergeFoodImport(food BahanMakanan, row FoodImportRow, overwrite bool) (BahanMakanan, []string) {
+	var changes []string
+	if row.MentahOlahan != "" && (food.MentahOlahan == "" || overwrite) && food.MentahOlahan != row.MentahOlahan {
+		food.MentahOlahan = row.MentahOlahan
+		changes = append(changes, FoodImportMentahOlahan)
+	}
+	if row.KelompokMakanan != "" && (food.KelompokMakanan == "" || overwrite) && food.KelompokMakanan != row.KelompokMakanan {
+		food.KelompokMakanan = row.KelompokMakanan
+		changes = append(changes, FoodImportKelompokMakanan)
+	}
+
+	// Setting an optional value points it to a new float, the food of the caller does not change
+	for field, nutrient := range foodNutrients {
+		value, ok := row.Values[field]
+		if !ok {
+			continue
+		}
+		current := nutrient.Get(&food)
+		if current != nil && (!overwrite || *current == value) {
+			continue
+		}
+		nutrient.Set(&food, value)
+		changes = append(changes, field)
+	}
+
+	sort.Strings(changes)
+	return food, changes
+}
@@ -0,0 +1,283 @@
+package model
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Languages of food names. The food composition table names foods in Indonesian, it is the fallback
+// when a food has no name in the language asked for.
+const (
+	FoodLanguageIndonesian = "id"
+	FoodLanguageEnglish    = "en"
+)
+
+var FoodLanguages = []string{FoodLanguageIndonesian, FoodLanguageEnglish}
+
+// IsFoodLanguage reports whether foods can be named in lang
+func IsFoodLanguage(lang string) bool {
+	for _, language := range FoodLanguages {
+		if language == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// FoodName is a localized name of a food of the food composition table. The primary name of a language is
+// the one shown, the others are synonyms that only help search ("telur", "endog").
+type FoodName struct {
+	ID        uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	FoodKode  string    `gorm:"size:20;not null;uniqueIndex:idx_food_name" json:"food_kode"`
+	Language  string    `gorm:"size:5;not null;uniqueIndex:idx_food_name" json:"language"`
+	Name      string    `gorm:"size:150;not null;uniqueIndex:idx_food_name" json:"name"`
+	Primary   bool      `gorm:"column:is_primary;not null;default:false" json:"primary"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (name *FoodName) BeforeCreate(_ *gorm.DB) error {
+	name.ID = uuid.New()
+	return nil
+}
+
+// FoodSearchResult is a food found by a search, named in the language of the search
+type FoodSearchResult struct {
+	Food BahanMakanan `json:"food"`
+	Name string       `json:"name"`
+	// Language of Name, Indonesian when the food has no name in the language of the search
+	Language    string `json:"language"`
+	MatchedName string `json:"matched_name"`
+	// Fuzzy is set when only a name close to the search matched, "telr" for "telur"
+	Fuzzy bool `json:"fuzzy"`
+	Score int  `json:"score"`
+}
+
+// FoodRankSignals lift foods in search beyond how their names match. Both count food logs by kode.
+type FoodRankSignals struct {
+	// Popularity counts the logs of every user
+	Popularity map[string]int
+	// UserLogs counts the logs of the user searching
+	UserLogs map[string]int
+}
+
+// Scores of how a name matches a search, a name in the language of the search and a primary name
+// rank a little higher than others matching the same way
+const (
+	foodMatchExact    = 100
+	foodMatchPrefix   = 80
+	foodMatchWords    = 60
+	foodMatchContains = 40
+	// A fuzzy match scores up to foodMatchFuzzy by the similarity of the names
+	foodMatchFuzzy = 35
+
+	foodMatchLanguageBonus = 10
+	foodMatchPrimaryBonus  = 5
+
+	// Popularity grows with the log of its count so staples do not drown everything else
+	foodPopularityWeight   = 3
+	foodPopularityMaxBonus = 15
+	foodUserLogBonus       = 15
+	foodUserLogMaxBonus    = 25
+
+	// FoodFuzzyThreshold is the trigram similarity a name needs to match a search it does not contain
+	FoodFuzzyThreshold = 0.3
+)
+
+// NormalizeFoodName lower cases a name and collapses its spaces and punctuation, for comparing names
+func NormalizeFoodName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '(' || r == ')' || r == '-' || r == '/' || r == '\t'
+	})
+	return strings.Join(words, " ")
+}
+
+// matchFoodName scores how name matches the normalized query, 0 when it does not
+func matchFoodName(query, name string) int {
+	name = NormalizeFoodName(name)
+	switch {
+	case name == query:
+		return foodMatchExact
+	case strings.HasPrefix(name, query):
+		return foodMatchPrefix
+	case matchFoodWords(strings.Fields(query), strings.Fields(name)):
+		return foodMatchWords
+	case strings.Contains(name, query):
+		return foodMatchContains
+	}
+	return 0
+}
+
+// matchFoodWords reports whether every word of the query starts a word of the name, in any order
+func matchFoodWords(query, name []string) bool {
+	for _, word := range query {
+		found := false
+		for _, candidate := range name {
+			if strings.HasPrefix(candidate, word) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// foodTrigrams returns the trigrams of a word, padded like pg_trgm so the start of a word weighs more
+func foodTrigrams(word string) map[string]struct{} {
+	padded := []rune("  " + word + " ")
+	trigrams := make(map[string]struct{}, len(padded))
+	for i := 0; i+3 <= len(padded); i++ {
+		trigrams[string(padded[i:i+3])] = struct{}{}
+	}
+	return trigrams
+}
+
+func trigramSimilarity(a, b map[string]struct{}) float64 {
+	shared := 0
+	for trigram := range a {
+		if _, ok := b[trigram]; ok {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// FoodNameSimilarity is how close a search is to a name, from 0 to 1. Every word of the search is compared
+// with the closest word of the name and the least similar one counts, so "telr ayam" is close to
+// "telur ayam kampung" but "ayam rebus" is not close to "telur ayam".
+func FoodNameSimilarity(query, name string) float64 {
+	queryWords := strings.Fields(NormalizeFoodName(query))
+	nameWords := strings.Fields(NormalizeFoodName(name))
+	if len(queryWords) == 0 || len(nameWords) == 0 {
+		return 0
+	}
+
+	nameTrigrams := make([]map[string]struct{}, len(nameWords))
+	for i, word := range nameWords {
+		nameTrigrams[i] = foodTrigrams(word)
+	}
+
+	similarity := 1.0
+	for _, word := range queryWords {
+		trigrams, best := foodTrigrams(word), 0.0
+		for _, candidate := range nameTrigrams {
+			best = math.Max(best, trigramSimilarity(trigrams, candidate))
+		}
+		similarity = math.Min(similarity, best)
+	}
+	return similarity
+}
+
+// foodSignalBonus scores how often a food was logged, by everyone and by the user searching
+func foodSignalBonus(kode string, signals FoodRankSignals) int {
+	bonus := 0
+	if count := signals.Popularity[kode]; count > 0 {
+		bonus += min(int(foodPopularityWeight*math.Log1p(float64(count))), foodPopularityMaxBonus)
+	}
+	if count := signals.UserLogs[kode]; count > 0 {
+		bonus += min(foodUserLogBonus+int(foodPopularityWeight*math.Log1p(float64(count))), foodUserLogMaxBonus)
+	}
+	return bonus
+}
+
+// DisplayFoodName returns the name of a food in lang with its language, the Indonesian name of the food
+// composition table when it has none
+func DisplayFoodName(food BahanMakanan, names []FoodName, lang string) (string, string) {
+	for _, name := range names {
+		if name.Language == lang && name.Primary {
+			return name.Name, lang
+		}
+	}
+	if lang != FoodLanguageIndonesian {
+		for _, name := range names {
+			if name.Language == FoodLanguageIndonesian && name.Primary {
+				return name.Name, FoodLanguageIndonesian
+			}
+		}
+	}
+	return food.NamaBahanMakanan, FoodLanguageIndonesian
+}
+
+// RankFoods searches foods by their names in every language, best matches first. names holds the
+// localized names of the foods by kode. Names that do not contain the search still match when they are
+// close enough to it, for less than a name that does. Foods logged often, and by the user above all, score more.
+func RankFoods(query, lang string, foods []BahanMakanan, names map[string][]FoodName, signals FoodRankSignals, limit int) []FoodSearchResult {
+	query = NormalizeFoodName(query)
+	if query == "" {
+		return []FoodSearchResult{}
+	}
+
+	results := make([]FoodSearchResult, 0)
+	for _, food := range foods {
+		// The name of the food composition table is the primary Indonesian name of every food
+		candidates := append([]FoodName{{
+			FoodKode: food.Kode,
+			Language: FoodLanguageIndonesian,
+			Name:     food.NamaBahanMakanan,
+			Primary:  true,
+		}}, names[food.Kode]...)
+
+		best, matched, fuzzy := 0, "", false
+		for _, candidate := range candidates {
+			score, near := matchFoodName(query, candidate.Name), false
+			if score == 0 && len([]rune(query)) >= 3 {
+				if similarity := FoodNameSimilarity(query, candidate.Name); similarity >= FoodFuzzyThreshold {
+					score, near = int(math.Ceil(foodMatchFuzzy*similarity)), true
+				}
+			}
+			if score == 0 {
+				continue
+			}
+			if candidate.Language == lang {
+				score += foodMatchLanguageBonus
+			}
+			if candidate.Primary {
+				score += foodMatchPrimaryBonus
+			}
+			if score > best {
+				best, matched, fuzzy = score, candidate.Name, near
+			}
+		}
+		if best == 0 {
+			continue
+		}
+		best += foodSignalBonus(food.Kode, signals)
+
+		name, language := DisplayFoodName(food, names[food.Kode], lang)
+		results = append(results, FoodSearchResult{
+			Food:        food,
+			Name:        name,
+			Language:    language,
+			MatchedName: matched,
+			Fuzzy:       fuzzy,
+			Score:       best,
+		})
+	}
+
+	// Shorter names first among equal matches, "telur ayam" before "telur ayam kampung rebus"
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if len(results[i].Name) != len(results[j].Name) {
+			return len(results[i].Name) < len(results[j].Name)
+		}
+		return results[i].Food.Kode < results[j].Food.Kode
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
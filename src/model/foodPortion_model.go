@@ -0,0 +1,181 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Kinds of portion units: a mass converts to grams alone, a volume through the density of the food and a
+// count only with the serving size of the food
+const (
+	PortionKindMass   = "mass"
+	PortionKindVolume = "volume"
+	PortionKindCount  = "count"
+)
+
+// Units a portion can be given in
+const (
+	PortionGram        = "g"
+	PortionKilogram    = "kg"
+	PortionMilliliter  = "ml"
+	PortionLiter       = "l"
+	PortionSendokTeh   = "sendok_teh"
+	PortionSendokMakan = "sendok_makan"
+	PortionGelas       = "gelas"
+	PortionMangkok     = "mangkok"
+	PortionCentong     = "centong"
+	PortionPiring      = "piring"
+	PortionPotong      = "potong"
+	PortionButir       = "butir"
+	PortionBuah        = "buah"
+	PortionBungkus     = "bungkus"
+)
+
+// DefaultFoodDensity is the grams per milliliter of a food without its own, the density of water
+const DefaultFoodDensity = 1.0
+
+// PortionUnit is a unit a food can be logged in. Size is grams for a mass and milliliters for a volume.
+type PortionUnit struct {
+	Unit    string  `json:"unit"`
+	Kind    string  `json:"kind"`
+	Size    float64 `json:"size,omitempty"`
+	Label   string  `json:"label"`
+	LabelEN string  `json:"label_en"`
+}
+
+// PortionUnits are the known units in the order they are offered. Household measures follow the usual
+// Indonesian sizes, a food may give its own.
+var PortionUnits = []PortionUnit{
+	{Unit: PortionGram, Kind: PortionKindMass, Size: 1, Label: "gram", LabelEN: "gram"},
+	{Unit: PortionKilogram, Kind: PortionKindMass, Size: 1000, Label: "kilogram", LabelEN: "kilogram"},
+	{Unit: PortionMilliliter, Kind: PortionKindVolume, Size: 1, Label: "mililiter", LabelEN: "milliliter"},
+	{Unit: PortionLiter, Kind: PortionKindVolume, Size: 1000, Label: "liter", LabelEN: "liter"},
+	{Unit: PortionSendokTeh, Kind: PortionKindVolume, Size: 5, Label: "sendok teh", LabelEN: "teaspoon"},
+	{Unit: PortionSendokMakan, Kind: PortionKindVolume, Size: 15, Label: "sendok makan", LabelEN: "tablespoon"},
+	{Unit: PortionGelas, Kind: PortionKindVolume, Size: 240, Label: "gelas", LabelEN: "glass"},
+	{Unit: PortionMangkok, Kind: PortionKindVolume, Size: 250, Label: "mangkok", LabelEN: "bowl"},
+	{Unit: PortionCentong, Kind: PortionKindCount, Label: "centong", LabelEN: "scoop"},
+	{Unit: PortionPiring, Kind: PortionKindCount, Label: "piring", LabelEN: "plate"},
+	{Unit: PortionPotong, Kind: PortionKindCount, Label: "potong", LabelEN: "slice"},
+	{Unit: PortionButir, Kind: PortionKindCount, Label: "butir", LabelEN: "piece"},
+	{Unit: PortionBuah, Kind: PortionKindCount, Label: "buah", LabelEN: "whole"},
+	{Unit: PortionBungkus, Kind: PortionKindCount, Label: "bungkus", LabelEN: "pack"},
+}
+
+// FindPortionUnit returns a known unit, false when there is none
+func FindPortionUnit(unit string) (PortionUnit, bool) {
+	for _, portionUnit := range PortionUnits {
+		if portionUnit.Unit == unit {
+			return portionUnit, true
+		}
+	}
+	return PortionUnit{}, false
+}
+
+// FoodPortion is the weight of one unit of a food: a serving size for a count ("1 potong tempe is 25 g"),
+// a measure replacing the usual one ("1 sendok makan gula is 12 g"), or for ml the density of the food
+type FoodPortion struct {
+	FoodKode  string    `gorm:"size:20;primaryKey" json:"food_kode"`
+	Unit      string    `gorm:"size:20;primaryKey" json:"unit"`
+	Grams     float64   `gorm:"not null" json:"grams"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"-"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// PortionOption is a unit a food can be logged in with the weight of one unit
+type PortionOption struct {
+	PortionUnit
+	Grams float64 `json:"grams"`
+	// Approximate is set when the weight uses the density of water, the food has none
+	Approximate bool `json:"approximate"`
+}
+
+// PortionNutrition is the nutrition of a portion of a food
+type PortionNutrition struct {
+	EnergiKal    float64  `json:"energi_kal"`
+	ProteinG     float64  `json:"protein_g"`
+	LemakG       float64  `json:"lemak_g"`
+	KarbohidratG float64  `json:"karbohidrat_g"`
+	SeratG       *float64 `json:"serat_g,omitempty"`
+}
+
+// PortionConversion is a quantity of a food in a unit converted to grams, with its nutrition
+type PortionConversion struct {
+	FoodKode    string           `json:"food_kode"`
+	Quantity    float64          `json:"quantity"`
+	Unit        string           `json:"unit"`
+	Grams       float64          `json:"grams"`
+	Approximate bool             `json:"approximate"`
+	Nutrition   PortionNutrition `json:"nutrition"`
+}
+
+// portionGrams returns the weight of one unit of a food given its portions, approximate when it falls
+// back to the density of water
+func portionGrams(unit PortionUnit, portions []FoodPortion) (float64, bool, bool) {
+	density, approximate := DefaultFoodDensity, true
+	for _, portion := range portions {
+		if portion.Unit == unit.Unit {
+			return portion.Grams, false, true
+		}
+		if portion.Unit == PortionMilliliter {
+			density, approximate = portion.Grams, false
+		}
+	}
+
+	switch unit.Kind {
+	case PortionKindMass:
+		return unit.Size, false, true
+	case PortionKindVolume:
+		return unit.Size * density, approximate, true
+	}
+	return 0, false, false
+}
+
+// PortionOptions returns the units a food can be logged in: every mass and volume, and the counts it has
+// a serving size for
+func PortionOptions(portions []FoodPortion) []PortionOption {
+	options := make([]PortionOption, 0, len(PortionUnits))
+	for _, unit := range PortionUnits {
+		grams, approximate, ok := portionGrams(unit, portions)
+		if !ok {
+			continue
+		}
+		options = append(options, PortionOption{PortionUnit: unit, Grams: grams, Approximate: approximate})
+	}
+	return options
+}
+
+// ConvertPortion converts a quantity of a food in a unit to grams
+func ConvertPortion(quantity float64, unit string, portions []FoodPortion) (float64, bool, error) {
+	portionUnit, ok := FindPortionUnit(unit)
+	if !ok {
+		return 0, false, fmt.Errorf("unknown unit %q", unit)
+	}
+	grams, approximate, ok := portionGrams(portionUnit, portions)
+	if !ok {
+		return 0, false, fmt.Errorf("the food has no serving size for %s", portionUnit.Label)
+	}
+	return roundPortion(quantity * grams), approximate, nil
+}
+
+// NutritionOfPortion scales the nutrition of a food, given per 100 g, to a portion
+func NutritionOfPortion(food BahanMakanan, grams float64) PortionNutrition {
+	factor := grams / 100
+	nutrition := PortionNutrition{
+		EnergiKal:    roundPortion(food.EnergiKal * factor),
+		ProteinG:     roundPortion(food.ProteinG * factor),
+		LemakG:       roundPortion(food.LemakG * factor),
+		KarbohidratG: roundPortion(food.KarbohidratG * factor),
+	}
+	if food.SeratG != nil {
+		serat := roundPortion(*food.SeratG * factor)
+		nutrition.SeratG = &serat
+	}
+	return nutrition
+}
+
+// roundPortion rounds to two decimals, the precision of meal nutrition
+func roundPortion(value float64) float64 {
+	return math.Round(value*100) / 100
+}
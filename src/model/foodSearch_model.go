@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FoodLog counts how often a user logged a food, it feeds the ranking of food search
+type FoodLog struct {
+	UserID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	FoodKode     string    `gorm:"size:20;primaryKey;index" json:"food_kode"`
+	Count        int       `gorm:"not null;default:0" json:"count"`
+	LastLoggedAt time.Time `gorm:"not null" json:"last_logged_at"`
+	// The portion logged last, the app offers it again
+	LastQuantity *float64 `json:"last_quantity,omitempty"`
+	LastUnit     *string  `gorm:"size:20" json:"last_unit,omitempty"`
+	LastGrams    *float64 `json:"last_grams,omitempty"`
+}
+
+// FoodPopularity counts the logs of every user per food. LogFood keeps it up, the ranking of food search
+// reads it instead of summing the logs.
+type FoodPopularity struct {
+	FoodKode string `gorm:"size:20;primaryKey" json:"food_kode"`
+	Count    int    `gorm:"not null;default:0" json:"count"`
+}
+
+// FoodCatalogEntry mirrors a food of the food service. Food search matches their names in the database
+// while the search index is unavailable.
+type FoodCatalogEntry struct {
+	Kode        string       `gorm:"size:20;primaryKey" json:"kode"`
+	Name        string       `gorm:"not null" json:"name"`
+	Food        BahanMakanan `gorm:"type:jsonb;serializer:json;not null" json:"food"`
+	RefreshedAt time.Time    `gorm:"not null;index" json:"refreshed_at"`
+}
+
+// FoodSearch records a food search for search analytics. Searches without results show the foods and
+// names the catalog is missing.
+type FoodSearch struct {
+	ID       uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Query    string    `gorm:"size:100;not null;index" json:"query"` // normalized
+	Language string    `gorm:"size:5;not null" json:"language"`
+	Results  int       `gorm:"not null" json:"results"`
+	// FuzzyOnly is set when every result only matched a name close to the search
+	FuzzyOnly bool `gorm:"not null;default:false" json:"fuzzy_only"`
+	// SelectedKode is the food the user logged from the results
+	SelectedKode *string   `gorm:"size:20" json:"selected_kode,omitempty"`
+	CreatedAt    time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (search *FoodSearch) BeforeCreate(_ *gorm.DB) error {
+	search.ID = uuid.New()
+	return nil
+}
+
+// FoodSearchGap is a search that found nothing, grouped over the searches of every user
+type FoodSearchGap struct {
+	Query          string    `json:"query"`
+	Language       string    `json:"language"`
+	Searches       int64     `json:"searches"`
+	Users          int64     `json:"users"`
+	LastSearchedAt time.Time `json:"last_searched_at"`
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	FraudReviewPending  = "pending"
+	FraudReviewApproved = "approved"
+	FraudReviewRejected = "rejected"
+
+	FraudListAllow = "allow"
+	FraudListDeny  = "deny"
+
+	FraudEntryUser  = "user"
+	FraudEntryEmail = "email"
+	FraudEntryIP    = "ip"
+
+	FraudRuleFailedPayments = "failed_payments"
+	FraudRuleGeoMismatch    = "geo_mismatch"
+	FraudRulePlanChurn      = "plan_churn"
+)
+
+// FraudReview is a checkout flagged by the fraud rules, its payment is held until an admin reviews it
+type FraudReview struct {
+	ID                 uuid.UUID         `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserSubscriptionID uuid.UUID         `gorm:"not null;index" json:"user_subscription_id"`
+	UserSubscription   *UserSubscription `gorm:"foreignKey:UserSubscriptionID" json:"user_subscription,omitempty"`
+	UserID             uuid.UUID         `gorm:"not null;index" json:"user_id"`
+	User               *User             `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	OrderID            string            `gorm:"size:100;index" json:"order_id"`
+	Rules              JSON              `gorm:"type:jsonb" json:"rules" swaggertype:"array,string"`
+	IPAddress          string            `gorm:"size:45" json:"ip_address"`
+	Country            string            `gorm:"size:2" json:"country"`
+	Status             string            `gorm:"size:20;default:'pending';index" json:"status"`
+	Notes              *string           `json:"notes,omitempty"`
+	ReviewedByID       *uuid.UUID        `gorm:"default:null" json:"reviewed_by_id,omitempty"`
+	ReviewedAt         *time.Time        `json:"reviewed_at,omitempty"`
+	CreatedAt          time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// FraudListEntry allows or denies checkouts for a user, email address or IP address regardless of the rules
+type FraudListEntry struct {
+	ID          uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	ListType    string     `gorm:"size:10;not null;uniqueIndex:idx_fraud_list_entry" json:"list_type"`
+	EntryType   string     `gorm:"size:10;not null;uniqueIndex:idx_fraud_list_entry" json:"entry_type"`
+	Value       string     `gorm:"size:255;not null;uniqueIndex:idx_fraud_list_entry" json:"value"`
+	Reason      string     `json:"reason"`
+	CreatedByID *uuid.UUID `gorm:"default:null" json:"created_by_id,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (fraudReview *FraudReview) BeforeCreate(_ *gorm.DB) error {
+	fraudReview.ID = uuid.New()
+	return nil
+}
+
+func (fraudListEntry *FraudListEntry) BeforeCreate(_ *gorm.DB) error {
+	fraudListEntry.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,36 @@
+package model
+
+// HomeCountry is the country the app is made for, visitors who cannot be located are taken to be in it
+const HomeCountry = "ID"
+
+// countryCurrencies are the currencies prices can be shown in for visitors of other countries
+var countryCurrencies = map[string]string{
+	"ID": CurrencyIDR,
+	"SG": "SGD",
+	"MY": "MYR",
+	"US": "USD",
+}
+
+// RegionalDefaults are the locale and currency a visitor gets until they choose their own
+type RegionalDefaults struct {
+	Country  string `json:"country"`
+	Locale   string `json:"locale"`
+	Currency string `json:"currency"`
+}
+
+// RegionalDefaultsOf returns the defaults of a visitor from country, an empty country is the home country.
+// Visitors abroad read English, prices stay in Rupiah where their currency is not shown.
+func RegionalDefaultsOf(country string) RegionalDefaults {
+	if country == "" {
+		country = HomeCountry
+	}
+
+	defaults := RegionalDefaults{Country: country, Locale: "en", Currency: CurrencyIDR}
+	if country == HomeCountry {
+		defaults.Locale = "id"
+	}
+	if currency, ok := countryCurrencies[country]; ok {
+		defaults.Currency = currency
+	}
+	return defaults
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Length bounds of a handle
+const (
+	HandleMinLength = 3
+	HandleMaxLength = 30
+)
+
+// reservedHandles would pass for the service, its staff or its routes. A handle is also reserved when it
+// only adds digits or underscores to one of them, like admin_1.
+var reservedHandles = []string{
+	"admin", "administrator", "root", "system", "sysadmin", "staff", "support", "help", "helpdesk", "moderator",
+	"mod", "official", "nutribox", "nutriteam", "gscnutriteam", "team", "security", "billing", "payment",
+	"api", "www", "mail", "email", "noreply", "me", "settings", "account", "login", "logout", "register",
+	"signup", "null", "undefined", "anonymous", "deleted", "user", "users", "everyone", "here",
+	"dokter", "doctor", "ahligizi", "nutritionist", "dietitian", "cs", "customerservice", "bantuan",
+}
+
+// HandleAvailability tells whether a handle can be taken, and why not
+type HandleAvailability struct {
+	Handle    string `json:"handle"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// NormalizeHandle turns a typed handle into its stored form: trimmed, without the leading @, lowercase
+func NormalizeHandle(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "@"))
+}
+
+// HandleProblem returns why a normalized handle cannot be used, or an empty string when its form is fine.
+// Handles are ASCII letters, digits and underscores and start with a letter.
+func HandleProblem(handle string) string {
+	if len(handle) < HandleMinLength || len(handle) > HandleMaxLength {
+		return "Handle must be 3 to 30 characters"
+	}
+	for _, r := range handle {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return "Handle may only contain letters, digits and underscores"
+		}
+	}
+	if handle[0] < 'a' || handle[0] > 'z' {
+		return "Handle must start with a letter"
+	}
+	if IsReservedHandle(handle) {
+		return "Handle is reserved"
+	}
+	return ""
+}
+
+// IsReservedHandle reports whether a handle is reserved or a reserved word with digits and underscores added
+func IsReservedHandle(handle string) bool {
+	stem := strings.TrimRight(strings.ReplaceAll(handle, "_", ""), "0123456789")
+	for _, reserved := range reservedHandles {
+		if handle == reserved || stem == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleRenameAt is when a user who last changed their handle at changedAt may change it again
+func HandleRenameAt(changedAt *time.Time, cooldown time.Duration) time.Time {
+	if changedAt == nil {
+		return time.Time{}
+	}
+	return changedAt.Add(cooldown)
+}
@@ -25,4 +25,5 @@ type WeightHeightStatistics struct {
 type HomeStatistics struct {
 	DailyNutrition         *DailyNutrition         `json:"daily_nutrition"`
 	WeightHeightStatistics *WeightHeightStatistics `json:"weight_height_statistics"`
+	DailyTip               *DailyTip               `json:"daily_tip,omitempty"`
 }
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// Headers of idempotent requests: the key a client sends, and the one marking a response replayed from an
+// earlier request with the key
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// IdempotencyKeyMaxLength bounds the keys clients send, UUIDs are recommended
+const IdempotencyKeyMaxLength = 255
+
+// IdempotencyKey is a request a client sent with an Idempotency-Key header and the response it got, so a retry
+// with the same key gets the same response instead of charging or subscribing again
+type IdempotencyKey struct {
+	// Scope is the user the key belongs to, or the path of unauthenticated requests
+	Scope string `gorm:"size:255;primaryKey" json:"scope"`
+	Key   string `gorm:"size:255;primaryKey" json:"key"`
+	// Fingerprint is of the method, the path and the body of the request, a key is only good for the same request
+	Fingerprint string     `gorm:"size:64;not null" json:"-"`
+	StatusCode  int        `gorm:"not null;default:0" json:"status_code"`
+	ContentType string     `gorm:"size:100" json:"content_type"`
+	Body        []byte     `gorm:"type:bytea" json:"-"`
+	CompletedAt *time.Time `gorm:"default:null" json:"completed_at,omitempty"` // nil while the first request runs
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// IdempotencyFingerprint identifies a request, so a key sent again with another request is told apart
+func IdempotencyFingerprint(method, path string, body []byte) string {
+	sum := sha256.New()
+	sum.Write([]byte(method + " " + path + "\n"))
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// Completed reports whether the response of the first request with the key was stored
+func (key *IdempotencyKey) Completed() bool {
+	return key.CompletedAt != nil
+}
+
+// IdempotentResponseStorable reports whether a response is kept for its key. Server errors are not: the request may not have done
+// anything and runs again when it is retried. Neither are conflicts and rate limits, they pass and a retry must
+// not get them replayed for as long as the key is kept.
+func IdempotentResponseStorable(statusCode int) bool {
+	return statusCode > 0 && statusCode < 500 &&
+		statusCode != http.StatusConflict && statusCode != http.StatusTooManyRequests
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	InstallmentPending = "pending"
+	InstallmentPaid    = "paid"
+	InstallmentFailed  = "failed"
+)
+
+// InstallmentSchedule is a single monthly installment of a subscription paid in installments
+type InstallmentSchedule struct {
+	ID                 uuid.UUID        `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserSubscriptionID uuid.UUID        `gorm:"not null;index" json:"user_subscription_id"`
+	UserSubscription   UserSubscription `gorm:"foreignKey:UserSubscriptionID" json:"-"`
+	InstallmentNumber  int              `gorm:"not null" json:"installment_number"`
+	Amount             int              `gorm:"not null" json:"amount"`
+	Currency           string           `gorm:"size:3;not null;default:IDR" json:"currency"`
+	DueDate            time.Time        `gorm:"not null;index" json:"due_date"`
+	OrderID            *string          `gorm:"size:100;uniqueIndex" json:"order_id,omitempty"`
+	PaymentURL         *string          `gorm:"size:255" json:"payment_url,omitempty"`
+	Status             string           `gorm:"size:20;default:'pending'" json:"status"`
+	Attempts           int              `gorm:"not null;default:0" json:"attempts"` // payment links sent
+	PaidAt             *time.Time       `json:"paid_at,omitempty"`
+	CreatedAt          time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (installment *InstallmentSchedule) BeforeCreate(_ *gorm.DB) error {
+	installment.ID = uuid.New()
+	return nil
+}
+
+// AmountMoney is the amount of the installment with its currency
+func (installment *InstallmentSchedule) AmountMoney() Money {
+	return Money{Amount: int64(installment.Amount), Currency: installment.Currency}
+}
+
+// BuildInstallmentSchedule splits total into count monthly installments starting at start.
+// Any remainder from the split is charged on the first installment.
+func BuildInstallmentSchedule(subscriptionID uuid.UUID, total Money, count int, start time.Time) []InstallmentSchedule {
+	amounts := total.Split(count)
+
+	schedule := make([]InstallmentSchedule, 0, len(amounts))
+	for i, amount := range amounts {
+		schedule = append(schedule, InstallmentSchedule{
+			UserSubscriptionID: subscriptionID,
+			InstallmentNumber:  i + 1,
+			Amount:             amount.Int(),
+			Currency:           amount.Currency,
+			DueDate:            start.AddDate(0, i, 0),
+			Status:             InstallmentPending,
+		})
+	}
+
+	return schedule
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LoginFailure is a password login that failed. Logins of an email or from an IP address with recent failures
+// must pass the CAPTCHA, the failures of an email are cleared when it logs in.
+type LoginFailure struct {
+	ID        uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Email     string    `gorm:"size:50;not null;index" json:"email"`
+	IPAddress string    `gorm:"size:45;not null;index" json:"ip_address"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (failure *LoginFailure) BeforeCreate(_ *gorm.DB) error {
+	failure.ID = uuid.New()
+	return nil
+}
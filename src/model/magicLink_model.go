@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MagicLink signs in a user from a link emailed to them, without a password. A link works once, until it
+// expires, and only on the device that asked for it: the device keeps a secret whose hash is stored here.
+type MagicLink struct {
+	ID         uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	DeviceHash string     `gorm:"size:64;not null" json:"-"`
+	IPAddress  string     `gorm:"size:45" json:"ip_address"`
+	UserAgent  string     `gorm:"size:255" json:"user_agent"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	UsedAt     *time.Time `gorm:"default:null" json:"used_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (link *MagicLink) BeforeCreate(_ *gorm.DB) error {
+	link.ID = uuid.New()
+	return nil
+}
+
+// Usable reports whether the link can still sign in at now
+func (link *MagicLink) Usable(now time.Time) bool {
+	return link.UsedAt == nil && now.Before(link.ExpiresAt)
+}
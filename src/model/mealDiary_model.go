@@ -0,0 +1,86 @@
+package model
+
+import "time"
+
+// Periods a meal diary can cover, a week starts on Monday
+const (
+	DiaryPeriodDay  = "day"
+	DiaryPeriodWeek = "week"
+)
+
+// MealDiaryDay is the meals of one day in the order they were eaten, with their totals
+type MealDiaryDay struct {
+	Date      string           `json:"date"`
+	Meals     []MealHistory    `json:"meals"`
+	MealCount int              `json:"meal_count"`
+	Total     NutritionAmounts `json:"total"`
+}
+
+// MealDiary is a user's meals over a day or a week, days without meals are listed empty
+type MealDiary struct {
+	Period string           `json:"period"`
+	From   string           `json:"from"`
+	To     string           `json:"to"`
+	Days   []MealDiaryDay   `json:"days"`
+	Total  NutritionAmounts `json:"total"`
+}
+
+// DiaryRange returns the first and last day of the period holding date, date is a calendar day at midnight
+func DiaryRange(period string, date time.Time) (time.Time, time.Time) {
+	if period != DiaryPeriodWeek {
+		return date, date
+	}
+	// Monday is the first day of the week
+	from := date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+	return from, from.AddDate(0, 0, 6)
+}
+
+// BuildMealDiary lays the meals out on every day from from to to, both inclusive.
+// Meals are placed on the calendar day of their meal time in the location of from.
+func BuildMealDiary(period string, meals []MealHistory, from, to time.Time) *MealDiary {
+	byDay := make(map[string][]MealHistory)
+	for _, meal := range meals {
+		day := meal.MealTime.In(from.Location()).Format("2006-01-02")
+		byDay[day] = append(byDay[day], meal)
+	}
+
+	diary := &MealDiary{
+		Period: period,
+		From:   from.Format("2006-01-02"),
+		To:     to.Format("2006-01-02"),
+		Days:   []MealDiaryDay{},
+	}
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		diaryDay := MealDiaryDay{Date: date, Meals: []MealHistory{}}
+
+		for _, meal := range byDay[date] {
+			diaryDay.Meals = append(diaryDay.Meals, meal)
+			diaryDay.Total.Calories += meal.Calories
+			diaryDay.Total.Protein += meal.Protein
+			diaryDay.Total.Carbs += meal.Carbs
+			diaryDay.Total.Fat += meal.Fat
+		}
+		diaryDay.MealCount = len(diaryDay.Meals)
+		diaryDay.Total = roundNutritionAmounts(diaryDay.Total)
+
+		diary.Days = append(diary.Days, diaryDay)
+		diary.Total.Calories += diaryDay.Total.Calories
+		diary.Total.Protein += diaryDay.Total.Protein
+		diary.Total.Carbs += diaryDay.Total.Carbs
+		diary.Total.Fat += diaryDay.Total.Fat
+	}
+	diary.Total = roundNutritionAmounts(diary.Total)
+
+	return diary
+}
+
+func roundNutritionAmounts(amounts NutritionAmounts) NutritionAmounts {
+	return NutritionAmounts{
+		Calories: roundNutrition(amounts.Calories),
+		Protein:  roundNutrition(amounts.Protein),
+		Carbs:    roundNutrition(amounts.Carbs),
+		Fat:      roundNutrition(amounts.Fat),
+	}
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,20 +10,51 @@ import (
 )
 
 type MealHistory struct {
-	ID             uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
-	UserID         uuid.UUID `gorm:"not null" json:"user_id"`
-	Title          string    `gorm:"not null" json:"title"`
-	MealTime       time.Time `gorm:"not null" json:"meal_time"`
-	Label          *string   `json:"label,omitempty"`
-	Calories       float64   `gorm:"type:decimal(6,2);not null" json:"calories"`
-	Protein        float64   `gorm:"type:decimal(6,2);not null" json:"protein"`
-	Carbs          float64   `gorm:"type:decimal(6,2);not null" json:"carbs"`
-	Fat            float64   `gorm:"type:decimal(6,2);not null" json:"fat"`
-	MealImage      string    `gorm:"not null" json:"meal_image"`
-	Comment        *string   `json:"comment,omitempty"`
-	Recommendation *string   `json:"recommendation,omitempty"`
-	CreatedAt      time.Time `gorm:"autoCreateTime:milli" json:"-"`
-	UpdatedAt      time.Time `gorm:"autoCreateTime:milli;autoUpdateTime:milli" json:"-"`
+	ID              uuid.UUID    `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID          uuid.UUID    `gorm:"not null" json:"user_id"`
+	Title           string       `gorm:"not null" json:"title"`
+	MealTime        time.Time    `gorm:"not null" json:"meal_time"`
+	Label           *string      `json:"label,omitempty"`
+	Calories        float64      `gorm:"type:decimal(6,2);not null" json:"calories"`
+	Protein         float64      `gorm:"type:decimal(6,2);not null" json:"protein"`
+	Carbs           float64      `gorm:"type:decimal(6,2);not null" json:"carbs"`
+	Fat             float64      `gorm:"type:decimal(6,2);not null" json:"fat"`
+	PortionQuantity *float64     `gorm:"type:decimal(8,2)" json:"portion_quantity,omitempty"` // the nutrients are for this portion
+	PortionUnit     *string      `gorm:"size:20" json:"portion_unit,omitempty"`
+	PortionGrams    *float64     `gorm:"type:decimal(8,2)" json:"portion_grams,omitempty"` // filled from the quantity for a mass unit
+	MealImage       ScanImage    `gorm:"not null" json:"meal_image"`
+	Comment         *string      `json:"comment,omitempty"`
+	Recommendation  *string      `json:"recommendation,omitempty"`
+	HealthGrade     *HealthGrade `gorm:"type:jsonb;serializer:json" json:"health_grade,omitempty"` // recomputed when the grading changes
+	CreatedAt       time.Time    `gorm:"autoCreateTime:milli" json:"-"`
+	UpdatedAt       time.Time    `gorm:"autoCreateTime:milli;autoUpdateTime:milli" json:"-"`
+}
+
+// NormalizePortion checks the portion of the meal and fills its grams when the unit is a mass, a meal may have no portion
+func (mealHistory *MealHistory) NormalizePortion() error {
+	if mealHistory.PortionQuantity == nil && mealHistory.PortionUnit == nil {
+		mealHistory.PortionGrams = nil
+		return nil
+	}
+	if mealHistory.PortionQuantity == nil || mealHistory.PortionUnit == nil {
+		return errors.New("portion_quantity and portion_unit must be given together")
+	}
+
+	unit, ok := FindPortionUnit(*mealHistory.PortionUnit)
+	if !ok {
+		return fmt.Errorf("unknown portion unit %q", *mealHistory.PortionUnit)
+	}
+	if *mealHistory.PortionQuantity <= 0 {
+		return errors.New("portion_quantity must be greater than zero")
+	}
+	if mealHistory.PortionGrams != nil && *mealHistory.PortionGrams <= 0 {
+		return errors.New("portion_grams must be greater than zero")
+	}
+	if unit.Kind == PortionKindMass {
+		grams := roundPortion(*mealHistory.PortionQuantity * unit.Size)
+		mealHistory.PortionGrams = &grams
+	}
+	return nil
 }
 
 func (mealHistory *MealHistory) BeforeCreate(_ *gorm.DB) error {
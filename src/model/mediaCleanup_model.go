@@ -0,0 +1,89 @@
+package model
+
+import (
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// What the janitor does with an orphaned media file
+const (
+	MediaCleanupArchive = "archive" // moved to the object storage bucket, its lifecycle rule expires it
+	MediaCleanupDelete  = "delete"
+)
+
+// MediaReference is a column holding addresses of files under /uploads, of the storage category they are
+// reported in
+type MediaReference struct {
+	Table    string
+	Column   string
+	Category string
+}
+
+// MediaReferences are the columns a file under /uploads is kept for, archived records included
+var MediaReferences = []MediaReference{
+	{Table: "meal_histories", Column: "meal_image", Category: StorageScanImages},
+	{Table: "users", Column: "profile_picture", Category: StorageAvatars},
+	{Table: "articles", Column: "image", Category: StorageContentImages},
+	{Table: "recipes", Column: "image", Category: StorageContentImages},
+	{Table: "payment_proofs", Column: "image_url", Category: StoragePaymentProofs},
+	{Table: TransactionDetailsTable, Column: "proof_image_url", Category: StoragePaymentProofs},
+	{Table: ArchiveTable(TransactionDetailsTable), Column: "proof_image_url", Category: StoragePaymentProofs},
+}
+
+// UploadPath is the path under /uploads of the file a stored address refers to, e.g. /uploads/scans/a.jpg for a
+// signed URL of it, false when the address is not one of an uploaded file. The host is not checked: keeping a
+// file referred to on another host is safer than removing one still in use.
+func UploadPath(ref string) (string, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil || parsed.Path == "" {
+		return "", false
+	}
+
+	cleaned := path.Clean("/" + strings.TrimLeft(parsed.Path, "/"))
+	if !strings.HasPrefix(cleaned, "/uploads/") {
+		return "", false
+	}
+	return cleaned, true
+}
+
+// IsOrphanedMedia reports whether the file at uploadPath, last modified at modTime, can be removed at now: no
+// record refers to it and it is older than grace, so an upload whose record is still being saved is kept
+func IsOrphanedMedia(uploadPath string, modTime time.Time, referenced map[string]bool, grace time.Duration, now time.Time) bool {
+	return !referenced[uploadPath] && !modTime.After(now.Add(-grace))
+}
+
+// MediaArchiveKey is the object an orphaned file is archived as, grouped by the day it was removed so a lifecycle
+// rule of the bucket can expire them
+func MediaArchiveKey(prefix, uploadPath string, now time.Time) string {
+	return prefix + now.UTC().Format("2006/01/02") + uploadPath
+}
+
+// MediaCleanupRun is the audit entry of the janitor removing orphaned media files, or of an admin previewing it.
+// Dry runs only count the orphaned files.
+type MediaCleanupRun struct {
+	ID             uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Action         string     `gorm:"size:10;not null" json:"action"` // archive or delete
+	DryRun         bool       `gorm:"not null" json:"dry_run"`
+	Referenced     int64      `gorm:"not null;default:0" json:"referenced"` // distinct files records refer to
+	Scanned        int64      `gorm:"not null;default:0" json:"scanned"`    // files found under /uploads
+	ScannedBytes   int64      `gorm:"not null;default:0" json:"scanned_bytes"`
+	Orphaned       int64      `gorm:"not null;default:0" json:"orphaned"`
+	OrphanedBytes  int64      `gorm:"not null;default:0" json:"orphaned_bytes"`
+	Removed        int64      `gorm:"not null;default:0" json:"removed"`         // archived or deleted files
+	BytesReclaimed int64      `gorm:"not null;default:0" json:"bytes_reclaimed"` // disk space the removed files took
+	Failed         int64      `gorm:"not null;default:0" json:"failed"`
+	Error          string     `gorm:"type:text" json:"error,omitempty"`              // the first failure
+	TriggeredByID  *uuid.UUID `gorm:"type:uuid;default:null" json:"triggered_by_id"` // nil for the daily job
+	StartedAt      time.Time  `gorm:"not null;index" json:"started_at"`
+	FinishedAt     time.Time  `gorm:"not null" json:"finished_at"`
+}
+
+func (run *MediaCleanupRun) BeforeCreate(_ *gorm.DB) error {
+	run.ID = uuid.New()
+	return nil
+}
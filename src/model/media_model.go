@@ -0,0 +1,11 @@
+package model
+
+// ScanImage is the stored address of a meal scan image, records keep it as stored and responses carry its signed
+// CDN URL, see middleware.SignMedia
+type ScanImage string
+
+// Avatar is the stored address of a profile picture. Pictures of identity providers are written out as they are.
+type Avatar string
+
+// ContentImage is the stored address of an article or a recipe image
+type ContentImage string
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Reasons a user reports another user for
+const (
+	ReportSpam          = "spam"
+	ReportHarassment    = "harassment"
+	ReportHateSpeech    = "hate_speech"
+	ReportInappropriate = "inappropriate_content"
+	ReportImpersonation = "impersonation"
+	ReportOther         = "other"
+)
+
+// Statuses of a report, every status but open is how a moderator resolved it
+const (
+	ReportOpen      = "open"
+	ReportDismissed = "dismissed"
+	ReportWarned    = "warned"
+	ReportSuspended = "suspended"
+)
+
+// UserBlock hides two users from each other on social features, whichever of them blocked the other
+type UserBlock struct {
+	BlockerID uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	BlockedID uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"-"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BlockedUser is a user the user blocked, as they see it
+type BlockedUser struct {
+	PublicProfile
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+// UserReport is a report of a user by another user, waiting in the moderation queue until it is resolved
+type UserReport struct {
+	ID             uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	ReporterID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"reporter_id"`
+	ReportedID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"reported_id"`
+	Reported       *User      `gorm:"foreignKey:ReportedID" json:"reported,omitempty"`
+	Reason         string     `gorm:"size:30;not null" json:"reason"`
+	Details        string     `gorm:"type:text" json:"details,omitempty"`
+	Status         string     `gorm:"size:20;not null;index" json:"status"`
+	Note           string     `gorm:"type:text" json:"note,omitempty"` // what the moderator told the reported user
+	ResolvedByID   *uuid.UUID `gorm:"type:uuid" json:"resolved_by_id,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"` // end of the suspension the report led to
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// SentReport is what the reporter is told of their report. It is the same whether the handle is someone's or not,
+// and whether they already had an open report of the user, so reports cannot probe handles.
+type SentReport struct {
+	Handle  string    `json:"handle"`
+	Reason  string    `json:"reason"`
+	Details string    `json:"details,omitempty"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+func (report *UserReport) BeforeCreate(_ *gorm.DB) error {
+	report.ID = uuid.New()
+	return nil
+}
+
+// IsSuspendedAt reports whether the account is suspended at now
+func (user *User) IsSuspendedAt(now time.Time) bool {
+	return user.SuspendedUntil != nil && now.Before(*user.SuspendedUntil)
+}
@@ -0,0 +1,220 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CurrencyIDR is the currency plans are priced and charged in
+const CurrencyIDR = "IDR"
+
+// currencyFormat is how amounts of a currency are written in the locale its users read
+type currencyFormat struct {
+	Symbol    string
+	Thousands string
+	Decimal   string
+	Digits    int // minor unit digits, amounts are stored in the minor unit
+}
+
+var currencyFormats = map[string]currencyFormat{
+	CurrencyIDR: {Symbol: "Rp ", Thousands: ".", Decimal: ",", Digits: 0},
+	"USD":       {Symbol: "$", Thousands: ",", Decimal: ".", Digits: 2},
+	"SGD":       {Symbol: "S$", Thousands: ",", Decimal: ".", Digits: 2},
+	"MYR":       {Symbol: "RM ", Thousands: ",", Decimal: ".", Digits: 2},
+}
+
+// Money is an amount in the minor unit of its currency. Rupiah has none in practice, so IDR amounts are whole Rupiah.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// IDR returns an amount of Rupiah
+func IDR(amount int) Money {
+	return Money{Amount: int64(amount), Currency: CurrencyIDR}
+}
+
+// MinorDigits is the number of decimals of the currency's minor unit as amounts store it, zero for Rupiah
+func MinorDigits(currency string) int {
+	return currencyFormats[currency].Digits
+}
+
+// ParseMoney reads a decimal amount such as the gross amount gateways send ("150000.00") without going through
+// floating point. Decimals beyond the minor unit are rounded half away from zero.
+func ParseMoney(value, currency string) (Money, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	whole, fraction, _ := strings.Cut(strings.TrimPrefix(value, "-"), ".")
+	if whole == "" && fraction == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+
+	digits := MinorDigits(currency)
+	roundUp := false
+	if len(fraction) > digits {
+		roundUp = fraction[digits] >= '5'
+		fraction = fraction[:digits]
+	}
+	fraction += strings.Repeat("0", digits-len(fraction))
+
+	amount, err := strconv.ParseInt("0"+whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+	if roundUp {
+		amount++
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Decimal writes the amount in major units with a dot, as gateways expect it ("150000", "15.00")
+func (m Money) Decimal() string {
+	digits := MinorDigits(m.Currency)
+	if digits == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	text := fmt.Sprintf("%0*d", digits+1, amount)
+	return sign + text[:len(text)-digits] + "." + text[len(text)-digits:]
+}
+
+// Int is the amount in minor units for the int columns that store it
+func (m Money) Int() int {
+	return int(m.Amount)
+}
+
+// Add adds other, both amounts must share a currency, callers check it where currencies can differ
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}
+}
+
+// Sub subtracts other, in the same currency
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}
+}
+
+// Percent returns percent of the amount, rounded half away from zero to the minor unit
+func (m Money) Percent(percent int) Money {
+	return Money{Amount: divRound(m.Amount*int64(percent), 100), Currency: m.Currency}
+}
+
+// Split divides the amount into n parts that add up to it exactly, the remainder goes to the first part
+func (m Money) Split(n int) []Money {
+	weights := make([]int64, max(n, 1))
+	for i := range weights {
+		weights[i] = 1
+	}
+	return m.Allocate(weights)
+}
+
+// Allocate divides the amount in proportion to weights, the parts add up to it exactly and the remainder
+// goes to the first part. Without a positive weight everything goes to the first part.
+func (m Money) Allocate(weights []int64) []Money {
+	parts := make([]Money, len(weights))
+	if len(parts) == 0 {
+		return parts
+	}
+
+	var total int64
+	for _, weight := range weights {
+		total += weight
+	}
+
+	allocated := int64(0)
+	for i, weight := range weights {
+		parts[i].Currency = m.Currency
+		if total > 0 {
+			parts[i].Amount = m.Amount * weight / total
+		}
+		allocated += parts[i].Amount
+	}
+	parts[0].Amount += m.Amount - allocated
+	return parts
+}
+
+// divRound divides rounding half away from zero
+func divRound(a, b int64) int64 {
+	if (a < 0) != (b < 0) {
+		return (a - b/2) / b
+	}
+	return (a + b/2) / b
+}
+
+// String formats the amount for people, e.g. Rp 150.000 or $1,500.00. Currencies without a known format
+// are written with their code and international separators.
+func (m Money) String() string {
+	return m.format(m.currencyFormat())
+}
+
+// Localized writes the amount with the separators readers of lang expect, "Rp 49,000" in English. Languages
+// without separators of their own get the ones of the currency.
+func (m Money) Localized(lang string) string {
+	format := m.currencyFormat()
+	if separators, ok := languageSeparators[lang]; ok {
+		format.Thousands, format.Decimal = separators[0], separators[1]
+	}
+	return m.format(format)
+}
+
+// languageSeparators are the thousands and decimal separators of each language
+var languageSeparators = map[string][2]string{
+	"id": {".", ","},
+	"en": {",", "."},
+}
+
+func (m Money) currencyFormat() currencyFormat {
+	format, ok := currencyFormats[m.Currency]
+	if !ok {
+		format = currencyFormat{Symbol: m.Currency + " ", Thousands: ",", Decimal: "."}
+	}
+	return format
+}
+
+func (m Money) format(format currencyFormat) string {
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	fraction := ""
+	if format.Digits > 0 {
+		if len(digits) <= format.Digits {
+			digits = strings.Repeat("0", format.Digits-len(digits)+1) + digits
+		}
+		fraction = format.Decimal + digits[len(digits)-format.Digits:]
+		digits = digits[:len(digits)-format.Digits]
+	}
+
+	return sign + format.Symbol + groupThousands(digits, format.Thousands) + fraction
+}
+
+// groupThousands inserts separator between every group of three digits
+func groupThousands(digits, separator string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(separator)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"app/src/messaging"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Categories of the notifications sent to users
+const (
+	NotificationAccount          = "account"
+	NotificationBilling          = "billing"
+	NotificationPaymentReminders = "payment_reminders"
+	NotificationMarketing        = "marketing"
+	NotificationDailyTips        = "daily_tips"
+)
+
+// Ways a user changes a preference
+const (
+	PreferenceSourceCenter      = "preference_center"
+	PreferenceSourceUnsubscribe = "unsubscribe_link"
+)
+
+// Channels notifications are sent through. The phone channels need a verified phone.
+const (
+	NotificationChannelEmail    = "email"
+	NotificationChannelSMS      = messaging.ChannelSMS
+	NotificationChannelWhatsApp = messaging.ChannelWhatsApp
+)
+
+// allChannels can carry any notification, the account emails are about the email address itself
+var allChannels = []string{NotificationChannelEmail, NotificationChannelSMS, NotificationChannelWhatsApp}
+
+// NotificationCategory groups notifications users subscribe to together. Required categories are
+// transactional: they are always sent and cannot be unsubscribed from.
+type NotificationCategory struct {
+	Key         string   `json:"key"`
+	Description string   `json:"description"`
+	Required    bool     `json:"required"`
+	Channels    []string `json:"channels"` // the channels users can pick, email first
+}
+
+var NotificationCategories = []NotificationCategory{
+	{Key: NotificationAccount, Description: "Password resets and email verification", Required: true,
+		Channels: []string{NotificationChannelEmail}},
+	{Key: NotificationBilling, Description: "Receipts, installment bills and payment proof results", Required: true,
+		Channels: allChannels},
+	{Key: NotificationPaymentReminders, Description: "Reminders to finish a pending payment", Channels: allChannels},
+	{Key: NotificationMarketing, Description: "Promotions and product news", Channels: allChannels},
+	{Key: NotificationDailyTips, Description: "A daily tip based on your food diary", Channels: allChannels},
+}
+
+// LookupNotificationCategory returns the category with key
+func LookupNotificationCategory(key string) (NotificationCategory, bool) {
+	for _, category := range NotificationCategories {
+		if category.Key == key {
+			return category, true
+		}
+	}
+	return NotificationCategory{}, false
+}
+
+// AllowsChannel reports whether notifications of the category can go through channel
+func (category NotificationCategory) AllowsChannel(channel string) bool {
+	for _, allowed := range category.Channels {
+		if allowed == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationPreference is the choice of a user for a category. Users without one are subscribed by email.
+type NotificationPreference struct {
+	UserID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	Category   string    `gorm:"size:30;primaryKey" json:"category"`
+	Subscribed bool      `gorm:"not null" json:"subscribed"`
+	Channel    string    `gorm:"size:20;not null;default:email" json:"channel"`
+	Source     string    `gorm:"size:30;not null" json:"source"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// NotificationPreferenceView is a category with whether the user receives it and through which channel
+type NotificationPreferenceView struct {
+	NotificationCategory
+	Subscribed bool       `json:"subscribed"`
+	Channel    string     `json:"channel"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+}
+
+// PreferenceViews lists every category with the choices of the user, required categories are always subscribed
+func PreferenceViews(preferences []NotificationPreference) []NotificationPreferenceView {
+	chosen := make(map[string]NotificationPreference, len(preferences))
+	for _, preference := range preferences {
+		chosen[preference.Category] = preference
+	}
+
+	views := make([]NotificationPreferenceView, len(NotificationCategories))
+	for i, category := range NotificationCategories {
+		views[i] = NotificationPreferenceView{NotificationCategory: category, Subscribed: true, Channel: NotificationChannelEmail}
+		if preference, ok := chosen[category.Key]; ok {
+			updatedAt := preference.UpdatedAt
+			views[i].UpdatedAt = &updatedAt
+			if !category.Required {
+				views[i].Subscribed = preference.Subscribed
+			}
+			if category.AllowsChannel(preference.Channel) {
+				views[i].Channel = preference.Channel
+			}
+		}
+	}
+	return views
+}
+
+// NotificationRecipient is who a notification goes to and how
+type NotificationRecipient struct {
+	UserID     *uuid.UUID // nil for addresses that are not a user's
+	Subscribed bool
+	Channel    string
+	Phone      string // the verified phone of the user, for the phone channels
+}
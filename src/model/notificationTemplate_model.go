@@ -0,0 +1,341 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Keys of the notifications whose copy can be edited, each one is sent by the email service
+const (
+	TemplateResetPassword    = "reset_password"
+	TemplateVerifyEmail      = "verify_email"
+	TemplatePaymentApproved  = "payment_approved"
+	TemplatePaymentRejected  = "payment_rejected"
+	TemplatePaymentReminder  = "payment_reminder"
+	TemplateReceipt          = "receipt"
+	TemplateInstallmentBill  = "installment_bill"
+	TemplateParentalConsent  = "parental_consent"
+	TemplateDailyTip         = "daily_tip"
+	TemplateRenewalFailed    = "renewal_failed"
+	TemplatePlanSunset       = "plan_sunset"
+	TemplateTrialEnded       = "trial_ended"
+	TemplateAccountWarning   = "account_warning"
+	TemplateAccountSuspended = "account_suspended"
+	TemplateMagicLink        = "magic_link"
+)
+
+// DefaultTemplateLocale is the locale notifications are sent in
+const DefaultTemplateLocale = "id"
+
+// TemplateDefinition is the built-in copy of a notification, used until a template is saved for it
+type TemplateDefinition struct {
+	Category string // the notification category users subscribe to
+	Subject  string
+	Body     string
+	// Variables the sender fills, with a sample value for previews
+	Variables map[string]string
+}
+
+// VariableNames returns the variables of the definition in alphabetical order
+func (definition TemplateDefinition) VariableNames() []string {
+	names := make([]string, 0, len(definition.Variables))
+	for name := range definition.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Data sets every variable of the definition from variables, so templates only fail on variables it does not have
+func (definition TemplateDefinition) Data(variables map[string]string) map[string]string {
+	data := make(map[string]string, len(definition.Variables))
+	for name := range definition.Variables {
+		data[name] = variables[name]
+	}
+	return data
+}
+
+var TemplateDefinitions = map[string]TemplateDefinition{
+	TemplateResetPassword: {
+		Category: NotificationAccount,
+		Subject:  "Reset password",
+		Body: `Dear user,
+
+To reset your password, click on this link: {{.reset_password_url}}
+
+If you did not request any password resets, then ignore this email.`,
+		Variables: map[string]string{"reset_password_url": "https://nutribox.id/reset-password?token=example"},
+	},
+	TemplateVerifyEmail: {
+		Category: NotificationAccount,
+		Subject:  "Email Verification",
+		Body: `Pengguna yang terhormat,
+
+Silakan klik tautan di bawah ini untuk memverifikasi alamat email Anda:
+{{.verification_url}}
+
+Apabila Anda tidak merasa membuat akun dengan email ini, mohon abaikan pesan ini.`,
+		Variables: map[string]string{"verification_url": "https://nutribox.id/verify-email?token=example"},
+	},
+	TemplatePaymentApproved: {
+		Category: NotificationBilling,
+		Subject:  "Pembayaran Anda telah diverifikasi",
+		Body: `Pengguna yang terhormat,
+
+Bukti transfer Anda telah kami verifikasi. Langganan {{.plan_name}} Anda sekarang aktif hingga {{.end_date}}.
+
+Terima kasih telah menggunakan Nutribox.`,
+		Variables: map[string]string{"plan_name": "Premium", "end_date": "31 December 2026"},
+	},
+	TemplatePaymentRejected: {
+		Category: NotificationBilling,
+		Subject:  "Bukti transfer Anda ditolak",
+		Body: `Pengguna yang terhormat,
+
+Mohon maaf, bukti transfer yang Anda unggah tidak dapat kami verifikasi.
+Alasan: {{.reason}}
+
+Silakan unggah ulang bukti transfer yang valid melalui aplikasi{{if .retry_link}}: {{.retry_link}}{{else}}.{{end}}`,
+		Variables: map[string]string{
+			"reason":     "Nominal transfer tidak sesuai",
+			"retry_link": "https://api.nutribox.id/v1/links/example",
+		},
+	},
+	TemplatePaymentReminder: {
+		Category: NotificationPaymentReminders,
+		Subject:  "Pengingat pembayaran langganan",
+		Body: `Pengguna yang terhormat,
+
+Pembayaran langganan {{.plan_name}} Anda sebesar {{.amount}} belum kami terima.
+Silakan selesaikan pembayaran melalui tautan berikut: {{.payment_link}}
+
+Tautan ini berlaku hingga {{.expires_at}}.{{if .note}}
+
+{{.note}}{{end}}`,
+		Variables: map[string]string{
+			"plan_name":    "Premium",
+			"amount":       "Rp 150.000",
+			"payment_link": "https://app.midtrans.com/snap/v2/vtweb/example",
+			"expires_at":   "31 December 2026 23:59",
+			"note":         "",
+		},
+	},
+	TemplateReceipt: {
+		Category: NotificationBilling,
+		Subject:  "Bukti pembayaran Nutribox {{.order_id}}",
+		Body: `Pengguna yang terhormat,
+
+Terima kasih atas pembayaran Anda. Berikut rincian transaksi Anda:
+
+Nomor pesanan: {{.order_id}}
+Langganan: {{.plan_name}}
+Jumlah: {{.amount}}
+Tanggal pembayaran: {{.paid_at}}
+
+Simpan email ini sebagai bukti pembayaran.`,
+		Variables: map[string]string{
+			"order_id":  "ORDER-1700000000",
+			"plan_name": "Premium",
+			"amount":    "Rp 150.000",
+			"paid_at":   "31 December 2026 10:00",
+		},
+	},
+	TemplateInstallmentBill: {
+		Category: NotificationBilling,
+		Subject:  "Tagihan cicilan langganan",
+		Body: `Pengguna yang terhormat,
+
+Cicilan ke-{{.installment_number}} langganan Anda sebesar {{.amount}} jatuh tempo pada {{.due_date}}.
+Silakan lakukan pembayaran melalui tautan berikut: {{.payment_link}}
+
+Akses premium akan ditangguhkan jika cicilan belum dibayar {{.grace_days}} hari setelah jatuh tempo.`,
+		Variables: map[string]string{
+			"installment_number": "2",
+			"amount":             "Rp 50.000",
+			"due_date":           "31 December 2026",
+			"payment_link":       "https://app.midtrans.com/snap/v2/vtweb/example",
+			"grace_days":         "7",
+		},
+	},
+	TemplateRenewalFailed: {
+		Category: NotificationBilling,
+		Subject:  "Perpanjangan otomatis langganan gagal",
+		Body: `Pengguna yang terhormat,
+
+Kami tidak berhasil memperpanjang langganan {{.plan_name}} Anda dengan kartu {{.masked_card}} sebesar {{.amount}}.
+Perpanjangan otomatis telah kami nonaktifkan dan langganan Anda berakhir pada {{.end_date}}.
+
+Anda dapat memperpanjang langganan kapan saja melalui aplikasi Nutribox.`,
+		Variables: map[string]string{
+			"plan_name":   "Premium",
+			"masked_card": "481111-1114",
+			"amount":      "Rp 150.000",
+			"end_date":    "31 December 2026",
+		},
+	},
+	TemplatePlanSunset: {
+		Category: NotificationBilling,
+		Subject:  "Paket langganan Anda akan diganti",
+		Body: `Pengguna yang terhormat,
+
+Paket {{.plan_name}} tidak lagi tersedia. Langganan Anda tetap berlaku seperti biasa hingga {{.end_date}}.
+Saat diperpanjang, langganan Anda akan dipindahkan ke paket {{.replacement_name}} seharga {{.replacement_price}}.
+
+{{.renewal_note}}`,
+		Variables: map[string]string{
+			"plan_name":         "Premium Lama",
+			"replacement_name":  "Premium",
+			"replacement_price": "Rp 150.000",
+			"end_date":          "31 December 2026",
+			"renewal_note":      "Perpanjangan otomatis Anda akan menagih kartu Anda sebesar harga paket baru.",
+		},
+	},
+	TemplateTrialEnded: {
+		Category: NotificationBilling,
+		Subject:  "Masa uji coba gratis Anda telah berakhir",
+		Body: `Pengguna yang terhormat,
+
+Masa uji coba gratis paket {{.plan_name}} Anda telah berakhir pada {{.end_date}}.
+Lanjutkan menikmati seluruh fitur paket {{.plan_name}} seharga {{.price}} melalui tautan berikut:
+{{.plans_url}}`,
+		Variables: map[string]string{
+			"plan_name": "Premium",
+			"price":     "Rp 150.000",
+			"end_date":  "31 December 2026",
+			"plans_url": "https://nutribox.id/plans",
+		},
+	},
+	TemplateAccountWarning: {
+		Category: NotificationAccount,
+		Subject:  "Peringatan untuk akun Nutribox Anda",
+		Body: `Pengguna yang terhormat,
+
+Kami menerima laporan dari pengguna lain tentang akun Anda dan meninjaunya.
+{{.note}}
+
+Mohon patuhi pedoman komunitas Nutribox. Pelanggaran berikutnya dapat membuat akun Anda ditangguhkan.`,
+		Variables: map[string]string{
+			"note": "Nama tampilan Anda mengandung kata yang menyinggung.",
+		},
+	},
+	TemplateAccountSuspended: {
+		Category: NotificationAccount,
+		Subject:  "Akun Nutribox Anda ditangguhkan",
+		Body: `Pengguna yang terhormat,
+
+Setelah meninjau laporan dari pengguna lain, akun Anda ditangguhkan hingga {{.suspended_until}}.
+{{.note}}
+
+Selama masa penangguhan Anda tidak dapat masuk ke aplikasi. Langganan Anda tetap berjalan.`,
+		Variables: map[string]string{
+			"note":            "Anda berulang kali mengirim pesan yang melecehkan pengguna lain.",
+			"suspended_until": "31 December 2026 15:04",
+		},
+	},
+	TemplateMagicLink: {
+		Category: NotificationAccount,
+		Subject:  "Tautan masuk Nutribox",
+		Body: `Pengguna yang terhormat,
+
+Klik tautan berikut untuk masuk ke Nutribox tanpa kata sandi. Tautan ini hanya berlaku sekali,
+hingga {{.expires_at}}, dan hanya di perangkat tempat Anda memintanya:
+{{.login_url}}
+
+Apabila Anda tidak meminta tautan ini, mohon abaikan pesan ini. Akun Anda tetap aman.`,
+		Variables: map[string]string{
+			"login_url":  "https://nutribox.id/magic-link?token=example",
+			"expires_at": "31 December 2026 15:04",
+		},
+	},
+	TemplateParentalConsent: {
+		Category: NotificationAccount,
+		Subject:  "Persetujuan orang tua untuk akun Nutribox",
+		Body: `Bapak/Ibu yang terhormat,
+
+{{.child_name}} mendaftar di Nutribox dan mencantumkan alamat email ini sebagai orang tua atau wali.
+Karena {{.child_name}} belum cukup umur, kami memerlukan persetujuan Anda sebelum memproses foto makanan
+dan data kesehatannya serta sebelum pembelian langganan dapat dilakukan.
+
+Silakan baca dan berikan jawaban Anda melalui tautan berikut sebelum {{.expires_at}}:
+{{.consent_url}}
+
+Apabila Anda tidak mengenal {{.child_name}}, mohon abaikan pesan ini.`,
+		Variables: map[string]string{
+			"child_name":  "Budi",
+			"consent_url": "https://nutribox.id/parental-consent?token=example",
+			"expires_at":  "31 December 2026 15:04",
+		},
+	},
+	TemplateDailyTip: {
+		Category: NotificationDailyTips,
+		Subject:  "{{.title}}",
+		Body: `{{.message}}
+
+Lihat ringkasan gizi Anda di aplikasi Nutribox.`,
+		Variables: map[string]string{
+			"title":   "Protein Anda masih kurang",
+			"message": "Asupan protein Anda kurang dari 50 g pada 4 dari 7 hari terakhir.",
+		},
+	},
+}
+
+// NotificationTemplate is a version of the copy of a notification in a locale. Edits save a new version,
+// the highest version is the one sent.
+type NotificationTemplate struct {
+	ID          uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Key         string    `gorm:"size:50;not null;uniqueIndex:idx_notification_template_version" json:"key"`
+	Locale      string    `gorm:"size:10;not null;uniqueIndex:idx_notification_template_version" json:"locale"`
+	Version     int       `gorm:"not null;uniqueIndex:idx_notification_template_version" json:"version"`
+	Subject     string    `gorm:"size:255;not null" json:"subject"`
+	Body        string    `gorm:"type:text;not null" json:"body"`
+	Variables   []string  `gorm:"type:jsonb;serializer:json" json:"variables"`
+	CreatedByID uuid.UUID `gorm:"not null" json:"created_by_id"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (notificationTemplate *NotificationTemplate) BeforeCreate(_ *gorm.DB) error {
+	notificationTemplate.ID = uuid.New()
+	return nil
+}
+
+// RenderedNotification is the subject and body of a notification after its variables are filled
+type RenderedNotification struct {
+	Key     string `json:"key"`
+	Locale  string `json:"locale"`
+	Version int    `json:"version"` // 0 for the built-in copy
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// RenderTemplate fills the variables of a subject and body written with Go template syntax ({{.plan_name}}).
+// Referencing a variable the notification does not have is an error.
+func RenderTemplate(subject, body string, variables map[string]string) (string, string, error) {
+	renderedSubject, err := renderText("subject", subject, variables)
+	if err != nil {
+		return "", "", err
+	}
+	renderedBody, err := renderText("body", body, variables)
+	if err != nil {
+		return "", "", err
+	}
+	return renderedSubject, renderedBody, nil
+}
+
+func renderText(name, text string, variables map[string]string) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, variables); err != nil {
+		return "", fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DailyNutritionSummary is the nutrition total of one user on one day, refreshed on every meal write
+// so reports read one row per day instead of aggregating the meal history
+type DailyNutritionSummary struct {
+	UserID    uuid.UUID `gorm:"primaryKey" json:"-"`
+	Date      time.Time `gorm:"primaryKey;type:date" json:"date"`
+	Calories  float64   `gorm:"type:decimal(10,2);not null;default:0" json:"calories"`
+	Protein   float64   `gorm:"type:decimal(10,2);not null;default:0" json:"protein"`
+	Carbs     float64   `gorm:"type:decimal(10,2);not null;default:0" json:"carbs"`
+	Fat       float64   `gorm:"type:decimal(10,2);not null;default:0" json:"fat"`
+	MealCount int       `gorm:"not null;default:0" json:"meal_count"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"-"`
+}
+
+// NutritionAmounts is a nutrition total or average over a report range
+type NutritionAmounts struct {
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"`
+	Carbs    float64 `json:"carbs"`
+	Fat      float64 `json:"fat"`
+}
+
+// NutritionReport is a user's nutrition per day over a date range, days without meals are zero
+type NutritionReport struct {
+	From         string                  `json:"from"`
+	To           string                  `json:"to"`
+	Days         []DailyNutritionSummary `json:"days"`
+	LoggedDays   int                     `json:"logged_days"`
+	TotalMeals   int                     `json:"total_meals"`
+	Total        NutritionAmounts        `json:"total"`
+	DailyAverage NutritionAmounts        `json:"daily_average"` // over the logged days only
+}
+
+// BuildNutritionReport lays the summaries out on every day from from to to, both inclusive
+func BuildNutritionReport(summaries []DailyNutritionSummary, from, to time.Time) *NutritionReport {
+	byDay := make(map[string]DailyNutritionSummary, len(summaries))
+	for _, summary := range summaries {
+		byDay[summary.Date.Format("2006-01-02")] = summary
+	}
+
+	report := &NutritionReport{
+		From: from.Format("2006-01-02"),
+		To:   to.Format("2006-01-02"),
+		Days: []DailyNutritionSummary{},
+	}
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		summary, ok := byDay[day.Format("2006-01-02")]
+		if !ok {
+			summary = DailyNutritionSummary{}
+		}
+		summary.Date = day
+		report.Days = append(report.Days, summary)
+
+		if summary.MealCount == 0 {
+			continue
+		}
+		report.LoggedDays++
+		report.TotalMeals += summary.MealCount
+		report.Total.Calories += summary.Calories
+		report.Total.Protein += summary.Protein
+		report.Total.Carbs += summary.Carbs
+		report.Total.Fat += summary.Fat
+	}
+
+	if report.LoggedDays > 0 {
+		days := float64(report.LoggedDays)
+		report.DailyAverage = NutritionAmounts{
+			Calories: roundNutrition(report.Total.Calories / days),
+			Protein:  roundNutrition(report.Total.Protein / days),
+			Carbs:    roundNutrition(report.Total.Carbs / days),
+			Fat:      roundNutrition(report.Total.Fat / days),
+		}
+	}
+
+	return report
+}
+
+func roundNutrition(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,226 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Steps of the onboarding checklist, in the order the app shows them
+const (
+	OnboardingProfileCompleted       = "profile_completed"
+	OnboardingFirstScan              = "first_scan"
+	OnboardingFirstLog               = "first_log"
+	OnboardingNotificationPermission = "notification_permission"
+)
+
+var OnboardingSteps = []string{
+	OnboardingProfileCompleted,
+	OnboardingFirstScan,
+	OnboardingFirstLog,
+	OnboardingNotificationPermission,
+}
+
+// Onboarding statuses, a user moves from not_started to in_progress with their first step
+// and to completed with their last one. Steps are never undone.
+const (
+	OnboardingNotStarted = "not_started"
+	OnboardingInProgress = "in_progress"
+	OnboardingCompleted  = "completed"
+)
+
+// OnboardingEventTypes are the domain events that complete onboarding steps
+var OnboardingEventTypes = []string{EventMealLogged, EventMealScanAdded, EventProfileCompleted, EventNotificationPermission}
+
+// UserOnboarding is when a user first completed each onboarding step, derived from their events
+type UserOnboarding struct {
+	UserID                   uuid.UUID  `gorm:"type:uuid;primaryKey" json:"user_id"`
+	ProfileCompletedAt       *time.Time `gorm:"default:null" json:"profile_completed_at"`
+	FirstScanAt              *time.Time `gorm:"default:null" json:"first_scan_at"`
+	FirstLogAt               *time.Time `gorm:"default:null" json:"first_log_at"`
+	NotificationPermissionAt *time.Time `gorm:"default:null" json:"notification_permission_at"`
+	CompletedAt              *time.Time `gorm:"default:null;index" json:"completed_at"`
+	UpdatedAt                time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// StepCompletedAt returns when the user completed step, nil while it is open
+func (onboarding *UserOnboarding) StepCompletedAt(step string) *time.Time {
+	if field := onboarding.stepField(step); field != nil {
+		return *field
+	}
+	return nil
+}
+
+func (onboarding *UserOnboarding) stepField(step string) **time.Time {
+	switch step {
+	case OnboardingProfileCompleted:
+		return &onboarding.ProfileCompletedAt
+	case OnboardingFirstScan:
+		return &onboarding.FirstScanAt
+	case OnboardingFirstLog:
+		return &onboarding.FirstLogAt
+	case OnboardingNotificationPermission:
+		return &onboarding.NotificationPermissionAt
+	}
+	return nil
+}
+
+// Complete marks step as done at, the first time only. It reports whether the step was open.
+func (onboarding *UserOnboarding) Complete(step string, at time.Time) bool {
+	field := onboarding.stepField(step)
+	if field == nil || *field != nil {
+		return false
+	}
+	*field = &at
+
+	if onboarding.CompletedAt == nil && onboarding.Status() == OnboardingCompleted {
+		onboarding.CompletedAt = &at
+	}
+	return true
+}
+
+// Apply completes the steps an event stands for. A scanned meal is logged as well, so it completes both
+// first_scan and first_log. It reports whether a step was completed.
+func (onboarding *UserOnboarding) Apply(event *DomainEvent) (bool, error) {
+	var payload EventPayload
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+		return false, err
+	}
+
+	switch event.Type {
+	case EventMealLogged:
+		logged := onboarding.Complete(OnboardingFirstLog, event.OccurredAt)
+		if payload.Scans > 0 {
+			return onboarding.Complete(OnboardingFirstScan, event.OccurredAt) || logged, nil
+		}
+		return logged, nil
+	case EventMealScanAdded:
+		return onboarding.Complete(OnboardingFirstScan, event.OccurredAt), nil
+	case EventProfileCompleted:
+		return onboarding.Complete(OnboardingProfileCompleted, event.OccurredAt), nil
+	case EventNotificationPermission:
+		if payload.Granted {
+			return onboarding.Complete(OnboardingNotificationPermission, event.OccurredAt), nil
+		}
+	}
+	return false, nil
+}
+
+// BuildUserOnboarding derives the onboarding of a user from their events in sequence order. Profiles completed
+// before profile_completed events were recorded count from the last change of the user.
+func BuildUserOnboarding(user *User, events []DomainEvent) (*UserOnboarding, error) {
+	onboarding := &UserOnboarding{UserID: user.ID}
+	for i := range events {
+		if _, err := onboarding.Apply(&events[i]); err != nil {
+			return nil, err
+		}
+	}
+	if user.ProfileComplete() {
+		onboarding.Complete(OnboardingProfileCompleted, user.UpdatedAt)
+	}
+	return onboarding, nil
+}
+
+func (onboarding *UserOnboarding) Status() string {
+	done := 0
+	for _, step := range OnboardingSteps {
+		if onboarding.StepCompletedAt(step) != nil {
+			done++
+		}
+	}
+
+	switch done {
+	case 0:
+		return OnboardingNotStarted
+	case len(OnboardingSteps):
+		return OnboardingCompleted
+	default:
+		return OnboardingInProgress
+	}
+}
+
+// OnboardingChecklist is the onboarding progress of a user as the app shows it
+type OnboardingChecklist struct {
+	Status         string               `json:"status"`
+	CompletedSteps int                  `json:"completed_steps"`
+	TotalSteps     int                  `json:"total_steps"`
+	NextStep       string               `json:"next_step,omitempty"` // first open step
+	CompletedAt    *time.Time           `json:"completed_at"`
+	Steps          []OnboardingStepView `json:"steps"`
+}
+
+type OnboardingStepView struct {
+	Step        string     `json:"step"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+func (onboarding *UserOnboarding) Checklist() OnboardingChecklist {
+	checklist := OnboardingChecklist{
+		Status:      onboarding.Status(),
+		TotalSteps:  len(OnboardingSteps),
+		CompletedAt: onboarding.CompletedAt,
+		Steps:       make([]OnboardingStepView, len(OnboardingSteps)),
+	}
+
+	for i, step := range OnboardingSteps {
+		completedAt := onboarding.StepCompletedAt(step)
+		checklist.Steps[i] = OnboardingStepView{Step: step, Completed: completedAt != nil, CompletedAt: completedAt}
+		if completedAt != nil {
+			checklist.CompletedSteps++
+		} else if checklist.NextStep == "" {
+			checklist.NextStep = step
+		}
+	}
+	return checklist
+}
+
+// OnboardingStepColumns are the columns of user_onboardings holding when each step was completed
+var OnboardingStepColumns = map[string]string{
+	OnboardingProfileCompleted:       "profile_completed_at",
+	OnboardingFirstScan:              "first_scan_at",
+	OnboardingFirstLog:               "first_log_at",
+	OnboardingNotificationPermission: "notification_permission_at",
+}
+
+// OnboardingFunnel follows the users who signed up in a period through the onboarding steps
+type OnboardingFunnel struct {
+	From      string                 `json:"from"`
+	To        string                 `json:"to"`
+	SignedUp  int64                  `json:"signed_up"`
+	Completed int64                  `json:"completed"`
+	Steps     []OnboardingFunnelStep `json:"steps"`
+}
+
+type OnboardingFunnelStep struct {
+	Step string `json:"step"`
+	// Users who completed the step, in any order
+	Users int64 `json:"users"`
+	// Users who completed the step and every step before it, and those the previous step lost
+	Reached int64   `json:"reached"`
+	DropOff int64   `json:"drop_off"`
+	Rate    float64 `json:"rate"` // reached over signed up
+}
+
+// NewOnboardingFunnel builds the funnel from the users of the cohort who completed each step
+// and who reached it, having completed every step before it too
+func NewOnboardingFunnel(from, to string, signedUp, completed int64, users, reached map[string]int64) *OnboardingFunnel {
+	funnel := &OnboardingFunnel{From: from, To: to, SignedUp: signedUp, Completed: completed}
+
+	previous := signedUp
+	for _, step := range OnboardingSteps {
+		stat := OnboardingFunnelStep{
+			Step:    step,
+			Users:   users[step],
+			Reached: reached[step],
+			DropOff: previous - reached[step],
+		}
+		if signedUp > 0 {
+			stat.Rate = float64(stat.Reached) / float64(signedUp)
+		}
+		funnel.Steps = append(funnel.Steps, stat)
+		previous = stat.Reached
+	}
+	return funnel
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Chat platforms the ops bot listens on
+const (
+	OpsPlatformSlack    = "slack"
+	OpsPlatformTelegram = "telegram"
+)
+
+// OpsBotIdentity links a Slack or Telegram account to an admin, commands run with the rights of that admin
+type OpsBotIdentity struct {
+	ID         uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID     uuid.UUID `gorm:"not null;index" json:"user_id"`
+	User       *User     `gorm:"foreignKey:UserID" json:"-"`
+	Platform   string    `gorm:"size:20;not null;uniqueIndex:idx_ops_bot_identity" json:"platform"`
+	ExternalID string    `gorm:"size:100;not null;uniqueIndex:idx_ops_bot_identity" json:"external_id"` // Slack user ID or Telegram user ID
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (opsBotIdentity *OpsBotIdentity) BeforeCreate(_ *gorm.DB) error {
+	opsBotIdentity.ID = uuid.New()
+	return nil
+}
+
+// OpsBotLinkCode is a one-time code an admin sends to the bot to link their chat account
+type OpsBotLinkCode struct {
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DailyKPIs are the headline numbers of one day
+type DailyKPIs struct {
+	Date                string `json:"date"`
+	NewUsers            int64  `json:"new_users"`
+	MealScans           int64  `json:"meal_scans"`
+	PaidOrders          int64  `json:"paid_orders"`
+	Revenue             int64  `json:"revenue"`
+	FailedPayments      int64  `json:"failed_payments"`
+	ActiveSubscriptions int64  `json:"active_subscriptions"`
+}
+
+// Summary renders the KPIs as a chat message
+func (kpis *DailyKPIs) Summary() string {
+	return fmt.Sprintf(
+		"Nutribox KPIs for %s\n"+
+			"New users: %d\n"+
+			"Meal scans: %d\n"+
+			"Paid orders: %d (%s)\n"+
+			"Failed payments: %d\n"+
+			"Active subscriptions: %d",
+		kpis.Date, kpis.NewUsers, kpis.MealScans, kpis.PaidOrders, Money{Amount: kpis.Revenue, Currency: CurrencyIDR}, kpis.FailedPayments, kpis.ActiveSubscriptions,
+	)
+}
+
+// ParseOpsCommand splits a chat message into a lowercase command name and its arguments.
+// Telegram style "/kpi@NutriboxBot" and Slack style "kpi" both give "kpi".
+func ParseOpsCommand(text string) (string, []string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	name := strings.TrimPrefix(fields[0], "/")
+	if at := strings.Index(name, "@"); at >= 0 {
+		name = name[:at]
+	}
+
+	return strings.ToLower(name), fields[1:]
+}
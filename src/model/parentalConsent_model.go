@@ -0,0 +1,128 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Parental consent statuses of a user. Features that process the data of minors or charge them
+// are restricted while it is pending.
+const (
+	ParentalConsentNotRequired = "not_required"
+	ParentalConsentPending     = "pending"
+	ParentalConsentGranted     = "granted"
+)
+
+// Statuses of a consent request sent to a guardian
+const (
+	ConsentRequestPending    = "pending"
+	ConsentRequestGranted    = "granted"
+	ConsentRequestDeclined   = "declined"
+	ConsentRequestSuperseded = "superseded" // the user asked another guardian, or the same one again
+)
+
+// ParentalConsentTextVersion identifies ParentalConsentText, change it with the text
+const ParentalConsentTextVersion = "2026-10"
+
+// ParentalConsentText is what the guardian agrees to
+const ParentalConsentText = `Saya adalah orang tua atau wali sah dari anak yang mendaftar di Nutribox. Saya mengizinkan Nutribox ` +
+	`memproses data pribadi anak tersebut, termasuk data kesehatan, foto makanan yang dipindai, dan riwayat makan, ` +
+	`untuk menyediakan layanan pemantauan gizi, serta mengizinkan anak tersebut melakukan pembelian langganan. ` +
+	`Saya dapat menarik persetujuan ini kapan saja dengan menghubungi Nutribox.`
+
+// Age is the age in full years on now of someone born on birthDate
+func Age(birthDate, now time.Time) int {
+	age := now.Year() - birthDate.Year()
+	if now.Month() < birthDate.Month() || (now.Month() == birthDate.Month() && now.Day() < birthDate.Day()) {
+		age--
+	}
+	return age
+}
+
+// ParentalConsentFor is the consent status of a user with birthDate whose status was current.
+// Users without a birth date are not asked, consent given stays while the user is a minor.
+func ParentalConsentFor(current string, birthDate *time.Time, consentAge int, now time.Time) string {
+	if birthDate == nil || consentAge <= 0 || Age(*birthDate, now) >= consentAge {
+		return ParentalConsentNotRequired
+	}
+	if current == ParentalConsentGranted {
+		return ParentalConsentGranted
+	}
+	return ParentalConsentPending
+}
+
+// ParentalConsentOnSelfChange is the consent status of a user who changed their own birth date. Claiming an
+// older birth date does not lift a pending consent, it stays pending until a guardian or an admin decides.
+func ParentalConsentOnSelfChange(current string, birthDate *time.Time, consentAge int, now time.Time) string {
+	if current == ParentalConsentPending && consentAge > 0 {
+		return ParentalConsentPending
+	}
+	return ParentalConsentFor(current, birthDate, consentAge, now)
+}
+
+// ParentalConsentRequest is a request for consent emailed to a guardian, with the evidence of their answer
+type ParentalConsentRequest struct {
+	ID            uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	GuardianEmail string     `gorm:"size:100;not null" json:"guardian_email"`
+	Status        string     `gorm:"size:20;not null;default:pending" json:"status"`
+	ExpiresAt     time.Time  `gorm:"not null" json:"expires_at"`
+	GuardianName  string     `gorm:"size:100" json:"guardian_name,omitempty"`
+	RespondedAt   *time.Time `gorm:"default:null" json:"responded_at,omitempty"`
+	// The answer as the guardian gave it and its SHA-256, kept as the consent artifact
+	Artifact       string    `gorm:"type:jsonb;default:null" json:"-"`
+	ArtifactSHA256 string    `gorm:"size:64" json:"artifact_sha256,omitempty"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (request *ParentalConsentRequest) BeforeCreate(_ *gorm.DB) error {
+	request.ID = uuid.New()
+	return nil
+}
+
+// ParentalConsentArtifact records who answered a consent request, to what text, and from where
+type ParentalConsentArtifact struct {
+	RequestID      uuid.UUID `json:"request_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	ChildName      string    `json:"child_name"`
+	ChildBirthDate string    `json:"child_birth_date"` // 2006-01-02
+	GuardianName   string    `json:"guardian_name"`
+	GuardianEmail  string    `json:"guardian_email"`
+	Decision       string    `json:"decision"`
+	TextVersion    string    `json:"text_version"`
+	Text           string    `json:"text"`
+	IPAddress      string    `json:"ip_address"`
+	UserAgent      string    `json:"user_agent"`
+	RespondedAt    time.Time `json:"responded_at"`
+}
+
+// Seal serializes the artifact and returns it with its SHA-256, which proves it was not edited later
+func (artifact ParentalConsentArtifact) Seal() (data string, digest string, err error) {
+	encoded, err := json.Marshal(artifact)
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return string(encoded), hex.EncodeToString(sum[:]), nil
+}
+
+// ParentalConsentStatus is the consent status of a user with their latest request
+type ParentalConsentStatus struct {
+	Status        string                  `json:"status"`
+	ConsentAge    int                     `json:"consent_age"`
+	LatestRequest *ParentalConsentRequest `json:"latest_request,omitempty"`
+}
+
+// ParentalConsentPrompt is what the guardian sees before answering
+type ParentalConsentPrompt struct {
+	ChildName     string    `json:"child_name"`
+	GuardianEmail string    `json:"guardian_email"`
+	Text          string    `json:"text"`
+	TextVersion   string    `json:"text_version"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
@@ -0,0 +1,176 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Scopes of partner API keys, a key only reaches the routes of its scopes
+const (
+	PartnerScopeReadFoods        = "read:foods"
+	PartnerScopeWriteMembers     = "write:members"
+	PartnerScopeReadEntitlements = "read:entitlements"
+	PartnerScopeReadObservations = "read:observations"
+)
+
+// PartnerScope describes a scope. Premium scopes touch member data and need the partner to have accepted
+// the current partner terms.
+type PartnerScope struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+	Premium     bool   `json:"premium"`
+	// RateLimit is the default number of requests a key makes in the scope per minute
+	RateLimit int `json:"rate_limit"`
+}
+
+var PartnerScopes = []PartnerScope{
+	{Key: PartnerScopeReadFoods, Description: "Read the food composition table", RateLimit: 120},
+	{Key: PartnerScopeWriteMembers, Description: "Enroll and remove members of the partner", Premium: true, RateLimit: 30},
+	{Key: PartnerScopeReadEntitlements, Description: "Read the premium access of members of the partner", Premium: true, RateLimit: 60},
+	{Key: PartnerScopeReadObservations, Description: "Read the weights and nutrition intake of members who consented, as FHIR", Premium: true, RateLimit: 30},
+}
+
+// LookupPartnerScope returns the scope with key
+func LookupPartnerScope(key string) (PartnerScope, bool) {
+	for _, scope := range PartnerScopes {
+		if scope.Key == key {
+			return scope, true
+		}
+	}
+	return PartnerScope{}, false
+}
+
+// PartnerKeyPrefix starts every partner API key, so leaked keys are easy to spot
+const PartnerKeyPrefix = "nbx_"
+
+// HashPartnerKey is what is stored of a key, the key itself is only shown when it is created
+func HashPartnerKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// PartnerKey is an API key of a partner with the scopes it was granted
+type PartnerKey struct {
+	ID      uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Partner string    `gorm:"size:50;not null;index" json:"partner"`
+	Name    string    `gorm:"size:100" json:"name"`
+	// KeyPrefix is the start of the key, to tell keys apart without storing them
+	KeyPrefix  string         `gorm:"size:16;not null" json:"key_prefix"`
+	KeyHash    string         `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Scopes     []string       `gorm:"type:jsonb;serializer:json;not null" json:"scopes"`
+	RateLimits map[string]int `gorm:"type:jsonb;serializer:json" json:"rate_limits,omitempty"` // per minute, overriding the scope default
+	// The partner terms the partner accepted, premium scopes only work with the current version
+	TermsVersion    string     `gorm:"size:20" json:"terms_version,omitempty"`
+	TermsAcceptedAt *time.Time `gorm:"default:null" json:"terms_accepted_at,omitempty"`
+	CreatedByID     uuid.UUID  `gorm:"not null" json:"created_by_id"`
+	LastUsedAt      *time.Time `gorm:"default:null" json:"last_used_at,omitempty"`
+	RevokedAt       *time.Time `gorm:"default:null" json:"revoked_at,omitempty"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	// TierLimits are the rate limits of the tier of the partner, loaded when the key authenticates
+	TierLimits map[string]int `gorm:"-" json:"-"`
+}
+
+func (key *PartnerKey) BeforeCreate(_ *gorm.DB) error {
+	key.ID = uuid.New()
+	return nil
+}
+
+// HasScope reports whether the key was granted scope
+func (key *PartnerKey) HasScope(scope string) bool {
+	for _, granted := range key.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RateLimit is the number of requests the key makes in scope per minute: its own limit, or the one of the tier
+// of its partner, or the default of the scope
+func (key *PartnerKey) RateLimit(scope string) int {
+	if limit, ok := key.RateLimits[scope]; ok && limit > 0 {
+		return limit
+	}
+	if limit, ok := key.TierLimits[scope]; ok && limit > 0 {
+		return limit
+	}
+	definition, _ := LookupPartnerScope(scope)
+	return definition.RateLimit
+}
+
+// AcceptedTerms reports whether the partner accepted the terms of version
+func (key *PartnerKey) AcceptedTerms(version string) bool {
+	return key.TermsAcceptedAt != nil && key.TermsVersion == version
+}
+
+// CreatedPartnerKey is a new key with the key itself, which is not shown again
+type CreatedPartnerKey struct {
+	PartnerKey
+	Key string `json:"key"`
+}
+
+// PartnerKeyUsage counts the requests of a key in a scope on a day, for audits
+type PartnerKeyUsage struct {
+	KeyID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"key_id"`
+	Scope     string    `gorm:"size:30;primaryKey" json:"scope"`
+	Day       time.Time `gorm:"type:date;primaryKey" json:"day"`
+	Requests  int64     `gorm:"not null;default:0" json:"requests"`
+	Throttled int64     `gorm:"not null;default:0" json:"throttled"` // requests refused by the rate limit
+	Errors    int64     `gorm:"not null;default:0" json:"errors"`    // served requests answered 4xx or 5xx
+}
+
+// PartnerMember is a user a partner enrolled, partners only read the data of their members
+type PartnerMember struct {
+	Partner   string    `gorm:"size:50;primaryKey" json:"partner"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"user_id"`
+	KeyID     uuid.UUID `gorm:"type:uuid;not null" json:"key_id"` // the key that enrolled the member
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// PartnerEnrollmentCode is the code a user hands a partner so it can enroll them, partners cannot enroll users
+// without one. A user has one code per partner, asking again replaces it, and enrolling uses it up.
+type PartnerEnrollmentCode struct {
+	Partner   string    `gorm:"size:50;primaryKey" json:"partner"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"-"`
+	CodeHash  string    `gorm:"size:64;not null" json:"-"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	// Code is what the user hands the partner, only returned when the code is created
+	Code string `gorm:"-" json:"code,omitempty"`
+}
+
+// PartnerEntitlement is the premium access of a member, with only what a partner needs
+type PartnerEntitlement struct {
+	UserID   uuid.UUID       `json:"user_id"`
+	Premium  bool            `json:"premium"`
+	PlanName string          `json:"plan_name,omitempty"`
+	Features map[string]bool `json:"features,omitempty"`
+	EndsAt   *time.Time      `json:"ends_at,omitempty"`
+}
+
+// PartnerConsent is the consent of a member for the partner to read their health data. Members give it
+// themselves, a partner enrolling them is not enough, and take it back at any time.
+type PartnerConsent struct {
+	Partner   string     `gorm:"size:50;primaryKey" json:"partner"`
+	UserID    uuid.UUID  `gorm:"type:uuid;primaryKey;index" json:"-"`
+	GrantedAt time.Time  `gorm:"not null" json:"granted_at"`
+	RevokedAt *time.Time `gorm:"default:null" json:"revoked_at,omitempty"`
+	// Where the consent was given from, for audits
+	IPAddress string `gorm:"size:45" json:"-"`
+	UserAgent string `gorm:"size:255" json:"-"`
+}
+
+// Active reports whether the consent is given
+func (consent *PartnerConsent) Active() bool {
+	return consent.RevokedAt == nil
+}
+
+// PartnerMembership is a partner the user is a member of, with whether they consented to share their health data
+type PartnerMembership struct {
+	Partner    string          `json:"partner"`
+	EnrolledAt time.Time       `json:"enrolled_at"`
+	Consent    *PartnerConsent `json:"consent,omitempty"`
+}
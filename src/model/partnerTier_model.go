@@ -0,0 +1,80 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PartnerTier is a commercial tier of the partner API: the rate limits its partners get and the requests their
+// plan includes each month, requests over it are billed as overage
+type PartnerTier struct {
+	Key  string `gorm:"size:30;primaryKey" json:"key"`
+	Name string `gorm:"size:100;not null" json:"name"`
+	// RateLimits are per minute per scope, a scope without one keeps its default
+	RateLimits map[string]int `gorm:"type:jsonb;serializer:json" json:"rate_limits,omitempty"`
+	// MonthlyRequests are the requests the keys of a partner make each month within the plan, 0 for no cap
+	MonthlyRequests int64 `gorm:"not null;default:0" json:"monthly_requests"`
+	// OveragePrice is what each 1000 requests over MonthlyRequests cost, in the minor unit of Currency
+	OveragePrice int64     `gorm:"not null;default:0" json:"overage_price"`
+	Currency     string    `gorm:"size:3;not null;default:IDR" json:"currency"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// PartnerAccount is the commercial tier a partner is on, partners without one get the scope defaults and no cap
+type PartnerAccount struct {
+	Partner   string    `gorm:"size:50;primaryKey" json:"partner"`
+	Tier      string    `gorm:"size:30;not null;index" json:"tier"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// PartnerKeyMonthUsage is what a key of a partner was used for in a month. Throttled requests were refused and are
+// not billed.
+type PartnerKeyMonthUsage struct {
+	KeyID     uuid.UUID `json:"key_id"`
+	Name      string    `json:"name"`
+	KeyPrefix string    `json:"key_prefix"`
+	Requests  int64     `json:"requests"`
+	Throttled int64     `json:"throttled"`
+}
+
+// PartnerOverage is what the keys of a partner were used for in a month against what its tier includes
+type PartnerOverage struct {
+	Partner          string                 `json:"partner"`
+	Month            string                 `json:"month"`
+	Tier             string                 `json:"tier,omitempty"`
+	IncludedRequests int64                  `json:"included_requests"` // 0 for no cap
+	Requests         int64                  `json:"requests"`          // served requests, throttled ones left out
+	Throttled        int64                  `json:"throttled"`
+	OverageRequests  int64                  `json:"overage_requests"`
+	OverageCost      Money                  `json:"overage_cost"`
+	Keys             []PartnerKeyMonthUsage `json:"keys"`
+}
+
+// NewPartnerOverage adds up the usage of the keys of a partner in month and bills the requests over what tier
+// includes, started thousands rounded up. tier is nil for partners without one.
+func NewPartnerOverage(partner, month string, tier *PartnerTier, keys []PartnerKeyMonthUsage) PartnerOverage {
+	overage := PartnerOverage{
+		Partner:     partner,
+		Month:       month,
+		OverageCost: Money{Currency: CurrencyIDR},
+		Keys:        keys,
+	}
+	for _, key := range keys {
+		overage.Requests += key.Requests - key.Throttled
+		overage.Throttled += key.Throttled
+	}
+	if tier == nil {
+		return overage
+	}
+
+	overage.Tier = tier.Key
+	overage.IncludedRequests = tier.MonthlyRequests
+	overage.OverageCost.Currency = tier.Currency
+	if tier.MonthlyRequests > 0 && overage.Requests > tier.MonthlyRequests {
+		overage.OverageRequests = overage.Requests - tier.MonthlyRequests
+		overage.OverageCost.Amount = (overage.OverageRequests + 999) / 1000 * tier.OveragePrice
+	}
+	return overage
+}
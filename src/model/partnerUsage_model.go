@@ -0,0 +1,120 @@
+package model
+
+import (
+	"time"
+)
+
+// Periods the usage of a partner is reported by
+const (
+	PartnerUsageDaily   = "day"
+	PartnerUsageMonthly = "month"
+)
+
+// PartnerUsageDay is what the keys of a partner were used for on a day, as the usage query returns it
+type PartnerUsageDay struct {
+	Day       time.Time
+	Requests  int64
+	Throttled int64
+	Errors    int64
+}
+
+// PartnerUsagePeriod is what the keys of a partner were used for in a day or a month. Throttled requests were
+// answered 429 and count as errors in the error rate, which is nil without requests. Remaining is what was left
+// of the monthly requests of the tier at the end of the period, nil without a cap.
+type PartnerUsagePeriod struct {
+	Period    string   `json:"period"` // YYYY-MM-DD or YYYY-MM
+	Requests  int64    `json:"requests"`
+	Throttled int64    `json:"throttled"`
+	Errors    int64    `json:"errors"` // served requests answered 4xx or 5xx
+	ErrorRate *float64 `json:"error_rate"`
+	Remaining *int64   `json:"remaining,omitempty"`
+}
+
+// PartnerQuota is what the keys of a partner used of the monthly requests of its tier this month. Requests over
+// it are still served and billed as overage.
+type PartnerQuota struct {
+	MonthlyRequests int64     `json:"monthly_requests"` // 0 for no cap
+	Used            int64     `json:"used"`
+	Remaining       *int64    `json:"remaining"` // nil without a cap
+	ResetsAt        time.Time `json:"resets_at"`
+}
+
+// PartnerUsageReport is the usage of the keys of a partner, for the partner itself, with the rate limits of the
+// key reading it
+type PartnerUsageReport struct {
+	Partner    string               `json:"partner"`
+	Tier       string               `json:"tier,omitempty"`
+	Interval   string               `json:"interval"`
+	From       string               `json:"from"`
+	To         string               `json:"to"`
+	Quota      PartnerQuota         `json:"quota"`
+	RateLimits map[string]int       `json:"rate_limits"` // per minute per scope of the key
+	Periods    []PartnerUsagePeriod `json:"periods"`
+}
+
+// NewPartnerUsageReport lays the daily usage of the partner of key out over the days or months from from to to.
+// days must start on the first of the month of from, the remaining requests of a period count the whole month
+// until it. used is what was served this month, tier is nil for partners without one.
+func NewPartnerUsageReport(key *PartnerKey, tier *PartnerTier, interval string, from, to time.Time, days []PartnerUsageDay, used int64, now time.Time) PartnerUsageReport {
+	report := PartnerUsageReport{
+		Partner:    key.Partner,
+		Interval:   interval,
+		From:       from.Format(time.DateOnly),
+		To:         to.Format(time.DateOnly),
+		Quota:      PartnerQuota{Used: used, ResetsAt: MonthStart(now).AddDate(0, 1, 0)},
+		RateLimits: make(map[string]int, len(key.Scopes)),
+		Periods:    []PartnerUsagePeriod{},
+	}
+	for _, scope := range key.Scopes {
+		report.RateLimits[scope] = key.RateLimit(scope)
+	}
+	var monthly int64
+	if tier != nil {
+		report.Tier = tier.Key
+		monthly = tier.MonthlyRequests
+	}
+	report.Quota.MonthlyRequests = monthly
+	if monthly > 0 {
+		remaining := max(monthly-used, 0)
+		report.Quota.Remaining = &remaining
+	}
+
+	byDay := make(map[string]PartnerUsageDay, len(days))
+	for _, day := range days {
+		byDay[day.Day.Format(time.DateOnly)] = day
+	}
+
+	layout := time.DateOnly
+	if interval == PartnerUsageMonthly {
+		layout = "2006-01"
+	}
+	var served int64 // this month until the day
+	for day := MonthStart(from); !day.After(to); day = day.AddDate(0, 0, 1) {
+		if day.Day() == 1 {
+			served = 0
+		}
+		usage := byDay[day.Format(time.DateOnly)]
+		served += usage.Requests - usage.Throttled
+		if day.Before(from) {
+			continue
+		}
+
+		period := day.Format(layout)
+		if len(report.Periods) == 0 || report.Periods[len(report.Periods)-1].Period != period {
+			report.Periods = append(report.Periods, PartnerUsagePeriod{Period: period})
+		}
+		current := &report.Periods[len(report.Periods)-1]
+		current.Requests += usage.Requests
+		current.Throttled += usage.Throttled
+		current.Errors += usage.Errors
+		if monthly > 0 {
+			remaining := max(monthly-served, 0)
+			current.Remaining = &remaining
+		}
+	}
+	for i := range report.Periods {
+		report.Periods[i].ErrorRate = share(float64(report.Periods[i].Errors+report.Periods[i].Throttled), report.Periods[i].Requests)
+	}
+
+	return report
+}
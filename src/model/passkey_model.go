@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// What a passkey challenge is for
+const (
+	PasskeyRegister = "register"
+	PasskeyLogin    = "login"
+)
+
+// DefaultPasskeyName names the passkeys the user did not name
+const DefaultPasskeyName = "Passkey"
+
+// Passkey is a WebAuthn credential a user signs in with instead of their password. The password keeps working,
+// support deletes the passkeys of a user who lost their devices.
+type Passkey struct {
+	ID             uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	CredentialID   string     `gorm:"size:1400;not null;uniqueIndex" json:"credential_id"` // base64url
+	PublicKey      []byte     `gorm:"type:bytea;not null" json:"-"`                        // COSE_Key
+	Algorithm      int64      `gorm:"not null" json:"algorithm"`
+	SignCount      int64      `gorm:"not null;default:0" json:"-"`
+	AAGUID         string     `gorm:"size:36" json:"aaguid"` // model of the authenticator
+	Transports     []string   `gorm:"type:jsonb;serializer:json" json:"transports"`
+	BackupEligible bool       `gorm:"not null;default:false" json:"backup_eligible"`
+	BackedUp       bool       `gorm:"not null;default:false" json:"backed_up"`
+	Name           string     `gorm:"size:100;not null" json:"name"`
+	LastUsedAt     *time.Time `gorm:"default:null" json:"last_used_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (passkey *Passkey) BeforeCreate(_ *gorm.DB) error {
+	passkey.ID = uuid.New()
+	return nil
+}
+
+// PasskeyChallenge is a challenge issued for a passkey registration or sign-in, it is deleted when answered
+type PasskeyChallenge struct {
+	ID        uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID    *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"` // nil for sign-ins
+	Purpose   string     `gorm:"size:20;not null" json:"purpose"`
+	Challenge string     `gorm:"size:64;not null" json:"-"` // base64url
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (challenge *PasskeyChallenge) BeforeCreate(_ *gorm.DB) error {
+	challenge.ID = uuid.New()
+	return nil
+}
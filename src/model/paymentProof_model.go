@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	PaymentProofPending  = "pending"
+	PaymentProofApproved = "approved"
+	PaymentProofRejected = "rejected"
+)
+
+// PaymentProof is a proof of transfer uploaded by a user for a bank transfer checkout
+type PaymentProof struct {
+	ID                 uuid.UUID         `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserSubscriptionID uuid.UUID         `gorm:"not null;index" json:"user_subscription_id"`
+	UserSubscription   *UserSubscription `gorm:"foreignKey:UserSubscriptionID" json:"user_subscription,omitempty"`
+	UserID             uuid.UUID         `gorm:"not null;index" json:"user_id"`
+	User               *User             `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	ImageURL           string            `gorm:"size:255;not null" json:"image_url"`
+	BankName           string            `gorm:"size:20" json:"bank_name"`
+	AccountName        string            `gorm:"size:100" json:"account_name"`
+	ReferenceNumber    string            `gorm:"size:100" json:"reference_number"`
+	Amount             int               `json:"amount"`
+	Status             string            `gorm:"size:20;default:'pending';index" json:"status"`
+	RejectionReason    *string           `json:"rejection_reason,omitempty"`
+	ReviewedByID       *uuid.UUID        `gorm:"default:null" json:"reviewed_by_id,omitempty"`
+	ReviewedAt         *time.Time        `json:"reviewed_at,omitempty"`
+	CreatedAt          time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (paymentProof *PaymentProof) BeforeCreate(_ *gorm.DB) error {
+	paymentProof.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const ReminderChannelEmail = "email"
+
+// PaymentReminder records a payment link sent to the user of an unpaid subscription
+type PaymentReminder struct {
+	ID                 uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserSubscriptionID uuid.UUID `gorm:"not null;index" json:"user_subscription_id"`
+	CheckoutSessionID  uuid.UUID `gorm:"not null" json:"checkout_session_id"`
+	SentByID           uuid.UUID `gorm:"not null" json:"sent_by_id"`
+	Channel            string    `gorm:"size:20;not null" json:"channel"`
+	PaymentLink        string    `gorm:"not null" json:"payment_link"`
+	LinkExpiresAt      time.Time `json:"link_expires_at"`
+	Note               string    `json:"note,omitempty"`
+	CreatedAt          time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (paymentReminder *PaymentReminder) BeforeCreate(_ *gorm.DB) error {
+	paymentReminder.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"app/src/messaging"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// What a phone code is for
+const (
+	PhoneOTPLogin  = "login"        // signs in the user with the verified phone
+	PhoneOTPVerify = "verify_phone" // proves the phone is the user's
+)
+
+// PhoneOTP is a one-time code sent to a phone. Only the hash of the code is stored, a code is used once and
+// locked after too many wrong guesses.
+type PhoneOTP struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Phone     string     `gorm:"size:20;not null;index:idx_phone_otps_phone_purpose" json:"phone"`
+	Purpose   string     `gorm:"size:20;not null;index:idx_phone_otps_phone_purpose" json:"purpose"`
+	UserID    *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"` // nil for login codes
+	Channel   string     `gorm:"size:20;not null" json:"channel"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"`
+	Attempts  int        `gorm:"not null;default:0" json:"attempts"`
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"default:null" json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime;index:idx_phone_otps_phone_purpose" json:"created_at"`
+}
+
+func (otp *PhoneOTP) BeforeCreate(_ *gorm.DB) error {
+	if otp.ID == uuid.Nil {
+		otp.ID = uuid.New()
+	}
+	return nil
+}
+
+// Usable reports whether the code can still be tried at now
+func (otp *PhoneOTP) Usable(now time.Time, maxAttempts int) bool {
+	return otp.UsedAt == nil && now.Before(otp.ExpiresAt) && otp.Attempts < maxAttempts
+}
+
+// PhoneMessage is a text sent to the phone of a user, a code or a notification, with its delivery status as
+// the provider reports it
+type PhoneMessage struct {
+	ID                uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID            *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	Phone             string     `gorm:"size:20;not null" json:"phone"`
+	Channel           string     `gorm:"size:20;not null" json:"channel"`
+	Purpose           string     `gorm:"size:30;not null" json:"purpose"` // a PhoneOTP purpose or a notification category
+	Provider          string     `gorm:"size:20;not null" json:"provider"`
+	ProviderMessageID string     `gorm:"size:64;index" json:"provider_message_id,omitempty"`
+	Status            string     `gorm:"size:20;not null" json:"status"`
+	ErrorCode         string     `gorm:"size:20" json:"error_code,omitempty"`
+	Error             string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt         time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (message *PhoneMessage) BeforeCreate(_ *gorm.DB) error {
+	if message.ID == uuid.Nil {
+		message.ID = uuid.New()
+	}
+	return nil
+}
+
+// phoneMessageStatusRank orders the statuses, callbacks arrive out of order
+var phoneMessageStatusRank = map[string]int{
+	messaging.StatusQueued:    1,
+	messaging.StatusSent:      2,
+	messaging.StatusDelivered: 3,
+	messaging.StatusRead:      4,
+	messaging.StatusFailed:    5,
+}
+
+// AdvancePhoneMessageStatus is the status of a message after the provider reported reported, a late callback
+// of an earlier status does not move it back
+func AdvancePhoneMessageStatus(current, reported string) string {
+	if phoneMessageStatusRank[reported] > phoneMessageStatusRank[current] {
+		return reported
+	}
+	return current
+}
@@ -0,0 +1,183 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Actions of the plan history
+const (
+	PlanChangeCreate   = "create"
+	PlanChangeUpdate   = "update"
+	PlanChangeArchive  = "archive"
+	PlanChangeDelete   = "delete"
+	PlanChangeSunset   = "sunset"
+	PlanChangeRollback = "rollback"
+)
+
+// rollbackActions are the changes that can be rolled back. Creating, archiving, deleting and sunsetting
+// a plan have effects on subscribers beyond the plan itself.
+var rollbackActions = []string{PlanChangeUpdate, PlanChangeRollback}
+
+// PlanFieldChange is the value of one term of a plan before and after a change, as JSON
+type PlanFieldChange struct {
+	Field string          `json:"field" example:"price"`
+	From  json.RawMessage `json:"from" swaggertype:"object"`
+	To    json.RawMessage `json:"to" swaggertype:"object"`
+}
+
+// PlanChange records an admin changing a subscription plan. It outlives the plan when the plan is deleted.
+type PlanChange struct {
+	ID          uuid.UUID         `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	PlanID      uuid.UUID         `gorm:"type:uuid;not null;index" json:"plan_id"`
+	Action      string            `gorm:"size:20;not null" json:"action"`
+	Changes     []PlanFieldChange `gorm:"type:jsonb;serializer:json;not null" json:"changes"`
+	RevertsID   *uuid.UUID        `gorm:"type:uuid;default:null" json:"reverts_id,omitempty"` // the change a rollback undid
+	ChangedByID uuid.UUID         `gorm:"type:uuid;not null" json:"changed_by_id"`
+	RequestID   string            `gorm:"size:64" json:"request_id,omitempty"`
+	ChangedAt   time.Time         `gorm:"not null;index" json:"changed_at"`
+}
+
+func (change *PlanChange) BeforeCreate(_ *gorm.DB) error {
+	change.ID = uuid.New()
+	return nil
+}
+
+// CanRollback reports whether the change is an edit of the plan that can be undone
+func (change *PlanChange) CanRollback() bool {
+	return slices.Contains(rollbackActions, change.Action) && len(change.Changes) > 0
+}
+
+// planField reads and writes one term of a plan as JSON
+type planField struct {
+	name string
+	get  func(plan *SubscriptionPlan) any
+	set  func(plan *SubscriptionPlan, value json.RawMessage) error
+}
+
+func valueField[T any](name string, field func(plan *SubscriptionPlan) *T) planField {
+	return planField{
+		name: name,
+		get:  func(plan *SubscriptionPlan) any { return *field(plan) },
+		set: func(plan *SubscriptionPlan, value json.RawMessage) error {
+			return setPlanField(value, field(plan))
+		},
+	}
+}
+
+// setPlanField decodes into a new value, a copy of a plan shares its pointers with the original
+func setPlanField[T any](value json.RawMessage, field *T) error {
+	var decoded T
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return err
+	}
+	*field = decoded
+	return nil
+}
+
+// timeField compares instants, a time read back from the database is in another location than the one parsed
+func timeField(name string, field func(plan *SubscriptionPlan) **time.Time) planField {
+	return planField{
+		name: name,
+		get: func(plan *SubscriptionPlan) any {
+			if *field(plan) == nil {
+				return nil
+			}
+			return (*field(plan)).UTC().Truncate(time.Microsecond)
+		},
+		set: func(plan *SubscriptionPlan, value json.RawMessage) error {
+			return setPlanField(value, field(plan))
+		},
+	}
+}
+
+// planFields are the terms of a plan kept in its history, in the order of the diff
+var planFields = []planField{
+	valueField("name", func(plan *SubscriptionPlan) *string { return &plan.Name }),
+	valueField("price", func(plan *SubscriptionPlan) *int { return &plan.Price }),
+	valueField("currency", func(plan *SubscriptionPlan) *string { return &plan.Currency }),
+	valueField("description", func(plan *SubscriptionPlan) *string { return &plan.Description }),
+	valueField("ai_scan_limit", func(plan *SubscriptionPlan) *int { return &plan.AIscanLimit }),
+	valueField("validity_days", func(plan *SubscriptionPlan) *int { return &plan.ValidityDays }),
+	valueField("features", func(plan *SubscriptionPlan) *PlanFeatures { return &plan.Features }),
+	valueField("is_active", func(plan *SubscriptionPlan) *bool { return &plan.IsActive }),
+	valueField("allow_installments", func(plan *SubscriptionPlan) *bool { return &plan.AllowInstallments }),
+	valueField("installment_count", func(plan *SubscriptionPlan) *int { return &plan.InstallmentCount }),
+	timeField("available_from", func(plan *SubscriptionPlan) **time.Time { return &plan.AvailableFrom }),
+	timeField("available_until", func(plan *SubscriptionPlan) **time.Time { return &plan.AvailableUntil }),
+	valueField("hidden", func(plan *SubscriptionPlan) *bool { return &plan.Hidden }),
+	valueField("limits_private", func(plan *SubscriptionPlan) *bool { return &plan.LimitsPrivate }),
+	valueField("chat_message_limit", func(plan *SubscriptionPlan) *int { return &plan.ChatMessageLimit }),
+	valueField("voice_log_limit", func(plan *SubscriptionPlan) *int { return &plan.VoiceLogLimit }),
+	valueField("trial_days", func(plan *SubscriptionPlan) *int { return &plan.TrialDays }),
+	timeField("archived_at", func(plan *SubscriptionPlan) **time.Time { return &plan.ArchivedAt }),
+	timeField("sunset_at", func(plan *SubscriptionPlan) **time.Time { return &plan.SunsetAt }),
+	valueField("replacement_plan_id", func(plan *SubscriptionPlan) **uuid.UUID { return &plan.ReplacementPlanID }),
+}
+
+func lookupPlanField(name string) (planField, bool) {
+	for _, field := range planFields {
+		if field.name == name {
+			return field, true
+		}
+	}
+	return planField{}, false
+}
+
+// DiffPlans lists the terms that differ between two versions of a plan. A nil before diffs against an empty plan,
+// for a plan that was created, a nil after against an empty plan for a plan that was deleted.
+func DiffPlans(before, after *SubscriptionPlan) []PlanFieldChange {
+	if before == nil {
+		before = &SubscriptionPlan{}
+	}
+	if after == nil {
+		after = &SubscriptionPlan{}
+	}
+
+	changes := []PlanFieldChange{}
+	for _, field := range planFields {
+		from, _ := json.Marshal(field.get(before))
+		to, _ := json.Marshal(field.get(after))
+		if bytes.Equal(from, to) {
+			continue
+		}
+		changes = append(changes, PlanFieldChange{Field: field.name, From: from, To: to})
+	}
+	return changes
+}
+
+// Revert sets the terms of the change back to their value before it. A term that changed again since
+// is not overwritten, the later change has to be rolled back first.
+func (change *PlanChange) Revert(plan *SubscriptionPlan) error {
+	reverted := *plan
+	for _, fieldChange := range change.Changes {
+		field, ok := lookupPlanField(fieldChange.Field)
+		if !ok {
+			return fmt.Errorf("unknown plan field %q", fieldChange.Field)
+		}
+
+		// Compare through the plan so both sides are read the same way
+		var expected SubscriptionPlan
+		if err := field.set(&expected, fieldChange.To); err != nil {
+			return err
+		}
+		current, _ := json.Marshal(field.get(plan))
+		changed, _ := json.Marshal(field.get(&expected))
+		if !bytes.Equal(current, changed) {
+			return fmt.Errorf("%s was changed again since, roll back the later change first", fieldChange.Field)
+		}
+
+		if err := field.set(&reverted, fieldChange.From); err != nil {
+			return err
+		}
+	}
+
+	*plan = reverted
+	return nil
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Features a subscription plan includes, the keys of PlanFeatures
+const (
+	FeatureScanAI         = "scan_ai"
+	FeatureScanCalorie    = "scan_calorie"
+	FeatureChatbot        = "chatbot"
+	FeatureBMICheck       = "bmi_check"
+	FeatureWeightTracking = "weight_tracking"
+	FeatureHealthInfo     = "health_info"
+)
+
+var PlanFeatureKeys = []string{
+	FeatureScanAI, FeatureScanCalorie, FeatureChatbot, FeatureBMICheck, FeatureWeightTracking, FeatureHealthInfo,
+}
+
+// PlanFeatures are the features a plan includes, stored as a JSON object of the feature keys. Keys the type does
+// not know are dropped when a stored plan is read.
+type PlanFeatures struct {
+	ScanAI         bool `json:"scan_ai"`
+	ScanCalorie    bool `json:"scan_calorie"`
+	Chatbot        bool `json:"chatbot"`
+	BMICheck       bool `json:"bmi_check"`
+	WeightTracking bool `json:"weight_tracking"`
+	HealthInfo     bool `json:"health_info"`
+}
+
+// flags are the fields of the features by key
+func (features *PlanFeatures) flags() map[string]*bool {
+	return map[string]*bool{
+		FeatureScanAI:         &features.ScanAI,
+		FeatureScanCalorie:    &features.ScanCalorie,
+		FeatureChatbot:        &features.Chatbot,
+		FeatureBMICheck:       &features.BMICheck,
+		FeatureWeightTracking: &features.WeightTracking,
+		FeatureHealthInfo:     &features.HealthInfo,
+	}
+}
+
+// Has reports whether the plan includes feature, false for features that do not exist
+func (features PlanFeatures) Has(feature string) bool {
+	flag, ok := features.flags()[feature]
+	return ok && *flag
+}
+
+// Map is the features by key, as the API returns them
+func (features PlanFeatures) Map() map[string]bool {
+	flags := features.flags()
+	result := make(map[string]bool, len(flags))
+	for key, flag := range flags {
+		result[key] = *flag
+	}
+	return result
+}
+
+// ParsePlanFeatures reads the features of a request, refusing keys that are not features. Features left out are
+// not included.
+func ParsePlanFeatures(values map[string]bool) (PlanFeatures, error) {
+	var features PlanFeatures
+	flags := features.flags()
+
+	var unknown []string
+	for key, value := range values {
+		flag, ok := flags[key]
+		if !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+		*flag = value
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return PlanFeatures{}, fmt.Errorf("unknown features: %s", strings.Join(unknown, ", "))
+	}
+	return features, nil
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PlanSnapshot is a plan as it was sold: the price, features and limits when a subscription was activated or a
+// transaction recorded. Later edits of the plan leave what a user bought as it was.
+type PlanSnapshot struct {
+	PlanID           uuid.UUID    `json:"plan_id"`
+	Name             string       `json:"name"`
+	Price            int          `json:"price"` // in the minor unit of Currency
+	Currency         string       `json:"currency"`
+	ValidityDays     int          `json:"validity_days"`
+	Features         PlanFeatures `json:"features"`
+	AIscanLimit      int          `json:"ai_scan_limit"`
+	ChatMessageLimit int          `json:"chat_message_limit"`
+	VoiceLogLimit    int          `json:"voice_log_limit"`
+	TakenAt          time.Time    `json:"taken_at"`
+}
+
+// NewPlanSnapshot snapshots plan at takenAt
+func NewPlanSnapshot(plan *SubscriptionPlan, takenAt time.Time) *PlanSnapshot {
+	return &PlanSnapshot{
+		PlanID:           plan.ID,
+		Name:             plan.Name,
+		Price:            plan.Price,
+		Currency:         plan.Currency,
+		ValidityDays:     plan.ValidityDays,
+		Features:         plan.Features,
+		AIscanLimit:      plan.AIscanLimit,
+		ChatMessageLimit: plan.ChatMessageLimit,
+		VoiceLogLimit:    plan.VoiceLogLimit,
+		TakenAt:          takenAt,
+	}
+}
+
+// PriceMoney is the price with its currency
+func (snapshot *PlanSnapshot) PriceMoney() Money {
+	return Money{Amount: int64(snapshot.Price), Currency: snapshot.Currency}
+}
+
+// PurchasedPlan is the plan of the subscription on the terms it was sold with. Without a snapshot, e.g. while
+// it is not paid, the plan is taken as it is now.
+func (userSubscription *UserSubscription) PurchasedPlan() SubscriptionPlan {
+	plan := userSubscription.Plan
+	snapshot := userSubscription.PlanSnapshot
+	if snapshot == nil || snapshot.PlanID != userSubscription.PlanID {
+		return plan
+	}
+
+	plan.ID = snapshot.PlanID
+	plan.Name = snapshot.Name
+	plan.Price = snapshot.Price
+	plan.Currency = snapshot.Currency
+	plan.ValidityDays = snapshot.ValidityDays
+	plan.Features = snapshot.Features
+	plan.AIscanLimit = snapshot.AIscanLimit
+	plan.ChatMessageLimit = snapshot.ChatMessageLimit
+	plan.VoiceLogLimit = snapshot.VoiceLogLimit
+	return plan
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Statuses of the migration of a subscription off a sunset plan
+const (
+	// PlanMigrationNotified subscribers were told about the replacement and have not renewed yet
+	PlanMigrationNotified = "notified"
+	// PlanMigrationMigrated subscribers renewed on, or bought, the replacement plan
+	PlanMigrationMigrated = "migrated"
+	// PlanMigrationLapsed subscriptions ended without moving to the replacement plan
+	PlanMigrationLapsed = "lapsed"
+)
+
+// PlanMigration tracks a subscription of a sunset plan until it moved to the replacement plan or lapsed
+type PlanMigration struct {
+	ID                 uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserSubscriptionID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"user_subscription_id"`
+	UserID             uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	FromPlanID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"from_plan_id"`
+	ToPlanID           uuid.UUID  `gorm:"type:uuid;not null" json:"to_plan_id"`
+	Status             string     `gorm:"size:20;not null;index" json:"status"`
+	NotifiedAt         *time.Time `gorm:"default:null" json:"notified_at,omitempty"` // nil when the email could not be sent
+	// MigratedToID is the subscription on the replacement plan
+	MigratedToID *uuid.UUID `gorm:"type:uuid;default:null" json:"migrated_to_id,omitempty"`
+	ResolvedAt   *time.Time `gorm:"default:null" json:"resolved_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (planMigration *PlanMigration) BeforeCreate(_ *gorm.DB) error {
+	planMigration.ID = uuid.New()
+	return nil
+}
+
+// PlanSunsetReport is the progress of moving the subscribers of a sunset plan to its replacement
+type PlanSunsetReport struct {
+	PlanID            uuid.UUID  `json:"plan_id"`
+	PlanName          string     `json:"plan_name"`
+	ReplacementPlanID uuid.UUID  `json:"replacement_plan_id"`
+	ReplacementName   string     `json:"replacement_plan_name"`
+	SunsetAt          time.Time  `json:"sunset_at"`
+	LastRunAt         *time.Time `json:"last_run_at"` // last migration run that changed anything, nil before
+	// Active subscriptions of the plan the migration has not picked up yet
+	Unprocessed int64 `json:"unprocessed"`
+	Notified    int64 `json:"notified"`
+	Migrated    int64 `json:"migrated"`
+	Lapsed      int64 `json:"lapsed"`
+	Total       int64 `json:"total"`
+	// Progress is the share of subscriptions that migrated or lapsed, 1 once none is left
+	Progress float64 `json:"progress"`
+	// MigrationRate is the share of resolved subscriptions that moved to the replacement, nil before any resolved
+	MigrationRate *float64 `json:"migration_rate"`
+	Done          bool     `json:"done"`
+}
+
+// Tally fills the totals of the report from the number of migrations per status
+func (report *PlanSunsetReport) Tally(statuses map[string]int64) {
+	report.Notified = statuses[PlanMigrationNotified]
+	report.Migrated = statuses[PlanMigrationMigrated]
+	report.Lapsed = statuses[PlanMigrationLapsed]
+	report.Total = report.Unprocessed + report.Notified + report.Migrated + report.Lapsed
+
+	resolved := report.Migrated + report.Lapsed
+	report.Progress = 1
+	if report.Total > 0 {
+		report.Progress = float64(resolved) / float64(report.Total)
+	}
+	report.MigrationRate = nil
+	if resolved > 0 {
+		rate := float64(report.Migrated) / float64(resolved)
+		report.MigrationRate = &rate
+	}
+	report.Done = resolved == report.Total
+}
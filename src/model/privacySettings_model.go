@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Who sees the public profile of a user
+const (
+	ProfilePrivate = "private" // only the user
+	ProfilePublic  = "public"  // every logged in user who knows the handle
+)
+
+// How much of the food diary and vitals coaches, clinicians with a share link and partners see, each level
+// includes the ones before it
+const (
+	CoachSharingNone    = "none"    // nothing
+	CoachSharingSummary = "summary" // daily nutrition totals and streaks
+	CoachSharingFull    = "full"    // every meal with its comment, and weights and heights
+)
+
+// coachSharingLevels orders the coach sharing values from least to most shared
+var coachSharingLevels = []string{CoachSharingNone, CoachSharingSummary, CoachSharingFull}
+
+// PrivacySettings are the choices of a user about what other users and coaches see. Users without a row
+// have DefaultPrivacySettings, which share nothing.
+type PrivacySettings struct {
+	UserID            uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	ProfileVisibility string    `gorm:"size:20;not null;default:private" json:"profile_visibility"`
+	CoachDataSharing  string    `gorm:"size:20;not null;default:none" json:"coach_data_sharing"`
+	UpdatedAt         time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// DefaultPrivacySettings are the settings of a user who never changed them
+func DefaultPrivacySettings(userID uuid.UUID) PrivacySettings {
+	return PrivacySettings{
+		UserID:            userID,
+		ProfileVisibility: ProfilePrivate,
+		CoachDataSharing:  CoachSharingNone,
+	}
+}
+
+// ProfileVisibleTo reports whether viewerID may see the public profile, users always see their own
+func (settings *PrivacySettings) ProfileVisibleTo(viewerID uuid.UUID) bool {
+	return viewerID == settings.UserID || settings.ProfileVisibility == ProfilePublic
+}
+
+// ProfileSeenBy returns what viewerID sees of the profile: all of it when it is visible to them, the handle they
+// typed alone otherwise, the same as for a handle nobody has
+func (settings *PrivacySettings) ProfileSeenBy(profile PublicProfile, viewerID uuid.UUID) PublicProfile {
+	if settings.ProfileVisibleTo(viewerID) {
+		return profile
+	}
+	return PublicProfile{Handle: profile.Handle}
+}
+
+// CoachCanSee reports whether a coach may read data that needs the sharing level, unknown levels are refused
+func (settings *PrivacySettings) CoachCanSee(level string) bool {
+	shared, needed := -1, -1
+	for i, value := range coachSharingLevels {
+		if value == settings.CoachDataSharing {
+			shared = i
+		}
+		if value == level {
+			needed = i
+		}
+	}
+	return needed >= 0 && shared >= needed
+}
+
+// PublicProfile is what other users see of a user on social features, never the email
+type PublicProfile struct {
+	Handle         string `json:"handle"`
+	Name           string `json:"name"`
+	ProfilePicture Avatar `json:"profile_picture,omitempty"`
+	CurrentStreak  int    `json:"current_streak"`
+}
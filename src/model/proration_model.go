@@ -0,0 +1,121 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransactionStatusProration marks the line item of a plan change, it records an adjustment and moves no money
+const TransactionStatusProration = "proration"
+
+// proratedSources are the sources subscriptions are paid through, complimentary and token subscriptions have
+// nothing to credit
+var proratedSources = []string{SourceMidtrans, SourceManualTransfer}
+
+// Proration is the adjustment for moving a subscription to another plan: the unused days of what was paid for
+// the current period are credited against the price of the new plan, which starts a period of its own
+type Proration struct {
+	FromPlanID uuid.UUID `json:"from_plan_id"`
+	ToPlanID   uuid.UUID `json:"to_plan_id"`
+	Currency   string    `json:"currency"`
+	// Paid is the price the current period was sold for, 0 for subscriptions that were not paid
+	Paid       int `json:"paid"`
+	PeriodDays int `json:"period_days"`
+	UnusedDays int `json:"unused_days"`
+	Credit     int `json:"credit"`
+	NewPrice   int `json:"new_price"`
+	// AmountDue is the new price less the credit, the credit left over goes back to the wallet as WalletCredit
+	AmountDue    int `json:"amount_due"`
+	WalletCredit int `json:"wallet_credit"`
+	// Comped is set when an admin waived the amount due, nothing is charged for it
+	Comped    bool      `json:"comped,omitempty"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+}
+
+// CanProrate reports whether a plan change of the subscription is prorated: it must be paid, current and billed
+// by this backend in one payment
+func (userSubscription *UserSubscription) CanProrate(now time.Time) bool {
+	return userSubscription.PaymentStatus == "success" && userSubscription.IsActive &&
+		!userSubscription.IsInstallment && !userSubscription.IsStoreManaged() &&
+		userSubscription.EndDate.After(now)
+}
+
+// CanUpgradeTo reports whether the subscriber may upgrade the subscription to plan at now themselves: the plan
+// costs more than what they bought, so the upgrade always has something to pay through the gateway
+func (userSubscription *UserSubscription) CanUpgradeTo(plan *SubscriptionPlan, now time.Time) bool {
+	current := userSubscription.PurchasedPlan()
+	return userSubscription.CanProrate(now) && plan.ID != userSubscription.PlanID &&
+		plan.Currency == CurrencyIDR && plan.Currency == current.Currency && plan.Price > current.Price
+}
+
+// PlanOption is a listed plan as a subscriber sees it, with the proration of upgrading to it now when they can
+type PlanOption struct {
+	Plan    SubscriptionPlanResponse `json:"plan"`
+	Current bool                     `json:"current"`
+	Upgrade *Proration               `json:"upgrade,omitempty"`
+}
+
+// Prorate computes the adjustment of moving the subscription to plan at now. The plan of the subscription must
+// be loaded, the credit follows the terms it was sold on.
+func Prorate(subscription *UserSubscription, plan *SubscriptionPlan, now time.Time) Proration {
+	current := subscription.PurchasedPlan()
+	proration := Proration{
+		FromPlanID: subscription.PlanID,
+		ToPlanID:   plan.ID,
+		Currency:   plan.Currency,
+		NewPrice:   plan.Price,
+		StartDate:  now,
+		EndDate:    now.AddDate(0, 0, plan.ValidityDays),
+	}
+	for _, source := range proratedSources {
+		if subscription.Source == source {
+			proration.Paid = current.Price
+		}
+	}
+
+	proration.PeriodDays = max(1, ceilDays(subscription.EndDate.Sub(subscription.StartDate)))
+	used := now
+	if used.Before(subscription.StartDate) {
+		used = subscription.StartDate
+	}
+	proration.UnusedDays = min(proration.PeriodDays, max(0, ceilDays(subscription.EndDate.Sub(used))))
+
+	// Rounded down, the user is never credited more than they paid
+	proration.Credit = int(int64(proration.Paid) * int64(proration.UnusedDays) / int64(proration.PeriodDays))
+	proration.AmountDue = max(0, proration.NewPrice-proration.Credit)
+	// The wallet holds Rupiah, a credit left over in another currency is not paid out
+	if plan.Currency == CurrencyIDR {
+		proration.WalletCredit = max(0, proration.Credit-proration.NewPrice)
+	}
+	return proration
+}
+
+// Description summarizes the adjustment for the line item of the plan change
+func (proration Proration) Description(fromPlan, toPlan string) string {
+	return fmt.Sprintf("Plan changed from %s to %s, %d of %d days unused credited %s against %s",
+		fromPlan, toPlan, proration.UnusedDays, proration.PeriodDays,
+		Money{Amount: int64(proration.Credit), Currency: proration.Currency},
+		Money{Amount: int64(proration.NewPrice), Currency: proration.Currency}) + proration.compedNote()
+}
+
+// compedNote tells in the description of the line item that the amount due was waived
+func (proration Proration) compedNote() string {
+	if !proration.Comped || proration.AmountDue <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(", %s due waived", Money{Amount: int64(proration.AmountDue), Currency: proration.Currency})
+}
+
+// ProrationOrderID is the order of the line item of a plan change
+func ProrationOrderID(subscriptionID uuid.UUID, now time.Time, sandbox bool) string {
+	return SandboxOrderID(fmt.Sprintf("PRORATE-%s-%d", subscriptionID.String()[:8], now.Unix()), sandbox)
+}
+
+// ceilDays counts the started days of a duration
+func ceilDays(duration time.Duration) int {
+	return int(math.Ceil(duration.Hours() / 24))
+}
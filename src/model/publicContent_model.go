@@ -0,0 +1,254 @@
+package model
+
+import (
+	"app/src/media"
+	"encoding/xml"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ExcerptLength is the longest excerpt, in characters, listed for an article or a recipe
+const ExcerptLength = 200
+
+// Public content kinds, also the website paths they are read at
+const (
+	PublicContentArticle = "articles"
+	PublicContentRecipe  = "recipes"
+)
+
+// PublicArticle is a published article as the marketing website renders it, lists leave the content out
+type PublicArticle struct {
+	Slug        string        `json:"slug"`
+	Title       string        `json:"title"`
+	Category    string        `json:"category,omitempty"`
+	Image       *ContentImage `json:"image,omitempty"`
+	Excerpt     string        `json:"excerpt"`
+	Content     string        `json:"content,omitempty"`
+	URL         string        `json:"url"`
+	PublishedAt time.Time     `json:"published_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// PublicRecipe is a curated recipe as the marketing website renders it, lists leave the ingredients and the
+// instructions out
+type PublicRecipe struct {
+	Slug         string        `json:"slug"`
+	Name         string        `json:"name"`
+	Label        *string       `json:"label,omitempty"`
+	Image        *ContentImage `json:"image,omitempty"`
+	Excerpt      string        `json:"excerpt"`
+	Description  string        `json:"description,omitempty"`
+	Ingredients  string        `json:"ingredients,omitempty"`
+	Instructions string        `json:"instructions,omitempty"`
+	URL          string        `json:"url"`
+	PublishedAt  time.Time     `json:"published_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// NewPublicArticle returns a published article as the website reads it, with its content when full
+func NewPublicArticle(article *Article, siteURL string, full bool) PublicArticle {
+	public := PublicArticle{
+		Slug:        article.Slug,
+		Title:       article.Title,
+		Image:       article.Image,
+		Excerpt:     Excerpt(article.Content, ExcerptLength),
+		URL:         PublicContentURL(siteURL, PublicContentArticle, article.Slug),
+		PublishedAt: *article.PublishedAt,
+		UpdatedAt:   article.UpdatedAt,
+	}
+	if article.Category != nil {
+		public.Category = article.Category.Name
+	}
+	if full {
+		public.Content = article.Content
+	}
+	return public
+}
+
+// NewPublicRecipe returns a curated recipe as the website reads it, with its ingredients and instructions when
+// full
+func NewPublicRecipe(recipe *Recipe, siteURL string, full bool) PublicRecipe {
+	public := PublicRecipe{
+		Slug:        recipe.Slug,
+		Name:        recipe.Name,
+		Label:       recipe.Label,
+		Image:       recipe.Image,
+		Excerpt:     Excerpt(recipe.Description, ExcerptLength),
+		URL:         PublicContentURL(siteURL, PublicContentRecipe, recipe.Slug),
+		PublishedAt: *recipe.PublishedAt,
+		UpdatedAt:   recipe.UpdatedAt,
+	}
+	if full {
+		public.Description = recipe.Description
+		public.Ingredients = recipe.Ingredients
+		public.Instructions = recipe.Instructions
+	}
+	return public
+}
+
+// PublicContentURL is the website page of a piece of content
+func PublicContentURL(siteURL, kind, slug string) string {
+	return strings.TrimRight(siteURL, "/") + "/" + kind + "/" + slug
+}
+
+var (
+	htmlBlockTag = regexp.MustCompile(`(?i)</?(p|div|br|hr|h[1-6]|li|ul|ol|blockquote|pre|table|tr|td|th)\b[^>]*>`)
+	htmlTag      = regexp.MustCompile(`<[^>]*>`)
+)
+
+// Excerpt is the text of content without its markup, cut at a word to at most length characters
+func Excerpt(content string, length int) string {
+	// Blocks are apart from each other, inline tags are within a word
+	text := htmlTag.ReplaceAllString(htmlBlockTag.ReplaceAllString(content, " "), "")
+	text = strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := string(runes[:length-1])
+	if space := strings.LastIndex(cut, " "); space > 0 {
+		cut = cut[:space]
+	}
+	return strings.TrimRight(cut, " .,;:") + "…"
+}
+
+// PublicFeedItem is an article or a recipe in the feeds, newest first
+type PublicFeedItem struct {
+	Kind        string
+	Title       string
+	Summary     string
+	Category    string
+	Image       *ContentImage
+	URL         string
+	PublishedAt time.Time
+	UpdatedAt   time.Time
+}
+
+// JSONFeed is a feed in the JSON Feed 1.1 format
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            string    `json:"id"`
+	URL           string    `json:"url"`
+	Title         string    `json:"title"`
+	Summary       string    `json:"summary"`
+	Image         string    `json:"image,omitempty"`
+	DatePublished time.Time `json:"date_published"`
+	DateModified  time.Time `json:"date_modified"`
+	Tags          []string  `json:"tags"`
+}
+
+// NewJSONFeed returns the items as a JSON Feed read at feedURL
+func NewJSONFeed(title, siteURL, feedURL string, items []PublicFeedItem) JSONFeed {
+	feed := JSONFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       title,
+		HomePageURL: siteURL,
+		FeedURL:     feedURL,
+		Items:       make([]JSONFeedItem, len(items)),
+	}
+	for i, item := range items {
+		tags := []string{item.Kind}
+		if item.Category != "" {
+			tags = append(tags, item.Category)
+		}
+		feed.Items[i] = JSONFeedItem{
+			ID:            item.URL,
+			URL:           item.URL,
+			Title:         item.Title,
+			Summary:       item.Summary,
+			DatePublished: item.PublishedAt,
+			DateModified:  item.UpdatedAt,
+			Tags:          tags,
+		}
+		if item.Image != nil {
+			feed.Items[i].Image = media.Sign(media.KindContent, string(*item.Image))
+		}
+	}
+	return feed
+}
+
+// RSSFeed is a feed in the RSS 2.0 format
+type RSSFeed struct {
+	XMLName xml.Name   `xml:"rss" swaggerignore:"true"`
+	Version string     `xml:"version,attr"`
+	Channel RSSChannel `xml:"channel"`
+}
+
+type RSSChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []RSSItem `xml:"item"`
+}
+
+type RSSItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        string   `xml:"guid"`
+	Description string   `xml:"description"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate"`
+}
+
+// NewRSSFeed returns the items as an RSS channel of the website
+func NewRSSFeed(title, description, siteURL string, items []PublicFeedItem) RSSFeed {
+	feed := RSSFeed{
+		Version: "2.0",
+		Channel: RSSChannel{
+			Title:       title,
+			Link:        siteURL,
+			Description: description,
+			Items:       make([]RSSItem, len(items)),
+		},
+	}
+	for i, item := range items {
+		categories := []string{item.Kind}
+		if item.Category != "" {
+			categories = append(categories, item.Category)
+		}
+		feed.Channel.Items[i] = RSSItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			GUID:        item.URL,
+			Description: item.Summary,
+			Categories:  categories,
+			PubDate:     item.PublishedAt.UTC().Format(time.RFC1123Z),
+		}
+	}
+	return feed
+}
+
+// Sitemap lists the website pages of the published content for search engines
+type Sitemap struct {
+	XMLName xml.Name     `xml:"urlset" swaggerignore:"true"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []SitemapURL `xml:"url"`
+}
+
+type SitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// NewSitemap returns the pages of the items, each last modified when its item was updated
+func NewSitemap(items []PublicFeedItem) Sitemap {
+	sitemap := Sitemap{
+		XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		URLs:  make([]SitemapURL, len(items)),
+	}
+	for i, item := range items {
+		sitemap.URLs[i] = SitemapURL{Loc: item.URL, LastMod: item.UpdatedAt.UTC().Format(time.DateOnly)}
+	}
+	return sitemap
+}
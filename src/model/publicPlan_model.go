@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Languages the public plan list formats prices in, Indonesian by default
+var PublicPlanLanguages = []string{"id", "en"}
+
+// PublicPlan is a plan as the marketing website shows it, without anything only admins or subscribers see
+type PublicPlan struct {
+	ID             uuid.UUID       `json:"id"`
+	Name           string          `json:"name"`
+	Description    string          `json:"description"`
+	Price          int             `json:"price"` // in the minor unit of Currency
+	Currency       string          `json:"currency"`
+	PriceFormatted string          `json:"price_formatted"`
+	Features       map[string]bool `json:"features"`
+	IsRecommended  bool            `json:"is_recommended"`
+	ValidityDays   int             `json:"validity_days"`
+	// Usage limits, -1 for unlimited, left out of plans that keep them private
+	AIscanLimit      *int `json:"ai_scan_limit,omitempty"`
+	ChatMessageLimit *int `json:"chat_message_limit,omitempty"`
+	VoiceLogLimit    *int `json:"voice_log_limit,omitempty"`
+	// Installment info, only set when the plan can be paid monthly
+	AllowInstallments         bool       `json:"allow_installments"`
+	InstallmentCount          int        `json:"installment_count,omitempty"`
+	InstallmentPriceFormatted string     `json:"installment_price_formatted,omitempty"`
+	AvailableUntil            *time.Time `json:"available_until,omitempty"`
+}
+
+// NewPublicPlan turns a plan of the in-app list into its public form with prices written for readers of lang
+func NewPublicPlan(plan *SubscriptionPlanResponse, limitsPrivate bool, lang string) PublicPlan {
+	public := PublicPlan{
+		ID:                plan.ID,
+		Name:              plan.Name,
+		Description:       plan.Description,
+		Price:             plan.Price,
+		Currency:          plan.Currency,
+		PriceFormatted:    Money{Amount: int64(plan.Price), Currency: plan.Currency}.Localized(lang),
+		Features:          plan.Features,
+		IsRecommended:     plan.IsRecommended,
+		ValidityDays:      plan.ValidityDays,
+		AllowInstallments: plan.AllowInstallments,
+		InstallmentCount:  plan.InstallmentCount,
+		AvailableUntil:    plan.AvailableUntil,
+	}
+	if plan.InstallmentPrice > 0 {
+		public.InstallmentPriceFormatted = Money{Amount: int64(plan.InstallmentPrice), Currency: plan.Currency}.Localized(lang)
+	}
+	if !limitsPrivate {
+		public.AIscanLimit = &plan.AIscanLimit
+		public.ChatMessageLimit = &plan.ChatMessageLimit
+		public.VoiceLogLimit = &plan.VoiceLogLimit
+	}
+	return public
+}
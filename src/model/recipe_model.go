@@ -8,18 +8,19 @@ import (
 )
 
 type Recipe struct {
-	ID           uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
-	UserID       uuid.UUID `gorm:"not null" json:"user_id"`
-	Name         string    `gorm:"not null" json:"name"`
-	Slug         string    `gorm:"unique;not null" json:"slug"`
-	Image        *string   `json:"image,omitempty"`
-	Description  string    `gorm:"type:text;not null" json:"description"`
-	Ingredients  string    `gorm:"type:text;not null" json:"ingredients"`
-	Instructions string    `gorm:"type:text;not null" json:"instructions"`
-	Label        *string   `json:"label,omitempty"`
-	Day          string    `gorm:"type:varchar(10);not null;check(day IN ('sunday', 'monday', 'tuesday', 'wednesday', 'thursday', 'friday', 'saturday'))" json:"day"`
-	CreatedAt    time.Time `gorm:"autoCreateTime:milli" json:"-"`
-	UpdatedAt    time.Time `gorm:"autoCreateTime:milli;autoUpdateTime:milli" json:"-"`
+	ID           uuid.UUID     `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID       uuid.UUID     `gorm:"not null" json:"user_id"`
+	Name         string        `gorm:"not null" json:"name"`
+	Slug         string        `gorm:"unique;not null" json:"slug"`
+	Image        *ContentImage `json:"image,omitempty"`
+	Description  string        `gorm:"type:text;not null" json:"description"`
+	Ingredients  string        `gorm:"type:text;not null" json:"ingredients"`
+	Instructions string        `gorm:"type:text;not null" json:"instructions"`
+	Label        *string       `json:"label,omitempty"`
+	Day          string        `gorm:"type:varchar(10);not null;check(day IN ('sunday', 'monday', 'tuesday', 'wednesday', 'thursday', 'friday', 'saturday'))" json:"day"`
+	PublishedAt  *time.Time    `gorm:"index" json:"published_at,omitempty"` // curated for the public website from then on
+	CreatedAt    time.Time     `gorm:"autoCreateTime:milli" json:"-"`
+	UpdatedAt    time.Time     `gorm:"autoCreateTime:milli;autoUpdateTime:milli" json:"-"`
 }
 
 func (recipe *Recipe) BeforeCreate(_ *gorm.DB) error {
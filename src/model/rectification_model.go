@@ -0,0 +1,65 @@
+package model
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subsystems holding personal data a rectification corrects
+const (
+	RectificationProfile       = "profile"
+	RectificationMeasurements  = "measurements"   // weight and height history
+	RectificationPaymentProofs = "payment_proofs" // name of the account transfers were sent from
+)
+
+// PersonalDataChange is a corrected value and the value it replaced
+type PersonalDataChange struct {
+	Subsystem string    `json:"subsystem"`
+	RecordID  uuid.UUID `json:"record_id"`
+	Field     string    `json:"field"`
+	Before    any       `json:"before"`
+	After     any       `json:"after"`
+}
+
+// Rectification is a correction of the personal data of a user, made by the user or by an admin on their behalf
+type Rectification struct {
+	UserID        uuid.UUID            `json:"user_id"`
+	RectifiedByID uuid.UUID            `json:"rectified_by_id"`
+	Reason        string               `json:"reason"`
+	Changes       []PersonalDataChange `json:"changes"`
+	RectifiedAt   time.Time            `json:"rectified_at"`
+}
+
+// Record adds the change of a field when after differs from before, pointers are compared by the value
+// they point to. It reports whether the field changed.
+func (rectification *Rectification) Record(subsystem string, recordID uuid.UUID, field string, before, after any) bool {
+	before, after = indirect(before), indirect(after)
+	if reflect.DeepEqual(before, after) {
+		return false
+	}
+
+	rectification.Changes = append(rectification.Changes, PersonalDataChange{
+		Subsystem: subsystem,
+		RecordID:  recordID,
+		Field:     field,
+		Before:    before,
+		After:     after,
+	})
+	return true
+}
+
+func indirect(value any) any {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
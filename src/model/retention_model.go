@@ -0,0 +1,104 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Retention rules, each one is enforced by the daily retention job once its period is set
+const (
+	RetentionScanImages       = "scan_images"       // meal photos are dropped, the meals stay
+	RetentionLogs             = "logs"              // log rows and rolled over log files are deleted
+	RetentionInactiveAccounts = "inactive_accounts" // accounts lose their personal data, their anonymous history stays
+	RetentionActivityLogs     = "activity_logs"     // the audit trail of activities is deleted
+	RetentionLoginLocations   = "login_locations"   // activities lose their IP address and logins their country, region and city, the rows stay
+)
+
+var RetentionRules = []string{RetentionScanImages, RetentionLogs, RetentionInactiveAccounts, RetentionActivityLogs, RetentionLoginLocations}
+
+// LoginLocationKeys are the details of a login activity that tell where it came from
+var LoginLocationKeys = []string{"country", "region", "city"}
+
+// RetentionLogTable is a table of log rows and the column holding when each one was written
+type RetentionLogTable struct {
+	Table  string
+	Column string
+}
+
+// RetentionLogTables are the tables the logs rule purges
+var RetentionLogTables = []RetentionLogTable{
+	{Table: "deep_link_clicks", Column: "clicked_at"},
+	{Table: "food_searches", Column: "created_at"},
+	{Table: "phone_messages", Column: "created_at"},
+}
+
+// RetentionPolicy is a rule with the period configured for it
+type RetentionPolicy struct {
+	Rule        string    `json:"rule"`
+	Description string    `json:"description"`
+	Months      int       `json:"months,omitempty"`
+	Days        int       `json:"days,omitempty"`
+	Enabled     bool      `json:"enabled"`          // a period is set
+	Cutoff      time.Time `json:"cutoff,omitempty"` // records older than this are past retention
+}
+
+// NewRetentionPolicy computes the cutoff of a rule kept for months and days, a zero period disables it
+func NewRetentionPolicy(rule, description string, months, days int, now time.Time) RetentionPolicy {
+	policy := RetentionPolicy{Rule: rule, Description: description, Months: months, Days: days}
+	if months > 0 || days > 0 {
+		policy.Enabled = true
+		policy.Cutoff = now.AddDate(0, -months, -days)
+	}
+	return policy
+}
+
+// RetentionRun is the audit entry of one rule applied by the retention job or previewed by an admin.
+// Dry runs only count what the rule would change.
+type RetentionRun struct {
+	ID            uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Rule          string     `gorm:"size:30;not null;index" json:"rule"`
+	DryRun        bool       `gorm:"not null" json:"dry_run"`
+	Cutoff        time.Time  `gorm:"not null" json:"cutoff"`
+	Matched       int64      `gorm:"not null;default:0" json:"matched"`  // records past retention when the run started
+	Affected      int64      `gorm:"not null;default:0" json:"affected"` // records deleted or anonymized
+	Error         string     `gorm:"type:text" json:"error,omitempty"`
+	TriggeredByID *uuid.UUID `gorm:"type:uuid;default:null" json:"triggered_by_id"` // nil for the daily job
+	StartedAt     time.Time  `gorm:"not null;index" json:"started_at"`
+	FinishedAt    time.Time  `gorm:"not null" json:"finished_at"`
+}
+
+func (run *RetentionRun) BeforeCreate(_ *gorm.DB) error {
+	run.ID = uuid.New()
+	return nil
+}
+
+// RetentionReport is the outcome of applying every enabled rule once
+type RetentionReport struct {
+	DryRun bool           `json:"dry_run"`
+	Runs   []RetentionRun `json:"runs"`
+}
+
+// AnonymizedUserFields are the updates that remove the personal data of a user. The email stays unique
+// and cannot receive mail, the empty password matches no login.
+func AnonymizedUserFields(userID uuid.UUID, at time.Time) map[string]any {
+	return map[string]any{
+		"name":                       "Deleted user",
+		"email":                      fmt.Sprintf("anonymized-%s@anonymized.invalid", userID),
+		"password":                   "",
+		"verified_email":             false,
+		"profile_picture":            nil,
+		"google_id_token":            nil,
+		"phone":                      nil,
+		"verified_phone":             false,
+		"birth_date":                 nil,
+		"medical_history":            nil,
+		"handle":                     nil,
+		"handle_changed_at":          nil,
+		"email_undeliverable_at":     nil,
+		"email_undeliverable_reason": "",
+		"anonymized_at":              at,
+	}
+}
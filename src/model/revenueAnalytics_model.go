@@ -0,0 +1,127 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Periods revenue analytics are aggregated by
+const (
+	RevenueIntervalDay   = "day"
+	RevenueIntervalWeek  = "week"
+	RevenueIntervalMonth = "month"
+)
+
+// RevenueBucket is what the aggregate queries return for one period, each filling its own counts
+type RevenueBucket struct {
+	Start              time.Time
+	Revenue            int64
+	Payments           int64
+	NewSubscribers     int64
+	ChurnedSubscribers int64
+}
+
+// RevenuePeriod is the revenue and the subscribers won and lost in one period
+type RevenuePeriod struct {
+	Period             string `json:"period"` // first day of the period, YYYY-MM-DD
+	Revenue            int64  `json:"revenue"`
+	Payments           int64  `json:"payments"`
+	NewSubscribers     int64  `json:"new_subscribers"`
+	ChurnedSubscribers int64  `json:"churned_subscribers"`
+}
+
+// RevenuePlan is the revenue a plan collected
+type RevenuePlan struct {
+	PlanID   uuid.UUID `json:"plan_id"`
+	Name     string    `json:"name"`
+	Revenue  int64     `json:"revenue"`
+	Payments int64     `json:"payments"`
+}
+
+// RevenueAnalytics is the revenue collected between two days and the subscribers won and lost, per period and
+// in total, with the monthly recurring revenue of the subscriptions active now
+type RevenueAnalytics struct {
+	From               string          `json:"from"`
+	To                 string          `json:"to"`
+	Interval           string          `json:"interval"`
+	Currency           string          `json:"currency"`
+	MRR                int64           `json:"mrr"`
+	ActiveSubscribers  int64           `json:"active_subscribers"`
+	Revenue            int64           `json:"revenue"`
+	Payments           int64           `json:"payments"`
+	NewSubscribers     int64           `json:"new_subscribers"`
+	ChurnedSubscribers int64           `json:"churned_subscribers"`
+	Plans              []RevenuePlan   `json:"plans"`
+	Periods            []RevenuePeriod `json:"periods"`
+}
+
+// RevenuePeriodStart is the start of the period of interval t falls in, weeks start on Monday as in Postgres
+func RevenuePeriodStart(t time.Time, interval string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case RevenueIntervalWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case RevenueIntervalMonth:
+		return MonthStart(day)
+	}
+	return day
+}
+
+// BuildRevenueAnalytics lays the buckets of the aggregate queries out over every period from the one of from
+// to the one of to, periods without any are zero
+func BuildRevenueAnalytics(from, to time.Time, interval string, buckets []RevenueBucket, plans []RevenuePlan) *RevenueAnalytics {
+	analytics := &RevenueAnalytics{
+		From:     from.Format(time.DateOnly),
+		To:       to.Format(time.DateOnly),
+		Interval: interval,
+		Currency: CurrencyIDR,
+		Plans:    plans,
+		Periods:  []RevenuePeriod{},
+	}
+	if analytics.Plans == nil {
+		analytics.Plans = []RevenuePlan{}
+	}
+
+	byStart := make(map[time.Time]*RevenueBucket)
+	for i := range buckets {
+		start := RevenuePeriodStart(buckets[i].Start, interval)
+		bucket, ok := byStart[start]
+		if !ok {
+			bucket = &RevenueBucket{Start: start}
+			byStart[start] = bucket
+		}
+		bucket.Revenue += buckets[i].Revenue
+		bucket.Payments += buckets[i].Payments
+		bucket.NewSubscribers += buckets[i].NewSubscribers
+		bucket.ChurnedSubscribers += buckets[i].ChurnedSubscribers
+	}
+
+	for start := RevenuePeriodStart(from, interval); !start.After(to); start = nextRevenuePeriod(start, interval) {
+		period := RevenuePeriod{Period: start.Format(time.DateOnly)}
+		if bucket, ok := byStart[start]; ok {
+			period.Revenue = bucket.Revenue
+			period.Payments = bucket.Payments
+			period.NewSubscribers = bucket.NewSubscribers
+			period.ChurnedSubscribers = bucket.ChurnedSubscribers
+		}
+
+		analytics.Revenue += period.Revenue
+		analytics.Payments += period.Payments
+		analytics.NewSubscribers += period.NewSubscribers
+		analytics.ChurnedSubscribers += period.ChurnedSubscribers
+		analytics.Periods = append(analytics.Periods, period)
+	}
+
+	return analytics
+}
+
+func nextRevenuePeriod(start time.Time, interval string) time.Time {
+	switch interval {
+	case RevenueIntervalWeek:
+		return start.AddDate(0, 0, 7)
+	case RevenueIntervalMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
@@ -0,0 +1,177 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RevenueRecognition is the part of a payment recognized as revenue in one month of its service period
+type RevenueRecognition struct {
+	ID                  uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	TransactionDetailID uuid.UUID `gorm:"not null;uniqueIndex:idx_revenue_transaction_month" json:"transaction_detail_id"`
+	UserSubscriptionID  uuid.UUID `gorm:"not null;index" json:"user_subscription_id"`
+	Month               time.Time `gorm:"type:date;not null;uniqueIndex:idx_revenue_transaction_month;index" json:"month"` // first day of the month
+	Amount              int       `gorm:"not null" json:"amount"`
+	Currency            string    `gorm:"size:3;not null;default:IDR" json:"currency"`
+	PaidAt              time.Time `gorm:"not null;index" json:"paid_at"`
+	CreatedAt           time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (revenueRecognition *RevenueRecognition) BeforeCreate(_ *gorm.DB) error {
+	revenueRecognition.ID = uuid.New()
+	return nil
+}
+
+// RevenueAggregate is the amount paid in one month and recognized in another
+type RevenueAggregate struct {
+	PaidMonth time.Time
+	Month     time.Time
+	Amount    int
+}
+
+// RevenueReportMonth summarizes revenue for one month, Deferred is the balance still to be recognized at month end
+type RevenueReportMonth struct {
+	Month      string `json:"month"`
+	Collected  int    `json:"collected"`
+	Recognized int    `json:"recognized"`
+	Deferred   int    `json:"deferred"`
+}
+
+// RevenueReport is the recognized vs deferred revenue for a range of months
+type RevenueReport struct {
+	From            string               `json:"from"`
+	To              string               `json:"to"`
+	TotalCollected  int                  `json:"total_collected"`
+	TotalRecognized int                  `json:"total_recognized"`
+	Months          []RevenueReportMonth `json:"months"`
+}
+
+// Transaction statuses that moved money in, and the ones of declined, cancelled or expired attempts
+var (
+	PaidTransactionStatuses   = []string{"capture", "settlement", "success"}
+	FailedTransactionStatuses = []string{"deny", "cancel", "expire", "failure", "failed"}
+)
+
+// IsPaid reports whether the transaction moved money in, as opposed to a pending or failed attempt
+func (t *TransactionDetail) IsPaid() bool {
+	return slices.Contains(PaidTransactionStatuses, t.TransactionStatus)
+}
+
+// IsFailed reports whether the payment attempt was declined, cancelled or expired
+func (t *TransactionDetail) IsFailed() bool {
+	return slices.Contains(FailedTransactionStatuses, t.TransactionStatus)
+}
+
+// Amount is the gross amount in the minor unit of the transaction currency
+func (t *TransactionDetail) Amount() int {
+	return t.Money().Int()
+}
+
+// Money is the gross amount in the currency of the transaction, gateways that send none charge Rupiah
+func (t *TransactionDetail) Money() Money {
+	currency := t.Currency
+	if currency == "" {
+		currency = CurrencyIDR
+	}
+
+	amount, err := ParseMoney(t.GrossAmount, currency)
+	if err != nil {
+		return Money{Currency: currency}
+	}
+	return amount
+}
+
+// MonthStart returns the first day of the month of t
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// BuildRecognitionSchedule spreads a paid transaction over the remaining service period of its subscription,
+// sandbox payments are not revenue.
+// Each month gets a share proportional to its time in the period, rounding differences go to the first month.
+func BuildRecognitionSchedule(detail *TransactionDetail, subscription *UserSubscription) []RevenueRecognition {
+	amount := detail.Money()
+	if !detail.IsPaid() || detail.IsSandbox || amount.Amount <= 0 {
+		return nil
+	}
+
+	// Months are calendar months in UTC
+	paidAt := detail.TransactionTime.UTC()
+	start := subscription.StartDate.UTC()
+	if paidAt.After(start) {
+		start = paidAt
+	}
+	end := subscription.EndDate.UTC()
+
+	entry := func(month time.Time, share Money) RevenueRecognition {
+		return RevenueRecognition{
+			TransactionDetailID: detail.ID,
+			UserSubscriptionID:  subscription.ID,
+			Month:               month,
+			Amount:              share.Int(),
+			Currency:            share.Currency,
+			PaidAt:              paidAt,
+		}
+	}
+
+	// Paid after the period ended, everything is recognized right away
+	if !end.After(start) {
+		return []RevenueRecognition{entry(MonthStart(start), amount)}
+	}
+
+	var months []time.Time
+	var seconds []int64
+	for month := MonthStart(start); month.Before(end); month = month.AddDate(0, 1, 0) {
+		segmentStart := month
+		if start.After(segmentStart) {
+			segmentStart = start
+		}
+		segmentEnd := month.AddDate(0, 1, 0)
+		if end.Before(segmentEnd) {
+			segmentEnd = end
+		}
+
+		months = append(months, month)
+		seconds = append(seconds, int64(segmentEnd.Sub(segmentStart).Seconds()))
+	}
+
+	schedule := make([]RevenueRecognition, 0, len(months))
+	for i, share := range amount.Allocate(seconds) {
+		schedule = append(schedule, entry(months[i], share))
+	}
+	return schedule
+}
+
+// BuildRevenueReport rolls aggregated recognition entries into a monthly report between from and to, inclusive
+func BuildRevenueReport(aggregates []RevenueAggregate, from, to time.Time) *RevenueReport {
+	report := &RevenueReport{
+		From:   from.Format("2006-01"),
+		To:     to.Format("2006-01"),
+		Months: []RevenueReportMonth{},
+	}
+
+	for month := MonthStart(from); !month.After(to); month = month.AddDate(0, 1, 0) {
+		summary := RevenueReportMonth{Month: month.Format("2006-01")}
+
+		for _, aggregate := range aggregates {
+			if aggregate.PaidMonth.Equal(month) {
+				summary.Collected += aggregate.Amount
+			}
+			if aggregate.Month.Equal(month) {
+				summary.Recognized += aggregate.Amount
+			}
+			if !aggregate.PaidMonth.After(month) && aggregate.Month.After(month) {
+				summary.Deferred += aggregate.Amount
+			}
+		}
+
+		report.TotalCollected += summary.Collected
+		report.TotalRecognized += summary.Recognized
+		report.Months = append(report.Months, summary)
+	}
+
+	return report
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"regexp"
+	"slices"
+	"time"
+)
+
+// roleName is the form of custom role names: lowercase letters, digits and underscores, starting with a letter
+var roleName = regexp.MustCompile(`^[a-z][a-z0-9_]{2,49}$`)
+
+// reservedRoleName is taken by GET /admin/roles/rights
+const reservedRoleName = "rights"
+
+// Role is a role admins created, granting some of the rights of config.Rights. The built-in roles of
+// config.RoleRights are not stored and cannot be changed.
+type Role struct {
+	Name        string    `gorm:"primaryKey;size:50" json:"name"`
+	Description string    `gorm:"size:255" json:"description"`
+	Rights      []string  `gorm:"type:jsonb;serializer:json;not null" json:"rights"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// RoleView is a role users can hold, built-in or custom, with how many hold it
+type RoleView struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Rights      []string `json:"rights"`
+	BuiltIn     bool     `json:"built_in"`
+	Users       int64    `json:"users"`
+}
+
+// ValidRoleName reports whether name has the form of a custom role name
+func ValidRoleName(name string) bool {
+	return roleName.MatchString(name) && name != reservedRoleName
+}
+
+// NormalizeRights sorts rights and drops duplicates, and returns the ones that are not in known
+func NormalizeRights(rights, known []string) (normalized, unknown []string) {
+	for _, right := range rights {
+		if !slices.Contains(known, right) {
+			unknown = append(unknown, right)
+			continue
+		}
+		normalized = append(normalized, right)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), unknown
+}
+
+// CustomRoleRights returns the rights of the stored roles by name. A stored role named like one of builtIn cannot
+// shadow it and is left out, its name is returned in ignored.
+func CustomRoleRights(roles []Role, builtIn map[string][]string) (custom map[string][]string, ignored []string) {
+	custom = make(map[string][]string, len(roles))
+	for _, role := range roles {
+		if _, ok := builtIn[role.Name]; ok {
+			ignored = append(ignored, role.Name)
+			continue
+		}
+		custom[role.Name] = role.Rights
+	}
+	return custom, ignored
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RuntimeConfigFlag is a tunable with the value in use, which is the environment's unless an admin overrode it
+type RuntimeConfigFlag struct {
+	Key         string     `json:"key"`
+	Description string     `json:"description"`
+	Kind        string     `json:"kind"` // int or bool
+	Min         *int       `json:"min,omitempty"`
+	Max         *int       `json:"max,omitempty"`
+	Value       string     `json:"value"`
+	Default     string     `json:"default"`
+	Overridden  bool       `json:"overridden"`
+	UpdatedByID *uuid.UUID `json:"updated_by_id,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// ConfigChange records an admin changing a runtime flag
+type ConfigChange struct {
+	ID          uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Key         string    `gorm:"size:100;not null;index" json:"key"`
+	OldValue    string    `gorm:"type:text;not null" json:"old_value"`
+	NewValue    string    `gorm:"type:text;not null" json:"new_value"`
+	Reset       bool      `gorm:"not null;default:false" json:"reset"` // the override was removed, NewValue is the default
+	Reason      string    `gorm:"size:255" json:"reason"`
+	ChangedByID uuid.UUID `gorm:"type:uuid;not null" json:"changed_by_id"`
+	ChangedAt   time.Time `gorm:"not null;index" json:"changed_at"`
+}
+
+func (change *ConfigChange) BeforeCreate(_ *gorm.DB) error {
+	change.ID = uuid.New()
+	return nil
+}
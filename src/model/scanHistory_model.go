@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScanProviderLogMeal is the food recognition service the scans go to
+const ScanProviderLogMeal = "logmeal"
+
+// ScanItem is a food recognized on one area of a scanned image, Name is the most probable of its candidates
+type ScanItem struct {
+	Name       string   `json:"name"`
+	Candidates []string `json:"candidates"`
+}
+
+// ScanHistory is one AI scan of a user. It keeps what was recognized when the meal it created is edited or deleted,
+// the nutrients are the provider's estimate for the whole image.
+type ScanHistory struct {
+	ID              uuid.UUID  `gorm:"primaryKey" json:"id"`
+	UserID          uuid.UUID  `gorm:"not null;index:idx_scan_histories_user_created,priority:1" json:"-"`
+	MealHistoryID   *uuid.UUID `json:"meal_history_id,omitempty"`
+	Provider        string     `gorm:"size:20;not null" json:"provider"`
+	ProviderImageID string     `gorm:"size:50" json:"-"`
+	Items           []ScanItem `gorm:"type:jsonb;serializer:json;not null" json:"items"`
+	Calories        float64    `gorm:"type:decimal(8,2);not null" json:"calories"`
+	Protein         float64    `gorm:"type:decimal(8,2);not null" json:"protein"`
+	Carbs           float64    `gorm:"type:decimal(8,2);not null" json:"carbs"`
+	Fat             float64    `gorm:"type:decimal(8,2);not null" json:"fat"`
+	CreatedAt       time.Time  `gorm:"not null;index:idx_scan_histories_user_created,priority:2" json:"created_at"`
+}
+
+// ScanItemsOf turns the candidate names per image area, most probable first, into scan items.
+// Areas nothing was recognized on are left out.
+func ScanItemsOf(foods [][]string) []ScanItem {
+	items := []ScanItem{}
+	for _, candidates := range foods {
+		if len(candidates) == 0 {
+			continue
+		}
+		items = append(items, ScanItem{Name: candidates[0], Candidates: candidates})
+	}
+	return items
+}
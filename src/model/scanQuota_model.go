@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScanQuota is the AI scan allowance of a subscription when a scan starts
+type ScanQuota struct {
+	SubscriptionID uuid.UUID
+	Limit          int // -1 for unlimited
+	Used           int
+}
+
+// IsUnlimited reports whether the plan has no scan limit
+func (q *ScanQuota) IsUnlimited() bool {
+	return q.Limit < 0
+}
+
+// Remaining is the number of scans left, -1 when unlimited
+func (q *ScanQuota) Remaining() int {
+	if q.IsUnlimited() {
+		return -1
+	}
+	return max(q.Limit-q.Used, 0)
+}
+
+// Exhausted reports whether another scan would exceed the limit
+func (q *ScanQuota) Exhausted() bool {
+	return !q.IsUnlimited() && q.Used >= q.Limit
+}
+
+// ScanUsage is what a user used of the AI scans of their subscription. Every renewal starts a subscription
+// period with its own count, the count resets at ResetsAt.
+type ScanUsage struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	PlanName       string    `json:"plan_name"`
+	Limit          int       `json:"limit"` // -1 for unlimited
+	Used           int       `json:"used"`
+	Remaining      int       `json:"remaining"` // -1 when unlimited
+	Unlimited      bool      `json:"unlimited"`
+	PeriodStart    time.Time `json:"period_start"`
+	ResetsAt       time.Time `json:"resets_at"`
+}
+
+// Usage is the usage of the quota over the subscription period from start to end
+func (q *ScanQuota) Usage(planName string, start, end time.Time) ScanUsage {
+	return ScanUsage{
+		SubscriptionID: q.SubscriptionID,
+		PlanName:       planName,
+		Limit:          q.Limit,
+		Used:           q.Used,
+		Remaining:      q.Remaining(),
+		Unlimited:      q.IsUnlimited(),
+		PeriodStart:    start,
+		ResetsAt:       end,
+	}
+}
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Indexes of the search index
+const (
+	SearchIndexFoods   = "foods"
+	SearchIndexContent = "content"
+)
+
+// Types of content found by content search
+const (
+	ContentTypeArticle = "article"
+	ContentTypeRecipe  = "recipe"
+)
+
+// FoodDocument is a food in the search index with its localized names, so a search in the index needs
+// neither the food service nor the database
+type FoodDocument struct {
+	Kode      string       `json:"kode"`
+	Food      BahanMakanan `json:"food"`
+	Names     []FoodName   `json:"names"`
+	NamesID   []string     `json:"names_id"` // every Indonesian name, the food composition table's first
+	NamesEN   []string     `json:"names_en"`
+	IndexedAt time.Time    `json:"indexed_at"`
+}
+
+func NewFoodDocument(food BahanMakanan, names []FoodName, indexedAt time.Time) FoodDocument {
+	doc := FoodDocument{
+		Kode:      food.Kode,
+		Food:      food,
+		Names:     names,
+		NamesID:   []string{food.NamaBahanMakanan},
+		NamesEN:   []string{},
+		IndexedAt: indexedAt,
+	}
+	for _, name := range names {
+		switch name.Language {
+		case FoodLanguageIndonesian:
+			doc.NamesID = append(doc.NamesID, name.Name)
+		case FoodLanguageEnglish:
+			doc.NamesEN = append(doc.NamesEN, name.Name)
+		}
+	}
+	return doc
+}
+
+// ContentDocument is an article or a recipe in the search index
+type ContentDocument struct {
+	Type        string     `json:"type"`
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Slug        string     `json:"slug"`
+	Image       *string    `json:"image,omitempty"`
+	Category    string     `json:"category,omitempty"`
+	Body        string     `json:"body"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	IndexedAt   time.Time  `json:"indexed_at"`
+}
+
+// DocumentID is the ID of the document in the index, articles and recipes share it
+func (doc ContentDocument) DocumentID() string {
+	return doc.Type + ":" + doc.ID.String()
+}
+
+func NewArticleDocument(article Article, category string, indexedAt time.Time) ContentDocument {
+	return ContentDocument{
+		Type:        ContentTypeArticle,
+		ID:          article.ID,
+		Title:       article.Title,
+		Slug:        article.Slug,
+		Image:       (*string)(article.Image), // the index keeps stored addresses, results are signed
+		Category:    category,
+		Body:        article.Content,
+		PublishedAt: article.PublishedAt,
+		IndexedAt:   indexedAt,
+	}
+}
+
+func NewRecipeDocument(recipe Recipe, indexedAt time.Time) ContentDocument {
+	return ContentDocument{
+		Type:      ContentTypeRecipe,
+		ID:        recipe.ID,
+		Title:     recipe.Name,
+		Slug:      recipe.Slug,
+		Image:     (*string)(recipe.Image),
+		Body:      strings.Join([]string{recipe.Description, recipe.Ingredients, recipe.Instructions}, "\n"),
+		IndexedAt: indexedAt,
+	}
+}
+
+// ContentSearchResult is an article or a recipe found by content search
+type ContentSearchResult struct {
+	Type    string        `json:"type"`
+	ID      uuid.UUID     `json:"id"`
+	Title   string        `json:"title"`
+	Slug    string        `json:"slug"`
+	Image   *ContentImage `json:"image,omitempty"`
+	Snippet string        `json:"snippet"`
+	Score   float64       `json:"score"`
+}
+
+// contentSnippetLength is the number of characters of a snippet
+const contentSnippetLength = 160
+
+// ContentSnippet cuts the part of body around the first word of the search, or its start when the
+// search is not in it
+func ContentSnippet(body, query string) string {
+	body = strings.Join(strings.Fields(body), " ")
+	if utf8.RuneCountInString(body) <= contentSnippetLength {
+		return body
+	}
+
+	runes := []rune(body)
+	start := 0
+	if words := strings.Fields(strings.ToLower(query)); len(words) > 0 {
+		lower := strings.ToLower(body)
+		if at := strings.Index(lower, words[0]); at >= 0 {
+			start = max(utf8.RuneCountInString(lower[:at])-contentSnippetLength/4, 0)
+		}
+	}
+	end := min(start+contentSnippetLength, len(runes))
+	start = max(end-contentSnippetLength, 0)
+
+	snippet := string(runes[start:end])
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// Categories storage usage is reported for
+const (
+	StorageScanImages    = "scan_images"
+	StorageAvatars       = "avatars"
+	StorageContentImages = "content_images"
+	StoragePaymentProofs = "payment_proofs"
+	StorageUnreferenced  = "unreferenced" // files under /uploads no record refers to, the media janitor removes them
+	StorageDiaryExports  = "diary_exports"
+	StorageBackups       = "backups"
+)
+
+var StorageCategories = []string{
+	StorageScanImages, StorageAvatars, StorageContentImages, StoragePaymentProofs, StorageUnreferenced,
+	StorageDiaryExports, StorageBackups,
+}
+
+// bytesPerTB is the unit storage is priced in
+const bytesPerTB = 1_000_000_000_000
+
+// StorageUsageSnapshot is what a category of stored files took up on a day, measured once a day
+type StorageUsageSnapshot struct {
+	Day        time.Time `gorm:"type:date;primaryKey" json:"day"`
+	Category   string    `gorm:"size:30;primaryKey" json:"category"`
+	Objects    int64     `gorm:"not null;default:0" json:"objects"`
+	Bytes      int64     `gorm:"not null;default:0" json:"bytes"`
+	MeasuredAt time.Time `gorm:"not null" json:"measured_at"`
+}
+
+// StorageCategoryUsage is what a category takes up on the last measured day and how much it grew over the report
+type StorageCategoryUsage struct {
+	Category    string `json:"category"`
+	Objects     int64  `json:"objects"`
+	Bytes       int64  `json:"bytes"`
+	GrowthBytes int64  `json:"growth_bytes"`
+}
+
+// StorageUsageDay is the storage every category took up together on a day
+type StorageUsageDay struct {
+	Day     time.Time `json:"day"`
+	Objects int64     `json:"objects"`
+	Bytes   int64     `json:"bytes"`
+}
+
+// StorageUsageReport is the storage used per category with its daily trend, and a forecast of it and of its cost
+// at the average daily growth of the trend
+type StorageUsageReport struct {
+	MeasuredAt    *time.Time             `json:"measured_at"`
+	Categories    []StorageCategoryUsage `json:"categories"`
+	TotalObjects  int64                  `json:"total_objects"`
+	TotalBytes    int64                  `json:"total_bytes"`
+	Trend         []StorageUsageDay      `json:"trend"`
+	DailyGrowth   int64                  `json:"daily_growth_bytes"`
+	ForecastDays  int                    `json:"forecast_days"`
+	ForecastBytes int64                  `json:"forecast_bytes"`
+	MonthlyCost   Money                  `json:"monthly_cost"`
+	ForecastCost  Money                  `json:"forecast_monthly_cost"`
+}
+
+// NewStorageUsageReport reports the snapshots of a range of days, forecasting forecastDays after the last one.
+// Costs are the bytes priced at pricePerTB a month.
+func NewStorageUsageReport(snapshots []StorageUsageSnapshot, forecastDays int, pricePerTB Money) StorageUsageReport {
+	report := StorageUsageReport{
+		Categories:   []StorageCategoryUsage{},
+		Trend:        []StorageUsageDay{},
+		ForecastDays: forecastDays,
+		MonthlyCost:  Money{Currency: pricePerTB.Currency},
+		ForecastCost: Money{Currency: pricePerTB.Currency},
+	}
+	if len(snapshots) == 0 {
+		return report
+	}
+
+	days := map[time.Time]*StorageUsageDay{}
+	first := map[string]*StorageUsageSnapshot{}
+	last := map[string]*StorageUsageSnapshot{}
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		day, ok := days[snapshot.Day]
+		if !ok {
+			day = &StorageUsageDay{Day: snapshot.Day}
+			days[snapshot.Day] = day
+		}
+		day.Objects += snapshot.Objects
+		day.Bytes += snapshot.Bytes
+
+		if previous, ok := last[snapshot.Category]; !ok || snapshot.Day.After(previous.Day) {
+			last[snapshot.Category] = snapshot
+		}
+		if previous, ok := first[snapshot.Category]; !ok || snapshot.Day.Before(previous.Day) {
+			first[snapshot.Category] = snapshot
+		}
+	}
+
+	for _, day := range days {
+		report.Trend = append(report.Trend, *day)
+	}
+	sort.Slice(report.Trend, func(i, j int) bool { return report.Trend[i].Day.Before(report.Trend[j].Day) })
+
+	// The categories are reported as of the last day, one missing from it is gone
+	lastDay := report.Trend[len(report.Trend)-1]
+	for _, category := range StorageCategories {
+		snapshot, ok := last[category]
+		if !ok || !snapshot.Day.Equal(lastDay.Day) {
+			continue
+		}
+		report.Categories = append(report.Categories, StorageCategoryUsage{
+			Category:    category,
+			Objects:     snapshot.Objects,
+			Bytes:       snapshot.Bytes,
+			GrowthBytes: snapshot.Bytes - first[category].Bytes,
+		})
+		if report.MeasuredAt == nil || snapshot.MeasuredAt.After(*report.MeasuredAt) {
+			measuredAt := snapshot.MeasuredAt
+			report.MeasuredAt = &measuredAt
+		}
+	}
+	report.TotalObjects = lastDay.Objects
+	report.TotalBytes = lastDay.Bytes
+
+	firstDay := report.Trend[0]
+	if elapsed := daysBetween(firstDay.Day, lastDay.Day); elapsed > 0 {
+		report.DailyGrowth = (lastDay.Bytes - firstDay.Bytes) / int64(elapsed)
+	}
+	report.ForecastBytes = max(report.TotalBytes+report.DailyGrowth*int64(forecastDays), 0)
+
+	report.MonthlyCost.Amount = StorageCost(report.TotalBytes, pricePerTB.Amount)
+	report.ForecastCost.Amount = StorageCost(report.ForecastBytes, pricePerTB.Amount)
+	return report
+}
+
+// StorageCost is the monthly cost of bytes at pricePerTB a month, rounded up to the minor unit
+func StorageCost(bytes, pricePerTB int64) int64 {
+	return (bytes*pricePerTB + bytesPerTB - 1) / bytesPerTB
+}
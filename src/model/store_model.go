@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	StoreApple  = "apple"
+	StoreGoogle = "google"
+)
+
+// StoreProduct maps an App Store or Google Play subscription product to a plan
+type StoreProduct struct {
+	ID        uuid.UUID         `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	Store     string            `gorm:"size:10;not null;uniqueIndex:idx_store_product" json:"store"`
+	ProductID string            `gorm:"size:255;not null;uniqueIndex:idx_store_product" json:"product_id"`
+	PlanID    uuid.UUID         `gorm:"not null" json:"plan_id"`
+	Plan      *SubscriptionPlan `gorm:"foreignKey:PlanID" json:"plan,omitempty"`
+	CreatedAt time.Time         `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// StorePurchase links a subscription to the store purchase that pays for it
+type StorePurchase struct {
+	ID                    uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserSubscriptionID    uuid.UUID `gorm:"not null;uniqueIndex" json:"user_subscription_id"`
+	UserID                uuid.UUID `gorm:"not null;index" json:"user_id"`
+	Store                 string    `gorm:"size:10;not null;uniqueIndex:idx_store_purchase" json:"store"`
+	ProductID             string    `gorm:"size:255;not null" json:"product_id"`
+	OriginalTransactionID string    `gorm:"size:512;not null;uniqueIndex:idx_store_purchase" json:"original_transaction_id"`
+	LatestTransactionID   string    `gorm:"size:255" json:"latest_transaction_id"`
+	ExpiresAt             time.Time `json:"expires_at"`
+	AutoRenew             bool      `json:"auto_renew"`
+	Environment           string    `gorm:"size:20" json:"environment"`
+	CreatedAt             time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt             time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (storeProduct *StoreProduct) BeforeCreate(_ *gorm.DB) error {
+	storeProduct.ID = uuid.New()
+	return nil
+}
+
+func (storePurchase *StorePurchase) BeforeCreate(_ *gorm.DB) error {
+	storePurchase.ID = uuid.New()
+	return nil
+}
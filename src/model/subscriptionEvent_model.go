@@ -0,0 +1,87 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Lifecycle statuses of a subscription
+const (
+	SubscriptionPending   = "pending"   // waiting for its first payment, held or failed payments included
+	SubscriptionActive    = "active"    // paid and running
+	SubscriptionExpired   = "expired"   // ran until its end date
+	SubscriptionCancelled = "cancelled" // ended early, refunded or revoked, it never runs again
+	SubscriptionSuspended = "suspended" // paid but blocked, an overdue installment for instance
+)
+
+// subscriptionTransitions are the statuses a subscription may move to from each status
+var subscriptionTransitions = map[string][]string{
+	SubscriptionPending:   {SubscriptionActive, SubscriptionCancelled},
+	SubscriptionActive:    {SubscriptionExpired, SubscriptionCancelled, SubscriptionSuspended},
+	SubscriptionSuspended: {SubscriptionActive, SubscriptionCancelled, SubscriptionExpired},
+	SubscriptionExpired:   {SubscriptionActive},
+}
+
+// CanTransitionSubscription reports whether a subscription may move from one status to another. A new
+// subscription, without a status yet, may start in any of them.
+func CanTransitionSubscription(from, to string) bool {
+	if from == "" {
+		_, known := subscriptionTransitions[to]
+		return known || to == SubscriptionCancelled
+	}
+	for _, next := range subscriptionTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// LifecycleStatus is the status the payment fields of the subscription call for at now. Payment statuses it
+// does not know leave the status as it is.
+func (userSubscription *UserSubscription) LifecycleStatus(now time.Time) string {
+	switch userSubscription.PaymentStatus {
+	case "success":
+		switch {
+		case !userSubscription.IsActive:
+			return SubscriptionCancelled
+		case !now.Before(userSubscription.EndDate):
+			return SubscriptionExpired
+		default:
+			return SubscriptionActive
+		}
+	case "pending", "on_hold", "failed":
+		// A failed payment can still be retried on the same order
+		return SubscriptionPending
+	case "suspended":
+		if !now.Before(userSubscription.EndDate) {
+			return SubscriptionExpired
+		}
+		return SubscriptionSuspended
+	case "refunded":
+		return SubscriptionCancelled
+	case "expired":
+		return SubscriptionExpired
+	default:
+		return userSubscription.Status
+	}
+}
+
+// SubscriptionEvent records a subscription moving from one status to another, FromStatus is empty when it was
+// created
+type SubscriptionEvent struct {
+	ID                 uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserSubscriptionID uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_subscription_id"`
+	FromStatus         string     `gorm:"size:20" json:"from_status"`
+	ToStatus           string     `gorm:"size:20;not null" json:"to_status"`
+	Reason             string     `gorm:"type:text" json:"reason"`
+	ActorID            *uuid.UUID `gorm:"type:uuid" json:"actor_id,omitempty"` // user or admin whose request moved it, empty for gateways and jobs
+	OccurredAt         time.Time  `gorm:"not null;index" json:"occurred_at"`
+}
+
+func (event *SubscriptionEvent) BeforeCreate(_ *gorm.DB) error {
+	event.ID = uuid.New()
+	return nil
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -10,12 +12,25 @@ type SubscriptionPlanResponse struct {
 	ID             uuid.UUID       `json:"id"`
 	Name           string          `json:"name"`
 	Price          int             `json:"price"`
+	Currency       string          `json:"currency"`
 	PriceFormatted string          `json:"price_formatted"`
 	Description    string          `json:"description"`
 	Features       map[string]bool `json:"features"`
 	IsRecommended  bool            `json:"is_recommended"`
 	ValidityDays   int             `json:"validity_days"`
 	AIscanLimit    int             `json:"ai_scan_limit"`
+	// Messages to the assistant per day, -1 for unlimited
+	ChatMessageLimit int `json:"chat_message_limit"`
+	// Voice logs per subscription, 0 when the plan has no voice logging, -1 for unlimited
+	VoiceLogLimit int `json:"voice_log_limit"`
+	// Installment info, only set when the plan can be paid monthly
+	AllowInstallments bool `json:"allow_installments"`
+	InstallmentCount  int  `json:"installment_count,omitempty"`
+	InstallmentPrice  int  `json:"installment_price,omitempty"`
+	// End of the availability window for limited-time plans
+	AvailableUntil *time.Time `json:"available_until,omitempty"`
+	// Days of the free trial new users may start, 0 when the plan has none
+	TrialDays int `json:"trial_days"`
 }
 
 // SubscriptionPlanWithUsers adalah model untuk plan dengan users
@@ -23,6 +38,7 @@ type SubscriptionPlanWithUsers struct {
 	ID             uuid.UUID                  `json:"id"`
 	Name           string                     `json:"name"`
 	Price          int                        `json:"price"`
+	Currency       string                     `json:"currency"`
 	PriceFormatted string                     `json:"price_formatted"`
 	Description    string                     `json:"description"`
 	AIscanLimit    int                        `json:"ai_scan_limit"`
@@ -31,6 +47,11 @@ type SubscriptionPlanWithUsers struct {
 	IsActive       bool                       `json:"is_active"`
 	Users          []UserSubscriptionResponse `json:"users,omitempty"`
 	UserCount      int                        `json:"user_count"`
+	// Set on plans deleted while in use
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	// Set on plans retired in favour of a replacement
+	SunsetAt          *time.Time `json:"sunset_at,omitempty"`
+	ReplacementPlanID *uuid.UUID `json:"replacement_plan_id,omitempty"`
 }
 
 func (subscriptionPlanResponse *SubscriptionPlanResponse) BeforeCreate(_ *gorm.DB) error {
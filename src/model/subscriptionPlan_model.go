@@ -8,18 +8,72 @@ import (
 )
 
 type SubscriptionPlan struct {
-	ID           uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()"`
-	Name         string    `gorm:"not null"`
-	Price        int       `gorm:"not null"` // in Rupiah
-	Description  string
-	AIscanLimit  int       `gorm:"not null"` // -1 for unlimited
-	ValidityDays int       `gorm:"not null"` // in days
-	Features     string    `gorm:"type:jsonb"`
-	IsActive     bool      `gorm:"default:true"`
-	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	ID                uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()"`
+	Name              string    `gorm:"not null"`
+	Price             int       `gorm:"not null"` // in the minor unit of Currency
+	Currency          string    `gorm:"size:3;not null;default:IDR"`
+	Description       string
+	AIscanLimit       int          `gorm:"not null"` // -1 for unlimited
+	ValidityDays      int          `gorm:"not null"` // in days
+	Features          PlanFeatures `gorm:"type:jsonb;serializer:json"`
+	IsActive          bool         `gorm:"default:true"`
+	AllowInstallments bool         `gorm:"default:false"`
+	InstallmentCount  int          `gorm:"default:0"` // number of monthly installments, e.g. 12 for annual plans
+	CreatedAt         time.Time    `gorm:"autoCreateTime"`
+	// Availability window for limited-time plans, nil means unbounded
+	AvailableFrom  *time.Time `gorm:"default:null"`
+	AvailableUntil *time.Time `gorm:"default:null"`
+	// Hidden plans are left out of the public list but can still be bought by direct ID or promo link
+	Hidden bool `gorm:"default:false"`
+	// The marketing website does not show the usage limits of plans that keep them private
+	LimitsPrivate bool `gorm:"not null;default:false"`
+	// Messages a subscriber may send the assistant per day, -1 for unlimited
+	ChatMessageLimit int `gorm:"not null;default:30"`
+	// Voice logs a subscriber may send per subscription, 0 when the plan has no voice logging, -1 for unlimited
+	VoiceLogLimit int `gorm:"not null;default:30"`
+	// Set when an admin deleted the plan while subscriptions still referred to it, archived plans stay inactive and hidden
+	ArchivedAt *time.Time `gorm:"default:null"`
+	// Set when an admin retired the plan: it cannot be bought anymore and its subscribers move to the
+	// replacement plan when they renew
+	SunsetAt          *time.Time `gorm:"default:null"`
+	ReplacementPlanID *uuid.UUID `gorm:"type:uuid;default:null;index"`
+	// Days of the free trial new users may start on the plan, 0 when it has none
+	TrialDays int `gorm:"not null;default:0"`
 }
 
 func (subscriptionPlan *SubscriptionPlan) BeforeCreate(_ *gorm.DB) error {
 	subscriptionPlan.ID = uuid.New()
 	return nil
 }
+
+// PriceMoney is the price with its currency
+func (subscriptionPlan *SubscriptionPlan) PriceMoney() Money {
+	return Money{Amount: int64(subscriptionPlan.Price), Currency: subscriptionPlan.Currency}
+}
+
+// IsAvailableAt reports whether the plan can be purchased at the given time
+func (subscriptionPlan *SubscriptionPlan) IsAvailableAt(now time.Time) bool {
+	if !subscriptionPlan.IsActive || subscriptionPlan.ArchivedAt != nil || subscriptionPlan.SunsetAt != nil {
+		return false
+	}
+	if subscriptionPlan.AvailableFrom != nil && now.Before(*subscriptionPlan.AvailableFrom) {
+		return false
+	}
+	if subscriptionPlan.AvailableUntil != nil && !now.Before(*subscriptionPlan.AvailableUntil) {
+		return false
+	}
+	return true
+}
+
+// CanTrialAt reports whether a free trial of the plan can be started at the given time
+func (subscriptionPlan *SubscriptionPlan) CanTrialAt(now time.Time) bool {
+	return subscriptionPlan.TrialDays > 0 && subscriptionPlan.IsAvailableAt(now)
+}
+
+// RenewsTo is the plan subscriptions on the plan renew on, the replacement of a sunset plan
+func (subscriptionPlan *SubscriptionPlan) RenewsTo() uuid.UUID {
+	if subscriptionPlan.SunsetAt != nil && subscriptionPlan.ReplacementPlanID != nil {
+		return *subscriptionPlan.ReplacementPlanID
+	}
+	return subscriptionPlan.ID
+}
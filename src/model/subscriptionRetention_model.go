@@ -0,0 +1,131 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionRetentionCell is what the cohort query returns: the users of a cohort with a paid subscription
+// running at some point of the month Offset months after the cohort month
+type SubscriptionRetentionCell struct {
+	Cohort time.Time
+	Offset int
+	Users  int64
+}
+
+// SubscriptionCohort is the users whose first paid subscription started in a month, and how many of them still
+// had one running in the months after. Months not reached yet are left out.
+type SubscriptionCohort struct {
+	Cohort      string                    `json:"cohort"` // YYYY-MM
+	Subscribers int64                     `json:"subscribers"`
+	Retention   []SubscriptionCohortMonth `json:"retention"`
+}
+
+// SubscriptionCohortMonth is the share of a cohort with a paid subscription running Month months after the
+// cohort month, month 0 is the cohort month itself. Rate is nil for empty cohorts.
+type SubscriptionCohortMonth struct {
+	Month    int      `json:"month"`
+	Retained int64    `json:"retained"`
+	Rate     *float64 `json:"rate"`
+}
+
+// PlanChurnRow is what the churn query returns for a plan
+type PlanChurnRow struct {
+	PlanID       uuid.UUID
+	Name         string
+	Ended        int64
+	Churned      int64
+	LifetimeDays float64 // of the churned subscribers together
+}
+
+// PlanChurn is how many paid subscriptions of a plan ended, how many of them without another one running on,
+// and how long the churned subscribers had paid subscriptions in all. The rate and the lifetime are nil without
+// any.
+type PlanChurn struct {
+	PlanID              uuid.UUID `json:"plan_id"`
+	Name                string    `json:"name"`
+	Ended               int64     `json:"ended"`
+	Churned             int64     `json:"churned"`
+	ChurnRate           *float64  `json:"churn_rate"`
+	AverageLifetimeDays *float64  `json:"average_lifetime_days"`
+}
+
+// SubscriptionRetention is the retention of the subscriber cohorts of a range of months and the churn of the
+// subscriptions that ended in it
+type SubscriptionRetention struct {
+	From                string               `json:"from"`
+	To                  string               `json:"to"`
+	Cohorts             []SubscriptionCohort `json:"cohorts"`
+	Plans               []PlanChurn          `json:"plans"`
+	Ended               int64                `json:"ended"`
+	Churned             int64                `json:"churned"`
+	ChurnRate           *float64             `json:"churn_rate"`
+	AverageLifetimeDays *float64             `json:"average_lifetime_days"`
+}
+
+// BuildSubscriptionRetention lays out the cohorts of every month from from to to, following each for up to months
+// months that are over or running at now, and rates the churn of the plans
+func BuildSubscriptionRetention(cells []SubscriptionRetentionCell, plans []PlanChurnRow, from, to time.Time, months int, now time.Time) *SubscriptionRetention {
+	retention := &SubscriptionRetention{
+		From:    from.Format("2006-01"),
+		To:      to.Format("2006-01"),
+		Cohorts: []SubscriptionCohort{},
+		Plans:   []PlanChurn{},
+	}
+
+	type cellKey struct {
+		cohort time.Time
+		offset int
+	}
+	users := make(map[cellKey]int64, len(cells))
+	for _, cell := range cells {
+		users[cellKey{MonthStart(cell.Cohort.UTC()), cell.Offset}] += cell.Users
+	}
+
+	current := MonthStart(now.UTC())
+	for month := MonthStart(from); !month.After(to); month = month.AddDate(0, 1, 0) {
+		cohort := SubscriptionCohort{
+			Cohort:      month.Format("2006-01"),
+			Subscribers: users[cellKey{month, 0}],
+			Retention:   []SubscriptionCohortMonth{},
+		}
+		for offset := 0; offset <= months && !month.AddDate(0, offset, 0).After(current); offset++ {
+			retained := users[cellKey{month, offset}]
+			cohort.Retention = append(cohort.Retention, SubscriptionCohortMonth{
+				Month:    offset,
+				Retained: retained,
+				Rate:     share(float64(retained), cohort.Subscribers),
+			})
+		}
+		retention.Cohorts = append(retention.Cohorts, cohort)
+	}
+
+	var lifetimeDays float64
+	for _, row := range plans {
+		retention.Plans = append(retention.Plans, PlanChurn{
+			PlanID:              row.PlanID,
+			Name:                row.Name,
+			Ended:               row.Ended,
+			Churned:             row.Churned,
+			ChurnRate:           share(float64(row.Churned), row.Ended),
+			AverageLifetimeDays: share(row.LifetimeDays, row.Churned),
+		})
+		retention.Ended += row.Ended
+		retention.Churned += row.Churned
+		lifetimeDays += row.LifetimeDays
+	}
+	retention.ChurnRate = share(float64(retention.Churned), retention.Ended)
+	retention.AverageLifetimeDays = share(lifetimeDays, retention.Churned)
+
+	return retention
+}
+
+// share is value over count, nil when there is nothing to divide by
+func share(value float64, count int64) *float64 {
+	if count == 0 {
+		return nil
+	}
+	result := value / float64(count)
+	return &result
+}
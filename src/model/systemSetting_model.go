@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// System setting keys
+const (
+	SettingMaintenanceMode      = "maintenance_mode" // "on" or "off"
+	SettingMaintenanceMessage   = "maintenance_message"
+	SettingKPIReportedOn        = "ops_kpi_reported_on" // date of the last daily KPI report, YYYY-MM-DD
+	SettingCountersReconciledOn = "user_counters_reconciled_on"
+	SettingArchivedOn           = "records_archived_on"
+	SettingRetentionAppliedOn   = "retention_applied_on"
+	SettingFoodGrading          = "food_grading" // JSON of the grading config, the default without it
+	SettingDailyTipsEmailedOn   = "daily_tips_emailed_on"
+	SettingCohortsAggregatedOn  = "cohorts_aggregated_on"
+	SettingMediaCleanedOn       = "media_cleaned_on"
+	SettingStorageMeasuredOn    = "storage_measured_on"
+
+	// SettingConfigPrefix starts the keys of the runtime flags admins override, e.g. config.retention_dry_run
+	SettingConfigPrefix = "config."
+)
+
+// SystemSetting is a runtime switch that admins can change without a deploy
+type SystemSetting struct {
+	Key         string     `gorm:"primaryKey;size:100" json:"key"`
+	Value       string     `gorm:"type:text;not null;default:''" json:"value"`
+	UpdatedByID *uuid.UUID `gorm:"default:null" json:"updated_by_id,omitempty"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// MaintenanceStatus tells clients whether the app is down for maintenance
+type MaintenanceStatus struct {
+	Enabled   bool       `json:"enabled"`
+	Message   string     `json:"message,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Formats transaction logs can be exported in
+const (
+	TransactionExportCSV  = "csv"
+	TransactionExportXLSX = "xlsx"
+)
+
+// TransactionExportRow is a transaction log flattened with its subscriber and plan for an export
+type TransactionExportRow struct {
+	ID                 uuid.UUID
+	OrderID            string
+	TransactionID      string
+	TransactionTime    time.Time
+	TransactionStatus  string
+	PaymentType        string
+	GrossAmount        string
+	Currency           string
+	IsSandbox          bool
+	SettlementTime     *time.Time
+	UserSubscriptionID uuid.UUID
+	UserID             *uuid.UUID
+	UserEmail          *string
+	PlanID             *uuid.UUID
+	PlanName           *string
+	CreatedAt          time.Time
+}
+
+// TransactionExportHeader are the columns of an export
+var TransactionExportHeader = []string{
+	"id", "order_id", "transaction_id", "transaction_time", "status", "payment_type", "gross_amount", "currency",
+	"is_sandbox", "settlement_time", "user_subscription_id", "user_id", "user_email", "plan_id", "plan_name", "created_at",
+}
+
+// TransactionExportAmountColumn is the index of gross_amount, the only numeric column
+const TransactionExportAmountColumn = 6
+
+// Record returns the values of the row in the order of TransactionExportHeader, times in RFC 3339 UTC
+func (row *TransactionExportRow) Record() []string {
+	var settlementTime string
+	if row.SettlementTime != nil {
+		settlementTime = row.SettlementTime.UTC().Format(time.RFC3339)
+	}
+	optionalID := func(id *uuid.UUID) string {
+		if id == nil {
+			return ""
+		}
+		return id.String()
+	}
+	optional := func(text *string) string {
+		if text == nil {
+			return ""
+		}
+		return *text
+	}
+
+	return []string{
+		row.ID.String(),
+		row.OrderID,
+		row.TransactionID,
+		row.TransactionTime.UTC().Format(time.RFC3339),
+		row.TransactionStatus,
+		row.PaymentType,
+		row.GrossAmount,
+		row.Currency,
+		strconv.FormatBool(row.IsSandbox),
+		settlementTime,
+		row.UserSubscriptionID.String(),
+		optionalID(row.UserID),
+		optional(row.UserEmail),
+		optionalID(row.PlanID),
+		optional(row.PlanName),
+		row.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// CSVRecord is Record escaped so spreadsheets run no value as a formula, the amount is left a number
+func (row *TransactionExportRow) CSVRecord() []string {
+	record := row.Record()
+	for i, value := range record {
+		if i != TransactionExportAmountColumn {
+			record[i] = csvText(value)
+		}
+	}
+	return record
+}
+
+// TransactionExportFilename is the name an export made at now is downloaded as
+func TransactionExportFilename(format string, now time.Time) string {
+	return fmt.Sprintf("nutribox-transactions-%s.%s", now.Format("20060102-150405"), format)
+}
+
+// TransactionExportContentType is the media type of an export in format
+func TransactionExportContentType(format string) string {
+	if format == TransactionExportXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
@@ -25,6 +25,7 @@ type TransactionDetail struct {
 	GrossAmount        string `gorm:"size:20"`
 	Currency           string `gorm:"size:10"`
 	FraudStatus        string `gorm:"size:20"`
+	IsSandbox          bool   `gorm:"not null;default:false;index"` // paid through the gateway sandbox, never recognized as revenue
 
 	// Credit Card specific fields
 	MaskedCard             *string `gorm:"size:50"`
@@ -53,9 +54,18 @@ type TransactionDetail struct {
 	// Settlement info
 	SettlementTime *time.Time
 
+	// Manual bank transfer fields
+	ProofImageURL   *string    `gorm:"size:255"`
+	ReferenceNumber *string    `gorm:"size:100"`
+	RecordedByID    *uuid.UUID `gorm:"default:null"`
+	Notes           *string
+
 	// Raw response for debugging
 	RawResponse JSON `gorm:"type:jsonb"`
 
+	// The plan the transaction paid for as it was sold, for disputes
+	PlanSnapshot *PlanSnapshot `gorm:"type:jsonb;serializer:json"`
+
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 }
 
@@ -12,12 +12,27 @@ type UserSubscriptionResponse struct {
 	UserID        uuid.UUID                `json:"user_id"`
 	Plan          SubscriptionPlanResponse `json:"plan"`
 	AIscansUsed   int                      `json:"ai_scans_used"`
+	VoiceLogsUsed int                      `json:"voice_logs_used"`
 	StartDate     time.Time                `json:"start_date"`
 	EndDate       time.Time                `json:"end_date"`
 	IsActive      bool                     `json:"is_active"`
 	PaymentMethod string                   `json:"payment_method"`
 	PaymentStatus string                   `json:"payment_status"`
+	Status        string                   `json:"status"`
+	Source        string                   `json:"source"`
+	SourceRef     string                   `json:"source_reference,omitempty"`
+	SourceData    JSON                     `json:"source_metadata,omitempty" swaggertype:"object"`
+	IsInstallment bool                     `json:"is_installment"`
+	WalletAmount  int                      `json:"wallet_amount_applied"`
+	IsSandbox     bool                     `json:"is_sandbox"`
 	CreatedAt     time.Time                `json:"created_at"`
+	Store         *StorePurchase           `json:"store,omitempty"`
+	AutoRenew     bool                     `json:"auto_renew"`
+	MaskedCard    string                   `json:"masked_card,omitempty"` // card auto-renewals are charged to
+	RenewalOfID   *uuid.UUID               `json:"renewal_of_id,omitempty"`
+	PlanSnapshot  *PlanSnapshot            `json:"plan_snapshot,omitempty"` // the plan as it was sold, Plan follows it
+	TrialStatus   string                   `json:"trial_status,omitempty"`  // active, converted or expired for free trials
+	DeletedAt     *time.Time               `json:"deleted_at,omitempty"`    // set on deleted subscriptions, listed with include_deleted
 }
 
 func (userSubscriptionPlanResponse *UserSubscriptionResponse) BeforeCreate(_ *gorm.DB) error {
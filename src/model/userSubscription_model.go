@@ -1,30 +1,165 @@
 package model
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Acquisition channels of a subscription
+const (
+	SourceMidtrans       = "midtrans"
+	SourceAppleIAP       = "apple_iap"
+	SourceGooglePlay     = "google_play"
+	SourceProductToken   = "product_token"
+	SourceAdminComp      = "admin_comp"
+	SourceManualTransfer = "manual_transfer"
+	SourceTrial          = "trial"
+)
+
+// Outcomes of a free trial once it ended, see TrialStatus
+const (
+	TrialActive    = "active"
+	TrialConverted = "converted" // the user bought a subscription during the trial
+	TrialExpired   = "expired"
+)
+
 type UserSubscription struct {
-	ID            uuid.UUID        `gorm:"primaryKey;default:uuid_generate_v4()"`
-	UserID        uuid.UUID        `gorm:"not null"`
-	User          User             `gorm:"foreignKey:UserID"`
-	PlanID        uuid.UUID        `gorm:"not null"`
-	Plan          SubscriptionPlan `gorm:"foreignKey:PlanID"`
-	AIscansUsed   int              `gorm:"default:0"`
-	StartDate     time.Time        `gorm:"not null"`
-	EndDate       time.Time        `gorm:"not null"`
-	IsActive      bool             `gorm:"default:true"`
-	PaymentMethod string           `gorm:"size:50"`
-	TransactionID string           `gorm:"size:100"`
-	PaymentStatus string           `gorm:"size:50;default:'pending'"`
-	CreatedAt     time.Time        `gorm:"autoCreateTime"`
+	ID                  uuid.UUID        `gorm:"primaryKey;default:uuid_generate_v4()"`
+	UserID              uuid.UUID        `gorm:"not null"`
+	User                User             `gorm:"foreignKey:UserID"`
+	PlanID              uuid.UUID        `gorm:"not null"`
+	Plan                SubscriptionPlan `gorm:"foreignKey:PlanID"`
+	AIscansUsed         int              `gorm:"default:0"`
+	VoiceLogsUsed       int              `gorm:"default:0"`
+	StartDate           time.Time        `gorm:"not null"`
+	EndDate             time.Time        `gorm:"not null"`
+	IsActive            bool             `gorm:"default:true"`
+	PaymentMethod       string           `gorm:"size:50"`
+	TransactionID       string           `gorm:"size:100"`
+	PaymentStatus       string           `gorm:"size:50;default:'pending'"`
+	Status              string           `gorm:"size:20;not null;default:pending;index"` // lifecycle status, see LifecycleStatus
+	StatusChangedAt     *time.Time       `gorm:"default:null"`
+	Source              string           `gorm:"size:30;index"`
+	SourceReference     string           `gorm:"size:512;index"` // product token ID, store original transaction ID, ...
+	SourceMetadata      JSON             `gorm:"type:jsonb" swaggertype:"object"`
+	IsInstallment       bool             `gorm:"default:false"`
+	CheckoutIP          string           `gorm:"size:45"`
+	CheckoutCountry     string           `gorm:"size:2"`
+	WalletAmountApplied int              `gorm:"default:0"`                    // wallet credit used at checkout, the gateway charges the rest
+	IsSandbox           bool             `gorm:"not null;default:false;index"` // bought by a sandbox user, paid through the gateway sandbox
+	CreatedAt           time.Time        `gorm:"autoCreateTime"`
+	StorePurchase       *StorePurchase   `gorm:"foreignKey:UserSubscriptionID"`
+	// Auto-renewal charges the card saved at checkout before the subscription ends, see CanAutoRenew
+	AutoRenew           bool       `gorm:"not null;default:false;index"`
+	SavedTokenID        string     `gorm:"size:255" json:"-"`
+	SavedTokenExpiresAt *time.Time `gorm:"default:null"`
+	MaskedCard          string     `gorm:"size:30"`
+	RenewalOfID         *uuid.UUID `gorm:"type:uuid;default:null;index"` // subscription this one renews
+	// The plan as it was sold when the subscription was activated, see PurchasedPlan
+	PlanSnapshot *PlanSnapshot `gorm:"type:jsonb;serializer:json"`
+	// How a free trial ended, empty while it runs and for subscriptions that are not trials
+	TrialOutcome string `gorm:"size:20;not null;default:'';index"`
+	// Set when an admin deleted the subscription, it keeps its billing history and can be restored. Raw queries
+	// over user_subscriptions leave deleted rows out themselves, except those reading money that was collected.
+	DeletedAt gorm.DeletedAt `gorm:"index" swaggertype:"string" format:"date-time"`
+}
+
+// IsStoreManaged reports whether renewals and cancellations are driven by an app store instead of this backend
+func (userSubscription *UserSubscription) IsStoreManaged() bool {
+	return userSubscription.Source == SourceAppleIAP || userSubscription.Source == SourceGooglePlay
+}
+
+// CanRestoreBeside reports whether the deleted subscription can be restored next to the other subscriptions of
+// its user: not when an active one created after it took its place
+func (userSubscription *UserSubscription) CanRestoreBeside(others []UserSubscription) bool {
+	for _, other := range others {
+		if other.ID != userSubscription.ID && other.IsActive && !other.DeletedAt.Valid &&
+			other.CreatedAt.After(userSubscription.CreatedAt) {
+			return false
+		}
+	}
+	return true
+}
+
+// TrialStatus is the state of a free trial, empty for subscriptions that are not trials
+func (userSubscription *UserSubscription) TrialStatus() string {
+	if userSubscription.Source != SourceTrial {
+		return ""
+	}
+	if userSubscription.TrialOutcome != "" {
+		return userSubscription.TrialOutcome
+	}
+	return TrialActive
+}
+
+// CanAutoRenew reports whether the subscription can be renewed by charging its saved card at now. Installments
+// and store subscriptions are billed their own way.
+func (userSubscription *UserSubscription) CanAutoRenew(now time.Time) bool {
+	return userSubscription.Source == SourceMidtrans && !userSubscription.IsInstallment &&
+		userSubscription.SavedTokenID != "" &&
+		(userSubscription.SavedTokenExpiresAt == nil || now.Before(*userSubscription.SavedTokenExpiresAt))
+}
+
+// RenewalInFlightStatuses are the payment statuses of a renewal that is paid or may still be, no other
+// renewal of the subscription is started while one of them has it
+var RenewalInFlightStatuses = []string{"pending", "success", "on_hold"}
+
+// RenewalPeriod is the period a renewal on plan covers, it starts when the subscription ends
+func (userSubscription *UserSubscription) RenewalPeriod(plan *SubscriptionPlan) (time.Time, time.Time) {
+	return userSubscription.EndDate, userSubscription.EndDate.AddDate(0, 0, plan.ValidityDays)
+}
+
+// RenewalOrderID is the gateway order of an attempt to renew a subscription
+func RenewalOrderID(subscriptionID uuid.UUID, attempt int, now time.Time, sandbox bool) string {
+	return SandboxOrderID(fmt.Sprintf("RENEW-%s-%d-%d", subscriptionID.String()[:8], attempt, now.Unix()), sandbox)
+}
+
+// midtransTimeZone is the zone of the times Midtrans sends without one
+var midtransTimeZone = time.FixedZone("WIB", 7*60*60)
+
+// ParseSavedTokenExpiry reads when a saved card token expires, e.g. "2027-12-31 07:00:00", nil when it cannot
+func ParseSavedTokenExpiry(value string) *time.Time {
+	expiresAt, err := time.ParseInLocation("2006-01-02 15:04:05", value, midtransTimeZone)
+	if err != nil {
+		return nil
+	}
+	return &expiresAt
+}
+
+// SandboxOrderPrefix marks the gateway orders of sandbox checkouts, so their notifications are verified
+// against the sandbox before any subscription is looked up
+const SandboxOrderPrefix = "SBX-"
+
+// SandboxOrderID prefixes the order ID of a sandbox checkout
+func SandboxOrderID(orderID string, sandbox bool) string {
+	if sandbox {
+		return SandboxOrderPrefix + orderID
+	}
+	return orderID
+}
+
+// IsSandboxOrder reports whether a gateway order was placed by a sandbox checkout
+func IsSandboxOrder(orderID string) bool {
+	return strings.HasPrefix(orderID, SandboxOrderPrefix)
+}
+
+// NewSourceMetadata encodes the channel specific details of a subscription
+func NewSourceMetadata(data map[string]interface{}) JSON {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil
+	}
+	return JSON(encoded)
 }
 
 type PurchaseSubscriptionRequest struct {
 	PaymentMethod string `json:"payment_method" validate:"omitempty,oneof=gopay shopeepay bank_transfer credit_card"`
+	Installment   bool   `json:"installment"`
 }
 
 type MidtransCallbackPayload struct {
@@ -43,9 +178,11 @@ type MidtransCallbackPayload struct {
 }
 
 type PaymentResponse struct {
-	TransactionToken string `json:"transaction_token"`
-	RedirectURL      string `json:"redirect_url"`
-	OrderID          string `json:"order_id"`
+	TransactionToken    string `json:"transaction_token"`
+	RedirectURL         string `json:"redirect_url"`
+	OrderID             string `json:"order_id"`
+	WalletAmountApplied int    `json:"wallet_amount_applied"`
+	AmountDue           int    `json:"amount_due"`
 }
 
 func (userSubscription *UserSubscription) BeforeCreate(_ *gorm.DB) error {
@@ -26,21 +26,53 @@ type User struct {
 	Password       string         `gorm:"not null" json:"-"`
 	Role           string         `gorm:"default:user;not null" json:"role"`
 	VerifiedEmail  bool           `gorm:"default:false;not null" json:"verified_email"`
-	ProfilePicture string         `gorm:"default:null" json:"profile_picture"`
+	ProfilePicture Avatar         `gorm:"default:null" json:"profile_picture"`
 	GoogleIDToken  string         `gorm:"default:null" json:"google_id_token"`
-	Phone          string         `gorm:"size:20;default:null" json:"phone"`
+	Phone          string         `gorm:"size:20;default:null;uniqueIndex:idx_users_verified_phone,where:verified_phone" json:"phone"`
+	VerifiedPhone  bool           `gorm:"default:false;not null" json:"verified_phone"`
 	BirthDate      *time.Time     `gorm:"default:null" json:"birth_date"`
 	Height         *float64       `gorm:"type:decimal(5,2);default:null" json:"height"`
 	Weight         *float64       `gorm:"type:decimal(5,2);default:null" json:"weight"`
 	Gender         *GenderType    `gorm:"type:varchar(10);default:null" json:"gender"`
 	ActivityLevel  *ActivityLevel `gorm:"type:varchar(10);default:null" json:"activity_level"`
 	MedicalHistory *string        `gorm:"type:text;default:null" json:"medical_history"`
-	CreatedAt      time.Time      `gorm:"autoCreateTime:milli" json:"-"`
-	UpdatedAt      time.Time      `gorm:"autoCreateTime:milli;autoUpdateTime:milli" json:"-"`
-	Token          []Token        `gorm:"foreignKey:user_id;references:id" json:"-"`
+	// Public name on social features, in place of the email. Unique, lowercase, unset until the user picks one.
+	Handle          *string    `gorm:"size:30;uniqueIndex;default:null" json:"handle"`
+	HandleChangedAt *time.Time `gorm:"default:null" json:"-"` // last rename, for the cooldown
+	// Set while a moderator suspended the account, it cannot authenticate until then
+	SuspendedUntil *time.Time `gorm:"default:null" json:"suspended_until,omitempty"`
+	// Sandbox users are QA accounts, their checkouts go to the gateway sandbox and stay out of revenue
+	IsSandbox bool `gorm:"not null;default:false" json:"is_sandbox"`
+	// Set when the retention job removed the personal data of the inactive account
+	AnonymizedAt *time.Time `gorm:"default:null;index" json:"anonymized_at,omitempty"`
+	// Set while mail to the email bounces for good or was reported as spam, the app asks the user for another one
+	EmailUndeliverableAt     *time.Time `gorm:"default:null" json:"email_undeliverable_at,omitempty"`
+	EmailUndeliverableReason string     `gorm:"size:20;not null;default:''" json:"email_undeliverable_reason,omitempty"`
+	// Whether the user is old enough or a guardian consented, see ParentalConsentFor
+	ParentalConsent string `gorm:"size:20;not null;default:not_required" json:"parental_consent"`
+	// Campaign or channel the user signed up from, see NormalizeAcquisitionSource
+	AcquisitionSource string `gorm:"size:50;default:null;index" json:"acquisition_source,omitempty"`
+	// Denormalized counters, kept up to date by events and corrected by a nightly reconciliation job
+	TotalScans      int        `gorm:"not null;default:0;index" json:"total_scans"`
+	TotalLoggedDays int        `gorm:"not null;default:0" json:"total_logged_days"`
+	CurrentStreak   int        `gorm:"not null;default:0" json:"current_streak"`
+	LifetimeSpend   int64      `gorm:"not null;default:0;index" json:"lifetime_spend"` // cash collected, in Rupiah
+	CountersAt      *time.Time `gorm:"default:null" json:"-"`                          // last correction by the reconciliation job
+	CreatedAt       time.Time  `gorm:"autoCreateTime:milli" json:"-"`
+	UpdatedAt       time.Time  `gorm:"autoCreateTime:milli;autoUpdateTime:milli" json:"-"`
+	Token           []Token    `gorm:"foreignKey:user_id;references:id" json:"-"`
 }
 
+// UserCounterFields are written only by counter updates, full saves of a user must omit them
+var UserCounterFields = []string{"TotalScans", "TotalLoggedDays", "CurrentStreak", "LifetimeSpend", "CountersAt"}
+
 func (user *User) BeforeCreate(_ *gorm.DB) error {
 	user.ID = uuid.New() // Generate UUID before create
 	return nil
 }
+
+// ProfileComplete reports whether the user filled in everything their nutrition needs are computed from
+func (user *User) ProfileComplete() bool {
+	return user.BirthDate != nil && user.Height != nil && *user.Height > 0 && user.Weight != nil && *user.Weight > 0 &&
+		user.Gender != nil && user.ActivityLevel != nil
+}
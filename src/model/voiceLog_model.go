@@ -0,0 +1,187 @@
+package model
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// VoiceLogQuota is the voice log allowance of a subscription when a voice log starts
+type VoiceLogQuota struct {
+	SubscriptionID uuid.UUID `json:"-"`
+	Limit          int       `json:"limit"` // 0 when the plan has no voice logging, -1 for unlimited
+	Used           int       `json:"used"`
+}
+
+// Remaining is the number of voice logs left, -1 when unlimited
+func (q *VoiceLogQuota) Remaining() int {
+	if q.Limit < 0 {
+		return -1
+	}
+	return max(q.Limit-q.Used, 0)
+}
+
+// Exhausted reports whether another voice log would exceed the limit
+func (q *VoiceLogQuota) Exhausted() bool {
+	return q.Limit >= 0 && q.Used >= q.Limit
+}
+
+// SpokenFood is a food named in a voice log with the portion said with it. Quantity is 1 when none was
+// said, Unit is empty when no known unit was.
+type SpokenFood struct {
+	Text     string
+	Name     string
+	Quantity float64
+	Unit     string
+}
+
+// VoiceLogEntry is a proposed diary entry for a food of a voice log, to be confirmed by the user
+type VoiceLogEntry struct {
+	Spoken   string  `json:"spoken"`
+	FoodKode string  `json:"food_kode"`
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+	Grams    float64 `json:"grams"`
+	// Approximate is set when the weight is a guess: the food has no serving for the unit, or no unit was said
+	Approximate bool             `json:"approximate"`
+	Nutrition   PortionNutrition `json:"nutrition"`
+	// SearchID marks the food as picked from this search when the entry is logged
+	SearchID uuid.UUID `json:"search_id"`
+	// Alternatives are other foods the name may mean
+	Alternatives []VoiceLogAlternative `json:"alternatives,omitempty"`
+}
+
+// VoiceLogAlternative is another food a spoken name may mean
+type VoiceLogAlternative struct {
+	FoodKode string `json:"food_kode"`
+	Name     string `json:"name"`
+}
+
+// VoiceLogProposal is what a voice log understood. Nothing is logged until the user confirms the entries.
+type VoiceLogProposal struct {
+	Transcript string          `json:"transcript"`
+	Entries    []VoiceLogEntry `json:"entries"`
+	// Unmatched are the spoken foods no food was found for
+	Unmatched []string         `json:"unmatched"`
+	Total     PortionNutrition `json:"total"`
+	Quota     VoiceLogQuota    `json:"quota"`
+}
+
+// spokenSeparators split a transcript into the foods it names
+var spokenSeparators = regexp.MustCompile(`(?i)[;\n]|[,.]\s+|\s+(?:dan|and|sama|serta|plus|with|dengan|lalu|terus|then|also|juga)\s+`)
+
+// spokenNumbers are the quantities said in words, in Indonesian and English
+var spokenNumbers = map[string]float64{
+	"satu": 1, "dua": 2, "tiga": 3, "empat": 4, "lima": 5, "enam": 6, "tujuh": 7, "delapan": 8, "sembilan": 9,
+	"sepuluh": 10, "setengah": 0.5, "seperempat": 0.25, "separuh": 0.5,
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7, "eight": 8,
+	"nine": 9, "ten": 10, "half": 0.5, "quarter": 0.25,
+}
+
+// spokenUnits are the spoken forms of the portion units, one or two words
+var spokenUnits = map[string]string{
+	"g": PortionGram, "gr": PortionGram, "gram": PortionGram, "grams": PortionGram,
+	"kg": PortionKilogram, "kilo": PortionKilogram, "kilogram": PortionKilogram, "kilograms": PortionKilogram,
+	"ml": PortionMilliliter, "mililiter": PortionMilliliter, "milliliter": PortionMilliliter, "milliliters": PortionMilliliter,
+	"l": PortionLiter, "liter": PortionLiter, "liters": PortionLiter, "litre": PortionLiter,
+	"sendok teh": PortionSendokTeh, "sdt": PortionSendokTeh, "teaspoon": PortionSendokTeh, "teaspoons": PortionSendokTeh, "tsp": PortionSendokTeh,
+	"sendok makan": PortionSendokMakan, "sdm": PortionSendokMakan, "sendok": PortionSendokMakan,
+	"tablespoon": PortionSendokMakan, "tablespoons": PortionSendokMakan, "tbsp": PortionSendokMakan,
+	"gelas": PortionGelas, "glass": PortionGelas, "glasses": PortionGelas, "cup": PortionGelas, "cups": PortionGelas,
+	"mangkok": PortionMangkok, "mangkuk": PortionMangkok, "bowl": PortionMangkok, "bowls": PortionMangkok,
+	"centong": PortionCentong, "scoop": PortionCentong, "scoops": PortionCentong,
+	"piring": PortionPiring, "plate": PortionPiring, "plates": PortionPiring,
+	"potong": PortionPotong, "slice": PortionPotong, "slices": PortionPotong,
+	"butir": PortionButir, "piece": PortionButir, "pieces": PortionButir, "buah": PortionBuah,
+	"bungkus": PortionBungkus, "pack": PortionBungkus, "packs": PortionBungkus,
+}
+
+// spokenFillers are left out before the first food of a part, "tadi saya makan ..." or "for lunch I had ..."
+var spokenFillers = map[string]bool{
+	"tadi": true, "saya": true, "aku": true, "baru": true, "barusan": true, "sudah": true, "udah": true,
+	"makan": true, "minum": true, "sarapan": true, "siang": true, "malam": true, "pagi": true, "ini": true,
+	"i": true, "just": true, "had": true, "ate": true, "have": true, "drank": true, "for": true,
+	"breakfast": true, "lunch": true, "dinner": true, "some": true,
+}
+
+// ParseSpokenFoods splits a transcript into the foods it names with their portions: "dua butir telur
+// rebus dan segelas susu" names 2 butir of telur rebus and 1 gelas of susu
+func ParseSpokenFoods(transcript string) []SpokenFood {
+	var foods []SpokenFood
+	for _, part := range spokenSeparators.Split(transcript, -1) {
+		words := strings.Fields(strings.ToLower(part))
+		for i, word := range words {
+			words[i] = strings.Trim(word, `"'!?().,`)
+		}
+
+		for len(words) > 0 && (spokenFillers[words[0]] || words[0] == "") {
+			words = words[1:]
+		}
+		if len(words) == 0 {
+			continue
+		}
+
+		food := SpokenFood{Text: strings.TrimSpace(part), Quantity: 1}
+		quantity, said := 0.0, false
+		for len(words) > 0 {
+			number, ok := spokenNumber(words[0])
+			if !ok {
+				break
+			}
+			// "satu setengah" is one and a half
+			quantity += number
+			said = true
+			words = words[1:]
+		}
+		if said {
+			food.Quantity = quantity
+		}
+
+		if len(words) > 0 {
+			if unit, rest, ok := spokenUnit(words); ok {
+				food.Unit, words = unit, rest
+			} else if unit, ok := spokenUnits[strings.TrimPrefix(words[0], "se")]; ok && strings.HasPrefix(words[0], "se") && !said {
+				// "sepiring" is one piring
+				food.Unit, words = unit, words[1:]
+			}
+		}
+		for len(words) > 0 && (words[0] == "of" || words[0] == "porsi" || words[0] == "portion") {
+			words = words[1:]
+		}
+
+		food.Name = strings.Join(words, " ")
+		if food.Name == "" {
+			continue
+		}
+		foods = append(foods, food)
+	}
+	return foods
+}
+
+// spokenNumber reads a quantity said in digits, "1,5" included, or in words
+func spokenNumber(word string) (float64, bool) {
+	if number, ok := spokenNumbers[word]; ok {
+		return number, true
+	}
+	number, err := strconv.ParseFloat(strings.Replace(word, ",", ".", 1), 64)
+	if err != nil || number <= 0 {
+		return 0, false
+	}
+	return number, true
+}
+
+// spokenUnit reads a unit of one or two words at the start of words
+func spokenUnit(words []string) (string, []string, bool) {
+	if len(words) > 1 {
+		if unit, ok := spokenUnits[words[0]+" "+words[1]]; ok {
+			return unit, words[2:], true
+		}
+	}
+	if unit, ok := spokenUnits[words[0]]; ok && len(words) > 1 {
+		return unit, words[1:], true
+	}
+	return "", words, false
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	WalletTypeRefund           = "refund"
+	WalletTypeReferral         = "referral"
+	WalletTypePromo            = "promo"
+	WalletTypeAdjustment       = "adjustment"
+	WalletTypeCheckout         = "checkout"
+	WalletTypeCheckoutReversal = "checkout_reversal"
+	WalletTypeProration        = "proration"
+)
+
+// Wallet holds the current credit balance of a user, in Rupiah
+type Wallet struct {
+	ID        uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID    uuid.UUID `gorm:"not null;uniqueIndex" json:"user_id"`
+	Balance   int       `gorm:"not null;default:0" json:"balance"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// WalletTransaction is an immutable ledger entry, positive amounts are credits and negative amounts are debits
+type WalletTransaction struct {
+	ID           uuid.UUID  `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	WalletID     uuid.UUID  `gorm:"not null;index" json:"wallet_id"`
+	UserID       uuid.UUID  `gorm:"not null;index" json:"user_id"`
+	Amount       int        `gorm:"not null" json:"amount"`
+	BalanceAfter int        `gorm:"not null" json:"balance_after"`
+	Type         string     `gorm:"size:30;not null" json:"type"`
+	Description  string     `json:"description"`
+	Reference    string     `gorm:"size:100;index" json:"reference,omitempty"`
+	CreatedByID  *uuid.UUID `gorm:"default:null" json:"created_by_id,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (wallet *Wallet) BeforeCreate(_ *gorm.DB) error {
+	wallet.ID = uuid.New()
+	return nil
+}
+
+func (walletTransaction *WalletTransaction) BeforeCreate(_ *gorm.DB) error {
+	walletTransaction.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,273 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Events outbound webhooks are sent for
+const (
+	WebhookSubscriptionActivated = "subscription.activated"
+	WebhookSubscriptionExpired   = "subscription.expired"
+	WebhookSubscriptionCancelled = "subscription.cancelled"
+	WebhookSubscriptionSuspended = "subscription.suspended"
+	WebhookPaymentFailed         = "payment.failed"
+)
+
+var WebhookEvents = []string{
+	WebhookSubscriptionActivated, WebhookSubscriptionExpired, WebhookSubscriptionCancelled,
+	WebhookSubscriptionSuspended, WebhookPaymentFailed,
+}
+
+// Delivery statuses of a webhook
+const (
+	WebhookDeliveryPending   = "pending"   // waiting for its next attempt
+	WebhookDeliveryDelivered = "delivered" // the endpoint answered 2xx
+	WebhookDeliveryFailed    = "failed"    // every attempt failed, it is not retried
+)
+
+// Statuses of a webhook replay
+const (
+	WebhookReplayRunning   = "running"   // some of its deliveries are still pending
+	WebhookReplayCompleted = "completed" // every delivery was delivered or failed
+)
+
+// Headers of a webhook request. The signature header is t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>">
+// with the secret of the endpoint, receivers should refuse old timestamps to stop replays.
+const (
+	WebhookSignatureHeader = "X-Nutribox-Signature"
+	WebhookEventHeader     = "X-Nutribox-Event"
+	WebhookDeliveryHeader  = "X-Nutribox-Delivery"
+)
+
+// WebhookSecretPrefix starts the secrets generated for endpoints
+const WebhookSecretPrefix = "whsec_"
+
+// WebhookEndpoint is a URL an admin registered to be posted the events it subscribes to
+type WebhookEndpoint struct {
+	ID          uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	URL         string    `gorm:"size:512;not null" json:"url"`
+	Description string    `gorm:"size:255" json:"description"`
+	Secret      string    `gorm:"size:100;not null" json:"-"` // signs the payloads, only shown when it is set
+	Events      []string  `gorm:"type:jsonb;serializer:json;not null" json:"events"`
+	IsActive    bool      `gorm:"not null;default:true" json:"is_active"`
+	CreatedByID uuid.UUID `gorm:"type:uuid;not null" json:"created_by_id"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (endpoint *WebhookEndpoint) BeforeCreate(_ *gorm.DB) error {
+	endpoint.ID = uuid.New()
+	return nil
+}
+
+// Subscribes reports whether the endpoint is sent event
+func (endpoint *WebhookEndpoint) Subscribes(event string) bool {
+	return endpoint.IsActive && slices.Contains(endpoint.Events, event)
+}
+
+// CreatedWebhookEndpoint is an endpoint with its secret, shown once when it is created or the secret is rotated
+type CreatedWebhookEndpoint struct {
+	WebhookEndpoint
+	Secret string `json:"secret"`
+}
+
+// WebhookDelivery is an event posted, or to be posted, to an endpoint, with the outcome of its last attempt
+type WebhookDelivery struct {
+	ID         uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	EndpointID uuid.UUID `gorm:"type:uuid;not null;index" json:"endpoint_id"`
+	Event      string    `gorm:"size:50;not null;index" json:"event"`
+	// EventID is the same for every endpoint sent the event, receivers deduplicate retries with it
+	EventID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"event_id"`
+	Payload        string     `gorm:"type:jsonb;not null" json:"payload"`
+	Status         string     `gorm:"size:20;not null;default:pending;index" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  *time.Time `gorm:"default:null;index" json:"next_attempt_at,omitempty"`
+	LastStatusCode int        `gorm:"not null;default:0" json:"last_status_code,omitempty"` // 0 when the request got no answer
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	LastAttemptAt  *time.Time `gorm:"default:null" json:"last_attempt_at,omitempty"`
+	DeliveredAt    *time.Time `gorm:"default:null" json:"delivered_at,omitempty"`
+	ReplayID       *uuid.UUID `gorm:"type:uuid;default:null;index" json:"replay_id,omitempty"` // the replay that sent the event again
+	CreatedAt      time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (delivery *WebhookDelivery) BeforeCreate(_ *gorm.DB) error {
+	delivery.ID = uuid.New()
+	return nil
+}
+
+// WebhookPayload is the JSON body posted for an event
+type WebhookPayload struct {
+	ID        uuid.UUID   `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+	// Replay is only set on events sent again by a replay, the ID and the creation time stay those of the event
+	Replay *WebhookReplayHint `json:"replay,omitempty"`
+}
+
+// WebhookReplayHint tells a receiver an event was sent again, it may have processed it already under the same ID
+type WebhookReplayHint struct {
+	ID         uuid.UUID `json:"id"`
+	ReplayedAt time.Time `json:"replayed_at"`
+}
+
+// SubscriptionWebhookData is the data of the subscription events
+type SubscriptionWebhookData struct {
+	SubscriptionID uuid.UUID  `json:"subscription_id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	PlanID         uuid.UUID  `json:"plan_id"`
+	Status         string     `json:"status"`
+	PreviousStatus string     `json:"previous_status,omitempty"`
+	Reason         string     `json:"reason,omitempty"`
+	Source         string     `json:"source"`
+	StartDate      time.Time  `json:"start_date"`
+	EndDate        time.Time  `json:"end_date"`
+	RenewalOfID    *uuid.UUID `json:"renewal_of_id,omitempty"`
+	IsSandbox      bool       `json:"is_sandbox"`
+}
+
+// PaymentWebhookData is the data of the payment events
+type PaymentWebhookData struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	PlanID         uuid.UUID `json:"plan_id"`
+	OrderID        string    `json:"order_id"`
+	Amount         int64     `json:"amount"` // in the minor unit of Currency
+	Currency       string    `json:"currency"`
+	Status         string    `json:"status"` // the gateway transaction status, e.g. deny or expire
+	Message        string    `json:"message,omitempty"`
+	IsSandbox      bool      `json:"is_sandbox"`
+}
+
+// WebhookReplay sends the events of a range again to an endpoint, e.g. after it was down, as new deliveries
+type WebhookReplay struct {
+	ID         uuid.UUID `gorm:"primaryKey;default:uuid_generate_v4()" json:"id"`
+	EndpointID uuid.UUID `gorm:"type:uuid;not null;index" json:"endpoint_id"`
+	// From and To bound the creation time of the events sent again
+	From        time.Time `gorm:"not null" json:"from"`
+	To          time.Time `gorm:"not null" json:"to"`
+	Events      []string  `gorm:"type:jsonb;serializer:json" json:"events,omitempty"` // empty for every event the endpoint subscribes to
+	Total       int64     `gorm:"not null;default:0" json:"total"`
+	CreatedByID uuid.UUID `gorm:"type:uuid;not null" json:"created_by_id"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	// Progress of its deliveries, counted when it is read
+	Status    string `gorm:"-" json:"status"`
+	Pending   int64  `gorm:"-" json:"pending"`
+	Delivered int64  `gorm:"-" json:"delivered"`
+	Failed    int64  `gorm:"-" json:"failed"`
+}
+
+func (replay *WebhookReplay) BeforeCreate(_ *gorm.DB) error {
+	replay.ID = uuid.New()
+	return nil
+}
+
+// SetProgress sets the progress of the replay from the number of its deliveries per status
+func (replay *WebhookReplay) SetProgress(deliveries map[string]int64) {
+	replay.Pending = deliveries[WebhookDeliveryPending]
+	replay.Delivered = deliveries[WebhookDeliveryDelivered]
+	replay.Failed = deliveries[WebhookDeliveryFailed]
+	replay.Status = WebhookReplayCompleted
+	if replay.Pending > 0 {
+		replay.Status = WebhookReplayRunning
+	}
+}
+
+// SubscriptionWebhookEvent is the event of a subscription moving to status, false for moves no event is sent for
+func SubscriptionWebhookEvent(status string) (string, bool) {
+	switch status {
+	case SubscriptionActive:
+		return WebhookSubscriptionActivated, true
+	case SubscriptionExpired:
+		return WebhookSubscriptionExpired, true
+	case SubscriptionCancelled:
+		return WebhookSubscriptionCancelled, true
+	case SubscriptionSuspended:
+		return WebhookSubscriptionSuspended, true
+	}
+	return "", false
+}
+
+// NewSubscriptionWebhookData describes a subscription that moved from a status to its current one
+func NewSubscriptionWebhookData(subscription *UserSubscription, from, reason string) SubscriptionWebhookData {
+	return SubscriptionWebhookData{
+		SubscriptionID: subscription.ID,
+		UserID:         subscription.UserID,
+		PlanID:         subscription.PlanID,
+		Status:         subscription.Status,
+		PreviousStatus: from,
+		Reason:         reason,
+		Source:         subscription.Source,
+		StartDate:      subscription.StartDate,
+		EndDate:        subscription.EndDate,
+		RenewalOfID:    subscription.RenewalOfID,
+		IsSandbox:      subscription.IsSandbox,
+	}
+}
+
+// NewPaymentWebhookData describes a failed payment of a subscription
+func NewPaymentWebhookData(subscription *UserSubscription, orderID string, amount Money, status, message string) PaymentWebhookData {
+	return PaymentWebhookData{
+		SubscriptionID: subscription.ID,
+		UserID:         subscription.UserID,
+		PlanID:         subscription.PlanID,
+		OrderID:        orderID,
+		Amount:         amount.Amount,
+		Currency:       amount.Currency,
+		Status:         status,
+		Message:        message,
+		IsSandbox:      subscription.IsSandbox,
+	}
+}
+
+// NewWebhookDeliveries are the deliveries of an event to the endpoints subscribed to it, all sharing one event ID
+func NewWebhookDeliveries(endpoints []WebhookEndpoint, event string, data interface{}, now time.Time) ([]WebhookDelivery, error) {
+	payload := WebhookPayload{ID: uuid.New(), Type: event, CreatedAt: now, Data: data}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []WebhookDelivery
+	for i := range endpoints {
+		if !endpoints[i].Subscribes(event) {
+			continue
+		}
+		deliveries = append(deliveries, WebhookDelivery{
+			EndpointID:    endpoints[i].ID,
+			Event:         event,
+			EventID:       payload.ID,
+			Payload:       string(body),
+			Status:        WebhookDeliveryPending,
+			NextAttemptAt: &now,
+		})
+	}
+	return deliveries, nil
+}
+
+// WebhookSignature is the value of the signature header of body signed with secret at t
+func WebhookSignature(secret string, t time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", t.Unix())
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", t.Unix(), hex.EncodeToString(mac.Sum(nil)))
+}
+
+// WebhookBackoff is the wait before retrying a delivery that failed attempt times: it doubles from base with
+// every attempt up to limit
+func WebhookBackoff(attempt int, base, limit time.Duration) time.Duration {
+	backoff := base
+	for i := 1; i < attempt && backoff < limit; i++ {
+		backoff *= 2
+	}
+	return min(backoff, limit)
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"app/src/requestid"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// httpTimeout bounds every call to the chat APIs
+const httpTimeout = 10 * time.Second
+
+// telegramAPIURL is the Telegram Bot API base, the bot token is appended to it
+const telegramAPIURL = "https://api.telegram.org/bot"
+
+var client = &http.Client{Timeout: httpTimeout}
+
+// Slack posts a plain text message to a Slack incoming webhook
+func Slack(ctx context.Context, webhookURL, text string) error {
+	return postJSON(ctx, webhookURL, map[string]string{"text": text})
+}
+
+// slackSignatureMaxAge rejects replayed Slack requests
+const slackSignatureMaxAge = 5 * time.Minute
+
+// VerifySlackSignature checks the X-Slack-Signature of a request signed with the app signing secret
+func VerifySlackSignature(secret, timestamp, signature string, body []byte, now time.Time) bool {
+	if secret == "" {
+		return false
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > slackSignatureMaxAge || age < -slackSignatureMaxAge {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// TelegramBot sends messages to chats through the Telegram Bot API
+type TelegramBot struct {
+	Token string
+}
+
+// NewTelegramBot returns nil when no token is configured
+func NewTelegramBot(token string) *TelegramBot {
+	if token == "" {
+		return nil
+	}
+	return &TelegramBot{Token: token}
+}
+
+// Send posts a plain text message to a chat, chatID is a numeric ID or an @channel username
+func (b *TelegramBot) Send(ctx context.Context, chatID, text string) error {
+	return postJSON(ctx, telegramAPIURL+b.Token+"/sendMessage", map[string]string{
+		"chat_id": chatID,
+		"text":    text,
+	})
+}
+
+func postJSON(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if id := requestid.From(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notify: unexpected status %d: %s", resp.StatusCode, detail)
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package pagination
+
+// Pagination is where a page of results sits in a list
+type Pagination struct {
+	Page         int   `json:"page"`
+	Limit        int   `json:"limit"`
+	TotalPages   int64 `json:"total_pages"`
+	TotalResults int64 `json:"total_results"`
+}
+
+// Paginate places page, of limit results each, in a list of total results. The last page may be partly filled,
+// a list without results has no pages.
+func Paginate(page, limit int, total int64) Pagination {
+	pagination := Pagination{Page: page, Limit: limit, TotalResults: total}
+	switch {
+	case total <= 0:
+	case limit <= 0:
+		pagination.TotalPages = 1
+	default:
+		pagination.TotalPages = (total + int64(limit) - 1) / int64(limit)
+	}
+	return pagination
+}
+
+// PaginatedResponse is a page of a list
+type PaginatedResponse[T any] struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Results []T    `json:"results"`
+	Pagination
+}
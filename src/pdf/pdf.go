@@ -0,0 +1,143 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 portrait in points
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Fonts of the standard 14 every reader has, text is encoded as WinAnsi
+const (
+	Regular = "F1"
+	Bold    = "F2"
+)
+
+// Document is a text-only PDF: lines of text and rules on A4 pages, with no images or embedded fonts
+type Document struct {
+	pages []*bytes.Buffer
+	info  map[string]string
+}
+
+// New returns a document with one empty page
+func New(title string) *Document {
+	d := &Document{info: map[string]string{"Title": title, "Producer": "Nutribox"}}
+	d.AddPage()
+	return d
+}
+
+// AddPage starts a new page, text and rules go to the last page
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// Pages is the number of pages
+func (d *Document) Pages() int {
+	return len(d.pages)
+}
+
+// Text writes text with its baseline at x, y from the top left corner of the page
+func (d *Document) Text(x, y float64, font string, size float64, text string) {
+	fmt.Fprintf(d.pages[len(d.pages)-1], "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, PageHeight-y, escape(text))
+}
+
+// Rule draws a horizontal line from x1 to x2 at y from the top of the page
+func (d *Document) Rule(x1, x2, y, width float64) {
+	fmt.Fprintf(d.pages[len(d.pages)-1], "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, PageHeight-y, x2, PageHeight-y)
+}
+
+// TextWidth estimates the width of text at size, from the average width of Helvetica characters
+func TextWidth(text string, size float64) float64 {
+	return float64(len([]rune(text))) * size * 0.5
+}
+
+// Fit shortens text with an ellipsis to at most width points
+func Fit(text string, size, width float64) string {
+	runes := []rune(text)
+	limit := int(width / (size * 0.5))
+	if len(runes) <= limit {
+		return text
+	}
+	if limit < 1 {
+		return ""
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// Bytes renders the document
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 catalog, 2 page tree, 3 and 4 fonts, 5 info, then a page and its content per page
+	const firstPage = 6
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (%s) >>", escape(d.info["Title"]), escape(d.info["Producer"])))
+
+	for i, content := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, Regular, Bold, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// winAnsi maps the characters of WinAnsiEncoding above Latin-1 to their codes
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88, '‰': 0x89,
+	'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95,
+	'–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// escape encodes text as WinAnsi in a PDF string, characters it lacks become "?"
+func escape(text string) string {
+	var out strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			out.WriteByte('\\')
+			out.WriteRune(r)
+		case r == '\n' || r == '\r' || r == '\t':
+			out.WriteByte(' ')
+		case r < 0x20:
+		case r < 0x80:
+			out.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&out, "\\%03o", r)
+		default:
+			if code, ok := winAnsi[r]; ok {
+				fmt.Fprintf(&out, "\\%03o", code)
+			} else {
+				out.WriteByte('?')
+			}
+		}
+	}
+	return out.String()
+}
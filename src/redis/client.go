@@ -0,0 +1,273 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultTimeout bounds a command when its context has no deadline
+	defaultTimeout = 2 * time.Second
+
+	// maxIdleConns is the number of connections kept open between commands
+	maxIdleConns = 16
+)
+
+// Error is an error reply of the server, the connection stays usable
+type Error string
+
+func (e Error) Error() string { return "redis: " + string(e) }
+
+// Client sends commands to one Redis server over a small pool of connections
+type Client struct {
+	addr     string
+	username string
+	password string
+	db       int
+	idle     chan *conn
+}
+
+type conn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// New parses a redis://[[user]:password@]host[:port][/db] URL, it returns nil when rawURL is empty.
+// Connections are opened on first use.
+func New(rawURL string) (*Client, error) {
+	if rawURL == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("redis: unsupported scheme %q", u.Scheme)
+	}
+
+	client := &Client{
+		addr: u.Host,
+		idle: make(chan *conn, maxIdleConns),
+	}
+	if u.Port() == "" {
+		client.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		client.username = u.User.Username()
+		client.password, _ = u.User.Password()
+	}
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		if client.db, err = strconv.Atoi(path); err != nil {
+			return nil, fmt.Errorf("redis: invalid database %q", path)
+		}
+	}
+
+	return client, nil
+}
+
+// Do sends a command and returns its reply: nil, int64, string, Error or []any
+func (c *Client) Do(ctx context.Context, args ...any) (any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := cn.do(ctx, args...)
+	if err != nil {
+		var serverErr Error
+		if !errors.As(err, &serverErr) {
+			cn.Close()
+			return nil, err
+		}
+	}
+
+	c.put(cn)
+	return reply, err
+}
+
+// Eval runs a Lua script atomically on the server
+func (c *Client) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	command := make([]any, 0, 3+len(keys)+len(args))
+	command = append(command, "EVAL", script, len(keys))
+	for _, key := range keys {
+		command = append(command, key)
+	}
+	return c.Do(ctx, append(command, args...)...)
+}
+
+// Close closes the idle connections
+func (c *Client) Close() {
+	for {
+		select {
+		case cn := <-c.idle:
+			cn.Close()
+		default:
+			return
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: defaultTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{Conn: netConn, reader: bufio.NewReader(netConn)}
+
+	if c.password != "" {
+		auth := []any{"AUTH", c.password}
+		if c.username != "" {
+			auth = []any{"AUTH", c.username, c.password}
+		}
+		if _, err := cn.do(ctx, auth...); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	if c.db != 0 {
+		if _, err := cn.do(ctx, "SELECT", c.db); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+
+	return cn, nil
+}
+
+func (c *Client) put(cn *conn) {
+	select {
+	case c.idle <- cn:
+	default:
+		cn.Close()
+	}
+}
+
+func (cn *conn) do(ctx context.Context, args ...any) (any, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	if err := cn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "*%d\r\n", len(args))
+	for _, arg := range args {
+		value := fmt.Sprint(arg)
+		fmt.Fprintf(&buf, "$%d\r\n%s\r\n", len(value), value)
+	}
+	if _, err := io.WriteString(cn, buf.String()); err != nil {
+		return nil, err
+	}
+
+	reply, err := readReply(cn.reader)
+	if err != nil {
+		return nil, err
+	}
+	if serverErr, ok := reply.(Error); ok {
+		return nil, serverErr
+	}
+	return reply, nil
+}
+
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return Error(body), nil
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(body)
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(body)
+		if err != nil || count < 0 {
+			return nil, err
+		}
+		items := make([]any, count)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+	}
+}
+
+// Int converts an integer or numeric string reply, a nil reply is zero. It takes the results of Do or Eval.
+func Int(reply any, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := reply.(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		return value, nil
+	case string:
+		return strconv.ParseInt(value, 10, 64)
+	default:
+		return 0, fmt.Errorf("redis: unexpected reply %T", reply)
+	}
+}
+
+// Strings converts an array reply of strings. It takes the results of Do or Eval.
+func Strings(reply any, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := reply.([]any)
+	if !ok && reply != nil {
+		return nil, fmt.Errorf("redis: unexpected reply %T", reply)
+	}
+
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		value, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("redis: unexpected item %T", item)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
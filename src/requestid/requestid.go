@@ -0,0 +1,49 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header carries the request ID on responses, outbound webhooks and calls to other services
+const Header = "X-Request-ID"
+
+// maxLength bounds the IDs accepted from clients
+const maxLength = 64
+
+type contextKey struct{}
+
+// New returns a short random ID such as req-1a2b3c4d, prefix tells requests and job runs apart
+func New(prefix string) string {
+	return prefix + "-" + uuid.New().String()[:8]
+}
+
+// Valid reports whether a client supplied ID can be used as is, it only allows characters
+// that are safe in headers and log lines
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// With returns a context carrying id, work started from it is traced back to the same request
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// From returns the request ID of ctx, or an empty string when it has none
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
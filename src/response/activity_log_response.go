@@ -0,0 +1,9 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithActivityLog struct {
+	Status  string            `json:"status"`
+	Message string            `json:"message"`
+	Data    model.ActivityLog `json:"data"`
+}
@@ -0,0 +1,15 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithAlertRule struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Data    model.AlertRule `json:"data"`
+}
+
+type SuccessWithAlertRules struct {
+	Status  string            `json:"status"`
+	Message string            `json:"message"`
+	Data    []model.AlertRule `json:"data"`
+}
@@ -0,0 +1,21 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithAssistantReply struct {
+	Status  string               `json:"status"`
+	Message string               `json:"message"`
+	Data    model.AssistantReply `json:"data"`
+}
+
+type SuccessWithAssistantQuota struct {
+	Status  string               `json:"status"`
+	Message string               `json:"message"`
+	Data    model.AssistantQuota `json:"data"`
+}
+
+type SuccessWithAssistantConversation struct {
+	Status  string                      `json:"status"`
+	Message string                      `json:"message"`
+	Data    model.AssistantConversation `json:"data"`
+}
@@ -16,3 +16,10 @@ type RefreshToken struct {
 	Status string `json:"status"`
 	Tokens Tokens `json:"tokens"`
 }
+
+type MagicLinkRequested struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	// DeviceCode is kept by the device that asked for the link, the link only signs in with it
+	DeviceCode string `json:"device_code"`
+}
@@ -0,0 +1,15 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithBackup struct {
+	Status  string       `json:"status"`
+	Message string       `json:"message"`
+	Data    model.Backup `json:"data"`
+}
+
+type SuccessWithBackups struct {
+	Status  string         `json:"status"`
+	Message string         `json:"message"`
+	Data    []model.Backup `json:"data"`
+}
@@ -0,0 +1,33 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithCheckoutSession struct {
+	Status  string                `json:"status"`
+	Message string                `json:"message"`
+	Data    model.CheckoutSession `json:"data"`
+}
+
+type SuccessWithCoupon struct {
+	Status  string       `json:"status"`
+	Message string       `json:"message"`
+	Data    model.Coupon `json:"data"`
+}
+
+type SuccessWithPaymentReminder struct {
+	Status  string                `json:"status"`
+	Message string                `json:"message"`
+	Data    model.PaymentReminder `json:"data"`
+}
+
+type SuccessWithPaymentReminders struct {
+	Status  string                  `json:"status"`
+	Message string                  `json:"message"`
+	Data    []model.PaymentReminder `json:"data"`
+}
+
+type SuccessWithCheckoutFunnel struct {
+	Status  string               `json:"status"`
+	Message string               `json:"message"`
+	Data    model.CheckoutFunnel `json:"data"`
+}
@@ -0,0 +1,15 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithTipRule struct {
+	Status  string        `json:"status"`
+	Message string        `json:"message"`
+	Data    model.TipRule `json:"data"`
+}
+
+type SuccessWithTipRules struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Data    []model.TipRule `json:"data"`
+}
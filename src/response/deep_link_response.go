@@ -0,0 +1,15 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithDeepLink struct {
+	Status  string              `json:"status"`
+	Message string              `json:"message"`
+	Data    model.BuiltDeepLink `json:"data"`
+}
+
+type SuccessWithDeepLinkClickStats struct {
+	Status  string                     `json:"status"`
+	Message string                     `json:"message"`
+	Data    []model.DeepLinkClickStats `json:"data"`
+}
@@ -0,0 +1,9 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithDiaryExport struct {
+	Status  string            `json:"status"`
+	Message string            `json:"message"`
+	Data    model.DiaryExport `json:"data"`
+}
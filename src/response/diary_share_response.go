@@ -0,0 +1,21 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithDiaryShare struct {
+	Status  string           `json:"status"`
+	Message string           `json:"message"`
+	Data    model.DiaryShare `json:"data"`
+}
+
+type SuccessWithDiaryShares struct {
+	Status  string             `json:"status"`
+	Message string             `json:"message"`
+	Data    []model.DiaryShare `json:"data"`
+}
+
+type SuccessWithSharedDiary struct {
+	Status  string            `json:"status"`
+	Message string            `json:"message"`
+	Data    model.SharedDiary `json:"data"`
+}
@@ -17,7 +17,11 @@ type AdminSubscriptionPlanWithUsersResponse struct {
 
 // AdminSubscriptionPlansResponse is a Swagger-friendly version of response.SuccessWithSubscriptionPlans
 type AdminSubscriptionPlansResponse struct {
-	Status  string                                   `json:"status"`
-	Message string                                   `json:"message"`
-	Data    []AdminSubscriptionPlanWithUsersResponse `json:"data"`
+	Status       string                                   `json:"status"`
+	Message      string                                   `json:"message"`
+	Data         []AdminSubscriptionPlanWithUsersResponse `json:"data"`
+	Page         int                                      `json:"page" example:"1"`
+	Limit        int                                      `json:"limit" example:"50"`
+	TotalPages   int64                                    `json:"total_pages" example:"1"`
+	TotalResults int64                                    `json:"total_results" example:"4"`
 }
@@ -123,23 +123,28 @@ type DeleteMealResponse struct {
 }
 
 type AddMealRequest struct {
-	Title    string    `json:"title" example:"Nasi Goreng"`
-	MealTime time.Time `json:"meal_time" example:"2023-10-10T12:00:00Z"`
-	Label    *string   `json:"label,omitempty" example:"Lunch"`
-	Calories float64   `json:"calories" example:"500.0"`
-	Protein  float64   `json:"protein" example:"20.0"`
-	Carbs    float64   `json:"carbs" example:"60.0"`
-	Fat      float64   `json:"fat" example:"15.0"`
+	Title           string    `json:"title" example:"Nasi Goreng"`
+	MealTime        time.Time `json:"meal_time" example:"2023-10-10T12:00:00Z"`
+	Label           *string   `json:"label,omitempty" example:"Lunch"`
+	Calories        float64   `json:"calories" example:"500.0"`
+	Protein         float64   `json:"protein" example:"20.0"`
+	Carbs           float64   `json:"carbs" example:"60.0"`
+	Fat             float64   `json:"fat" example:"15.0"`
+	PortionQuantity *float64  `json:"portion_quantity,omitempty" example:"1.5"`
+	PortionUnit     *string   `json:"portion_unit,omitempty" example:"piring"`
+	PortionGrams    *float64  `json:"portion_grams,omitempty" example:"300"`
 }
 
 type UpdateMealRequest struct {
-	Title    string    `json:"title,omitempty" example:"Nasi Goreng Spesial"`
-	MealTime time.Time `json:"meal_time,omitempty" example:"2023-10-10T12:30:00Z"`
-	Label    *string   `json:"label,omitempty" example:"Dinner"`
-	Calories float64   `json:"calories,omitempty" example:"550.0"`
-	Protein  float64   `json:"protein,omitempty" example:"25.0"`
-	Carbs    float64   `json:"carbs,omitempty" example:"65.0"`
-	Fat      float64   `json:"fat,omitempty" example:"18.0"`
+	Title           string    `json:"title,omitempty" example:"Nasi Goreng Spesial"`
+	MealTime        time.Time `json:"meal_time,omitempty" example:"2023-10-10T12:30:00Z"`
+	Label           *string   `json:"label,omitempty" example:"Dinner"`
+	Calories        float64   `json:"calories,omitempty" example:"550.0"`
+	Protein         float64   `json:"protein,omitempty" example:"25.0"`
+	Carbs           float64   `json:"carbs,omitempty" example:"65.0"`
+	Fat             float64   `json:"fat,omitempty" example:"18.0"`
+	PortionQuantity *float64  `json:"portion_quantity,omitempty" example:"200"`
+	PortionUnit     *string   `json:"portion_unit,omitempty" example:"g"`
 }
 
 type NutrientDetail struct {
@@ -157,6 +162,8 @@ type Nutrient struct {
 type MealScanResponse struct {
 	Status   string   `json:"status" example:"success"`
 	Message  string   `json:"message" example:"Meal scanned successfully"`
+	ScanID   string   `json:"scan_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	MealID   string   `json:"meal_id" example:"550e8400-e29b-41d4-a716-446655440000"`
 	Foods    []string `json:"foods" example:"chicken,rice,salad"`
 	Nutrient Nutrient `json:"nutrient"`
 }
@@ -172,15 +179,18 @@ type GetAllMealsResponse struct {
 }
 
 type MealHistory struct {
-	ID       string    `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	UserID   string    `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	Title    string    `json:"title" example:"Scanned Meal"`
-	MealTime time.Time `json:"meal_time" example:"2023-10-01T12:00:00Z"`
-	Label    *string   `json:"label,omitempty" example:"Lunch"`
-	Calories float64   `json:"calories" example:"250.5"`
-	Protein  float64   `json:"protein" example:"30.2"`
-	Carbs    float64   `json:"carbs" example:"45.3"`
-	Fat      float64   `json:"fat" example:"10.1"`
+	ID              string    `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	UserID          string    `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Title           string    `json:"title" example:"Scanned Meal"`
+	MealTime        time.Time `json:"meal_time" example:"2023-10-01T12:00:00Z"`
+	Label           *string   `json:"label,omitempty" example:"Lunch"`
+	Calories        float64   `json:"calories" example:"250.5"`
+	Protein         float64   `json:"protein" example:"30.2"`
+	Carbs           float64   `json:"carbs" example:"45.3"`
+	Fat             float64   `json:"fat" example:"10.1"`
+	PortionQuantity *float64  `json:"portion_quantity,omitempty" example:"200"`
+	PortionUnit     *string   `json:"portion_unit,omitempty" example:"g"`
+	PortionGrams    *float64  `json:"portion_grams,omitempty" example:"200"`
 }
 
 type UsersWeightHeightHistory struct {
@@ -361,39 +371,42 @@ type ArticleCategoryResponse struct {
 }
 
 type CreateRecipeRequest struct {
-	Name         string  `json:"name" example:"Nasi Goreng Spesial"`
-	Slug         string  `json:"slug" example:"nasi-goreng-spesial"`
-	Image        *string `json:"image,omitempty" example:"https://example.com/nasi-goreng.jpg"`
-	Description  string  `json:"description" example:"Nasi goreng dengan bumbu rahasia"`
-	Ingredients  string  `json:"ingredients" example:"Nasi, telur, bawang, kecap"`
-	Instructions string  `json:"instructions" example:"1. Tumis bawang..."`
-	Label        *string `json:"label,omitempty" example:"Main Course"`
-	Day          string  `json:"day" example:"monday"`
+	Name         string     `json:"name" example:"Nasi Goreng Spesial"`
+	Slug         string     `json:"slug" example:"nasi-goreng-spesial"`
+	Image        *string    `json:"image,omitempty" example:"https://example.com/nasi-goreng.jpg"`
+	Description  string     `json:"description" example:"Nasi goreng dengan bumbu rahasia"`
+	Ingredients  string     `json:"ingredients" example:"Nasi, telur, bawang, kecap"`
+	Instructions string     `json:"instructions" example:"1. Tumis bawang..."`
+	Label        *string    `json:"label,omitempty" example:"Main Course"`
+	Day          string     `json:"day" example:"monday"`
+	PublishedAt  *time.Time `json:"published_at,omitempty" example:"2023-10-10T12:00:00Z"`
 }
 
 type UpdateRecipeRequest struct {
-	Name         string  `json:"name,omitempty" example:"Nasi Goreng Premium"`
-	Slug         string  `json:"slug,omitempty" example:"nasi-goreng-premium"`
-	Image        *string `json:"image,omitempty" example:"https://example.com/nasi-goreng-premium.jpg"`
-	Description  string  `json:"description,omitempty" example:"Nasi goreng dengan bumbu premium"`
-	Ingredients  string  `json:"ingredients,omitempty" example:"Nasi, telur, bawang, kecap, ayam"`
-	Instructions string  `json:"instructions,omitempty" example:"1. Tumis bawang... 2. Masukkan ayam..."`
-	Label        *string `json:"label,omitempty" example:"Special Menu"`
-	Day          string  `json:"day,omitempty" example:"tuesday"`
+	Name         string     `json:"name,omitempty" example:"Nasi Goreng Premium"`
+	Slug         string     `json:"slug,omitempty" example:"nasi-goreng-premium"`
+	Image        *string    `json:"image,omitempty" example:"https://example.com/nasi-goreng-premium.jpg"`
+	Description  string     `json:"description,omitempty" example:"Nasi goreng dengan bumbu premium"`
+	Ingredients  string     `json:"ingredients,omitempty" example:"Nasi, telur, bawang, kecap, ayam"`
+	Instructions string     `json:"instructions,omitempty" example:"1. Tumis bawang... 2. Masukkan ayam..."`
+	Label        *string    `json:"label,omitempty" example:"Special Menu"`
+	Day          string     `json:"day,omitempty" example:"tuesday"`
+	PublishedAt  *time.Time `json:"published_at,omitempty" example:"2023-10-11T12:00:00Z"`
 }
 
 type RecipeResponse struct {
-	ID           string    `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	UserID       string    `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	Name         string    `json:"name" example:"Nasi Goreng Spesial"`
-	Slug         string    `json:"slug" example:"nasi-goreng-spesial"`
-	Image        *string   `json:"image,omitempty" example:"https://example.com/nasi-goreng.jpg"`
-	Description  string    `json:"description" example:"Nasi goreng dengan bumbu rahasia"`
-	Ingredients  string    `json:"ingredients" example:"Nasi, telur, bawang, kecap"`
-	Instructions string    `json:"instructions" example:"1. Tumis bawang..."`
-	Label        *string   `json:"label,omitempty" example:"Main Course"`
-	CreatedAt    time.Time `json:"created_at" example:"2023-10-10T12:00:00Z"`
-	Day          string    `json:"day" example:"monday"`
+	ID           string     `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	UserID       string     `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Name         string     `json:"name" example:"Nasi Goreng Spesial"`
+	Slug         string     `json:"slug" example:"nasi-goreng-spesial"`
+	Image        *string    `json:"image,omitempty" example:"https://example.com/nasi-goreng.jpg"`
+	Description  string     `json:"description" example:"Nasi goreng dengan bumbu rahasia"`
+	Ingredients  string     `json:"ingredients" example:"Nasi, telur, bawang, kecap"`
+	Instructions string     `json:"instructions" example:"1. Tumis bawang..."`
+	Label        *string    `json:"label,omitempty" example:"Main Course"`
+	CreatedAt    time.Time  `json:"created_at" example:"2023-10-10T12:00:00Z"`
+	Day          string     `json:"day" example:"monday"`
+	PublishedAt  *time.Time `json:"published_at,omitempty" example:"2023-10-10T12:00:00Z"`
 }
 
 type PurchaseSubscriptionRequest struct {
@@ -48,6 +48,12 @@ type TransactionDetailResponse struct {
 	// Settlement info
 	SettlementTime *time.Time `json:"settlement_time,omitempty"`
 
+	// Manual bank transfer fields
+	ProofImageURL   *string    `json:"proof_image_url,omitempty"`
+	ReferenceNumber *string    `json:"reference_number,omitempty"`
+	RecordedByID    *uuid.UUID `json:"recorded_by_id,omitempty"`
+	Notes           *string    `json:"notes,omitempty"`
+
 	// Raw response for debugging
 	RawResponse JSONData `json:"raw_response,omitempty"`
 
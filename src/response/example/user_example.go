@@ -10,6 +10,7 @@ type User struct {
 	ID             uuid.UUID  `json:"id" example:"e088d183-9eea-4a11-8d5d-74d7ec91bdf5"`
 	Name           string     `json:"name" example:"fake name"`
 	Email          string     `json:"email" example:"fake@example.com"`
+	Handle         *string    `json:"handle" example:"fake_name"`
 	Role           string     `json:"role" example:"user"`
 	VerifiedEmail  bool       `json:"verified_email" example:"false"`
 	BirthDate      *time.Time `json:"birth_date,omitempty" example:"2000-01-01T00:00:00Z"`
@@ -0,0 +1,21 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithExperiment struct {
+	Status  string           `json:"status"`
+	Message string           `json:"message"`
+	Data    model.Experiment `json:"data"`
+}
+
+type SuccessWithExperiments struct {
+	Status  string             `json:"status"`
+	Message string             `json:"message"`
+	Data    []model.Experiment `json:"data"`
+}
+
+type SuccessWithExperimentResults struct {
+	Status  string                  `json:"status"`
+	Message string                  `json:"message"`
+	Data    model.ExperimentResults `json:"data"`
+}
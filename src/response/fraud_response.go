@@ -0,0 +1,15 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithFraudReview struct {
+	Status  string            `json:"status"`
+	Message string            `json:"message"`
+	Data    model.FraudReview `json:"data"`
+}
+
+type SuccessWithFraudListEntry struct {
+	Status  string               `json:"status"`
+	Message string               `json:"message"`
+	Data    model.FraudListEntry `json:"data"`
+}
@@ -0,0 +1,15 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithStoreProduct struct {
+	Status  string             `json:"status"`
+	Message string             `json:"message"`
+	Data    model.StoreProduct `json:"data"`
+}
+
+type SuccessWithStoreProducts struct {
+	Status  string               `json:"status"`
+	Message string               `json:"message"`
+	Data    []model.StoreProduct `json:"data"`
+}
@@ -0,0 +1,27 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithBlockedUser struct {
+	Status  string            `json:"status"`
+	Message string            `json:"message"`
+	Data    model.BlockedUser `json:"data"`
+}
+
+type SuccessWithBlockedUsers struct {
+	Status  string              `json:"status"`
+	Message string              `json:"message"`
+	Data    []model.BlockedUser `json:"data"`
+}
+
+type SuccessWithUserReport struct {
+	Status  string           `json:"status"`
+	Message string           `json:"message"`
+	Data    model.UserReport `json:"data"`
+}
+
+type SuccessWithSentReport struct {
+	Status  string           `json:"status"`
+	Message string           `json:"message"`
+	Data    model.SentReport `json:"data"`
+}
@@ -0,0 +1,15 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithNotificationPreference struct {
+	Status  string                           `json:"status"`
+	Message string                           `json:"message"`
+	Data    model.NotificationPreferenceView `json:"data"`
+}
+
+type SuccessWithNotificationPreferences struct {
+	Status  string                             `json:"status"`
+	Message string                             `json:"message"`
+	Data    []model.NotificationPreferenceView `json:"data"`
+}
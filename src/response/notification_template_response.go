@@ -0,0 +1,21 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithNotificationTemplate struct {
+	Status  string                     `json:"status"`
+	Message string                     `json:"message"`
+	Data    model.NotificationTemplate `json:"data"`
+}
+
+type SuccessWithNotificationTemplates struct {
+	Status  string                       `json:"status"`
+	Message string                       `json:"message"`
+	Data    []model.NotificationTemplate `json:"data"`
+}
+
+type SuccessWithRenderedNotification struct {
+	Status  string                     `json:"status"`
+	Message string                     `json:"message"`
+	Data    model.RenderedNotification `json:"data"`
+}
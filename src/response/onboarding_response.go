@@ -0,0 +1,15 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithOnboardingChecklist struct {
+	Status  string                    `json:"status"`
+	Message string                    `json:"message"`
+	Data    model.OnboardingChecklist `json:"data"`
+}
+
+type SuccessWithOnboardingFunnel struct {
+	Status  string                 `json:"status"`
+	Message string                 `json:"message"`
+	Data    model.OnboardingFunnel `json:"data"`
+}
@@ -0,0 +1,34 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithOpsBotLinkCode struct {
+	Status  string               `json:"status"`
+	Message string               `json:"message"`
+	Data    model.OpsBotLinkCode `json:"data"`
+}
+
+type SuccessWithOpsBotIdentities struct {
+	Status  string                 `json:"status"`
+	Message string                 `json:"message"`
+	Data    []model.OpsBotIdentity `json:"data"`
+}
+
+type SuccessWithMaintenanceStatus struct {
+	Status  string                  `json:"status"`
+	Message string                  `json:"message"`
+	Data    model.MaintenanceStatus `json:"data"`
+}
+
+// SlackCommandReply is the response to a Slack slash command
+type SlackCommandReply struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// TelegramReply answers a Telegram webhook update with a sendMessage call
+type TelegramReply struct {
+	Method string `json:"method"`
+	ChatID int64  `json:"chat_id"`
+	Text   string `json:"text"`
+}
@@ -0,0 +1,21 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithParentalConsentStatus struct {
+	Status  string                      `json:"status"`
+	Message string                      `json:"message"`
+	Data    model.ParentalConsentStatus `json:"data"`
+}
+
+type SuccessWithParentalConsentRequest struct {
+	Status  string                       `json:"status"`
+	Message string                       `json:"message"`
+	Data    model.ParentalConsentRequest `json:"data"`
+}
+
+type SuccessWithParentalConsentPrompt struct {
+	Status  string                      `json:"status"`
+	Message string                      `json:"message"`
+	Data    model.ParentalConsentPrompt `json:"data"`
+}
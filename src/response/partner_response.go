@@ -0,0 +1,87 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithPartnerKeys struct {
+	Status  string             `json:"status"`
+	Message string             `json:"message"`
+	Data    []model.PartnerKey `json:"data"`
+}
+
+type SuccessWithPartnerKey struct {
+	Status  string           `json:"status"`
+	Message string           `json:"message"`
+	Data    model.PartnerKey `json:"data"`
+}
+
+type SuccessWithCreatedPartnerKey struct {
+	Status  string                  `json:"status"`
+	Message string                  `json:"message"`
+	Data    model.CreatedPartnerKey `json:"data"`
+}
+
+type SuccessWithPartnerKeyUsage struct {
+	Status  string                  `json:"status"`
+	Message string                  `json:"message"`
+	Data    []model.PartnerKeyUsage `json:"data"`
+}
+
+type SuccessWithPartnerUsageReport struct {
+	Status  string                   `json:"status"`
+	Message string                   `json:"message"`
+	Data    model.PartnerUsageReport `json:"data"`
+}
+
+type SuccessWithPartnerTiers struct {
+	Status  string              `json:"status"`
+	Message string              `json:"message"`
+	Data    []model.PartnerTier `json:"data"`
+}
+
+type SuccessWithPartnerTier struct {
+	Status  string            `json:"status"`
+	Message string            `json:"message"`
+	Data    model.PartnerTier `json:"data"`
+}
+
+type SuccessWithPartnerAccount struct {
+	Status  string               `json:"status"`
+	Message string               `json:"message"`
+	Data    model.PartnerAccount `json:"data"`
+}
+
+type SuccessWithPartnerOverage struct {
+	Status  string                 `json:"status"`
+	Message string                 `json:"message"`
+	Data    []model.PartnerOverage `json:"data"`
+}
+
+type SuccessWithPartnerMember struct {
+	Status  string              `json:"status"`
+	Message string              `json:"message"`
+	Data    model.PartnerMember `json:"data"`
+}
+
+type SuccessWithPartnerEntitlement struct {
+	Status  string                   `json:"status"`
+	Message string                   `json:"message"`
+	Data    model.PartnerEntitlement `json:"data"`
+}
+
+type SuccessWithPartnerMemberships struct {
+	Status  string                    `json:"status"`
+	Message string                    `json:"message"`
+	Data    []model.PartnerMembership `json:"data"`
+}
+
+type SuccessWithPartnerEnrollmentCode struct {
+	Status  string                      `json:"status"`
+	Message string                      `json:"message"`
+	Data    model.PartnerEnrollmentCode `json:"data"`
+}
+
+type SuccessWithPartnerConsent struct {
+	Status  string               `json:"status"`
+	Message string               `json:"message"`
+	Data    model.PartnerConsent `json:"data"`
+}
@@ -0,0 +1,36 @@
+package response
+
+import (
+	"app/src/model"
+	"app/src/webauthn"
+
+	"github.com/google/uuid"
+)
+
+type PasskeyRegistrationOptions struct {
+	Status      string    `json:"status"`
+	Message     string    `json:"message"`
+	ChallengeID uuid.UUID `json:"challenge_id"`
+	// PublicKey is passed to navigator.credentials.create as publicKey, after decoding its base64url values
+	PublicKey webauthn.CreationOptions `json:"public_key"`
+}
+
+type PasskeyLoginOptions struct {
+	Status      string    `json:"status"`
+	Message     string    `json:"message"`
+	ChallengeID uuid.UUID `json:"challenge_id"`
+	// PublicKey is passed to navigator.credentials.get as publicKey, after decoding its base64url values
+	PublicKey webauthn.RequestOptions `json:"public_key"`
+}
+
+type SuccessWithPasskey struct {
+	Status  string        `json:"status"`
+	Message string        `json:"message"`
+	Data    model.Passkey `json:"data"`
+}
+
+type SuccessWithPasskeys struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Data    []model.Passkey `json:"data"`
+}
@@ -7,3 +7,15 @@ type PaymentResponse struct {
 	Message string                 `json:"message"`
 	Data    *model.PaymentResponse `json:"data"`
 }
+
+type SuccessWithPaymentProof struct {
+	Status  string             `json:"status"`
+	Message string             `json:"message"`
+	Data    model.PaymentProof `json:"data"`
+}
+
+type SuccessWithInstallments struct {
+	Status  string                      `json:"status"`
+	Message string                      `json:"message"`
+	Data    []model.InstallmentSchedule `json:"data"`
+}
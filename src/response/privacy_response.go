@@ -0,0 +1,15 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithPrivacySettings struct {
+	Status  string                `json:"status"`
+	Message string                `json:"message"`
+	Data    model.PrivacySettings `json:"data"`
+}
+
+type SuccessWithPublicProfile struct {
+	Status  string              `json:"status"`
+	Message string              `json:"message"`
+	Data    model.PublicProfile `json:"data"`
+}
@@ -0,0 +1,17 @@
+package response
+
+import "app/src/model"
+
+// SuccessWithPublicArticle is a response for a published article on the website
+type SuccessWithPublicArticle struct {
+	Status  string              `json:"status"`
+	Message string              `json:"message"`
+	Data    model.PublicArticle `json:"data"`
+}
+
+// SuccessWithPublicRecipe is a response for a curated recipe on the website
+type SuccessWithPublicRecipe struct {
+	Status  string             `json:"status"`
+	Message string             `json:"message"`
+	Data    model.PublicRecipe `json:"data"`
+}
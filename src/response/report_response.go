@@ -0,0 +1,33 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithRevenueReport struct {
+	Status  string              `json:"status"`
+	Message string              `json:"message"`
+	Data    model.RevenueReport `json:"data"`
+}
+
+type SuccessWithRevenueAnalytics struct {
+	Status  string                 `json:"status"`
+	Message string                 `json:"message"`
+	Data    model.RevenueAnalytics `json:"data"`
+}
+
+type SuccessWithSubscriptionRetention struct {
+	Status  string                      `json:"status"`
+	Message string                      `json:"message"`
+	Data    model.SubscriptionRetention `json:"data"`
+}
+
+type SuccessWithNutritionReport struct {
+	Status  string                `json:"status"`
+	Message string                `json:"message"`
+	Data    model.NutritionReport `json:"data"`
+}
+
+type SuccessWithEventReplay struct {
+	Status  string            `json:"status"`
+	Message string            `json:"message"`
+	Data    model.EventReplay `json:"data"`
+}
@@ -3,6 +3,8 @@ package response
 import (
 	"app/src/model"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Common struct {
@@ -34,6 +36,12 @@ type SuccessWithProductToken struct {
 	Data    model.ProductToken `json:"data"`
 }
 
+type SuccessWithMealDiary struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Data    model.MealDiary `json:"data"`
+}
+
 type SuccessWithMealScanDetail struct {
 	Status         string                  `json:"status"`
 	Message        string                  `json:"message"`
@@ -71,16 +79,6 @@ type SuccessWithTokens struct {
 	Tokens  Tokens     `json:"tokens"`
 }
 
-type SuccessWithPaginate[T any] struct {
-	Status       string `json:"status"`
-	Message      string `json:"message"`
-	Results      []T    `json:"results"`
-	Page         int    `json:"page"`
-	Limit        int    `json:"limit"`
-	TotalPages   int64  `json:"total_pages"`
-	TotalResults int64  `json:"total_results"`
-}
-
 type ErrorDetails struct {
 	Status  string      `json:"status"`
 	Message string      `json:"message"`
@@ -155,6 +153,14 @@ type SubscriptionPlansResponse struct {
 	Status  string                           `json:"status"`
 	Message string                           `json:"message"`
 	Data    []model.SubscriptionPlanResponse `json:"data"`
+	// Copy of the paywall variant of the signed in user, when a paywall experiment runs
+	Paywall *model.ExposedVariant `json:"paywall,omitempty"`
+}
+
+type SubscriptionPlanDetailResponse struct {
+	Status  string                         `json:"status"`
+	Message string                         `json:"message"`
+	Data    model.SubscriptionPlanResponse `json:"data"`
 }
 
 type UserSubscriptionResponse struct {
@@ -191,6 +197,74 @@ type SuccessWithBahanMakananList struct {
 	Data    []model.BahanMakanan `json:"data"`
 }
 
+type SuccessWithFoodNames struct {
+	Status  string           `json:"status"`
+	Message string           `json:"message"`
+	Data    []model.FoodName `json:"data"`
+}
+
+type SuccessWithFoodSearchResults struct {
+	Status   string                   `json:"status"`
+	Message  string                   `json:"message"`
+	SearchID uuid.UUID                `json:"search_id"`
+	Data     []model.FoodSearchResult `json:"data"`
+}
+
+type SuccessWithPortionUnits struct {
+	Status  string              `json:"status"`
+	Message string              `json:"message"`
+	Data    []model.PortionUnit `json:"data"`
+}
+
+type SuccessWithPortionOptions struct {
+	Status  string                `json:"status"`
+	Message string                `json:"message"`
+	Data    []model.PortionOption `json:"data"`
+}
+
+type SuccessWithPortionConversion struct {
+	Status  string                  `json:"status"`
+	Message string                  `json:"message"`
+	Data    model.PortionConversion `json:"data"`
+}
+
+type SuccessWithFoodComparison struct {
+	Status  string               `json:"status"`
+	Message string               `json:"message"`
+	Data    model.FoodComparison `json:"data"`
+}
+
+type SuccessWithFoodAlternatives struct {
+	Status  string                            `json:"status"`
+	Message string                            `json:"message"`
+	Data    []model.FoodAlternativeSuggestion `json:"data"`
+}
+
+type SuccessWithFoodAlternativeFeedback struct {
+	Status  string                        `json:"status"`
+	Message string                        `json:"message"`
+	Data    model.FoodAlternativeFeedback `json:"data"`
+}
+
+type SuccessWithDietPreference struct {
+	Status       string                     `json:"status"`
+	Message      string                     `json:"message"`
+	Data         model.DietPreference       `json:"data"`
+	Restrictions []model.DietaryRestriction `json:"restrictions"` // every restriction a user can set
+}
+
+type SuccessWithFoodLog struct {
+	Status  string        `json:"status"`
+	Message string        `json:"message"`
+	Data    model.FoodLog `json:"data"`
+}
+
+type SuccessWithFoodSearchGaps struct {
+	Status  string                `json:"status"`
+	Message string                `json:"message"`
+	Data    []model.FoodSearchGap `json:"data"`
+}
+
 // SuccessWithHomeStatistics represents a successful response with home statistics
 type SuccessWithHomeStatistics struct {
 	Status  string               `json:"status"`
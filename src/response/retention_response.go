@@ -0,0 +1,33 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithRetentionPolicies struct {
+	Status  string                  `json:"status"`
+	Message string                  `json:"message"`
+	Data    []model.RetentionPolicy `json:"data"`
+}
+
+type SuccessWithRetentionReport struct {
+	Status  string                `json:"status"`
+	Message string                `json:"message"`
+	Data    model.RetentionReport `json:"data"`
+}
+
+type SuccessWithRetentionRuns struct {
+	Status  string               `json:"status"`
+	Message string               `json:"message"`
+	Data    []model.RetentionRun `json:"data"`
+}
+
+type SuccessWithMediaCleanupRun struct {
+	Status  string                `json:"status"`
+	Message string                `json:"message"`
+	Data    model.MediaCleanupRun `json:"data"`
+}
+
+type SuccessWithMediaCleanupRuns struct {
+	Status  string                  `json:"status"`
+	Message string                  `json:"message"`
+	Data    []model.MediaCleanupRun `json:"data"`
+}
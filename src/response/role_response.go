@@ -0,0 +1,27 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithRoles struct {
+	Status  string           `json:"status"`
+	Message string           `json:"message"`
+	Data    []model.RoleView `json:"data"`
+}
+
+type SuccessWithRoleView struct {
+	Status  string         `json:"status"`
+	Message string         `json:"message"`
+	Data    model.RoleView `json:"data"`
+}
+
+type SuccessWithRights struct {
+	Status  string   `json:"status"`
+	Message string   `json:"message"`
+	Data    []string `json:"data"`
+}
+
+type SuccessWithRole struct {
+	Status  string     `json:"status"`
+	Message string     `json:"message"`
+	Data    model.Role `json:"data"`
+}
@@ -2,18 +2,11 @@ package response
 
 import (
 	"app/src/model"
-)
+	"app/src/pagination"
+	"time"
 
-// SuccessWithPaginateSubscriptions adalah respons untuk daftar subscription dengan pagination
-type SuccessWithPaginateSubscriptions struct {
-	Status       string                           `json:"status"`
-	Message      string                           `json:"message"`
-	Results      []model.UserSubscriptionResponse `json:"results"`
-	Page         int                              `json:"page"`
-	Limit        int                              `json:"limit"`
-	TotalPages   int64                            `json:"total_pages"`
-	TotalResults int64                            `json:"total_results"`
-}
+	"github.com/google/uuid"
+)
 
 // SuccessWithSubscription adalah respons untuk detail subscription
 type SuccessWithSubscription struct {
@@ -42,17 +35,43 @@ type SuccessWithSubscriptionPlans struct {
 	Status  string                      `json:"status"`
 	Message string                      `json:"message"`
 	Data    []SubscriptionPlanWithUsers `json:"data"`
+	pagination.Pagination
 }
 
-// SuccessWithTransactions is a response for transaction logs
+// SuccessWithTransactions is a response for transaction logs, paginated unless they are those of one subscription
 type SuccessWithTransactions struct {
-	Status       string                    `json:"status"`
-	Message      string                    `json:"message"`
-	Data         []model.TransactionDetail `json:"data"`
-	Page         int                       `json:"page,omitempty"`
-	Limit        int                       `json:"limit,omitempty"`
-	TotalPages   int64                     `json:"total_pages,omitempty"`
-	TotalResults int64                     `json:"total_results,omitempty"`
+	Status  string                    `json:"status"`
+	Message string                    `json:"message"`
+	Data    []model.TransactionDetail `json:"data"`
+	*pagination.Pagination
+}
+
+// SuccessWithRegionalDefaults is a response for the locale and currency a visitor starts with
+type SuccessWithRegionalDefaults struct {
+	Status  string                 `json:"status"`
+	Message string                 `json:"message"`
+	Data    model.RegionalDefaults `json:"data"`
+}
+
+// SuccessWithPublicPlans is a response for the plans listed on the marketing website
+type SuccessWithPublicPlans struct {
+	Status  string             `json:"status"`
+	Message string             `json:"message"`
+	Data    []model.PublicPlan `json:"data"`
+}
+
+// SuccessWithScanUsage is a response for the AI scan usage of a subscription
+type SuccessWithScanUsage struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Data    model.ScanUsage `json:"data"`
+}
+
+// SuccessWithSubscriptionEvents is a response for the status history of a subscription
+type SuccessWithSubscriptionEvents struct {
+	Status  string                    `json:"status"`
+	Message string                    `json:"message"`
+	Data    []model.SubscriptionEvent `json:"data"`
 }
 
 // SuccessWithTransaction is a response for a single transaction
@@ -64,15 +83,27 @@ type SuccessWithTransaction struct {
 
 // SubscriptionPlanResponse is a response for a single subscription plan
 type SubscriptionPlanResponse struct {
-	ID             string          `json:"id"`
-	Name           string          `json:"name"`
-	Price          int             `json:"price"`
-	PriceFormatted string          `json:"price_formatted"`
-	Description    string          `json:"description"`
-	AIscanLimit    int             `json:"ai_scan_limit"`
-	ValidityDays   int             `json:"validity_days"`
-	Features       map[string]bool `json:"features"`
-	IsActive       bool            `json:"is_active"`
+	ID                string          `json:"id"`
+	Name              string          `json:"name"`
+	Price             int             `json:"price"`
+	Currency          string          `json:"currency"`
+	PriceFormatted    string          `json:"price_formatted"`
+	Description       string          `json:"description"`
+	AIscanLimit       int             `json:"ai_scan_limit"`
+	ChatMessageLimit  int             `json:"chat_message_limit"`
+	VoiceLogLimit     int             `json:"voice_log_limit"`
+	ValidityDays      int             `json:"validity_days"`
+	Features          map[string]bool `json:"features"`
+	IsActive          bool            `json:"is_active"`
+	AllowInstallments bool            `json:"allow_installments"`
+	InstallmentCount  int             `json:"installment_count"`
+	Hidden            bool            `json:"hidden"`
+	AvailableFrom     *time.Time      `json:"available_from"`
+	AvailableUntil    *time.Time      `json:"available_until"`
+	ArchivedAt        *time.Time      `json:"archived_at,omitempty"`
+	SunsetAt          *time.Time      `json:"sunset_at,omitempty"`
+	ReplacementPlanID *uuid.UUID      `json:"replacement_plan_id,omitempty"`
+	TrialDays         int             `json:"trial_days"`
 }
 
 // SuccessWithSubscriptionPlan is a response for a single subscription plan
@@ -81,3 +112,38 @@ type SuccessWithSubscriptionPlan struct {
 	Message string                   `json:"message"`
 	Data    SubscriptionPlanResponse `json:"data"`
 }
+
+// SuccessWithProration is a response for the proration of a plan change
+type SuccessWithProration struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Data    model.Proration `json:"data"`
+}
+
+// SuccessWithPlanSunsetReport is a response for the migration progress of a sunset plan
+type SuccessWithPlanSunsetReport struct {
+	Status  string                 `json:"status"`
+	Message string                 `json:"message"`
+	Data    model.PlanSunsetReport `json:"data"`
+}
+
+// SuccessWithEntitlementDiagnosis is a response for the entitlement resolution of a user
+type SuccessWithEntitlementDiagnosis struct {
+	Status  string                     `json:"status"`
+	Message string                     `json:"message"`
+	Data    model.EntitlementDiagnosis `json:"data"`
+}
+
+// SuccessWithAdminAction is a response for a staged destructive admin action
+type SuccessWithAdminAction struct {
+	Status  string            `json:"status"`
+	Message string            `json:"message"`
+	Data    model.AdminAction `json:"data"`
+}
+
+// SuccessWithPlanOptions is a response for the plans a subscriber may buy or upgrade to
+type SuccessWithPlanOptions struct {
+	Status  string             `json:"status"`
+	Message string             `json:"message"`
+	Data    []model.PlanOption `json:"data"`
+}
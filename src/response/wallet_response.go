@@ -0,0 +1,15 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithWallet struct {
+	Status  string       `json:"status"`
+	Message string       `json:"message"`
+	Data    model.Wallet `json:"data"`
+}
+
+type SuccessWithWalletTransaction struct {
+	Status  string                  `json:"status"`
+	Message string                  `json:"message"`
+	Data    model.WalletTransaction `json:"data"`
+}
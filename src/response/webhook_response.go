@@ -0,0 +1,33 @@
+package response
+
+import "app/src/model"
+
+type SuccessWithWebhookEndpoint struct {
+	Status  string                `json:"status"`
+	Message string                `json:"message"`
+	Data    model.WebhookEndpoint `json:"data"`
+}
+
+type SuccessWithCreatedWebhookEndpoint struct {
+	Status  string                       `json:"status"`
+	Message string                       `json:"message"`
+	Data    model.CreatedWebhookEndpoint `json:"data"`
+}
+
+type SuccessWithWebhookEndpoints struct {
+	Status  string                  `json:"status"`
+	Message string                  `json:"message"`
+	Data    []model.WebhookEndpoint `json:"data"`
+}
+
+type SuccessWithWebhookDelivery struct {
+	Status  string                `json:"status"`
+	Message string                `json:"message"`
+	Data    model.WebhookDelivery `json:"data"`
+}
+
+type SuccessWithWebhookReplay struct {
+	Status  string              `json:"status"`
+	Message string              `json:"message"`
+	Data    model.WebhookReplay `json:"data"`
+}
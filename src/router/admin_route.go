@@ -8,45 +8,341 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func AdminRoutes(v1 fiber.Router, userService service.UserService, tokenService service.TokenService, productTokenService service.ProductTokenService, subscriptionService service.SubscriptionService) {
+func AdminRoutes(
+	v1 fiber.Router,
+	userService service.UserService,
+	tokenService service.TokenService,
+	productTokenService service.ProductTokenService,
+	subscriptionService service.SubscriptionService,
+	paymentProofService service.PaymentProofService,
+	walletService service.WalletService,
+	fraudService service.FraudService,
+	fraudReviewService service.FraudReviewService,
+	iapService service.IAPService,
+	couponService service.CouponService,
+	checkoutService service.CheckoutService,
+	revenueService service.RevenueService,
+	alertService service.AlertService,
+	opsBotService service.OpsBotService,
+	maintenanceService service.MaintenanceService,
+	entitlementService service.EntitlementService,
+	eventLogService service.EventLogService,
+	backupService service.BackupService,
+	notificationTemplateService service.NotificationTemplateService,
+	deepLinkService service.DeepLinkService,
+	experimentService service.ExperimentService,
+	onboardingService service.OnboardingService,
+	retentionService service.RetentionService,
+	mediaCleanupService service.MediaCleanupService,
+	rectificationService service.RectificationService,
+	partnerService service.PartnerService,
+	runtimeConfigService service.RuntimeConfigService,
+	foodNameService service.FoodNameService,
+	foodImportService service.FoodImportService,
+	foodGradeService service.FoodGradeService,
+	dailyTipService service.DailyTipService,
+	cohortService service.CohortService,
+	planSunsetService service.PlanSunsetService,
+	adminActionService service.AdminActionService,
+	moderationService service.ModerationService,
+	webhookService service.WebhookService,
+	storageUsageService service.StorageUsageService,
+	idempotencyService service.IdempotencyService,
+	emailDeliveryService service.EmailDeliveryService,
+	emailSuppressionService service.EmailSuppressionService,
+	activityLogService service.ActivityLogService,
+	phoneService service.PhoneService,
+	roleService service.RoleService,
+	passkeyService service.PasskeyService,
+) {
 	adminProductTokenController := controller.NewAdminProductTokenController(productTokenService)
 	adminUserController := controller.NewAdminUserController(userService, tokenService)
 	adminSubscriptionController := controller.NewAdminSubscriptionController(subscriptionService)
+	adminPaymentProofController := controller.NewAdminPaymentProofController(paymentProofService)
+	adminWalletController := controller.NewAdminWalletController(walletService)
+	adminFraudController := controller.NewAdminFraudController(fraudService, fraudReviewService)
+	adminStoreProductController := controller.NewAdminStoreProductController(iapService)
+	adminCouponController := controller.NewAdminCouponController(couponService)
+	adminCheckoutController := controller.NewAdminCheckoutController(checkoutService)
+	adminReportController := controller.NewAdminReportController(revenueService)
+	idempotent := m.Idempotent(idempotencyService)
+	adminAlertController := controller.NewAdminAlertController(alertService)
+	adminWebhookController := controller.NewAdminWebhookController(webhookService)
+	adminStorageController := controller.NewAdminStorageController(storageUsageService)
+	adminEmailController := controller.NewAdminEmailController(emailDeliveryService, emailSuppressionService)
+	adminAuditLogController := controller.NewAdminAuditLogController(activityLogService)
+	adminPhoneController := controller.NewAdminPhoneController(phoneService)
+	adminRoleController := controller.NewAdminRoleController(roleService)
+	adminPasskeyController := controller.NewAdminPasskeyController(passkeyService)
+	adminOpsController := controller.NewAdminOpsController(opsBotService, maintenanceService)
+	adminEntitlementController := controller.NewAdminEntitlementController(entitlementService)
+	adminEventController := controller.NewAdminEventController(eventLogService)
+	adminBackupController := controller.NewAdminBackupController(backupService)
+	adminNotificationTemplateController := controller.NewAdminNotificationTemplateController(notificationTemplateService)
+	adminDeepLinkController := controller.NewAdminDeepLinkController(deepLinkService)
+	adminExperimentController := controller.NewAdminExperimentController(experimentService)
+	adminOnboardingController := controller.NewAdminOnboardingController(onboardingService)
+	adminRetentionController := controller.NewAdminRetentionController(retentionService, mediaCleanupService)
+	rectificationController := controller.NewRectificationController(rectificationService)
+	adminPartnerKeyController := controller.NewAdminPartnerKeyController(partnerService)
+	adminConfigController := controller.NewAdminConfigController(runtimeConfigService)
+	adminFoodSearchController := controller.NewAdminFoodSearchController(foodNameService)
+	adminFoodImportController := controller.NewAdminFoodImportController(foodImportService)
+	adminFoodGradingController := controller.NewAdminFoodGradingController(foodGradeService)
+	adminTipRuleController := controller.NewAdminTipRuleController(dailyTipService)
+	adminCohortController := controller.NewAdminCohortController(cohortService)
+	adminPlanSunsetController := controller.NewAdminPlanSunsetController(planSunsetService)
+	adminActionController := controller.NewAdminActionController(adminActionService)
+	adminModerationController := controller.NewAdminModerationController(moderationService)
 
+	// Every admin route requires rights of the role of the user, see m.RequirePermission
 	admin := v1.Group("/admin", m.Auth(userService, productTokenService))
 
 	// Product Token routes
-	productTokens := admin.Group("/product-tokens", m.Auth(userService, productTokenService, "getProductTokens"))
+	productTokens := admin.Group("/product-tokens", m.RequirePermission("getProductTokens"))
 	productTokens.Get("/", adminProductTokenController.GetAllProductTokens)
-	productTokens.Post("/", m.Auth(userService, productTokenService, "createProductToken"), adminProductTokenController.CreateProductToken)
-	productTokens.Put("/:id", m.Auth(userService, productTokenService, "updateProductToken"), adminProductTokenController.UpdateProductToken)
-	productTokens.Delete("/:id", m.Auth(userService, productTokenService, "deleteProductToken"), adminProductTokenController.DeleteProductToken)
+	productTokens.Post("/", m.RequirePermission("createProductToken"), adminProductTokenController.CreateProductToken)
+	productTokens.Put("/:id", m.RequirePermission("updateProductToken"), adminProductTokenController.UpdateProductToken)
+	productTokens.Delete("/:id", m.RequirePermission("deleteProductToken"), adminProductTokenController.DeleteProductToken)
 
 	// User management routes
-	users := admin.Group("/users", m.Auth(userService, productTokenService, "getUsers"))
+	users := admin.Group("/users", m.RequirePermission("getUsers"))
 	users.Get("/", adminUserController.GetAllUsers)
-	users.Get("/:id", m.Auth(userService, productTokenService, "getUserDetails"), adminUserController.GetUserDetails)
-	users.Patch("/:id", m.Auth(userService, productTokenService, "updateUser"), adminUserController.UpdateUser)
+	users.Get("/:id", m.RequirePermission("getUserDetails"), adminUserController.GetUserDetails)
+	users.Get("/:id/entitlements/debug", m.RequirePermission("getUserDetails"), adminEntitlementController.DebugEntitlements)
+	users.Patch("/:id", m.RequirePermission("updateUser"), adminUserController.UpdateUser)
+	users.Patch("/:id/sandbox", m.RequirePermission("updateUser"), adminUserController.UpdateUserSandbox)
+	users.Post("/:id/rectification", m.RequirePermission("rectifyPersonalData"), rectificationController.RectifyUser)
+	users.Get("/:id/wallet", m.RequirePermission("manageWallets"), adminWalletController.GetUserWallet)
+	users.Post("/:id/wallet/adjustments", m.RequirePermission("manageWallets"), adminWalletController.AdjustUserWallet)
+	users.Delete("/:id/suspension", m.RequirePermission("moderateUsers"), adminModerationController.LiftSuspension)
+	users.Delete("/:id/email-suppression", m.RequirePermission("updateUser"), adminEmailController.LiftSuppression)
+	users.Get("/:id/phone-messages", m.RequirePermission("getUserDetails"), adminPhoneController.GetUserMessages)
+	users.Get("/:id/passkeys", m.RequirePermission("getUserDetails"), adminPasskeyController.GetUserPasskeys)
+	users.Delete("/:id/passkeys", m.RequirePermission("updateUser"), adminPasskeyController.ResetUserPasskeys)
+	users.Put("/:id/role", m.RequirePermission("manageRoles"), adminRoleController.AssignRole)
+
+	// Moderation queue of reports users filed about other users
+	userReports := admin.Group("/user-reports", m.RequirePermission("moderateUsers"))
+	userReports.Get("/", adminModerationController.GetReports)
+	userReports.Post("/:id/resolve", adminModerationController.ResolveReport)
 
 	// Subscription routes
-	subscriptions := admin.Group("/subscriptions", m.Auth(userService, productTokenService, "getSubscriptions"))
+	subscriptions := admin.Group("/subscriptions", m.RequirePermission("getSubscriptions"))
 	subscriptions.Get("/", adminSubscriptionController.GetAllUserSubscriptions)
+	subscriptions.Post("/comp", m.RequirePermission("manageSubscriptions"), adminSubscriptionController.CompSubscription)
+	subscriptions.Post("/cancel", m.RequirePermission("manageSubscriptions"), adminActionController.CancelSubscriptions)
 
 	// Specific subscription routes
 	subscription := subscriptions.Group("/:subscription_id")
 	subscription.Get("/", adminSubscriptionController.GetUserSubscriptionDetails)
-	subscription.Patch("/", adminSubscriptionController.UpdateUserSubscription, m.Auth(userService, productTokenService, "manageSubscriptions"))
-	subscription.Get("/transactions", adminSubscriptionController.GetTransactionLogs, m.Auth(userService, productTokenService, "viewTransactions"))
-	subscription.Patch("/payment-status", adminSubscriptionController.UpdatePaymentStatus, m.Auth(userService, productTokenService, "updatePaymentStatus"))
+	subscription.Patch("/", m.RequirePermission("manageSubscriptions"), adminSubscriptionController.UpdateUserSubscription)
+	subscription.Delete("/", m.RequirePermission("manageSubscriptions"), adminActionController.DeleteUserSubscription)
+	subscription.Post("/restore", m.RequirePermission("manageSubscriptions"), adminSubscriptionController.RestoreUserSubscription)
+	subscription.Get("/transactions", m.RequirePermission("viewTransactions"), adminSubscriptionController.GetTransactionLogs)
+	subscription.Get("/history", adminSubscriptionController.GetSubscriptionHistory)
+	subscription.Get("/proration", m.RequirePermission("manageSubscriptions"), adminSubscriptionController.PreviewProration)
+	subscription.Patch("/payment-status", m.RequirePermission("updatePaymentStatus"), idempotent, adminSubscriptionController.UpdatePaymentStatus)
+	subscription.Get("/payment-reminders", adminCheckoutController.GetPaymentReminders)
+	subscription.Post("/send-payment-reminder", m.RequirePermission("manageSubscriptions"), adminCheckoutController.SendPaymentReminder)
 
 	// Subscription plans routes
-	subscriptionPlans := admin.Group("/subscription-plans", m.Auth(userService, productTokenService, "getSubscriptionPlans"))
+	subscriptionPlans := admin.Group("/subscription-plans", m.RequirePermission("getSubscriptionPlans"))
 	subscriptionPlans.Get("/", adminSubscriptionController.GetAllSubscriptionPlans)
 	subscriptionPlans.Get("/:plan_id", adminSubscriptionController.GetSubscriptionPlanByID)
-	subscriptionPlans.Patch("/:plan_id", adminSubscriptionController.UpdateSubscriptionPlan, m.Auth(userService, productTokenService, "manageSubscriptionPlans"))
+	subscriptionPlans.Post("/", m.RequirePermission("manageSubscriptionPlans"), adminSubscriptionController.CreateSubscriptionPlan)
+	subscriptionPlans.Patch("/:plan_id", m.RequirePermission("manageSubscriptionPlans"), adminSubscriptionController.UpdateSubscriptionPlan)
+	subscriptionPlans.Delete("/:plan_id", m.RequirePermission("manageSubscriptionPlans"), adminActionController.DeleteSubscriptionPlan)
+	subscriptionPlans.Get("/:plan_id/sunset", adminPlanSunsetController.GetSunsetProgress)
+	subscriptionPlans.Post("/:plan_id/sunset", m.RequirePermission("manageSubscriptionPlans"), adminPlanSunsetController.SunsetPlan)
+	subscriptionPlans.Get("/:plan_id/history", adminSubscriptionController.GetPlanHistory)
+	subscriptionPlans.Post("/:plan_id/history/:change_id/rollback", m.RequirePermission("manageSubscriptionPlans"), adminSubscriptionController.RollbackPlanChange)
+
+	// Destructive actions waiting for their undo window
+	actions := admin.Group("/actions", m.RequirePermission("manageAdminActions"))
+	actions.Get("/", adminActionController.GetActions)
+	actions.Post("/:id/undo", adminActionController.Undo)
 
 	// All transactions route
-	transactions := admin.Group("/transactions", m.Auth(userService, productTokenService, "viewTransactions"))
+	transactions := admin.Group("/transactions", m.RequirePermission("viewTransactions"))
 	transactions.Get("/", adminSubscriptionController.GetAllTransactions)
+	transactions.Get("/export", adminSubscriptionController.ExportTransactions)
+	transactions.Post("/manual", m.RequirePermission("createManualTransaction"), idempotent, adminSubscriptionController.CreateManualTransaction)
 	transactions.Get("/:id", adminSubscriptionController.GetTransactionByID)
+
+	// Payment proof verification queue
+	paymentProofs := admin.Group("/payment-proofs", m.RequirePermission("verifyPaymentProofs"))
+	paymentProofs.Get("/", adminPaymentProofController.GetPaymentProofs)
+	paymentProofs.Patch("/:id/approve", adminPaymentProofController.ApprovePaymentProof)
+	paymentProofs.Patch("/:id/reject", adminPaymentProofController.RejectPaymentProof)
+
+	// Fraud review queue and allow/deny lists
+	fraud := admin.Group("/fraud", m.RequirePermission("manageFraud"))
+	fraud.Get("/reviews", adminFraudController.GetReviews)
+	fraud.Patch("/reviews/:id/approve", adminFraudController.ApproveReview)
+	fraud.Patch("/reviews/:id/reject", adminFraudController.RejectReview)
+	fraud.Get("/lists", adminFraudController.GetListEntries)
+	fraud.Post("/lists", adminFraudController.CreateListEntry)
+	fraud.Delete("/lists/:id", adminFraudController.DeleteListEntry)
+
+	// In-app purchase product mapping
+	storeProducts := admin.Group("/store-products", m.RequirePermission("manageStoreProducts"))
+	storeProducts.Get("/", adminStoreProductController.GetStoreProducts)
+	storeProducts.Post("/", adminStoreProductController.CreateStoreProduct)
+	storeProducts.Delete("/:id", adminStoreProductController.DeleteStoreProduct)
+
+	// Checkout coupons
+	coupons := admin.Group("/coupons", m.RequirePermission("manageCoupons"))
+	coupons.Get("/", adminCouponController.GetCoupons)
+	coupons.Post("/", adminCouponController.CreateCoupon)
+	coupons.Patch("/:id", adminCouponController.UpdateCoupon)
+
+	// Finance reports
+	reports := admin.Group("/reports", m.RequirePermission("viewRevenueReports"))
+	reports.Get("/revenue", adminReportController.GetRevenueReport)
+	reports.Get("/partner-overage", adminPartnerKeyController.GetOverage)
+
+	// Business event alerts
+	alerts := admin.Group("/alerts", m.RequirePermission("manageAlerts"))
+	alerts.Get("/", adminAlertController.GetAlertRules)
+	alerts.Post("/", adminAlertController.CreateAlertRule)
+	alerts.Patch("/:id", adminAlertController.UpdateAlertRule)
+	alerts.Delete("/:id", adminAlertController.DeleteAlertRule)
+	alerts.Post("/:id/test", adminAlertController.TestAlertRule)
+
+	// Diagnostics for ops
+	diagnostics := admin.Group("/diagnostics")
+	diagnostics.Get("/storage", m.RequirePermission("viewStorageUsage"), adminStorageController.GetStorageUsage)
+	diagnostics.Get("/email", m.RequirePermission("viewEmailHealth"), adminEmailController.GetEmailHealth)
+
+	// Custom roles granting some of the admin rights
+	roles := admin.Group("/roles", m.RequirePermission("manageRoles"))
+	roles.Get("/", adminRoleController.GetRoles)
+	roles.Get("/rights", adminRoleController.GetRights)
+	roles.Get("/:name", adminRoleController.GetRole)
+	roles.Post("/", adminRoleController.CreateRole)
+	roles.Patch("/:name", adminRoleController.UpdateRole)
+	roles.Delete("/:name", adminRoleController.DeleteRole)
+
+	// Audit trail of the activities utils.LogUserActivity logs
+	auditLogs := admin.Group("/audit-logs", m.RequirePermission("viewAuditLogs"))
+	auditLogs.Get("/", adminAuditLogController.GetAuditLogs)
+	auditLogs.Get("/:id", adminAuditLogController.GetAuditLog)
+
+	// Outbound webhooks for subscription and payment events
+	webhooks := admin.Group("/webhooks", m.RequirePermission("manageWebhooks"))
+	webhooks.Get("/", adminWebhookController.GetWebhookEndpoints)
+	webhooks.Post("/", adminWebhookController.CreateWebhookEndpoint)
+	webhooks.Get("/deliveries", adminWebhookController.GetWebhookDeliveries)
+	webhooks.Post("/deliveries/:id/retry", adminWebhookController.RetryWebhookDelivery)
+	webhooks.Get("/replays/:id", adminWebhookController.GetWebhookReplay)
+	webhooks.Post("/:id/replay", adminWebhookController.ReplayWebhookEvents)
+	webhooks.Patch("/:id", adminWebhookController.UpdateWebhookEndpoint)
+	webhooks.Delete("/:id", adminWebhookController.DeleteWebhookEndpoint)
+
+	// Ops bot account linking
+	opsBot := admin.Group("/ops-bot", m.RequirePermission("useOpsBot"))
+	opsBot.Post("/link-code", adminOpsController.CreateLinkCode)
+	opsBot.Get("/identities", adminOpsController.GetIdentities)
+	opsBot.Delete("/identities/:id", adminOpsController.DeleteIdentity)
+
+	// Maintenance mode
+	maintenance := admin.Group("/maintenance", m.RequirePermission("manageMaintenance"))
+	maintenance.Get("/", adminOpsController.GetMaintenance)
+	maintenance.Put("/", adminOpsController.UpdateMaintenance)
+
+	// Event log replay
+	events := admin.Group("/events", m.RequirePermission("replayEvents"))
+	events.Post("/replay/:projection", adminEventController.ReplayEvents)
+
+	// Database backups
+	backups := admin.Group("/backups", m.RequirePermission("manageBackups"))
+	backups.Get("/", adminBackupController.GetBackups)
+	backups.Post("/", adminBackupController.CreateBackup)
+
+	// Notification copy
+	templates := admin.Group("/notification-templates", m.RequirePermission("manageNotificationTemplates"))
+	templates.Get("/", adminNotificationTemplateController.GetTemplates)
+	templates.Post("/", adminNotificationTemplateController.SaveTemplate)
+	templates.Post("/preview", adminNotificationTemplateController.PreviewTemplate)
+	templates.Post("/versions/:id/restore", adminNotificationTemplateController.RestoreVersion)
+	templates.Get("/:key/:locale/versions", adminNotificationTemplateController.GetVersions)
+	templates.Delete("/:key/:locale", adminNotificationTemplateController.DeleteTemplate)
+
+	// Tracked app links
+	deepLinks := admin.Group("/deep-links", m.RequirePermission("manageDeepLinks"))
+	deepLinks.Post("/", adminDeepLinkController.CreateDeepLink)
+	deepLinks.Get("/clicks", adminDeepLinkController.GetClickStats)
+
+	// A/B experiments
+	experiments := admin.Group("/experiments", m.RequirePermission("manageExperiments"))
+	experiments.Get("/", adminExperimentController.GetExperiments)
+	experiments.Post("/", adminExperimentController.CreateExperiment)
+	experiments.Post("/:id/start", adminExperimentController.StartExperiment)
+	experiments.Post("/:id/stop", adminExperimentController.StopExperiment)
+	experiments.Get("/:id/results", adminExperimentController.GetResults)
+
+	// Onboarding
+	onboarding := admin.Group("/onboarding", m.RequirePermission("viewOnboardingFunnel"))
+	onboarding.Get("/funnel", adminOnboardingController.GetFunnel)
+
+	// Product analytics
+	analytics := admin.Group("/analytics")
+	analytics.Get("/cohorts", m.RequirePermission("viewCohortAnalytics"), adminCohortController.GetCohorts)
+	analytics.Get("/revenue", m.RequirePermission("viewRevenueReports"), adminReportController.GetRevenueAnalytics)
+	analytics.Get("/retention", m.RequirePermission("viewRevenueReports"), adminReportController.GetRetentionAnalytics)
+	analytics.Get("/checkout-funnel", m.RequirePermission("viewCheckoutFunnel"), adminCheckoutController.GetCheckoutFunnel)
+
+	// Data retention
+	retention := admin.Group("/retention", m.RequirePermission("manageRetention"))
+	retention.Get("/policies", adminRetentionController.GetPolicies)
+	retention.Post("/dry-run", adminRetentionController.DryRun)
+	retention.Get("/runs", adminRetentionController.GetRuns)
+	retention.Post("/media/dry-run", adminRetentionController.DryRunMediaCleanup)
+	retention.Get("/media/runs", adminRetentionController.GetMediaCleanupRuns)
+
+	// Partner API keys
+	partnerKeys := admin.Group("/partner-keys", m.RequirePermission("managePartnerKeys"))
+	partnerKeys.Get("/", adminPartnerKeyController.GetKeys)
+	partnerKeys.Post("/", adminPartnerKeyController.CreateKey)
+	partnerKeys.Patch("/:id", adminPartnerKeyController.UpdateKey)
+	partnerKeys.Delete("/:id", adminPartnerKeyController.RevokeKey)
+	partnerKeys.Get("/:id/usage", adminPartnerKeyController.GetUsage)
+
+	// Commercial tiers of the partner API
+	partnerTiers := admin.Group("/partner-tiers", m.RequirePermission("managePartnerKeys"))
+	partnerTiers.Get("/", adminPartnerKeyController.GetTiers)
+	partnerTiers.Post("/", adminPartnerKeyController.CreateTier)
+	partnerTiers.Patch("/:key", adminPartnerKeyController.UpdateTier)
+	partnerTiers.Put("/partners/:partner", adminPartnerKeyController.SetTier)
+
+	// Runtime config
+	runtimeConfig := admin.Group("/config", m.RequirePermission("manageConfig"))
+	runtimeConfig.Get("/", adminConfigController.GetConfig)
+	runtimeConfig.Patch("/", adminConfigController.UpdateConfig)
+	runtimeConfig.Get("/history", adminConfigController.GetHistory)
+
+	// Food search analytics
+	foodSearch := admin.Group("/food-search", m.RequirePermission("viewSearchAnalytics"))
+	foodSearch.Get("/gaps", adminFoodSearchController.GetSearchGaps)
+
+	// Nutrition dataset imports
+	foodImports := admin.Group("/food-imports", m.RequirePermission("importFoods"))
+	foodImports.Get("/", adminFoodImportController.GetImports)
+	foodImports.Post("/", adminFoodImportController.ImportFoods)
+	foodImports.Get("/:id", adminFoodImportController.GetImport)
+	foodImports.Get("/:id/items", adminFoodImportController.GetItems)
+
+	// Health grading of foods and meals
+	foodGrading := admin.Group("/food-grading", m.RequirePermission("manageFoodGrading"))
+	foodGrading.Get("/", adminFoodGradingController.GetGrading)
+	foodGrading.Put("/", adminFoodGradingController.UpdateGrading)
+	foodGrading.Delete("/", adminFoodGradingController.ResetGrading)
+
+	// Catalog of daily tips
+	tipRules := admin.Group("/tip-rules", m.RequirePermission("manageTipRules"))
+	tipRules.Get("/", adminTipRuleController.GetTipRules)
+	tipRules.Post("/", adminTipRuleController.CreateTipRule)
+	tipRules.Patch("/:id", adminTipRuleController.UpdateTipRule)
+	tipRules.Delete("/:id", adminTipRuleController.DeleteTipRule)
 }
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func AssistantRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, assistantService service.AssistantService) {
+	assistantController := controller.NewAssistantController(assistantService)
+
+	assistant := v1.Group("/assistant", m.Auth(u, p))
+	// The diary of the user is sent to the LLM provider, minors need the consent of a guardian as for scans
+	assistant.Post("/chat", m.ParentalConsentRequired(), assistantController.Chat)
+	assistant.Get("/quota", assistantController.GetQuota)
+	assistant.Get("/conversations", assistantController.GetConversations)
+	assistant.Get("/conversations/:id", assistantController.GetConversation)
+	assistant.Delete("/conversations/:id", assistantController.DeleteConversation)
+}
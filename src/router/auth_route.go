@@ -11,18 +11,18 @@ import (
 
 func AuthRoutes(
 	v1 fiber.Router, a service.AuthService, u service.UserService, p service.ProductTokenService,
-	t service.TokenService, e service.EmailService,
+	t service.TokenService, e service.EmailService, pc service.ParentalConsentService, cs service.CaptchaService,
 ) {
-	authController := controller.NewAuthController(a, u, t, e)
+	authController := controller.NewAuthController(a, u, t, e, pc)
 	config.GoogleConfig()
 
 	auth := v1.Group("/auth")
 
-	auth.Post("/register", authController.Register)
+	auth.Post("/register", m.Captcha(cs), authController.Register)
 	auth.Post("/login", authController.Login)
 	auth.Post("/logout", authController.Logout)
 	auth.Post("/refresh-tokens", authController.RefreshTokens)
-	auth.Post("/forgot-password", authController.ForgotPassword)
+	auth.Post("/forgot-password", m.Captcha(cs), authController.ForgotPassword)
 	auth.Post("/reset-password", authController.ResetPassword)
 	auth.Post("/send-verification-email", m.Auth(u, p), authController.SendVerificationEmail)
 	auth.Post("/verify-email", authController.VerifyEmail)
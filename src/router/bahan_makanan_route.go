@@ -8,14 +8,33 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func BahanMakananRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, bahanMakananService service.BahanMakananService) {
-	bahanMakananController := controller.NewBahanMakananController(bahanMakananService)
+func BahanMakananRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, bahanMakananService service.BahanMakananService, foodNameService service.FoodNameService, foodPortionService service.FoodPortionService, foodComparisonService service.FoodComparisonService, foodAlternativeService service.FoodAlternativeService, foodGradeService service.FoodGradeService) {
+	bahanMakananController := controller.NewBahanMakananController(bahanMakananService, foodGradeService)
+	foodNameController := controller.NewFoodNameController(foodNameService)
+	foodPortionController := controller.NewFoodPortionController(foodPortionService)
+	foodComparisonController := controller.NewFoodComparisonController(foodComparisonService)
+	foodAlternativeController := controller.NewFoodAlternativeController(foodAlternativeService)
 
 	bahanMakanan := v1.Group("/bahan-makanan")
 	bahanMakanan.Get("/", m.Auth(u, p), bahanMakananController.GetAllBahanMakanan)
+	bahanMakanan.Get("/search", m.Auth(u, p), foodNameController.Search)
+	bahanMakanan.Get("/units", m.Auth(u, p), foodPortionController.GetUnits)
 	bahanMakanan.Get("/:id", m.Auth(u, p), bahanMakananController.GetBahanMakananById)
 	bahanMakanan.Get("/kode/:kode", m.Auth(u, p), bahanMakananController.GetBahanMakananByKode)
+	bahanMakanan.Get("/kode/:kode/names", m.Auth(u, p), foodNameController.GetNames)
+	bahanMakanan.Put("/kode/:kode/names", m.Auth(u, p, "manageUsers"), foodNameController.SetNames)
+	bahanMakanan.Get("/kode/:kode/portions", m.Auth(u, p), foodPortionController.GetPortions)
+	bahanMakanan.Put("/kode/:kode/portions", m.Auth(u, p, "manageUsers"), foodPortionController.SetPortions)
+	bahanMakanan.Get("/kode/:kode/convert", m.Auth(u, p), foodPortionController.Convert)
+	bahanMakanan.Post("/kode/:kode/log", m.Auth(u, p), foodNameController.LogFood)
 	bahanMakanan.Get("/mentah-olahan/:mentah_olahan", m.Auth(u, p), bahanMakananController.GetBahanMakananByMentahOlahan)
 	bahanMakanan.Get("/kelompok/:kelompok", m.Auth(u, p), bahanMakananController.GetBahanMakananByKelompok)
 	bahanMakanan.Put("/:id", m.Auth(u, p, "manageUsers"), bahanMakananController.UpdateBahanMakanan)
+
+	foods := v1.Group("/foods")
+	foods.Get("/compare", m.Auth(u, p), foodComparisonController.Compare)
+	foods.Get("/diet-preferences", m.Auth(u, p), foodAlternativeController.GetDietPreference)
+	foods.Put("/diet-preferences", m.Auth(u, p), foodAlternativeController.UpdateDietPreference)
+	foods.Get("/:id/alternatives", m.Auth(u, p), foodAlternativeController.GetAlternatives)
+	foods.Post("/:id/alternatives/feedback", m.Auth(u, p), foodAlternativeController.SendFeedback)
 }
@@ -0,0 +1,15 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func DeepLinkRoutes(v1 fiber.Router, deepLinkService service.DeepLinkService) {
+	deepLinkController := controller.NewDeepLinkController(deepLinkService)
+
+	// Reached from notifications, the signed token identifies the screen and the user
+	v1.Get("/links/:token", deepLinkController.Open)
+}
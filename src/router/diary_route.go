@@ -0,0 +1,34 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func DiaryRoutes(
+	v1 fiber.Router, u service.UserService, p service.ProductTokenService, voiceLogService service.VoiceLogService,
+	diaryExportService service.DiaryExportService, diaryShareService service.DiaryShareService,
+) {
+	voiceLogController := controller.NewVoiceLogController(voiceLogService)
+	diaryExportController := controller.NewDiaryExportController(diaryExportService)
+	diaryShareController := controller.NewDiaryShareController(diaryShareService)
+
+	diary := v1.Group("/diary", m.Auth(u, p))
+	// The voice of the user is sent to the speech provider, minors need the consent of a guardian as for scans
+	diary.Post("/voice", m.ParentalConsentRequired(), m.VoiceLogQuota(voiceLogService), voiceLogController.LogVoice)
+
+	exports := v1.Group("/users/me/diary", m.Auth(u, p))
+	exports.Get("/export", diaryExportController.Export)
+	exports.Get("/exports/:id", diaryExportController.GetExport)
+	exports.Get("/exports/:id/download", diaryExportController.Download)
+	exports.Get("/exports/:id/downloads", diaryExportController.GetDownloads)
+	exports.Post("/shares", diaryShareController.CreateShare)
+	exports.Get("/shares", diaryShareController.GetShares)
+	exports.Delete("/shares/:id", diaryShareController.RevokeShare)
+
+	// The doctor opens the shared link without an account, the token in it is the only credential
+	v1.Get("/shared/diary", diaryShareController.ViewShare)
+}
@@ -0,0 +1,15 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func DownloadRoutes(v1 fiber.Router, downloadLinkService service.DownloadLinkService, diaryExportService service.DiaryExportService) {
+	downloadController := controller.NewDownloadController(downloadLinkService, diaryExportService)
+
+	// Download links open in a browser without an account, the token in them is the only credential
+	v1.Get("/downloads", downloadController.Download)
+}
@@ -0,0 +1,15 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func EmailRoutes(v1 fiber.Router, emailSuppressionService service.EmailSuppressionService) {
+	emailController := controller.NewEmailController(emailSuppressionService)
+
+	// SNS notifications - authenticated by the signature of SNS instead of a user
+	v1.Post("/email/ses/notifications", emailController.HandleSESNotification)
+}
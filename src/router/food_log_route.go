@@ -0,0 +1,23 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func FoodLogRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, ml service.MealService) {
+	mealController := controller.NewMealController(ml)
+
+	// The food diary: a food log is a meal of /meals with its time, portion and nutrients, the day and week
+	// views total them per day. Logging and reading the diary is free, like POST /meals.
+	foodLogs := v1.Group("/food-logs", m.Auth(u, p))
+
+	foodLogs.Get("/", mealController.GetDiary)
+	foodLogs.Post("/", mealController.AddMeal)
+	foodLogs.Get("/:mealId", mealController.GetMealByID)
+	foodLogs.Put("/:mealId", mealController.UpdateMeal)
+	foodLogs.Delete("/:mealId", mealController.DeleteMeal)
+}
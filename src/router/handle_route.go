@@ -0,0 +1,17 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func HandleRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, handleService service.HandleService) {
+	handleController := controller.NewHandleController(handleService)
+
+	// Registered before the user routes, /users/:userId would take handle-available otherwise
+	v1.Get("/users/handle-available", m.Auth(u, p), handleController.CheckAvailability)
+	v1.Put("/users/me/handle", m.Auth(u, p), handleController.SetHandle)
+}
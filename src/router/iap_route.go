@@ -0,0 +1,22 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func IAPRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, iapService service.IAPService) {
+	iapController := controller.NewIAPController(iapService)
+
+	iap := v1.Group("/iap")
+
+	// Store server notifications - authenticated by signature or token instead of a user
+	iap.Post("/apple/notifications", iapController.HandleAppleNotification)
+	iap.Post("/google/notifications", iapController.HandleGoogleNotification)
+
+	iap.Post("/apple/verify", m.Auth(u, p), m.ParentalConsentRequired(), iapController.VerifyAppleReceipt)
+	iap.Post("/google/verify", m.Auth(u, p), m.ParentalConsentRequired(), iapController.VerifyGooglePurchase)
+}
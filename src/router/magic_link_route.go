@@ -0,0 +1,18 @@
+package router
+
+import (
+	"app/src/config"
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func MagicLinkRoutes(v1 fiber.Router, t service.TokenService, magicLinkService service.MagicLinkService) {
+	magicLinkController := controller.NewMagicLinkController(magicLinkService, t)
+
+	// Every request counts against the limit of the IP address, whether or not a link went out
+	v1.Post("/auth/magic-link", m.HourlyLimiter(config.MagicLinkMaxPerIPPerHour), magicLinkController.Request)
+	v1.Post("/auth/magic-link/login", magicLinkController.Login)
+}
@@ -3,19 +3,23 @@ package router
 import (
 	"app/src/controller"
 	m "app/src/middleware"
+	"app/src/model"
 	"app/src/service"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func MealRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, ml service.MealService, ss service.SubscriptionService) {
+func MealRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, ml service.MealService, ss service.SubscriptionService, ns service.NutritionSummaryService, sq service.ScanQuotaService, fa service.FeatureAccessService) {
 	mealController := controller.NewMealController(ml)
+	nutritionController := controller.NewNutritionController(ns)
 
 	meal := v1.Group("/meals")
 
-	meal.Get("/", m.Auth(u, p), m.SubscriptionRequired(ss, "health_info"), mealController.GetMeals)
+	meal.Get("/", m.Auth(u, p), m.SubscriptionRequired(ss), m.FeatureRequired(fa, model.FeatureHealthInfo), mealController.GetMeals)
 	meal.Post("/", m.Auth(u, p), mealController.AddMeal)
-	meal.Post("/scan", m.Auth(u, p), mealController.ScanMeal)
+	meal.Post("/scan", m.Auth(u, p), m.ParentalConsentRequired(), m.ScanQuota(sq), mealController.ScanMeal)
+	meal.Get("/diary", m.Auth(u, p), mealController.GetDiary)
+	meal.Get("/nutrition-report", m.Auth(u, p), m.SubscriptionRequired(ss), m.FeatureRequired(fa, model.FeatureHealthInfo), nutritionController.GetNutritionReport)
 	meal.Get("/:mealId", m.Auth(u, p), mealController.GetMealByID)
 	meal.Put("/:mealId", m.Auth(u, p), mealController.UpdateMeal)
 	meal.Delete("/:mealId", m.Auth(u, p), mealController.DeleteMeal)
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ModerationRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, moderationService service.ModerationService) {
+	moderationController := controller.NewModerationController(moderationService)
+
+	v1.Get("/users/me/blocks", m.Auth(u, p), moderationController.GetBlocks)
+	v1.Post("/profiles/:handle/block", m.Auth(u, p), moderationController.Block)
+	v1.Delete("/profiles/:handle/block", m.Auth(u, p), moderationController.Unblock)
+	v1.Post("/profiles/:handle/report", m.Auth(u, p), moderationController.Report)
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func NotificationRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, notificationPreferenceService service.NotificationPreferenceService) {
+	notificationController := controller.NewNotificationController(notificationPreferenceService)
+
+	notifications := v1.Group("/notifications")
+	notifications.Get("/preferences", m.Auth(u, p), notificationController.GetPreferences)
+	notifications.Put("/preferences", m.Auth(u, p), notificationController.UpdatePreferences)
+
+	// Reached from emails, the token of the link identifies the user
+	notifications.Post("/unsubscribe", notificationController.Unsubscribe)
+}
@@ -0,0 +1,17 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func OnboardingRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, onboardingService service.OnboardingService) {
+	onboardingController := controller.NewOnboardingController(onboardingService)
+
+	onboarding := v1.Group("/users/me/onboarding")
+	onboarding.Get("/", m.Auth(u, p), onboardingController.GetOnboarding)
+	onboarding.Post("/notification-permission", m.Auth(u, p), onboardingController.ReportNotificationPermission)
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func OpsBotRoutes(v1 fiber.Router, opsBotService service.OpsBotService) {
+	opsBotController := controller.NewOpsBotController(opsBotService)
+
+	opsBot := v1.Group("/ops-bot")
+
+	// Chat platform webhooks - authenticated by signature or secret, commands run as the linked admin
+	opsBot.Post("/slack", opsBotController.HandleSlackCommand)
+	opsBot.Post("/telegram", opsBotController.HandleTelegramUpdate)
+}
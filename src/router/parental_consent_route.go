@@ -0,0 +1,20 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ParentalConsentRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, parentalConsentService service.ParentalConsentService) {
+	parentalConsentController := controller.NewParentalConsentController(parentalConsentService)
+
+	v1.Get("/users/me/parental-consent", m.Auth(u, p), parentalConsentController.GetStatus)
+	v1.Post("/users/me/parental-consent", m.Auth(u, p), parentalConsentController.RequestConsent)
+
+	// The guardian answers from the emailed link, the token in it is the only credential
+	v1.Get("/parental-consent", parentalConsentController.GetPrompt)
+	v1.Post("/parental-consent", parentalConsentController.Respond)
+}
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PartnerConsentRoutes let users see the partners that enrolled them and decide which may read their health data
+func PartnerConsentRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, partnerService service.PartnerService) {
+	partnerConsentController := controller.NewPartnerConsentController(partnerService)
+
+	partners := v1.Group("/users/me/partners", m.Auth(u, p))
+	partners.Get("/", partnerConsentController.GetMemberships)
+	// Health data of minors leaves Nutribox only with the consent of a guardian
+	partners.Post("/:partner/enrollment-code", m.ParentalConsentRequired(), partnerConsentController.CreateEnrollmentCode)
+	partners.Put("/:partner/consent", m.ParentalConsentRequired(), partnerConsentController.GrantConsent)
+	partners.Delete("/:partner/consent", partnerConsentController.RevokeConsent)
+}
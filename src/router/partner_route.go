@@ -0,0 +1,36 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/model"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PartnerRoutes are the partner API, authenticated by partner API keys instead of a user
+func PartnerRoutes(
+	v1 fiber.Router, partnerService service.PartnerService, bahanMakananService service.BahanMakananService, fhirService service.FHIRService,
+) {
+	partnerController := controller.NewPartnerController(partnerService, bahanMakananService, fhirService)
+
+	partner := v1.Group("/partner")
+
+	partner.Get("/usage", m.PartnerSelf(partnerService), partnerController.GetUsage)
+
+	readFoods := m.PartnerKey(partnerService, model.PartnerScopeReadFoods)
+	partner.Get("/foods", readFoods, partnerController.GetFoods)
+	partner.Get("/foods/:kode", readFoods, partnerController.GetFood)
+
+	writeMembers := m.PartnerKey(partnerService, model.PartnerScopeWriteMembers)
+	partner.Post("/members", writeMembers, partnerController.EnrollMember)
+	partner.Delete("/members/:userId", writeMembers, partnerController.RemoveMember)
+
+	partner.Get("/members/:userId/entitlements",
+		m.PartnerKey(partnerService, model.PartnerScopeReadEntitlements), partnerController.GetEntitlement)
+
+	// FHIR search of the observations of a member, the path mirrors the FHIR REST API
+	partner.Get("/members/:userId/fhir/Observation",
+		m.PartnerKey(partnerService, model.PartnerScopeReadObservations), partnerController.GetObservations)
+}
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func PasskeyRoutes(
+	v1 fiber.Router, u service.UserService, p service.ProductTokenService, t service.TokenService,
+	passkeyService service.PasskeyService,
+) {
+	passkeyController := controller.NewPasskeyController(passkeyService, t)
+
+	v1.Post("/auth/passkey/options", passkeyController.LoginOptions)
+	v1.Post("/auth/passkey/login", passkeyController.Login)
+
+	passkeys := v1.Group("/users/me/passkeys", m.Auth(u, p))
+	passkeys.Get("/", passkeyController.GetPasskeys)
+	passkeys.Post("/options", passkeyController.RegistrationOptions)
+	passkeys.Post("/", passkeyController.Register)
+	passkeys.Delete("/:id", passkeyController.DeletePasskey)
+}
@@ -0,0 +1,29 @@
+package router
+
+import (
+	"app/src/config"
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func PhoneRoutes(
+	v1 fiber.Router, u service.UserService, p service.ProductTokenService, t service.TokenService,
+	phoneService service.PhoneService,
+) {
+	phoneController := controller.NewPhoneController(phoneService, t)
+
+	// Every request counts against the limit of the IP address, whether or not a code went out, so the limit
+	// does not tell which phones have an account
+	codeLimiter := m.HourlyLimiter(config.OTPMaxPerIPPerHour)
+
+	v1.Post("/auth/otp/send", codeLimiter, phoneController.SendLoginCode)
+	v1.Post("/auth/otp/login", phoneController.Login)
+	v1.Post("/users/me/phone/otp", m.Auth(u, p), codeLimiter, phoneController.SendVerificationCode)
+	v1.Post("/users/me/phone/verify", m.Auth(u, p), phoneController.VerifyPhone)
+
+	// Twilio status callbacks - authenticated by the signature of Twilio instead of a user
+	v1.Post("/messaging/twilio/status", phoneController.HandleTwilioStatus)
+}
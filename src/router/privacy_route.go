@@ -0,0 +1,17 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func PrivacyRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, privacyService service.PrivacyService) {
+	privacyController := controller.NewPrivacyController(privacyService)
+
+	v1.Get("/users/me/privacy", m.Auth(u, p), privacyController.GetSettings)
+	v1.Patch("/users/me/privacy", m.Auth(u, p), privacyController.UpdateSettings)
+	v1.Get("/profiles/:handle", m.Auth(u, p), privacyController.GetPublicProfile)
+}
@@ -0,0 +1,27 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func PublicRoutes(v1 fiber.Router, publicPlanService service.PublicPlanService, publicContentService service.PublicContentService, geoService service.GeoService) {
+	publicPlanController := controller.NewPublicPlanController(publicPlanService)
+	geoController := controller.NewGeoController(geoService)
+	publicContentController := controller.NewPublicContentController(publicContentService)
+
+	// Read by the marketing website, without authentication
+	public := v1.Group("/public", m.PublicLimiter())
+	public.Get("/plans", publicPlanController.GetPlans)
+	public.Get("/locale", geoController.GetDefaults)
+	public.Get("/articles", publicContentController.GetArticles)
+	public.Get("/articles/:slug", publicContentController.GetArticle)
+	public.Get("/recipes", publicContentController.GetRecipes)
+	public.Get("/recipes/:slug", publicContentController.GetRecipe)
+	public.Get("/feed.json", publicContentController.GetJSONFeed)
+	public.Get("/feed.xml", publicContentController.GetRSSFeed)
+	public.Get("/sitemap.xml", publicContentController.GetSitemap)
+}
@@ -0,0 +1,15 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RectificationRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, rectificationService service.RectificationService) {
+	rectificationController := controller.NewRectificationController(rectificationService)
+
+	v1.Post("/users/me/rectification", m.Auth(u, p), rectificationController.RectifyMine)
+}
@@ -3,8 +3,16 @@ package router
 import (
 	"app/src/config"
 	"app/src/grpc"
+	"app/src/iap"
+	"app/src/llm"
+	m "app/src/middleware"
+	"app/src/redis"
+	"app/src/searchindex"
 	"app/src/service"
+	"app/src/speech"
+	"app/src/utils"
 	"app/src/validation"
+	"context"
 	"fmt"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,37 +23,138 @@ func Routes(app *fiber.App, db *gorm.DB) {
 	validate := validation.Validator()
 	grpcServerAddr := fmt.Sprintf("%s:%s", config.GRPC_HOST, config.GRPC_PORT)
 	client, _ := grpc.NewBahanMakananClient(grpcServerAddr)
+	searchIndexService := service.NewSearchIndexService(db, searchIndexClient(), client)
 
-	healthCheckService := service.NewHealthCheckService(db)
-	emailService := service.NewEmailService()
+	healthCheckService := service.NewHealthCheckService(db, searchIndexService)
+	notificationTemplateService := service.NewNotificationTemplateService(db, validate)
+	notificationPreferenceService := service.NewNotificationPreferenceService(db, validate)
+	experimentService := service.NewExperimentService(db, validate)
+	emailDeliveryService := service.NewEmailDeliveryService(db)
+	emailSuppressionService := service.NewEmailSuppressionService(db)
+	activityLogService := service.NewActivityLogService(db, validate)
+	// Activities are kept in the database besides the activity log file, for the audit log API
+	utils.SetActivityStore(activityLogService)
+	phoneService := service.NewPhoneService(db, validate)
+	roleService := service.NewRoleService(db, validate)
+	passkeyService := service.NewPasskeyService(db, validate)
+	emailService := service.NewEmailService(notificationTemplateService, notificationPreferenceService, experimentService, emailDeliveryService, phoneService)
 	userService := service.NewUserService(db, validate)
 	paymentService := service.NewMidtransPaymentService()
-	subscriptionService := service.NewSubscriptionService(db, paymentService)
+	sandboxPaymentService := service.NewMidtransSandboxPaymentService()
+	alertService := service.NewAlertService(db, validate, emailService)
+	walletService := service.NewWalletService(db, validate, alertService)
+	geoService := service.NewGeoService()
+	fraudService := service.NewFraudService(db, validate, geoService)
+	subscriptionService := service.NewSubscriptionService(db, validate, paymentService, sandboxPaymentService, walletService, fraudService, alertService)
 	tokenService := service.NewTokenService(db, validate, userService, subscriptionService)
-	authService := service.NewAuthService(db, validate, userService, tokenService)
+	captchaService, err := service.NewCaptchaService(db)
+	if err != nil {
+		utils.Log.Fatalf("Failed to set up CAPTCHA: %v", err)
+	}
+	authService := service.NewAuthService(db, validate, userService, tokenService, captchaService)
 	productTokenService := service.NewProductTokenService(db, validate)
-	mealService := service.NewMealService(db, config.LogMealApiKey, config.LogMealBaseUrl)
+	foodGradeService := service.NewFoodGradeService(db, validate)
+	dailyTipService := service.NewDailyTipService(db, validate, emailService)
+	mealService := service.NewMealService(db, validate, config.LogMealApiKey, config.LogMealBaseUrl, alertService, foodGradeService, dailyTipService)
+	nutritionSummaryService := service.NewNutritionSummaryService(db, validate)
+	scanQuotaService := service.NewScanQuotaService(db, redisClient())
+	// The scan limits cached in Redis are dropped when a subscription changes
+	service.OnSubscriptionChange(scanQuotaService.Invalidate)
+	featureAccessService := service.NewFeatureAccessService(db)
 	uwhService := service.NewUsersWeightHeightService(db)
-	articleService := service.NewArticlesService(db)
-	recipesService := service.NewRecipesService(db)
+	articleService := service.NewArticlesService(db, searchIndexService)
+	recipesService := service.NewRecipesService(db, searchIndexService)
 	loginStreakService := service.NewLoginStreakService(db, validate)
-	bahanMakananService := service.NewBahanMakananService(client)
+	bahanMakananService := service.NewBahanMakananService(client, searchIndexService)
+	deepLinkService := service.NewDeepLinkService(db, validate)
+	publicPlanService := service.NewPublicPlanService(db, validate)
+	publicContentService := service.NewPublicContentService(db, validate)
+	paymentProofService := service.NewPaymentProofService(db, validate, subscriptionService, emailService, deepLinkService)
+	installmentService := service.NewInstallmentService(db, paymentService, sandboxPaymentService, emailService)
+	fraudReviewService := service.NewFraudReviewService(db, validate, subscriptionService)
+	iapService := service.NewIAPService(db, validate, subscriptionService, scanQuotaService, appleClient(), googleClient())
+	couponService := service.NewCouponService(db, validate)
+	revenueService := service.NewRevenueService(db, validate)
+	checkoutService := service.NewCheckoutService(db, validate, couponService, subscriptionService, emailService)
+	maintenanceService := service.NewMaintenanceService(db, validate)
+	opsBotService := service.NewOpsBotService(db, emailService, maintenanceService)
+	entitlementService := service.NewEntitlementService(db, scanQuotaService, maintenanceService)
+	eventLogService := service.NewEventLogService(db)
+	backupService := service.NewBackupService(db, validate)
+	onboardingService := service.NewOnboardingService(db, validate)
+	cohortService := service.NewCohortService(db, validate)
+	planSunsetService := service.NewPlanSunsetService(db, validate, emailService)
+	adminActionService := service.NewAdminActionService(db, validate)
+	retentionService := service.NewRetentionService(db, validate)
+	mediaCleanupService := service.NewMediaCleanupService(db, validate)
+	rectificationService := service.NewRectificationService(db, validate)
+	parentalConsentService := service.NewParentalConsentService(db, validate, emailService)
+	handleService := service.NewHandleService(db, validate)
+	privacyService := service.NewPrivacyService(db, validate)
+	moderationService := service.NewModerationService(db, validate, emailService)
+	webhookService := service.NewWebhookService(db, validate)
+	storageUsageService := service.NewStorageUsageService(db, validate)
+	idempotencyService := service.NewIdempotencyService(db)
+	partnerService := service.NewPartnerService(db, validate, subscriptionService)
+	runtimeConfigService := service.NewRuntimeConfigService(db, validate)
+	foodPortionService := service.NewFoodPortionService(db, validate, bahanMakananService)
+	foodComparisonService := service.NewFoodComparisonService(db, validate, bahanMakananService)
+	foodAlternativeService := service.NewFoodAlternativeService(db, validate, bahanMakananService)
+	foodNameService := service.NewFoodNameService(db, validate, bahanMakananService, searchIndexService, foodPortionService)
+	foodImportService := service.NewFoodImportService(db, validate, client, searchIndexService)
+	contentSearchService := service.NewContentSearchService(db, validate, searchIndexService)
+	assistantService := service.NewAssistantService(db, validate, llmProvider(), alertService)
+	downloadLinkService := service.NewDownloadLinkService(db, validate)
+	magicLinkService := service.NewMagicLinkService(db, validate, emailService)
+	diaryExportService := service.NewDiaryExportService(db, validate, downloadLinkService)
+	diaryShareService := service.NewDiaryShareService(db, validate)
+	fhirService := service.NewFHIRService(db, validate)
+	voiceLogService := service.NewVoiceLogService(db, validate, speechTranscriber(), foodNameService, foodPortionService, alertService)
 
-	v1 := app.Group("/v1")
+	v1 := app.Group("/v1",
+		m.RequestDeadline(config.DBQueryTimeout, config.DBAdminQueryTimeout),
+		m.TestClock(config.TimeTravelEnabled),
+		m.Maintenance(maintenanceService))
+	// Logins record where they come from
+	v1.Use("/auth", m.Geo(geoService))
 
 	HealthCheckRoutes(v1, healthCheckService)
-	AuthRoutes(v1, authService, userService, productTokenService, tokenService, emailService)
+	AuthRoutes(v1, authService, userService, productTokenService, tokenService, emailService, parentalConsentService, captchaService)
+	OnboardingRoutes(v1, userService, productTokenService, onboardingService)
+	RectificationRoutes(v1, userService, productTokenService, rectificationService)
+	ParentalConsentRoutes(v1, userService, productTokenService, parentalConsentService)
+	HandleRoutes(v1, userService, productTokenService, handleService)
+	PrivacyRoutes(v1, userService, productTokenService, privacyService)
+	ModerationRoutes(v1, userService, productTokenService, moderationService)
 	UserRoutes(v1, userService, productTokenService, tokenService)
 	ProductTokenRoutes(v1, userService, productTokenService)
-	MealRoutes(v1, userService, productTokenService, mealService, subscriptionService)
+	MealRoutes(v1, userService, productTokenService, mealService, subscriptionService, nutritionSummaryService, scanQuotaService, featureAccessService)
+	FoodLogRoutes(v1, userService, productTokenService, mealService)
+	ScanRoutes(v1, userService, productTokenService, mealService, scanQuotaService)
 	UsersWeightHeightRoutes(v1, userService, productTokenService, uwhService)
 	ArticleRoutes(v1, userService, productTokenService, articleService)
 	RecipeRoutes(v1, userService, productTokenService, recipesService)
-	SubscriptionRoutes(v1, userService, productTokenService, subscriptionService)
-	AdminRoutes(v1, userService, tokenService, productTokenService, subscriptionService)
+	SearchRoutes(v1, userService, productTokenService, contentSearchService)
+	SubscriptionRoutes(v1, userService, productTokenService, subscriptionService, paymentProofService, installmentService, checkoutService, experimentService, scanQuotaService, idempotencyService, featureAccessService)
+	AdminRoutes(v1, userService, tokenService, productTokenService, subscriptionService, paymentProofService, walletService, fraudService, fraudReviewService, iapService, couponService, checkoutService, revenueService, alertService, opsBotService, maintenanceService, entitlementService, eventLogService, backupService, notificationTemplateService, deepLinkService, experimentService, onboardingService, retentionService, mediaCleanupService, rectificationService, partnerService, runtimeConfigService, foodNameService, foodImportService, foodGradeService, dailyTipService, cohortService, planSunsetService, adminActionService, moderationService, webhookService, storageUsageService, idempotencyService, emailDeliveryService, emailSuppressionService, activityLogService, phoneService, roleService, passkeyService)
 	LoginStreakRoutes(v1, userService, productTokenService, loginStreakService)
-	BahanMakananRoutes(v1, userService, productTokenService, bahanMakananService)
+	BahanMakananRoutes(v1, userService, productTokenService, bahanMakananService, foodNameService, foodPortionService, foodComparisonService, foodAlternativeService, foodGradeService)
 	HomeRoutes(v1, userService, productTokenService, mealService)
+	WalletRoutes(v1, userService, productTokenService, walletService)
+	IAPRoutes(v1, userService, productTokenService, iapService)
+	OpsBotRoutes(v1, opsBotService)
+	NotificationRoutes(v1, userService, productTokenService, notificationPreferenceService)
+	DeepLinkRoutes(v1, deepLinkService)
+	PublicRoutes(v1, publicPlanService, publicContentService, geoService)
+	PartnerRoutes(v1, partnerService, bahanMakananService, fhirService)
+	PartnerConsentRoutes(v1, userService, productTokenService, partnerService)
+	AssistantRoutes(v1, userService, productTokenService, assistantService)
+	DiaryRoutes(v1, userService, productTokenService, voiceLogService, diaryExportService, diaryShareService)
+	DownloadRoutes(v1, downloadLinkService, diaryExportService)
+	EmailRoutes(v1, emailSuppressionService)
+	PhoneRoutes(v1, userService, productTokenService, tokenService, phoneService)
+	MagicLinkRoutes(v1, tokenService, magicLinkService)
+	PasskeyRoutes(v1, userService, productTokenService, tokenService, passkeyService)
 
 	// TODO: add another routes here...
 
@@ -53,3 +162,70 @@ func Routes(app *fiber.App, db *gorm.DB) {
 		DocsRoutes(v1)
 	}
 }
+
+// appleClient returns nil when App Store purchases are not configured
+func appleClient() *iap.AppleClient {
+	client, err := iap.NewAppleClient(config.AppleIAPSharedSecret, config.AppleIAPBundleID, config.AppleIAPRootCAPath)
+	if err != nil {
+		utils.Log.Warnf("App Store purchases disabled: %v", err)
+		return nil
+	}
+	return client
+}
+
+// redisClient returns nil when Redis is not configured
+func redisClient() *redis.Client {
+	client, err := redis.New(config.RedisURL)
+	if err != nil {
+		utils.Log.Warnf("Redis disabled: %v", err)
+		return nil
+	}
+	return client
+}
+
+// searchIndexClient returns nil when no search index is configured
+func searchIndexClient() *searchindex.Client {
+	client, err := searchindex.New(config.SearchIndexURL, config.SearchIndexPrefix)
+	if err != nil {
+		utils.Log.Warnf("Search index disabled: %v", err)
+		return nil
+	}
+	return client
+}
+
+// llmProvider returns nil when the assistant is not configured
+func llmProvider() llm.Provider {
+	provider, err := llm.New(config.AssistantProvider, config.AssistantAPIKey, config.AssistantBaseURL, config.AssistantTimeout,
+		llm.Options{Model: config.AssistantModel, MaxTokens: config.AssistantMaxTokens, Temperature: 0.3})
+	if err != nil {
+		utils.Log.Warnf("Assistant disabled: %v", err)
+		return nil
+	}
+	if provider == nil {
+		utils.Log.Warn("Assistant disabled: ASSISTANT_API_KEY is not set")
+	}
+	return provider
+}
+
+// speechTranscriber returns nil when voice logging is not configured
+func speechTranscriber() speech.Transcriber {
+	transcriber, err := speech.New(config.SpeechProvider, config.SpeechAPIKey, config.SpeechBaseURL, config.SpeechModel, config.SpeechTimeout)
+	if err != nil {
+		utils.Log.Warnf("Voice logging disabled: %v", err)
+		return nil
+	}
+	if transcriber == nil {
+		utils.Log.Warn("Voice logging disabled: SPEECH_API_KEY is not set")
+	}
+	return transcriber
+}
+
+// googleClient returns nil when Google Play purchases are not configured
+func googleClient() *iap.GoogleClient {
+	client, err := iap.NewGoogleClient(context.Background(), config.GooglePlayPackageName, config.GooglePlayServiceAccountPath)
+	if err != nil {
+		utils.Log.Warnf("Google Play purchases disabled: %v", err)
+		return nil
+	}
+	return client
+}
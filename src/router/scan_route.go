@@ -0,0 +1,19 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ScanRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, ml service.MealService, sq service.ScanQuotaService) {
+	mealController := controller.NewMealController(ml)
+
+	scan := v1.Group("/scan")
+
+	// Same scan as /meals/scan, it logs the meal and takes one AI scan from the plan
+	scan.Post("/food", m.Auth(u, p), m.ParentalConsentRequired(), m.ScanQuota(sq), mealController.ScanMeal)
+	scan.Get("/history", m.Auth(u, p), mealController.GetScanHistory)
+}
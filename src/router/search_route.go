@@ -0,0 +1,16 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SearchRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, contentSearchService service.ContentSearchService) {
+	searchController := controller.NewSearchController(contentSearchService)
+
+	search := v1.Group("/search")
+	search.Get("/", m.Auth(u, p), searchController.SearchContent)
+}
@@ -13,12 +13,27 @@ func SubscriptionRoutes(
 	u service.UserService,
 	p service.ProductTokenService,
 	subService service.SubscriptionService,
+	paymentProofService service.PaymentProofService,
+	installmentService service.InstallmentService,
+	checkoutService service.CheckoutService,
+	experimentService service.ExperimentService,
+	scanQuotaService service.ScanQuotaService,
+	idempotencyService service.IdempotencyService,
+	featureAccessService service.FeatureAccessService,
 ) {
-	subController := controller.NewSubscriptionController(subService)
+	subController := controller.NewSubscriptionController(subService, experimentService, scanQuotaService, featureAccessService)
+	paymentProofController := controller.NewPaymentProofController(paymentProofService)
+	installmentController := controller.NewInstallmentController(installmentService)
+	checkoutController := controller.NewCheckoutController(checkoutService)
+	checkoutVelocity := m.CheckoutVelocity()
+	// Retries of a purchase with the same Idempotency-Key get the first response instead of a second charge
+	// Replays of a completed request skip checkoutVelocity, the 429s it answers are not stored for their key
+	idempotent := m.Idempotent(idempotencyService)
 
 	subGroup := v1.Group("/subscriptions")
 	{
 		subGroup.Get("/plans", subController.GetPlans)
+		subGroup.Get("/plans/:planID", subController.GetPlan)
 
 		// Webhook endpoint for payment notification - doesn't require auth
 		subGroup.Post("/notification", subController.HandlePaymentNotification)
@@ -29,8 +44,24 @@ func SubscriptionRoutes(
 		authGroup := subGroup.Group("", m.Auth(u, p))
 		{
 			authGroup.Get("/me", subController.GetMySubscription)
+			authGroup.Get("/me/usage", subController.GetMyUsage)
+			authGroup.Get("/me/plans", subController.GetMyPlans)
+			authGroup.Post("/me/upgrade", m.ParentalConsentRequired(), idempotent, checkoutVelocity, subController.UpgradeMySubscription)
+			authGroup.Delete("/me/auto-renew", subController.CancelMyAutoRenew)
 			authGroup.Get("/check-feature", subController.CheckFeatureAccess)
-			authGroup.Post("/purchase/:planID", subController.PurchasePlan)
+			authGroup.Post("/purchase/:planID", m.ParentalConsentRequired(), idempotent, checkoutVelocity, subController.PurchasePlan)
+			authGroup.Post("/trial/:planID", m.ParentalConsentRequired(), idempotent, subController.StartTrial)
+			authGroup.Post("/:subscriptionID/payment-proof", idempotent, paymentProofController.UploadPaymentProof)
+			authGroup.Get("/:subscriptionID/installments", installmentController.GetInstallments)
+			authGroup.Put("/:subscriptionID/auto-renew", subController.SetAutoRenew)
 		}
 	}
+
+	// Checkout sessions, the token in the payment link is enough to view and pay a session
+	checkout := v1.Group("/checkout")
+	{
+		checkout.Post("/", m.Auth(u, p), m.ParentalConsentRequired(), idempotent, checkoutVelocity, checkoutController.CreateCheckout)
+		checkout.Get("/:token", checkoutController.GetCheckout)
+		checkout.Post("/:token/pay", idempotent, checkoutVelocity, checkoutController.PayCheckout)
+	}
 }
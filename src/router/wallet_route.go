@@ -0,0 +1,17 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func WalletRoutes(v1 fiber.Router, u service.UserService, p service.ProductTokenService, walletService service.WalletService) {
+	walletController := controller.NewWalletController(walletService)
+
+	wallet := v1.Group("/wallet", m.Auth(u, p))
+	wallet.Get("/", walletController.GetMyWallet)
+	wallet.Get("/transactions", walletController.GetMyTransactions)
+}
@@ -0,0 +1,259 @@
+package searchindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultTimeout bounds a request when its context has no deadline
+	defaultTimeout = 2 * time.Second
+
+	// downCooldown is how long the index is skipped after a failure, so searches fall back at once
+	// instead of each waiting for a timeout
+	downCooldown = 30 * time.Second
+)
+
+// Client talks to an Elasticsearch or OpenSearch cluster over its REST API. Index names get a prefix
+// so several environments can share a cluster.
+type Client struct {
+	endpoint string
+	username string
+	password string
+	prefix   string
+	http     *http.Client
+
+	// downUntil holds the unix nanoseconds until which the index counts as down
+	downUntil atomic.Int64
+}
+
+// Document is a document to index with its ID
+type Document struct {
+	ID     string
+	Source any
+}
+
+// Hit is a document found by a search
+type Hit struct {
+	ID     string          `json:"_id"`
+	Score  float64         `json:"_score"`
+	Source json.RawMessage `json:"_source"`
+}
+
+// New parses an http(s)://[user:password@]host[:port] URL, it returns nil when rawURL is empty
+func New(rawURL, prefix string) (*Client, error) {
+	if rawURL == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("searchindex: unsupported scheme %q", u.Scheme)
+	}
+
+	client := &Client{prefix: prefix, http: &http.Client{}}
+	if u.User != nil {
+		client.username = u.User.Username()
+		client.password, _ = u.User.Password()
+		u.User = nil
+	}
+	client.endpoint = strings.TrimRight(u.String(), "/")
+
+	return client, nil
+}
+
+// Available reports whether the index answered lately, callers fall back to SQL when it did not
+func (c *Client) Available() bool {
+	return time.Now().UnixNano() >= c.downUntil.Load()
+}
+
+// Index is the full name of an index
+func (c *Client) Index(name string) string {
+	if c.prefix == "" {
+		return name
+	}
+	return c.prefix + "_" + name
+}
+
+// Health checks that the cluster answers and is not red
+func (c *Client) Health(ctx context.Context) error {
+	var health struct {
+		Status string `json:"status"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/_cluster/health", nil, &health); err != nil {
+		return err
+	}
+	if health.Status == "red" {
+		c.markDown()
+		return fmt.Errorf("searchindex: cluster is red")
+	}
+	return nil
+}
+
+// EnsureIndex creates an index with mapping unless it exists
+func (c *Client) EnsureIndex(ctx context.Context, name string, mapping any) error {
+	err := c.do(ctx, http.MethodHead, "/"+c.Index(name), nil, nil)
+	if err == nil {
+		return nil
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusNotFound {
+		return err
+	}
+	return c.do(ctx, http.MethodPut, "/"+c.Index(name), mapping, nil)
+}
+
+// Put indexes a document, replacing the one with the same ID
+func (c *Client) Put(ctx context.Context, name string, doc Document) error {
+	return c.do(ctx, http.MethodPut, "/"+c.Index(name)+"/_doc/"+url.PathEscape(doc.ID), doc.Source, nil)
+}
+
+// Delete removes a document, it is not an error when there is none
+func (c *Client) Delete(ctx context.Context, name, id string) error {
+	err := c.do(ctx, http.MethodDelete, "/"+c.Index(name)+"/_doc/"+url.PathEscape(id), nil, nil)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.Code == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// Bulk indexes documents in one request
+func (c *Client) Bulk(ctx context.Context, name string, docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, doc := range docs {
+		action := map[string]any{"index": map[string]string{"_index": c.Index(name), "_id": doc.ID}}
+		if err := encoder.Encode(action); err != nil {
+			return err
+		}
+		if err := encoder.Encode(doc.Source); err != nil {
+			return err
+		}
+	}
+
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID    string          `json:"_id"`
+			Error json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := c.send(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", &body, &result); err != nil {
+		return err
+	}
+	if result.Errors {
+		for _, item := range result.Items {
+			for _, outcome := range item {
+				if len(outcome.Error) > 0 {
+					return fmt.Errorf("searchindex: indexing %s: %s", outcome.ID, outcome.Error)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteWhere removes the documents matching query, as in _delete_by_query
+func (c *Client) DeleteWhere(ctx context.Context, name string, query any) error {
+	return c.do(ctx, http.MethodPost, "/"+c.Index(name)+"/_delete_by_query?refresh=true",
+		map[string]any{"query": query}, nil)
+}
+
+// Search runs a query and returns up to size hits, best first
+func (c *Client) Search(ctx context.Context, name string, query any, size int) ([]Hit, error) {
+	var result struct {
+		Hits struct {
+			Hits []Hit `json:"hits"`
+		} `json:"hits"`
+	}
+	body := map[string]any{"query": query, "size": size}
+	if err := c.do(ctx, http.MethodPost, "/"+c.Index(name)+"/_search", body, &result); err != nil {
+		return nil, err
+	}
+	return result.Hits.Hits, nil
+}
+
+// StatusError is an error answer of the cluster
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("searchindex: status %d: %s", e.Code, e.Body)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	return c.send(ctx, method, path, "application/json", reader, out)
+}
+
+// send makes a request. Failing to reach the cluster or a server error marks the index down for a while,
+// a client error does not.
+func (c *Client) send(ctx context.Context, method, path, contentType string, body io.Reader, out any) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		c.markDown()
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if resp.StatusCode >= 500 {
+			c.markDown()
+		}
+		return &StatusError{Code: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+
+	c.downUntil.Store(0)
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Client) markDown() {
+	c.downUntil.Store(time.Now().Add(downCooldown).UnixNano())
+}
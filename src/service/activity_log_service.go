@@ -0,0 +1,213 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Activities are queued by the requests that log them and written in batches by one background writer
+const (
+	activityLogQueueSize     = 1024
+	activityLogBatchSize     = 100
+	activityLogFlushInterval = time.Second
+	activityLogSaveTimeout   = 5 * time.Second // bounds the write of a batch
+)
+
+type ActivityLogService interface {
+	// SaveActivity queues an activity utils.LogUserActivity logged. A failed write is only logged, and when the
+	// queue is full the activity is dropped, it stays in the activity log file.
+	SaveActivity(data utils.ActivityData)
+	// Close writes the queued activities and stops the writer, later activities are written one by one
+	Close()
+	// GetLogs lists the activities matching the query, latest first
+	GetLogs(c *fiber.Ctx, query *validation.ActivityLogQuery) ([]model.ActivityLog, int64, error)
+	GetLog(c *fiber.Ctx, id uuid.UUID) (*model.ActivityLog, error)
+}
+
+type activityLogService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+
+	queue     chan *model.ActivityLog
+	stop      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+func NewActivityLogService(db *gorm.DB, validate *validator.Validate) ActivityLogService {
+	s := &activityLogService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+		queue:    make(chan *model.ActivityLog, activityLogQueueSize),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go s.write()
+	return s
+}
+
+func (s *activityLogService) SaveActivity(data utils.ActivityData) {
+	activity := &model.ActivityLog{
+		UserID:     data.UserID,
+		Action:     data.Action,
+		Resource:   data.Resource,
+		ResourceID: data.ResourceID,
+		RequestID:  data.RequestID,
+		IPAddress:  data.IPAddress,
+		UserAgent:  data.UserAgent,
+		StatusCode: data.StatusCode,
+	}
+	if len(activity.UserAgent) > 255 {
+		activity.UserAgent = activity.UserAgent[:255]
+	}
+	if stored := data.StoredDetails(); stored != nil {
+		details, err := json.Marshal(stored)
+		if err != nil {
+			s.Log.Errorf("Failed to encode the details of activity %s: %v", data.Action, err)
+		} else {
+			activity.Details = model.JSON(details)
+		}
+	}
+
+	select {
+	case <-s.stop:
+		s.insert([]*model.ActivityLog{activity})
+		return
+	default:
+	}
+
+	select {
+	case s.queue <- activity:
+	default:
+		s.Log.Warnf("Activity log queue is full, dropped activity %s of %s", data.Action, data.UserID)
+	}
+}
+
+func (s *activityLogService) Close() {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.stopped
+	})
+}
+
+// write inserts the queued activities once a batch is full or every activityLogFlushInterval, and what is left
+// in the queue when the service is closed
+func (s *activityLogService) write() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(activityLogFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*model.ActivityLog, 0, activityLogBatchSize)
+	flush := func() {
+		s.insert(batch)
+		batch = make([]*model.ActivityLog, 0, activityLogBatchSize)
+	}
+	for {
+		select {
+		case activity := <-s.queue:
+			if batch = append(batch, activity); len(batch) == activityLogBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-s.stop:
+			for {
+				select {
+				case activity := <-s.queue:
+					if batch = append(batch, activity); len(batch) == activityLogBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *activityLogService) insert(batch []*model.ActivityLog) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), activityLogSaveTimeout)
+	defer cancel()
+	if err := s.DB.WithContext(ctx).Create(&batch).Error; err != nil {
+		s.Log.Errorf("Failed to save %d activities: %v", len(batch), err)
+	}
+}
+
+func (s *activityLogService) GetLogs(c *fiber.Ctx, query *validation.ActivityLogQuery) ([]model.ActivityLog, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	// Dates are YYYY-MM-DD, so they compare as strings
+	if query.From != "" && query.To != "" && query.To < query.From {
+		return nil, 0, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+
+	db := s.DB.WithContext(c.UserContext()).Model(&model.ActivityLog{})
+	if query.AdminID != "" {
+		db = db.Where("user_id = ?", query.AdminID)
+	}
+	if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+	if query.Resource != "" {
+		db = db.Where("resource = ?", query.Resource)
+	}
+	if query.ResourceID != "" {
+		db = db.Where("resource_id = ?", query.ResourceID)
+	}
+	if query.From != "" {
+		from, _ := time.ParseInLocation("2006-01-02", query.From, time.Local)
+		db = db.Where("created_at >= ?", from)
+	}
+	if query.To != "" {
+		to, _ := time.ParseInLocation("2006-01-02", query.To, time.Local)
+		db = db.Where("created_at < ?", to.AddDate(0, 0, 1))
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	logs := []model.ActivityLog{}
+	if err := db.
+		Order("created_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+func (s *activityLogService) GetLog(c *fiber.Ctx, id uuid.UUID) (*model.ActivityLog, error) {
+	activity := new(model.ActivityLog)
+	if err := s.DB.WithContext(c.UserContext()).First(activity, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Audit log not found")
+		}
+		return nil, err
+	}
+	return activity, nil
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AdminActionService interface {
+	// StagePlanDeletion holds the deletion of a plan for the undo window, see deleteSubscriptionPlan for what it does
+	StagePlanDeletion(c *fiber.Ctx, adminID, planID uuid.UUID) (*model.AdminAction, error)
+	// StageCancellation holds the cancellation of subscriptions for the undo window. Cancelled subscriptions end
+	// right away and do not renew, payments are left as they are.
+	StageCancellation(c *fiber.Ctx, adminID uuid.UUID, req *validation.CancelSubscriptions) (*model.AdminAction, error)
+	// StageSubscriptionDeletion holds the soft deletion of a subscription for the undo window. The deleted
+	// subscription stops granting access and leaves reports, its status history and transactions are kept.
+	StageSubscriptionDeletion(c *fiber.Ctx, adminID, subscriptionID uuid.UUID) (*model.AdminAction, error)
+
+	GetActions(c *fiber.Ctx, query *validation.AdminActionQuery) ([]model.AdminAction, int64, error)
+	// Undo drops a staged action within its undo window
+	Undo(c *fiber.Ctx, adminID, actionID uuid.UUID) (*model.AdminAction, error)
+
+	// FinalizeDue carries out the staged actions whose undo window is over. An action that fails is marked
+	// failed with the reason and not retried.
+	FinalizeDue(ctx context.Context) error
+}
+
+type adminActionService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewAdminActionService(db *gorm.DB, validate *validator.Validate) AdminActionService {
+	return &adminActionService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+// stagedFor selects the staged actions of a kind that target any of ids
+func stagedFor(kind string, ids []uuid.UUID) func(db *gorm.DB) *gorm.DB {
+	targets := make([]string, len(ids))
+	for i, id := range ids {
+		targets[i] = id.String()
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("status = ? AND kind = ?", model.AdminActionStaged, kind).
+			Where("EXISTS (SELECT 1 FROM jsonb_array_elements_text(admin_actions.target_ids) target WHERE target IN ?)", targets)
+	}
+}
+
+func (s *adminActionService) StagePlanDeletion(c *fiber.Ctx, adminID, planID uuid.UUID) (*model.AdminAction, error) {
+	var plan model.SubscriptionPlan
+	if err := s.DB.WithContext(c.UserContext()).First(&plan, "id = ?", planID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+		}
+		return nil, err
+	}
+	if plan.ArchivedAt != nil {
+		return nil, fiber.NewError(fiber.StatusConflict, "Subscription plan is already archived")
+	}
+
+	return s.stage(c, &model.AdminAction{
+		Kind:          model.AdminActionDeletePlan,
+		TargetIDs:     []uuid.UUID{plan.ID},
+		RequestedByID: adminID,
+	})
+}
+
+func (s *adminActionService) StageCancellation(c *fiber.Ctx, adminID uuid.UUID, req *validation.CancelSubscriptions) (*model.AdminAction, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(req.SubscriptionIDs))
+	for i, id := range req.SubscriptionIDs {
+		ids[i] = uuid.MustParse(id)
+	}
+
+	var subscriptions []model.UserSubscription
+	if err := s.DB.WithContext(c.UserContext()).Where("id IN ?", ids).Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	if len(subscriptions) != len(ids) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Subscription not found")
+	}
+	for _, subscription := range subscriptions {
+		if subscription.IsStoreManaged() {
+			return nil, fiber.NewError(fiber.StatusConflict, fmt.Sprintf("Subscription %s is managed by the app store", subscription.ID))
+		}
+		if !subscription.IsActive {
+			return nil, fiber.NewError(fiber.StatusConflict, fmt.Sprintf("Subscription %s is not active", subscription.ID))
+		}
+	}
+
+	return s.stage(c, &model.AdminAction{
+		Kind:          model.AdminActionCancelSubscriptions,
+		TargetIDs:     ids,
+		Reason:        req.Reason,
+		RequestedByID: adminID,
+	})
+}
+
+func (s *adminActionService) StageSubscriptionDeletion(c *fiber.Ctx, adminID, subscriptionID uuid.UUID) (*model.AdminAction, error) {
+	var subscription model.UserSubscription
+	if err := s.DB.WithContext(c.UserContext()).First(&subscription, "id = ?", subscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription not found")
+		}
+		return nil, err
+	}
+
+	return s.stage(c, &model.AdminAction{
+		Kind:          model.AdminActionDeleteSubscription,
+		TargetIDs:     []uuid.UUID{subscription.ID},
+		RequestedByID: adminID,
+	})
+}
+
+// stage holds an action for the undo window unless an action of its kind is already staged for one of its targets
+func (s *adminActionService) stage(c *fiber.Ctx, action *model.AdminAction) (*model.AdminAction, error) {
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		var staged int64
+		if err := tx.Model(&model.AdminAction{}).Scopes(stagedFor(action.Kind, action.TargetIDs)).Count(&staged).Error; err != nil {
+			return err
+		}
+		if staged > 0 {
+			return fiber.NewError(fiber.StatusConflict, "An action on the same target is already waiting to be finalized")
+		}
+
+		action.Status = model.AdminActionStaged
+		action.FinalizeAt = time.Now().Add(time.Duration(config.AdminUndoWindowMinutes) * time.Minute)
+		return tx.Create(action).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Log.Infof("Staged %s %s of %d targets until %s", action.Kind, action.ID, len(action.TargetIDs), action.FinalizeAt.Format(time.RFC3339))
+	return action, nil
+}
+
+func (s *adminActionService) GetActions(c *fiber.Ctx, query *validation.AdminActionQuery) ([]model.AdminAction, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	db := s.DB.WithContext(c.UserContext()).Model(&model.AdminAction{})
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+
+	var totalResults int64
+	if err := db.Count(&totalResults).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var actions []model.AdminAction
+	if err := db.
+		Order("created_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&actions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return actions, totalResults, nil
+}
+
+func (s *adminActionService) Undo(c *fiber.Ctx, adminID, actionID uuid.UUID) (*model.AdminAction, error) {
+	var action model.AdminAction
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&action, "id = ?", actionID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, "Admin action not found")
+			}
+			return err
+		}
+
+		now := time.Now()
+		if !action.CanUndo(now) {
+			if action.Status == model.AdminActionUndone {
+				return fiber.NewError(fiber.StatusConflict, "Admin action was already undone")
+			}
+			return fiber.NewError(fiber.StatusConflict, "The undo window of this action is over")
+		}
+
+		action.Status = model.AdminActionUndone
+		action.UndoneByID = &adminID
+		action.UndoneAt = &now
+		return tx.Model(&action).Select("Status", "UndoneByID", "UndoneAt").Updates(&action).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Log.Infof("Undid %s %s", action.Kind, action.ID)
+	return &action, nil
+}
+
+func (s *adminActionService) FinalizeDue(ctx context.Context) error {
+	now := time.Now()
+
+	var due []model.AdminAction
+	if err := s.DB.WithContext(ctx).
+		Select("id").
+		Where("status = ? AND finalize_at <= ?", model.AdminActionStaged, now).
+		Order("finalize_at").
+		Find(&due).Error; err != nil {
+		return err
+	}
+
+	for _, action := range due {
+		if err := s.finalize(ctx, action.ID, now); err != nil {
+			s.Log.Errorf("Failed to finalize admin action %s: %v", action.ID, err)
+		}
+	}
+	return nil
+}
+
+// finalize carries out one action while it is locked, so an undo or another instance cannot get in between
+func (s *adminActionService) finalize(ctx context.Context, actionID uuid.UUID, now time.Time) error {
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var action model.AdminAction
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("id = ? AND status = ? AND finalize_at <= ?", actionID, model.AdminActionStaged, now).
+			Limit(1).
+			Find(&action)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		// The action runs in a savepoint, a failure is recorded instead of rolling back the whole transaction
+		if err := tx.Transaction(func(tx *gorm.DB) error {
+			return carryOut(tx, &action, now)
+		}); err != nil {
+			s.Log.Warnf("Admin action %s (%s) failed: %v", action.ID, action.Kind, err)
+			action.Status = model.AdminActionFailed
+			action.Error = err.Error()
+		} else {
+			action.Status = model.AdminActionFinalized
+			action.FinalizedAt = &now
+		}
+		return tx.Model(&action).Select("Status", "Error", "FinalizedAt").Updates(&action).Error
+	})
+}
+
+func carryOut(tx *gorm.DB, action *model.AdminAction, now time.Time) error {
+	switch action.Kind {
+	case model.AdminActionDeletePlan:
+		for _, planID := range action.TargetIDs {
+			if _, err := deleteSubscriptionPlan(tx, action.RequestedByID, planID); err != nil {
+				return err
+			}
+		}
+		return nil
+	case model.AdminActionCancelSubscriptions:
+		return cancelSubscriptions(tx, action, now)
+	case model.AdminActionDeleteSubscription:
+		// Subscriptions deleted in the meantime are left as they are
+		return tx.Where("id IN ?", action.TargetIDs).Delete(&model.UserSubscription{}).Error
+	default:
+		return fmt.Errorf("unknown admin action %q", action.Kind)
+	}
+}
+
+// cancelSubscriptions ends the subscriptions at now and turns their renewal off. Subscriptions that ended
+// in the meantime are left as they are.
+func cancelSubscriptions(tx *gorm.DB, action *model.AdminAction, now time.Time) error {
+	var subscriptions []model.UserSubscription
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ? AND is_active = ?", action.TargetIDs, true).
+		Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		subscription.IsActive = false
+		subscription.AutoRenew = false
+		if subscription.EndDate.After(now) {
+			subscription.EndDate = now
+		}
+		if err := syncSubscriptionStatus(tx, &subscription, action.Reason, &action.RequestedByID, now); err != nil {
+			if isTransitionConflict(err) {
+				continue
+			}
+			return err
+		}
+		if err := tx.Model(&subscription).Select("IsActive", "AutoRenew", "EndDate").Updates(&subscription).Error; err != nil {
+			return err
+		}
+
+		if err := appendEvent(tx, model.EventSubscriptionCancelled, subscription.UserID, subscription.ID,
+			model.EventPayload{PlanID: subscription.PlanID.String()}, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
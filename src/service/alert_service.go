@@ -0,0 +1,328 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/notify"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultAlertWindowMinutes is used for counted events when a rule sets no window
+	defaultAlertWindowMinutes = 15
+	// alertDeliveryTimeout bounds evaluating and delivering the rules for one occurrence
+	alertDeliveryTimeout = 30 * time.Second
+)
+
+type AlertService interface {
+	GetRules(c *fiber.Ctx) ([]model.AlertRule, error)
+	CreateRule(c *fiber.Ctx, adminID uuid.UUID, req *validation.CreateAlertRule) (*model.AlertRule, error)
+	UpdateRule(c *fiber.Ctx, ruleID uuid.UUID, req *validation.UpdateAlertRule) (*model.AlertRule, error)
+	DeleteRule(c *fiber.Ctx, ruleID uuid.UUID) error
+	TestRule(c *fiber.Ctx, ruleID uuid.UUID) error
+
+	// Emit records an occurrence of a business event and notifies the rules it triggers in the background.
+	// value is the amount for valued events such as refunds and is ignored for counted events. The delivery
+	// outlives ctx but keeps its request ID.
+	Emit(ctx context.Context, event string, value int, message string)
+}
+
+type alertService struct {
+	Log          *logrus.Logger
+	DB           *gorm.DB
+	Validate     *validator.Validate
+	EmailService EmailService
+	Telegram     *notify.TelegramBot
+
+	// occurrences of counted events, kept per process for the longest allowed window
+	mu          sync.Mutex
+	occurrences map[string][]time.Time
+}
+
+func NewAlertService(db *gorm.DB, validate *validator.Validate, emailService EmailService) AlertService {
+	return &alertService{
+		Log:          utils.Log,
+		DB:           db,
+		Validate:     validate,
+		EmailService: emailService,
+		Telegram:     notify.NewTelegramBot(config.TelegramBotToken),
+		occurrences:  make(map[string][]time.Time),
+	}
+}
+
+func (s *alertService) GetRules(c *fiber.Ctx) ([]model.AlertRule, error) {
+	var rules []model.AlertRule
+	if err := s.DB.WithContext(c.UserContext()).Order("event, created_at").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (s *alertService) CreateRule(c *fiber.Ctx, adminID uuid.UUID, req *validation.CreateAlertRule) (*model.AlertRule, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.validateTarget(req.Channel, req.Target); err != nil {
+		return nil, err
+	}
+
+	rule := &model.AlertRule{
+		Event:           req.Event,
+		Channel:         req.Channel,
+		Target:          strings.TrimSpace(req.Target),
+		Threshold:       req.Threshold,
+		WindowMinutes:   req.WindowMinutes,
+		CooldownMinutes: 60,
+		IsActive:        true,
+		CreatedByID:     adminID,
+	}
+
+	if req.CooldownMinutes != nil {
+		rule.CooldownMinutes = *req.CooldownMinutes
+	}
+
+	if model.IsCountedAlertEvent(rule.Event) && rule.WindowMinutes == 0 {
+		rule.WindowMinutes = defaultAlertWindowMinutes
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Create(rule).Error; err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *alertService) UpdateRule(c *fiber.Ctx, ruleID uuid.UUID, req *validation.UpdateAlertRule) (*model.AlertRule, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	rule, err := s.findRule(c, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Target != nil {
+		if err := s.validateTarget(rule.Channel, *req.Target); err != nil {
+			return nil, err
+		}
+		rule.Target = strings.TrimSpace(*req.Target)
+	}
+
+	if req.Threshold != nil {
+		rule.Threshold = *req.Threshold
+	}
+
+	if req.WindowMinutes != nil {
+		rule.WindowMinutes = *req.WindowMinutes
+	}
+
+	if req.CooldownMinutes != nil {
+		rule.CooldownMinutes = *req.CooldownMinutes
+	}
+
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Save(rule).Error; err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *alertService) DeleteRule(c *fiber.Ctx, ruleID uuid.UUID) error {
+	result := s.DB.WithContext(c.UserContext()).Delete(&model.AlertRule{}, "id = ?", ruleID)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "Alert rule not found")
+	}
+
+	return nil
+}
+
+// TestRule delivers a sample alert so admins can check the channel is set up correctly
+func (s *alertService) TestRule(c *fiber.Ctx, ruleID uuid.UUID) error {
+	rule, err := s.findRule(c, ruleID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.deliver(c.UserContext(), rule, "Test alert, this channel is receiving Nutribox alerts"); err != nil {
+		s.Log.Errorf("Failed to deliver test alert for rule %s: %v", rule.ID, err)
+		return fiber.NewError(fiber.StatusBadGateway, "Failed to deliver test alert")
+	}
+
+	return nil
+}
+
+func (s *alertService) Emit(ctx context.Context, event string, value int, message string) {
+	now := time.Now()
+	if model.IsCountedAlertEvent(event) {
+		s.record(event, now)
+	}
+
+	// Callers are request handlers, delivery must not slow them down or fail them
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), alertDeliveryTimeout)
+		defer cancel()
+
+		if err := s.evaluate(ctx, event, value, message, now); err != nil {
+			s.Log.Errorf("Failed to evaluate %s alerts: %v", event, err)
+		}
+	}()
+}
+
+func (s *alertService) evaluate(ctx context.Context, event string, value int, message string, now time.Time) error {
+	var rules []model.AlertRule
+	if err := s.DB.WithContext(ctx).
+		Where("event = ? AND is_active = ?", event, true).
+		Find(&rules).Error; err != nil {
+		return err
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		text := fmt.Sprintf("[%s] %s", rule.Event, message)
+
+		if model.IsCountedAlertEvent(event) {
+			window := rule.WindowMinutes
+			if window == 0 {
+				window = defaultAlertWindowMinutes
+			}
+			value = s.countSince(event, now.Add(-time.Duration(window)*time.Minute))
+			text = fmt.Sprintf("%s (%d in the last %d minutes)", text, value, window)
+		}
+
+		if !rule.Matches(value) || rule.InCooldown(now) {
+			continue
+		}
+
+		claimed, err := s.claim(ctx, rule, now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.deliver(ctx, rule, text); err != nil {
+			s.Log.Errorf("Failed to deliver %s alert for rule %s: %v", rule.Event, rule.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// claim starts the cooldown of a rule, it fails when another occurrence or instance fired the rule first
+func (s *alertService) claim(ctx context.Context, rule *model.AlertRule, now time.Time) (bool, error) {
+	result := s.DB.WithContext(ctx).
+		Model(&model.AlertRule{}).
+		Where("id = ?", rule.ID).
+		Where("last_triggered_at IS NULL OR last_triggered_at <= ?", now.Add(-time.Duration(rule.CooldownMinutes)*time.Minute)).
+		Update("last_triggered_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (s *alertService) deliver(ctx context.Context, rule *model.AlertRule, text string) error {
+	switch rule.Channel {
+	case model.AlertChannelEmail:
+		return s.EmailService.SendEmail(rule.Target, "Nutribox alert: "+rule.Event, text)
+	case model.AlertChannelSlack:
+		return notify.Slack(ctx, rule.Target, text)
+	case model.AlertChannelTelegram:
+		if s.Telegram == nil {
+			return errors.New("telegram bot token is not configured")
+		}
+		return s.Telegram.Send(ctx, rule.Target, text)
+	}
+
+	return fmt.Errorf("unknown alert channel %q", rule.Channel)
+}
+
+func (s *alertService) validateTarget(channel, target string) error {
+	target = strings.TrimSpace(target)
+
+	switch channel {
+	case model.AlertChannelEmail:
+		if s.Validate.Var(target, "email") != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Email alerts need a valid email address as target")
+		}
+	case model.AlertChannelSlack:
+		if s.Validate.Var(target, "url") != nil || !strings.HasPrefix(target, "https://") {
+			return fiber.NewError(fiber.StatusBadRequest, "Slack alerts need an https webhook URL as target")
+		}
+	case model.AlertChannelTelegram:
+		if target == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "Telegram alerts need a chat ID as target")
+		}
+		if s.Telegram == nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Telegram alerts are not configured")
+		}
+	}
+
+	return nil
+}
+
+func (s *alertService) findRule(c *fiber.Ctx, ruleID uuid.UUID) (*model.AlertRule, error) {
+	rule := new(model.AlertRule)
+	if err := s.DB.WithContext(c.UserContext()).First(rule, "id = ?", ruleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Alert rule not found")
+		}
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// record adds an occurrence and drops the ones older than the longest window a rule can use
+func (s *alertService) record(event string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := now.Add(-24 * time.Hour)
+	kept := s.occurrences[event][:0]
+	for _, at := range s.occurrences[event] {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	s.occurrences[event] = append(kept, now)
+}
+
+func (s *alertService) countSince(event string, since time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, at := range s.occurrences[event] {
+		if at.After(since) {
+			count++
+		}
+	}
+	return count
+}
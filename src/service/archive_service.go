@@ -0,0 +1,139 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// archiveBatchSize bounds the rows moved by one statement so the live tables are never locked for long
+const archiveBatchSize = 1000
+
+type ArchiveService interface {
+	// ArchiveOldRecords moves the rows older than ARCHIVE_AFTER_MONTHS to the archive tables once a day
+	ArchiveOldRecords(ctx context.Context) error
+}
+
+type archiveService struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewArchiveService(db *gorm.DB) ArchiveService {
+	return &archiveService{
+		Log: utils.Log,
+		DB:  db,
+	}
+}
+
+func (s *archiveService) ArchiveOldRecords(ctx context.Context) error {
+	if config.ArchiveAfterMonths <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	claimed, err := claimDailyRun(ctx, s.DB, model.SettingArchivedOn, now.Format("2006-01-02"))
+	if err != nil || !claimed {
+		return err
+	}
+
+	cutoff := model.ArchiveCutoff(now, config.ArchiveAfterMonths)
+	for _, table := range model.ArchivedTables {
+		moved, err := s.archiveTable(ctx, table, cutoff)
+		if moved > 0 {
+			s.Log.Infof("Archived %d rows of %s created before %s", moved, table, cutoff.Format("2006-01-02"))
+		}
+		if err != nil {
+			return fmt.Errorf("archiving %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// archiveTable moves the rows of table created before cutoff, oldest year first
+func (s *archiveService) archiveTable(ctx context.Context, table string, cutoff time.Time) (int64, error) {
+	db := s.DB.WithContext(ctx)
+	archive := model.ArchiveTable(table)
+
+	var names []string
+	if err := db.Raw(`SELECT attname FROM pg_attribute WHERE attrelid = ?::regclass AND attnum > 0 AND NOT attisdropped ORDER BY attnum`,
+		table).Scan(&names).Error; err != nil {
+		return 0, err
+	}
+	for i, name := range names {
+		names[i] = fmt.Sprintf("%q", name)
+	}
+	columns := strings.Join(names, ", ")
+
+	var moved int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return moved, err
+		}
+
+		var oldest sql.NullTime
+		if err := db.Raw(fmt.Sprintf(`SELECT MIN(created_at) FROM %s WHERE created_at < ?`, table), cutoff).
+			Scan(&oldest).Error; err != nil {
+			return moved, err
+		}
+		if !oldest.Valid {
+			return moved, nil
+		}
+
+		partition, from, to := model.ArchivePartition(table, oldest.Time)
+		if err := createArchivePartition(db, archive, partition, from, to); err != nil {
+			return moved, err
+		}
+		if to.After(cutoff) {
+			to = cutoff
+		}
+
+		// Each batch is deleted and inserted by one statement, a failure leaves the row in the live table
+		result := db.Exec(fmt.Sprintf(`WITH moved AS (
+				DELETE FROM %[1]s WHERE id IN (
+					SELECT id FROM %[1]s WHERE created_at >= ? AND created_at < ? LIMIT ?
+				) RETURNING %[3]s
+			)
+			INSERT INTO %[2]s (%[3]s) SELECT %[3]s FROM moved`, table, archive, columns),
+			from, to, archiveBatchSize)
+		if result.Error != nil {
+			return moved, result.Error
+		}
+		moved += result.RowsAffected
+
+		if result.RowsAffected == 0 {
+			return moved, errors.New("no rows moved although some are due")
+		}
+	}
+}
+
+// createArchivePartition creates the yearly partition of an archive when it does not exist yet
+func createArchivePartition(db *gorm.DB, archive, partition string, from, to time.Time) error {
+	statement := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')`,
+		partition, archive, from.Format(time.RFC3339), to.Format(time.RFC3339))
+	if config.ArchiveTablespace != "" {
+		statement += fmt.Sprintf(` TABLESPACE %q`, config.ArchiveTablespace)
+	}
+
+	return db.Exec(statement).Error
+}
+
+// firstWithArchive looks a record up in its live table, then in the archive of that table
+func firstWithArchive(db *gorm.DB, table string, dest any, query func(*gorm.DB) *gorm.DB) error {
+	err := query(db).First(dest).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return query(db.Table(model.ArchiveTable(table))).First(dest).Error
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
@@ -24,28 +25,31 @@ type ArticlesService interface {
 }
 
 type articlesService struct {
-	Log *logrus.Logger
-	DB  *gorm.DB
+	Log                *logrus.Logger
+	DB                 *gorm.DB
+	SearchIndexService SearchIndexService
 }
 
-func NewArticlesService(db *gorm.DB) ArticlesService {
+func NewArticlesService(db *gorm.DB, searchIndexService SearchIndexService) ArticlesService {
 	return &articlesService{
-		Log: logrus.New(),
-		DB:  db,
+		Log:                logrus.New(),
+		DB:                 db,
+		SearchIndexService: searchIndexService,
 	}
 }
 
 func (s *articlesService) CreateArticle(ctx *fiber.Ctx, article *model.Article) (*model.Article, error) {
-	if err := s.DB.WithContext(ctx.Context()).Create(article).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).Create(article).Error; err != nil {
 		s.Log.Errorf("Failed to create article: %+v", err)
 		return nil, err
 	}
+	s.SearchIndexService.IndexArticle(ctx.UserContext(), article.ID)
 	return article, nil
 }
 
 func (s *articlesService) GetArticles(ctx *fiber.Ctx) ([]model.ArticleResponse, error) {
 	var articles []model.Article
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Preload("Category"). // Preload the Category relationship
 		Order("created_at DESC").
 		Find(&articles).Error; err != nil {
@@ -82,7 +86,7 @@ func (s *articlesService) GetArticles(ctx *fiber.Ctx) ([]model.ArticleResponse,
 
 func (s *articlesService) GetArticleByID(ctx *fiber.Ctx, articleID string) (*model.ArticleResponse, error) {
 	var article model.Article
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Preload("Category"). // Preload the Category relationship
 		Where("id = ?", articleID).
 		First(&article).Error; err != nil {
@@ -117,7 +121,7 @@ func (s *articlesService) GetArticleByID(ctx *fiber.Ctx, articleID string) (*mod
 
 func (s *articlesService) UpdateArticle(ctx *fiber.Ctx, articleID string, article *model.Article) (*model.Article, error) {
 	existingArticle := new(model.Article)
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Where("id = ?", articleID).
 		First(existingArticle).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -151,28 +155,32 @@ func (s *articlesService) UpdateArticle(ctx *fiber.Ctx, articleID string, articl
 		return existingArticle, nil
 	}
 
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Model(existingArticle).
 		Updates(updates).Error; err != nil {
 		s.Log.Errorf("Failed to update article: %+v", err)
 		return nil, err
 	}
+	s.SearchIndexService.IndexArticle(ctx.UserContext(), existingArticle.ID)
 
 	return existingArticle, nil
 }
 
 func (s *articlesService) DeleteArticle(ctx *fiber.Ctx, articleID string) error {
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Where("id = ?", articleID).
 		Delete(&model.Article{}).Error; err != nil {
 		s.Log.Errorf("Failed to delete article: %+v", err)
 		return err
 	}
+	if id, err := uuid.Parse(articleID); err == nil {
+		s.SearchIndexService.RemoveContent(ctx.UserContext(), model.ContentTypeArticle, id)
+	}
 	return nil
 }
 
 func (s *articlesService) CreateArticleCategory(ctx *fiber.Ctx, category *model.ArticleCategory) (*model.ArticleCategory, error) {
-	if err := s.DB.WithContext(ctx.Context()).Create(category).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).Create(category).Error; err != nil {
 		s.Log.Errorf("Failed to create article category: %+v", err)
 		return nil, err
 	}
@@ -181,7 +189,7 @@ func (s *articlesService) CreateArticleCategory(ctx *fiber.Ctx, category *model.
 
 func (s *articlesService) GetArticleCategories(ctx *fiber.Ctx) ([]model.ArticleCategory, error) {
 	var categories []model.ArticleCategory
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Order("created_at DESC").
 		Find(&categories).Error; err != nil {
 		s.Log.Errorf("Failed to get article categories: %+v", err)
@@ -193,7 +201,7 @@ func (s *articlesService) GetArticleCategories(ctx *fiber.Ctx) ([]model.ArticleC
 func (s *articlesService) DeleteArticleCategory(ctx *fiber.Ctx, categoryID string) error {
 	// First check if any articles are using this category
 	var count int64
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Model(&model.Article{}).
 		Where("category_id = ?", categoryID).
 		Count(&count).Error; err != nil {
@@ -205,7 +213,7 @@ func (s *articlesService) DeleteArticleCategory(ctx *fiber.Ctx, categoryID strin
 		return fiber.NewError(fiber.StatusBadRequest, "Cannot delete category that is in use by articles")
 	}
 
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Where("id = ?", categoryID).
 		Delete(&model.ArticleCategory{}).Error; err != nil {
 		s.Log.Errorf("Failed to delete article category: %+v", err)
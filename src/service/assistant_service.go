@@ -0,0 +1,392 @@
+package service
+
+import (
+	"app/src/bulkhead"
+	"app/src/config"
+	"app/src/llm"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// assistantTitleLength is the length in characters of a conversation title, taken from its first message
+const assistantTitleLength = 60
+
+type AssistantService interface {
+	// Chat answers a message of the user with their profile, targets and recent diary as context, in a new
+	// conversation when none is given. Messages count against the daily quota of the plan of the user, a
+	// message the provider could not answer is given back.
+	Chat(c *fiber.Ctx, userID uuid.UUID, req *validation.AssistantChat) (*model.AssistantReply, error)
+	GetQuota(c *fiber.Ctx, userID uuid.UUID) (*model.AssistantQuota, error)
+
+	GetConversations(c *fiber.Ctx, userID uuid.UUID, query *validation.AssistantConversationQuery) ([]model.AssistantConversation, int64, error)
+	GetConversation(c *fiber.Ctx, userID, id uuid.UUID) (*model.AssistantConversation, error)
+	DeleteConversation(c *fiber.Ctx, userID, id uuid.UUID) error
+}
+
+type assistantService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+	Provider llm.Provider
+	Alerts   AlertService
+}
+
+// NewAssistantService answers chats with provider, a nil provider leaves the assistant unavailable
+func NewAssistantService(db *gorm.DB, validate *validator.Validate, provider llm.Provider, alerts AlertService) AssistantService {
+	return &assistantService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+		Provider: provider,
+		Alerts:   alerts,
+	}
+}
+
+func (s *assistantService) Chat(c *fiber.Ctx, userID uuid.UUID, req *validation.AssistantChat) (*model.AssistantReply, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	text, err := screenText("Message", req.Message, true)
+	if err != nil {
+		return nil, err
+	}
+	guardrail := model.AssistantGuardrail(text)
+	// Emergencies are answered without the provider, so they are even while it is down
+	if s.Provider == nil && guardrail != model.AssistantGuardEmergency {
+		return nil, fiber.NewError(fiber.StatusServiceUnavailable, "The assistant is not available")
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+	conversation := new(model.AssistantConversation)
+	if req.ConversationID != "" {
+		if err := db.First(conversation, "id = ? AND user_id = ?", req.ConversationID, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fiber.NewError(fiber.StatusNotFound, "Conversation not found")
+			}
+			return nil, err
+		}
+	}
+
+	// Built before the message is stored, so the history does not hold it yet
+	var prompt []llm.Message
+	if guardrail != model.AssistantGuardEmergency {
+		var err error
+		if prompt, err = s.prompt(db, userID, conversation.ID, text); err != nil {
+			return nil, err
+		}
+	}
+
+	var quota model.AssistantQuota
+	message := &model.AssistantMessage{UserID: userID, Role: llm.RoleUser, Content: text, Guardrail: guardrail}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Locking the user serializes the messages of a user, so parallel requests cannot pass the quota together
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&model.User{}, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		var err error
+		if quota, err = s.quota(tx, userID); err != nil {
+			return err
+		}
+		// An emergency is always answered
+		if guardrail != model.AssistantGuardEmergency {
+			if quota.Exhausted() {
+				return fiber.NewError(fiber.StatusTooManyRequests, "You reached the daily message limit of the assistant")
+			}
+			if err := countAssistantMessage(tx, userID, 1); err != nil {
+				return err
+			}
+			quota.Used++
+		}
+
+		if conversation.ID == uuid.Nil {
+			conversation.UserID = userID
+			conversation.Title = assistantTitle(text)
+			if err := tx.Create(conversation).Error; err != nil {
+				return err
+			}
+		}
+		message.ConversationID = conversation.ID
+		return tx.Create(message).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &model.AssistantMessage{ConversationID: conversation.ID, UserID: userID, Role: llm.RoleAssistant, Guardrail: guardrail}
+	if guardrail == model.AssistantGuardEmergency {
+		reply.Content = model.AssistantEmergencyReply
+	} else {
+		// Provider calls are bounded by their client timeout rather than the query deadline of the request
+		var answer *llm.Reply
+		err := llmBulkhead.Do(c.UserContext(), func() (err error) {
+			answer, err = s.Provider.Chat(context.WithoutCancel(c.UserContext()), prompt)
+			return err
+		})
+		if err != nil {
+			s.giveBack(userID, conversation, message, req.ConversationID == "")
+			var busy *bulkhead.FullError
+			if errors.As(err, &busy) {
+				return nil, err
+			}
+			s.Alerts.Emit(c.UserContext(), model.AlertAIProviderError, 1, fmt.Sprintf("Assistant provider %s failed: %v", s.Provider.Name(), err))
+			return nil, fiber.NewError(fiber.StatusBadGateway, "The assistant could not answer, please try again")
+		}
+
+		reply.Content = strings.TrimSpace(answer.Content)
+		if guardrail == model.AssistantGuardMedical {
+			reply.Content += "\n\n" + model.AssistantMedicalDisclaimer
+		}
+		reply.Provider = s.Provider.Name()
+		reply.Model = answer.Model
+		reply.PromptTokens = answer.PromptTokens
+		reply.CompletionTokens = answer.CompletionTokens
+	}
+
+	// The answer may have taken longer than the deadline of the request
+	if err := s.DB.WithContext(context.WithoutCancel(c.UserContext())).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(reply).Error; err != nil {
+			return err
+		}
+		return tx.Model(conversation).UpdateColumn("updated_at", time.Now()).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	return &model.AssistantReply{ConversationID: conversation.ID, Message: *reply, Quota: quota}, nil
+}
+
+// prompt is the chat sent to the provider: the context of the user as system prompt, the latest messages of
+// the conversation and the new message
+func (s *assistantService) prompt(db *gorm.DB, userID, conversationID uuid.UUID, text string) ([]llm.Message, error) {
+	user, err := s.context(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.history(db, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []llm.Message{{Role: llm.RoleSystem, Content: model.AssistantSystemPrompt(*user, time.Now())}}
+	for _, message := range history {
+		messages = append(messages, llm.Message{Role: message.Role, Content: message.Content})
+	}
+	return append(messages, llm.Message{Role: llm.RoleUser, Content: text}), nil
+}
+
+// giveBack removes a message the provider did not answer, with its conversation when it was the first,
+// and uncounts it
+func (s *assistantService) giveBack(userID uuid.UUID, conversation *model.AssistantConversation, message *model.AssistantMessage, created bool) {
+	// The request may be cancelled already
+	err := s.DB.WithContext(context.Background()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(message).Error; err != nil {
+			return err
+		}
+		if created {
+			if err := tx.Delete(conversation).Error; err != nil {
+				return err
+			}
+		}
+		return countAssistantMessage(tx, userID, -1)
+	})
+	if err != nil {
+		s.Log.Errorf("Failed to give back assistant message %s: %v", message.ID, err)
+	}
+}
+
+// countAssistantMessage adds delta to the messages of the user today
+func countAssistantMessage(db *gorm.DB, userID uuid.UUID, delta int) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"messages": gorm.Expr("GREATEST(assistant_usages.messages + ?, 0)", delta)}),
+	}).Create(&model.AssistantUsage{UserID: userID, Date: assistantDay(), Messages: max(delta, 0)}).Error
+}
+
+// assistantDay is the day messages are counted on
+func assistantDay() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// history returns the latest messages of a conversation, oldest first. Emergency exchanges are left out,
+// the provider never saw them.
+func (s *assistantService) history(db *gorm.DB, conversationID uuid.UUID) ([]model.AssistantMessage, error) {
+	history := []model.AssistantMessage{}
+	if conversationID == uuid.Nil || config.AssistantHistoryMessages <= 0 {
+		return history, nil
+	}
+
+	if err := db.Where("conversation_id = ? AND guardrail <> ?", conversationID, model.AssistantGuardEmergency).
+		Order("created_at DESC").
+		Limit(config.AssistantHistoryMessages).
+		Find(&history).Error; err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	return history, nil
+}
+
+// context gathers what the assistant knows of the user. The medical history stays out, it is not sent to
+// the provider.
+func (s *assistantService) context(db *gorm.DB, userID uuid.UUID) (*model.AssistantContext, error) {
+	user := new(model.User)
+	if err := db.Select("id", "name", "birth_date", "gender", "activity_level", "height", "weight").
+		First(user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	info := &model.AssistantContext{Name: user.Name, Height: user.Height, Weight: user.Weight}
+	if user.BirthDate != nil {
+		age := model.Age(*user.BirthDate, now)
+		info.Age = &age
+	}
+	if user.Gender != nil {
+		info.Gender = string(*user.Gender)
+	}
+	if user.ActivityLevel != nil {
+		info.ActivityLevel = string(*user.ActivityLevel)
+	}
+
+	var targets []model.UsersWeightHeightTarget
+	if err := db.Where("user_id = ?", userID).Order("target_date DESC").Limit(1).Find(&targets).Error; err != nil {
+		return nil, err
+	}
+	if len(targets) > 0 {
+		info.TargetWeight = &targets[0].Weight
+	}
+
+	var preferences []model.DietPreference
+	if err := db.Where("user_id = ?", userID).Limit(1).Find(&preferences).Error; err != nil {
+		return nil, err
+	}
+	if len(preferences) > 0 {
+		info.Restrictions = preferences[0].Restrictions
+	}
+
+	today := assistantDay()
+	if err := db.Where("user_id = ? AND date >= ? AND date < ?", userID,
+		today.AddDate(0, 0, -config.AssistantContextDays).Format("2006-01-02"), today.Format("2006-01-02")).
+		Order("date").
+		Find(&info.Days).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Select("title", "meal_time", "calories").
+		Where("user_id = ? AND meal_time >= ?", userID, today).
+		Order("meal_time").
+		Find(&info.TodayMeals).Error; err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+func (s *assistantService) GetQuota(c *fiber.Ctx, userID uuid.UUID) (*model.AssistantQuota, error) {
+	quota, err := s.quota(s.DB.WithContext(c.UserContext()), userID)
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+// quota counts the messages of the user today against the limit of their plan, or the free limit without one
+func (s *assistantService) quota(db *gorm.DB, userID uuid.UUID) (model.AssistantQuota, error) {
+	quota := model.AssistantQuota{Limit: config.AssistantFreeDailyMessages.Get()}
+
+	var subscriptions []model.UserSubscription
+	if err := db.Preload("Plan").Scopes(activeSubscription(userID)).Limit(1).Find(&subscriptions).Error; err != nil {
+		return quota, err
+	}
+	if len(subscriptions) > 0 {
+		quota.Limit = subscriptions[0].PurchasedPlan().ChatMessageLimit
+	}
+
+	var usage []model.AssistantUsage
+	if err := db.Where("user_id = ? AND date = ?", userID, assistantDay().Format("2006-01-02")).Limit(1).Find(&usage).Error; err != nil {
+		return quota, err
+	}
+	if len(usage) > 0 {
+		quota.Used = usage[0].Messages
+	}
+
+	return quota, nil
+}
+
+func (s *assistantService) GetConversations(c *fiber.Ctx, userID uuid.UUID, query *validation.AssistantConversationQuery) ([]model.AssistantConversation, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	var conversations []model.AssistantConversation
+	var totalResults int64
+
+	db := s.DB.WithContext(c.UserContext()).Model(&model.AssistantConversation{}).Where("user_id = ?", userID)
+
+	if err := db.Count(&totalResults).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.
+		Order("updated_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&conversations).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return conversations, totalResults, nil
+}
+
+func (s *assistantService) GetConversation(c *fiber.Ctx, userID, id uuid.UUID) (*model.AssistantConversation, error) {
+	conversation := new(model.AssistantConversation)
+	if err := s.DB.WithContext(c.UserContext()).
+		Preload("Messages", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		First(conversation, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Conversation not found")
+		}
+		return nil, err
+	}
+
+	return conversation, nil
+}
+
+func (s *assistantService) DeleteConversation(c *fiber.Ctx, userID, id uuid.UUID) error {
+	return s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&model.AssistantConversation{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fiber.NewError(fiber.StatusNotFound, "Conversation not found")
+		}
+		// The messages stay counted in the usage of their day
+		return tx.Where("conversation_id = ?", id).Delete(&model.AssistantMessage{}).Error
+	})
+}
+
+// assistantTitle shortens the first message of a conversation to its title
+func assistantTitle(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= assistantTitleLength {
+		return text
+	}
+	return string([]rune(text)[:assistantTitleLength-1]) + "…"
+}
@@ -31,10 +31,12 @@ type authService struct {
 	Validate     *validator.Validate
 	UserService  UserService
 	TokenService TokenService
+	Captcha      CaptchaService
 }
 
 func NewAuthService(
 	db *gorm.DB, validate *validator.Validate, userService UserService, tokenService TokenService,
+	captchaService CaptchaService,
 ) AuthService {
 	return &authService{
 		Log:          utils.Log,
@@ -42,6 +44,7 @@ func NewAuthService(
 		Validate:     validate,
 		UserService:  userService,
 		TokenService: tokenService,
+		Captcha:      captchaService,
 	}
 }
 
@@ -50,6 +53,11 @@ func (s *authService) Register(c *fiber.Ctx, req *validation.Register) (*model.U
 		return nil, err
 	}
 
+	name, err := screenText("Name", req.Name, false)
+	if err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
 		s.Log.Errorf("Failed to hash password: %+v", err)
@@ -57,7 +65,7 @@ func (s *authService) Register(c *fiber.Ctx, req *validation.Register) (*model.U
 	}
 
 	user := &model.User{
-		Name:           req.Name,
+		Name:           name,
 		Email:          req.Email,
 		Password:       hashedPassword,
 		BirthDate:      &req.BirthDate,
@@ -67,9 +75,11 @@ func (s *authService) Register(c *fiber.Ctx, req *validation.Register) (*model.U
 		ActivityLevel:  &req.ActivityLevel,
 		MedicalHistory: &req.MedicalHistory,
 	}
+	user.AcquisitionSource = model.NormalizeAcquisitionSource(req.AcquisitionSource)
+	user.ParentalConsent = model.ParentalConsentFor("", user.BirthDate, config.ParentalConsentAge, time.Now())
 
 	// Mulai transaksi database
-	tx := s.DB.WithContext(c.Context()).Begin()
+	tx := s.DB.WithContext(c.UserContext()).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -115,15 +125,28 @@ func (s *authService) Login(c *fiber.Ctx, req *validation.Login) (*model.User, e
 		return nil, err
 	}
 
+	required, err := s.Captcha.LoginRequiresCaptcha(c, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if required {
+		if err := s.Captcha.Check(c); err != nil {
+			return nil, err
+		}
+	}
+
 	user, err := s.UserService.GetUserByEmail(c, req.Email)
 	if err != nil {
+		s.Captcha.RecordLoginFailure(c, req.Email)
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid email or password")
 	}
 
 	if !utils.CheckPasswordHash(req.Password, user.Password) {
+		s.Captcha.RecordLoginFailure(c, req.Email)
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid email or password")
 	}
 
+	s.Captcha.ClearLoginFailures(c, req.Email)
 	return user, nil
 }
 
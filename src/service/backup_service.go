@@ -0,0 +1,179 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/objectstore"
+	"app/src/utils"
+	"app/src/validation"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type BackupService interface {
+	GetBackups(c *fiber.Ctx) ([]model.Backup, error)
+	// Request queues a backup, the backup job dumps and uploads it
+	Request(c *fiber.Ctx, adminID uuid.UUID, req *validation.CreateBackup) (*model.Backup, error)
+
+	// RunPending takes the oldest requested backup, dumps the database with pg_dump and uploads the dump.
+	// Backups left running by an instance that stopped are marked failed once BACKUP_TIMEOUT has passed.
+	RunPending(ctx context.Context) error
+}
+
+type backupService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+	Store    *objectstore.Client
+}
+
+func NewBackupService(db *gorm.DB, validate *validator.Validate) BackupService {
+	return &backupService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+		Store: objectstore.New(config.BackupS3Endpoint, config.BackupS3Region, config.BackupS3Bucket,
+			config.BackupS3Access, config.BackupS3Secret),
+	}
+}
+
+func (s *backupService) GetBackups(c *fiber.Ctx) ([]model.Backup, error) {
+	var backups []model.Backup
+	if err := s.DB.WithContext(c.UserContext()).Order("created_at DESC").Find(&backups).Error; err != nil {
+		return nil, err
+	}
+
+	return backups, nil
+}
+
+func (s *backupService) Request(c *fiber.Ctx, adminID uuid.UUID, req *validation.CreateBackup) (*model.Backup, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if s.Store == nil {
+		return nil, fiber.NewError(fiber.StatusServiceUnavailable, "Backups are not configured, set BACKUP_S3_BUCKET")
+	}
+
+	var inProgress int64
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(&model.Backup{}).
+		Where("status IN ?", []string{model.BackupPending, model.BackupRunning}).
+		Count(&inProgress).Error; err != nil {
+		return nil, err
+	}
+	if inProgress > 0 {
+		return nil, fiber.NewError(fiber.StatusConflict, "A backup is already in progress")
+	}
+
+	backup := &model.Backup{
+		Status:        model.BackupPending,
+		Reason:        strings.TrimSpace(req.Reason),
+		RequestedByID: adminID,
+	}
+	if err := s.DB.WithContext(c.UserContext()).Create(backup).Error; err != nil {
+		return nil, err
+	}
+
+	return backup, nil
+}
+
+func (s *backupService) RunPending(ctx context.Context) error {
+	if s.Store == nil {
+		return nil
+	}
+
+	db := s.DB.WithContext(ctx)
+
+	if err := db.Model(&model.Backup{}).
+		Where("status = ? AND started_at < ?", model.BackupRunning, time.Now().Add(-config.BackupTimeout)).
+		Updates(map[string]any{"status": model.BackupFailed, "error": "The backup did not finish within BACKUP_TIMEOUT"}).Error; err != nil {
+		return err
+	}
+
+	var backup model.Backup
+	result := db.Where("status = ?", model.BackupPending).Order("created_at").Limit(1).Find(&backup)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	// Another instance may claim the same backup, only the one that moves it to running dumps it
+	startedAt := time.Now()
+	result = db.Model(&model.Backup{}).
+		Where("id = ? AND status = ?", backup.ID, model.BackupPending).
+		Updates(map[string]any{"status": model.BackupRunning, "started_at": startedAt})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	s.Log.Infof("Starting backup %s requested by %s: %s", backup.ID, backup.RequestedByID, backup.Reason)
+
+	objectURL, size, err := s.dumpAndUpload(ctx, backup.ID, startedAt)
+	completedAt := time.Now()
+	if err != nil {
+		s.Log.Errorf("Backup %s failed: %v", backup.ID, err)
+		return db.Model(&model.Backup{}).Where("id = ?", backup.ID).Updates(map[string]any{
+			"status":       model.BackupFailed,
+			"error":        err.Error(),
+			"completed_at": completedAt,
+		}).Error
+	}
+
+	s.Log.Infof("Backup %s uploaded to %s (%d bytes) in %s", backup.ID, objectURL, size, completedAt.Sub(startedAt))
+	return db.Model(&model.Backup{}).Where("id = ?", backup.ID).Updates(map[string]any{
+		"status":       model.BackupCompleted,
+		"object_url":   objectURL,
+		"size_bytes":   size,
+		"completed_at": completedAt,
+	}).Error
+}
+
+// dumpAndUpload writes a pg_dump custom format archive to a temporary file and uploads it
+func (s *backupService) dumpAndUpload(ctx context.Context, id uuid.UUID, startedAt time.Time) (string, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.BackupTimeout)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "backup-")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "nutribox.dump")
+	cmd := exec.CommandContext(ctx, config.BackupPgDumpPath,
+		"--format=custom",
+		"--no-owner",
+		"--host", config.DBHost,
+		"--port", strconv.Itoa(config.DBPort),
+		"--username", config.DBUser,
+		"--dbname", config.DBName,
+		"--file", path,
+	)
+	cmd.Env = append(os.Environ(), "PGPASSWORD="+config.DBPassword)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", 0, fmt.Errorf("pg_dump failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	key := model.BackupObjectKey(config.BackupS3Prefix, id, startedAt)
+	size, err := s.Store.PutFile(ctx, key, path, "application/octet-stream")
+	if err != nil {
+		return "", 0, fmt.Errorf("upload failed: %w", err)
+	}
+
+	return s.Store.URL(key), size, nil
+}
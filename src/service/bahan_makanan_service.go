@@ -19,14 +19,16 @@ type BahanMakananService interface {
 }
 
 type bahanMakananService struct {
-	Log    *logrus.Logger
-	Client *grpc.BahanMakananClient
+	Log                *logrus.Logger
+	Client             *grpc.BahanMakananClient
+	SearchIndexService SearchIndexService
 }
 
-func NewBahanMakananService(client *grpc.BahanMakananClient) BahanMakananService {
+func NewBahanMakananService(client *grpc.BahanMakananClient, searchIndexService SearchIndexService) BahanMakananService {
 	return &bahanMakananService{
-		Log:    logrus.New(),
-		Client: client,
+		Log:                logrus.New(),
+		Client:             client,
+		SearchIndexService: searchIndexService,
 	}
 }
 
@@ -161,7 +163,7 @@ func ConvertModelToPb(model *model.BahanMakanan) *pb.BahanMakanan {
 }
 
 func (s *bahanMakananService) GetAllBahanMakanan(ctx *fiber.Ctx) ([]model.BahanMakanan, error) {
-	response, err := s.Client.GetAllBahanMakanan(ctx.Context())
+	response, err := s.Client.GetAllBahanMakanan(ctx.UserContext())
 	if err != nil {
 		s.Log.Errorf("Failed to get all bahan makanan: %+v", err)
 		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to get bahan makanan data")
@@ -176,7 +178,7 @@ func (s *bahanMakananService) GetAllBahanMakanan(ctx *fiber.Ctx) ([]model.BahanM
 }
 
 func (s *bahanMakananService) GetBahanMakananByKode(ctx *fiber.Ctx, kode string) (*model.BahanMakanan, error) {
-	response, err := s.Client.GetBahanMakananByKode(ctx.Context(), kode)
+	response, err := s.Client.GetBahanMakananByKode(ctx.UserContext(), kode)
 	if err != nil {
 		s.Log.Errorf("Failed to get bahan makanan by kode: %+v", err)
 		return nil, fiber.NewError(fiber.StatusNotFound, "Bahan makanan not found")
@@ -187,7 +189,7 @@ func (s *bahanMakananService) GetBahanMakananByKode(ctx *fiber.Ctx, kode string)
 }
 
 func (s *bahanMakananService) GetBahanMakananById(ctx *fiber.Ctx, id uint32) (*model.BahanMakanan, error) {
-	response, err := s.Client.GetBahanMakananById(ctx.Context(), id)
+	response, err := s.Client.GetBahanMakananById(ctx.UserContext(), id)
 	if err != nil {
 		s.Log.Errorf("Failed to get bahan makanan by id: %+v", err)
 		return nil, fiber.NewError(fiber.StatusNotFound, "Bahan makanan not found")
@@ -198,7 +200,7 @@ func (s *bahanMakananService) GetBahanMakananById(ctx *fiber.Ctx, id uint32) (*m
 }
 
 func (s *bahanMakananService) GetBahanMakananByMentahOlahan(ctx *fiber.Ctx, mentahOlahan string) ([]model.BahanMakanan, error) {
-	response, err := s.Client.GetBahanMakananByMentahOlahan(ctx.Context(), mentahOlahan)
+	response, err := s.Client.GetBahanMakananByMentahOlahan(ctx.UserContext(), mentahOlahan)
 	if err != nil {
 		s.Log.Errorf("Failed to get bahan makanan by mentah/olahan: %+v", err)
 		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to get bahan makanan data")
@@ -213,7 +215,7 @@ func (s *bahanMakananService) GetBahanMakananByMentahOlahan(ctx *fiber.Ctx, ment
 }
 
 func (s *bahanMakananService) GetBahanMakananByKelompok(ctx *fiber.Ctx, kelompokMakanan string) ([]model.BahanMakanan, error) {
-	response, err := s.Client.GetBahanMakananByKelompok(ctx.Context(), kelompokMakanan)
+	response, err := s.Client.GetBahanMakananByKelompok(ctx.UserContext(), kelompokMakanan)
 	if err != nil {
 		s.Log.Errorf("Failed to get bahan makanan by kelompok: %+v", err)
 		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to get bahan makanan data")
@@ -230,12 +232,14 @@ func (s *bahanMakananService) GetBahanMakananByKelompok(ctx *fiber.Ctx, kelompok
 func (s *bahanMakananService) UpdateBahanMakanan(ctx *fiber.Ctx, id uint32, bahanMakanan *model.BahanMakanan) (*model.BahanMakanan, error) {
 	pbBahanMakanan := ConvertModelToPb(bahanMakanan)
 
-	response, err := s.Client.UpdateBahanMakanan(ctx.Context(), id, pbBahanMakanan)
+	response, err := s.Client.UpdateBahanMakanan(ctx.UserContext(), id, pbBahanMakanan)
 	if err != nil {
 		s.Log.Errorf("Failed to update bahan makanan: %+v", err)
 		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to update bahan makanan")
 	}
 
 	updatedBahanMakanan := ConvertPbToModel(response.BahanMakanan)
+	s.SearchIndexService.IndexFood(ctx.UserContext(), updatedBahanMakanan.Kode)
+
 	return &updatedBahanMakanan, nil
 }
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"app/src/bulkhead"
+	"app/src/config"
+)
+
+// Bulkheads of the external providers, a slow provider fills its own slots and then fails fast
+// instead of holding every request handler
+var (
+	paymentBulkhead = bulkhead.New("Payment gateway", config.BulkheadPaymentSize, config.BulkheadWait)
+	aiBulkhead      = bulkhead.New("Food recognition", config.BulkheadAISize, config.BulkheadWait)
+	emailBulkhead   = bulkhead.New("Email", config.BulkheadEmailSize, config.BulkheadWait)
+	llmBulkhead     = bulkhead.New("Assistant", config.BulkheadLLMSize, config.BulkheadWait)
+	speechBulkhead  = bulkhead.New("Speech to text", config.BulkheadSpeechSize, config.BulkheadWait)
+)
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"app/src/captcha"
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Headers of the CAPTCHA token the widget gave the browser, and of the key trusted test clients skip it with
+const (
+	CaptchaTokenHeader  = "X-Captcha-Token"
+	CaptchaBypassHeader = "X-Captcha-Bypass"
+)
+
+type CaptchaService interface {
+	// Check verifies the CAPTCHA token of the request. It passes when CAPTCHA is off or the request carries a
+	// bypass key, and answers 428 when the token is missing so the client shows the widget.
+	Check(c *fiber.Ctx) error
+	// LoginRequiresCaptcha reports whether a login of email from the address of the request must pass the CAPTCHA,
+	// after failures of the email or the address. The address only counts when it is the client's, see failureIP.
+	LoginRequiresCaptcha(c *fiber.Ctx, email string) (bool, error)
+	RecordLoginFailure(c *fiber.Ctx, email string)
+	ClearLoginFailures(c *fiber.Ctx, email string)
+	// PurgeLoginFailures deletes the failures that no longer count
+	PurgeLoginFailures(ctx context.Context) error
+}
+
+type captchaService struct {
+	Log        *logrus.Logger
+	DB         *gorm.DB
+	Verifier   captcha.Verifier
+	BypassKeys []string
+}
+
+// NewCaptchaService returns a service that lets every request through when no provider is configured, and
+// an error when the configured one cannot be used: CAPTCHA must not be off because of a typo
+func NewCaptchaService(db *gorm.DB) (CaptchaService, error) {
+	verifier, err := captcha.New(config.CaptchaProvider, captcha.Settings{
+		Secret:   config.CaptchaSecret,
+		Endpoint: config.CaptchaEndpoint,
+		MinScore: config.CaptchaMinScore,
+		Timeout:  config.CaptchaTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for _, key := range strings.Split(config.CaptchaBypassKeys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return &captchaService{
+		Log:        utils.Log,
+		DB:         db,
+		Verifier:   verifier,
+		BypassKeys: keys,
+	}, nil
+}
+
+func (s *captchaService) Check(c *fiber.Ctx) error {
+	if s.Verifier == nil || s.bypassed(c) {
+		return nil
+	}
+
+	token := c.Get(CaptchaTokenHeader)
+	if token == "" {
+		return fiber.NewError(fiber.StatusPreconditionRequired, "Complete the CAPTCHA to continue")
+	}
+	if len(token) > 4096 {
+		return fiber.NewError(fiber.StatusBadRequest, "CAPTCHA verification failed, try again")
+	}
+
+	if err := s.Verifier.Verify(c.UserContext(), token, c.IP()); err != nil {
+		if errors.Is(err, captcha.ErrRejected) {
+			s.Log.Infof("Rejected the CAPTCHA of %s %s from %s: %v", c.Method(), c.Path(), c.IP(), err)
+			return fiber.NewError(fiber.StatusBadRequest, "CAPTCHA verification failed, try again")
+		}
+		s.Log.Errorf("Failed to verify a CAPTCHA: %v", err)
+		return fiber.NewError(fiber.StatusServiceUnavailable, "CAPTCHA verification is unavailable, try again later")
+	}
+	return nil
+}
+
+func (s *captchaService) LoginRequiresCaptcha(c *fiber.Ctx, email string) (bool, error) {
+	if s.Verifier == nil {
+		return false, nil
+	}
+
+	db := s.DB.WithContext(c.UserContext()).Model(&model.LoginFailure{})
+	if ip := failureIP(c); ip != "" {
+		db = db.Where("email = ? OR ip_address = ?", email, ip)
+	} else {
+		db = db.Where("email = ?", email)
+	}
+
+	var failures int64
+	if err := db.Where("created_at > ?", time.Now().Add(-config.CaptchaLoginWindow)).
+		Count(&failures).Error; err != nil {
+		return false, err
+	}
+	return failures >= int64(config.CaptchaLoginFailures), nil
+}
+
+func (s *captchaService) RecordLoginFailure(c *fiber.Ctx, email string) {
+	if s.Verifier == nil {
+		return
+	}
+	if len(email) > 50 {
+		email = email[:50]
+	}
+	if err := s.DB.WithContext(c.UserContext()).
+		Create(&model.LoginFailure{Email: email, IPAddress: failureIP(c)}).Error; err != nil {
+		s.Log.Errorf("Failed to record a login failure of %s: %v", email, err)
+	}
+}
+
+func (s *captchaService) ClearLoginFailures(c *fiber.Ctx, email string) {
+	if s.Verifier == nil {
+		return
+	}
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("email = ?", email).Delete(&model.LoginFailure{}).Error; err != nil {
+		s.Log.Errorf("Failed to clear the login failures of %s: %v", email, err)
+	}
+}
+
+func (s *captchaService) PurgeLoginFailures(ctx context.Context) error {
+	result := s.DB.WithContext(ctx).
+		Where("created_at < ?", time.Now().Add(-config.CaptchaLoginWindow)).
+		Delete(&model.LoginFailure{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		s.Log.Infof("Purged %d login failures", result.RowsAffected)
+	}
+	return nil
+}
+
+// failureIP returns the address the login failures of a request count for. It is empty for a request from one of
+// TRUSTED_PROXIES that did not carry the client address in PROXY_HEADER: c.IP() is then the address of the proxy,
+// shared by all its clients, and one client failing would make everyone pass the CAPTCHA. Behind a proxy missing
+// from TRUSTED_PROXIES this cannot be told apart, the proxy settings must be configured.
+func failureIP(c *fiber.Ctx) string {
+	ip := c.IP()
+	if c.IsProxyTrusted() && ip == c.Context().RemoteIP().String() {
+		return ""
+	}
+	return ip
+}
+
+// bypassed reports whether the request carries one of the keys of trusted test clients
+func (s *captchaService) bypassed(c *fiber.Ctx) bool {
+	key := c.Get(CaptchaBypassHeader)
+	if key == "" {
+		return false
+	}
+	for _, bypassKey := range s.BypassKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(bypassKey)) == 1 {
+			return true
+		}
+	}
+	return false
+}
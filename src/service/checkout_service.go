@@ -0,0 +1,426 @@
+package service
+
+import (
+	"app/src/clock"
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// checkoutTokenLength is the length of the random token in a shareable payment link
+const checkoutTokenLength = 32
+
+type CheckoutService interface {
+	CreateSession(c *fiber.Ctx, userID uuid.UUID, req *validation.CreateCheckout) (*model.CheckoutSession, error)
+	GetSession(c *fiber.Ctx, token string) (*model.CheckoutSession, error)
+	PaySession(c *fiber.Ctx, token string) (*model.PaymentResponse, error)
+
+	// Admin payment reminders
+	SendPaymentReminder(c *fiber.Ctx, adminID, subscriptionID uuid.UUID, req *validation.SendPaymentReminder) (*model.PaymentReminder, error)
+	GetPaymentReminders(c *fiber.Ctx, subscriptionID uuid.UUID) ([]model.PaymentReminder, error)
+
+	// GetFunnel follows the users through the checkout of each plan between two days
+	GetFunnel(c *fiber.Ctx, query *validation.CheckoutFunnelQuery) (*model.CheckoutFunnel, error)
+}
+
+type checkoutService struct {
+	Log                 *logrus.Logger
+	DB                  *gorm.DB
+	Validate            *validator.Validate
+	CouponService       CouponService
+	SubscriptionService SubscriptionService
+	EmailService        EmailService
+}
+
+func NewCheckoutService(
+	db *gorm.DB, validate *validator.Validate, couponService CouponService, subscriptionService SubscriptionService, emailService EmailService,
+) CheckoutService {
+	return &checkoutService{
+		Log:                 utils.Log,
+		DB:                  db,
+		Validate:            validate,
+		CouponService:       couponService,
+		SubscriptionService: subscriptionService,
+		EmailService:        emailService,
+	}
+}
+
+// newCheckoutSession prices a plan for a user, coupon may be nil
+func newCheckoutSession(
+	userID uuid.UUID, plan *model.SubscriptionPlan, coupon *model.Coupon, paymentMethod string, installment bool,
+) *model.CheckoutSession {
+	session := &model.CheckoutSession{
+		Token:         utils.GenerateRandomString(checkoutTokenLength),
+		UserID:        userID,
+		PlanID:        plan.ID,
+		PaymentMethod: paymentMethod,
+		Installment:   installment,
+		Currency:      plan.Currency,
+		Subtotal:      plan.Price,
+		Status:        model.CheckoutOpen,
+		ExpiresAt:     time.Now().Add(time.Duration(config.CheckoutSessionTTLMinutes) * time.Minute),
+	}
+
+	if coupon != nil {
+		session.CouponID = &coupon.ID
+		session.CouponCode = coupon.Code
+		session.Discount = coupon.DiscountFor(plan.PriceMoney()).Int()
+	}
+
+	session.Total = session.Subtotal - session.Discount
+	return session
+}
+
+// appendCheckoutEvent records a step of the checkout funnel, a failure never fails the checkout
+func appendCheckoutEvent(db *gorm.DB, eventType string, session *model.CheckoutSession) {
+	if err := appendEvent(db, eventType, session.UserID, session.ID,
+		model.EventPayload{PlanID: session.PlanID.String()}, time.Now()); err != nil {
+		utils.Log.Errorf("Failed to append %s event of checkout session %s: %v", eventType, session.ID, err)
+	}
+}
+
+// checkoutLink is the shareable page where anyone holding the link can pay for the session
+func checkoutLink(session *model.CheckoutSession) string {
+	return fmt.Sprintf("%s/checkout/%s", strings.TrimRight(config.FrontendURL, "/"), session.Token)
+}
+
+func (s *checkoutService) CreateSession(c *fiber.Ctx, userID uuid.UUID, req *validation.CreateCheckout) (*model.CheckoutSession, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var plan model.SubscriptionPlan
+	if err := s.DB.WithContext(c.UserContext()).First(&plan, "id = ?", req.PlanID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+		}
+		return nil, err
+	}
+
+	if !plan.IsAvailableAt(clock.Now(c.UserContext())) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+	}
+
+	if req.Installment && (!plan.AllowInstallments || plan.InstallmentCount < 2) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Installments are not available for this plan")
+	}
+
+	// Renewals are charged to the card saved by the payment, installments are billed their own way
+	if req.AutoRenew && (req.PaymentMethod != "credit_card" || req.Installment) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Auto-renewal needs a credit card payment without installments")
+	}
+
+	// Midtrans, wallet credit and bank transfers settle in Rupiah, other currencies are for app store prices
+	if plan.Currency != model.CurrencyIDR {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "This plan can only be bought through the app stores")
+	}
+
+	var coupon *model.Coupon
+	if req.CouponCode != "" {
+		var err error
+		if coupon, err = s.CouponService.Resolve(c, req.CouponCode, plan.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	session := newCheckoutSession(userID, &plan, coupon, req.PaymentMethod, req.Installment)
+	session.AutoRenew = req.AutoRenew
+	if err := s.DB.WithContext(c.UserContext()).Create(session).Error; err != nil {
+		return nil, err
+	}
+	appendCheckoutEvent(s.DB.WithContext(c.UserContext()), model.EventCheckoutCreated, session)
+
+	return s.withDetails(session, &plan)
+}
+
+func (s *checkoutService) GetSession(c *fiber.Ctx, token string) (*model.CheckoutSession, error) {
+	session, err := s.findByToken(c, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan model.SubscriptionPlan
+	if err := s.DB.WithContext(c.UserContext()).First(&plan, "id = ?", session.PlanID).Error; err != nil {
+		return nil, err
+	}
+
+	return s.withDetails(session, &plan)
+}
+
+// PaySession starts the gateway payment of a session, an attempt that is still pending is returned as is
+func (s *checkoutService) PaySession(c *fiber.Ctx, token string) (*model.PaymentResponse, error) {
+	session, err := s.findByToken(c, token)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case session.Status == model.CheckoutPaid:
+		return nil, fiber.NewError(fiber.StatusConflict, "Checkout session is already paid")
+	case !session.IsPayable(time.Now()):
+		return nil, fiber.NewError(fiber.StatusGone, "Checkout session has expired")
+	}
+
+	if session.Status == model.CheckoutPending && session.PaymentURL != nil && *session.PaymentURL != "" {
+		payment := &model.PaymentResponse{
+			RedirectURL:         *session.PaymentURL,
+			WalletAmountApplied: session.WalletAmountApplied,
+			AmountDue:           session.AmountDue,
+		}
+		if session.OrderID != nil {
+			payment.OrderID = *session.OrderID
+		}
+		if session.PaymentToken != nil {
+			payment.TransactionToken = *session.PaymentToken
+		}
+		return payment, nil
+	}
+
+	return s.SubscriptionService.StartCheckout(c, session)
+}
+
+// SendPaymentReminder extends the payment link of an unpaid subscription and emails it to the user
+func (s *checkoutService) SendPaymentReminder(
+	c *fiber.Ctx, adminID, subscriptionID uuid.UUID, req *validation.SendPaymentReminder,
+) (*model.PaymentReminder, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var subscription model.UserSubscription
+	if err := s.DB.WithContext(c.UserContext()).
+		Preload("User").
+		Preload("Plan").
+		First(&subscription, "id = ?", subscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription not found")
+		}
+		return nil, err
+	}
+
+	if subscription.IsStoreManaged() {
+		return nil, fiber.NewError(fiber.StatusConflict, "Subscription is billed by the app store")
+	}
+
+	if subscription.PaymentStatus != "pending" && subscription.PaymentStatus != "failed" {
+		return nil, fiber.NewError(fiber.StatusConflict, "Subscription is not awaiting payment")
+	}
+
+	session, err := s.reminderSession(c, &subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	link := checkoutLink(session)
+	if err := s.EmailService.SendPaymentReminderEmail(
+		subscription.User.Email, subscription.Plan.Name, session.TotalMoney(), link, session.ExpiresAt, req.Note,
+	); err != nil {
+		if errors.Is(err, ErrUnsubscribed) {
+			return nil, fiber.NewError(fiber.StatusConflict, "The user unsubscribed from payment reminders")
+		}
+		if errors.Is(err, ErrEmailSuppressed) {
+			return nil, fiber.NewError(fiber.StatusConflict, "The email of the user is undeliverable")
+		}
+		return nil, fiber.NewError(fiber.StatusBadGateway, "Failed to send payment reminder")
+	}
+
+	reminder := &model.PaymentReminder{
+		UserSubscriptionID: subscription.ID,
+		CheckoutSessionID:  session.ID,
+		SentByID:           adminID,
+		Channel:            model.ReminderChannelEmail,
+		PaymentLink:        link,
+		LinkExpiresAt:      session.ExpiresAt,
+		Note:               req.Note,
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Create(reminder).Error; err != nil {
+		return nil, err
+	}
+
+	return reminder, nil
+}
+
+func (s *checkoutService) GetPaymentReminders(c *fiber.Ctx, subscriptionID uuid.UUID) ([]model.PaymentReminder, error) {
+	var reminders []model.PaymentReminder
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("user_subscription_id = ?", subscriptionID).
+		Order("created_at DESC").
+		Find(&reminders).Error; err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// reminderSession extends the latest checkout session of a subscription and reopens it when its last attempt failed.
+// Subscriptions created before checkout sessions existed get a new session for their plan.
+func (s *checkoutService) reminderSession(c *fiber.Ctx, subscription *model.UserSubscription) (*model.CheckoutSession, error) {
+	session := new(model.CheckoutSession)
+	err := s.DB.WithContext(c.UserContext()).
+		Where("user_subscription_id = ?", subscription.ID).
+		Order("created_at DESC").
+		First(session).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		session = newCheckoutSession(subscription.UserID, &subscription.Plan, nil, subscription.PaymentMethod, subscription.IsInstallment)
+		if err := s.DB.WithContext(c.UserContext()).Create(session).Error; err != nil {
+			return nil, err
+		}
+		return session, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session.ExpiresAt = time.Now().Add(time.Duration(config.CheckoutSessionTTLMinutes) * time.Minute)
+	if session.Status == model.CheckoutFailed || session.Status == model.CheckoutExpired {
+		session.Status = model.CheckoutOpen
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(session).
+		Select("ExpiresAt", "Status").
+		Updates(session).Error; err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// findByToken loads a session by its link token and marks it expired once the link is no longer valid
+func (s *checkoutService) findByToken(c *fiber.Ctx, token string) (*model.CheckoutSession, error) {
+	session := new(model.CheckoutSession)
+	if err := s.DB.WithContext(c.UserContext()).Where("token = ?", token).First(session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Checkout session not found")
+		}
+		return nil, err
+	}
+
+	// A pending gateway transaction may still settle after the link expired, so only unpaid attempts expire
+	if (session.Status == model.CheckoutOpen || session.Status == model.CheckoutFailed) && !session.IsPayable(time.Now()) {
+		if err := s.DB.WithContext(c.UserContext()).
+			Model(session).
+			Update("status", model.CheckoutExpired).Error; err != nil {
+			s.Log.Errorf("Failed to expire checkout session %s: %v", session.ID, err)
+		}
+	}
+
+	return session, nil
+}
+
+func (s *checkoutService) withDetails(session *model.CheckoutSession, plan *model.SubscriptionPlan) (*model.CheckoutSession, error) {
+	planResponse, err := toPlanResponse(*plan)
+	if err != nil {
+		return nil, err
+	}
+
+	session.Plan = planResponse
+	session.PaymentLink = checkoutLink(session)
+	return session, nil
+}
+
+func (s *checkoutService) GetFunnel(c *fiber.Ctx, query *validation.CheckoutFunnelQuery) (*model.CheckoutFunnel, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	from, _ := time.ParseInLocation("2006-01-02", query.From, time.Local)
+	to, _ := time.ParseInLocation("2006-01-02", query.To, time.Local)
+	if to.Before(from) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+
+	// Which steps each user did per plan in the period, then how many users did each step and reached it
+	var steps, columns, previous []string
+	for i, step := range model.CheckoutFunnelSteps {
+		steps = append(steps, fmt.Sprintf("bool_or(domain_events.type = '%s') AS step_%d", step, i))
+		previous = append(previous, fmt.Sprintf("step_%d", i))
+		columns = append(columns,
+			fmt.Sprintf("COUNT(*) FILTER (WHERE step_%d) AS users_%d", i, i),
+			fmt.Sprintf("COUNT(*) FILTER (WHERE %s) AS reached_%d", strings.Join(previous, " AND "), i))
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+	perUser := db.Table("domain_events").
+		Select("domain_events.user_id, domain_events.payload->>'plan_id' AS plan_id, "+strings.Join(steps, ", ")).
+		Joins("JOIN users ON users.id = domain_events.user_id").
+		// QA accounts stay out of product metrics like they stay out of revenue
+		Where("users.is_sandbox = ?", false).
+		Where("domain_events.type IN ?", model.CheckoutFunnelSteps).
+		Where("domain_events.occurred_at >= ? AND domain_events.occurred_at < ?", from, to.AddDate(0, 0, 1)).
+		Group("domain_events.user_id, domain_events.payload->>'plan_id'")
+	if query.PlanID != "" {
+		perUser = perUser.Where("domain_events.payload->>'plan_id' = ?", query.PlanID)
+	}
+
+	var rows []map[string]any
+	if err := db.Table("(?) AS funnel", perUser).
+		Select("plan_id, " + strings.Join(columns, ", ")).
+		Group("plan_id").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var planIDs []uuid.UUID
+	for _, row := range rows {
+		if planID, err := uuid.Parse(fmt.Sprint(row["plan_id"])); err == nil {
+			planIDs = append(planIDs, planID)
+		}
+	}
+	names := make(map[uuid.UUID]string, len(planIDs))
+	if len(planIDs) > 0 {
+		var plans []model.SubscriptionPlan
+		if err := db.Select("id", "name").Where("id IN ?", planIDs).Find(&plans).Error; err != nil {
+			return nil, err
+		}
+		for _, plan := range plans {
+			names[plan.ID] = plan.Name
+		}
+	}
+
+	funnel := &model.CheckoutFunnel{From: query.From, To: query.To, Plans: []model.CheckoutFunnelPlan{}}
+	totalUsers := make(map[string]int64, len(model.CheckoutFunnelSteps))
+	totalReached := make(map[string]int64, len(model.CheckoutFunnelSteps))
+	for _, row := range rows {
+		planID, err := uuid.Parse(fmt.Sprint(row["plan_id"]))
+		if err != nil {
+			continue
+		}
+
+		users := make(map[string]int64, len(model.CheckoutFunnelSteps))
+		reached := make(map[string]int64, len(model.CheckoutFunnelSteps))
+		for i, step := range model.CheckoutFunnelSteps {
+			users[step] = countValue(row[fmt.Sprintf("users_%d", i)])
+			reached[step] = countValue(row[fmt.Sprintf("reached_%d", i)])
+			totalUsers[step] += users[step]
+			totalReached[step] += reached[step]
+		}
+		funnel.Plans = append(funnel.Plans, model.CheckoutFunnelPlan{
+			PlanID:   planID,
+			PlanName: names[planID],
+			Steps:    model.NewCheckoutFunnelSteps(users, reached),
+		})
+	}
+	funnel.Steps = model.NewCheckoutFunnelSteps(totalUsers, totalReached)
+
+	// Most viewed plans first
+	sort.SliceStable(funnel.Plans, func(i, j int) bool {
+		return funnel.Plans[i].Steps[0].Users > funnel.Plans[j].Steps[0].Users
+	})
+
+	return funnel, nil
+}
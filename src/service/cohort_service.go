@@ -0,0 +1,193 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// cohortBatchSize is the number of user stats read and aggregates written at once
+const cohortBatchSize = 1000
+
+// cohortStatsQuery upserts the cohort and activity of the users whose window after sign up is not over yet, or
+// was not computed since it ended. Stats of older users are final: their plan is that of the first paid
+// subscription in the window and the diary of inactive accounts is removed by retention later on.
+// %[1]d is the window in days, %[2]d the adherence period in days.
+const cohortStatsQuery = `INSERT INTO user_cohort_stats
+		(user_id, signup_date, signup_month, plan, source, active_weeks, logged_days, computed_at)
+	SELECT u.id, u.created_at::date, date_trunc('month', u.created_at)::date,
+		COALESCE((SELECT subscription_plans.name FROM user_subscriptions
+			JOIN subscription_plans ON subscription_plans.id = user_subscriptions.plan_id
+			WHERE user_subscriptions.user_id = u.id AND user_subscriptions.payment_status = 'success'
+				AND user_subscriptions.deleted_at IS NULL
+				AND user_subscriptions.start_date < u.created_at + make_interval(days => %[1]d)
+			ORDER BY user_subscriptions.start_date LIMIT 1), ?),
+		COALESCE(NULLIF(u.acquisition_source, ''), ?),
+		COALESCE(activity.active_weeks, 0), COALESCE(activity.logged_days, 0), ?
+	FROM users u
+	LEFT JOIN user_cohort_stats stats ON stats.user_id = u.id
+	LEFT JOIN LATERAL (
+		SELECT bit_or(1::bigint << ((d.date - u.created_at::date) / 7)) AS active_weeks,
+			COUNT(*) FILTER (WHERE d.date < u.created_at::date + %[2]d) AS logged_days
+		FROM daily_nutrition_summaries d
+		WHERE d.user_id = u.id AND d.meal_count > 0
+			AND d.date >= u.created_at::date AND d.date < u.created_at::date + %[1]d
+	) activity ON true
+	WHERE u.role = 'user' AND u.is_sandbox = false
+		AND (stats.user_id IS NULL OR stats.computed_at::date <= stats.signup_date + %[1]d)
+	ON CONFLICT (user_id) DO UPDATE SET
+		plan = excluded.plan,
+		source = excluded.source,
+		active_weeks = excluded.active_weeks,
+		logged_days = excluded.logged_days,
+		computed_at = excluded.computed_at`
+
+type CohortService interface {
+	// Aggregate refreshes the cohort stats of the users and rebuilds the cohort aggregates once a night
+	Aggregate(ctx context.Context) error
+
+	// GetComparison compares retention and adherence between the cohorts of a dimension among the users who
+	// signed up in a range of months, from the aggregates of the last night
+	GetComparison(c *fiber.Ctx, query *validation.CohortQuery) (*model.CohortComparison, error)
+}
+
+type cohortService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewCohortService(db *gorm.DB, validate *validator.Validate) CohortService {
+	return &cohortService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *cohortService) Aggregate(ctx context.Context) error {
+	now := time.Now()
+
+	// Before the first run there is nothing to compare, it does not wait for the night
+	var previous int64
+	if err := s.DB.WithContext(ctx).
+		Model(&model.SystemSetting{}).
+		Where("key = ?", model.SettingCohortsAggregatedOn).
+		Count(&previous).Error; err != nil {
+		return err
+	}
+
+	if previous > 0 && now.Hour() < config.CohortAggregateHour.Get() {
+		return nil
+	}
+
+	claimed, err := claimDailyRun(ctx, s.DB, model.SettingCohortsAggregatedOn, now.Format("2006-01-02"))
+	if err != nil || !claimed {
+		return err
+	}
+
+	db := s.DB.WithContext(ctx)
+
+	result := db.Exec(fmt.Sprintf(cohortStatsQuery, model.CohortWindowDays(), model.CohortAdherenceDays),
+		model.CohortFreePlan, model.CohortOrganicSource, now)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if err := db.Where("NOT EXISTS (SELECT 1 FROM users WHERE users.id = user_cohort_stats.user_id)").
+		Delete(&model.UserCohortStats{}).Error; err != nil {
+		return err
+	}
+
+	// Which weeks are over changes every day, the aggregates of every cohort are rebuilt
+	type cohortKey struct {
+		month        time.Time
+		plan, source string
+	}
+	aggregates := map[cohortKey]*model.CohortAggregate{}
+	var batch []model.UserCohortStats
+	if err := db.FindInBatches(&batch, cohortBatchSize, func(_ *gorm.DB, _ int) error {
+		for _, stats := range batch {
+			key := cohortKey{month: stats.SignupMonth, plan: stats.Plan, source: stats.Source}
+			aggregate, ok := aggregates[key]
+			if !ok {
+				aggregate = &model.CohortAggregate{SignupMonth: stats.SignupMonth, Plan: stats.Plan, Source: stats.Source, ComputedAt: now}
+				aggregates[key] = aggregate
+			}
+			aggregate.Add(stats, now)
+		}
+		return nil
+	}).Error; err != nil {
+		return err
+	}
+
+	rows := make([]model.CohortAggregate, 0, len(aggregates))
+	for _, aggregate := range aggregates {
+		rows = append(rows, *aggregate)
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&model.CohortAggregate{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&rows, cohortBatchSize).Error
+	}); err != nil {
+		return err
+	}
+
+	s.Log.Infof("Aggregated %d cohorts, refreshed the stats of %d users", len(rows), result.RowsAffected)
+	return nil
+}
+
+func (s *cohortService) GetComparison(c *fiber.Ctx, query *validation.CohortQuery) (*model.CohortComparison, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	from, _ := time.Parse("2006-01", query.From)
+	to, _ := time.Parse("2006-01", query.To)
+	if to.Before(from) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+
+	db := s.DB.WithContext(c.UserContext()).Where("signup_month BETWEEN ? AND ?", from, to)
+	if query.Plan != "" {
+		db = db.Where("plan = ?", query.Plan)
+	}
+	if query.Source != "" {
+		db = db.Where("source = ?", model.NormalizeAcquisitionSource(query.Source))
+	}
+
+	var aggregates []model.CohortAggregate
+	if err := db.Find(&aggregates).Error; err != nil {
+		return nil, err
+	}
+
+	comparison := &model.CohortComparison{
+		Dimension:     query.Dimension,
+		From:          query.From,
+		To:            query.To,
+		AdherenceDays: model.CohortAdherenceDays,
+		Cohorts:       model.CompareCohorts(aggregates, query.Dimension),
+	}
+	for _, aggregate := range aggregates {
+		if comparison.ComputedAt == nil || aggregate.ComputedAt.After(*comparison.ComputedAt) {
+			computedAt := aggregate.ComputedAt
+			comparison.ComputedAt = &computedAt
+		}
+	}
+
+	return comparison, nil
+}
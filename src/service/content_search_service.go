@@ -0,0 +1,121 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type ContentSearchService interface {
+	// Search finds published articles and recipes in the search index, or in the database when it is down
+	Search(c *fiber.Ctx, query *validation.ContentSearchQuery) ([]model.ContentSearchResult, error)
+}
+
+type contentSearchService struct {
+	Log                *logrus.Logger
+	DB                 *gorm.DB
+	Validate           *validator.Validate
+	SearchIndexService SearchIndexService
+}
+
+func NewContentSearchService(db *gorm.DB, validate *validator.Validate, searchIndexService SearchIndexService) ContentSearchService {
+	return &contentSearchService{
+		Log:                utils.Log,
+		DB:                 db,
+		Validate:           validate,
+		SearchIndexService: searchIndexService,
+	}
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (s *contentSearchService) Search(c *fiber.Ctx, query *validation.ContentSearchQuery) ([]model.ContentSearchResult, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	results, err := s.SearchIndexService.SearchContent(c.UserContext(), query.Q, query.Type, query.Limit)
+	if !errors.Is(err, ErrSearchIndexUnavailable) {
+		return results, err
+	}
+
+	return s.searchDatabase(c, query)
+}
+
+// searchDatabase matches the search as a whole in titles and texts, a match in the title counts double
+func (s *contentSearchService) searchDatabase(c *fiber.Ctx, query *validation.ContentSearchQuery) ([]model.ContentSearchResult, error) {
+	db := s.DB.WithContext(c.UserContext())
+	search := strings.ToLower(strings.TrimSpace(query.Q))
+	pattern := "%" + likeEscaper.Replace(search) + "%"
+	results := make([]model.ContentSearchResult, 0)
+
+	if query.Type == "" || query.Type == model.ContentTypeArticle {
+		var articles []model.Article
+		if err := db.
+			Where("published_at IS NOT NULL AND published_at <= ?", time.Now()).
+			Where("title ILIKE ? OR content ILIKE ?", pattern, pattern).
+			Order("published_at DESC").
+			Limit(query.Limit).
+			Find(&articles).Error; err != nil {
+			return nil, err
+		}
+		for _, article := range articles {
+			results = append(results, model.ContentSearchResult{
+				Type:    model.ContentTypeArticle,
+				ID:      article.ID,
+				Title:   article.Title,
+				Slug:    article.Slug,
+				Image:   article.Image,
+				Snippet: model.ContentSnippet(article.Content, search),
+				Score:   contentMatchScore(search, article.Title),
+			})
+		}
+	}
+
+	if query.Type == "" || query.Type == model.ContentTypeRecipe {
+		var recipes []model.Recipe
+		if err := db.
+			Where("name ILIKE ? OR description ILIKE ? OR ingredients ILIKE ?", pattern, pattern, pattern).
+			Order("created_at DESC").
+			Limit(query.Limit).
+			Find(&recipes).Error; err != nil {
+			return nil, err
+		}
+		for _, recipe := range recipes {
+			results = append(results, model.ContentSearchResult{
+				Type:    model.ContentTypeRecipe,
+				ID:      recipe.ID,
+				Title:   recipe.Name,
+				Slug:    recipe.Slug,
+				Image:   recipe.Image,
+				Snippet: model.ContentSnippet(recipe.Description+" "+recipe.Ingredients, search),
+				Score:   contentMatchScore(search, recipe.Name),
+			})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results, nil
+}
+
+func contentMatchScore(search, title string) float64 {
+	if strings.Contains(strings.ToLower(title), search) {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type CouponService interface {
+	GetCoupons(c *fiber.Ctx, query *validation.CouponQuery) ([]model.Coupon, int64, error)
+	CreateCoupon(c *fiber.Ctx, req *validation.CreateCoupon) (*model.Coupon, error)
+	UpdateCoupon(c *fiber.Ctx, couponID uuid.UUID, req *validation.UpdateCoupon) (*model.Coupon, error)
+
+	// Checkout helpers
+	Resolve(c *fiber.Ctx, code string, planID uuid.UUID) (*model.Coupon, error)
+}
+
+type couponService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewCouponService(db *gorm.DB, validate *validator.Validate) CouponService {
+	return &couponService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *couponService) GetCoupons(c *fiber.Ctx, query *validation.CouponQuery) ([]model.Coupon, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	var coupons []model.Coupon
+	var totalResults int64
+
+	db := s.DB.WithContext(c.UserContext()).Model(&model.Coupon{})
+
+	if err := db.Count(&totalResults).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.
+		Order("created_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&coupons).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return coupons, totalResults, nil
+}
+
+func (s *couponService) CreateCoupon(c *fiber.Ctx, req *validation.CreateCoupon) (*model.Coupon, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if (req.DiscountPercent == 0) == (req.DiscountAmount == 0) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Set either discount_percent or discount_amount")
+	}
+
+	coupon := &model.Coupon{
+		Code:            strings.ToUpper(req.Code),
+		Description:     req.Description,
+		DiscountPercent: req.DiscountPercent,
+		DiscountAmount:  req.DiscountAmount,
+		MaxRedemptions:  req.MaxRedemptions,
+		IsActive:        true,
+	}
+
+	if req.PlanID != "" {
+		planID := uuid.MustParse(req.PlanID)
+		if err := s.DB.WithContext(c.UserContext()).First(&model.SubscriptionPlan{}, "id = ?", planID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+			}
+			return nil, err
+		}
+		coupon.PlanID = &planID
+	}
+
+	var err error
+	if coupon.ValidFrom, err = parseAvailability(req.ValidFrom); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid valid_from, expected RFC3339 timestamp")
+	}
+	if coupon.ValidUntil, err = parseAvailability(req.ValidUntil); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid valid_until, expected RFC3339 timestamp")
+	}
+	if coupon.ValidFrom != nil && coupon.ValidUntil != nil && !coupon.ValidFrom.Before(*coupon.ValidUntil) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "valid_from must be before valid_until")
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Create(coupon).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, fiber.NewError(fiber.StatusConflict, "Coupon code already exists")
+		}
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+func (s *couponService) UpdateCoupon(c *fiber.Ctx, couponID uuid.UUID, req *validation.UpdateCoupon) (*model.Coupon, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var coupon model.Coupon
+	if err := s.DB.WithContext(c.UserContext()).First(&coupon, "id = ?", couponID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Coupon not found")
+		}
+		return nil, err
+	}
+
+	if req.Description != nil {
+		coupon.Description = *req.Description
+	}
+
+	if req.ValidUntil != nil {
+		validUntil, err := parseAvailability(*req.ValidUntil)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid valid_until, expected RFC3339 timestamp")
+		}
+		coupon.ValidUntil = validUntil
+	}
+
+	if req.MaxRedemptions != nil {
+		coupon.MaxRedemptions = *req.MaxRedemptions
+	}
+
+	if req.IsActive != nil {
+		coupon.IsActive = *req.IsActive
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Save(&coupon).Error; err != nil {
+		return nil, err
+	}
+
+	return &coupon, nil
+}
+
+// Resolve looks up a coupon by code and checks it can be used for the plan right now
+func (s *couponService) Resolve(c *fiber.Ctx, code string, planID uuid.UUID) (*model.Coupon, error) {
+	var coupon model.Coupon
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("code = ?", strings.ToUpper(strings.TrimSpace(code))).
+		First(&coupon).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Coupon is invalid or expired")
+		}
+		return nil, err
+	}
+
+	if !coupon.IsRedeemableAt(time.Now(), planID) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Coupon is invalid or expired")
+	}
+
+	return &coupon, nil
+}
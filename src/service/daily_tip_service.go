@@ -0,0 +1,309 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dailyTipBatchSize is the number of users whose tips are emailed per query
+const dailyTipBatchSize = 200
+
+type DailyTipService interface {
+	// GetTodayTip returns the tip of the user for today, building it on the first call of the day. It is
+	// nil when no rule matches the diary of the user.
+	GetTodayTip(ctx context.Context, userID uuid.UUID) (*model.DailyTip, error)
+	// EmailDailyTips emails today's tip, once a day after config.DailyTipHour, to the users who logged a
+	// meal in the last week and did not unsubscribe from daily tips
+	EmailDailyTips(ctx context.Context) error
+
+	GetRules(c *fiber.Ctx) ([]model.TipRule, error)
+	CreateRule(c *fiber.Ctx, adminID uuid.UUID, req *validation.CreateTipRule) (*model.TipRule, error)
+	UpdateRule(c *fiber.Ctx, adminID, id uuid.UUID, req *validation.UpdateTipRule) (*model.TipRule, error)
+	DeleteRule(c *fiber.Ctx, id uuid.UUID) error
+}
+
+type dailyTipService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+	Emails   EmailService
+}
+
+func NewDailyTipService(db *gorm.DB, validate *validator.Validate, emails EmailService) DailyTipService {
+	return &dailyTipService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+		Emails:   emails,
+	}
+}
+
+// tipDay is the date tips are built for, in the time zone of the summary dates
+func tipDay() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+func (s *dailyTipService) GetTodayTip(ctx context.Context, userID uuid.UUID) (*model.DailyTip, error) {
+	var rules []model.TipRule
+	if err := s.DB.WithContext(ctx).Where("enabled = ?", true).Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	return s.todayTip(s.DB.WithContext(ctx), userID, rules, tipDay())
+}
+
+// todayTip returns the stored tip of the day or builds it from the enabled rules
+func (s *dailyTipService) todayTip(db *gorm.DB, userID uuid.UUID, rules []model.TipRule, day time.Time) (*model.DailyTip, error) {
+	date := day.Format("2006-01-02")
+
+	var tips []model.DailyTip
+	if err := db.Where("user_id = ? AND date = ?", userID, date).Limit(1).Find(&tips).Error; err != nil {
+		return nil, err
+	}
+	if len(tips) > 0 {
+		return &tips[0], nil
+	}
+
+	var summaries []model.DailyNutritionSummary
+	if err := db.Where("user_id = ? AND date >= ? AND date < ?", userID,
+		day.AddDate(0, 0, -model.TipMaxWindowDays).Format("2006-01-02"), date).
+		Find(&summaries).Error; err != nil {
+		return nil, err
+	}
+
+	var shown []struct {
+		RuleKey string
+		Date    time.Time
+	}
+	if err := db.Model(&model.DailyTip{}).
+		Select("rule_key, MAX(date) AS date").
+		Where("user_id = ? AND date >= ?", userID, day.AddDate(0, 0, -model.TipMaxWindowDays).Format("2006-01-02")).
+		Group("rule_key").
+		Scan(&shown).Error; err != nil {
+		return nil, err
+	}
+	lastShown := make(map[string]time.Time, len(shown))
+	for _, rule := range shown {
+		lastShown[rule.RuleKey] = time.Date(rule.Date.Year(), rule.Date.Month(), rule.Date.Day(), 0, 0, 0, 0, day.Location())
+	}
+
+	match, ok := model.PickTip(rules, summaries, lastShown, day)
+	if !ok {
+		return nil, nil
+	}
+	title, message, err := match.Render()
+	if err != nil {
+		return nil, fmt.Errorf("rendering tip %s: %w", match.Rule.Key, err)
+	}
+
+	tip := &model.DailyTip{UserID: userID, Date: day, RuleKey: match.Rule.Key, Title: title, Message: message}
+	// Another request may have built it meanwhile, theirs is kept
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(tip)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if err := db.First(tip, "user_id = ? AND date = ?", userID, date).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return tip, nil
+}
+
+func (s *dailyTipService) EmailDailyTips(ctx context.Context) error {
+	now := time.Now()
+	if now.Hour() < config.DailyTipHour.Get() {
+		return nil
+	}
+
+	claimed, err := claimDailyRun(ctx, s.DB, model.SettingDailyTipsEmailedOn, now.Format("2006-01-02"))
+	if err != nil || !claimed {
+		return err
+	}
+
+	db := s.DB.WithContext(ctx)
+	var rules []model.TipRule
+	if err := db.Where("enabled = ?", true).Find(&rules).Error; err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	day := tipDay()
+	sent, failed := 0, 0
+	var lastID uuid.UUID
+	for {
+		var users []model.User
+		if err := db.Select("id", "email").
+			Where("id > ? AND verified_email = ?", lastID, true).
+			Where("EXISTS (SELECT 1 FROM daily_nutrition_summaries WHERE daily_nutrition_summaries.user_id = users.id AND date >= ? AND date < ?)",
+				day.AddDate(0, 0, -7).Format("2006-01-02"), day.Format("2006-01-02")).
+			Order("id").
+			Limit(dailyTipBatchSize).
+			Find(&users).Error; err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			break
+		}
+		lastID = users[len(users)-1].ID
+
+		for _, user := range users {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			tip, err := s.todayTip(db, user.ID, rules, day)
+			if err != nil {
+				s.Log.Errorf("Failed to build the daily tip of user %s: %v", user.ID, err)
+				failed++
+				continue
+			}
+			if tip == nil || tip.EmailedAt != nil {
+				continue
+			}
+
+			if err := s.Emails.SendDailyTipEmail(user.Email, tip.Title, tip.Message); err != nil {
+				if !errors.Is(err, ErrUnsubscribed) && !errors.Is(err, ErrEmailSuppressed) {
+					failed++
+				}
+				continue
+			}
+			if err := db.Model(tip).Where("user_id = ? AND date = ?", user.ID, day.Format("2006-01-02")).
+				UpdateColumn("emailed_at", time.Now()).Error; err != nil {
+				s.Log.Errorf("Failed to mark the daily tip of user %s emailed: %v", user.ID, err)
+			}
+			sent++
+		}
+	}
+
+	s.Log.Infof("Emailed %d daily tips, %d failed", sent, failed)
+	return nil
+}
+
+func (s *dailyTipService) GetRules(c *fiber.Ctx) ([]model.TipRule, error) {
+	var rules []model.TipRule
+	if err := s.DB.WithContext(c.UserContext()).Order("priority DESC, key").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (s *dailyTipService) CreateRule(c *fiber.Ctx, adminID uuid.UUID, req *validation.CreateTipRule) (*model.TipRule, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	rule := &model.TipRule{
+		Key:          req.Key,
+		Metric:       req.Metric,
+		Comparison:   req.Comparison,
+		Threshold:    req.Threshold,
+		MinDays:      req.MinDays,
+		WindowDays:   req.WindowDays,
+		Title:        req.Title,
+		Message:      req.Message,
+		Priority:     req.Priority,
+		CooldownDays: req.CooldownDays,
+		Enabled:      req.Enabled == nil || *req.Enabled,
+		UpdatedByID:  &adminID,
+	}
+	if err := model.CheckTipRule(*rule); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Create(rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, fiber.NewError(fiber.StatusConflict, "Tip rule key already exists")
+		}
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *dailyTipService) UpdateRule(c *fiber.Ctx, adminID, id uuid.UUID, req *validation.UpdateTipRule) (*model.TipRule, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	rule := new(model.TipRule)
+	if err := s.DB.WithContext(c.UserContext()).First(rule, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Tip rule not found")
+		}
+		return nil, err
+	}
+
+	if req.Metric != nil {
+		rule.Metric = *req.Metric
+	}
+	if req.Comparison != nil {
+		rule.Comparison = *req.Comparison
+	}
+	if req.Threshold != nil {
+		rule.Threshold = *req.Threshold
+	}
+	if req.MinDays != nil {
+		rule.MinDays = *req.MinDays
+	}
+	if req.WindowDays != nil {
+		rule.WindowDays = *req.WindowDays
+	}
+	if req.Title != nil {
+		rule.Title = *req.Title
+	}
+	if req.Message != nil {
+		rule.Message = *req.Message
+	}
+	if req.Priority != nil {
+		rule.Priority = *req.Priority
+	}
+	if req.CooldownDays != nil {
+		rule.CooldownDays = *req.CooldownDays
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	rule.UpdatedByID = &adminID
+
+	if err := model.CheckTipRule(*rule); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Tips already given today keep their copy
+	if err := s.DB.WithContext(c.UserContext()).Save(rule).Error; err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *dailyTipService) DeleteRule(c *fiber.Ctx, id uuid.UUID) error {
+	result := s.DB.WithContext(c.UserContext()).Delete(&model.TipRule{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "Tip rule not found")
+	}
+
+	return nil
+}
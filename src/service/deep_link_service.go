@@ -0,0 +1,182 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type DeepLinkService interface {
+	// Build signs a tracked link that opens the screen of link in the app
+	Build(link model.DeepLink) (*model.BuiltDeepLink, error)
+	// Open records the click on a tracked link and returns where to redirect. Links that are invalid or
+	// expired open the app home.
+	Open(c *fiber.Ctx, token string) string
+
+	CreateLink(c *fiber.Ctx, req *validation.CreateDeepLink) (*model.BuiltDeepLink, error)
+	GetClickStats(c *fiber.Ctx, query *validation.DeepLinkClickQuery) ([]model.DeepLinkClickStats, error)
+}
+
+type deepLinkService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewDeepLinkService(db *gorm.DB, validate *validator.Validate) DeepLinkService {
+	return &deepLinkService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *deepLinkService) Build(link model.DeepLink) (*model.BuiltDeepLink, error) {
+	if _, err := link.Path(); err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(config.DeepLinkTTL)
+	claims := jwt.MapClaims{
+		"sub":    link.UserID.String(),
+		"exp":    expiresAt.Unix(),
+		"type":   config.TokenTypeDeepLink,
+		"kind":   link.Kind,
+		"target": link.Target,
+		"src":    link.Source,
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.JWTSecret))
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.BuiltDeepLink{
+		URL:       fmt.Sprintf("%s/v1/links/%s", strings.TrimRight(config.AppURL, "/"), token),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (s *deepLinkService) Open(c *fiber.Ctx, token string) string {
+	home := strings.TrimRight(config.DeepLinkBaseURL, "/") + "/"
+
+	link, err := parseDeepLinkToken(token)
+	if err != nil {
+		return home
+	}
+	path, err := link.Path()
+	if err != nil {
+		return home
+	}
+
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	// A click that is not recorded still opens the screen
+	if err := s.DB.WithContext(c.UserContext()).Create(&model.DeepLinkClick{
+		Kind:      link.Kind,
+		Target:    link.Target,
+		Source:    link.Source,
+		UserID:    link.UserID,
+		UserAgent: userAgent,
+		ClickedAt: time.Now(),
+	}).Error; err != nil {
+		s.Log.Errorf("Failed to record the click on a %s link: %v", link.Kind, err)
+	}
+	if err := recordConversion(s.DB.WithContext(c.UserContext()), link.UserID, model.ConversionLinkClick, 0, time.Now()); err != nil {
+		s.Log.Errorf("Failed to record the link click conversion of %s: %v", link.UserID, err)
+	}
+
+	return home + path
+}
+
+func (s *deepLinkService) CreateLink(c *fiber.Ctx, req *validation.CreateDeepLink) (*model.BuiltDeepLink, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	userID := uuid.MustParse(req.UserID)
+	if err := s.DB.WithContext(c.UserContext()).Select("id").First(&model.User{}, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
+		}
+		return nil, err
+	}
+
+	link, err := s.Build(model.DeepLink{Kind: req.Kind, UserID: userID, Target: req.Target, Source: req.Source})
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return link, nil
+}
+
+func (s *deepLinkService) GetClickStats(c *fiber.Ctx, query *validation.DeepLinkClickQuery) ([]model.DeepLinkClickStats, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	from, _ := time.Parse("2006-01-02", query.From)
+	to, _ := time.Parse("2006-01-02", query.To)
+	if to.Before(from) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+
+	stats := []model.DeepLinkClickStats{}
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(&model.DeepLinkClick{}).
+		Select("kind, source, COUNT(*) AS clicks, COUNT(DISTINCT user_id) AS unique_users").
+		Where("clicked_at >= ? AND clicked_at < ?", from, to.AddDate(0, 0, 1)).
+		Group("kind, source").
+		Order("clicks DESC").
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// buildDeepLinkURL builds a tracked link for an email, an empty string when it cannot be built
+// so the email still goes out
+func buildDeepLinkURL(deepLinks DeepLinkService, link model.DeepLink) string {
+	built, err := deepLinks.Build(link)
+	if err != nil {
+		utils.Log.Warnf("Failed to build a %s link: %v", link.Kind, err)
+		return ""
+	}
+	return built.URL
+}
+
+func parseDeepLinkToken(token string) (*model.DeepLink, error) {
+	sub, err := utils.VerifyToken(token, config.JWTSecret, config.TokenTypeDeepLink)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return nil, err
+	}
+	claims := parsed.Claims.(jwt.MapClaims)
+	link := &model.DeepLink{UserID: userID}
+	link.Kind, _ = claims["kind"].(string)
+	link.Target, _ = claims["target"].(string)
+	link.Source, _ = claims["src"].(string)
+	return link, nil
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/pdf"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// diaryExportTimeout marks an export left running by an instance that stopped as failed
+const diaryExportTimeout = 15 * time.Minute
+
+type DiaryExportService interface {
+	// Export exports the diary of the user from one day to another. Ranges up to DIARY_EXPORT_SYNC_DAYS are
+	// produced at once and returned completed with their content, longer ones are queued and returned pending.
+	Export(c *fiber.Ctx, user *model.User, query *validation.DiaryExportQuery) (*model.DiaryExport, error)
+	// GetExport returns an export of the user without its content, with a new one-time download link once it is
+	// completed
+	GetExport(c *fiber.Ctx, userID, id uuid.UUID) (*model.DiaryExport, error)
+	// Download returns a completed export of the user with its content
+	Download(c *fiber.Ctx, userID, id uuid.UUID) (*model.DiaryExport, error)
+	// GetDownloads returns the audited downloads of an export of the user, newest first
+	GetDownloads(c *fiber.Ctx, userID, id uuid.UUID) ([]model.DownloadAttempt, error)
+
+	// RunPending produces the queued exports, oldest first, and drops the files of the expired ones
+	RunPending(ctx context.Context) error
+}
+
+type diaryExportService struct {
+	Log           *logrus.Logger
+	DB            *gorm.DB
+	Validate      *validator.Validate
+	DownloadLinks DownloadLinkService
+}
+
+func NewDiaryExportService(db *gorm.DB, validate *validator.Validate, downloadLinks DownloadLinkService) DiaryExportService {
+	return &diaryExportService{
+		Log:           utils.Log,
+		DB:            db,
+		Validate:      validate,
+		DownloadLinks: downloadLinks,
+	}
+}
+
+func (s *diaryExportService) Export(c *fiber.Ctx, user *model.User, query *validation.DiaryExportQuery) (*model.DiaryExport, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	// Meals are grouped into the days of the server, as the daily summaries are
+	from, _ := time.ParseInLocation("2006-01-02", query.From, time.Local)
+	to, _ := time.ParseInLocation("2006-01-02", query.To, time.Local)
+	if to.Before(from) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+	if from.AddDate(0, 0, config.DiaryExportMaxDays-1).Before(to) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Export range is limited to %d days", config.DiaryExportMaxDays))
+	}
+
+	export := &model.DiaryExport{
+		UserID: user.ID,
+		Format: query.Format,
+		From:   from,
+		To:     to,
+		Status: model.DiaryExportPending,
+	}
+
+	if !from.AddDate(0, 0, config.DiaryExportSyncDays-1).Before(to) {
+		if err := s.produce(s.DB.WithContext(c.UserContext()), export, user); err != nil {
+			return nil, err
+		}
+		return export, nil
+	}
+
+	var inProgress int64
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(&model.DiaryExport{}).
+		Where("user_id = ? AND status IN ?", user.ID, []string{model.DiaryExportPending, model.DiaryExportRunning}).
+		Count(&inProgress).Error; err != nil {
+		return nil, err
+	}
+	if inProgress > 0 {
+		return nil, fiber.NewError(fiber.StatusConflict, "An export of your diary is already in progress")
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Create(export).Error; err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+func (s *diaryExportService) GetExport(c *fiber.Ctx, userID, id uuid.UUID) (*model.DiaryExport, error) {
+	export, err := s.find(c.UserContext(), userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if export.Status == model.DiaryExportCompleted {
+		url, expiresAt, err := s.DownloadLinks.Issue(c.UserContext(), model.DownloadDiaryExport, export.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		export.DownloadURL = url
+		export.DownloadExpiresAt = &expiresAt
+	}
+	return export, nil
+}
+
+func (s *diaryExportService) Download(c *fiber.Ctx, userID, id uuid.UUID) (*model.DiaryExport, error) {
+	export, err := s.find(c.UserContext(), userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch export.Status {
+	case model.DiaryExportCompleted:
+	case model.DiaryExportExpired:
+		return nil, fiber.NewError(fiber.StatusGone, "Export has expired, request it again")
+	case model.DiaryExportFailed:
+		return nil, fiber.NewError(fiber.StatusConflict, "Export failed, request it again")
+	default:
+		return nil, fiber.NewError(fiber.StatusConflict, "Export is not ready yet")
+	}
+
+	var stored model.DiaryExport
+	if err := s.DB.WithContext(c.UserContext()).Select("content").First(&stored, "id = ?", export.ID).Error; err != nil {
+		return nil, err
+	}
+	export.Content = stored.Content
+
+	return export, nil
+}
+
+func (s *diaryExportService) GetDownloads(c *fiber.Ctx, userID, id uuid.UUID) ([]model.DownloadAttempt, error) {
+	if _, err := s.find(c.UserContext(), userID, id); err != nil {
+		return nil, err
+	}
+	return s.DownloadLinks.GetAttempts(c.UserContext(), model.DownloadDiaryExport, id, userID)
+}
+
+// find returns an export of the user without its content
+func (s *diaryExportService) find(ctx context.Context, userID, id uuid.UUID) (*model.DiaryExport, error) {
+	export := new(model.DiaryExport)
+	if err := s.DB.WithContext(ctx).
+		Omit("content").
+		First(export, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Export not found")
+		}
+		return nil, err
+	}
+	return export, nil
+}
+
+func (s *diaryExportService) RunPending(ctx context.Context) error {
+	db := s.DB.WithContext(ctx)
+	now := time.Now()
+
+	if err := db.Model(&model.DiaryExport{}).
+		Where("status = ? AND started_at < ?", model.DiaryExportRunning, now.Add(-diaryExportTimeout)).
+		Updates(map[string]any{"status": model.DiaryExportFailed, "error": "The export did not finish in time"}).Error; err != nil {
+		return err
+	}
+
+	if err := db.Model(&model.DiaryExport{}).
+		Where("status = ? AND expires_at < ?", model.DiaryExportCompleted, now).
+		Updates(map[string]any{"status": model.DiaryExportExpired, "content": nil}).Error; err != nil {
+		return err
+	}
+
+	for ctx.Err() == nil {
+		var export model.DiaryExport
+		result := db.Where("status = ?", model.DiaryExportPending).Order("created_at").Limit(1).Find(&export)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		// Another instance may claim the same export, only the one that moves it to running produces it
+		result = db.Model(&model.DiaryExport{}).
+			Where("id = ? AND status = ?", export.ID, model.DiaryExportPending).
+			Updates(map[string]any{"status": model.DiaryExportRunning, "started_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		var user model.User
+		err := db.First(&user, "id = ?", export.UserID).Error
+		if err == nil {
+			err = s.produce(db, &export, &user)
+		}
+		if err != nil {
+			s.Log.Errorf("Diary export %s failed: %v", export.ID, err)
+			if err := db.Model(&model.DiaryExport{}).Where("id = ?", export.ID).Updates(map[string]any{
+				"status":       model.DiaryExportFailed,
+				"error":        err.Error(),
+				"completed_at": time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// produce renders the export and saves it completed, creating it when it is new
+func (s *diaryExportService) produce(db *gorm.DB, export *model.DiaryExport, user *model.User) error {
+	// Dates read back from the database are midnight UTC, the days of the export are those of the server
+	export.From = time.Date(export.From.Year(), export.From.Month(), export.From.Day(), 0, 0, 0, 0, time.Local)
+	export.To = time.Date(export.To.Year(), export.To.Month(), export.To.Day(), 0, 0, 0, 0, time.Local)
+
+	var meals []model.MealHistory
+	if err := db.Where("user_id = ? AND meal_time >= ? AND meal_time < ?", export.UserID, export.From, export.To.AddDate(0, 0, 1)).
+		Order("meal_time").
+		Find(&meals).Error; err != nil {
+		return err
+	}
+	for i := range meals {
+		meals[i].MealTime = meals[i].MealTime.In(time.Local)
+	}
+
+	var content []byte
+	switch export.Format {
+	case model.DiaryExportPDF:
+		content = diaryPDF(user, export, meals, time.Now())
+	default:
+		var err error
+		if content, err = model.DiaryCSV(meals); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(config.DiaryExportTTL)
+	export.Status = model.DiaryExportCompleted
+	export.Meals = len(meals)
+	export.SizeBytes = len(content)
+	export.Content = content
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	return db.Save(export).Error
+}
+
+// Layout of the PDF export in points
+const (
+	diaryMargin     = 40.0
+	diaryRowHeight  = 13.0
+	diaryBottom     = pdf.PageHeight - 50
+	diaryTextSize   = 9.0
+	diaryHeaderSize = 10.0
+)
+
+// diaryColumns are the left edges and widths of the meal table
+var diaryColumns = []struct {
+	Title string
+	X     float64
+	Width float64
+}{
+	{"Time", diaryMargin, 38},
+	{"Meal", 80, 200},
+	{"Label", 285, 75},
+	{"kcal", 365, 50},
+	{"Protein g", 420, 50},
+	{"Carbs g", 475, 50},
+	{"Fat g", 525, 30},
+}
+
+// diaryPDF lays the diary out as a document to hand to a doctor or dietitian: the user and the range, averages
+// over the logged days, then the meals of every day with the day total
+func diaryPDF(user *model.User, export *model.DiaryExport, meals []model.MealHistory, now time.Time) []byte {
+	doc := pdf.New("Food diary of " + user.Name)
+	days := model.DiaryDays(meals)
+	y := 0.0
+
+	footer := func() {
+		doc.Text(diaryMargin, pdf.PageHeight-30, pdf.Regular, 8,
+			fmt.Sprintf("Nutribox food diary of %s, %s to %s, page %d", user.Name,
+				export.From.Format("2 Jan 2006"), export.To.Format("2 Jan 2006"), doc.Pages()))
+	}
+	tableHeader := func() {
+		for _, column := range diaryColumns {
+			doc.Text(column.X, y, pdf.Bold, diaryTextSize, column.Title)
+		}
+		doc.Rule(diaryMargin, pdf.PageWidth-diaryMargin, y+4, 0.5)
+		y += diaryRowHeight + 4
+	}
+	// need makes room for the lines that follow, on a new page when they do not fit
+	need := func(lines int) {
+		if y+float64(lines)*diaryRowHeight <= diaryBottom {
+			return
+		}
+		doc.AddPage()
+		footer()
+		y = 50
+		tableHeader()
+	}
+	number := func(value float64) string {
+		return fmt.Sprintf("%.1f", value)
+	}
+
+	footer()
+	y = 60
+	doc.Text(diaryMargin, y, pdf.Bold, 16, "Food diary")
+	y += 22
+	doc.Text(diaryMargin, y, pdf.Regular, diaryHeaderSize, fmt.Sprintf("%s (%s)", user.Name, user.Email))
+	y += 14
+	doc.Text(diaryMargin, y, pdf.Regular, diaryHeaderSize,
+		fmt.Sprintf("From %s to %s, generated %s", export.From.Format("2 January 2006"), export.To.Format("2 January 2006"),
+			now.Format("2 January 2006 15:04")))
+	y += 14
+
+	var total model.NutritionAmounts
+	for _, day := range days {
+		total.Calories += day.Total.Calories
+		total.Protein += day.Total.Protein
+		total.Carbs += day.Total.Carbs
+		total.Fat += day.Total.Fat
+	}
+	summary := fmt.Sprintf("%d meals on %d of %d days", len(meals), len(days), int(export.To.Sub(export.From).Hours()/24+0.5)+1)
+	if len(days) > 0 {
+		count := float64(len(days))
+		summary += fmt.Sprintf(", per logged day on average %.0f kcal, protein %.1f g, carbs %.1f g, fat %.1f g",
+			total.Calories/count, total.Protein/count, total.Carbs/count, total.Fat/count)
+	}
+	doc.Text(diaryMargin, y, pdf.Regular, diaryHeaderSize, summary)
+	y += 28
+
+	if len(days) == 0 {
+		doc.Text(diaryMargin, y, pdf.Regular, diaryHeaderSize, "No meals were logged in this period.")
+		return doc.Bytes()
+	}
+
+	tableHeader()
+	for _, day := range days {
+		need(3)
+		y += 4
+		doc.Text(diaryMargin, y, pdf.Bold, diaryHeaderSize, day.Date.Format("Monday, 2 January 2006"))
+		doc.Text(diaryColumns[3].X, y, pdf.Bold, diaryTextSize, number(day.Total.Calories))
+		doc.Text(diaryColumns[4].X, y, pdf.Bold, diaryTextSize, number(day.Total.Protein))
+		doc.Text(diaryColumns[5].X, y, pdf.Bold, diaryTextSize, number(day.Total.Carbs))
+		doc.Text(diaryColumns[6].X, y, pdf.Bold, diaryTextSize, number(day.Total.Fat))
+		y += diaryRowHeight
+
+		for _, meal := range day.Meals {
+			need(1)
+			var label string
+			if meal.Label != nil {
+				label = *meal.Label
+			}
+			cells := []string{meal.MealTime.Format("15:04"), meal.Title, label,
+				number(meal.Calories), number(meal.Protein), number(meal.Carbs), number(meal.Fat)}
+			for i, column := range diaryColumns {
+				doc.Text(column.X, y, pdf.Regular, diaryTextSize, pdf.Fit(cells[i], diaryTextSize, column.Width))
+			}
+			y += diaryRowHeight
+		}
+		doc.Rule(diaryMargin, pdf.PageWidth-diaryMargin, y-diaryRowHeight+4, 0.25)
+	}
+
+	return doc.Bytes()
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type DiaryShareService interface {
+	// CreateShare opens a read-only link to the diary, the vitals or both of the user over a date range. The
+	// link is only returned here.
+	CreateShare(c *fiber.Ctx, userID uuid.UUID, req *validation.CreateDiaryShare) (*model.DiaryShare, error)
+	// GetShares returns the links of the user, newest first, without their URL
+	GetShares(c *fiber.Ctx, userID uuid.UUID) ([]model.DiaryShare, error)
+	// RevokeShare closes a link of the user at once
+	RevokeShare(c *fiber.Ctx, userID, id uuid.UUID) (*model.DiaryShare, error)
+
+	// ViewShare returns what a link shows and counts the view, for whoever holds the link
+	ViewShare(c *fiber.Ctx, req *validation.Token) (*model.SharedDiary, error)
+}
+
+type diaryShareService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewDiaryShareService(db *gorm.DB, validate *validator.Validate) DiaryShareService {
+	return &diaryShareService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *diaryShareService) CreateShare(c *fiber.Ctx, userID uuid.UUID, req *validation.CreateDiaryShare) (*model.DiaryShare, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+	if !req.Diary && !req.Vitals {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Share the diary, the vitals or both")
+	}
+
+	// The link shows no more than the privacy settings let coaches see, asking for more is refused up front
+	settings, err := privacySettingsOf(s.DB.WithContext(c.UserContext()), userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Diary && !settings.CoachCanSee(model.CoachSharingSummary) {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Your privacy settings do not share your diary, set coach data sharing to summary or full first")
+	}
+	if req.Vitals && !settings.CoachCanSee(model.CoachSharingFull) {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Your privacy settings do not share your vitals, set coach data sharing to full first")
+	}
+
+	// Meals are grouped into the days of the server, as the daily summaries are
+	from, _ := time.ParseInLocation("2006-01-02", req.From, time.Local)
+	to, _ := time.ParseInLocation("2006-01-02", req.To, time.Local)
+	if to.Before(from) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+	if from.AddDate(0, 0, config.DiaryExportMaxDays-1).Before(to) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Shared range is limited to %d days", config.DiaryExportMaxDays))
+	}
+
+	now := time.Now()
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	if ttl > config.DiaryShareMaxTTL {
+		return nil, fiber.NewError(fiber.StatusBadRequest,
+			fmt.Sprintf("Share links can stay open for at most %d days", int(config.DiaryShareMaxTTL.Hours()/24)))
+	}
+
+	var active int64
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(&model.DiaryShare{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Count(&active).Error; err != nil {
+		return nil, err
+	}
+	if active >= int64(config.DiaryShareMaxActive) {
+		return nil, fiber.NewError(fiber.StatusConflict,
+			fmt.Sprintf("You already have %d open share links, revoke one first", config.DiaryShareMaxActive))
+	}
+
+	share := &model.DiaryShare{
+		UserID:    userID,
+		Label:     strings.TrimSpace(req.Label),
+		From:      from,
+		To:        to,
+		Diary:     req.Diary,
+		Vitals:    req.Vitals,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := s.DB.WithContext(c.UserContext()).Create(share).Error; err != nil {
+		return nil, err
+	}
+
+	token, err := diaryShareToken(share.ID, share.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	share.URL = fmt.Sprintf("%s/shared-diary?token=%s", strings.TrimRight(config.FrontendURL, "/"), url.QueryEscape(token))
+
+	return share, nil
+}
+
+func (s *diaryShareService) GetShares(c *fiber.Ctx, userID uuid.UUID) ([]model.DiaryShare, error) {
+	shares := []model.DiaryShare{}
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&shares).Error; err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+func (s *diaryShareService) RevokeShare(c *fiber.Ctx, userID, id uuid.UUID) (*model.DiaryShare, error) {
+	share := new(model.DiaryShare)
+	if err := s.DB.WithContext(c.UserContext()).First(share, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Share link not found")
+		}
+		return nil, err
+	}
+
+	if share.RevokedAt == nil {
+		now := time.Now()
+		if err := s.DB.WithContext(c.UserContext()).
+			Model(share).
+			Where("revoked_at IS NULL").
+			Update("revoked_at", now).Error; err != nil {
+			return nil, err
+		}
+		share.RevokedAt = &now
+	}
+
+	return share, nil
+}
+
+func (s *diaryShareService) ViewShare(c *fiber.Ctx, req *validation.Token) (*model.SharedDiary, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	// Revoked, expired and unknown links answer alike, holders of a closed link learn nothing of it
+	closed := fiber.NewError(fiber.StatusNotFound, "This share link is invalid, expired or was revoked")
+	sub, err := utils.VerifyToken(req.Token, config.JWTSecret, config.TokenTypeDiaryShare)
+	if err != nil {
+		return nil, closed
+	}
+	shareID, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, closed
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+	now := time.Now()
+
+	var share model.DiaryShare
+	if err := db.First(&share, "id = ?", shareID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, closed
+		}
+		return nil, err
+	}
+	if !share.Active(now) {
+		return nil, closed
+	}
+
+	var user model.User
+	if err := db.First(&user, "id = ?", share.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, closed
+		}
+		return nil, err
+	}
+	if user.AnonymizedAt != nil {
+		return nil, closed
+	}
+
+	// Dates read back from the database are midnight UTC, the days of the share are those of the server
+	from := time.Date(share.From.Year(), share.From.Month(), share.From.Day(), 0, 0, 0, 0, time.Local)
+	to := time.Date(share.To.Year(), share.To.Month(), share.To.Day(), 0, 0, 0, 0, time.Local)
+	end := to.AddDate(0, 0, 1)
+
+	shared := &model.SharedDiary{
+		Label:     share.Label,
+		From:      from.Format("2006-01-02"),
+		To:        to.Format("2006-01-02"),
+		ExpiresAt: share.ExpiresAt,
+		Patient:   model.SharedPatient{Name: user.Name, Gender: user.Gender},
+	}
+	if user.BirthDate != nil {
+		age := model.Age(*user.BirthDate, now)
+		shared.Patient.Age = &age
+	}
+
+	// The privacy settings of the user at the time of the view apply, lowering them narrows open links too
+	settings, err := privacySettingsOf(db, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if share.Diary && settings.CoachCanSee(model.CoachSharingSummary) {
+		var meals []model.MealHistory
+		if err := db.Where("user_id = ? AND meal_time >= ? AND meal_time < ?", user.ID, from, end).
+			Order("meal_time").
+			Find(&meals).Error; err != nil {
+			return nil, err
+		}
+		for i := range meals {
+			meals[i].MealTime = meals[i].MealTime.In(time.Local)
+		}
+		shared.Diary = model.NewSharedFood(meals, int(end.Sub(from).Hours()/24+0.5))
+		if !settings.CoachCanSee(model.CoachSharingFull) {
+			shared.Diary.OnlyTotals()
+		}
+	}
+
+	if share.Vitals && settings.CoachCanSee(model.CoachSharingFull) {
+		var records []model.UsersWeightHeightHistory
+		if err := db.Where("user_id = ? AND recorded_at >= ? AND recorded_at < ?", user.ID, from, end).
+			Order("recorded_at").
+			Find(&records).Error; err != nil {
+			return nil, err
+		}
+		shared.Vitals = make([]model.SharedVital, len(records))
+		for i, record := range records {
+			shared.Vitals[i] = model.NewSharedVital(record)
+		}
+	}
+
+	if err := db.Model(&model.DiaryShare{}).
+		Where("id = ?", share.ID).
+		Updates(map[string]any{"views": gorm.Expr("views + 1"), "last_viewed_at": now}).Error; err != nil {
+		s.Log.Errorf("Failed to count view of diary share %s: %v", share.ID, err)
+	}
+
+	return shared, nil
+}
+
+// diaryShareToken signs a share link, it expires with the share
+func diaryShareToken(shareID uuid.UUID, expiresAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"sub":  shareID.String(),
+		"iat":  time.Now().Unix(),
+		"exp":  expiresAt.Unix(),
+		"type": config.TokenTypeDiaryShare,
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.JWTSecret))
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// downloadLinkRetention is how long expired links are kept before they are purged, their attempts stay
+const downloadLinkRetention = 24 * time.Hour
+
+type DownloadLinkService interface {
+	// Issue returns a link downloading a file of the user once within DOWNLOAD_LINK_TTL, and when it expires
+	Issue(ctx context.Context, kind string, resourceID, userID uuid.UUID) (string, time.Time, error)
+	// Redeem uses up the link of the token, for whoever holds it, once serve took the file of the link. A link
+	// whose file serve fails on is not used up. Every attempt through a link is audited, served or refused.
+	Redeem(c *fiber.Ctx, req *validation.Token, serve func(link *model.DownloadLink) error) error
+	// GetAttempts returns the audited downloads of a file of the user, newest first
+	GetAttempts(ctx context.Context, kind string, resourceID, userID uuid.UUID) ([]model.DownloadAttempt, error)
+
+	// PurgeExpired deletes the links that expired a day ago
+	PurgeExpired(ctx context.Context) error
+}
+
+type downloadLinkService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewDownloadLinkService(db *gorm.DB, validate *validator.Validate) DownloadLinkService {
+	return &downloadLinkService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *downloadLinkService) Issue(ctx context.Context, kind string, resourceID, userID uuid.UUID) (string, time.Time, error) {
+	link := &model.DownloadLink{
+		Kind:       kind,
+		ResourceID: resourceID,
+		UserID:     userID,
+		ExpiresAt:  time.Now().Add(config.DownloadLinkTTL),
+	}
+	if err := s.DB.WithContext(ctx).Create(link).Error; err != nil {
+		return "", time.Time{}, err
+	}
+
+	// The token outlives the link for as long as the link is kept, so late attempts are audited as expired
+	claims := jwt.MapClaims{
+		"sub":  link.ID.String(),
+		"iat":  time.Now().Unix(),
+		"exp":  link.ExpiresAt.Add(downloadLinkRetention).Unix(),
+		"type": config.TokenTypeDownload,
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.JWTSecret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return "/v1/downloads?token=" + url.QueryEscape(token), link.ExpiresAt, nil
+}
+
+func (s *downloadLinkService) Redeem(c *fiber.Ctx, req *validation.Token, serve func(link *model.DownloadLink) error) error {
+	if err := s.Validate.Struct(req); err != nil {
+		return err
+	}
+
+	invalid := fiber.NewError(fiber.StatusNotFound, "This download link is invalid")
+	sub, err := utils.VerifyToken(req.Token, config.JWTSecret, config.TokenTypeDownload)
+	if err != nil {
+		return invalid
+	}
+	linkID, err := uuid.Parse(sub)
+	if err != nil {
+		return invalid
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+	now := time.Now()
+
+	var link model.DownloadLink
+	if err := db.First(&link, "id = ?", linkID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return invalid
+		}
+		return err
+	}
+
+	// The file is checked before the link is used up, so a link of a file that cannot be served yet still works
+	// once it can. Two requests with the same link race for it, only the one that marks it used is served.
+	outcome := link.Refusal(now)
+	var serveErr error
+	if outcome == "" {
+		if serveErr = serve(&link); serveErr != nil {
+			outcome = model.DownloadUnavailable
+		}
+	}
+	if outcome == "" {
+		result := db.Model(&model.DownloadLink{}).
+			Where("id = ? AND used_at IS NULL AND expires_at > ?", link.ID, now).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		outcome = model.DownloadServed
+		if result.RowsAffected == 0 {
+			outcome = model.DownloadAlreadyUsed
+		} else {
+			link.UsedAt = &now
+		}
+	}
+
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	if err := db.Create(&model.DownloadAttempt{
+		LinkID:     link.ID,
+		Kind:       link.Kind,
+		ResourceID: link.ResourceID,
+		UserID:     link.UserID,
+		Outcome:    outcome,
+		IPAddress:  c.IP(),
+		UserAgent:  userAgent,
+	}).Error; err != nil {
+		return err
+	}
+
+	switch outcome {
+	case model.DownloadAlreadyUsed:
+		s.Log.Warnf("Download link %s was used again from %s", link.ID, c.IP())
+		return fiber.NewError(fiber.StatusGone, "This download link was already used, request a new one")
+	case model.DownloadExpired:
+		return fiber.NewError(fiber.StatusGone, "This download link has expired, request a new one")
+	case model.DownloadUnavailable:
+		return serveErr
+	}
+
+	return nil
+}
+
+func (s *downloadLinkService) GetAttempts(ctx context.Context, kind string, resourceID, userID uuid.UUID) ([]model.DownloadAttempt, error) {
+	attempts := []model.DownloadAttempt{}
+	if err := s.DB.WithContext(ctx).
+		Where("kind = ? AND resource_id = ? AND user_id = ?", kind, resourceID, userID).
+		Order("created_at DESC").
+		Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (s *downloadLinkService) PurgeExpired(ctx context.Context) error {
+	result := s.DB.WithContext(ctx).
+		Where("expires_at < ?", time.Now().Add(-downloadLinkRetention)).
+		Delete(&model.DownloadLink{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		s.Log.Infof("Purged %d expired download links", result.RowsAffected)
+	}
+	return nil
+}
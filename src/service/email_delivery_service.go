@@ -0,0 +1,263 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/mailer"
+	"app/src/model"
+	"app/src/utils"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// emailHealthCheckInterval is how long the active provider is kept before the attempts are counted again
+	emailHealthCheckInterval = time.Minute
+	// emailRecentBounces is the number of bounces the health report lists
+	emailRecentBounces = 20
+)
+
+var errNoEmailProvider = errors.New("no email provider is configured")
+
+// ErrEmailSuppressed is returned when an email is not sent because its recipient bounced or complained before
+var ErrEmailSuppressed = errors.New("the recipient address is suppressed")
+
+type EmailDeliveryService interface {
+	// Send sends the message through the active provider, and through the other one when it fails without a
+	// bounce. Every attempt is recorded for the health of the providers. Suppressed recipients are skipped with
+	// ErrEmailSuppressed.
+	Send(ctx context.Context, message *mailer.Message) error
+	// GetHealth reports the attempts of each provider over EMAIL_HEALTH_WINDOW and the latest bounces
+	GetHealth(c *fiber.Ctx) (*model.EmailHealthReport, error)
+
+	// PurgeAttempts deletes the attempts older than EMAIL_ATTEMPT_RETENTION
+	PurgeAttempts(ctx context.Context) error
+}
+
+type emailDeliveryService struct {
+	Log              *logrus.Logger
+	DB               *gorm.DB
+	PrimaryProvider  string
+	FallbackProvider string
+	Primary          mailer.Sender
+	Fallback         mailer.Sender
+
+	mu            sync.Mutex
+	active        string
+	activeCheckAt time.Time
+}
+
+// NewEmailDeliveryService sends through EMAIL_PROVIDER and fails over to EMAIL_FALLBACK_PROVIDER
+func NewEmailDeliveryService(db *gorm.DB) EmailDeliveryService {
+	fallback := config.EmailFallbackProvider
+	if fallback == config.EmailProvider {
+		fallback = ""
+	}
+
+	return &emailDeliveryService{
+		Log:              utils.Log,
+		DB:               db,
+		PrimaryProvider:  config.EmailProvider,
+		FallbackProvider: fallback,
+		Primary:          emailSender(config.EmailProvider),
+		Fallback:         emailSender(fallback),
+	}
+}
+
+// emailSender returns nil when provider is not set or not configured
+func emailSender(provider string) mailer.Sender {
+	if provider == "" {
+		return nil
+	}
+	sender, err := mailer.New(provider, mailer.Settings{
+		SMTPHost:     config.SMTPHost,
+		SMTPPort:     config.SMTPPort,
+		SMTPUsername: config.SMTPUsername,
+		SMTPPassword: config.SMTPPassword,
+		SESRegion:    config.SESRegion,
+		SESAccessKey: config.SESAccessKey,
+		SESSecretKey: config.SESSecretKey,
+		SESEndpoint:  config.SESEndpoint,
+		Timeout:      config.EmailTimeout,
+	})
+	if err != nil {
+		utils.Log.Warnf("Email provider %s disabled: %v", provider, err)
+		return nil
+	}
+	if sender == nil {
+		utils.Log.Warnf("Email provider %s disabled: it is not configured", provider)
+	}
+	return sender
+}
+
+func (s *emailDeliveryService) Send(ctx context.Context, message *mailer.Message) error {
+	senders := s.senders(ctx)
+	if len(senders) == 0 {
+		return errNoEmailProvider
+	}
+
+	var suppressed int64
+	if err := s.DB.WithContext(ctx).
+		Model(&model.EmailSuppression{}).
+		Where("email = ?", model.NormalizeSuppressedEmail(message.To)).
+		Count(&suppressed).Error; err != nil {
+		return err
+	}
+	if suppressed > 0 {
+		return ErrEmailSuppressed
+	}
+
+	var err error
+	for _, sender := range senders {
+		err = sender.Send(ctx, message)
+		s.record(ctx, sender.Name(), message.To, err)
+		if err == nil || mailer.IsBounce(err) {
+			return err
+		}
+		s.Log.Warnf("Failed to send email through %s: %v", sender.Name(), err)
+	}
+	return err
+}
+
+// senders are the configured providers, the active one first
+func (s *emailDeliveryService) senders(ctx context.Context) []mailer.Sender {
+	var senders []mailer.Sender
+	for _, sender := range []mailer.Sender{s.Primary, s.Fallback} {
+		if sender != nil {
+			senders = append(senders, sender)
+		}
+	}
+	if len(senders) == 2 && s.activeProvider(ctx) == senders[1].Name() {
+		senders[0], senders[1] = senders[1], senders[0]
+	}
+	return senders
+}
+
+// activeProvider reads the active provider from the attempts at most once a minute. When they cannot be read the
+// last one stays active.
+func (s *emailDeliveryService) activeProvider(ctx context.Context) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(s.activeCheckAt) {
+		return s.active
+	}
+	s.activeCheckAt = now.Add(emailHealthCheckInterval)
+
+	providers, err := s.health(ctx, now.Add(-config.EmailHealthWindow))
+	if err != nil {
+		s.Log.Errorf("Failed to read the health of the email providers: %v", err)
+		return s.active
+	}
+	active := model.ActiveEmailProvider(providers[0], providers[1])
+	if s.active != "" && active != s.active {
+		s.Log.Warnf("Email fails over from %s to %s", s.active, active)
+	}
+	s.active = active
+	return s.active
+}
+
+// health rates the primary and the fallback provider from their attempts since since
+func (s *emailDeliveryService) health(ctx context.Context, since time.Time) ([]model.EmailProviderHealth, error) {
+	var rows []struct {
+		Provider string
+		Outcome  string
+		Count    int64
+	}
+	if err := s.DB.WithContext(ctx).
+		Model(&model.EmailAttempt{}).
+		Select("provider, outcome, COUNT(*) AS count").
+		Where("created_at >= ?", since).
+		Group("provider, outcome").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	outcomes := make(map[string]map[string]int64)
+	for _, row := range rows {
+		if outcomes[row.Provider] == nil {
+			outcomes[row.Provider] = make(map[string]int64)
+		}
+		outcomes[row.Provider][row.Outcome] = row.Count
+	}
+
+	thresholds := model.EmailFailoverThresholds{
+		MinAttempts:   int64(config.EmailFailoverMinAttempts),
+		MaxErrorRate:  config.EmailFailoverErrorRate,
+		MaxBounceRate: config.EmailFailoverBounceRate,
+	}
+	return []model.EmailProviderHealth{
+		model.NewEmailProviderHealth(s.PrimaryProvider, model.EmailProviderPrimary, s.Primary != nil,
+			outcomes[s.PrimaryProvider], thresholds),
+		model.NewEmailProviderHealth(s.FallbackProvider, model.EmailProviderFallback, s.Fallback != nil,
+			outcomes[s.FallbackProvider], thresholds),
+	}, nil
+}
+
+// record saves the outcome of an attempt, a failure to save it is only logged so the email is not sent twice
+func (s *emailDeliveryService) record(ctx context.Context, provider, recipient string, err error) {
+	attempt := &model.EmailAttempt{Provider: provider, Recipient: recipient, Outcome: model.EmailSent}
+	if err != nil {
+		attempt.Outcome = model.EmailFailed
+		if mailer.IsBounce(err) {
+			attempt.Outcome = model.EmailBounced
+		}
+		attempt.Error = err.Error()
+		var sendErr *mailer.Error
+		if errors.As(err, &sendErr) {
+			attempt.Code = sendErr.Code
+		}
+	}
+
+	if err := s.DB.WithContext(ctx).Create(attempt).Error; err != nil {
+		s.Log.Errorf("Failed to record the email attempt through %s: %v", provider, err)
+	}
+}
+
+func (s *emailDeliveryService) GetHealth(c *fiber.Ctx) (*model.EmailHealthReport, error) {
+	since := time.Now().Add(-config.EmailHealthWindow)
+	providers, err := s.health(c.UserContext(), since)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.EmailHealthReport{
+		Since:         since,
+		Active:        model.ActiveEmailProvider(providers[0], providers[1]),
+		RecentBounces: []model.EmailAttempt{},
+	}
+	for _, provider := range providers {
+		if provider.Provider != "" {
+			report.Providers = append(report.Providers, provider)
+		}
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("outcome = ?", model.EmailBounced).
+		Order("created_at DESC").
+		Limit(emailRecentBounces).
+		Find(&report.RecentBounces).Error; err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (s *emailDeliveryService) PurgeAttempts(ctx context.Context) error {
+	result := s.DB.WithContext(ctx).
+		Where("created_at < ?", time.Now().Add(-config.EmailAttemptRetention)).
+		Delete(&model.EmailAttempt{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		s.Log.Infof("Purged %d email attempts", result.RowsAffected)
+	}
+	return nil
+}
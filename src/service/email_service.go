@@ -2,44 +2,85 @@ package service
 
 import (
 	"app/src/config"
+	"app/src/mailer"
+	"app/src/model"
 	"app/src/utils"
+	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
-	"gopkg.in/gomail.v2"
 )
 
 type EmailService interface {
 	SendEmail(to, subject, body string) error
 	SendResetPasswordEmail(to, token string) error
 	SendVerificationEmail(to, token string) error
+	SendPaymentApprovedEmail(to, planName string, endDate time.Time) error
+	SendPaymentRejectedEmail(to, reason, retryLink string) error
+	SendPaymentReminderEmail(to, planName string, amount model.Money, paymentLink string, expiresAt time.Time, note string) error
+	SendReceiptEmail(to, planName, orderID string, amount model.Money, paidAt time.Time) error
+	SendInstallmentBillEmail(to string, installmentNumber int, amount model.Money, dueDate time.Time, paymentLink string, graceDays int) error
+	SendParentalConsentEmail(to, childName, token string, expiresAt time.Time) error
+	SendDailyTipEmail(to, title, message string) error
+	SendRenewalFailedEmail(to, planName, maskedCard string, amount model.Money, endDate time.Time) error
+	SendPlanSunsetEmail(to, planName, replacementName string, replacementPrice model.Money, endDate time.Time, autoRenew bool) error
+	SendTrialEndedEmail(to, planName string, price model.Money, endDate time.Time) error
+	SendAccountWarningEmail(to, note string) error
+	SendAccountSuspendedEmail(to, note string, suspendedUntil time.Time) error
+	SendMagicLinkEmail(to, token string, expiresAt time.Time) error
 }
 
 type emailService struct {
-	Log    *logrus.Logger
-	Dialer *gomail.Dialer
+	Log         *logrus.Logger
+	Delivery    EmailDeliveryService
+	Templates   NotificationTemplateService
+	Preferences NotificationPreferenceService
+	Experiments ExperimentService
+	Phone       PhoneService
 }
 
-func NewEmailService() EmailService {
+// NewEmailService sends the copy of the notification templates admins saved, or the built-in copy,
+// to users subscribed to the category of the notification. Users in a running notification experiment
+// get the copy of their variant, users who chose SMS or WhatsApp for the category get it on their phone.
+func NewEmailService(
+	templates NotificationTemplateService, preferences NotificationPreferenceService, experiments ExperimentService,
+	delivery EmailDeliveryService, phone PhoneService,
+) EmailService {
 	return &emailService{
-		Log: utils.Log,
-		Dialer: gomail.NewDialer(
-			config.SMTPHost,
-			config.SMTPPort,
-			config.SMTPUsername,
-			config.SMTPPassword,
-		),
+		Log:         utils.Log,
+		Delivery:    delivery,
+		Templates:   templates,
+		Preferences: preferences,
+		Experiments: experiments,
+		Phone:       phone,
 	}
 }
 
 func (s *emailService) SendEmail(to, subject, body string) error {
-	mailer := gomail.NewMessage()
-	mailer.SetHeader("From", config.EmailFrom)
-	mailer.SetHeader("To", to)
-	mailer.SetHeader("Subject", subject)
-	mailer.SetBody("text/plain", body)
+	return s.send(to, subject, body, nil)
+}
+
+func (s *emailService) send(to, subject, body string, headers map[string]string) error {
+	message := &mailer.Message{
+		From:    config.EmailFrom,
+		To:      to,
+		Subject: subject,
+		Body:    body,
+		Headers: headers,
+	}
 
-	if err := s.Dialer.DialAndSend(mailer); err != nil {
+	if err := emailBulkhead.Do(context.Background(), func() error {
+		return s.Delivery.Send(context.Background(), message)
+	}); err != nil {
+		if errors.Is(err, ErrEmailSuppressed) {
+			s.Log.Infof("Not sending email to %s, the address is suppressed", to)
+			return err
+		}
 		s.Log.Errorf("Failed to send email: %v", err)
 		return err
 	}
@@ -47,29 +88,209 @@ func (s *emailService) SendEmail(to, subject, body string) error {
 	return nil
 }
 
-func (s *emailService) SendResetPasswordEmail(to, token string) error {
-	subject := "Reset password"
+// sendTemplate renders the notification in the default locale and sends it, unless the recipient unsubscribed
+// from its category. Notifications users can unsubscribe from carry an unsubscribe link and the one-click
+// List-Unsubscribe headers. Notifications for the phone of the user fall back to email when the text fails.
+func (s *emailService) sendTemplate(to, key string, variables map[string]string) error {
+	ctx := context.Background()
+	category := model.TemplateDefinitions[key].Category
 
-	// TODO: replace this url with the link to the reset password page of your front-end app
-	resetPasswordURL := fmt.Sprintf("%s/reset-password?token=%s", config.FrontendURL, token)
-	body := fmt.Sprintf(`Dear user,
+	recipient, err := s.Preferences.Recipient(ctx, to, category)
+	if err != nil {
+		// Without the preference the email may go to someone who unsubscribed, it is not sent
+		s.Log.Errorf("Failed to check the %s preference of %s: %v", category, to, err)
+		return err
+	}
+	if !recipient.Subscribed {
+		s.Log.Infof("Not sending %s email to %s, unsubscribed from %s", key, to, category)
+		return ErrUnsubscribed
+	}
 
-To reset your password, click on this link: %s
+	notification, err := s.Templates.Render(ctx, key, model.DefaultTemplateLocale, variables)
+	if err != nil {
+		s.Log.Errorf("Failed to render %s email: %v", key, err)
+		return err
+	}
+
+	userID := recipient.UserID
+	if userID == nil {
+		return s.SendEmail(to, notification.Subject, notification.Body)
+	}
+
+	s.applyExperiment(ctx, notification, *userID, variables)
+
+	if recipient.Channel != model.NotificationChannelEmail {
+		err := s.Phone.Notify(ctx, recipient, category, notification.Subject+"\n\n"+notification.Body)
+		if err == nil {
+			return nil
+		}
+		s.Log.Warnf("Failed to send %s by %s to user %s, sending it by email: %v", key, recipient.Channel, *userID, err)
+	}
 
-If you did not request any password resets, then ignore this email.`, resetPasswordURL)
-	return s.SendEmail(to, subject, body)
+	if definition, _ := model.LookupNotificationCategory(category); definition.Required {
+		return s.SendEmail(to, notification.Subject, notification.Body)
+	}
+
+	token, err := unsubscribeToken(*userID, category)
+	if err != nil {
+		return err
+	}
+	page, oneClick := unsubscribeLinks(token)
+	body := notification.Body + "\n\n--\nBerhenti menerima email seperti ini: " + page
+
+	return s.send(to, notification.Subject, body, map[string]string{
+		"List-Unsubscribe":      "<" + oneClick + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	})
 }
 
-func (s *emailService) SendVerificationEmail(to, token string) error {
-	subject := "Email Verification"
+// applyExperiment replaces the subject or body with those of the user's variant when an experiment runs on the notification
+func (s *emailService) applyExperiment(ctx context.Context, notification *model.RenderedNotification, userID uuid.UUID, variables map[string]string) {
+	exposed, err := s.Experiments.Expose(ctx, model.ExperimentSurfaceNotification, notification.Key, userID)
+	if err != nil {
+		s.Log.Errorf("Failed to assign the %s experiment variant of %s: %v", notification.Key, userID, err)
+		return
+	}
+	if exposed == nil || len(exposed.Content) == 0 {
+		return
+	}
+
+	data := model.TemplateDefinitions[notification.Key].Data(variables)
+	subject, body, err := model.RenderTemplate(exposed.Content["subject"], exposed.Content["body"], data)
+	if err != nil {
+		s.Log.Errorf("Variant %s of experiment %s does not render, sending the regular copy: %v", exposed.Variant, exposed.Experiment, err)
+		return
+	}
+	if subject != "" {
+		notification.Subject = subject
+	}
+	if body != "" {
+		notification.Body = body
+	}
+}
+
+func (s *emailService) SendResetPasswordEmail(to, token string) error {
+	// TODO: replace this url with the link to the reset password page of your front-end app
+	return s.sendTemplate(to, model.TemplateResetPassword, map[string]string{
+		"reset_password_url": fmt.Sprintf("%s/reset-password?token=%s", config.FrontendURL, token),
+	})
+}
 
+func (s *emailService) SendVerificationEmail(to, token string) error {
 	// TODO: replace this url with the link to the email verification page of your front-end app
-	verificationEmailURL := fmt.Sprintf("%s/verify-email?token=%s", config.FrontendURL, token)
-	body := fmt.Sprintf(`Pengguna yang terhormat,
+	return s.sendTemplate(to, model.TemplateVerifyEmail, map[string]string{
+		"verification_url": fmt.Sprintf("%s/verify-email?token=%s", config.FrontendURL, token),
+	})
+}
+
+func (s *emailService) SendPaymentApprovedEmail(to, planName string, endDate time.Time) error {
+	return s.sendTemplate(to, model.TemplatePaymentApproved, map[string]string{
+		"plan_name": planName,
+		"end_date":  endDate.Format("02 January 2006"),
+	})
+}
+
+func (s *emailService) SendPaymentRejectedEmail(to, reason, retryLink string) error {
+	return s.sendTemplate(to, model.TemplatePaymentRejected, map[string]string{
+		"reason":     reason,
+		"retry_link": retryLink,
+	})
+}
+
+func (s *emailService) SendPaymentReminderEmail(to, planName string, amount model.Money, paymentLink string, expiresAt time.Time, note string) error {
+	return s.sendTemplate(to, model.TemplatePaymentReminder, map[string]string{
+		"plan_name":    planName,
+		"amount":       amount.String(),
+		"payment_link": paymentLink,
+		"expires_at":   expiresAt.Format("02 January 2006 15:04"),
+		"note":         note,
+	})
+}
+
+func (s *emailService) SendReceiptEmail(to, planName, orderID string, amount model.Money, paidAt time.Time) error {
+	return s.sendTemplate(to, model.TemplateReceipt, map[string]string{
+		"order_id":  orderID,
+		"plan_name": planName,
+		"amount":    amount.String(),
+		"paid_at":   paidAt.Format("02 January 2006 15:04"),
+	})
+}
+
+func (s *emailService) SendInstallmentBillEmail(to string, installmentNumber int, amount model.Money, dueDate time.Time, paymentLink string, graceDays int) error {
+	return s.sendTemplate(to, model.TemplateInstallmentBill, map[string]string{
+		"installment_number": strconv.Itoa(installmentNumber),
+		"amount":             amount.String(),
+		"due_date":           dueDate.Format("02 January 2006"),
+		"payment_link":       paymentLink,
+		"grace_days":         strconv.Itoa(graceDays),
+	})
+}
 
-Silakan klik tautan di bawah ini untuk memverifikasi alamat email Anda:
-%s
+func (s *emailService) SendParentalConsentEmail(to, childName, token string, expiresAt time.Time) error {
+	return s.sendTemplate(to, model.TemplateParentalConsent, map[string]string{
+		"child_name":  childName,
+		"consent_url": fmt.Sprintf("%s/parental-consent?token=%s", config.FrontendURL, token),
+		"expires_at":  expiresAt.Format("02 January 2006 15:04"),
+	})
+}
+
+func (s *emailService) SendMagicLinkEmail(to, token string, expiresAt time.Time) error {
+	return s.sendTemplate(to, model.TemplateMagicLink, map[string]string{
+		"login_url":  fmt.Sprintf("%s/magic-link?token=%s", config.FrontendURL, token),
+		"expires_at": expiresAt.Format("02 January 2006 15:04"),
+	})
+}
+
+func (s *emailService) SendDailyTipEmail(to, title, message string) error {
+	return s.sendTemplate(to, model.TemplateDailyTip, map[string]string{
+		"title":   title,
+		"message": message,
+	})
+}
+
+func (s *emailService) SendRenewalFailedEmail(to, planName, maskedCard string, amount model.Money, endDate time.Time) error {
+	return s.sendTemplate(to, model.TemplateRenewalFailed, map[string]string{
+		"plan_name":   planName,
+		"masked_card": maskedCard,
+		"amount":      amount.String(),
+		"end_date":    endDate.Format("02 January 2006"),
+	})
+}
+
+func (s *emailService) SendPlanSunsetEmail(
+	to, planName, replacementName string, replacementPrice model.Money, endDate time.Time, autoRenew bool,
+) error {
+	note := "Anda dapat memperpanjang langganan dengan paket baru kapan saja melalui aplikasi Nutribox."
+	if autoRenew {
+		note = "Perpanjangan otomatis Anda akan menagih kartu Anda sebesar harga paket baru."
+	}
+	return s.sendTemplate(to, model.TemplatePlanSunset, map[string]string{
+		"plan_name":         planName,
+		"replacement_name":  replacementName,
+		"replacement_price": replacementPrice.String(),
+		"end_date":          endDate.Format("02 January 2006"),
+		"renewal_note":      note,
+	})
+}
+
+func (s *emailService) SendTrialEndedEmail(to, planName string, price model.Money, endDate time.Time) error {
+	return s.sendTemplate(to, model.TemplateTrialEnded, map[string]string{
+		"plan_name": planName,
+		"price":     price.String(),
+		"end_date":  endDate.Format("02 January 2006"),
+		"plans_url": fmt.Sprintf("%s/plans", strings.TrimRight(config.FrontendURL, "/")),
+	})
+}
+
+func (s *emailService) SendAccountWarningEmail(to, note string) error {
+	return s.sendTemplate(to, model.TemplateAccountWarning, map[string]string{
+		"note": note,
+	})
+}
 
-Apabila Anda tidak merasa membuat akun dengan email ini, mohon abaikan pesan ini.`, verificationEmailURL)
-	return s.SendEmail(to, subject, body)
+func (s *emailService) SendAccountSuspendedEmail(to, note string, suspendedUntil time.Time) error {
+	return s.sendTemplate(to, model.TemplateAccountSuspended, map[string]string{
+		"note":            note,
+		"suspended_until": suspendedUntil.Format("02 January 2006 15:04"),
+	})
 }
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/mailer"
+	"app/src/model"
+	"app/src/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type EmailSuppressionService interface {
+	// HandleSESNotification takes an SNS message of the SES feedback topic: it confirms the subscription, and
+	// suppresses the addresses that bounced for good or complained, flagging their users
+	HandleSESNotification(c *fiber.Ctx, body []byte) error
+	// Lift sends to the email of the user again, once an admin checked it can receive mail
+	Lift(c *fiber.Ctx, userID uuid.UUID) (*model.User, error)
+}
+
+type emailSuppressionService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	HTTP     *http.Client
+	Verifier *mailer.SNSVerifier
+}
+
+func NewEmailSuppressionService(db *gorm.DB) EmailSuppressionService {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return &emailSuppressionService{
+		Log:      utils.Log,
+		DB:       db,
+		HTTP:     client,
+		Verifier: mailer.NewSNSVerifier(client),
+	}
+}
+
+func (s *emailSuppressionService) HandleSESNotification(c *fiber.Ctx, body []byte) error {
+	message := new(mailer.SNSMessage)
+	if err := json.Unmarshal(body, message); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid notification")
+	}
+
+	if config.SESNotificationTopicArn == "" || message.TopicArn != config.SESNotificationTopicArn {
+		return fiber.NewError(fiber.StatusForbidden, "Notification belongs to another topic")
+	}
+	if err := s.Verifier.Verify(c.UserContext(), message); err != nil {
+		s.Log.Errorf("Rejected SES notification: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid notification")
+	}
+
+	switch message.Type {
+	case mailer.SNSSubscriptionConfirmation:
+		return s.confirmSubscription(c.UserContext(), message)
+	case mailer.SNSNotification:
+	default:
+		return nil
+	}
+
+	feedback, err := mailer.ParseSESNotification(message.Message)
+	if err != nil {
+		s.Log.Errorf("Rejected SES notification %s: %v", message.MessageID, err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid notification")
+	}
+
+	for _, report := range feedback {
+		if !report.Permanent {
+			s.Log.Infof("Transient %s for %s: %s", report.Kind, report.Email, report.Detail)
+			continue
+		}
+		if err := s.suppress(c.UserContext(), report); err != nil {
+			s.Log.Errorf("Failed to suppress %s after a %s: %v", report.Email, report.Kind, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// confirmSubscription visits the link SNS sends when the topic is subscribed to the endpoint
+func (s *emailSuppressionService) confirmSubscription(ctx context.Context, message *mailer.SNSMessage) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, message.SubscribeURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.HTTP.Do(req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadGateway, "Failed to confirm the subscription")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fiber.NewError(fiber.StatusBadGateway, fmt.Sprintf("Failed to confirm the subscription: %d", resp.StatusCode))
+	}
+
+	s.Log.Infof("Confirmed the subscription of %s", message.TopicArn)
+	return nil
+}
+
+// suppress stops sending to the address of the report and flags the users with that email
+func (s *emailSuppressionService) suppress(ctx context.Context, report mailer.Feedback) error {
+	email := model.NormalizeSuppressedEmail(report.Email)
+	if email == "" {
+		return nil
+	}
+
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		suppression := new(model.EmailSuppression)
+		err := tx.Where("email = ?", email).Take(suppression).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			suppression = &model.EmailSuppression{Email: email, Reason: report.Kind}
+		case err != nil:
+			return err
+		}
+		suppression.Reason = model.SuppressionReason(suppression.Reason, report.Kind)
+		suppression.Detail = report.Detail
+		suppression.Provider = mailer.ProviderSES
+		suppression.Count++
+		if err := tx.Save(suppression).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.User{}).
+			Where("LOWER(email) = ?", email).
+			Updates(map[string]any{
+				"email_undeliverable_at":     time.Now(),
+				"email_undeliverable_reason": suppression.Reason,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		s.Log.Warnf("Suppressed %s after a %s, %d users flagged", email, report.Kind, result.RowsAffected)
+		return nil
+	})
+}
+
+func (s *emailSuppressionService) Lift(c *fiber.Ctx, userID uuid.UUID) (*model.User, error) {
+	user := new(model.User)
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(user, "id = ?", userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, "User not found")
+			}
+			return err
+		}
+
+		result := tx.Where("email = ?", model.NormalizeSuppressedEmail(user.Email)).Delete(&model.EmailSuppression{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 && user.EmailUndeliverableAt == nil {
+			return fiber.NewError(fiber.StatusConflict, "Email is not suppressed")
+		}
+
+		return syncEmailDeliverability(tx, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// syncEmailDeliverability flags user when their email is suppressed and clears the flag otherwise, after the
+// email changed or its suppression was lifted
+func syncEmailDeliverability(tx *gorm.DB, user *model.User) error {
+	suppression := new(model.EmailSuppression)
+	err := tx.Where("email = ?", model.NormalizeSuppressedEmail(user.Email)).Take(suppression).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	suppressed := err == nil
+
+	updates := map[string]any{"email_undeliverable_at": nil, "email_undeliverable_reason": ""}
+	if suppressed {
+		updates = map[string]any{
+			"email_undeliverable_at":     suppression.UpdatedAt,
+			"email_undeliverable_reason": suppression.Reason,
+		}
+	}
+	if err := tx.Model(&model.User{}).Where("id = ?", user.ID).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	user.EmailUndeliverableAt = nil
+	user.EmailUndeliverableReason = ""
+	if suppressed {
+		user.EmailUndeliverableAt = &suppression.UpdatedAt
+		user.EmailUndeliverableReason = suppression.Reason
+	}
+	return nil
+}
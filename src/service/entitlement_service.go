@@ -0,0 +1,236 @@
+package service
+
+import (
+	"app/src/clock"
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type EntitlementService interface {
+	// Diagnose walks the checks a scan request of the user goes through, with the state each one reads,
+	// without changing anything
+	Diagnose(c *fiber.Ctx, userID uuid.UUID) (*model.EntitlementDiagnosis, error)
+}
+
+type entitlementService struct {
+	Log         *logrus.Logger
+	DB          *gorm.DB
+	ScanQuota   ScanQuotaService
+	Maintenance MaintenanceService
+}
+
+func NewEntitlementService(db *gorm.DB, scanQuota ScanQuotaService, maintenance MaintenanceService) EntitlementService {
+	return &entitlementService{
+		Log:         utils.Log,
+		DB:          db,
+		ScanQuota:   scanQuota,
+		Maintenance: maintenance,
+	}
+}
+
+func (s *entitlementService) Diagnose(c *fiber.Ctx, userID uuid.UUID) (*model.EntitlementDiagnosis, error) {
+	db := s.DB.WithContext(c.UserContext())
+	now := clock.Now(c.UserContext())
+
+	var user model.User
+	if err := db.Select("id").First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
+		}
+		return nil, err
+	}
+
+	diagnosis := &model.EntitlementDiagnosis{
+		UserID:        userID,
+		CheckedAt:     now,
+		Subscriptions: []model.SubscriptionCandidate{},
+	}
+
+	// The maintenance middleware reads a cached status, which is what users are answered with
+	if enabled, _ := s.Maintenance.IsEnabled(c.UserContext()); enabled {
+		diagnosis.AddCheck(model.EntitlementMaintenance, false, "Maintenance mode is on, user routes answer 503")
+	} else {
+		diagnosis.AddCheck(model.EntitlementMaintenance, true, "Maintenance mode is off")
+	}
+
+	if err := s.checkProductToken(c, diagnosis); err != nil {
+		return nil, err
+	}
+
+	subscription, err := s.resolveSubscription(c, diagnosis)
+	if err != nil {
+		return nil, err
+	}
+	if subscription != nil {
+		plan := subscription.PurchasedPlan()
+		diagnosis.Plan = &model.PlanEntitlement{
+			ID:          plan.ID,
+			Name:        plan.Name,
+			IsActive:    plan.IsActive,
+			AIscanLimit: plan.AIscanLimit,
+			Features:    plan.Features.Map(),
+		}
+	}
+
+	if err := s.checkScanQuota(c, diagnosis, subscription); err != nil {
+		return nil, err
+	}
+	return diagnosis, nil
+}
+
+// checkProductToken mirrors the auth middleware, which deletes an expired token on the user's next request
+func (s *entitlementService) checkProductToken(c *fiber.Ctx, diagnosis *model.EntitlementDiagnosis) error {
+	var token model.ProductToken
+	result := s.DB.WithContext(c.UserContext()).
+		Where("user_id = ?", diagnosis.UserID).
+		Limit(1).
+		Find(&token)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		diagnosis.AddCheck(model.EntitlementProductToken, false, "No product token is activated, requests are rejected with 403")
+		return nil
+	}
+
+	entitlement := &model.ProductTokenEntitlement{
+		TokenID:     token.ID,
+		ActivatedAt: token.ActivatedAt,
+	}
+	diagnosis.ProductToken = entitlement
+
+	expDays, err := strconv.Atoi(config.ProductTokenExpDays)
+	if err != nil {
+		diagnosis.AddCheck(model.EntitlementProductToken, false, "PRODUCT_TOKEN_EXP_DAYS is not a number, every request fails with 500")
+		return nil
+	}
+	if token.ActivatedAt == nil {
+		diagnosis.AddCheck(model.EntitlementProductToken, false, "The product token has no activation time")
+		return nil
+	}
+
+	expiresAt := token.ActivatedAt.Add(time.Duration(expDays) * 24 * time.Hour)
+	entitlement.ExpiresAt = &expiresAt
+	entitlement.Expired = diagnosis.CheckedAt.After(expiresAt)
+	if entitlement.Expired {
+		diagnosis.AddCheck(model.EntitlementProductToken, false,
+			fmt.Sprintf("The product token expired on %s, it is deleted on the next request", expiresAt.Format(time.RFC3339)))
+		return nil
+	}
+
+	diagnosis.AddCheck(model.EntitlementProductToken, true, fmt.Sprintf("Valid until %s", expiresAt.Format(time.RFC3339)))
+	return nil
+}
+
+// resolveSubscription lists every subscription of the user with why it does or does not qualify,
+// and selects the one the active subscription lookup returns
+func (s *entitlementService) resolveSubscription(c *fiber.Ctx, diagnosis *model.EntitlementDiagnosis) (*model.UserSubscription, error) {
+	db := s.DB.WithContext(c.UserContext())
+
+	var subscriptions []model.UserSubscription
+	if err := db.Preload("Plan").
+		Where("user_id = ?", diagnosis.UserID).
+		Order("end_date DESC").
+		Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+
+	var active model.UserSubscription
+	result := db.Scopes(activeSubscription(diagnosis.UserID)).Limit(1).Find(&active)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var selected *model.UserSubscription
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		candidate := model.SubscriptionCandidate{
+			ID:            subscription.ID,
+			PlanID:        subscription.PlanID,
+			PlanName:      subscription.Plan.Name,
+			Source:        subscription.Source,
+			PaymentStatus: subscription.PaymentStatus,
+			IsActive:      subscription.IsActive,
+			StartDate:     subscription.StartDate,
+			EndDate:       subscription.EndDate,
+			AIscansUsed:   subscription.AIscansUsed,
+			Selected:      result.RowsAffected > 0 && subscription.ID == active.ID,
+			Rejections:    model.SubscriptionRejections(subscription, diagnosis.CheckedAt),
+		}
+		if candidate.Selected {
+			selected = subscription
+		}
+		diagnosis.Subscriptions = append(diagnosis.Subscriptions, candidate)
+	}
+
+	// Scans do not need a subscription, without one they are not counted
+	switch {
+	case selected != nil:
+		diagnosis.AddCheck(model.EntitlementActiveSubscription, true,
+			fmt.Sprintf("Subscription %s on plan %s until %s", selected.ID, selected.Plan.Name, selected.EndDate.Format(time.RFC3339)))
+	case len(subscriptions) == 0:
+		diagnosis.AddCheck(model.EntitlementActiveSubscription, true, "The user never subscribed, scans are not counted")
+	default:
+		diagnosis.AddCheck(model.EntitlementActiveSubscription, true,
+			fmt.Sprintf("None of the %d subscriptions qualifies, see their rejections, scans are not counted", len(subscriptions)))
+	}
+
+	return selected, nil
+}
+
+// checkScanQuota shows the scans pending in Redis beside the database count, and takes whether the user can scan
+// from the scan quota service, which the scan quota middleware asks
+func (s *entitlementService) checkScanQuota(
+	c *fiber.Ctx, diagnosis *model.EntitlementDiagnosis, subscription *model.UserSubscription,
+) error {
+	quota, allowed, err := s.ScanQuota.Allowed(c, diagnosis.UserID)
+	if err != nil {
+		return err
+	}
+	if quota == nil {
+		diagnosis.AddCheck(model.EntitlementScanQuota, allowed, "No active subscription to count scans against, scans are not limited")
+		return nil
+	}
+
+	entitlement := &model.ScanQuotaEntitlement{
+		Limit:          quota.Limit,
+		UsedInDatabase: subscription.AIscansUsed,
+		CacheState:     model.ScanCacheOK,
+		Used:           quota.Used,
+		Remaining:      quota.Remaining(),
+		Exhausted:      quota.Exhausted(),
+	}
+	diagnosis.Quota = entitlement
+
+	pending, enabled, err := s.ScanQuota.Pending(c.UserContext(), quota.SubscriptionID)
+	switch {
+	case !enabled:
+		entitlement.CacheState = model.ScanCacheDisabled
+	case err != nil:
+		// Scans are then counted in the database, which holds every scan but the unflushed ones
+		entitlement.CacheState = fmt.Sprintf("%s: %v", model.ScanCacheUnavailable, err)
+	default:
+		entitlement.PendingInCache = pending
+	}
+
+	switch {
+	case quota.IsUnlimited():
+		diagnosis.AddCheck(model.EntitlementScanQuota, allowed, "The plan has unlimited scans")
+	case !allowed:
+		diagnosis.AddCheck(model.EntitlementScanQuota, allowed,
+			fmt.Sprintf("All %d scans are used, scans are rejected with scan_quota_exceeded", quota.Limit))
+	default:
+		diagnosis.AddCheck(model.EntitlementScanQuota, allowed, fmt.Sprintf("%d of %d scans left", quota.Remaining(), quota.Limit))
+	}
+	return nil
+}
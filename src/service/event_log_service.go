@@ -0,0 +1,176 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/requestid"
+	"app/src/utils"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// replayBatchSize is the number of users whose events are loaded and projected at once
+const replayBatchSize = 500
+
+type EventLogService interface {
+	// Replay rebuilds a derived table from the event log and replaces what it holds.
+	// Changes made while it runs can be counted twice, it is meant to run when traffic is low.
+	Replay(ctx context.Context, projection string) (*model.EventReplay, error)
+}
+
+type eventLogService struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewEventLogService(db *gorm.DB) EventLogService {
+	return &eventLogService{
+		Log: utils.Log,
+		DB:  db,
+	}
+}
+
+func (s *eventLogService) Replay(ctx context.Context, projection string) (*model.EventReplay, error) {
+	if !slices.Contains(model.Projections, projection) {
+		return nil, fiber.NewError(fiber.StatusBadRequest,
+			fmt.Sprintf("Unknown projection, expected one of: %s", strings.Join(model.Projections, ", ")))
+	}
+
+	replay := &model.EventReplay{
+		Projection: projection,
+		StartedAt:  time.Now(),
+	}
+
+	if err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if projection == model.ProjectionOnboarding {
+			return s.replayOnboarding(tx, replay)
+		}
+		return s.replayUserCounters(tx, replay)
+	}); err != nil {
+		return nil, err
+	}
+
+	replay.DurationMs = time.Since(replay.StartedAt).Milliseconds()
+	s.Log.Infof("Replayed %d events into %s of %d users in %dms", replay.Events, projection, replay.Users, replay.DurationMs)
+	return replay, nil
+}
+
+// replayUserCounters recomputes the counters of every user from their events, users without events have none to count
+func (s *eventLogService) replayUserCounters(tx *gorm.DB, replay *model.EventReplay) error {
+	since := streakCutoff(replay.StartedAt)
+
+	var users []model.User
+	return tx.Select("id").FindInBatches(&users, replayBatchSize, func(batch *gorm.DB, _ int) error {
+		ids := make([]uuid.UUID, len(users))
+		for i, user := range users {
+			ids[i] = user.ID
+		}
+
+		var events []model.DomainEvent
+		if err := tx.Where("user_id IN ?", ids).Order("sequence").Find(&events).Error; err != nil {
+			return err
+		}
+
+		projections := make(map[uuid.UUID]*model.UserCounterProjection, len(ids))
+		for _, id := range ids {
+			projections[id] = model.NewUserCounterProjection()
+		}
+		for i := range events {
+			if err := projections[events[i].UserID].Apply(&events[i]); err != nil {
+				return fmt.Errorf("failed to apply event %d: %w", events[i].Sequence, err)
+			}
+		}
+
+		for id, projection := range projections {
+			counters := projection.Counters(since)
+			if err := tx.Model(&model.User{}).Where("id = ?", id).Updates(map[string]any{
+				"total_scans":       counters.TotalScans,
+				"total_logged_days": counters.TotalLoggedDays,
+				"current_streak":    counters.CurrentStreak,
+				"lifetime_spend":    counters.LifetimeSpend,
+				"counters_at":       replay.StartedAt,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		replay.Events += len(events)
+		replay.Users += len(ids)
+		return nil
+	}).Error
+}
+
+// replayOnboarding rebuilds the onboarding of every user from their events
+func (s *eventLogService) replayOnboarding(tx *gorm.DB, replay *model.EventReplay) error {
+	if err := tx.Where("1 = 1").Delete(&model.UserOnboarding{}).Error; err != nil {
+		return err
+	}
+
+	var users []model.User
+	return tx.Select("id", "birth_date", "height", "weight", "gender", "activity_level", "updated_at").
+		FindInBatches(&users, replayBatchSize, func(batch *gorm.DB, _ int) error {
+			ids := make([]uuid.UUID, len(users))
+			for i, user := range users {
+				ids[i] = user.ID
+			}
+
+			var events []model.DomainEvent
+			if err := tx.Where("user_id IN ? AND type IN ?", ids, model.OnboardingEventTypes).
+				Order("sequence").
+				Find(&events).Error; err != nil {
+				return err
+			}
+
+			userEvents := make(map[uuid.UUID][]model.DomainEvent, len(ids))
+			for _, event := range events {
+				userEvents[event.UserID] = append(userEvents[event.UserID], event)
+			}
+
+			onboardings := make([]*model.UserOnboarding, len(users))
+			for i := range users {
+				onboarding, err := model.BuildUserOnboarding(&users[i], userEvents[users[i].ID])
+				if err != nil {
+					return fmt.Errorf("failed to apply the events of user %s: %w", users[i].ID, err)
+				}
+				onboardings[i] = onboarding
+			}
+			if err := tx.Create(onboardings).Error; err != nil {
+				return err
+			}
+
+			replay.Events += len(events)
+			replay.Users += len(ids)
+			return nil
+		}).Error
+}
+
+// appendEvent adds an event to the log, with the request ID of the context of db.
+// Callers pass the transaction of the change the event describes when they have one.
+func appendEvent(db *gorm.DB, eventType string, userID, subjectID uuid.UUID, payload model.EventPayload, occurredAt time.Time) error {
+	event, err := model.NewDomainEvent(eventType, userID, subjectID, payload, occurredAt)
+	if err != nil {
+		return err
+	}
+	if db.Statement.Context != nil {
+		event.RequestID = requestid.From(db.Statement.Context)
+	}
+
+	if err := db.Create(event).Error; err != nil {
+		return err
+	}
+
+	// The event is what matters to the caller, a failed onboarding update is corrected by a replay
+	if slices.Contains(model.OnboardingEventTypes, eventType) {
+		if err := applyOnboardingEvent(db, event); err != nil {
+			utils.Log.Errorf("Failed to apply %s event %d to the onboarding of user %s: %v", eventType, event.Sequence, userID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExperimentService interface {
+	GetExperiments(c *fiber.Ctx) ([]model.Experiment, error)
+	CreateExperiment(c *fiber.Ctx, adminID uuid.UUID, req *validation.CreateExperiment) (*model.Experiment, error)
+	// StartExperiment starts assigning variants, one experiment runs at a time on a surface and target
+	StartExperiment(c *fiber.Ctx, experimentID uuid.UUID) (*model.Experiment, error)
+	// StopExperiment stops assigning variants, everyone sees the regular content again and results stay readable
+	StopExperiment(c *fiber.Ctx, experimentID uuid.UUID) (*model.Experiment, error)
+	GetResults(c *fiber.Ctx, experimentID uuid.UUID) (*model.ExperimentResults, error)
+
+	// Expose returns the variant of the user in the experiment running on the surface and target and logs
+	// the exposure. It returns nil when no experiment runs there.
+	Expose(ctx context.Context, surface, target string, userID uuid.UUID) (*model.ExposedVariant, error)
+	// ExposePaywall exposes the signed in user of a public request to the paywall experiment,
+	// anonymous requests see the regular copy
+	ExposePaywall(c *fiber.Ctx) (*model.ExposedVariant, error)
+}
+
+type experimentService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewExperimentService(db *gorm.DB, validate *validator.Validate) ExperimentService {
+	return &experimentService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *experimentService) GetExperiments(c *fiber.Ctx) ([]model.Experiment, error) {
+	var experiments []model.Experiment
+	if err := s.DB.WithContext(c.UserContext()).Order("created_at DESC").Find(&experiments).Error; err != nil {
+		return nil, err
+	}
+
+	return experiments, nil
+}
+
+func (s *experimentService) CreateExperiment(c *fiber.Ctx, adminID uuid.UUID, req *validation.CreateExperiment) (*model.Experiment, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	experiment := &model.Experiment{
+		Key:         strings.TrimSpace(req.Key),
+		Description: req.Description,
+		Surface:     req.Surface,
+		Target:      req.Target,
+		Status:      model.ExperimentDraft,
+		CreatedByID: adminID,
+	}
+
+	seen := make(map[string]bool, len(req.Variants))
+	for _, variant := range req.Variants {
+		if seen[variant.Key] {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Variant %s is listed twice", variant.Key))
+		}
+		seen[variant.Key] = true
+		experiment.Variants = append(experiment.Variants, model.ExperimentVariant{
+			Key:     variant.Key,
+			Weight:  variant.Weight,
+			Content: variant.Content,
+		})
+	}
+
+	switch req.Surface {
+	case model.ExperimentSurfacePaywall:
+		experiment.Target = model.ExperimentPaywallTarget
+	case model.ExperimentSurfaceNotification:
+		definition, ok := model.TemplateDefinitions[req.Target]
+		if !ok {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "The target of a notification experiment is a notification template key")
+		}
+		for _, variant := range experiment.Variants {
+			for field := range variant.Content {
+				if field != "subject" && field != "body" {
+					return nil, fiber.NewError(fiber.StatusBadRequest,
+						fmt.Sprintf("Variant %s of a notification can only change subject and body", variant.Key))
+				}
+			}
+			if _, _, err := model.RenderTemplate(variant.Content["subject"], variant.Content["body"], definition.Variables); err != nil {
+				return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Variant %s: %v", variant.Key, err))
+			}
+		}
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Create(experiment).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, fiber.NewError(fiber.StatusConflict, "Experiment key already exists")
+		}
+		return nil, err
+	}
+
+	return experiment, nil
+}
+
+func (s *experimentService) StartExperiment(c *fiber.Ctx, experimentID uuid.UUID) (*model.Experiment, error) {
+	experiment, err := s.getExperiment(c, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	if experiment.Status != model.ExperimentDraft {
+		return nil, fiber.NewError(fiber.StatusConflict, "Only draft experiments can be started")
+	}
+
+	var running int64
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(&model.Experiment{}).
+		Where("surface = ? AND target = ? AND status = ?", experiment.Surface, experiment.Target, model.ExperimentRunning).
+		Count(&running).Error; err != nil {
+		return nil, err
+	}
+	if running > 0 {
+		return nil, fiber.NewError(fiber.StatusConflict, "Another experiment is running on the same target, stop it first")
+	}
+
+	now := time.Now()
+	experiment.Status = model.ExperimentRunning
+	experiment.StartedAt = &now
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(experiment).
+		Select("Status", "StartedAt").
+		Updates(experiment).Error; err != nil {
+		return nil, err
+	}
+
+	return experiment, nil
+}
+
+func (s *experimentService) StopExperiment(c *fiber.Ctx, experimentID uuid.UUID) (*model.Experiment, error) {
+	experiment, err := s.getExperiment(c, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	if experiment.Status != model.ExperimentRunning {
+		return nil, fiber.NewError(fiber.StatusConflict, "Only running experiments can be stopped")
+	}
+
+	now := time.Now()
+	experiment.Status = model.ExperimentStopped
+	experiment.StoppedAt = &now
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(experiment).
+		Select("Status", "StoppedAt").
+		Updates(experiment).Error; err != nil {
+		return nil, err
+	}
+
+	return experiment, nil
+}
+
+func (s *experimentService) GetResults(c *fiber.Ctx, experimentID uuid.UUID) (*model.ExperimentResults, error) {
+	experiment, err := s.getExperiment(c, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	db := s.DB.WithContext(c.UserContext())
+
+	var exposures []struct {
+		Variant   string
+		Users     int64
+		Exposures int64
+	}
+	if err := db.Model(&model.ExperimentAssignment{}).
+		Select("variant, COUNT(*) AS users, COALESCE(SUM(exposures), 0) AS exposures").
+		Where("experiment_id = ?", experimentID).
+		Group("variant").
+		Scan(&exposures).Error; err != nil {
+		return nil, err
+	}
+
+	var conversions []struct {
+		Variant string
+		Event   string
+		Users   int64
+		Count   int64
+		Value   int64
+	}
+	if err := db.Model(&model.ExperimentConversion{}).
+		Select("variant, event, COUNT(DISTINCT user_id) AS users, COUNT(*) AS count, COALESCE(SUM(value), 0) AS value").
+		Where("experiment_id = ?", experimentID).
+		Group("variant, event").
+		Scan(&conversions).Error; err != nil {
+		return nil, err
+	}
+
+	results := &model.ExperimentResults{Experiment: *experiment}
+	stats := make(map[string]*model.ExperimentVariantStat, len(experiment.Variants))
+	for _, variant := range experiment.Variants {
+		results.Variants = append(results.Variants, model.ExperimentVariantStat{
+			Variant:     variant.Key,
+			Conversions: map[string]model.ConversionSummary{},
+		})
+	}
+	for i := range results.Variants {
+		stats[results.Variants[i].Variant] = &results.Variants[i]
+	}
+
+	for _, row := range exposures {
+		if stat, ok := stats[row.Variant]; ok {
+			stat.Users = row.Users
+			stat.Exposures = row.Exposures
+		}
+	}
+	for _, row := range conversions {
+		stat, ok := stats[row.Variant]
+		if !ok {
+			continue
+		}
+		summary := model.ConversionSummary{Users: row.Users, Count: row.Count, Value: row.Value}
+		if stat.Users > 0 {
+			summary.Rate = float64(row.Users) / float64(stat.Users)
+		}
+		stat.Conversions[row.Event] = summary
+	}
+
+	return results, nil
+}
+
+func (s *experimentService) Expose(ctx context.Context, surface, target string, userID uuid.UUID) (*model.ExposedVariant, error) {
+	var experiment model.Experiment
+	result := s.DB.WithContext(ctx).
+		Where("surface = ? AND target = ? AND status = ?", surface, target, model.ExperimentRunning).
+		Limit(1).
+		Find(&experiment)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+
+	variant := experiment.AssignVariant(userID)
+	if variant == nil {
+		return nil, nil
+	}
+
+	// A user keeps the variant of their first exposure, even when the weights were changed since
+	now := time.Now()
+	assignment := model.ExperimentAssignment{
+		ExperimentID:   experiment.ID,
+		UserID:         userID,
+		Variant:        variant.Key,
+		Exposures:      1,
+		FirstExposedAt: now,
+		LastExposedAt:  now,
+	}
+	if err := s.DB.WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "experiment_id"}, {Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"exposures":       gorm.Expr("experiment_assignments.exposures + 1"),
+				"last_exposed_at": now,
+			}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "variant"}}},
+	).Create(&assignment).Error; err != nil {
+		return nil, err
+	}
+
+	exposed := &model.ExposedVariant{Experiment: experiment.Key, Variant: assignment.Variant}
+	if assigned := experiment.Variant(assignment.Variant); assigned != nil {
+		exposed.Content = assigned.Content
+	}
+	return exposed, nil
+}
+
+func (s *experimentService) ExposePaywall(c *fiber.Ctx) (*model.ExposedVariant, error) {
+	userID := optionalUserID(c)
+	if userID == nil {
+		return nil, nil
+	}
+
+	return s.Expose(c.UserContext(), model.ExperimentSurfacePaywall, model.ExperimentPaywallTarget, *userID)
+}
+
+// optionalUserID is the user of a valid access token on a route where signing in is optional, nil without one
+func optionalUserID(c *fiber.Ctx) *uuid.UUID {
+	token := strings.TrimSpace(strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "))
+	if token == "" {
+		return nil
+	}
+	sub, err := utils.VerifyToken(token, config.JWTSecret, config.TokenTypeAccess)
+	if err != nil {
+		return nil
+	}
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return nil
+	}
+	return &userID
+}
+
+func (s *experimentService) getExperiment(c *fiber.Ctx, experimentID uuid.UUID) (*model.Experiment, error) {
+	var experiment model.Experiment
+	if err := s.DB.WithContext(c.UserContext()).First(&experiment, "id = ?", experimentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Experiment not found")
+		}
+		return nil, err
+	}
+
+	return &experiment, nil
+}
+
+// recordConversion adds the event to every running experiment the user was exposed to, with the variant
+// they saw. Callers pass the transaction of the change the event describes when they have one.
+func recordConversion(db *gorm.DB, userID uuid.UUID, event string, value int64, occurredAt time.Time) error {
+	var assignments []model.ExperimentAssignment
+	if err := db.Model(&model.ExperimentAssignment{}).
+		Joins("JOIN experiments ON experiments.id = experiment_assignments.experiment_id").
+		Where("experiment_assignments.user_id = ? AND experiments.status = ?", userID, model.ExperimentRunning).
+		Find(&assignments).Error; err != nil {
+		return err
+	}
+	if len(assignments) == 0 {
+		return nil
+	}
+
+	conversions := make([]model.ExperimentConversion, len(assignments))
+	for i, assignment := range assignments {
+		conversions[i] = model.ExperimentConversion{
+			ExperimentID: assignment.ExperimentID,
+			UserID:       userID,
+			Variant:      assignment.Variant,
+			Event:        event,
+			Value:        value,
+			OccurredAt:   occurredAt,
+		}
+	}
+	return db.Create(&conversions).Error
+}
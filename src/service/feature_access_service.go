@@ -0,0 +1,46 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// FeatureAccessService is where premium endpoints ask whether a user may use a feature of the plans
+type FeatureAccessService interface {
+	// HasFeature reports whether the active subscription of the user includes feature, on the terms it was sold
+	// with. Users without an active subscription have no feature, and nobody has a feature that does not exist.
+	HasFeature(ctx context.Context, userID uuid.UUID, feature string) (bool, error)
+}
+
+type featureAccessService struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewFeatureAccessService(db *gorm.DB) FeatureAccessService {
+	return &featureAccessService{
+		Log: utils.Log,
+		DB:  db,
+	}
+}
+
+func (s *featureAccessService) HasFeature(ctx context.Context, userID uuid.UUID, feature string) (bool, error) {
+	var subscription model.UserSubscription
+	result := s.DB.WithContext(ctx).
+		Preload("Plan").
+		Scopes(activeSubscription(userID)).
+		Order("user_subscriptions.end_date DESC").
+		Limit(1).
+		Find(&subscription)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	plan := subscription.PurchasedPlan()
+	return plan.Features.Has(feature), nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"app/src/fhir"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// fhirMaxDays is the longest range of observations a partner reads at once
+const fhirMaxDays = 366
+
+type FHIRService interface {
+	// GetObservations returns the weights, heights and BMI (vital-signs) and the daily nutrition intake (survey)
+	// of a member over a date range as a FHIR searchset. The member must have consented to the partner reading
+	// their health data and their privacy settings must share the observations, users who are not members of the
+	// partner are not found.
+	GetObservations(c *fiber.Ctx, key *model.PartnerKey, userID uuid.UUID, query *validation.PartnerObservationQuery) (*fhir.Bundle, error)
+}
+
+type fhirService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewFHIRService(db *gorm.DB, validate *validator.Validate) FHIRService {
+	return &fhirService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *fhirService) GetObservations(
+	c *fiber.Ctx, key *model.PartnerKey, userID uuid.UUID, query *validation.PartnerObservationQuery,
+) (*fhir.Bundle, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	// Days are those of the server, as the daily summaries are
+	from, _ := time.ParseInLocation("2006-01-02", query.From, time.Local)
+	to, _ := time.ParseInLocation("2006-01-02", query.To, time.Local)
+	if to.Before(from) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+	if from.AddDate(0, 0, fhirMaxDays-1).Before(to) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Observation range is limited to %d days", fhirMaxDays))
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+
+	var members int64
+	if err := db.Model(&model.PartnerMember{}).
+		Where("partner = ? AND user_id = ?", key.Partner, userID).
+		Count(&members).Error; err != nil {
+		return nil, err
+	}
+	if members == 0 {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Member not found")
+	}
+
+	var consents int64
+	if err := db.Model(&model.PartnerConsent{}).
+		Where("partner = ? AND user_id = ? AND revoked_at IS NULL", key.Partner, userID).
+		Count(&consents).Error; err != nil {
+		return nil, err
+	}
+	if consents == 0 {
+		return nil, fiber.NewError(fiber.StatusForbidden, "The member has not consented to sharing their health data with you")
+	}
+
+	// Partners see no more than the privacy settings let coaches see: daily intake totals with a summary,
+	// weights and heights with full sharing
+	settings, err := privacySettingsOf(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	vitals := (query.Category == "" || query.Category == fhir.CategoryVitalSigns) && settings.CoachCanSee(model.CoachSharingFull)
+	intake := (query.Category == "" || query.Category == fhir.CategorySurvey) && settings.CoachCanSee(model.CoachSharingSummary)
+	if !vitals && !intake {
+		return nil, fiber.NewError(fiber.StatusForbidden, "The privacy settings of the member do not share these observations")
+	}
+
+	patient := fhir.Patient(userID.String())
+	observations := []fhir.Observation{}
+
+	if vitals {
+		var records []model.UsersWeightHeightHistory
+		if err := db.Where("user_id = ? AND recorded_at >= ? AND recorded_at < ?", userID, from, to.AddDate(0, 0, 1)).
+			Order("recorded_at").
+			Find(&records).Error; err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			id := record.ID.String()
+			vital := model.NewSharedVital(record)
+			observations = append(observations,
+				fhir.BodyWeight("weight-"+id, patient, record.RecordedAt, vital.Weight),
+				fhir.BodyHeight("height-"+id, patient, record.RecordedAt, vital.Height))
+			if vital.BMI > 0 {
+				observations = append(observations, fhir.BMI("bmi-"+id, patient, record.RecordedAt, vital.BMI))
+			}
+		}
+	}
+
+	if intake {
+		var summaries []model.DailyNutritionSummary
+		if err := db.Where("user_id = ? AND date BETWEEN ? AND ? AND meal_count > 0", userID, query.From, query.To).
+			Order("date").
+			Find(&summaries).Error; err != nil {
+			return nil, err
+		}
+		for _, summary := range summaries {
+			// Dates read back from the database are midnight UTC, the day is that of the server
+			day := time.Date(summary.Date.Year(), summary.Date.Month(), summary.Date.Day(), 0, 0, 0, 0, time.Local)
+			observations = append(observations, fhir.DailyIntake(
+				fmt.Sprintf("intake-%s-%s", day.Format("20060102"), userID), patient, day,
+				summary.Calories, summary.Protein, summary.Carbs, summary.Fat))
+		}
+	}
+
+	bundle := fhir.SearchSet(observations, time.Now())
+	return &bundle, nil
+}
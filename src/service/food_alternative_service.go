@@ -0,0 +1,193 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FoodAlternativeService interface {
+	// GetAlternatives suggests healthier foods of the same group to eat instead of a food, ranked by the
+	// goal and the dietary restrictions of the user and by feedback
+	GetAlternatives(c *fiber.Ctx, userID uuid.UUID, id uint32, query *validation.FoodAlternativeQuery) ([]model.FoodAlternativeSuggestion, error)
+	// SendFeedback records whether the user found an alternative helpful. Alternatives the user did not
+	// are no longer suggested to them, feedback of every user moves the ranking for everyone.
+	SendFeedback(c *fiber.Ctx, userID uuid.UUID, id uint32, req *validation.FoodAlternativeFeedback) (*model.FoodAlternativeFeedback, error)
+	GetDietPreference(c *fiber.Ctx, userID uuid.UUID) (*model.DietPreference, error)
+	UpdateDietPreference(c *fiber.Ctx, userID uuid.UUID, req *validation.UpdateDietPreference) (*model.DietPreference, error)
+}
+
+type foodAlternativeService struct {
+	Log                 *logrus.Logger
+	DB                  *gorm.DB
+	Validate            *validator.Validate
+	BahanMakananService BahanMakananService
+}
+
+func NewFoodAlternativeService(db *gorm.DB, validate *validator.Validate, bahanMakananService BahanMakananService) FoodAlternativeService {
+	return &foodAlternativeService{
+		Log:                 utils.Log,
+		DB:                  db,
+		Validate:            validate,
+		BahanMakananService: bahanMakananService,
+	}
+}
+
+func (s *foodAlternativeService) GetAlternatives(c *fiber.Ctx, userID uuid.UUID, id uint32, query *validation.FoodAlternativeQuery) ([]model.FoodAlternativeSuggestion, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	food, err := s.BahanMakananService.GetBahanMakananById(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if food.KelompokMakanan == "" {
+		return []model.FoodAlternativeSuggestion{}, nil
+	}
+
+	candidates, err := s.BahanMakananService.GetBahanMakananByKelompok(c, food.KelompokMakanan)
+	if err != nil {
+		return nil, err
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+
+	goal, err := s.goal(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preference, err := s.GetDietPreference(c, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	signals, err := s.signals(db, userID, food.Kode)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.RankFoodAlternatives(*food, candidates, goal, preference.Restrictions, signals, query.Limit), nil
+}
+
+func (s *foodAlternativeService) SendFeedback(c *fiber.Ctx, userID uuid.UUID, id uint32, req *validation.FoodAlternativeFeedback) (*model.FoodAlternativeFeedback, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	food, err := s.BahanMakananService.GetBahanMakananById(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.AlternativeKode == food.Kode {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "A food is no alternative to itself")
+	}
+	if _, err := s.BahanMakananService.GetBahanMakananByKode(c, req.AlternativeKode); err != nil {
+		return nil, err
+	}
+
+	feedback := &model.FoodAlternativeFeedback{
+		UserID:          userID,
+		FoodKode:        food.Kode,
+		AlternativeKode: req.AlternativeKode,
+		Helpful:         *req.Helpful,
+	}
+	if err := s.DB.WithContext(c.UserContext()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "food_kode"}, {Name: "alternative_kode"}},
+		DoUpdates: clause.AssignmentColumns([]string{"helpful", "updated_at"}),
+	}).Create(feedback).Error; err != nil {
+		return nil, err
+	}
+
+	return feedback, nil
+}
+
+func (s *foodAlternativeService) GetDietPreference(c *fiber.Ctx, userID uuid.UUID) (*model.DietPreference, error) {
+	preference := &model.DietPreference{UserID: userID, Restrictions: []string{}}
+	err := s.DB.WithContext(c.UserContext()).First(preference, "user_id = ?", userID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return preference, nil
+}
+
+func (s *foodAlternativeService) UpdateDietPreference(c *fiber.Ctx, userID uuid.UUID, req *validation.UpdateDietPreference) (*model.DietPreference, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	restrictions := []string{}
+	seen := make(map[string]bool)
+	for _, restriction := range req.Restrictions {
+		if !seen[restriction] {
+			seen[restriction] = true
+			restrictions = append(restrictions, restriction)
+		}
+	}
+
+	preference := &model.DietPreference{UserID: userID, Restrictions: restrictions}
+	if err := s.DB.WithContext(c.UserContext()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"restrictions", "updated_at"}),
+	}).Create(preference).Error; err != nil {
+		return nil, err
+	}
+
+	return preference, nil
+}
+
+// goal compares the weight of the user with their latest weight target
+func (s *foodAlternativeService) goal(db *gorm.DB, userID uuid.UUID) (string, error) {
+	user := new(model.User)
+	if err := db.Select("id", "weight").First(user, "id = ?", userID).Error; err != nil {
+		return "", err
+	}
+
+	var targets []model.UsersWeightHeightTarget
+	if err := db.Where("user_id = ?", userID).Order("target_date DESC").Limit(1).Find(&targets).Error; err != nil {
+		return "", err
+	}
+	if len(targets) == 0 {
+		return model.GoalMaintain, nil
+	}
+
+	return model.DietGoal(user.Weight, &targets[0].Weight), nil
+}
+
+// signals collects the feedback on the alternatives of a food
+func (s *foodAlternativeService) signals(db *gorm.DB, userID uuid.UUID, kode string) (model.AlternativeSignals, error) {
+	signals := model.AlternativeSignals{
+		Votes: make(map[string]model.AlternativeVotes),
+		User:  make(map[string]bool),
+	}
+
+	var feedback []model.FoodAlternativeFeedback
+	if err := db.Where("food_kode = ?", kode).Find(&feedback).Error; err != nil {
+		return signals, err
+	}
+	for _, vote := range feedback {
+		votes := signals.Votes[vote.AlternativeKode]
+		if vote.Helpful {
+			votes.Helpful++
+		} else {
+			votes.NotHelpful++
+		}
+		signals.Votes[vote.AlternativeKode] = votes
+
+		if vote.UserID == userID {
+			signals.User[vote.AlternativeKode] = vote.Helpful
+		}
+	}
+
+	return signals, nil
+}
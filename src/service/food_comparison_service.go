@@ -0,0 +1,121 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	minComparedFoods = 2
+	maxComparedFoods = 4
+
+	// foodAlternativeLimit is the number of healthier swaps a comparison suggests
+	foodAlternativeLimit = 5
+)
+
+type FoodComparisonService interface {
+	// Compare compares 2 to 4 foods per 100 g and per serving and suggests healthier foods of their groups
+	Compare(c *fiber.Ctx, query *validation.FoodCompareQuery) (*model.FoodComparison, error)
+}
+
+type foodComparisonService struct {
+	Log                 *logrus.Logger
+	DB                  *gorm.DB
+	Validate            *validator.Validate
+	BahanMakananService BahanMakananService
+}
+
+func NewFoodComparisonService(db *gorm.DB, validate *validator.Validate, bahanMakananService BahanMakananService) FoodComparisonService {
+	return &foodComparisonService{
+		Log:                 utils.Log,
+		DB:                  db,
+		Validate:            validate,
+		BahanMakananService: bahanMakananService,
+	}
+}
+
+func (s *foodComparisonService) Compare(c *fiber.Ctx, query *validation.FoodCompareQuery) (*model.FoodComparison, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	ids, err := parseFoodIDs(query.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	foods := make([]model.BahanMakanan, 0, len(ids))
+	kodes := make([]string, 0, len(ids))
+	for _, id := range ids {
+		food, err := s.BahanMakananService.GetBahanMakananById(c, id)
+		if err != nil {
+			return nil, err
+		}
+		foods = append(foods, *food)
+		kodes = append(kodes, food.Kode)
+	}
+
+	var portions []model.FoodPortion
+	if err := s.DB.WithContext(c.UserContext()).Where("food_kode IN ?", kodes).Find(&portions).Error; err != nil {
+		return nil, err
+	}
+	byKode := make(map[string][]model.FoodPortion)
+	for _, portion := range portions {
+		byKode[portion.FoodKode] = append(byKode[portion.FoodKode], portion)
+	}
+
+	comparison := model.CompareFoods(foods, byKode)
+
+	// Alternatives are a suggestion, the comparison does not fail without them
+	var candidates []model.BahanMakanan
+	groups := make(map[string]bool)
+	for _, food := range foods {
+		if food.KelompokMakanan == "" || groups[food.KelompokMakanan] {
+			continue
+		}
+		groups[food.KelompokMakanan] = true
+
+		group, err := s.BahanMakananService.GetBahanMakananByKelompok(c, food.KelompokMakanan)
+		if err != nil {
+			s.Log.Errorf("Failed to get foods of group %s for alternatives: %v", food.KelompokMakanan, err)
+			continue
+		}
+		candidates = append(candidates, group...)
+	}
+	comparison.Alternatives = model.SuggestAlternatives(foods, candidates, foodAlternativeLimit)
+
+	return &comparison, nil
+}
+
+// parseFoodIDs reads a comma separated list of distinct food IDs
+func parseFoodIDs(raw string) ([]uint32, error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) < minComparedFoods || len(parts) > maxComparedFoods {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Compare 2 to 4 foods")
+	}
+
+	ids := make([]uint32, 0, len(parts))
+	seen := make(map[uint32]bool, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		id, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid food ID "+part)
+		}
+		if seen[uint32(id)] {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Food "+part+" is given twice")
+		}
+		seen[uint32(id)] = true
+		ids = append(ids, uint32(id))
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// foodGradingCacheTTL is how long an instance trusts its copy of the grading config
+const foodGradingCacheTTL = time.Minute
+
+type FoodGradeService interface {
+	GetConfig(ctx context.Context) (*model.GradingConfig, error)
+	// UpdateConfig replaces the grading under a new version, grades of an older one are recomputed when
+	// next read. This instance applies it right away, the others within foodGradingCacheTTL.
+	UpdateConfig(c *fiber.Ctx, adminID uuid.UUID, req *validation.UpdateFoodGrading) (*model.GradingConfig, error)
+	ResetConfig(c *fiber.Ctx, adminID uuid.UUID) (*model.GradingConfig, error)
+
+	// GradeFoods sets the grade of the foods and stores the grades that changed. Grading never fails a
+	// request, errors are logged and the foods left without a grade.
+	GradeFoods(ctx context.Context, foods []model.BahanMakanan)
+	GradeFood(ctx context.Context, food *model.BahanMakanan)
+	// GradeMeal sets the grade of a meal about to be saved
+	GradeMeal(ctx context.Context, meal *model.MealHistory)
+	// RegradeMeals grades the meals without a grade of the current version and stores it
+	RegradeMeals(ctx context.Context, meals []model.MealHistory)
+}
+
+type foodGradeService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+
+	mu       sync.Mutex
+	cached   *model.GradingConfig
+	cachedAt time.Time
+}
+
+func NewFoodGradeService(db *gorm.DB, validate *validator.Validate) FoodGradeService {
+	return &foodGradeService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *foodGradeService) GetConfig(ctx context.Context) (*model.GradingConfig, error) {
+	return s.load(s.DB.WithContext(ctx))
+}
+
+// load reads the saved config, the default one without it
+func (s *foodGradeService) load(db *gorm.DB) (*model.GradingConfig, error) {
+	setting := new(model.SystemSetting)
+	err := db.First(setting, "key = ?", model.SettingFoodGrading).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		config := model.DefaultGradingConfig
+		return &config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config := new(model.GradingConfig)
+	if err := json.Unmarshal([]byte(setting.Value), config); err != nil {
+		return nil, fmt.Errorf("reading the grading config: %w", err)
+	}
+	config.UpdatedByID = setting.UpdatedByID
+	updatedAt := setting.UpdatedAt
+	config.UpdatedAt = &updatedAt
+
+	return config, nil
+}
+
+func (s *foodGradeService) UpdateConfig(c *fiber.Ctx, adminID uuid.UUID, req *validation.UpdateFoodGrading) (*model.GradingConfig, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	food, meal := gradingProfile(req.Food), gradingProfile(req.Meal)
+	if err := food.Check(model.IsFoodGradingKey); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Food grading: %v", err))
+	}
+	if err := meal.Check(model.IsMealGradingKey); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Meal grading: %v", err))
+	}
+
+	return s.save(c.UserContext(), adminID, food, meal)
+}
+
+func (s *foodGradeService) ResetConfig(c *fiber.Ctx, adminID uuid.UUID) (*model.GradingConfig, error) {
+	// Saved rather than deleted so the version still goes up
+	return s.save(c.UserContext(), adminID, model.DefaultGradingConfig.Food, model.DefaultGradingConfig.Meal)
+}
+
+// save stores the profiles under the next version
+func (s *foodGradeService) save(ctx context.Context, adminID uuid.UUID, food, meal model.GradingProfile) (*model.GradingConfig, error) {
+	var config *model.GradingConfig
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := s.load(tx.Clauses(clause.Locking{Strength: "UPDATE"}))
+		if err != nil {
+			return err
+		}
+
+		config = &model.GradingConfig{Version: current.Version + 1, Food: food, Meal: meal}
+		value, err := json.Marshal(config)
+		if err != nil {
+			return err
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_by_id", "updated_at"}),
+		}).Create(&model.SystemSetting{
+			Key:         model.SettingFoodGrading,
+			Value:       string(value),
+			UpdatedByID: &adminID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	config.UpdatedByID, config.UpdatedAt = &adminID, &now
+
+	s.mu.Lock()
+	s.cached, s.cachedAt = config, now
+	s.mu.Unlock()
+
+	return config, nil
+}
+
+// config is the cached grading config
+func (s *foodGradeService) config(ctx context.Context) (*model.GradingConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached == nil || time.Since(s.cachedAt) > foodGradingCacheTTL {
+		config, err := s.GetConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.cached, s.cachedAt = config, time.Now()
+	}
+
+	return s.cached, nil
+}
+
+func (s *foodGradeService) GradeFoods(ctx context.Context, foods []model.BahanMakanan) {
+	if len(foods) == 0 {
+		return
+	}
+	config, err := s.config(ctx)
+	if err != nil {
+		s.Log.Errorf("Failed to read the grading config: %v", err)
+		return
+	}
+
+	kodes := make([]string, 0, len(foods))
+	for i := range foods {
+		grade := config.GradeFood(foods[i])
+		foods[i].Grade = &grade
+		kodes = append(kodes, foods[i].Kode)
+	}
+
+	var stored []model.FoodGrade
+	if err := s.DB.WithContext(ctx).Where("food_kode IN ?", kodes).Find(&stored).Error; err != nil {
+		s.Log.Errorf("Failed to get food grades: %v", err)
+		return
+	}
+	byKode := make(map[string]model.FoodGrade, len(stored))
+	for _, grade := range stored {
+		byKode[grade.FoodKode] = grade
+	}
+
+	// The food values may change in the food service too, a grade is stored again whenever it differs
+	changed := []model.FoodGrade{}
+	for _, food := range foods {
+		if grade, ok := byKode[food.Kode]; ok && grade.Version == food.Grade.Version &&
+			grade.Grade == food.Grade.Grade && grade.Score == food.Grade.Score {
+			continue
+		}
+		changed = append(changed, model.FoodGrade{
+			FoodKode: food.Kode,
+			Grade:    food.Grade.Grade,
+			Score:    food.Grade.Score,
+			Lights:   food.Grade.Lights,
+			Version:  food.Grade.Version,
+		})
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	if err := s.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "food_kode"}},
+		DoUpdates: clause.AssignmentColumns([]string{"grade", "score", "lights", "version", "updated_at"}),
+	}).CreateInBatches(&changed, 500).Error; err != nil {
+		s.Log.Errorf("Failed to store food grades: %v", err)
+	}
+}
+
+func (s *foodGradeService) GradeFood(ctx context.Context, food *model.BahanMakanan) {
+	foods := []model.BahanMakanan{*food}
+	s.GradeFoods(ctx, foods)
+	food.Grade = foods[0].Grade
+}
+
+func (s *foodGradeService) GradeMeal(ctx context.Context, meal *model.MealHistory) {
+	config, err := s.config(ctx)
+	if err != nil {
+		s.Log.Errorf("Failed to read the grading config: %v", err)
+		return
+	}
+
+	grade := config.GradeMeal(*meal)
+	meal.HealthGrade = &grade
+}
+
+func (s *foodGradeService) RegradeMeals(ctx context.Context, meals []model.MealHistory) {
+	if len(meals) == 0 {
+		return
+	}
+	config, err := s.config(ctx)
+	if err != nil {
+		s.Log.Errorf("Failed to read the grading config: %v", err)
+		return
+	}
+
+	for i := range meals {
+		if meals[i].HealthGrade != nil && meals[i].HealthGrade.Version == config.Version {
+			continue
+		}
+		grade := config.GradeMeal(meals[i])
+		meals[i].HealthGrade = &grade
+
+		// UpdateColumns leaves updated_at alone, the meal itself did not change
+		if err := s.DB.WithContext(ctx).Model(&model.MealHistory{}).Where("id = ?", meals[i].ID).
+			Select("health_grade").UpdateColumns(&model.MealHistory{HealthGrade: &grade}).Error; err != nil {
+			s.Log.Errorf("Failed to store the grade of meal %s: %v", meals[i].ID, err)
+		}
+	}
+}
+
+// gradingProfile converts a validated profile
+func gradingProfile(req validation.GradingProfile) model.GradingProfile {
+	profile := model.GradingProfile{
+		Nutrients: make([]model.GradedNutrient, 0, len(req.Nutrients)),
+		Grades:    make([]model.GradeBand, 0, len(req.Grades)),
+	}
+	for _, nutrient := range req.Nutrients {
+		profile.Nutrients = append(profile.Nutrients, model.GradedNutrient{
+			Key:        nutrient.Key,
+			Direction:  nutrient.Direction,
+			Thresholds: nutrient.Thresholds,
+			Low:        nutrient.Low,
+			High:       nutrient.High,
+		})
+	}
+	for _, band := range req.Grades {
+		profile.Grades = append(profile.Grades, model.GradeBand{Grade: band.Grade, MaxScore: band.MaxScore})
+	}
+	return profile
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"app/src/grpc"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// foodImportMaxSize is the largest dataset an import reads, the full USDA export is about 10 MB
+	foodImportMaxSize = 20 << 20
+
+	// foodImportTimeout bounds an import, every update is a request to the food service
+	foodImportTimeout = 30 * time.Minute
+
+	// foodImportBatchSize is the number of report rows written at once
+	foodImportBatchSize = 500
+)
+
+type FoodImportService interface {
+	// Import reads a nutrition dataset and merges it into the food catalog in the background. Rows matching
+	// a food fill the values it is missing, or replace them with overwrite. The food service cannot create
+	// foods, rows matching none are staged in the report to be added there.
+	Import(c *fiber.Ctx, adminID uuid.UUID, req *validation.ImportFoods, file *multipart.FileHeader) (*model.FoodImport, error)
+	GetImports(c *fiber.Ctx, query *validation.FoodImportQuery) ([]model.FoodImport, int64, error)
+	GetImport(c *fiber.Ctx, id uuid.UUID) (*model.FoodImport, error)
+	GetItems(c *fiber.Ctx, id uuid.UUID, query *validation.FoodImportItemQuery) ([]model.FoodImportItem, int64, error)
+}
+
+type foodImportService struct {
+	Log                *logrus.Logger
+	DB                 *gorm.DB
+	Validate           *validator.Validate
+	Foods              *grpc.BahanMakananClient
+	SearchIndexService SearchIndexService
+}
+
+func NewFoodImportService(
+	db *gorm.DB, validate *validator.Validate, foods *grpc.BahanMakananClient, searchIndexService SearchIndexService,
+) FoodImportService {
+	return &foodImportService{
+		Log:                utils.Log,
+		DB:                 db,
+		Validate:           validate,
+		Foods:              foods,
+		SearchIndexService: searchIndexService,
+	}
+}
+
+func (s *foodImportService) Import(c *fiber.Ctx, adminID uuid.UUID, req *validation.ImportFoods, file *multipart.FileHeader) (*model.FoodImport, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+	if s.Foods == nil {
+		return nil, fiber.NewError(fiber.StatusServiceUnavailable, "Food service is unavailable")
+	}
+	if file.Size > foodImportMaxSize {
+		return nil, fiber.NewError(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("The dataset must not be larger than %d MB", foodImportMaxSize>>20))
+	}
+
+	mapping := model.FoodImportMappings[req.Source]
+	if req.Mapping != "" {
+		var override model.FoodImportMapping
+		if err := json.Unmarshal([]byte(req.Mapping), &override); err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid mapping")
+		}
+		for field, column := range override {
+			if !model.IsFoodImportField(field) {
+				return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Unknown field %q in mapping", field))
+			}
+			if column.Column == "" {
+				return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Field %q has no column in mapping", field))
+			}
+			if column.Unit != "" {
+				if _, err := model.ConvertFoodUnit(1, column.Unit, column.Unit); err != nil {
+					return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Field %q: %v", field, err))
+				}
+			}
+		}
+		mapping = mapping.Merge(override)
+	}
+
+	// Reading the file before answering reports a wrong file or mapping at once
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Failed to read the dataset")
+	}
+	defer reader.Close()
+
+	rows, ignored, err := model.ParseFoodImport(reader, mapping)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Failed to read the dataset: %v", err))
+	}
+	if len(rows) == 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "The dataset has no rows")
+	}
+
+	foodImport := &model.FoodImport{
+		Source:         req.Source,
+		FileName:       file.Filename,
+		DryRun:         req.DryRun,
+		Overwrite:      req.Overwrite,
+		Status:         model.FoodImportRunning,
+		Rows:           len(rows),
+		IgnoredColumns: ignored,
+		CreatedByID:    adminID,
+	}
+	if err := s.DB.WithContext(c.UserContext()).Create(foodImport).Error; err != nil {
+		return nil, err
+	}
+
+	// The context is taken before the handler returns, fiber reuses c for the next request
+	ctx := context.WithoutCancel(c.UserContext())
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, foodImportTimeout)
+		defer cancel()
+
+		s.run(ctx, *foodImport, rows)
+	}()
+
+	return foodImport, nil
+}
+
+// run matches the rows with the catalog, updates the matched foods unless it is a dry run and writes the report
+func (s *foodImportService) run(ctx context.Context, foodImport model.FoodImport, rows []model.FoodImportRow) {
+	db := s.DB.WithContext(ctx)
+
+	finish := func(err error) {
+		now := time.Now()
+		foodImport.FinishedAt = &now
+		foodImport.Status = model.FoodImportCompleted
+		if err != nil {
+			s.Log.Errorf("Food import %s failed: %v", foodImport.ID, err)
+			foodImport.Status = model.FoodImportFailed
+			foodImport.Error = err.Error()
+		}
+		// Saved even when the import timed out
+		if err := s.DB.Save(&foodImport).Error; err != nil {
+			s.Log.Errorf("Failed to save food import %s: %v", foodImport.ID, err)
+		}
+	}
+
+	response, err := s.Foods.GetAllBahanMakanan(ctx)
+	if err != nil {
+		finish(fmt.Errorf("getting the foods: %w", err))
+		return
+	}
+	foods := make([]model.BahanMakanan, 0, len(response.BahanMakanan))
+	for _, pbFood := range response.BahanMakanan {
+		foods = append(foods, ConvertPbToModel(pbFood))
+	}
+
+	var names []model.FoodName
+	if err := db.Find(&names).Error; err != nil {
+		finish(fmt.Errorf("getting the food names: %w", err))
+		return
+	}
+	byKode := make(map[string][]model.FoodName)
+	for _, name := range names {
+		byKode[name.FoodKode] = append(byKode[name.FoodKode], name)
+	}
+
+	// A food is merged once, by the first row matching it
+	seenKode := make(map[string]bool)
+	seenName := make(map[string]bool)
+
+	items := make([]model.FoodImportItem, 0, foodImportBatchSize)
+	for _, row := range rows {
+		item := s.importRow(ctx, foodImport, row, foods, byKode, seenKode, seenName)
+		switch item.Action {
+		case model.FoodImportActionUpdate:
+			foodImport.Matched++
+			foodImport.Updated++
+		case model.FoodImportActionUnchanged:
+			foodImport.Matched++
+			foodImport.Skipped++
+		case model.FoodImportActionNew:
+			foodImport.New++
+		case model.FoodImportActionDuplicate:
+			foodImport.Skipped++
+		case model.FoodImportActionFailed:
+			foodImport.Matched++
+			foodImport.Failed++
+		case model.FoodImportActionInvalid:
+			foodImport.Failed++
+		}
+
+		items = append(items, item)
+		if len(items) == foodImportBatchSize {
+			if err := db.Create(&items).Error; err != nil {
+				finish(fmt.Errorf("saving the report: %w", err))
+				return
+			}
+			items = items[:0]
+		}
+	}
+	if len(items) > 0 {
+		if err := db.Create(&items).Error; err != nil {
+			finish(fmt.Errorf("saving the report: %w", err))
+			return
+		}
+	}
+
+	finish(nil)
+	s.Log.Infof("Food import %s finished: %d rows, %d updated, %d new, %d skipped, %d failed",
+		foodImport.ID, foodImport.Rows, foodImport.Updated, foodImport.New, foodImport.Skipped, foodImport.Failed)
+}
+
+func (s *foodImportService) importRow(
+	ctx context.Context, foodImport model.FoodImport, row model.FoodImportRow, foods []model.BahanMakanan,
+	names map[string][]model.FoodName, seenKode, seenName map[string]bool,
+) model.FoodImportItem {
+	item := model.FoodImportItem{ImportID: foodImport.ID, Line: row.Line, Name: row.Name}
+	if row.Error != "" {
+		item.Action = model.FoodImportActionInvalid
+		item.Error = row.Error
+		return item
+	}
+
+	match := model.MatchFoodImport(row, foods, names)
+	if match == nil {
+		name := model.NormalizeFoodName(row.Name)
+		if seenName[name] {
+			item.Action = model.FoodImportActionDuplicate
+			return item
+		}
+		seenName[name] = true
+
+		food := row.Food()
+		item.Action = model.FoodImportActionNew
+		item.Food = &food
+		return item
+	}
+
+	kode := match.Food.Kode
+	item.MatchedKode = &kode
+	item.MatchedBy = match.By
+	item.MatchScore = match.Score
+	if seenKode[kode] {
+		item.Action = model.FoodImportActionDuplicate
+		return item
+	}
+	seenKode[kode] = true
+
+	merged, changes := model.MergeFoodImport(*match.Food, row, foodImport.Overwrite)
+	if len(changes) == 0 {
+		item.Action = model.FoodImportActionUnchanged
+		return item
+	}
+	item.Action = model.FoodImportActionUpdate
+	item.Changes = changes
+	item.Food = &merged
+	if foodImport.DryRun {
+		return item
+	}
+
+	if _, err := s.Foods.UpdateBahanMakanan(ctx, merged.ID, ConvertModelToPb(&merged)); err != nil {
+		item.Action = model.FoodImportActionFailed
+		item.Error = err.Error()
+		return item
+	}
+	*match.Food = merged
+	s.SearchIndexService.IndexFood(ctx, kode)
+
+	return item
+}
+
+func (s *foodImportService) GetImports(c *fiber.Ctx, query *validation.FoodImportQuery) ([]model.FoodImport, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	var imports []model.FoodImport
+	var totalResults int64
+
+	db := s.DB.WithContext(c.UserContext()).Model(&model.FoodImport{})
+	if err := db.Count(&totalResults).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Order("created_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&imports).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return imports, totalResults, nil
+}
+
+func (s *foodImportService) GetImport(c *fiber.Ctx, id uuid.UUID) (*model.FoodImport, error) {
+	foodImport := new(model.FoodImport)
+	if err := s.DB.WithContext(c.UserContext()).First(foodImport, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Food import not found")
+		}
+		return nil, err
+	}
+
+	return foodImport, nil
+}
+
+func (s *foodImportService) GetItems(c *fiber.Ctx, id uuid.UUID, query *validation.FoodImportItemQuery) ([]model.FoodImportItem, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+	if _, err := s.GetImport(c, id); err != nil {
+		return nil, 0, err
+	}
+
+	var items []model.FoodImportItem
+	var totalResults int64
+
+	db := s.DB.WithContext(c.UserContext()).Model(&model.FoodImportItem{}).Where("import_id = ?", id)
+	if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+	if err := db.Count(&totalResults).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Order("line ASC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return items, totalResults, nil
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FoodNameService interface {
+	GetNames(c *fiber.Ctx, kode string) ([]model.FoodName, error)
+	// SetNames replaces the localized names and synonyms of a food
+	SetNames(c *fiber.Ctx, kode string, req *validation.SetFoodNames) ([]model.FoodName, error)
+	// Search finds foods by any of their names, or names close to the search, and records the search for
+	// analytics. Matches in the language of the search and foods the user logged before rank first.
+	Search(c *fiber.Ctx, userID uuid.UUID, query *validation.FoodSearchQuery) ([]model.FoodSearchResult, uuid.UUID, error)
+	// LogFood counts a food the user picked, marking it as the choice of the search it came from. A portion
+	// in a household measure is kept in grams too.
+	LogFood(c *fiber.Ctx, userID uuid.UUID, kode string, req *validation.LogFood) (*model.FoodLog, error)
+	// GetSearchGaps groups the searches without results, the most searched first
+	GetSearchGaps(c *fiber.Ctx, query *validation.FoodSearchGapQuery) ([]model.FoodSearchGap, error)
+}
+
+type foodNameService struct {
+	Log                 *logrus.Logger
+	DB                  *gorm.DB
+	Validate            *validator.Validate
+	BahanMakananService BahanMakananService
+	SearchIndexService  SearchIndexService
+	FoodPortionService  FoodPortionService
+}
+
+// foodIndexCandidates is how many foods the search index returns per result asked for, the ranking
+// picks among them
+const foodIndexCandidates = 5
+
+func NewFoodNameService(
+	db *gorm.DB, validate *validator.Validate, bahanMakananService BahanMakananService, searchIndexService SearchIndexService,
+	foodPortionService FoodPortionService,
+) FoodNameService {
+	return &foodNameService{
+		Log:                 utils.Log,
+		DB:                  db,
+		Validate:            validate,
+		BahanMakananService: bahanMakananService,
+		SearchIndexService:  searchIndexService,
+		FoodPortionService:  foodPortionService,
+	}
+}
+
+func (s *foodNameService) GetNames(c *fiber.Ctx, kode string) ([]model.FoodName, error) {
+	if _, err := s.BahanMakananService.GetBahanMakananByKode(c, kode); err != nil {
+		return nil, err
+	}
+
+	var names []model.FoodName
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("food_kode = ?", kode).
+		Order("language ASC, is_primary DESC, name ASC").
+		Find(&names).Error; err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+func (s *foodNameService) SetNames(c *fiber.Ctx, kode string, req *validation.SetFoodNames) ([]model.FoodName, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+	if _, err := s.BahanMakananService.GetBahanMakananByKode(c, kode); err != nil {
+		return nil, err
+	}
+
+	names := make([]model.FoodName, 0, len(req.Names))
+	primary := make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, input := range req.Names {
+		name := strings.TrimSpace(input.Name)
+		key := input.Language + ":" + model.NormalizeFoodName(name)
+		if seen[key] {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Name %q is given twice", name))
+		}
+		seen[key] = true
+
+		if input.Primary {
+			if primary[input.Language] {
+				return nil, fiber.NewError(fiber.StatusBadRequest,
+					fmt.Sprintf("Only one primary name is allowed per language, %s has more", input.Language))
+			}
+			primary[input.Language] = true
+		}
+
+		names = append(names, model.FoodName{
+			FoodKode: kode,
+			Language: input.Language,
+			Name:     name,
+			Primary:  input.Primary,
+		})
+	}
+
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("food_kode = ?", kode).Delete(&model.FoodName{}).Error; err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return nil
+		}
+		return tx.Create(&names).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.SearchIndexService.IndexFood(c.UserContext(), kode)
+
+	return s.GetNames(c, kode)
+}
+
+func (s *foodNameService) Search(c *fiber.Ctx, userID uuid.UUID, query *validation.FoodSearchQuery) ([]model.FoodSearchResult, uuid.UUID, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+
+	foods, byKode, err := s.candidates(c, query)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	kodes := make([]string, 0, len(foods))
+	for _, food := range foods {
+		kodes = append(kodes, food.Kode)
+	}
+	signals, err := s.rankSignals(db, userID, kodes)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	results := model.RankFoods(query.Q, query.Lang, foods, byKode, signals, query.Limit)
+
+	// Analytics must not fail the search
+	search := &model.FoodSearch{
+		UserID:    userID,
+		Query:     model.NormalizeFoodName(query.Q),
+		Language:  query.Lang,
+		Results:   len(results),
+		FuzzyOnly: len(results) > 0,
+	}
+	for _, result := range results {
+		if !result.Fuzzy {
+			search.FuzzyOnly = false
+			break
+		}
+	}
+	if err := db.Create(search).Error; err != nil {
+		s.Log.Errorf("Failed to record food search of user %s: %v", userID, err)
+		return results, uuid.Nil, nil
+	}
+
+	return results, search.ID, nil
+}
+
+func (s *foodNameService) LogFood(c *fiber.Ctx, userID uuid.UUID, kode string, req *validation.LogFood) (*model.FoodLog, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	log := &model.FoodLog{
+		UserID:       userID,
+		FoodKode:     kode,
+		Count:        1,
+		LastLoggedAt: time.Now(),
+	}
+	updates := map[string]any{
+		"count":          gorm.Expr("food_logs.count + 1"),
+		"last_logged_at": log.LastLoggedAt,
+	}
+
+	if req.Unit != "" {
+		// Converting checks that the food exists
+		conversion, err := s.FoodPortionService.Convert(c, kode, &validation.PortionQuery{Quantity: req.Quantity, Unit: req.Unit})
+		if err != nil {
+			return nil, err
+		}
+		log.LastQuantity, log.LastUnit, log.LastGrams = &conversion.Quantity, &conversion.Unit, &conversion.Grams
+		updates["last_quantity"] = conversion.Quantity
+		updates["last_unit"] = conversion.Unit
+		updates["last_grams"] = conversion.Grams
+	} else if _, err := s.BahanMakananService.GetBahanMakananByKode(c, kode); err != nil {
+		return nil, err
+	}
+
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "food_kode"}},
+			DoUpdates: clause.Assignments(updates),
+		}).Create(log).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "food_kode"}},
+			DoUpdates: clause.Assignments(map[string]any{"count": gorm.Expr("food_popularities.count + 1")}),
+		}).Create(&model.FoodPopularity{FoodKode: kode, Count: 1}).Error; err != nil {
+			return err
+		}
+
+		if req.SearchID != "" {
+			// Only the searches of the user can be marked
+			if err := tx.Model(&model.FoodSearch{}).
+				Where("id = ? AND user_id = ?", req.SearchID, userID).
+				Update("selected_kode", kode).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.First(log, "user_id = ? AND food_kode = ?", userID, kode).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return log, nil
+}
+
+func (s *foodNameService) GetSearchGaps(c *fiber.Ctx, query *validation.FoodSearchGapQuery) ([]model.FoodSearchGap, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	from, _ := time.Parse("2006-01-02", query.From)
+	to, _ := time.Parse("2006-01-02", query.To)
+	if to.Before(from) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+
+	var gaps []model.FoodSearchGap
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(&model.FoodSearch{}).
+		Select("query, language, COUNT(*) AS searches, COUNT(DISTINCT user_id) AS users, MAX(created_at) AS last_searched_at").
+		Where("results = 0 AND created_at >= ? AND created_at < ?", from, to.AddDate(0, 0, 1)).
+		Group("query, language").
+		Order("searches DESC, last_searched_at DESC").
+		Limit(query.Limit).
+		Scan(&gaps).Error; err != nil {
+		return nil, err
+	}
+
+	return gaps, nil
+}
+
+// candidates returns the foods a search may find with their names. The search index narrows them down,
+// without it the database matches the names of the catalog mirror and of the food names, close ones
+// included, and keeps the closest. Foods the mirror has not caught up with wait for its next refresh.
+func (s *foodNameService) candidates(c *fiber.Ctx, query *validation.FoodSearchQuery) ([]model.BahanMakanan, map[string][]model.FoodName, error) {
+	byKode := make(map[string][]model.FoodName)
+	size := query.Limit * foodIndexCandidates
+
+	docs, err := s.SearchIndexService.SearchFoods(c.UserContext(), query.Q, size)
+	if err == nil {
+		foods := make([]model.BahanMakanan, 0, len(docs))
+		for _, doc := range docs {
+			foods = append(foods, doc.Food)
+			byKode[doc.Kode] = doc.Names
+		}
+		return foods, byKode, nil
+	}
+	if !errors.Is(err, ErrSearchIndexUnavailable) {
+		return nil, nil, err
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+	search := strings.TrimSpace(query.Q)
+
+	// % and <% match names close to the whole search and to a word of it, with the trigram indexes
+	var kodes []string
+	if err := db.Raw(`
+		SELECT kode FROM (
+			SELECT kode, name FROM food_catalog_entries
+			UNION ALL
+			SELECT food_kode, name FROM food_names
+		) AS names
+		WHERE name ILIKE ? OR name % ? OR ? <% name
+		GROUP BY kode
+		ORDER BY MAX(GREATEST(similarity(name, ?), word_similarity(?, name))) DESC, kode
+		LIMIT ?`,
+		"%"+likeEscaper.Replace(search)+"%", search, search, search, search, size,
+	).Scan(&kodes).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(kodes) == 0 {
+		return nil, byKode, nil
+	}
+
+	var entries []model.FoodCatalogEntry
+	if err := db.Where("kode IN ?", kodes).Find(&entries).Error; err != nil {
+		return nil, nil, err
+	}
+	foods := make([]model.BahanMakanan, 0, len(entries))
+	for _, entry := range entries {
+		foods = append(foods, entry.Food)
+	}
+
+	var names []model.FoodName
+	if err := db.Where("food_kode IN ?", kodes).Find(&names).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, name := range names {
+		byKode[name.FoodKode] = append(byKode[name.FoodKode], name)
+	}
+
+	return foods, byKode, nil
+}
+
+// rankSignals reads how often every user and the user searching logged the candidate foods
+func (s *foodNameService) rankSignals(db *gorm.DB, userID uuid.UUID, kodes []string) (model.FoodRankSignals, error) {
+	signals := model.FoodRankSignals{
+		Popularity: make(map[string]int),
+		UserLogs:   make(map[string]int),
+	}
+	if len(kodes) == 0 {
+		return signals, nil
+	}
+
+	var popularity []model.FoodPopularity
+	if err := db.Where("food_kode IN ?", kodes).Find(&popularity).Error; err != nil {
+		return signals, err
+	}
+	for _, food := range popularity {
+		signals.Popularity[food.FoodKode] = food.Count
+	}
+
+	var logs []model.FoodLog
+	if err := db.Where("user_id = ? AND food_kode IN ?", userID, kodes).Find(&logs).Error; err != nil {
+		return signals, err
+	}
+	for _, log := range logs {
+		signals.UserLogs[log.FoodKode] = log.Count
+	}
+
+	return signals, nil
+}
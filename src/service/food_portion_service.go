@@ -0,0 +1,123 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type FoodPortionService interface {
+	// GetPortions lists the units a food can be logged in with the weight of one unit
+	GetPortions(c *fiber.Ctx, kode string) ([]model.PortionOption, error)
+	// SetPortions replaces the serving sizes and the density of a food
+	SetPortions(c *fiber.Ctx, kode string, req *validation.SetFoodPortions) ([]model.PortionOption, error)
+	// Convert converts a quantity of a food in a unit to grams with the nutrition of that portion
+	Convert(c *fiber.Ctx, kode string, query *validation.PortionQuery) (*model.PortionConversion, error)
+}
+
+type foodPortionService struct {
+	Log                 *logrus.Logger
+	DB                  *gorm.DB
+	Validate            *validator.Validate
+	BahanMakananService BahanMakananService
+}
+
+func NewFoodPortionService(db *gorm.DB, validate *validator.Validate, bahanMakananService BahanMakananService) FoodPortionService {
+	return &foodPortionService{
+		Log:                 utils.Log,
+		DB:                  db,
+		Validate:            validate,
+		BahanMakananService: bahanMakananService,
+	}
+}
+
+func (s *foodPortionService) GetPortions(c *fiber.Ctx, kode string) ([]model.PortionOption, error) {
+	if _, err := s.BahanMakananService.GetBahanMakananByKode(c, kode); err != nil {
+		return nil, err
+	}
+
+	portions, err := s.portions(c, kode)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.PortionOptions(portions), nil
+}
+
+func (s *foodPortionService) SetPortions(c *fiber.Ctx, kode string, req *validation.SetFoodPortions) ([]model.PortionOption, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+	if _, err := s.BahanMakananService.GetBahanMakananByKode(c, kode); err != nil {
+		return nil, err
+	}
+
+	portions := make([]model.FoodPortion, 0, len(req.Portions))
+	seen := make(map[string]bool)
+	for _, input := range req.Portions {
+		if seen[input.Unit] {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Unit "+input.Unit+" is given twice")
+		}
+		seen[input.Unit] = true
+
+		portions = append(portions, model.FoodPortion{FoodKode: kode, Unit: input.Unit, Grams: input.Grams})
+	}
+
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("food_kode = ?", kode).Delete(&model.FoodPortion{}).Error; err != nil {
+			return err
+		}
+		if len(portions) == 0 {
+			return nil
+		}
+		return tx.Create(&portions).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return model.PortionOptions(portions), nil
+}
+
+func (s *foodPortionService) Convert(c *fiber.Ctx, kode string, query *validation.PortionQuery) (*model.PortionConversion, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	food, err := s.BahanMakananService.GetBahanMakananByKode(c, kode)
+	if err != nil {
+		return nil, err
+	}
+
+	portions, err := s.portions(c, kode)
+	if err != nil {
+		return nil, err
+	}
+
+	grams, approximate, err := model.ConvertPortion(query.Quantity, query.Unit, portions)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return &model.PortionConversion{
+		FoodKode:    kode,
+		Quantity:    query.Quantity,
+		Unit:        query.Unit,
+		Grams:       grams,
+		Approximate: approximate,
+		Nutrition:   model.NutritionOfPortion(*food, grams),
+	}, nil
+}
+
+func (s *foodPortionService) portions(c *fiber.Ctx, kode string) ([]model.FoodPortion, error) {
+	var portions []model.FoodPortion
+	if err := s.DB.WithContext(c.UserContext()).Where("food_kode = ?", kode).Find(&portions).Error; err != nil {
+		return nil, err
+	}
+	return portions, nil
+}
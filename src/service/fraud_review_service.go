@@ -0,0 +1,128 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FraudReviewService interface {
+	GetReviews(c *fiber.Ctx, query *validation.FraudReviewQuery) ([]model.FraudReview, int64, error)
+	ApproveReview(c *fiber.Ctx, adminID, reviewID uuid.UUID, req *validation.ReviewFraud) (*model.FraudReview, error)
+	RejectReview(c *fiber.Ctx, adminID, reviewID uuid.UUID, req *validation.ReviewFraud) (*model.FraudReview, error)
+}
+
+type fraudReviewService struct {
+	Log                 *logrus.Logger
+	DB                  *gorm.DB
+	Validate            *validator.Validate
+	SubscriptionService SubscriptionService
+}
+
+func NewFraudReviewService(db *gorm.DB, validate *validator.Validate, subscriptionService SubscriptionService) FraudReviewService {
+	return &fraudReviewService{
+		Log:                 utils.Log,
+		DB:                  db,
+		Validate:            validate,
+		SubscriptionService: subscriptionService,
+	}
+}
+
+func (s *fraudReviewService) GetReviews(c *fiber.Ctx, query *validation.FraudReviewQuery) ([]model.FraudReview, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []model.FraudReview
+	var totalResults int64
+
+	db := s.DB.WithContext(c.UserContext()).Model(&model.FraudReview{})
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+
+	if err := db.Count(&totalResults).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.
+		Preload("User").
+		Preload("UserSubscription.Plan").
+		Order("created_at ASC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&reviews).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, totalResults, nil
+}
+
+func (s *fraudReviewService) ApproveReview(c *fiber.Ctx, adminID, reviewID uuid.UUID, req *validation.ReviewFraud) (*model.FraudReview, error) {
+	return s.decide(c, adminID, reviewID, req, model.FraudReviewApproved)
+}
+
+func (s *fraudReviewService) RejectReview(c *fiber.Ctx, adminID, reviewID uuid.UUID, req *validation.ReviewFraud) (*model.FraudReview, error) {
+	return s.decide(c, adminID, reviewID, req, model.FraudReviewRejected)
+}
+
+// decide records the review outcome first so the subscription is no longer considered held when it is released
+func (s *fraudReviewService) decide(
+	c *fiber.Ctx, adminID, reviewID uuid.UUID, req *validation.ReviewFraud, status string,
+) (*model.FraudReview, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	review := new(model.FraudReview)
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		// The review stays locked until it is decided, a second admin waits and finds it decided
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(review, "id = ?", reviewID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, "Fraud review not found")
+			}
+			return err
+		}
+
+		if review.Status != model.FraudReviewPending {
+			return fiber.NewError(fiber.StatusConflict, "Fraud review has already been decided")
+		}
+
+		// The payment is released before the decision is saved: a failed release leaves the review pending, and
+		// releasing a subscription that is no longer held changes nothing, so deciding again after a failed save
+		// is safe
+		if _, err := s.SubscriptionService.ReleaseHeldPayment(c, review.UserSubscriptionID, status == model.FraudReviewApproved); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		review.Status = status
+		review.ReviewedByID = &adminID
+		review.ReviewedAt = &now
+		if req.Notes != "" {
+			review.Notes = &req.Notes
+		}
+
+		if err := tx.Model(review).
+			Select("Status", "ReviewedByID", "ReviewedAt", "Notes").
+			Updates(review).Error; err != nil {
+			s.Log.Errorf("Failed to update fraud review %s after releasing its payment: %+v", review.ID, err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
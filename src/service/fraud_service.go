@@ -0,0 +1,288 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// FraudAssessment is the outcome of running the fraud rules against a checkout
+type FraudAssessment struct {
+	Denied    bool
+	Rules     []string
+	IPAddress string
+	Country   string
+}
+
+type FraudService interface {
+	Evaluate(c *fiber.Ctx, user *model.User) (*FraudAssessment, error)
+	FlagForReview(c *fiber.Ctx, subscription *model.UserSubscription, assessment *FraudAssessment) error
+	IsHeld(c *fiber.Ctx, subscriptionID uuid.UUID) (bool, error)
+
+	// Allow/deny list management
+	GetListEntries(c *fiber.Ctx, query *validation.FraudListQuery) ([]model.FraudListEntry, int64, error)
+	CreateListEntry(c *fiber.Ctx, adminID uuid.UUID, req *validation.CreateFraudListEntry) (*model.FraudListEntry, error)
+	DeleteListEntry(c *fiber.Ctx, entryID uuid.UUID) error
+}
+
+// fraudRule reports whether a checkout matches a suspicious pattern
+type fraudRule struct {
+	Name  string
+	Check func(ctx context.Context, user *model.User, country string) (bool, error)
+}
+
+type fraudService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+	Geo      GeoService
+	rules    []fraudRule
+}
+
+func NewFraudService(db *gorm.DB, validate *validator.Validate, geo GeoService) FraudService {
+	s := &fraudService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+		Geo:      geo,
+	}
+
+	s.rules = []fraudRule{
+		{Name: model.FraudRuleFailedPayments, Check: s.checkFailedPayments},
+		{Name: model.FraudRuleGeoMismatch, Check: s.checkGeoMismatch},
+		{Name: model.FraudRulePlanChurn, Check: s.checkPlanChurn},
+	}
+
+	return s
+}
+
+func (s *fraudService) Evaluate(c *fiber.Ctx, user *model.User) (*FraudAssessment, error) {
+	assessment := &FraudAssessment{
+		IPAddress: c.IP(),
+		Country:   s.Geo.Locate(c).Country,
+	}
+
+	listType, err := s.matchList(c.UserContext(), user, assessment.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	switch listType {
+	case model.FraudListDeny:
+		s.Log.Warnf("Checkout denied for user %s from %s by deny list", user.ID, assessment.IPAddress)
+		assessment.Denied = true
+		return assessment, nil
+	case model.FraudListAllow:
+		return assessment, nil
+	}
+
+	for _, rule := range s.rules {
+		matched, err := rule.Check(c.UserContext(), user, assessment.Country)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			assessment.Rules = append(assessment.Rules, rule.Name)
+		}
+	}
+
+	return assessment, nil
+}
+
+func (s *fraudService) FlagForReview(c *fiber.Ctx, subscription *model.UserSubscription, assessment *FraudAssessment) error {
+	rules, err := json.Marshal(assessment.Rules)
+	if err != nil {
+		return err
+	}
+
+	review := &model.FraudReview{
+		UserSubscriptionID: subscription.ID,
+		UserID:             subscription.UserID,
+		OrderID:            subscription.TransactionID,
+		Rules:              model.JSON(rules),
+		IPAddress:          assessment.IPAddress,
+		Country:            assessment.Country,
+		Status:             model.FraudReviewPending,
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Create(review).Error; err != nil {
+		s.Log.Errorf("Failed to flag subscription %s for review: %+v", subscription.ID, err)
+		return err
+	}
+
+	s.Log.Warnf("Subscription %s held for fraud review: %s", subscription.ID, strings.Join(assessment.Rules, ", "))
+	return nil
+}
+
+// IsHeld reports whether a subscription has a fraud review that has not been approved
+func (s *fraudService) IsHeld(c *fiber.Ctx, subscriptionID uuid.UUID) (bool, error) {
+	var count int64
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(&model.FraudReview{}).
+		Where("user_subscription_id = ? AND status <> ?", subscriptionID, model.FraudReviewApproved).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (s *fraudService) GetListEntries(c *fiber.Ctx, query *validation.FraudListQuery) ([]model.FraudListEntry, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	var entries []model.FraudListEntry
+	var totalResults int64
+
+	db := s.DB.WithContext(c.UserContext()).Model(&model.FraudListEntry{})
+	if query.ListType != "" {
+		db = db.Where("list_type = ?", query.ListType)
+	}
+
+	if err := db.Count(&totalResults).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.
+		Order("created_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return entries, totalResults, nil
+}
+
+func (s *fraudService) CreateListEntry(c *fiber.Ctx, adminID uuid.UUID, req *validation.CreateFraudListEntry) (*model.FraudListEntry, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	value := strings.TrimSpace(req.Value)
+	switch req.EntryType {
+	case model.FraudEntryUser:
+		if _, err := uuid.Parse(value); err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid user ID format")
+		}
+	case model.FraudEntryEmail:
+		value = strings.ToLower(value)
+	}
+
+	entry := &model.FraudListEntry{
+		ListType:    req.ListType,
+		EntryType:   req.EntryType,
+		Value:       value,
+		Reason:      req.Reason,
+		CreatedByID: &adminID,
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Create(entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, fiber.NewError(fiber.StatusConflict, "Entry already exists")
+		}
+		s.Log.Errorf("Failed to create fraud list entry: %+v", err)
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (s *fraudService) DeleteListEntry(c *fiber.Ctx, entryID uuid.UUID) error {
+	result := s.DB.WithContext(c.UserContext()).Delete(&model.FraudListEntry{}, "id = ?", entryID)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "Entry not found")
+	}
+
+	return nil
+}
+
+// matchList returns the list the checkout is on, deny entries take precedence over allow entries
+func (s *fraudService) matchList(ctx context.Context, user *model.User, ipAddress string) (string, error) {
+	var entries []model.FraudListEntry
+	if err := s.DB.WithContext(ctx).
+		Where("(entry_type = ? AND value = ?) OR (entry_type = ? AND value = ?) OR (entry_type = ? AND value = ?)",
+			model.FraudEntryUser, user.ID.String(),
+			model.FraudEntryEmail, strings.ToLower(user.Email),
+			model.FraudEntryIP, ipAddress).
+		Find(&entries).Error; err != nil {
+		return "", err
+	}
+
+	listType := ""
+	for _, entry := range entries {
+		if entry.ListType == model.FraudListDeny {
+			return model.FraudListDeny, nil
+		}
+		listType = entry.ListType
+	}
+
+	return listType, nil
+}
+
+// checkFailedPayments flags users with repeated declined card payments
+func (s *fraudService) checkFailedPayments(ctx context.Context, user *model.User, _ string) (bool, error) {
+	var failed int64
+	if err := s.DB.WithContext(ctx).
+		Model(&model.TransactionDetail{}).
+		Joins("JOIN user_subscriptions ON user_subscriptions.id = transaction_details.user_subscription_id").
+		Where("user_subscriptions.user_id = ?", user.ID).
+		Where("transaction_details.payment_type = ?", "credit_card").
+		Where("transaction_details.transaction_status IN ?", []string{"deny", "failure", "cancel"}).
+		Where("transaction_details.transaction_time >= ?", time.Now().Add(-time.Duration(config.FraudFailedWindowHours)*time.Hour)).
+		Count(&failed).Error; err != nil {
+		return false, err
+	}
+
+	return failed >= int64(config.FraudMaxFailedPayments.Get()), nil
+}
+
+// checkGeoMismatch flags checkouts from a different country than the user's last paid checkout
+func (s *fraudService) checkGeoMismatch(ctx context.Context, user *model.User, country string) (bool, error) {
+	if country == "" {
+		return false, nil
+	}
+
+	var last model.UserSubscription
+	if err := s.DB.WithContext(ctx).
+		Where("user_id = ? AND payment_status = ? AND checkout_country <> ?", user.ID, "success", "").
+		Order("created_at DESC").
+		First(&last).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return last.CheckoutCountry != country, nil
+}
+
+// checkPlanChurn flags users starting many checkouts in a short period
+func (s *fraudService) checkPlanChurn(ctx context.Context, user *model.User, _ string) (bool, error) {
+	var purchases int64
+	if err := s.DB.WithContext(ctx).
+		Model(&model.UserSubscription{}).
+		Where("user_id = ? AND created_at >= ?", user.ID, time.Now().AddDate(0, 0, -config.FraudChurnWindowDays)).
+		Count(&purchases).Error; err != nil {
+		return false, err
+	}
+
+	return purchases >= int64(config.FraudMaxPurchases.Get()), nil
+}
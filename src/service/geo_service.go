@@ -0,0 +1,128 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/geoip"
+	"app/src/model"
+	"app/src/utils"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// geoCacheMaxEntries bounds the addresses kept in memory, the cache is emptied when it is full
+	geoCacheMaxEntries = 10000
+	// geoFailureTTL is how long an address the lookup failed for is left unknown before it is looked up again
+	geoFailureTTL = time.Minute
+)
+
+type GeoService interface {
+	// Locate returns where the request comes from: the CDN headers first, then the lookup service for public
+	// addresses. The location is worked out once per request, a failed lookup leaves it unknown.
+	Locate(c *fiber.Ctx) geoip.Location
+	// Defaults returns the locale and currency of the visitor, those of the home country when unknown
+	Defaults(c *fiber.Ctx) model.RegionalDefaults
+}
+
+type geoService struct {
+	Log     *logrus.Logger
+	Locator geoip.Locator // nil when only the headers are read
+
+	mu    sync.Mutex
+	cache map[string]cachedLocation // by IP address
+}
+
+type cachedLocation struct {
+	location  geoip.Location
+	expiresAt time.Time
+}
+
+func NewGeoService() GeoService {
+	locator, err := geoip.New(config.GeoIPProvider, geoip.Settings{
+		Token:    config.GeoIPToken,
+		Endpoint: config.GeoIPEndpoint,
+		Timeout:  config.GeoIPTimeout,
+	})
+	if err != nil {
+		utils.Log.Errorf("IP lookups are off: %v", err)
+	}
+
+	return &geoService{
+		Log:     utils.Log,
+		Locator: locator,
+		cache:   map[string]cachedLocation{},
+	}
+}
+
+func (s *geoService) Locate(c *fiber.Ctx) geoip.Location {
+	if location, ok := c.Locals(geoip.LocalsKey).(geoip.Location); ok {
+		return location
+	}
+
+	location := s.fromHeaders(c)
+	if !location.Known() {
+		location = s.lookup(c)
+	}
+
+	c.Locals(geoip.LocalsKey, location)
+	return location
+}
+
+func (s *geoService) Defaults(c *fiber.Ctx) model.RegionalDefaults {
+	return model.RegionalDefaultsOf(s.Locate(c).Country)
+}
+
+// fromHeaders reads the location the CDN sent, the region and city only count with a country
+func (s *geoService) fromHeaders(c *fiber.Ctx) geoip.Location {
+	if config.GeoIPCountryHeader == "" {
+		return geoip.Location{}
+	}
+
+	location := geoip.Location{Country: geoip.NormalizeCountry(c.Get(config.GeoIPCountryHeader))}
+	if !location.Known() {
+		return location
+	}
+	if config.GeoIPRegionHeader != "" {
+		location.Region = strings.TrimSpace(c.Get(config.GeoIPRegionHeader))
+	}
+	if config.GeoIPCityHeader != "" {
+		location.City = strings.TrimSpace(c.Get(config.GeoIPCityHeader))
+	}
+	return location
+}
+
+// lookup asks the lookup service where the client address is, answers are kept in memory only
+func (s *geoService) lookup(c *fiber.Ctx) geoip.Location {
+	ip := c.IP()
+	if s.Locator == nil || !geoip.IsPublic(ip) {
+		return geoip.Location{}
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.cache[ip]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.location
+	}
+
+	location, err := s.Locator.Locate(c.UserContext(), ip)
+	ttl := config.GeoIPCacheTTL
+	if err != nil {
+		s.Log.Warnf("Failed to locate an address with %s: %v", s.Locator.Name(), err)
+		location, ttl = geoip.Location{}, geoFailureTTL
+	}
+
+	s.mu.Lock()
+	if len(s.cache) >= geoCacheMaxEntries {
+		s.cache = map[string]cachedLocation{}
+	}
+	s.cache[ip] = cachedLocation{location: location, expiresAt: now.Add(ttl)}
+	s.mu.Unlock()
+
+	return location
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"app/src/clock"
+	"app/src/config"
+	"app/src/model"
+	"app/src/textfilter"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type HandleService interface {
+	// CheckAvailability tells whether the user can take a handle, their own handle counts as available
+	CheckAvailability(c *fiber.Ctx, userID uuid.UUID, query *validation.HandleQuery) (*model.HandleAvailability, error)
+	// SetHandle picks or renames the public handle of the user. A rename waits for config.HandleRenameCooldown
+	// after the last change.
+	SetHandle(c *fiber.Ctx, userID uuid.UUID, req *validation.UpdateHandle) (*model.User, error)
+}
+
+type handleService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewHandleService(db *gorm.DB, validate *validator.Validate) HandleService {
+	return &handleService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+// handleProblem returns why a normalized handle cannot be used whoever asks, or an empty string
+func handleProblem(handle string) string {
+	if problem := model.HandleProblem(handle); problem != "" {
+		return problem
+	}
+	// Handles are shown to everyone, so even mild words are refused
+	if textfilter.Check(handle) != textfilter.SeverityNone {
+		return "Handle is not allowed"
+	}
+	return ""
+}
+
+func (s *handleService) CheckAvailability(c *fiber.Ctx, userID uuid.UUID, query *validation.HandleQuery) (*model.HandleAvailability, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	availability := &model.HandleAvailability{Handle: model.NormalizeHandle(query.Name)}
+	if problem := handleProblem(availability.Handle); problem != "" {
+		availability.Reason = problem
+		return availability, nil
+	}
+
+	var taken int64
+	if err := s.DB.WithContext(c.UserContext()).Model(&model.User{}).
+		Where("handle = ? AND id <> ?", availability.Handle, userID).
+		Count(&taken).Error; err != nil {
+		return nil, err
+	}
+	if taken > 0 {
+		availability.Reason = "Handle is already taken"
+		return availability, nil
+	}
+
+	availability.Available = true
+	return availability, nil
+}
+
+func (s *handleService) SetHandle(c *fiber.Ctx, userID uuid.UUID, req *validation.UpdateHandle) (*model.User, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	handle := model.NormalizeHandle(req.Handle)
+	if problem := handleProblem(handle); problem != "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, problem)
+	}
+
+	user := new(model.User)
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(user, "id = ?", userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, "User not found")
+			}
+			return err
+		}
+		if user.Handle != nil && *user.Handle == handle {
+			return nil
+		}
+
+		now := clock.Now(c.UserContext())
+		if user.Handle != nil {
+			if renameAt := model.HandleRenameAt(user.HandleChangedAt, config.HandleRenameCooldown); now.Before(renameAt) {
+				return fiber.NewError(fiber.StatusTooManyRequests,
+					fmt.Sprintf("Handle can be changed again after %s", renameAt.Format("2006-01-02 15:04 MST")))
+			}
+		}
+
+		if err := tx.Model(user).Updates(map[string]any{"handle": handle, "handle_changed_at": now}).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return fiber.NewError(fiber.StatusConflict, "Handle is already taken")
+			}
+			return err
+		}
+		user.Handle, user.HandleChangedAt = &handle, &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
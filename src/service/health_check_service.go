@@ -2,6 +2,7 @@ package service
 
 import (
 	"app/src/utils"
+	"context"
 	"errors"
 	"runtime"
 
@@ -12,17 +13,21 @@ import (
 type HealthCheckService interface {
 	GormCheck() error
 	MemoryHeapCheck() error
+	// SearchIndexCheck reports whether the search index answers, false when none is configured
+	SearchIndexCheck(ctx context.Context) (bool, error)
 }
 
 type healthCheckService struct {
-	Log *logrus.Logger
-	DB  *gorm.DB
+	Log                *logrus.Logger
+	DB                 *gorm.DB
+	SearchIndexService SearchIndexService
 }
 
-func NewHealthCheckService(db *gorm.DB) HealthCheckService {
+func NewHealthCheckService(db *gorm.DB, searchIndexService SearchIndexService) HealthCheckService {
 	return &healthCheckService{
-		Log: utils.Log,
-		DB:  db,
+		Log:                utils.Log,
+		DB:                 db,
+		SearchIndexService: searchIndexService,
 	}
 }
 
@@ -59,3 +64,16 @@ func (s *healthCheckService) MemoryHeapCheck() error {
 
 	return nil
 }
+
+func (s *healthCheckService) SearchIndexCheck(ctx context.Context) (bool, error) {
+	if !s.SearchIndexService.Enabled() {
+		return false, nil
+	}
+
+	if err := s.SearchIndexService.Health(ctx); err != nil {
+		s.Log.Errorf("search index is down: %v", err)
+		return true, err
+	}
+
+	return true, nil
+}
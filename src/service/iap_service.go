@@ -0,0 +1,428 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/iap"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type IAPService interface {
+	VerifyAppleReceipt(c *fiber.Ctx, userID uuid.UUID, req *validation.VerifyAppleReceipt) (*model.UserSubscriptionResponse, error)
+	VerifyGooglePurchase(c *fiber.Ctx, userID uuid.UUID, req *validation.VerifyGooglePurchase) (*model.UserSubscriptionResponse, error)
+	HandleAppleNotification(c *fiber.Ctx, req *validation.AppleNotification) error
+	HandleGoogleNotification(c *fiber.Ctx, token string, body []byte) error
+
+	// Store product mapping
+	GetStoreProducts(c *fiber.Ctx) ([]model.StoreProduct, error)
+	CreateStoreProduct(c *fiber.Ctx, req *validation.CreateStoreProduct) (*model.StoreProduct, error)
+	DeleteStoreProduct(c *fiber.Ctx, productID uuid.UUID) error
+}
+
+type iapService struct {
+	Log                 *logrus.Logger
+	DB                  *gorm.DB
+	Validate            *validator.Validate
+	SubscriptionService SubscriptionService
+	ScanQuota           ScanQuotaService
+	Apple               *iap.AppleClient
+	Google              *iap.GoogleClient
+}
+
+// NewIAPService creates the in-app purchase service, a store whose client is nil is treated as not configured
+func NewIAPService(
+	db *gorm.DB, validate *validator.Validate, subscriptionService SubscriptionService, scanQuota ScanQuotaService,
+	apple *iap.AppleClient, google *iap.GoogleClient,
+) IAPService {
+	return &iapService{
+		Log:                 utils.Log,
+		DB:                  db,
+		Validate:            validate,
+		SubscriptionService: subscriptionService,
+		ScanQuota:           scanQuota,
+		Apple:               apple,
+		Google:              google,
+	}
+}
+
+func (s *iapService) VerifyAppleReceipt(c *fiber.Ctx, userID uuid.UUID, req *validation.VerifyAppleReceipt) (*model.UserSubscriptionResponse, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if s.Apple == nil {
+		return nil, fiber.NewError(fiber.StatusServiceUnavailable, "App Store purchases are not available")
+	}
+
+	receipt, err := s.Apple.VerifyReceipt(c.UserContext(), req.ReceiptData)
+	if err != nil {
+		s.Log.Warnf("Apple receipt validation failed for user %s: %v", userID, err)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid App Store receipt")
+	}
+
+	purchase, err := s.applyReceipt(c, &userID, receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.SubscriptionService.GetUserSubscriptionByID(c, purchase.UserSubscriptionID)
+}
+
+func (s *iapService) VerifyGooglePurchase(c *fiber.Ctx, userID uuid.UUID, req *validation.VerifyGooglePurchase) (*model.UserSubscriptionResponse, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if s.Google == nil {
+		return nil, fiber.NewError(fiber.StatusServiceUnavailable, "Google Play purchases are not available")
+	}
+
+	receipt, needsAck, err := s.Google.GetSubscription(c.UserContext(), req.PurchaseToken)
+	if err != nil {
+		s.Log.Warnf("Google purchase validation failed for user %s: %v", userID, err)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid Google Play purchase")
+	}
+
+	if receipt.ProductID != req.ProductID {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Purchase does not match the product")
+	}
+
+	purchase, err := s.applyReceipt(c, &userID, receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	if needsAck {
+		if err := s.Google.Acknowledge(c.UserContext(), receipt.ProductID, req.PurchaseToken); err != nil {
+			// The next verification or notification retries the acknowledgement
+			s.Log.Errorf("Failed to acknowledge google purchase for subscription %s: %v", purchase.UserSubscriptionID, err)
+		}
+	}
+
+	return s.SubscriptionService.GetUserSubscriptionByID(c, purchase.UserSubscriptionID)
+}
+
+func (s *iapService) HandleAppleNotification(c *fiber.Ctx, req *validation.AppleNotification) error {
+	if err := s.Validate.Struct(req); err != nil {
+		return err
+	}
+
+	if s.Apple == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "App Store purchases are not available")
+	}
+
+	notification, err := s.Apple.ParseNotification(req.SignedPayload)
+	if err != nil {
+		s.Log.Errorf("Rejected apple notification: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid notification")
+	}
+
+	s.Log.Infof("Apple notification %s %s", notification.NotificationType, notification.Subtype)
+	if notification.Receipt == nil {
+		return nil
+	}
+
+	_, err = s.applyReceipt(c, nil, notification.Receipt)
+	return err
+}
+
+func (s *iapService) HandleGoogleNotification(c *fiber.Ctx, token string, body []byte) error {
+	if config.GooglePlayNotificationToken == "" ||
+		subtle.ConstantTimeCompare([]byte(token), []byte(config.GooglePlayNotificationToken)) != 1 {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid notification token")
+	}
+
+	if s.Google == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Google Play purchases are not available")
+	}
+
+	notification, err := iap.ParseGoogleNotification(body)
+	if err != nil {
+		s.Log.Errorf("Rejected google notification: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid notification")
+	}
+
+	if notification.IsTest || notification.PurchaseToken == "" {
+		return nil
+	}
+
+	if notification.PackageName != s.Google.PackageName {
+		return fiber.NewError(fiber.StatusBadRequest, "Notification belongs to another app")
+	}
+
+	s.Log.Infof("Google notification type %d for product %s", notification.NotificationType, notification.ProductID)
+
+	receipt, _, err := s.Google.GetSubscription(c.UserContext(), notification.PurchaseToken)
+	if err != nil {
+		return err
+	}
+
+	if notification.NotificationType == iap.GoogleNotificationRevoked {
+		receipt.Revoked = true
+		receipt.Active = false
+	}
+
+	_, err = s.applyReceipt(c, nil, receipt)
+	return err
+}
+
+func (s *iapService) GetStoreProducts(c *fiber.Ctx) ([]model.StoreProduct, error) {
+	var products []model.StoreProduct
+	if err := s.DB.WithContext(c.UserContext()).
+		Preload("Plan").
+		Order("store ASC, product_id ASC").
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func (s *iapService) CreateStoreProduct(c *fiber.Ctx, req *validation.CreateStoreProduct) (*model.StoreProduct, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	planID := uuid.MustParse(req.PlanID)
+	if _, err := s.SubscriptionService.GetSubscriptionPlanByID(c, planID); err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+	}
+
+	product := &model.StoreProduct{
+		Store:     req.Store,
+		ProductID: req.ProductID,
+		PlanID:    planID,
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Create(product).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, fiber.NewError(fiber.StatusConflict, "Store product is already mapped")
+		}
+		return nil, err
+	}
+
+	return product, nil
+}
+
+func (s *iapService) DeleteStoreProduct(c *fiber.Ctx, productID uuid.UUID) error {
+	result := s.DB.WithContext(c.UserContext()).Delete(&model.StoreProduct{}, "id = ?", productID)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "Store product not found")
+	}
+
+	return nil
+}
+
+// applyReceipt creates or updates the subscription backed by a store purchase.
+// Only a verification request from the app (userID set) may create a new one, notifications update existing purchases.
+func (s *iapService) applyReceipt(c *fiber.Ctx, userID *uuid.UUID, receipt *iap.Receipt) (*model.StorePurchase, error) {
+	var product model.StoreProduct
+	if err := s.DB.WithContext(c.UserContext()).
+		Preload("Plan").
+		Where("store = ? AND product_id = ?", receipt.Store, receipt.ProductID).
+		First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Store product is not linked to a plan")
+		}
+		return nil, err
+	}
+
+	paymentStatus, isActive := storeSubscriptionStatus(receipt)
+
+	purchase := new(model.StorePurchase)
+	renewed := false
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("store = ? AND original_transaction_id = ?", receipt.Store, receipt.OriginalTransactionID).
+			First(purchase).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		isNew := errors.Is(err, gorm.ErrRecordNotFound)
+		if isNew && userID == nil {
+			s.Log.Warnf("Ignoring %s notification for unknown purchase %s", receipt.Store, receipt.OriginalTransactionID)
+			purchase = nil
+			return nil
+		}
+
+		if !isNew && userID != nil && purchase.UserID != *userID {
+			return fiber.NewError(fiber.StatusConflict, "Purchase is linked to another account")
+		}
+
+		ownerID := purchase.UserID
+		if isNew {
+			ownerID = *userID
+		}
+		if accepted, err := acceptsStorePurchase(tx, ownerID, receipt); err != nil {
+			return err
+		} else if !accepted {
+			if userID == nil {
+				s.Log.Warnf("Ignoring %s notification for test purchase %s of user %s", receipt.Store, receipt.OriginalTransactionID, ownerID)
+				purchase = nil
+				return nil
+			}
+			return fiber.NewError(fiber.StatusForbidden, "Store test purchases are only accepted from sandbox accounts")
+		}
+
+		var subscription model.UserSubscription
+		if isNew {
+			subscription = model.UserSubscription{
+				UserID:        *userID,
+				PlanID:        product.PlanID,
+				StartDate:     time.Now(),
+				PaymentMethod: storeSource(receipt.Store),
+				Source:        storeSource(receipt.Store),
+			}
+		} else if err := tx.First(&subscription, "id = ?", purchase.UserSubscriptionID).Error; err != nil {
+			return err
+		}
+
+		subscription.PlanID = product.PlanID
+		subscription.EndDate = receipt.ExpiresAt
+		subscription.TransactionID = receipt.TransactionID
+		subscription.PaymentStatus = paymentStatus
+		subscription.IsActive = isActive
+		subscription.IsSandbox = receipt.IsSandbox()
+		subscription.SourceReference = receipt.OriginalTransactionID
+		subscription.SourceMetadata = model.NewSourceMetadata(map[string]interface{}{
+			"store":       receipt.Store,
+			"product_id":  receipt.ProductID,
+			"auto_renew":  receipt.AutoRenew,
+			"environment": receipt.Environment,
+		})
+		newPeriod := isNew || purchase.LatestTransactionID != receipt.TransactionID
+
+		// Every period is bought again, on the terms of the plan at the time
+		if newPeriod {
+			subscription.PlanSnapshot = nil
+		}
+		// Store renewals keep the subscription, the usage of the period they start is counted from zero
+		if newPeriod && !isNew && !receipt.Revoked {
+			subscription.AIscansUsed = 0
+			subscription.VoiceLogsUsed = 0
+			renewed = true
+		}
+		if err := snapshotPlan(tx, &subscription, nil); err != nil {
+			return err
+		}
+		if isNew {
+			err = createSubscription(tx, &subscription, receipt.Store+" purchase", userID)
+		} else {
+			err = syncSubscriptionStatus(tx, &subscription, receipt.Store+" notification", requestActor(c), time.Now())
+			if isTransitionConflict(err) {
+				// The store already applied it, the purchase is recorded while the status stays
+				s.Log.Warnf("Subscription %s keeps status %s on %s purchase %s: %v",
+					subscription.ID, subscription.Status, receipt.Store, receipt.OriginalTransactionID, err)
+				err = nil
+			}
+			if err == nil {
+				err = tx.Save(&subscription).Error
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		purchase.UserSubscriptionID = subscription.ID
+		purchase.UserID = subscription.UserID
+		purchase.Store = receipt.Store
+		purchase.ProductID = receipt.ProductID
+		purchase.OriginalTransactionID = receipt.OriginalTransactionID
+		purchase.LatestTransactionID = receipt.TransactionID
+		purchase.ExpiresAt = receipt.ExpiresAt
+		purchase.AutoRenew = receipt.AutoRenew
+		purchase.Environment = receipt.Environment
+		if err := tx.Save(purchase).Error; err != nil {
+			return err
+		}
+
+		if newPeriod && !receipt.Revoked {
+			detail := &model.TransactionDetail{
+				UserSubscriptionID: subscription.ID,
+				OrderID:            receipt.TransactionID,
+				TransactionID:      receipt.TransactionID,
+				TransactionStatus:  "settlement",
+				TransactionTime:    time.Now(),
+				StatusMessage:      fmt.Sprintf("Paid through %s in-app purchase", receipt.Store),
+				PaymentType:        subscription.PaymentMethod,
+				GrossAmount:        product.Plan.PriceMoney().Decimal(),
+				Currency:           product.Plan.Currency,
+				IsSandbox:          subscription.IsSandbox,
+				RawResponse:        model.JSON(receipt.Raw),
+			}
+			if err := snapshotPlan(tx, &subscription, detail); err != nil {
+				return err
+			}
+			if err := tx.Create(detail).Error; err != nil {
+				return err
+			}
+			if err := recognizeRevenue(tx, detail, &subscription); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if renewed {
+		s.ScanQuota.Reset(context.WithoutCancel(c.UserContext()), purchase.UserSubscriptionID)
+	}
+
+	if purchase != nil {
+		s.Log.Infof("Store purchase %s for subscription %s is now %s until %s",
+			purchase.ID, purchase.UserSubscriptionID, paymentStatus, receipt.ExpiresAt.Format(time.RFC3339))
+	}
+
+	return purchase, nil
+}
+
+// acceptsStorePurchase reports whether the purchase of a receipt can back a subscription of the user. Purchases made
+// with a store test account unlock the app for free, in production they are only accepted from sandbox users, such
+// as the account given to app review.
+func acceptsStorePurchase(tx *gorm.DB, userID uuid.UUID, receipt *iap.Receipt) (bool, error) {
+	if !receipt.IsSandbox() || !config.IsProd {
+		return true, nil
+	}
+
+	var user model.User
+	if err := tx.Select("id", "is_sandbox").First(&user, "id = ?", userID).Error; err != nil {
+		return false, err
+	}
+	return user.IsSandbox, nil
+}
+
+func storeSubscriptionStatus(receipt *iap.Receipt) (string, bool) {
+	switch {
+	case receipt.Revoked:
+		return "refunded", false
+	case receipt.Active:
+		return "success", true
+	default:
+		return "expired", false
+	}
+}
+
+func storeSource(store string) string {
+	if store == model.StoreApple {
+		return model.SourceAppleIAP
+	}
+	return model.SourceGooglePlay
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// idempotencyLockTimeout is how long a key stays taken by a request that never completed, e.g. because its
+// instance died, before a retry may run it again
+const idempotencyLockTimeout = 2 * time.Minute
+
+type IdempotencyService interface {
+	// Begin takes key for a request in scope. It returns false and the key as stored when an earlier request took
+	// it, with its response once that request completed.
+	Begin(ctx context.Context, scope, key, fingerprint string) (*model.IdempotencyKey, bool, error)
+	// Complete stores the response of the request that took the key
+	Complete(ctx context.Context, key *model.IdempotencyKey, statusCode int, contentType string, body []byte) error
+	// Release frees a key whose request failed, so a retry runs it again
+	Release(ctx context.Context, key *model.IdempotencyKey) error
+
+	// PurgeExpired deletes the keys older than IDEMPOTENCY_KEY_TTL
+	PurgeExpired(ctx context.Context) error
+}
+
+type idempotencyService struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewIdempotencyService(db *gorm.DB) IdempotencyService {
+	return &idempotencyService{
+		Log: utils.Log,
+		DB:  db,
+	}
+}
+
+func (s *idempotencyService) Begin(ctx context.Context, scope, key, fingerprint string) (*model.IdempotencyKey, bool, error) {
+	db := s.DB.WithContext(ctx)
+	now := time.Now()
+
+	// An expired key, or one its request abandoned, is free again
+	if err := db.
+		Where("scope = ? AND key = ?", scope, key).
+		Where("expires_at < ? OR (completed_at IS NULL AND created_at < ?)", now, now.Add(-idempotencyLockTimeout)).
+		Delete(&model.IdempotencyKey{}).Error; err != nil {
+		return nil, false, err
+	}
+
+	record := &model.IdempotencyKey{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(config.IdempotencyKeyTTL),
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return record, true, nil
+	}
+
+	existing := new(model.IdempotencyKey)
+	if err := db.First(existing, "scope = ? AND key = ?", scope, key).Error; err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, key *model.IdempotencyKey, statusCode int, contentType string, body []byte) error {
+	now := time.Now()
+	key.StatusCode = statusCode
+	key.ContentType = contentType
+	key.Body = body
+	key.CompletedAt = &now
+
+	return s.DB.WithContext(ctx).Model(key).Updates(map[string]interface{}{
+		"status_code":  key.StatusCode,
+		"content_type": key.ContentType,
+		"body":         key.Body,
+		"completed_at": key.CompletedAt,
+	}).Error
+}
+
+func (s *idempotencyService) Release(ctx context.Context, key *model.IdempotencyKey) error {
+	return s.DB.WithContext(ctx).
+		Where("scope = ? AND key = ? AND completed_at IS NULL", key.Scope, key.Key).
+		Delete(&model.IdempotencyKey{}).Error
+}
+
+func (s *idempotencyService) PurgeExpired(ctx context.Context) error {
+	result := s.DB.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&model.IdempotencyKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		s.Log.Infof("Purged %d expired idempotency keys", result.RowsAffected)
+	}
+	return nil
+}
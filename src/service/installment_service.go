@@ -0,0 +1,191 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// installmentBillingLeadDays is how many days before the due date a payment link is sent
+const installmentBillingLeadDays = 3
+
+type InstallmentService interface {
+	GetInstallments(c *fiber.Ctx, userID, subscriptionID uuid.UUID) ([]model.InstallmentSchedule, error)
+
+	// Background jobs
+	BillDueInstallments(ctx context.Context) error
+	SuspendOverdueSubscriptions(ctx context.Context) error
+}
+
+type installmentService struct {
+	Log            *logrus.Logger
+	DB             *gorm.DB
+	Payment        PaymentGateway
+	SandboxPayment PaymentGateway
+	EmailService   EmailService
+}
+
+func NewInstallmentService(db *gorm.DB, payment, sandboxPayment PaymentGateway, emailService EmailService) InstallmentService {
+	return &installmentService{
+		Log:            utils.Log,
+		DB:             db,
+		Payment:        payment,
+		SandboxPayment: sandboxPayment,
+		EmailService:   emailService,
+	}
+}
+
+func (s *installmentService) GetInstallments(c *fiber.Ctx, userID, subscriptionID uuid.UUID) ([]model.InstallmentSchedule, error) {
+	var subscription model.UserSubscription
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("id = ? AND user_id = ?", subscriptionID, userID).
+		First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription not found")
+		}
+		return nil, err
+	}
+
+	if !subscription.IsInstallment {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Subscription is not paid in installments")
+	}
+
+	var installments []model.InstallmentSchedule
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("user_subscription_id = ?", subscriptionID).
+		Order("installment_number ASC").
+		Find(&installments).Error; err != nil {
+		return nil, err
+	}
+
+	return installments, nil
+}
+
+// BillDueInstallments creates a gateway payment link for every upcoming installment and emails it to the user.
+// Failed payments are billed again with a new link once the retry interval passed, until the attempts run out.
+func (s *installmentService) BillDueInstallments(ctx context.Context) error {
+	now := time.Now()
+
+	var installments []model.InstallmentSchedule
+	if err := s.DB.WithContext(ctx).
+		Preload("UserSubscription.User").
+		Joins("JOIN user_subscriptions ON user_subscriptions.id = installment_schedules.user_subscription_id").
+		Where("(installment_schedules.status = ? AND installment_schedules.order_id IS NULL) OR "+
+			"(installment_schedules.status = ? AND installment_schedules.attempts < ? AND installment_schedules.updated_at <= ?)",
+			model.InstallmentPending, model.InstallmentFailed, config.InstallmentMaxAttempts, now.Add(-config.InstallmentRetryInterval)).
+		Where("installment_schedules.due_date <= ?", now.AddDate(0, 0, installmentBillingLeadDays)).
+		Where("user_subscriptions.payment_status IN ? AND user_subscriptions.deleted_at IS NULL", []string{"success", "suspended"}).
+		Find(&installments).Error; err != nil {
+		return err
+	}
+
+	for i := range installments {
+		if err := s.billInstallment(ctx, &installments[i]); err != nil {
+			s.Log.Errorf("Failed to bill installment %s: %v", installments[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *installmentService) billInstallment(ctx context.Context, installment *model.InstallmentSchedule) error {
+	subscription := installment.UserSubscription
+	user := subscription.User
+
+	gateway, err := paymentGateway(s.Payment, s.SandboxPayment, subscription.IsSandbox)
+	if err != nil {
+		return err
+	}
+
+	orderID := model.SandboxOrderID(
+		fmt.Sprintf("INST-%s-%d-%d", subscription.ID.String()[:8], installment.InstallmentNumber, time.Now().Unix()),
+		subscription.IsSandbox)
+	userDetails := map[string]interface{}{
+		"first_name": user.Name,
+		"last_name":  "",
+		"email":      user.Email,
+		"phone":      user.Phone,
+	}
+
+	paymentToken, err := gateway.CreateTransaction(ctx, orderID, installment.Amount, userDetails, subscription.PaymentMethod)
+	if err != nil {
+		return fmt.Errorf("payment creation failed: %w", err)
+	}
+
+	// A retry replaces the link of the failed payment, the installment waits for the new one
+	installment.OrderID = &orderID
+	installment.PaymentURL = &paymentToken.RedirectURL
+	installment.Status = model.InstallmentPending
+	installment.Attempts++
+	if err := s.DB.WithContext(ctx).
+		Model(installment).
+		Select("OrderID", "PaymentURL", "Status", "Attempts").
+		Updates(installment).Error; err != nil {
+		return err
+	}
+
+	if errEmail := s.EmailService.SendInstallmentBillEmail(user.Email, installment.InstallmentNumber,
+		installment.AmountMoney(), installment.DueDate, paymentToken.RedirectURL, config.InstallmentGraceDays.Get()); errEmail != nil {
+		s.Log.Warnf("Failed to send installment reminder to %s: %v", user.Email, errEmail)
+	}
+
+	return nil
+}
+
+// SuspendOverdueSubscriptions deactivates subscriptions with an installment unpaid past the grace period
+func (s *installmentService) SuspendOverdueSubscriptions(ctx context.Context) error {
+	cutoff := time.Now().AddDate(0, 0, -config.InstallmentGraceDays.Get())
+
+	overdue := s.DB.
+		Model(&model.InstallmentSchedule{}).
+		Select("user_subscription_id").
+		Where("status <> ? AND due_date < ?", model.InstallmentPaid, cutoff)
+
+	var suspended int
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var subscriptions []model.UserSubscription
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("is_installment = ? AND payment_status = ? AND id IN (?)", true, "success", overdue).
+			Find(&subscriptions).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for i := range subscriptions {
+			subscription := &subscriptions[i]
+			subscription.IsActive = false
+			subscription.PaymentStatus = "suspended"
+			if err := syncSubscriptionStatus(tx, subscription, "installment overdue", nil, now); err != nil {
+				// An ended subscription has nothing left to suspend
+				if isTransitionConflict(err) {
+					continue
+				}
+				return err
+			}
+			if err := tx.Model(subscription).Select("IsActive", "PaymentStatus").Updates(subscription).Error; err != nil {
+				return err
+			}
+			suspended++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if suspended > 0 {
+		s.Log.Infof("Suspended %d subscriptions with overdue installments", suspended)
+	}
+
+	return nil
+}
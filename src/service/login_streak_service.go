@@ -2,6 +2,7 @@ package service
 
 import (
 	"app/src/model"
+	"app/src/utils"
 	"errors"
 	"time"
 
@@ -85,7 +86,19 @@ func (service *loginStreakServiceImpl) RecordLogin(userID uuid.UUID) error {
 		LongestStreak: longestStreak,
 	}
 
-	return service.DB.Create(&newStreak).Error
+	if err := service.DB.Create(&newStreak).Error; err != nil {
+		return err
+	}
+
+	if err := setUserStreak(service.DB, userID, currentStreak); err != nil {
+		utils.Log.Errorf("Failed to store streak for user %s: %v", userID, err)
+	}
+	if err := appendEvent(service.DB, model.EventUserLoggedIn, userID, newStreak.ID,
+		model.EventPayload{Day: model.EventDay(todayStart), Streak: currentStreak}, today); err != nil {
+		utils.Log.Errorf("Failed to append login event of user %s: %v", userID, err)
+	}
+
+	return nil
 }
 
 func (service *loginStreakServiceImpl) GetLoginStreak(userID uuid.UUID) (*model.LoginStreakResponse, error) {
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// magicLinkRetention is how long expired links are kept, for the hourly limit and support questions
+	magicLinkRetention = 24 * time.Hour
+
+	// magicLinkSendTimeout bounds the lookup and email of a link, they run after the request is answered
+	magicLinkSendTimeout = 30 * time.Second
+)
+
+type MagicLinkService interface {
+	// Request emails a link that signs in the user of the email, and returns the device code the link only works
+	// with. It answers the same whether or not the email is a user's, so the endpoint does not tell which
+	// addresses have an account: the user is looked up and emailed in the background once the request is
+	// answered, so it takes as long either way, and the limits of the user and failed or suppressed emails are
+	// logged instead of answered.
+	Request(c *fiber.Ctx, req *validation.RequestMagicLink) (string, error)
+	// Login signs in the user of the link, once and on the device that asked for it
+	Login(c *fiber.Ctx, req *validation.MagicLinkLogin) (*model.User, error)
+	// PurgeExpired deletes the links that expired a day ago
+	PurgeExpired(ctx context.Context) error
+}
+
+type magicLinkService struct {
+	Log          *logrus.Logger
+	DB           *gorm.DB
+	Validate     *validator.Validate
+	EmailService EmailService
+}
+
+func NewMagicLinkService(db *gorm.DB, validate *validator.Validate, emailService EmailService) MagicLinkService {
+	return &magicLinkService{
+		Log:          utils.Log,
+		DB:           db,
+		Validate:     validate,
+		EmailService: emailService,
+	}
+}
+
+func (s *magicLinkService) Request(c *fiber.Ctx, req *validation.RequestMagicLink) (string, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return "", err
+	}
+
+	deviceCode, err := magicLinkDeviceCode()
+	if err != nil {
+		return "", err
+	}
+
+	// Fiber reuses the request and its strings once it is answered, the goroutine keeps copies
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	email := strings.Clone(req.Email)
+	link := &model.MagicLink{
+		DeviceHash: hashDeviceCode(deviceCode),
+		IPAddress:  strings.Clone(c.IP()),
+		UserAgent:  strings.Clone(userAgent),
+	}
+
+	go func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), magicLinkSendTimeout)
+		defer cancel()
+
+		if err := s.send(ctx, email, link); err != nil {
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				s.Log.Warnf("Magic link to %s not sent: %s", email, fiberErr.Message)
+			} else {
+				s.Log.Errorf("Failed to send a magic link to %s: %v", email, err)
+			}
+		}
+	}(c.UserContext())
+
+	return deviceCode, nil
+}
+
+// send emails link to the user of email, unless there is none or they got one too recently or too often
+func (s *magicLinkService) send(ctx context.Context, email string, link *model.MagicLink) error {
+	db := s.DB.WithContext(ctx)
+	now := time.Now()
+
+	var user model.User
+	result := db.Select("id", "email").Where("email = ?", email).Limit(1).Find(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		s.Log.Infof("Not sending a magic link to %s, no user has it", email)
+		return nil
+	}
+
+	var latest model.MagicLink
+	result = db.Where("user_id = ?", user.ID).Order("created_at DESC").Limit(1).Find(&latest)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 && now.Sub(latest.CreatedAt) < config.MagicLinkResendInterval {
+		return fiber.NewError(fiber.StatusTooManyRequests, "Wait a moment before asking for another link")
+	}
+
+	var sent int64
+	if err := db.Model(&model.MagicLink{}).
+		Where("user_id = ? AND created_at > ?", user.ID, now.Add(-time.Hour)).Count(&sent).Error; err != nil {
+		return err
+	}
+	if sent >= int64(config.MagicLinkMaxPerHour) {
+		return fiber.NewError(fiber.StatusTooManyRequests, "Too many links were sent to this email, try again later")
+	}
+
+	link.UserID = user.ID
+	link.ExpiresAt = now.Add(config.MagicLinkTTL)
+	if err := db.Create(link).Error; err != nil {
+		return err
+	}
+
+	claims := jwt.MapClaims{
+		"sub":  link.ID.String(),
+		"iat":  now.Unix(),
+		"exp":  link.ExpiresAt.Unix(),
+		"type": config.TokenTypeMagicLink,
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.JWTSecret))
+	if err != nil {
+		return err
+	}
+
+	if err := s.EmailService.SendMagicLinkEmail(user.Email, token, link.ExpiresAt); err != nil {
+		s.Log.Errorf("Failed to send the magic link %s: %v", link.ID, err)
+		return fiber.NewError(fiber.StatusBadGateway, "Failed to send the link")
+	}
+	return nil
+}
+
+func (s *magicLinkService) Login(c *fiber.Ctx, req *validation.MagicLinkLogin) (*model.User, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	invalid := fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired link")
+	sub, err := utils.VerifyToken(req.Token, config.JWTSecret, config.TokenTypeMagicLink)
+	if err != nil {
+		return nil, invalid
+	}
+	linkID, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, invalid
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+	now := time.Now()
+
+	var link model.MagicLink
+	if err := db.First(&link, "id = ?", linkID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, invalid
+		}
+		return nil, err
+	}
+	if !link.Usable(now) {
+		return nil, invalid
+	}
+	// A link opened on another device is not used up, the device that asked for it can still sign in
+	if subtle.ConstantTimeCompare([]byte(hashDeviceCode(strings.ToLower(req.DeviceCode))), []byte(link.DeviceHash)) != 1 {
+		s.Log.Warnf("Magic link %s was opened on another device from %s", link.ID, c.IP())
+		return nil, fiber.NewError(fiber.StatusForbidden, "Open the link on the device you asked for it from")
+	}
+
+	// Two requests with the same link race for it, only the one that marks it used signs in
+	result := db.Model(&model.MagicLink{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", link.ID, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, invalid
+	}
+
+	user := new(model.User)
+	if err := db.First(user, "id = ?", link.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, invalid
+		}
+		return nil, err
+	}
+
+	// Opening the link proves the email is the user's
+	if !user.VerifiedEmail {
+		if err := db.Model(user).Update("verified_email", true).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+func (s *magicLinkService) PurgeExpired(ctx context.Context) error {
+	result := s.DB.WithContext(ctx).
+		Where("expires_at < ?", time.Now().Add(-magicLinkRetention)).
+		Delete(&model.MagicLink{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		s.Log.Infof("Purged %d expired magic links", result.RowsAffected)
+	}
+	return nil
+}
+
+// magicLinkDeviceCode is a random secret the requesting device keeps
+func magicLinkDeviceCode() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashDeviceCode is what is stored of a device code
+func hashDeviceCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maintenanceCacheTTL is how long an instance trusts its copy of the maintenance switch
+const maintenanceCacheTTL = 15 * time.Second
+
+type MaintenanceService interface {
+	GetStatus(ctx context.Context) (*model.MaintenanceStatus, error)
+	SetStatus(ctx context.Context, adminID uuid.UUID, enabled bool, message string) (*model.MaintenanceStatus, error)
+	UpdateStatus(c *fiber.Ctx, adminID uuid.UUID, req *validation.UpdateMaintenance) (*model.MaintenanceStatus, error)
+
+	// IsEnabled is the cached check used on every request, it fails open when the settings cannot be read
+	IsEnabled(ctx context.Context) (bool, string)
+}
+
+type maintenanceService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+
+	mu       sync.Mutex
+	cached   *model.MaintenanceStatus
+	cachedAt time.Time
+}
+
+func NewMaintenanceService(db *gorm.DB, validate *validator.Validate) MaintenanceService {
+	return &maintenanceService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *maintenanceService) GetStatus(ctx context.Context) (*model.MaintenanceStatus, error) {
+	var settings []model.SystemSetting
+	if err := s.DB.WithContext(ctx).
+		Where("key IN ?", []string{model.SettingMaintenanceMode, model.SettingMaintenanceMessage}).
+		Find(&settings).Error; err != nil {
+		return nil, err
+	}
+
+	status := &model.MaintenanceStatus{}
+	for _, setting := range settings {
+		switch setting.Key {
+		case model.SettingMaintenanceMode:
+			status.Enabled = setting.Value == "on"
+			updatedAt := setting.UpdatedAt
+			status.UpdatedAt = &updatedAt
+		case model.SettingMaintenanceMessage:
+			status.Message = setting.Value
+		}
+	}
+
+	return status, nil
+}
+
+func (s *maintenanceService) SetStatus(ctx context.Context, adminID uuid.UUID, enabled bool, message string) (*model.MaintenanceStatus, error) {
+	mode := "off"
+	if enabled {
+		mode = "on"
+	}
+
+	settings := []model.SystemSetting{
+		{Key: model.SettingMaintenanceMode, Value: mode, UpdatedByID: &adminID},
+		{Key: model.SettingMaintenanceMessage, Value: message, UpdatedByID: &adminID},
+	}
+
+	if err := s.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_by_id", "updated_at"}),
+	}).Create(&settings).Error; err != nil {
+		return nil, err
+	}
+
+	status, err := s.GetStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// This instance switches right away, the others within maintenanceCacheTTL
+	s.mu.Lock()
+	s.cached, s.cachedAt = status, time.Now()
+	s.mu.Unlock()
+
+	return status, nil
+}
+
+func (s *maintenanceService) UpdateStatus(
+	c *fiber.Ctx, adminID uuid.UUID, req *validation.UpdateMaintenance,
+) (*model.MaintenanceStatus, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	return s.SetStatus(c.UserContext(), adminID, *req.Enabled, req.Message)
+}
+
+func (s *maintenanceService) IsEnabled(ctx context.Context) (bool, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached == nil || time.Since(s.cachedAt) > maintenanceCacheTTL {
+		status, err := s.GetStatus(ctx)
+		if err != nil {
+			s.Log.Errorf("Failed to read maintenance status: %v", err)
+			status = &model.MaintenanceStatus{}
+		}
+		s.cached, s.cachedAt = status, time.Now()
+	}
+
+	return s.cached.Enabled, s.cached.Message
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,7 +14,10 @@ import (
 	"time"
 
 	"app/src/model"
+	"app/src/requestid"
+	"app/src/validation"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -23,6 +27,8 @@ import (
 type MealService interface {
 	ScanMeal(c *fiber.Ctx, imageFile *multipart.FileHeader, userID uuid.UUID) (*MealScanResponse, error)
 	GetMeals(c *fiber.Ctx) ([]model.MealHistory, int64, error)
+	GetScanHistory(c *fiber.Ctx, userID uuid.UUID, query *validation.ScanHistoryQuery) ([]model.ScanHistory, int64, error)
+	GetDiary(c *fiber.Ctx, userID uuid.UUID, query *validation.MealDiaryQuery) (*model.MealDiary, error)
 	GetMealByID(c *fiber.Ctx, id string) (*model.MealHistory, error)
 	GetMealScanDetailByID(c *fiber.Ctx, id string) (*model.MealHistoryDetail, error)
 	AddMealScanDetail(c *fiber.Ctx, mealId string, meal *model.MealHistoryDetail) (*model.MealHistoryDetail, error)
@@ -34,21 +40,48 @@ type MealService interface {
 }
 
 type mealService struct {
-	Log     *logrus.Logger
-	DB      *gorm.DB
-	ApiKey  string
-	BaseURL string
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+	ApiKey   string
+	BaseURL  string
+	Alerts   AlertService
+	Grades   FoodGradeService
+	Tips     DailyTipService
 }
 
-func NewMealService(db *gorm.DB, apiKey, baseURL string) *mealService {
+func NewMealService(db *gorm.DB, validate *validator.Validate, apiKey, baseURL string, alerts AlertService, grades FoodGradeService, tips DailyTipService) *mealService {
 	return &mealService{
-		Log:     logrus.New(),
-		DB:      db,
-		ApiKey:  apiKey,
-		BaseURL: baseURL,
+		Log:      logrus.New(),
+		DB:       db,
+		Validate: validate,
+		ApiKey:   apiKey,
+		BaseURL:  baseURL,
+		Alerts:   alerts,
+		Grades:   grades,
+		Tips:     tips,
 	}
 }
 
+// logMealClient bounds LogMeal calls, a hung call would otherwise hold its bulkhead slot forever
+var logMealClient = &http.Client{Timeout: 60 * time.Second}
+
+// doLogMeal sends a request to LogMeal in a slot of the AI bulkhead, the slot is held until the response arrives.
+// The request ID of the request context is sent along so LogMeal support can find the call.
+func doLogMeal(req *http.Request) (*http.Response, error) {
+	release, err := aiBulkhead.Acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if id := requestid.From(req.Context()); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+
+	return logMealClient.Do(req)
+}
+
 // Response structure
 type NutrientDetail struct {
 	Quantity float64 `json:"quantity"`
@@ -63,8 +96,11 @@ type Nutrient struct {
 }
 
 type MealScanResponse struct {
-	Foods     [][]string `json:"foods"`
-	TotalNutr Nutrient   `json:"total_nutrient"`
+	ScanID      uuid.UUID          `json:"scan_id"`
+	MealID      uuid.UUID          `json:"meal_id"`
+	Foods       [][]string         `json:"foods"`
+	TotalNutr   Nutrient           `json:"total_nutrient"`
+	HealthGrade *model.HealthGrade `json:"health_grade,omitempty"`
 }
 
 // ScanMeal handles the image scanning process
@@ -75,32 +111,65 @@ func (s *mealService) ScanMeal(c *fiber.Ctx, imageFile *multipart.FileHeader, us
 	}
 	defer file.Close()
 
+	// LogMeal calls are bounded by their client timeout rather than the query deadline of the request,
+	// the context only carries the request ID
+	logMealCtx := context.WithoutCancel(c.UserContext())
+
 	// Step 1: Upload Image to Segmentation API
-	imageId, foods, err := s.uploadImageToSegmentationAPI(file, imageFile.Filename)
+	imageId, foods, err := s.uploadImageToSegmentationAPI(logMealCtx, file, imageFile.Filename)
 	if err != nil {
+		s.Alerts.Emit(c.UserContext(), model.AlertAIProviderError, 1, fmt.Sprintf("LogMeal segmentation failed: %v", err))
 		return nil, err
 	}
 
 	// Step 2: Convert imageId to string and get Nutrition Info
 	imageIdStr := strconv.Itoa(imageId)
-	totalNutr, err := s.getNutritionInfo(imageIdStr)
+	totalNutr, err := s.getNutritionInfo(logMealCtx, imageIdStr)
 	if err != nil {
+		s.Alerts.Emit(c.UserContext(), model.AlertAIProviderError, 1, fmt.Sprintf("LogMeal nutrition lookup failed: %v", err))
 		return nil, err
 	}
 
 	// Step 3: Simpan hasil scan ke database (MealHistory & MealHistoryDetail)
-	if err := s.saveMealHistory(userID, foods, totalNutr); err != nil {
+	mealHistory, err := s.saveMealHistory(c.UserContext(), userID, foods, totalNutr)
+	if err != nil {
 		return nil, err
 	}
 
+	scan := &model.ScanHistory{
+		ID:              uuid.New(),
+		UserID:          userID,
+		MealHistoryID:   &mealHistory.ID,
+		Provider:        model.ScanProviderLogMeal,
+		ProviderImageID: imageIdStr,
+		Items:           model.ScanItemsOf(foods),
+		Calories:        totalNutr.Calories.Quantity,
+		Protein:         totalNutr.Protein.Quantity,
+		Carbs:           totalNutr.Carbs.Quantity,
+		Fat:             totalNutr.Fat.Quantity,
+		CreatedAt:       mealHistory.CreatedAt,
+	}
+	if err := s.DB.WithContext(c.UserContext()).Create(scan).Error; err != nil {
+		return nil, err
+	}
+
+	if err := incrementUserScans(s.DB, userID); err != nil {
+		s.Log.Errorf("Failed to count scan for user %s: %v", userID, err)
+	}
+	s.refreshMealDays(userID, mealHistory.MealTime)
+	s.appendMealEvent(c, model.EventMealLogged, userID, mealHistory.ID, model.EventPayload{Day: model.EventDay(mealHistory.MealTime), Scans: 1})
+
 	return &MealScanResponse{
-		Foods:     foods,
-		TotalNutr: totalNutr,
+		ScanID:      scan.ID,
+		MealID:      mealHistory.ID,
+		Foods:       foods,
+		TotalNutr:   totalNutr,
+		HealthGrade: mealHistory.HealthGrade,
 	}, nil
 }
 
 // Upload image to segmentation API and extract food names
-func (s *mealService) uploadImageToSegmentationAPI(file io.Reader, filename string) (int, [][]string, error) {
+func (s *mealService) uploadImageToSegmentationAPI(ctx context.Context, file io.Reader, filename string) (int, [][]string, error) {
 	url := fmt.Sprintf("%s/v2/image/segmentation/complete/v1.1?language=eng", s.BaseURL)
 	buffer := &bytes.Buffer{}
 	writer := multipart.NewWriter(buffer)
@@ -112,14 +181,14 @@ func (s *mealService) uploadImageToSegmentationAPI(file io.Reader, filename stri
 	io.Copy(part, file)
 	writer.Close()
 
-	req, err := http.NewRequest("POST", url, buffer)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, buffer)
 	if err != nil {
 		return 0, nil, err
 	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("Authorization", "Bearer "+s.ApiKey)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := doLogMeal(req)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -169,18 +238,18 @@ func (s *mealService) uploadImageToSegmentationAPI(file io.Reader, filename stri
 }
 
 // Fetch nutrition info based on imageId
-func (s *mealService) getNutritionInfo(imageId string) (Nutrient, error) {
+func (s *mealService) getNutritionInfo(ctx context.Context, imageId string) (Nutrient, error) {
 	url := fmt.Sprintf("%s/v2/nutrition/recipe/nutritionalInfo/v1.1?language=eng", s.BaseURL)
 	payload, _ := json.Marshal(map[string]string{"imageId": imageId})
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
 	if err != nil {
 		return Nutrient{}, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+s.ApiKey)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := doLogMeal(req)
 	if err != nil {
 		return Nutrient{}, err
 	}
@@ -218,7 +287,7 @@ func (s *mealService) getNutritionInfo(imageId string) (Nutrient, error) {
 }
 
 // Save meal history and details
-func (s *mealService) saveMealHistory(userID uuid.UUID, foods [][]string, totalNutr Nutrient) error {
+func (s *mealService) saveMealHistory(ctx context.Context, userID uuid.UUID, foods [][]string, totalNutr Nutrient) (*model.MealHistory, error) {
 	mealHistory := model.MealHistory{
 		ID:        uuid.New(),
 		UserID:    userID,
@@ -231,9 +300,10 @@ func (s *mealService) saveMealHistory(userID uuid.UUID, foods [][]string, totalN
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
+	s.Grades.GradeMeal(ctx, &mealHistory)
 
 	if err := s.DB.Create(&mealHistory).Error; err != nil {
-		return err
+		return nil, err
 	}
 
 	saveMaps := make(map[string]any)
@@ -248,7 +318,11 @@ func (s *mealService) saveMealHistory(userID uuid.UUID, foods [][]string, totalN
 		UpdatedAt:     time.Now(),
 	}
 
-	return s.DB.Create(&mealDetail).Error
+	if err := s.DB.Create(&mealDetail).Error; err != nil {
+		return nil, err
+	}
+
+	return &mealHistory, nil
 }
 
 func (s *mealService) GetMeals(c *fiber.Ctx) ([]model.MealHistory, int64, error) {
@@ -266,7 +340,7 @@ func (s *mealService) GetMeals(c *fiber.Ctx) ([]model.MealHistory, int64, error)
 	var meals []model.MealHistory
 	var totalResults int64
 
-	if err := s.DB.WithContext(c.Context()).
+	if err := s.DB.WithContext(c.UserContext()).
 		Model(&model.MealHistory{}).
 		Where("user_id = ?", userID).
 		Count(&totalResults).Error; err != nil {
@@ -274,7 +348,7 @@ func (s *mealService) GetMeals(c *fiber.Ctx) ([]model.MealHistory, int64, error)
 		return nil, 0, err
 	}
 
-	if err := s.DB.WithContext(c.Context()).
+	if err := s.DB.WithContext(c.UserContext()).
 		Where("user_id = ?", userID).
 		Order("meal_time DESC").
 		Offset(offset).
@@ -283,14 +357,74 @@ func (s *mealService) GetMeals(c *fiber.Ctx) ([]model.MealHistory, int64, error)
 		s.Log.Errorf("Failed to get meals: %+v", err)
 		return nil, 0, err
 	}
+	s.Grades.RegradeMeals(c.UserContext(), meals)
 
 	return meals, totalResults, nil
 }
 
+func (s *mealService) GetScanHistory(c *fiber.Ctx, userID uuid.UUID, query *validation.ScanHistoryQuery) ([]model.ScanHistory, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+	// Validation lets page=0 and limit=0 through, they ask for the first page of the default size
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 {
+		query.Limit = 10
+	}
+
+	var scans []model.ScanHistory
+	var totalResults int64
+
+	db := s.DB.WithContext(c.UserContext()).Model(&model.ScanHistory{}).Where("user_id = ?", userID)
+
+	if err := db.Count(&totalResults).Error; err != nil {
+		s.Log.Errorf("Failed to count scans: %+v", err)
+		return nil, 0, err
+	}
+
+	if err := db.
+		Order("created_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&scans).Error; err != nil {
+		s.Log.Errorf("Failed to get scans: %+v", err)
+		return nil, 0, err
+	}
+
+	return scans, totalResults, nil
+}
+
+func (s *mealService) GetDiary(c *fiber.Ctx, userID uuid.UUID, query *validation.MealDiaryQuery) (*model.MealDiary, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+	if query.Period == "" {
+		query.Period = model.DiaryPeriodDay
+	}
+
+	// Days are calendar days of the server time zone, the same ones the nutrition summaries use
+	date, _ := time.ParseInLocation("2006-01-02", query.Date, time.Local)
+	from, to := model.DiaryRange(query.Period, date)
+
+	var meals []model.MealHistory
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("user_id = ? AND meal_time >= ? AND meal_time < ?", userID, from, to.AddDate(0, 0, 1)).
+		Order("meal_time").
+		Find(&meals).Error; err != nil {
+		s.Log.Errorf("Failed to get meal diary: %+v", err)
+		return nil, err
+	}
+	s.Grades.RegradeMeals(c.UserContext(), meals)
+
+	return model.BuildMealDiary(query.Period, meals, from, to), nil
+}
+
 func (s *mealService) GetMealByID(c *fiber.Ctx, id string) (*model.MealHistory, error) {
 	meal := new(model.MealHistory)
 
-	result := s.DB.WithContext(c.Context()).First(meal, "id = ?", id)
+	result := s.DB.WithContext(c.UserContext()).First(meal, "id = ?", id)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil, fiber.NewError(fiber.StatusNotFound, "Meal not found")
@@ -311,13 +445,19 @@ func (s *mealService) GetMealByID(c *fiber.Ctx, id string) (*model.MealHistory,
 		return nil, fiber.NewError(fiber.StatusForbidden, "You don't have permission to access this resource")
 	}
 
+	if result.Error == nil {
+		meals := []model.MealHistory{*meal}
+		s.Grades.RegradeMeals(c.UserContext(), meals)
+		meal = &meals[0]
+	}
+
 	return meal, result.Error
 }
 
 func (s *mealService) GetMealScanDetailByID(c *fiber.Ctx, id string) (*model.MealHistoryDetail, error) {
 	meal := new(model.MealHistory)
 
-	result := s.DB.WithContext(c.Context()).First(meal, "id = ?", id)
+	result := s.DB.WithContext(c.UserContext()).First(meal, "id = ?", id)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil, fiber.NewError(fiber.StatusNotFound, "Meal not found")
@@ -329,19 +469,21 @@ func (s *mealService) GetMealScanDetailByID(c *fiber.Ctx, id string) (*model.Mea
 
 	mealScanDetail := new(model.MealHistoryDetail)
 
-	resultScanDetail := s.DB.WithContext(c.Context()).First(mealScanDetail, "meal_history_id = ?", id)
+	scanDetailErr := firstWithArchive(s.DB.WithContext(c.UserContext()), model.MealHistoryDetailsTable, mealScanDetail, func(db *gorm.DB) *gorm.DB {
+		return db.Where("meal_history_id = ?", id)
+	})
 
 	user, ok := c.Locals("user").(*model.User)
 	if !ok || user == nil {
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "User data not found in context")
 	}
 
-	if errors.Is(resultScanDetail.Error, gorm.ErrRecordNotFound) {
+	if errors.Is(scanDetailErr, gorm.ErrRecordNotFound) {
 		return nil, fiber.NewError(fiber.StatusNotFound, "Meal not found")
 	}
 
-	if resultScanDetail.Error != nil {
-		s.Log.Errorf("Failed get meal by id: %+v", resultScanDetail.Error)
+	if scanDetailErr != nil {
+		s.Log.Errorf("Failed get meal by id: %+v", scanDetailErr)
 	}
 
 	userID := user.ID
@@ -359,16 +501,24 @@ func (s *mealService) AddMeal(c *fiber.Ctx, meal *model.MealHistory) (*model.Mea
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "User data not found in context")
 	}
 
+	if err := meal.NormalizePortion(); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
 	meal.ID = uuid.New()
 	meal.UserID = user.ID
 	meal.CreatedAt = time.Now()
 	meal.UpdatedAt = time.Now()
+	s.Grades.GradeMeal(c.UserContext(), meal)
 
-	if err := s.DB.WithContext(c.Context()).Create(meal).Error; err != nil {
+	if err := s.DB.WithContext(c.UserContext()).Create(meal).Error; err != nil {
 		s.Log.Errorf("Failed to add meal: %+v", err)
 		return nil, err
 	}
 
+	s.refreshMealDays(user.ID, meal.MealTime)
+	s.appendMealEvent(c, model.EventMealLogged, user.ID, meal.ID, model.EventPayload{Day: model.EventDay(meal.MealTime)})
+
 	return meal, nil
 }
 
@@ -379,7 +529,7 @@ func (s *mealService) UpdateMeal(c *fiber.Ctx, id string, meal *model.MealHistor
 	}
 
 	existingMeal := new(model.MealHistory)
-	if err := s.DB.WithContext(c.Context()).First(existingMeal, "id = ?", id).Error; err != nil {
+	if err := s.DB.WithContext(c.UserContext()).First(existingMeal, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fiber.NewError(fiber.StatusNotFound, "Meal not found")
 		}
@@ -391,6 +541,11 @@ func (s *mealService) UpdateMeal(c *fiber.Ctx, id string, meal *model.MealHistor
 		return nil, fiber.NewError(fiber.StatusForbidden, "You don't have permission to update this meal")
 	}
 
+	if err := meal.NormalizePortion(); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	previousMealTime := existingMeal.MealTime
 	existingMeal.Title = meal.Title
 	existingMeal.MealTime = meal.MealTime
 	existingMeal.Label = meal.Label
@@ -398,13 +553,22 @@ func (s *mealService) UpdateMeal(c *fiber.Ctx, id string, meal *model.MealHistor
 	existingMeal.Protein = meal.Protein
 	existingMeal.Carbs = meal.Carbs
 	existingMeal.Fat = meal.Fat
+	existingMeal.PortionQuantity = meal.PortionQuantity
+	existingMeal.PortionUnit = meal.PortionUnit
+	existingMeal.PortionGrams = meal.PortionGrams
 	existingMeal.UpdatedAt = time.Now()
+	s.Grades.GradeMeal(c.UserContext(), existingMeal)
 
-	if err := s.DB.WithContext(c.Context()).Save(existingMeal).Error; err != nil {
+	if err := s.DB.WithContext(c.UserContext()).Save(existingMeal).Error; err != nil {
 		s.Log.Errorf("Failed to update meal: %+v", err)
 		return nil, err
 	}
 
+	s.refreshMealDays(user.ID, previousMealTime, existingMeal.MealTime)
+	if day := model.EventDay(existingMeal.MealTime); day != model.EventDay(previousMealTime) {
+		s.appendMealEvent(c, model.EventMealMoved, user.ID, existingMeal.ID, model.EventPayload{Day: day})
+	}
+
 	return existingMeal, nil
 }
 
@@ -415,7 +579,7 @@ func (s *mealService) DeleteMeal(c *fiber.Ctx, id string) error {
 	}
 
 	meal := new(model.MealHistory)
-	if err := s.DB.WithContext(c.Context()).First(meal, "id = ?", id).Error; err != nil {
+	if err := s.DB.WithContext(c.UserContext()).First(meal, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fiber.NewError(fiber.StatusNotFound, "Meal not found")
 		}
@@ -427,11 +591,18 @@ func (s *mealService) DeleteMeal(c *fiber.Ctx, id string) error {
 		return fiber.NewError(fiber.StatusForbidden, "You don't have permission to delete this meal")
 	}
 
-	if err := s.DB.WithContext(c.Context()).Delete(meal).Error; err != nil {
+	if err := s.DB.WithContext(c.UserContext()).Delete(meal).Error; err != nil {
 		s.Log.Errorf("Failed to delete meal: %+v", err)
 		return err
 	}
 
+	// A deleted scanned meal no longer counts as a scan
+	if err := recountUserScans(s.DB, user.ID); err != nil {
+		s.Log.Errorf("Failed to recount scans for user %s: %v", user.ID, err)
+	}
+	s.refreshMealDays(user.ID, meal.MealTime)
+	s.appendMealEvent(c, model.EventMealDeleted, user.ID, meal.ID, model.EventPayload{})
+
 	return nil
 }
 
@@ -441,46 +612,52 @@ func (s *mealService) AddMealScanDetail(c *fiber.Ctx, mealId string, meal *model
 	meal.CreatedAt = time.Now()
 	meal.UpdatedAt = time.Now()
 
-	if err := s.DB.WithContext(c.Context()).Create(meal).Error; err != nil {
+	if err := s.DB.WithContext(c.UserContext()).Create(meal).Error; err != nil {
 		s.Log.Errorf("Failed to add meal scan detail: %+v", err)
 		return nil, err
 	}
 
+	var userID uuid.UUID
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(&model.MealHistory{}).
+		Select("user_id").
+		Where("id = ?", meal.MealHistoryID).
+		Scan(&userID).Error; err == nil {
+		if err := recountUserScans(s.DB, userID); err != nil {
+			s.Log.Errorf("Failed to recount scans for user %s: %v", userID, err)
+		}
+		s.appendMealEvent(c, model.EventMealScanAdded, userID, meal.MealHistoryID, model.EventPayload{})
+	}
+
 	return meal, nil
 }
 
-// GetTodayNutrition fetches today's nutrition data for a user
+// GetTodayNutrition reads today's nutrition of a user from the daily summaries
 func (s *mealService) GetTodayNutrition(c *fiber.Ctx, userID uuid.UUID) (*model.DailyNutrition, error) {
-	todayStart := time.Now().Truncate(24 * time.Hour)
-	todayEnd := todayStart.Add(24 * time.Hour)
-
-	var meals []model.MealHistory
-	if err := s.DB.WithContext(c.Context()).
-		Where("user_id = ? AND meal_time >= ? AND meal_time < ?", userID, todayStart, todayEnd).
-		Find(&meals).Error; err != nil {
-		s.Log.Errorf("Failed to get today's meals: %+v", err)
-		return nil, err
+	now := time.Now()
+	dailyNutrition := &model.DailyNutrition{
+		Date: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()),
 	}
 
-	dailyNutrition := &model.DailyNutrition{
-		Date:     todayStart,
-		Calories: 0,
-		Protein:  0,
-		Carbs:    0,
-		Fat:      0,
+	var summaries []model.DailyNutritionSummary
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("user_id = ? AND date = CURRENT_DATE", userID).
+		Limit(1).
+		Find(&summaries).Error; err != nil {
+		s.Log.Errorf("Failed to get today's nutrition summary: %+v", err)
+		return nil, err
 	}
 
-	for _, meal := range meals {
-		dailyNutrition.Calories += meal.Calories
-		dailyNutrition.Protein += meal.Protein
-		dailyNutrition.Carbs += meal.Carbs
-		dailyNutrition.Fat += meal.Fat
+	if len(summaries) > 0 {
+		dailyNutrition.Calories = summaries[0].Calories
+		dailyNutrition.Protein = summaries[0].Protein
+		dailyNutrition.Carbs = summaries[0].Carbs
+		dailyNutrition.Fat = summaries[0].Fat
 	}
 
 	return dailyNutrition, nil
 }
 
-// GetHomeStatistics fetches combined statistics for home screen
 func (s *mealService) GetHomeStatistics(c *fiber.Ctx, userID uuid.UUID) (*model.HomeStatistics, error) {
 	// Get today's nutrition data
 	dailyNutrition, err := s.GetTodayNutrition(c, userID)
@@ -533,11 +710,39 @@ func (s *mealService) GetHomeStatistics(c *fiber.Ctx, userID uuid.UUID) (*model.
 		LatestWeightTarget: latestTarget,
 	}
 
+	// The tip is a nice to have, the statistics are shown without it
+	tip, err := s.Tips.GetTodayTip(c.UserContext(), userID)
+	if err != nil {
+		s.Log.Errorf("Failed to get the daily tip: %+v", err)
+	}
+
 	// Combine all statistics
 	homeStats := &model.HomeStatistics{
 		DailyNutrition:         dailyNutrition,
 		WeightHeightStatistics: weightHeightStats,
+		DailyTip:               tip,
 	}
 
 	return homeStats, nil
 }
+
+// appendMealEvent records a meal change in the event log, the change itself is already saved
+func (s *mealService) appendMealEvent(c *fiber.Ctx, eventType string, userID, mealID uuid.UUID, payload model.EventPayload) {
+	if err := appendEvent(s.DB.WithContext(c.UserContext()), eventType, userID, mealID, payload, time.Now()); err != nil {
+		s.Log.Errorf("Failed to append %s event of meal %s: %v", eventType, mealID, err)
+	}
+}
+
+// refreshMealDays keeps the logged days counter and the daily nutrition summaries of the user in line with their meals,
+// mealTimes are the times of the meals that were added, moved or deleted
+func (s *mealService) refreshMealDays(userID uuid.UUID, mealTimes ...time.Time) {
+	if err := recountLoggedDays(s.DB, userID); err != nil {
+		s.Log.Errorf("Failed to recount logged days for user %s: %v", userID, err)
+	}
+
+	for _, mealTime := range mealTimes {
+		if err := refreshDailySummary(s.DB, userID, mealTime); err != nil {
+			s.Log.Errorf("Failed to refresh nutrition summary for user %s: %v", userID, err)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/objectstore"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type MediaCleanupService interface {
+	// DryRun counts the orphaned media files the janitor would remove now, without removing anything
+	DryRun(c *fiber.Ctx, adminID uuid.UUID) (*model.MediaCleanupRun, error)
+	GetRuns(c *fiber.Ctx, query *validation.MediaCleanupRunQuery) ([]model.MediaCleanupRun, error)
+
+	// CleanOrphans archives or deletes, once a day, the files under /uploads no record refers to anymore, or only
+	// reports them while MEDIA_CLEANUP_DRY_RUN is on. Each run gets an audit entry with the space it reclaimed.
+	CleanOrphans(ctx context.Context) error
+}
+
+type mediaCleanupService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+	Store    *objectstore.Client
+	Dir      string
+}
+
+func NewMediaCleanupService(db *gorm.DB, validate *validator.Validate) MediaCleanupService {
+	return &mediaCleanupService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+		Store: objectstore.New(config.BackupS3Endpoint, config.BackupS3Region, config.BackupS3Bucket,
+			config.BackupS3Access, config.BackupS3Secret),
+		Dir: utils.UploadDir,
+	}
+}
+
+func (s *mediaCleanupService) DryRun(c *fiber.Ctx, adminID uuid.UUID) (*model.MediaCleanupRun, error) {
+	return s.run(c.UserContext(), true, &adminID)
+}
+
+func (s *mediaCleanupService) GetRuns(c *fiber.Ctx, query *validation.MediaCleanupRunQuery) ([]model.MediaCleanupRun, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = 50
+	}
+
+	var runs []model.MediaCleanupRun
+	if err := s.DB.WithContext(c.UserContext()).Order("started_at DESC").Limit(limit).Find(&runs).Error; err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+func (s *mediaCleanupService) CleanOrphans(ctx context.Context) error {
+	now := time.Now()
+	claimed, err := claimDailyRun(ctx, s.DB, model.SettingMediaCleanedOn, now.Format("2006-01-02"))
+	if err != nil || !claimed {
+		return err
+	}
+
+	run, err := s.run(ctx, config.MediaCleanupDryRun.Get(), nil)
+	if err != nil {
+		return err
+	}
+	if run.Failed > 0 {
+		return fmt.Errorf("%d orphaned media files were not removed: %s", run.Failed, run.Error)
+	}
+	return nil
+}
+
+// run removes, or counts in a dry run, the orphaned files and records the audit entry. Nothing is removed unless
+// every reference was read, a file missing from the set would be taken for an orphan.
+func (s *mediaCleanupService) run(ctx context.Context, dryRun bool, triggeredByID *uuid.UUID) (*model.MediaCleanupRun, error) {
+	db := s.DB.WithContext(ctx)
+	run := &model.MediaCleanupRun{
+		Action:        config.MediaCleanupAction,
+		DryRun:        dryRun,
+		TriggeredByID: triggeredByID,
+		StartedAt:     time.Now(),
+	}
+	if run.Action != model.MediaCleanupDelete {
+		run.Action = model.MediaCleanupArchive
+	}
+	if run.Action == model.MediaCleanupArchive && s.Store == nil && !dryRun {
+		return nil, fmt.Errorf("orphaned media cannot be archived without BACKUP_S3_BUCKET, set MEDIA_CLEANUP_ACTION=delete to delete them")
+	}
+
+	references, err := mediaReferences(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the media references: %w", err)
+	}
+	run.Referenced = int64(len(references))
+	referenced := make(map[string]bool, len(references))
+	for uploadPath := range references {
+		referenced[uploadPath] = true
+	}
+
+	err = filepath.WalkDir(s.Dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && file == s.Dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		// Hidden files like .gitkeep are not uploads
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(s.Dir, file)
+		if err != nil {
+			return err
+		}
+		uploadPath := path.Join("/", utils.UploadDir, filepath.ToSlash(relative))
+
+		run.Scanned++
+		run.ScannedBytes += info.Size()
+		if !model.IsOrphanedMedia(uploadPath, info.ModTime(), referenced, config.MediaCleanupGrace, run.StartedAt) {
+			return nil
+		}
+		run.Orphaned++
+		run.OrphanedBytes += info.Size()
+		if dryRun {
+			return nil
+		}
+
+		if err := s.remove(ctx, run.Action, file, uploadPath, run.StartedAt); err != nil {
+			run.Failed++
+			if run.Error == "" {
+				run.Error = fmt.Sprintf("%s: %v", uploadPath, err)
+			}
+			return nil
+		}
+		run.Removed++
+		run.BytesReclaimed += info.Size()
+		return nil
+	})
+	if err != nil && run.Error == "" {
+		run.Error = err.Error()
+	}
+	run.FinishedAt = time.Now()
+
+	if err := db.Create(run).Error; err != nil {
+		return nil, err
+	}
+	if run.Error != "" {
+		s.Log.Errorf("Orphaned media cleanup failed after removing %d of %d files: %s", run.Removed, run.Orphaned, run.Error)
+	}
+	if run.Removed > 0 {
+		s.Log.Infof("Removed %d orphaned media files (%s), reclaiming %.1f MB", run.Removed, run.Action,
+			float64(run.BytesReclaimed)/(1<<20))
+	}
+
+	return run, nil
+}
+
+// mediaReferences reads the upload paths every media reference holds, with the storage category of the first
+// reference to each. Archive tables that were not created yet hold none.
+func mediaReferences(db *gorm.DB) (map[string]string, error) {
+	referenced := make(map[string]string)
+	for _, reference := range model.MediaReferences {
+		if !db.Migrator().HasTable(reference.Table) {
+			continue
+		}
+
+		rows, err := db.Table(reference.Table).
+			Distinct(reference.Column).
+			Where(fmt.Sprintf("%s IS NOT NULL AND %s <> ''", reference.Column, reference.Column)).
+			Rows()
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var ref string
+			if err := rows.Scan(&ref); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if uploadPath, ok := model.UploadPath(ref); ok && referenced[uploadPath] == "" {
+				referenced[uploadPath] = reference.Category
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return referenced, nil
+}
+
+// remove archives the file to the bucket before deleting it, or only deletes it
+func (s *mediaCleanupService) remove(ctx context.Context, action, file, uploadPath string, now time.Time) error {
+	if action == model.MediaCleanupArchive {
+		contentType := mime.TypeByExtension(filepath.Ext(file))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		if _, err := s.Store.PutFile(ctx, model.MediaArchiveKey(config.MediaArchivePrefix, uploadPath, now), file, contentType); err != nil {
+			return fmt.Errorf("failed to archive: %w", err)
+		}
+	}
+	return os.Remove(file)
+}
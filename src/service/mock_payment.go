@@ -1,6 +1,10 @@
 package service
 
-import "github.com/google/uuid"
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
 
 type MockPayment struct{}
 
@@ -15,21 +19,28 @@ func (m *MockPayment) Refund(transactionID string) error {
 	return nil
 }
 
-func (m *MockPayment) CreateTransaction(orderID string, amount int, userDetails map[string]interface{}, paymentMethod string) (*PaymentToken, error) {
+func (m *MockPayment) CreateTransaction(ctx context.Context, orderID string, amount int, userDetails map[string]interface{}, paymentMethod string) (*PaymentToken, error) {
 	return &PaymentToken{
 		Token:       "mock_token_" + uuid.New().String(),
 		RedirectURL: "https://example.com/mock_payment",
 	}, nil
 }
 
-func (m *MockPayment) CheckTransactionStatus(transactionID string) (interface{}, error) {
+func (m *MockPayment) ChargeSavedCard(ctx context.Context, orderID string, amount int, savedTokenID string) (*CardCharge, error) {
+	return &CardCharge{
+		TransactionID:     "mock_" + uuid.New().String(),
+		TransactionStatus: "capture",
+	}, nil
+}
+
+func (m *MockPayment) CheckTransactionStatus(ctx context.Context, transactionID string) (interface{}, error) {
 	return map[string]string{
 		"transaction_id": transactionID,
 		"status":         "settlement",
 	}, nil
 }
 
-func (m *MockPayment) HandleNotification(notificationJSON []byte) (interface{}, error) {
+func (m *MockPayment) HandleNotification(ctx context.Context, notificationJSON []byte) (interface{}, error) {
 	return map[string]string{
 		"status": "success",
 	}, nil
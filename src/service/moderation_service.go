@@ -0,0 +1,374 @@
+package service
+
+import (
+	"app/src/clock"
+	"app/src/model"
+	"app/src/textfilter"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ModerationService interface {
+	// Block hides the user behind a handle and the blocker from each other on social features. Handles nobody has
+	// are answered like those of hidden profiles, with the handle alone, so blocks cannot probe handles.
+	Block(c *fiber.Ctx, blockerID uuid.UUID, handle string) (*model.BlockedUser, error)
+	Unblock(c *fiber.Ctx, blockerID uuid.UUID, handle string) error
+	// GetBlocks lists the users the user blocked, the profiles they cannot see show the handle alone
+	GetBlocks(c *fiber.Ctx, userID uuid.UUID) ([]model.BlockedUser, error)
+	// Report puts a report of the user behind a handle in the moderation queue, one open report per pair. The
+	// answer is the same for handles nobody has and for users already reported.
+	Report(c *fiber.Ctx, reporterID uuid.UUID, handle string, req *validation.ReportUser) (*model.SentReport, error)
+
+	GetReports(c *fiber.Ctx, query *validation.UserReportQuery) ([]model.UserReport, int64, error)
+	// ResolveReport closes an open report: dismissed, or the reported user is warned or suspended by email
+	ResolveReport(c *fiber.Ctx, adminID, reportID uuid.UUID, req *validation.ResolveUserReport) (*model.UserReport, error)
+	// LiftSuspension lets a suspended user back in before the suspension ends
+	LiftSuspension(c *fiber.Ctx, userID uuid.UUID) (*model.User, error)
+}
+
+type moderationService struct {
+	Log          *logrus.Logger
+	DB           *gorm.DB
+	Validate     *validator.Validate
+	EmailService EmailService
+}
+
+func NewModerationService(db *gorm.DB, validate *validator.Validate, emailService EmailService) ModerationService {
+	return &moderationService{
+		Log:          utils.Log,
+		DB:           db,
+		Validate:     validate,
+		EmailService: emailService,
+	}
+}
+
+// blockedBetween reports whether either user blocked the other. Every read of a user's data on behalf of
+// another user checks it, like privacySettingsOf.
+func blockedBetween(db *gorm.DB, userID, otherID uuid.UUID) (bool, error) {
+	var blocks int64
+	err := db.Model(&model.UserBlock{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", userID, otherID, otherID, userID).
+		Count(&blocks).Error
+	return blocks > 0, err
+}
+
+// userByHandle returns the user behind a handle, anonymized users have none
+func userByHandle(db *gorm.DB, handle string) (*model.User, error) {
+	var user model.User
+	if err := db.Where("handle = ?", model.NormalizeHandle(handle)).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func publicProfileOf(user *model.User) model.PublicProfile {
+	var handle string
+	if user.Handle != nil {
+		handle = *user.Handle
+	}
+	return model.PublicProfile{
+		Handle:         handle,
+		Name:           user.Name,
+		ProfilePicture: user.ProfilePicture,
+		CurrentStreak:  user.CurrentStreak,
+	}
+}
+
+// handleOwner returns the user behind a handle, nil when nobody has it
+func handleOwner(db *gorm.DB, handle string) (*model.User, error) {
+	user, err := userByHandle(db, handle)
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusNotFound {
+		return nil, nil
+	}
+	return user, err
+}
+
+func (s *moderationService) Block(c *fiber.Ctx, blockerID uuid.UUID, handle string) (*model.BlockedUser, error) {
+	db := s.DB.WithContext(c.UserContext())
+
+	blocked, err := handleOwner(db, handle)
+	if err != nil {
+		return nil, err
+	}
+	if blocked == nil {
+		return &model.BlockedUser{
+			PublicProfile: model.PublicProfile{Handle: model.NormalizeHandle(handle)},
+			BlockedAt:     clock.Now(c.UserContext()),
+		}, nil
+	}
+	if blocked.ID == blockerID {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "You cannot block yourself")
+	}
+
+	block := model.UserBlock{BlockerID: blockerID, BlockedID: blocked.ID}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error; err != nil {
+		return nil, err
+	}
+	if err := db.First(&block, "blocker_id = ? AND blocked_id = ?", blockerID, blocked.ID).Error; err != nil {
+		return nil, err
+	}
+
+	settings, err := privacySettingsOf(db, blocked.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.BlockedUser{PublicProfile: settings.ProfileSeenBy(publicProfileOf(blocked), blockerID), BlockedAt: block.CreatedAt}, nil
+}
+
+func (s *moderationService) Unblock(c *fiber.Ctx, blockerID uuid.UUID, handle string) error {
+	db := s.DB.WithContext(c.UserContext())
+	notBlocked := fiber.NewError(fiber.StatusNotFound, "User is not blocked")
+
+	blocked, err := handleOwner(db, handle)
+	if err != nil {
+		return err
+	}
+	if blocked == nil {
+		return notBlocked
+	}
+
+	result := db.Where("blocker_id = ? AND blocked_id = ?", blockerID, blocked.ID).Delete(&model.UserBlock{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notBlocked
+	}
+	return nil
+}
+
+func (s *moderationService) GetBlocks(c *fiber.Ctx, userID uuid.UUID) ([]model.BlockedUser, error) {
+	db := s.DB.WithContext(c.UserContext())
+
+	var rows []struct {
+		model.User
+		BlockedAt time.Time
+	}
+	if err := db.Model(&model.User{}).
+		Select("users.*, user_blocks.created_at AS blocked_at").
+		Joins("JOIN user_blocks ON user_blocks.blocked_id = users.id").
+		Where("user_blocks.blocker_id = ?", userID).
+		Order("user_blocks.created_at DESC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i := range rows {
+		ids[i] = rows[i].ID
+	}
+	var stored []model.PrivacySettings
+	if len(ids) > 0 {
+		if err := db.Where("user_id IN ?", ids).Find(&stored).Error; err != nil {
+			return nil, err
+		}
+	}
+	settings := make(map[uuid.UUID]model.PrivacySettings, len(stored))
+	for _, setting := range stored {
+		settings[setting.UserID] = setting
+	}
+
+	blocks := make([]model.BlockedUser, len(rows))
+	for i := range rows {
+		setting, ok := settings[rows[i].ID]
+		if !ok {
+			setting = model.DefaultPrivacySettings(rows[i].ID)
+		}
+		blocks[i] = model.BlockedUser{PublicProfile: setting.ProfileSeenBy(publicProfileOf(&rows[i].User), userID), BlockedAt: rows[i].BlockedAt}
+	}
+	return blocks, nil
+}
+
+func (s *moderationService) Report(c *fiber.Ctx, reporterID uuid.UUID, handle string, req *validation.ReportUser) (*model.SentReport, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+	reported, err := handleOwner(db, handle)
+	if err != nil {
+		return nil, err
+	}
+	if reported != nil && reported.ID == reporterID {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "You cannot report yourself")
+	}
+
+	details := maskProfanity(textfilter.NormalizeMultiline(req.Details))
+	sent := &model.SentReport{
+		Handle:  model.NormalizeHandle(handle),
+		Reason:  req.Reason,
+		Details: details,
+		SentAt:  clock.Now(c.UserContext()),
+	}
+	if reported == nil {
+		return sent, nil
+	}
+
+	report := &model.UserReport{
+		ReporterID: reporterID,
+		ReportedID: reported.ID,
+		Reason:     req.Reason,
+		Details:    details,
+		Status:     model.ReportOpen,
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Serializes reports of the same user so the same reporter cannot file twice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&model.User{}, "id = ?", reported.ID).Error; err != nil {
+			return err
+		}
+
+		var open int64
+		if err := tx.Model(&model.UserReport{}).
+			Where("reporter_id = ? AND reported_id = ? AND status = ?", reporterID, reported.ID, model.ReportOpen).
+			Count(&open).Error; err != nil {
+			return err
+		}
+		if open > 0 {
+			// The open report stands, a moderator will review it
+			return nil
+		}
+
+		return tx.Create(report).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sent, nil
+}
+
+func (s *moderationService) GetReports(c *fiber.Ctx, query *validation.UserReportQuery) ([]model.UserReport, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	db := s.DB.WithContext(c.UserContext()).Model(&model.UserReport{})
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+
+	var totalResults int64
+	if err := db.Count(&totalResults).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// The queue is worked oldest first, resolved reports are looked up newest first
+	order := "created_at DESC"
+	if query.Status == model.ReportOpen {
+		order = "created_at ASC"
+	}
+
+	var reports []model.UserReport
+	if err := db.Preload("Reported").
+		Order(order).
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&reports).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return reports, totalResults, nil
+}
+
+func (s *moderationService) ResolveReport(c *fiber.Ctx, adminID, reportID uuid.UUID, req *validation.ResolveUserReport) (*model.UserReport, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	now := clock.Now(c.UserContext())
+	report := new(model.UserReport)
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(report, "id = ?", reportID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, "Report not found")
+			}
+			return err
+		}
+		if report.Status != model.ReportOpen {
+			return fiber.NewError(fiber.StatusConflict, "Report is already resolved")
+		}
+
+		reported := new(model.User)
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(reported, "id = ?", report.ReportedID).Error; err != nil {
+			return err
+		}
+
+		switch req.Action {
+		case "dismiss":
+			report.Status = model.ReportDismissed
+		case "warn":
+			report.Status = model.ReportWarned
+		case "suspend":
+			report.Status = model.ReportSuspended
+			until := now.AddDate(0, 0, req.SuspendDays)
+			// A shorter suspension never cuts one already running
+			if reported.SuspendedUntil != nil && reported.SuspendedUntil.After(until) {
+				until = *reported.SuspendedUntil
+			}
+			if err := tx.Model(reported).Update("suspended_until", until).Error; err != nil {
+				return err
+			}
+			reported.SuspendedUntil = &until
+			report.SuspendedUntil = &until
+		}
+
+		report.Note = req.Note
+		report.ResolvedByID = &adminID
+		report.ResolvedAt = &now
+		if err := tx.Save(report).Error; err != nil {
+			return err
+		}
+		report.Reported = reported
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch report.Status {
+	case model.ReportWarned:
+		if err := s.EmailService.SendAccountWarningEmail(report.Reported.Email, report.Note); err != nil {
+			s.Log.Warnf("Failed to send the warning of report %s: %v", report.ID, err)
+		}
+	case model.ReportSuspended:
+		if err := s.EmailService.SendAccountSuspendedEmail(report.Reported.Email, report.Note, *report.SuspendedUntil); err != nil {
+			s.Log.Warnf("Failed to send the suspension of report %s: %v", report.ID, err)
+		}
+	}
+
+	return report, nil
+}
+
+func (s *moderationService) LiftSuspension(c *fiber.Ctx, userID uuid.UUID) (*model.User, error) {
+	user := new(model.User)
+	db := s.DB.WithContext(c.UserContext())
+	if err := db.First(user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
+		}
+		return nil, err
+	}
+	if !user.IsSuspendedAt(clock.Now(c.UserContext())) {
+		return nil, fiber.NewError(fiber.StatusConflict, "User is not suspended")
+	}
+
+	if err := db.Model(user).Update("suspended_until", nil).Error; err != nil {
+		return nil, err
+	}
+	user.SuspendedUntil = nil
+	return user, nil
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrUnsubscribed is returned when an email is not sent because its recipient unsubscribed from its category
+var ErrUnsubscribed = errors.New("the recipient unsubscribed from these notifications")
+
+type NotificationPreferenceService interface {
+	GetPreferences(c *fiber.Ctx, userID uuid.UUID) ([]model.NotificationPreferenceView, error)
+	UpdatePreferences(c *fiber.Ctx, userID uuid.UUID, req *validation.UpdateNotificationPreferences) ([]model.NotificationPreferenceView, error)
+	// Unsubscribe turns off the category of an unsubscribe link, the link is the only credential
+	Unsubscribe(c *fiber.Ctx, req *validation.Unsubscribe) (*model.NotificationPreferenceView, error)
+
+	// Recipient looks up the user an email goes to, whether they receive the category and through which channel.
+	// Addresses that are not a user's and required categories are always sent, by email to non-users. The phone
+	// channels are only used while the user has a verified phone.
+	Recipient(ctx context.Context, email, category string) (*model.NotificationRecipient, error)
+}
+
+type notificationPreferenceService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewNotificationPreferenceService(db *gorm.DB, validate *validator.Validate) NotificationPreferenceService {
+	return &notificationPreferenceService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *notificationPreferenceService) GetPreferences(c *fiber.Ctx, userID uuid.UUID) ([]model.NotificationPreferenceView, error) {
+	var preferences []model.NotificationPreference
+	if err := s.DB.WithContext(c.UserContext()).Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return nil, err
+	}
+
+	return model.PreferenceViews(preferences), nil
+}
+
+func (s *notificationPreferenceService) UpdatePreferences(c *fiber.Ctx, userID uuid.UUID, req *validation.UpdateNotificationPreferences) ([]model.NotificationPreferenceView, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if len(req.Preferences) == 0 && len(req.Channels) == 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "No notification preferences to update")
+	}
+
+	var existing []model.NotificationPreference
+	if err := s.DB.WithContext(c.UserContext()).Where("user_id = ?", userID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	// Subscriptions and channels are saved together, a change to one keeps the other
+	changed := make(map[string]*model.NotificationPreference)
+	preference := func(key string) *model.NotificationPreference {
+		if preference, ok := changed[key]; ok {
+			return preference
+		}
+		preference := &model.NotificationPreference{
+			UserID: userID, Category: key, Subscribed: true, Channel: model.NotificationChannelEmail,
+		}
+		for _, saved := range existing {
+			if saved.Category == key {
+				*preference = saved
+			}
+		}
+		preference.Source = model.PreferenceSourceCenter
+		changed[key] = preference
+		return preference
+	}
+
+	for key, subscribed := range req.Preferences {
+		category, ok := model.LookupNotificationCategory(key)
+		if !ok {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Unknown notification category %q", key))
+		}
+		if category.Required && !subscribed {
+			return nil, fiber.NewError(fiber.StatusBadRequest,
+				fmt.Sprintf("%s notifications are required and cannot be turned off", category.Key))
+		}
+		if category.Required {
+			continue
+		}
+		preference(key).Subscribed = subscribed
+	}
+
+	var verifiedPhone *bool
+	for key, channel := range req.Channels {
+		category, ok := model.LookupNotificationCategory(key)
+		if !ok {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Unknown notification category %q", key))
+		}
+		if !category.AllowsChannel(channel) {
+			return nil, fiber.NewError(fiber.StatusBadRequest,
+				fmt.Sprintf("%s notifications cannot be sent by %s", category.Key, channel))
+		}
+		if channel != model.NotificationChannelEmail {
+			if verifiedPhone == nil {
+				var user model.User
+				if err := s.DB.WithContext(c.UserContext()).Select("verified_phone").First(&user, "id = ?", userID).Error; err != nil {
+					return nil, err
+				}
+				verifiedPhone = &user.VerifiedPhone
+			}
+			if !*verifiedPhone {
+				return nil, fiber.NewError(fiber.StatusBadRequest,
+					fmt.Sprintf("Verify your phone number to receive notifications by %s", channel))
+			}
+		}
+		preference(key).Channel = channel
+	}
+
+	if len(changed) > 0 {
+		preferences := make([]model.NotificationPreference, 0, len(changed))
+		for _, preference := range changed {
+			preferences = append(preferences, *preference)
+		}
+		if err := s.save(c.UserContext(), []string{"subscribed", "channel", "source", "updated_at"}, preferences...); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetPreferences(c, userID)
+}
+
+func (s *notificationPreferenceService) Unsubscribe(c *fiber.Ctx, req *validation.Unsubscribe) (*model.NotificationPreferenceView, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	userID, key, err := parseUnsubscribeToken(req.Token)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid unsubscribe link")
+	}
+	category, ok := model.LookupNotificationCategory(key)
+	if !ok || category.Required {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid unsubscribe link")
+	}
+
+	preference := model.NotificationPreference{
+		UserID:     userID,
+		Category:   key,
+		Subscribed: false,
+		Channel:    model.NotificationChannelEmail,
+		Source:     model.PreferenceSourceUnsubscribe,
+	}
+	// The user may have been deleted since the email was sent, there is nothing left to unsubscribe then
+	if err := s.DB.WithContext(c.UserContext()).First(&model.User{}, "id = ?", userID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	} else if err := s.save(c.UserContext(), []string{"subscribed", "source", "updated_at"}, preference); err != nil {
+		return nil, err
+	}
+
+	updatedAt := time.Now()
+	return &model.NotificationPreferenceView{NotificationCategory: category, UpdatedAt: &updatedAt}, nil
+}
+
+func (s *notificationPreferenceService) Recipient(ctx context.Context, email, category string) (*model.NotificationRecipient, error) {
+	recipient := &model.NotificationRecipient{Subscribed: true, Channel: model.NotificationChannelEmail}
+
+	var user model.User
+	result := s.DB.WithContext(ctx).Select("id", "phone", "verified_phone").Where("email = ?", email).Limit(1).Find(&user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return recipient, nil
+	}
+	recipient.UserID = &user.ID
+
+	definition, ok := model.LookupNotificationCategory(category)
+	if !ok {
+		return recipient, nil
+	}
+
+	var preference model.NotificationPreference
+	result = s.DB.WithContext(ctx).Where("user_id = ? AND category = ?", user.ID, category).Limit(1).Find(&preference)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return recipient, nil
+	}
+
+	if !definition.Required {
+		recipient.Subscribed = preference.Subscribed
+	}
+	if preference.Channel != model.NotificationChannelEmail && definition.AllowsChannel(preference.Channel) && user.VerifiedPhone {
+		recipient.Channel = preference.Channel
+		recipient.Phone = user.Phone
+	}
+	return recipient, nil
+}
+
+// save upserts preferences, updating columns of the ones the user already has
+func (s *notificationPreferenceService) save(ctx context.Context, columns []string, preferences ...model.NotificationPreference) error {
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "category"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(&preferences).Error
+}
+
+// unsubscribeToken signs a link that unsubscribes the user from the category. It does not expire,
+// the links in old emails must keep working.
+func unsubscribeToken(userID uuid.UUID, category string) (string, error) {
+	claims := jwt.MapClaims{
+		"sub":      userID.String(),
+		"iat":      time.Now().Unix(),
+		"type":     config.TokenTypeUnsubscribe,
+		"category": category,
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.JWTSecret))
+}
+
+func parseUnsubscribeToken(token string) (uuid.UUID, string, error) {
+	sub, err := utils.VerifyToken(token, config.JWTSecret, config.TokenTypeUnsubscribe)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	category, _ := parsed.Claims.(jwt.MapClaims)["category"].(string)
+	return userID, category, nil
+}
+
+// unsubscribeLinks are the page users open from the email and the URL mail clients post to for a
+// one-click unsubscribe (RFC 8058)
+func unsubscribeLinks(token string) (page, oneClick string) {
+	query := url.Values{"token": {token}}.Encode()
+	page = fmt.Sprintf("%s/unsubscribe?%s", strings.TrimRight(config.FrontendURL, "/"), query)
+	oneClick = fmt.Sprintf("%s/v1/notifications/unsubscribe?%s", strings.TrimRight(config.AppURL, "/"), query)
+	return page, oneClick
+}
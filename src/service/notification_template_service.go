@@ -0,0 +1,261 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type NotificationTemplateService interface {
+	// GetTemplates returns the version in use of every saved template
+	GetTemplates(c *fiber.Ctx, query *validation.NotificationTemplateQuery) ([]model.NotificationTemplate, error)
+	GetVersions(c *fiber.Ctx, key, locale string) ([]model.NotificationTemplate, error)
+	// SaveTemplate saves the copy as the next version of the template, which is sent from then on
+	SaveTemplate(c *fiber.Ctx, adminID uuid.UUID, req *validation.SaveNotificationTemplate) (*model.NotificationTemplate, error)
+	// RestoreVersion saves an earlier version again as the next one
+	RestoreVersion(c *fiber.Ctx, adminID, templateID uuid.UUID) (*model.NotificationTemplate, error)
+	// DeleteTemplate removes every version of the template, the built-in copy is sent again
+	DeleteTemplate(c *fiber.Ctx, key, locale string) error
+	Preview(c *fiber.Ctx, req *validation.PreviewNotificationTemplate) (*model.RenderedNotification, error)
+
+	// Render fills the template in use for key in locale with variables. Without a saved template in the
+	// locale, the default locale and then the built-in copy are used.
+	Render(ctx context.Context, key, locale string, variables map[string]string) (*model.RenderedNotification, error)
+}
+
+type notificationTemplateService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewNotificationTemplateService(db *gorm.DB, validate *validator.Validate) NotificationTemplateService {
+	return &notificationTemplateService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *notificationTemplateService) GetTemplates(c *fiber.Ctx, query *validation.NotificationTemplateQuery) ([]model.NotificationTemplate, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	db := s.DB.WithContext(c.UserContext()).
+		Select("DISTINCT ON (key, locale) *").
+		Order("key, locale, version DESC")
+	if query.Key != "" {
+		db = db.Where("key = ?", query.Key)
+	}
+	if query.Locale != "" {
+		db = db.Where("locale = ?", query.Locale)
+	}
+
+	var templates []model.NotificationTemplate
+	if err := db.Find(&templates).Error; err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (s *notificationTemplateService) GetVersions(c *fiber.Ctx, key, locale string) ([]model.NotificationTemplate, error) {
+	var templates []model.NotificationTemplate
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("key = ? AND locale = ?", key, locale).
+		Order("version DESC").
+		Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Notification template not found")
+	}
+
+	return templates, nil
+}
+
+func (s *notificationTemplateService) SaveTemplate(c *fiber.Ctx, adminID uuid.UUID, req *validation.SaveNotificationTemplate) (*model.NotificationTemplate, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	locale := req.Locale
+	if locale == "" {
+		locale = model.DefaultTemplateLocale
+	}
+
+	return s.saveVersion(c, adminID, req.Key, locale, req.Subject, req.Body)
+}
+
+func (s *notificationTemplateService) RestoreVersion(c *fiber.Ctx, adminID, templateID uuid.UUID) (*model.NotificationTemplate, error) {
+	var version model.NotificationTemplate
+	if err := s.DB.WithContext(c.UserContext()).First(&version, "id = ?", templateID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Notification template not found")
+		}
+		return nil, err
+	}
+
+	return s.saveVersion(c, adminID, version.Key, version.Locale, version.Subject, version.Body)
+}
+
+// saveVersion checks the copy renders with the variables of the notification and saves it as the next version
+func (s *notificationTemplateService) saveVersion(c *fiber.Ctx, adminID uuid.UUID, key, locale, subject, body string) (*model.NotificationTemplate, error) {
+	definition, ok := model.TemplateDefinitions[key]
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Unknown notification template key")
+	}
+	if _, _, err := model.RenderTemplate(subject, body, definition.Variables); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	template := &model.NotificationTemplate{
+		Key:         key,
+		Locale:      locale,
+		Subject:     subject,
+		Body:        body,
+		Variables:   definition.VariableNames(),
+		CreatedByID: adminID,
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+	if err := db.Model(&model.NotificationTemplate{}).
+		Where("key = ? AND locale = ?", key, locale).
+		Select("COALESCE(MAX(version), 0) + 1").
+		Scan(&template.Version).Error; err != nil {
+		return nil, err
+	}
+
+	// Two admins saving at once get the same version, the unique index keeps the second one out
+	if err := db.Create(template).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, fiber.NewError(fiber.StatusConflict, "The template was changed meanwhile, reload it and save again")
+		}
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (s *notificationTemplateService) DeleteTemplate(c *fiber.Ctx, key, locale string) error {
+	result := s.DB.WithContext(c.UserContext()).
+		Where("key = ? AND locale = ?", key, locale).
+		Delete(&model.NotificationTemplate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "Notification template not found")
+	}
+
+	return nil
+}
+
+func (s *notificationTemplateService) Preview(c *fiber.Ctx, req *validation.PreviewNotificationTemplate) (*model.RenderedNotification, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	definition := model.TemplateDefinitions[req.Key]
+	variables := make(map[string]string, len(definition.Variables))
+	for name, sample := range definition.Variables {
+		variables[name] = sample
+	}
+	for name, value := range req.Variables {
+		if _, ok := variables[name]; ok {
+			variables[name] = value
+		}
+	}
+
+	locale := req.Locale
+	if locale == "" {
+		locale = model.DefaultTemplateLocale
+	}
+
+	if req.Subject == "" && req.Body == "" {
+		rendered, err := s.Render(c.UserContext(), req.Key, locale, variables)
+		if err != nil {
+			return nil, err
+		}
+		return rendered, nil
+	}
+
+	subject, body := req.Subject, req.Body
+	if subject == "" {
+		subject = definition.Subject
+	}
+	if body == "" {
+		body = definition.Body
+	}
+
+	renderedSubject, renderedBody, err := model.RenderTemplate(subject, body, variables)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return &model.RenderedNotification{
+		Key:     req.Key,
+		Locale:  locale,
+		Subject: renderedSubject,
+		Body:    renderedBody,
+	}, nil
+}
+
+func (s *notificationTemplateService) Render(ctx context.Context, key, locale string, variables map[string]string) (*model.RenderedNotification, error) {
+	definition, ok := model.TemplateDefinitions[key]
+	if !ok {
+		return nil, errors.New("unknown notification template " + key)
+	}
+
+	data := definition.Data(variables)
+
+	for _, candidate := range []string{locale, model.DefaultTemplateLocale} {
+		var template model.NotificationTemplate
+		result := s.DB.WithContext(ctx).
+			Where("key = ? AND locale = ?", key, candidate).
+			Order("version DESC").
+			Limit(1).
+			Find(&template)
+		if result.Error != nil {
+			s.Log.Errorf("Failed to load notification template %s/%s: %v", key, candidate, result.Error)
+			break
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		subject, body, err := model.RenderTemplate(template.Subject, template.Body, data)
+		if err != nil {
+			s.Log.Errorf("Notification template %s/%s version %d does not render, sending the built-in copy: %v",
+				key, candidate, template.Version, err)
+			break
+		}
+		return &model.RenderedNotification{
+			Key:     key,
+			Locale:  candidate,
+			Version: template.Version,
+			Subject: subject,
+			Body:    body,
+		}, nil
+	}
+
+	subject, body, err := model.RenderTemplate(definition.Subject, definition.Body, data)
+	if err != nil {
+		return nil, err
+	}
+	return &model.RenderedNotification{
+		Key:     key,
+		Locale:  model.DefaultTemplateLocale,
+		Subject: subject,
+		Body:    body,
+	}, nil
+}
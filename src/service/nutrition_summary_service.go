@@ -0,0 +1,119 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// nutritionReportMaxDays bounds the range of a single nutrition report
+const nutritionReportMaxDays = 366
+
+// Days are calendar days of the database time zone, the same ones the logged days counter uses
+const (
+	dailySummaryInsert = `INSERT INTO daily_nutrition_summaries (user_id, date, calories, protein, carbs, fat, meal_count, updated_at)
+		SELECT user_id, DATE(meal_time), SUM(calories), SUM(protein), SUM(carbs), SUM(fat), COUNT(*), NOW()
+		FROM meal_histories`
+	dailySummaryUpsert = ` GROUP BY user_id, DATE(meal_time)
+		ON CONFLICT (user_id, date) DO UPDATE SET
+			calories = EXCLUDED.calories,
+			protein = EXCLUDED.protein,
+			carbs = EXCLUDED.carbs,
+			fat = EXCLUDED.fat,
+			meal_count = EXCLUDED.meal_count,
+			updated_at = EXCLUDED.updated_at`
+	// A summary without any meal left on its day is removed rather than zeroed
+	emptySummaryCondition = `NOT EXISTS (SELECT 1 FROM meal_histories
+		WHERE meal_histories.user_id = daily_nutrition_summaries.user_id
+			AND DATE(meal_histories.meal_time) = daily_nutrition_summaries.date)`
+)
+
+type NutritionSummaryService interface {
+	GetReport(c *fiber.Ctx, userID uuid.UUID, query *validation.NutritionReportQuery) (*model.NutritionReport, error)
+
+	// Backfill summarizes the days that have no summary yet or whose meals changed after it, and drops empty ones
+	Backfill(ctx context.Context) error
+}
+
+type nutritionSummaryService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewNutritionSummaryService(db *gorm.DB, validate *validator.Validate) NutritionSummaryService {
+	return &nutritionSummaryService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *nutritionSummaryService) GetReport(
+	c *fiber.Ctx, userID uuid.UUID, query *validation.NutritionReportQuery,
+) (*model.NutritionReport, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	from, _ := time.Parse("2006-01-02", query.From)
+	to, _ := time.Parse("2006-01-02", query.To)
+
+	if to.Before(from) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+	if from.AddDate(0, 0, nutritionReportMaxDays-1).Before(to) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Report range is limited to 366 days")
+	}
+
+	var summaries []model.DailyNutritionSummary
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("user_id = ? AND date BETWEEN ? AND ?", userID, query.From, query.To).
+		Order("date").
+		Find(&summaries).Error; err != nil {
+		s.Log.Errorf("Failed to get nutrition summaries: %+v", err)
+		return nil, err
+	}
+
+	return model.BuildNutritionReport(summaries, from, to), nil
+}
+
+func (s *nutritionSummaryService) Backfill(ctx context.Context) error {
+	db := s.DB.WithContext(ctx)
+
+	result := db.Exec(dailySummaryInsert + ` WHERE (user_id, DATE(meal_time)) IN (
+			SELECT meal_histories.user_id, DATE(meal_histories.meal_time) FROM meal_histories
+			LEFT JOIN daily_nutrition_summaries ON daily_nutrition_summaries.user_id = meal_histories.user_id
+				AND daily_nutrition_summaries.date = DATE(meal_histories.meal_time)
+			WHERE daily_nutrition_summaries.user_id IS NULL
+				OR meal_histories.updated_at > daily_nutrition_summaries.updated_at
+		)` + dailySummaryUpsert)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		s.Log.Infof("Backfilled %d daily nutrition summaries", result.RowsAffected)
+	}
+
+	return db.Where(emptySummaryCondition).Delete(&model.DailyNutritionSummary{}).Error
+}
+
+// refreshDailySummary recomputes the summary of the day a meal was eaten on, mealTime is any time within that day
+func refreshDailySummary(db *gorm.DB, userID uuid.UUID, mealTime time.Time) error {
+	if err := db.Exec(dailySummaryInsert+` WHERE user_id = ? AND DATE(meal_time) = CAST(? AS timestamptz)::date`+dailySummaryUpsert,
+		userID, mealTime).Error; err != nil {
+		return err
+	}
+
+	return db.Where("user_id = ? AND date = CAST(? AS timestamptz)::date", userID, mealTime).
+		Where(emptySummaryCondition).
+		Delete(&model.DailyNutritionSummary{}).Error
+}
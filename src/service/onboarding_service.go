@@ -0,0 +1,172 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OnboardingService interface {
+	GetOnboarding(c *fiber.Ctx, userID uuid.UUID) (*model.OnboardingChecklist, error)
+	// ReportNotificationPermission records whether the app was allowed to notify, granting it completes the step
+	ReportNotificationPermission(c *fiber.Ctx, userID uuid.UUID, req *validation.NotificationPermission) (*model.OnboardingChecklist, error)
+	// GetFunnel follows the users who signed up between two days through the onboarding steps
+	GetFunnel(c *fiber.Ctx, query *validation.OnboardingFunnelQuery) (*model.OnboardingFunnel, error)
+}
+
+type onboardingService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewOnboardingService(db *gorm.DB, validate *validator.Validate) OnboardingService {
+	return &onboardingService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *onboardingService) GetOnboarding(c *fiber.Ctx, userID uuid.UUID) (*model.OnboardingChecklist, error) {
+	onboarding, err := loadOnboarding(s.DB.WithContext(c.UserContext()), userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	checklist := onboarding.Checklist()
+	return &checklist, nil
+}
+
+func (s *onboardingService) ReportNotificationPermission(c *fiber.Ctx, userID uuid.UUID, req *validation.NotificationPermission) (*model.OnboardingChecklist, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if err := appendEvent(s.DB.WithContext(c.UserContext()), model.EventNotificationPermission, userID, userID,
+		model.EventPayload{Granted: *req.Granted}, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return s.GetOnboarding(c, userID)
+}
+
+func (s *onboardingService) GetFunnel(c *fiber.Ctx, query *validation.OnboardingFunnelQuery) (*model.OnboardingFunnel, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	from, _ := time.Parse("2006-01-02", query.From)
+	to, _ := time.Parse("2006-01-02", query.To)
+	if to.Before(from) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+
+	// Users who did nothing yet have no onboarding row, they count as signed up only
+	columns := []string{"COUNT(*) AS signed_up", "COUNT(user_onboardings.completed_at) AS completed"}
+	var previous []string
+	for i, step := range model.OnboardingSteps {
+		column := "user_onboardings." + model.OnboardingStepColumns[step]
+		previous = append(previous, column+" IS NOT NULL")
+		columns = append(columns,
+			fmt.Sprintf("COUNT(%s) AS users_%d", column, i),
+			fmt.Sprintf("COUNT(*) FILTER (WHERE %s) AS reached_%d", strings.Join(previous, " AND "), i))
+	}
+
+	counts := map[string]any{}
+	if err := s.DB.WithContext(c.UserContext()).
+		Table("users").
+		Select(strings.Join(columns, ", ")).
+		Joins("LEFT JOIN user_onboardings ON user_onboardings.user_id = users.id").
+		// QA accounts stay out of product metrics like they stay out of revenue
+		Where("users.is_sandbox = ?", false).
+		Where("users.created_at >= ? AND users.created_at < ?", from, to.AddDate(0, 0, 1)).
+		Take(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	users := make(map[string]int64, len(model.OnboardingSteps))
+	reached := make(map[string]int64, len(model.OnboardingSteps))
+	for i, step := range model.OnboardingSteps {
+		users[step] = countValue(counts[fmt.Sprintf("users_%d", i)])
+		reached[step] = countValue(counts[fmt.Sprintf("reached_%d", i)])
+	}
+
+	return model.NewOnboardingFunnel(query.From, query.To,
+		countValue(counts["signed_up"]), countValue(counts["completed"]), users, reached), nil
+}
+
+func countValue(value any) int64 {
+	count, _ := value.(int64)
+	return count
+}
+
+// loadOnboarding returns the onboarding of the user, building it from their events the first time.
+// lock holds the row until the end of the transaction of db.
+func loadOnboarding(db *gorm.DB, userID uuid.UUID, lock bool) (*model.UserOnboarding, error) {
+	find := func(onboarding *model.UserOnboarding) (int64, error) {
+		query := db
+		if lock {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		result := query.Where("user_id = ?", userID).Limit(1).Find(onboarding)
+		return result.RowsAffected, result.Error
+	}
+
+	var onboarding model.UserOnboarding
+	found, err := find(&onboarding)
+	if err != nil || found > 0 {
+		return &onboarding, err
+	}
+
+	var user model.User
+	if err := db.Select("id", "birth_date", "height", "weight", "gender", "activity_level", "updated_at").
+		First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	var events []model.DomainEvent
+	if err := db.Where("user_id = ? AND type IN ?", userID, model.OnboardingEventTypes).
+		Order("sequence").
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	built, err := model.BuildUserOnboarding(&user, events)
+	if err != nil {
+		return nil, err
+	}
+
+	// Another request may have built it meanwhile, theirs is kept
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(built).Error; err != nil {
+		return nil, err
+	}
+	if _, err := find(&onboarding); err != nil {
+		return nil, err
+	}
+	return &onboarding, nil
+}
+
+// applyOnboardingEvent completes the onboarding steps an appended event stands for
+func applyOnboardingEvent(db *gorm.DB, event *model.DomainEvent) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		onboarding, err := loadOnboarding(tx, event.UserID, true)
+		if err != nil {
+			return err
+		}
+
+		completed, err := onboarding.Apply(event)
+		if err != nil || !completed {
+			return err
+		}
+		return tx.Save(onboarding).Error
+	})
+}
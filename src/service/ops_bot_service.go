@@ -0,0 +1,439 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/notify"
+	"app/src/utils"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// opsBotLinkCodeLength is short enough to type into a chat
+	opsBotLinkCodeLength = 8
+	opsBotLinkCodeTTL    = 10 * time.Minute
+)
+
+// errOpsUsage makes the bot answer with the usage of the command
+var errOpsUsage = errors.New("invalid command arguments")
+
+// opsCommand is a chat command and the admin right needed to run it
+type opsCommand struct {
+	usage string
+	right string
+	run   func(s *opsBotService, ctx context.Context, admin *model.User, args []string) (string, error)
+}
+
+// opsCommands only contains read-only or reversible operations, destructive admin actions stay in the dashboard
+var opsCommands = map[string]opsCommand{
+	"kpi":         {"kpi [YYYY-MM-DD] - key numbers of a day, today by default", "viewRevenueReports", (*opsBotService).kpiCommand},
+	"user":        {"user <email> - look up a user, their subscription and wallet", "getUserDetails", (*opsBotService).userCommand},
+	"receipt":     {"receipt <order_id> - email the payment receipt of an order to its user again", "viewTransactions", (*opsBotService).receiptCommand},
+	"maintenance": {"maintenance on|off|status [message] - toggle maintenance mode", "manageMaintenance", (*opsBotService).maintenanceCommand},
+}
+
+type OpsBotService interface {
+	CreateLinkCode(c *fiber.Ctx, adminID uuid.UUID) (*model.OpsBotLinkCode, error)
+	GetIdentities(c *fiber.Ctx, adminID uuid.UUID) ([]model.OpsBotIdentity, error)
+	DeleteIdentity(c *fiber.Ctx, adminID, identityID uuid.UUID) error
+
+	// HandleCommand runs a chat command as the admin linked to the sender and returns the reply
+	HandleCommand(ctx context.Context, platform, externalID, text string) string
+
+	GetDailyKPIs(ctx context.Context, day time.Time) (*model.DailyKPIs, error)
+	ReportDailyKPIs(ctx context.Context) error
+}
+
+type opsBotService struct {
+	Log                *logrus.Logger
+	DB                 *gorm.DB
+	EmailService       EmailService
+	MaintenanceService MaintenanceService
+	Telegram           *notify.TelegramBot
+}
+
+func NewOpsBotService(db *gorm.DB, emailService EmailService, maintenanceService MaintenanceService) OpsBotService {
+	return &opsBotService{
+		Log:                utils.Log,
+		DB:                 db,
+		EmailService:       emailService,
+		MaintenanceService: maintenanceService,
+		Telegram:           notify.NewTelegramBot(config.TelegramBotToken),
+	}
+}
+
+// CreateLinkCode issues a one-time code the admin sends to the bot as "link <code>"
+func (s *opsBotService) CreateLinkCode(c *fiber.Ctx, adminID uuid.UUID) (*model.OpsBotLinkCode, error) {
+	token := &model.Token{
+		Token:   utils.GenerateRandomString(opsBotLinkCodeLength),
+		UserID:  adminID,
+		Type:    config.TokenTypeOpsBotLink,
+		Expires: time.Now().Add(opsBotLinkCodeTTL),
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Create(token).Error; err != nil {
+		return nil, err
+	}
+
+	return &model.OpsBotLinkCode{Code: token.Token, ExpiresAt: token.Expires}, nil
+}
+
+func (s *opsBotService) GetIdentities(c *fiber.Ctx, adminID uuid.UUID) ([]model.OpsBotIdentity, error) {
+	var identities []model.OpsBotIdentity
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("user_id = ?", adminID).
+		Order("created_at").
+		Find(&identities).Error; err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+func (s *opsBotService) DeleteIdentity(c *fiber.Ctx, adminID, identityID uuid.UUID) error {
+	result := s.DB.WithContext(c.UserContext()).
+		Where("user_id = ?", adminID).
+		Delete(&model.OpsBotIdentity{}, "id = ?", identityID)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "Linked account not found")
+	}
+
+	return nil
+}
+
+func (s *opsBotService) HandleCommand(ctx context.Context, platform, externalID, text string) string {
+	name, args := model.ParseOpsCommand(text)
+
+	if name == "link" {
+		return s.link(ctx, platform, externalID, args)
+	}
+
+	var identity model.OpsBotIdentity
+	if err := s.DB.WithContext(ctx).
+		Preload("User").
+		Where("platform = ? AND external_id = ?", platform, externalID).
+		First(&identity).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			s.Log.Errorf("Failed to load ops bot identity %s/%s: %v", platform, externalID, err)
+		}
+		return "This account is not linked to a Nutribox admin. Create a link code in the admin dashboard and send: link <code>"
+	}
+
+	admin := identity.User
+	if admin == nil || !hasRight(admin.Role, "useOpsBot") {
+		return "You don't have permission to use the ops bot"
+	}
+
+	command, ok := opsCommands[name]
+	if !ok {
+		return s.help(admin)
+	}
+
+	if !hasRight(admin.Role, command.right) {
+		return "You don't have permission to run " + name
+	}
+
+	reply, err := command.run(s, ctx, admin, args)
+	if err != nil {
+		if errors.Is(err, errOpsUsage) {
+			return "Usage: " + command.usage
+		}
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			return fiberErr.Message
+		}
+		s.Log.Errorf("Ops bot command %q by %s failed: %v", name, admin.ID, err)
+		return "Command failed, check the server logs"
+	}
+
+	utils.LogUserActivity(utils.ActivityData{
+		UserID:   admin.ID.String(),
+		Action:   "ops_bot_" + name,
+		Resource: "ops_bot",
+		Details: map[string]interface{}{
+			"platform": platform,
+			"args":     args,
+		},
+		StatusCode: fiber.StatusOK,
+	})
+
+	return reply
+}
+
+// link binds the chat account to the admin who created the code, a code works once
+func (s *opsBotService) link(ctx context.Context, platform, externalID string, args []string) string {
+	if len(args) != 1 {
+		return "Usage: link <code>"
+	}
+
+	var admin model.User
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var token model.Token
+		if err := tx.
+			Where("token = ? AND type = ? AND expires > ?", args[0], config.TokenTypeOpsBotLink, time.Now()).
+			First(&token).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&token).Error; err != nil {
+			return err
+		}
+
+		if err := tx.First(&admin, "id = ?", token.UserID).Error; err != nil {
+			return err
+		}
+
+		identity := &model.OpsBotIdentity{UserID: admin.ID, Platform: platform, ExternalID: externalID}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "platform"}, {Name: "external_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"user_id"}),
+		}).Create(identity).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "Link code is invalid or expired"
+	}
+	if err != nil {
+		s.Log.Errorf("Failed to link ops bot account %s/%s: %v", platform, externalID, err)
+		return "Linking failed, please try again"
+	}
+
+	return fmt.Sprintf("Linked to %s. Send help to see the available commands.", admin.Email)
+}
+
+func (s *opsBotService) help(admin *model.User) string {
+	lines := []string{"Available commands:"}
+	for _, name := range []string{"kpi", "user", "receipt", "maintenance"} {
+		if command := opsCommands[name]; hasRight(admin.Role, command.right) {
+			lines = append(lines, command.usage)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (s *opsBotService) kpiCommand(ctx context.Context, _ *model.User, args []string) (string, error) {
+	day := time.Now()
+	if len(args) > 0 {
+		var err error
+		if day, err = time.ParseInLocation("2006-01-02", args[0], time.Local); err != nil {
+			return "", errOpsUsage
+		}
+	}
+
+	kpis, err := s.GetDailyKPIs(ctx, day)
+	if err != nil {
+		return "", err
+	}
+
+	return kpis.Summary(), nil
+}
+
+func (s *opsBotService) userCommand(ctx context.Context, _ *model.User, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errOpsUsage
+	}
+
+	var user model.User
+	if err := s.DB.WithContext(ctx).Where("LOWER(email) = LOWER(?)", args[0]).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", fiber.NewError(fiber.StatusNotFound, "User not found")
+		}
+		return "", err
+	}
+
+	lines := []string{
+		fmt.Sprintf("%s <%s>", user.Name, user.Email),
+		fmt.Sprintf("ID: %s", user.ID),
+		fmt.Sprintf("Role: %s, email verified: %t", user.Role, user.VerifiedEmail),
+		fmt.Sprintf("Joined: %s", user.CreatedAt.Format("02 Jan 2006")),
+	}
+
+	var subscription model.UserSubscription
+	err := s.DB.WithContext(ctx).
+		Preload("Plan").
+		Where("user_id = ?", user.ID).
+		Order("created_at DESC").
+		First(&subscription).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		lines = append(lines, "Subscription: none")
+	case err != nil:
+		return "", err
+	default:
+		lines = append(lines, fmt.Sprintf("Subscription: %s, payment %s, active %t until %s (order %s)",
+			subscription.Plan.Name, subscription.PaymentStatus, subscription.IsActive,
+			subscription.EndDate.Format("02 Jan 2006"), subscription.TransactionID))
+	}
+
+	var wallet model.Wallet
+	if err := s.DB.WithContext(ctx).Where("user_id = ?", user.ID).Limit(1).Find(&wallet).Error; err != nil {
+		return "", err
+	}
+	lines = append(lines, "Wallet: "+model.IDR(wallet.Balance).String())
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func (s *opsBotService) receiptCommand(ctx context.Context, _ *model.User, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errOpsUsage
+	}
+
+	var detail model.TransactionDetail
+	if err := firstWithArchive(s.DB.WithContext(ctx), model.TransactionDetailsTable, &detail, func(db *gorm.DB) *gorm.DB {
+		return db.
+			Preload("UserSubscription.User").
+			Preload("UserSubscription.Plan").
+			Where("order_id = ? AND transaction_status IN ?", args[0], model.PaidTransactionStatuses).
+			Order("transaction_time")
+	}); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", fiber.NewError(fiber.StatusNotFound, "No paid transaction found for this order")
+		}
+		return "", err
+	}
+
+	subscription := detail.UserSubscription
+	if err := s.EmailService.SendReceiptEmail(
+		subscription.User.Email, subscription.Plan.Name, detail.OrderID, detail.Money(), detail.TransactionTime,
+	); err != nil {
+		return "", fiber.NewError(fiber.StatusBadGateway, "Failed to send the receipt email")
+	}
+
+	return fmt.Sprintf("Receipt for order %s sent to %s", detail.OrderID, subscription.User.Email), nil
+}
+
+func (s *opsBotService) maintenanceCommand(ctx context.Context, admin *model.User, args []string) (string, error) {
+	action := "status"
+	if len(args) > 0 {
+		action = strings.ToLower(args[0])
+	}
+
+	var status *model.MaintenanceStatus
+	var err error
+	switch action {
+	case "status":
+		status, err = s.MaintenanceService.GetStatus(ctx)
+	case "on", "off":
+		status, err = s.MaintenanceService.SetStatus(ctx, admin.ID, action == "on", strings.Join(args[1:], " "))
+	default:
+		return "", errOpsUsage
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if !status.Enabled {
+		return "Maintenance mode is off", nil
+	}
+	if status.Message != "" {
+		return "Maintenance mode is on: " + status.Message, nil
+	}
+	return "Maintenance mode is on", nil
+}
+
+// GetDailyKPIs counts the activity of one local calendar day, active subscriptions are counted as of now
+func (s *opsBotService) GetDailyKPIs(ctx context.Context, day time.Time) (*model.DailyKPIs, error) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 0, 1)
+	db := s.DB.WithContext(ctx)
+
+	kpis := &model.DailyKPIs{Date: start.Format("2006-01-02")}
+
+	if err := db.Model(&model.User{}).
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Count(&kpis.NewUsers).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&model.MealHistory{}).
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Count(&kpis.MealScans).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&model.TransactionDetail{}).
+		Where("transaction_status IN ? AND transaction_time >= ? AND transaction_time < ?", model.PaidTransactionStatuses, start, end).
+		Where("is_sandbox = ?", false).
+		Distinct("order_id").
+		Count(&kpis.PaidOrders).Error; err != nil {
+		return nil, err
+	}
+
+	// Recognition entries are deduplicated per order, so their sum is the cash collected
+	if err := db.Model(&model.RevenueRecognition{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("paid_at >= ? AND paid_at < ?", start, end).
+		Scan(&kpis.Revenue).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&model.TransactionDetail{}).
+		Where("transaction_status IN ? AND transaction_time >= ? AND transaction_time < ?", model.FailedTransactionStatuses, start, end).
+		Where("is_sandbox = ?", false).
+		Count(&kpis.FailedPayments).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&model.UserSubscription{}).
+		Where("is_active = ? AND end_date > ? AND is_sandbox = ?", true, time.Now(), false).
+		Count(&kpis.ActiveSubscriptions).Error; err != nil {
+		return nil, err
+	}
+
+	return kpis, nil
+}
+
+// ReportDailyKPIs posts yesterday's KPIs once a day after OPS_BOT_REPORT_HOUR, it runs hourly on every instance
+func (s *opsBotService) ReportDailyKPIs(ctx context.Context) error {
+	if config.OpsBotReportSlackWebhookURL == "" && (config.OpsBotReportTelegramChatID == "" || s.Telegram == nil) {
+		return nil
+	}
+
+	now := time.Now()
+	if now.Hour() < config.OpsBotReportHour.Get() {
+		return nil
+	}
+
+	claimed, err := claimDailyRun(ctx, s.DB, model.SettingKPIReportedOn, now.Format("2006-01-02"))
+	if err != nil || !claimed {
+		return err
+	}
+
+	kpis, err := s.GetDailyKPIs(ctx, now.AddDate(0, 0, -1))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if config.OpsBotReportSlackWebhookURL != "" {
+		if err := notify.Slack(ctx, config.OpsBotReportSlackWebhookURL, kpis.Summary()); err != nil {
+			errs = append(errs, fmt.Errorf("slack: %w", err))
+		}
+	}
+	if config.OpsBotReportTelegramChatID != "" && s.Telegram != nil {
+		if err := s.Telegram.Send(ctx, config.OpsBotReportTelegramChatID, kpis.Summary()); err != nil {
+			errs = append(errs, fmt.Errorf("telegram: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// hasRight reports whether a built-in or custom role grants a right
+func hasRight(role, right string) bool {
+	return config.HasRights(role, right)
+}
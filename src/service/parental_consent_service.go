@@ -0,0 +1,248 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ParentalConsentService interface {
+	GetStatus(c *fiber.Ctx, userID uuid.UUID) (*model.ParentalConsentStatus, error)
+	// RequestConsent emails a consent link to the guardian of an under-age user. Links sent before stop working.
+	RequestConsent(c *fiber.Ctx, user *model.User, req *validation.RequestParentalConsent) (*model.ParentalConsentRequest, error)
+
+	// GetPrompt returns what the guardian is asked to agree to, the link is the only credential
+	GetPrompt(c *fiber.Ctx, req *validation.Token) (*model.ParentalConsentPrompt, error)
+	// Respond records the answer of the guardian with the consent artifact, granting lifts the restrictions
+	Respond(c *fiber.Ctx, req *validation.RespondParentalConsent) (*model.ParentalConsentRequest, error)
+}
+
+type parentalConsentService struct {
+	Log          *logrus.Logger
+	DB           *gorm.DB
+	Validate     *validator.Validate
+	EmailService EmailService
+}
+
+func NewParentalConsentService(db *gorm.DB, validate *validator.Validate, emailService EmailService) ParentalConsentService {
+	return &parentalConsentService{
+		Log:          utils.Log,
+		DB:           db,
+		Validate:     validate,
+		EmailService: emailService,
+	}
+}
+
+func (s *parentalConsentService) GetStatus(c *fiber.Ctx, userID uuid.UUID) (*model.ParentalConsentStatus, error) {
+	db := s.DB.WithContext(c.UserContext())
+
+	var user model.User
+	if err := db.Select("id", "parental_consent").First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
+		}
+		return nil, err
+	}
+
+	status := &model.ParentalConsentStatus{Status: user.ParentalConsent, ConsentAge: config.ParentalConsentAge}
+
+	var latest model.ParentalConsentRequest
+	result := db.Where("user_id = ?", userID).Order("created_at DESC").Limit(1).Find(&latest)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		status.LatestRequest = &latest
+	}
+
+	return status, nil
+}
+
+func (s *parentalConsentService) RequestConsent(c *fiber.Ctx, user *model.User, req *validation.RequestParentalConsent) (*model.ParentalConsentRequest, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if user.ParentalConsent != model.ParentalConsentPending {
+		return nil, fiber.NewError(fiber.StatusConflict, "Parental consent is not needed for this account")
+	}
+	guardianEmail := strings.ToLower(strings.TrimSpace(req.GuardianEmail))
+	if guardianEmail == strings.ToLower(user.Email) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "The guardian email must not be your own")
+	}
+
+	request := &model.ParentalConsentRequest{
+		UserID:        user.ID,
+		GuardianEmail: guardianEmail,
+		Status:        model.ConsentRequestPending,
+		ExpiresAt:     time.Now().Add(config.ParentalConsentLinkTTL),
+	}
+
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.ParentalConsentRequest{}).
+			Where("user_id = ? AND status = ?", user.ID, model.ConsentRequestPending).
+			Update("status", model.ConsentRequestSuperseded).Error; err != nil {
+			return err
+		}
+		return tx.Create(request).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := parentalConsentToken(request.ID, request.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.EmailService.SendParentalConsentEmail(guardianEmail, user.Name, token, request.ExpiresAt); err != nil {
+		s.Log.Errorf("Failed to send parental consent email of user %s: %v", user.ID, err)
+		return nil, fiber.NewError(fiber.StatusBadGateway, "Failed to send the email to the guardian, try again later")
+	}
+
+	return request, nil
+}
+
+func (s *parentalConsentService) GetPrompt(c *fiber.Ctx, req *validation.Token) (*model.ParentalConsentPrompt, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	request, child, err := s.openRequest(s.DB.WithContext(c.UserContext()), req.Token, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ParentalConsentPrompt{
+		ChildName:     child.Name,
+		GuardianEmail: request.GuardianEmail,
+		Text:          model.ParentalConsentText,
+		TextVersion:   model.ParentalConsentTextVersion,
+		ExpiresAt:     request.ExpiresAt,
+	}, nil
+}
+
+func (s *parentalConsentService) Respond(c *fiber.Ctx, req *validation.RespondParentalConsent) (*model.ParentalConsentRequest, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var request *model.ParentalConsentRequest
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		var child *model.User
+		var err error
+		request, child, err = s.openRequest(tx, req.Token, true)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		artifact := model.ParentalConsentArtifact{
+			RequestID:     request.ID,
+			UserID:        child.ID,
+			ChildName:     child.Name,
+			GuardianName:  strings.TrimSpace(req.GuardianName),
+			GuardianEmail: request.GuardianEmail,
+			Decision:      req.Decision,
+			TextVersion:   model.ParentalConsentTextVersion,
+			Text:          model.ParentalConsentText,
+			IPAddress:     c.IP(),
+			UserAgent:     string(c.Request().Header.UserAgent()),
+			RespondedAt:   now,
+		}
+		if child.BirthDate != nil {
+			artifact.ChildBirthDate = child.BirthDate.Format("2006-01-02")
+		}
+		data, digest, err := artifact.Seal()
+		if err != nil {
+			return err
+		}
+
+		request.Status = req.Decision
+		request.GuardianName = artifact.GuardianName
+		request.RespondedAt = &now
+		request.Artifact = data
+		request.ArtifactSHA256 = digest
+		if err := tx.Model(request).
+			Select("Status", "GuardianName", "RespondedAt", "Artifact", "ArtifactSHA256").
+			Updates(request).Error; err != nil {
+			return err
+		}
+
+		if req.Decision != model.ConsentRequestGranted {
+			return nil
+		}
+		return tx.Model(&model.User{}).
+			Where("id = ?", child.ID).
+			Update("parental_consent", model.ParentalConsentGranted).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// openRequest returns the pending request of a consent link and the user it is for, locking the request
+// when it is about to be answered
+func (s *parentalConsentService) openRequest(db *gorm.DB, token string, lock bool) (*model.ParentalConsentRequest, *model.User, error) {
+	sub, err := utils.VerifyToken(token, config.JWTSecret, config.TokenTypeConsent)
+	if err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, "Invalid or expired consent link")
+	}
+	requestID, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, "Invalid or expired consent link")
+	}
+
+	query := db
+	if lock {
+		query = db.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var request model.ParentalConsentRequest
+	if err := query.First(&request, "id = ?", requestID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, "Invalid or expired consent link")
+		}
+		return nil, nil, err
+	}
+	if request.Status != model.ConsentRequestPending {
+		return nil, nil, fiber.NewError(fiber.StatusConflict, "This consent link was already answered or replaced by a newer one")
+	}
+	if time.Now().After(request.ExpiresAt) {
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, "Invalid or expired consent link")
+	}
+
+	var child model.User
+	if err := db.First(&child, "id = ?", request.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, "Invalid or expired consent link")
+		}
+		return nil, nil, err
+	}
+
+	return &request, &child, nil
+}
+
+// parentalConsentToken signs the link of a consent request, it expires with the request
+func parentalConsentToken(requestID uuid.UUID, expiresAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"sub":  requestID.String(),
+		"iat":  time.Now().Unix(),
+		"exp":  expiresAt.Unix(),
+		"type": config.TokenTypeConsent,
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.JWTSecret))
+}
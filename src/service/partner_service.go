@@ -0,0 +1,824 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// partnerKeyLength is the length of the random part of a partner API key
+const partnerKeyLength = 40
+
+// partnerUsageMaxDays bounds the range of the usage a partner reads at once
+const partnerUsageMaxDays = 366
+
+// enrollmentCodeAlphabet leaves out the letters and digits that read alike, users copy the codes by hand
+const enrollmentCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// enrollmentCodeLength is the length of an enrollment code, 40 bits
+const enrollmentCodeLength = 8
+
+type PartnerService interface {
+	GetKeys(c *fiber.Ctx) ([]model.PartnerKey, error)
+	// CreateKey issues a key, premium scopes need the partner to accept the current partner terms
+	CreateKey(c *fiber.Ctx, adminID uuid.UUID, req *validation.CreatePartnerKey) (*model.CreatedPartnerKey, error)
+	UpdateKey(c *fiber.Ctx, keyID uuid.UUID, req *validation.UpdatePartnerKey) (*model.PartnerKey, error)
+	RevokeKey(c *fiber.Ctx, keyID uuid.UUID) (*model.PartnerKey, error)
+	GetUsage(c *fiber.Ctx, keyID uuid.UUID, query *validation.PartnerKeyUsageQuery) ([]model.PartnerKeyUsage, error)
+
+	GetTiers(c *fiber.Ctx) ([]model.PartnerTier, error)
+	CreateTier(c *fiber.Ctx, req *validation.CreatePartnerTier) (*model.PartnerTier, error)
+	UpdateTier(c *fiber.Ctx, tierKey string, req *validation.UpdatePartnerTier) (*model.PartnerTier, error)
+	// SetTier puts a partner on a tier, its keys get the rate limits of the tier on their next request. An empty
+	// tier takes the partner off its tier.
+	SetTier(c *fiber.Ctx, partner string, req *validation.SetPartnerTier) (*model.PartnerAccount, error)
+	// GetOverage adds up the requests of the keys of every partner in a month and bills the requests over what
+	// the current tier of the partner includes
+	GetOverage(c *fiber.Ctx, query *validation.PartnerOverageQuery) ([]model.PartnerOverage, error)
+
+	// Authenticate returns the key a partner sent, it returns nil for unknown and revoked keys
+	Authenticate(ctx context.Context, key string) (*model.PartnerKey, error)
+	// RecordUsage counts a request of the key in scope on today's usage, throttled when the rate limit refused it
+	// and failed when it was answered with an error
+	RecordUsage(ctx context.Context, keyID uuid.UUID, scope string, throttled, failed bool) error
+	// GetPartnerUsage reports the usage of the keys of the partner of key, per day or month, to the partner
+	GetPartnerUsage(c *fiber.Ctx, key *model.PartnerKey, query *validation.PartnerUsageQuery) (*model.PartnerUsageReport, error)
+
+	// EnrollMember enrolls the user who handed the partner an enrollment code. Unknown emails and wrong, expired
+	// or used codes answer alike, so partners cannot probe which emails have an account.
+	EnrollMember(c *fiber.Ctx, key *model.PartnerKey, req *validation.EnrollPartnerMember) (*model.PartnerMember, error)
+	RemoveMember(c *fiber.Ctx, key *model.PartnerKey, userID uuid.UUID) error
+	// GetEntitlement returns the premium access of a member, users who are not members of the partner are not found
+	GetEntitlement(c *fiber.Ctx, key *model.PartnerKey, userID uuid.UUID) (*model.PartnerEntitlement, error)
+
+	// GetMemberships returns the partners the user is a member of, with their consent to share health data
+	GetMemberships(c *fiber.Ctx, userID uuid.UUID) ([]model.PartnerMembership, error)
+	// CreateEnrollmentCode gives the user a code to hand a partner so it can enroll them, replacing their previous
+	// code for the partner
+	CreateEnrollmentCode(c *fiber.Ctx, userID uuid.UUID, partner string) (*model.PartnerEnrollmentCode, error)
+	// GrantConsent lets a partner the user is a member of read their health data
+	GrantConsent(c *fiber.Ctx, userID uuid.UUID, partner string) (*model.PartnerConsent, error)
+	// RevokeConsent stops a partner from reading the health data of the user
+	RevokeConsent(c *fiber.Ctx, userID uuid.UUID, partner string) (*model.PartnerConsent, error)
+}
+
+type partnerService struct {
+	Log                 *logrus.Logger
+	DB                  *gorm.DB
+	Validate            *validator.Validate
+	SubscriptionService SubscriptionService
+}
+
+func NewPartnerService(db *gorm.DB, validate *validator.Validate, subscriptionService SubscriptionService) PartnerService {
+	return &partnerService{
+		Log:                 utils.Log,
+		DB:                  db,
+		Validate:            validate,
+		SubscriptionService: subscriptionService,
+	}
+}
+
+func (s *partnerService) GetKeys(c *fiber.Ctx) ([]model.PartnerKey, error) {
+	var keys []model.PartnerKey
+	if err := s.DB.WithContext(c.UserContext()).Order("partner, created_at DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (s *partnerService) CreateKey(c *fiber.Ctx, adminID uuid.UUID, req *validation.CreatePartnerKey) (*model.CreatedPartnerKey, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	secret := model.PartnerKeyPrefix + utils.GenerateRandomString(partnerKeyLength)
+	key := model.PartnerKey{
+		Partner:     strings.ToLower(req.Partner),
+		Name:        req.Name,
+		KeyPrefix:   secret[:len(model.PartnerKeyPrefix)+8],
+		KeyHash:     model.HashPartnerKey(secret),
+		Scopes:      uniqueScopes(req.Scopes),
+		RateLimits:  req.RateLimits,
+		CreatedByID: adminID,
+	}
+	if req.TermsVersion != "" {
+		now := time.Now()
+		key.TermsVersion = req.TermsVersion
+		key.TermsAcceptedAt = &now
+	}
+	if err := checkPartnerKey(&key); err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Create(&key).Error; err != nil {
+		return nil, err
+	}
+
+	return &model.CreatedPartnerKey{PartnerKey: key, Key: secret}, nil
+}
+
+func (s *partnerService) UpdateKey(c *fiber.Ctx, keyID uuid.UUID, req *validation.UpdatePartnerKey) (*model.PartnerKey, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	key, err := s.getKey(c, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, fiber.NewError(fiber.StatusConflict, "Partner key is revoked")
+	}
+
+	if req.Scopes != nil {
+		key.Scopes = uniqueScopes(req.Scopes)
+	}
+	if req.RateLimits != nil {
+		key.RateLimits = req.RateLimits
+	}
+	if req.TermsVersion != "" && req.TermsVersion != key.TermsVersion {
+		now := time.Now()
+		key.TermsVersion = req.TermsVersion
+		key.TermsAcceptedAt = &now
+	}
+	if err := checkPartnerKey(key); err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(key).
+		Select("Scopes", "RateLimits", "TermsVersion", "TermsAcceptedAt").
+		Updates(key).Error; err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (s *partnerService) RevokeKey(c *fiber.Ctx, keyID uuid.UUID) (*model.PartnerKey, error) {
+	key, err := s.getKey(c, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return key, nil
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	if err := s.DB.WithContext(c.UserContext()).Model(key).Update("revoked_at", now).Error; err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (s *partnerService) GetUsage(c *fiber.Ctx, keyID uuid.UUID, query *validation.PartnerKeyUsageQuery) ([]model.PartnerKeyUsage, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	from, _ := time.Parse("2006-01-02", query.From)
+	to, _ := time.Parse("2006-01-02", query.To)
+	if to.Before(from) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+
+	if _, err := s.getKey(c, keyID); err != nil {
+		return nil, err
+	}
+
+	var usage []model.PartnerKeyUsage
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("key_id = ? AND day BETWEEN ? AND ?", keyID, query.From, query.To).
+		Order("day, scope").
+		Find(&usage).Error; err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+func (s *partnerService) GetTiers(c *fiber.Ctx) ([]model.PartnerTier, error) {
+	var tiers []model.PartnerTier
+	if err := s.DB.WithContext(c.UserContext()).Order("monthly_requests, key").Find(&tiers).Error; err != nil {
+		return nil, err
+	}
+
+	return tiers, nil
+}
+
+func (s *partnerService) CreateTier(c *fiber.Ctx, req *validation.CreatePartnerTier) (*model.PartnerTier, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+	if err := checkRateLimitScopes(req.RateLimits); err != nil {
+		return nil, err
+	}
+
+	tier := model.PartnerTier{
+		Key:             req.Key,
+		Name:            req.Name,
+		RateLimits:      req.RateLimits,
+		MonthlyRequests: req.MonthlyRequests,
+		OveragePrice:    req.OveragePrice,
+		Currency:        req.Currency,
+	}
+	if tier.Currency == "" {
+		tier.Currency = model.CurrencyIDR
+	}
+
+	result := s.DB.WithContext(c.UserContext()).Clauses(clause.OnConflict{DoNothing: true}).Create(&tier)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fiber.NewError(fiber.StatusConflict, "Partner tier already exists")
+	}
+
+	return &tier, nil
+}
+
+func (s *partnerService) UpdateTier(c *fiber.Ctx, tierKey string, req *validation.UpdatePartnerTier) (*model.PartnerTier, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var tier model.PartnerTier
+	if err := s.DB.WithContext(c.UserContext()).First(&tier, "key = ?", tierKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Partner tier not found")
+		}
+		return nil, err
+	}
+
+	if req.Name != nil {
+		tier.Name = *req.Name
+	}
+	if req.RateLimits != nil {
+		if err := checkRateLimitScopes(req.RateLimits); err != nil {
+			return nil, err
+		}
+		tier.RateLimits = req.RateLimits
+	}
+	if req.MonthlyRequests != nil {
+		tier.MonthlyRequests = *req.MonthlyRequests
+	}
+	if req.OveragePrice != nil {
+		tier.OveragePrice = *req.OveragePrice
+	}
+	if req.Currency != nil {
+		tier.Currency = *req.Currency
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Save(&tier).Error; err != nil {
+		return nil, err
+	}
+
+	return &tier, nil
+}
+
+func (s *partnerService) SetTier(c *fiber.Ctx, partner string, req *validation.SetPartnerTier) (*model.PartnerAccount, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+	account := model.PartnerAccount{Partner: strings.ToLower(partner), Tier: req.Tier}
+	if req.Tier == "" {
+		if err := db.Delete(&model.PartnerAccount{}, "partner = ?", account.Partner).Error; err != nil {
+			return nil, err
+		}
+		return &account, nil
+	}
+
+	var tiers int64
+	if err := db.Model(&model.PartnerTier{}).Where("key = ?", req.Tier).Count(&tiers).Error; err != nil {
+		return nil, err
+	}
+	if tiers == 0 {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Partner tier not found")
+	}
+
+	var keys int64
+	if err := db.Model(&model.PartnerKey{}).Where("partner = ?", account.Partner).Count(&keys).Error; err != nil {
+		return nil, err
+	}
+	if keys == 0 {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Partner has no keys")
+	}
+
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "partner"}},
+		DoUpdates: clause.AssignmentColumns([]string{"tier", "updated_at"}),
+	}).Create(&account).Error; err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+func (s *partnerService) GetOverage(c *fiber.Ctx, query *validation.PartnerOverageQuery) ([]model.PartnerOverage, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	from, _ := time.Parse("2006-01", query.Month)
+	db := s.DB.WithContext(c.UserContext())
+
+	type keyUsage struct {
+		Partner string
+		model.PartnerKeyMonthUsage
+	}
+	usageQuery := db.Table("partner_key_usages").
+		Select("partner_keys.partner, partner_keys.id AS key_id, partner_keys.name, partner_keys.key_prefix, "+
+			"SUM(partner_key_usages.requests) AS requests, SUM(partner_key_usages.throttled) AS throttled").
+		Joins("JOIN partner_keys ON partner_keys.id = partner_key_usages.key_id").
+		Where("partner_key_usages.day >= ? AND partner_key_usages.day < ?", from, from.AddDate(0, 1, 0)).
+		Group("partner_keys.partner, partner_keys.id, partner_keys.name, partner_keys.key_prefix").
+		Order("partner_keys.partner, requests DESC")
+	if query.Partner != "" {
+		usageQuery = usageQuery.Where("partner_keys.partner = ?", strings.ToLower(query.Partner))
+	}
+
+	var usage []keyUsage
+	if err := usageQuery.Scan(&usage).Error; err != nil {
+		return nil, err
+	}
+
+	var accounts []model.PartnerAccount
+	if err := db.Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	var tiers []model.PartnerTier
+	if err := db.Find(&tiers).Error; err != nil {
+		return nil, err
+	}
+	tierByKey := make(map[string]*model.PartnerTier, len(tiers))
+	for i := range tiers {
+		tierByKey[tiers[i].Key] = &tiers[i]
+	}
+	tierOf := make(map[string]*model.PartnerTier, len(accounts))
+	for _, account := range accounts {
+		tierOf[account.Partner] = tierByKey[account.Tier]
+	}
+
+	report := []model.PartnerOverage{}
+	for start := 0; start < len(usage); {
+		end := start
+		var keys []model.PartnerKeyMonthUsage
+		for ; end < len(usage) && usage[end].Partner == usage[start].Partner; end++ {
+			keys = append(keys, usage[end].PartnerKeyMonthUsage)
+		}
+		report = append(report, model.NewPartnerOverage(usage[start].Partner, query.Month, tierOf[usage[start].Partner], keys))
+		start = end
+	}
+
+	return report, nil
+}
+
+func (s *partnerService) Authenticate(ctx context.Context, secret string) (*model.PartnerKey, error) {
+	if !strings.HasPrefix(secret, model.PartnerKeyPrefix) {
+		return nil, nil
+	}
+
+	var key model.PartnerKey
+	result := s.DB.WithContext(ctx).
+		Where("key_hash = ? AND revoked_at IS NULL", model.HashPartnerKey(secret)).
+		Limit(1).
+		Find(&key)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+
+	tier, err := partnerTier(s.DB.WithContext(ctx), key.Partner)
+	if err != nil {
+		return nil, err
+	}
+	if tier != nil {
+		key.TierLimits = tier.RateLimits
+	}
+
+	return &key, nil
+}
+
+func (s *partnerService) RecordUsage(ctx context.Context, keyID uuid.UUID, scope string, throttled, failed bool) error {
+	now := time.Now()
+	usage := model.PartnerKeyUsage{
+		KeyID:    keyID,
+		Scope:    scope,
+		Day:      time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()),
+		Requests: 1,
+	}
+	if throttled {
+		usage.Throttled = 1
+	}
+	if failed {
+		usage.Errors = 1
+	}
+
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "key_id"}, {Name: "scope"}, {Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"requests":  gorm.Expr("partner_key_usages.requests + 1"),
+				"throttled": gorm.Expr("partner_key_usages.throttled + ?", usage.Throttled),
+				"errors":    gorm.Expr("partner_key_usages.errors + ?", usage.Errors),
+			}),
+		}).Create(&usage).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.PartnerKey{}).Where("id = ?", keyID).Update("last_used_at", now).Error
+	})
+}
+
+func (s *partnerService) GetPartnerUsage(c *fiber.Ctx, key *model.PartnerKey, query *validation.PartnerUsageQuery) (*model.PartnerUsageReport, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	interval := query.Interval
+	if interval == "" {
+		interval = model.PartnerUsageDaily
+	}
+
+	to := today
+	if query.To != "" {
+		to, _ = time.Parse("2006-01-02", query.To)
+	}
+	from := to.AddDate(0, 0, -29)
+	if interval == model.PartnerUsageMonthly {
+		from = model.MonthStart(to).AddDate(0, -11, 0)
+	}
+	if query.From != "" {
+		from, _ = time.Parse("2006-01-02", query.From)
+	}
+	if interval == model.PartnerUsageMonthly {
+		from = model.MonthStart(from)
+	}
+
+	if to.Before(from) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+	if from.AddDate(0, 0, partnerUsageMaxDays).Before(to) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Usage range is limited to 366 days")
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+	tier, err := partnerTier(db, key.Partner)
+	if err != nil {
+		return nil, err
+	}
+
+	// Revoked keys of the partner count as well, their requests were billed
+	usage := db.Table("partner_key_usages").
+		Joins("JOIN partner_keys ON partner_keys.id = partner_key_usages.key_id").
+		Where("partner_keys.partner = ?", key.Partner)
+
+	var days []model.PartnerUsageDay
+	if err := usage.Session(&gorm.Session{}).
+		Select("partner_key_usages.day, SUM(partner_key_usages.requests) AS requests, "+
+			"SUM(partner_key_usages.throttled) AS throttled, SUM(partner_key_usages.errors) AS errors").
+		Where("partner_key_usages.day >= ? AND partner_key_usages.day <= ?", model.MonthStart(from), to).
+		Group("partner_key_usages.day").
+		Order("partner_key_usages.day").
+		Scan(&days).Error; err != nil {
+		return nil, err
+	}
+
+	var used int64
+	if err := usage.Session(&gorm.Session{}).
+		Select("COALESCE(SUM(partner_key_usages.requests - partner_key_usages.throttled), 0)").
+		Where("partner_key_usages.day >= ?", model.MonthStart(today)).
+		Scan(&used).Error; err != nil {
+		return nil, err
+	}
+
+	report := model.NewPartnerUsageReport(key, tier, interval, from, to, days, used, now)
+	return &report, nil
+}
+
+func (s *partnerService) EnrollMember(c *fiber.Ctx, key *model.PartnerKey, req *validation.EnrollPartnerMember) (*model.PartnerMember, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	invalid := fiber.NewError(fiber.StatusForbidden, "The email or the enrollment code is invalid")
+
+	var user model.User
+	if err := s.DB.WithContext(c.UserContext()).
+		Select("id").
+		First(&user, "email = ?", strings.ToLower(strings.TrimSpace(req.Email))).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, invalid
+		}
+		return nil, err
+	}
+
+	member := &model.PartnerMember{Partner: key.Partner, UserID: user.ID, KeyID: key.ID}
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		var code model.PartnerEnrollmentCode
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("partner = ? AND user_id = ? AND expires_at > ?", key.Partner, user.ID, time.Now()).
+			Limit(1).
+			Find(&code)
+		if result.Error != nil {
+			return result.Error
+		}
+		given := hashEnrollmentCode(key.Partner, strings.ToUpper(strings.TrimSpace(req.Code)))
+		if result.RowsAffected == 0 || !hmac.Equal([]byte(given), []byte(code.CodeHash)) {
+			return invalid
+		}
+
+		// The code is used up, the partner needs a new one to enroll the user again
+		if err := tx.Where("partner = ? AND user_id = ?", key.Partner, user.ID).Delete(&model.PartnerEnrollmentCode{}).Error; err != nil {
+			return err
+		}
+
+		created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(member)
+		if created.Error != nil {
+			return created.Error
+		}
+		if created.RowsAffected == 0 {
+			return fiber.NewError(fiber.StatusConflict, "User is already a member of the partner")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+func (s *partnerService) RemoveMember(c *fiber.Ctx, key *model.PartnerKey, userID uuid.UUID) error {
+	return s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("partner = ? AND user_id = ?", key.Partner, userID).Delete(&model.PartnerMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fiber.NewError(fiber.StatusNotFound, "Member not found")
+		}
+
+		// Consent is given to a membership, enrolling the user again needs it again
+		return tx.Where("partner = ? AND user_id = ?", key.Partner, userID).Delete(&model.PartnerConsent{}).Error
+	})
+}
+
+func (s *partnerService) GetEntitlement(c *fiber.Ctx, key *model.PartnerKey, userID uuid.UUID) (*model.PartnerEntitlement, error) {
+	var members int64
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(&model.PartnerMember{}).
+		Where("partner = ? AND user_id = ?", key.Partner, userID).
+		Count(&members).Error; err != nil {
+		return nil, err
+	}
+	if members == 0 {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Member not found")
+	}
+
+	entitlement := &model.PartnerEntitlement{UserID: userID}
+	subscription, err := s.SubscriptionService.GetUserActiveSubscription(c, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entitlement, nil
+		}
+		return nil, err
+	}
+
+	entitlement.Premium = true
+	entitlement.PlanName = subscription.Plan.Name
+	entitlement.Features = subscription.Plan.Features
+	entitlement.EndsAt = &subscription.EndDate
+	return entitlement, nil
+}
+
+func (s *partnerService) GetMemberships(c *fiber.Ctx, userID uuid.UUID) ([]model.PartnerMembership, error) {
+	var members []model.PartnerMember
+	if err := s.DB.WithContext(c.UserContext()).Where("user_id = ?", userID).Order("created_at").Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	var consents []model.PartnerConsent
+	if err := s.DB.WithContext(c.UserContext()).Where("user_id = ?", userID).Find(&consents).Error; err != nil {
+		return nil, err
+	}
+	byPartner := make(map[string]*model.PartnerConsent, len(consents))
+	for i := range consents {
+		byPartner[consents[i].Partner] = &consents[i]
+	}
+
+	memberships := make([]model.PartnerMembership, len(members))
+	for i, member := range members {
+		memberships[i] = model.PartnerMembership{Partner: member.Partner, EnrolledAt: member.CreatedAt, Consent: byPartner[member.Partner]}
+	}
+	return memberships, nil
+}
+
+func (s *partnerService) CreateEnrollmentCode(c *fiber.Ctx, userID uuid.UUID, partner string) (*model.PartnerEnrollmentCode, error) {
+	var keys int64
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(&model.PartnerKey{}).
+		Where("partner = ? AND revoked_at IS NULL", partner).
+		Count(&keys).Error; err != nil {
+		return nil, err
+	}
+	if keys == 0 {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Partner not found")
+	}
+
+	code, err := newEnrollmentCode()
+	if err != nil {
+		return nil, err
+	}
+	enrollment := &model.PartnerEnrollmentCode{
+		Partner:   partner,
+		UserID:    userID,
+		CodeHash:  hashEnrollmentCode(partner, code),
+		ExpiresAt: time.Now().Add(config.PartnerEnrollmentCodeTTL),
+	}
+	if err := s.DB.WithContext(c.UserContext()).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "partner"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"code_hash", "expires_at"}),
+		}).
+		Create(enrollment).Error; err != nil {
+		return nil, err
+	}
+
+	enrollment.Code = code
+	return enrollment, nil
+}
+
+func (s *partnerService) GrantConsent(c *fiber.Ctx, userID uuid.UUID, partner string) (*model.PartnerConsent, error) {
+	if err := s.requireMembership(c, userID, partner); err != nil {
+		return nil, err
+	}
+
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	consent := &model.PartnerConsent{
+		Partner:   partner,
+		UserID:    userID,
+		GrantedAt: time.Now(),
+		IPAddress: c.IP(),
+		UserAgent: userAgent,
+	}
+	if err := s.DB.WithContext(c.UserContext()).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "partner"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"granted_at", "revoked_at", "ip_address", "user_agent"}),
+		}).
+		Create(consent).Error; err != nil {
+		return nil, err
+	}
+
+	return consent, nil
+}
+
+func (s *partnerService) RevokeConsent(c *fiber.Ctx, userID uuid.UUID, partner string) (*model.PartnerConsent, error) {
+	var consent model.PartnerConsent
+	if err := s.DB.WithContext(c.UserContext()).First(&consent, "partner = ? AND user_id = ?", partner, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Consent not found")
+		}
+		return nil, err
+	}
+	if !consent.Active() {
+		return &consent, nil
+	}
+
+	now := time.Now()
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(&model.PartnerConsent{}).
+		Where("partner = ? AND user_id = ?", partner, userID).
+		Update("revoked_at", now).Error; err != nil {
+		return nil, err
+	}
+	consent.RevokedAt = &now
+
+	return &consent, nil
+}
+
+// newEnrollmentCode is a random code of enrollmentCodeLength characters of enrollmentCodeAlphabet
+func newEnrollmentCode() (string, error) {
+	code := make([]byte, enrollmentCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(enrollmentCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = enrollmentCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// hashEnrollmentCode is what is stored of an enrollment code, keyed as the phone codes are
+func hashEnrollmentCode(partner, code string) string {
+	mac := hmac.New(sha256.New, []byte(config.JWTSecret))
+	mac.Write([]byte(partner + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *partnerService) requireMembership(c *fiber.Ctx, userID uuid.UUID, partner string) error {
+	var members int64
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(&model.PartnerMember{}).
+		Where("partner = ? AND user_id = ?", partner, userID).
+		Count(&members).Error; err != nil {
+		return err
+	}
+	if members == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "You are not a member of this partner")
+	}
+	return nil
+}
+
+func (s *partnerService) getKey(c *fiber.Ctx, keyID uuid.UUID) (*model.PartnerKey, error) {
+	var key model.PartnerKey
+	if err := s.DB.WithContext(c.UserContext()).First(&key, "id = ?", keyID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Partner key not found")
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// checkRateLimitScopes checks rate limits are set for partner scopes
+// partnerTier is the commercial tier partner is on, nil without one
+func partnerTier(db *gorm.DB, partner string) (*model.PartnerTier, error) {
+	var tier model.PartnerTier
+	result := db.
+		Joins("JOIN partner_accounts ON partner_accounts.tier = partner_tiers.key").
+		Where("partner_accounts.partner = ?", partner).
+		Limit(1).
+		Find(&tier)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	return &tier, nil
+}
+
+func checkRateLimitScopes(rateLimits map[string]int) error {
+	for scope := range rateLimits {
+		if _, ok := model.LookupPartnerScope(scope); !ok {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Rate limit set for unknown scope %s", scope))
+		}
+	}
+	return nil
+}
+
+// checkPartnerKey checks the rate limits are of granted scopes and the terms cover the premium ones
+func checkPartnerKey(key *model.PartnerKey) error {
+	for scope := range key.RateLimits {
+		if !key.HasScope(scope) {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Rate limit set for %s, which is not granted", scope))
+		}
+	}
+	for _, scope := range key.Scopes {
+		definition, _ := model.LookupPartnerScope(scope)
+		if definition.Premium && !key.AcceptedTerms(config.PartnerTermsVersion) {
+			return fiber.NewError(fiber.StatusBadRequest,
+				fmt.Sprintf("%s needs the partner to accept the partner terms %s", scope, config.PartnerTermsVersion))
+		}
+	}
+	return nil
+}
+
+func uniqueScopes(scopes []string) []string {
+	unique := make([]string, 0, len(scopes))
+	seen := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"app/src/webauthn"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PasskeyService interface {
+	// RegistrationOptions issues a challenge for the user to register a passkey, with the options of
+	// navigator.credentials.create
+	RegistrationOptions(c *fiber.Ctx, user *model.User) (uuid.UUID, *webauthn.CreationOptions, error)
+	Register(c *fiber.Ctx, user *model.User, req *validation.RegisterPasskey) (*model.Passkey, error)
+	// LoginOptions issues a challenge to sign in with any passkey, with the options of navigator.credentials.get
+	LoginOptions(c *fiber.Ctx) (uuid.UUID, *webauthn.RequestOptions, error)
+	// Login signs in the user of the passkey that answered the challenge
+	Login(c *fiber.Ctx, req *validation.PasskeyLogin) (*model.User, error)
+	GetPasskeys(ctx context.Context, userID uuid.UUID) ([]model.Passkey, error)
+	DeletePasskey(c *fiber.Ctx, userID, passkeyID uuid.UUID) error
+	// ResetPasskeys deletes every passkey of the user, who signs in with their password until they register new ones
+	ResetPasskeys(c *fiber.Ctx, userID uuid.UUID) (int64, error)
+	// PurgeChallenges deletes the challenges that were never answered
+	PurgeChallenges(ctx context.Context) error
+}
+
+type passkeyService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+	RP       *webauthn.RelyingParty
+}
+
+func NewPasskeyService(db *gorm.DB, validate *validator.Validate) PasskeyService {
+	origins := []string{}
+	for _, origin := range strings.Split(config.WebAuthnOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	return &passkeyService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+		RP: &webauthn.RelyingParty{
+			ID:      config.WebAuthnRPID,
+			Name:    config.WebAuthnRPName,
+			Origins: origins,
+			Timeout: config.WebAuthnTimeout,
+		},
+	}
+}
+
+func (s *passkeyService) RegistrationOptions(c *fiber.Ctx, user *model.User) (uuid.UUID, *webauthn.CreationOptions, error) {
+	passkeys, err := s.GetPasskeys(c.UserContext(), user.ID)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	if len(passkeys) >= config.PasskeyMaxPerUser {
+		return uuid.Nil, nil, fiber.NewError(fiber.StatusConflict,
+			fmt.Sprintf("You have %d passkeys, delete one before adding another", len(passkeys)))
+	}
+
+	// The authenticator refuses to make a second passkey for this site on a device that has one
+	exclude := make([]webauthn.CredentialDescriptor, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		exclude = append(exclude, webauthn.CredentialDescriptor{
+			Type: "public-key", ID: passkey.CredentialID, Transports: passkey.Transports,
+		})
+	}
+
+	challengeID, challenge, err := s.issueChallenge(c.UserContext(), &user.ID, model.PasskeyRegister)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	options := s.RP.CreationOptions(challenge, webauthn.UserEntity{
+		ID:          webauthn.EncodeBase64URL(user.ID[:]),
+		Name:        user.Email,
+		DisplayName: user.Name,
+	}, exclude)
+	return challengeID, options, nil
+}
+
+func (s *passkeyService) Register(c *fiber.Ctx, user *model.User, req *validation.RegisterPasskey) (*model.Passkey, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.consumeChallenge(c.UserContext(), req.ChallengeID, model.PasskeyRegister, &user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	invalid := fiber.NewError(fiber.StatusBadRequest, "The passkey could not be verified, try again")
+	credentialID, err := webauthn.DecodeBase64URL(req.Credential.ID)
+	if err != nil {
+		return nil, invalid
+	}
+	clientDataJSON, err := webauthn.DecodeBase64URL(req.Credential.Response.ClientDataJSON)
+	if err != nil {
+		return nil, invalid
+	}
+	attestationObject, err := webauthn.DecodeBase64URL(req.Credential.Response.AttestationObject)
+	if err != nil {
+		return nil, invalid
+	}
+
+	credential, err := s.RP.VerifyRegistration(challenge, clientDataJSON, attestationObject)
+	if err != nil {
+		s.Log.Warnf("Refused a passkey of user %s: %v", user.ID, err)
+		return nil, invalid
+	}
+	if !bytes.Equal(credential.ID, credentialID) {
+		return nil, invalid
+	}
+
+	var count int64
+	if err := s.DB.WithContext(c.UserContext()).Model(&model.Passkey{}).
+		Where("user_id = ?", user.ID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= int64(config.PasskeyMaxPerUser) {
+		return nil, fiber.NewError(fiber.StatusConflict,
+			fmt.Sprintf("You have %d passkeys, delete one before adding another", count))
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = model.DefaultPasskeyName
+	}
+	aaguid, _ := uuid.FromBytes(credential.AAGUID)
+	passkey := &model.Passkey{
+		UserID:         user.ID,
+		CredentialID:   webauthn.EncodeBase64URL(credential.ID),
+		PublicKey:      credential.PublicKey,
+		Algorithm:      credential.Algorithm,
+		SignCount:      int64(credential.SignCount),
+		AAGUID:         aaguid.String(),
+		Transports:     req.Credential.Response.Transports,
+		BackupEligible: credential.BackupEligible,
+		BackedUp:       credential.BackedUp,
+		Name:           name,
+	}
+	if err := s.DB.WithContext(c.UserContext()).Create(passkey).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, fiber.NewError(fiber.StatusConflict, "This passkey is already registered")
+		}
+		return nil, err
+	}
+
+	return passkey, nil
+}
+
+func (s *passkeyService) LoginOptions(c *fiber.Ctx) (uuid.UUID, *webauthn.RequestOptions, error) {
+	challengeID, challenge, err := s.issueChallenge(c.UserContext(), nil, model.PasskeyLogin)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	return challengeID, s.RP.RequestOptions(challenge), nil
+}
+
+func (s *passkeyService) Login(c *fiber.Ctx, req *validation.PasskeyLogin) (*model.User, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.consumeChallenge(c.UserContext(), req.ChallengeID, model.PasskeyLogin, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	invalid := fiber.NewError(fiber.StatusUnauthorized, "Invalid passkey")
+	credentialID, err := webauthn.DecodeBase64URL(req.Credential.ID)
+	if err != nil {
+		return nil, invalid
+	}
+	response := req.Credential.Response
+	clientDataJSON, err := webauthn.DecodeBase64URL(response.ClientDataJSON)
+	if err != nil {
+		return nil, invalid
+	}
+	authenticatorData, err := webauthn.DecodeBase64URL(response.AuthenticatorData)
+	if err != nil {
+		return nil, invalid
+	}
+	signature, err := webauthn.DecodeBase64URL(response.Signature)
+	if err != nil {
+		return nil, invalid
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+
+	passkey := new(model.Passkey)
+	if err := db.First(passkey, "credential_id = ?", webauthn.EncodeBase64URL(credentialID)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, invalid
+		}
+		return nil, err
+	}
+	// Discoverable passkeys tell whose they are, it must be the user they were registered for
+	if response.UserHandle != "" {
+		handle, err := webauthn.DecodeBase64URL(response.UserHandle)
+		if err != nil || !bytes.Equal(handle, passkey.UserID[:]) {
+			return nil, invalid
+		}
+	}
+
+	assertion, err := s.RP.VerifyAssertion(challenge, clientDataJSON, authenticatorData, signature, &webauthn.Credential{
+		PublicKey: passkey.PublicKey,
+		SignCount: uint32(passkey.SignCount),
+	})
+	if err != nil {
+		if errors.Is(err, webauthn.ErrSignCountRegressed) {
+			s.Log.Warnf("Passkey %s of user %s may be cloned, its sign count went back", passkey.ID, passkey.UserID)
+		}
+		return nil, invalid
+	}
+
+	now := time.Now()
+	if err := db.Model(passkey).Updates(map[string]any{
+		"sign_count":   int64(assertion.SignCount),
+		"backed_up":    assertion.BackedUp,
+		"last_used_at": now,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	user := new(model.User)
+	if err := db.First(user, "id = ?", passkey.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, invalid
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *passkeyService) GetPasskeys(ctx context.Context, userID uuid.UUID) ([]model.Passkey, error) {
+	passkeys := []model.Passkey{}
+	if err := s.DB.WithContext(ctx).Where("user_id = ?", userID).
+		Order("created_at DESC").Find(&passkeys).Error; err != nil {
+		return nil, err
+	}
+	return passkeys, nil
+}
+
+func (s *passkeyService) DeletePasskey(c *fiber.Ctx, userID, passkeyID uuid.UUID) error {
+	result := s.DB.WithContext(c.UserContext()).
+		Where("id = ? AND user_id = ?", passkeyID, userID).Delete(&model.Passkey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "Passkey not found")
+	}
+	return nil
+}
+
+func (s *passkeyService) ResetPasskeys(c *fiber.Ctx, userID uuid.UUID) (int64, error) {
+	result := s.DB.WithContext(c.UserContext()).Where("user_id = ?", userID).Delete(&model.Passkey{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected > 0 {
+		s.Log.Infof("Deleted the %d passkeys of user %s", result.RowsAffected, userID)
+	}
+	return result.RowsAffected, nil
+}
+
+func (s *passkeyService) PurgeChallenges(ctx context.Context) error {
+	result := s.DB.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&model.PasskeyChallenge{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		s.Log.Infof("Purged %d expired passkey challenges", result.RowsAffected)
+	}
+	return nil
+}
+
+func (s *passkeyService) issueChallenge(ctx context.Context, userID *uuid.UUID, purpose string) (uuid.UUID, []byte, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	record := &model.PasskeyChallenge{
+		UserID:    userID,
+		Purpose:   purpose,
+		Challenge: webauthn.EncodeBase64URL(challenge),
+		ExpiresAt: time.Now().Add(config.WebAuthnTimeout),
+	}
+	if err := s.DB.WithContext(ctx).Create(record).Error; err != nil {
+		return uuid.Nil, nil, err
+	}
+	return record.ID, challenge, nil
+}
+
+// consumeChallenge deletes the challenge so it is answered once, and returns it
+func (s *passkeyService) consumeChallenge(ctx context.Context, id, purpose string, userID *uuid.UUID) ([]byte, error) {
+	expired := fiber.NewError(fiber.StatusBadRequest, "The passkey challenge expired, try again")
+
+	db := s.DB.WithContext(ctx).Where("id = ? AND purpose = ? AND expires_at > ?", id, purpose, time.Now())
+	if userID != nil {
+		db = db.Where("user_id = ?", *userID)
+	}
+
+	record := new(model.PasskeyChallenge)
+	if err := db.First(record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, expired
+		}
+		return nil, err
+	}
+
+	// Two answers to the same challenge race for it, only the one that deletes it goes on
+	result := s.DB.WithContext(ctx).Where("id = ?", record.ID).Delete(&model.PasskeyChallenge{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, expired
+	}
+
+	return webauthn.DecodeBase64URL(record.Challenge)
+}
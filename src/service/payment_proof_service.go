@@ -0,0 +1,247 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PaymentProofService interface {
+	UploadPaymentProof(c *fiber.Ctx, userID, subscriptionID uuid.UUID, req *validation.UploadPaymentProof, file *multipart.FileHeader) (*model.PaymentProof, error)
+	GetPaymentProofs(c *fiber.Ctx, query *validation.PaymentProofQuery) ([]model.PaymentProof, int64, error)
+	ApprovePaymentProof(c *fiber.Ctx, adminID, proofID uuid.UUID) (*model.PaymentProof, error)
+	RejectPaymentProof(c *fiber.Ctx, adminID, proofID uuid.UUID, req *validation.RejectPaymentProof) (*model.PaymentProof, error)
+}
+
+type paymentProofService struct {
+	Log                 *logrus.Logger
+	DB                  *gorm.DB
+	Validate            *validator.Validate
+	SubscriptionService SubscriptionService
+	EmailService        EmailService
+	DeepLinks           DeepLinkService
+}
+
+func NewPaymentProofService(
+	db *gorm.DB, validate *validator.Validate, subscriptionService SubscriptionService, emailService EmailService,
+	deepLinks DeepLinkService,
+) PaymentProofService {
+	return &paymentProofService{
+		Log:                 utils.Log,
+		DB:                  db,
+		Validate:            validate,
+		SubscriptionService: subscriptionService,
+		EmailService:        emailService,
+		DeepLinks:           deepLinks,
+	}
+}
+
+func (s *paymentProofService) UploadPaymentProof(
+	c *fiber.Ctx, userID, subscriptionID uuid.UUID, req *validation.UploadPaymentProof, file *multipart.FileHeader,
+) (*model.PaymentProof, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var subscription model.UserSubscription
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("id = ? AND user_id = ?", subscriptionID, userID).
+		First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription not found")
+		}
+		return nil, err
+	}
+
+	if subscription.PaymentMethod != "bank_transfer" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Proof of payment is only accepted for bank transfer checkouts")
+	}
+
+	if subscription.PaymentStatus != "pending" {
+		return nil, fiber.NewError(fiber.StatusConflict, "Subscription is no longer awaiting payment")
+	}
+
+	var pendingCount int64
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(&model.PaymentProof{}).
+		Where("user_subscription_id = ? AND status = ?", subscriptionID, model.PaymentProofPending).
+		Count(&pendingCount).Error; err != nil {
+		return nil, err
+	}
+
+	if pendingCount > 0 {
+		return nil, fiber.NewError(fiber.StatusConflict, "A proof of payment is already awaiting verification")
+	}
+
+	imageURL, err := utils.SaveUploadedImage(c, file, "payment-proofs")
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &model.PaymentProof{
+		UserSubscriptionID: subscription.ID,
+		UserID:             userID,
+		ImageURL:           imageURL,
+		BankName:           req.BankName,
+		AccountName:        req.AccountName,
+		ReferenceNumber:    req.ReferenceNumber,
+		Amount:             req.Amount,
+		Status:             model.PaymentProofPending,
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Create(proof).Error; err != nil {
+		s.Log.Errorf("Failed to save payment proof: %+v", err)
+		return nil, err
+	}
+
+	return proof, nil
+}
+
+func (s *paymentProofService) GetPaymentProofs(c *fiber.Ctx, query *validation.PaymentProofQuery) ([]model.PaymentProof, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	var proofs []model.PaymentProof
+	var totalResults int64
+
+	db := s.DB.WithContext(c.UserContext()).Model(&model.PaymentProof{})
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+
+	if err := db.Count(&totalResults).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.
+		Preload("User").
+		Preload("UserSubscription.Plan").
+		Order("created_at ASC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&proofs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return proofs, totalResults, nil
+}
+
+func (s *paymentProofService) ApprovePaymentProof(c *fiber.Ctx, adminID, proofID uuid.UUID) (*model.PaymentProof, error) {
+	proof, err := s.getPendingProof(c, proofID)
+	if err != nil {
+		return nil, err
+	}
+
+	bankName := proof.BankName
+	notes := fmt.Sprintf("Transfer from %s verified by admin", proof.AccountName)
+	detail := &model.TransactionDetail{
+		TransactionID:   proof.ID.String(),
+		StatusMessage:   "Proof of payment approved",
+		PaymentType:     "manual_bank_transfer",
+		GrossAmount:     model.IDR(proof.Amount).Decimal(),
+		Currency:        model.CurrencyIDR,
+		TransactionTime: proof.CreatedAt,
+		Bank:            &bankName,
+		ProofImageURL:   &proof.ImageURL,
+		RecordedByID:    &adminID,
+		Notes:           &notes,
+	}
+	if proof.ReferenceNumber != "" {
+		detail.ReferenceNumber = &proof.ReferenceNumber
+	}
+
+	subscription, err := s.SubscriptionService.ConfirmManualPayment(c, proof.UserSubscriptionID, detail)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.markReviewed(c, proof, adminID, model.PaymentProofApproved, nil); err != nil {
+		return nil, err
+	}
+
+	if proof.User != nil {
+		if errEmail := s.EmailService.SendPaymentApprovedEmail(proof.User.Email, subscription.Plan.Name, subscription.EndDate); errEmail != nil {
+			s.Log.Warnf("Failed to send payment approved email to %s: %v", proof.User.Email, errEmail)
+		}
+	}
+
+	return proof, nil
+}
+
+func (s *paymentProofService) RejectPaymentProof(
+	c *fiber.Ctx, adminID, proofID uuid.UUID, req *validation.RejectPaymentProof,
+) (*model.PaymentProof, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	proof, err := s.getPendingProof(c, proofID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.markReviewed(c, proof, adminID, model.PaymentProofRejected, &req.Reason); err != nil {
+		return nil, err
+	}
+
+	if proof.User != nil {
+		retryLink := model.DeepLink{Kind: model.DeepLinkRenewSubscription, UserID: proof.UserID, Source: model.TemplatePaymentRejected}
+		if proof.UserSubscription != nil {
+			retryLink.Target = proof.UserSubscription.PlanID.String()
+		}
+		if errEmail := s.EmailService.SendPaymentRejectedEmail(proof.User.Email, req.Reason, buildDeepLinkURL(s.DeepLinks, retryLink)); errEmail != nil {
+			s.Log.Warnf("Failed to send payment rejected email to %s: %v", proof.User.Email, errEmail)
+		}
+	}
+
+	return proof, nil
+}
+
+func (s *paymentProofService) getPendingProof(c *fiber.Ctx, proofID uuid.UUID) (*model.PaymentProof, error) {
+	proof := new(model.PaymentProof)
+
+	if err := s.DB.WithContext(c.UserContext()).
+		Preload("User").
+		Preload("UserSubscription").
+		First(proof, "id = ?", proofID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Payment proof not found")
+		}
+		return nil, err
+	}
+
+	if proof.Status != model.PaymentProofPending {
+		return nil, fiber.NewError(fiber.StatusConflict, "Payment proof has already been reviewed")
+	}
+
+	return proof, nil
+}
+
+func (s *paymentProofService) markReviewed(c *fiber.Ctx, proof *model.PaymentProof, adminID uuid.UUID, status string, reason *string) error {
+	now := time.Now()
+	proof.Status = status
+	proof.ReviewedByID = &adminID
+	proof.ReviewedAt = &now
+	proof.RejectionReason = reason
+
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(proof).
+		Select("Status", "ReviewedByID", "ReviewedAt", "RejectionReason").
+		Updates(proof).Error; err != nil {
+		s.Log.Errorf("Failed to update payment proof %s: %+v", proof.ID, err)
+		return err
+	}
+
+	return nil
+}
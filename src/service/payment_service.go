@@ -3,6 +3,8 @@ package service
 import (
 	"app/src/config"
 	midtransutils "app/src/midtrans"
+	"app/src/requestid"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -19,6 +21,7 @@ type MidtransPaymentService struct {
 	CoreAPIClient coreapi.Client
 	Log           *logrus.Logger
 	IsProduction  bool
+	ServerKey     string
 }
 
 type PaymentToken struct {
@@ -26,13 +29,34 @@ type PaymentToken struct {
 	RedirectURL string `json:"redirect_url"`
 }
 
+// CardCharge is the outcome of charging a saved card, TransactionStatus is that of the gateway (capture, deny, ...)
+type CardCharge struct {
+	TransactionID     string
+	TransactionStatus string
+	StatusMessage     string
+}
+
 func NewMidtransPaymentService() *MidtransPaymentService {
+	return newMidtransPaymentService(config.MidtransServerKey, config.MidtransStatus == "PRODUCTION")
+}
+
+// NewMidtransSandboxPaymentService returns the gateway of sandbox users. Outside production it is the regular
+// gateway, in production it needs MIDTRANS_SANDBOX_SERVER_KEY and is nil without it, so a sandbox checkout
+// can never reach the live gateway.
+func NewMidtransSandboxPaymentService() PaymentGateway {
+	if config.MidtransStatus != "PRODUCTION" {
+		return NewMidtransPaymentService()
+	}
+	if config.MidtransSandboxServerKey == "" {
+		return nil
+	}
+	return newMidtransPaymentService(config.MidtransSandboxServerKey, false)
+}
+
+func newMidtransPaymentService(serverKey string, isProduction bool) *MidtransPaymentService {
 	var snapClient snap.Client
 	var coreAPIClient coreapi.Client
 
-	serverKey := config.MidtransServerKey
-	isProduction := config.MidtransStatus == "PRODUCTION"
-
 	// Set environment
 	env := midtrans.Sandbox
 	if isProduction {
@@ -48,10 +72,11 @@ func NewMidtransPaymentService() *MidtransPaymentService {
 		CoreAPIClient: coreAPIClient,
 		Log:           logrus.New(),
 		IsProduction:  isProduction,
+		ServerKey:     serverKey,
 	}
 }
 
-func (s *MidtransPaymentService) CreateTransaction(orderID string, amount int, userDetails map[string]interface{}, paymentMethod string) (*PaymentToken, error) {
+func (s *MidtransPaymentService) CreateTransaction(ctx context.Context, orderID string, amount int, userDetails map[string]interface{}, paymentMethod string) (*PaymentToken, error) {
 	// Create transaction request
 	req := &snap.Request{
 		TransactionDetails: midtrans.TransactionDetails{
@@ -69,8 +94,10 @@ func (s *MidtransPaymentService) CreateTransaction(orderID string, amount int, u
 	// Add payment method specific configuration if specified
 	if paymentMethod != "" {
 		if paymentMethod == "credit_card" {
+			// The saved card is what auto-renewals are charged to
 			req.CreditCard = &snap.CreditCardDetails{
-				Secure: true,
+				Secure:   true,
+				SaveCard: true,
 			}
 		} else if paymentMethod == "gopay" || paymentMethod == "shopeepay" {
 			req.EnabledPayments = []snap.SnapPaymentType{snap.SnapPaymentType(paymentMethod)}
@@ -85,8 +112,20 @@ func (s *MidtransPaymentService) CreateTransaction(orderID string, amount int, u
 	}
 	// When paymentMethod is not specified, Midtrans will show all available payment methods
 
+	// The SDK cannot send custom headers, the request ID travels in a custom field instead,
+	// which Midtrans shows on the dashboard and echoes back in every notification of the order
+	req.CustomField1 = requestid.From(ctx)
+
 	// Create Snap transaction
-	snapResp, err := s.SnapClient.CreateTransaction(req)
+	var snapResp *snap.Response
+	err := paymentBulkhead.Do(ctx, func() error {
+		var snapErr *midtrans.Error
+		snapResp, snapErr = s.SnapClient.CreateTransaction(req)
+		if snapErr != nil {
+			return snapErr
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error creating snap transaction: %w", err)
 	}
@@ -97,8 +136,42 @@ func (s *MidtransPaymentService) CreateTransaction(orderID string, amount int, u
 	}, nil
 }
 
-func (s *MidtransPaymentService) CheckTransactionStatus(transactionID string) (interface{}, error) {
-	response, err := s.CoreAPIClient.CheckTransaction(transactionID)
+// ChargeSavedCard charges a card saved by an earlier payment without the customer. Declines are final, captures
+// are confirmed by the payment notification like any other payment.
+func (s *MidtransPaymentService) ChargeSavedCard(ctx context.Context, orderID string, amount int, savedTokenID string) (*CardCharge, error) {
+	origin := requestid.From(ctx)
+	req := &coreapi.ChargeReq{
+		PaymentType: coreapi.PaymentTypeCreditCard,
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  orderID,
+			GrossAmt: int64(amount),
+		},
+		CreditCard:   &coreapi.CreditCardDetails{TokenID: savedTokenID},
+		CustomField1: &origin,
+	}
+
+	var response *coreapi.ChargeResponse
+	err := paymentBulkhead.Do(ctx, func() error {
+		var chargeErr *midtrans.Error
+		response, chargeErr = s.CoreAPIClient.ChargeTransaction(req)
+		if chargeErr != nil {
+			return chargeErr
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error charging saved card: %w", err)
+	}
+
+	return &CardCharge{
+		TransactionID:     response.TransactionID,
+		TransactionStatus: response.TransactionStatus,
+		StatusMessage:     response.StatusMessage,
+	}, nil
+}
+
+func (s *MidtransPaymentService) CheckTransactionStatus(ctx context.Context, transactionID string) (interface{}, error) {
+	response, err := s.checkTransaction(ctx, transactionID)
 	if err != nil {
 		return nil, fmt.Errorf("error checking transaction status: %w", err)
 	}
@@ -106,7 +179,7 @@ func (s *MidtransPaymentService) CheckTransactionStatus(transactionID string) (i
 	return response, nil
 }
 
-func (s *MidtransPaymentService) HandleNotification(notificationJSON []byte) (interface{}, error) {
+func (s *MidtransPaymentService) HandleNotification(ctx context.Context, notificationJSON []byte) (interface{}, error) {
 	var notificationPayload map[string]interface{}
 
 	jsonErr := json.Unmarshal(notificationJSON, &notificationPayload)
@@ -131,10 +204,14 @@ func (s *MidtransPaymentService) HandleNotification(notificationJSON []byte) (in
 		return nil, errors.New("notification does not contain order_id")
 	}
 
+	if origin, _ := notificationPayload["custom_field1"].(string); origin != "" {
+		s.Log.Infof("Order %s was created by request %s, notification handled by request %s", orderID, origin, requestid.From(ctx))
+	}
+
 	s.Log.Infof("Checking transaction status for order ID: %s", orderID)
 
 	// Get transaction status from Midtrans
-	response, txErr := s.CoreAPIClient.CheckTransaction(orderID)
+	response, txErr := s.checkTransaction(ctx, orderID)
 	if txErr != nil {
 		s.Log.Errorf("Error checking transaction with Midtrans: %v", txErr)
 		return nil, fmt.Errorf("error checking transaction: %w", txErr)
@@ -152,6 +229,20 @@ func (s *MidtransPaymentService) HandleNotification(notificationJSON []byte) (in
 	return response, nil
 }
 
+// checkTransaction asks the Core API for the status of a transaction in a slot of the payment bulkhead
+func (s *MidtransPaymentService) checkTransaction(ctx context.Context, orderID string) (*coreapi.TransactionStatusResponse, error) {
+	var response *coreapi.TransactionStatusResponse
+	err := paymentBulkhead.Do(ctx, func() error {
+		var statusErr *midtrans.Error
+		response, statusErr = s.CoreAPIClient.CheckTransaction(orderID)
+		if statusErr != nil {
+			return statusErr
+		}
+		return nil
+	})
+	return response, err
+}
+
 // verifySignatureKey verifies the signature key from Midtrans notification
 // The signature is generated using SHA512(order_id+status_code+gross_amount+ServerKey)
 func (s *MidtransPaymentService) verifySignatureKey(notification map[string]interface{}) (bool, error) {
@@ -166,11 +257,8 @@ func (s *MidtransPaymentService) verifySignatureKey(notification map[string]inte
 		return false, errors.New("notification missing required fields for signature verification")
 	}
 
-	// Get the server key from config
-	serverKey := config.MidtransServerKey
-
-	// Use the midtrans package for verification
-	isValid, _ := midtransutils.VerifySignature(orderID, statusCode, grossAmount, signatureKey, serverKey)
+	// Use the midtrans package for verification, the sandbox gateway signs with its own key
+	isValid, _ := midtransutils.VerifySignature(orderID, statusCode, grossAmount, signatureKey, s.ServerKey)
 
 	return isValid, nil
 }
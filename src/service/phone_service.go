@@ -0,0 +1,439 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/messaging"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPhoneUnavailable is returned when a text is not sent because no phone provider is configured
+var ErrPhoneUnavailable = errors.New("phone messages are not configured")
+
+// phoneOTPRetention is how long expired codes are kept, for the hourly limit and support questions
+const phoneOTPRetention = 24 * time.Hour
+
+type PhoneService interface {
+	// SendLoginCode texts a login code to a verified phone. It answers the same whether or not the phone is a
+	// user's, so the endpoint does not tell which numbers have an account: the limits of the phone and failed
+	// sends, which only happen to phones with one, are logged instead of answered.
+	SendLoginCode(c *fiber.Ctx, req *validation.SendPhoneCode) error
+	// Login signs in the user of the verified phone with the code sent to it
+	Login(c *fiber.Ctx, req *validation.CheckPhoneCode) (*model.User, error)
+	// SendVerificationCode texts a code to the phone the user wants to add, at most OTPMaxPerUserPerHour an hour
+	// whatever the phones
+	SendVerificationCode(c *fiber.Ctx, user *model.User, req *validation.SendPhoneCode) error
+	// VerifyPhone sets the phone of the user once they entered the code sent to it
+	VerifyPhone(c *fiber.Ctx, user *model.User, req *validation.CheckPhoneCode) (*model.User, error)
+
+	// Notify texts a notification of category to the verified phone of recipient
+	Notify(ctx context.Context, recipient *model.NotificationRecipient, category, text string) error
+	// HandleTwilioStatus records the delivery status Twilio posts for a message, signed with the auth token
+	HandleTwilioStatus(c *fiber.Ctx, params map[string]string, signature string) error
+	GetMessages(c *fiber.Ctx, userID uuid.UUID, query *validation.PhoneMessageQuery) ([]model.PhoneMessage, int64, error)
+	// PurgeCodes deletes the codes that expired a day ago
+	PurgeCodes(ctx context.Context) error
+}
+
+type phoneService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+	Sender   messaging.Sender
+}
+
+func NewPhoneService(db *gorm.DB, validate *validator.Validate) PhoneService {
+	sender, err := messaging.New(config.PhoneProvider, messaging.Settings{
+		TwilioAccountSID:     config.TwilioAccountSID,
+		TwilioAuthToken:      config.TwilioAuthToken,
+		TwilioSMSFrom:        config.TwilioSMSFrom,
+		TwilioWhatsAppFrom:   config.TwilioWhatsAppFrom,
+		TwilioStatusCallback: config.TwilioStatusCallbackURL,
+		TwilioEndpoint:       config.TwilioEndpoint,
+		Timeout:              config.PhoneTimeout,
+	})
+	if err != nil {
+		utils.Log.Warnf("Phone provider %s disabled: %v", config.PhoneProvider, err)
+	}
+
+	return &phoneService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+		Sender:   sender,
+	}
+}
+
+func (s *phoneService) SendLoginCode(c *fiber.Ctx, req *validation.SendPhoneCode) error {
+	phone, err := s.parse(req)
+	if err != nil {
+		return err
+	}
+
+	var user model.User
+	result := s.DB.WithContext(c.UserContext()).Select("id").
+		Where("phone = ? AND verified_phone", phone).Limit(1).Find(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		s.Log.Infof("Not sending a login code to %s, no user verified it", phone)
+		return nil
+	}
+
+	if err := s.sendCode(c.UserContext(), &user.ID, phone, model.PhoneOTPLogin, phoneChannel(req.Channel)); err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			s.Log.Warnf("Login code to %s not sent: %s", phone, fiberErr.Message)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *phoneService) Login(c *fiber.Ctx, req *validation.CheckPhoneCode) (*model.User, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+	phone, err := messaging.NormalizePhone(req.Phone, config.PhoneDefaultCountryCode)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired code")
+	}
+
+	otp, err := s.checkCode(c.UserContext(), phone, model.PhoneOTPLogin, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	user := new(model.User)
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("id = ? AND phone = ? AND verified_phone", otp.UserID, phone).First(user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired code")
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *phoneService) SendVerificationCode(c *fiber.Ctx, user *model.User, req *validation.SendPhoneCode) error {
+	phone, err := s.parse(req)
+	if err != nil {
+		return err
+	}
+	if user.VerifiedPhone && user.Phone == phone {
+		return fiber.NewError(fiber.StatusConflict, "Phone is already verified")
+	}
+	if err := s.checkPhoneFree(c.UserContext(), user.ID, phone); err != nil {
+		return err
+	}
+
+	var sent int64
+	if err := s.DB.WithContext(c.UserContext()).Model(&model.PhoneOTP{}).
+		Where("user_id = ? AND purpose = ? AND created_at > ?", user.ID, model.PhoneOTPVerify, time.Now().Add(-time.Hour)).
+		Count(&sent).Error; err != nil {
+		return err
+	}
+	if sent >= int64(config.OTPMaxPerUserPerHour) {
+		return fiber.NewError(fiber.StatusTooManyRequests, "Too many codes were sent for your account, try again later")
+	}
+
+	return s.sendCode(c.UserContext(), &user.ID, phone, model.PhoneOTPVerify, phoneChannel(req.Channel))
+}
+
+func (s *phoneService) VerifyPhone(c *fiber.Ctx, user *model.User, req *validation.CheckPhoneCode) (*model.User, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+	phone, err := messaging.NormalizePhone(req.Phone, config.PhoneDefaultCountryCode)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid phone number")
+	}
+
+	otp, err := s.checkCode(c.UserContext(), phone, model.PhoneOTPVerify, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if otp.UserID == nil || *otp.UserID != user.ID {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired code")
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Model(&model.User{}).Where("id = ?", user.ID).
+		Updates(map[string]any{"phone": phone, "verified_phone": true}).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, fiber.NewError(fiber.StatusConflict, "Phone is already in use")
+		}
+		return nil, err
+	}
+
+	user.Phone = phone
+	user.VerifiedPhone = true
+	return user, nil
+}
+
+func (s *phoneService) Notify(ctx context.Context, recipient *model.NotificationRecipient, category, text string) error {
+	_, err := s.send(ctx, recipient.UserID, recipient.Phone, recipient.Channel, category, text)
+	return err
+}
+
+func (s *phoneService) HandleTwilioStatus(c *fiber.Ctx, params map[string]string, signature string) error {
+	// The URL Twilio signed is the callback it was given, the request may reach us through a proxy
+	if config.TwilioAuthToken == "" || config.TwilioStatusCallbackURL == "" ||
+		!messaging.VerifyTwilioSignature(config.TwilioAuthToken, config.TwilioStatusCallbackURL, params, signature) {
+		return fiber.NewError(fiber.StatusForbidden, "Invalid signature")
+	}
+
+	update, err := messaging.ParseTwilioStatus(params)
+	if err != nil {
+		s.Log.Errorf("Rejected Twilio status callback: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid status callback")
+	}
+
+	return s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		message := new(model.PhoneMessage)
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("provider = ? AND provider_message_id = ?", messaging.ProviderTwilio, update.MessageID).
+			Limit(1).Find(message)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			s.Log.Warnf("Twilio status %s of unknown message %s", update.Status, update.MessageID)
+			return nil
+		}
+
+		status := model.AdvancePhoneMessageStatus(message.Status, update.Status)
+		if status == message.Status {
+			return nil
+		}
+		updates := map[string]any{"status": status}
+		if status == messaging.StatusFailed {
+			updates["error_code"] = update.ErrorCode
+		}
+		return tx.Model(message).Updates(updates).Error
+	})
+}
+
+func (s *phoneService) GetMessages(c *fiber.Ctx, userID uuid.UUID, query *validation.PhoneMessageQuery) ([]model.PhoneMessage, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	db := s.DB.WithContext(c.UserContext()).Model(&model.PhoneMessage{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var messages []model.PhoneMessage
+	if err := db.Order("created_at DESC").
+		Offset((query.Page - 1) * query.Limit).Limit(query.Limit).Find(&messages).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return messages, total, nil
+}
+
+func (s *phoneService) PurgeCodes(ctx context.Context) error {
+	result := s.DB.WithContext(ctx).
+		Where("expires_at < ?", time.Now().Add(-phoneOTPRetention)).
+		Delete(&model.PhoneOTP{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		s.Log.Infof("Purged %d expired phone codes", result.RowsAffected)
+	}
+	return nil
+}
+
+// parse validates req and returns its phone in E.164
+func (s *phoneService) parse(req *validation.SendPhoneCode) (string, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return "", err
+	}
+	if s.Sender == nil {
+		return "", fiber.NewError(fiber.StatusServiceUnavailable, "Phone codes are not available")
+	}
+
+	phone, err := messaging.NormalizePhone(req.Phone, config.PhoneDefaultCountryCode)
+	if err != nil {
+		return "", fiber.NewError(fiber.StatusBadRequest, "Invalid phone number")
+	}
+	return phone, nil
+}
+
+// checkPhoneFree refuses a phone another user verified
+func (s *phoneService) checkPhoneFree(ctx context.Context, userID uuid.UUID, phone string) error {
+	var taken int64
+	if err := s.DB.WithContext(ctx).Model(&model.User{}).
+		Where("phone = ? AND verified_phone AND id <> ?", phone, userID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return fiber.NewError(fiber.StatusConflict, "Phone is already in use")
+	}
+	return nil
+}
+
+// sendCode texts a new code for purpose to phone, unless the phone got one too recently or too often
+func (s *phoneService) sendCode(ctx context.Context, userID *uuid.UUID, phone, purpose, channel string) error {
+	now := time.Now()
+
+	var latest model.PhoneOTP
+	result := s.DB.WithContext(ctx).Where("phone = ?", phone).Order("created_at DESC").Limit(1).Find(&latest)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 && now.Sub(latest.CreatedAt) < config.OTPResendInterval {
+		return fiber.NewError(fiber.StatusTooManyRequests, "Wait a moment before asking for another code")
+	}
+
+	var sent int64
+	if err := s.DB.WithContext(ctx).Model(&model.PhoneOTP{}).
+		Where("phone = ? AND created_at > ?", phone, now.Add(-time.Hour)).Count(&sent).Error; err != nil {
+		return err
+	}
+	if sent >= int64(config.OTPMaxPerHour) {
+		return fiber.NewError(fiber.StatusTooManyRequests, "Too many codes were sent to this phone, try again later")
+	}
+
+	code, err := otpCode()
+	if err != nil {
+		return err
+	}
+	otp := &model.PhoneOTP{
+		Phone:     phone,
+		Purpose:   purpose,
+		UserID:    userID,
+		Channel:   channel,
+		CodeHash:  hashOTP(phone, code),
+		ExpiresAt: now.Add(config.OTPTTL),
+	}
+	if err := s.DB.WithContext(ctx).Create(otp).Error; err != nil {
+		return err
+	}
+
+	text := fmt.Sprintf("%s adalah kode Nutribox kamu. Berlaku %d menit, jangan berikan kode ini kepada siapa pun.",
+		code, int(config.OTPTTL.Minutes()))
+	if _, err := s.send(ctx, userID, phone, channel, purpose, text); err != nil {
+		if messaging.IsPermanent(err) {
+			return fiber.NewError(fiber.StatusBadRequest, "The phone cannot receive messages on "+channel)
+		}
+		return fiber.NewError(fiber.StatusBadGateway, "Failed to send the code")
+	}
+	return nil
+}
+
+// checkCode uses the latest code sent to phone for purpose, each guess counts against its attempts
+func (s *phoneService) checkCode(ctx context.Context, phone, purpose, code string) (*model.PhoneOTP, error) {
+	invalid := fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired code")
+
+	otp := new(model.PhoneOTP)
+	result := s.DB.WithContext(ctx).Where("phone = ? AND purpose = ?", phone, purpose).
+		Order("created_at DESC").Limit(1).Find(otp)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || !otp.Usable(time.Now(), config.OTPMaxAttempts) {
+		return nil, invalid
+	}
+
+	// The attempt is counted before the code is compared, parallel guesses cannot go past the limit
+	result = s.DB.WithContext(ctx).Model(&model.PhoneOTP{}).
+		Where("id = ? AND used_at IS NULL AND attempts < ?", otp.ID, config.OTPMaxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || !hmac.Equal([]byte(hashOTP(phone, code)), []byte(otp.CodeHash)) {
+		return nil, invalid
+	}
+
+	result = s.DB.WithContext(ctx).Model(&model.PhoneOTP{}).
+		Where("id = ? AND used_at IS NULL", otp.ID).Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, invalid
+	}
+	return otp, nil
+}
+
+// send texts phone and records the message with the status the provider answered
+func (s *phoneService) send(ctx context.Context, userID *uuid.UUID, phone, channel, purpose, text string) (*model.PhoneMessage, error) {
+	if s.Sender == nil {
+		return nil, ErrPhoneUnavailable
+	}
+
+	message := &model.PhoneMessage{
+		UserID:   userID,
+		Phone:    phone,
+		Channel:  channel,
+		Purpose:  purpose,
+		Provider: s.Sender.Name(),
+		Status:   messaging.StatusQueued,
+	}
+	id, err := s.Sender.Send(ctx, &messaging.Message{To: phone, Channel: channel, Body: text})
+	if err != nil {
+		s.Log.Errorf("Failed to send %s %s to %s: %v", channel, purpose, phone, err)
+		message.Status = messaging.StatusFailed
+		message.Error = err.Error()
+		var sendErr *messaging.Error
+		if errors.As(err, &sendErr) && sendErr.Code != 0 {
+			message.ErrorCode = strconv.Itoa(sendErr.Code)
+		}
+	}
+	message.ProviderMessageID = id
+
+	if recordErr := s.DB.WithContext(ctx).Create(message).Error; recordErr != nil {
+		s.Log.Errorf("Failed to record the %s %s to %s: %v", channel, purpose, phone, recordErr)
+	}
+	return message, err
+}
+
+// phoneChannel is the channel a code is sent through, SMS unless the user asked for WhatsApp
+func phoneChannel(channel string) string {
+	if channel == "" {
+		return messaging.ChannelSMS
+	}
+	return channel
+}
+
+// otpCode is a random 6 digit code
+func otpCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashOTP is what is stored of a code, keyed so a leaked table does not give away codes by brute force
+func hashOTP(phone, code string) string {
+	mac := hmac.New(sha256.New, []byte(config.JWTSecret))
+	mac.Write([]byte(phone + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
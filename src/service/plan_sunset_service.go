@@ -0,0 +1,267 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// planMigrationBatchSize is the number of subscribers of sunset plans notified per run
+const planMigrationBatchSize = 500
+
+// planMigratedQuery resolves the migrations of the users who renewed on, or bought, the replacement plan since
+// they were picked up
+const planMigratedQuery = `UPDATE plan_migrations SET status = ?, migrated_to_id = s.id, resolved_at = ?, updated_at = ?
+	FROM user_subscriptions s
+	WHERE plan_migrations.status = ? AND s.user_id = plan_migrations.user_id AND s.plan_id = plan_migrations.to_plan_id
+		AND s.payment_status = 'success' AND s.created_at >= plan_migrations.created_at AND s.deleted_at IS NULL`
+
+// planLapsedQuery resolves the migrations of the subscriptions that ended without a renewal that may still be paid
+const planLapsedQuery = `UPDATE plan_migrations SET status = ?, resolved_at = ?, updated_at = ?
+	FROM user_subscriptions s
+	WHERE plan_migrations.status = ? AND s.id = plan_migrations.user_subscription_id AND s.end_date <= ?
+		AND NOT EXISTS (SELECT 1 FROM user_subscriptions renewals
+			WHERE renewals.renewal_of_id = s.id AND renewals.payment_status IN ('pending', 'on_hold')
+				AND renewals.deleted_at IS NULL)`
+
+type PlanSunsetService interface {
+	// Sunset retires a plan in favour of a replacement: the plan cannot be bought anymore and its subscribers
+	// move to the replacement when they renew
+	Sunset(c *fiber.Ctx, adminID, planID uuid.UUID, req *validation.SunsetPlan) (*model.SubscriptionPlan, error)
+
+	// GetProgress reports how many subscribers of a sunset plan moved to the replacement, lapsed or are still to
+	GetProgress(c *fiber.Ctx, planID uuid.UUID) (*model.PlanSunsetReport, error)
+
+	// Migrate tells the subscribers of sunset plans about their replacement plan and resolves the migrations
+	// of the subscriptions that moved to it or ended
+	Migrate(ctx context.Context) error
+}
+
+type planSunsetService struct {
+	Log          *logrus.Logger
+	DB           *gorm.DB
+	Validate     *validator.Validate
+	EmailService EmailService
+}
+
+func NewPlanSunsetService(db *gorm.DB, validate *validator.Validate, emailService EmailService) PlanSunsetService {
+	return &planSunsetService{
+		Log:          utils.Log,
+		DB:           db,
+		Validate:     validate,
+		EmailService: emailService,
+	}
+}
+
+// unmigrated selects the current subscriptions of sunset plans the migration has not picked up yet. Store
+// subscriptions renew at the store, a renewal paid ahead on the plan is picked up once it starts.
+func unmigrated(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Joins("JOIN subscription_plans sunset_plans ON sunset_plans.id = user_subscriptions.plan_id").
+			Where("sunset_plans.sunset_at IS NOT NULL AND sunset_plans.replacement_plan_id IS NOT NULL").
+			Where("user_subscriptions.is_active = ? AND user_subscriptions.payment_status = ?", true, "success").
+			Where("user_subscriptions.start_date <= ? AND user_subscriptions.end_date > ?", now, now).
+			Where("user_subscriptions.source NOT IN ?", []string{model.SourceAppleIAP, model.SourceGooglePlay}).
+			Where("NOT EXISTS (SELECT 1 FROM plan_migrations WHERE plan_migrations.user_subscription_id = user_subscriptions.id)").
+			Where(`NOT EXISTS (SELECT 1 FROM user_subscriptions renewals WHERE renewals.renewal_of_id = user_subscriptions.id
+				AND renewals.plan_id = user_subscriptions.plan_id AND renewals.payment_status = 'success'
+				AND renewals.deleted_at IS NULL)`)
+	}
+}
+
+func (s *planSunsetService) Sunset(c *fiber.Ctx, adminID, planID uuid.UUID, req *validation.SunsetPlan) (*model.SubscriptionPlan, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	replacementID := uuid.MustParse(req.ReplacementPlanID)
+	if replacementID == planID {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "A plan cannot replace itself")
+	}
+
+	var plan model.SubscriptionPlan
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&plan, "id = ?", planID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+			}
+			return err
+		}
+		if plan.ArchivedAt != nil {
+			return fiber.NewError(fiber.StatusConflict, "Archived plans cannot be sunset")
+		}
+		if plan.SunsetAt != nil {
+			return fiber.NewError(fiber.StatusConflict, "Subscription plan is already sunset")
+		}
+
+		var replacement model.SubscriptionPlan
+		if err := tx.First(&replacement, "id = ?", replacementID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, "Replacement plan not found")
+			}
+			return err
+		}
+		now := time.Now()
+		if !replacement.IsAvailableAt(now) {
+			return fiber.NewError(fiber.StatusBadRequest, "Replacement plan must be available for purchase")
+		}
+		if replacement.Currency != plan.Currency {
+			return fiber.NewError(fiber.StatusBadRequest, "Replacement plan must be priced in "+plan.Currency)
+		}
+
+		before := plan
+		plan.SunsetAt = &now
+		plan.ReplacementPlanID = &replacement.ID
+		if err := tx.Model(&plan).Select("SunsetAt", "ReplacementPlanID").Updates(&plan).Error; err != nil {
+			return err
+		}
+		return recordPlanChange(tx, model.PlanChangeSunset, adminID, &before, &plan, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Log.Infof("Sunset plan %s in favour of %s", plan.ID, replacementID)
+	return &plan, nil
+}
+
+func (s *planSunsetService) GetProgress(c *fiber.Ctx, planID uuid.UUID) (*model.PlanSunsetReport, error) {
+	db := s.DB.WithContext(c.UserContext())
+
+	var plan model.SubscriptionPlan
+	if err := db.First(&plan, "id = ?", planID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+		}
+		return nil, err
+	}
+	if plan.SunsetAt == nil || plan.ReplacementPlanID == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan is not sunset")
+	}
+
+	var replacement model.SubscriptionPlan
+	if err := db.First(&replacement, "id = ?", *plan.ReplacementPlanID).Error; err != nil {
+		return nil, err
+	}
+
+	report := &model.PlanSunsetReport{
+		PlanID:            plan.ID,
+		PlanName:          plan.Name,
+		ReplacementPlanID: replacement.ID,
+		ReplacementName:   replacement.Name,
+		SunsetAt:          *plan.SunsetAt,
+	}
+
+	if err := db.Model(&model.UserSubscription{}).
+		Scopes(unmigrated(time.Now())).
+		Where("user_subscriptions.plan_id = ?", plan.ID).
+		Count(&report.Unprocessed).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Status    string
+		Count     int64
+		UpdatedAt *time.Time
+	}
+	if err := db.Model(&model.PlanMigration{}).
+		Select("status, COUNT(*) AS count, MAX(updated_at) AS updated_at").
+		Where("from_plan_id = ?", plan.ID).
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	statuses := map[string]int64{}
+	for _, row := range rows {
+		statuses[row.Status] = row.Count
+		if row.UpdatedAt != nil && (report.LastRunAt == nil || row.UpdatedAt.After(*report.LastRunAt)) {
+			report.LastRunAt = row.UpdatedAt
+		}
+	}
+	report.Tally(statuses)
+
+	return report, nil
+}
+
+func (s *planSunsetService) Migrate(ctx context.Context) error {
+	now := time.Now()
+	db := s.DB.WithContext(ctx)
+
+	var subscriptions []model.UserSubscription
+	if err := db.
+		Preload("User").
+		Preload("Plan").
+		Scopes(unmigrated(now)).
+		Order("user_subscriptions.end_date").
+		Limit(planMigrationBatchSize).
+		Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	replacements := map[uuid.UUID]*model.SubscriptionPlan{}
+	notified := 0
+	for _, subscription := range subscriptions {
+		replacementID := *subscription.Plan.ReplacementPlanID
+		replacement, ok := replacements[replacementID]
+		if !ok {
+			replacement = &model.SubscriptionPlan{}
+			if err := db.First(replacement, "id = ?", replacementID).Error; err != nil {
+				return err
+			}
+			replacements[replacementID] = replacement
+		}
+
+		// The unique subscription keeps instances running the job at once from emailing twice
+		migration := model.PlanMigration{
+			UserSubscriptionID: subscription.ID,
+			UserID:             subscription.UserID,
+			FromPlanID:         subscription.PlanID,
+			ToPlanID:           replacement.ID,
+			Status:             model.PlanMigrationNotified,
+		}
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&migration)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		if err := s.EmailService.SendPlanSunsetEmail(subscription.User.Email, subscription.Plan.Name, replacement.Name,
+			replacement.PriceMoney(), subscription.EndDate, subscription.AutoRenew); err != nil {
+			s.Log.Warnf("Failed to tell %s about the sunset of plan %s: %v", subscription.User.Email, subscription.PlanID, err)
+			continue
+		}
+		if err := db.Model(&migration).Update("notified_at", now).Error; err != nil {
+			return err
+		}
+		notified++
+	}
+
+	migrated := db.Exec(planMigratedQuery, model.PlanMigrationMigrated, now, now, model.PlanMigrationNotified)
+	if migrated.Error != nil {
+		return migrated.Error
+	}
+	lapsed := db.Exec(planLapsedQuery, model.PlanMigrationLapsed, now, now, model.PlanMigrationNotified, now)
+	if lapsed.Error != nil {
+		return lapsed.Error
+	}
+
+	if len(subscriptions) > 0 || migrated.RowsAffected > 0 || lapsed.RowsAffected > 0 {
+		s.Log.Infof("Plan migration: notified %d of %d subscribers, %d migrated, %d lapsed",
+			notified, len(subscriptions), migrated.RowsAffected, lapsed.RowsAffected)
+	}
+	return nil
+}
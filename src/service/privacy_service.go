@@ -0,0 +1,123 @@
+package service
+
+import (
+	"app/src/clock"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PrivacyService interface {
+	GetSettings(c *fiber.Ctx, userID uuid.UUID) (*model.PrivacySettings, error)
+	UpdateSettings(c *fiber.Ctx, userID uuid.UUID, req *validation.UpdatePrivacySettings) (*model.PrivacySettings, error)
+	// GetPublicProfile returns the profile behind a handle when its owner lets the viewer see it and neither
+	// blocked the other. Hidden and missing profiles are both not found, so handles cannot be probed.
+	GetPublicProfile(c *fiber.Ctx, viewerID uuid.UUID, handle string) (*model.PublicProfile, error)
+}
+
+type privacyService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewPrivacyService(db *gorm.DB, validate *validator.Validate) PrivacyService {
+	return &privacyService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+// privacySettingsOf returns the privacy settings of a user, the defaults when they never changed them.
+// Every read of a user's data on behalf of another user or a coach goes through it.
+func privacySettingsOf(db *gorm.DB, userID uuid.UUID) (*model.PrivacySettings, error) {
+	var settings model.PrivacySettings
+	result := db.Where("user_id = ?", userID).Limit(1).Find(&settings)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		settings = model.DefaultPrivacySettings(userID)
+	}
+	return &settings, nil
+}
+
+func (s *privacyService) GetSettings(c *fiber.Ctx, userID uuid.UUID) (*model.PrivacySettings, error) {
+	return privacySettingsOf(s.DB.WithContext(c.UserContext()), userID)
+}
+
+func (s *privacyService) UpdateSettings(c *fiber.Ctx, userID uuid.UUID, req *validation.UpdatePrivacySettings) (*model.PrivacySettings, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var settings *model.PrivacySettings
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if settings, err = privacySettingsOf(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID); err != nil {
+			return err
+		}
+
+		if req.ProfileVisibility != nil {
+			settings.ProfileVisibility = *req.ProfileVisibility
+		}
+		if req.CoachDataSharing != nil {
+			settings.CoachDataSharing = *req.CoachDataSharing
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"profile_visibility", "coach_data_sharing", "updated_at"}),
+		}).Create(settings).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (s *privacyService) GetPublicProfile(c *fiber.Ctx, viewerID uuid.UUID, handle string) (*model.PublicProfile, error) {
+	db := s.DB.WithContext(c.UserContext())
+	notFound := fiber.NewError(fiber.StatusNotFound, "Profile not found")
+
+	user, err := userByHandle(db, handle)
+	if err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusNotFound {
+			return nil, notFound
+		}
+		return nil, err
+	}
+	if user.ID != viewerID && user.IsSuspendedAt(clock.Now(c.UserContext())) {
+		return nil, notFound
+	}
+
+	settings, err := privacySettingsOf(db, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.ProfileVisibleTo(viewerID) {
+		return nil, notFound
+	}
+
+	blocked, err := blockedBetween(db, viewerID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, notFound
+	}
+
+	profile := publicProfileOf(user)
+	return &profile, nil
+}
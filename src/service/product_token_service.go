@@ -44,7 +44,7 @@ func NewProductTokenService(db *gorm.DB, validate *validator.Validate) *productT
 
 func (s *productTokenService) GetProductTokenByUserID(c *fiber.Ctx, userID uuid.UUID) (*model.ProductToken, error) {
 	var productToken model.ProductToken
-	err := s.DB.WithContext(c.Context()).
+	err := s.DB.WithContext(c.UserContext()).
 		Where("user_id = ?", userID).
 		First(&productToken).Error
 
@@ -55,7 +55,7 @@ func (s *productTokenService) GetProductTokenByUserID(c *fiber.Ctx, userID uuid.
 }
 
 func (s *productTokenService) DeleteProductToken(c *fiber.Ctx, tokenID uuid.UUID) error {
-	return s.DB.WithContext(c.Context()).
+	return s.DB.WithContext(c.UserContext()).
 		Where("id = ?", tokenID).
 		Delete(&model.ProductToken{}).Error
 }
@@ -79,7 +79,7 @@ func (s *productTokenService) VerifyProductToken(c *fiber.Ctx, query *validation
 	}
 
 	var productToken model.ProductToken
-	if err := s.DB.WithContext(c.Context()).
+	if err := s.DB.WithContext(c.UserContext()).
 		Preload("SubscriptionPlan").
 		Where("token = ? AND user_id IS NULL AND is_active = ?", query.Token, true).
 		First(&productToken).Error; err != nil {
@@ -96,7 +96,7 @@ func (s *productTokenService) VerifyProductToken(c *fiber.Ctx, query *validation
 		"activated_at": &now,
 	}
 
-	if err := s.DB.WithContext(c.Context()).Model(&model.ProductToken{}).Where("id = ?", productToken.ID).Updates(updateData).Error; err != nil {
+	if err := s.DB.WithContext(c.UserContext()).Model(&model.ProductToken{}).Where("id = ?", productToken.ID).Updates(updateData).Error; err != nil {
 		s.Log.Errorf("Failed to update product token %s with user ID %s: %v", productToken.ID, user.ID, err)
 		return fiber.ErrInternalServerError
 	}
@@ -108,7 +108,7 @@ func (s *productTokenService) VerifyProductToken(c *fiber.Ctx, query *validation
 	if productToken.SubscriptionPlanID != nil && productToken.SubscriptionPlan != nil {
 		// Check if user already has an active subscription to this plan to avoid duplicates
 		var existingUserSubscription model.UserSubscription
-		err := s.DB.WithContext(c.Context()).
+		err := s.DB.WithContext(c.UserContext()).
 			Where("user_id = ? AND plan_id = ? AND is_active = ?", user.ID, productToken.SubscriptionPlanID, true).
 			First(&existingUserSubscription).Error
 
@@ -117,16 +117,24 @@ func (s *productTokenService) VerifyProductToken(c *fiber.Ctx, query *validation
 		} else if errors.Is(err, gorm.ErrRecordNotFound) {
 			// User does not have this specific active subscription, proceed to create
 			userSubscription := model.UserSubscription{
-				UserID:        user.ID,
-				PlanID:        *productToken.SubscriptionPlanID,
-				StartDate:     time.Now(),
-				EndDate:       time.Now().AddDate(0, 0, productToken.SubscriptionPlan.ValidityDays),
-				PaymentMethod: "product_token",
-				PaymentStatus: "success", // Assuming token verification implies successful "payment"
-				IsActive:      true,
-				TransactionID: fmt.Sprintf("TOKEN-%s", productToken.ID.String()), // Link to product token
+				UserID:          user.ID,
+				PlanID:          *productToken.SubscriptionPlanID,
+				StartDate:       time.Now(),
+				EndDate:         time.Now().AddDate(0, 0, productToken.SubscriptionPlan.ValidityDays),
+				PaymentMethod:   "product_token",
+				PaymentStatus:   "success", // Assuming token verification implies successful "payment"
+				IsActive:        true,
+				TransactionID:   fmt.Sprintf("TOKEN-%s", productToken.ID.String()), // Link to product token
+				Source:          model.SourceProductToken,
+				SourceReference: productToken.ID.String(),
+				SourceMetadata: model.NewSourceMetadata(map[string]interface{}{
+					"token": productToken.Token,
+				}),
 			}
-			if err := s.DB.WithContext(c.Context()).Create(&userSubscription).Error; err != nil {
+			if err := snapshotPlan(s.DB.WithContext(c.UserContext()), &userSubscription, nil); err != nil {
+				s.Log.Errorf("Failed to snapshot plan %s: %v", *productToken.SubscriptionPlanID, err)
+			}
+			if err := createSubscription(s.DB.WithContext(c.UserContext()), &userSubscription, "product token redeemed", &user.ID); err != nil {
 				s.Log.Errorf("Failed to create subscription for user %s with plan %s: %v", user.ID, *productToken.SubscriptionPlanID, err)
 				// Decide if this should be a hard error or just a warning. For now, log and continue.
 			}
@@ -145,7 +153,7 @@ func (s *productTokenService) VerifyProductToken(c *fiber.Ctx, query *validation
 
 func (s *productTokenService) GetAllProductTokens(c *fiber.Ctx, query *validation.ProductTokenQuery) ([]model.ProductToken, error) {
 	var tokens []model.ProductToken
-	db := s.DB.WithContext(c.Context()).Preload("SubscriptionPlan")
+	db := s.DB.WithContext(c.UserContext()).Preload("SubscriptionPlan")
 
 	if query != nil && query.WithUser {
 		db = db.Preload("User").Preload("CreatedBy")
@@ -177,7 +185,7 @@ func (s *productTokenService) CreateProductToken(c *fiber.Ctx, req *validation.C
 
 	// Periksa apakah token sudah ada
 	var existingCount int64
-	if err := s.DB.WithContext(c.Context()).Model(&model.ProductToken{}).Where("token = ?", token).Count(&existingCount).Error; err != nil {
+	if err := s.DB.WithContext(c.UserContext()).Model(&model.ProductToken{}).Where("token = ?", token).Count(&existingCount).Error; err != nil {
 		return nil, fiber.ErrInternalServerError
 	}
 
@@ -198,7 +206,7 @@ func (s *productTokenService) CreateProductToken(c *fiber.Ctx, req *validation.C
 		}
 		// Verify if the plan ID exists
 		var plan model.SubscriptionPlan
-		if err := s.DB.WithContext(c.Context()).First(&plan, "id = ?", planID).Error; err != nil {
+		if err := s.DB.WithContext(c.UserContext()).First(&plan, "id = ?", planID).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
 			}
@@ -207,12 +215,12 @@ func (s *productTokenService) CreateProductToken(c *fiber.Ctx, req *validation.C
 		productToken.SubscriptionPlanID = &planID
 	}
 
-	if err := s.DB.WithContext(c.Context()).Create(&productToken).Error; err != nil {
+	if err := s.DB.WithContext(c.UserContext()).Create(&productToken).Error; err != nil {
 		return nil, err
 	}
 
 	// Reload the token with the creator information
-	if err := s.DB.WithContext(c.Context()).Preload("CreatedBy").Preload("SubscriptionPlan").First(&productToken, productToken.ID).Error; err != nil {
+	if err := s.DB.WithContext(c.UserContext()).Preload("CreatedBy").Preload("SubscriptionPlan").First(&productToken, productToken.ID).Error; err != nil {
 		s.Log.Warnf("Unable to load creator or subscription plan information: %v", err)
 	}
 
@@ -220,7 +228,7 @@ func (s *productTokenService) CreateProductToken(c *fiber.Ctx, req *validation.C
 }
 
 func (s *productTokenService) AdminDeleteProductToken(c *fiber.Ctx, tokenID uuid.UUID) error {
-	return s.DB.WithContext(c.Context()).
+	return s.DB.WithContext(c.UserContext()).
 		Where("id = ?", tokenID).
 		Delete(&model.ProductToken{}).Error
 }
@@ -232,7 +240,7 @@ func (s *productTokenService) UpdateProductToken(c *fiber.Ctx, tokenID uuid.UUID
 		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request data: "+err.Error())
 	}
 
-	if err := s.DB.WithContext(c.Context()).First(&productToken, "id = ?", tokenID).Error; err != nil {
+	if err := s.DB.WithContext(c.UserContext()).First(&productToken, "id = ?", tokenID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fiber.NewError(fiber.StatusNotFound, "Product token not found")
 		}
@@ -243,7 +251,7 @@ func (s *productTokenService) UpdateProductToken(c *fiber.Ctx, tokenID uuid.UUID
 	if req.Token != nil && *req.Token != "" && *req.Token != productToken.Token {
 		// Check if the new token already exists for another record
 		var existingTokenCount int64
-		if err := s.DB.WithContext(c.Context()).Model(&model.ProductToken{}).Where("token = ? AND id <> ?", *req.Token, tokenID).Count(&existingTokenCount).Error; err != nil {
+		if err := s.DB.WithContext(c.UserContext()).Model(&model.ProductToken{}).Where("token = ? AND id <> ?", *req.Token, tokenID).Count(&existingTokenCount).Error; err != nil {
 			return nil, fiber.ErrInternalServerError
 		}
 		if existingTokenCount > 0 {
@@ -268,7 +276,7 @@ func (s *productTokenService) UpdateProductToken(c *fiber.Ctx, tokenID uuid.UUID
 			}
 			// Verify if the plan ID exists
 			var plan model.SubscriptionPlan
-			if err := s.DB.WithContext(c.Context()).First(&plan, "id = ?", planID).Error; err != nil {
+			if err := s.DB.WithContext(c.UserContext()).First(&plan, "id = ?", planID).Error; err != nil {
 				if errors.Is(err, gorm.ErrRecordNotFound) {
 					return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
 				}
@@ -278,12 +286,12 @@ func (s *productTokenService) UpdateProductToken(c *fiber.Ctx, tokenID uuid.UUID
 		}
 	}
 
-	if err := s.DB.WithContext(c.Context()).Save(&productToken).Error; err != nil {
+	if err := s.DB.WithContext(c.UserContext()).Save(&productToken).Error; err != nil {
 		return nil, fiber.ErrInternalServerError
 	}
 
 	// Reload the token with potentially updated relations
-	if err := s.DB.WithContext(c.Context()).Preload("CreatedBy").Preload("User").Preload("SubscriptionPlan").First(&productToken, productToken.ID).Error; err != nil {
+	if err := s.DB.WithContext(c.UserContext()).Preload("CreatedBy").Preload("User").Preload("SubscriptionPlan").First(&productToken, productToken.ID).Error; err != nil {
 		s.Log.Warnf("Unable to load full product token information after update: %v", err)
 		// Not returning error here, as the main update succeeded
 	}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"app/src/clock"
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// maxCachedContent bounds the pages and items an instance keeps, the cache starts over past it
+const maxCachedContent = 1000
+
+type PublicContentService interface {
+	// GetArticles lists the published articles for the marketing website, newest first, without their content.
+	// Every instance serves its copy for PUBLIC_CONTENT_CACHE_TTL, like everything below.
+	GetArticles(c *fiber.Ctx, query *validation.PublicContentQuery) ([]model.PublicArticle, int64, error)
+	GetArticle(c *fiber.Ctx, slug string) (*model.PublicArticle, error)
+	// GetRecipes lists the recipes curated for the website, newest first, without their ingredients and
+	// instructions
+	GetRecipes(c *fiber.Ctx, query *validation.PublicContentQuery) ([]model.PublicRecipe, int64, error)
+	GetRecipe(c *fiber.Ctx, slug string) (*model.PublicRecipe, error)
+	// GetFeed returns the PUBLIC_FEED_SIZE newest articles and recipes together
+	GetFeed(c *fiber.Ctx) ([]model.PublicFeedItem, error)
+	// GetSitemap returns every published article and recipe
+	GetSitemap(c *fiber.Ctx) ([]model.PublicFeedItem, error)
+}
+
+type publicContentService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+
+	mu     sync.Mutex
+	cached map[string]cachedPublicContent
+}
+
+type cachedPublicContent struct {
+	value    any
+	total    int64
+	cachedAt time.Time
+}
+
+func NewPublicContentService(db *gorm.DB, validate *validator.Validate) PublicContentService {
+	return &publicContentService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+		cached:   map[string]cachedPublicContent{},
+	}
+}
+
+// published keeps the content whose publication time has come, drafts have none
+func published(now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("published_at IS NOT NULL AND published_at <= ?", now)
+	}
+}
+
+// load serves key from the cache, or loads and caches it. Errors, not found included, are not cached.
+func (s *publicContentService) load(key string, loader func() (any, int64, error)) (any, int64, error) {
+	s.mu.Lock()
+	cached, ok := s.cached[key]
+	s.mu.Unlock()
+	if ok && time.Since(cached.cachedAt) <= config.PublicContentCacheTTL {
+		return cached.value, cached.total, nil
+	}
+
+	value, total, err := loader()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.cached) >= maxCachedContent {
+		s.cached = map[string]cachedPublicContent{}
+	}
+	s.cached[key] = cachedPublicContent{value: value, total: total, cachedAt: time.Now()}
+	return value, total, nil
+}
+
+func (s *publicContentService) GetArticles(c *fiber.Ctx, query *validation.PublicContentQuery) ([]model.PublicArticle, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	key := fmt.Sprintf("articles:%d:%d", query.Page, query.Limit)
+	value, total, err := s.load(key, func() (any, int64, error) {
+		db := s.DB.WithContext(c.UserContext()).Model(&model.Article{}).Scopes(published(clock.Now(c.UserContext())))
+
+		var totalResults int64
+		if err := db.Count(&totalResults).Error; err != nil {
+			return nil, 0, err
+		}
+
+		var articles []model.Article
+		if err := db.Preload("Category").
+			Order("published_at DESC").
+			Offset((query.Page - 1) * query.Limit).
+			Limit(query.Limit).
+			Find(&articles).Error; err != nil {
+			return nil, 0, err
+		}
+
+		public := make([]model.PublicArticle, len(articles))
+		for i := range articles {
+			public[i] = model.NewPublicArticle(&articles[i], config.PublicSiteURL, false)
+		}
+		return public, totalResults, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return value.([]model.PublicArticle), total, nil
+}
+
+func (s *publicContentService) GetArticle(c *fiber.Ctx, slug string) (*model.PublicArticle, error) {
+	value, _, err := s.load("article:"+slug, func() (any, int64, error) {
+		var article model.Article
+		if err := s.DB.WithContext(c.UserContext()).
+			Scopes(published(clock.Now(c.UserContext()))).
+			Preload("Category").
+			First(&article, "slug = ?", slug).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, 0, fiber.NewError(fiber.StatusNotFound, "Article not found")
+			}
+			return nil, 0, err
+		}
+
+		public := model.NewPublicArticle(&article, config.PublicSiteURL, true)
+		return &public, 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*model.PublicArticle), nil
+}
+
+func (s *publicContentService) GetRecipes(c *fiber.Ctx, query *validation.PublicContentQuery) ([]model.PublicRecipe, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	key := fmt.Sprintf("recipes:%d:%d", query.Page, query.Limit)
+	value, total, err := s.load(key, func() (any, int64, error) {
+		db := s.DB.WithContext(c.UserContext()).Model(&model.Recipe{}).Scopes(published(clock.Now(c.UserContext())))
+
+		var totalResults int64
+		if err := db.Count(&totalResults).Error; err != nil {
+			return nil, 0, err
+		}
+
+		var recipes []model.Recipe
+		if err := db.Order("published_at DESC").
+			Offset((query.Page - 1) * query.Limit).
+			Limit(query.Limit).
+			Find(&recipes).Error; err != nil {
+			return nil, 0, err
+		}
+
+		public := make([]model.PublicRecipe, len(recipes))
+		for i := range recipes {
+			public[i] = model.NewPublicRecipe(&recipes[i], config.PublicSiteURL, false)
+		}
+		return public, totalResults, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return value.([]model.PublicRecipe), total, nil
+}
+
+func (s *publicContentService) GetRecipe(c *fiber.Ctx, slug string) (*model.PublicRecipe, error) {
+	value, _, err := s.load("recipe:"+slug, func() (any, int64, error) {
+		var recipe model.Recipe
+		if err := s.DB.WithContext(c.UserContext()).
+			Scopes(published(clock.Now(c.UserContext()))).
+			First(&recipe, "slug = ?", slug).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, 0, fiber.NewError(fiber.StatusNotFound, "Recipe not found")
+			}
+			return nil, 0, err
+		}
+
+		public := model.NewPublicRecipe(&recipe, config.PublicSiteURL, true)
+		return &public, 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*model.PublicRecipe), nil
+}
+
+func (s *publicContentService) GetFeed(c *fiber.Ctx) ([]model.PublicFeedItem, error) {
+	value, _, err := s.load("feed", func() (any, int64, error) {
+		items, err := s.feedItems(c, config.PublicFeedSize)
+		return items, 0, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]model.PublicFeedItem), nil
+}
+
+func (s *publicContentService) GetSitemap(c *fiber.Ctx) ([]model.PublicFeedItem, error) {
+	value, _, err := s.load("sitemap", func() (any, int64, error) {
+		items, err := s.feedItems(c, 0)
+		return items, 0, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]model.PublicFeedItem), nil
+}
+
+// feedItems returns the newest published articles and recipes together, all of them when size is 0
+func (s *publicContentService) feedItems(c *fiber.Ctx, size int) ([]model.PublicFeedItem, error) {
+	now := clock.Now(c.UserContext())
+	newest := func(db *gorm.DB) *gorm.DB {
+		db = db.Scopes(published(now)).Order("published_at DESC")
+		if size > 0 {
+			db = db.Limit(size)
+		}
+		return db
+	}
+
+	var articles []model.Article
+	if err := s.DB.WithContext(c.UserContext()).Scopes(newest).Preload("Category").Find(&articles).Error; err != nil {
+		return nil, err
+	}
+	var recipes []model.Recipe
+	if err := s.DB.WithContext(c.UserContext()).Scopes(newest).Find(&recipes).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]model.PublicFeedItem, 0, len(articles)+len(recipes))
+	for i := range articles {
+		article := model.NewPublicArticle(&articles[i], config.PublicSiteURL, false)
+		items = append(items, model.PublicFeedItem{
+			Kind:        model.PublicContentArticle,
+			Title:       article.Title,
+			Summary:     article.Excerpt,
+			Category:    article.Category,
+			Image:       article.Image,
+			URL:         article.URL,
+			PublishedAt: article.PublishedAt,
+			UpdatedAt:   article.UpdatedAt,
+		})
+	}
+	for i := range recipes {
+		recipe := model.NewPublicRecipe(&recipes[i], config.PublicSiteURL, false)
+		item := model.PublicFeedItem{
+			Kind:        model.PublicContentRecipe,
+			Title:       recipe.Name,
+			Summary:     recipe.Excerpt,
+			Image:       recipe.Image,
+			URL:         recipe.URL,
+			PublishedAt: recipe.PublishedAt,
+			UpdatedAt:   recipe.UpdatedAt,
+		}
+		if recipe.Label != nil {
+			item.Category = *recipe.Label
+		}
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].PublishedAt.After(items[j].PublishedAt) })
+	if size > 0 && len(items) > size {
+		items = items[:size]
+	}
+	return items, nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"app/src/clock"
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PublicPlanService interface {
+	// GetPlans lists the plans for sale on the marketing website with prices written for readers of the
+	// language. Every instance serves its copy for PUBLIC_PLANS_CACHE_TTL, plan edits show up within it.
+	GetPlans(c *fiber.Ctx, query *validation.PublicPlanQuery) ([]model.PublicPlan, error)
+}
+
+type publicPlanService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+
+	mu     sync.Mutex
+	cached map[string]cachedPublicPlans // by language
+}
+
+type cachedPublicPlans struct {
+	plans    []model.PublicPlan
+	cachedAt time.Time
+}
+
+func NewPublicPlanService(db *gorm.DB, validate *validator.Validate) PublicPlanService {
+	return &publicPlanService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+		cached:   map[string]cachedPublicPlans{},
+	}
+}
+
+func (s *publicPlanService) GetPlans(c *fiber.Ctx, query *validation.PublicPlanQuery) ([]model.PublicPlan, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+	lang := query.Lang
+	if lang == "" {
+		lang = model.PublicPlanLanguages[0]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cached, ok := s.cached[lang]; ok && time.Since(cached.cachedAt) <= config.PublicPlansCacheTTL {
+		return cached.plans, nil
+	}
+
+	var plans []model.SubscriptionPlan
+	if err := s.DB.WithContext(c.UserContext()).
+		Scopes(listedPlans(clock.Now(c.UserContext()))).
+		Order("price ASC").
+		Find(&plans).Error; err != nil {
+		return nil, err
+	}
+
+	public := make([]model.PublicPlan, 0, len(plans))
+	for _, plan := range plans {
+		response, err := toPlanResponse(plan)
+		if err != nil {
+			// A plan with broken features is left out rather than taking the whole list down
+			s.Log.Errorf("Failed to list plan %s publicly: %v", plan.ID, err)
+			continue
+		}
+		public = append(public, model.NewPublicPlan(response, plan.LimitsPrivate, lang))
+	}
+
+	s.cached[lang] = cachedPublicPlans{plans: public, cachedAt: time.Now()}
+	return public, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
@@ -18,28 +19,37 @@ type RecipesService interface {
 }
 
 type recipesService struct {
-	Log *logrus.Logger
-	DB  *gorm.DB
+	Log                *logrus.Logger
+	DB                 *gorm.DB
+	SearchIndexService SearchIndexService
 }
 
-func NewRecipesService(db *gorm.DB) RecipesService {
+func NewRecipesService(db *gorm.DB, searchIndexService SearchIndexService) RecipesService {
 	return &recipesService{
-		Log: logrus.New(),
-		DB:  db,
+		Log:                logrus.New(),
+		DB:                 db,
+		SearchIndexService: searchIndexService,
 	}
 }
 
 func (s *recipesService) CreateRecipe(ctx *fiber.Ctx, recipe *model.Recipe) (*model.Recipe, error) {
-	if err := s.DB.WithContext(ctx.Context()).Create(recipe).Error; err != nil {
+	name, err := screenText("Recipe name", recipe.Name, false)
+	if err != nil {
+		return nil, err
+	}
+	recipe.Name = name
+
+	if err := s.DB.WithContext(ctx.UserContext()).Create(recipe).Error; err != nil {
 		s.Log.Errorf("Failed to create recipe: %+v", err)
 		return nil, err
 	}
+	s.SearchIndexService.IndexRecipe(ctx.UserContext(), recipe.ID)
 	return recipe, nil
 }
 
 func (s *recipesService) GetRecipes(ctx *fiber.Ctx) ([]model.Recipe, error) {
 	var recipes []model.Recipe
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Order("created_at DESC").
 		Find(&recipes).Error; err != nil {
 		s.Log.Errorf("Failed to get recipes: %+v", err)
@@ -50,7 +60,7 @@ func (s *recipesService) GetRecipes(ctx *fiber.Ctx) ([]model.Recipe, error) {
 
 func (s *recipesService) GetRecipeByID(ctx *fiber.Ctx, recipeID string) (*model.Recipe, error) {
 	var recipe model.Recipe
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Where("id = ?", recipeID).
 		First(&recipe).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -64,7 +74,7 @@ func (s *recipesService) GetRecipeByID(ctx *fiber.Ctx, recipeID string) (*model.
 
 func (s *recipesService) UpdateRecipe(ctx *fiber.Ctx, recipeID string, recipe *model.Recipe) (*model.Recipe, error) {
 	existingRecipe := new(model.Recipe)
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Where("id = ?", recipeID).
 		First(existingRecipe).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -76,7 +86,11 @@ func (s *recipesService) UpdateRecipe(ctx *fiber.Ctx, recipeID string, recipe *m
 
 	updates := make(map[string]interface{})
 	if recipe.Name != "" {
-		updates["name"] = recipe.Name
+		name, err := screenText("Recipe name", recipe.Name, false)
+		if err != nil {
+			return nil, err
+		}
+		updates["name"] = name
 	}
 	if recipe.Slug != "" {
 		updates["slug"] = recipe.Slug
@@ -96,27 +110,34 @@ func (s *recipesService) UpdateRecipe(ctx *fiber.Ctx, recipeID string, recipe *m
 	if recipe.Label != nil {
 		updates["label"] = recipe.Label
 	}
+	if recipe.PublishedAt != nil {
+		updates["published_at"] = recipe.PublishedAt
+	}
 
 	if len(updates) == 0 {
 		return existingRecipe, nil
 	}
 
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Model(existingRecipe).
 		Updates(updates).Error; err != nil {
 		s.Log.Errorf("Failed to update recipe: %+v", err)
 		return nil, err
 	}
+	s.SearchIndexService.IndexRecipe(ctx.UserContext(), existingRecipe.ID)
 
 	return existingRecipe, nil
 }
 
 func (s *recipesService) DeleteRecipe(ctx *fiber.Ctx, recipeID string) error {
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Where("id = ?", recipeID).
 		Delete(&model.Recipe{}).Error; err != nil {
 		s.Log.Errorf("Failed to delete recipe: %+v", err)
 		return err
 	}
+	if id, err := uuid.Parse(recipeID); err == nil {
+		s.SearchIndexService.RemoveContent(ctx.UserContext(), model.ContentTypeRecipe, id)
+	}
 	return nil
 }
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RectificationService interface {
+	// Rectify corrects the personal data of a user across the profile, the measurement history and the payment
+	// proofs in one transaction, and returns every value it replaced. Nothing is changed when one correction fails.
+	Rectify(c *fiber.Ctx, userID, rectifiedByID uuid.UUID, req *validation.RectifyPersonalData) (*model.Rectification, error)
+}
+
+type rectificationService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewRectificationService(db *gorm.DB, validate *validator.Validate) RectificationService {
+	return &rectificationService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *rectificationService) Rectify(c *fiber.Ctx, userID, rectifiedByID uuid.UUID, req *validation.RectifyPersonalData) (*model.Rectification, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+	if req.Profile == nil && len(req.Measurements) == 0 && len(req.PaymentProofs) == 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Nothing to rectify")
+	}
+
+	rectification := &model.Rectification{
+		UserID:        userID,
+		RectifiedByID: rectifiedByID,
+		Reason:        req.Reason,
+		Changes:       []model.PersonalDataChange{},
+		RectifiedAt:   time.Now(),
+	}
+
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, "User not found")
+			}
+			return err
+		}
+		if user.AnonymizedAt != nil {
+			return fiber.NewError(fiber.StatusConflict, "The account was anonymized, it has no personal data left to rectify")
+		}
+		wasComplete := user.ProfileComplete()
+
+		var latest model.UsersWeightHeightHistory
+		result := tx.Where("user_id = ?", userID).Order("recorded_at DESC").Limit(1).Find(&latest)
+		if result.Error != nil {
+			return result.Error
+		}
+		hasLatest := result.RowsAffected > 0
+
+		latestCorrected, err := rectifyMeasurements(tx, rectification, userID, req.Measurements, &latest)
+		if err != nil {
+			return err
+		}
+
+		profile := req.Profile
+		if profile == nil {
+			profile = &validation.RectifyProfile{}
+		}
+		// The current weight and height are those of the latest measurement, a correction of one corrects the other
+		if hasLatest && latestCorrected {
+			if profile.Weight == nil {
+				profile.Weight = &latest.Weight
+			}
+			if profile.Height == nil {
+				profile.Height = &latest.Height
+			}
+		}
+		if hasLatest && (profile.Weight != nil || profile.Height != nil) {
+			correction := validation.RectifyMeasurement{ID: latest.ID.String(), Weight: profile.Weight, Height: profile.Height}
+			if _, err := rectifyMeasurements(tx, rectification, userID, []validation.RectifyMeasurement{correction}, &latest); err != nil {
+				return err
+			}
+		}
+
+		if err := rectifyProfile(tx, rectification, &user, profile); err != nil {
+			return err
+		}
+		if err := rectifyPaymentProofs(tx, rectification, userID, req.PaymentProofs); err != nil {
+			return err
+		}
+
+		if !wasComplete && user.ProfileComplete() {
+			return appendEvent(tx, model.EventProfileCompleted, userID, userID, model.EventPayload{}, rectification.RectifiedAt)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rectification, nil
+}
+
+// rectifyProfile corrects the fields of user that profile sets and keeps user up to date
+func rectifyProfile(tx *gorm.DB, rectification *model.Rectification, user *model.User, profile *validation.RectifyProfile) error {
+	updates := map[string]any{}
+	record := func(field string, before, after any) bool {
+		if !rectification.Record(model.RectificationProfile, user.ID, field, before, after) {
+			return false
+		}
+		updates[field] = after
+		return true
+	}
+
+	if profile.Name != nil && record("name", user.Name, *profile.Name) {
+		user.Name = *profile.Name
+	}
+	if profile.Email != nil {
+		email := strings.ToLower(strings.TrimSpace(*profile.Email))
+		if record("email", user.Email, email) {
+			user.Email = email
+			// The corrected address has not been verified yet
+			if record("verified_email", user.VerifiedEmail, false) {
+				user.VerifiedEmail = false
+			}
+		}
+	}
+	if profile.Phone != nil && record("phone", user.Phone, *profile.Phone) {
+		user.Phone = *profile.Phone
+		// The corrected number has not been verified yet
+		if record("verified_phone", user.VerifiedPhone, false) {
+			user.VerifiedPhone = false
+		}
+	}
+	if profile.BirthDate != nil && record("birth_date", user.BirthDate, *profile.BirthDate) {
+		user.BirthDate = profile.BirthDate
+		consentFor := model.ParentalConsentFor
+		if rectification.RectifiedByID == user.ID {
+			consentFor = model.ParentalConsentOnSelfChange
+		}
+		consent := consentFor(user.ParentalConsent, user.BirthDate, config.ParentalConsentAge, rectification.RectifiedAt)
+		if record("parental_consent", user.ParentalConsent, consent) {
+			user.ParentalConsent = consent
+		}
+	}
+	if profile.Height != nil && record("height", user.Height, *profile.Height) {
+		user.Height = profile.Height
+	}
+	if profile.Weight != nil && record("weight", user.Weight, *profile.Weight) {
+		user.Weight = profile.Weight
+	}
+	if profile.Gender != nil && record("gender", user.Gender, *profile.Gender) {
+		user.Gender = profile.Gender
+	}
+	if profile.ActivityLevel != nil && record("activity_level", user.ActivityLevel, *profile.ActivityLevel) {
+		user.ActivityLevel = profile.ActivityLevel
+	}
+	if profile.MedicalHistory != nil && record("medical_history", user.MedicalHistory, *profile.MedicalHistory) {
+		user.MedicalHistory = profile.MedicalHistory
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+	if err := tx.Model(&model.User{}).Where("id = ?", user.ID).Updates(updates).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return fiber.NewError(fiber.StatusConflict, "Email is already in use")
+		}
+		return err
+	}
+	if _, ok := updates["email"]; ok {
+		return syncEmailDeliverability(tx, user)
+	}
+	return nil
+}
+
+// rectifyMeasurements corrects measurements of the user and reports whether latest, which follows the
+// corrections, was one of them
+func rectifyMeasurements(tx *gorm.DB, rectification *model.Rectification, userID uuid.UUID,
+	corrections []validation.RectifyMeasurement, latest *model.UsersWeightHeightHistory) (bool, error) {
+	latestCorrected := false
+
+	for _, correction := range corrections {
+		var measurement model.UsersWeightHeightHistory
+		if err := tx.Where("id = ? AND user_id = ?", correction.ID, userID).First(&measurement).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return false, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("Measurement %s not found", correction.ID))
+			}
+			return false, err
+		}
+
+		updates := map[string]any{}
+		if correction.Weight != nil &&
+			rectification.Record(model.RectificationMeasurements, measurement.ID, "weight", measurement.Weight, *correction.Weight) {
+			updates["weight"] = *correction.Weight
+			measurement.Weight = *correction.Weight
+		}
+		if correction.Height != nil &&
+			rectification.Record(model.RectificationMeasurements, measurement.ID, "height", measurement.Height, *correction.Height) {
+			updates["height"] = *correction.Height
+			measurement.Height = *correction.Height
+		}
+		if len(updates) == 0 {
+			continue
+		}
+
+		if err := tx.Model(&model.UsersWeightHeightHistory{}).Where("id = ?", measurement.ID).Updates(updates).Error; err != nil {
+			return false, err
+		}
+		if measurement.ID == latest.ID {
+			*latest = measurement
+			latestCorrected = true
+		}
+	}
+
+	return latestCorrected, nil
+}
+
+// rectifyPaymentProofs corrects the account name on payment proofs of the user
+func rectifyPaymentProofs(tx *gorm.DB, rectification *model.Rectification, userID uuid.UUID, corrections []validation.RectifyPaymentProof) error {
+	for _, correction := range corrections {
+		var proof model.PaymentProof
+		if err := tx.Select("id", "account_name").Where("id = ? AND user_id = ?", correction.ID, userID).First(&proof).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("Payment proof %s not found", correction.ID))
+			}
+			return err
+		}
+
+		if !rectification.Record(model.RectificationPaymentProofs, proof.ID, "account_name", proof.AccountName, correction.AccountName) {
+			continue
+		}
+		if err := tx.Model(&model.PaymentProof{}).Where("id = ?", proof.ID).Update("account_name", correction.AccountName).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RenewalService interface {
+	// RenewDue charges the saved card of every subscription that opted in to auto-renewal and ends within
+	// RENEWAL_LEAD_HOURS. Each attempt is a pending subscription for the next period with its own gateway
+	// order, the payment notification activates it like any other payment. Declines are retried every
+	// RENEWAL_RETRY_HOURS, after RENEWAL_MAX_ATTEMPTS of them auto-renewal is turned off and the user emailed.
+	RenewDue(ctx context.Context) error
+	// ExpireEnded moves the active and suspended subscriptions that reached their end date to expired
+	ExpireEnded(ctx context.Context) error
+	// EndTrials settles the free trials that reached their end date: a trial converted when its user bought a
+	// subscription since it started, otherwise it expired and the user is emailed the price of the plan
+	EndTrials(ctx context.Context) error
+}
+
+type renewalService struct {
+	Log            *logrus.Logger
+	DB             *gorm.DB
+	Payment        PaymentGateway
+	SandboxPayment PaymentGateway
+	EmailService   EmailService
+}
+
+func NewRenewalService(db *gorm.DB, payment, sandboxPayment PaymentGateway, emailService EmailService) RenewalService {
+	return &renewalService{
+		Log:            utils.Log,
+		DB:             db,
+		Payment:        payment,
+		SandboxPayment: sandboxPayment,
+		EmailService:   emailService,
+	}
+}
+
+// renewable selects the subscriptions due for a renewal attempt at now: opted in, paid, ending within the lead
+// time, without a renewal in flight and without a declined one within the retry interval
+func renewable(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("user_subscriptions.auto_renew = ? AND user_subscriptions.is_active = ? AND user_subscriptions.payment_status = ?",
+				true, true, "success").
+			Where("user_subscriptions.source = ? AND user_subscriptions.is_installment = ? AND user_subscriptions.saved_token_id <> ''",
+				model.SourceMidtrans, false).
+			Where("user_subscriptions.end_date > ? AND user_subscriptions.end_date <= ?",
+				now, now.Add(time.Duration(config.RenewalLeadHours)*time.Hour)).
+			Where(`NOT EXISTS (SELECT 1 FROM user_subscriptions renewals
+				WHERE renewals.renewal_of_id = user_subscriptions.id AND renewals.deleted_at IS NULL
+				AND (renewals.payment_status IN ? OR renewals.created_at > ?))`,
+				model.RenewalInFlightStatuses, now.Add(-time.Duration(config.RenewalRetryHours)*time.Hour))
+	}
+}
+
+func (s *renewalService) RenewDue(ctx context.Context) error {
+	now := time.Now()
+
+	var due []model.UserSubscription
+	if err := s.DB.WithContext(ctx).
+		Select("id").
+		Scopes(renewable(now)).
+		Find(&due).Error; err != nil {
+		return err
+	}
+
+	renewed := 0
+	for _, subscription := range due {
+		ok, err := s.renew(ctx, subscription.ID, now)
+		if err != nil {
+			s.Log.Errorf("Failed to renew subscription %s: %v", subscription.ID, err)
+			continue
+		}
+		if ok {
+			renewed++
+		}
+	}
+
+	if len(due) > 0 {
+		s.Log.Infof("Charged %d of %d subscriptions due for renewal", renewed, len(due))
+	}
+	return nil
+}
+
+// renew makes one renewal attempt and reports whether the card was charged. The pending renewal is created
+// while the subscription is locked, so instances running the job at once never charge a card twice.
+func (s *renewalService) renew(ctx context.Context, subscriptionID any, now time.Time) (bool, error) {
+	var subscription model.UserSubscription
+	var plan model.SubscriptionPlan
+	var renewal *model.UserSubscription
+	var attempts int64
+
+	if err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Preload("User").
+			Preload("Plan").
+			Scopes(renewable(now)).
+			Where("user_subscriptions.id = ?", subscriptionID).
+			Limit(1).
+			Find(&subscription)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		if err := tx.Model(&model.UserSubscription{}).
+			Where("renewal_of_id = ?", subscription.ID).
+			Count(&attempts).Error; err != nil {
+			return err
+		}
+
+		// Subscribers of a sunset plan renew on its replacement
+		plan = subscription.Plan
+		if planID := subscription.Plan.RenewsTo(); planID != subscription.PlanID {
+			plan = model.SubscriptionPlan{}
+			if err := tx.First(&plan, "id = ?", planID).Error; err != nil {
+				return err
+			}
+		}
+
+		// Plans that can no longer be bought are not renewed either
+		if !subscription.CanAutoRenew(now) || !plan.IsActive || plan.ArchivedAt != nil ||
+			plan.Currency != model.CurrencyIDR || attempts >= int64(config.RenewalMaxAttempts) {
+			return tx.Model(&subscription).Update("auto_renew", false).Error
+		}
+
+		metadata := map[string]interface{}{
+			"payment_method": "credit_card",
+			"renewal_of":     subscription.ID.String(),
+			"attempt":        attempts + 1,
+		}
+		if plan.ID != subscription.PlanID {
+			metadata["migrated_from"] = subscription.PlanID.String()
+		}
+
+		start, end := subscription.RenewalPeriod(&plan)
+		orderID := model.RenewalOrderID(subscription.ID, int(attempts)+1, now, subscription.IsSandbox)
+		renewal = &model.UserSubscription{
+			UserID:              subscription.UserID,
+			PlanID:              plan.ID,
+			StartDate:           start,
+			EndDate:             end,
+			IsActive:            false, // activated by the payment notification
+			PaymentMethod:       "credit_card",
+			TransactionID:       orderID,
+			PaymentStatus:       "pending",
+			Source:              model.SourceMidtrans,
+			SourceReference:     orderID,
+			IsSandbox:           subscription.IsSandbox,
+			AutoRenew:           true,
+			SavedTokenID:        subscription.SavedTokenID,
+			SavedTokenExpiresAt: subscription.SavedTokenExpiresAt,
+			MaskedCard:          subscription.MaskedCard,
+			RenewalOfID:         &subscription.ID,
+			SourceMetadata:      model.NewSourceMetadata(metadata),
+		}
+		return createSubscription(tx, renewal, "auto-renewal", nil)
+	}); err != nil {
+		return false, err
+	}
+	if renewal == nil {
+		if subscription.ID != uuid.Nil && !subscription.AutoRenew {
+			s.Log.Infof("Turned off auto-renewal of subscription %s", subscription.ID)
+		}
+		return false, nil
+	}
+
+	gateway, err := paymentGateway(s.Payment, s.SandboxPayment, subscription.IsSandbox)
+	if err != nil {
+		s.decline(ctx, &subscription, &plan, renewal, int(attempts)+1, err.Error())
+		return false, err
+	}
+
+	charge, err := gateway.ChargeSavedCard(ctx, renewal.TransactionID, plan.Price, subscription.SavedTokenID)
+	if err != nil {
+		// A charge that went through after all is still activated by its notification
+		s.decline(ctx, &subscription, &plan, renewal, int(attempts)+1, err.Error())
+		return false, err
+	}
+
+	switch charge.TransactionStatus {
+	case "capture", "settlement", "pending":
+		return true, nil
+	default:
+		s.decline(ctx, &subscription, &plan, renewal, int(attempts)+1, fmt.Sprintf("%s: %s", charge.TransactionStatus, charge.StatusMessage))
+		return false, nil
+	}
+}
+
+func (s *renewalService) ExpireEnded(ctx context.Context) error {
+	now := time.Now()
+
+	var ended []model.UserSubscription
+	if err := s.DB.WithContext(ctx).
+		Where("status IN ? AND end_date <= ?", []string{model.SubscriptionActive, model.SubscriptionSuspended}, now).
+		Find(&ended).Error; err != nil {
+		return err
+	}
+
+	expired := 0
+	for i := range ended {
+		if err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return syncSubscriptionStatus(tx, &ended[i], "end date reached", nil, now)
+		}); err != nil {
+			s.Log.Errorf("Failed to expire subscription %s: %v", ended[i].ID, err)
+			continue
+		}
+		if ended[i].Status == model.SubscriptionExpired {
+			expired++
+		}
+	}
+
+	if expired > 0 {
+		s.Log.Infof("Expired %d subscriptions", expired)
+	}
+	return nil
+}
+
+func (s *renewalService) EndTrials(ctx context.Context) error {
+	now := time.Now()
+
+	var ended []model.UserSubscription
+	if err := s.DB.WithContext(ctx).
+		Preload("User").
+		Preload("Plan").
+		Where("source = ? AND trial_outcome = '' AND end_date <= ?", model.SourceTrial, now).
+		Find(&ended).Error; err != nil {
+		return err
+	}
+
+	converted := 0
+	for i := range ended {
+		trial := &ended[i]
+		outcome := model.TrialExpired
+		if err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var paid int64
+			if err := tx.Model(&model.UserSubscription{}).
+				Where("user_id = ? AND source <> ? AND payment_status = ? AND created_at >= ?",
+					trial.UserID, model.SourceTrial, "success", trial.StartDate).
+				Count(&paid).Error; err != nil {
+				return err
+			}
+			if paid > 0 {
+				outcome = model.TrialConverted
+			}
+
+			// Only one instance settles a trial
+			result := tx.Model(trial).
+				Where("trial_outcome = ''").
+				Update("trial_outcome", outcome)
+			if result.Error != nil || result.RowsAffected == 0 {
+				outcome = ""
+				return result.Error
+			}
+			return syncSubscriptionStatus(tx, trial, "free trial "+outcome, nil, now)
+		}); err != nil {
+			s.Log.Errorf("Failed to end trial %s: %v", trial.ID, err)
+			continue
+		}
+
+		switch outcome {
+		case model.TrialConverted:
+			converted++
+		case model.TrialExpired:
+			if err := s.EmailService.SendTrialEndedEmail(trial.User.Email, trial.Plan.Name, trial.Plan.PriceMoney(),
+				trial.EndDate); err != nil {
+				s.Log.Warnf("Failed to send trial end to %s: %v", trial.User.Email, err)
+			}
+		}
+	}
+
+	if len(ended) > 0 {
+		s.Log.Infof("Ended %d free trials, %d converted", len(ended), converted)
+	}
+	return nil
+}
+
+// decline fails a renewal attempt, the last allowed attempt turns auto-renewal off and tells the user
+func (s *renewalService) decline(
+	ctx context.Context, subscription *model.UserSubscription, plan *model.SubscriptionPlan, renewal *model.UserSubscription, attempt int, reason string,
+) {
+	s.Log.Warnf("Renewal %d of subscription %s was declined: %s", attempt, subscription.ID, reason)
+
+	if err := s.DB.WithContext(ctx).
+		Model(renewal).
+		Where("payment_status = ?", "pending").
+		Update("payment_status", "failed").Error; err != nil {
+		s.Log.Errorf("Failed to mark renewal %s as failed: %v", renewal.ID, err)
+	}
+
+	data := model.NewPaymentWebhookData(renewal, renewal.TransactionID, plan.PriceMoney(), "failed", reason)
+	if err := enqueueWebhook(s.DB.WithContext(ctx), model.WebhookPaymentFailed, data, time.Now()); err != nil {
+		s.Log.Errorf("Failed to queue the payment failure webhook of renewal %s: %v", renewal.ID, err)
+	}
+
+	if attempt < config.RenewalMaxAttempts {
+		return
+	}
+
+	if err := s.DB.WithContext(ctx).
+		Model(subscription).
+		Update("auto_renew", false).Error; err != nil {
+		s.Log.Errorf("Failed to turn off auto-renewal of subscription %s: %v", subscription.ID, err)
+		return
+	}
+
+	if err := s.EmailService.SendRenewalFailedEmail(subscription.User.Email, plan.Name, subscription.MaskedCard,
+		plan.PriceMoney(), subscription.EndDate); err != nil {
+		s.Log.Warnf("Failed to send renewal failure to %s: %v", subscription.User.Email, err)
+	}
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/logfile"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// retentionBatchSize bounds the rows changed by one statement so the live tables are never locked for long
+const retentionBatchSize = 1000
+
+type RetentionService interface {
+	GetPolicies(c *fiber.Ctx) ([]model.RetentionPolicy, error)
+	// DryRun counts what every enabled rule would change now, without changing anything
+	DryRun(c *fiber.Ctx, adminID uuid.UUID) (*model.RetentionReport, error)
+	GetRuns(c *fiber.Ctx, query *validation.RetentionRunQuery) ([]model.RetentionRun, error)
+
+	// ApplyPolicies applies every enabled rule once a day, or only reports what they would change while
+	// RETENTION_DRY_RUN is on. Each rule gets an audit entry.
+	ApplyPolicies(ctx context.Context) error
+}
+
+type retentionService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewRetentionService(db *gorm.DB, validate *validator.Validate) RetentionService {
+	return &retentionService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+// retentionRule counts the records past the cutoff and changes them
+type retentionRule struct {
+	count func(db *gorm.DB, cutoff time.Time) (int64, error)
+	apply func(db *gorm.DB, cutoff time.Time, now time.Time) (int64, error)
+}
+
+var retentionRules = map[string]retentionRule{
+	model.RetentionScanImages:       {count: countScanImages, apply: dropScanImages},
+	model.RetentionLogs:             {count: countLogs, apply: purgeLogs},
+	model.RetentionInactiveAccounts: {count: countInactiveAccounts, apply: anonymizeInactiveAccounts},
+	model.RetentionActivityLogs:     {count: countActivityLogs, apply: purgeActivityLogs},
+	model.RetentionLoginLocations:   {count: countLoginLocations, apply: dropLoginLocations},
+}
+
+func (s *retentionService) GetPolicies(c *fiber.Ctx) ([]model.RetentionPolicy, error) {
+	return retentionPolicies(time.Now()), nil
+}
+
+func (s *retentionService) DryRun(c *fiber.Ctx, adminID uuid.UUID) (*model.RetentionReport, error) {
+	return s.run(c.UserContext(), true, &adminID)
+}
+
+func (s *retentionService) GetRuns(c *fiber.Ctx, query *validation.RetentionRunQuery) ([]model.RetentionRun, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = 50
+	}
+
+	db := s.DB.WithContext(c.UserContext()).Order("started_at DESC").Limit(limit)
+	if query.Rule != "" {
+		db = db.Where("rule = ?", query.Rule)
+	}
+
+	var runs []model.RetentionRun
+	if err := db.Find(&runs).Error; err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+func (s *retentionService) ApplyPolicies(ctx context.Context) error {
+	now := time.Now()
+	claimed, err := claimDailyRun(ctx, s.DB, model.SettingRetentionAppliedOn, now.Format("2006-01-02"))
+	if err != nil || !claimed {
+		return err
+	}
+
+	report, err := s.run(ctx, config.RetentionDryRun.Get(), nil)
+	if err != nil {
+		return err
+	}
+
+	for _, run := range report.Runs {
+		if run.Error != "" {
+			return fmt.Errorf("retention rule %s: %s", run.Rule, run.Error)
+		}
+	}
+	return nil
+}
+
+// run applies, or counts in a dry run, every enabled rule and records an audit entry per rule.
+// A failing rule is recorded and does not stop the next ones.
+func (s *retentionService) run(ctx context.Context, dryRun bool, triggeredByID *uuid.UUID) (*model.RetentionReport, error) {
+	db := s.DB.WithContext(ctx)
+	report := &model.RetentionReport{DryRun: dryRun}
+
+	for _, policy := range retentionPolicies(time.Now()) {
+		if !policy.Enabled {
+			continue
+		}
+		rule := retentionRules[policy.Rule]
+
+		run := model.RetentionRun{
+			Rule:          policy.Rule,
+			DryRun:        dryRun,
+			Cutoff:        policy.Cutoff,
+			TriggeredByID: triggeredByID,
+			StartedAt:     time.Now(),
+		}
+
+		matched, err := rule.count(db, policy.Cutoff)
+		run.Matched = matched
+		if err == nil && !dryRun && matched > 0 {
+			run.Affected, err = rule.apply(db, policy.Cutoff, run.StartedAt)
+		}
+		if err != nil {
+			run.Error = err.Error()
+		}
+		run.FinishedAt = time.Now()
+
+		if err := db.Create(&run).Error; err != nil {
+			return nil, err
+		}
+		if run.Error != "" {
+			s.Log.Errorf("Retention rule %s failed after %d of %d records: %s", run.Rule, run.Affected, run.Matched, run.Error)
+		} else if run.Affected > 0 {
+			s.Log.Infof("Retention rule %s changed %d records older than %s", run.Rule, run.Affected, run.Cutoff.Format("2006-01-02"))
+		}
+
+		report.Runs = append(report.Runs, run)
+	}
+
+	return report, nil
+}
+
+// retentionPolicies are the rules with the periods configured for them
+func retentionPolicies(now time.Time) []model.RetentionPolicy {
+	return []model.RetentionPolicy{
+		model.NewRetentionPolicy(model.RetentionScanImages, "Photos of meals are dropped, the meals and their nutrition stay",
+			config.RetentionScanImageMonths, 0, now),
+		model.NewRetentionPolicy(model.RetentionLogs,
+			"Deep link clicks, food searches and phone messages are deleted, and the days of the activity and request log files",
+			0, config.RetentionLogDays, now),
+		model.NewRetentionPolicy(model.RetentionInactiveAccounts,
+			"Accounts without a login, meal or subscription since the cutoff lose their name, email, contact and medical data",
+			config.RetentionInactiveAccountMonths, 0, now),
+		model.NewRetentionPolicy(model.RetentionActivityLogs, "The audit trail of user and admin activities is deleted",
+			config.RetentionActivityLogMonths, 0, now),
+		model.NewRetentionPolicy(model.RetentionLoginLocations, "Activities lose the IP address and logins the country, region and city they came from, the rows stay",
+			0, config.RetentionLoginLocationDays, now),
+	}
+}
+
+// applyInBatches runs statement, which changes at most retentionBatchSize rows, until it changes none
+func applyInBatches(db *gorm.DB, statement func(tx *gorm.DB) *gorm.DB) (int64, error) {
+	var total int64
+	for {
+		if err := db.Statement.Context.Err(); err != nil {
+			return total, err
+		}
+
+		result := statement(db)
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+		if result.RowsAffected < retentionBatchSize {
+			return total, nil
+		}
+	}
+}
+
+func scanImagesQuery(db *gorm.DB, cutoff time.Time) *gorm.DB {
+	return db.Model(&model.MealHistory{}).Where("meal_image <> '' AND created_at < ?", cutoff)
+}
+
+func countScanImages(db *gorm.DB, cutoff time.Time) (int64, error) {
+	var count int64
+	err := scanImagesQuery(db, cutoff).Count(&count).Error
+	return count, err
+}
+
+func dropScanImages(db *gorm.DB, cutoff time.Time, _ time.Time) (int64, error) {
+	return applyInBatches(db, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&model.MealHistory{}).
+			Where("id IN (?)", scanImagesQuery(tx, cutoff).Select("id").Limit(retentionBatchSize)).
+			Update("meal_image", "")
+	})
+}
+
+func countLogs(db *gorm.DB, cutoff time.Time) (int64, error) {
+	var total int64
+	for _, table := range model.RetentionLogTables {
+		var count int64
+		if err := db.Table(table.Table).Where(table.Column+" < ?", cutoff).Count(&count).Error; err != nil {
+			return total, err
+		}
+		total += count
+	}
+
+	files, err := expiredLogFiles(cutoff)
+	return total + int64(len(files)), err
+}
+
+func purgeLogs(db *gorm.DB, cutoff time.Time, _ time.Time) (int64, error) {
+	var total int64
+	for _, table := range model.RetentionLogTables {
+		deleted, err := applyInBatches(db, func(tx *gorm.DB) *gorm.DB {
+			return tx.Exec(fmt.Sprintf(`DELETE FROM %[1]s WHERE ctid IN (SELECT ctid FROM %[1]s WHERE %[2]s < ? LIMIT ?)`,
+				table.Table, table.Column), cutoff, retentionBatchSize)
+		})
+		total += deleted
+		if err != nil {
+			return total, fmt.Errorf("purging %s: %w", table.Table, err)
+		}
+	}
+
+	files, err := expiredLogFiles(cutoff)
+	if err != nil {
+		return total, err
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return total, fmt.Errorf("purging %s: %w", file, err)
+		}
+		total++
+	}
+	return total, nil
+}
+
+// expiredLogFiles lists the days of the log files before the cutoff, each day is a file
+func expiredLogFiles(cutoff time.Time) ([]string, error) {
+	var expired []string
+	for _, path := range []string{utils.ActivityLogFile, utils.RequestLogFile} {
+		files, err := logfile.Expired(path, cutoff)
+		if err != nil {
+			return expired, err
+		}
+		expired = append(expired, files...)
+	}
+	return expired, nil
+}
+
+func countActivityLogs(db *gorm.DB, cutoff time.Time) (int64, error) {
+	var count int64
+	err := db.Model(&model.ActivityLog{}).Where("created_at < ?", cutoff).Count(&count).Error
+	return count, err
+}
+
+func purgeActivityLogs(db *gorm.DB, cutoff time.Time, _ time.Time) (int64, error) {
+	return applyInBatches(db, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("id IN (?)", tx.Model(&model.ActivityLog{}).Select("id").Where("created_at < ?", cutoff).Limit(retentionBatchSize)).
+			Delete(&model.ActivityLog{})
+	})
+}
+
+// loginLocationsQuery selects the activities that still tell where they came from: an IP address, or the
+// location of a login
+func loginLocationsQuery(db *gorm.DB, cutoff time.Time) *gorm.DB {
+	located := make([]string, len(model.LoginLocationKeys))
+	for i, key := range model.LoginLocationKeys {
+		located[i] = fmt.Sprintf("details->>'%s' IS NOT NULL", key)
+	}
+	return db.Model(&model.ActivityLog{}).
+		Where("created_at < ?", cutoff).
+		Where("(ip_address IS NOT NULL AND ip_address <> '') OR (action = ? AND ("+strings.Join(located, " OR ")+"))", "login")
+}
+
+func countLoginLocations(db *gorm.DB, cutoff time.Time) (int64, error) {
+	var count int64
+	err := loginLocationsQuery(db, cutoff).Count(&count).Error
+	return count, err
+}
+
+func dropLoginLocations(db *gorm.DB, cutoff time.Time, _ time.Time) (int64, error) {
+	return applyInBatches(db, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&model.ActivityLog{}).
+			Where("id IN (?)", loginLocationsQuery(tx, cutoff).Select("id").Limit(retentionBatchSize)).
+			Updates(map[string]interface{}{
+				"ip_address": gorm.Expr("NULL"),
+				"details": gorm.Expr("CASE WHEN action = 'login' THEN details - ?::text[] ELSE details END",
+					"{"+strings.Join(model.LoginLocationKeys, ",")+"}"),
+			})
+	})
+}
+
+// inactiveAccountsQuery selects the regular accounts that were neither used nor paid for since the cutoff
+func inactiveAccountsQuery(db *gorm.DB, cutoff time.Time) *gorm.DB {
+	return db.Model(&model.User{}).
+		Where("users.anonymized_at IS NULL AND users.role = ? AND users.created_at < ?", "user", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM login_streaks WHERE login_streaks.user_id = users.id AND login_streaks.login_date >= ?)", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM tokens WHERE tokens.user_id = users.id AND tokens.created_at >= ?)", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM meal_histories WHERE meal_histories.user_id = users.id AND meal_histories.created_at >= ?)", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM user_subscriptions WHERE user_subscriptions.user_id = users.id AND user_subscriptions.end_date >= ? "+
+			"AND user_subscriptions.deleted_at IS NULL)", cutoff)
+}
+
+func countInactiveAccounts(db *gorm.DB, cutoff time.Time) (int64, error) {
+	var count int64
+	err := inactiveAccountsQuery(db, cutoff).Count(&count).Error
+	return count, err
+}
+
+// anonymizeInactiveAccounts removes the personal data of inactive accounts and signs them out. Their meals,
+// measurements and payments stay for the statistics, tied to an account nobody can be identified by.
+func anonymizeInactiveAccounts(db *gorm.DB, cutoff time.Time, now time.Time) (int64, error) {
+	var total int64
+	for {
+		if err := db.Statement.Context.Err(); err != nil {
+			return total, err
+		}
+
+		var ids []uuid.UUID
+		if err := inactiveAccountsQuery(db, cutoff).Limit(retentionBatchSize).Pluck("users.id", &ids).Error; err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		for _, id := range ids {
+			if err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Model(&model.User{}).Where("id = ?", id).Updates(model.AnonymizedUserFields(id, now)).Error; err != nil {
+					return err
+				}
+				// Chats are free text about the user, they go with the rest of the personal data
+				if err := tx.Where("user_id = ?", id).Delete(&model.AssistantMessage{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("user_id = ?", id).Delete(&model.AssistantConversation{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("user_id = ?", id).Delete(&model.DiaryExport{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("user_id = ?", id).Delete(&model.DiaryShare{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("user_id = ?", id).Delete(&model.PartnerConsent{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("user_id = ?", id).Delete(&model.PartnerEnrollmentCode{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("user_id = ?", id).Delete(&model.PrivacySettings{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("blocker_id = ? OR blocked_id = ?", id, id).Delete(&model.UserBlock{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("user_id = ?", id).Delete(&model.Passkey{}).Error; err != nil {
+					return err
+				}
+				return tx.Where("user_id = ?", id).Delete(&model.Token{}).Error
+			}); err != nil {
+				return total, fmt.Errorf("anonymizing user %s: %w", id, err)
+			}
+			total++
+		}
+	}
+}
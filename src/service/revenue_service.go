@@ -0,0 +1,323 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// revenueReportMaxMonths bounds the range of a single revenue report
+	revenueReportMaxMonths = 36
+	// revenueAnalyticsMaxDays bounds the range of daily revenue analytics
+	revenueAnalyticsMaxDays = 366
+)
+
+// paidSubscriptionsOf is the condition on the subscriptions of table that users ever paid for, through the gateway,
+// an app store or a bank transfer. Store subscriptions that lapsed or were refunded by the store were paid until
+// then, a refunded gateway or transfer payment is a held one that was rejected and never collected. Trials, comps,
+// product tokens, sandbox purchases and payments that did not go through or are held are left out.
+func paidSubscriptionsOf(table string) string {
+	stores := "'" + model.SourceAppleIAP + "', '" + model.SourceGooglePlay + "'"
+	return table + ".source IN ('" + model.SourceMidtrans + "', '" + model.SourceManualTransfer + "', " + stores + ") AND " +
+		table + ".is_sandbox = false AND " + table + ".payment_status NOT IN ('pending', 'failed', 'on_hold') AND NOT (" +
+		table + ".payment_status = 'refunded' AND " + table + ".source NOT IN (" + stores + ")) AND " +
+		table + ".deleted_at IS NULL"
+}
+
+// paidSubscriptions is paidSubscriptionsOf the user_subscriptions table
+var paidSubscriptions = paidSubscriptionsOf("user_subscriptions")
+
+type RevenueService interface {
+	GetRevenueReport(c *fiber.Ctx, query *validation.RevenueReportQuery) (*model.RevenueReport, error)
+	// GetAnalytics aggregates the revenue collected, per period and per plan, and the subscribers won and lost
+	// between two days, with the monthly recurring revenue of the subscriptions active now
+	GetAnalytics(c *fiber.Ctx, query *validation.RevenueAnalyticsQuery) (*model.RevenueAnalytics, error)
+	// GetRetention follows the subscriber cohorts of a range of months and rates the churn per plan of the paid
+	// subscriptions that ended in it
+	GetRetention(c *fiber.Ctx, query *validation.SubscriptionRetentionQuery) (*model.SubscriptionRetention, error)
+}
+
+type revenueService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewRevenueService(db *gorm.DB, validate *validator.Validate) RevenueService {
+	return &revenueService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *revenueService) GetRevenueReport(c *fiber.Ctx, query *validation.RevenueReportQuery) (*model.RevenueReport, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	from, _ := time.Parse("2006-01", query.From)
+	to, _ := time.Parse("2006-01", query.To)
+
+	if to.Before(from) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+	if from.AddDate(0, revenueReportMaxMonths, 0).Before(to) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Report range is limited to 36 months")
+	}
+
+	var aggregates []model.RevenueAggregate
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(&model.RevenueRecognition{}).
+		Select("date_trunc('month', paid_at AT TIME ZONE 'UTC')::date AS paid_month, month, SUM(amount) AS amount").
+		Where("paid_at < ?", to.AddDate(0, 1, 0)).
+		// The report is in Rupiah, app store payments in other currencies are left out
+		Where("currency = ?", model.CurrencyIDR).
+		Group("paid_month, month").
+		Scan(&aggregates).Error; err != nil {
+		return nil, err
+	}
+
+	return model.BuildRevenueReport(aggregates, from, to), nil
+}
+
+func (s *revenueService) GetAnalytics(c *fiber.Ctx, query *validation.RevenueAnalyticsQuery) (*model.RevenueAnalytics, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	from, _ := time.Parse(time.DateOnly, query.From)
+	to, _ := time.Parse(time.DateOnly, query.To)
+
+	if to.Before(from) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+	if from.AddDate(0, revenueReportMaxMonths, 0).Before(to) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Analytics range is limited to 36 months")
+	}
+	if query.Interval == model.RevenueIntervalDay && from.AddDate(0, 0, revenueAnalyticsMaxDays).Before(to) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Daily analytics are limited to 366 days, use a weekly or monthly interval")
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+	// Periods are in UTC and the range covers the whole of its last day
+	end := to.AddDate(0, 0, 1)
+	var buckets []model.RevenueBucket
+
+	// Cash collected, the recognition schedule has it in Rupiah without sandbox payments
+	var revenue []model.RevenueBucket
+	if err := db.Model(&model.RevenueRecognition{}).
+		Select("date_trunc(?, paid_at AT TIME ZONE 'UTC') AS start, SUM(amount) AS revenue, "+
+			"COUNT(DISTINCT transaction_detail_id) AS payments", query.Interval).
+		Where("paid_at >= ? AND paid_at < ? AND currency = ?", from, end, model.CurrencyIDR).
+		Group("start").
+		Scan(&revenue).Error; err != nil {
+		return nil, err
+	}
+	buckets = append(buckets, revenue...)
+
+	// A subscriber is new in the period their first paid subscription started in
+	var won []model.RevenueBucket
+	if err := db.Table("(?) AS firsts",
+		db.Model(&model.UserSubscription{}).
+			Select("user_id, MIN(start_date) AS first_start").
+			Where(paidSubscriptions).
+			Group("user_id")).
+		Select("date_trunc(?, first_start AT TIME ZONE 'UTC') AS start, COUNT(*) AS new_subscribers", query.Interval).
+		Where("first_start >= ? AND first_start < ?", from, end).
+		Group("start").
+		Scan(&won).Error; err != nil {
+		return nil, err
+	}
+	buckets = append(buckets, won...)
+
+	// A subscriber churns when a paid subscription ends without another paid one running on, renewals are not churn
+	var lost []model.RevenueBucket
+	if err := db.Model(&model.SubscriptionEvent{}).
+		Select("date_trunc(?, subscription_events.occurred_at AT TIME ZONE 'UTC') AS start, "+
+			"COUNT(DISTINCT user_subscriptions.user_id) AS churned_subscribers", query.Interval).
+		Joins("JOIN user_subscriptions ON user_subscriptions.id = subscription_events.user_subscription_id").
+		Where("subscription_events.to_status IN ? AND subscription_events.occurred_at >= ? AND subscription_events.occurred_at < ?",
+			[]string{model.SubscriptionExpired, model.SubscriptionCancelled}, from, end).
+		Where(paidSubscriptions).
+		Where(`NOT EXISTS (SELECT 1 FROM user_subscriptions others
+			WHERE others.user_id = user_subscriptions.user_id AND others.id <> user_subscriptions.id AND ` + paidSubscriptionsOf("others") + `
+			AND others.start_date <= subscription_events.occurred_at AND others.end_date > subscription_events.occurred_at)`).
+		Group("start").
+		Scan(&lost).Error; err != nil {
+		return nil, err
+	}
+	buckets = append(buckets, lost...)
+
+	var plans []model.RevenuePlan
+	if err := db.Model(&model.RevenueRecognition{}).
+		Select("user_subscriptions.plan_id, MAX(subscription_plans.name) AS name, SUM(revenue_recognitions.amount) AS revenue, "+
+			"COUNT(DISTINCT revenue_recognitions.transaction_detail_id) AS payments").
+		Joins("JOIN user_subscriptions ON user_subscriptions.id = revenue_recognitions.user_subscription_id").
+		Joins("LEFT JOIN subscription_plans ON subscription_plans.id = user_subscriptions.plan_id").
+		Where("revenue_recognitions.paid_at >= ? AND revenue_recognitions.paid_at < ? AND revenue_recognitions.currency = ?",
+			from, end, model.CurrencyIDR).
+		Group("user_subscriptions.plan_id").
+		Order("revenue DESC").
+		Scan(&plans).Error; err != nil {
+		return nil, err
+	}
+
+	analytics := model.BuildRevenueAnalytics(from, to, query.Interval, buckets, plans)
+
+	// MRR spreads the price each active subscriber paid, as sold, over 30 days of its validity
+	if err := db.Model(&model.UserSubscription{}).
+		Select("COALESCE(ROUND(SUM(COALESCE((plan_snapshot->>'price')::numeric, subscription_plans.price) * 30 / "+
+			"NULLIF(COALESCE((plan_snapshot->>'validity_days')::numeric, subscription_plans.validity_days), 0))), 0), "+
+			"COUNT(DISTINCT user_subscriptions.user_id)").
+		Joins("JOIN subscription_plans ON subscription_plans.id = user_subscriptions.plan_id").
+		Where("user_subscriptions.status = ?", model.SubscriptionActive).
+		Where(paidSubscriptions).
+		Where("COALESCE(plan_snapshot->>'currency', subscription_plans.currency) = ?", model.CurrencyIDR).
+		Row().Scan(&analytics.MRR, &analytics.ActiveSubscribers); err != nil {
+		return nil, err
+	}
+
+	return analytics, nil
+}
+
+func (s *revenueService) GetRetention(c *fiber.Ctx, query *validation.SubscriptionRetentionQuery) (*model.SubscriptionRetention, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	from, _ := time.Parse("2006-01", query.From)
+	to, _ := time.Parse("2006-01", query.To)
+
+	if to.Before(from) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+	if from.AddDate(0, revenueReportMaxMonths, 0).Before(to) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Retention range is limited to 36 months")
+	}
+
+	db := s.DB.WithContext(c.UserContext())
+	now := time.Now()
+	end := to.AddDate(0, 1, 0)
+
+	// The cohort of a user is the month their first paid subscription started in, they are retained in every
+	// month one of their paid subscriptions ran in
+	var cells []model.SubscriptionRetentionCell
+	if err := db.Raw(`
+		WITH firsts AS (
+			SELECT user_id, date_trunc('month', MIN(start_date) AT TIME ZONE 'UTC') AS cohort
+			FROM user_subscriptions
+			WHERE `+paidSubscriptions+`
+			GROUP BY user_id
+		), running AS (
+			SELECT DISTINCT user_subscriptions.user_id, months.month
+			FROM user_subscriptions
+			CROSS JOIN LATERAL generate_series(
+				date_trunc('month', user_subscriptions.start_date AT TIME ZONE 'UTC'),
+				LEAST(date_trunc('month', user_subscriptions.end_date AT TIME ZONE 'UTC'), date_trunc('month', ? AT TIME ZONE 'UTC')),
+				interval '1 month') AS months(month)
+			WHERE `+paidSubscriptions+`
+		)
+		SELECT firsts.cohort,
+			(EXTRACT(YEAR FROM age(running.month, firsts.cohort)) * 12 + EXTRACT(MONTH FROM age(running.month, firsts.cohort)))::int AS "offset",
+			COUNT(*) AS users
+		FROM firsts
+		JOIN running ON running.user_id = firsts.user_id AND running.month >= firsts.cohort
+		WHERE firsts.cohort >= ? AND firsts.cohort < ? AND running.month <= firsts.cohort + make_interval(months => ?)
+		GROUP BY firsts.cohort, "offset"`,
+		now, from, end, query.Months).
+		Scan(&cells).Error; err != nil {
+		return nil, err
+	}
+
+	// A subscription churns when it ends without another paid one running on. Only the ones that ended by now
+	// are rated, the lifetime of a churned subscriber is the days their paid subscriptions covered until then,
+	// days covered by overlapping subscriptions count once.
+	var plans []model.PlanChurnRow
+	if err := db.Raw(`
+		SELECT ended.plan_id, MAX(subscription_plans.name) AS name, COUNT(*) AS ended,
+			COUNT(*) FILTER (WHERE NOT ended.renewed) AS churned,
+			COALESCE(SUM(ended.lifetime_days) FILTER (WHERE NOT ended.renewed), 0) AS lifetime_days
+		FROM (
+			SELECT user_subscriptions.plan_id,
+				EXISTS (SELECT 1 FROM user_subscriptions others
+					WHERE others.user_id = user_subscriptions.user_id AND others.id <> user_subscriptions.id AND `+paidSubscriptionsOf("others")+`
+					AND others.start_date <= user_subscriptions.end_date AND others.end_date > user_subscriptions.end_date) AS renewed,
+				(SELECT SUM(EXTRACT(EPOCH FROM upper(covered) - lower(covered))) / 86400
+					FROM unnest((SELECT range_agg(tstzrange(others.start_date, LEAST(others.end_date, user_subscriptions.end_date)))
+						FROM user_subscriptions others
+						WHERE others.user_id = user_subscriptions.user_id AND `+paidSubscriptionsOf("others")+`
+						AND others.start_date < user_subscriptions.end_date)) AS covered) AS lifetime_days
+			FROM user_subscriptions
+			WHERE `+paidSubscriptions+`
+			AND user_subscriptions.end_date >= ? AND user_subscriptions.end_date < ? AND user_subscriptions.end_date <= ?
+		) ended
+		LEFT JOIN subscription_plans ON subscription_plans.id = ended.plan_id
+		GROUP BY ended.plan_id
+		ORDER BY ended DESC`,
+		from, end, now).
+		Scan(&plans).Error; err != nil {
+		return nil, err
+	}
+
+	return model.BuildSubscriptionRetention(cells, plans, from, to, query.Months, now), nil
+}
+
+// recognizeRevenue writes the recognition schedule of a paid transaction.
+// Gateways can report one payment more than once (capture then settlement), only the first one is recognized.
+func recognizeRevenue(db *gorm.DB, detail *model.TransactionDetail, subscription *model.UserSubscription) error {
+	schedule := model.BuildRecognitionSchedule(detail, subscription)
+	if len(schedule) == 0 {
+		return nil
+	}
+
+	if detail.OrderID != "" {
+		var recognized int64
+		if err := db.Model(&model.RevenueRecognition{}).
+			Joins("JOIN transaction_details ON transaction_details.id = revenue_recognitions.transaction_detail_id").
+			Where("transaction_details.order_id = ?", detail.OrderID).
+			Count(&recognized).Error; err != nil {
+			return err
+		}
+		if recognized > 0 {
+			return nil
+		}
+	}
+
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&schedule)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Already recognized, the event was appended with it
+		return nil
+	}
+
+	var amount int
+	for _, recognition := range schedule {
+		amount += recognition.Amount
+	}
+	if err := appendEvent(db, model.EventRevenueRecognized, subscription.UserID, detail.ID,
+		model.EventPayload{Amount: amount, Currency: schedule[0].Currency}, schedule[0].PaidAt); err != nil {
+		return err
+	}
+
+	// Conversion values are summed in Rupiah
+	value := int64(0)
+	if schedule[0].Currency == model.CurrencyIDR {
+		value = int64(amount)
+	}
+	if err := recordConversion(db, subscription.UserID, model.ConversionPurchase, value, schedule[0].PaidAt); err != nil {
+		return err
+	}
+
+	return recountLifetimeSpend(db, subscription.UserID)
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type RoleService interface {
+	// GetRoles lists the built-in roles, then the custom ones, with their rights and how many users hold them
+	GetRoles(c *fiber.Ctx) ([]model.RoleView, error)
+	GetRole(c *fiber.Ctx, name string) (*model.RoleView, error)
+	// GetRights lists the rights a custom role can grant, in alphabetical order
+	GetRights() []string
+	// CreateRole, UpdateRole and DeleteRole change the custom roles. This instance applies the change right away,
+	// the others on their next Reload.
+	CreateRole(c *fiber.Ctx, req *validation.CreateRole) (*model.Role, error)
+	UpdateRole(c *fiber.Ctx, name string, req *validation.UpdateRole) (*model.Role, error)
+	DeleteRole(c *fiber.Ctx, name string) error
+	// AssignRole gives a user a built-in or custom role, admins cannot change their own
+	AssignRole(c *fiber.Ctx, adminID, userID uuid.UUID, req *validation.AssignRole) (*model.User, error)
+
+	// Reload loads the custom roles the permission checks read
+	Reload(ctx context.Context) error
+}
+
+type roleService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewRoleService(db *gorm.DB, validate *validator.Validate) RoleService {
+	return &roleService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *roleService) GetRoles(c *fiber.Ctx) ([]model.RoleView, error) {
+	var roles []model.Role
+	if err := s.DB.WithContext(c.UserContext()).Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	users, err := s.countHolders(c.UserContext(), "")
+	if err != nil {
+		return nil, err
+	}
+
+	builtIn := slices.Clone(config.Roles)
+	slices.Sort(builtIn)
+
+	views := make([]model.RoleView, 0, len(builtIn)+len(roles))
+	for _, name := range builtIn {
+		views = append(views, model.RoleView{
+			Name: name, Rights: config.RoleRights[name], BuiltIn: true, Users: users[name],
+		})
+	}
+	for _, role := range roles {
+		views = append(views, model.RoleView{
+			Name: role.Name, Description: role.Description, Rights: role.Rights, Users: users[role.Name],
+		})
+	}
+	return views, nil
+}
+
+func (s *roleService) GetRole(c *fiber.Ctx, name string) (*model.RoleView, error) {
+	view := &model.RoleView{Name: name}
+	if rights, ok := config.RoleRights[name]; ok {
+		view.Rights = rights
+		view.BuiltIn = true
+	} else {
+		role := new(model.Role)
+		if err := s.DB.WithContext(c.UserContext()).First(role, "name = ?", name).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fiber.NewError(fiber.StatusNotFound, "Role not found")
+			}
+			return nil, err
+		}
+		view.Description = role.Description
+		view.Rights = role.Rights
+	}
+
+	users, err := s.countHolders(c.UserContext(), name)
+	if err != nil {
+		return nil, err
+	}
+	view.Users = users[name]
+	return view, nil
+}
+
+func (s *roleService) GetRights() []string {
+	rights := slices.Clone(config.Rights)
+	slices.Sort(rights)
+	return rights
+}
+
+func (s *roleService) CreateRole(c *fiber.Ctx, req *validation.CreateRole) (*model.Role, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if !model.ValidRoleName(name) {
+		return nil, fiber.NewError(fiber.StatusBadRequest,
+			"Role name must be 3 to 50 lowercase letters, digits and underscores, starting with a letter, and not rights")
+	}
+	if _, ok := config.RoleRights[name]; ok {
+		return nil, fiber.NewError(fiber.StatusConflict, fmt.Sprintf("%s is a built-in role", name))
+	}
+	rights, err := knownRights(req.Rights)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &model.Role{Name: name, Description: req.Description, Rights: rights}
+	if err := s.DB.WithContext(c.UserContext()).Create(role).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, fiber.NewError(fiber.StatusConflict, fmt.Sprintf("Role %s already exists", name))
+		}
+		return nil, err
+	}
+
+	s.apply(c.UserContext())
+	return role, nil
+}
+
+func (s *roleService) UpdateRole(c *fiber.Ctx, name string, req *validation.UpdateRole) (*model.Role, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+	if _, ok := config.RoleRights[name]; ok {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Built-in roles cannot be changed")
+	}
+	if req.Rights != nil && len(req.Rights) == 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "A role must grant at least one right")
+	}
+
+	role := new(model.Role)
+	if err := s.DB.WithContext(c.UserContext()).First(role, "name = ?", name).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Role not found")
+		}
+		return nil, err
+	}
+
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if req.Rights != nil {
+		rights, err := knownRights(req.Rights)
+		if err != nil {
+			return nil, err
+		}
+		role.Rights = rights
+	}
+	if err := s.DB.WithContext(c.UserContext()).Save(role).Error; err != nil {
+		return nil, err
+	}
+
+	s.apply(c.UserContext())
+	return role, nil
+}
+
+func (s *roleService) DeleteRole(c *fiber.Ctx, name string) error {
+	if _, ok := config.RoleRights[name]; ok {
+		return fiber.NewError(fiber.StatusBadRequest, "Built-in roles cannot be deleted")
+	}
+
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		var holders int64
+		if err := tx.Model(&model.User{}).Where("role = ?", name).Count(&holders).Error; err != nil {
+			return err
+		}
+		if holders > 0 {
+			return fiber.NewError(fiber.StatusConflict,
+				fmt.Sprintf("%d users hold the role, assign them another one first", holders))
+		}
+
+		result := tx.Where("name = ?", name).Delete(&model.Role{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fiber.NewError(fiber.StatusNotFound, "Role not found")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.apply(c.UserContext())
+	return nil
+}
+
+func (s *roleService) AssignRole(c *fiber.Ctx, adminID, userID uuid.UUID, req *validation.AssignRole) (*model.User, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+	if adminID == userID {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "You cannot change your own role")
+	}
+
+	user := new(model.User)
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		// The role is checked against the database, another instance may have created it since the last Reload
+		if _, ok := config.RoleRights[req.Role]; !ok {
+			var custom int64
+			if err := tx.Model(&model.Role{}).Where("name = ?", req.Role).Count(&custom).Error; err != nil {
+				return err
+			}
+			if custom == 0 {
+				return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Unknown role %q", req.Role))
+			}
+		}
+
+		if err := tx.First(user, "id = ?", userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, "User not found")
+			}
+			return err
+		}
+		if user.Role == req.Role {
+			return nil
+		}
+
+		if err := tx.Model(&model.User{}).Where("id = ?", user.ID).Update("role", req.Role).Error; err != nil {
+			return err
+		}
+		user.Role = req.Role
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *roleService) Reload(ctx context.Context) error {
+	var roles []model.Role
+	if err := s.DB.WithContext(ctx).Find(&roles).Error; err != nil {
+		return err
+	}
+
+	custom, ignored := model.CustomRoleRights(roles, config.RoleRights)
+	for _, name := range ignored {
+		s.Log.Warnf("Ignoring custom role %s, it is a built-in role", name)
+	}
+	config.SetCustomRoles(custom)
+	return nil
+}
+
+// apply reloads the roles after a change, the change is saved and reaches this instance on the next Reload
+// when it fails
+func (s *roleService) apply(ctx context.Context) {
+	if err := s.Reload(ctx); err != nil {
+		s.Log.Errorf("Failed to reload the roles: %v", err)
+	}
+}
+
+// countHolders returns how many users hold each role, or only role when it is not empty
+func (s *roleService) countHolders(ctx context.Context, role string) (map[string]int64, error) {
+	db := s.DB.WithContext(ctx).Model(&model.User{})
+	if role != "" {
+		db = db.Where("role = ?", role)
+	}
+
+	var holders []struct {
+		Role  string
+		Users int64
+	}
+	if err := db.Select("role, COUNT(*) AS users").Group("role").Scan(&holders).Error; err != nil {
+		return nil, err
+	}
+	users := make(map[string]int64, len(holders))
+	for _, holder := range holders {
+		users[holder.Role] = holder.Users
+	}
+	return users, nil
+}
+
+// knownRights returns rights sorted without duplicates, refusing the ones no role can grant
+func knownRights(rights []string) ([]string, error) {
+	normalized, unknown := model.NormalizeRights(rights, config.Rights)
+	if len(unknown) > 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Unknown rights: %s", strings.Join(unknown, ", ")))
+	}
+	return normalized, nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RuntimeConfigService interface {
+	GetConfig(c *fiber.Ctx) ([]model.RuntimeConfigFlag, error)
+	// UpdateConfig overrides runtime flags, or removes their override, and records the change. This instance
+	// applies it right away, the others on their next Reload.
+	UpdateConfig(c *fiber.Ctx, adminID uuid.UUID, req *validation.UpdateRuntimeConfig) ([]model.RuntimeConfigFlag, error)
+	GetHistory(c *fiber.Ctx, query *validation.ConfigChangeQuery) ([]model.ConfigChange, int64, error)
+
+	// Reload applies the saved overrides, flags without one return to the environment's value
+	Reload(ctx context.Context) error
+}
+
+type runtimeConfigService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+func NewRuntimeConfigService(db *gorm.DB, validate *validator.Validate) RuntimeConfigService {
+	return &runtimeConfigService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+func (s *runtimeConfigService) GetConfig(c *fiber.Ctx) ([]model.RuntimeConfigFlag, error) {
+	overrides, err := s.overrides(s.DB.WithContext(c.UserContext()))
+	if err != nil {
+		return nil, err
+	}
+
+	flags := make([]model.RuntimeConfigFlag, 0, len(config.RuntimeFlags))
+	for _, flag := range config.RuntimeFlags {
+		view := model.RuntimeConfigFlag{
+			Key:         flag.Key,
+			Description: flag.Description,
+			Kind:        flag.Kind,
+			Value:       flag.Value(),
+			Default:     flag.Default,
+		}
+		if flag.Kind == config.RuntimeFlagInt {
+			low, high := flag.Min, flag.Max
+			view.Min, view.Max = &low, &high
+		}
+		if override, ok := overrides[flag.Key]; ok {
+			view.Overridden = true
+			view.UpdatedByID = override.UpdatedByID
+			updatedAt := override.UpdatedAt
+			view.UpdatedAt = &updatedAt
+		}
+		flags = append(flags, view)
+	}
+
+	return flags, nil
+}
+
+func (s *runtimeConfigService) UpdateConfig(c *fiber.Ctx, adminID uuid.UUID, req *validation.UpdateRuntimeConfig) ([]model.RuntimeConfigFlag, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(req.Values))
+	for key, value := range req.Values {
+		flag, ok := config.LookupRuntimeFlag(key)
+		if !ok {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Unknown configuration flag %q", key))
+		}
+		if value != nil {
+			if err := flag.Check(strings.TrimSpace(*value)); err != nil {
+				return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+			}
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	now := time.Now()
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		overrides, err := s.overrides(tx.Clauses(clause.Locking{Strength: "UPDATE"}))
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			flag, _ := config.LookupRuntimeFlag(key)
+			oldValue := flag.Default
+			if override, ok := overrides[key]; ok {
+				oldValue = override.Value
+			}
+
+			change := model.ConfigChange{
+				Key:         key,
+				OldValue:    oldValue,
+				Reason:      req.Reason,
+				ChangedByID: adminID,
+				ChangedAt:   now,
+			}
+			if value := req.Values[key]; value != nil {
+				change.NewValue = strings.TrimSpace(*value)
+				if err := tx.Clauses(clause.OnConflict{
+					Columns:   []clause.Column{{Name: "key"}},
+					DoUpdates: clause.AssignmentColumns([]string{"value", "updated_by_id", "updated_at"}),
+				}).Create(&model.SystemSetting{
+					Key:         model.SettingConfigPrefix + key,
+					Value:       change.NewValue,
+					UpdatedByID: &adminID,
+				}).Error; err != nil {
+					return err
+				}
+			} else {
+				if _, ok := overrides[key]; !ok {
+					continue
+				}
+				change.NewValue = flag.Default
+				change.Reset = true
+				if err := tx.Delete(&model.SystemSetting{}, "key = ?", model.SettingConfigPrefix+key).Error; err != nil {
+					return err
+				}
+			}
+
+			if change.NewValue == change.OldValue && !change.Reset {
+				continue
+			}
+			if err := tx.Create(&change).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Reload(c.UserContext()); err != nil {
+		return nil, err
+	}
+
+	return s.GetConfig(c)
+}
+
+func (s *runtimeConfigService) GetHistory(c *fiber.Ctx, query *validation.ConfigChangeQuery) ([]model.ConfigChange, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	db := s.DB.WithContext(c.UserContext()).Model(&model.ConfigChange{})
+	if query.Key != "" {
+		db = db.Where("key = ?", query.Key)
+	}
+
+	var totalResults int64
+	if err := db.Count(&totalResults).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var changes []model.ConfigChange
+	if err := db.
+		Order("changed_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&changes).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return changes, totalResults, nil
+}
+
+func (s *runtimeConfigService) Reload(ctx context.Context) error {
+	overrides, err := s.overrides(s.DB.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	for _, flag := range config.RuntimeFlags {
+		value := flag.Default
+		if override, ok := overrides[flag.Key]; ok {
+			value = override.Value
+		}
+		if value == flag.Value() {
+			continue
+		}
+		// A saved value that no longer passes the checks keeps the flag as it is
+		if err := flag.Apply(value); err != nil {
+			s.Log.Errorf("Ignoring configuration override of %s: %v", flag.Key, err)
+			continue
+		}
+		s.Log.Infof("Configuration flag %s set to %s", flag.Key, value)
+	}
+
+	return nil
+}
+
+// overrides returns the saved overrides by flag key
+func (s *runtimeConfigService) overrides(db *gorm.DB) (map[string]model.SystemSetting, error) {
+	var settings []model.SystemSetting
+	if err := db.Where("key LIKE ?", model.SettingConfigPrefix+"%").Find(&settings).Error; err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]model.SystemSetting, len(settings))
+	for _, setting := range settings {
+		overrides[strings.TrimPrefix(setting.Key, model.SettingConfigPrefix)] = setting
+	}
+	return overrides, nil
+}
@@ -0,0 +1,485 @@
+package service
+
+import (
+	"app/src/clock"
+	"app/src/config"
+	"app/src/model"
+	"app/src/redis"
+	"app/src/utils"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Redis keys of the scan counters. A pending counter holds the scans of a subscription not yet added
+// to ai_scans_used, the dirty set lists the subscriptions that have one.
+const (
+	scanQuotaPendingKey = "scan_quota:pending:"
+	scanQuotaDirtyKey   = "scan_quota:dirty"
+
+	// scanQuotaFlushLockKey is held by the instance flushing, so two instances never write the same scans
+	scanQuotaFlushLockKey = "scan_quota:flush_lock"
+	scanQuotaFlushLockTTL = time.Minute
+
+	// The quota of the active subscription of a user is cached under the first key, the second one moves on
+	// whenever the cached quota is dropped
+	scanQuotaCacheKey      = "scan_quota:quota:"
+	scanQuotaGenerationKey = "scan_quota:generation:"
+	// scanQuotaNone is cached for a user without a subscription to count against
+	scanQuotaNone = "none"
+)
+
+// consumeScanScript takes one scan unless the scans in the database plus the pending ones reached the limit.
+// ARGV: limit (-1 for unlimited), scans used in the database, subscription ID. Returns the pending count or -1.
+const consumeScanScript = `
+local pending = tonumber(redis.call('GET', KEYS[1]) or '0')
+local limit = tonumber(ARGV[1])
+if limit >= 0 and tonumber(ARGV[2]) + pending >= limit then
+	return -1
+end
+pending = redis.call('INCR', KEYS[1])
+redis.call('SADD', KEYS[2], ARGV[3])
+return pending`
+
+// refundScanScript gives a scan back, the flush subtracts it when it was already written
+const refundScanScript = `
+redis.call('DECR', KEYS[1])
+redis.call('SADD', KEYS[2], ARGV[1])
+return 1`
+
+// takePendingScansScript empties the pending counter of a subscription and returns what it held
+const takePendingScansScript = `
+local pending = redis.call('GET', KEYS[1]) or '0'
+redis.call('DEL', KEYS[1])
+redis.call('SREM', KEYS[2], ARGV[1])
+return pending`
+
+// flushedScansScript subtracts the scans written to the database from the pending counter, keeping the scans
+// taken since it was read, and drops the counter once it is empty. With the generation and quota keys of the
+// user it also drops their cached quota, whose scans used are now short of the ones written.
+// ARGV: scans written, subscription ID, TTL of the generation in milliseconds.
+const flushedScansScript = `
+local left = redis.call('DECRBY', KEYS[1], ARGV[1])
+if left == 0 then
+	redis.call('DEL', KEYS[1])
+	redis.call('SREM', KEYS[2], ARGV[2])
+end
+if #KEYS == 4 then
+	redis.call('INCR', KEYS[3])
+	redis.call('PEXPIRE', KEYS[3], ARGV[3])
+	redis.call('DEL', KEYS[4])
+end
+return left`
+
+// cacheQuotaScript caches the quota of a user read from the database, unless it was dropped since the
+// generation given was read: the quota read may predate the change that dropped it.
+// ARGV: generation, quota, TTL in milliseconds.
+const cacheQuotaScript = `
+if (redis.call('GET', KEYS[1]) or '0') ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[2], ARGV[2], 'PX', ARGV[3])
+return 1`
+
+// dropQuotaScript drops the cached quota of a user and moves their generation on.
+// ARGV: TTL of the generation in milliseconds.
+const dropQuotaScript = `
+redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+redis.call('DEL', KEYS[2])
+return 1`
+
+// releaseLockScript deletes a lock only when it is still held with the token of the caller
+const releaseLockScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`
+
+type ScanQuotaService interface {
+	// Consume takes one scan from the user's active subscription. It returns a nil quota when the user has no
+	// subscription to count against, and consumed false when the quota is exhausted.
+	Consume(c *fiber.Ctx, userID uuid.UUID) (quota *model.ScanQuota, consumed bool, err error)
+	// Allowed tells, without taking a scan, whether Consume would let the user scan now: users without a
+	// subscription scan unmetered and get a nil quota, subscribers until their quota, pending scans included, is used
+	Allowed(c *fiber.Ctx, userID uuid.UUID) (quota *model.ScanQuota, allowed bool, err error)
+	// Refund gives back a scan taken by Consume, for scans that failed
+	Refund(ctx context.Context, quota *model.ScanQuota)
+
+	// Usage returns what the user used of the scans of their active subscription, scans pending in Redis included
+	Usage(c *fiber.Ctx, userID uuid.UUID) (*model.ScanUsage, error)
+	// Reset starts the scan count of a subscription over, for a store subscription renewed in place. The
+	// caller resets ai_scans_used, the scans pending in Redis and the cached quota are dropped.
+	Reset(ctx context.Context, subscriptionID uuid.UUID)
+	// Invalidate drops the cached quota of a user, their subscriptions changed
+	Invalidate(ctx context.Context, userID uuid.UUID)
+
+	// Pending returns the scans of a subscription counted in Redis and not flushed yet,
+	// enabled is false when scans are counted in the database directly
+	Pending(ctx context.Context, subscriptionID uuid.UUID) (pending int64, enabled bool, err error)
+
+	// Flush adds the scans counted in Redis to ai_scans_used. It runs periodically and on startup,
+	// which also writes the counts left behind by instances that stopped before their flush.
+	Flush(ctx context.Context) error
+}
+
+type scanQuotaService struct {
+	Log   *logrus.Logger
+	DB    *gorm.DB
+	Redis *redis.Client
+}
+
+// NewScanQuotaService counts scans in Redis when a client is given, otherwise in Postgres on every scan. With
+// Redis, the quota of the active subscription of a user is cached for SCAN_QUOTA_CACHE_TTL, or until it ends.
+func NewScanQuotaService(db *gorm.DB, redisClient *redis.Client) ScanQuotaService {
+	return &scanQuotaService{
+		Log:   utils.Log,
+		DB:    db,
+		Redis: redisClient,
+	}
+}
+
+// activeQuota returns the quota of the active subscription of the user with the scans written to the database,
+// nil when the user has no subscription to count against. It is read from the cache when Redis has it.
+func (s *scanQuotaService) activeQuota(c *fiber.Ctx, userID uuid.UUID) (*model.ScanQuota, error) {
+	ctx := c.UserContext()
+	if s.Redis == nil {
+		quota, _, err := s.loadQuota(ctx, userID)
+		return quota, err
+	}
+
+	quota, generation, cached, err := s.cachedQuota(ctx, userID)
+	if err != nil {
+		s.Log.Warnf("Failed to read cached scan quota of user %s, reading the database: %v", userID, err)
+		quota, _, err := s.loadQuota(ctx, userID)
+		return quota, err
+	}
+	if cached {
+		return quota, nil
+	}
+
+	quota, endDate, err := s.loadQuota(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.cacheQuota(ctx, userID, generation, quota, endDate)
+	return quota, nil
+}
+
+// loadQuota reads the quota of the active subscription of the user and when it ends from the database
+func (s *scanQuotaService) loadQuota(ctx context.Context, userID uuid.UUID) (*model.ScanQuota, *time.Time, error) {
+	var subscription model.UserSubscription
+	result := s.DB.WithContext(ctx).
+		Preload("Plan").
+		Scopes(activeSubscription(userID)).
+		Limit(1).
+		Find(&subscription)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, nil
+	}
+
+	return &model.ScanQuota{
+		SubscriptionID: subscription.ID,
+		Limit:          subscription.PurchasedPlan().AIscanLimit,
+		Used:           subscription.AIscansUsed,
+	}, &subscription.EndDate, nil
+}
+
+// cachedQuota returns the cached quota of a user, cached false when there is none. The generation is what
+// cacheQuota takes after a miss.
+func (s *scanQuotaService) cachedQuota(ctx context.Context, userID uuid.UUID) (quota *model.ScanQuota, generation string, cached bool, err error) {
+	generation = "0"
+	values, err := s.Redis.Do(ctx, "MGET", scanQuotaGenerationKey+userID.String(), scanQuotaCacheKey+userID.String())
+	if err != nil {
+		return nil, generation, false, err
+	}
+	reply, ok := values.([]any)
+	if !ok || len(reply) != 2 {
+		return nil, generation, false, fmt.Errorf("redis: unexpected reply %T", values)
+	}
+	if value, ok := reply[0].(string); ok {
+		generation = value
+	}
+
+	value, ok := reply[1].(string)
+	if !ok {
+		return nil, generation, false, nil
+	}
+	if value == scanQuotaNone {
+		return nil, generation, true, nil
+	}
+
+	// The subscription ID, the limit and the scans used, separated by spaces
+	fields := strings.Fields(value)
+	if len(fields) != 3 {
+		return nil, generation, false, fmt.Errorf("malformed cached quota %q", value)
+	}
+	quota = new(model.ScanQuota)
+	if quota.SubscriptionID, err = uuid.Parse(fields[0]); err != nil {
+		return nil, generation, false, err
+	}
+	if quota.Limit, err = strconv.Atoi(fields[1]); err != nil {
+		return nil, generation, false, err
+	}
+	if quota.Used, err = strconv.Atoi(fields[2]); err != nil {
+		return nil, generation, false, err
+	}
+	return quota, generation, true, nil
+}
+
+// cacheQuota caches the quota of a user read after generation, until the subscription ends at the latest
+func (s *scanQuotaService) cacheQuota(ctx context.Context, userID uuid.UUID, generation string, quota *model.ScanQuota, endDate *time.Time) {
+	ttl := config.ScanQuotaCacheTTL
+	value := scanQuotaNone
+	if quota != nil {
+		ttl = min(ttl, endDate.Sub(clock.Now(ctx)))
+		value = fmt.Sprintf("%s %d %d", quota.SubscriptionID, quota.Limit, quota.Used)
+	}
+	if ttl.Milliseconds() <= 0 {
+		return
+	}
+
+	if _, err := s.Redis.Eval(ctx, cacheQuotaScript,
+		[]string{scanQuotaGenerationKey + userID.String(), scanQuotaCacheKey + userID.String()},
+		generation, value, ttl.Milliseconds()); err != nil {
+		s.Log.Warnf("Failed to cache scan quota of user %s: %v", userID, err)
+	}
+}
+
+// generationTTL outlives the quotas cached before the generation moved on
+func generationTTL() int64 {
+	return 2 * config.ScanQuotaCacheTTL.Milliseconds()
+}
+
+func (s *scanQuotaService) Consume(c *fiber.Ctx, userID uuid.UUID) (*model.ScanQuota, bool, error) {
+	quota, err := s.activeQuota(c, userID)
+	if err != nil || quota == nil {
+		return nil, false, err
+	}
+
+	if s.Redis != nil {
+		pending, err := redis.Int(s.Redis.Eval(c.UserContext(), consumeScanScript,
+			[]string{scanQuotaPendingKey + quota.SubscriptionID.String(), scanQuotaDirtyKey},
+			quota.Limit, quota.Used, quota.SubscriptionID))
+		if err == nil {
+			if pending < 0 {
+				return quota, false, nil
+			}
+			quota.Used += int(pending)
+			return quota, true, nil
+		}
+		s.Log.Warnf("Failed to count scan in Redis, counting in the database: %v", err)
+	}
+
+	if quota.Exhausted() {
+		return quota, false, nil
+	}
+
+	result := s.DB.WithContext(c.UserContext()).
+		Model(&model.UserSubscription{}).
+		Where("id = ?", quota.SubscriptionID).
+		Where("? < 0 OR ai_scans_used < ?", quota.Limit, quota.Limit).
+		Update("ai_scans_used", gorm.Expr("ai_scans_used + 1"))
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return quota, false, nil
+	}
+	// The cached quota does not count the scan
+	s.Invalidate(c.UserContext(), userID)
+
+	quota.Used++
+	return quota, true, nil
+}
+
+func (s *scanQuotaService) Allowed(c *fiber.Ctx, userID uuid.UUID) (*model.ScanQuota, bool, error) {
+	quota, err := s.activeQuota(c, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if quota == nil {
+		return nil, true, nil
+	}
+
+	// Consume counts in the database when Redis fails, which holds every scan but the unflushed ones
+	pending, enabled, err := s.Pending(c.UserContext(), quota.SubscriptionID)
+	if err == nil && enabled {
+		quota.Used = max(quota.Used+int(pending), 0)
+	}
+
+	return quota, !quota.Exhausted(), nil
+}
+
+func (s *scanQuotaService) Refund(ctx context.Context, quota *model.ScanQuota) {
+	if s.Redis != nil {
+		_, err := s.Redis.Eval(ctx, refundScanScript,
+			[]string{scanQuotaPendingKey + quota.SubscriptionID.String(), scanQuotaDirtyKey},
+			quota.SubscriptionID)
+		if err == nil {
+			return
+		}
+		s.Log.Warnf("Failed to refund scan in Redis, refunding in the database: %v", err)
+	}
+
+	if err := s.DB.WithContext(ctx).
+		Model(&model.UserSubscription{}).
+		Where("id = ?", quota.SubscriptionID).
+		Update("ai_scans_used", gorm.Expr("GREATEST(ai_scans_used - 1, 0)")).Error; err != nil {
+		s.Log.Errorf("Failed to refund scan of subscription %s: %v", quota.SubscriptionID, err)
+	}
+}
+
+func (s *scanQuotaService) Usage(c *fiber.Ctx, userID uuid.UUID) (*model.ScanUsage, error) {
+	var subscription model.UserSubscription
+	result := s.DB.WithContext(c.UserContext()).
+		Preload("Plan").
+		Scopes(activeSubscription(userID)).
+		Limit(1).
+		Find(&subscription)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fiber.NewError(fiber.StatusNotFound, "No active subscription found")
+	}
+
+	plan := subscription.PurchasedPlan()
+	quota := &model.ScanQuota{
+		SubscriptionID: subscription.ID,
+		Limit:          plan.AIscanLimit,
+		Used:           subscription.AIscansUsed,
+	}
+
+	pending, enabled, err := s.Pending(c.UserContext(), subscription.ID)
+	if err != nil {
+		// The scans flushed to the database are still shown
+		s.Log.Warnf("Failed to read pending scans of subscription %s: %v", subscription.ID, err)
+	} else if enabled {
+		quota.Used = max(quota.Used+int(pending), 0)
+	}
+
+	usage := quota.Usage(plan.Name, subscription.StartDate, subscription.EndDate)
+	return &usage, nil
+}
+
+func (s *scanQuotaService) Reset(ctx context.Context, subscriptionID uuid.UUID) {
+	if s.Redis == nil {
+		return
+	}
+
+	if _, err := s.Redis.Eval(ctx, takePendingScansScript,
+		[]string{scanQuotaPendingKey + subscriptionID.String(), scanQuotaDirtyKey},
+		subscriptionID); err != nil {
+		s.Log.Errorf("Failed to reset pending scans of subscription %s: %v", subscriptionID, err)
+	}
+
+	var subscription model.UserSubscription
+	if err := s.DB.WithContext(ctx).Select("user_id").First(&subscription, "id = ?", subscriptionID).Error; err != nil {
+		s.Log.Errorf("Failed to find the user of subscription %s to drop their cached scan quota: %v", subscriptionID, err)
+		return
+	}
+	s.Invalidate(ctx, subscription.UserID)
+}
+
+func (s *scanQuotaService) Invalidate(ctx context.Context, userID uuid.UUID) {
+	if s.Redis == nil {
+		return
+	}
+
+	if _, err := s.Redis.Eval(ctx, dropQuotaScript,
+		[]string{scanQuotaGenerationKey + userID.String(), scanQuotaCacheKey + userID.String()},
+		generationTTL()); err != nil {
+		s.Log.Errorf("Failed to drop cached scan quota of user %s: %v", userID, err)
+	}
+}
+
+func (s *scanQuotaService) Pending(ctx context.Context, subscriptionID uuid.UUID) (int64, bool, error) {
+	if s.Redis == nil {
+		return 0, false, nil
+	}
+
+	pending, err := redis.Int(s.Redis.Do(ctx, "GET", scanQuotaPendingKey+subscriptionID.String()))
+	return pending, true, err
+}
+
+func (s *scanQuotaService) Flush(ctx context.Context) error {
+	if s.Redis == nil {
+		return nil
+	}
+
+	// Another instance is flushing, its scans would be written twice
+	token := uuid.NewString()
+	locked, err := s.Redis.Do(ctx, "SET", scanQuotaFlushLockKey, token, "NX", "PX", scanQuotaFlushLockTTL.Milliseconds())
+	if err != nil || locked == nil {
+		return err
+	}
+	defer func() {
+		if _, err := s.Redis.Eval(context.WithoutCancel(ctx), releaseLockScript, []string{scanQuotaFlushLockKey}, token); err != nil {
+			s.Log.Warnf("Failed to release the scan flush lock: %v", err)
+		}
+	}()
+
+	ids, err := redis.Strings(s.Redis.Do(ctx, "SMEMBERS", scanQuotaDirtyKey))
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	var flushed int
+	for _, id := range ids {
+		keys := []string{scanQuotaPendingKey + id, scanQuotaDirtyKey}
+		pending, err := redis.Int(s.Redis.Do(ctx, "GET", keys[0]))
+		if err != nil {
+			return err
+		}
+
+		// The counter is only lowered once the scans are in the database, until then Consume counts them
+		// from Redis. A failed write leaves them there for the next flush.
+		if pending != 0 {
+			var subscription model.UserSubscription
+			if err := s.DB.WithContext(ctx).
+				Model(&subscription).
+				Clauses(clause.Returning{Columns: []clause.Column{{Name: "user_id"}}}).
+				Where("id = ?", id).
+				Update("ai_scans_used", gorm.Expr("GREATEST(ai_scans_used + ?, 0)", pending)).Error; err != nil {
+				return err
+			}
+			if subscription.UserID != uuid.Nil {
+				keys = append(keys, scanQuotaGenerationKey+subscription.UserID.String(), scanQuotaCacheKey+subscription.UserID.String())
+			}
+			flushed++
+		}
+
+		if _, err := s.Redis.Eval(ctx, flushedScansScript, keys, pending, id, generationTTL()); err != nil {
+			s.Log.Errorf("Wrote %d scans of subscription %s but failed to lower its counter: %v", pending, id, err)
+			return err
+		}
+	}
+
+	if flushed > 0 {
+		s.Log.Debugf("Flushed scan counters of %d subscriptions in %s", flushed, time.Since(start))
+	}
+	return nil
+}
+
+// activeSubscription selects the paid, current subscription of a user, expiry follows the clock of the query context.
+// Renewals are paid ahead and only apply once they start.
+func activeSubscription(userID uuid.UUID) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		now := clock.Now(db.Statement.Context)
+		return db.Where("user_subscriptions.user_id = ? AND user_subscriptions.start_date <= ? AND user_subscriptions.end_date > ? AND user_subscriptions.is_active = ? AND user_subscriptions.payment_status = ?",
+			userID, now, now, true, "success")
+	}
+}
@@ -0,0 +1,440 @@
+package service
+
+import (
+	"app/src/grpc"
+	"app/src/model"
+	"app/src/searchindex"
+	"app/src/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// searchIndexWriteTimeout bounds the indexing that follows a write
+	searchIndexWriteTimeout = 5 * time.Second
+
+	// searchIndexBatchSize is the number of documents of a bulk request when reindexing
+	searchIndexBatchSize = 500
+
+	// searchReindexTimeout bounds a whole reindex, its requests may take longer than searches
+	searchReindexTimeout = 10 * time.Minute
+)
+
+// ErrSearchIndexUnavailable is returned by searches when no index is configured or it is down, callers
+// search the database instead
+var ErrSearchIndexUnavailable = errors.New("search index unavailable")
+
+type SearchIndexService interface {
+	// Enabled reports whether a search index is configured
+	Enabled() bool
+	Health(ctx context.Context) error
+
+	// IndexFood, IndexArticle, IndexRecipe and RemoveContent update the index after a write. They neither
+	// wait for the index nor fail, the next reindex repairs what they miss.
+	IndexFood(ctx context.Context, kode string)
+	IndexArticle(ctx context.Context, id uuid.UUID)
+	IndexRecipe(ctx context.Context, id uuid.UUID)
+	RemoveContent(ctx context.Context, contentType string, id uuid.UUID)
+	// Reindex rebuilds the indexes from the food service and the database, dropping documents whose
+	// source is gone
+	Reindex(ctx context.Context) error
+	// RefreshFoodCatalog copies the foods of the food service into the database, food search matches
+	// them there while the index is unavailable
+	RefreshFoodCatalog(ctx context.Context) error
+
+	// SearchFoods returns the foods whose names match query, typos included, best first
+	SearchFoods(ctx context.Context, query string, size int) ([]model.FoodDocument, error)
+	// SearchContent returns published articles and recipes matching query, of one type when contentType is set
+	SearchContent(ctx context.Context, query, contentType string, size int) ([]model.ContentSearchResult, error)
+}
+
+type searchIndexService struct {
+	Log    *logrus.Logger
+	DB     *gorm.DB
+	Client *searchindex.Client
+	Foods  *grpc.BahanMakananClient
+}
+
+// NewSearchIndexService returns a service that does nothing when client is nil
+func NewSearchIndexService(db *gorm.DB, client *searchindex.Client, foods *grpc.BahanMakananClient) SearchIndexService {
+	return &searchIndexService{
+		Log:    utils.Log,
+		DB:     db,
+		Client: client,
+		Foods:  foods,
+	}
+}
+
+var foodIndexMapping = map[string]any{
+	"mappings": map[string]any{
+		"properties": map[string]any{
+			"kode":       map[string]any{"type": "keyword"},
+			"food":       map[string]any{"type": "object", "enabled": false},
+			"names":      map[string]any{"type": "object", "enabled": false},
+			"names_id":   map[string]any{"type": "text", "analyzer": "indonesian"},
+			"names_en":   map[string]any{"type": "text", "analyzer": "english"},
+			"indexed_at": map[string]any{"type": "date"},
+		},
+	},
+}
+
+var contentIndexMapping = map[string]any{
+	"mappings": map[string]any{
+		"properties": map[string]any{
+			"type":         map[string]any{"type": "keyword"},
+			"id":           map[string]any{"type": "keyword"},
+			"title":        map[string]any{"type": "text"},
+			"slug":         map[string]any{"type": "keyword"},
+			"image":        map[string]any{"type": "keyword", "index": false},
+			"category":     map[string]any{"type": "keyword"},
+			"body":         map[string]any{"type": "text"},
+			"published_at": map[string]any{"type": "date"},
+			"indexed_at":   map[string]any{"type": "date"},
+		},
+	},
+}
+
+func (s *searchIndexService) Enabled() bool {
+	return s.Client != nil
+}
+
+func (s *searchIndexService) Health(ctx context.Context) error {
+	if s.Client == nil {
+		return ErrSearchIndexUnavailable
+	}
+	return s.Client.Health(ctx)
+}
+
+func (s *searchIndexService) IndexFood(ctx context.Context, kode string) {
+	s.write(ctx, "food "+kode, func(ctx context.Context) error {
+		if s.Foods == nil {
+			return errors.New("food service unavailable")
+		}
+		response, err := s.Foods.GetBahanMakananByKode(ctx, kode)
+		if err != nil {
+			return err
+		}
+
+		var names []model.FoodName
+		if err := s.DB.WithContext(ctx).Where("food_kode = ?", kode).Find(&names).Error; err != nil {
+			return err
+		}
+
+		doc := model.NewFoodDocument(ConvertPbToModel(response.BahanMakanan), names, indexTime())
+		return s.Client.Put(ctx, model.SearchIndexFoods, searchindex.Document{ID: doc.Kode, Source: doc})
+	})
+}
+
+func (s *searchIndexService) IndexArticle(ctx context.Context, id uuid.UUID) {
+	s.write(ctx, "article "+id.String(), func(ctx context.Context) error {
+		var article model.Article
+		if err := s.DB.WithContext(ctx).Preload("Category").First(&article, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return s.Client.Delete(ctx, model.SearchIndexContent, model.ContentTypeArticle+":"+id.String())
+			}
+			return err
+		}
+
+		doc := articleDocument(article)
+		return s.Client.Put(ctx, model.SearchIndexContent, searchindex.Document{ID: doc.DocumentID(), Source: doc})
+	})
+}
+
+func (s *searchIndexService) IndexRecipe(ctx context.Context, id uuid.UUID) {
+	s.write(ctx, "recipe "+id.String(), func(ctx context.Context) error {
+		var recipe model.Recipe
+		if err := s.DB.WithContext(ctx).First(&recipe, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return s.Client.Delete(ctx, model.SearchIndexContent, model.ContentTypeRecipe+":"+id.String())
+			}
+			return err
+		}
+
+		doc := model.NewRecipeDocument(recipe, indexTime())
+		return s.Client.Put(ctx, model.SearchIndexContent, searchindex.Document{ID: doc.DocumentID(), Source: doc})
+	})
+}
+
+func (s *searchIndexService) RemoveContent(ctx context.Context, contentType string, id uuid.UUID) {
+	s.write(ctx, contentType+" "+id.String(), func(ctx context.Context) error {
+		return s.Client.Delete(ctx, model.SearchIndexContent, contentType+":"+id.String())
+	})
+}
+
+// write runs an indexing in the background, callers are request handlers the index must not slow down
+func (s *searchIndexService) write(ctx context.Context, what string, index func(ctx context.Context) error) {
+	if s.Client == nil || !s.Client.Available() {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), searchIndexWriteTimeout)
+		defer cancel()
+
+		if err := index(ctx); err != nil {
+			s.Log.Warnf("Failed to index %s, the next reindex will: %v", what, err)
+		}
+	}()
+}
+
+func (s *searchIndexService) Reindex(ctx context.Context) error {
+	if s.Client == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, searchReindexTimeout)
+	defer cancel()
+
+	if err := s.Client.EnsureIndex(ctx, model.SearchIndexFoods, foodIndexMapping); err != nil {
+		return err
+	}
+	if err := s.Client.EnsureIndex(ctx, model.SearchIndexContent, contentIndexMapping); err != nil {
+		return err
+	}
+
+	// Documents not rewritten since start have no source anymore
+	start := indexTime()
+	stale := map[string]any{"range": map[string]any{"indexed_at": map[string]any{"lt": start}}}
+
+	foods, err := s.reindexFoods(ctx, start)
+	if err != nil {
+		// The foods stay as they were, content can still be rebuilt
+		s.Log.Errorf("Failed to reindex foods: %v", err)
+	} else if err := s.Client.DeleteWhere(ctx, model.SearchIndexFoods, stale); err != nil {
+		return err
+	}
+
+	content, err := s.reindexContent(ctx, start)
+	if err != nil {
+		return fmt.Errorf("reindexing content: %w", err)
+	}
+	if err := s.Client.DeleteWhere(ctx, model.SearchIndexContent, stale); err != nil {
+		return err
+	}
+
+	s.Log.Infof("Search index rebuilt: %d foods, %d articles and recipes", foods, content)
+	return nil
+}
+
+func (s *searchIndexService) reindexFoods(ctx context.Context, indexedAt time.Time) (int, error) {
+	if s.Foods == nil {
+		return 0, errors.New("food service unavailable")
+	}
+	response, err := s.Foods.GetAllBahanMakanan(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var names []model.FoodName
+	if err := s.DB.WithContext(ctx).Find(&names).Error; err != nil {
+		return 0, err
+	}
+	byKode := make(map[string][]model.FoodName)
+	for _, name := range names {
+		byKode[name.FoodKode] = append(byKode[name.FoodKode], name)
+	}
+
+	docs := make([]searchindex.Document, 0, searchIndexBatchSize)
+	for _, pbFood := range response.BahanMakanan {
+		food := ConvertPbToModel(pbFood)
+		docs = append(docs, searchindex.Document{
+			ID:     food.Kode,
+			Source: model.NewFoodDocument(food, byKode[food.Kode], indexedAt),
+		})
+		if len(docs) == searchIndexBatchSize {
+			if err := s.Client.Bulk(ctx, model.SearchIndexFoods, docs); err != nil {
+				return 0, err
+			}
+			docs = docs[:0]
+		}
+	}
+	if err := s.Client.Bulk(ctx, model.SearchIndexFoods, docs); err != nil {
+		return 0, err
+	}
+
+	return len(response.BahanMakanan), nil
+}
+
+func (s *searchIndexService) RefreshFoodCatalog(ctx context.Context) error {
+	if s.Foods == nil {
+		return errors.New("food service unavailable")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, searchReindexTimeout)
+	defer cancel()
+
+	response, err := s.Foods.GetAllBahanMakanan(ctx)
+	if err != nil {
+		return err
+	}
+	if len(response.BahanMakanan) == 0 {
+		// Rather a broken food service than an empty catalog, the mirror stays as it was
+		return errors.New("the food service returned no foods")
+	}
+
+	refreshedAt := time.Now()
+	entries := make([]model.FoodCatalogEntry, 0, len(response.BahanMakanan))
+	for _, pbFood := range response.BahanMakanan {
+		food := ConvertPbToModel(pbFood)
+		entries = append(entries, model.FoodCatalogEntry{
+			Kode:        food.Kode,
+			Name:        food.NamaBahanMakanan,
+			Food:        food,
+			RefreshedAt: refreshedAt,
+		})
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "kode"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "food", "refreshed_at"}),
+		}).CreateInBatches(&entries, searchIndexBatchSize).Error; err != nil {
+			return err
+		}
+		// Foods the food service dropped were not refreshed
+		return tx.Where("refreshed_at < ?", refreshedAt).Delete(&model.FoodCatalogEntry{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Log.Infof("Food catalog refreshed: %d foods", len(entries))
+	return nil
+}
+
+func (s *searchIndexService) reindexContent(ctx context.Context, indexedAt time.Time) (int, error) {
+	total := 0
+
+	var articles []model.Article
+	result := s.DB.WithContext(ctx).Preload("Category").FindInBatches(&articles, searchIndexBatchSize, func(_ *gorm.DB, _ int) error {
+		docs := make([]searchindex.Document, 0, len(articles))
+		for _, article := range articles {
+			doc := articleDocument(article)
+			doc.IndexedAt = indexedAt
+			docs = append(docs, searchindex.Document{ID: doc.DocumentID(), Source: doc})
+		}
+		total += len(docs)
+		return s.Client.Bulk(ctx, model.SearchIndexContent, docs)
+	})
+	if result.Error != nil {
+		return total, result.Error
+	}
+
+	var recipes []model.Recipe
+	result = s.DB.WithContext(ctx).FindInBatches(&recipes, searchIndexBatchSize, func(_ *gorm.DB, _ int) error {
+		docs := make([]searchindex.Document, 0, len(recipes))
+		for _, recipe := range recipes {
+			doc := model.NewRecipeDocument(recipe, indexedAt)
+			docs = append(docs, searchindex.Document{ID: doc.DocumentID(), Source: doc})
+		}
+		total += len(docs)
+		return s.Client.Bulk(ctx, model.SearchIndexContent, docs)
+	})
+	return total, result.Error
+}
+
+func (s *searchIndexService) SearchFoods(ctx context.Context, query string, size int) ([]model.FoodDocument, error) {
+	if s.Client == nil || !s.Client.Available() {
+		return nil, ErrSearchIndexUnavailable
+	}
+
+	hits, err := s.Client.Search(ctx, model.SearchIndexFoods, map[string]any{
+		"bool": map[string]any{
+			"should": []any{
+				map[string]any{"multi_match": map[string]any{
+					"query": query, "fields": []string{"names_id", "names_en"}, "fuzziness": "AUTO",
+				}},
+				map[string]any{"multi_match": map[string]any{
+					"query": query, "fields": []string{"names_id", "names_en"}, "type": "phrase_prefix",
+				}},
+			},
+			"minimum_should_match": 1,
+		},
+	}, size)
+	if err != nil {
+		s.Log.Warnf("Food search falls back to the food service: %v", err)
+		return nil, ErrSearchIndexUnavailable
+	}
+
+	docs := make([]model.FoodDocument, 0, len(hits))
+	for _, hit := range hits {
+		var doc model.FoodDocument
+		if err := json.Unmarshal(hit.Source, &doc); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+func (s *searchIndexService) SearchContent(ctx context.Context, query, contentType string, size int) ([]model.ContentSearchResult, error) {
+	if s.Client == nil || !s.Client.Available() {
+		return nil, ErrSearchIndexUnavailable
+	}
+
+	// Recipes are always published, articles once their publication time passed
+	filters := []any{
+		map[string]any{"bool": map[string]any{
+			"should": []any{
+				map[string]any{"term": map[string]any{"type": model.ContentTypeRecipe}},
+				map[string]any{"range": map[string]any{"published_at": map[string]any{"lte": "now"}}},
+			},
+			"minimum_should_match": 1,
+		}},
+	}
+	if contentType != "" {
+		filters = append(filters, map[string]any{"term": map[string]any{"type": contentType}})
+	}
+
+	hits, err := s.Client.Search(ctx, model.SearchIndexContent, map[string]any{
+		"bool": map[string]any{
+			"must": map[string]any{"multi_match": map[string]any{
+				"query": query, "fields": []string{"title^3", "category^2", "body"}, "fuzziness": "AUTO",
+			}},
+			"filter": filters,
+		},
+	}, size)
+	if err != nil {
+		s.Log.Warnf("Content search falls back to the database: %v", err)
+		return nil, ErrSearchIndexUnavailable
+	}
+
+	results := make([]model.ContentSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		var doc model.ContentDocument
+		if err := json.Unmarshal(hit.Source, &doc); err != nil {
+			return nil, err
+		}
+		results = append(results, model.ContentSearchResult{
+			Type:    doc.Type,
+			ID:      doc.ID,
+			Title:   doc.Title,
+			Slug:    doc.Slug,
+			Image:   (*model.ContentImage)(doc.Image),
+			Snippet: model.ContentSnippet(doc.Body, query),
+			Score:   hit.Score,
+		})
+	}
+	return results, nil
+}
+
+func articleDocument(article model.Article) model.ContentDocument {
+	category := ""
+	if article.Category != nil {
+		category = article.Category.Name
+	}
+	return model.NewArticleDocument(article, category, indexTime())
+}
+
+// indexTime is now at the precision of dates in the index
+func indexTime() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StorageUsageService interface {
+	// Measure records, once a day, the objects and bytes of every storage category: the files under /uploads by
+	// the records referring to them, the diary exports kept in the database and the backups in object storage
+	Measure(ctx context.Context) error
+
+	// GetReport reports the storage per category over the last days with a forecast of its size and cost
+	GetReport(c *fiber.Ctx, query *validation.StorageUsageQuery) (*model.StorageUsageReport, error)
+}
+
+type storageUsageService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+	Dir      string
+}
+
+func NewStorageUsageService(db *gorm.DB, validate *validator.Validate) StorageUsageService {
+	return &storageUsageService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+		Dir:      utils.UploadDir,
+	}
+}
+
+func (s *storageUsageService) Measure(ctx context.Context) error {
+	now := time.Now()
+	claimed, err := claimDailyRun(ctx, s.DB, model.SettingStorageMeasuredOn, now.Format("2006-01-02"))
+	if err != nil || !claimed {
+		return err
+	}
+
+	db := s.DB.WithContext(ctx)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	usage := make(map[string]*model.StorageUsageSnapshot, len(model.StorageCategories))
+	for _, category := range model.StorageCategories {
+		usage[category] = &model.StorageUsageSnapshot{Day: day, Category: category, MeasuredAt: now}
+	}
+
+	if err := s.measureUploads(ctx, usage); err != nil {
+		return err
+	}
+
+	exports := usage[model.StorageDiaryExports]
+	if err := db.Model(&model.DiaryExport{}).
+		Where("status = ? AND content IS NOT NULL", model.DiaryExportCompleted).
+		Select("COUNT(*), COALESCE(SUM(size_bytes), 0)").
+		Row().Scan(&exports.Objects, &exports.Bytes); err != nil {
+		return err
+	}
+
+	backups := usage[model.StorageBackups]
+	if err := db.Model(&model.Backup{}).
+		Where("status = ? AND completed_at >= ?", model.BackupCompleted, now.Add(-config.BackupRetention)).
+		Select("COUNT(*), COALESCE(SUM(size_bytes), 0)").
+		Row().Scan(&backups.Objects, &backups.Bytes); err != nil {
+		return err
+	}
+
+	snapshots := make([]model.StorageUsageSnapshot, 0, len(usage))
+	var total int64
+	for _, category := range model.StorageCategories {
+		snapshots = append(snapshots, *usage[category])
+		total += usage[category].Bytes
+	}
+
+	if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&snapshots).Error; err != nil {
+		return err
+	}
+
+	s.Log.Infof("Measured %.1f MB of storage", float64(total)/(1<<20))
+	return nil
+}
+
+// measureUploads adds the files under /uploads to the category of the first record referring to them, or to the
+// unreferenced files
+func (s *storageUsageService) measureUploads(ctx context.Context, usage map[string]*model.StorageUsageSnapshot) error {
+	references, err := mediaReferences(s.DB.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(s.Dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && file == s.Dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(s.Dir, file)
+		if err != nil {
+			return err
+		}
+
+		category, ok := references[path.Join("/", utils.UploadDir, filepath.ToSlash(relative))]
+		if !ok {
+			category = model.StorageUnreferenced
+		}
+		usage[category].Objects++
+		usage[category].Bytes += info.Size()
+		return nil
+	})
+}
+
+func (s *storageUsageService) GetReport(c *fiber.Ctx, query *validation.StorageUsageQuery) (*model.StorageUsageReport, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	price, err := model.ParseMoney(config.StoragePricePerTB, config.StoragePriceCurrency)
+	if err != nil {
+		s.Log.Warnf("Invalid STORAGE_PRICE_PER_TB %q, storage costs are reported as zero: %v", config.StoragePricePerTB, err)
+		price = model.Money{Currency: config.StoragePriceCurrency}
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1-query.Days)
+
+	var snapshots []model.StorageUsageSnapshot
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("day >= ?", from).
+		Order("day").
+		Find(&snapshots).Error; err != nil {
+		return nil, err
+	}
+
+	report := model.NewStorageUsageReport(snapshots, query.ForecastDays, price)
+	return &report, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"app/src/model"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// nextSubscriptionStatus returns the status the payment fields of a subscription call for at now, or a conflict
+// when its lifecycle does not allow the move
+func nextSubscriptionStatus(subscription *model.UserSubscription, now time.Time) (string, error) {
+	to := subscription.LifecycleStatus(now)
+	if to != subscription.Status && !model.CanTransitionSubscription(subscription.Status, to) {
+		return "", fiber.NewError(fiber.StatusConflict,
+			fmt.Sprintf("Subscription cannot go from %s to %s", subscription.Status, to))
+	}
+	return to, nil
+}
+
+// syncSubscriptionStatus moves a subscription to the status its payment fields call for and records the move.
+// Every write of the payment fields goes through it, nothing is written when the move is not allowed.
+func syncSubscriptionStatus(db *gorm.DB, subscription *model.UserSubscription, reason string, actorID *uuid.UUID, now time.Time) error {
+	to, err := nextSubscriptionStatus(subscription, now)
+	if err != nil || to == subscription.Status {
+		return err
+	}
+
+	from := subscription.Status
+	if err := db.Model(subscription).UpdateColumns(map[string]interface{}{
+		"status":            to,
+		"status_changed_at": now,
+	}).Error; err != nil {
+		return err
+	}
+	subscription.Status = to
+	subscription.StatusChangedAt = &now
+
+	return recordSubscriptionEvent(db, subscription, from, reason, actorID)
+}
+
+// createSubscription creates a subscription in the status its payment fields call for and records it.
+// Associations loaded on it are not written.
+func createSubscription(db *gorm.DB, subscription *model.UserSubscription, reason string, actorID *uuid.UUID) error {
+	now := time.Now()
+	subscription.Status = subscription.LifecycleStatus(now)
+	subscription.StatusChangedAt = &now
+
+	if err := db.Omit(clause.Associations).Create(subscription).Error; err != nil {
+		return err
+	}
+	return recordSubscriptionEvent(db, subscription, "", reason, actorID)
+}
+
+// subscriptionListener holds the function set with OnSubscriptionChange
+var subscriptionListener atomic.Pointer[func(ctx context.Context, userID uuid.UUID)]
+
+// OnSubscriptionChange sets a function told of the users whose subscription was created or changed status, nil
+// tells no one. It is told within the transaction of the change, what it drops may be read again before the
+// change is committed.
+func OnSubscriptionChange(listener func(ctx context.Context, userID uuid.UUID)) {
+	if listener == nil {
+		subscriptionListener.Store(nil)
+		return
+	}
+	subscriptionListener.Store(&listener)
+}
+
+func recordSubscriptionEvent(db *gorm.DB, subscription *model.UserSubscription, from, reason string, actorID *uuid.UUID) error {
+	if listener := subscriptionListener.Load(); listener != nil {
+		(*listener)(db.Statement.Context, subscription.UserID)
+	}
+
+	if err := db.Create(&model.SubscriptionEvent{
+		UserSubscriptionID: subscription.ID,
+		FromStatus:         from,
+		ToStatus:           subscription.Status,
+		Reason:             reason,
+		ActorID:            actorID,
+		OccurredAt:         *subscription.StatusChangedAt,
+	}).Error; err != nil {
+		return err
+	}
+
+	event, ok := model.SubscriptionWebhookEvent(subscription.Status)
+	if !ok || from == subscription.Status {
+		return nil
+	}
+	return enqueueWebhook(db, event, model.NewSubscriptionWebhookData(subscription, from, reason), *subscription.StatusChangedAt)
+}
+
+// isTransitionConflict reports whether err is a status move refused by syncSubscriptionStatus. Gateways and
+// stores report what already happened, their notifications log the conflict instead of failing.
+func isTransitionConflict(err error) bool {
+	var fiberErr *fiber.Error
+	return errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusConflict
+}
+
+// requestActor returns the user behind a request, nil for gateway notifications
+func requestActor(ctx *fiber.Ctx) *uuid.UUID {
+	if user, ok := ctx.Locals("user").(*model.User); ok {
+		return &user.ID
+	}
+	return nil
+}
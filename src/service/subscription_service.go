@@ -1,25 +1,35 @@
 package service
 
 import (
+	"app/src/clock"
+	"app/src/config"
 	"app/src/model"
+	"app/src/requestid"
+	"app/src/utils"
 	"app/src/validation"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"mime/multipart"
+	"strings"
 	"time"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PaymentGateway interface {
 	Charge(amount int, method string) (*PaymentResponse, error)
 	Refund(transactionID string) error
-	CreateTransaction(orderID string, amount int, userDetails map[string]interface{}, paymentMethod string) (*PaymentToken, error)
-	CheckTransactionStatus(transactionID string) (interface{}, error)
-	HandleNotification(notificationJSON []byte) (interface{}, error)
+	CreateTransaction(ctx context.Context, orderID string, amount int, userDetails map[string]interface{}, paymentMethod string) (*PaymentToken, error)
+	ChargeSavedCard(ctx context.Context, orderID string, amount int, savedTokenID string) (*CardCharge, error)
+	CheckTransactionStatus(ctx context.Context, transactionID string) (interface{}, error)
+	HandleNotification(ctx context.Context, notificationJSON []byte) (interface{}, error)
 }
 
 type PaymentResponse struct {
@@ -29,108 +39,347 @@ type PaymentResponse struct {
 
 type SubscriptionService interface {
 	GetAllPlans(ctx *fiber.Ctx) ([]model.SubscriptionPlanResponse, error)
-	PurchasePlan(ctx *fiber.Ctx, userID uuid.UUID, planID uuid.UUID, paymentMethod string) (*model.PaymentResponse, error)
+	GetPlan(ctx *fiber.Ctx, planID uuid.UUID) (*model.SubscriptionPlanResponse, error)
+	PurchasePlan(ctx *fiber.Ctx, userID uuid.UUID, planID uuid.UUID, paymentMethod string, installment bool) (*model.PaymentResponse, error)
+	StartCheckout(ctx *fiber.Ctx, session *model.CheckoutSession) (*model.PaymentResponse, error)
 	GetUserActiveSubscription(ctx *fiber.Ctx, userID uuid.UUID) (*model.UserSubscriptionResponse, error)
-	CheckFeatureAccess(ctx *fiber.Ctx, userID uuid.UUID, feature string) (bool, error)
 	IncrementScanUsage(ctx *fiber.Ctx, userID uuid.UUID) error
 	GetRemainingScans(ctx *fiber.Ctx, userID uuid.UUID) (int, error)
 	HandlePaymentNotification(ctx *fiber.Ctx, notificationData []byte) error
+	// SetAutoRenew opts a subscription of a user in or out of auto-renewal, renewals paid ahead follow
+	SetAutoRenew(ctx *fiber.Ctx, userID, subscriptionID uuid.UUID, req *validation.UpdateAutoRenew) (*model.UserSubscriptionResponse, error)
+	// CancelMyAutoRenew opts the active subscription of a user out of auto-renewal
+	CancelMyAutoRenew(ctx *fiber.Ctx, userID uuid.UUID) (*model.UserSubscriptionResponse, error)
+	// GetMyPlans lists the plans for sale, cheapest first, with the proration of upgrading the active subscription
+	// of the user to each plan they can upgrade to
+	GetMyPlans(ctx *fiber.Ctx, userID uuid.UUID) ([]model.PlanOption, error)
+	// UpgradeMySubscription starts the checkout of a pricier plan less the unused part of the active subscription,
+	// which is cancelled once the upgrade is paid
+	UpgradeMySubscription(ctx *fiber.Ctx, userID uuid.UUID, req *validation.UpgradeSubscription) (*model.PaymentResponse, error)
+	// StartTrial starts the free trial of a plan for a user who never subscribed, without a payment. The features of
+	// the plan are available right away, the trial ends after the trial days of the plan.
+	StartTrial(ctx *fiber.Ctx, userID, planID uuid.UUID) (*model.UserSubscriptionResponse, error)
 
 	// Admin-related methods
 	GetAllUserSubscriptions(ctx *fiber.Ctx, query *validation.SubscriptionQuery) ([]model.UserSubscriptionResponse, int64, error)
 	GetUserSubscriptionByID(ctx *fiber.Ctx, subscriptionID uuid.UUID) (*model.UserSubscriptionResponse, error)
-	GetAllSubscriptionPlansWithUsers(ctx *fiber.Ctx, withUsers bool) ([]model.SubscriptionPlanWithUsers, error)
+	// GetAllSubscriptionPlansWithUsers lists the plans matching the query with the number of active subscribers,
+	// and the subscribers themselves when asked for
+	GetAllSubscriptionPlansWithUsers(ctx *fiber.Ctx, query *validation.SubscriptionPlanQuery) ([]model.SubscriptionPlanWithUsers, int64, error)
 	UpdateUserSubscription(ctx *fiber.Ctx, subscriptionID uuid.UUID, req *validation.UpdateSubscription) (*model.UserSubscriptionResponse, error)
-	DeleteUserSubscription(ctx *fiber.Ctx, subscriptionID uuid.UUID) error
+	// PreviewProration computes the proration of moving a subscription to a plan now, without changing anything
+	PreviewProration(ctx *fiber.Ctx, subscriptionID, planID uuid.UUID) (*model.Proration, error)
+	// RestoreUserSubscription brings back a subscription soft deleted through AdminActionService, unless a
+	// newer active subscription of the user took its place
+	RestoreUserSubscription(ctx *fiber.Ctx, subscriptionID uuid.UUID) (*model.UserSubscriptionResponse, error)
 	GetTransactionsBySubscriptionID(ctx *fiber.Ctx, subscriptionID uuid.UUID) ([]model.TransactionDetail, error)
+	// GetSubscriptionHistory returns the status moves of a subscription, oldest first
+	GetSubscriptionHistory(ctx *fiber.Ctx, subscriptionID uuid.UUID) ([]model.SubscriptionEvent, error)
 	UpdatePaymentStatus(ctx *fiber.Ctx, subscriptionID uuid.UUID, status string) (*model.UserSubscriptionResponse, error)
-	GetAllTransactions(ctx *fiber.Ctx, page, limit int) ([]model.TransactionDetail, int64, error)
+	GetAllTransactions(ctx *fiber.Ctx, query *validation.TransactionQuery) ([]model.TransactionDetail, int64, error)
 	GetTransactionByID(ctx *fiber.Ctx, transactionID uuid.UUID) (*model.TransactionDetail, error)
+	ExportTransactions(ctx *fiber.Ctx, query *validation.TransactionExportQuery) (TransactionExport, error)
+	CreateManualTransaction(ctx *fiber.Ctx, adminID uuid.UUID, req *validation.CreateManualTransaction, proof *multipart.FileHeader) (*model.TransactionDetail, error)
+	CompSubscription(ctx *fiber.Ctx, adminID uuid.UUID, req *validation.CompSubscription) (*model.UserSubscriptionResponse, error)
+	ConfirmManualPayment(ctx *fiber.Ctx, subscriptionID uuid.UUID, detail *model.TransactionDetail) (*model.UserSubscriptionResponse, error)
+	ReleaseHeldPayment(ctx *fiber.Ctx, subscriptionID uuid.UUID, approved bool) (*model.UserSubscriptionResponse, error)
 	GetSubscriptionPlanByID(ctx *fiber.Ctx, planID uuid.UUID) (*model.SubscriptionPlan, error)
-	UpdateSubscriptionPlan(ctx *fiber.Ctx, planID uuid.UUID, req *validation.UpdateSubscriptionPlan) (*model.SubscriptionPlan, error)
+	CreateSubscriptionPlan(ctx *fiber.Ctx, adminID uuid.UUID, req *validation.CreateSubscriptionPlan) (*model.SubscriptionPlan, error)
+	UpdateSubscriptionPlan(ctx *fiber.Ctx, adminID, planID uuid.UUID, req *validation.UpdateSubscriptionPlan) (*model.SubscriptionPlan, error)
+
+	// GetPlanHistory lists the changes of a plan, newest first, also for a plan that was deleted
+	GetPlanHistory(ctx *fiber.Ctx, planID uuid.UUID, query *validation.PlanChangeQuery) ([]model.PlanChange, int64, error)
+	// RollbackPlanChange sets the terms an edit of a plan changed back to their value before it, as a change of its own
+	RollbackPlanChange(ctx *fiber.Ctx, adminID, planID, changeID uuid.UUID) (*model.SubscriptionPlan, error)
 }
 
 type subscriptionService struct {
-	DB      *gorm.DB
-	Log     *logrus.Logger
-	Payment PaymentGateway
+	DB       *gorm.DB
+	Log      *logrus.Logger
+	Validate *validator.Validate
+	Payment  PaymentGateway
+	Wallet   WalletService
+	Fraud    FraudService
+	Alerts   AlertService
+
+	// SandboxPayment is the gateway of sandbox users, nil when sandbox checkouts are not configured
+	SandboxPayment PaymentGateway
+}
+
+func installmentCount(plan model.SubscriptionPlan) int {
+	if !plan.AllowInstallments {
+		return 0
+	}
+	return plan.InstallmentCount
 }
 
-func formatCurrency(amount int) string {
-	return fmt.Sprintf("Rp %d", amount)
+// installmentPrice is the regular monthly amount, the first installment may be slightly higher
+func installmentPrice(plan model.SubscriptionPlan) int {
+	if !plan.AllowInstallments || plan.InstallmentCount < 2 {
+		return 0
+	}
+	amounts := plan.PriceMoney().Split(plan.InstallmentCount)
+	return amounts[len(amounts)-1].Int()
 }
 
-func NewSubscriptionService(db *gorm.DB, payment PaymentGateway) SubscriptionService {
+func NewSubscriptionService(
+	db *gorm.DB, validate *validator.Validate, payment, sandboxPayment PaymentGateway, wallet WalletService, fraud FraudService, alerts AlertService,
+) SubscriptionService {
 	return &subscriptionService{
-		DB:      db,
-		Log:     logrus.New(),
-		Payment: payment,
+		DB:             db,
+		Log:            logrus.New(),
+		Validate:       validate,
+		Payment:        payment,
+		Wallet:         wallet,
+		Fraud:          fraud,
+		Alerts:         alerts,
+		SandboxPayment: sandboxPayment,
+	}
+}
+
+// paymentGateway picks the gateway of a checkout, sandbox checkouts fail rather than fall back to the live gateway
+func paymentGateway(payment, sandboxPayment PaymentGateway, sandbox bool) (PaymentGateway, error) {
+	if !sandbox {
+		return payment, nil
+	}
+	if sandboxPayment == nil {
+		return nil, fiber.NewError(fiber.StatusServiceUnavailable, "Sandbox payments are not configured")
+	}
+	return sandboxPayment, nil
+}
+
+// listedPlans selects the plans listed for sale at now: active, not hidden, not retired and within their
+// availability window
+func listedPlans(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("is_active = ? AND hidden = ? AND sunset_at IS NULL", true, false).
+			Where("available_from IS NULL OR available_from <= ?", now).
+			Where("available_until IS NULL OR available_until > ?", now)
 	}
 }
 
 func (s *subscriptionService) GetAllPlans(ctx *fiber.Ctx) ([]model.SubscriptionPlanResponse, error) {
+	now := clock.Now(ctx.UserContext())
+
 	var plans []model.SubscriptionPlan
-	if err := s.DB.WithContext(ctx.Context()).
-		Where("is_active = ?", true).
+	if err := s.DB.WithContext(ctx.UserContext()).
+		Scopes(listedPlans(now)).
 		Find(&plans).Error; err != nil {
 		return nil, err
 	}
 
 	var responses []model.SubscriptionPlanResponse
 	for _, plan := range plans {
-		var features map[string]bool
-		if err := json.Unmarshal([]byte(plan.Features), &features); err != nil {
+		planResponse, err := toPlanResponse(plan)
+		if err != nil {
 			return nil, err
 		}
-
-		responses = append(responses, model.SubscriptionPlanResponse{
-			ID:             plan.ID,
-			Name:           plan.Name,
-			Price:          plan.Price,
-			PriceFormatted: formatCurrency(plan.Price),
-			Features:       features,
-			IsRecommended:  plan.Name == "Early Bird",
-			Description:    plan.Description,
-			ValidityDays:   plan.ValidityDays,
-			AIscanLimit:    plan.AIscanLimit,
-		})
+		responses = append(responses, *planResponse)
 	}
 
 	return responses, nil
 }
 
-func (s *subscriptionService) PurchasePlan(ctx *fiber.Ctx, userID uuid.UUID, planID uuid.UUID, paymentMethod string) (*model.PaymentResponse, error) {
+// GetPlan resolves a single purchasable plan, including hidden plans reached by direct ID or promo link. The
+// bearer token is optional on this route.
+func (s *subscriptionService) GetPlan(ctx *fiber.Ctx, planID uuid.UUID) (*model.SubscriptionPlanResponse, error) {
+	var plan model.SubscriptionPlan
+	if err := s.DB.WithContext(ctx.UserContext()).First(&plan, "id = ?", planID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+		}
+		return nil, err
+	}
+
+	if !plan.IsAvailableAt(clock.Now(ctx.UserContext())) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+	}
+
+	// Views of signed in users start the checkout funnel, they never fail the request
+	if viewerID := optionalUserID(ctx); viewerID != nil {
+		if err := appendEvent(s.DB.WithContext(ctx.UserContext()), model.EventPlanViewed, *viewerID, plan.ID,
+			model.EventPayload{PlanID: plan.ID.String()}, time.Now()); err != nil {
+			s.Log.Errorf("Failed to append plan view of plan %s: %v", plan.ID, err)
+		}
+	}
+
+	return toPlanResponse(plan)
+}
+
+func toPlanResponse(plan model.SubscriptionPlan) (*model.SubscriptionPlanResponse, error) {
+	return &model.SubscriptionPlanResponse{
+		ID:             plan.ID,
+		Name:           plan.Name,
+		Price:          plan.Price,
+		Currency:       plan.Currency,
+		PriceFormatted: plan.PriceMoney().String(),
+		Features:       plan.Features.Map(),
+		IsRecommended:  plan.Name == "Early Bird",
+		Description:    plan.Description,
+		ValidityDays:   plan.ValidityDays,
+		AIscanLimit:    plan.AIscanLimit,
+
+		ChatMessageLimit:  plan.ChatMessageLimit,
+		VoiceLogLimit:     plan.VoiceLogLimit,
+		AllowInstallments: plan.AllowInstallments,
+		InstallmentCount:  installmentCount(plan),
+		InstallmentPrice:  installmentPrice(plan),
+
+		AvailableUntil: plan.AvailableUntil,
+		TrialDays:      plan.TrialDays,
+	}, nil
+}
+
+// PurchasePlan checks out a plan without a coupon and starts its payment right away
+func (s *subscriptionService) PurchasePlan(ctx *fiber.Ctx, userID uuid.UUID, planID uuid.UUID, paymentMethod string, installment bool) (*model.PaymentResponse, error) {
+	var plan model.SubscriptionPlan
+	if err := s.DB.WithContext(ctx.UserContext()).First(&plan, "id = ?", planID).Error; err != nil {
+		return nil, errors.New("subscription plan not found")
+	}
+
+	if !plan.IsAvailableAt(clock.Now(ctx.UserContext())) {
+		return nil, errors.New("subscription plan is not available")
+	}
+
+	if installment && (!plan.AllowInstallments || plan.InstallmentCount < 2) {
+		return nil, errors.New("installments are not available for this plan")
+	}
+
+	if plan.Currency != model.CurrencyIDR {
+		return nil, errors.New("this plan can only be bought through the app stores")
+	}
+
+	session := newCheckoutSession(userID, &plan, nil, paymentMethod, installment)
+	if err := s.DB.WithContext(ctx.UserContext()).Create(session).Error; err != nil {
+		return nil, fmt.Errorf("failed to create checkout session: %w", err)
+	}
+	appendCheckoutEvent(s.DB.WithContext(ctx.UserContext()), model.EventCheckoutCreated, session)
+
+	return s.StartCheckout(ctx, session)
+}
+
+// StartCheckout creates the pending subscription and the gateway transaction for a checkout session.
+// The gateway order is stored on the session, payment notifications are resolved through it.
+func (s *subscriptionService) StartCheckout(ctx *fiber.Ctx, session *model.CheckoutSession) (*model.PaymentResponse, error) {
 	var plan model.SubscriptionPlan
-	if err := s.DB.WithContext(ctx.Context()).First(&plan, "id = ?", planID).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).First(&plan, "id = ?", session.PlanID).Error; err != nil {
 		return nil, errors.New("subscription plan not found")
 	}
 
-	fmt.Println("paymentMethod", paymentMethod)
+	userID := session.UserID
+	paymentMethod := session.PaymentMethod
+	installment := session.Installment
+
 	// Get user details
 	var user model.User
-	if err := s.DB.WithContext(ctx.Context()).First(&user, "id = ?", userID).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).First(&user, "id = ?", userID).Error; err != nil {
 		return nil, errors.New("user not found")
 	}
 
+	gateway, err := paymentGateway(s.Payment, s.SandboxPayment, user.IsSandbox)
+	if err != nil {
+		return nil, err
+	}
+
+	assessment, err := s.Fraud.Evaluate(ctx, &user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate fraud rules: %w", err)
+	}
+
+	if assessment.Denied {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Payment cannot be processed for this account")
+	}
+
 	// Generate a unique order ID
-	orderID := fmt.Sprintf("SUB-%s-%d", userID.String()[:8], time.Now().Unix())
+	orderID := model.SandboxOrderID(fmt.Sprintf("SUB-%s-%d", userID.String()[:8], time.Now().Unix()), user.IsSandbox)
 
 	// Create a new subscription with pending status
 	subscription := model.UserSubscription{
-		UserID:        userID,
-		PlanID:        planID,
-		StartDate:     time.Now(),
-		EndDate:       time.Now().AddDate(0, 0, plan.ValidityDays),
-		PaymentMethod: paymentMethod,
-		TransactionID: orderID,
-		PaymentStatus: "pending",
-		IsActive:      false, // Will be activated after payment is completed
+		UserID:          userID,
+		PlanID:          plan.ID,
+		StartDate:       clock.Now(ctx.UserContext()),
+		EndDate:         clock.Now(ctx.UserContext()).AddDate(0, 0, plan.ValidityDays),
+		PaymentMethod:   paymentMethod,
+		TransactionID:   orderID,
+		PaymentStatus:   "pending",
+		IsActive:        false, // Will be activated after payment is completed
+		IsInstallment:   installment,
+		CheckoutIP:      assessment.IPAddress,
+		CheckoutCountry: assessment.Country,
+		Source:          model.SourceMidtrans,
+		SourceReference: orderID,
+		IsSandbox:       user.IsSandbox,
+		AutoRenew:       session.AutoRenew,
+		SourceMetadata: model.NewSourceMetadata(map[string]interface{}{
+			"payment_method":      paymentMethod,
+			"installment":         installment,
+			"checkout_session_id": session.ID.String(),
+			"coupon_code":         session.CouponCode,
+			"upgrade_from_id":     session.UpgradeFromID,
+			"proration_credit":    session.ProrationCredit,
+		}),
 	}
 
 	// Save subscription to database
-	if err := s.DB.WithContext(ctx.Context()).Create(&subscription).Error; err != nil {
+	if err := createSubscription(s.DB.WithContext(ctx.UserContext()), &subscription, "checkout started", &userID); err != nil {
 		return nil, fmt.Errorf("failed to create subscription: %w", err)
 	}
+	appendCheckoutEvent(s.DB.WithContext(ctx.UserContext()), model.EventPaymentAttempted, session)
+
+	// Suspicious checkouts can still be paid, but the subscription is held until an admin reviews it
+	if len(assessment.Rules) > 0 {
+		if err := s.Fraud.FlagForReview(ctx, &subscription, assessment); err != nil {
+			s.DB.WithContext(ctx.UserContext()).Delete(&subscription)
+			return nil, fmt.Errorf("failed to flag subscription for review: %w", err)
+		}
+	}
+
+	// The gateway only charges the first installment now, the rest are billed monthly
+	chargeAmount := session.Total
+	if installment {
+		schedule := model.BuildInstallmentSchedule(subscription.ID, session.TotalMoney(), plan.InstallmentCount, subscription.StartDate)
+		schedule[0].OrderID = &orderID
+		if err := s.DB.WithContext(ctx.UserContext()).Create(&schedule).Error; err != nil {
+			s.DB.WithContext(ctx.UserContext()).Delete(&subscription)
+			return nil, fmt.Errorf("failed to create installment schedule: %w", err)
+		}
+		chargeAmount = schedule[0].Amount
+	}
+
+	// Wallet credits are applied before anything is charged through the gateway
+	walletApplied, err := s.Wallet.ApplyToCheckout(ctx, userID, chargeAmount, orderID)
+	if err != nil {
+		s.DB.WithContext(ctx.UserContext()).Where("user_subscription_id = ?", subscription.ID).Delete(&model.InstallmentSchedule{})
+		s.DB.WithContext(ctx.UserContext()).Delete(&subscription)
+		return nil, fmt.Errorf("failed to apply wallet credit: %w", err)
+	}
+
+	if walletApplied > 0 {
+		subscription.WalletAmountApplied = walletApplied
+		if err := s.DB.WithContext(ctx.UserContext()).
+			Model(&subscription).
+			Update("wallet_amount_applied", walletApplied).Error; err != nil {
+			s.Log.Errorf("Failed to store wallet amount for subscription %s: %v", subscription.ID, err)
+		}
+		chargeAmount -= walletApplied
+	}
+
+	// Fully covered by the wallet or a coupon, nothing to charge
+	if chargeAmount == 0 {
+		if err := s.settleWithoutGateway(ctx, &subscription, walletApplied); err != nil {
+			return nil, err
+		}
+
+		payment := &model.PaymentResponse{
+			OrderID:             orderID,
+			WalletAmountApplied: walletApplied,
+			AmountDue:           0,
+		}
+		s.attachCheckoutPayment(ctx, session, &subscription, payment)
+		s.syncCheckoutSession(ctx, session, &subscription)
+		return payment, nil
+	}
 
 	// Prepare user details for Midtrans
 	userDetails := map[string]interface{}{
@@ -141,19 +390,167 @@ func (s *subscriptionService) PurchasePlan(ctx *fiber.Ctx, userID uuid.UUID, pla
 	}
 
 	// Create transaction in Midtrans
-	paymentToken, err := s.Payment.CreateTransaction(orderID, plan.Price, userDetails, paymentMethod)
+	paymentToken, err := gateway.CreateTransaction(ctx.UserContext(), orderID, chargeAmount, userDetails, paymentMethod)
 	if err != nil {
 		// Rollback subscription creation if payment fails
-		s.DB.WithContext(ctx.Context()).Delete(&subscription)
+		if errWallet := s.Wallet.ReverseCheckout(ctx, userID, orderID); errWallet != nil {
+			s.Log.Errorf("Failed to return wallet credit for order %s: %v", orderID, errWallet)
+		}
+		s.DB.WithContext(ctx.UserContext()).Where("user_subscription_id = ?", subscription.ID).Delete(&model.InstallmentSchedule{})
+		s.DB.WithContext(ctx.UserContext()).Delete(&subscription)
 		return nil, fmt.Errorf("payment creation failed: %w", err)
 	}
 
+	payment := &model.PaymentResponse{
+		TransactionToken:    paymentToken.Token,
+		RedirectURL:         paymentToken.RedirectURL,
+		OrderID:             orderID,
+		WalletAmountApplied: walletApplied,
+		AmountDue:           chargeAmount,
+	}
+	s.attachCheckoutPayment(ctx, session, &subscription, payment)
+
 	// Return payment details
-	return &model.PaymentResponse{
-		TransactionToken: paymentToken.Token,
-		RedirectURL:      paymentToken.RedirectURL,
-		OrderID:          orderID,
-	}, nil
+	return payment, nil
+}
+
+// attachCheckoutPayment stores the gateway order of a payment attempt on its checkout session
+func (s *subscriptionService) attachCheckoutPayment(
+	ctx *fiber.Ctx, session *model.CheckoutSession, subscription *model.UserSubscription, payment *model.PaymentResponse,
+) {
+	session.Status = model.CheckoutPending
+	session.OrderID = &payment.OrderID
+	session.PaymentToken = &payment.TransactionToken
+	session.PaymentURL = &payment.RedirectURL
+	session.WalletAmountApplied = payment.WalletAmountApplied
+	session.AmountDue = payment.AmountDue
+	session.UserSubscriptionID = &subscription.ID
+
+	if err := s.DB.WithContext(ctx.UserContext()).Save(session).Error; err != nil {
+		s.Log.Errorf("Failed to attach order %s to checkout session %s: %v", payment.OrderID, session.ID, err)
+	}
+}
+
+// syncCheckoutSession mirrors the payment outcome of a subscription onto the checkout session that started it
+func (s *subscriptionService) syncCheckoutSession(ctx *fiber.Ctx, session *model.CheckoutSession, subscription *model.UserSubscription) {
+	if session == nil {
+		return
+	}
+
+	status := session.Status
+	switch subscription.PaymentStatus {
+	case "success", "on_hold":
+		status = model.CheckoutPaid
+	case "failed":
+		status = model.CheckoutFailed
+	}
+
+	if status == session.Status {
+		return
+	}
+
+	updates := map[string]interface{}{"status": status}
+	if status == model.CheckoutPaid {
+		updates["paid_at"] = time.Now()
+	}
+
+	if err := s.DB.WithContext(ctx.UserContext()).Model(session).Updates(updates).Error; err != nil {
+		s.Log.Errorf("Failed to update checkout session %s to %s: %v", session.ID, status, err)
+		return
+	}
+	if status == model.CheckoutPaid {
+		appendCheckoutEvent(s.DB.WithContext(ctx.UserContext()), model.EventCheckoutPaid, session)
+	}
+
+	if status == model.CheckoutPaid && session.UpgradeFromID != nil {
+		s.completeUpgrade(ctx, subscription)
+	}
+
+	if status == model.CheckoutPaid && session.CouponID != nil {
+		if err := s.DB.WithContext(ctx.UserContext()).
+			Model(&model.Coupon{}).
+			Where("id = ?", *session.CouponID).
+			UpdateColumn("redemptions", gorm.Expr("redemptions + 1")).Error; err != nil {
+			s.Log.Errorf("Failed to count redemption of coupon %s: %v", *session.CouponID, err)
+		}
+	}
+}
+
+// findCheckoutSession returns the checkout session owning a gateway order, or nil for orders created without one
+func (s *subscriptionService) findCheckoutSession(ctx *fiber.Ctx, orderID string) *model.CheckoutSession {
+	var session model.CheckoutSession
+	if err := s.DB.WithContext(ctx.UserContext()).Where("order_id = ?", orderID).First(&session).Error; err != nil {
+		return nil
+	}
+	return &session
+}
+
+// settleWithoutGateway activates a subscription whose checkout amount was fully covered by wallet credit or a coupon
+func (s *subscriptionService) settleWithoutGateway(ctx *fiber.Ctx, subscription *model.UserSubscription, amount int) error {
+	now := time.Now()
+
+	if subscription.IsInstallment {
+		if err := s.DB.WithContext(ctx.UserContext()).
+			Model(&model.InstallmentSchedule{}).
+			Where("user_subscription_id = ? AND installment_number = ?", subscription.ID, 1).
+			Updates(map[string]interface{}{"status": model.InstallmentPaid, "paid_at": now}).Error; err != nil {
+			return fmt.Errorf("failed to update installment: %w", err)
+		}
+	}
+
+	s.applyPaymentStatus(subscription, "settlement")
+	s.holdIfUnderReview(ctx, subscription)
+
+	paymentType, statusMessage := "wallet", "Paid with wallet credit"
+	if amount == 0 {
+		paymentType, statusMessage = "coupon", "Fully discounted by coupon"
+	}
+
+	detail := &model.TransactionDetail{
+		UserSubscriptionID: subscription.ID,
+		OrderID:            subscription.TransactionID,
+		TransactionID:      subscription.TransactionID,
+		TransactionStatus:  "settlement",
+		TransactionTime:    now,
+		StatusMessage:      statusMessage,
+		PaymentType:        paymentType,
+		GrossAmount:        model.IDR(amount).Decimal(),
+		Currency:           model.CurrencyIDR,
+		SettlementTime:     &now,
+	}
+
+	return s.recordTransaction(ctx, subscription, detail)
+}
+
+// holdIfUnderReview keeps a paid subscription inactive while its fraud review is unresolved
+func (s *subscriptionService) holdIfUnderReview(ctx *fiber.Ctx, subscription *model.UserSubscription) {
+	if subscription.PaymentStatus != "success" {
+		return
+	}
+
+	held, err := s.Fraud.IsHeld(ctx, subscription.ID)
+	if err != nil {
+		// Fail closed, an admin can still release the payment from the review queue
+		s.Log.Errorf("Failed to check fraud review for subscription %s: %v", subscription.ID, err)
+		held = true
+	}
+
+	if held {
+		s.Log.Infof("Holding subscription %s for fraud review", subscription.ID)
+		subscription.PaymentStatus = "on_hold"
+		subscription.IsActive = false
+	}
+}
+
+// releaseWalletCredit returns wallet credit held by a checkout whose payment failed
+func (s *subscriptionService) releaseWalletCredit(ctx *fiber.Ctx, subscription *model.UserSubscription) {
+	if subscription.PaymentStatus != "failed" || subscription.WalletAmountApplied == 0 {
+		return
+	}
+
+	if err := s.Wallet.ReverseCheckout(ctx, subscription.UserID, subscription.TransactionID); err != nil {
+		s.Log.Errorf("Failed to return wallet credit for subscription %s: %v", subscription.ID, err)
+	}
 }
 
 func (s *subscriptionService) HandlePaymentNotification(ctx *fiber.Ctx, notificationData []byte) error {
@@ -183,9 +580,16 @@ func (s *subscriptionService) HandlePaymentNotification(ctx *fiber.Ctx, notifica
 	}
 	s.Log.Infof("Transaction status: %s", transactionStatusStr)
 
+	// Sandbox orders are signed by the sandbox gateway
+	gateway, err := paymentGateway(s.Payment, s.SandboxPayment, model.IsSandboxOrder(orderID))
+	if err != nil {
+		s.Log.Errorf("Rejecting notification for sandbox order %s: %v", orderID, err)
+		return err
+	}
+
 	// Try to get transaction status from Midtrans but don't fail if it doesn't work
 	var transactionStatus interface{}
-	transactionStatus, err := s.Payment.HandleNotification(notificationData)
+	transactionStatus, err = gateway.HandleNotification(ctx.UserContext(), notificationData)
 	if err != nil {
 		// Check if the error is related to signature verification
 		if err.Error() == "invalid signature key" || err.Error() == "error verifying signature" {
@@ -200,11 +604,24 @@ func (s *subscriptionService) HandlePaymentNotification(ctx *fiber.Ctx, notifica
 		s.Log.Infof("Transaction status from Midtrans: %v", transactionStatus)
 	}
 
+	// Installment payments carry their own order ID per installment
+	var installment model.InstallmentSchedule
+	if err := s.DB.WithContext(ctx.UserContext()).
+		Where("order_id = ?", orderID).
+		First(&installment).Error; err == nil {
+		return s.handleInstallmentNotification(ctx, &installment, transactionStatusStr, notification, notificationData)
+	}
+
+	// Gateway orders belong to a checkout session, orders from before sessions existed point at the subscription directly
+	subscriptionQuery := s.DB.WithContext(ctx.UserContext()).Where("transaction_id = ?", orderID)
+	session := s.findCheckoutSession(ctx, orderID)
+	if session != nil && session.UserSubscriptionID != nil {
+		subscriptionQuery = s.DB.WithContext(ctx.UserContext()).Where("id = ?", *session.UserSubscriptionID)
+	}
+
 	// Find subscription in database
 	var subscription model.UserSubscription
-	if err := s.DB.WithContext(ctx.Context()).
-		Where("transaction_id = ?", orderID).
-		First(&subscription).Error; err != nil {
+	if err := subscriptionQuery.First(&subscription).Error; err != nil {
 		s.Log.Errorf("Subscription not found for order ID %s: %v", orderID, err)
 		return fmt.Errorf("subscription not found with order ID %s: %w", orderID, err)
 	}
@@ -212,8 +629,92 @@ func (s *subscriptionService) HandlePaymentNotification(ctx *fiber.Ctx, notifica
 	s.Log.Infof("Found subscription: ID=%s, UserID=%s, Status=%s",
 		subscription.ID, subscription.UserID, subscription.PaymentStatus)
 
-	// Process based on transaction status
-	switch transactionStatusStr {
+	s.applyPaymentStatus(&subscription, transactionStatusStr)
+	s.holdIfUnderReview(ctx, &subscription)
+	s.releaseWalletCredit(ctx, &subscription)
+	rememberSavedCard(&subscription, notification)
+
+	// Save detailed transaction information
+	transactionDetail := s.createTransactionDetailFromNotification(subscription.ID, notification, notificationData)
+	if err := s.recordTransaction(ctx, &subscription, transactionDetail); err != nil {
+		return err
+	}
+	s.syncCheckoutSession(ctx, session, &subscription)
+
+	s.Log.Infof("Successfully updated subscription %s to status: %s",
+		subscription.ID, subscription.PaymentStatus)
+	return nil
+}
+
+// handleInstallmentNotification applies a gateway notification to a single installment.
+// The first installment activates the subscription, later ones lift a suspension once nothing is overdue.
+func (s *subscriptionService) handleInstallmentNotification(
+	ctx *fiber.Ctx,
+	installment *model.InstallmentSchedule,
+	transactionStatus string,
+	notification map[string]interface{},
+	rawData []byte,
+) error {
+	var subscription model.UserSubscription
+	if err := s.DB.WithContext(ctx.UserContext()).
+		Where("id = ?", installment.UserSubscriptionID).
+		First(&subscription).Error; err != nil {
+		s.Log.Errorf("Subscription not found for installment %s: %v", installment.ID, err)
+		return fmt.Errorf("subscription not found for installment %s: %w", installment.ID, err)
+	}
+
+	switch transactionStatus {
+	case "capture", "settlement":
+		now := time.Now()
+		installment.Status = model.InstallmentPaid
+		installment.PaidAt = &now
+	case "deny", "cancel", "expire":
+		installment.Status = model.InstallmentFailed
+	}
+
+	if err := s.DB.WithContext(ctx.UserContext()).Save(installment).Error; err != nil {
+		s.Log.Errorf("Failed to update installment %s: %v", installment.ID, err)
+		return fmt.Errorf("failed to update installment: %w", err)
+	}
+
+	if installment.InstallmentNumber == 1 {
+		s.applyPaymentStatus(&subscription, transactionStatus)
+		s.holdIfUnderReview(ctx, &subscription)
+		s.releaseWalletCredit(ctx, &subscription)
+	} else if installment.Status == model.InstallmentPaid && subscription.PaymentStatus == "suspended" {
+		var overdue int64
+		if err := s.DB.WithContext(ctx.UserContext()).
+			Model(&model.InstallmentSchedule{}).
+			Where("user_subscription_id = ? AND status <> ? AND due_date < ?", subscription.ID, model.InstallmentPaid, time.Now()).
+			Count(&overdue).Error; err != nil {
+			return err
+		}
+
+		if overdue == 0 {
+			s.Log.Infof("Reactivating suspended subscription %s", subscription.ID)
+			subscription.PaymentStatus = "success"
+			subscription.IsActive = true
+		}
+	}
+
+	transactionDetail := s.createTransactionDetailFromNotification(subscription.ID, notification, rawData)
+	if err := s.recordTransaction(ctx, &subscription, transactionDetail); err != nil {
+		return err
+	}
+
+	// The first installment is the order of the checkout session
+	if installment.InstallmentNumber == 1 && installment.OrderID != nil {
+		s.syncCheckoutSession(ctx, s.findCheckoutSession(ctx, *installment.OrderID), &subscription)
+	}
+
+	s.Log.Infof("Installment %d of subscription %s is now %s",
+		installment.InstallmentNumber, subscription.ID, installment.Status)
+	return nil
+}
+
+// applyPaymentStatus maps a gateway transaction status onto the subscription
+func (s *subscriptionService) applyPaymentStatus(subscription *model.UserSubscription, transactionStatus string) {
+	switch transactionStatus {
 	case "capture", "settlement":
 		// Payment success
 		s.Log.Infof("Updating subscription %s to success status", subscription.ID)
@@ -229,26 +730,94 @@ func (s *subscriptionService) HandlePaymentNotification(ctx *fiber.Ctx, notifica
 		s.Log.Infof("Subscription %s remains in pending status", subscription.ID)
 	default:
 		s.Log.Warnf("Unhandled transaction status for subscription %s: %s",
-			subscription.ID, transactionStatusStr)
+			subscription.ID, transactionStatus)
+	}
+}
+
+// rememberSavedCard keeps the card token a paid card payment saved, auto-renewals are charged to it
+func rememberSavedCard(subscription *model.UserSubscription, notification map[string]interface{}) {
+	token := getString(notification, "saved_token_id", "")
+	if token == "" || (subscription.PaymentStatus != "success" && subscription.PaymentStatus != "on_hold") {
+		return
+	}
+
+	subscription.SavedTokenID = token
+	subscription.SavedTokenExpiresAt = model.ParseSavedTokenExpiry(getString(notification, "saved_token_id_expired_at", ""))
+	subscription.MaskedCard = getString(notification, "masked_card", "")
+}
+
+// recordTransaction saves the subscription state together with its transaction detail.
+// The payments of the gateway and the admin status overrides go through here.
+func (s *subscriptionService) recordTransaction(ctx *fiber.Ctx, subscription *model.UserSubscription, detail *model.TransactionDetail) error {
+	detail.IsSandbox = subscription.IsSandbox
+
+	if err := snapshotPlan(s.DB.WithContext(ctx.UserContext()), subscription, detail); err != nil {
+		return err
 	}
 
-	// Update the subscription
-	if err := s.DB.WithContext(ctx.Context()).Save(&subscription).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).Transaction(func(tx *gorm.DB) error {
+		err := syncSubscriptionStatus(tx, subscription, "payment "+detail.TransactionStatus, requestActor(ctx), time.Now())
+		if isTransitionConflict(err) {
+			// The payment already happened at the gateway, it is recorded while the status stays
+			s.Log.Warnf("Subscription %s keeps status %s on payment %s: %v", subscription.ID, subscription.Status, detail.TransactionStatus, err)
+		} else if err != nil {
+			return err
+		}
+		return tx.Save(subscription).Error
+	}); err != nil {
 		s.Log.Errorf("Failed to update subscription %s: %v", subscription.ID, err)
 		return fmt.Errorf("failed to update subscription: %w", err)
 	}
 
-	// Save detailed transaction information
-	transactionDetail := s.createTransactionDetailFromNotification(subscription.ID, notification, notificationData)
-	if err := s.DB.WithContext(ctx.Context()).Create(&transactionDetail).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).Create(detail).Error; err != nil {
 		s.Log.Errorf("Failed to save transaction details: %v", err)
 		// Continue even if saving details fails
 	} else {
-		s.Log.Infof("Saved transaction details with ID: %s", transactionDetail.ID)
+		s.Log.Infof("Saved transaction details with ID: %s", detail.ID)
+
+		if err := recognizeRevenue(s.DB.WithContext(ctx.UserContext()), detail, subscription); err != nil {
+			s.Log.Errorf("Failed to schedule revenue recognition for transaction %s: %v", detail.ID, err)
+		}
 	}
 
-	s.Log.Infof("Successfully updated subscription %s to status: %s",
-		subscription.ID, subscription.PaymentStatus)
+	if detail.IsFailed() {
+		data := model.NewPaymentWebhookData(subscription, detail.OrderID, detail.Money(), detail.TransactionStatus, detail.StatusMessage)
+		if err := enqueueWebhook(s.DB.WithContext(ctx.UserContext()), model.WebhookPaymentFailed, data, time.Now()); err != nil {
+			s.Log.Errorf("Failed to queue the payment failure webhook of order %s: %v", detail.OrderID, err)
+		}
+	}
+
+	if detail.IsFailed() && !detail.IsSandbox {
+		s.Alerts.Emit(ctx.UserContext(), model.AlertPaymentFailureSpike, 1,
+			fmt.Sprintf("Payment for order %s is %s", detail.OrderID, detail.TransactionStatus))
+	}
+
+	return nil
+}
+
+// snapshotPlan keeps on a paid subscription the plan as it was sold, a change of plan takes a new snapshot. A
+// transaction, when given, is recorded with the snapshot of its subscription, or the plan as it is now while the
+// subscription is not paid.
+func snapshotPlan(db *gorm.DB, subscription *model.UserSubscription, detail *model.TransactionDetail) error {
+	snapshot := subscription.PlanSnapshot
+	if snapshot == nil || snapshot.PlanID != subscription.PlanID {
+		plan := subscription.Plan
+		if plan.ID != subscription.PlanID {
+			plan = model.SubscriptionPlan{}
+			if err := db.First(&plan, "id = ?", subscription.PlanID).Error; err != nil {
+				return err
+			}
+		}
+
+		snapshot = model.NewPlanSnapshot(&plan, time.Now())
+		if subscription.PaymentStatus == "success" {
+			subscription.PlanSnapshot = snapshot
+		}
+	}
+
+	if detail != nil {
+		detail.PlanSnapshot = snapshot
+	}
 	return nil
 }
 
@@ -352,9 +921,9 @@ func getString(data map[string]interface{}, key string, defaultValue string) str
 
 func (s *subscriptionService) GetUserActiveSubscription(ctx *fiber.Ctx, userID uuid.UUID) (*model.UserSubscriptionResponse, error) {
 	var subscription model.UserSubscription
-	err := s.DB.WithContext(ctx.Context()).
+	err := s.DB.WithContext(ctx.UserContext()).
 		Preload("Plan").
-		Where("user_subscriptions.user_id = ? AND user_subscriptions.end_date > ? AND user_subscriptions.is_active = ? AND user_subscriptions.payment_status = ?", userID, time.Now(), true, "success").
+		Scopes(activeSubscription(userID)).
 		First(&subscription).Error
 
 	if err != nil {
@@ -364,51 +933,315 @@ func (s *subscriptionService) GetUserActiveSubscription(ctx *fiber.Ctx, userID u
 	return s.toSubscriptionResponse(&subscription)
 }
 
-func (s *subscriptionService) toSubscriptionResponse(sub *model.UserSubscription) (*model.UserSubscriptionResponse, error) {
-	// Initialize features map
-	features := make(map[string]bool)
+func (s *subscriptionService) SetAutoRenew(
+	ctx *fiber.Ctx, userID, subscriptionID uuid.UUID, req *validation.UpdateAutoRenew,
+) (*model.UserSubscriptionResponse, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
 
-	// Only try to unmarshal if Features is not empty
-	if sub.Plan.Features != "" {
-		if err := json.Unmarshal([]byte(sub.Plan.Features), &features); err != nil {
-			s.Log.Errorf("Failed to unmarshal features: %v", err)
-			return nil, fmt.Errorf("invalid feature format: %w", err)
+	var subscription model.UserSubscription
+	if err := s.DB.WithContext(ctx.UserContext()).
+		Joins("Plan").
+		Where("user_subscriptions.id = ? AND user_subscriptions.user_id = ?", subscriptionID, userID).
+		First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription not found")
 		}
+		return nil, err
 	}
 
-	return &model.UserSubscriptionResponse{
-		ID:     sub.ID,
-		UserID: sub.UserID,
-		Plan: model.SubscriptionPlanResponse{
-			ID:             sub.Plan.ID,
-			Name:           sub.Plan.Name,
-			Price:          sub.Plan.Price,
-			PriceFormatted: formatCurrency(sub.Plan.Price),
-			Features:       features,
-			Description:    sub.Plan.Description,
-			ValidityDays:   sub.Plan.ValidityDays,
-			AIscanLimit:    sub.Plan.AIscanLimit,
-		},
-		AIscansUsed:   sub.AIscansUsed,
-		StartDate:     sub.StartDate,
-		EndDate:       sub.EndDate,
-		IsActive:      sub.IsActive,
-		PaymentMethod: sub.PaymentMethod,
-		PaymentStatus: sub.PaymentStatus,
-		CreatedAt:     sub.CreatedAt,
-	}, nil
+	if subscription.IsStoreManaged() {
+		return nil, fiber.NewError(fiber.StatusConflict, "Renewal of app store subscriptions is managed by the store")
+	}
+	if *req.Enabled && !subscription.CanAutoRenew(time.Now()) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Auto-renewal needs a subscription paid once by credit card without installments")
+	}
+
+	if err := s.DB.WithContext(ctx.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&subscription).Update("auto_renew", *req.Enabled).Error; err != nil {
+			return err
+		}
+		// A renewal already paid keeps the choice for the period after it
+		return tx.Model(&model.UserSubscription{}).
+			Where("renewal_of_id = ? AND start_date > ?", subscription.ID, time.Now()).
+			Update("auto_renew", *req.Enabled).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.toSubscriptionResponse(&subscription)
 }
 
-func (s *subscriptionService) CheckFeatureAccess(ctx *fiber.Ctx, userID uuid.UUID, feature string) (bool, error) {
-	sub, err := s.GetUserActiveSubscription(ctx, userID)
+// findActiveSubscription returns the subscription a user is currently entitled through, with its plan
+func (s *subscriptionService) findActiveSubscription(ctx *fiber.Ctx, userID uuid.UUID) (*model.UserSubscription, error) {
+	var subscription model.UserSubscription
+	if err := s.DB.WithContext(ctx.UserContext()).
+		Preload("Plan").
+		Scopes(activeSubscription(userID)).
+		First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "No active subscription found")
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (s *subscriptionService) CancelMyAutoRenew(ctx *fiber.Ctx, userID uuid.UUID) (*model.UserSubscriptionResponse, error) {
+	subscription, err := s.findActiveSubscription(ctx, userID)
 	if err != nil {
-		return false, nil
+		return nil, err
 	}
-	return sub.Plan.Features[feature], nil
+
+	disabled := false
+	return s.SetAutoRenew(ctx, userID, subscription.ID, &validation.UpdateAutoRenew{Enabled: &disabled})
+}
+
+func (s *subscriptionService) GetMyPlans(ctx *fiber.Ctx, userID uuid.UUID) ([]model.PlanOption, error) {
+	now := clock.Now(ctx.UserContext())
+
+	// Users without an active subscription see the plans to buy, without upgrades
+	var active *model.UserSubscription
+	var subscription model.UserSubscription
+	err := s.DB.WithContext(ctx.UserContext()).Preload("Plan").Scopes(activeSubscription(userID)).First(&subscription).Error
+	switch {
+	case err == nil:
+		active = &subscription
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	var plans []model.SubscriptionPlan
+	if err := s.DB.WithContext(ctx.UserContext()).
+		Scopes(listedPlans(now)).
+		Order("price ASC").
+		Find(&plans).Error; err != nil {
+		return nil, err
+	}
+
+	options := make([]model.PlanOption, 0, len(plans))
+	for i := range plans {
+		response, err := toPlanResponse(plans[i])
+		if err != nil {
+			return nil, err
+		}
+
+		option := model.PlanOption{Plan: *response}
+		if active != nil {
+			option.Current = plans[i].ID == active.PlanID
+			if active.CanUpgradeTo(&plans[i], now) {
+				proration := model.Prorate(active, &plans[i], now)
+				option.Upgrade = &proration
+			}
+		}
+		options = append(options, option)
+	}
+
+	return options, nil
+}
+
+func (s *subscriptionService) UpgradeMySubscription(
+	ctx *fiber.Ctx, userID uuid.UUID, req *validation.UpgradeSubscription,
+) (*model.PaymentResponse, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	active, err := s.findActiveSubscription(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := clock.Now(ctx.UserContext())
+	var plan model.SubscriptionPlan
+	if err := s.DB.WithContext(ctx.UserContext()).First(&plan, "id = ?", req.PlanID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+		}
+		return nil, err
+	}
+	if !plan.IsAvailableAt(now) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+	}
+
+	switch {
+	case plan.ID == active.PlanID:
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Subscription is already on this plan")
+	case active.IsStoreManaged():
+		return nil, fiber.NewError(fiber.StatusConflict, "Plans of app store subscriptions are changed in the store")
+	case !active.CanProrate(now):
+		return nil, fiber.NewError(fiber.StatusConflict, "Only subscriptions paid in one payment can be upgraded")
+	case !active.CanUpgradeTo(&plan, now):
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Subscriptions can only be upgraded to a pricier plan sold in Rupiah")
+	}
+
+	proration := model.Prorate(active, &plan, now)
+	session := newCheckoutSession(userID, &plan, nil, req.PaymentMethod, false)
+	session.UpgradeFromID = &active.ID
+	session.ProrationCredit = proration.Credit
+	session.Total -= proration.Credit
+	if err := s.DB.WithContext(ctx.UserContext()).Create(session).Error; err != nil {
+		return nil, fmt.Errorf("failed to create checkout session: %w", err)
+	}
+	appendCheckoutEvent(s.DB.WithContext(ctx.UserContext()), model.EventCheckoutCreated, session)
+
+	return s.StartCheckout(ctx, session)
+}
+
+func (s *subscriptionService) StartTrial(ctx *fiber.Ctx, userID, planID uuid.UUID) (*model.UserSubscriptionResponse, error) {
+	now := clock.Now(ctx.UserContext())
+
+	var plan model.SubscriptionPlan
+	if err := s.DB.WithContext(ctx.UserContext()).First(&plan, "id = ?", planID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+		}
+		return nil, err
+	}
+	if !plan.IsAvailableAt(now) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+	}
+	if !plan.CanTrialAt(now) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "This plan has no free trial")
+	}
+
+	subscription := model.UserSubscription{
+		UserID:        userID,
+		PlanID:        plan.ID,
+		Plan:          plan,
+		StartDate:     now,
+		EndDate:       now.AddDate(0, 0, plan.TrialDays),
+		PaymentMethod: "trial",
+		TransactionID: fmt.Sprintf("TRIAL-%s-%d", userID.String()[:8], now.Unix()),
+		PaymentStatus: "success",
+		IsActive:      true,
+		Source:        model.SourceTrial,
+		SourceMetadata: model.NewSourceMetadata(map[string]interface{}{
+			"trial_days": plan.TrialDays,
+		}),
+	}
+
+	if err := s.DB.WithContext(ctx.UserContext()).Transaction(func(tx *gorm.DB) error {
+		// Locking the user keeps two requests at once from starting two trials
+		var user model.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		subscription.IsSandbox = user.IsSandbox
+
+		// Trials are for new subscribers, a user who paid for a plan or had a trial before has none
+		var previous int64
+		if err := tx.Model(&model.UserSubscription{}).
+			Where("user_id = ? AND (source = ? OR payment_status IN ?)", userID, model.SourceTrial,
+				[]string{"success", "suspended", "refunded", "expired"}).
+			Count(&previous).Error; err != nil {
+			return err
+		}
+		if previous > 0 {
+			return fiber.NewError(fiber.StatusConflict, "Free trials are only available to new subscribers")
+		}
+
+		if err := snapshotPlan(tx, &subscription, nil); err != nil {
+			return err
+		}
+		return createSubscription(tx, &subscription, fmt.Sprintf("free trial of %d days", plan.TrialDays), &userID)
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.toSubscriptionResponse(&subscription)
+}
+
+// completeUpgrade cancels the subscription a paid upgrade replaces. The upgrade is paid already, a failure is
+// logged rather than failing the payment.
+func (s *subscriptionService) completeUpgrade(ctx *fiber.Ctx, subscription *model.UserSubscription) {
+	if subscription.PaymentStatus != "success" {
+		return
+	}
+
+	var session model.CheckoutSession
+	if err := s.DB.WithContext(ctx.UserContext()).
+		Where("user_subscription_id = ? AND upgrade_from_id IS NOT NULL", subscription.ID).
+		First(&session).Error; err != nil {
+		return
+	}
+
+	err := s.DB.WithContext(ctx.UserContext()).Transaction(func(tx *gorm.DB) error {
+		var upgraded model.UserSubscription
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&upgraded, "id = ?", *session.UpgradeFromID).Error; err != nil {
+			return err
+		}
+		if !upgraded.IsActive {
+			return nil
+		}
+
+		upgraded.IsActive = false
+		upgraded.AutoRenew = false
+		if err := syncSubscriptionStatus(tx, &upgraded, fmt.Sprintf("upgraded by subscription %s", subscription.ID),
+			&subscription.UserID, time.Now()); err != nil {
+			return err
+		}
+		return tx.Model(&upgraded).Updates(map[string]interface{}{"is_active": false, "auto_renew": false}).Error
+	})
+	if err != nil {
+		s.Log.Errorf("Failed to cancel subscription %s upgraded by %s: %v", *session.UpgradeFromID, subscription.ID, err)
+	}
+}
+
+func (s *subscriptionService) toSubscriptionResponse(sub *model.UserSubscription) (*model.UserSubscriptionResponse, error) {
+	// Subscribers keep the terms they bought, whatever happened to the plan since
+	plan := sub.PurchasedPlan()
+
+	return &model.UserSubscriptionResponse{
+		ID:     sub.ID,
+		UserID: sub.UserID,
+		Plan: model.SubscriptionPlanResponse{
+			ID:             plan.ID,
+			Name:           plan.Name,
+			Price:          plan.Price,
+			Currency:       plan.Currency,
+			PriceFormatted: plan.PriceMoney().String(),
+			Features:       plan.Features.Map(),
+			Description:    plan.Description,
+			ValidityDays:   plan.ValidityDays,
+			AIscanLimit:    plan.AIscanLimit,
+			VoiceLogLimit:  plan.VoiceLogLimit,
+		},
+		AIscansUsed:   sub.AIscansUsed,
+		VoiceLogsUsed: sub.VoiceLogsUsed,
+		StartDate:     sub.StartDate,
+		EndDate:       sub.EndDate,
+		IsActive:      sub.IsActive,
+		PaymentMethod: sub.PaymentMethod,
+		PaymentStatus: sub.PaymentStatus,
+		Status:        sub.Status,
+		Source:        sub.Source,
+		SourceRef:     sub.SourceReference,
+		SourceData:    sub.SourceMetadata,
+		IsInstallment: sub.IsInstallment,
+		WalletAmount:  sub.WalletAmountApplied,
+		IsSandbox:     sub.IsSandbox,
+		CreatedAt:     sub.CreatedAt,
+		Store:         sub.StorePurchase,
+		AutoRenew:     sub.AutoRenew,
+		MaskedCard:    sub.MaskedCard,
+		RenewalOfID:   sub.RenewalOfID,
+		PlanSnapshot:  sub.PlanSnapshot,
+		TrialStatus:   sub.TrialStatus(),
+		DeletedAt:     deletedAt(sub.DeletedAt),
+	}, nil
+}
+
+func deletedAt(deleted gorm.DeletedAt) *time.Time {
+	if !deleted.Valid {
+		return nil
+	}
+	return &deleted.Time
 }
 
 func (s *subscriptionService) IncrementScanUsage(ctx *fiber.Ctx, userID uuid.UUID) error {
-	return s.DB.WithContext(ctx.Context()).
+	return s.DB.WithContext(ctx.UserContext()).
 		Model(&model.UserSubscription{}).
 		Where("user_id = ? AND is_active = ?", userID, true).
 		Update("ai_scans_used", gorm.Expr("ai_scans_used + 1")).
@@ -429,18 +1262,43 @@ func (s *subscriptionService) GetRemainingScans(ctx *fiber.Ctx, userID uuid.UUID
 
 // GetAllUserSubscriptions retrieves all user subscriptions with pagination and filtering
 func (s *subscriptionService) GetAllUserSubscriptions(ctx *fiber.Ctx, query *validation.SubscriptionQuery) ([]model.UserSubscriptionResponse, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
 	var subscriptions []model.UserSubscription
 	var totalResults int64
 
-	db := s.DB.WithContext(ctx.Context()).
+	db := s.DB.WithContext(ctx.UserContext()).
 		Preload("Plan").
-		Preload("User")
+		Preload("User").
+		Preload("StorePurchase")
+
+	if query.IncludeDeleted {
+		db = db.Unscoped()
+	}
 
 	// Apply status filter if provided
 	if query.Status != "" {
 		db = db.Where("user_subscriptions.payment_status = ?", query.Status)
 	}
 
+	if query.PaymentMethod != "" {
+		db = db.Where("user_subscriptions.payment_method = ?", query.PaymentMethod)
+	}
+
+	if query.Source != "" {
+		db = db.Where("user_subscriptions.source = ?", query.Source)
+	}
+
+	// A running trial has no outcome yet
+	switch query.Trial {
+	case model.TrialActive:
+		db = db.Where("user_subscriptions.source = ? AND user_subscriptions.trial_outcome = ''", model.SourceTrial)
+	case model.TrialConverted, model.TrialExpired:
+		db = db.Where("user_subscriptions.source = ? AND user_subscriptions.trial_outcome = ?", model.SourceTrial, query.Trial)
+	}
+
 	// Count total results
 	if err := db.Model(&model.UserSubscription{}).Count(&totalResults).Error; err != nil {
 		return nil, 0, err
@@ -472,9 +1330,10 @@ func (s *subscriptionService) GetAllUserSubscriptions(ctx *fiber.Ctx, query *val
 func (s *subscriptionService) GetUserSubscriptionByID(ctx *fiber.Ctx, subscriptionID uuid.UUID) (*model.UserSubscriptionResponse, error) {
 	var subscription model.UserSubscription
 
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Preload("Plan").
 		Preload("User").
+		Preload("StorePurchase").
 		Where("user_subscriptions.id = ?", subscriptionID).
 		First(&subscription).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -486,60 +1345,112 @@ func (s *subscriptionService) GetUserSubscriptionByID(ctx *fiber.Ctx, subscripti
 	return s.toSubscriptionResponse(&subscription)
 }
 
-// GetAllSubscriptionPlansWithUsers retrieves all subscription plans with their users
-func (s *subscriptionService) GetAllSubscriptionPlansWithUsers(ctx *fiber.Ctx, withUsers bool) ([]model.SubscriptionPlanWithUsers, error) {
-	var plans []model.SubscriptionPlan
+// GetAllSubscriptionPlansWithUsers retrieves the subscription plans matching the query with their users
+func (s *subscriptionService) GetAllSubscriptionPlansWithUsers(ctx *fiber.Ctx, query *validation.SubscriptionPlanQuery) ([]model.SubscriptionPlanWithUsers, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+	if query.MinPrice != nil && query.MaxPrice != nil && *query.MinPrice > *query.MaxPrice {
+		return nil, 0, fiber.NewError(fiber.StatusBadRequest, "min_price must not be above max_price")
+	}
+	// Prices are in the minor unit of each currency, they only compare within one
+	if (query.MinPrice != nil || query.MaxPrice != nil) && query.Currency == "" {
+		return nil, 0, fiber.NewError(fiber.StatusBadRequest, "currency is required with min_price or max_price")
+	}
 
-	if err := s.DB.WithContext(ctx.Context()).Find(&plans).Error; err != nil {
-		return nil, err
+	db := s.DB.WithContext(ctx.UserContext()).Model(&model.SubscriptionPlan{})
+	if query.IsActive != nil {
+		db = db.Where("subscription_plans.is_active = ?", *query.IsActive)
+	}
+	if query.Currency != "" {
+		db = db.Where("subscription_plans.currency = ?", query.Currency)
+	}
+	if query.MinPrice != nil {
+		db = db.Where("subscription_plans.price >= ?", *query.MinPrice)
+	}
+	if query.MaxPrice != nil {
+		db = db.Where("subscription_plans.price <= ?", *query.MaxPrice)
+	}
+	if search := strings.TrimSpace(query.Search); search != "" {
+		pattern := "%" + likeEscaper.Replace(search) + "%"
+		db = db.Where("subscription_plans.name ILIKE ? OR subscription_plans.description ILIKE ?", pattern, pattern)
+	}
+
+	var totalResults int64
+	if err := db.Count(&totalResults).Error; err != nil {
+		return nil, 0, err
 	}
 
-	var result []model.SubscriptionPlanWithUsers
+	sortBy := query.SortBy
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	order := query.Order
+	if order == "" {
+		order = "asc"
+	}
+	column := "subscription_plans." + sortBy
+	if sortBy == "user_count" {
+		column = "user_count"
+	}
+	if sortBy == "price" {
+		// Plans of each currency are sorted apart
+		db = db.Order("subscription_plans.currency")
+	}
 
-	for _, plan := range plans {
-		// Parse features
-		var features map[string]bool
-		if err := json.Unmarshal([]byte(plan.Features), &features); err != nil {
-			return nil, err
-		}
+	// The subscriber count is selected with the plan so the list can be sorted by it
+	var plans []struct {
+		model.SubscriptionPlan `gorm:"embedded"`
+		UserCount              int64
+	}
+	if err := db.
+		Select(`subscription_plans.*, (SELECT COUNT(*) FROM user_subscriptions
+			WHERE user_subscriptions.plan_id = subscription_plans.id AND user_subscriptions.is_active = ?
+				AND user_subscriptions.deleted_at IS NULL) AS user_count`, true).
+		Order(column + " " + order).
+		Order("subscription_plans.id").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Scan(&plans).Error; err != nil {
+		return nil, 0, err
+	}
 
-		planWithUsers := model.SubscriptionPlanWithUsers{
-			ID:             plan.ID,
-			Name:           plan.Name,
-			Price:          plan.Price,
-			PriceFormatted: formatCurrency(plan.Price),
-			Description:    plan.Description,
-			AIscanLimit:    plan.AIscanLimit,
-			ValidityDays:   plan.ValidityDays,
-			Features:       features,
-			IsActive:       plan.IsActive,
-		}
+	result := []model.SubscriptionPlanWithUsers{}
 
-		// Count users for this plan
-		var userCount int64
-		if err := s.DB.WithContext(ctx.Context()).
-			Model(&model.UserSubscription{}).
-			Where("plan_id = ? AND is_active = ?", plan.ID, true).
-			Count(&userCount).Error; err != nil {
-			return nil, err
-		}
+	for _, row := range plans {
+		plan := row.SubscriptionPlan
 
-		planWithUsers.UserCount = int(userCount)
+		planWithUsers := model.SubscriptionPlanWithUsers{
+			ID:                plan.ID,
+			Name:              plan.Name,
+			Price:             plan.Price,
+			Currency:          plan.Currency,
+			PriceFormatted:    plan.PriceMoney().String(),
+			Description:       plan.Description,
+			AIscanLimit:       plan.AIscanLimit,
+			ValidityDays:      plan.ValidityDays,
+			Features:          plan.Features.Map(),
+			IsActive:          plan.IsActive,
+			ArchivedAt:        plan.ArchivedAt,
+			SunsetAt:          plan.SunsetAt,
+			ReplacementPlanID: plan.ReplacementPlanID,
+			UserCount:         int(row.UserCount),
+		}
 
 		// Get users if requested
-		if withUsers {
+		if query.WithUsers {
 			var subscriptions []model.UserSubscription
-			if err := s.DB.WithContext(ctx.Context()).
+			if err := s.DB.WithContext(ctx.UserContext()).
 				Preload("User").
 				Where("plan_id = ? AND is_active = ?", plan.ID, true).
 				Find(&subscriptions).Error; err != nil {
-				return nil, err
+				return nil, 0, err
 			}
 
 			for _, sub := range subscriptions {
 				response, err := s.toSubscriptionResponse(&sub)
 				if err != nil {
-					return nil, err
+					return nil, 0, err
 				}
 				planWithUsers.Users = append(planWithUsers.Users, *response)
 			}
@@ -548,14 +1459,14 @@ func (s *subscriptionService) GetAllSubscriptionPlansWithUsers(ctx *fiber.Ctx, w
 		result = append(result, planWithUsers)
 	}
 
-	return result, nil
+	return result, totalResults, nil
 }
 
 // UpdateUserSubscription updates a user subscription
 func (s *subscriptionService) UpdateUserSubscription(ctx *fiber.Ctx, subscriptionID uuid.UUID, req *validation.UpdateSubscription) (*model.UserSubscriptionResponse, error) {
 	var subscription model.UserSubscription
 
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Preload("Plan").
 		Where("user_subscriptions.id = ?", subscriptionID).
 		First(&subscription).Error; err != nil {
@@ -566,13 +1477,44 @@ func (s *subscriptionService) UpdateUserSubscription(ctx *fiber.Ctx, subscriptio
 	}
 
 	// Update fields if provided
+	var proration *model.Proration
+	fromPlan := subscription.PurchasedPlan()
 	if req.PlanID != nil {
 		// Verify the plan exists
 		var plan model.SubscriptionPlan
-		if err := s.DB.WithContext(ctx.Context()).First(&plan, "id = ?", *req.PlanID).Error; err != nil {
+		if err := s.DB.WithContext(ctx.UserContext()).First(&plan, "id = ?", *req.PlanID).Error; err != nil {
 			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid plan ID")
 		}
-		subscription.PlanID = *req.PlanID
+
+		// A paid subscription moves to the new plan for a period of its own, less its unused days
+		now := time.Now()
+		if plan.ID != subscription.PlanID && subscription.CanProrate(now) {
+			prorate := req.Prorate == nil || *req.Prorate
+			if prorate && plan.Currency != fromPlan.Currency {
+				return nil, fiber.NewError(fiber.StatusBadRequest, "Plan changes are only prorated between plans of the same currency")
+			}
+
+			// An upgrade is paid through POST /subscriptions/me/upgrade, which keeps the current plan until the
+			// payment settles. Admins only give it away on purpose.
+			prorated := model.Prorate(&subscription, &plan, now)
+			if plan.Currency == fromPlan.Currency && prorated.AmountDue > 0 {
+				if req.Comp == nil || !*req.Comp {
+					return nil, fiber.NewError(fiber.StatusConflict, fmt.Sprintf(
+						"The change leaves %s due, the subscriber upgrades through checkout, or set comp to waive it",
+						model.Money{Amount: int64(prorated.AmountDue), Currency: prorated.Currency}))
+				}
+				prorated.Comped = true
+			}
+
+			if prorate {
+				proration = &prorated
+				subscription.StartDate = proration.StartDate
+				subscription.EndDate = proration.EndDate
+			}
+		}
+
+		subscription.PlanID = plan.ID
+		subscription.Plan = plan
 	}
 
 	if req.IsActive != nil {
@@ -583,6 +1525,10 @@ func (s *subscriptionService) UpdateUserSubscription(ctx *fiber.Ctx, subscriptio
 		subscription.AIscansUsed = *req.AIscansUsed
 	}
 
+	if subscription.IsStoreManaged() && (req.EndDate != nil || req.PaymentMethod != nil) {
+		return nil, fiber.NewError(fiber.StatusConflict, "Billing of app store subscriptions is managed by the store")
+	}
+
 	if req.StartDate != nil {
 		subscription.StartDate = *req.StartDate
 	}
@@ -595,13 +1541,32 @@ func (s *subscriptionService) UpdateUserSubscription(ctx *fiber.Ctx, subscriptio
 		subscription.PaymentMethod = *req.PaymentMethod
 	}
 
-	// Save changes
-	if err := s.DB.WithContext(ctx.Context()).Save(&subscription).Error; err != nil {
+	if _, err := nextSubscriptionStatus(&subscription, time.Now()); err != nil {
 		return nil, err
 	}
 
+	if proration != nil {
+		if err := s.recordProration(ctx, &subscription, fromPlan.Name, proration); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := snapshotPlan(s.DB.WithContext(ctx.UserContext()), &subscription, nil); err != nil {
+			return nil, err
+		}
+
+		// Save changes
+		if err := s.DB.WithContext(ctx.UserContext()).Transaction(func(tx *gorm.DB) error {
+			if err := syncSubscriptionStatus(tx, &subscription, "updated by admin", requestActor(ctx), time.Now()); err != nil {
+				return err
+			}
+			return tx.Save(&subscription).Error
+		}); err != nil {
+			return nil, err
+		}
+	}
+
 	// Refresh subscription data
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Preload("Plan").
 		Where("user_subscriptions.id = ?", subscriptionID).
 		First(&subscription).Error; err != nil {
@@ -611,46 +1576,181 @@ func (s *subscriptionService) UpdateUserSubscription(ctx *fiber.Ctx, subscriptio
 	return s.toSubscriptionResponse(&subscription)
 }
 
-// DeleteUserSubscription deletes a user subscription
-func (s *subscriptionService) DeleteUserSubscription(ctx *fiber.Ctx, subscriptionID uuid.UUID) error {
+func (s *subscriptionService) PreviewProration(ctx *fiber.Ctx, subscriptionID, planID uuid.UUID) (*model.Proration, error) {
 	var subscription model.UserSubscription
-
-	if err := s.DB.WithContext(ctx.Context()).
-		Where("id = ?", subscriptionID).
+	if err := s.DB.WithContext(ctx.UserContext()).
+		Preload("Plan").
+		Where("user_subscriptions.id = ?", subscriptionID).
 		First(&subscription).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fiber.NewError(fiber.StatusNotFound, "Subscription not found")
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription not found")
+		}
+		return nil, err
+	}
+
+	var plan model.SubscriptionPlan
+	if err := s.DB.WithContext(ctx.UserContext()).First(&plan, "id = ?", planID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
 		}
+		return nil, err
+	}
+
+	now := time.Now()
+	switch {
+	case plan.ID == subscription.PlanID:
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Subscription is already on this plan")
+	case !subscription.CanProrate(now):
+		return nil, fiber.NewError(fiber.StatusConflict, "Only current subscriptions paid in one payment are prorated")
+	case plan.Currency != subscription.PurchasedPlan().Currency:
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Plan changes are only prorated between plans of the same currency")
+	}
+
+	proration := model.Prorate(&subscription, &plan, now)
+	return &proration, nil
+}
+
+// recordProration saves a subscription moved to another plan with the line item of its proration, a credit the
+// new plan does not use up goes to the wallet. All of it is saved in one transaction.
+func (s *subscriptionService) recordProration(ctx *fiber.Ctx, subscription *model.UserSubscription, fromPlan string, proration *model.Proration) error {
+	now := time.Now()
+	orderID := model.ProrationOrderID(subscription.ID, now, subscription.IsSandbox)
+	raw, err := json.Marshal(proration)
+	if err != nil {
 		return err
 	}
 
-	// Delete subscription
-	if err := s.DB.WithContext(ctx.Context()).Delete(&subscription).Error; err != nil {
+	detail := &model.TransactionDetail{
+		UserSubscriptionID: subscription.ID,
+		OrderID:            orderID,
+		TransactionID:      orderID,
+		TransactionStatus:  model.TransactionStatusProration,
+		TransactionTime:    now,
+		StatusMessage:      proration.Description(fromPlan, subscription.Plan.Name),
+		PaymentType:        "proration",
+		GrossAmount:        model.Money{Amount: int64(proration.AmountDue), Currency: proration.Currency}.Decimal(),
+		Currency:           proration.Currency,
+		RawResponse:        model.JSON(raw),
+		IsSandbox:          subscription.IsSandbox,
+	}
+
+	err = s.DB.WithContext(ctx.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := snapshotPlan(tx, subscription, detail); err != nil {
+			return err
+		}
+		if err := syncSubscriptionStatus(tx, subscription, "plan changed by admin", requestActor(ctx), now); err != nil {
+			return err
+		}
+		if err := tx.Save(subscription).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(detail).Error; err != nil {
+			return err
+		}
+
+		if proration.WalletCredit > 0 && !subscription.IsSandbox {
+			if err := s.Wallet.CreditProration(ctx.UserContext(), tx, subscription.UserID, proration.WalletCredit, orderID); err != nil {
+				return fmt.Errorf("failed to credit wallet: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.Log.Errorf("Failed to save plan change %s of subscription %s: %v", orderID, subscription.ID, err)
 		return err
 	}
 
+	s.Log.Infof("Prorated plan change %s of subscription %s: credit %d, due %d, comped %t",
+		orderID, subscription.ID, proration.Credit, proration.AmountDue, proration.Comped)
 	return nil
 }
 
+// RestoreUserSubscription brings back a deleted subscription as it was when it was deleted
+func (s *subscriptionService) RestoreUserSubscription(ctx *fiber.Ctx, subscriptionID uuid.UUID) (*model.UserSubscriptionResponse, error) {
+	var subscription model.UserSubscription
+	db := s.DB.WithContext(ctx.UserContext()).Unscoped()
+
+	if err := db.Where("id = ?", subscriptionID).First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription not found")
+		}
+		return nil, err
+	}
+	if !subscription.DeletedAt.Valid {
+		return nil, fiber.NewError(fiber.StatusConflict, "Subscription is not deleted")
+	}
+
+	err := s.DB.WithContext(ctx.UserContext()).Transaction(func(tx *gorm.DB) error {
+		var others []model.UserSubscription
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND id <> ?", subscription.UserID, subscription.ID).
+			Find(&others).Error; err != nil {
+			return err
+		}
+		if !subscription.CanRestoreBeside(others) {
+			return fiber.NewError(fiber.StatusConflict, "The user has a newer active subscription")
+		}
+
+		return tx.Unscoped().Model(&subscription).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetUserSubscriptionByID(ctx, subscriptionID)
+}
+
 // GetTransactionsBySubscriptionID retrieves all transactions for a subscription
 func (s *subscriptionService) GetTransactionsBySubscriptionID(ctx *fiber.Ctx, subscriptionID uuid.UUID) ([]model.TransactionDetail, error) {
-	var transactions []model.TransactionDetail
+	var transactions, archived []model.TransactionDetail
 
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Where("user_subscription_id = ?", subscriptionID).
 		Order("created_at DESC").
 		Find(&transactions).Error; err != nil {
 		return nil, err
 	}
 
-	return transactions, nil
+	// Archived transactions are all older than the live ones
+	if err := s.DB.WithContext(ctx.UserContext()).
+		Table(model.ArchiveTable(model.TransactionDetailsTable)).
+		Where("user_subscription_id = ?", subscriptionID).
+		Order("created_at DESC").
+		Find(&archived).Error; err != nil {
+		return nil, err
+	}
+
+	return append(transactions, archived...), nil
+}
+
+func (s *subscriptionService) GetSubscriptionHistory(ctx *fiber.Ctx, subscriptionID uuid.UUID) ([]model.SubscriptionEvent, error) {
+	var subscriptions int64
+	if err := s.DB.WithContext(ctx.UserContext()).
+		Model(&model.UserSubscription{}).
+		Where("id = ?", subscriptionID).
+		Count(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	if subscriptions == 0 {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Subscription not found")
+	}
+
+	var events []model.SubscriptionEvent
+	if err := s.DB.WithContext(ctx.UserContext()).
+		Where("user_subscription_id = ?", subscriptionID).
+		Order("occurred_at ASC, id ASC").
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	return events, nil
 }
 
 // UpdatePaymentStatus updates the payment status of a subscription
 func (s *subscriptionService) UpdatePaymentStatus(ctx *fiber.Ctx, subscriptionID uuid.UUID, status string) (*model.UserSubscriptionResponse, error) {
 	var subscription model.UserSubscription
 
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Joins("Plan").
 		Where("user_subscriptions.id = ?", subscriptionID).
 		First(&subscription).Error; err != nil {
@@ -660,6 +1760,10 @@ func (s *subscriptionService) UpdatePaymentStatus(ctx *fiber.Ctx, subscriptionID
 		return nil, err
 	}
 
+	if subscription.IsStoreManaged() {
+		return nil, fiber.NewError(fiber.StatusConflict, "Payment status of app store subscriptions is managed by the store")
+	}
+
 	// Update payment status
 	subscription.PaymentStatus = status
 
@@ -670,8 +1774,8 @@ func (s *subscriptionService) UpdatePaymentStatus(ctx *fiber.Ctx, subscriptionID
 		subscription.IsActive = false
 	}
 
-	// Save changes
-	if err := s.DB.WithContext(ctx.Context()).Save(&subscription).Error; err != nil {
+	// Unlike gateway notifications, an admin cannot force a status the lifecycle does not allow
+	if _, err := nextSubscriptionStatus(&subscription, time.Now()); err != nil {
 		return nil, err
 	}
 
@@ -681,37 +1785,70 @@ func (s *subscriptionService) UpdatePaymentStatus(ctx *fiber.Ctx, subscriptionID
 		OrderID:            subscription.TransactionID,
 		TransactionStatus:  status,
 		TransactionTime:    time.Now(),
-		GrossAmount:        fmt.Sprintf("%d", subscription.Plan.Price),
-		Currency:           "IDR",
+		GrossAmount:        subscription.Plan.PriceMoney().Decimal(),
+		Currency:           subscription.Plan.Currency,
 	}
 
-	if err := s.DB.WithContext(ctx.Context()).Create(&transactionDetail).Error; err != nil {
-		s.Log.Warnf("Failed to create transaction record: %v", err)
-		// Continue even if transaction record creation fails
+	if err := s.recordTransaction(ctx, &subscription, transactionDetail); err != nil {
+		return nil, err
 	}
 
 	return s.toSubscriptionResponse(&subscription)
 }
 
-// GetAllTransactions retrieves all transaction logs with pagination
-func (s *subscriptionService) GetAllTransactions(ctx *fiber.Ctx, page, limit int) ([]model.TransactionDetail, int64, error) {
+// GetAllTransactions retrieves the transaction logs matching the filters with pagination
+func (s *subscriptionService) GetAllTransactions(ctx *fiber.Ctx, query *validation.TransactionQuery) ([]model.TransactionDetail, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
 	var transactions []model.TransactionDetail
 	var totalResults int64
 
-	// Count total results
-	if err := s.DB.WithContext(ctx.Context()).
-		Model(&model.TransactionDetail{}).
-		Count(&totalResults).Error; err != nil {
-		return nil, 0, err
+	// Dates are YYYY-MM-DD, so they compare as strings
+	if query.StartDate != "" && query.EndDate != "" && query.EndDate < query.StartDate {
+		return nil, 0, fiber.NewError(fiber.StatusBadRequest, "start_date must not be after end_date")
 	}
 
-	// Apply pagination
-	if err := s.DB.WithContext(ctx.Context()).
-		Preload("UserSubscription").
-		Preload("UserSubscription.User").
-		Order("created_at DESC").
-		Offset((page - 1) * limit).
-		Limit(limit).
+	db := s.DB.WithContext(ctx.UserContext()).Model(&model.TransactionDetail{})
+
+	if query.StartDate != "" {
+		start, _ := time.ParseInLocation("2006-01-02", query.StartDate, time.Local)
+		db = db.Where("transaction_details.transaction_time >= ?", start)
+	}
+	if query.EndDate != "" {
+		end, _ := time.ParseInLocation("2006-01-02", query.EndDate, time.Local)
+		db = db.Where("transaction_details.transaction_time < ?", end.AddDate(0, 0, 1))
+	}
+
+	if query.Status != "" {
+		db = db.Where("transaction_details.transaction_status = ?", query.Status)
+	}
+
+	// Plan and user belong to the subscription the transaction paid for
+	if query.PlanID != "" || query.UserID != "" {
+		subscriptions := s.DB.Model(&model.UserSubscription{}).Select("id")
+		if query.PlanID != "" {
+			subscriptions = subscriptions.Where("plan_id = ?", query.PlanID)
+		}
+		if query.UserID != "" {
+			subscriptions = subscriptions.Where("user_id = ?", query.UserID)
+		}
+		db = db.Where("transaction_details.user_subscription_id IN (?)", subscriptions)
+	}
+
+	// Count total results
+	if err := db.Count(&totalResults).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	if err := db.
+		Preload("UserSubscription").
+		Preload("UserSubscription.User").
+		Order("transaction_details.created_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
 		Find(&transactions).Error; err != nil {
 		return nil, 0, err
 	}
@@ -722,12 +1859,13 @@ func (s *subscriptionService) GetAllTransactions(ctx *fiber.Ctx, page, limit int
 func (s *subscriptionService) GetTransactionByID(ctx *fiber.Ctx, transactionID uuid.UUID) (*model.TransactionDetail, error) {
 	var transaction model.TransactionDetail
 
-	if err := s.DB.WithContext(ctx.Context()).
-		Preload("UserSubscription").
-		Preload("UserSubscription.User").
-		Preload("UserSubscription.Plan").
-		Where("id = ?", transactionID).
-		First(&transaction).Error; err != nil {
+	if err := firstWithArchive(s.DB.WithContext(ctx.UserContext()), model.TransactionDetailsTable, &transaction, func(db *gorm.DB) *gorm.DB {
+		return db.
+			Preload("UserSubscription").
+			Preload("UserSubscription.User").
+			Preload("UserSubscription.Plan").
+			Where("id = ?", transactionID)
+	}); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fiber.NewError(fiber.StatusNotFound, "Transaction not found")
 		}
@@ -737,25 +1875,445 @@ func (s *subscriptionService) GetTransactionByID(ctx *fiber.Ctx, transactionID u
 	return &transaction, nil
 }
 
+// TransactionExport reads the rows of an export and passes them to write one at a time, it stops at the first
+// error of write
+type TransactionExport func(write func(*model.TransactionExportRow) error) error
+
+// ExportTransactions checks the filters of an export and returns the export to run. The export reads live
+// and archived transaction logs, newest first, without holding them in memory. It outlives the request, so
+// it gets its own deadline of config.DBExportTimeout.
+func (s *subscriptionService) ExportTransactions(ctx *fiber.Ctx, query *validation.TransactionExportQuery) (TransactionExport, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	var from, to time.Time
+	if query.From != "" {
+		from, _ = time.ParseInLocation("2006-01-02", query.From, time.Local)
+	}
+	if query.To != "" {
+		to, _ = time.ParseInLocation("2006-01-02", query.To, time.Local)
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must not be after to")
+	}
+
+	rows := func(db *gorm.DB, table string) *gorm.DB {
+		db = db.Table(table + " AS t").
+			Select("t.id, t.order_id, t.transaction_id, t.transaction_time, t.transaction_status, t.payment_type, " +
+				"t.gross_amount, t.currency, t.is_sandbox, t.settlement_time, t.user_subscription_id, " +
+				"us.user_id, u.email AS user_email, us.plan_id, p.name AS plan_name, t.created_at").
+			Joins("LEFT JOIN user_subscriptions us ON us.id = t.user_subscription_id").
+			Joins("LEFT JOIN users u ON u.id = us.user_id").
+			Joins("LEFT JOIN subscription_plans p ON p.id = us.plan_id")
+		if !from.IsZero() {
+			db = db.Where("t.transaction_time >= ?", from)
+		}
+		if !to.IsZero() {
+			db = db.Where("t.transaction_time < ?", to.AddDate(0, 0, 1))
+		}
+		if query.Status != "" {
+			db = db.Where("t.transaction_status = ?", query.Status)
+		}
+		if query.PlanID != "" {
+			db = db.Where("us.plan_id = ?", query.PlanID)
+		}
+		return db.Order("t.created_at DESC")
+	}
+
+	parent := context.WithoutCancel(ctx.UserContext())
+	return func(write func(*model.TransactionExportRow) error) error {
+		exportCtx, cancel := context.WithTimeout(parent, config.DBExportTimeout)
+		defer cancel()
+
+		db := s.DB.WithContext(exportCtx)
+		for _, table := range []string{model.TransactionDetailsTable, model.ArchiveTable(model.TransactionDetailsTable)} {
+			if err := streamRows(db, rows(db, table), write); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+// streamRows scans the rows of query one at a time into write
+func streamRows[T any](db *gorm.DB, query *gorm.DB, write func(*T) error) error {
+	cursor, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	for cursor.Next() {
+		var row T
+		if err := db.ScanRows(cursor, &row); err != nil {
+			return err
+		}
+		if err := write(&row); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// CreateManualTransaction records an offline bank transfer and activates the subscription
+func (s *subscriptionService) CreateManualTransaction(ctx *fiber.Ctx, adminID uuid.UUID, req *validation.CreateManualTransaction, proof *multipart.FileHeader) (*model.TransactionDetail, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var user model.User
+	if err := s.DB.WithContext(ctx.UserContext()).First(&user, "id = ?", req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
+		}
+		return nil, err
+	}
+
+	var plan model.SubscriptionPlan
+	if err := s.DB.WithContext(ctx.UserContext()).First(&plan, "id = ?", req.PlanID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+		}
+		return nil, err
+	}
+
+	transferredAt := time.Now()
+	if req.TransferredAt != "" {
+		// The admin enters the day of the transfer in local time, like the report filters
+		if parsed, err := time.ParseInLocation("2006-01-02", req.TransferredAt, time.Local); err == nil {
+			transferredAt = parsed
+		}
+	}
+
+	amount := plan.Price
+	if req.Amount > 0 {
+		amount = req.Amount
+	}
+
+	// Two transfers of a user can be recorded within the same second, the order ID must not tell them apart by time
+	orderID := "MANUAL-" + uuid.NewString()
+
+	subscription := model.UserSubscription{
+		UserID:          user.ID,
+		PlanID:          plan.ID,
+		StartDate:       time.Now(),
+		EndDate:         time.Now().AddDate(0, 0, plan.ValidityDays),
+		PaymentMethod:   "bank_transfer",
+		TransactionID:   orderID,
+		PaymentStatus:   "pending",
+		IsActive:        false,
+		Source:          model.SourceManualTransfer,
+		SourceReference: orderID,
+		SourceMetadata: model.NewSourceMetadata(map[string]interface{}{
+			"recorded_by":      adminID.String(),
+			"bank_name":        req.BankName,
+			"reference_number": req.ReferenceNumber,
+		}),
+	}
+
+	proofURL, err := utils.SaveUploadedImage(ctx, proof, "payment-proofs")
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &model.TransactionDetail{
+		OrderID:           orderID,
+		TransactionID:     orderID,
+		TransactionStatus: "settlement",
+		TransactionTime:   transferredAt,
+		StatusMessage:     "Manual bank transfer recorded by admin",
+		PaymentType:       "manual_bank_transfer",
+		GrossAmount:       model.IDR(amount).Decimal(),
+		Currency:          model.CurrencyIDR,
+		SettlementTime:    &transferredAt,
+		ProofImageURL:     &proofURL,
+		RecordedByID:      &adminID,
+	}
+
+	if req.BankName != "" {
+		detail.Bank = &req.BankName
+	}
+	if req.ReferenceNumber != "" {
+		detail.ReferenceNumber = &req.ReferenceNumber
+	}
+	if req.Notes != "" {
+		detail.Notes = &req.Notes
+	}
+
+	// The subscription, its payment and its revenue are saved together, the proof is only kept with them
+	if err := s.DB.WithContext(ctx.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := createSubscription(tx, &subscription, "manual transfer recorded", &adminID); err != nil {
+			return fmt.Errorf("failed to create subscription: %w", err)
+		}
+
+		// Manual transfers are confirmed by the admin, so they settle immediately
+		s.applyPaymentStatus(&subscription, "settlement")
+		detail.UserSubscriptionID = subscription.ID
+		if err := snapshotPlan(tx, &subscription, detail); err != nil {
+			return err
+		}
+		if err := syncSubscriptionStatus(tx, &subscription, "payment settlement", &adminID, time.Now()); err != nil {
+			return err
+		}
+		if err := tx.Save(&subscription).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(detail).Error; err != nil {
+			return err
+		}
+		return recognizeRevenue(tx, detail, &subscription)
+	}); err != nil {
+		if removeErr := utils.RemoveUploadedFile(proofURL); removeErr != nil {
+			s.Log.Errorf("Failed to remove payment proof %s of an unrecorded transfer: %v", proofURL, removeErr)
+		}
+		s.Log.Errorf("Failed to record manual transfer %s: %v", orderID, err)
+		return nil, err
+	}
+
+	return s.GetTransactionByID(ctx, detail.ID)
+}
+
+// CompSubscription grants a complimentary subscription, no payment is recorded for it
+func (s *subscriptionService) CompSubscription(ctx *fiber.Ctx, adminID uuid.UUID, req *validation.CompSubscription) (*model.UserSubscriptionResponse, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var user model.User
+	if err := s.DB.WithContext(ctx.UserContext()).First(&user, "id = ?", req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
+		}
+		return nil, err
+	}
+
+	var plan model.SubscriptionPlan
+	if err := s.DB.WithContext(ctx.UserContext()).First(&plan, "id = ?", req.PlanID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+		}
+		return nil, err
+	}
+
+	durationDays := plan.ValidityDays
+	if req.DurationDays > 0 {
+		durationDays = req.DurationDays
+	}
+
+	subscription := model.UserSubscription{
+		UserID:        user.ID,
+		PlanID:        plan.ID,
+		Plan:          plan,
+		StartDate:     time.Now(),
+		EndDate:       time.Now().AddDate(0, 0, durationDays),
+		PaymentMethod: "comp",
+		TransactionID: fmt.Sprintf("COMP-%s-%d", user.ID.String()[:8], time.Now().Unix()),
+		PaymentStatus: "success",
+		IsActive:      true,
+		Source:        model.SourceAdminComp,
+		SourceMetadata: model.NewSourceMetadata(map[string]interface{}{
+			"granted_by": adminID.String(),
+			"reason":     req.Reason,
+		}),
+	}
+
+	if err := snapshotPlan(s.DB.WithContext(ctx.UserContext()), &subscription, nil); err != nil {
+		return nil, err
+	}
+	if err := createSubscription(s.DB.WithContext(ctx.UserContext()), &subscription, "complimentary: "+req.Reason, &adminID); err != nil {
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	return s.toSubscriptionResponse(&subscription)
+}
+
+// ConfirmManualPayment settles a pending subscription whose payment was verified outside the gateway
+func (s *subscriptionService) ConfirmManualPayment(ctx *fiber.Ctx, subscriptionID uuid.UUID, detail *model.TransactionDetail) (*model.UserSubscriptionResponse, error) {
+	var subscription model.UserSubscription
+	if err := s.DB.WithContext(ctx.UserContext()).
+		Preload("Plan").
+		Where("user_subscriptions.id = ?", subscriptionID).
+		First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription not found")
+		}
+		return nil, err
+	}
+
+	if subscription.PaymentStatus == "success" {
+		return nil, fiber.NewError(fiber.StatusConflict, "Subscription has already been paid")
+	}
+
+	s.applyPaymentStatus(&subscription, "settlement")
+	if _, err := nextSubscriptionStatus(&subscription, time.Now()); err != nil {
+		return nil, err
+	}
+
+	detail.UserSubscriptionID = subscription.ID
+	detail.OrderID = subscription.TransactionID
+	detail.TransactionStatus = "settlement"
+	if detail.TransactionTime.IsZero() {
+		detail.TransactionTime = time.Now()
+	}
+	if detail.Currency == "" {
+		detail.Currency = "IDR"
+	}
+
+	if err := s.recordTransaction(ctx, &subscription, detail); err != nil {
+		return nil, err
+	}
+
+	return s.toSubscriptionResponse(&subscription)
+}
+
+// ReleaseHeldPayment applies an admin fraud review decision to a subscription.
+// Approval activates a held payment, rejection refunds it or cancels a checkout that is still unpaid.
+func (s *subscriptionService) ReleaseHeldPayment(ctx *fiber.Ctx, subscriptionID uuid.UUID, approved bool) (*model.UserSubscriptionResponse, error) {
+	var subscription model.UserSubscription
+	if err := s.DB.WithContext(ctx.UserContext()).
+		Preload("Plan").
+		Where("user_subscriptions.id = ?", subscriptionID).
+		First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription not found")
+		}
+		return nil, err
+	}
+
+	switch {
+	case approved && subscription.PaymentStatus == "on_hold":
+		s.applyPaymentStatus(&subscription, "settlement")
+	case !approved && subscription.PaymentStatus == "on_hold":
+		gateway, err := paymentGateway(s.Payment, s.SandboxPayment, subscription.IsSandbox)
+		if err != nil {
+			return nil, err
+		}
+		if err := gateway.Refund(subscription.TransactionID); err != nil {
+			return nil, fmt.Errorf("failed to refund payment: %w", err)
+		}
+		subscription.PaymentStatus = "refunded"
+		subscription.IsActive = false
+
+		// The held payment may be discounted or a first installment, so the gateway amount is what was refunded
+		var held model.TransactionDetail
+		if err := s.DB.WithContext(ctx.UserContext()).
+			Where("order_id = ?", subscription.TransactionID).
+			Order("transaction_time DESC").
+			First(&held).Error; err == nil && !held.IsSandbox {
+			s.Alerts.Emit(ctx.UserContext(), model.AlertLargeRefund, held.Amount(),
+				fmt.Sprintf("Refunded %s for held order %s", held.Money(), subscription.TransactionID))
+		}
+	case !approved && subscription.PaymentStatus == "pending":
+		s.applyPaymentStatus(&subscription, "deny")
+	default:
+		// Unpaid approved checkouts settle normally once the payment arrives
+		return s.toSubscriptionResponse(&subscription)
+	}
+
+	if err := snapshotPlan(s.DB.WithContext(ctx.UserContext()), &subscription, nil); err != nil {
+		return nil, err
+	}
+	if err := s.DB.WithContext(ctx.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := syncSubscriptionStatus(tx, &subscription, "fraud review", requestActor(ctx), time.Now()); err != nil {
+			return err
+		}
+		return tx.Save(&subscription).Error
+	}); err != nil {
+		s.Log.Errorf("Failed to update subscription %s: %v", subscription.ID, err)
+		return nil, fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	if !approved && subscription.WalletAmountApplied > 0 {
+		if err := s.Wallet.ReverseCheckout(ctx, subscription.UserID, subscription.TransactionID); err != nil {
+			s.Log.Errorf("Failed to return wallet credit for subscription %s: %v", subscription.ID, err)
+		}
+	}
+	if approved {
+		s.completeUpgrade(ctx, &subscription)
+	}
+
+	return s.toSubscriptionResponse(&subscription)
+}
+
 func (s *subscriptionService) GetSubscriptionPlanByID(ctx *fiber.Ctx, planID uuid.UUID) (*model.SubscriptionPlan, error) {
 	var plan model.SubscriptionPlan
 
-	if err := s.DB.WithContext(ctx.Context()).First(&plan, "id = ?", planID).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).First(&plan, "id = ?", planID).Error; err != nil {
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+func (s *subscriptionService) CreateSubscriptionPlan(ctx *fiber.Ctx, adminID uuid.UUID, req *validation.CreateSubscriptionPlan) (*model.SubscriptionPlan, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	plan := model.SubscriptionPlan{
+		Name:              req.Name,
+		Price:             req.Price,
+		Currency:          req.Currency,
+		Description:       req.Description,
+		AIscanLimit:       req.AIscanLimit,
+		ValidityDays:      req.ValidityDays,
+		IsActive:          req.IsActive == nil || *req.IsActive,
+		AllowInstallments: req.AllowInstallments,
+		InstallmentCount:  req.InstallmentCount,
+		Hidden:            req.Hidden,
+		LimitsPrivate:     req.LimitsPrivate,
+		TrialDays:         req.TrialDays,
+		ChatMessageLimit:  30,
+		VoiceLogLimit:     30,
+	}
+	if plan.Currency == "" {
+		plan.Currency = model.CurrencyIDR
+	}
+	if req.ChatMessageLimit != nil {
+		plan.ChatMessageLimit = *req.ChatMessageLimit
+	}
+	if req.VoiceLogLimit != nil {
+		plan.VoiceLogLimit = *req.VoiceLogLimit
+	}
+
+	var err error
+	if plan.AvailableFrom, err = parseAvailability(req.AvailableFrom); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid available_from, expected RFC3339 timestamp")
+	}
+	if plan.AvailableUntil, err = parseAvailability(req.AvailableUntil); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid available_until, expected RFC3339 timestamp")
+	}
+	if err := checkPlanTerms(&plan); err != nil {
+		return nil, err
+	}
+
+	if plan.Features, err = model.ParsePlanFeatures(req.Features); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := s.DB.WithContext(ctx.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&plan).Error; err != nil {
+			return err
+		}
+		return recordPlanChange(tx, model.PlanChangeCreate, adminID, nil, &plan, nil)
+	}); err != nil {
 		return nil, err
 	}
 
 	return &plan, nil
 }
 
-func (s *subscriptionService) UpdateSubscriptionPlan(ctx *fiber.Ctx, planID uuid.UUID, req *validation.UpdateSubscriptionPlan) (*model.SubscriptionPlan, error) {
+func (s *subscriptionService) UpdateSubscriptionPlan(ctx *fiber.Ctx, adminID, planID uuid.UUID, req *validation.UpdateSubscriptionPlan) (*model.SubscriptionPlan, error) {
 	var plan model.SubscriptionPlan
 
-	if err := s.DB.WithContext(ctx.Context()).First(&plan, "id = ?", planID).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).First(&plan, "id = ?", planID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
 		}
 		return nil, err
 	}
+	before := plan
 
 	// Update fields if provided
 	if req.Name != nil {
@@ -766,6 +2324,10 @@ func (s *subscriptionService) UpdateSubscriptionPlan(ctx *fiber.Ctx, planID uuid
 		plan.Price = *req.Price
 	}
 
+	if req.Currency != nil {
+		plan.Currency = *req.Currency
+	}
+
 	if req.ValidityDays != nil {
 		plan.ValidityDays = *req.ValidityDays
 	}
@@ -774,7 +2336,18 @@ func (s *subscriptionService) UpdateSubscriptionPlan(ctx *fiber.Ctx, planID uuid
 		plan.AIscanLimit = *req.AIscanLimit
 	}
 
+	if req.ChatMessageLimit != nil {
+		plan.ChatMessageLimit = *req.ChatMessageLimit
+	}
+
+	if req.VoiceLogLimit != nil {
+		plan.VoiceLogLimit = *req.VoiceLogLimit
+	}
+
 	if req.IsActive != nil {
+		if *req.IsActive && plan.ArchivedAt != nil {
+			return nil, fiber.NewError(fiber.StatusConflict, "Archived plans cannot be activated again, create a new plan")
+		}
 		plan.IsActive = *req.IsActive
 	}
 
@@ -782,19 +2355,258 @@ func (s *subscriptionService) UpdateSubscriptionPlan(ctx *fiber.Ctx, planID uuid
 		plan.Description = *req.Description
 	}
 
+	if req.AllowInstallments != nil {
+		plan.AllowInstallments = *req.AllowInstallments
+	}
+
+	if req.InstallmentCount != nil {
+		plan.InstallmentCount = *req.InstallmentCount
+	}
+
+	if req.Hidden != nil {
+		plan.Hidden = *req.Hidden
+	}
+
+	if req.LimitsPrivate != nil {
+		plan.LimitsPrivate = *req.LimitsPrivate
+	}
+
+	if req.TrialDays != nil {
+		plan.TrialDays = *req.TrialDays
+	}
+
+	if req.AvailableFrom != nil {
+		availableFrom, err := parseAvailability(*req.AvailableFrom)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid available_from, expected RFC3339 timestamp")
+		}
+		plan.AvailableFrom = availableFrom
+	}
+
+	if req.AvailableUntil != nil {
+		availableUntil, err := parseAvailability(*req.AvailableUntil)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid available_until, expected RFC3339 timestamp")
+		}
+		plan.AvailableUntil = availableUntil
+	}
+
+	if err := checkPlanTerms(&plan); err != nil {
+		return nil, err
+	}
+
 	// Update features if provided
 	if req.Features != nil {
-		featuresJSON, err := json.Marshal(req.Features)
+		features, err := model.ParsePlanFeatures(*req.Features)
 		if err != nil {
-			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid features format")
+			return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
-		plan.Features = string(featuresJSON)
+		plan.Features = features
 	}
 
 	// Save changes
-	if err := s.DB.WithContext(ctx.Context()).Save(&plan).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&plan).Error; err != nil {
+			return err
+		}
+		return recordPlanChange(tx, model.PlanChangeUpdate, adminID, &before, &plan, nil)
+	}); err != nil {
 		return nil, err
 	}
 
 	return &plan, nil
 }
+
+// deleteSubscriptionPlan deletes a plan nothing refers to and returns nil. Plans with subscriptions, checkouts,
+// store products, product tokens or coupons are archived instead and returned.
+func deleteSubscriptionPlan(db *gorm.DB, adminID, planID uuid.UUID) (*model.SubscriptionPlan, error) {
+	var archived *model.SubscriptionPlan
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var plan model.SubscriptionPlan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&plan, "id = ?", planID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+			}
+			return err
+		}
+		if plan.ArchivedAt != nil {
+			archived = &plan
+			return nil
+		}
+
+		// Subscribers keep their subscription and its history, a plan anything refers to is only retired
+		referenced := false
+		for _, reference := range []struct {
+			model  any
+			column string
+		}{
+			{&model.UserSubscription{}, "plan_id"},
+			{&model.CheckoutSession{}, "plan_id"},
+			{&model.StoreProduct{}, "plan_id"},
+			{&model.ProductToken{}, "subscription_plan_id"},
+			{&model.Coupon{}, "plan_id"},
+			{&model.SubscriptionPlan{}, "replacement_plan_id"},
+		} {
+			var count int64
+			if err := tx.Model(reference.model).Where(reference.column+" = ?", plan.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				referenced = true
+				break
+			}
+		}
+
+		if !referenced {
+			if err := tx.Delete(&plan).Error; err != nil {
+				return err
+			}
+			return recordPlanChange(tx, model.PlanChangeDelete, adminID, &plan, nil, nil)
+		}
+
+		before := plan
+		now := time.Now()
+		plan.IsActive = false
+		plan.Hidden = true
+		plan.ArchivedAt = &now
+		if err := tx.Model(&plan).Select("IsActive", "Hidden", "ArchivedAt").Updates(&plan).Error; err != nil {
+			return err
+		}
+		archived = &plan
+		return recordPlanChange(tx, model.PlanChangeArchive, adminID, &before, &plan, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return archived, nil
+}
+
+func (s *subscriptionService) GetPlanHistory(ctx *fiber.Ctx, planID uuid.UUID, query *validation.PlanChangeQuery) ([]model.PlanChange, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	db := s.DB.WithContext(ctx.UserContext()).Model(&model.PlanChange{}).Where("plan_id = ?", planID)
+
+	var totalResults int64
+	if err := db.Count(&totalResults).Error; err != nil {
+		return nil, 0, err
+	}
+	if totalResults == 0 {
+		var count int64
+		if err := s.DB.WithContext(ctx.UserContext()).Model(&model.SubscriptionPlan{}).Where("id = ?", planID).Count(&count).Error; err != nil {
+			return nil, 0, err
+		}
+		if count == 0 {
+			return nil, 0, fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+		}
+	}
+
+	var changes []model.PlanChange
+	if err := db.
+		Order("changed_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&changes).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return changes, totalResults, nil
+}
+
+func (s *subscriptionService) RollbackPlanChange(ctx *fiber.Ctx, adminID, planID, changeID uuid.UUID) (*model.SubscriptionPlan, error) {
+	var plan model.SubscriptionPlan
+	err := s.DB.WithContext(ctx.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&plan, "id = ?", planID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, "Subscription plan not found")
+			}
+			return err
+		}
+
+		var change model.PlanChange
+		if err := tx.First(&change, "id = ? AND plan_id = ?", changeID, planID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, "Plan change not found")
+			}
+			return err
+		}
+		if !change.CanRollback() {
+			return fiber.NewError(fiber.StatusConflict, "Only edits of a plan can be rolled back")
+		}
+
+		before := plan
+		if err := change.Revert(&plan); err != nil {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		if plan.IsActive && plan.ArchivedAt != nil {
+			return fiber.NewError(fiber.StatusConflict, "Archived plans cannot be activated again, create a new plan")
+		}
+		if err := checkPlanTerms(&plan); err != nil {
+			return err
+		}
+
+		if err := tx.Save(&plan).Error; err != nil {
+			return err
+		}
+		return recordPlanChange(tx, model.PlanChangeRollback, adminID, &before, &plan, &change.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Log.Infof("Rolled back change %s of plan %s", changeID, planID)
+	return &plan, nil
+}
+
+// checkPlanTerms rejects installment and availability settings that contradict each other
+func checkPlanTerms(plan *model.SubscriptionPlan) error {
+	if plan.AllowInstallments && plan.InstallmentCount < 2 {
+		return fiber.NewError(fiber.StatusBadRequest, "Installment count must be at least 2 when installments are allowed")
+	}
+	if plan.AvailableFrom != nil && plan.AvailableUntil != nil && !plan.AvailableFrom.Before(*plan.AvailableUntil) {
+		return fiber.NewError(fiber.StatusBadRequest, "available_from must be before available_until")
+	}
+	return nil
+}
+
+// recordPlanChange adds a change of a plan to its history with the terms it changed, edits that changed nothing
+// are left out. A nil before records a created plan, a nil after a deleted one.
+func recordPlanChange(db *gorm.DB, action string, adminID uuid.UUID, before, after *model.SubscriptionPlan, revertsID *uuid.UUID) error {
+	changes := model.DiffPlans(before, after)
+	if len(changes) == 0 && action == model.PlanChangeUpdate {
+		return nil
+	}
+
+	change := model.PlanChange{
+		Action:      action,
+		Changes:     changes,
+		RevertsID:   revertsID,
+		ChangedByID: adminID,
+		ChangedAt:   time.Now(),
+	}
+	if after != nil {
+		change.PlanID = after.ID
+	} else {
+		change.PlanID = before.ID
+	}
+	if db.Statement.Context != nil {
+		change.RequestID = requestid.From(db.Statement.Context)
+	}
+	return db.Create(&change).Error
+}
+
+// parseAvailability reads an availability bound, an empty string clears it
+func parseAvailability(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
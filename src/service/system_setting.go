@@ -0,0 +1,26 @@
+package service
+
+import (
+	"app/src/model"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// claimDailyRun moves the date stored under key to day and reports whether this call moved it.
+// Jobs that run hourly on every instance use it so only one of them does the daily work.
+func claimDailyRun(ctx context.Context, db *gorm.DB, key, day string) (bool, error) {
+	result := db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "system_settings.value <> excluded.value"},
+		}},
+	}).Create(&model.SystemSetting{Key: key, Value: day})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
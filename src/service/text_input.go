@@ -0,0 +1,53 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/textfilter"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// profanityAction is the configured action for text whose worst word has the severity
+func profanityAction(severity textfilter.Severity) string {
+	switch severity {
+	case textfilter.SeverityMild:
+		return config.ProfanityMildAction
+	case textfilter.SeveritySevere:
+		return config.ProfanitySevereAction
+	default:
+		return textfilter.ActionAllow
+	}
+}
+
+// screenText normalizes text a user typed for others to see and applies the profanity action configured for
+// its worst word. Free text keeps its line breaks. label names the input in errors.
+func screenText(label, text string, multiline bool) (string, error) {
+	if multiline {
+		text = textfilter.NormalizeMultiline(text)
+	} else {
+		text = textfilter.Normalize(text)
+	}
+	if text == "" {
+		return "", fiber.NewError(fiber.StatusBadRequest, label+" must not be empty")
+	}
+
+	switch profanityAction(textfilter.Check(text)) {
+	case textfilter.ActionAllow:
+		return text, nil
+	case textfilter.ActionReject:
+		return "", fiber.NewError(fiber.StatusBadRequest, label+" contains language that is not allowed")
+	default:
+		return maskProfanity(text), nil
+	}
+}
+
+// maskProfanity masks the words of every severity whose action is not to allow them, unknown actions mask
+func maskProfanity(text string) string {
+	var severities []textfilter.Severity
+	for _, severity := range []textfilter.Severity{textfilter.SeverityMild, textfilter.SeveritySevere} {
+		if profanityAction(severity) != textfilter.ActionAllow {
+			severities = append(severities, severity)
+		}
+	}
+	return textfilter.Mask(text, severities...)
+}
@@ -100,17 +100,17 @@ func (s *tokenService) SaveToken(c *fiber.Ctx, token, userID, tokenType string,
 		Expires: expires,
 	}
 
-	return s.DB.WithContext(c.Context()).Create(tokenDoc).Error
+	return s.DB.WithContext(c.UserContext()).Create(tokenDoc).Error
 }
 
 // ✅ Hapus Token Berdasarkan Jenisnya
 func (s *tokenService) DeleteToken(c *fiber.Ctx, tokenType string, userID string) error {
-	return s.DB.WithContext(c.Context()).Where("type = ? AND user_id = ?", tokenType, userID).Delete(&model.Token{}).Error
+	return s.DB.WithContext(c.UserContext()).Where("type = ? AND user_id = ?", tokenType, userID).Delete(&model.Token{}).Error
 }
 
 // ✅ Hapus Semua Token User
 func (s *tokenService) DeleteAllToken(c *fiber.Ctx, userID string) error {
-	return s.DB.WithContext(c.Context()).Where("user_id = ?", userID).Delete(&model.Token{}).Error
+	return s.DB.WithContext(c.UserContext()).Where("user_id = ?", userID).Delete(&model.Token{}).Error
 }
 
 // ✅ Ambil Token Berdasarkan User ID
@@ -121,7 +121,7 @@ func (s *tokenService) GetTokenByUserID(c *fiber.Ctx, tokenStr string) (*model.T
 	}
 
 	tokenDoc := new(model.Token)
-	err = s.DB.WithContext(c.Context()).Where("token = ? AND user_id = ?", tokenStr, userID).First(tokenDoc).Error
+	err = s.DB.WithContext(c.UserContext()).Where("token = ? AND user_id = ?", tokenStr, userID).First(tokenDoc).Error
 	return tokenDoc, err
 }
 
@@ -129,7 +129,7 @@ func (s *tokenService) GetTokenByUserID(c *fiber.Ctx, tokenStr string) (*model.T
 func (s *tokenService) GenerateAuthTokens(c *fiber.Ctx, user *model.User) (*res.Tokens, error) {
 	// Cek apakah user memiliki Product Token yang aktif
 	var isProductTokenVerified bool
-	if err := s.DB.WithContext(c.Context()).Where("user_id = ?", user.ID).First(&model.ProductToken{}).Error; err == nil {
+	if err := s.DB.WithContext(c.UserContext()).Where("user_id = ?", user.ID).First(&model.ProductToken{}).Error; err == nil {
 		isProductTokenVerified = true
 	}
 
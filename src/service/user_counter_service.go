@@ -0,0 +1,161 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Source queries of the denormalized user counters, %[1]s is the user ID column they are computed for
+const (
+	totalScansQuery = `SELECT COUNT(*) FROM (
+			SELECT meal_history_id FROM meal_history_details
+			UNION ALL
+			SELECT meal_history_id FROM meal_history_details_archive
+		) AS scans
+		JOIN meal_histories ON meal_histories.id = scans.meal_history_id
+		WHERE meal_histories.user_id = %[1]s`
+	totalLoggedDaysQuery = `SELECT COUNT(DISTINCT DATE(meal_time)) FROM meal_histories WHERE meal_histories.user_id = %[1]s`
+	// A streak only counts while the user logged in today or yesterday, the ? is the start of yesterday
+	currentStreakQuery = `SELECT COALESCE((SELECT current_streak FROM login_streaks
+		WHERE login_streaks.user_id = %[1]s AND login_date >= ? ORDER BY login_date DESC LIMIT 1), 0)`
+	lifetimeSpendQuery = `SELECT COALESCE(SUM(revenue_recognitions.amount), 0) FROM revenue_recognitions
+		JOIN user_subscriptions ON user_subscriptions.id = revenue_recognitions.user_subscription_id
+		WHERE user_subscriptions.user_id = %[1]s AND revenue_recognitions.currency = 'IDR'`
+)
+
+type UserCounterService interface {
+	// Reconcile recomputes every user's counters from the source tables once a night and alerts when they drifted
+	Reconcile(ctx context.Context) error
+}
+
+type userCounterService struct {
+	Log    *logrus.Logger
+	DB     *gorm.DB
+	Alerts AlertService
+}
+
+func NewUserCounterService(db *gorm.DB, alerts AlertService) UserCounterService {
+	return &userCounterService{
+		Log:    utils.Log,
+		DB:     db,
+		Alerts: alerts,
+	}
+}
+
+func (s *userCounterService) Reconcile(ctx context.Context) error {
+	now := time.Now()
+
+	// The first run fills the counters of existing users, it does not wait for the night and is not drift
+	var previous int64
+	if err := s.DB.WithContext(ctx).
+		Model(&model.SystemSetting{}).
+		Where("key = ?", model.SettingCountersReconciledOn).
+		Count(&previous).Error; err != nil {
+		return err
+	}
+	initializing := previous == 0
+
+	if !initializing && now.Hour() < config.UserCounterReconcileHour.Get() {
+		return nil
+	}
+
+	claimed, err := claimDailyRun(ctx, s.DB, model.SettingCountersReconciledOn, now.Format("2006-01-02"))
+	if err != nil || !claimed {
+		return err
+	}
+
+	since := streakCutoff(now)
+	db := s.DB.WithContext(ctx)
+
+	// Streaks of users who stopped logging in end without any event, that is expected and not drift
+	if err := db.Model(&model.User{}).
+		Where("current_streak > 0").
+		Where("NOT EXISTS (SELECT 1 FROM login_streaks WHERE login_streaks.user_id = users.id AND login_date >= ?)", since).
+		Update("current_streak", 0).Error; err != nil {
+		return err
+	}
+
+	expected := fmt.Sprintf(`SELECT u.id, (%s) AS total_scans, (%s) AS total_logged_days, (%s) AS current_streak, (%s) AS lifetime_spend FROM users u`,
+		fmt.Sprintf(totalScansQuery, "u.id"),
+		fmt.Sprintf(totalLoggedDaysQuery, "u.id"),
+		fmt.Sprintf(currentStreakQuery, "u.id"),
+		fmt.Sprintf(lifetimeSpendQuery, "u.id"),
+	)
+
+	result := db.Exec(`UPDATE users SET
+			total_scans = expected.total_scans,
+			total_logged_days = expected.total_logged_days,
+			current_streak = expected.current_streak,
+			lifetime_spend = expected.lifetime_spend,
+			counters_at = ?
+		FROM (`+expected+`) AS expected
+		WHERE users.id = expected.id
+			AND (users.total_scans, users.total_logged_days, users.current_streak, users.lifetime_spend)
+			IS DISTINCT FROM (expected.total_scans, expected.total_logged_days, expected.current_streak, expected.lifetime_spend)`,
+		now, since,
+	)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if initializing {
+		s.Log.Infof("Initialized counters of %d users", result.RowsAffected)
+		return nil
+	}
+
+	if result.RowsAffected > 0 {
+		s.Log.Warnf("User counter reconciliation corrected %d users", result.RowsAffected)
+		s.Alerts.Emit(ctx, model.AlertReconciliationMismatch, int(result.RowsAffected),
+			fmt.Sprintf("User counter reconciliation corrected %d users", result.RowsAffected))
+	}
+
+	return nil
+}
+
+// streakCutoff is the start of yesterday, a login since then keeps a streak alive
+func streakCutoff(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -1)
+}
+
+// incrementUserScans counts a completed meal scan
+func incrementUserScans(db *gorm.DB, userID uuid.UUID) error {
+	return db.Model(&model.User{}).
+		Where("id = ?", userID).
+		Update("total_scans", gorm.Expr("total_scans + 1")).Error
+}
+
+// recountUserScans refreshes the scan counter when scans are removed or attached to an existing meal
+func recountUserScans(db *gorm.DB, userID uuid.UUID) error {
+	return db.Model(&model.User{}).
+		Where("id = ?", userID).
+		Update("total_scans", gorm.Expr("("+fmt.Sprintf(totalScansQuery, "?")+")", userID)).Error
+}
+
+// recountLoggedDays refreshes the number of days with a meal, any meal added, moved or deleted can change it
+func recountLoggedDays(db *gorm.DB, userID uuid.UUID) error {
+	return db.Model(&model.User{}).
+		Where("id = ?", userID).
+		Update("total_logged_days", gorm.Expr("("+fmt.Sprintf(totalLoggedDaysQuery, "?")+")", userID)).Error
+}
+
+// setUserStreak stores the streak computed when the user logged in
+func setUserStreak(db *gorm.DB, userID uuid.UUID, streak int) error {
+	return db.Model(&model.User{}).
+		Where("id = ?", userID).
+		Update("current_streak", streak).Error
+}
+
+// recountLifetimeSpend refreshes the cash a user paid from the revenue recognized for their subscriptions
+func recountLifetimeSpend(db *gorm.DB, userID uuid.UUID) error {
+	return db.Model(&model.User{}).
+		Where("id = ?", userID).
+		Update("lifetime_spend", gorm.Expr("("+fmt.Sprintf(lifetimeSpendQuery, "?")+")", userID)).Error
+}
@@ -1,11 +1,14 @@
 package service
 
 import (
+	"app/src/config"
 	"app/src/model"
 	"app/src/response"
+	"app/src/textfilter"
 	"app/src/utils"
 	"app/src/validation"
 	"errors"
+	"fmt"
 
 	// "net/http"
 	// "strings"
@@ -25,6 +28,8 @@ type UserService interface {
 	CreateUser(c *fiber.Ctx, req *validation.CreateUser) (*model.User, error)
 	UpdatePassOrVerify(c *fiber.Ctx, req *validation.UpdatePassOrVerify, id string) error
 	UpdateUser(c *fiber.Ctx, req *validation.UpdateUser, id string) (*model.User, error)
+	// UpdateSandbox marks a QA account, its later checkouts use the gateway sandbox. Existing subscriptions keep their flag.
+	UpdateSandbox(c *fiber.Ctx, req *validation.UpdateUserSandbox, id string) (*model.User, error)
 	DeleteUser(c *fiber.Ctx, id string) error
 	CreateGoogleUser(c *fiber.Ctx, req *validation.GoogleLogin) (*model.User, error)
 	GetUserStatistics(c *fiber.Ctx, userID string) (*response.UserStatistics, error)
@@ -53,7 +58,12 @@ func (s *userService) GetUsers(c *fiber.Ctx, params *validation.QueryUser) ([]mo
 	}
 
 	offset := (params.Page - 1) * params.Limit
-	query := s.DB.WithContext(c.Context()).Order("created_at asc")
+	query := s.DB.WithContext(c.UserContext())
+	if params.SortBy != "" {
+		// Counters are denormalized on the user row, sorting by them needs no aggregate query
+		query = query.Order(params.SortBy + " desc")
+	}
+	query = query.Order("created_at asc")
 
 	if search := params.Search; search != "" {
 		query = query.Where("name LIKE ? OR email LIKE ? OR role LIKE ?",
@@ -78,7 +88,7 @@ func (s *userService) GetUsers(c *fiber.Ctx, params *validation.QueryUser) ([]mo
 func (s *userService) GetUserByID(c *fiber.Ctx, id string) (*model.User, error) {
 	user := new(model.User)
 
-	result := s.DB.WithContext(c.Context()).First(user, "id = ?", id)
+	result := s.DB.WithContext(c.UserContext()).First(user, "id = ?", id)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
@@ -94,7 +104,7 @@ func (s *userService) GetUserByID(c *fiber.Ctx, id string) (*model.User, error)
 func (s *userService) GetUserByEmail(c *fiber.Ctx, email string) (*model.User, error) {
 	user := new(model.User)
 
-	result := s.DB.WithContext(c.Context()).Where("email = ?", email).First(user)
+	result := s.DB.WithContext(c.UserContext()).Where("email = ?", email).First(user)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
@@ -112,6 +122,15 @@ func (s *userService) CreateUser(c *fiber.Ctx, req *validation.CreateUser) (*mod
 		return nil, err
 	}
 
+	if _, ok := config.RightsOf(req.Role); !ok {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Unknown role %q", req.Role))
+	}
+
+	name, err := screenText("Name", req.Name, false)
+	if err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
 		s.Log.Errorf("Failed hash password: %+v", err)
@@ -119,13 +138,13 @@ func (s *userService) CreateUser(c *fiber.Ctx, req *validation.CreateUser) (*mod
 	}
 
 	user := &model.User{
-		Name:     req.Name,
+		Name:     name,
 		Email:    req.Email,
 		Password: hashedPassword,
 		Role:     req.Role,
 	}
 
-	result := s.DB.WithContext(c.Context()).Create(user)
+	result := s.DB.WithContext(c.UserContext()).Create(user)
 
 	if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
 		return nil, fiber.NewError(fiber.StatusConflict, "Email is already in use")
@@ -149,12 +168,20 @@ func (s *userService) UpdateUser(c *fiber.Ctx, req *validation.UpdateUser, id st
 		return nil, fiber.NewError(fiber.StatusBadRequest, "No fields to update")
 	}
 
+	var name string
+	if req.Name != "" {
+		var err error
+		if name, err = screenText("Name", req.Name, false); err != nil {
+			return nil, err
+		}
+	}
+
 	currentUser, err := s.GetUserByID(c, id)
 	if err != nil {
 		return nil, err
 	}
 
-	tx := s.DB.WithContext(c.Context()).Begin()
+	tx := s.DB.WithContext(c.UserContext()).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -163,8 +190,8 @@ func (s *userService) UpdateUser(c *fiber.Ctx, req *validation.UpdateUser, id st
 
 	updateBody := &model.User{}
 
-	if req.Name != "" {
-		updateBody.Name = req.Name
+	if name != "" {
+		updateBody.Name = name
 	}
 	if req.Email != "" {
 		updateBody.Email = req.Email
@@ -178,6 +205,12 @@ func (s *userService) UpdateUser(c *fiber.Ctx, req *validation.UpdateUser, id st
 	}
 	if req.BirthDate != nil {
 		updateBody.BirthDate = req.BirthDate
+		consentFor := model.ParentalConsentFor
+		if actor, ok := c.Locals("user").(*model.User); !ok || actor.ID == currentUser.ID {
+			consentFor = model.ParentalConsentOnSelfChange
+		}
+		updateBody.ParentalConsent = consentFor(currentUser.ParentalConsent, req.BirthDate,
+			config.ParentalConsentAge, time.Now())
 	}
 	if req.Height != nil {
 		updateBody.Height = req.Height
@@ -195,7 +228,7 @@ func (s *userService) UpdateUser(c *fiber.Ctx, req *validation.UpdateUser, id st
 		updateBody.MedicalHistory = req.MedicalHistory
 	}
 	if req.ProfilePicture != nil {
-		updateBody.ProfilePicture = *req.ProfilePicture
+		updateBody.ProfilePicture = model.Avatar(*req.ProfilePicture)
 	}
 
 	if err := tx.Model(&model.User{}).Where("id = ?", id).Updates(updateBody).Error; err != nil {
@@ -207,6 +240,15 @@ func (s *userService) UpdateUser(c *fiber.Ctx, req *validation.UpdateUser, id st
 		return nil, err
 	}
 
+	if req.Email != "" {
+		currentUser.Email = req.Email
+		if err := syncEmailDeliverability(tx, currentUser); err != nil {
+			tx.Rollback()
+			s.Log.Errorf("Failed to check the deliverability of the new email: %+v", err)
+			return nil, err
+		}
+	}
+
 	if req.Weight != nil || req.Height != nil {
 		weight := currentUser.Weight
 		if req.Weight != nil {
@@ -251,6 +293,13 @@ func (s *userService) UpdateUser(c *fiber.Ctx, req *validation.UpdateUser, id st
 		return nil, err
 	}
 
+	if !currentUser.ProfileComplete() && updatedUser.ProfileComplete() {
+		if err := appendEvent(s.DB.WithContext(c.UserContext()), model.EventProfileCompleted, updatedUser.ID, updatedUser.ID,
+			model.EventPayload{}, time.Now()); err != nil {
+			s.Log.Errorf("Failed to append profile_completed event of user %s: %v", updatedUser.ID, err)
+		}
+	}
+
 	return updatedUser, nil
 }
 
@@ -276,7 +325,7 @@ func (s *userService) UpdatePassOrVerify(c *fiber.Ctx, req *validation.UpdatePas
 		VerifiedEmail: req.VerifiedEmail,
 	}
 
-	result := s.DB.WithContext(c.Context()).Where("id = ?", id).Updates(updateBody)
+	result := s.DB.WithContext(c.UserContext()).Where("id = ?", id).Updates(updateBody)
 
 	if result.RowsAffected == 0 {
 		return fiber.NewError(fiber.StatusNotFound, "User not found")
@@ -289,10 +338,30 @@ func (s *userService) UpdatePassOrVerify(c *fiber.Ctx, req *validation.UpdatePas
 	return result.Error
 }
 
+func (s *userService) UpdateSandbox(c *fiber.Ctx, req *validation.UpdateUserSandbox, id string) (*model.User, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.GetUserByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).
+		Model(user).
+		Update("is_sandbox", *req.IsSandbox).Error; err != nil {
+		s.Log.Errorf("Failed to update sandbox flag of user %s: %+v", id, err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
 func (s *userService) DeleteUser(c *fiber.Ctx, id string) error {
 	user := new(model.User)
 
-	result := s.DB.WithContext(c.Context()).Delete(user, "id = ?", id)
+	result := s.DB.WithContext(c.UserContext()).Delete(user, "id = ?", id)
 
 	if result.RowsAffected == 0 {
 		return fiber.NewError(fiber.StatusNotFound, "User not found")
@@ -313,15 +382,17 @@ func (s *userService) CreateGoogleUser(c *fiber.Ctx, req *validation.GoogleLogin
 	userFromDB, err := s.GetUserByEmail(c, req.Email)
 	if err != nil {
 		if err.Error() == "User not found" {
+			// The name comes from the Google account, it is masked rather than refused so the user can still sign in
 			user := &model.User{
-				Name:           req.Name,
+				Name:           maskProfanity(textfilter.Normalize(req.Name)),
 				Email:          req.Email,
 				VerifiedEmail:  true,
-				ProfilePicture: req.ProfilePicture,
+				ProfilePicture: model.Avatar(req.ProfilePicture),
 				GoogleIDToken:  req.GoogleIDToken,
 			}
+			user.AcquisitionSource = model.NormalizeAcquisitionSource(req.AcquisitionSource)
 
-			if createErr := s.DB.WithContext(c.Context()).Create(user).Error; createErr != nil {
+			if createErr := s.DB.WithContext(c.UserContext()).Create(user).Error; createErr != nil {
 				s.Log.Errorf("Failed to create user: %+v", createErr)
 				return nil, createErr
 			}
@@ -332,7 +403,7 @@ func (s *userService) CreateGoogleUser(c *fiber.Ctx, req *validation.GoogleLogin
 		return nil, err
 	}
 
-	if updateErr := s.DB.WithContext(c.Context()).Save(userFromDB).Error; updateErr != nil {
+	if updateErr := s.DB.WithContext(c.UserContext()).Omit(model.UserCounterFields...).Save(userFromDB).Error; updateErr != nil {
 		s.Log.Errorf("Failed to update user: %+v", updateErr)
 		return nil, updateErr
 	}
@@ -345,7 +416,7 @@ func (s *userService) GetUserStatistics(c *fiber.Ctx, userID string) (*response.
 		Height     float64   `json:"height"`
 		RecordedAt time.Time `json:"recorded_at"`
 	}
-	if err := s.DB.WithContext(c.Context()).
+	if err := s.DB.WithContext(c.UserContext()).
 		Model(&model.UsersWeightHeightHistory{}).
 		Where("user_id = ?", userID).
 		Order("recorded_at asc").
@@ -367,7 +438,7 @@ func (s *userService) GetUserStatistics(c *fiber.Ctx, userID string) (*response.
 		Weight     float64   `json:"weight"`
 		RecordedAt time.Time `json:"recorded_at"`
 	}
-	if err := s.DB.WithContext(c.Context()).
+	if err := s.DB.WithContext(c.UserContext()).
 		Model(&model.UsersWeightHeightHistory{}).
 		Where("user_id = ?", userID).
 		Order("recorded_at asc").
@@ -389,7 +460,7 @@ func (s *userService) GetUserStatistics(c *fiber.Ctx, userID string) (*response.
 		Calories   float64   `json:"calories"`
 		RecordedAt time.Time `json:"recorded_at"`
 	}
-	if err := s.DB.WithContext(c.Context()).
+	if err := s.DB.WithContext(c.UserContext()).
 		Model(&model.MealHistory{}).
 		Where("user_id = ?", userID).
 		Order("meal_time asc").
@@ -42,7 +42,7 @@ func (s *usersWeightHeightService) AddWeightHeight(ctx *fiber.Ctx, record *model
 		record.RecordedAt = time.Now()
 	}
 
-	if err := s.DB.WithContext(ctx.Context()).Create(record).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).Create(record).Error; err != nil {
 		s.Log.Errorf("Failed to add weight and height record: %+v", err)
 		return nil, err
 	}
@@ -57,7 +57,7 @@ func (s *usersWeightHeightService) AddWeightHeight(ctx *fiber.Ctx, record *model
 
 func (s *usersWeightHeightService) GetWeightHeights(ctx *fiber.Ctx, userID uuid.UUID) ([]model.UsersWeightHeightHistory, error) {
 	var records []model.UsersWeightHeightHistory
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Where("user_id = ?", userID).
 		Order("recorded_at DESC").
 		Find(&records).Error; err != nil {
@@ -70,7 +70,7 @@ func (s *usersWeightHeightService) GetWeightHeights(ctx *fiber.Ctx, userID uuid.
 
 func (s *usersWeightHeightService) GetWeightHeightByID(ctx *fiber.Ctx, recordID string, userID uuid.UUID) (*model.UsersWeightHeightHistory, error) {
 	existingRecord := new(model.UsersWeightHeightHistory)
-	if err := s.DB.WithContext(ctx.Context()).First(existingRecord, "id = ? AND user_id = ?", recordID, userID).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).First(existingRecord, "id = ? AND user_id = ?", recordID, userID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fiber.NewError(fiber.StatusNotFound, "Record not found")
 		}
@@ -83,7 +83,7 @@ func (s *usersWeightHeightService) GetWeightHeightByID(ctx *fiber.Ctx, recordID
 
 func (s *usersWeightHeightService) UpdateWeightHeight(ctx *fiber.Ctx, recordID string, record *model.UsersWeightHeightHistory) (*model.UsersWeightHeightHistory, error) {
 	existingRecord := new(model.UsersWeightHeightHistory)
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		First(existingRecord, "id = ? AND user_id = ?", recordID, record.UserID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fiber.NewError(fiber.StatusNotFound, "Record not found")
@@ -107,7 +107,7 @@ func (s *usersWeightHeightService) UpdateWeightHeight(ctx *fiber.Ctx, recordID s
 	}
 
 	if len(updates) > 0 {
-		if err := s.DB.WithContext(ctx.Context()).
+		if err := s.DB.WithContext(ctx.UserContext()).
 			Model(existingRecord).
 			Updates(updates).Error; err != nil {
 			s.Log.Errorf("Failed to update weight and height record: %+v", err)
@@ -125,7 +125,7 @@ func (s *usersWeightHeightService) UpdateWeightHeight(ctx *fiber.Ctx, recordID s
 
 func (s *usersWeightHeightService) DeleteWeightHeight(ctx *fiber.Ctx, recordID string, userID uuid.UUID) error {
 	existingRecord := new(model.UsersWeightHeightHistory)
-	if err := s.DB.WithContext(ctx.Context()).First(existingRecord, "id = ? AND user_id = ?", recordID, userID).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).First(existingRecord, "id = ? AND user_id = ?", recordID, userID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fiber.NewError(fiber.StatusNotFound, "Record not found")
 		}
@@ -133,7 +133,7 @@ func (s *usersWeightHeightService) DeleteWeightHeight(ctx *fiber.Ctx, recordID s
 		return err
 	}
 
-	if err := s.DB.WithContext(ctx.Context()).Delete(existingRecord).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).Delete(existingRecord).Error; err != nil {
 		s.Log.Errorf("Failed to delete weight and height record: %+v", err)
 		return err
 	}
@@ -148,12 +148,12 @@ func (s *usersWeightHeightService) DeleteWeightHeight(ctx *fiber.Ctx, recordID s
 
 func (s *usersWeightHeightService) updateUserHeightWeight(ctx *fiber.Ctx, userID uuid.UUID) error {
 	var latestRecord model.UsersWeightHeightHistory
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Where("user_id = ?", userID).
 		Order("recorded_at DESC").
 		First(&latestRecord).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			if err := s.DB.WithContext(ctx.Context()).
+			if err := s.DB.WithContext(ctx.UserContext()).
 				Model(&model.User{}).
 				Where("id = ?", userID).
 				Updates(map[string]interface{}{"height": 0, "weight": 0}).Error; err != nil {
@@ -166,7 +166,7 @@ func (s *usersWeightHeightService) updateUserHeightWeight(ctx *fiber.Ctx, userID
 		return err
 	}
 
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Model(&model.User{}).
 		Where("id = ?", userID).
 		Updates(map[string]interface{}{"height": latestRecord.Height, "weight": latestRecord.Weight}).Error; err != nil {
@@ -196,7 +196,7 @@ func (s *usersWeightHeightService) AddWeightHeightTarget(ctx *fiber.Ctx, record
 		record.HeightHistory = *user.Height
 	}
 
-	if err := s.DB.WithContext(ctx.Context()).Create(record).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).Create(record).Error; err != nil {
 		s.Log.Errorf("Failed to add weight and height target record: %+v", err)
 		return nil, err
 	}
@@ -206,7 +206,7 @@ func (s *usersWeightHeightService) AddWeightHeightTarget(ctx *fiber.Ctx, record
 
 func (s *usersWeightHeightService) GetWeightHeightsTarget(ctx *fiber.Ctx, userID uuid.UUID) ([]model.UsersWeightHeightTarget, error) {
 	var records []model.UsersWeightHeightTarget
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Where("user_id = ?", userID).
 		Order("target_date DESC").
 		Find(&records).Error; err != nil {
@@ -219,7 +219,7 @@ func (s *usersWeightHeightService) GetWeightHeightsTarget(ctx *fiber.Ctx, userID
 
 func (s *usersWeightHeightService) GetWeightHeightTargetByID(ctx *fiber.Ctx, recordID string, userID uuid.UUID) (*model.UsersWeightHeightTarget, error) {
 	existingRecord := new(model.UsersWeightHeightTarget)
-	if err := s.DB.WithContext(ctx.Context()).First(existingRecord, "id = ? AND user_id = ?", recordID, userID).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).First(existingRecord, "id = ? AND user_id = ?", recordID, userID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fiber.NewError(fiber.StatusNotFound, "Record not found")
 		}
@@ -238,7 +238,7 @@ func (s *usersWeightHeightService) UpdateWeightHeightTarget(ctx *fiber.Ctx, reco
 		s.Log.Errorf("Failed to get user information: %+v", err)
 		return nil, err
 	}
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		First(existingRecord, "id = ? AND user_id = ?", recordID, record.UserID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fiber.NewError(fiber.StatusNotFound, "Record not found")
@@ -268,7 +268,7 @@ func (s *usersWeightHeightService) UpdateWeightHeightTarget(ctx *fiber.Ctx, reco
 	updates["weight_history"] = user.Weight
 	updates["height_history"] = user.Height
 
-	if err := s.DB.WithContext(ctx.Context()).
+	if err := s.DB.WithContext(ctx.UserContext()).
 		Model(existingRecord).
 		Updates(updates).Error; err != nil {
 		s.Log.Errorf("Failed to update weight and height target record: %+v", err)
@@ -280,7 +280,7 @@ func (s *usersWeightHeightService) UpdateWeightHeightTarget(ctx *fiber.Ctx, reco
 
 func (s *usersWeightHeightService) DeleteWeightHeightTarget(ctx *fiber.Ctx, recordID string, userID uuid.UUID) error {
 	existingRecord := new(model.UsersWeightHeightTarget)
-	if err := s.DB.WithContext(ctx.Context()).First(existingRecord, "id = ? AND user_id = ?", recordID, userID).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).First(existingRecord, "id = ? AND user_id = ?", recordID, userID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fiber.NewError(fiber.StatusNotFound, "Record not found")
 		}
@@ -288,7 +288,7 @@ func (s *usersWeightHeightService) DeleteWeightHeightTarget(ctx *fiber.Ctx, reco
 		return err
 	}
 
-	if err := s.DB.WithContext(ctx.Context()).Delete(existingRecord).Error; err != nil {
+	if err := s.DB.WithContext(ctx.UserContext()).Delete(existingRecord).Error; err != nil {
 		s.Log.Errorf("Failed to delete weight and height target record: %+v", err)
 		return err
 	}
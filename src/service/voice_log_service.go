@@ -0,0 +1,266 @@
+package service
+
+import (
+	"app/src/bulkhead"
+	"app/src/config"
+	"app/src/model"
+	"app/src/speech"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// voiceLogFormats are the audio formats the speech providers accept, by file extension
+var voiceLogFormats = map[string]bool{
+	".flac": true, ".m4a": true, ".mp3": true, ".mp4": true, ".mpeg": true, ".mpga": true,
+	".oga": true, ".ogg": true, ".wav": true, ".webm": true,
+}
+
+// voiceLogGramsPerPortion is the weight of a food said without a unit it has a serving for, the
+// nutrition of the food service is given per 100 g
+const voiceLogGramsPerPortion = 100
+
+type VoiceLogService interface {
+	// ConsumeQuota takes one voice log from the user's active subscription. It returns a nil quota when the
+	// user has no subscription, and consumed false when the plan has no voice log left.
+	ConsumeQuota(c *fiber.Ctx, userID uuid.UUID) (quota *model.VoiceLogQuota, consumed bool, err error)
+	// RefundQuota gives back a voice log taken by ConsumeQuota, for voice logs that failed
+	RefundQuota(ctx context.Context, quota *model.VoiceLogQuota)
+	// Propose transcribes an audio clip and proposes diary entries for the foods it names. Neither the clip
+	// nor the transcript is kept, the user logs the entries they confirm.
+	Propose(c *fiber.Ctx, userID uuid.UUID, quota model.VoiceLogQuota, audio *multipart.FileHeader, req *validation.VoiceLog) (*model.VoiceLogProposal, error)
+}
+
+type voiceLogService struct {
+	Log                *logrus.Logger
+	DB                 *gorm.DB
+	Validate           *validator.Validate
+	Transcriber        speech.Transcriber
+	FoodNameService    FoodNameService
+	FoodPortionService FoodPortionService
+	Alerts             AlertService
+}
+
+// NewVoiceLogService answers 503 to voice logs while transcriber is nil
+func NewVoiceLogService(
+	db *gorm.DB, validate *validator.Validate, transcriber speech.Transcriber, foodNameService FoodNameService,
+	foodPortionService FoodPortionService, alerts AlertService,
+) VoiceLogService {
+	return &voiceLogService{
+		Log:                utils.Log,
+		DB:                 db,
+		Validate:           validate,
+		Transcriber:        transcriber,
+		FoodNameService:    foodNameService,
+		FoodPortionService: foodPortionService,
+		Alerts:             alerts,
+	}
+}
+
+func (s *voiceLogService) ConsumeQuota(c *fiber.Ctx, userID uuid.UUID) (*model.VoiceLogQuota, bool, error) {
+	var subscription model.UserSubscription
+	result := s.DB.WithContext(c.UserContext()).
+		Preload("Plan").
+		Scopes(activeSubscription(userID)).
+		Limit(1).
+		Find(&subscription)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, false, nil
+	}
+
+	quota := &model.VoiceLogQuota{
+		SubscriptionID: subscription.ID,
+		Limit:          subscription.PurchasedPlan().VoiceLogLimit,
+		Used:           subscription.VoiceLogsUsed,
+	}
+	if quota.Exhausted() {
+		return quota, false, nil
+	}
+
+	result = s.DB.WithContext(c.UserContext()).
+		Model(&model.UserSubscription{}).
+		Where("id = ?", quota.SubscriptionID).
+		Where("? < 0 OR voice_logs_used < ?", quota.Limit, quota.Limit).
+		Update("voice_logs_used", gorm.Expr("voice_logs_used + 1"))
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return quota, false, nil
+	}
+
+	quota.Used++
+	return quota, true, nil
+}
+
+func (s *voiceLogService) RefundQuota(ctx context.Context, quota *model.VoiceLogQuota) {
+	if err := s.DB.WithContext(ctx).
+		Model(&model.UserSubscription{}).
+		Where("id = ?", quota.SubscriptionID).
+		Update("voice_logs_used", gorm.Expr("GREATEST(voice_logs_used - 1, 0)")).Error; err != nil {
+		s.Log.Errorf("Failed to refund voice log of subscription %s: %v", quota.SubscriptionID, err)
+	}
+}
+
+func (s *voiceLogService) Propose(
+	c *fiber.Ctx, userID uuid.UUID, quota model.VoiceLogQuota, audio *multipart.FileHeader, req *validation.VoiceLog,
+) (*model.VoiceLogProposal, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+	if s.Transcriber == nil {
+		return nil, fiber.NewError(fiber.StatusServiceUnavailable, "Voice logging is not available")
+	}
+	if audio.Size > config.VoiceLogMaxBytes {
+		return nil, fiber.NewError(fiber.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Audio clip is larger than %d KB", config.VoiceLogMaxBytes>>10))
+	}
+	if !voiceLogFormats[strings.ToLower(filepath.Ext(audio.Filename))] {
+		return nil, fiber.NewError(fiber.StatusUnsupportedMediaType, "Audio must be flac, m4a, mp3, mp4, mpeg, ogg, wav or webm")
+	}
+
+	file, err := audio.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Provider calls are bounded by their client timeout rather than the query deadline of the request
+	var transcript *speech.Transcript
+	err = speechBulkhead.Do(c.UserContext(), func() (err error) {
+		transcript, err = s.Transcriber.Transcribe(context.WithoutCancel(c.UserContext()), file, audio.Filename, req.Lang)
+		return err
+	})
+	if err != nil {
+		var busy *bulkhead.FullError
+		if errors.As(err, &busy) {
+			return nil, err
+		}
+		s.Alerts.Emit(c.UserContext(), model.AlertAIProviderError, 1, fmt.Sprintf("Speech provider %s failed: %v", s.Transcriber.Name(), err))
+		return nil, fiber.NewError(fiber.StatusBadGateway, "The voice log could not be transcribed, please try again")
+	}
+
+	spoken := model.ParseSpokenFoods(transcript.Text)
+	if len(spoken) == 0 {
+		return nil, fiber.NewError(fiber.StatusUnprocessableEntity, "No food was heard in the voice log")
+	}
+	if len(spoken) > config.VoiceLogMaxEntries {
+		spoken = spoken[:config.VoiceLogMaxEntries]
+	}
+
+	lang := req.Lang
+	if lang == "" && model.IsFoodLanguage(transcript.Language) {
+		lang = transcript.Language
+	}
+
+	proposal := &model.VoiceLogProposal{
+		Transcript: transcript.Text,
+		Entries:    []model.VoiceLogEntry{},
+		Unmatched:  []string{},
+		Quota:      quota,
+	}
+	for _, food := range spoken {
+		entry, err := s.propose(c, userID, food, lang)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			proposal.Unmatched = append(proposal.Unmatched, food.Text)
+			continue
+		}
+
+		proposal.Entries = append(proposal.Entries, *entry)
+		proposal.Total.EnergiKal += entry.Nutrition.EnergiKal
+		proposal.Total.ProteinG += entry.Nutrition.ProteinG
+		proposal.Total.LemakG += entry.Nutrition.LemakG
+		proposal.Total.KarbohidratG += entry.Nutrition.KarbohidratG
+	}
+	if len(proposal.Entries) == 0 {
+		return nil, fiber.NewError(fiber.StatusUnprocessableEntity, "None of the foods heard in the voice log was found")
+	}
+
+	return proposal, nil
+}
+
+// propose finds the food a spoken name means and weighs its portion, nil when no food matches
+func (s *voiceLogService) propose(c *fiber.Ctx, userID uuid.UUID, food model.SpokenFood, lang string) (*model.VoiceLogEntry, error) {
+	name := []rune(food.Name)
+	if len(name) < 2 {
+		return nil, nil
+	}
+	if len(name) > 100 {
+		name = name[:100]
+	}
+
+	results, searchID, err := s.FoodNameService.Search(c, userID, &validation.FoodSearchQuery{Q: string(name), Lang: lang, Limit: 3})
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	kode := results[0].Food.Kode
+	entry := &model.VoiceLogEntry{
+		Spoken:   food.Text,
+		FoodKode: kode,
+		Name:     results[0].Name,
+		Quantity: food.Quantity,
+		Unit:     food.Unit,
+		SearchID: searchID,
+	}
+	for _, result := range results[1:] {
+		entry.Alternatives = append(entry.Alternatives, model.VoiceLogAlternative{FoodKode: result.Food.Kode, Name: result.Name})
+	}
+
+	if entry.Unit == "" {
+		// "dua telur" is two of the count serving of the food when it has one
+		options, err := s.FoodPortionService.GetPortions(c, kode)
+		if err != nil {
+			return nil, err
+		}
+		for _, option := range options {
+			if option.Kind == model.PortionKindCount && option.Grams > 0 {
+				entry.Unit = option.Unit
+				break
+			}
+		}
+	}
+
+	if entry.Unit != "" {
+		conversion, err := s.FoodPortionService.Convert(c, kode, &validation.PortionQuery{Quantity: entry.Quantity, Unit: entry.Unit})
+		var fiberErr *fiber.Error
+		switch {
+		case err == nil:
+			entry.Grams, entry.Approximate, entry.Nutrition = conversion.Grams, conversion.Approximate, conversion.Nutrition
+			return entry, nil
+		case errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusBadRequest:
+			// The food has no serving for the unit said, its weight is guessed below
+		default:
+			return nil, err
+		}
+	}
+
+	conversion, err := s.FoodPortionService.Convert(c, kode, &validation.PortionQuery{Quantity: entry.Quantity * voiceLogGramsPerPortion, Unit: model.PortionGram})
+	if err != nil {
+		return nil, err
+	}
+	entry.Quantity, entry.Unit, entry.Grams, entry.Approximate = conversion.Quantity, model.PortionGram, conversion.Grams, true
+	entry.Nutrition = conversion.Nutrition
+
+	return entry, nil
+}
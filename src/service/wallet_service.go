@@ -0,0 +1,243 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WalletService interface {
+	GetWallet(c *fiber.Ctx, userID uuid.UUID) (*model.Wallet, error)
+	GetTransactions(c *fiber.Ctx, userID uuid.UUID, query *validation.WalletQuery) ([]model.WalletTransaction, int64, error)
+	AdjustBalance(c *fiber.Ctx, adminID, userID uuid.UUID, req *validation.AdjustWallet) (*model.WalletTransaction, error)
+
+	// Checkout helpers
+	ApplyToCheckout(c *fiber.Ctx, userID uuid.UUID, amount int, orderID string) (int, error)
+	ReverseCheckout(c *fiber.Ctx, userID uuid.UUID, orderID string) error
+	// CreditProration pays out the credit of a downgrade the new plan does not use up, inside tx which saves the
+	// plan change
+	CreditProration(ctx context.Context, tx *gorm.DB, userID uuid.UUID, amount int, orderID string) error
+}
+
+type walletService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+	Alerts   AlertService
+}
+
+func NewWalletService(db *gorm.DB, validate *validator.Validate, alerts AlertService) WalletService {
+	return &walletService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+		Alerts:   alerts,
+	}
+}
+
+func (s *walletService) GetWallet(c *fiber.Ctx, userID uuid.UUID) (*model.Wallet, error) {
+	wallet := &model.Wallet{UserID: userID}
+
+	if err := s.DB.WithContext(c.UserContext()).
+		Where("user_id = ?", userID).
+		FirstOrCreate(wallet).Error; err != nil {
+		s.Log.Errorf("Failed to get wallet for user %s: %+v", userID, err)
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+func (s *walletService) GetTransactions(c *fiber.Ctx, userID uuid.UUID, query *validation.WalletQuery) ([]model.WalletTransaction, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	var transactions []model.WalletTransaction
+	var totalResults int64
+
+	db := s.DB.WithContext(c.UserContext()).
+		Model(&model.WalletTransaction{}).
+		Where("user_id = ?", userID)
+
+	if err := db.Count(&totalResults).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.
+		Order("created_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&transactions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return transactions, totalResults, nil
+}
+
+func (s *walletService) AdjustBalance(c *fiber.Ctx, adminID, userID uuid.UUID, req *validation.AdjustWallet) (*model.WalletTransaction, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var user model.User
+	if err := s.DB.WithContext(c.UserContext()).First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
+		}
+		return nil, err
+	}
+
+	var entry *model.WalletTransaction
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		var err error
+		entry, err = s.post(c.UserContext(), tx, userID, req.Amount, req.Type, req.Description, req.Reference, &adminID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Type == model.WalletTypeRefund && req.Amount > 0 {
+		s.Alerts.Emit(c.UserContext(), model.AlertLargeRefund, req.Amount,
+			fmt.Sprintf("Wallet refund of %s to %s by admin %s", model.IDR(req.Amount), user.Email, adminID))
+	}
+
+	return entry, nil
+}
+
+// ApplyToCheckout debits up to amount from the user's wallet for an order and returns the amount applied
+func (s *walletService) ApplyToCheckout(c *fiber.Ctx, userID uuid.UUID, amount int, orderID string) (int, error) {
+	applied := 0
+
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		wallet, err := s.lockWallet(c.UserContext(), tx, userID)
+		if err != nil {
+			return err
+		}
+
+		applied = min(wallet.Balance, amount)
+		if applied <= 0 {
+			applied = 0
+			return nil
+		}
+
+		description := fmt.Sprintf("Applied to order %s", orderID)
+		_, err = s.post(c.UserContext(), tx, userID, -applied, model.WalletTypeCheckout, description, orderID, nil)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return applied, nil
+}
+
+// ReverseCheckout gives back the credit applied to an order whose payment did not go through. The gateway can
+// report the failure more than once at the same time, the wallet is locked before the entries of the order are
+// read so only one of them returns the credit.
+func (s *walletService) ReverseCheckout(c *fiber.Ctx, userID uuid.UUID, orderID string) error {
+	return s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if _, err := s.lockWallet(c.UserContext(), tx, userID); err != nil {
+			return err
+		}
+
+		var entries []model.WalletTransaction
+		if err := tx.Where("user_id = ? AND reference = ? AND type IN ?", userID, orderID,
+			[]string{model.WalletTypeCheckout, model.WalletTypeCheckoutReversal}).
+			Find(&entries).Error; err != nil {
+			return err
+		}
+
+		outstanding := 0
+		for _, entry := range entries {
+			outstanding -= entry.Amount
+		}
+
+		if outstanding <= 0 {
+			return nil
+		}
+
+		description := fmt.Sprintf("Returned from unpaid order %s", orderID)
+		_, err := s.post(c.UserContext(), tx, userID, outstanding, model.WalletTypeCheckoutReversal, description, orderID, nil)
+		return err
+	})
+}
+
+func (s *walletService) CreditProration(ctx context.Context, tx *gorm.DB, userID uuid.UUID, amount int, orderID string) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	description := fmt.Sprintf("Unused days credited by plan change %s", orderID)
+	_, err := s.post(ctx, tx, userID, amount, model.WalletTypeProration, description, orderID, nil)
+	return err
+}
+
+// lockWallet loads the wallet row for update, creating it on first use
+func (s *walletService) lockWallet(ctx context.Context, tx *gorm.DB, userID uuid.UUID) (*model.Wallet, error) {
+	if err := tx.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.Wallet{UserID: userID}).Error; err != nil {
+		return nil, err
+	}
+
+	wallet := new(model.Wallet)
+	if err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		First(wallet).Error; err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// post writes a ledger entry and updates the cached balance, it must run inside a transaction
+func (s *walletService) post(
+	ctx context.Context, tx *gorm.DB, userID uuid.UUID, amount int, entryType, description, reference string, createdByID *uuid.UUID,
+) (*model.WalletTransaction, error) {
+	wallet, err := s.lockWallet(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	newBalance := wallet.Balance + amount
+	if newBalance < 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Insufficient wallet balance")
+	}
+
+	if err := tx.WithContext(ctx).
+		Model(wallet).
+		Update("balance", newBalance).Error; err != nil {
+		return nil, err
+	}
+
+	entry := &model.WalletTransaction{
+		WalletID:     wallet.ID,
+		UserID:       userID,
+		Amount:       amount,
+		BalanceAfter: newBalance,
+		Type:         entryType,
+		Description:  description,
+		Reference:    reference,
+		CreatedByID:  createdByID,
+	}
+
+	if err := tx.WithContext(ctx).Create(entry).Error; err != nil {
+		return nil, err
+	}
+
+	s.Log.Infof("Wallet %s of user %s changed by %d (%s), balance %d", wallet.ID, userID, amount, entryType, newBalance)
+	return entry, nil
+}
@@ -0,0 +1,578 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// webhookBatchSize bounds the deliveries sent per pass of the job
+	webhookBatchSize = 100
+	// webhookErrorLength bounds the error, or response body, kept of a failed attempt
+	webhookErrorLength = 500
+	// webhookReplayMaxRange bounds the range of events a replay sends again
+	webhookReplayMaxRange = 31 * 24 * time.Hour
+)
+
+type WebhookService interface {
+	GetEndpoints(c *fiber.Ctx) ([]model.WebhookEndpoint, error)
+	// CreateEndpoint registers an endpoint, generating its secret unless one is given. The secret is only shown
+	// in the response.
+	CreateEndpoint(c *fiber.Ctx, adminID uuid.UUID, req *validation.CreateWebhookEndpoint) (*model.CreatedWebhookEndpoint, error)
+	UpdateEndpoint(c *fiber.Ctx, endpointID uuid.UUID, req *validation.UpdateWebhookEndpoint) (*model.WebhookEndpoint, error)
+	DeleteEndpoint(c *fiber.Ctx, endpointID uuid.UUID) error
+
+	GetDeliveries(c *fiber.Ctx, query *validation.WebhookDeliveryQuery) ([]model.WebhookDelivery, int64, error)
+	// RetryDelivery sends a delivery again on the next pass of the job, with all its attempts
+	RetryDelivery(c *fiber.Ctx, deliveryID uuid.UUID) (*model.WebhookDelivery, error)
+
+	// ReplayEvents sends the events created in a range again to an endpoint, oldest first, as new deliveries whose
+	// payload keeps the event ID and carries a replay hint. An endpoint runs one replay at a time.
+	ReplayEvents(c *fiber.Ctx, adminID, endpointID uuid.UUID, query *validation.ReplayWebhookEvents) (*model.WebhookReplay, error)
+	// GetReplay returns a replay with the progress of its deliveries
+	GetReplay(c *fiber.Ctx, replayID uuid.UUID) (*model.WebhookReplay, error)
+
+	// DeliverDue posts the deliveries whose next attempt is due, rescheduling the failed ones with an exponential
+	// backoff until WEBHOOK_MAX_ATTEMPTS
+	DeliverDue(ctx context.Context) error
+}
+
+type webhookService struct {
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+	Client   *http.Client
+}
+
+func NewWebhookService(db *gorm.DB, validate *validator.Validate) WebhookService {
+	return &webhookService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+		Client:   newWebhookClient(),
+	}
+}
+
+// newWebhookClient returns the client of the deliveries. The answer of an endpoint is kept in the delivery, so
+// the client must not be pointed at the internal network: it follows no redirect and only connects to public
+// addresses, checked once the name of the endpoint is resolved.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{Timeout: config.WebhookTimeout, Control: webhookDialControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Through a proxy the dialer would only see the address of the proxy
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   config.WebhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// webhookDialControl refuses to connect to an address that is not public, whatever name resolved to it
+func webhookDialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublicAddr(ip) {
+		return fmt.Errorf("webhook endpoints cannot be on %s, it is not a public address", ip)
+	}
+	return nil
+}
+
+// nonPublicPrefixes are the ranges netip does not tell from public ones: "this network" and the shared address
+// space of carrier-grade NAT
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// isPublicAddr reports whether ip is neither loopback, private, link-local, multicast nor unspecified
+func isPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// enqueueWebhook queues event for the endpoints subscribed to it in the transaction of the change it reports, so
+// an event is only sent for a change that was saved
+func enqueueWebhook(db *gorm.DB, event string, data interface{}, now time.Time) error {
+	var endpoints []model.WebhookEndpoint
+	if err := db.Where("is_active = ?", true).Find(&endpoints).Error; err != nil {
+		return err
+	}
+
+	deliveries, err := model.NewWebhookDeliveries(endpoints, event, data, now)
+	if err != nil || len(deliveries) == 0 {
+		return err
+	}
+	return db.Create(&deliveries).Error
+}
+
+func (s *webhookService) GetEndpoints(c *fiber.Ctx) ([]model.WebhookEndpoint, error) {
+	var endpoints []model.WebhookEndpoint
+	if err := s.DB.WithContext(c.UserContext()).Order("created_at").Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+
+	return endpoints, nil
+}
+
+func (s *webhookService) CreateEndpoint(c *fiber.Ctx, adminID uuid.UUID, req *validation.CreateWebhookEndpoint) (*model.CreatedWebhookEndpoint, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret = model.WebhookSecretPrefix + utils.GenerateRandomString(32)
+	}
+
+	endpoint := model.WebhookEndpoint{
+		URL:         strings.TrimSpace(req.URL),
+		Description: req.Description,
+		Secret:      secret,
+		Events:      req.Events,
+		IsActive:    true,
+		CreatedByID: adminID,
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Create(&endpoint).Error; err != nil {
+		return nil, err
+	}
+
+	return &model.CreatedWebhookEndpoint{WebhookEndpoint: endpoint, Secret: secret}, nil
+}
+
+func (s *webhookService) UpdateEndpoint(c *fiber.Ctx, endpointID uuid.UUID, req *validation.UpdateWebhookEndpoint) (*model.WebhookEndpoint, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	endpoint := new(model.WebhookEndpoint)
+	if err := s.DB.WithContext(c.UserContext()).First(endpoint, "id = ?", endpointID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Webhook endpoint not found")
+		}
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+		endpoint.URL = strings.TrimSpace(*req.URL)
+	}
+
+	if req.Description != nil {
+		endpoint.Description = *req.Description
+	}
+
+	if req.Events != nil {
+		endpoint.Events = req.Events
+	}
+
+	if req.IsActive != nil {
+		endpoint.IsActive = *req.IsActive
+	}
+
+	if err := s.DB.WithContext(c.UserContext()).Save(endpoint).Error; err != nil {
+		return nil, err
+	}
+
+	return endpoint, nil
+}
+
+func (s *webhookService) DeleteEndpoint(c *fiber.Ctx, endpointID uuid.UUID) error {
+	return s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&model.WebhookEndpoint{}, "id = ?", endpointID)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return fiber.NewError(fiber.StatusNotFound, "Webhook endpoint not found")
+		}
+
+		// The log of an endpoint goes with it
+		return tx.Delete(&model.WebhookDelivery{}, "endpoint_id = ?", endpointID).Error
+	})
+}
+
+func (s *webhookService) GetDeliveries(c *fiber.Ctx, query *validation.WebhookDeliveryQuery) ([]model.WebhookDelivery, int64, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []model.WebhookDelivery
+	var totalResults int64
+
+	db := s.DB.WithContext(c.UserContext())
+
+	if query.EndpointID != "" {
+		db = db.Where("endpoint_id = ?", query.EndpointID)
+	}
+
+	if query.ReplayID != "" {
+		db = db.Where("replay_id = ?", query.ReplayID)
+	}
+
+	if query.Event != "" {
+		db = db.Where("event = ?", query.Event)
+	}
+
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+
+	if err := db.Model(&model.WebhookDelivery{}).Count(&totalResults).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Order("created_at DESC").
+		Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, totalResults, nil
+}
+
+func (s *webhookService) RetryDelivery(c *fiber.Ctx, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	delivery := new(model.WebhookDelivery)
+	if err := s.DB.WithContext(c.UserContext()).First(delivery, "id = ?", deliveryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Webhook delivery not found")
+		}
+		return nil, err
+	}
+
+	if delivery.Status == model.WebhookDeliveryDelivered {
+		return nil, fiber.NewError(fiber.StatusConflict, "Webhook delivery was already delivered")
+	}
+
+	now := time.Now()
+	delivery.Status = model.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = &now
+
+	if err := s.DB.WithContext(c.UserContext()).Model(delivery).Updates(map[string]interface{}{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (s *webhookService) ReplayEvents(c *fiber.Ctx, adminID, endpointID uuid.UUID, query *validation.ReplayWebhookEvents) (*model.WebhookReplay, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	from, _ := time.Parse(time.RFC3339, query.From)
+	to, _ := time.Parse(time.RFC3339, query.To)
+	if !from.Before(to) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must be before to")
+	}
+	if to.Sub(from) > webhookReplayMaxRange {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Replays are limited to 31 days of events")
+	}
+
+	endpoint := new(model.WebhookEndpoint)
+	if err := s.DB.WithContext(c.UserContext()).First(endpoint, "id = ?", endpointID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Webhook endpoint not found")
+		}
+		return nil, err
+	}
+	if !endpoint.IsActive {
+		return nil, fiber.NewError(fiber.StatusConflict, "Activate the webhook endpoint before replaying events to it")
+	}
+
+	events := endpoint.Events
+	if query.Event != "" {
+		if !endpoint.Subscribes(query.Event) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "The webhook endpoint does not subscribe to "+query.Event)
+		}
+		events = []string{query.Event}
+	}
+
+	replay := &model.WebhookReplay{
+		EndpointID:  endpoint.ID,
+		From:        from,
+		To:          to,
+		CreatedByID: adminID,
+	}
+	if query.Event != "" {
+		replay.Events = events
+	}
+
+	err := s.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		// Lock the endpoint so two replays cannot start at once
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&model.WebhookEndpoint{}, "id = ?", endpoint.ID).Error; err != nil {
+			return err
+		}
+
+		var running int64
+		if err := tx.Model(&model.WebhookDelivery{}).
+			Where("endpoint_id = ? AND replay_id IS NOT NULL AND status = ?", endpoint.ID, model.WebhookDeliveryPending).
+			Count(&running).Error; err != nil {
+			return err
+		}
+		if running > 0 {
+			return fiber.NewError(fiber.StatusConflict, "A replay to this webhook endpoint is still running")
+		}
+
+		if err := tx.Create(replay).Error; err != nil {
+			return err
+		}
+
+		// Every endpoint was sent the same payload of an event, any delivery of it holds the original. Deliveries
+		// of earlier replays carry a hint already and are left out.
+		now := time.Now()
+		result := tx.Exec(`
+			INSERT INTO webhook_deliveries (id, endpoint_id, event, event_id, payload, status, attempts, next_attempt_at, replay_id, created_at)
+			SELECT uuid_generate_v4(), ?, originals.event, originals.event_id,
+				originals.payload || jsonb_build_object('replay', jsonb_build_object('id', ?::text, 'replayed_at', ?::text)),
+				?, 0, ?, ?, ?
+			FROM (
+				SELECT DISTINCT ON (event_id) event, event_id, payload, created_at
+				FROM webhook_deliveries
+				WHERE created_at >= ? AND created_at <= ? AND event IN ? AND replay_id IS NULL
+				ORDER BY event_id, created_at
+			) originals
+			ORDER BY originals.created_at`,
+			endpoint.ID, replay.ID.String(), now.UTC().Format(time.RFC3339Nano),
+			model.WebhookDeliveryPending, now, replay.ID, now,
+			from, to, events)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fiber.NewError(fiber.StatusNotFound, "No webhook events were sent in the range")
+		}
+
+		replay.Total = result.RowsAffected
+		return tx.Model(replay).Update("total", replay.Total).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	replay.SetProgress(map[string]int64{model.WebhookDeliveryPending: replay.Total})
+	return replay, nil
+}
+
+func (s *webhookService) GetReplay(c *fiber.Ctx, replayID uuid.UUID) (*model.WebhookReplay, error) {
+	db := s.DB.WithContext(c.UserContext())
+
+	replay := new(model.WebhookReplay)
+	if err := db.First(replay, "id = ?", replayID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Webhook replay not found")
+		}
+		return nil, err
+	}
+
+	var counts []struct {
+		Status string
+		Count  int64
+	}
+	if err := db.Model(&model.WebhookDelivery{}).
+		Select("status, COUNT(*) AS count").
+		Where("replay_id = ?", replay.ID).
+		Group("status").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	deliveries := make(map[string]int64, len(counts))
+	for _, count := range counts {
+		deliveries[count.Status] = count.Count
+	}
+	replay.SetProgress(deliveries)
+
+	return replay, nil
+}
+
+func (s *webhookService) DeliverDue(ctx context.Context) error {
+	db := s.DB.WithContext(ctx)
+	sent := make(map[uuid.UUID]bool)
+
+	for {
+		var deliveries []model.WebhookDelivery
+		if err := db.
+			Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, time.Now()).
+			Order("next_attempt_at").
+			Limit(webhookBatchSize).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+
+		progressed := false
+		for i := range deliveries {
+			if sent[deliveries[i].ID] {
+				continue
+			}
+			sent[deliveries[i].ID] = true
+			progressed = true
+
+			if err := s.deliver(ctx, &deliveries[i]); err != nil {
+				return err
+			}
+		}
+
+		if !progressed || len(deliveries) < webhookBatchSize {
+			return nil
+		}
+	}
+}
+
+// deliver makes one attempt at a delivery it claims first, so an instance running the job at the same time
+// skips it
+func (s *webhookService) deliver(ctx context.Context, delivery *model.WebhookDelivery) error {
+	db := s.DB.WithContext(ctx)
+	now := time.Now()
+
+	// The lease outlasts the request, a delivery whose instance died is picked up again after it
+	lease := now.Add(config.WebhookTimeout + time.Minute)
+	result := db.Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, model.WebhookDeliveryPending, delivery.NextAttemptAt).
+		Update("next_attempt_at", lease)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	endpoint := new(model.WebhookEndpoint)
+	if err := db.First(endpoint, "id = ?", delivery.EndpointID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	updates := map[string]interface{}{"last_attempt_at": now}
+	if endpoint.ID == uuid.Nil || !endpoint.IsActive {
+		updates["status"] = model.WebhookDeliveryFailed
+		updates["next_attempt_at"] = nil
+		updates["last_error"] = "the endpoint was removed or deactivated"
+		return db.Model(delivery).Updates(updates).Error
+	}
+
+	statusCode, err := s.post(ctx, endpoint, delivery, now)
+	attempts := delivery.Attempts + 1
+	updates["attempts"] = attempts
+	updates["last_status_code"] = statusCode
+
+	switch {
+	case err == nil:
+		updates["status"] = model.WebhookDeliveryDelivered
+		updates["next_attempt_at"] = nil
+		updates["last_error"] = ""
+		updates["delivered_at"] = now
+	case attempts >= config.WebhookMaxAttempts:
+		updates["status"] = model.WebhookDeliveryFailed
+		updates["next_attempt_at"] = nil
+		updates["last_error"] = truncateWebhookError(err.Error())
+		s.Log.Warnf("Webhook %s of %s to %s failed after %d attempts: %v", delivery.ID, delivery.Event, endpoint.URL, attempts, err)
+	default:
+		updates["next_attempt_at"] = now.Add(model.WebhookBackoff(attempts, config.WebhookRetryBase, config.WebhookRetryMax))
+		updates["last_error"] = truncateWebhookError(err.Error())
+	}
+
+	return db.Model(delivery).Updates(updates).Error
+}
+
+// post sends the payload of a delivery signed with the secret of its endpoint, an error for any answer but 2xx
+func (s *webhookService) post(ctx context.Context, endpoint *model.WebhookEndpoint, delivery *model.WebhookDelivery, now time.Time) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Nutribox-Webhooks/1.0")
+	req.Header.Set(model.WebhookSignatureHeader, model.WebhookSignature(endpoint.Secret, now, body))
+	req.Header.Set(model.WebhookEventHeader, delivery.Event)
+	req.Header.Set(model.WebhookDeliveryHeader, delivery.ID.String())
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		answer, _ := io.ReadAll(io.LimitReader(res.Body, webhookErrorLength))
+		return res.StatusCode, fmt.Errorf("endpoint answered %d: %s", res.StatusCode, strings.TrimSpace(string(answer)))
+	}
+	return res.StatusCode, nil
+}
+
+// validateWebhookURL requires an https URL. An endpoint given by an address must be on a public one, the address
+// of a name is checked at each delivery since it may change.
+func validateWebhookURL(rawURL string) error {
+	endpoint, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || endpoint.Scheme != "https" || endpoint.Hostname() == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Webhook endpoints need an https URL")
+	}
+	if ip, err := netip.ParseAddr(endpoint.Hostname()); err == nil && !isPublicAddr(ip) {
+		return fiber.NewError(fiber.StatusBadRequest, "Webhook endpoints must be on a public address")
+	}
+	if host := strings.ToLower(endpoint.Hostname()); host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fiber.NewError(fiber.StatusBadRequest, "Webhook endpoints must be on a public address")
+	}
+	return nil
+}
+
+func truncateWebhookError(message string) string {
+	if len(message) > webhookErrorLength {
+		return message[:webhookErrorLength]
+	}
+	return message
+}
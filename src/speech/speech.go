@@ -0,0 +1,125 @@
+package speech
+
+import (
+	"app/src/requestid"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Providers New knows
+const (
+	ProviderOpenAI = "openai" // OpenAI and the APIs compatible with its audio transcriptions
+)
+
+// Transcript is the text of an audio clip
+type Transcript struct {
+	Text     string
+	Language string
+	Model    string
+	// Seconds is the length of the clip when the provider tells it
+	Seconds float64
+}
+
+// Transcriber turns speech into text. language is an ISO 639-1 hint, empty to let the provider detect it.
+type Transcriber interface {
+	Name() string
+	Transcribe(ctx context.Context, audio io.Reader, filename, language string) (*Transcript, error)
+}
+
+// New returns the transcriber by name, nil when no API key is configured. baseURL replaces the API endpoint
+// of the provider when set.
+func New(provider, apiKey, baseURL, model string, timeout time.Duration) (Transcriber, error) {
+	if apiKey == "" {
+		return nil, nil
+	}
+
+	client := &http.Client{Timeout: timeout}
+	baseURL = strings.TrimRight(baseURL, "/")
+	switch provider {
+	case ProviderOpenAI:
+		if baseURL == "" {
+			baseURL = "https://api.openai.com/v1"
+		}
+		return &openAI{apiKey: apiKey, baseURL: baseURL, model: model, http: client}, nil
+	}
+	return nil, fmt.Errorf("speech: unknown provider %q", provider)
+}
+
+type openAI struct {
+	apiKey  string
+	baseURL string
+	model   string
+	http    *http.Client
+}
+
+func (p *openAI) Name() string {
+	return ProviderOpenAI
+}
+
+func (p *openAI) Transcribe(ctx context.Context, audio io.Reader, filename, language string) (*Transcript, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, audio); err != nil {
+		return nil, err
+	}
+	fields := map[string]string{"model": p.model, "response_format": "json"}
+	if language != "" {
+		fields["language"] = language
+	}
+	for key, value := range fields {
+		if err := form.WriteField(key, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if id := requestid.From(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("speech: unexpected status %d: %s", resp.StatusCode, detail)
+	}
+
+	var out struct {
+		Text     string  `json:"text"`
+		Language string  `json:"language"`
+		Duration float64 `json:"duration"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+
+	return &Transcript{
+		Text:     strings.TrimSpace(out.Text),
+		Language: out.Language,
+		Model:    p.model,
+		Seconds:  out.Duration,
+	}, nil
+}
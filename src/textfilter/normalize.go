@@ -0,0 +1,62 @@
+// Package textfilter normalizes text typed by users and screens it for profanity before it is stored or shown
+// to other users.
+package textfilter
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// invisible are format characters that only hide text or flip its direction. Joiners and variation
+// selectors stay, emoji sequences and some scripts need them.
+var invisible = map[rune]bool{
+	// zero width space, word joiner and byte order mark
+	'\u200B': true, '\u2060': true, '\uFEFF': true,
+	// bidi embeddings, overrides and isolates
+	'\u202A': true, '\u202B': true, '\u202C': true, '\u202D': true, '\u202E': true,
+	'\u2066': true, '\u2067': true, '\u2068': true, '\u2069': true,
+}
+
+// Normalize prepares single line input such as a name or a title: NFC composed, control and invisible
+// characters removed, whitespace runs collapsed to one space and trimmed
+func Normalize(text string) string {
+	return strings.Join(strings.FieldsFunc(clean(text, false), unicode.IsSpace), " ")
+}
+
+// NormalizeMultiline prepares free text such as a chat message like Normalize but keeps line breaks,
+// at most one empty line in a row
+func NormalizeMultiline(text string) string {
+	lines := strings.Split(clean(text, true), "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.Join(strings.FieldsFunc(line, unicode.IsSpace), " ")
+		if line == "" && (len(kept) == 0 || kept[len(kept)-1] == "") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// clean composes the text and drops what cannot be displayed, newlines are kept when asked for
+func clean(text string, keepNewlines bool) string {
+	text = strings.ToValidUTF8(text, "")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var cleaned strings.Builder
+	cleaned.Grow(len(text))
+	for _, r := range norm.NFC.String(text) {
+		switch {
+		case r == '\n' && keepNewlines:
+			cleaned.WriteRune(r)
+		case r == '\t' || r == '\n' || r == '\r':
+			cleaned.WriteRune(' ')
+		case unicode.IsControl(r) || invisible[r]:
+		default:
+			cleaned.WriteRune(r)
+		}
+	}
+	return cleaned.String()
+}
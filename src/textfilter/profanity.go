@@ -0,0 +1,152 @@
+package textfilter
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Severity of the worst word in a text
+type Severity int
+
+const (
+	SeverityNone Severity = iota
+	SeverityMild
+	SeveritySevere
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityMild:
+		return "mild"
+	case SeveritySevere:
+		return "severe"
+	default:
+		return "none"
+	}
+}
+
+// Actions taken on text with profanity of a severity
+const (
+	ActionAllow  = "allow"  // kept as typed
+	ActionMask   = "mask"   // the words are replaced by asterisks
+	ActionReject = "reject" // the input is refused
+)
+
+// Actions lists the valid actions
+var Actions = []string{ActionAllow, ActionMask, ActionReject}
+
+// Words are matched whole, so names like Dickson or Bassett stay untouched. Swear words that are also
+// everyday words, of a food diary (babi) or of chat (pis), are left out.
+var profanity = map[Severity][]string{
+	SeverityMild: {
+		// English
+		"shit", "bullshit", "bitch", "bastard", "asshole", "crap", "wanker", "prick",
+		// Indonesian and regional
+		"anjing", "anjir", "bangsat", "bajingan", "brengsek", "goblok", "tolol", "bego", "kampret", "taik",
+		"asu", "jancok", "jancuk", "keparat", "sialan",
+	},
+	SeveritySevere: {
+		// English
+		"fuck", "fucker", "fucking", "motherfucker", "cunt", "whore", "slut", "nigger", "nigga", "faggot", "retard",
+		// Indonesian and regional
+		"kontol", "memek", "ngentot", "entot", "jembut", "pepek", "lonte", "pelacur", "ngewe", "perek", "bencong",
+	},
+}
+
+// leet maps look-alike digits and symbols to the letters they stand for
+var leet = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's',
+}
+
+// words maps the canonical form of every listed word to its severity
+var words = func() map[string]Severity {
+	words := make(map[string]Severity)
+	for severity, list := range profanity {
+		for _, word := range list {
+			words[canonical(word)] = severity
+		}
+	}
+	return words
+}()
+
+// canonical lowercases a word, undoes look-alikes and squeezes repeated letters, so "Fuuuck" and "4njing"
+// match their listed word
+func canonical(word string) string {
+	var result strings.Builder
+	var last rune
+	for _, r := range strings.ToLower(word) {
+		if mapped, ok := leet[r]; ok {
+			r = mapped
+		}
+		if r == last {
+			continue
+		}
+		result.WriteRune(r)
+		last = r
+	}
+	return result.String()
+}
+
+// span is a word of the text, as byte offsets
+type span struct {
+	start, end int
+	severity   Severity
+}
+
+// scan returns the listed words in text. A word is a run of letters, digits and look-alike symbols.
+func scan(text string) []span {
+	var found []span
+	start := -1
+	check := func(end int) {
+		if start < 0 {
+			return
+		}
+		if severity, ok := words[canonical(text[start:end])]; ok {
+			found = append(found, span{start: start, end: end, severity: severity})
+		}
+		start = -1
+	}
+
+	for i, r := range text {
+		_, lookalike := leet[r]
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || lookalike {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		check(i)
+	}
+	check(len(text))
+	return found
+}
+
+// Check returns the severity of the worst listed word in text
+func Check(text string) Severity {
+	worst := SeverityNone
+	for _, word := range scan(text) {
+		worst = max(worst, word.severity)
+	}
+	return worst
+}
+
+// Mask replaces the listed words of the given severities in text with as many asterisks as they have characters
+func Mask(text string, severities ...Severity) string {
+	var masked strings.Builder
+	last := 0
+	for _, word := range scan(text) {
+		if !slices.Contains(severities, word.severity) {
+			continue
+		}
+		masked.WriteString(text[last:word.start])
+		masked.WriteString(strings.Repeat("*", utf8.RuneCountInString(text[word.start:word.end])))
+		last = word.end
+	}
+	if last == 0 {
+		return text
+	}
+	masked.WriteString(text[last:])
+	return masked.String()
+}
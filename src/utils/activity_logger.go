@@ -1,13 +1,15 @@
 package utils
 
 import (
-	"fmt"
+	"app/src/geoip"
+	"app/src/logfile"
+	"app/src/requestid"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -18,6 +20,13 @@ var (
 	RequestLog *logrus.Logger
 )
 
+// Files of ActivityLog and RequestLog. They roll over every day, the retention job purges the days past
+// RETENTION_LOG_DAYS.
+var (
+	ActivityLogFile = filepath.Join("logs", "activity.log")
+	RequestLogFile  = filepath.Join("logs", "request.log")
+)
+
 // ActivityData represents user activity data to be logged
 type ActivityData struct {
 	UserID      string      `json:"userID"`
@@ -30,6 +39,54 @@ type ActivityData struct {
 	UserAgent   string      `json:"userAgent,omitempty"`
 	StatusCode  int         `json:"statusCode,omitempty"`
 	ElapsedTime string      `json:"elapsedTime,omitempty"`
+	// Location is where the request came from. It is kept in the store with the details, where the retention
+	// job removes it, and never written to the activity log file.
+	Location map[string]string `json:"-"`
+}
+
+// StoredDetails are the details the store keeps, with the location
+func (data ActivityData) StoredDetails() interface{} {
+	if len(data.Location) == 0 {
+		return data.Details
+	}
+	details := map[string]interface{}{}
+	if fields, ok := data.Details.(map[string]interface{}); ok {
+		for key, value := range fields {
+			details[key] = value
+		}
+	}
+	for key, value := range data.Location {
+		details[key] = value
+	}
+	return details
+}
+
+// ActivityStore keeps the activities LogUserActivity logs so they can be queried, it reports its own failures.
+// SaveActivity runs on the request path and must not wait for the store.
+type ActivityStore interface {
+	SaveActivity(data ActivityData)
+	// Close saves what the store still holds, it is called once on shutdown
+	Close()
+}
+
+// activityStore holds the store set with SetActivityStore
+var activityStore atomic.Pointer[ActivityStore]
+
+// SetActivityStore sets the store LogUserActivity saves to besides the activity log file, nil only logs to the file
+func SetActivityStore(store ActivityStore) {
+	if store == nil {
+		activityStore.Store(nil)
+		return
+	}
+	activityStore.Store(&store)
+}
+
+// CloseActivityStore detaches the store and waits for it to save what it holds, later activities only go to
+// the activity log file
+func CloseActivityStore() {
+	if store := activityStore.Swap(nil); store != nil {
+		(*store).Close()
+	}
 }
 
 // RequestResponseData represents API request and response data to be logged
@@ -48,18 +105,13 @@ type RequestResponseData struct {
 
 func init() {
 	// Create logs directory if it doesn't exist
-	logDir := "logs"
-	if err := os.MkdirAll(logDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(ActivityLogFile), 0755); err != nil {
 		Log.Fatalf("Failed to create log directory: %v", err)
 	}
 
 	// Initialize activity logger
 	ActivityLog = logrus.New()
-	activityLogFile, err := os.OpenFile(
-		filepath.Join(logDir, "activity.log"),
-		os.O_CREATE|os.O_WRONLY|os.O_APPEND,
-		0666,
-	)
+	activityLogFile, err := logfile.Open(ActivityLogFile, time.Now)
 	if err != nil {
 		Log.Fatalf("Failed to open activity log file: %v", err)
 	}
@@ -71,11 +123,7 @@ func init() {
 
 	// Initialize request logger
 	RequestLog = logrus.New()
-	requestLogFile, err := os.OpenFile(
-		filepath.Join(logDir, "request.log"),
-		os.O_CREATE|os.O_WRONLY|os.O_APPEND,
-		0666,
-	)
+	requestLogFile, err := logfile.Open(RequestLogFile, time.Now)
 	if err != nil {
 		Log.Fatalf("Failed to open request log file: %v", err)
 	}
@@ -89,7 +137,7 @@ func init() {
 // LogUserActivity logs user activity
 func LogUserActivity(data ActivityData) {
 	if data.RequestID == "" {
-		data.RequestID = fmt.Sprintf("req-%s", uuid.New().String()[:8])
+		data.RequestID = requestid.New("req")
 	}
 
 	ActivityLog.WithFields(logrus.Fields{
@@ -104,12 +152,16 @@ func LogUserActivity(data ActivityData) {
 		"statusCode":  data.StatusCode,
 		"elapsedTime": data.ElapsedTime,
 	}).Info("User activity")
+
+	if store := activityStore.Load(); store != nil {
+		(*store).SaveActivity(data)
+	}
 }
 
 // LogAPIRequest logs API request
 func LogAPIRequest(data RequestResponseData) {
 	if data.RequestID == "" {
-		data.RequestID = fmt.Sprintf("req-%s", uuid.New().String()[:8])
+		data.RequestID = requestid.New("req")
 	}
 
 	RequestLog.WithFields(logrus.Fields{
@@ -141,6 +193,16 @@ func LogAPIResponse(data RequestResponseData) {
 // LogLogin logs user login activity
 func LogLogin(c *fiber.Ctx, userID string, success bool) {
 	requestID := getRequestID(c)
+	var where map[string]string
+	if location, ok := c.Locals(geoip.LocalsKey).(geoip.Location); ok && location.Known() {
+		where = map[string]string{"country": location.Country}
+		if location.Region != "" {
+			where["region"] = location.Region
+		}
+		if location.City != "" {
+			where["city"] = location.City
+		}
+	}
 	LogUserActivity(ActivityData{
 		UserID:     userID,
 		Action:     "login",
@@ -149,6 +211,7 @@ func LogLogin(c *fiber.Ctx, userID string, success bool) {
 		IPAddress:  c.IP(),
 		UserAgent:  c.Get("User-Agent"),
 		StatusCode: c.Response().StatusCode(),
+		Location:   where,
 	})
 }
 
@@ -240,7 +303,7 @@ func LogWeightUpdate(c *fiber.Ctx, userID string, weight float64) {
 func getRequestID(c *fiber.Ctx) string {
 	requestID := c.Locals("requestID")
 	if requestID == nil {
-		return fmt.Sprintf("req-%s", uuid.New().String()[:8])
+		return requestid.New("req")
 	}
 	return requestID.(string)
 }
@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"app/src/bulkhead"
 	"app/src/response"
 	"app/src/validation"
+	"context"
 	"errors"
 
 	"github.com/gofiber/fiber/v2"
@@ -18,6 +20,18 @@ func ErrorHandler(c *fiber.Ctx, err error) error {
 		return response.Error(c, fiberErr.Code, fiberErr.Message, nil)
 	}
 
+	// An external provider is saturated, the client can retry shortly
+	var busy *bulkhead.FullError
+	if errors.As(err, &busy) {
+		c.Set(fiber.HeaderRetryAfter, "5")
+		return response.Error(c, fiber.StatusServiceUnavailable, busy.Error(), nil)
+	}
+
+	// The request deadline passed or the client disconnected while the database was working
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return response.Error(c, fiber.StatusGatewayTimeout, "Request timed out, please try again", nil)
+	}
+
 	return response.Error(c, fiber.StatusInternalServerError, "Internal Server Error", nil)
 }
 
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"fmt"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// UploadDir is the root directory served under /uploads
+const UploadDir = "uploads"
+
+// MaxUploadSize is the maximum accepted size for uploaded images (5 MB)
+const MaxUploadSize = 5 * 1024 * 1024
+
+var allowedImageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".webp": true,
+	".pdf":  true,
+}
+
+// SaveUploadedImage stores an uploaded image inside uploads/<folder> and returns its public path
+func SaveUploadedImage(c *fiber.Ctx, file *multipart.FileHeader, folder string) (string, error) {
+	if file.Size > MaxUploadSize {
+		return "", fiber.NewError(fiber.StatusBadRequest, "File is too large, maximum size is 5MB")
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if !allowedImageExtensions[ext] {
+		return "", fiber.NewError(fiber.StatusBadRequest, "Unsupported file type, allowed: jpg, jpeg, png, webp, pdf")
+	}
+
+	dir := filepath.Join(UploadDir, folder)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	filename := uuid.New().String() + ext
+	if err := c.SaveFile(file, filepath.Join(dir, filename)); err != nil {
+		return "", fmt.Errorf("failed to save uploaded file: %w", err)
+	}
+
+	return fmt.Sprintf("/%s/%s/%s", UploadDir, folder, filename), nil
+}
+
+// RemoveUploadedFile deletes a file stored by SaveUploadedImage, given its public path
+func RemoveUploadedFile(publicPath string) error {
+	err := os.Remove(filepath.FromSlash(strings.TrimPrefix(publicPath, "/")))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
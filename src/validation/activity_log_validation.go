@@ -0,0 +1,13 @@
+package validation
+
+// ActivityLogQuery adalah struktur untuk filter dan paginasi log aktivitas admin, tanggal memakai waktu aktivitas
+type ActivityLogQuery struct {
+	Page       int    `query:"page" validate:"number,min=1"`
+	Limit      int    `query:"limit" validate:"number,min=1,max=100"`
+	AdminID    string `query:"admin_id" validate:"omitempty,uuid"`
+	Action     string `query:"action" validate:"omitempty,max=64"`
+	Resource   string `query:"resource" validate:"omitempty,max=50"`
+	ResourceID string `query:"resource_id" validate:"omitempty,max=100"`
+	From       string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To         string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+}
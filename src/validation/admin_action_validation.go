@@ -0,0 +1,14 @@
+package validation
+
+// CancelSubscriptions adalah struktur untuk membatalkan beberapa subscription sekaligus setelah jendela undo
+type CancelSubscriptions struct {
+	SubscriptionIDs []string `json:"subscription_ids" validate:"required,min=1,max=100,unique,dive,uuid"`
+	Reason          string   `json:"reason" validate:"required,max=255" example:"Chargebacks of a stolen card batch"`
+}
+
+// AdminActionQuery adalah struktur untuk query aksi admin yang ditahan
+type AdminActionQuery struct {
+	Status string `query:"status" validate:"omitempty,oneof=staged finalized undone failed"`
+	Page   int    `query:"page" validate:"omitempty,number,min=1"`
+	Limit  int    `query:"limit" validate:"omitempty,number,min=1,max=100"`
+}
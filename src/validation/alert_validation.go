@@ -0,0 +1,20 @@
+package validation
+
+// CreateAlertRule adalah struktur untuk membuat aturan notifikasi admin
+type CreateAlertRule struct {
+	Event           string `json:"event" validate:"required,oneof=payment_failure_spike large_refund ai_provider_error reconciliation_mismatch"`
+	Channel         string `json:"channel" validate:"required,oneof=email slack telegram"`
+	Target          string `json:"target" validate:"required,max=512"`
+	Threshold       int    `json:"threshold" validate:"required,min=1"`
+	WindowMinutes   int    `json:"window_minutes" validate:"omitempty,min=1,max=1440"`
+	CooldownMinutes *int   `json:"cooldown_minutes" validate:"omitempty,min=0,max=10080"`
+}
+
+// UpdateAlertRule adalah struktur untuk update aturan notifikasi admin
+type UpdateAlertRule struct {
+	Target          *string `json:"target" validate:"omitempty,max=512"`
+	Threshold       *int    `json:"threshold" validate:"omitempty,min=1"`
+	WindowMinutes   *int    `json:"window_minutes" validate:"omitempty,min=1,max=1440"`
+	CooldownMinutes *int    `json:"cooldown_minutes" validate:"omitempty,min=0,max=10080"`
+	IsActive        *bool   `json:"is_active" validate:"omitempty"`
+}
@@ -0,0 +1,14 @@
+package validation
+
+// AssistantChat adalah struktur untuk mengirim pesan ke asisten gizi
+type AssistantChat struct {
+	// Kosongkan untuk memulai percakapan baru
+	ConversationID string `json:"conversation_id" validate:"omitempty,uuid"`
+	Message        string `json:"message" validate:"required,max=2000"`
+}
+
+// AssistantConversationQuery adalah struktur untuk query daftar percakapan asisten
+type AssistantConversationQuery struct {
+	Page  int `query:"page" validate:"omitempty,number,min=1"`
+	Limit int `query:"limit" validate:"omitempty,number,min=1,max=100"`
+}
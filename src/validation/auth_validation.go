@@ -9,12 +9,15 @@ type Register struct {
 	Name           string              `json:"name" validate:"required,max=50" example:"fake name"`
 	Email          string              `json:"email" validate:"required,email,max=50" example:"fake@example.com"`
 	Password       string              `json:"password" validate:"required,min=8,max=20,password" example:"password1"`
-	BirthDate      time.Time           `json:"birth_date" validate:"required" example:"2000-01-01T00:00:00Z"`
+	BirthDate      time.Time           `json:"birth_date" validate:"required,lt" example:"2000-01-01T00:00:00Z"`
 	Height         float64             `json:"height" validate:"required,gt=0" example:"170.5"`
 	Weight         float64             `json:"weight" validate:"required,gt=0" example:"65.5"`
 	Gender         model.GenderType    `json:"gender" validate:"required,oneof=Male Female" example:"Male"`
 	ActivityLevel  model.ActivityLevel `json:"activity_level" validate:"required,oneof=Light Medium Heavy" example:"Medium"`
 	MedicalHistory string              `json:"medical_history,omitempty" validate:"max=1000" example:"No known medical issues"`
+	GuardianEmail  string              `json:"guardian_email,omitempty" validate:"omitempty,email,max=100" example:"parent@example.com"`
+	// Campaign or channel of the install, e.g. the utm_source of the link the app was opened from
+	AcquisitionSource string `json:"acquisition_source,omitempty" validate:"omitempty,max=50,printascii" example:"instagram"`
 }
 
 type Login struct {
@@ -27,6 +30,8 @@ type GoogleLogin struct {
 	Email          string `json:"email" validate:"required,email,max=50"`
 	ProfilePicture string `json:"profile_picture"`
 	GoogleIDToken  string `json:"google_id_token"`
+	// AcquisitionSource is only kept for new users
+	AcquisitionSource string `json:"acquisition_source" validate:"omitempty,max=50,printascii"`
 }
 
 type Logout struct {
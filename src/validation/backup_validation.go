@@ -0,0 +1,6 @@
+package validation
+
+// CreateBackup adalah struktur untuk meminta backup database oleh admin
+type CreateBackup struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
@@ -0,0 +1,23 @@
+package validation
+
+// CreateCheckout adalah struktur untuk membuat sesi checkout
+type CreateCheckout struct {
+	PlanID        string `json:"plan_id" validate:"required,uuid"`
+	CouponCode    string `json:"coupon_code" validate:"omitempty,max=50"`
+	PaymentMethod string `json:"payment_method" validate:"omitempty,oneof=gopay shopeepay bank_transfer credit_card"`
+	Installment   bool   `json:"installment"`
+	// AutoRenew renews the subscription with the card it is paid with, only for credit card payments
+	AutoRenew bool `json:"auto_renew"`
+}
+
+// SendPaymentReminder adalah struktur untuk mengirim pengingat pembayaran oleh admin
+type SendPaymentReminder struct {
+	Note string `json:"note" validate:"omitempty,max=500"`
+}
+
+// CheckoutFunnelQuery adalah struktur untuk query funnel checkout dalam periode
+type CheckoutFunnelQuery struct {
+	From   string `query:"from" validate:"required,datetime=2006-01-02"`
+	To     string `query:"to" validate:"required,datetime=2006-01-02"`
+	PlanID string `query:"plan_id" validate:"omitempty,uuid"`
+}
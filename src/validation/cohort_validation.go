@@ -0,0 +1,10 @@
+package validation
+
+// CohortQuery adalah struktur untuk query perbandingan retensi dan kepatuhan antar kohort pengguna
+type CohortQuery struct {
+	Dimension string `query:"dimension" validate:"required,oneof=signup_month plan source"`
+	From      string `query:"from" validate:"required,datetime=2006-01"`
+	To        string `query:"to" validate:"required,datetime=2006-01"`
+	Plan      string `query:"plan" validate:"omitempty,max=100"`
+	Source    string `query:"source" validate:"omitempty,max=50"`
+}
@@ -0,0 +1,27 @@
+package validation
+
+// CouponQuery adalah struktur untuk query daftar kupon
+type CouponQuery struct {
+	Page  int `query:"page" validate:"omitempty,number,min=1"`
+	Limit int `query:"limit" validate:"omitempty,number,min=1,max=100"`
+}
+
+// CreateCoupon adalah struktur untuk membuat kupon diskon
+type CreateCoupon struct {
+	Code            string `json:"code" validate:"required,alphanum,min=3,max=50"`
+	Description     string `json:"description" validate:"omitempty,max=255"`
+	DiscountPercent int    `json:"discount_percent" validate:"omitempty,min=1,max=100"`
+	DiscountAmount  int    `json:"discount_amount" validate:"omitempty,min=1"`
+	PlanID          string `json:"plan_id" validate:"omitempty,uuid"`
+	ValidFrom       string `json:"valid_from" validate:"omitempty"`
+	ValidUntil      string `json:"valid_until" validate:"omitempty"`
+	MaxRedemptions  int    `json:"max_redemptions" validate:"omitempty,min=1"`
+}
+
+// UpdateCoupon adalah struktur untuk update kupon diskon
+type UpdateCoupon struct {
+	Description    *string `json:"description" validate:"omitempty,max=255"`
+	ValidUntil     *string `json:"valid_until" validate:"omitempty"`
+	MaxRedemptions *int    `json:"max_redemptions" validate:"omitempty,min=0"`
+	IsActive       *bool   `json:"is_active" validate:"omitempty"`
+}
@@ -0,0 +1,15 @@
+package validation
+
+// CreateDeepLink adalah struktur untuk membuat deep link aplikasi untuk pengguna
+type CreateDeepLink struct {
+	Kind   string `json:"kind" validate:"required,oneof=renew_subscription diary_day scan_result"`
+	UserID string `json:"user_id" validate:"required,uuid"`
+	Target string `json:"target" validate:"omitempty,max=64"`
+	Source string `json:"source" validate:"omitempty,max=50"`
+}
+
+// DeepLinkClickQuery adalah struktur untuk query statistik klik deep link
+type DeepLinkClickQuery struct {
+	From string `query:"from" validate:"required,datetime=2006-01-02"`
+	To   string `query:"to" validate:"required,datetime=2006-01-02"`
+}
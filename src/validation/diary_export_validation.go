@@ -0,0 +1,8 @@
+package validation
+
+// DiaryExportQuery adalah struktur untuk query ekspor catatan makan pengguna
+type DiaryExportQuery struct {
+	From   string `query:"from" validate:"required,datetime=2006-01-02"`
+	To     string `query:"to" validate:"required,datetime=2006-01-02"`
+	Format string `query:"format" validate:"required,oneof=csv pdf"`
+}
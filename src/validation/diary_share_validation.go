@@ -0,0 +1,11 @@
+package validation
+
+// CreateDiaryShare adalah struktur untuk membuat tautan baca saja catatan makan dan data vital untuk dokter
+type CreateDiaryShare struct {
+	Label         string `json:"label" validate:"omitempty,max=100" example:"dr. Andi, Klinik Sehat"`
+	From          string `json:"from" validate:"required,datetime=2006-01-02" example:"2026-09-01"`
+	To            string `json:"to" validate:"required,datetime=2006-01-02" example:"2026-09-30"`
+	Diary         bool   `json:"diary" example:"true"`
+	Vitals        bool   `json:"vitals" example:"true"`
+	ExpiresInDays int    `json:"expires_in_days" validate:"required,min=1,max=365" example:"7"`
+}
@@ -0,0 +1,17 @@
+package validation
+
+// ExperimentVariant adalah struktur untuk satu varian eksperimen
+type ExperimentVariant struct {
+	Key     string            `json:"key" validate:"required,alphanum,max=50"`
+	Weight  int               `json:"weight" validate:"required,min=1,max=100"`
+	Content map[string]string `json:"content" validate:"omitempty"`
+}
+
+// CreateExperiment adalah struktur untuk membuat eksperimen A/B
+type CreateExperiment struct {
+	Key         string              `json:"key" validate:"required,max=50"`
+	Description string              `json:"description" validate:"omitempty,max=255"`
+	Surface     string              `json:"surface" validate:"required,oneof=notification paywall"`
+	Target      string              `json:"target" validate:"omitempty,max=50"`
+	Variants    []ExperimentVariant `json:"variants" validate:"required,min=2,max=10,dive"`
+}
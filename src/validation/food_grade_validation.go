@@ -0,0 +1,28 @@
+package validation
+
+// UpdateFoodGrading adalah struktur untuk mengubah penilaian kesehatan makanan (per 100 g) dan makanan yang discan (per porsi)
+type UpdateFoodGrading struct {
+	Food GradingProfile `json:"food" validate:"required"`
+	Meal GradingProfile `json:"meal" validate:"required"`
+}
+
+// GradingProfile adalah struktur untuk nutrien yang dinilai dan batas skor setiap grade A sampai E
+type GradingProfile struct {
+	Nutrients []GradedNutrient `json:"nutrients" validate:"required,min=1,max=20,dive"`
+	Grades    []GradeBand      `json:"grades" validate:"required,len=5,dive"`
+}
+
+// GradedNutrient adalah struktur untuk nutrien yang dinilai, low dan high adalah batas lampu lalu lintas nutrien yang dibatasi
+type GradedNutrient struct {
+	Key        string    `json:"key" validate:"required,max=50" example:"natrium_na_mg"`
+	Direction  string    `json:"direction" validate:"required,oneof=limit encourage" example:"limit"`
+	Thresholds []float64 `json:"thresholds" validate:"required,min=1,max=20,dive,gte=0"`
+	Low        float64   `json:"low" validate:"gte=0" example:"120"`
+	High       float64   `json:"high" validate:"gte=0" example:"600"`
+}
+
+// GradeBand adalah struktur untuk skor tertinggi yang mendapat sebuah grade
+type GradeBand struct {
+	Grade    string `json:"grade" validate:"required,oneof=A B C D E" example:"A"`
+	MaxScore int    `json:"max_score" example:"-1"`
+}
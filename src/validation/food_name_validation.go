@@ -0,0 +1,95 @@
+package validation
+
+// FoodSearchQuery adalah struktur untuk query pencarian bahan makanan dengan nama dalam bahasa Indonesia atau Inggris
+type FoodSearchQuery struct {
+	Q     string `query:"q" validate:"required,min=2,max=100"`
+	Lang  string `query:"lang" validate:"omitempty,oneof=id en"`
+	Limit int    `query:"limit" validate:"omitempty,number,min=1,max=50"`
+}
+
+// SetFoodNames adalah struktur untuk mengganti nama lokal dan sinonim sebuah bahan makanan
+type SetFoodNames struct {
+	Names []FoodNameInput `json:"names" validate:"max=50,dive"`
+}
+
+// FoodNameInput adalah struktur untuk satu nama bahan makanan, hanya satu nama utama per bahasa
+type FoodNameInput struct {
+	Language string `json:"language" validate:"required,oneof=id en" example:"en"`
+	Name     string `json:"name" validate:"required,max=150" example:"Egg"`
+	Primary  bool   `json:"primary" example:"true"`
+}
+
+// LogFood adalah struktur untuk mencatat bahan makanan yang dipilih pengguna, opsional dari hasil pencarian
+type LogFood struct {
+	SearchID string `json:"search_id" validate:"omitempty,uuid" example:"3f1c2a9e-0b7d-4e8a-9c61-2d5f7a8b9c0d"`
+	// Quantity dan Unit adalah porsi yang dimakan, dalam gram, ml atau ukuran rumah tangga
+	Quantity float64 `json:"quantity" validate:"required_with=Unit,omitempty,gt=0,max=10000" example:"1.5"`
+	Unit     string  `json:"unit" validate:"required_with=Quantity,omitempty,oneof=g kg ml l sendok_teh sendok_makan gelas mangkok centong piring potong butir buah bungkus" example:"piring"`
+}
+
+// PortionQuery adalah struktur untuk query konversi porsi bahan makanan ke gram
+type PortionQuery struct {
+	Quantity float64 `query:"quantity" validate:"required,gt=0,max=10000"`
+	Unit     string  `query:"unit" validate:"required,oneof=g kg ml l sendok_teh sendok_makan gelas mangkok centong piring potong butir buah bungkus"`
+}
+
+// SetFoodPortions adalah struktur untuk mengganti ukuran porsi sebuah bahan makanan
+type SetFoodPortions struct {
+	Portions []FoodPortionInput `json:"portions" validate:"max=30,dive"`
+}
+
+// FoodPortionInput adalah struktur untuk berat satu satuan bahan makanan, untuk ml berat per mililiter (massa jenis)
+type FoodPortionInput struct {
+	Unit  string  `json:"unit" validate:"required,oneof=ml sendok_teh sendok_makan gelas mangkok centong piring potong butir buah bungkus" example:"potong"`
+	Grams float64 `json:"grams" validate:"required,gt=0,max=5000" example:"25"`
+}
+
+// FoodSearchGapQuery adalah struktur untuk query pencarian bahan makanan tanpa hasil
+type FoodSearchGapQuery struct {
+	From  string `query:"from" validate:"required,datetime=2006-01-02"`
+	To    string `query:"to" validate:"required,datetime=2006-01-02"`
+	Limit int    `query:"limit" validate:"omitempty,number,min=1,max=200"`
+}
+
+// ImportFoods adalah struktur untuk impor dataset gizi (USDA, BPOM atau dataset lain dengan pemetaan kolom)
+type ImportFoods struct {
+	Source string `form:"source" validate:"required,oneof=usda bpom custom"`
+	// Mapping adalah JSON {"field": {"column": "...", "unit": "..."}} yang menggantikan pemetaan bawaan sumber
+	Mapping   string `form:"mapping" validate:"required_if=Source custom,omitempty,json,max=10000"`
+	DryRun    bool   `form:"dry_run"`
+	Overwrite bool   `form:"overwrite"`
+}
+
+// FoodImportQuery adalah struktur untuk query riwayat impor dataset gizi
+type FoodImportQuery struct {
+	Page  int `query:"page" validate:"omitempty,number,min=1"`
+	Limit int `query:"limit" validate:"omitempty,number,min=1,max=100"`
+}
+
+// FoodImportItemQuery adalah struktur untuk query baris laporan impor dataset gizi
+type FoodImportItemQuery struct {
+	Action string `query:"action" validate:"omitempty,oneof=update unchanged new duplicate invalid failed"`
+	Page   int    `query:"page" validate:"omitempty,number,min=1"`
+	Limit  int    `query:"limit" validate:"omitempty,number,min=1,max=500"`
+}
+
+// FoodCompareQuery adalah struktur untuk query perbandingan gizi 2 sampai 4 bahan makanan
+type FoodCompareQuery struct {
+	IDs string `query:"ids" validate:"required,max=100"`
+}
+
+// FoodAlternativeQuery adalah struktur untuk query alternatif bahan makanan yang lebih sehat
+type FoodAlternativeQuery struct {
+	Limit int `query:"limit" validate:"omitempty,number,min=1,max=20"`
+}
+
+// FoodAlternativeFeedback adalah struktur untuk umpan balik pengguna atas alternatif bahan makanan
+type FoodAlternativeFeedback struct {
+	AlternativeKode string `json:"alternative_kode" validate:"required,max=20" example:"AR011"`
+	Helpful         *bool  `json:"helpful" validate:"required" example:"true"`
+}
+
+// UpdateDietPreference adalah struktur untuk mengganti pantangan makan pengguna
+type UpdateDietPreference struct {
+	Restrictions []string `json:"restrictions" validate:"max=10,dive,oneof=vegetarian vegan lactose_free nut_free low_sugar low_sodium" example:"vegetarian,low_sugar"`
+}
@@ -0,0 +1,28 @@
+package validation
+
+// FraudReviewQuery adalah struktur untuk query antrian review transaksi mencurigakan
+type FraudReviewQuery struct {
+	Page   int    `query:"page" validate:"omitempty,number,min=1"`
+	Limit  int    `query:"limit" validate:"omitempty,number,min=1,max=100"`
+	Status string `query:"status" validate:"omitempty,oneof=pending approved rejected"`
+}
+
+// ReviewFraud adalah struktur untuk keputusan admin atas transaksi yang ditahan
+type ReviewFraud struct {
+	Notes string `json:"notes" validate:"omitempty,max=500"`
+}
+
+// FraudListQuery adalah struktur untuk query daftar allow/deny
+type FraudListQuery struct {
+	Page     int    `query:"page" validate:"omitempty,number,min=1"`
+	Limit    int    `query:"limit" validate:"omitempty,number,min=1,max=100"`
+	ListType string `query:"list_type" validate:"omitempty,oneof=allow deny"`
+}
+
+// CreateFraudListEntry adalah struktur untuk menambah entri allow/deny list
+type CreateFraudListEntry struct {
+	ListType  string `json:"list_type" validate:"required,oneof=allow deny"`
+	EntryType string `json:"entry_type" validate:"required,oneof=user email ip"`
+	Value     string `json:"value" validate:"required,max=255"`
+	Reason    string `json:"reason" validate:"omitempty,max=255"`
+}
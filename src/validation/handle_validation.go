@@ -0,0 +1,11 @@
+package validation
+
+// HandleQuery adalah struktur untuk mengecek ketersediaan handle publik
+type HandleQuery struct {
+	Name string `query:"name" validate:"required,max=100"`
+}
+
+// UpdateHandle adalah struktur untuk memilih atau mengganti handle publik
+type UpdateHandle struct {
+	Handle string `json:"handle" validate:"required,max=100" example:"budi_sehat"`
+}
@@ -0,0 +1,24 @@
+package validation
+
+// VerifyAppleReceipt adalah struktur untuk validasi receipt pembelian App Store
+type VerifyAppleReceipt struct {
+	ReceiptData string `json:"receipt_data" validate:"required"`
+}
+
+// VerifyGooglePurchase adalah struktur untuk validasi pembelian Google Play
+type VerifyGooglePurchase struct {
+	ProductID     string `json:"product_id" validate:"required,max=255"`
+	PurchaseToken string `json:"purchase_token" validate:"required,max=512"`
+}
+
+// AppleNotification adalah struktur untuk App Store Server Notification V2
+type AppleNotification struct {
+	SignedPayload string `json:"signedPayload" validate:"required"`
+}
+
+// CreateStoreProduct adalah struktur untuk memetakan produk store ke plan
+type CreateStoreProduct struct {
+	Store     string `json:"store" validate:"required,oneof=apple google"`
+	ProductID string `json:"product_id" validate:"required,max=255"`
+	PlanID    string `json:"plan_id" validate:"required,uuid"`
+}
@@ -0,0 +1,12 @@
+package validation
+
+// RequestMagicLink adalah struktur untuk meminta tautan masuk tanpa kata sandi yang dikirim ke email
+type RequestMagicLink struct {
+	Email string `json:"email" validate:"required,email,max=50" example:"fake@example.com"`
+}
+
+// MagicLinkLogin adalah struktur untuk masuk dengan tautan dari email di perangkat yang memintanya
+type MagicLinkLogin struct {
+	Token      string `json:"token" validate:"required,max=2550"`
+	DeviceCode string `json:"device_code" validate:"required,len=64,hexadecimal"`
+}
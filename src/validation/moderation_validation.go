@@ -0,0 +1,21 @@
+package validation
+
+// ReportUser adalah struktur untuk melaporkan pengguna lain ke moderator
+type ReportUser struct {
+	Reason  string `json:"reason" validate:"required,oneof=spam harassment hate_speech inappropriate_content impersonation other" example:"harassment"`
+	Details string `json:"details" validate:"omitempty,max=1000" example:"Sends insulting messages about my meals"`
+}
+
+// UserReportQuery adalah struktur untuk query antrian moderasi laporan pengguna
+type UserReportQuery struct {
+	Status string `query:"status" validate:"omitempty,oneof=open dismissed warned suspended"`
+	Page   int    `query:"page" validate:"omitempty,number,min=1"`
+	Limit  int    `query:"limit" validate:"omitempty,number,min=1,max=100"`
+}
+
+// ResolveUserReport adalah struktur untuk menyelesaikan laporan pengguna: diabaikan, peringatan, atau penangguhan akun
+type ResolveUserReport struct {
+	Action      string `json:"action" validate:"required,oneof=dismiss warn suspend" example:"suspend"`
+	Note        string `json:"note" validate:"required_unless=Action dismiss,max=1000" example:"Repeated harassment of other users"`
+	SuspendDays int    `json:"suspend_days" validate:"required_if=Action suspend,omitempty,min=1,max=365" example:"7"`
+}
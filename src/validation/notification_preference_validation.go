@@ -0,0 +1,12 @@
+package validation
+
+// UpdateNotificationPreferences adalah struktur untuk mengubah langganan dan saluran kategori notifikasi pengguna
+type UpdateNotificationPreferences struct {
+	Preferences map[string]bool   `json:"preferences" validate:"required_without=Channels"`
+	Channels    map[string]string `json:"channels" validate:"required_without=Preferences,dive,oneof=email sms whatsapp"`
+}
+
+// Unsubscribe adalah struktur untuk berhenti berlangganan melalui tautan di email
+type Unsubscribe struct {
+	Token string `json:"token" query:"token" validate:"required"`
+}
@@ -0,0 +1,25 @@
+package validation
+
+// NotificationTemplateQuery adalah struktur untuk query daftar template notifikasi
+type NotificationTemplateQuery struct {
+	Key    string `query:"key" validate:"omitempty,max=50"`
+	Locale string `query:"locale" validate:"omitempty,max=10"`
+}
+
+// SaveNotificationTemplate adalah struktur untuk menyimpan versi baru template notifikasi
+type SaveNotificationTemplate struct {
+	Key     string `json:"key" validate:"required,oneof=reset_password verify_email payment_approved payment_rejected payment_reminder receipt installment_bill"`
+	Locale  string `json:"locale" validate:"omitempty,min=2,max=10"`
+	Subject string `json:"subject" validate:"required,max=255"`
+	Body    string `json:"body" validate:"required,max=20000"`
+}
+
+// PreviewNotificationTemplate adalah struktur untuk melihat hasil render template notifikasi.
+// Subject dan body kosong memakai template yang sedang berlaku, variabel kosong memakai contoh.
+type PreviewNotificationTemplate struct {
+	Key       string            `json:"key" validate:"required,oneof=reset_password verify_email payment_approved payment_rejected payment_reminder receipt installment_bill"`
+	Locale    string            `json:"locale" validate:"omitempty,min=2,max=10"`
+	Subject   string            `json:"subject" validate:"omitempty,max=255"`
+	Body      string            `json:"body" validate:"omitempty,max=20000"`
+	Variables map[string]string `json:"variables" validate:"omitempty"`
+}
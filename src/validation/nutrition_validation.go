@@ -0,0 +1,19 @@
+package validation
+
+// NutritionReportQuery adalah struktur untuk query laporan nutrisi harian pengguna
+type NutritionReportQuery struct {
+	From string `query:"from" validate:"required,datetime=2006-01-02"`
+	To   string `query:"to" validate:"required,datetime=2006-01-02"`
+}
+
+// MealDiaryQuery adalah struktur untuk query buku harian makan pengguna per hari atau per minggu
+type MealDiaryQuery struct {
+	Date   string `query:"date" validate:"required,datetime=2006-01-02"`
+	Period string `query:"period" validate:"omitempty,oneof=day week"`
+}
+
+// ScanHistoryQuery adalah struktur untuk query riwayat scan makanan pengguna
+type ScanHistoryQuery struct {
+	Page  int `query:"page" validate:"omitempty,number,min=1"`
+	Limit int `query:"limit" validate:"omitempty,number,min=1,max=100"`
+}
@@ -0,0 +1,12 @@
+package validation
+
+// NotificationPermission adalah struktur untuk melaporkan izin notifikasi yang diberikan pengguna di aplikasi
+type NotificationPermission struct {
+	Granted *bool `json:"granted" validate:"required"`
+}
+
+// OnboardingFunnelQuery adalah struktur untuk query funnel onboarding pengguna yang mendaftar dalam periode
+type OnboardingFunnelQuery struct {
+	From string `query:"from" validate:"required,datetime=2006-01-02"`
+	To   string `query:"to" validate:"required,datetime=2006-01-02"`
+}
@@ -0,0 +1,21 @@
+package validation
+
+// UpdateMaintenance adalah struktur untuk mengaktifkan atau menonaktifkan mode maintenance
+type UpdateMaintenance struct {
+	Enabled *bool  `json:"enabled" validate:"required"`
+	Message string `json:"message" validate:"omitempty,max=255"`
+}
+
+// TelegramUpdate adalah struktur untuk pesan masuk dari webhook bot Telegram
+type TelegramUpdate struct {
+	Message *struct {
+		From struct {
+			ID int64 `json:"id"`
+		} `json:"from"`
+		Chat struct {
+			ID   int64  `json:"id"`
+			Type string `json:"type"`
+		} `json:"chat"`
+		Text string `json:"text"`
+	} `json:"message"`
+}
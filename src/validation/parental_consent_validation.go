@@ -0,0 +1,13 @@
+package validation
+
+// RequestParentalConsent adalah struktur untuk meminta persetujuan orang tua atau wali melalui email
+type RequestParentalConsent struct {
+	GuardianEmail string `json:"guardian_email" validate:"required,email,max=100" example:"parent@example.com"`
+}
+
+// RespondParentalConsent adalah struktur untuk jawaban orang tua atau wali dari tautan di email
+type RespondParentalConsent struct {
+	Token        string `json:"token" validate:"required,max=2550"`
+	GuardianName string `json:"guardian_name" validate:"required,max=100" example:"Siti Rahayu"`
+	Decision     string `json:"decision" validate:"required,oneof=granted declined" example:"granted"`
+}
@@ -0,0 +1,75 @@
+package validation
+
+// CreatePartnerKey adalah struktur untuk membuat API key partner dengan scope yang diizinkan
+type CreatePartnerKey struct {
+	Partner    string         `json:"partner" validate:"required,max=50,alphanum" example:"klinikgizi"`
+	Name       string         `json:"name" validate:"omitempty,max=100" example:"Production"`
+	Scopes     []string       `json:"scopes" validate:"required,min=1,dive,oneof=read:foods write:members read:entitlements read:observations" example:"read:foods"`
+	RateLimits map[string]int `json:"rate_limits" validate:"omitempty,dive,min=1,max=10000"`
+	// Versi syarat partner yang disetujui partner, wajib untuk scope premium
+	TermsVersion string `json:"terms_version" validate:"omitempty,max=20" example:"2026-10"`
+}
+
+// UpdatePartnerKey adalah struktur untuk mengubah scope, batas request, atau persetujuan syarat API key partner
+type UpdatePartnerKey struct {
+	Scopes       []string       `json:"scopes" validate:"omitempty,min=1,dive,oneof=read:foods write:members read:entitlements read:observations"`
+	RateLimits   map[string]int `json:"rate_limits" validate:"omitempty,dive,min=1,max=10000"`
+	TermsVersion string         `json:"terms_version" validate:"omitempty,max=20" example:"2026-10"`
+}
+
+// PartnerKeyUsageQuery adalah struktur untuk query pemakaian API key partner
+type PartnerKeyUsageQuery struct {
+	From string `query:"from" validate:"required,datetime=2006-01-02"`
+	To   string `query:"to" validate:"required,datetime=2006-01-02"`
+}
+
+// PartnerUsageQuery adalah struktur untuk query pemakaian API oleh partner sendiri, per hari atau per bulan
+type PartnerUsageQuery struct {
+	Interval string `query:"interval" validate:"omitempty,oneof=day month"`
+	From     string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To       string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// EnrollPartnerMember adalah struktur untuk mendaftarkan pengguna sebagai member partner
+type EnrollPartnerMember struct {
+	Email string `json:"email" validate:"required,email,max=50" example:"member@example.com"`
+	// Code adalah kode pendaftaran yang diberikan pengguna kepada partner dari aplikasi
+	Code string `json:"code" validate:"required,max=20" example:"K7QF3M9X"`
+}
+
+// PartnerObservationQuery adalah struktur untuk query observasi FHIR seorang member partner
+type PartnerObservationQuery struct {
+	From     string `query:"from" validate:"required,datetime=2006-01-02"`
+	To       string `query:"to" validate:"required,datetime=2006-01-02"`
+	Category string `query:"category" validate:"omitempty,oneof=vital-signs survey"`
+}
+
+// CreatePartnerTier adalah struktur untuk membuat tier komersial API partner
+type CreatePartnerTier struct {
+	Key             string         `json:"key" validate:"required,max=30,alphanum,lowercase" example:"enterprise"`
+	Name            string         `json:"name" validate:"required,max=100" example:"Enterprise"`
+	RateLimits      map[string]int `json:"rate_limits" validate:"omitempty,dive,min=1,max=10000"`
+	MonthlyRequests int64          `json:"monthly_requests" validate:"min=0" example:"1000000"`
+	OveragePrice    int64          `json:"overage_price" validate:"min=0" example:"500"`
+	Currency        string         `json:"currency" validate:"omitempty,oneof=IDR USD SGD MYR" example:"IDR"`
+}
+
+// UpdatePartnerTier adalah struktur untuk mengubah batas request dan harga tier komersial API partner
+type UpdatePartnerTier struct {
+	Name            *string        `json:"name" validate:"omitempty,max=100"`
+	RateLimits      map[string]int `json:"rate_limits" validate:"omitempty,dive,min=1,max=10000"`
+	MonthlyRequests *int64         `json:"monthly_requests" validate:"omitempty,min=0"`
+	OveragePrice    *int64         `json:"overage_price" validate:"omitempty,min=0"`
+	Currency        *string        `json:"currency" validate:"omitempty,oneof=IDR USD SGD MYR"`
+}
+
+// SetPartnerTier adalah struktur untuk menetapkan tier komersial seorang partner, kosong untuk melepasnya
+type SetPartnerTier struct {
+	Tier string `json:"tier" validate:"omitempty,max=30" example:"enterprise"`
+}
+
+// PartnerOverageQuery adalah struktur untuk query laporan kelebihan pemakaian API partner dalam sebulan
+type PartnerOverageQuery struct {
+	Month   string `query:"month" validate:"required,datetime=2006-01"`
+	Partner string `query:"partner" validate:"omitempty,max=50"`
+}
@@ -0,0 +1,37 @@
+package validation
+
+// RegisterPasskey adalah struktur untuk mendaftarkan passkey dengan jawaban navigator.credentials.create
+type RegisterPasskey struct {
+	ChallengeID string             `json:"challenge_id" validate:"required,uuid"`
+	Name        string             `json:"name" validate:"omitempty,max=100" example:"iPhone"`
+	Credential  PasskeyAttestation `json:"credential"`
+}
+
+// PasskeyAttestation adalah struktur PublicKeyCredential hasil pendaftaran passkey, nilai biner dalam base64url
+type PasskeyAttestation struct {
+	ID       string `json:"id" validate:"required,max=1400"`
+	Type     string `json:"type" validate:"required,eq=public-key"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON" validate:"required,max=4096"`
+		AttestationObject string   `json:"attestationObject" validate:"required,max=65536"`
+		Transports        []string `json:"transports" validate:"max=10,dive,max=20"`
+	} `json:"response"`
+}
+
+// PasskeyLogin adalah struktur untuk masuk dengan jawaban navigator.credentials.get
+type PasskeyLogin struct {
+	ChallengeID string           `json:"challenge_id" validate:"required,uuid"`
+	Credential  PasskeyAssertion `json:"credential"`
+}
+
+// PasskeyAssertion adalah struktur PublicKeyCredential hasil masuk dengan passkey, nilai biner dalam base64url
+type PasskeyAssertion struct {
+	ID       string `json:"id" validate:"required,max=1400"`
+	Type     string `json:"type" validate:"required,eq=public-key"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON" validate:"required,max=4096"`
+		AuthenticatorData string `json:"authenticatorData" validate:"required,max=4096"`
+		Signature         string `json:"signature" validate:"required,max=1024"`
+		UserHandle        string `json:"userHandle" validate:"max=128"`
+	} `json:"response"`
+}
@@ -0,0 +1,19 @@
+package validation
+
+// SendPhoneCode adalah struktur untuk mengirim kode OTP ke nomor telepon melalui SMS atau WhatsApp
+type SendPhoneCode struct {
+	Phone   string `json:"phone" validate:"required,max=20" example:"081234567890"`
+	Channel string `json:"channel" validate:"omitempty,oneof=sms whatsapp" example:"whatsapp"`
+}
+
+// CheckPhoneCode adalah struktur untuk memeriksa kode OTP yang dikirim ke nomor telepon
+type CheckPhoneCode struct {
+	Phone string `json:"phone" validate:"required,max=20" example:"081234567890"`
+	Code  string `json:"code" validate:"required,len=6,numeric" example:"123456"`
+}
+
+// PhoneMessageQuery adalah struktur untuk paginasi pesan SMS dan WhatsApp yang dikirim ke pengguna
+type PhoneMessageQuery struct {
+	Page  int `query:"page" validate:"number,min=1"`
+	Limit int `query:"limit" validate:"number,min=1,max=100"`
+}
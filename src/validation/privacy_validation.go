@@ -0,0 +1,7 @@
+package validation
+
+// UpdatePrivacySettings adalah struktur untuk mengubah pengaturan privasi, field yang tidak dikirim tidak berubah
+type UpdatePrivacySettings struct {
+	ProfileVisibility *string `json:"profile_visibility" validate:"omitempty,oneof=private public" example:"public"`
+	CoachDataSharing  *string `json:"coach_data_sharing" validate:"omitempty,oneof=none summary full" example:"summary"`
+}
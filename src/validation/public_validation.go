@@ -0,0 +1,12 @@
+package validation
+
+// PublicPlanQuery adalah struktur untuk query daftar plan publik di website
+type PublicPlanQuery struct {
+	Lang string `query:"lang" validate:"omitempty,oneof=id en"`
+}
+
+// PublicContentQuery adalah struktur untuk query daftar artikel dan resep publik di website
+type PublicContentQuery struct {
+	Page  int `query:"page" validate:"omitempty,number,min=1"`
+	Limit int `query:"limit" validate:"omitempty,number,min=1,max=50"`
+}
@@ -0,0 +1,40 @@
+package validation
+
+import (
+	"app/src/model"
+	"time"
+)
+
+// RectifyPersonalData adalah struktur untuk memperbaiki data pribadi pengguna di semua subsistem dalam satu transaksi
+type RectifyPersonalData struct {
+	Reason        string                `json:"reason" validate:"required,max=500" example:"Birth date was entered wrong at sign up"`
+	Profile       *RectifyProfile       `json:"profile"`
+	Measurements  []RectifyMeasurement  `json:"measurements" validate:"omitempty,max=50,dive"`
+	PaymentProofs []RectifyPaymentProof `json:"payment_proofs" validate:"omitempty,max=50,dive"`
+}
+
+// RectifyProfile adalah struktur untuk memperbaiki data profil, kolom yang kosong tidak diubah
+type RectifyProfile struct {
+	Name           *string              `json:"name" validate:"omitempty,min=1,max=50" example:"fake name"`
+	Email          *string              `json:"email" validate:"omitempty,email,max=50" example:"fake@example.com"`
+	Phone          *string              `json:"phone" validate:"omitempty,max=20" example:"+6281234567890"`
+	BirthDate      *time.Time           `json:"birth_date"`
+	Height         *float64             `json:"height" validate:"omitempty,gt=0,lte=300" example:"175.5"`
+	Weight         *float64             `json:"weight" validate:"omitempty,gt=0,lte=500" example:"70.3"`
+	Gender         *model.GenderType    `json:"gender" validate:"omitempty,oneof=Male Female" example:"Male"`
+	ActivityLevel  *model.ActivityLevel `json:"activity_level" validate:"omitempty,oneof=Light Medium Heavy" example:"Medium"`
+	MedicalHistory *string              `json:"medical_history" validate:"omitempty,max=1000" example:"No known allergies"`
+}
+
+// RectifyMeasurement adalah struktur untuk memperbaiki berat dan tinggi badan yang pernah dicatat
+type RectifyMeasurement struct {
+	ID     string   `json:"id" validate:"required,uuid"`
+	Height *float64 `json:"height" validate:"omitempty,gt=0,lte=300" example:"175.5"`
+	Weight *float64 `json:"weight" validate:"omitempty,gt=0,lte=500" example:"70.3"`
+}
+
+// RectifyPaymentProof adalah struktur untuk memperbaiki nama pemilik rekening pada bukti transfer
+type RectifyPaymentProof struct {
+	ID          string `json:"id" validate:"required,uuid"`
+	AccountName string `json:"account_name" validate:"required,max=100" example:"Budi Santoso"`
+}
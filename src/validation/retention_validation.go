@@ -0,0 +1,12 @@
+package validation
+
+// RetentionRunQuery adalah struktur untuk query riwayat audit aturan retensi data
+type RetentionRunQuery struct {
+	Rule  string `query:"rule" validate:"omitempty,oneof=scan_images logs inactive_accounts activity_logs login_locations"`
+	Limit int    `query:"limit" validate:"omitempty,min=1,max=200"`
+}
+
+// MediaCleanupRunQuery adalah struktur untuk query riwayat pembersihan media yang tidak lagi dipakai
+type MediaCleanupRunQuery struct {
+	Limit int `query:"limit" validate:"omitempty,min=1,max=200"`
+}
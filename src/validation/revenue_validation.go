@@ -0,0 +1,21 @@
+package validation
+
+// RevenueReportQuery adalah struktur untuk query laporan pendapatan per bulan
+type RevenueReportQuery struct {
+	From string `query:"from" validate:"required,datetime=2006-01"`
+	To   string `query:"to" validate:"required,datetime=2006-01"`
+}
+
+// RevenueAnalyticsQuery adalah struktur untuk query analitik pendapatan dan pelanggan per periode
+type RevenueAnalyticsQuery struct {
+	From     string `query:"from" validate:"required,datetime=2006-01-02"`
+	To       string `query:"to" validate:"required,datetime=2006-01-02"`
+	Interval string `query:"interval" validate:"required,oneof=day week month"`
+}
+
+// SubscriptionRetentionQuery adalah struktur untuk query retensi kohort pelanggan dan churn per paket
+type SubscriptionRetentionQuery struct {
+	From   string `query:"from" validate:"required,datetime=2006-01"`
+	To     string `query:"to" validate:"required,datetime=2006-01"`
+	Months int    `query:"months" validate:"min=1,max=24"`
+}
@@ -0,0 +1,19 @@
+package validation
+
+// CreateRole adalah struktur untuk membuat role kustom dengan sebagian hak akses admin
+type CreateRole struct {
+	Name        string   `json:"name" validate:"required,min=3,max=50" example:"support"`
+	Description string   `json:"description" validate:"omitempty,max=255" example:"Customer support, reads users and subscriptions"`
+	Rights      []string `json:"rights" validate:"required,min=1,dive,required,max=50" example:"getUsers,getUserDetails"`
+}
+
+// UpdateRole adalah struktur untuk mengubah deskripsi atau hak akses role kustom, field kosong tidak diubah
+type UpdateRole struct {
+	Description *string  `json:"description" validate:"omitempty,max=255" example:"Customer support"`
+	Rights      []string `json:"rights" validate:"omitempty,min=1,dive,required,max=50" example:"getUsers,getUserDetails,getSubscriptions"`
+}
+
+// AssignRole adalah struktur untuk mengganti role pengguna oleh admin
+type AssignRole struct {
+	Role string `json:"role" validate:"required,max=50" example:"support"`
+}
@@ -0,0 +1,14 @@
+package validation
+
+// UpdateRuntimeConfig adalah struktur untuk mengubah flag konfigurasi tanpa restart, nilai null mengembalikan flag ke default
+type UpdateRuntimeConfig struct {
+	Values map[string]*string `json:"values" validate:"required,min=1,max=20"`
+	Reason string             `json:"reason" validate:"required,max=255" example:"Card testing wave, tighten checkout limits"`
+}
+
+// ConfigChangeQuery adalah struktur untuk query riwayat perubahan konfigurasi
+type ConfigChangeQuery struct {
+	Key   string `query:"key" validate:"omitempty,max=100"`
+	Page  int    `query:"page" validate:"omitempty,number,min=1"`
+	Limit int    `query:"limit" validate:"omitempty,number,min=1,max=100"`
+}
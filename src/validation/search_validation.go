@@ -0,0 +1,8 @@
+package validation
+
+// ContentSearchQuery adalah struktur untuk query pencarian artikel dan resep
+type ContentSearchQuery struct {
+	Q     string `query:"q" validate:"required,min=2,max=100"`
+	Type  string `query:"type" validate:"omitempty,oneof=article recipe"`
+	Limit int    `query:"limit" validate:"omitempty,number,min=1,max=50"`
+}
@@ -0,0 +1,7 @@
+package validation
+
+// StorageUsageQuery adalah struktur untuk query laporan penggunaan penyimpanan
+type StorageUsageQuery struct {
+	Days         int `query:"days" validate:"number,min=1,max=365"`
+	ForecastDays int `query:"forecast_days" validate:"number,min=1,max=365"`
+}
@@ -8,9 +8,29 @@ import (
 
 // SubscriptionQuery adalah struktur untuk query parameter subscription
 type SubscriptionQuery struct {
-	Page   int    `query:"page"`
-	Limit  int    `query:"limit"`
-	Status string `query:"status"`
+	Page          int    `query:"page"`
+	Limit         int    `query:"limit"`
+	Status        string `query:"status"`
+	PaymentMethod string `query:"payment_method"`
+	Source        string `query:"source"`
+	// Trial lists the free trials in a state, active, converted or expired
+	Trial string `query:"trial" validate:"omitempty,oneof=active converted expired"`
+	// IncludeDeleted lists the subscriptions admins deleted along with the others
+	IncludeDeleted bool `query:"include_deleted"`
+}
+
+// SubscriptionPlanQuery adalah struktur untuk filter, pencarian, urutan dan paginasi daftar subscription plan admin
+type SubscriptionPlanQuery struct {
+	Page      int    `query:"page" validate:"number,min=1"`
+	Limit     int    `query:"limit" validate:"number,min=1,max=100"`
+	IsActive  *bool  `query:"is_active"`
+	MinPrice  *int   `query:"min_price" validate:"omitempty,min=0"`
+	MaxPrice  *int   `query:"max_price" validate:"omitempty,min=0"`
+	Currency  string `query:"currency" validate:"omitempty,oneof=IDR USD SGD MYR"` // wajib dengan min_price atau max_price
+	Search    string `query:"search" validate:"omitempty,max=100"`
+	SortBy    string `query:"sort_by" validate:"omitempty,oneof=price name user_count created_at"`
+	Order     string `query:"order" validate:"omitempty,oneof=asc desc"`
+	WithUsers bool   `query:"with_users"`
 }
 
 // UpdateSubscription adalah struktur untuk update subscription
@@ -21,6 +41,10 @@ type UpdateSubscription struct {
 	StartDate     *time.Time `json:"start_date" validate:"omitempty"`
 	EndDate       *time.Time `json:"end_date" validate:"omitempty"`
 	PaymentMethod *string    `json:"payment_method" validate:"omitempty"`
+	// Prorate credits the unused days of a paid subscription against the new plan, true by default on a change of plan
+	Prorate *bool `json:"prorate" validate:"omitempty"`
+	// Comp gives away an upgrade that leaves an amount due, it is refused without it
+	Comp *bool `json:"comp" validate:"omitempty"`
 }
 
 // UpdatePaymentStatus adalah struktur untuk update payment status
@@ -28,13 +52,145 @@ type UpdatePaymentStatus struct {
 	Status string `json:"status" validate:"required,oneof=pending success failed"`
 }
 
+// UpdateAutoRenew adalah struktur untuk mengaktifkan atau menonaktifkan perpanjangan otomatis subscription
+type UpdateAutoRenew struct {
+	Enabled *bool `json:"enabled" validate:"required"`
+}
+
+// UpgradeSubscription adalah struktur untuk upgrade subscription aktif ke plan yang lebih mahal oleh pengguna
+type UpgradeSubscription struct {
+	PlanID        string `json:"plan_id" validate:"required,uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	PaymentMethod string `json:"payment_method" validate:"omitempty,oneof=gopay shopeepay bank_transfer credit_card" example:"gopay"`
+}
+
+// CreateSubscriptionPlan adalah struktur untuk membuat subscription plan baru
+type CreateSubscriptionPlan struct {
+	Name         string          `json:"name" validate:"required,min=2,max=50" example:"Premium Bulanan"`
+	Price        int             `json:"price" validate:"required,min=1" example:"49000"` // in the minor unit of the currency
+	Currency     string          `json:"currency" validate:"omitempty,oneof=IDR USD SGD MYR" example:"IDR"`
+	Description  string          `json:"description" validate:"omitempty,max=1000"`
+	AIscanLimit  int             `json:"ai_scan_limit" validate:"required,min=-1" example:"100"` // -1 for unlimited
+	ValidityDays int             `json:"validity_days" validate:"required,min=1" example:"30"`
+	Features     map[string]bool `json:"features" validate:"omitempty,dive,keys,oneof=scan_ai scan_calorie chatbot bmi_check weight_tracking health_info,endkeys"`
+	IsActive     *bool           `json:"is_active" validate:"omitempty"` // active unless false
+
+	AllowInstallments bool `json:"allow_installments" validate:"omitempty"`
+	InstallmentCount  int  `json:"installment_count" validate:"omitempty,min=2,max=12"`
+
+	// Messages to the assistant per day, -1 for unlimited, 30 when left out
+	ChatMessageLimit *int `json:"chat_message_limit" validate:"omitempty,min=-1"`
+	// Voice logs per subscription, 0 leaves voice logging out of the plan, -1 for unlimited, 30 when left out
+	VoiceLogLimit *int `json:"voice_log_limit" validate:"omitempty,min=-1"`
+
+	// Leaves the usage limits out of the public plan list of the marketing website
+	LimitsPrivate bool `json:"limits_private" validate:"omitempty"`
+
+	// Days of the free trial new users may start, 0 for none
+	TrialDays int `json:"trial_days" validate:"omitempty,min=0,max=90" example:"7"`
+
+	// Availability window in RFC3339
+	Hidden         bool   `json:"hidden" validate:"omitempty"`
+	AvailableFrom  string `json:"available_from" validate:"omitempty"`
+	AvailableUntil string `json:"available_until" validate:"omitempty"`
+}
+
 // UpdateSubscriptionPlan adalah struktur untuk update subscription plan
 type UpdateSubscriptionPlan struct {
 	Name         *string          `json:"name" validate:"omitempty,min=2,max=50"`
-	Price        *int             `json:"price" validate:"omitempty,min=1"`
+	Price        *int             `json:"price" validate:"omitempty,min=1"` // in the minor unit of the currency
+	Currency     *string          `json:"currency" validate:"omitempty,oneof=IDR USD SGD MYR"`
 	Description  *string          `json:"description" validate:"omitempty"`
 	AIscanLimit  *int             `json:"ai_scan_limit" validate:"omitempty,min=1"`
 	ValidityDays *int             `json:"validity_days" validate:"omitempty,min=1"`
-	Features     *map[string]bool `json:"features" validate:"omitempty"`
+	Features     *map[string]bool `json:"features" validate:"omitempty,dive,keys,oneof=scan_ai scan_calorie chatbot bmi_check weight_tracking health_info,endkeys"`
 	IsActive     *bool            `json:"is_active" validate:"omitempty"`
+
+	AllowInstallments *bool `json:"allow_installments" validate:"omitempty"`
+	InstallmentCount  *int  `json:"installment_count" validate:"omitempty,min=2,max=12"`
+
+	// Messages to the assistant per day, -1 for unlimited
+	ChatMessageLimit *int `json:"chat_message_limit" validate:"omitempty,min=-1"`
+	// Voice logs per subscription, 0 leaves voice logging out of the plan, -1 for unlimited
+	VoiceLogLimit *int `json:"voice_log_limit" validate:"omitempty,min=-1"`
+
+	// Leaves the usage limits out of the public plan list of the marketing website
+	LimitsPrivate *bool `json:"limits_private" validate:"omitempty"`
+
+	// Days of the free trial new users may start, 0 ends the offer for new trials
+	TrialDays *int `json:"trial_days" validate:"omitempty,min=0,max=90"`
+
+	// Availability window in RFC3339, an empty string clears the bound
+	Hidden         *bool   `json:"hidden" validate:"omitempty"`
+	AvailableFrom  *string `json:"available_from" validate:"omitempty"`
+	AvailableUntil *string `json:"available_until" validate:"omitempty"`
+}
+
+// CompSubscription adalah struktur untuk memberikan subscription gratis oleh admin
+type CompSubscription struct {
+	UserID       string `json:"user_id" validate:"required,uuid"`
+	PlanID       string `json:"plan_id" validate:"required,uuid"`
+	DurationDays int    `json:"duration_days" validate:"omitempty,min=1,max=3650"`
+	Reason       string `json:"reason" validate:"required,max=255"`
+}
+
+// CreateManualTransaction adalah struktur untuk mencatat pembayaran transfer bank manual
+type CreateManualTransaction struct {
+	UserID          string `form:"user_id" validate:"required,uuid"`
+	PlanID          string `form:"plan_id" validate:"required,uuid"`
+	Amount          int    `form:"amount" validate:"omitempty,min=1"`
+	BankName        string `form:"bank_name" validate:"omitempty,max=20"`
+	ReferenceNumber string `form:"reference_number" validate:"omitempty,max=100"`
+	TransferredAt   string `form:"transferred_at" validate:"omitempty,datetime=2006-01-02"`
+	Notes           string `form:"notes" validate:"omitempty,max=500"`
+}
+
+// TransactionQuery adalah struktur untuk filter dan paginasi daftar log transaksi admin, tanggal memakai waktu transaksi
+type TransactionQuery struct {
+	Page      int    `query:"page" validate:"number,min=1"`
+	Limit     int    `query:"limit" validate:"number,min=1,max=100"`
+	StartDate string `query:"start_date" validate:"omitempty,datetime=2006-01-02"`
+	EndDate   string `query:"end_date" validate:"omitempty,datetime=2006-01-02"`
+	Status    string `query:"status" validate:"omitempty,max=50"`
+	PlanID    string `query:"plan_id" validate:"omitempty,uuid"`
+	UserID    string `query:"user_id" validate:"omitempty,uuid"`
+}
+
+// TransactionExportQuery adalah struktur untuk filter ekspor log transaksi, tanggal memakai waktu transaksi
+type TransactionExportQuery struct {
+	From   string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To     string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+	Status string `query:"status" validate:"omitempty,max=50"`
+	PlanID string `query:"plan_id" validate:"omitempty,uuid"`
+	Format string `query:"format" validate:"omitempty,oneof=csv xlsx"`
+}
+
+// UploadPaymentProof adalah struktur untuk upload bukti transfer oleh user
+type UploadPaymentProof struct {
+	BankName        string `form:"bank_name" validate:"required,max=20"`
+	AccountName     string `form:"account_name" validate:"required,max=100"`
+	ReferenceNumber string `form:"reference_number" validate:"omitempty,max=100"`
+	Amount          int    `form:"amount" validate:"required,min=1"`
+}
+
+// PaymentProofQuery adalah struktur untuk query antrian verifikasi bukti transfer
+type PaymentProofQuery struct {
+	Page   int    `query:"page" validate:"omitempty,number,min=1"`
+	Limit  int    `query:"limit" validate:"omitempty,number,min=1,max=100"`
+	Status string `query:"status" validate:"omitempty,oneof=pending approved rejected"`
+}
+
+// RejectPaymentProof adalah struktur untuk menolak bukti transfer
+type RejectPaymentProof struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// SunsetPlan adalah struktur untuk menghentikan subscription plan dan memindahkan pelanggannya ke plan pengganti
+type SunsetPlan struct {
+	ReplacementPlanID string `json:"replacement_plan_id" validate:"required,uuid"`
+}
+
+// PlanChangeQuery adalah struktur untuk query riwayat perubahan subscription plan
+type PlanChangeQuery struct {
+	Page  int `query:"page" validate:"omitempty,number,min=1"`
+	Limit int `query:"limit" validate:"omitempty,number,min=1,max=100"`
 }
@@ -0,0 +1,30 @@
+package validation
+
+// CreateTipRule adalah struktur untuk menambah aturan tips harian ke katalog
+type CreateTipRule struct {
+	Key          string  `json:"key" validate:"required,max=50" example:"low_fiber"`
+	Metric       string  `json:"metric" validate:"required,oneof=calories protein carbs fat meal_count always" example:"protein"`
+	Comparison   string  `json:"comparison" validate:"required_unless=Metric always,omitempty,oneof=below above" example:"below"`
+	Threshold    float64 `json:"threshold" validate:"gte=0" example:"50"`
+	MinDays      int     `json:"min_days" validate:"gte=0,lte=30" example:"4"`
+	WindowDays   int     `json:"window_days" validate:"required,min=1,max=30" example:"7"`
+	Title        string  `json:"title" validate:"required,max=255" example:"Protein Anda masih kurang"`
+	Message      string  `json:"message" validate:"required,max=2000" example:"Asupan protein Anda kurang dari {{.threshold}} g pada {{.days}} dari {{.window}} hari terakhir."`
+	Priority     int     `json:"priority" validate:"gte=-1000,lte=1000" example:"50"`
+	CooldownDays int     `json:"cooldown_days" validate:"gte=0,lte=30" example:"3"`
+	Enabled      *bool   `json:"enabled" example:"true"`
+}
+
+// UpdateTipRule adalah struktur untuk mengubah aturan tips harian, field yang kosong tidak diubah
+type UpdateTipRule struct {
+	Metric       *string  `json:"metric" validate:"omitempty,oneof=calories protein carbs fat meal_count always"`
+	Comparison   *string  `json:"comparison" validate:"omitempty,oneof=below above"`
+	Threshold    *float64 `json:"threshold" validate:"omitempty,gte=0"`
+	MinDays      *int     `json:"min_days" validate:"omitempty,gte=0,lte=30"`
+	WindowDays   *int     `json:"window_days" validate:"omitempty,min=1,max=30"`
+	Title        *string  `json:"title" validate:"omitempty,min=1,max=255"`
+	Message      *string  `json:"message" validate:"omitempty,min=1,max=2000"`
+	Priority     *int     `json:"priority" validate:"omitempty,gte=-1000,lte=1000"`
+	CooldownDays *int     `json:"cooldown_days" validate:"omitempty,gte=0,lte=30"`
+	Enabled      *bool    `json:"enabled"`
+}
@@ -9,7 +9,7 @@ type CreateUser struct {
 	Name     string `json:"name" validate:"required,max=50" example:"fake name"`
 	Email    string `json:"email" validate:"required,email,max=50" example:"fake@example.com"`
 	Password string `json:"password" validate:"required,min=8,max=20,password" example:"password1"`
-	Role     string `json:"role" validate:"required,oneof=user admin,max=50" example:"user"`
+	Role     string `json:"role" validate:"required,max=50" example:"user"`
 }
 
 type UpdateUser struct {
@@ -25,6 +25,11 @@ type UpdateUser struct {
 	ProfilePicture *string              `form:"profile_picture,omitempty" validate:"omitempty,url" example:"https://example.com/image.jpg"`
 }
 
+// UpdateUserSandbox adalah struktur untuk menandai user sebagai akun sandbox (QA) oleh admin
+type UpdateUserSandbox struct {
+	IsSandbox *bool `json:"is_sandbox" validate:"required" example:"true"`
+}
+
 type UpdatePassOrVerify struct {
 	Password      string `json:"password,omitempty" validate:"omitempty,min=8,max=20,password" example:"password1"`
 	VerifiedEmail bool   `json:"verified_email" swaggerignore:"true" validate:"omitempty,boolean"`
@@ -34,4 +39,5 @@ type QueryUser struct {
 	Page   int    `validate:"omitempty,number,max=50"`
 	Limit  int    `validate:"omitempty,number,max=50"`
 	Search string `validate:"omitempty,max=50"`
+	SortBy string `validate:"omitempty,oneof=total_scans total_logged_days current_streak lifetime_spend"`
 }
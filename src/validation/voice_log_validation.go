@@ -0,0 +1,7 @@
+package validation
+
+// VoiceLog adalah struktur untuk form pencatatan makanan dengan suara
+type VoiceLog struct {
+	// Bahasa rekaman, kosongkan agar dideteksi otomatis
+	Lang string `form:"lang" validate:"omitempty,oneof=id en"`
+}
@@ -0,0 +1,15 @@
+package validation
+
+// WalletQuery adalah struktur untuk query riwayat transaksi wallet
+type WalletQuery struct {
+	Page  int `query:"page" validate:"omitempty,number,min=1"`
+	Limit int `query:"limit" validate:"omitempty,number,min=1,max=100"`
+}
+
+// AdjustWallet adalah struktur untuk penyesuaian saldo wallet oleh admin
+type AdjustWallet struct {
+	Amount      int    `json:"amount" validate:"required,ne=0"`
+	Type        string `json:"type" validate:"required,oneof=refund referral promo adjustment"`
+	Description string `json:"description" validate:"required,max=255"`
+	Reference   string `json:"reference" validate:"omitempty,max=100"`
+}
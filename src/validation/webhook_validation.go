@@ -0,0 +1,35 @@
+package validation
+
+// CreateWebhookEndpoint adalah struktur untuk mendaftarkan endpoint webhook keluar
+type CreateWebhookEndpoint struct {
+	URL         string   `json:"url" validate:"required,url,max=512" example:"https://partner.example.com/nutribox/webhooks"`
+	Description string   `json:"description" validate:"omitempty,max=255"`
+	Events      []string `json:"events" validate:"required,min=1,dive,oneof=subscription.activated subscription.expired subscription.cancelled subscription.suspended payment.failed"`
+	// Secret the payloads are signed with, generated when left out
+	Secret string `json:"secret" validate:"omitempty,min=16,max=100"`
+}
+
+// UpdateWebhookEndpoint adalah struktur untuk update endpoint webhook keluar
+type UpdateWebhookEndpoint struct {
+	URL         *string  `json:"url" validate:"omitempty,url,max=512"`
+	Description *string  `json:"description" validate:"omitempty,max=255"`
+	Events      []string `json:"events" validate:"omitempty,min=1,dive,oneof=subscription.activated subscription.expired subscription.cancelled subscription.suspended payment.failed"`
+	IsActive    *bool    `json:"is_active" validate:"omitempty"`
+}
+
+// WebhookDeliveryQuery adalah struktur untuk filter dan paginasi log pengiriman webhook
+type WebhookDeliveryQuery struct {
+	Page       int    `query:"page" validate:"number,min=1"`
+	Limit      int    `query:"limit" validate:"number,min=1,max=100"`
+	EndpointID string `query:"endpoint_id" validate:"omitempty,uuid"`
+	ReplayID   string `query:"replay_id" validate:"omitempty,uuid"`
+	Event      string `query:"event" validate:"omitempty,max=50"`
+	Status     string `query:"status" validate:"omitempty,oneof=pending delivered failed"`
+}
+
+// ReplayWebhookEvents adalah struktur untuk mengirim ulang event webhook dalam rentang waktu ke sebuah endpoint
+type ReplayWebhookEvents struct {
+	From  string `query:"from" validate:"required,datetime=2006-01-02T15:04:05Z07:00" example:"2026-10-14T08:00:00Z"`
+	To    string `query:"to" validate:"required,datetime=2006-01-02T15:04:05Z07:00" example:"2026-10-14T20:00:00Z"`
+	Event string `query:"event" validate:"omitempty,oneof=subscription.activated subscription.expired subscription.cancelled subscription.suspended payment.failed"`
+}
@@ -0,0 +1,128 @@
+package webauthn
+
+import (
+	"errors"
+	"math"
+)
+
+// maxCBORDepth bounds the nesting of decoded items, authenticator data is never more than a few levels deep
+const maxCBORDepth = 8
+
+var errCBOR = errors.New("webauthn: malformed CBOR")
+
+// decodeCBOR decodes the first CBOR item of data and returns it with the bytes after it. It covers what
+// authenticators send: integers as int64, byte strings, text strings, arrays, maps keyed by integers or text,
+// tags and simple values, all of definite length. Floats are skipped and decoded as nil.
+func decodeCBOR(data []byte) (any, []byte, error) {
+	return decodeItem(data, 0)
+}
+
+func decodeItem(data []byte, depth int) (any, []byte, error) {
+	if depth > maxCBORDepth || len(data) == 0 {
+		return nil, nil, errCBOR
+	}
+	major, info := data[0]>>5, data[0]&0x1f
+	data = data[1:]
+
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		case 25, 26, 27:
+			size := 1 << (info - 24)
+			if len(data) < size {
+				return nil, nil, errCBOR
+			}
+			return nil, data[size:], nil
+		}
+		return nil, nil, errCBOR
+	}
+
+	n, data, err := readArgument(info, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if n > math.MaxInt64 {
+			return nil, nil, errCBOR
+		}
+		return int64(n), data, nil
+	case 1:
+		if n > math.MaxInt64 {
+			return nil, nil, errCBOR
+		}
+		return -1 - int64(n), data, nil
+	case 2, 3:
+		if n > uint64(len(data)) {
+			return nil, nil, errCBOR
+		}
+		if major == 3 {
+			return string(data[:n]), data[n:], nil
+		}
+		return data[:n:n], data[n:], nil
+	case 4:
+		// Every item takes a byte at least, a longer count is a lie
+		if n > uint64(len(data)) {
+			return nil, nil, errCBOR
+		}
+		items := make([]any, 0, n)
+		for range n {
+			var item any
+			if item, data, err = decodeItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+	case 5:
+		if n > uint64(len(data))/2 {
+			return nil, nil, errCBOR
+		}
+		m := make(map[any]any, n)
+		for range n {
+			var key, value any
+			if key, data, err = decodeItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errCBOR
+			}
+			if value, data, err = decodeItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			m[key] = value
+		}
+		return m, data, nil
+	case 6:
+		return decodeItem(data, depth+1)
+	}
+	return nil, nil, errCBOR
+}
+
+// readArgument reads the count or value that follows the initial byte of an item
+func readArgument(info byte, data []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(data) < size {
+			return 0, nil, errCBOR
+		}
+		var n uint64
+		for _, b := range data[:size] {
+			n = n<<8 | uint64(b)
+		}
+		return n, data[size:], nil
+	}
+	// Indefinite lengths and reserved values, authenticators do not send them
+	return 0, nil, errCBOR
+}
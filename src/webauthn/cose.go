@@ -0,0 +1,111 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+)
+
+// COSE algorithms of the passkeys accepted, the ones platform authenticators and security keys use
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// SupportedAlgorithms are offered to authenticators in this order of preference
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE_Key labels and values, RFC 9053
+const (
+	coseKty      int64 = 1
+	coseAlg      int64 = 3
+	coseCrv      int64 = -1 // n of RSA keys
+	coseX        int64 = -2 // e of RSA keys
+	coseY        int64 = -3
+	coseKtyOKP   int64 = 1
+	coseKtyEC2   int64 = 2
+	coseKtyRSA   int64 = 3
+	coseP256     int64 = 1
+	coseEd25519  int64 = 6
+	minRSAKeyLen       = 2048
+)
+
+// publicKey is the public key of a passkey, parsed from the COSE_Key the authenticator sent
+type publicKey struct {
+	alg     int64
+	ecdsa   *ecdsa.PublicKey
+	ed25519 ed25519.PublicKey
+	rsa     *rsa.PublicKey
+}
+
+func parsePublicKey(cose []byte) (*publicKey, error) {
+	item, rest, err := decodeCBOR(cose)
+	if err != nil || len(rest) > 0 {
+		return nil, ErrInvalidPublicKey
+	}
+	m, ok := item.(map[any]any)
+	if !ok {
+		return nil, ErrInvalidPublicKey
+	}
+	kty, _ := m[coseKty].(int64)
+	alg, _ := m[coseAlg].(int64)
+
+	switch alg {
+	case AlgES256:
+		crv, _ := m[coseCrv].(int64)
+		x, _ := m[coseX].([]byte)
+		y, _ := m[coseY].([]byte)
+		if kty != coseKtyEC2 || crv != coseP256 || len(x) != 32 || len(y) != 32 {
+			return nil, ErrInvalidPublicKey
+		}
+		// Refuses points that are not on the curve
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, ErrInvalidPublicKey
+		}
+		return &publicKey{alg: alg, ecdsa: &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}}, nil
+	case AlgEdDSA:
+		crv, _ := m[coseCrv].(int64)
+		x, _ := m[coseX].([]byte)
+		if kty != coseKtyOKP || crv != coseEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, ErrInvalidPublicKey
+		}
+		return &publicKey{alg: alg, ed25519: ed25519.PublicKey(x)}, nil
+	case AlgRS256:
+		n, _ := m[coseCrv].([]byte)
+		e, _ := m[coseX].([]byte)
+		if kty != coseKtyRSA || len(e) == 0 || len(e) > 4 {
+			return nil, ErrInvalidPublicKey
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < minRSAKeyLen || key.E < 3 || key.E%2 == 0 {
+			return nil, ErrInvalidPublicKey
+		}
+		return &publicKey{alg: alg, rsa: key}, nil
+	}
+	return nil, ErrUnsupportedAlgorithm
+}
+
+// verify reports whether signature is the signature of data by the key
+func (key *publicKey) verify(data, signature []byte) bool {
+	switch key.alg {
+	case AlgES256:
+		digest := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(key.ecdsa, digest[:], signature)
+	case AlgEdDSA:
+		return ed25519.Verify(key.ed25519, data, signature)
+	case AlgRS256:
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(key.rsa, crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
@@ -0,0 +1,311 @@
+// Package webauthn registers passkeys and verifies sign-ins with them, as a WebAuthn relying party. Attestation
+// statements are not verified, passkeys of any authenticator are accepted.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Flags of the authenticator data
+const (
+	flagUserPresent    = 0x01
+	flagUserVerified   = 0x04
+	flagBackupEligible = 0x08
+	flagBackupState    = 0x10
+	flagAttestedData   = 0x40
+	flagExtensions     = 0x80
+)
+
+// maxCredentialIDLength is the longest credential ID WebAuthn allows
+const maxCredentialIDLength = 1023
+
+var (
+	ErrInvalidClientData        = errors.New("webauthn: invalid client data")
+	ErrChallengeMismatch        = errors.New("webauthn: the challenge is not the one issued")
+	ErrOriginNotAllowed         = errors.New("webauthn: origin not allowed")
+	ErrInvalidAuthenticatorData = errors.New("webauthn: invalid authenticator data")
+	ErrRPIDMismatch             = errors.New("webauthn: the passkey is for another relying party")
+	ErrUserNotPresent           = errors.New("webauthn: the user was not present")
+	ErrUserNotVerified          = errors.New("webauthn: the user was not verified")
+	ErrInvalidPublicKey         = errors.New("webauthn: invalid public key")
+	ErrUnsupportedAlgorithm     = errors.New("webauthn: unsupported public key algorithm")
+	ErrInvalidSignature         = errors.New("webauthn: invalid signature")
+	// ErrSignCountRegressed is returned when the authenticator counted fewer sign-ins than before, the passkey
+	// was probably cloned
+	ErrSignCountRegressed = errors.New("webauthn: the sign count went back")
+)
+
+// RelyingParty is the site passkeys are registered for
+type RelyingParty struct {
+	ID      string   // domain the passkeys are bound to, such as nutribox.id
+	Name    string   // name the authenticator shows
+	Origins []string // origins of the pages that can use the passkeys, such as https://app.nutribox.id
+	Timeout time.Duration
+}
+
+// Credential is a registered passkey
+type Credential struct {
+	ID             []byte
+	PublicKey      []byte // COSE_Key as the authenticator sent it
+	Algorithm      int64
+	SignCount      uint32
+	AAGUID         []byte // model of the authenticator, zeros when it does not tell
+	BackupEligible bool   // the passkey can be synced to other devices
+	BackedUp       bool
+}
+
+// Assertion is a verified sign-in with a passkey
+type Assertion struct {
+	SignCount uint32
+	BackedUp  bool
+}
+
+// CreationOptions are the options of navigator.credentials.create, binary values are base64url
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are the options of navigator.credentials.get, binary values are base64url
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// NewChallenge returns a random challenge for a registration or a sign-in
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// CreationOptions asks for a discoverable passkey of user, verified by the authenticator, that is not one of
+// exclude
+func (rp *RelyingParty) CreationOptions(challenge []byte, user UserEntity, exclude []CredentialDescriptor) *CreationOptions {
+	params := make([]CredentialParameter, 0, len(SupportedAlgorithms))
+	for _, alg := range SupportedAlgorithms {
+		params = append(params, CredentialParameter{Type: "public-key", Alg: alg})
+	}
+	if exclude == nil {
+		exclude = []CredentialDescriptor{}
+	}
+
+	return &CreationOptions{
+		Challenge:          EncodeBase64URL(challenge),
+		RP:                 RelyingPartyEntity{ID: rp.ID, Name: rp.Name},
+		User:               user,
+		PubKeyCredParams:   params,
+		Timeout:            rp.Timeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:        "required",
+			RequireResidentKey: true,
+			UserVerification:   "required",
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions asks for any discoverable passkey of the relying party, the authenticator lets the user pick
+func (rp *RelyingParty) RequestOptions(challenge []byte) *RequestOptions {
+	return &RequestOptions{
+		Challenge:        EncodeBase64URL(challenge),
+		Timeout:          rp.Timeout.Milliseconds(),
+		RPID:             rp.ID,
+		AllowCredentials: []CredentialDescriptor{},
+		UserVerification: "required",
+	}
+}
+
+// VerifyRegistration checks the response of navigator.credentials.create to challenge and returns the passkey
+func (rp *RelyingParty) VerifyRegistration(challenge, clientDataJSON, attestationObject []byte) (*Credential, error) {
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	item, _, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, ErrInvalidAuthenticatorData
+	}
+	object, ok := item.(map[any]any)
+	if !ok {
+		return nil, ErrInvalidAuthenticatorData
+	}
+	authData, ok := object["authData"].([]byte)
+	if !ok {
+		return nil, ErrInvalidAuthenticatorData
+	}
+
+	flags, signCount, rest, err := rp.parseAuthData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if flags&flagAttestedData == 0 || len(rest) < 18 {
+		return nil, ErrInvalidAuthenticatorData
+	}
+	aaguid := rest[:16]
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLength == 0 || idLength > maxCredentialIDLength || idLength > len(rest) {
+		return nil, ErrInvalidAuthenticatorData
+	}
+	id := rest[:idLength]
+	rest = rest[idLength:]
+
+	_, extensions, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	// Only extension outputs can follow the key
+	if len(extensions) > 0 && flags&flagExtensions == 0 {
+		return nil, ErrInvalidAuthenticatorData
+	}
+	cose := rest[:len(rest)-len(extensions)]
+	key, err := parsePublicKey(cose)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:             bytes.Clone(id),
+		PublicKey:      bytes.Clone(cose),
+		Algorithm:      key.alg,
+		SignCount:      signCount,
+		AAGUID:         bytes.Clone(aaguid),
+		BackupEligible: flags&flagBackupEligible != 0,
+		BackedUp:       flags&flagBackupState != 0,
+	}, nil
+}
+
+// VerifyAssertion checks the response of navigator.credentials.get to challenge was signed by credential
+func (rp *RelyingParty) VerifyAssertion(challenge, clientDataJSON, authenticatorData, signature []byte, credential *Credential) (*Assertion, error) {
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return nil, err
+	}
+
+	flags, signCount, _, err := rp.parseAuthData(authenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	if !key.verify(append(bytes.Clone(authenticatorData), clientDataHash[:]...), signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	// Authenticators that count sign-ins only count up, synced passkeys always send 0
+	if (signCount != 0 || credential.SignCount != 0) && signCount <= credential.SignCount {
+		return nil, ErrSignCountRegressed
+	}
+
+	return &Assertion{SignCount: signCount, BackedUp: flags&flagBackupState != 0}, nil
+}
+
+func (rp *RelyingParty) verifyClientData(raw []byte, ceremony string, challenge []byte) error {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil || data.Type != ceremony {
+		return ErrInvalidClientData
+	}
+
+	sent, err := DecodeBase64URL(data.Challenge)
+	if err != nil || subtle.ConstantTimeCompare(sent, challenge) != 1 {
+		return ErrChallengeMismatch
+	}
+	if data.CrossOrigin || !slices.Contains(rp.Origins, data.Origin) {
+		return ErrOriginNotAllowed
+	}
+	return nil
+}
+
+// parseAuthData checks the authenticator data is for the relying party and the user was present and verified,
+// and returns its flags, sign count and the attested data and extensions after them
+func (rp *RelyingParty) parseAuthData(authData []byte) (byte, uint32, []byte, error) {
+	if len(authData) < 37 {
+		return 0, 0, nil, ErrInvalidAuthenticatorData
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if subtle.ConstantTimeCompare(authData[:32], rpIDHash[:]) != 1 {
+		return 0, 0, nil, ErrRPIDMismatch
+	}
+
+	flags := authData[32]
+	if flags&flagUserPresent == 0 {
+		return 0, 0, nil, ErrUserNotPresent
+	}
+	if flags&flagUserVerified == 0 {
+		return 0, 0, nil, ErrUserNotVerified
+	}
+
+	return flags, binary.BigEndian.Uint32(authData[33:37]), authData[37:], nil
+}
+
+// EncodeBase64URL encodes binary WebAuthn values the way browsers do, base64url without padding
+func EncodeBase64URL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeBase64URL decodes a base64url value, with or without padding
+func DecodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,168 @@
+// Package xlsx writes a workbook of one sheet row by row, so exports larger than memory can be streamed to
+// the client. Cells are text or numbers, there are no styles, formulas or shared strings.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Cell is a value of a row
+type Cell struct {
+	value  string
+	number bool
+}
+
+// Text is a cell shown as typed, spreadsheets never run it as a formula
+func Text(value string) Cell {
+	return Cell{value: value}
+}
+
+// Number is a numeric cell, value must be a decimal number such as "15000" or "-2.5"
+func Number(value string) Cell {
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return Text(value)
+	}
+	return Cell{value: value, number: true}
+}
+
+// Writer writes the rows of a sheet to an underlying writer. Rows are written as they come, Close must be
+// called to finish the file.
+type Writer struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+	err   error
+}
+
+// static parts of the package, the sheet itself is written last
+var parts = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// NewWriter starts a workbook with one sheet called name on w
+func NewWriter(w io.Writer, name string) (*Writer, error) {
+	archive := zip.NewWriter(w)
+	for _, part := range parts {
+		if err := writePart(archive, part.name, part.content); err != nil {
+			return nil, err
+		}
+	}
+	workbook := xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + escape(sheetName(name)) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	if err := writePart(archive, "xl/workbook.xml", workbook); err != nil {
+		return nil, err
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	writer := &Writer{zip: archive, sheet: bufio.NewWriter(sheet)}
+	writer.write(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return writer, writer.err
+}
+
+// WriteRow appends a row to the sheet
+func (w *Writer) WriteRow(cells ...Cell) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.rows++
+	row := strconv.Itoa(w.rows)
+	w.write(`<row r="` + row + `">`)
+	for i, cell := range cells {
+		ref := column(i) + row
+		if cell.number {
+			w.write(`<c r="` + ref + `"><v>` + cell.value + `</v></c>`)
+			continue
+		}
+		w.write(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">` + escape(cell.value) + `</t></is></c>`)
+	}
+	w.write(`</row>`)
+	return w.err
+}
+
+// Flush writes the buffered rows to the underlying writer
+func (w *Writer) Flush() error {
+	if w.err == nil {
+		w.err = w.sheet.Flush()
+	}
+	return w.err
+}
+
+// Close ends the sheet and the archive, it does not close the underlying writer
+func (w *Writer) Close() error {
+	w.write(`</sheetData></worksheet>`)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	w.err = errors.New("xlsx: writer closed")
+	return w.zip.Close()
+}
+
+func (w *Writer) write(s string) {
+	if w.err == nil {
+		_, w.err = w.sheet.WriteString(s)
+	}
+}
+
+func writePart(archive *zip.Writer, name, content string) error {
+	part, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(part, content)
+	return err
+}
+
+// column returns the letters of the zero based column index: A to Z, then AA
+func column(index int) string {
+	var letters []byte
+	for index >= 0 {
+		letters = append([]byte{byte('A' + index%26)}, letters...)
+		index = index/26 - 1
+	}
+	return string(letters)
+}
+
+// escape makes text safe in XML, characters XML cannot hold become U+FFFD
+func escape(text string) string {
+	var escaped strings.Builder
+	_ = xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
+
+// sheetName trims a sheet name to the 31 characters spreadsheets allow, without the characters they refuse
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if name == "" {
+		return "Sheet1"
+	}
+	return name
+}
@@ -0,0 +1,176 @@
+package integration
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+	"app/test"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// activityTestResource marks the activities of these tests, so they are told apart from what other tests log
+const activityTestResource = "activity_test"
+
+// insertActivity saves an activity as written at the given time
+func insertActivity(t *testing.T, userID, action, resourceID string, at time.Time) {
+	activity := &model.ActivityLog{UserID: userID, Action: action, Resource: activityTestResource, ResourceID: resourceID}
+	require.NoError(t, test.DB.Create(activity).Error)
+	require.NoError(t, test.DB.Model(activity).UpdateColumn("created_at", at).Error)
+}
+
+func clearActivities(t *testing.T) {
+	require.NoError(t, test.DB.Where("resource = ?", activityTestResource).Delete(&model.ActivityLog{}).Error)
+}
+
+func TestActivityLogService(t *testing.T) {
+	t.Run("SaveActivity", func(t *testing.T) {
+		t.Run("should write the queued activities on close", func(t *testing.T) {
+			clearActivities(t)
+			t.Cleanup(func() { clearActivities(t) })
+			activityLogService := service.NewActivityLogService(test.DB, validation.Validator())
+
+			for i := 0; i < 150; i++ {
+				activityLogService.SaveActivity(utils.ActivityData{
+					UserID: "admin", Action: "update", Resource: activityTestResource, RequestID: "req_test",
+					Location: map[string]string{"country": "ID"},
+				})
+			}
+			activityLogService.Close()
+
+			var saved []model.ActivityLog
+			require.NoError(t, test.DB.Where("resource = ?", activityTestResource).Find(&saved).Error)
+			assert.Len(t, saved, 150)
+			assert.JSONEq(t, `{"country":"ID"}`, string(saved[0].Details))
+
+			// The writer is stopped, the activity is written right away
+			activityLogService.SaveActivity(utils.ActivityData{UserID: "admin", Action: "delete", Resource: activityTestResource})
+			var count int64
+			require.NoError(t, test.DB.Model(&model.ActivityLog{}).Where("resource = ? AND action = ?", activityTestResource, "delete").
+				Count(&count).Error)
+			assert.Equal(t, int64(1), count)
+		})
+	})
+
+	t.Run("GetLogs", func(t *testing.T) {
+		activityLogService := service.NewActivityLogService(test.DB, validation.Validator())
+		t.Cleanup(activityLogService.Close)
+
+		admin, other := uuid.NewString(), uuid.NewString()
+		now := time.Now()
+		day := func(daysAgo int) string { return now.AddDate(0, 0, -daysAgo).Format("2006-01-02") }
+		clearActivities(t)
+		t.Cleanup(func() { clearActivities(t) })
+		insertActivity(t, admin, "update_plan", "plan-1", now.AddDate(0, 0, -10))
+		insertActivity(t, admin, "delete_plan", "plan-2", now.AddDate(0, 0, -3))
+		insertActivity(t, other, "update_plan", "plan-1", now)
+
+		getLogs := func(t *testing.T, query validation.ActivityLogQuery) ([]model.ActivityLog, int64, error) {
+			query.Page, query.Limit, query.Resource = 1, 10, activityTestResource
+			var logs []model.ActivityLog
+			var total int64
+			err := inRequest(t, func(c *fiber.Ctx) (err error) {
+				logs, total, err = activityLogService.GetLogs(c, &query)
+				return err
+			})
+			return logs, total, err
+		}
+		resourceIDs := func(logs []model.ActivityLog) []string {
+			ids := make([]string, 0, len(logs))
+			for _, log := range logs {
+				ids = append(ids, log.ResourceID+":"+log.Action)
+			}
+			return ids
+		}
+
+		t.Run("should list the latest first", func(t *testing.T) {
+			logs, total, err := getLogs(t, validation.ActivityLogQuery{})
+
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), total)
+			assert.Equal(t, []string{"plan-1:update_plan", "plan-2:delete_plan", "plan-1:update_plan"}, resourceIDs(logs))
+			assert.Equal(t, other, logs[0].UserID)
+		})
+
+		t.Run("should filter by admin, action and resource", func(t *testing.T) {
+			logs, _, err := getLogs(t, validation.ActivityLogQuery{AdminID: admin})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"plan-2:delete_plan", "plan-1:update_plan"}, resourceIDs(logs))
+
+			logs, _, err = getLogs(t, validation.ActivityLogQuery{Action: "update_plan"})
+			require.NoError(t, err)
+			assert.Len(t, logs, 2)
+
+			logs, total, err := getLogs(t, validation.ActivityLogQuery{AdminID: admin, ResourceID: "plan-1"})
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), total)
+			assert.Equal(t, []string{"plan-1:update_plan"}, resourceIDs(logs))
+		})
+
+		t.Run("should include whole days from the first to the last", func(t *testing.T) {
+			logs, _, err := getLogs(t, validation.ActivityLogQuery{From: day(3), To: day(3)})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"plan-2:delete_plan"}, resourceIDs(logs))
+
+			logs, _, err = getLogs(t, validation.ActivityLogQuery{From: day(3)})
+			require.NoError(t, err)
+			assert.Len(t, logs, 2)
+
+			logs, _, err = getLogs(t, validation.ActivityLogQuery{To: day(4)})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"plan-1:update_plan"}, resourceIDs(logs))
+			assert.Equal(t, admin, logs[0].UserID)
+		})
+
+		t.Run("should refuse a range that ends before it starts", func(t *testing.T) {
+			_, _, err := getLogs(t, validation.ActivityLogQuery{From: day(0), To: day(1)})
+
+			assert.Equal(t, fiber.StatusBadRequest, err.(*fiber.Error).Code)
+		})
+
+		t.Run("should refuse dates in another format", func(t *testing.T) {
+			_, _, err := getLogs(t, validation.ActivityLogQuery{From: now.Format("02-01-2006")})
+
+			assert.Error(t, err)
+		})
+	})
+}
+
+func TestRetentionServiceActivityLogs(t *testing.T) {
+	// Only the activity log rule runs, and for real
+	scanImages, logDays, inactive, activities := config.RetentionScanImageMonths, config.RetentionLogDays,
+		config.RetentionInactiveAccountMonths, config.RetentionActivityLogMonths
+	dryRun := config.RetentionDryRun.Get()
+	t.Cleanup(func() {
+		config.RetentionScanImageMonths, config.RetentionLogDays = scanImages, logDays
+		config.RetentionInactiveAccountMonths, config.RetentionActivityLogMonths = inactive, activities
+		config.RetentionDryRun.Set(dryRun)
+		test.DB.Where("key = ?", model.SettingRetentionAppliedOn).Delete(&model.SystemSetting{})
+		clearActivities(t)
+	})
+	config.RetentionScanImageMonths, config.RetentionLogDays, config.RetentionInactiveAccountMonths = 0, 0, 0
+	config.RetentionActivityLogMonths = 24
+	config.RetentionDryRun.Set(false)
+	require.NoError(t, test.DB.Where("key = ?", model.SettingRetentionAppliedOn).Delete(&model.SystemSetting{}).Error)
+
+	clearActivities(t)
+	now := time.Now()
+	insertActivity(t, "admin", "expired", "old", now.AddDate(0, -25, 0))
+	insertActivity(t, "admin", "kept", "recent", now.AddDate(0, -23, 0))
+
+	retentionService := service.NewRetentionService(test.DB, validation.Validator())
+	require.NoError(t, retentionService.ApplyPolicies(context.Background()))
+
+	var left []model.ActivityLog
+	require.NoError(t, test.DB.Where("resource = ?", activityTestResource).Find(&left).Error)
+	require.Len(t, left, 1)
+	assert.Equal(t, "kept", left[0].Action)
+}
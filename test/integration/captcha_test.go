@@ -0,0 +1,41 @@
+package integration
+
+import (
+	"app/src/captcha"
+	"app/src/config"
+	"app/src/service"
+	"app/test"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCaptchaService(t *testing.T) {
+	provider, secret := config.CaptchaProvider, config.CaptchaSecret
+	t.Cleanup(func() { config.CaptchaProvider, config.CaptchaSecret = provider, secret })
+
+	t.Run("should let every request through without a provider", func(t *testing.T) {
+		config.CaptchaProvider, config.CaptchaSecret = "", ""
+
+		captchaService, err := service.NewCaptchaService(test.DB)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, captchaService)
+	})
+
+	t.Run("should refuse an unknown provider instead of turning CAPTCHA off", func(t *testing.T) {
+		config.CaptchaProvider, config.CaptchaSecret = "hcaptcha", "secret"
+
+		_, err := service.NewCaptchaService(test.DB)
+
+		assert.ErrorContains(t, err, "unknown provider")
+	})
+
+	t.Run("should refuse a provider without its secret", func(t *testing.T) {
+		config.CaptchaProvider, config.CaptchaSecret = captcha.ProviderTurnstile, ""
+
+		_, err := service.NewCaptchaService(test.DB)
+
+		assert.Error(t, err)
+	})
+}
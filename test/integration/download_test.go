@@ -0,0 +1,103 @@
+package integration
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"app/test/helper"
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadLinkServiceRedeem(t *testing.T) {
+	downloadLinkService := service.NewDownloadLinkService(test.DB, validation.Validator())
+	diaryExportService := service.NewDiaryExportService(test.DB, validation.Validator(), downloadLinkService)
+
+	helper.ClearAll(test.DB)
+	user := &model.User{Name: "Test", Email: "export@gmail.com", Password: "password1"}
+	helper.InsertUser(test.DB, user)
+	now := time.Now()
+	expiresAt := now.AddDate(0, 0, 7)
+	export := &model.DiaryExport{
+		UserID: user.ID, Format: model.DiaryExportCSV, From: now.AddDate(0, -2, 0), To: now,
+		Status: model.DiaryExportFailed, Content: []byte("date,meal\n"), CompletedAt: &now, ExpiresAt: &expiresAt,
+	}
+	require.NoError(t, test.DB.Create(export).Error)
+	t.Cleanup(func() {
+		test.DB.Where("user_id = ?", user.ID).Delete(&model.DownloadAttempt{})
+		test.DB.Where("user_id = ?", user.ID).Delete(&model.DownloadLink{})
+		test.DB.Delete(export)
+	})
+
+	downloadURL, _, err := downloadLinkService.Issue(context.Background(), model.DownloadDiaryExport, export.ID, user.ID)
+	require.NoError(t, err)
+	link, err := url.Parse(downloadURL)
+	require.NoError(t, err)
+	token := &validation.Token{Token: link.Query().Get("token")}
+
+	// redeem downloads the export through the link, the way the download route does
+	redeem := func(t *testing.T) ([]byte, error) {
+		var content []byte
+		err := inRequest(t, func(c *fiber.Ctx) error {
+			return downloadLinkService.Redeem(c, token, func(link *model.DownloadLink) error {
+				served, err := diaryExportService.Download(c, link.UserID, link.ResourceID)
+				if err != nil {
+					return err
+				}
+				content = served.Content
+				return nil
+			})
+		})
+		return content, err
+	}
+	outcomes := func(t *testing.T) []string {
+		var attempts []model.DownloadAttempt
+		require.NoError(t, test.DB.Where("user_id = ?", user.ID).Order("created_at").Find(&attempts).Error)
+		outcomes := []string{}
+		for _, attempt := range attempts {
+			outcomes = append(outcomes, attempt.Outcome)
+		}
+		return outcomes
+	}
+
+	t.Run("should keep the link of an export that cannot be served", func(t *testing.T) {
+		_, err := redeem(t)
+
+		assert.Equal(t, fiber.StatusConflict, err.(*fiber.Error).Code)
+		var stored model.DownloadLink
+		require.NoError(t, test.DB.First(&stored, "user_id = ?", user.ID).Error)
+		assert.Nil(t, stored.UsedAt)
+		assert.Equal(t, []string{model.DownloadUnavailable}, outcomes(t))
+	})
+
+	t.Run("should serve the link once the export can be", func(t *testing.T) {
+		require.NoError(t, test.DB.Model(export).Update("status", model.DiaryExportCompleted).Error)
+
+		content, err := redeem(t)
+		require.NoError(t, err)
+		assert.Equal(t, "date,meal\n", string(content))
+
+		_, err = redeem(t)
+		assert.Equal(t, fiber.StatusGone, err.(*fiber.Error).Code)
+		assert.Equal(t, []string{model.DownloadUnavailable, model.DownloadServed, model.DownloadAlreadyUsed}, outcomes(t))
+	})
+
+	t.Run("should still let the user download with their token", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			require.NoError(t, inRequest(t, func(c *fiber.Ctx) error {
+				served, err := diaryExportService.Download(c, user.ID, export.ID)
+				if err == nil {
+					assert.Equal(t, "date,meal\n", string(served.Content))
+				}
+				return err
+			}))
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"app/test/helper"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntitlementServiceDiagnose(t *testing.T) {
+	scanQuotaService := service.NewScanQuotaService(test.DB, nil)
+	entitlementService := service.NewEntitlementService(test.DB, scanQuotaService,
+		service.NewMaintenanceService(test.DB, validation.Validator()))
+
+	// insertScanner adds a user with an activated product token, who passes every check but the quota
+	insertScanner := func(t *testing.T) *model.User {
+		helper.ClearAll(test.DB)
+		user := &model.User{Name: "Test", Email: "scanner@gmail.com", Password: "password1"}
+		helper.InsertUser(test.DB, user)
+		now := time.Now()
+		token := &model.ProductToken{UserID: user.ID, Token: uuid.NewString(), ActivatedAt: &now}
+		require.NoError(t, test.DB.Create(token).Error)
+		t.Cleanup(func() {
+			test.DB.Delete(token)
+			test.DB.Unscoped().Where("user_id = ?", user.ID).Delete(&model.UserSubscription{})
+		})
+		return user
+	}
+	diagnose := func(t *testing.T, userID uuid.UUID) *model.EntitlementDiagnosis {
+		var diagnosis *model.EntitlementDiagnosis
+		require.NoError(t, inRequest(t, func(c *fiber.Ctx) (err error) {
+			diagnosis, err = entitlementService.Diagnose(c, userID)
+			return err
+		}))
+		return diagnosis
+	}
+	// allowed is what the scan quota middleware decides for the user
+	allowed := func(t *testing.T, userID uuid.UUID) bool {
+		var ok bool
+		require.NoError(t, inRequest(t, func(c *fiber.Ctx) (err error) {
+			_, ok, err = scanQuotaService.Allowed(c, userID)
+			return err
+		}))
+		return ok
+	}
+	subscribe := func(t *testing.T, userID uuid.UUID, limit, used int) {
+		plan := &model.SubscriptionPlan{Name: "Entitlement test", Price: 50000, AIscanLimit: limit, ValidityDays: 30}
+		require.NoError(t, test.DB.Create(plan).Error)
+		t.Cleanup(func() { test.DB.Delete(plan) })
+		now := time.Now()
+		require.NoError(t, test.DB.Create(&model.UserSubscription{
+			UserID: userID, PlanID: plan.ID, StartDate: now.Add(-time.Hour), EndDate: now.AddDate(0, 0, 30),
+			IsActive: true, PaymentStatus: "success", Status: "active", AIscansUsed: used,
+		}).Error)
+	}
+
+	t.Run("should let a user without a subscription scan", func(t *testing.T) {
+		user := insertScanner(t)
+
+		diagnosis := diagnose(t, user.ID)
+
+		assert.True(t, allowed(t, user.ID))
+		assert.True(t, diagnosis.CanScan)
+		assert.Nil(t, diagnosis.Quota)
+	})
+
+	t.Run("should refuse a subscriber who used all scans", func(t *testing.T) {
+		user := insertScanner(t)
+		subscribe(t, user.ID, 5, 5)
+
+		diagnosis := diagnose(t, user.ID)
+
+		assert.False(t, allowed(t, user.ID))
+		assert.False(t, diagnosis.CanScan)
+		require.NotNil(t, diagnosis.Quota)
+		assert.True(t, diagnosis.Quota.Exhausted)
+	})
+
+	t.Run("should let a subscriber with scans left scan", func(t *testing.T) {
+		user := insertScanner(t)
+		subscribe(t, user.ID, 5, 4)
+
+		diagnosis := diagnose(t, user.ID)
+
+		assert.True(t, allowed(t, user.ID))
+		assert.True(t, diagnosis.CanScan)
+		assert.Equal(t, 1, diagnosis.Quota.Remaining)
+	})
+}
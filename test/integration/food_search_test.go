@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFoods finds every food of the catalog mirror
+type fakeFoods struct {
+	service.BahanMakananService
+}
+
+func (f *fakeFoods) GetBahanMakananByKode(_ *fiber.Ctx, kode string) (*model.BahanMakanan, error) {
+	return &model.BahanMakanan{Kode: kode}, nil
+}
+
+func TestFoodNameServiceSearchWithoutIndex(t *testing.T) {
+	// Without a search index client the search matches the database
+	foodNameService := service.NewFoodNameService(test.DB, validation.Validator(), &fakeFoods{},
+		service.NewSearchIndexService(test.DB, nil, nil), nil)
+
+	catalog := []model.FoodCatalogEntry{
+		{Kode: "TEST001", Name: "Nasi putih"},
+		{Kode: "TEST002", Name: "Telur ayam rebus"},
+		{Kode: "TEST003", Name: "Tempe goreng"},
+	}
+	for i := range catalog {
+		catalog[i].Food = model.BahanMakanan{Kode: catalog[i].Kode, NamaBahanMakanan: catalog[i].Name}
+		catalog[i].RefreshedAt = time.Now()
+	}
+	require.NoError(t, test.DB.Create(&catalog).Error)
+	synonym := &model.FoodName{FoodKode: "TEST001", Language: "en", Name: "White rice", Primary: true}
+	require.NoError(t, test.DB.Create(synonym).Error)
+	t.Cleanup(func() {
+		test.DB.Delete(synonym)
+		test.DB.Delete(&catalog)
+		test.DB.Where("food_kode LIKE 'TEST%'").Delete(&model.FoodLog{})
+		test.DB.Where("food_kode LIKE 'TEST%'").Delete(&model.FoodPopularity{})
+		test.DB.Where("query IN ('telur', 'rice', 'tmpe goreng', 'rebus')").Delete(&model.FoodSearch{})
+	})
+
+	search := func(t *testing.T, userID uuid.UUID, q string) []model.FoodSearchResult {
+		var results []model.FoodSearchResult
+		require.NoError(t, inRequest(t, func(c *fiber.Ctx) (err error) {
+			results, _, err = foodNameService.Search(c, userID, &validation.FoodSearchQuery{Q: q, Limit: 10})
+			return err
+		}))
+		return results
+	}
+	kodes := func(results []model.FoodSearchResult) []string {
+		found := make([]string, 0, len(results))
+		for _, result := range results {
+			found = append(found, result.Food.Kode)
+		}
+		return found
+	}
+	logFood := func(t *testing.T, userID uuid.UUID, kode string) {
+		require.NoError(t, inRequest(t, func(c *fiber.Ctx) error {
+			_, err := foodNameService.LogFood(c, userID, kode, &validation.LogFood{})
+			return err
+		}))
+	}
+
+	t.Run("should find a food by a part of its catalog name", func(t *testing.T) {
+		assert.Equal(t, []string{"TEST002"}, kodes(search(t, uuid.New(), "telur")))
+	})
+
+	t.Run("should find a food by one of its names", func(t *testing.T) {
+		assert.Equal(t, []string{"TEST001"}, kodes(search(t, uuid.New(), "rice")))
+	})
+
+	t.Run("should find a food by a name with a typo", func(t *testing.T) {
+		assert.Contains(t, kodes(search(t, uuid.New(), "tmpe goreng")), "TEST003")
+	})
+
+	t.Run("should count the logs of every user on the food", func(t *testing.T) {
+		first, second := uuid.New(), uuid.New()
+		logFood(t, first, "TEST002")
+		logFood(t, first, "TEST002")
+		logFood(t, second, "TEST002")
+
+		var popularity model.FoodPopularity
+		require.NoError(t, test.DB.First(&popularity, "food_kode = ?", "TEST002").Error)
+		assert.Equal(t, 3, popularity.Count)
+		assert.Equal(t, []string{"TEST002"}, kodes(search(t, second, "rebus")))
+	})
+}
@@ -0,0 +1,233 @@
+package integration
+
+import (
+	"app/src/config"
+	"app/src/geoip"
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"app/test/helper"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGeo locates every request in country
+type fakeGeo struct {
+	service.GeoService
+	country string
+}
+
+func (f *fakeGeo) Locate(_ *fiber.Ctx) geoip.Location {
+	return geoip.Location{Country: f.country}
+}
+
+// fakeHeldPayments records the held payments released by the reviews, failing with err
+type fakeHeldPayments struct {
+	service.SubscriptionService
+	err      error
+	released map[uuid.UUID]bool
+}
+
+func (f *fakeHeldPayments) ReleaseHeldPayment(_ *fiber.Ctx, subscriptionID uuid.UUID, approved bool) (*model.UserSubscriptionResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.released[subscriptionID] = approved
+	return nil, nil
+}
+
+func TestFraudServiceEvaluate(t *testing.T) {
+	geo := &fakeGeo{country: "ID"}
+	fraudService := service.NewFraudService(test.DB, validation.Validator(), geo)
+
+	maxPurchases := config.FraudMaxPurchases.Get()
+	t.Cleanup(func() { config.FraudMaxPurchases.Set(maxPurchases) })
+	config.FraudMaxPurchases.Set(3)
+
+	plan := &model.SubscriptionPlan{Name: "Fraud Premium", Price: 50000, Currency: "IDR", AIscanLimit: 10, ValidityDays: 30}
+	require.NoError(t, test.DB.Create(plan).Error)
+	t.Cleanup(func() {
+		test.DB.Unscoped().Where("plan_id = ?", plan.ID).Delete(&model.UserSubscription{})
+		test.DB.Delete(plan)
+	})
+
+	newUser := func(t *testing.T) *model.User {
+		helper.ClearAll(test.DB)
+		user := &model.User{Name: "Test", Email: "Fraud@gmail.com", Password: "password1"}
+		helper.InsertUser(test.DB, user)
+		t.Cleanup(func() { test.DB.Unscoped().Where("user_id = ?", user.ID).Delete(&model.UserSubscription{}) })
+		return user
+	}
+	subscribe := func(t *testing.T, user *model.User, country string) {
+		require.NoError(t, test.DB.Create(&model.UserSubscription{
+			UserID: user.ID, PlanID: plan.ID, StartDate: time.Now(), EndDate: time.Now().AddDate(0, 0, 30),
+			PaymentStatus: "success", CheckoutCountry: country,
+		}).Error)
+	}
+	list := func(t *testing.T, listType, entryType, value string) {
+		entry := &model.FraudListEntry{ListType: listType, EntryType: entryType, Value: value}
+		require.NoError(t, test.DB.Create(entry).Error)
+		t.Cleanup(func() { test.DB.Delete(entry) })
+	}
+	evaluate := func(t *testing.T, user *model.User) *service.FraudAssessment {
+		var assessment *service.FraudAssessment
+		require.NoError(t, inRequest(t, func(c *fiber.Ctx) (err error) {
+			assessment, err = fraudService.Evaluate(c, user)
+			return err
+		}))
+		return assessment
+	}
+
+	t.Run("should match no rule for a first checkout", func(t *testing.T) {
+		user := newUser(t)
+
+		assessment := evaluate(t, user)
+
+		assert.False(t, assessment.Denied)
+		assert.Empty(t, assessment.Rules)
+		assert.Equal(t, "ID", assessment.Country)
+	})
+
+	t.Run("should flag a checkout from another country than the last paid one", func(t *testing.T) {
+		user := newUser(t)
+		subscribe(t, user, "SG")
+
+		assert.Equal(t, []string{model.FraudRuleGeoMismatch}, evaluate(t, user).Rules)
+	})
+
+	t.Run("should flag many checkouts in a short period", func(t *testing.T) {
+		user := newUser(t)
+		for i := 0; i < 3; i++ {
+			subscribe(t, user, "ID")
+		}
+
+		assert.Equal(t, []string{model.FraudRulePlanChurn}, evaluate(t, user).Rules)
+	})
+
+	t.Run("should deny a listed email whatever its case", func(t *testing.T) {
+		user := newUser(t)
+		list(t, model.FraudListDeny, model.FraudEntryEmail, "fraud@gmail.com")
+
+		assert.True(t, evaluate(t, user).Denied)
+	})
+
+	t.Run("should skip the rules for an allowed user", func(t *testing.T) {
+		user := newUser(t)
+		subscribe(t, user, "SG")
+		list(t, model.FraudListAllow, model.FraudEntryUser, user.ID.String())
+
+		assessment := evaluate(t, user)
+
+		assert.False(t, assessment.Denied)
+		assert.Empty(t, assessment.Rules)
+	})
+
+	t.Run("should deny a user on both lists", func(t *testing.T) {
+		user := newUser(t)
+		list(t, model.FraudListAllow, model.FraudEntryUser, user.ID.String())
+		list(t, model.FraudListDeny, model.FraudEntryUser, user.ID.String())
+
+		assert.True(t, evaluate(t, user).Denied)
+	})
+}
+
+func TestFraudReviewServiceDecide(t *testing.T) {
+	payments := &fakeHeldPayments{}
+	fraudService := service.NewFraudService(test.DB, validation.Validator(), &fakeGeo{})
+	fraudReviewService := service.NewFraudReviewService(test.DB, validation.Validator(), payments)
+	adminID := uuid.New()
+
+	plan := &model.SubscriptionPlan{Name: "Fraud Review Premium", Price: 50000, Currency: "IDR", AIscanLimit: 10, ValidityDays: 30}
+	require.NoError(t, test.DB.Create(plan).Error)
+	t.Cleanup(func() { test.DB.Delete(plan) })
+
+	// flag holds a new checkout for review the way the checkout does
+	flag := func(t *testing.T) (*model.UserSubscription, *model.FraudReview) {
+		payments.err, payments.released = nil, map[uuid.UUID]bool{}
+		helper.ClearAll(test.DB)
+		user := &model.User{Name: "Test", Email: "review@gmail.com", Password: "password1"}
+		helper.InsertUser(test.DB, user)
+		subscription := &model.UserSubscription{
+			UserID: user.ID, PlanID: plan.ID, StartDate: time.Now(), EndDate: time.Now().AddDate(0, 0, 30),
+			PaymentStatus: "on_hold", TransactionID: "ORDER-FRAUD",
+		}
+		require.NoError(t, test.DB.Create(subscription).Error)
+		t.Cleanup(func() { test.DB.Unscoped().Delete(subscription) })
+		require.NoError(t, inRequest(t, func(c *fiber.Ctx) error {
+			return fraudService.FlagForReview(c, subscription, &service.FraudAssessment{Rules: []string{model.FraudRuleGeoMismatch}})
+		}))
+		review := new(model.FraudReview)
+		require.NoError(t, test.DB.First(review, "user_subscription_id = ?", subscription.ID).Error)
+		t.Cleanup(func() { test.DB.Delete(review) })
+		return subscription, review
+	}
+	isHeld := func(t *testing.T, subscription *model.UserSubscription) bool {
+		var held bool
+		require.NoError(t, inRequest(t, func(c *fiber.Ctx) (err error) {
+			held, err = fraudService.IsHeld(c, subscription.ID)
+			return err
+		}))
+		return held
+	}
+	decide := func(t *testing.T, review *model.FraudReview, approve bool) error {
+		return inRequest(t, func(c *fiber.Ctx) error {
+			var err error
+			if approve {
+				_, err = fraudReviewService.ApproveReview(c, adminID, review.ID, &validation.ReviewFraud{Notes: "checked"})
+			} else {
+				_, err = fraudReviewService.RejectReview(c, adminID, review.ID, &validation.ReviewFraud{})
+			}
+			return err
+		})
+	}
+
+	t.Run("should hold the subscription until the review is approved", func(t *testing.T) {
+		subscription, review := flag(t)
+		require.True(t, isHeld(t, subscription))
+
+		require.NoError(t, decide(t, review, true))
+
+		assert.False(t, isHeld(t, subscription))
+		assert.Equal(t, map[uuid.UUID]bool{subscription.ID: true}, payments.released)
+		require.NoError(t, test.DB.First(review, "id = ?", review.ID).Error)
+		assert.Equal(t, model.FraudReviewApproved, review.Status)
+		assert.Equal(t, &adminID, review.ReviewedByID)
+	})
+
+	t.Run("should keep a rejected subscription held", func(t *testing.T) {
+		subscription, review := flag(t)
+
+		require.NoError(t, decide(t, review, false))
+
+		assert.True(t, isHeld(t, subscription))
+		assert.Equal(t, map[uuid.UUID]bool{subscription.ID: false}, payments.released)
+	})
+
+	t.Run("should decide a review once", func(t *testing.T) {
+		_, review := flag(t)
+		require.NoError(t, decide(t, review, true))
+
+		err := decide(t, review, false)
+
+		assert.Equal(t, fiber.StatusConflict, err.(*fiber.Error).Code)
+		assert.Len(t, payments.released, 1)
+	})
+
+	t.Run("should leave the review pending when the payment cannot be released", func(t *testing.T) {
+		subscription, review := flag(t)
+		payments.err = errors.New("gateway unavailable")
+
+		assert.Error(t, decide(t, review, true))
+
+		require.NoError(t, test.DB.First(review, "id = ?", review.ID).Error)
+		assert.Equal(t, model.FraudReviewPending, review.Status)
+		assert.True(t, isHeld(t, subscription))
+	})
+}
@@ -0,0 +1,192 @@
+package integration
+
+import (
+	"app/src/config"
+	"app/src/iap"
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"app/test/helper"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAppStore answers receipt validations with a receipt of environment for the latest transaction
+type fakeAppStore struct {
+	environment   string
+	transactionID string
+}
+
+func (f *fakeAppStore) RoundTrip(_ *http.Request) (*http.Response, error) {
+	expires := strconv.FormatInt(time.Now().AddDate(0, 1, 0).UnixMilli(), 10)
+	body := `{"status":0,"environment":"` + f.environment + `","receipt":{"bundle_id":"com.nutriteam.app"},` +
+		`"latest_receipt_info":[{"product_id":"premium_monthly","transaction_id":"` + f.transactionID + `",` +
+		`"original_transaction_id":"2000","expires_date_ms":"` + expires + `"}]}`
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+func TestIAPServiceVerifyAppleReceipt(t *testing.T) {
+	store := &fakeAppStore{}
+	apple, err := iap.NewAppleClient("secret", "com.nutriteam.app", "")
+	require.NoError(t, err)
+	apple.HTTP = &http.Client{Transport: store}
+	subscriptionService := service.NewSubscriptionService(test.DB, validation.Validator(), nil, nil, nil, nil, nil)
+	iapService := service.NewIAPService(test.DB, validation.Validator(), subscriptionService,
+		service.NewScanQuotaService(test.DB, nil), apple, nil)
+
+	isProd := config.IsProd
+	t.Cleanup(func() { config.IsProd = isProd })
+
+	plan := &model.SubscriptionPlan{Name: "Store Premium", Price: 50000, Currency: "IDR", AIscanLimit: 10, ValidityDays: 30}
+	require.NoError(t, test.DB.Create(plan).Error)
+	product := &model.StoreProduct{Store: model.StoreApple, ProductID: "premium_monthly", PlanID: plan.ID}
+	require.NoError(t, test.DB.Create(product).Error)
+	t.Cleanup(func() {
+		test.DB.Delete(product)
+		test.DB.Delete(plan)
+	})
+
+	// newUser inserts a user whose store purchases are removed after the test
+	newUser := func(t *testing.T, sandbox bool) *model.User {
+		helper.ClearAll(test.DB)
+		user := &model.User{Name: "Test", Email: "store@gmail.com", Password: "password1", IsSandbox: sandbox}
+		helper.InsertUser(test.DB, user)
+		t.Cleanup(func() {
+			subscriptions := test.DB.Model(&model.UserSubscription{}).Select("id").Where("user_id = ?", user.ID)
+			test.DB.Where("user_subscription_id IN (?)", subscriptions).Delete(&model.RevenueRecognition{})
+			test.DB.Where("user_subscription_id IN (?)", subscriptions).Delete(&model.SubscriptionEvent{})
+			test.DB.Where("user_subscription_id IN (?)", subscriptions).Delete(&model.TransactionDetail{})
+			test.DB.Where("user_id = ?", user.ID).Delete(&model.StorePurchase{})
+			test.DB.Unscoped().Where("user_id = ?", user.ID).Delete(&model.UserSubscription{})
+		})
+		return user
+	}
+	verify := func(t *testing.T, user *model.User) (*model.UserSubscriptionResponse, error) {
+		var subscription *model.UserSubscriptionResponse
+		err := inRequest(t, func(c *fiber.Ctx) (err error) {
+			subscription, err = iapService.VerifyAppleReceipt(c, user.ID, &validation.VerifyAppleReceipt{ReceiptData: "receipt"})
+			return err
+		})
+		return subscription, err
+	}
+	stored := func(t *testing.T, user *model.User) []model.UserSubscription {
+		var subscriptions []model.UserSubscription
+		require.NoError(t, test.DB.Where("user_id = ?", user.ID).Find(&subscriptions).Error)
+		return subscriptions
+	}
+
+	t.Run("should activate the subscription of a production receipt as paid", func(t *testing.T) {
+		config.IsProd = true
+		*store = fakeAppStore{environment: "Production", transactionID: "2001"}
+		user := newUser(t, false)
+
+		_, err := verify(t, user)
+		require.NoError(t, err)
+
+		subscriptions := stored(t, user)
+		require.Len(t, subscriptions, 1)
+		assert.Equal(t, "success", subscriptions[0].PaymentStatus)
+		assert.Equal(t, model.SourceAppleIAP, subscriptions[0].Source)
+		assert.False(t, subscriptions[0].IsSandbox)
+		var payments int64
+		require.NoError(t, test.DB.Model(&model.TransactionDetail{}).
+			Where("user_subscription_id = ?", subscriptions[0].ID).Count(&payments).Error)
+		assert.Equal(t, int64(1), payments)
+	})
+
+	t.Run("should renew the subscription of a purchase on its next transaction", func(t *testing.T) {
+		config.IsProd = true
+		*store = fakeAppStore{environment: "Production", transactionID: "2001"}
+		user := newUser(t, false)
+		_, err := verify(t, user)
+		require.NoError(t, err)
+
+		store.transactionID = "2002"
+		_, err = verify(t, user)
+		require.NoError(t, err)
+
+		subscriptions := stored(t, user)
+		require.Len(t, subscriptions, 1)
+		assert.Equal(t, "2002", subscriptions[0].TransactionID)
+	})
+
+	t.Run("should refuse a sandbox receipt of a user in production", func(t *testing.T) {
+		config.IsProd = true
+		*store = fakeAppStore{environment: "Sandbox", transactionID: "2001"}
+		user := newUser(t, false)
+
+		_, err := verify(t, user)
+
+		assert.Equal(t, fiber.StatusForbidden, err.(*fiber.Error).Code)
+		assert.Empty(t, stored(t, user))
+	})
+
+	t.Run("should accept a sandbox receipt of a sandbox user in production, out of revenue", func(t *testing.T) {
+		config.IsProd = true
+		*store = fakeAppStore{environment: "Sandbox", transactionID: "2001"}
+		user := newUser(t, true)
+
+		_, err := verify(t, user)
+		require.NoError(t, err)
+
+		subscriptions := stored(t, user)
+		require.Len(t, subscriptions, 1)
+		assert.True(t, subscriptions[0].IsSandbox)
+	})
+
+	t.Run("should accept a sandbox receipt of any user outside production", func(t *testing.T) {
+		config.IsProd = false
+		*store = fakeAppStore{environment: "Sandbox", transactionID: "2001"}
+		user := newUser(t, false)
+
+		_, err := verify(t, user)
+
+		require.NoError(t, err)
+		assert.Len(t, stored(t, user), 1)
+	})
+}
+
+func TestIAPServiceHandleGoogleNotification(t *testing.T) {
+	iapService := service.NewIAPService(test.DB, validation.Validator(), nil, nil, nil, nil)
+
+	token := config.GooglePlayNotificationToken
+	t.Cleanup(func() { config.GooglePlayNotificationToken = token })
+
+	handle := func(t *testing.T, token string) error {
+		return inRequest(t, func(c *fiber.Ctx) error {
+			return iapService.HandleGoogleNotification(c, token, []byte(`{"message":{"data":""}}`))
+		})
+	}
+
+	t.Run("should refuse a notification without the configured token", func(t *testing.T) {
+		config.GooglePlayNotificationToken = uuid.NewString()
+
+		assert.Equal(t, fiber.StatusUnauthorized, handle(t, "").(*fiber.Error).Code)
+		assert.Equal(t, fiber.StatusUnauthorized, handle(t, uuid.NewString()).(*fiber.Error).Code)
+	})
+
+	t.Run("should refuse every notification while no token is configured", func(t *testing.T) {
+		config.GooglePlayNotificationToken = ""
+
+		assert.Equal(t, fiber.StatusUnauthorized, handle(t, "").(*fiber.Error).Code)
+	})
+
+	t.Run("should take a notification with the configured token", func(t *testing.T) {
+		config.GooglePlayNotificationToken = uuid.NewString()
+
+		// Google Play is not configured in the test, the token got the notification that far
+		err := handle(t, config.GooglePlayNotificationToken)
+
+		assert.Equal(t, fiber.StatusServiceUnavailable, err.(*fiber.Error).Code)
+	})
+}
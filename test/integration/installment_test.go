@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/service"
+	"app/test"
+	"app/test/helper"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInstallmentEmails records the installments billed by email
+type fakeInstallmentEmails struct {
+	service.EmailService
+	billed []int
+}
+
+func (f *fakeInstallmentEmails) SendInstallmentBillEmail(_ string, installmentNumber int, _ model.Money, _ time.Time, _ string, _ int) error {
+	f.billed = append(f.billed, installmentNumber)
+	return nil
+}
+
+func TestInstallmentServiceBillDueInstallments(t *testing.T) {
+	attempts, interval := config.InstallmentMaxAttempts, config.InstallmentRetryInterval
+	t.Cleanup(func() { config.InstallmentMaxAttempts, config.InstallmentRetryInterval = attempts, interval })
+	config.InstallmentMaxAttempts = 3
+	config.InstallmentRetryInterval = 24 * time.Hour
+
+	helper.ClearAll(test.DB)
+	user := &model.User{Name: "Test", Email: "installment@gmail.com", Password: "password1"}
+	helper.InsertUser(test.DB, user)
+	plan := &model.SubscriptionPlan{Name: "Installment test", Price: 300000, AIscanLimit: 10, ValidityDays: 90}
+	require.NoError(t, test.DB.Create(plan).Error)
+	now := time.Now()
+	subscription := &model.UserSubscription{
+		UserID: user.ID, PlanID: plan.ID, StartDate: now, EndDate: now.AddDate(0, 3, 0),
+		PaymentStatus: "success", Status: "active", IsInstallment: true,
+	}
+	require.NoError(t, test.DB.Create(subscription).Error)
+	t.Cleanup(func() {
+		test.DB.Where("user_subscription_id = ?", subscription.ID).Delete(&model.InstallmentSchedule{})
+		test.DB.Unscoped().Delete(subscription)
+		test.DB.Delete(plan)
+	})
+
+	// The installments with their payment links sent so far and when their status last changed
+	for _, installment := range []struct {
+		number   int
+		status   string
+		attempts int
+		changed  time.Time
+	}{
+		{1, model.InstallmentPaid, 1, now.AddDate(0, -1, 0)},
+		{2, model.InstallmentFailed, 1, now.Add(-25 * time.Hour)},  // retried
+		{3, model.InstallmentFailed, 1, now.Add(-time.Hour)},       // too soon
+		{4, model.InstallmentFailed, 3, now.Add(-72 * time.Hour)},  // out of attempts
+		{5, model.InstallmentPending, 0, now.Add(-72 * time.Hour)}, // billed the first time
+	} {
+		orderID := fmt.Sprintf("INST-TEST-%d", installment.number)
+		schedule := &model.InstallmentSchedule{
+			UserSubscriptionID: subscription.ID, InstallmentNumber: installment.number, Amount: 100000,
+			DueDate: now, Status: installment.status, Attempts: installment.attempts,
+		}
+		if installment.status != model.InstallmentPending {
+			schedule.OrderID = &orderID
+		}
+		require.NoError(t, test.DB.Create(schedule).Error)
+		require.NoError(t, test.DB.Model(schedule).UpdateColumn("updated_at", installment.changed).Error)
+	}
+
+	emails := &fakeInstallmentEmails{}
+	installmentService := service.NewInstallmentService(test.DB, &service.MockPayment{}, nil, emails)
+
+	require.NoError(t, installmentService.BillDueInstallments(context.Background()))
+
+	assert.ElementsMatch(t, []int{2, 5}, emails.billed)
+
+	var retried model.InstallmentSchedule
+	require.NoError(t, test.DB.First(&retried, "user_subscription_id = ? AND installment_number = 2", subscription.ID).Error)
+	assert.Equal(t, model.InstallmentPending, retried.Status)
+	assert.Equal(t, 2, retried.Attempts)
+	assert.NotEqual(t, "INST-TEST-2", *retried.OrderID)
+
+	t.Run("should not bill a retried installment again while its link is open", func(t *testing.T) {
+		emails.billed = nil
+
+		require.NoError(t, installmentService.BillDueInstallments(context.Background()))
+
+		assert.Empty(t, emails.billed)
+	})
+}
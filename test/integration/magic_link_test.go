@@ -0,0 +1,191 @@
+package integration
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"app/test/helper"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMagicLinkEmails records the magic links sent, failing them with err. Emails wait for release when it is set.
+type fakeMagicLinkEmails struct {
+	service.EmailService
+	err     error
+	release chan struct{}
+	mu      sync.Mutex
+	tokens  []string
+}
+
+func (f *fakeMagicLinkEmails) SendMagicLinkEmail(_, token string, _ time.Time) error {
+	if f.release != nil {
+		<-f.release
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokens = append(f.tokens, token)
+	return f.err
+}
+
+// sent waits for the links sent in the background to reach count, and for no other to follow
+func (f *fakeMagicLinkEmails) sent(t *testing.T, count int) []string {
+	sent := func() int {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return len(f.tokens)
+	}
+	require.Eventually(t, func() bool { return sent() >= count }, time.Second, 10*time.Millisecond)
+	assert.Never(t, func() bool { return sent() > count }, 200*time.Millisecond, 10*time.Millisecond)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.tokens)
+}
+
+// newMagicLinkTestService clears the users and links and restores the limits after the test
+func newMagicLinkTestService(t *testing.T) (service.MagicLinkService, *fakeMagicLinkEmails) {
+	resend, perHour := config.MagicLinkResendInterval, config.MagicLinkMaxPerHour
+	t.Cleanup(func() {
+		config.MagicLinkResendInterval, config.MagicLinkMaxPerHour = resend, perHour
+	})
+	config.MagicLinkResendInterval = time.Minute
+	config.MagicLinkMaxPerHour = 5
+
+	helper.ClearAll(test.DB)
+	require.NoError(t, test.DB.Where("1 = 1").Delete(&model.MagicLink{}).Error)
+
+	emails := &fakeMagicLinkEmails{}
+	return service.NewMagicLinkService(test.DB, validation.Validator(), emails), emails
+}
+
+func TestMagicLinkService(t *testing.T) {
+	const email = "magic@gmail.com"
+	insertMagicLinkUser := func() *model.User {
+		user := &model.User{Name: "Test", Email: email, Password: "password1"}
+		helper.InsertUser(test.DB, user)
+		return user
+	}
+	request := func(t *testing.T, magicLinkService service.MagicLinkService, email string) (deviceCode string, err error) {
+		err = inRequest(t, func(c *fiber.Ctx) (err error) {
+			deviceCode, err = magicLinkService.Request(c, &validation.RequestMagicLink{Email: email})
+			return err
+		})
+		return deviceCode, err
+	}
+	login := func(t *testing.T, magicLinkService service.MagicLinkService, req *validation.MagicLinkLogin) (user *model.User, err error) {
+		err = inRequest(t, func(c *fiber.Ctx) (err error) {
+			user, err = magicLinkService.Login(c, req)
+			return err
+		})
+		return user, err
+	}
+
+	t.Run("Request", func(t *testing.T) {
+		t.Run("should answer an email without an account with a device code and send nothing", func(t *testing.T) {
+			magicLinkService, emails := newMagicLinkTestService(t)
+
+			deviceCode, err := request(t, magicLinkService, "nobody@gmail.com")
+
+			require.NoError(t, err)
+			assert.Len(t, deviceCode, 64)
+			assert.Empty(t, emails.sent(t, 0))
+		})
+
+		t.Run("should not answer the resend limit of a user", func(t *testing.T) {
+			magicLinkService, emails := newMagicLinkTestService(t)
+			insertMagicLinkUser()
+
+			_, err := request(t, magicLinkService, email)
+			require.NoError(t, err)
+			emails.sent(t, 1)
+			deviceCode, err := request(t, magicLinkService, email)
+
+			require.NoError(t, err)
+			assert.Len(t, deviceCode, 64)
+			assert.Len(t, emails.sent(t, 1), 1)
+		})
+
+		t.Run("should not answer the hourly limit of a user", func(t *testing.T) {
+			magicLinkService, emails := newMagicLinkTestService(t)
+			insertMagicLinkUser()
+			config.MagicLinkResendInterval = 0
+			config.MagicLinkMaxPerHour = 2
+
+			for i := 0; i < 3; i++ {
+				_, err := request(t, magicLinkService, email)
+				assert.NoError(t, err)
+				emails.sent(t, min(i+1, 2))
+			}
+
+			assert.Len(t, emails.sent(t, 2), 2)
+		})
+
+		t.Run("should answer before the link is emailed", func(t *testing.T) {
+			magicLinkService, emails := newMagicLinkTestService(t)
+			insertMagicLinkUser()
+			emails.release = make(chan struct{})
+
+			deviceCode, err := request(t, magicLinkService, email)
+
+			require.NoError(t, err)
+			assert.Len(t, deviceCode, 64)
+			close(emails.release)
+			assert.Len(t, emails.sent(t, 1), 1)
+		})
+
+		t.Run("should not answer a suppressed or failed email", func(t *testing.T) {
+			magicLinkService, emails := newMagicLinkTestService(t)
+			insertMagicLinkUser()
+			emails.err = service.ErrEmailSuppressed
+
+			deviceCode, err := request(t, magicLinkService, email)
+
+			require.NoError(t, err)
+			assert.Len(t, deviceCode, 64)
+			assert.Len(t, emails.sent(t, 1), 1)
+		})
+	})
+
+	t.Run("Login", func(t *testing.T) {
+		t.Run("should sign in once on the device that asked for the link", func(t *testing.T) {
+			magicLinkService, emails := newMagicLinkTestService(t)
+			user := insertMagicLinkUser()
+			deviceCode, err := request(t, magicLinkService, email)
+			require.NoError(t, err)
+			req := &validation.MagicLinkLogin{Token: emails.sent(t, 1)[0], DeviceCode: deviceCode}
+
+			loggedIn, err := login(t, magicLinkService, req)
+			require.NoError(t, err)
+			assert.Equal(t, user.ID, loggedIn.ID)
+			assert.True(t, loggedIn.VerifiedEmail)
+
+			_, err = login(t, magicLinkService, req)
+			assert.Equal(t, fiber.StatusUnauthorized, err.(*fiber.Error).Code)
+		})
+
+		t.Run("should refuse another device and leave the link usable", func(t *testing.T) {
+			magicLinkService, emails := newMagicLinkTestService(t)
+			insertMagicLinkUser()
+			deviceCode, err := request(t, magicLinkService, email)
+			require.NoError(t, err)
+			other, err := request(t, magicLinkService, "nobody@gmail.com")
+			require.NoError(t, err)
+			token := emails.sent(t, 1)[0]
+
+			_, err = login(t, magicLinkService, &validation.MagicLinkLogin{Token: token, DeviceCode: other})
+			assert.Equal(t, fiber.StatusForbidden, err.(*fiber.Error).Code)
+
+			_, err = login(t, magicLinkService, &validation.MagicLinkLogin{Token: token, DeviceCode: deviceCode})
+			assert.NoError(t, err)
+		})
+	})
+}
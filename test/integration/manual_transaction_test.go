@@ -0,0 +1,135 @@
+package integration
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+	"app/test"
+	"app/test/helper"
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// proofUpload returns the file header of a transfer receipt as an admin uploads it
+func proofUpload(t *testing.T) *multipart.FileHeader {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("proof", "receipt.png")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("\x89PNG\r\n\x1a\n"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = form.RemoveAll() })
+	return form.File["proof"][0]
+}
+
+// savedProofs lists the payment proofs stored on disk
+func savedProofs(t *testing.T) []string {
+	proofs, err := filepath.Glob(filepath.Join(utils.UploadDir, "payment-proofs", "*"))
+	require.NoError(t, err)
+	return proofs
+}
+
+func TestSubscriptionServiceCreateManualTransaction(t *testing.T) {
+	subscriptionService := service.NewSubscriptionService(test.DB, validation.Validator(), nil, nil, nil, nil, nil)
+	adminID := uuid.New()
+
+	plan := &model.SubscriptionPlan{Name: "Transfer Premium", Price: 75000, Currency: "IDR", AIscanLimit: 10, ValidityDays: 30}
+	require.NoError(t, test.DB.Create(plan).Error)
+	t.Cleanup(func() { test.DB.Delete(plan) })
+
+	newUser := func(t *testing.T) *model.User {
+		helper.ClearAll(test.DB)
+		user := &model.User{Name: "Test", Email: "transfer@gmail.com", Password: "password1"}
+		helper.InsertUser(test.DB, user)
+		before := savedProofs(t)
+		t.Cleanup(func() {
+			subscriptions := test.DB.Model(&model.UserSubscription{}).Select("id").Where("user_id = ?", user.ID)
+			test.DB.Where("user_subscription_id IN (?)", subscriptions).Delete(&model.RevenueRecognition{})
+			test.DB.Where("user_subscription_id IN (?)", subscriptions).Delete(&model.SubscriptionEvent{})
+			test.DB.Where("user_subscription_id IN (?)", subscriptions).Delete(&model.TransactionDetail{})
+			test.DB.Unscoped().Where("user_id = ?", user.ID).Delete(&model.UserSubscription{})
+			for _, proof := range savedProofs(t) {
+				if !slices.Contains(before, proof) {
+					_ = os.Remove(proof)
+				}
+			}
+		})
+		return user
+	}
+	record := func(t *testing.T, user *model.User, transferredAt string) (*model.TransactionDetail, error) {
+		var detail *model.TransactionDetail
+		err := inRequest(t, func(c *fiber.Ctx) (err error) {
+			detail, err = subscriptionService.CreateManualTransaction(c, adminID, &validation.CreateManualTransaction{
+				UserID: user.ID.String(), PlanID: plan.ID.String(), BankName: "BCA", TransferredAt: transferredAt,
+			}, proofUpload(t))
+			return err
+		})
+		return detail, err
+	}
+
+	t.Run("should activate the subscription with the transfer and its proof", func(t *testing.T) {
+		user := newUser(t)
+
+		detail, err := record(t, user, "2026-10-01")
+		require.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(detail.OrderID, "MANUAL-"))
+		assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local), detail.TransactionTime.In(time.Local))
+		require.NotNil(t, detail.ProofImageURL)
+		assert.FileExists(t, strings.TrimPrefix(*detail.ProofImageURL, "/"))
+		var subscription model.UserSubscription
+		require.NoError(t, test.DB.First(&subscription, "id = ?", detail.UserSubscriptionID).Error)
+		assert.Equal(t, "success", subscription.PaymentStatus)
+		assert.True(t, subscription.IsActive)
+		assert.Equal(t, model.SubscriptionActive, subscription.Status)
+	})
+
+	t.Run("should give transfers recorded within a second their own orders", func(t *testing.T) {
+		user := newUser(t)
+
+		first, err := record(t, user, "")
+		require.NoError(t, err)
+		second, err := record(t, user, "")
+		require.NoError(t, err)
+
+		assert.NotEqual(t, first.OrderID, second.OrderID)
+	})
+
+	t.Run("should save nothing and remove the proof when the payment cannot be saved", func(t *testing.T) {
+		user := newUser(t)
+		before := savedProofs(t)
+		const callback = "test:fail_transaction_details"
+		require.NoError(t, test.DB.Callback().Create().Before("gorm:create").Register(callback, func(db *gorm.DB) {
+			if db.Statement.Table == model.TransactionDetailsTable {
+				_ = db.AddError(errors.New("disk full"))
+			}
+		}))
+		t.Cleanup(func() { _ = test.DB.Callback().Create().Remove(callback) })
+
+		_, err := record(t, user, "")
+
+		assert.Error(t, err)
+		var subscriptions int64
+		require.NoError(t, test.DB.Unscoped().Model(&model.UserSubscription{}).Where("user_id = ?", user.ID).Count(&subscriptions).Error)
+		assert.Equal(t, int64(0), subscriptions)
+		assert.ElementsMatch(t, before, savedProofs(t))
+	})
+}
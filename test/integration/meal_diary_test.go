@@ -0,0 +1,78 @@
+package integration
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"app/test/helper"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMealServiceGetDiary(t *testing.T) {
+	validate := validation.Validator()
+	mealService := service.NewMealService(test.DB, validate, "", "", nil, service.NewFoodGradeService(test.DB, validate), nil)
+
+	helper.ClearAll(test.DB)
+	user := &model.User{Name: "Test", Email: "diary@gmail.com", Password: "password1"}
+	helper.InsertUser(test.DB, user)
+	t.Cleanup(func() { test.DB.Where("user_id = ?", user.ID).Delete(&model.MealHistory{}) })
+
+	// Days are calendar days of the server time zone
+	at := func(day, hour, minute, second int) time.Time {
+		return time.Date(2025, time.March, day, hour, minute, second, 0, time.Local)
+	}
+	for _, meal := range []model.MealHistory{
+		{Title: "Sunday before", MealTime: at(2, 23, 59, 59), Calories: 100},
+		{Title: "Monday midnight", MealTime: at(3, 0, 0, 0), Calories: 200},
+		{Title: "Monday night", MealTime: at(3, 23, 59, 59), Calories: 300},
+		{Title: "Sunday night", MealTime: at(9, 23, 59, 59), Calories: 400},
+		{Title: "Monday after", MealTime: at(10, 0, 0, 0), Calories: 500},
+	} {
+		meal.UserID = user.ID
+		require.NoError(t, test.DB.Create(&meal).Error)
+	}
+
+	diary := func(t *testing.T, date, period string) *model.MealDiary {
+		var result *model.MealDiary
+		err := inRequest(t, func(c *fiber.Ctx) (err error) {
+			result, err = mealService.GetDiary(c, user.ID, &validation.MealDiaryQuery{Date: date, Period: period})
+			return err
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("should cover a day from its midnight to the last second", func(t *testing.T) {
+		result := diary(t, "2025-03-03", model.DiaryPeriodDay)
+
+		require.Len(t, result.Days, 1)
+		assert.Equal(t, 2, result.Days[0].MealCount)
+		assert.Equal(t, "Monday midnight", result.Days[0].Meals[0].Title)
+		assert.Equal(t, 500.0, result.Total.Calories)
+	})
+
+	t.Run("should cover the week from Monday midnight to the end of Sunday", func(t *testing.T) {
+		result := diary(t, "2025-03-05", model.DiaryPeriodWeek)
+
+		assert.Equal(t, "2025-03-03", result.From)
+		assert.Equal(t, "2025-03-09", result.To)
+		require.Len(t, result.Days, 7)
+		assert.Equal(t, 2, result.Days[0].MealCount)
+		assert.Equal(t, 1, result.Days[6].MealCount)
+		assert.Equal(t, 900.0, result.Total.Calories)
+	})
+
+	t.Run("should default to the day", func(t *testing.T) {
+		result := diary(t, "2025-03-10", "")
+
+		assert.Equal(t, model.DiaryPeriodDay, result.Period)
+		require.Len(t, result.Days, 1)
+		assert.Equal(t, "Monday after", result.Days[0].Meals[0].Title)
+	})
+}
@@ -0,0 +1,99 @@
+package integration
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"app/test/helper"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartnerServiceEnrollMember(t *testing.T) {
+	partnerService := service.NewPartnerService(test.DB, validation.Validator(), nil)
+	const email = "member@gmail.com"
+
+	// setup adds the partner with a key and a user, and removes them after the test
+	setup := func(t *testing.T) (*model.PartnerKey, *model.User) {
+		helper.ClearAll(test.DB)
+		key := &model.PartnerKey{
+			Partner: "klinik", KeyPrefix: "nbx_test", KeyHash: uuid.NewString(),
+			Scopes: []string{model.PartnerScopeWriteMembers}, CreatedByID: uuid.New(),
+		}
+		require.NoError(t, test.DB.Create(key).Error)
+		user := &model.User{Name: "Test", Email: email, Password: "password1"}
+		helper.InsertUser(test.DB, user)
+		t.Cleanup(func() {
+			test.DB.Delete(key)
+			test.DB.Where("partner = ?", key.Partner).Delete(&model.PartnerEnrollmentCode{})
+			test.DB.Where("partner = ?", key.Partner).Delete(&model.PartnerMember{})
+		})
+		return key, user
+	}
+	createCode := func(t *testing.T, userID uuid.UUID, partner string) (code *model.PartnerEnrollmentCode, err error) {
+		err = inRequest(t, func(c *fiber.Ctx) (err error) {
+			code, err = partnerService.CreateEnrollmentCode(c, userID, partner)
+			return err
+		})
+		return code, err
+	}
+	enroll := func(t *testing.T, key *model.PartnerKey, email, code string) (member *model.PartnerMember, err error) {
+		err = inRequest(t, func(c *fiber.Ctx) (err error) {
+			member, err = partnerService.EnrollMember(c, key, &validation.EnrollPartnerMember{Email: email, Code: code})
+			return err
+		})
+		return member, err
+	}
+
+	t.Run("should enroll once with the code of the user", func(t *testing.T) {
+		key, user := setup(t)
+		code, err := createCode(t, user.ID, key.Partner)
+		require.NoError(t, err)
+		assert.Len(t, code.Code, 8)
+
+		member, err := enroll(t, key, email, code.Code)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, member.UserID)
+
+		_, err = enroll(t, key, email, code.Code)
+		assert.Equal(t, fiber.StatusForbidden, err.(*fiber.Error).Code)
+	})
+
+	t.Run("should answer an unknown email as a wrong code", func(t *testing.T) {
+		key, user := setup(t)
+		code, err := createCode(t, user.ID, key.Partner)
+		require.NoError(t, err)
+
+		_, unknown := enroll(t, key, "nobody@gmail.com", code.Code)
+		_, wrong := enroll(t, key, email, "AAAAAAAA")
+		_, missing := enroll(t, key, email, "")
+
+		assert.Equal(t, fiber.StatusForbidden, unknown.(*fiber.Error).Code)
+		assert.Equal(t, unknown, wrong)
+		assert.Error(t, missing)
+
+		_, err = enroll(t, key, email, code.Code)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should refuse the code of another partner", func(t *testing.T) {
+		key, user := setup(t)
+		code, err := createCode(t, user.ID, key.Partner)
+		require.NoError(t, err)
+
+		_, err = enroll(t, &model.PartnerKey{ID: key.ID, Partner: "rumahsakit"}, email, code.Code)
+		assert.Equal(t, fiber.StatusForbidden, err.(*fiber.Error).Code)
+	})
+
+	t.Run("should not give codes for unknown partners", func(t *testing.T) {
+		_, user := setup(t)
+
+		_, err := createCode(t, user.ID, "nobody")
+		assert.Equal(t, fiber.StatusNotFound, err.(*fiber.Error).Code)
+	})
+}
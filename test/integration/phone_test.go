@@ -0,0 +1,248 @@
+package integration
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"app/test/helper"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTwilio records the texts the phone service sends, answering them with status
+type fakeTwilio struct {
+	mu     sync.Mutex
+	status int
+	texts  []url.Values
+}
+
+func (f *fakeTwilio) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.texts = append(f.texts, r.PostForm)
+	w.WriteHeader(f.status)
+	_, _ = w.Write([]byte(`{"sid":"SM00000000000000000000000000000000"}`))
+}
+
+func (f *fakeTwilio) sent() []url.Values {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]url.Values(nil), f.texts...)
+}
+
+// lastCode is the code of the latest text, the first 6 digits of its body
+func (f *fakeTwilio) lastCode(t *testing.T) string {
+	texts := f.sent()
+	require.NotEmpty(t, texts)
+	return texts[len(texts)-1].Get("Body")[:6]
+}
+
+// newPhoneTestService points the phone service at a fake Twilio and clears the codes and messages
+func newPhoneTestService(t *testing.T) (service.PhoneService, *fakeTwilio) {
+	twilio := &fakeTwilio{status: http.StatusCreated}
+	server := httptest.NewServer(twilio)
+	t.Cleanup(server.Close)
+
+	provider, sid, token, from, endpoint := config.PhoneProvider, config.TwilioAccountSID, config.TwilioAuthToken,
+		config.TwilioSMSFrom, config.TwilioEndpoint
+	resend, perHour, perUser, attempts := config.OTPResendInterval, config.OTPMaxPerHour, config.OTPMaxPerUserPerHour,
+		config.OTPMaxAttempts
+	t.Cleanup(func() {
+		config.PhoneProvider, config.TwilioAccountSID, config.TwilioAuthToken = provider, sid, token
+		config.TwilioSMSFrom, config.TwilioEndpoint = from, endpoint
+		config.OTPResendInterval, config.OTPMaxPerHour, config.OTPMaxPerUserPerHour = resend, perHour, perUser
+		config.OTPMaxAttempts = attempts
+	})
+	config.PhoneProvider = "twilio"
+	config.TwilioAccountSID = "AC00000000000000000000000000000000"
+	config.TwilioAuthToken = "token"
+	config.TwilioSMSFrom = "+15550000000"
+	config.TwilioEndpoint = server.URL
+	config.OTPResendInterval = time.Minute
+	config.OTPMaxPerHour = 5
+	config.OTPMaxPerUserPerHour = 10
+	config.OTPMaxAttempts = 5
+
+	helper.ClearAll(test.DB)
+	require.NoError(t, test.DB.Where("1 = 1").Delete(&model.PhoneOTP{}).Error)
+	require.NoError(t, test.DB.Where("1 = 1").Delete(&model.PhoneMessage{}).Error)
+
+	return service.NewPhoneService(test.DB, validation.Validator()), twilio
+}
+
+// inRequest runs call inside a request, the way the handlers call the service, and returns its error
+func inRequest(t *testing.T, call func(c *fiber.Ctx) error) error {
+	var err error
+	app := fiber.New()
+	app.Post("/", func(c *fiber.Ctx) error {
+		err = call(c)
+		return nil
+	})
+
+	_, testErr := app.Test(httptest.NewRequest(http.MethodPost, "/", nil), 5000)
+	require.NoError(t, testErr)
+	return err
+}
+
+func TestPhoneService(t *testing.T) {
+	const phone = "+6281234567890"
+	insertPhoneUser := func() *model.User {
+		user := &model.User{Name: "Test", Email: "phone@gmail.com", Password: "password1", Phone: phone, VerifiedPhone: true}
+		helper.InsertUser(test.DB, user)
+		return user
+	}
+
+	t.Run("SendLoginCode", func(t *testing.T) {
+		t.Run("should answer the same for a phone without an account and send nothing", func(t *testing.T) {
+			phoneService, twilio := newPhoneTestService(t)
+
+			err := inRequest(t, func(c *fiber.Ctx) error {
+				return phoneService.SendLoginCode(c, &validation.SendPhoneCode{Phone: "081299999999"})
+			})
+
+			assert.NoError(t, err)
+			assert.Empty(t, twilio.sent())
+		})
+
+		t.Run("should not answer the resend limit of a phone with an account", func(t *testing.T) {
+			phoneService, twilio := newPhoneTestService(t)
+			insertPhoneUser()
+			req := &validation.SendPhoneCode{Phone: "081234567890"}
+
+			require.NoError(t, inRequest(t, func(c *fiber.Ctx) error { return phoneService.SendLoginCode(c, req) }))
+			assert.NoError(t, inRequest(t, func(c *fiber.Ctx) error { return phoneService.SendLoginCode(c, req) }))
+
+			assert.Len(t, twilio.sent(), 1)
+		})
+
+		t.Run("should not answer the hourly limit of a phone with an account", func(t *testing.T) {
+			phoneService, twilio := newPhoneTestService(t)
+			insertPhoneUser()
+			config.OTPResendInterval = 0
+			config.OTPMaxPerHour = 2
+			req := &validation.SendPhoneCode{Phone: "081234567890"}
+
+			for i := 0; i < 3; i++ {
+				assert.NoError(t, inRequest(t, func(c *fiber.Ctx) error { return phoneService.SendLoginCode(c, req) }))
+			}
+
+			assert.Len(t, twilio.sent(), 2)
+		})
+
+		t.Run("should not answer a failed send to a phone with an account", func(t *testing.T) {
+			phoneService, twilio := newPhoneTestService(t)
+			insertPhoneUser()
+			twilio.status = http.StatusInternalServerError
+
+			err := inRequest(t, func(c *fiber.Ctx) error {
+				return phoneService.SendLoginCode(c, &validation.SendPhoneCode{Phone: "081234567890"})
+			})
+
+			assert.NoError(t, err)
+			assert.Len(t, twilio.sent(), 1)
+		})
+	})
+
+	t.Run("Login", func(t *testing.T) {
+		t.Run("should sign in once with the code sent", func(t *testing.T) {
+			phoneService, twilio := newPhoneTestService(t)
+			user := insertPhoneUser()
+			require.NoError(t, inRequest(t, func(c *fiber.Ctx) error {
+				return phoneService.SendLoginCode(c, &validation.SendPhoneCode{Phone: phone})
+			}))
+			req := &validation.CheckPhoneCode{Phone: phone, Code: twilio.lastCode(t)}
+
+			var loggedIn *model.User
+			err := inRequest(t, func(c *fiber.Ctx) (err error) {
+				loggedIn, err = phoneService.Login(c, req)
+				return err
+			})
+			require.NoError(t, err)
+			assert.Equal(t, user.ID, loggedIn.ID)
+
+			err = inRequest(t, func(c *fiber.Ctx) error {
+				_, err := phoneService.Login(c, req)
+				return err
+			})
+			assert.Equal(t, fiber.StatusUnauthorized, err.(*fiber.Error).Code)
+		})
+
+		t.Run("should lock the code after too many wrong guesses", func(t *testing.T) {
+			phoneService, twilio := newPhoneTestService(t)
+			insertPhoneUser()
+			config.OTPMaxAttempts = 3
+			require.NoError(t, inRequest(t, func(c *fiber.Ctx) error {
+				return phoneService.SendLoginCode(c, &validation.SendPhoneCode{Phone: phone})
+			}))
+			code := twilio.lastCode(t)
+			wrong := "000000"
+			if code == wrong {
+				wrong = "111111"
+			}
+
+			for i := 0; i < config.OTPMaxAttempts; i++ {
+				err := inRequest(t, func(c *fiber.Ctx) error {
+					_, err := phoneService.Login(c, &validation.CheckPhoneCode{Phone: phone, Code: wrong})
+					return err
+				})
+				assert.Equal(t, fiber.StatusUnauthorized, err.(*fiber.Error).Code)
+			}
+
+			err := inRequest(t, func(c *fiber.Ctx) error {
+				_, err := phoneService.Login(c, &validation.CheckPhoneCode{Phone: phone, Code: code})
+				return err
+			})
+			assert.Equal(t, fiber.StatusUnauthorized, err.(*fiber.Error).Code)
+
+			var otp model.PhoneOTP
+			require.NoError(t, test.DB.Where("phone = ?", phone).First(&otp).Error)
+			assert.Equal(t, config.OTPMaxAttempts, otp.Attempts)
+			assert.Nil(t, otp.UsedAt)
+		})
+	})
+
+	t.Run("SendVerificationCode", func(t *testing.T) {
+		t.Run("should cap the codes of a user whatever the phones", func(t *testing.T) {
+			phoneService, twilio := newPhoneTestService(t)
+			user := &model.User{Name: "Test", Email: "verify@gmail.com", Password: "password1"}
+			helper.InsertUser(test.DB, user)
+			config.OTPMaxPerUserPerHour = 2
+
+			phones := []string{"081200000001", "081200000002", "081200000003"}
+			for _, number := range phones[:2] {
+				require.NoError(t, inRequest(t, func(c *fiber.Ctx) error {
+					return phoneService.SendVerificationCode(c, user, &validation.SendPhoneCode{Phone: number})
+				}))
+			}
+			err := inRequest(t, func(c *fiber.Ctx) error {
+				return phoneService.SendVerificationCode(c, user, &validation.SendPhoneCode{Phone: phones[2]})
+			})
+
+			assert.Equal(t, fiber.StatusTooManyRequests, err.(*fiber.Error).Code)
+			assert.Len(t, twilio.sent(), 2)
+		})
+
+		t.Run("should answer the limits of the phone", func(t *testing.T) {
+			phoneService, _ := newPhoneTestService(t)
+			user := &model.User{Name: "Test", Email: "verify@gmail.com", Password: "password1"}
+			helper.InsertUser(test.DB, user)
+			req := &validation.SendPhoneCode{Phone: "081200000001"}
+
+			require.NoError(t, inRequest(t, func(c *fiber.Ctx) error { return phoneService.SendVerificationCode(c, user, req) }))
+			err := inRequest(t, func(c *fiber.Ctx) error { return phoneService.SendVerificationCode(c, user, req) })
+
+			assert.Equal(t, fiber.StatusTooManyRequests, err.(*fiber.Error).Code)
+		})
+	})
+}
@@ -0,0 +1,222 @@
+package integration
+
+import (
+	"app/src/fhir"
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"app/test/helper"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// insertPrivacyUser adds a user and removes what the test stored about them afterwards
+func insertPrivacyUser(t *testing.T, email string) *model.User {
+	user := &model.User{Name: "Test", Email: email, Password: "password1"}
+	helper.InsertUser(test.DB, user)
+	t.Cleanup(func() {
+		for _, table := range []any{
+			&model.PrivacySettings{}, &model.DiaryShare{}, &model.MealHistory{}, &model.UsersWeightHeightHistory{},
+			&model.DailyNutritionSummary{}, &model.PartnerMember{}, &model.PartnerConsent{},
+		} {
+			test.DB.Where("user_id = ?", user.ID).Delete(table)
+		}
+	})
+	return user
+}
+
+// setCoachSharing saves the coach data sharing level of a user
+func setCoachSharing(t *testing.T, privacyService service.PrivacyService, userID uuid.UUID, level string) {
+	require.NoError(t, inRequest(t, func(c *fiber.Ctx) error {
+		_, err := privacyService.UpdateSettings(c, userID, &validation.UpdatePrivacySettings{CoachDataSharing: &level})
+		return err
+	}))
+}
+
+func TestPrivacyService(t *testing.T) {
+	privacyService := service.NewPrivacyService(test.DB, validation.Validator())
+
+	t.Run("UpdateSettings", func(t *testing.T) {
+		t.Run("should start private and change only the fields sent", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			user := insertPrivacyUser(t, "privacy@gmail.com")
+
+			var settings *model.PrivacySettings
+			require.NoError(t, inRequest(t, func(c *fiber.Ctx) (err error) {
+				settings, err = privacyService.GetSettings(c, user.ID)
+				return err
+			}))
+			assert.Equal(t, model.ProfilePrivate, settings.ProfileVisibility)
+			assert.Equal(t, model.CoachSharingNone, settings.CoachDataSharing)
+
+			public := model.ProfilePublic
+			require.NoError(t, inRequest(t, func(c *fiber.Ctx) (err error) {
+				_, err = privacyService.UpdateSettings(c, user.ID, &validation.UpdatePrivacySettings{ProfileVisibility: &public})
+				return err
+			}))
+			setCoachSharing(t, privacyService, user.ID, model.CoachSharingSummary)
+
+			require.NoError(t, inRequest(t, func(c *fiber.Ctx) (err error) {
+				settings, err = privacyService.GetSettings(c, user.ID)
+				return err
+			}))
+			assert.Equal(t, model.ProfilePublic, settings.ProfileVisibility)
+			assert.Equal(t, model.CoachSharingSummary, settings.CoachDataSharing)
+		})
+	})
+
+	t.Run("GetPublicProfile", func(t *testing.T) {
+		t.Run("should answer a private profile as a missing one", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			handle := "budi_s"
+			owner := insertPrivacyUser(t, "owner@gmail.com")
+			require.NoError(t, test.DB.Model(owner).Update("handle", handle).Error)
+			viewer := insertPrivacyUser(t, "viewer@gmail.com")
+
+			getProfile := func(viewerID uuid.UUID, handle string) error {
+				return inRequest(t, func(c *fiber.Ctx) error {
+					_, err := privacyService.GetPublicProfile(c, viewerID, handle)
+					return err
+				})
+			}
+
+			hidden := getProfile(viewer.ID, handle)
+			missing := getProfile(viewer.ID, "nobody_here")
+			assert.Equal(t, fiber.StatusNotFound, hidden.(*fiber.Error).Code)
+			assert.Equal(t, missing, hidden)
+			assert.NoError(t, getProfile(owner.ID, handle))
+
+			public := model.ProfilePublic
+			require.NoError(t, inRequest(t, func(c *fiber.Ctx) (err error) {
+				_, err = privacyService.UpdateSettings(c, owner.ID, &validation.UpdatePrivacySettings{ProfileVisibility: &public})
+				return err
+			}))
+			assert.NoError(t, getProfile(viewer.ID, handle))
+		})
+	})
+
+	t.Run("DiaryShare", func(t *testing.T) {
+		diaryShareService := service.NewDiaryShareService(test.DB, validation.Validator())
+		today := time.Now().Format("2006-01-02")
+		createShare := func(userID uuid.UUID, diary, vitals bool) (share *model.DiaryShare, err error) {
+			err = inRequest(t, func(c *fiber.Ctx) (err error) {
+				share, err = diaryShareService.CreateShare(c, userID, &validation.CreateDiaryShare{
+					From: today, To: today, Diary: diary, Vitals: vitals, ExpiresInDays: 1,
+				})
+				return err
+			})
+			return share, err
+		}
+		viewShare := func(t *testing.T, share *model.DiaryShare) *model.SharedDiary {
+			link, err := url.Parse(share.URL)
+			require.NoError(t, err)
+			var shared *model.SharedDiary
+			require.NoError(t, inRequest(t, func(c *fiber.Ctx) (err error) {
+				shared, err = diaryShareService.ViewShare(c, &validation.Token{Token: link.Query().Get("token")})
+				return err
+			}))
+			return shared
+		}
+
+		t.Run("should refuse to share what the settings do not", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			user := insertPrivacyUser(t, "share@gmail.com")
+
+			_, err := createShare(user.ID, true, false)
+			assert.Equal(t, fiber.StatusForbidden, err.(*fiber.Error).Code)
+
+			setCoachSharing(t, privacyService, user.ID, model.CoachSharingSummary)
+			_, err = createShare(user.ID, true, true)
+			assert.Equal(t, fiber.StatusForbidden, err.(*fiber.Error).Code)
+			_, err = createShare(user.ID, true, false)
+			assert.NoError(t, err)
+		})
+
+		t.Run("should show the totals without the meals with a summary", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			user := insertPrivacyUser(t, "share@gmail.com")
+			require.NoError(t, test.DB.Create(&model.MealHistory{UserID: user.ID, Title: "Nasi goreng", MealTime: time.Now(), Calories: 500}).Error)
+			setCoachSharing(t, privacyService, user.ID, model.CoachSharingFull)
+			share, err := createShare(user.ID, true, false)
+			require.NoError(t, err)
+
+			shared := viewShare(t, share)
+			require.Len(t, shared.Diary.Days, 1)
+			assert.Len(t, shared.Diary.Days[0].Meals, 1)
+
+			setCoachSharing(t, privacyService, user.ID, model.CoachSharingSummary)
+			shared = viewShare(t, share)
+			require.Len(t, shared.Diary.Days, 1)
+			assert.Equal(t, 500.0, shared.Diary.Days[0].Total.Calories)
+			assert.Empty(t, shared.Diary.Days[0].Meals)
+
+			setCoachSharing(t, privacyService, user.ID, model.CoachSharingNone)
+			assert.Nil(t, viewShare(t, share).Diary)
+		})
+	})
+
+	t.Run("FHIR", func(t *testing.T) {
+		fhirService := service.NewFHIRService(test.DB, validation.Validator())
+		key := &model.PartnerKey{Partner: "klinik"}
+		today := time.Now()
+		query := &validation.PartnerObservationQuery{From: today.Format("2006-01-02"), To: today.Format("2006-01-02")}
+		insertMember := func(t *testing.T) *model.User {
+			helper.ClearAll(test.DB)
+			user := insertPrivacyUser(t, "member@gmail.com")
+			require.NoError(t, test.DB.Create(&model.PartnerMember{Partner: key.Partner, UserID: user.ID, KeyID: user.ID}).Error)
+			require.NoError(t, test.DB.Create(&model.PartnerConsent{Partner: key.Partner, UserID: user.ID, GrantedAt: today}).Error)
+			require.NoError(t, test.DB.Create(&model.UsersWeightHeightHistory{UserID: user.ID, Weight: 60, Height: 165}).Error)
+			require.NoError(t, test.DB.Create(&model.DailyNutritionSummary{
+				UserID: user.ID, Date: today, Calories: 1800, Protein: 60, Carbs: 250, Fat: 50, MealCount: 3,
+			}).Error)
+			return user
+		}
+		observations := func(userID uuid.UUID, query *validation.PartnerObservationQuery) (bundle *fhir.Bundle, err error) {
+			err = inRequest(t, func(c *fiber.Ctx) (err error) {
+				bundle, err = fhirService.GetObservations(c, key, userID, query)
+				return err
+			})
+			return bundle, err
+		}
+
+		t.Run("should refuse a member who shares nothing despite the consent", func(t *testing.T) {
+			user := insertMember(t)
+
+			_, err := observations(user.ID, query)
+
+			assert.Equal(t, fiber.StatusForbidden, err.(*fiber.Error).Code)
+		})
+
+		t.Run("should return the intake alone with a summary", func(t *testing.T) {
+			user := insertMember(t)
+			setCoachSharing(t, privacyService, user.ID, model.CoachSharingSummary)
+
+			bundle, err := observations(user.ID, query)
+			require.NoError(t, err)
+			require.Len(t, bundle.Entry, 1)
+			assert.Equal(t, fhir.CategorySurvey, bundle.Entry[0].Resource.Category[0].Coding[0].Code)
+
+			_, err = observations(user.ID, &validation.PartnerObservationQuery{
+				From: query.From, To: query.To, Category: fhir.CategoryVitalSigns,
+			})
+			assert.Equal(t, fiber.StatusForbidden, err.(*fiber.Error).Code)
+		})
+
+		t.Run("should return the vitals too with full sharing", func(t *testing.T) {
+			user := insertMember(t)
+			setCoachSharing(t, privacyService, user.ID, model.CoachSharingFull)
+
+			bundle, err := observations(user.ID, query)
+
+			require.NoError(t, err)
+			assert.Len(t, bundle.Entry, 4)
+		})
+	})
+}
@@ -0,0 +1,64 @@
+package integration
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"app/test/helper"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevenueServiceGetRetention(t *testing.T) {
+	revenueService := service.NewRevenueService(test.DB, validation.Validator())
+
+	helper.ClearAll(test.DB)
+	plan := &model.SubscriptionPlan{Name: "Retention Premium", Price: 50000, Currency: "IDR", AIscanLimit: 10, ValidityDays: 30}
+	require.NoError(t, test.DB.Create(plan).Error)
+	t.Cleanup(func() {
+		test.DB.Unscoped().Where("plan_id = ?", plan.ID).Delete(&model.UserSubscription{})
+		test.DB.Delete(plan)
+	})
+
+	// The subscriptions of the test started in January 2001, no other data of the database is in that cohort
+	start := time.Date(2001, 1, 10, 0, 0, 0, 0, time.UTC)
+	subscribe := func(t *testing.T, email, source, paymentStatus string) {
+		user := &model.User{Name: "Test", Email: email, Password: "password1"}
+		helper.InsertUser(test.DB, user)
+		require.NoError(t, test.DB.Create(&model.UserSubscription{
+			UserID: user.ID, PlanID: plan.ID, StartDate: start, EndDate: start.AddDate(0, 0, 30),
+			Source: source, PaymentStatus: paymentStatus, Status: model.SubscriptionExpired,
+		}).Error)
+	}
+	subscribe(t, "apple@gmail.com", model.SourceAppleIAP, "expired")
+	subscribe(t, "google@gmail.com", model.SourceGooglePlay, "refunded")
+	subscribe(t, "midtrans@gmail.com", model.SourceMidtrans, "success")
+	subscribe(t, "rejected@gmail.com", model.SourceMidtrans, "refunded")
+	subscribe(t, "failed@gmail.com", model.SourceManualTransfer, "failed")
+
+	var retention *model.SubscriptionRetention
+	require.NoError(t, inRequest(t, func(c *fiber.Ctx) (err error) {
+		retention, err = revenueService.GetRetention(c, &validation.SubscriptionRetentionQuery{From: "2001-01", To: "2001-01", Months: 1})
+		return err
+	}))
+
+	t.Run("should count lapsed and refunded store subscriptions as paid", func(t *testing.T) {
+		require.Len(t, retention.Cohorts, 1)
+		assert.Equal(t, "2001-01", retention.Cohorts[0].Cohort)
+		assert.Equal(t, int64(3), retention.Cohorts[0].Subscribers)
+	})
+
+	t.Run("should churn the subscribers whose paid subscription ended", func(t *testing.T) {
+		require.Len(t, retention.Plans, 1)
+		assert.Equal(t, plan.ID, retention.Plans[0].PlanID)
+		assert.Equal(t, int64(3), retention.Plans[0].Ended)
+		assert.Equal(t, int64(3), retention.Plans[0].Churned)
+		require.NotNil(t, retention.Plans[0].AverageLifetimeDays)
+		assert.InDelta(t, 30, *retention.Plans[0].AverageLifetimeDays, 0.01)
+	})
+}
@@ -0,0 +1,149 @@
+package integration
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"context"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntimeConfigService(t *testing.T) {
+	runtimeConfigService := service.NewRuntimeConfigService(test.DB, validation.Validator())
+	ctx := context.Background()
+
+	// clearOverrides removes the overrides and the changes of the test, and returns the flags to their defaults
+	clearOverrides := func(t *testing.T) {
+		require.NoError(t, test.DB.Where("key LIKE ?", model.SettingConfigPrefix+"%").Delete(&model.SystemSetting{}).Error)
+		require.NoError(t, test.DB.Where("reason = ?", "runtime config test").Delete(&model.ConfigChange{}).Error)
+		require.NoError(t, runtimeConfigService.Reload(ctx))
+	}
+	// saveOverride stores an override the way another instance does
+	saveOverride := func(t *testing.T, key, value string) {
+		require.NoError(t, test.DB.Save(&model.SystemSetting{Key: model.SettingConfigPrefix + key, Value: value}).Error)
+	}
+	defaultOf := func(t *testing.T, key string) string {
+		flag, ok := config.LookupRuntimeFlag(key)
+		require.True(t, ok)
+		return flag.Default
+	}
+
+	t.Run("Reload", func(t *testing.T) {
+		t.Run("should apply the overrides saved by another instance", func(t *testing.T) {
+			clearOverrides(t)
+			t.Cleanup(func() { clearOverrides(t) })
+			saveOverride(t, "fraud_max_purchases", "9")
+			saveOverride(t, "retention_dry_run", "false")
+
+			require.NoError(t, runtimeConfigService.Reload(ctx))
+
+			assert.Equal(t, 9, config.FraudMaxPurchases.Get())
+			assert.False(t, config.RetentionDryRun.Get())
+		})
+
+		t.Run("should return a flag to its default once its override is removed", func(t *testing.T) {
+			clearOverrides(t)
+			t.Cleanup(func() { clearOverrides(t) })
+			saveOverride(t, "daily_tip_hour", "21")
+			require.NoError(t, runtimeConfigService.Reload(ctx))
+			require.Equal(t, 21, config.DailyTipHour.Get())
+
+			require.NoError(t, test.DB.Delete(&model.SystemSetting{}, "key = ?", model.SettingConfigPrefix+"daily_tip_hour").Error)
+			require.NoError(t, runtimeConfigService.Reload(ctx))
+
+			flag, _ := config.LookupRuntimeFlag("daily_tip_hour")
+			assert.Equal(t, defaultOf(t, "daily_tip_hour"), flag.Value())
+		})
+
+		t.Run("should keep a flag as it is when its saved value is invalid", func(t *testing.T) {
+			clearOverrides(t)
+			t.Cleanup(func() { clearOverrides(t) })
+			saveOverride(t, "ops_bot_report_hour", "30")
+			saveOverride(t, "installment_grace_days", "10")
+
+			require.NoError(t, runtimeConfigService.Reload(ctx))
+
+			flag, _ := config.LookupRuntimeFlag("ops_bot_report_hour")
+			assert.Equal(t, defaultOf(t, "ops_bot_report_hour"), flag.Value())
+			assert.Equal(t, 10, config.InstallmentGraceDays.Get())
+		})
+
+		t.Run("should ignore overrides of unknown flags", func(t *testing.T) {
+			clearOverrides(t)
+			t.Cleanup(func() { clearOverrides(t) })
+			saveOverride(t, "removed_flag", "1")
+
+			assert.NoError(t, runtimeConfigService.Reload(ctx))
+		})
+	})
+
+	t.Run("UpdateConfig", func(t *testing.T) {
+		adminID := uuid.New()
+		update := func(t *testing.T, values map[string]*string) error {
+			return inRequest(t, func(c *fiber.Ctx) error {
+				_, err := runtimeConfigService.UpdateConfig(c, adminID, &validation.UpdateRuntimeConfig{
+					Values: values, Reason: "runtime config test",
+				})
+				return err
+			})
+		}
+		changes := func(t *testing.T, key string) []model.ConfigChange {
+			var changes []model.ConfigChange
+			require.NoError(t, test.DB.Where("key = ? AND reason = ?", key, "runtime config test").
+				Order("changed_at").Find(&changes).Error)
+			return changes
+		}
+
+		t.Run("should apply an override right away and record the change", func(t *testing.T) {
+			clearOverrides(t)
+			t.Cleanup(func() { clearOverrides(t) })
+			value := "42"
+
+			require.NoError(t, update(t, map[string]*string{"assistant_free_daily_messages": &value}))
+
+			assert.Equal(t, 42, config.AssistantFreeDailyMessages.Get())
+			recorded := changes(t, "assistant_free_daily_messages")
+			require.Len(t, recorded, 1)
+			assert.Equal(t, defaultOf(t, "assistant_free_daily_messages"), recorded[0].OldValue)
+			assert.Equal(t, "42", recorded[0].NewValue)
+			assert.Equal(t, adminID, recorded[0].ChangedByID)
+		})
+
+		t.Run("should return a flag to its default when its value is null", func(t *testing.T) {
+			clearOverrides(t)
+			t.Cleanup(func() { clearOverrides(t) })
+			value := "42"
+			require.NoError(t, update(t, map[string]*string{"assistant_free_daily_messages": &value}))
+
+			require.NoError(t, update(t, map[string]*string{"assistant_free_daily_messages": nil}))
+
+			flag, _ := config.LookupRuntimeFlag("assistant_free_daily_messages")
+			assert.Equal(t, flag.Default, flag.Value())
+			recorded := changes(t, "assistant_free_daily_messages")
+			require.Len(t, recorded, 2)
+			assert.True(t, recorded[1].Reset)
+		})
+
+		t.Run("should refuse invalid values and unknown flags without changing anything", func(t *testing.T) {
+			clearOverrides(t)
+			t.Cleanup(func() { clearOverrides(t) })
+			valid, invalid := "5", "25"
+
+			err := update(t, map[string]*string{"daily_tip_hour": &valid, "cohort_aggregate_hour": &invalid})
+			assert.Equal(t, fiber.StatusBadRequest, err.(*fiber.Error).Code)
+			err = update(t, map[string]*string{"db_password": &valid})
+			assert.Equal(t, fiber.StatusBadRequest, err.(*fiber.Error).Code)
+
+			assert.Empty(t, changes(t, "daily_tip_hour"))
+			flag, _ := config.LookupRuntimeFlag("daily_tip_hour")
+			assert.Equal(t, flag.Default, flag.Value())
+		})
+	})
+}
@@ -0,0 +1,67 @@
+package integration
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"app/test/helper"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMealServiceGetScanHistory(t *testing.T) {
+	validate := validation.Validator()
+	mealService := service.NewMealService(test.DB, validate, "", "", nil, service.NewFoodGradeService(test.DB, validate), nil)
+
+	helper.ClearAll(test.DB)
+	user := &model.User{Name: "Test", Email: "scans@gmail.com", Password: "password1"}
+	helper.InsertUser(test.DB, user)
+	t.Cleanup(func() { test.DB.Where("user_id = ?", user.ID).Delete(&model.ScanHistory{}) })
+	for i := 0; i < 3; i++ {
+		require.NoError(t, test.DB.Create(&model.ScanHistory{
+			ID: uuid.New(), UserID: user.ID, Provider: "logmeal", Items: []model.ScanItem{},
+			CreatedAt: time.Now().Add(-time.Duration(i) * time.Minute),
+		}).Error)
+	}
+
+	history := func(t *testing.T, query *validation.ScanHistoryQuery) ([]model.ScanHistory, int64) {
+		var scans []model.ScanHistory
+		var total int64
+		require.NoError(t, inRequest(t, func(c *fiber.Ctx) (err error) {
+			scans, total, err = mealService.GetScanHistory(c, user.ID, query)
+			return err
+		}))
+		return scans, total
+	}
+
+	t.Run("should answer page 0 with the first page", func(t *testing.T) {
+		query := &validation.ScanHistoryQuery{Page: 0, Limit: 2}
+
+		scans, total := history(t, query)
+
+		assert.Len(t, scans, 2)
+		assert.Equal(t, int64(3), total)
+		assert.Equal(t, 1, query.Page)
+	})
+
+	t.Run("should use the default page size for limit 0", func(t *testing.T) {
+		query := &validation.ScanHistoryQuery{Page: 1, Limit: 0}
+
+		scans, _ := history(t, query)
+
+		assert.Len(t, scans, 3)
+		assert.Equal(t, 10, query.Limit)
+	})
+
+	t.Run("should answer the pages after the first", func(t *testing.T) {
+		scans, _ := history(t, &validation.ScanHistoryQuery{Page: 2, Limit: 2})
+
+		assert.Len(t, scans, 1)
+	})
+}
@@ -0,0 +1,76 @@
+package integration
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/redis"
+	"app/src/service"
+	"app/test"
+	"app/test/helper"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanQuotaServiceCachedQuota(t *testing.T) {
+	if config.RedisURL == "" {
+		t.Skip("REDIS_URL is not set, scans are counted in the database")
+	}
+	redisClient, err := redis.New(config.RedisURL)
+	require.NoError(t, err)
+	scanQuotaService := service.NewScanQuotaService(test.DB, redisClient)
+
+	// subscribe gives a new user a subscription of limit scans
+	subscribe := func(t *testing.T, limit int) (*model.User, *model.SubscriptionPlan) {
+		helper.ClearAll(test.DB)
+		user := &model.User{Name: "Test", Email: "quota@gmail.com", Password: "password1"}
+		helper.InsertUser(test.DB, user)
+		plan := &model.SubscriptionPlan{Name: "Quota test", Price: 50000, AIscanLimit: limit, ValidityDays: 30}
+		require.NoError(t, test.DB.Create(plan).Error)
+		now := time.Now()
+		subscription := &model.UserSubscription{
+			UserID: user.ID, PlanID: plan.ID, StartDate: now.Add(-time.Hour), EndDate: now.AddDate(0, 0, 30),
+			IsActive: true, PaymentStatus: "success",
+		}
+		require.NoError(t, test.DB.Create(subscription).Error)
+		t.Cleanup(func() {
+			scanQuotaService.Reset(context.Background(), subscription.ID)
+			test.DB.Unscoped().Delete(subscription)
+			test.DB.Delete(plan)
+		})
+		return user, plan
+	}
+	consume := func(t *testing.T, user *model.User) bool {
+		var consumed bool
+		require.NoError(t, inRequest(t, func(c *fiber.Ctx) (err error) {
+			_, consumed, err = scanQuotaService.Consume(c, user.ID)
+			return err
+		}))
+		return consumed
+	}
+
+	t.Run("should keep the cached limit until the quota is invalidated", func(t *testing.T) {
+		user, plan := subscribe(t, 1)
+		require.True(t, consume(t, user))
+		require.NoError(t, test.DB.Model(plan).Updates(&model.SubscriptionPlan{AIscanLimit: 3}).Error)
+
+		assert.False(t, consume(t, user))
+
+		scanQuotaService.Invalidate(context.Background(), user.ID)
+		assert.True(t, consume(t, user))
+	})
+
+	t.Run("should count the flushed scans once", func(t *testing.T) {
+		user, _ := subscribe(t, 2)
+		require.True(t, consume(t, user))
+
+		require.NoError(t, scanQuotaService.Flush(context.Background()))
+
+		assert.True(t, consume(t, user))
+		assert.False(t, consume(t, user))
+	})
+}
@@ -0,0 +1,74 @@
+package integration
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionServiceGetAllSubscriptionPlansWithUsers(t *testing.T) {
+	subscriptionService := service.NewSubscriptionService(test.DB, validation.Validator(), nil, nil, nil, nil, nil)
+	// Every plan of the test has the marker in its description, the searches of the test are scoped with it or
+	// match only its names
+	const marker = "plan-query-test"
+
+	for _, plan := range []*model.SubscriptionPlan{
+		{Name: "Diskon 100% Premium", Price: 100000, Currency: "IDR"},
+		{Name: "Diskon 1000 Premium", Price: 50000, Currency: "IDR"},
+		{Name: "Paket_A Premium", Price: 1500, Currency: "USD"},
+		{Name: "PaketXA Premium", Price: 2000, Currency: "SGD"},
+	} {
+		plan.Description, plan.AIscanLimit, plan.ValidityDays = marker, 10, 30
+		require.NoError(t, test.DB.Create(plan).Error)
+	}
+	t.Cleanup(func() { test.DB.Where("description = ?", marker).Delete(&model.SubscriptionPlan{}) })
+
+	getPlans := func(t *testing.T, query validation.SubscriptionPlanQuery) ([]string, error) {
+		query.Page, query.Limit = 1, 50
+		var plans []model.SubscriptionPlanWithUsers
+		err := inRequest(t, func(c *fiber.Ctx) (err error) {
+			plans, _, err = subscriptionService.GetAllSubscriptionPlansWithUsers(c, &query)
+			return err
+		})
+		names := []string{}
+		for _, plan := range plans {
+			if plan.Description == marker {
+				names = append(names, plan.Name)
+			}
+		}
+		return names, err
+	}
+
+	t.Run("should search % and _ literally", func(t *testing.T) {
+		names, err := getPlans(t, validation.SubscriptionPlanQuery{Search: "100%"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Diskon 100% Premium"}, names)
+
+		names, err = getPlans(t, validation.SubscriptionPlanQuery{Search: "paket_a"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Paket_A Premium"}, names)
+	})
+
+	t.Run("should require a currency with a price range", func(t *testing.T) {
+		minPrice := 1000
+		_, err := getPlans(t, validation.SubscriptionPlanQuery{MinPrice: &minPrice})
+		assert.Equal(t, fiber.StatusBadRequest, err.(*fiber.Error).Code)
+
+		names, err := getPlans(t, validation.SubscriptionPlanQuery{MinPrice: &minPrice, Currency: "USD", Search: marker})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Paket_A Premium"}, names)
+	})
+
+	t.Run("should sort prices within each currency", func(t *testing.T) {
+		names, err := getPlans(t, validation.SubscriptionPlanQuery{Search: marker, SortBy: "price", Order: "desc"})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Diskon 100% Premium", "Diskon 1000 Premium", "PaketXA Premium", "Paket_A Premium"}, names)
+	})
+}
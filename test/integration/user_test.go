@@ -2,6 +2,7 @@ package integration
 
 import (
 	"app/src/model"
+	"app/src/pagination"
 	"app/src/response"
 	"app/src/validation"
 	"app/test"
@@ -308,7 +309,7 @@ func TestUserRoutes(t *testing.T) {
 			bytes, err := io.ReadAll(apiResponse.Body)
 			assert.Nil(t, err)
 
-			responseBody := new(response.SuccessWithPaginate[model.User])
+			responseBody := new(pagination.PaginatedResponse[model.User])
 
 			err = json.Unmarshal(bytes, responseBody)
 			assert.Nil(t, err)
@@ -371,7 +372,7 @@ func TestUserRoutes(t *testing.T) {
 			bytes, err := io.ReadAll(apiResponse.Body)
 			assert.Nil(t, err)
 
-			responseBody := new(response.SuccessWithPaginate[model.User])
+			responseBody := new(pagination.PaginatedResponse[model.User])
 
 			err = json.Unmarshal(bytes, responseBody)
 			assert.Nil(t, err)
@@ -403,7 +404,7 @@ func TestUserRoutes(t *testing.T) {
 			bytes, err := io.ReadAll(apiResponse.Body)
 			assert.Nil(t, err)
 
-			responseBody := new(response.SuccessWithPaginate[model.User])
+			responseBody := new(pagination.PaginatedResponse[model.User])
 
 			err = json.Unmarshal(bytes, responseBody)
 			assert.Nil(t, err)
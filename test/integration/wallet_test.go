@@ -0,0 +1,174 @@
+package integration
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"app/test/helper"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestWalletService(t *testing.T) {
+	walletService := service.NewWalletService(test.DB, validation.Validator(), nil)
+
+	// newWallet gives a new user a wallet holding balance, the way a referral credit does
+	newWallet := func(t *testing.T, balance int) *model.User {
+		helper.ClearAll(test.DB)
+		user := &model.User{Name: "Test", Email: "wallet@gmail.com", Password: "password1"}
+		helper.InsertUser(test.DB, user)
+		t.Cleanup(func() {
+			test.DB.Where("user_id = ?", user.ID).Delete(&model.WalletTransaction{})
+			test.DB.Where("user_id = ?", user.ID).Delete(&model.Wallet{})
+		})
+		if balance > 0 {
+			require.NoError(t, test.DB.Transaction(func(tx *gorm.DB) error {
+				return walletService.CreditProration(context.Background(), tx, user.ID, balance, "SEED")
+			}))
+		}
+		return user
+	}
+	balanceOf := func(t *testing.T, user *model.User) int {
+		var wallet model.Wallet
+		require.NoError(t, test.DB.First(&wallet, "user_id = ?", user.ID).Error)
+		return wallet.Balance
+	}
+	apply := func(t *testing.T, user *model.User, amount int, orderID string) int {
+		var applied int
+		require.NoError(t, inRequest(t, func(c *fiber.Ctx) (err error) {
+			applied, err = walletService.ApplyToCheckout(c, user.ID, amount, orderID)
+			return err
+		}))
+		return applied
+	}
+	reverse := func(t *testing.T, user *model.User, orderID string) error {
+		return inRequest(t, func(c *fiber.Ctx) error {
+			return walletService.ReverseCheckout(c, user.ID, orderID)
+		})
+	}
+
+	t.Run("ApplyToCheckout", func(t *testing.T) {
+		t.Run("should apply the whole balance when the order costs more", func(t *testing.T) {
+			user := newWallet(t, 30000)
+
+			assert.Equal(t, 30000, apply(t, user, 50000, "ORDER-1"))
+			assert.Equal(t, 0, balanceOf(t, user))
+		})
+
+		t.Run("should apply only the price of the order", func(t *testing.T) {
+			user := newWallet(t, 30000)
+
+			assert.Equal(t, 20000, apply(t, user, 20000, "ORDER-1"))
+			assert.Equal(t, 10000, balanceOf(t, user))
+		})
+
+		t.Run("should apply nothing from an empty wallet", func(t *testing.T) {
+			user := newWallet(t, 0)
+
+			assert.Equal(t, 0, apply(t, user, 20000, "ORDER-1"))
+			assert.Equal(t, 0, balanceOf(t, user))
+		})
+	})
+
+	t.Run("ReverseCheckout", func(t *testing.T) {
+		t.Run("should give back the credit applied to the order", func(t *testing.T) {
+			user := newWallet(t, 30000)
+			apply(t, user, 20000, "ORDER-1")
+
+			require.NoError(t, reverse(t, user, "ORDER-1"))
+
+			assert.Equal(t, 30000, balanceOf(t, user))
+		})
+
+		t.Run("should give back the credit once when the failure is reported twice", func(t *testing.T) {
+			user := newWallet(t, 30000)
+			apply(t, user, 20000, "ORDER-1")
+
+			require.NoError(t, reverse(t, user, "ORDER-1"))
+			require.NoError(t, reverse(t, user, "ORDER-1"))
+
+			assert.Equal(t, 30000, balanceOf(t, user))
+			var reversals int64
+			require.NoError(t, test.DB.Model(&model.WalletTransaction{}).
+				Where("user_id = ? AND type = ?", user.ID, model.WalletTypeCheckoutReversal).Count(&reversals).Error)
+			assert.Equal(t, int64(1), reversals)
+		})
+
+		t.Run("should give back the credit once when the failure is reported twice at the same time", func(t *testing.T) {
+			user := newWallet(t, 30000)
+			apply(t, user, 20000, "ORDER-1")
+
+			var wg sync.WaitGroup
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					assert.NoError(t, reverse(t, user, "ORDER-1"))
+				}()
+			}
+			wg.Wait()
+
+			assert.Equal(t, 30000, balanceOf(t, user))
+		})
+
+		t.Run("should leave the credit of other orders", func(t *testing.T) {
+			user := newWallet(t, 30000)
+			apply(t, user, 10000, "ORDER-1")
+			apply(t, user, 10000, "ORDER-2")
+
+			require.NoError(t, reverse(t, user, "ORDER-1"))
+			require.NoError(t, reverse(t, user, "ORDER-3"))
+
+			assert.Equal(t, 20000, balanceOf(t, user))
+		})
+	})
+
+	t.Run("CreditProration", func(t *testing.T) {
+		t.Run("should credit the unused days with the plan change", func(t *testing.T) {
+			user := newWallet(t, 5000)
+
+			require.NoError(t, test.DB.Transaction(func(tx *gorm.DB) error {
+				return walletService.CreditProration(context.Background(), tx, user.ID, 12000, "CHANGE-1")
+			}))
+
+			assert.Equal(t, 17000, balanceOf(t, user))
+			var entry model.WalletTransaction
+			require.NoError(t, test.DB.First(&entry, "user_id = ? AND reference = ?", user.ID, "CHANGE-1").Error)
+			assert.Equal(t, model.WalletTypeProration, entry.Type)
+			assert.Equal(t, 17000, entry.BalanceAfter)
+		})
+
+		t.Run("should credit nothing with the plan change when it fails", func(t *testing.T) {
+			user := newWallet(t, 5000)
+
+			err := test.DB.Transaction(func(tx *gorm.DB) error {
+				if err := walletService.CreditProration(context.Background(), tx, user.ID, 12000, "CHANGE-1"); err != nil {
+					return err
+				}
+				return fiber.NewError(fiber.StatusConflict, "plan change failed")
+			})
+
+			assert.Error(t, err)
+			assert.Equal(t, 5000, balanceOf(t, user))
+		})
+
+		t.Run("should credit nothing without unused days", func(t *testing.T) {
+			user := newWallet(t, 0)
+
+			require.NoError(t, test.DB.Transaction(func(tx *gorm.DB) error {
+				return walletService.CreditProration(context.Background(), tx, user.ID, 0, "CHANGE-1")
+			}))
+
+			var entries int64
+			require.NoError(t, test.DB.Model(&model.WalletTransaction{}).Where("user_id = ?", user.ID).Count(&entries).Error)
+			assert.Equal(t, int64(0), entries)
+		})
+	})
+}
@@ -0,0 +1,92 @@
+package integration
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"app/src/validation"
+	"app/test"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookServiceEndpointAddress(t *testing.T) {
+	webhookService := service.NewWebhookService(test.DB, validation.Validator())
+	adminID := uuid.New()
+
+	create := func(t *testing.T, url string) error {
+		return inRequest(t, func(c *fiber.Ctx) error {
+			created, err := webhookService.CreateEndpoint(c, adminID, &validation.CreateWebhookEndpoint{
+				URL: url, Events: []string{"subscription.activated"},
+			})
+			if err == nil {
+				t.Cleanup(func() { test.DB.Delete(&model.WebhookEndpoint{}, "id = ?", created.ID) })
+			}
+			return err
+		})
+	}
+
+	t.Run("should accept a public https endpoint", func(t *testing.T) {
+		assert.NoError(t, create(t, "https://partner.example.com/webhooks"))
+		assert.NoError(t, create(t, "https://93.184.216.34/webhooks"))
+	})
+
+	t.Run("should refuse an endpoint on the internal network", func(t *testing.T) {
+		for _, url := range []string{
+			"https://127.0.0.1/webhooks",
+			"https://[::1]/webhooks",
+			"https://localhost:8443/webhooks",
+			"https://10.0.0.5/webhooks",
+			"https://192.168.1.10/webhooks",
+			"https://169.254.169.254/latest/meta-data",
+			"https://100.64.0.1/webhooks",
+			"https://[fd00::1]/webhooks",
+		} {
+			err := create(t, url)
+			if assert.Error(t, err, url) {
+				assert.Equal(t, fiber.StatusBadRequest, err.(*fiber.Error).Code, url)
+			}
+		}
+	})
+
+	t.Run("should refuse an http endpoint", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusBadRequest, create(t, "http://partner.example.com/webhooks").(*fiber.Error).Code)
+	})
+
+	t.Run("should not deliver to an endpoint whose name resolves to the internal network", func(t *testing.T) {
+		var received atomic.Int32
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			received.Add(1)
+			_, _ = w.Write([]byte("internal secret"))
+		}))
+		t.Cleanup(server.Close)
+
+		// Saved as a name would resolve later, the API refuses the address itself
+		endpoint := &model.WebhookEndpoint{URL: server.URL, Secret: "whsec_test", Events: []string{"subscription.activated"},
+			IsActive: true, CreatedByID: adminID}
+		require.NoError(t, test.DB.Create(endpoint).Error)
+		due := time.Now().Add(-time.Minute)
+		delivery := &model.WebhookDelivery{EndpointID: endpoint.ID, Event: "subscription.activated", EventID: uuid.New(),
+			Payload: "{}", Status: model.WebhookDeliveryPending, NextAttemptAt: &due}
+		require.NoError(t, test.DB.Create(delivery).Error)
+		t.Cleanup(func() {
+			test.DB.Delete(delivery)
+			test.DB.Delete(endpoint)
+		})
+
+		require.NoError(t, webhookService.DeliverDue(context.Background()))
+
+		require.NoError(t, test.DB.First(delivery, "id = ?", delivery.ID).Error)
+		assert.Equal(t, int32(0), received.Load())
+		assert.Contains(t, delivery.LastError, "not a public address")
+		assert.NotContains(t, delivery.LastError, "internal secret")
+	})
+}
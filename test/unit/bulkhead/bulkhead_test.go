@@ -0,0 +1,66 @@
+package bulkhead_test
+
+import (
+	"app/src/bulkhead"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkhead(t *testing.T) {
+	t.Run("should fail fast once every slot is taken", func(t *testing.T) {
+		b := bulkhead.New("Payment gateway", 2, 10*time.Millisecond)
+
+		first, err := b.Acquire(context.Background())
+		assert.NoError(t, err)
+		_, err = b.Acquire(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, b.InUse())
+
+		_, err = b.Acquire(context.Background())
+		var full *bulkhead.FullError
+		assert.True(t, errors.As(err, &full))
+		assert.Equal(t, "Payment gateway", full.Name)
+
+		first()
+		assert.Equal(t, 1, b.InUse())
+	})
+
+	t.Run("should hand a released slot to a waiting call", func(t *testing.T) {
+		b := bulkhead.New("Email", 1, time.Second)
+		release, _ := b.Acquire(context.Background())
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			release()
+		}()
+
+		err := b.Do(context.Background(), func() error { return nil })
+		assert.NoError(t, err)
+		assert.Zero(t, b.InUse())
+	})
+
+	t.Run("should stop waiting when the context ends", func(t *testing.T) {
+		b := bulkhead.New("Food recognition", 1, time.Second)
+		_, _ = b.Acquire(context.Background())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := b.Acquire(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("should not bound a bulkhead without slots", func(t *testing.T) {
+		b := bulkhead.New("Email", 0, 0)
+
+		for i := 0; i < 100; i++ {
+			_, err := b.Acquire(context.Background())
+			assert.NoError(t, err)
+		}
+		assert.Zero(t, b.Size())
+	})
+}
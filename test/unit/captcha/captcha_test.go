@@ -0,0 +1,106 @@
+package captcha_test
+
+import (
+	"app/src/captcha"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// siteverify answers like the provider, and records the form it got
+func siteverify(t *testing.T, status int, body string, form *map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if form != nil {
+			*form = map[string]string{
+				"secret":   r.PostForm.Get("secret"),
+				"response": r.PostForm.Get("response"),
+				"remoteip": r.PostForm.Get("remoteip"),
+			}
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func verifier(t *testing.T, provider, endpoint string) captcha.Verifier {
+	v, err := captcha.New(provider, captcha.Settings{
+		Secret: "secret", Endpoint: endpoint, MinScore: 0.5, Timeout: time.Second,
+	})
+	require.NoError(t, err)
+	return v
+}
+
+func TestNew(t *testing.T) {
+	t.Run("should turn CAPTCHA off without a provider", func(t *testing.T) {
+		v, err := captcha.New("", captcha.Settings{})
+
+		assert.NoError(t, err)
+		assert.Nil(t, v)
+	})
+
+	t.Run("should require the secret of the provider", func(t *testing.T) {
+		_, err := captcha.New(captcha.ProviderTurnstile, captcha.Settings{})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should refuse an unknown provider", func(t *testing.T) {
+		_, err := captcha.New("hcaptcha", captcha.Settings{Secret: "secret"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	t.Run("should accept a token the provider accepts", func(t *testing.T) {
+		var form map[string]string
+		server := siteverify(t, http.StatusOK, `{"success": true}`, &form)
+
+		err := verifier(t, captcha.ProviderTurnstile, server.URL).Verify(context.Background(), "token", "203.0.113.7")
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"secret": "secret", "response": "token", "remoteip": "203.0.113.7"}, form)
+	})
+
+	t.Run("should reject a token the provider rejects", func(t *testing.T) {
+		server := siteverify(t, http.StatusOK, `{"success": false, "error-codes": ["timeout-or-duplicate"]}`, nil)
+
+		err := verifier(t, captcha.ProviderTurnstile, server.URL).Verify(context.Background(), "token", "")
+
+		assert.ErrorIs(t, err, captcha.ErrRejected)
+		assert.ErrorContains(t, err, "timeout-or-duplicate")
+	})
+
+	t.Run("should reject a reCAPTCHA v3 score below the minimum", func(t *testing.T) {
+		server := siteverify(t, http.StatusOK, `{"success": true, "score": 0.3}`, nil)
+
+		err := verifier(t, captcha.ProviderRecaptcha, server.URL).Verify(context.Background(), "token", "")
+
+		assert.ErrorIs(t, err, captcha.ErrRejected)
+	})
+
+	t.Run("should accept a reCAPTCHA v3 score at the minimum", func(t *testing.T) {
+		server := siteverify(t, http.StatusOK, `{"success": true, "score": 0.5}`, nil)
+
+		err := verifier(t, captcha.ProviderRecaptcha, server.URL).Verify(context.Background(), "token", "")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should tell an unavailable provider from a rejected token", func(t *testing.T) {
+		server := siteverify(t, http.StatusInternalServerError, ``, nil)
+
+		err := verifier(t, captcha.ProviderRecaptcha, server.URL).Verify(context.Background(), "token", "")
+
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, captcha.ErrRejected)
+	})
+}
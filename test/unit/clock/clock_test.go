@@ -0,0 +1,49 @@
+package clock_test
+
+import (
+	"app/src/clock"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOverride(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should return the offset to an RFC 3339 time", func(t *testing.T) {
+		offset, err := clock.ParseOverride("2024-03-31T12:00:00Z", now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 30*24*time.Hour, offset)
+	})
+
+	t.Run("should accept a negative duration", func(t *testing.T) {
+		offset, err := clock.ParseOverride("-24h", now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, -24*time.Hour, offset)
+	})
+
+	t.Run("should reject a value that is neither a time nor a duration", func(t *testing.T) {
+		_, err := clock.ParseOverride("next month", now)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestNow(t *testing.T) {
+	t.Run("should use the real clock without an offset", func(t *testing.T) {
+		ctx := context.Background()
+
+		assert.Zero(t, clock.Offset(ctx))
+		assert.WithinDuration(t, time.Now(), clock.Now(ctx), time.Second)
+	})
+
+	t.Run("should shift the time by the offset of the context", func(t *testing.T) {
+		ctx := clock.WithOffset(context.Background(), 720*time.Hour)
+
+		assert.WithinDuration(t, time.Now().Add(720*time.Hour), clock.Now(ctx), time.Second)
+	})
+}
@@ -0,0 +1,35 @@
+package config_test
+
+import (
+	"app/src/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasRights(t *testing.T) {
+	config.SetCustomRoles(map[string][]string{"support": {"getUsers", "getSubscriptions"}})
+	t.Cleanup(func() { config.SetCustomRoles(nil) })
+
+	t.Run("should grant the rights of built-in roles", func(t *testing.T) {
+		assert.True(t, config.HasRights("admin", "getUsers", "updatePaymentStatus"))
+		assert.False(t, config.HasRights("user", "getUsers"))
+	})
+
+	t.Run("should grant every required right of a custom role or none", func(t *testing.T) {
+		assert.True(t, config.HasRights("support", "getUsers"))
+		assert.True(t, config.HasRights("support", "getUsers", "getSubscriptions"))
+		assert.False(t, config.HasRights("support", "getUsers", "updatePaymentStatus"))
+	})
+
+	t.Run("should grant nothing to unknown roles", func(t *testing.T) {
+		assert.False(t, config.HasRights("deleted_role", "getUsers"))
+		assert.False(t, config.HasRights(""))
+	})
+
+	t.Run("should not let custom roles shadow built-in ones", func(t *testing.T) {
+		config.SetCustomRoles(map[string][]string{"user": {"updatePaymentStatus"}})
+
+		assert.False(t, config.HasRights("user", "updatePaymentStatus"))
+	})
+}
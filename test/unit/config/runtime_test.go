@@ -0,0 +1,101 @@
+package config_test
+
+import (
+	"app/src/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lookup returns a runtime flag and puts its value back after the test
+func lookup(t *testing.T, key string) *config.RuntimeFlag {
+	flag, ok := config.LookupRuntimeFlag(key)
+	require.True(t, ok)
+	value := flag.Value()
+	t.Cleanup(func() { require.NoError(t, flag.Apply(value)) })
+	return flag
+}
+
+func TestFlag(t *testing.T) {
+	t.Run("should return the zero value before it is set", func(t *testing.T) {
+		var hours config.Flag[int]
+		var enabled config.Flag[bool]
+
+		assert.Equal(t, 0, hours.Get())
+		assert.False(t, enabled.Get())
+
+		hours.Set(7)
+		assert.Equal(t, 7, hours.Get())
+	})
+}
+
+func TestRuntimeFlagCheck(t *testing.T) {
+	t.Run("should accept whole numbers within the bounds of an int flag", func(t *testing.T) {
+		flag := lookup(t, "ops_bot_report_hour")
+
+		for _, value := range []string{"0", "12", "23"} {
+			assert.NoError(t, flag.Check(value), value)
+		}
+		for _, value := range []string{"-1", "24", "1.5", "", "noon", " 12"} {
+			assert.Error(t, flag.Check(value), value)
+		}
+	})
+
+	t.Run("should name the bounds of an int flag in the error", func(t *testing.T) {
+		flag := lookup(t, "checkout_max_attempts_per_user")
+
+		assert.EqualError(t, flag.Check("0"), "checkout_max_attempts_per_user must be between 1 and 1000")
+		assert.EqualError(t, flag.Check("many"), "checkout_max_attempts_per_user must be a whole number")
+	})
+
+	t.Run("should accept the booleans strconv reads for a bool flag", func(t *testing.T) {
+		flag := lookup(t, "retention_dry_run")
+
+		for _, value := range []string{"true", "false", "1", "0", "TRUE"} {
+			assert.NoError(t, flag.Check(value), value)
+		}
+		for _, value := range []string{"yes", "off", ""} {
+			assert.Error(t, flag.Check(value), value)
+		}
+	})
+}
+
+func TestRuntimeFlagApply(t *testing.T) {
+	t.Run("should set the flag the app reads", func(t *testing.T) {
+		flag := lookup(t, "installment_grace_days")
+
+		require.NoError(t, flag.Apply("14"))
+
+		assert.Equal(t, 14, config.InstallmentGraceDays.Get())
+		assert.Equal(t, "14", flag.Value())
+	})
+
+	t.Run("should keep the value when the new one is invalid", func(t *testing.T) {
+		flag := lookup(t, "media_cleanup_dry_run")
+		require.NoError(t, flag.Apply("false"))
+
+		assert.Error(t, flag.Apply("maybe"))
+
+		assert.False(t, config.MediaCleanupDryRun.Get())
+	})
+}
+
+func TestLookupRuntimeFlag(t *testing.T) {
+	t.Run("should only find the registered flags", func(t *testing.T) {
+		_, ok := config.LookupRuntimeFlag("daily_tip_hour")
+		assert.True(t, ok)
+
+		_, ok = config.LookupRuntimeFlag("db_password")
+		assert.False(t, ok)
+	})
+
+	t.Run("should register every flag once with its environment value as default", func(t *testing.T) {
+		keys := map[string]bool{}
+		for _, flag := range config.RuntimeFlags {
+			assert.False(t, keys[flag.Key], flag.Key)
+			keys[flag.Key] = true
+			assert.NoError(t, flag.Check(flag.Default), flag.Key)
+		}
+	})
+}
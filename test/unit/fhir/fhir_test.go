@@ -0,0 +1,79 @@
+package fhir_test
+
+import (
+	"app/src/fhir"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVitalSigns(t *testing.T) {
+	patient := fhir.Patient("b5f2c1de-0000-4000-8000-000000000001")
+	at := time.Date(2026, 10, 1, 8, 30, 0, 0, time.FixedZone("WIB", 7*3600))
+
+	t.Run("should code a weight in kilograms with LOINC", func(t *testing.T) {
+		weight := fhir.BodyWeight("weight-1", patient, at, 70.456)
+
+		assert.Equal(t, "Observation", weight.ResourceType)
+		assert.Equal(t, "final", weight.Status)
+		assert.Equal(t, fhir.CategoryVitalSigns, weight.Category[0].Coding[0].Code)
+		assert.Equal(t, fhir.SystemLOINC, weight.Code.Coding[0].System)
+		assert.Equal(t, fhir.LOINCBodyWeight, weight.Code.Coding[0].Code)
+		assert.Equal(t, "Patient/b5f2c1de-0000-4000-8000-000000000001", weight.Subject.Reference)
+		assert.Equal(t, "2026-10-01T08:30:00+07:00", weight.EffectiveDateTime)
+		assert.Equal(t, fhir.Quantity{Value: 70.46, Unit: "kg", System: fhir.SystemUCUM, Code: "kg"}, *weight.ValueQuantity)
+	})
+
+	t.Run("should code height in centimetres and BMI in kg/m2", func(t *testing.T) {
+		assert.Equal(t, "cm", fhir.BodyHeight("height-1", patient, at, 175).ValueQuantity.Code)
+		bmi := fhir.BMI("bmi-1", patient, at, 22.9)
+		assert.Equal(t, fhir.LOINCBMI, bmi.Code.Coding[0].Code)
+		assert.Equal(t, "kg/m2", bmi.ValueQuantity.Code)
+	})
+}
+
+func TestDailyIntake(t *testing.T) {
+	day := time.Date(2026, 10, 1, 15, 0, 0, 0, time.FixedZone("WIB", 7*3600))
+	intake := fhir.DailyIntake("intake-20261001-1", fhir.Patient("1"), day, 1850.5, 70.25, 240, 60.123)
+
+	assert.Equal(t, fhir.CategorySurvey, intake.Category[0].Coding[0].Code)
+	assert.Equal(t, fhir.LOINCEnergyTotal, intake.Code.Coding[0].Code)
+	assert.Equal(t, 1850.5, intake.ValueQuantity.Value)
+	assert.Equal(t, "kcal", intake.ValueQuantity.Code)
+	assert.Empty(t, intake.EffectiveDateTime)
+	assert.Equal(t, &fhir.Period{Start: "2026-10-01T00:00:00+07:00", End: "2026-10-01T23:59:59+07:00"}, intake.EffectivePeriod)
+
+	if assert.Len(t, intake.Component, 3) {
+		assert.Equal(t, "protein", intake.Component[0].Code.Coding[0].Code)
+		assert.Equal(t, 70.25, intake.Component[0].ValueQuantity.Value)
+		assert.Equal(t, "g", intake.Component[2].ValueQuantity.Code)
+		assert.Equal(t, 60.12, intake.Component[2].ValueQuantity.Value)
+	}
+}
+
+func TestSearchSet(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	t.Run("should bundle observations as a searchset", func(t *testing.T) {
+		patient := fhir.Patient("1")
+		bundle := fhir.SearchSet([]fhir.Observation{
+			fhir.BodyWeight("weight-1", patient, now, 70),
+			fhir.BodyHeight("height-1", patient, now, 175),
+		}, now)
+
+		assert.Equal(t, "Bundle", bundle.ResourceType)
+		assert.Equal(t, "searchset", bundle.Type)
+		assert.Equal(t, 2, bundle.Total)
+		assert.Equal(t, "height-1", bundle.Entry[1].Resource.ID)
+		assert.Equal(t, "2026-10-16T09:00:00Z", bundle.Timestamp)
+	})
+
+	t.Run("should leave entry out when there is nothing to return", func(t *testing.T) {
+		out, err := json.Marshal(fhir.SearchSet([]fhir.Observation{}, now))
+		assert.NoError(t, err)
+		assert.NotContains(t, string(out), `"entry"`)
+		assert.Contains(t, string(out), `"total":0`)
+	})
+}
@@ -0,0 +1,140 @@
+package geoip_test
+
+import (
+	"app/src/geoip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lookupServer answers like the lookup service, and records the path with the query and the authorization it got
+func lookupServer(t *testing.T, status int, body string, path, authorization *string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if path != nil {
+			*path = r.URL.RequestURI()
+		}
+		if authorization != nil {
+			*authorization = r.Header.Get("Authorization")
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func locator(t *testing.T, provider, endpoint, token string) geoip.Locator {
+	l, err := geoip.New(provider, geoip.Settings{Token: token, Endpoint: endpoint, Timeout: time.Second})
+	require.NoError(t, err)
+	return l
+}
+
+func TestNew(t *testing.T) {
+	t.Run("should only read the headers without a provider", func(t *testing.T) {
+		l, err := geoip.New("", geoip.Settings{})
+
+		assert.NoError(t, err)
+		assert.Nil(t, l)
+	})
+
+	t.Run("should reject an unknown provider", func(t *testing.T) {
+		_, err := geoip.New("maxmind", geoip.Settings{})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should require the address in the endpoint", func(t *testing.T) {
+		_, err := geoip.New(geoip.ProviderIPinfo, geoip.Settings{Endpoint: "https://geo.example.com/lookup"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should refuse plain HTTP off the loopback", func(t *testing.T) {
+		_, err := geoip.New(geoip.ProviderIPAPI, geoip.Settings{Endpoint: "http://ip-api.com/json/{ip}"})
+		assert.Error(t, err)
+
+		_, err = geoip.New(geoip.ProviderIPAPI, geoip.Settings{Endpoint: "http://127.0.0.1:8080/json/{ip}"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should require the key of the ip-api HTTPS service", func(t *testing.T) {
+		_, err := geoip.New(geoip.ProviderIPAPI, geoip.Settings{})
+		assert.Error(t, err)
+
+		l, err := geoip.New(geoip.ProviderIPAPI, geoip.Settings{Token: "key"})
+		assert.NoError(t, err)
+		assert.NotNil(t, l)
+	})
+}
+
+func TestLocate(t *testing.T) {
+	t.Run("should read an ipinfo answer", func(t *testing.T) {
+		var path, authorization string
+		server := lookupServer(t, http.StatusOK,
+			`{"ip":"36.68.1.1","city":"Bandung","region":"West Java","country":"ID","loc":"-6.9,107.6"}`, &path, &authorization)
+
+		location, err := locator(t, geoip.ProviderIPinfo, server.URL+"/{ip}/json", "token").Locate(context.Background(), "36.68.1.1")
+
+		require.NoError(t, err)
+		assert.Equal(t, geoip.Location{Country: "ID", Region: "West Java", City: "Bandung"}, location)
+		assert.Equal(t, "/36.68.1.1/json", path)
+		assert.Equal(t, "Bearer token", authorization)
+	})
+
+	t.Run("should read an ip-api answer", func(t *testing.T) {
+		var path, authorization string
+		server := lookupServer(t, http.StatusOK,
+			`{"status":"success","countryCode":"SG","regionName":"Central Singapore","city":"Singapore"}`, &path, &authorization)
+
+		location, err := locator(t, geoip.ProviderIPAPI, server.URL+"/json/{ip}?key={token}", "a&b").Locate(context.Background(), "8.8.8.8")
+
+		require.NoError(t, err)
+		assert.Equal(t, geoip.Location{Country: "SG", Region: "Central Singapore", City: "Singapore"}, location)
+		assert.Equal(t, "/json/8.8.8.8?key=a%26b", path)
+		assert.Empty(t, authorization)
+	})
+
+	t.Run("should leave addresses the service cannot locate unknown", func(t *testing.T) {
+		failed := lookupServer(t, http.StatusOK, `{"status":"fail","message":"reserved range"}`, nil, nil)
+		bogon := lookupServer(t, http.StatusOK, `{"ip":"10.0.0.1","bogon":true}`, nil, nil)
+
+		location, err := locator(t, geoip.ProviderIPAPI, failed.URL+"/{ip}", "").Locate(context.Background(), "1.1.1.1")
+		require.NoError(t, err)
+		assert.False(t, location.Known())
+
+		location, err = locator(t, geoip.ProviderIPinfo, bogon.URL+"/{ip}", "").Locate(context.Background(), "1.1.1.1")
+		require.NoError(t, err)
+		assert.False(t, location.Known())
+	})
+
+	t.Run("should fail when the service answers with an error", func(t *testing.T) {
+		server := lookupServer(t, http.StatusTooManyRequests, `{"error":"rate limited"}`, nil, nil)
+
+		_, err := locator(t, geoip.ProviderIPinfo, server.URL+"/{ip}", "").Locate(context.Background(), "1.1.1.1")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestNormalizeCountry(t *testing.T) {
+	assert.Equal(t, "ID", geoip.NormalizeCountry(" id "))
+	assert.Equal(t, "", geoip.NormalizeCountry("XX"))
+	assert.Equal(t, "", geoip.NormalizeCountry("T1"))
+	assert.Equal(t, "", geoip.NormalizeCountry("IDN"))
+	assert.Equal(t, "", geoip.NormalizeCountry(""))
+}
+
+func TestIsPublic(t *testing.T) {
+	assert.True(t, geoip.IsPublic("36.68.1.1"))
+	assert.True(t, geoip.IsPublic("2001:4860:4860::8888"))
+	assert.False(t, geoip.IsPublic("10.0.0.1"))
+	assert.False(t, geoip.IsPublic("192.168.1.10"))
+	assert.False(t, geoip.IsPublic("127.0.0.1"))
+	assert.False(t, geoip.IsPublic("fe80::1"))
+	assert.False(t, geoip.IsPublic("not an address"))
+}
@@ -0,0 +1,338 @@
+package iap_test
+
+import (
+	"app/src/iap"
+	"app/src/model"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bundleID = "com.nutriteam.app"
+
+// roundTripFunc answers the requests of a client in place of the store
+type roundTripFunc func(r *http.Request) *http.Response
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r), nil
+}
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// signer is a certificate authority standing in for Apple, with a leaf certificate signing the notifications
+type signer struct {
+	root    *x509.Certificate
+	leaf    *x509.Certificate
+	leafKey *ecdsa.PrivateKey
+}
+
+func newSigner(t *testing.T) *signer {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	require.NoError(t, err)
+	root, err := x509.ParseCertificate(rootDER)
+	require.NoError(t, err)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test Signing"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, root, &leafKey.PublicKey, rootKey)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(leafDER)
+	require.NoError(t, err)
+
+	return &signer{root: root, leaf: leaf, leafKey: leafKey}
+}
+
+// sign signs claims as a JWS with the certificate chain in its x5c header
+func (s *signer) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["x5c"] = []string{
+		base64.StdEncoding.EncodeToString(s.leaf.Raw),
+		base64.StdEncoding.EncodeToString(s.root.Raw),
+	}
+	signed, err := token.SignedString(s.leafKey)
+	require.NoError(t, err)
+	return signed
+}
+
+// appleClient creates an App Store client trusting root, its receipt requests are answered by store
+func appleClient(t *testing.T, root *x509.Certificate, store roundTripFunc) *iap.AppleClient {
+	rootPath := filepath.Join(t.TempDir(), "AppleRootCA-G3.pem")
+	require.NoError(t, os.WriteFile(rootPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw}), 0644))
+	client, err := iap.NewAppleClient("secret", bundleID, rootPath)
+	require.NoError(t, err)
+	client.HTTP = &http.Client{Transport: store}
+	return client
+}
+
+func TestAppleClientVerifyReceipt(t *testing.T) {
+	expires := time.Now().Add(24 * time.Hour).UnixMilli()
+	verified := func(environment, bundle string) string {
+		body, _ := json.Marshal(map[string]interface{}{
+			"status":      0,
+			"environment": environment,
+			"receipt":     map[string]string{"bundle_id": bundle},
+			"latest_receipt_info": []map[string]string{
+				{"product_id": "premium_monthly", "transaction_id": "1001", "original_transaction_id": "1000",
+					"expires_date_ms": "1000"},
+				{"product_id": "premium_monthly", "transaction_id": "1002", "original_transaction_id": "1000",
+					"expires_date_ms": strconv.FormatInt(expires, 10)},
+			},
+			"pending_renewal_info": []map[string]string{{"original_transaction_id": "1000", "auto_renew_status": "1"}},
+		})
+		return string(body)
+	}
+
+	t.Run("should return the latest period of a production receipt", func(t *testing.T) {
+		var hosts []string
+		client := appleClient(t, newSigner(t).root, func(r *http.Request) *http.Response {
+			hosts = append(hosts, r.URL.Host)
+			return jsonResponse(http.StatusOK, verified("Production", bundleID))
+		})
+
+		receipt, err := client.VerifyReceipt(context.Background(), "receipt")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"buy.itunes.apple.com"}, hosts)
+		assert.Equal(t, model.StoreApple, receipt.Store)
+		assert.Equal(t, "1002", receipt.TransactionID)
+		assert.Equal(t, "1000", receipt.OriginalTransactionID)
+		assert.True(t, receipt.Active)
+		assert.True(t, receipt.AutoRenew)
+		assert.False(t, receipt.IsSandbox())
+	})
+
+	t.Run("should validate a sandbox receipt against the sandbox and tell it is one", func(t *testing.T) {
+		var hosts []string
+		client := appleClient(t, newSigner(t).root, func(r *http.Request) *http.Response {
+			hosts = append(hosts, r.URL.Host)
+			if r.URL.Host == "buy.itunes.apple.com" {
+				return jsonResponse(http.StatusOK, `{"status":21007}`)
+			}
+			return jsonResponse(http.StatusOK, verified("Sandbox", bundleID))
+		})
+
+		receipt, err := client.VerifyReceipt(context.Background(), "receipt")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"buy.itunes.apple.com", "sandbox.itunes.apple.com"}, hosts)
+		assert.True(t, receipt.IsSandbox())
+	})
+
+	t.Run("should reject the receipt of another app", func(t *testing.T) {
+		client := appleClient(t, newSigner(t).root, func(r *http.Request) *http.Response {
+			return jsonResponse(http.StatusOK, verified("Production", "com.example.other"))
+		})
+
+		_, err := client.VerifyReceipt(context.Background(), "receipt")
+
+		assert.ErrorContains(t, err, "another app")
+	})
+
+	t.Run("should reject a receipt apple refused", func(t *testing.T) {
+		client := appleClient(t, newSigner(t).root, func(r *http.Request) *http.Response {
+			return jsonResponse(http.StatusOK, `{"status":21003}`)
+		})
+
+		_, err := client.VerifyReceipt(context.Background(), "receipt")
+
+		assert.ErrorContains(t, err, "21003")
+	})
+}
+
+func TestAppleClientParseNotification(t *testing.T) {
+	apple := newSigner(t)
+	client := appleClient(t, apple.root, nil)
+	expires := time.Now().Add(24 * time.Hour).UnixMilli()
+
+	// notification signs a renewal notification of bundle with s, its transaction and renewal info too
+	notification := func(t *testing.T, s *signer, bundle, environment string) string {
+		return s.sign(t, jwt.MapClaims{
+			"notificationType": "DID_RENEW",
+			"data": map[string]interface{}{
+				"bundleId":    bundle,
+				"environment": environment,
+				"signedTransactionInfo": s.sign(t, jwt.MapClaims{
+					"originalTransactionId": "1000", "transactionId": "1003", "productId": "premium_monthly",
+					"bundleId": bundle, "expiresDate": expires,
+				}),
+				"signedRenewalInfo": s.sign(t, jwt.MapClaims{"autoRenewStatus": 1}),
+			},
+		})
+	}
+
+	t.Run("should decode a notification signed through the trusted root", func(t *testing.T) {
+		parsed, err := client.ParseNotification(notification(t, apple, bundleID, "Production"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "DID_RENEW", parsed.NotificationType)
+		require.NotNil(t, parsed.Receipt)
+		assert.Equal(t, "1003", parsed.Receipt.TransactionID)
+		assert.Equal(t, "1000", parsed.Receipt.OriginalTransactionID)
+		assert.True(t, parsed.Receipt.Active)
+		assert.True(t, parsed.Receipt.AutoRenew)
+		assert.False(t, parsed.Receipt.IsSandbox())
+	})
+
+	t.Run("should tell a sandbox notification", func(t *testing.T) {
+		parsed, err := client.ParseNotification(notification(t, apple, bundleID, "Sandbox"))
+
+		require.NoError(t, err)
+		assert.True(t, parsed.Receipt.IsSandbox())
+	})
+
+	t.Run("should reject a notification signed through another root", func(t *testing.T) {
+		_, err := client.ParseNotification(notification(t, newSigner(t), bundleID, "Production"))
+
+		assert.ErrorContains(t, err, "untrusted signing certificate")
+	})
+
+	t.Run("should reject a notification without its certificate chain", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"notificationType": "TEST"})
+		signed, err := token.SignedString(apple.leafKey)
+		require.NoError(t, err)
+
+		_, err = client.ParseNotification(signed)
+
+		assert.ErrorContains(t, err, "missing x5c header")
+	})
+
+	t.Run("should reject a notification of another app", func(t *testing.T) {
+		_, err := client.ParseNotification(notification(t, apple, "com.example.other", "Production"))
+
+		assert.ErrorContains(t, err, "another app")
+	})
+
+	t.Run("should reject every notification without a root certificate", func(t *testing.T) {
+		unconfigured, err := iap.NewAppleClient("secret", bundleID, "")
+		require.NoError(t, err)
+
+		_, err = unconfigured.ParseNotification(notification(t, apple, bundleID, "Production"))
+
+		assert.ErrorContains(t, err, "not configured")
+	})
+}
+
+func TestGoogleClientGetSubscription(t *testing.T) {
+	expiry := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	subscription := func(extra string) string {
+		return `{"subscriptionState":"SUBSCRIPTION_STATE_ACTIVE","latestOrderId":"GPA.1234",` +
+			`"acknowledgementState":"ACKNOWLEDGEMENT_STATE_PENDING",` + extra +
+			`"lineItems":[{"productId":"premium_monthly","expiryTime":"` + expiry + `","autoRenewingPlan":{"autoRenewEnabled":true}}]}`
+	}
+	googleClient := func(body string, paths *[]string) *iap.GoogleClient {
+		return &iap.GoogleClient{PackageName: bundleID, HTTP: &http.Client{Transport: roundTripFunc(func(r *http.Request) *http.Response {
+			*paths = append(*paths, r.URL.EscapedPath())
+			return jsonResponse(http.StatusOK, body)
+		})}}
+	}
+
+	t.Run("should look the purchase token up for the package", func(t *testing.T) {
+		var paths []string
+		client := googleClient(subscription(""), &paths)
+
+		receipt, needsAck, err := client.GetSubscription(context.Background(), "token/with spaces")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/androidpublisher/v3/applications/" + bundleID + "/purchases/subscriptionsv2/tokens/token%2Fwith%20spaces"}, paths)
+		assert.Equal(t, model.StoreGoogle, receipt.Store)
+		assert.Equal(t, "token/with spaces", receipt.OriginalTransactionID)
+		assert.Equal(t, "GPA.1234", receipt.TransactionID)
+		assert.True(t, receipt.Active)
+		assert.True(t, receipt.AutoRenew)
+		assert.True(t, needsAck)
+		assert.False(t, receipt.IsSandbox())
+	})
+
+	t.Run("should tell a test purchase", func(t *testing.T) {
+		var paths []string
+		client := googleClient(subscription(`"testPurchase":{},`), &paths)
+
+		receipt, _, err := client.GetSubscription(context.Background(), "token")
+
+		require.NoError(t, err)
+		assert.True(t, receipt.IsSandbox())
+	})
+
+	t.Run("should fail on a token google does not know", func(t *testing.T) {
+		client := &iap.GoogleClient{PackageName: bundleID, HTTP: &http.Client{Transport: roundTripFunc(func(r *http.Request) *http.Response {
+			return jsonResponse(http.StatusNotFound, `{"error":{"message":"not found"}}`)
+		})}}
+
+		_, _, err := client.GetSubscription(context.Background(), "token")
+
+		assert.ErrorContains(t, err, "status 404")
+	})
+}
+
+func TestParseGoogleNotification(t *testing.T) {
+	envelope := func(data string) []byte {
+		return []byte(`{"message":{"data":"` + base64.StdEncoding.EncodeToString([]byte(data)) + `"}}`)
+	}
+
+	t.Run("should decode a subscription notification", func(t *testing.T) {
+		notification, err := iap.ParseGoogleNotification(envelope(`{"packageName":"` + bundleID + `",` +
+			`"subscriptionNotification":{"notificationType":12,"purchaseToken":"token","subscriptionId":"premium_monthly"}}`))
+
+		require.NoError(t, err)
+		assert.Equal(t, bundleID, notification.PackageName)
+		assert.Equal(t, iap.GoogleNotificationRevoked, notification.NotificationType)
+		assert.Equal(t, "token", notification.PurchaseToken)
+		assert.False(t, notification.IsTest)
+	})
+
+	t.Run("should tell a test notification", func(t *testing.T) {
+		notification, err := iap.ParseGoogleNotification(envelope(`{"packageName":"` + bundleID + `","testNotification":{"version":"1.0"}}`))
+
+		require.NoError(t, err)
+		assert.True(t, notification.IsTest)
+	})
+
+	t.Run("should reject a message that is not base64", func(t *testing.T) {
+		_, err := iap.ParseGoogleNotification([]byte(`{"message":{"data":"%%%"}}`))
+
+		assert.Error(t, err)
+	})
+}
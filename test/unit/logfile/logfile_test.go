@@ -0,0 +1,119 @@
+package logfile_test
+
+import (
+	"app/src/logfile"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clock is a clock the test moves by hand
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func read(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestFile(t *testing.T) {
+	t.Run("should move the lines of a day aside on the first write of the next", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "activity.log")
+		c := &clock{now: time.Date(2026, 10, 15, 23, 59, 0, 0, time.Local)}
+		file, err := logfile.Open(path, c.Now)
+		require.NoError(t, err)
+		t.Cleanup(func() { file.Close() })
+
+		_, err = file.Write([]byte("first\n"))
+		require.NoError(t, err)
+		c.now = c.now.Add(2 * time.Minute)
+		_, err = file.Write([]byte("second\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "first\n", read(t, filepath.Join(filepath.Dir(path), "activity-2026-10-15.log")))
+		assert.Equal(t, "second\n", read(t, path))
+	})
+
+	t.Run("should roll over a file left from an earlier day when opened", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "request.log")
+		require.NoError(t, os.WriteFile(path, []byte("old\n"), 0644))
+		yesterday := time.Now().AddDate(0, 0, -1)
+		require.NoError(t, os.Chtimes(path, yesterday, yesterday))
+
+		file, err := logfile.Open(path, time.Now)
+		require.NoError(t, err)
+		t.Cleanup(func() { file.Close() })
+
+		rolled := filepath.Join(filepath.Dir(path), "request-"+yesterday.Format("2006-01-02")+".log")
+		assert.Equal(t, "old\n", read(t, rolled))
+		assert.Empty(t, read(t, path))
+	})
+
+	t.Run("should keep appending to a file of today", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "request.log")
+		require.NoError(t, os.WriteFile(path, []byte("earlier\n"), 0644))
+
+		file, err := logfile.Open(path, time.Now)
+		require.NoError(t, err)
+		t.Cleanup(func() { file.Close() })
+		_, err = file.Write([]byte("later\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "earlier\nlater\n", read(t, path))
+	})
+
+	t.Run("should not replace a day rolled over before", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "activity.log")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "activity-2026-10-15.log"), []byte("first run\n"), 0644))
+		c := &clock{now: time.Date(2026, 10, 15, 12, 0, 0, 0, time.Local)}
+		file, err := logfile.Open(path, c.Now)
+		require.NoError(t, err)
+		t.Cleanup(func() { file.Close() })
+
+		_, err = file.Write([]byte("second run\n"))
+		require.NoError(t, err)
+		c.now = c.now.AddDate(0, 0, 1)
+		_, err = file.Write([]byte("next day\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "first run\n", read(t, filepath.Join(dir, "activity-2026-10-15.log")))
+		assert.Equal(t, "second run\n", read(t, filepath.Join(dir, "activity-2026-10-15.1.log")))
+	})
+}
+
+func TestExpired(t *testing.T) {
+	t.Run("should list the days of the file before the cutoff", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, name := range []string{
+			"activity.log", "activity-2026-07-01.log", "activity-2026-07-01.1.log", "activity-2026-07-17.log",
+			"activity-2026-07-18.log", "request-2026-07-01.log", "activity-notes.log",
+		} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+		}
+
+		expired, err := logfile.Expired(filepath.Join(dir, "activity.log"), time.Date(2026, 7, 18, 8, 0, 0, 0, time.Local))
+
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{
+			filepath.Join(dir, "activity-2026-07-01.log"),
+			filepath.Join(dir, "activity-2026-07-01.1.log"),
+			filepath.Join(dir, "activity-2026-07-17.log"),
+		}, expired)
+	})
+
+	t.Run("should list nothing without the directory", func(t *testing.T) {
+		expired, err := logfile.Expired(filepath.Join(t.TempDir(), "missing", "activity.log"), time.Now())
+
+		assert.NoError(t, err)
+		assert.Empty(t, expired)
+	})
+}
@@ -0,0 +1,110 @@
+package mailer_test
+
+import (
+	"app/src/mailer"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSES(t *testing.T, handler http.HandlerFunc) mailer.Sender {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	sender, err := mailer.New(mailer.ProviderSES, mailer.Settings{
+		SESRegion:    "ap-southeast-1",
+		SESAccessKey: "AKIDEXAMPLE",
+		SESSecretKey: "secret",
+		SESEndpoint:  server.URL,
+	})
+	assert.NoError(t, err)
+	return sender
+}
+
+var message = &mailer.Message{
+	From:    "support@nutribox.id",
+	To:      "user@example.com",
+	Subject: "Hello",
+	Body:    "Hi there",
+	Headers: map[string]string{"List-Unsubscribe": "<https://nutribox.id/unsubscribe>"},
+}
+
+func TestSESSend(t *testing.T) {
+	t.Run("should send the raw message signed", func(t *testing.T) {
+		sender := newSES(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v2/email/outbound-emails", r.URL.Path)
+			assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"),
+				"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"))
+			assert.Contains(t, r.Header.Get("Authorization"), "/ap-southeast-1/ses/aws4_request")
+
+			var body struct {
+				FromEmailAddress string
+				Destination      struct{ ToAddresses []string }
+				Content          struct{ Raw struct{ Data string } }
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			raw, err := base64.StdEncoding.DecodeString(body.Content.Raw.Data)
+			assert.NoError(t, err)
+
+			assert.Equal(t, "support@nutribox.id", body.FromEmailAddress)
+			assert.Equal(t, []string{"user@example.com"}, body.Destination.ToAddresses)
+			assert.Contains(t, string(raw), "List-Unsubscribe: <https://nutribox.id/unsubscribe>")
+			assert.Contains(t, string(raw), "Hi there")
+			w.Write([]byte(`{"MessageId":"1"}`))
+		})
+
+		assert.NoError(t, sender.Send(context.Background(), message))
+	})
+
+	t.Run("should report a rejected message as a failure of the provider", func(t *testing.T) {
+		sender := newSES(t, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("X-Amzn-Errortype", "MessageRejected:http://internal.amazon.com/coral/")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"Email address is not verified."}`))
+		})
+
+		err := sender.Send(context.Background(), message)
+
+		assert.False(t, mailer.IsBounce(err))
+		assert.EqualError(t, err, "ses: 400 MessageRejected Email address is not verified.")
+	})
+
+	t.Run("should report throttling as a failure of the provider", func(t *testing.T) {
+		sender := newSES(t, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("X-Amzn-Errortype", "TooManyRequestsException")
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+
+		err := sender.Send(context.Background(), message)
+
+		var sendErr *mailer.Error
+		assert.True(t, errors.As(err, &sendErr))
+		assert.Equal(t, http.StatusTooManyRequests, sendErr.Code)
+		assert.False(t, mailer.IsBounce(err))
+	})
+}
+
+func TestNew(t *testing.T) {
+	t.Run("should leave unconfigured providers out", func(t *testing.T) {
+		smtp, err := mailer.New(mailer.ProviderSMTP, mailer.Settings{})
+		assert.NoError(t, err)
+		assert.Nil(t, smtp)
+
+		ses, err := mailer.New(mailer.ProviderSES, mailer.Settings{})
+		assert.NoError(t, err)
+		assert.Nil(t, ses)
+	})
+
+	t.Run("should refuse unknown providers", func(t *testing.T) {
+		_, err := mailer.New("pigeon", mailer.Settings{})
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,171 @@
+package mailer_test
+
+import (
+	"app/src/mailer"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const certURL = "https://sns.ap-southeast-1.amazonaws.com/SimpleNotificationService-test.pem"
+
+// certTransport serves the signing certificate whatever the request, and counts the requests
+type certTransport struct {
+	cert     []byte
+	requests int
+}
+
+func (t *certTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	t.requests++
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(string(t.cert)))}, nil
+}
+
+func newSigner(t *testing.T) (*rsa.PrivateKey, *certTransport) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	return key, &certTransport{cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, message *mailer.SNSMessage, signed string) {
+	sum := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	assert.NoError(t, err)
+	message.SignatureVersion = "2"
+	message.Signature = base64.StdEncoding.EncodeToString(signature)
+}
+
+func notification() *mailer.SNSMessage {
+	return &mailer.SNSMessage{
+		Type:           mailer.SNSNotification,
+		MessageID:      "22b80b92-fdea-4c2c-8f9d-bdfb0c7bf324",
+		TopicArn:       "arn:aws:sns:ap-southeast-1:123456789012:ses-feedback",
+		Message:        `{"notificationType":"Bounce"}`,
+		Timestamp:      "2026-10-16T08:00:00.000Z",
+		SigningCertURL: certURL,
+	}
+}
+
+func TestSNSVerify(t *testing.T) {
+	t.Run("should accept a notification signed by SNS and fetch the certificate once", func(t *testing.T) {
+		key, transport := newSigner(t)
+		verifier := mailer.NewSNSVerifier(&http.Client{Transport: transport})
+		message := notification()
+		sign(t, key, message, "Message\n"+message.Message+"\nMessageId\n"+message.MessageID+
+			"\nTimestamp\n"+message.Timestamp+"\nTopicArn\n"+message.TopicArn+"\nType\nNotification\n")
+
+		assert.NoError(t, verifier.Verify(context.Background(), message))
+		assert.NoError(t, verifier.Verify(context.Background(), message))
+		assert.Equal(t, 1, transport.requests)
+	})
+
+	t.Run("should sign the subscription link of a confirmation", func(t *testing.T) {
+		key, transport := newSigner(t)
+		verifier := mailer.NewSNSVerifier(&http.Client{Transport: transport})
+		message := notification()
+		message.Type = mailer.SNSSubscriptionConfirmation
+		message.Token = "token"
+		message.SubscribeURL = "https://sns.ap-southeast-1.amazonaws.com/?Action=ConfirmSubscription&Token=token"
+		sign(t, key, message, "Message\n"+message.Message+"\nMessageId\n"+message.MessageID+
+			"\nSubscribeURL\n"+message.SubscribeURL+"\nTimestamp\n"+message.Timestamp+"\nToken\ntoken"+
+			"\nTopicArn\n"+message.TopicArn+"\nType\nSubscriptionConfirmation\n")
+
+		assert.NoError(t, verifier.Verify(context.Background(), message))
+	})
+
+	t.Run("should reject a tampered message", func(t *testing.T) {
+		key, transport := newSigner(t)
+		verifier := mailer.NewSNSVerifier(&http.Client{Transport: transport})
+		message := notification()
+		sign(t, key, message, "Message\n"+message.Message+"\nMessageId\n"+message.MessageID+
+			"\nTimestamp\n"+message.Timestamp+"\nTopicArn\n"+message.TopicArn+"\nType\nNotification\n")
+		message.Message = `{"notificationType":"Complaint"}`
+
+		assert.Error(t, verifier.Verify(context.Background(), message))
+	})
+
+	t.Run("should not fetch a certificate outside of SNS", func(t *testing.T) {
+		key, transport := newSigner(t)
+		verifier := mailer.NewSNSVerifier(&http.Client{Transport: transport})
+		for _, link := range []string{
+			"http://sns.ap-southeast-1.amazonaws.com/cert.pem",
+			"https://sns.ap-southeast-1.amazonaws.com.evil.example/cert.pem",
+			"https://sns.ap-southeast-1.amazonaws.com/cert.txt",
+		} {
+			message := notification()
+			message.SigningCertURL = link
+			sign(t, key, message, "unused")
+
+			assert.Error(t, verifier.Verify(context.Background(), message), link)
+		}
+		assert.Equal(t, 0, transport.requests)
+	})
+}
+
+func TestParseSESNotification(t *testing.T) {
+	t.Run("should read the recipients of a bounce", func(t *testing.T) {
+		feedback, err := mailer.ParseSESNotification(`{
+			"notificationType": "Bounce",
+			"bounce": {
+				"bounceType": "Permanent",
+				"bounceSubType": "General",
+				"timestamp": "2026-10-16T08:00:00.000Z",
+				"bouncedRecipients": [{"emailAddress": "Gone@example.com", "diagnosticCode": "smtp; 550 5.1.1 user unknown"}]
+			}
+		}`)
+
+		assert.NoError(t, err)
+		assert.Len(t, feedback, 1)
+		assert.Equal(t, "Gone@example.com", feedback[0].Email)
+		assert.Equal(t, mailer.FeedbackBounce, feedback[0].Kind)
+		assert.True(t, feedback[0].Permanent)
+		assert.Equal(t, "Permanent General: smtp; 550 5.1.1 user unknown", feedback[0].Detail)
+	})
+
+	t.Run("should keep transient bounces apart", func(t *testing.T) {
+		feedback, err := mailer.ParseSESNotification(`{"eventType": "Bounce", "bounce": {"bounceType": "Transient",
+			"bounceSubType": "MailboxFull", "bouncedRecipients": [{"emailAddress": "full@example.com"}]}}`)
+
+		assert.NoError(t, err)
+		assert.Len(t, feedback, 1)
+		assert.False(t, feedback[0].Permanent)
+	})
+
+	t.Run("should read complaints as permanent", func(t *testing.T) {
+		feedback, err := mailer.ParseSESNotification(`{"notificationType": "Complaint", "complaint": {
+			"complaintFeedbackType": "abuse", "complainedRecipients": [{"emailAddress": "angry@example.com"}]}}`)
+
+		assert.NoError(t, err)
+		assert.Len(t, feedback, 1)
+		assert.Equal(t, mailer.FeedbackComplaint, feedback[0].Kind)
+		assert.True(t, feedback[0].Permanent)
+	})
+
+	t.Run("should have no feedback for deliveries", func(t *testing.T) {
+		feedback, err := mailer.ParseSESNotification(`{"notificationType": "Delivery"}`)
+
+		assert.NoError(t, err)
+		assert.Empty(t, feedback)
+	})
+}
@@ -0,0 +1,156 @@
+package media_test
+
+import (
+	"app/src/media"
+	"app/src/model"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSigner() *media.Signer {
+	return media.New("https://cdn.nutribox.id/", "secret", 3, map[string]time.Duration{
+		media.KindScan:   time.Hour,
+		media.KindAvatar: 24 * time.Hour,
+	}, []string{"https://api.nutribox.id"})
+}
+
+func parse(t *testing.T, signed string) (string, url.Values) {
+	parsed, err := url.Parse(signed)
+	assert.NoError(t, err)
+	return parsed.Path, parsed.Query()
+}
+
+func TestSignerSign(t *testing.T) {
+	signer := newSigner()
+	now := time.Date(2026, 10, 16, 10, 20, 0, 0, time.UTC)
+
+	t.Run("should sign a stored path for the CDN", func(t *testing.T) {
+		signed := signer.Sign(media.KindScan, "/uploads/scans/a.jpg", now)
+		path, query := parse(t, signed)
+
+		assert.True(t, strings.HasPrefix(signed, "https://cdn.nutribox.id/uploads/scans/a.jpg?"))
+		assert.Equal(t, "/uploads/scans/a.jpg", path)
+		assert.Equal(t, "3", query.Get("v"))
+		assert.Equal(t, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC).Unix(), mustInt(t, query.Get("exp")))
+	})
+
+	t.Run("should keep the URL for the whole TTL", func(t *testing.T) {
+		assert.Equal(t,
+			signer.Sign(media.KindScan, "/uploads/scans/a.jpg", now),
+			signer.Sign(media.KindScan, "/uploads/scans/a.jpg", now.Add(30*time.Minute)))
+	})
+
+	t.Run("should sign URLs of the origins and the CDN the same way as paths", func(t *testing.T) {
+		expected := signer.Sign(media.KindAvatar, "/uploads/avatars/b.png", now)
+
+		assert.Equal(t, expected, signer.Sign(media.KindAvatar, "https://api.nutribox.id/uploads/avatars/b.png", now))
+		assert.Equal(t, expected, signer.Sign(media.KindAvatar, expected, now))
+	})
+
+	t.Run("should leave addresses on other hosts as they are", func(t *testing.T) {
+		avatar := "https://lh3.googleusercontent.com/a/photo.jpg"
+
+		assert.Equal(t, avatar, signer.Sign(media.KindAvatar, avatar, now))
+		assert.Equal(t, "data:image/png;base64,AAAA", signer.Sign(media.KindAvatar, "data:image/png;base64,AAAA", now))
+		assert.Empty(t, signer.Sign(media.KindAvatar, "", now))
+	})
+
+	t.Run("should serve addresses as stored without a signer", func(t *testing.T) {
+		var none *media.Signer
+
+		assert.Equal(t, "/uploads/scans/a.jpg", none.Sign(media.KindScan, "/uploads/scans/a.jpg", now))
+		assert.Nil(t, media.New("", "secret", 1, nil, nil))
+	})
+}
+
+func TestSignerVerify(t *testing.T) {
+	signer := newSigner()
+	now := time.Date(2026, 10, 16, 10, 20, 0, 0, time.UTC)
+	path, query := parse(t, signer.Sign(media.KindScan, "/uploads/scans/a.jpg", now))
+
+	t.Run("should accept a URL it signed", func(t *testing.T) {
+		assert.NoError(t, signer.Verify(path, query, now.Add(time.Hour)))
+	})
+
+	t.Run("should refuse an expired URL", func(t *testing.T) {
+		assert.ErrorIs(t, signer.Verify(path, query, now.Add(2*time.Hour)), media.ErrExpired)
+	})
+
+	t.Run("should refuse another path", func(t *testing.T) {
+		assert.ErrorIs(t, signer.Verify("/uploads/scans/b.jpg", query, now), media.ErrInvalid)
+	})
+
+	t.Run("should refuse URLs of an older key version", func(t *testing.T) {
+		bumped := newSigner()
+		bumped.Version = 4
+
+		assert.ErrorIs(t, bumped.Verify(path, query, now), media.ErrVersion)
+	})
+
+	t.Run("should refuse an unsigned URL", func(t *testing.T) {
+		assert.ErrorIs(t, signer.Verify(path, url.Values{}, now), media.ErrUnsigned)
+	})
+}
+
+func TestMediaFieldsMarshalStored(t *testing.T) {
+	media.SetDefault(newSigner())
+	defer media.SetDefault(nil)
+
+	body, err := json.Marshal(model.MealHistory{MealImage: "/uploads/scans/a.jpg"})
+	assert.NoError(t, err)
+
+	var meal map[string]interface{}
+	assert.NoError(t, json.Unmarshal(body, &meal))
+	assert.Equal(t, "/uploads/scans/a.jpg", meal["meal_image"])
+}
+
+func TestSignerSignJSON(t *testing.T) {
+	signer := newSigner()
+	now := time.Date(2026, 10, 16, 10, 20, 0, 0, time.UTC)
+
+	t.Run("should sign the media addresses of a document for the kind of their folder", func(t *testing.T) {
+		body := []byte(`{"meal_image":"/uploads/scans/a.jpg","user":{"profile_picture":"https://api.nutribox.id/uploads/avatars/b.png"}}`)
+
+		var signed struct {
+			MealImage string `json:"meal_image"`
+			User      struct {
+				ProfilePicture string `json:"profile_picture"`
+			} `json:"user"`
+		}
+		assert.NoError(t, json.Unmarshal(signer.SignJSON(body, now), &signed))
+		assert.Equal(t, signer.Sign(media.KindScan, "/uploads/scans/a.jpg", now), signed.MealImage)
+		assert.Equal(t, signer.Sign(media.KindAvatar, "/uploads/avatars/b.png", now), signed.User.ProfilePicture)
+	})
+
+	t.Run("should leave other strings and hosts as they are", func(t *testing.T) {
+		body := []byte(`{"path":"/v1/users","avatar":"https://lh3.googleusercontent.com/a.jpg","note":"say \"/uploads/x\""}`)
+
+		assert.Equal(t, string(body), string(signer.SignJSON(body, now)))
+	})
+
+	t.Run("should leave the document as it is without a signer", func(t *testing.T) {
+		var none *media.Signer
+		body := []byte(`{"meal_image":"/uploads/scans/a.jpg"}`)
+
+		assert.Equal(t, string(body), string(none.SignJSON(body, now)))
+	})
+}
+
+func TestKindOf(t *testing.T) {
+	assert.Equal(t, media.KindScan, media.KindOf("/uploads/scans/a.jpg"))
+	assert.Equal(t, media.KindAvatar, media.KindOf("/uploads/avatars/b.png"))
+	assert.Equal(t, media.KindProof, media.KindOf("/uploads/payment-proofs/c.pdf"))
+	assert.Equal(t, media.KindContent, media.KindOf("/uploads/articles/d.jpg"))
+	assert.Equal(t, media.KindContent, media.KindOf("/images/scans/e.jpg"))
+}
+
+func mustInt(t *testing.T, value string) int64 {
+	var parsed int64
+	assert.NoError(t, json.Unmarshal([]byte(value), &parsed))
+	return parsed
+}
@@ -0,0 +1,186 @@
+package messaging_test
+
+import (
+	"app/src/messaging"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTwilio(t *testing.T, settings messaging.Settings, handler http.HandlerFunc) messaging.Sender {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	settings.TwilioAccountSID = "AC123"
+	settings.TwilioAuthToken = "token"
+	settings.TwilioEndpoint = server.URL
+	sender, err := messaging.New(messaging.ProviderTwilio, settings)
+	assert.NoError(t, err)
+	return sender
+}
+
+func TestTwilioSend(t *testing.T) {
+	t.Run("should send an SMS from the configured number", func(t *testing.T) {
+		sender := newTwilio(t, messaging.Settings{TwilioSMSFrom: "+15005550006", TwilioStatusCallback: "https://api.nutribox.id/v1/messaging/twilio/status"},
+			func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+				username, password, _ := r.BasicAuth()
+				assert.Equal(t, "AC123", username)
+				assert.Equal(t, "token", password)
+
+				assert.NoError(t, r.ParseForm())
+				assert.Equal(t, "+6281234567890", r.PostForm.Get("To"))
+				assert.Equal(t, "+15005550006", r.PostForm.Get("From"))
+				assert.Equal(t, "Halo", r.PostForm.Get("Body"))
+				assert.Equal(t, "https://api.nutribox.id/v1/messaging/twilio/status", r.PostForm.Get("StatusCallback"))
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"sid":"SM1","status":"queued"}`))
+			})
+
+		id, err := sender.Send(context.Background(), &messaging.Message{To: "+6281234567890", Channel: messaging.ChannelSMS, Body: "Halo"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "SM1", id)
+	})
+
+	t.Run("should prefix WhatsApp numbers", func(t *testing.T) {
+		sender := newTwilio(t, messaging.Settings{TwilioWhatsAppFrom: "+14155238886"}, func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "whatsapp:+6281234567890", r.PostForm.Get("To"))
+			assert.Equal(t, "whatsapp:+14155238886", r.PostForm.Get("From"))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"sid":"SM2"}`))
+		})
+
+		id, err := sender.Send(context.Background(), &messaging.Message{To: "+6281234567890", Channel: messaging.ChannelWhatsApp, Body: "Halo"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "SM2", id)
+	})
+
+	t.Run("should not send WhatsApp without a sender", func(t *testing.T) {
+		sender := newTwilio(t, messaging.Settings{}, func(http.ResponseWriter, *http.Request) {
+			t.Error("no request expected")
+		})
+
+		_, err := sender.Send(context.Background(), &messaging.Message{To: "+6281234567890", Channel: messaging.ChannelWhatsApp})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should report an invalid number as permanent", func(t *testing.T) {
+		sender := newTwilio(t, messaging.Settings{}, func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":21211,"message":"The 'To' number is not a valid phone number.","status":400}`))
+		})
+
+		_, err := sender.Send(context.Background(), &messaging.Message{To: "+620", Channel: messaging.ChannelSMS})
+
+		assert.True(t, messaging.IsPermanent(err))
+		assert.EqualError(t, err, "twilio: 21211 The 'To' number is not a valid phone number.")
+	})
+
+	t.Run("should report throttling as a failure of the provider", func(t *testing.T) {
+		sender := newTwilio(t, messaging.Settings{}, func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"code":20429,"message":"Too Many Requests"}`))
+		})
+
+		_, err := sender.Send(context.Background(), &messaging.Message{To: "+6281234567890", Channel: messaging.ChannelSMS})
+
+		assert.Error(t, err)
+		assert.False(t, messaging.IsPermanent(err))
+	})
+}
+
+func TestNew(t *testing.T) {
+	t.Run("should leave an unconfigured provider out", func(t *testing.T) {
+		sender, err := messaging.New(messaging.ProviderTwilio, messaging.Settings{})
+
+		assert.NoError(t, err)
+		assert.Nil(t, sender)
+	})
+
+	t.Run("should refuse unknown providers", func(t *testing.T) {
+		_, err := messaging.New("pigeon", messaging.Settings{})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestNormalizePhone(t *testing.T) {
+	for _, phone := range []string{"081234567890", "0812-3456-7890", "6281234567890", "+62 812 3456 7890", "0062812 3456 7890", "81234567890"} {
+		normalized, err := messaging.NormalizePhone(phone, "62")
+
+		assert.NoError(t, err, phone)
+		assert.Equal(t, "+6281234567890", normalized, phone)
+	}
+
+	for _, phone := range []string{"", "12", "0812abc", "+0812345678", "+62812345678901234", "62+81234567"} {
+		_, err := messaging.NormalizePhone(phone, "62")
+
+		assert.ErrorIs(t, err, messaging.ErrInvalidPhone, phone)
+	}
+}
+
+func TestVerifyTwilioSignature(t *testing.T) {
+	url := "https://api.nutribox.id/v1/messaging/twilio/status"
+	params := map[string]string{"MessageSid": "SM1", "MessageStatus": "delivered", "AccountSid": "AC123"}
+
+	mac := hmac.New(sha1.New, []byte("token"))
+	mac.Write([]byte(url + "AccountSidAC123" + "MessageSidSM1" + "MessageStatusdelivered"))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	t.Run("should accept the signature of the URL and sorted params", func(t *testing.T) {
+		assert.True(t, messaging.VerifyTwilioSignature("token", url, params, signature))
+	})
+
+	t.Run("should refuse a changed param", func(t *testing.T) {
+		forged := map[string]string{"MessageSid": "SM1", "MessageStatus": "failed", "AccountSid": "AC123"}
+
+		assert.False(t, messaging.VerifyTwilioSignature("token", url, forged, signature))
+	})
+
+	t.Run("should refuse another auth token", func(t *testing.T) {
+		assert.False(t, messaging.VerifyTwilioSignature("other", url, params, signature))
+	})
+}
+
+func TestParseTwilioStatus(t *testing.T) {
+	t.Run("should normalize the statuses", func(t *testing.T) {
+		for status, expected := range map[string]string{
+			"accepted":    messaging.StatusQueued,
+			"sending":     messaging.StatusQueued,
+			"sent":        messaging.StatusSent,
+			"delivered":   messaging.StatusDelivered,
+			"read":        messaging.StatusRead,
+			"undelivered": messaging.StatusFailed,
+			"failed":      messaging.StatusFailed,
+		} {
+			update, err := messaging.ParseTwilioStatus(map[string]string{"MessageSid": "SM1", "MessageStatus": status})
+
+			assert.NoError(t, err, status)
+			assert.Equal(t, expected, update.Status, status)
+		}
+	})
+
+	t.Run("should keep the error code of a failed message", func(t *testing.T) {
+		update, err := messaging.ParseTwilioStatus(map[string]string{"MessageSid": "SM1", "MessageStatus": "undelivered", "ErrorCode": "30003"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "SM1", update.MessageID)
+		assert.Equal(t, "30003", update.ErrorCode)
+	})
+
+	t.Run("should refuse a callback without a message", func(t *testing.T) {
+		_, err := messaging.ParseTwilioStatus(map[string]string{"MessageStatus": "sent"})
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,88 @@
+package middleware_test
+
+import (
+	"app/src/config"
+	m "app/src/middleware"
+	"app/src/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkoutApp serves POST / behind CheckoutVelocity, to the user in the X-User header as Auth would have set them
+func checkoutApp(t *testing.T, perUser, perIP int) *fiber.App {
+	userLimit, ipLimit := config.CheckoutMaxAttemptsPerUser.Get(), config.CheckoutMaxAttemptsPerIP.Get()
+	window, block := config.CheckoutWindowMinutes, config.CheckoutBlockMinutes
+	t.Cleanup(func() {
+		config.CheckoutMaxAttemptsPerUser.Set(userLimit)
+		config.CheckoutMaxAttemptsPerIP.Set(ipLimit)
+		config.CheckoutWindowMinutes, config.CheckoutBlockMinutes = window, block
+	})
+	config.CheckoutMaxAttemptsPerUser.Set(perUser)
+	config.CheckoutMaxAttemptsPerIP.Set(perIP)
+	config.CheckoutWindowMinutes, config.CheckoutBlockMinutes = 10, 30
+
+	app := fiber.New()
+	app.Post("/", func(c *fiber.Ctx) error {
+		if id := c.Get("X-User"); id != "" {
+			c.Locals("user", &model.User{ID: uuid.MustParse(id)})
+		}
+		return c.Next()
+	}, m.CheckoutVelocity(), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func checkout(t *testing.T, app *fiber.App, userID uuid.UUID) *http.Response {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	if userID != uuid.Nil {
+		req.Header.Set("X-User", userID.String())
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestCheckoutVelocity(t *testing.T) {
+	t.Run("should block a user over the limit and tell them when to retry", func(t *testing.T) {
+		app := checkoutApp(t, 2, 100)
+		user := uuid.New()
+
+		assert.Equal(t, http.StatusOK, checkout(t, app, user).StatusCode)
+		assert.Equal(t, http.StatusOK, checkout(t, app, user).StatusCode)
+		resp := checkout(t, app, user)
+
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "1801", resp.Header.Get(fiber.HeaderRetryAfter))
+	})
+
+	t.Run("should keep a blocked user blocked", func(t *testing.T) {
+		app := checkoutApp(t, 1, 100)
+		user := uuid.New()
+		checkout(t, app, user)
+		checkout(t, app, user)
+
+		assert.Equal(t, http.StatusTooManyRequests, checkout(t, app, user).StatusCode)
+	})
+
+	t.Run("should count every user on their own", func(t *testing.T) {
+		app := checkoutApp(t, 1, 100)
+
+		assert.Equal(t, http.StatusOK, checkout(t, app, uuid.New()).StatusCode)
+		assert.Equal(t, http.StatusOK, checkout(t, app, uuid.New()).StatusCode)
+	})
+
+	t.Run("should block an address over the limit whatever the user", func(t *testing.T) {
+		app := checkoutApp(t, 100, 2)
+
+		assert.Equal(t, http.StatusOK, checkout(t, app, uuid.New()).StatusCode)
+		assert.Equal(t, http.StatusOK, checkout(t, app, uuid.Nil).StatusCode)
+		assert.Equal(t, http.StatusTooManyRequests, checkout(t, app, uuid.New()).StatusCode)
+	})
+}
@@ -0,0 +1,143 @@
+package middleware_test
+
+import (
+	m "app/src/middleware"
+	"app/src/model"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryIdempotency keeps the keys of service.IdempotencyService in memory
+type memoryIdempotency struct {
+	mu   sync.Mutex
+	keys map[string]*model.IdempotencyKey
+}
+
+func newMemoryIdempotency() *memoryIdempotency {
+	return &memoryIdempotency{keys: map[string]*model.IdempotencyKey{}}
+}
+
+func (s *memoryIdempotency) Begin(_ context.Context, scope, key, fingerprint string) (*model.IdempotencyKey, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.keys[scope+"\n"+key]; ok {
+		return record, false, nil
+	}
+	record := &model.IdempotencyKey{Scope: scope, Key: key, Fingerprint: fingerprint}
+	s.keys[scope+"\n"+key] = record
+	return record, true, nil
+}
+
+func (s *memoryIdempotency) Complete(_ context.Context, key *model.IdempotencyKey, statusCode int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	key.StatusCode, key.ContentType, key.Body, key.CompletedAt = statusCode, contentType, body, &now
+	return nil
+}
+
+func (s *memoryIdempotency) Release(_ context.Context, key *model.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, key.Scope+"\n"+key.Key)
+	return nil
+}
+
+func (s *memoryIdempotency) PurgeExpired(context.Context) error {
+	return nil
+}
+
+func TestIdempotent(t *testing.T) {
+	// send posts to app with the key and returns the status and whether the response was replayed
+	send := func(t *testing.T, app *fiber.App, key string) (int, bool) {
+		request := httptest.NewRequest(http.MethodPost, "/", nil)
+		request.Header.Set(model.IdempotencyKeyHeader, key)
+		resp, err := app.Test(request)
+		require.NoError(t, err)
+		return resp.StatusCode, resp.Header.Get(model.IdempotentReplayedHeader) == "true"
+	}
+
+	t.Run("should replay the response of a completed request", func(t *testing.T) {
+		runs := 0
+		app := fiber.New()
+		app.Post("/", m.Idempotent(newMemoryIdempotency()), func(c *fiber.Ctx) error {
+			runs++
+			return c.Status(fiber.StatusCreated).JSON(fiber.Map{"run": runs})
+		})
+
+		status, replayed := send(t, app, "key-1")
+		assert.Equal(t, http.StatusCreated, status)
+		assert.False(t, replayed)
+
+		status, replayed = send(t, app, "key-1")
+		assert.Equal(t, http.StatusCreated, status)
+		assert.True(t, replayed)
+		assert.Equal(t, 1, runs)
+	})
+
+	t.Run("should not replay the rate limit of a limiter after it", func(t *testing.T) {
+		limited := true
+		runs := 0
+		app := fiber.New()
+		app.Post("/", m.Idempotent(newMemoryIdempotency()), func(c *fiber.Ctx) error {
+			if limited {
+				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"code": "checkout_velocity"})
+			}
+			return c.Next()
+		}, func(c *fiber.Ctx) error {
+			runs++
+			return c.SendStatus(fiber.StatusCreated)
+		})
+
+		status, _ := send(t, app, "key-2")
+		assert.Equal(t, http.StatusTooManyRequests, status)
+
+		limited = false
+		status, replayed := send(t, app, "key-2")
+		assert.Equal(t, http.StatusCreated, status)
+		assert.False(t, replayed)
+		assert.Equal(t, 1, runs)
+	})
+
+	t.Run("should run a request again after a conflict", func(t *testing.T) {
+		runs := 0
+		app := fiber.New()
+		app.Post("/", m.Idempotent(newMemoryIdempotency()), func(c *fiber.Ctx) error {
+			runs++
+			if runs == 1 {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": "busy"})
+			}
+			return c.SendStatus(fiber.StatusCreated)
+		})
+
+		status, _ := send(t, app, "key-3")
+		assert.Equal(t, http.StatusConflict, status)
+
+		status, replayed := send(t, app, "key-3")
+		assert.Equal(t, http.StatusCreated, status)
+		assert.False(t, replayed)
+	})
+
+	t.Run("should refuse a key reused for another request", func(t *testing.T) {
+		app := fiber.New()
+		app.Post("/", m.Idempotent(newMemoryIdempotency()), func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusCreated)
+		})
+
+		send(t, app, "key-4")
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"plan_id":"b"}`))
+		request.Header.Set(model.IdempotencyKeyHeader, "key-4")
+		resp, err := app.Test(request)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+}
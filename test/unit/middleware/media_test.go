@@ -0,0 +1,66 @@
+package middleware_test
+
+import (
+	"app/src/media"
+	m "app/src/middleware"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mediaApp() *fiber.App {
+	app := fiber.New()
+	app.Use(m.SignMedia())
+	app.Use("/uploads", m.VerifyMedia())
+	app.Get("/uploads/*", func(c *fiber.Ctx) error {
+		return c.SendString("file")
+	})
+	app.Get("/proof", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"image_url": "/uploads/payment-proofs/a.jpg"})
+	})
+	return app
+}
+
+func TestMediaMiddleware(t *testing.T) {
+	signer := media.New("https://cdn.nutribox.id", "secret", 1, nil, nil)
+	media.SetDefault(signer)
+	t.Cleanup(func() { media.SetDefault(nil) })
+	app := mediaApp()
+
+	status := func(t *testing.T, target string) int {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil))
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("should sign media addresses in JSON responses", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/proof", nil))
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		assert.Contains(t, string(body), "https://cdn.nutribox.id/uploads/payment-proofs/a.jpg?")
+	})
+
+	t.Run("should serve files for signed URLs only", func(t *testing.T) {
+		signed := signer.Sign(media.KindProof, "/uploads/payment-proofs/a.jpg", time.Now())
+
+		assert.Equal(t, http.StatusOK, status(t, strings.TrimPrefix(signed, "https://cdn.nutribox.id")))
+		assert.Equal(t, http.StatusForbidden, status(t, "/uploads/payment-proofs/a.jpg"))
+		assert.Equal(t, http.StatusForbidden, status(t, "/uploads/payment-proofs/b.jpg?"+strings.SplitN(signed, "?", 2)[1]))
+	})
+
+	t.Run("should not serve files without a signer", func(t *testing.T) {
+		media.SetDefault(nil)
+		defer media.SetDefault(signer)
+
+		assert.Equal(t, http.StatusNotFound, status(t, "/uploads/payment-proofs/a.jpg"))
+	})
+}
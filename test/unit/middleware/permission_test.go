@@ -0,0 +1,65 @@
+package middleware_test
+
+import (
+	"app/src/config"
+	m "app/src/middleware"
+	"app/src/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// permissionApp serves GET / to a user of role, as Auth would have set them, behind RequirePermission(rights...)
+func permissionApp(role string, rights ...string) *fiber.App {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		if role != "" {
+			c.Locals("user", &model.User{Role: role})
+		}
+		return c.Next()
+	}, m.RequirePermission(rights...), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func TestRequirePermission(t *testing.T) {
+	config.SetCustomRoles(map[string][]string{"support": {"getUsers", "getSubscriptions"}})
+	t.Cleanup(func() { config.SetCustomRoles(nil) })
+
+	status := func(t *testing.T, app *fiber.App) int {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("should let through a built-in role with the right", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, status(t, permissionApp("admin", "updatePaymentStatus")))
+	})
+
+	t.Run("should let through a custom role with every right", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, status(t, permissionApp("support", "getUsers", "getSubscriptions")))
+	})
+
+	t.Run("should forbid a custom role without the right", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, status(t, permissionApp("support", "updatePaymentStatus")))
+		assert.Equal(t, http.StatusForbidden, status(t, permissionApp("support", "getUsers", "updatePaymentStatus")))
+	})
+
+	t.Run("should forbid roles that do not exist", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, status(t, permissionApp("deleted_role", "getUsers")))
+		assert.Equal(t, http.StatusForbidden, status(t, permissionApp("user", "getUsers")))
+	})
+
+	t.Run("should ask to authenticate without a user", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, status(t, permissionApp("", "getUsers")))
+	})
+
+	t.Run("should panic on a right no role can grant", func(t *testing.T) {
+		assert.Panics(t, func() { m.RequirePermission("launchRockets") })
+	})
+}
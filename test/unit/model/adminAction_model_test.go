@@ -0,0 +1,33 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminActionCanUndo(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should be undone within the window", func(t *testing.T) {
+		action := model.AdminAction{Status: model.AdminActionStaged, FinalizeAt: now.Add(time.Minute)}
+
+		assert.True(t, action.CanUndo(now))
+	})
+
+	t.Run("should not be undone once the window is over", func(t *testing.T) {
+		action := model.AdminAction{Status: model.AdminActionStaged, FinalizeAt: now}
+
+		assert.False(t, action.CanUndo(now))
+	})
+
+	t.Run("should not be undone once finalized or undone", func(t *testing.T) {
+		for _, status := range []string{model.AdminActionFinalized, model.AdminActionUndone, model.AdminActionFailed} {
+			action := model.AdminAction{Status: status, FinalizeAt: now.Add(time.Minute)}
+
+			assert.False(t, action.CanUndo(now), status)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertRuleMatches(t *testing.T) {
+	t.Run("should fire once the threshold is reached", func(t *testing.T) {
+		rule := model.AlertRule{IsActive: true, Threshold: 5}
+
+		assert.False(t, rule.Matches(4))
+		assert.True(t, rule.Matches(5))
+	})
+
+	t.Run("should not fire when muted", func(t *testing.T) {
+		rule := model.AlertRule{IsActive: false, Threshold: 1}
+
+		assert.False(t, rule.Matches(10))
+	})
+}
+
+func TestAlertRuleInCooldown(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should not be in cooldown before firing", func(t *testing.T) {
+		rule := model.AlertRule{CooldownMinutes: 60}
+
+		assert.False(t, rule.InCooldown(now))
+	})
+
+	t.Run("should wait for the cooldown after firing", func(t *testing.T) {
+		triggered := now.Add(-30 * time.Minute)
+		rule := model.AlertRule{CooldownMinutes: 60, LastTriggeredAt: &triggered}
+
+		assert.True(t, rule.InCooldown(now))
+		assert.False(t, rule.InCooldown(now.Add(30*time.Minute)))
+	})
+}
+
+func TestIsCountedAlertEvent(t *testing.T) {
+	assert.True(t, model.IsCountedAlertEvent(model.AlertPaymentFailureSpike))
+	assert.True(t, model.IsCountedAlertEvent(model.AlertAIProviderError))
+	assert.False(t, model.IsCountedAlertEvent(model.AlertLargeRefund))
+	assert.False(t, model.IsCountedAlertEvent(model.AlertReconciliationMismatch))
+}
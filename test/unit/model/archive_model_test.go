@@ -0,0 +1,35 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchivePartition(t *testing.T) {
+	t.Run("should place a row in the partition of its year", func(t *testing.T) {
+		name, from, to := model.ArchivePartition(model.TransactionDetailsTable, time.Date(2024, time.June, 15, 10, 0, 0, 0, time.UTC))
+
+		assert.Equal(t, "transaction_details_archive_2024", name)
+		assert.Equal(t, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), from)
+		assert.Equal(t, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), to)
+	})
+
+	t.Run("should use the UTC year", func(t *testing.T) {
+		jakarta := time.FixedZone("WIB", 7*60*60)
+		name, _, _ := model.ArchivePartition(model.MealHistoryDetailsTable, time.Date(2025, time.January, 1, 3, 0, 0, 0, jakarta))
+
+		assert.Equal(t, "meal_history_details_archive_2024", name)
+	})
+}
+
+func TestArchiveCutoff(t *testing.T) {
+	t.Run("should start at the beginning of the month", func(t *testing.T) {
+		now := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+
+		assert.Equal(t, time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC), model.ArchiveCutoff(now, 24))
+		assert.Equal(t, time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC), model.ArchiveCutoff(now, 6))
+	})
+}
@@ -0,0 +1,63 @@
+package model_test
+
+import (
+	"app/src/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssistantGuardrail(t *testing.T) {
+	t.Run("should catch an emergency before a medical term", func(t *testing.T) {
+		assert.Equal(t, model.AssistantGuardEmergency, model.AssistantGuardrail("Saya keracunan obat, apa yang harus dimakan?"))
+	})
+
+	t.Run("should catch medical terms in any case", func(t *testing.T) {
+		assert.Equal(t, model.AssistantGuardMedical, model.AssistantGuardrail("Can I eat rice with DIABETES?"))
+	})
+
+	t.Run("should let nutrition questions through", func(t *testing.T) {
+		assert.Empty(t, model.AssistantGuardrail("Berapa protein dalam tempe?"))
+	})
+}
+
+func TestAssistantQuotaExhausted(t *testing.T) {
+	assert.False(t, model.AssistantQuota{Limit: 5, Used: 4}.Exhausted())
+	assert.True(t, model.AssistantQuota{Limit: 5, Used: 5}.Exhausted())
+	assert.True(t, model.AssistantQuota{Limit: 0, Used: 0}.Exhausted())
+	assert.False(t, model.AssistantQuota{Limit: -1, Used: 1000}.Exhausted())
+}
+
+func TestAssistantSystemPrompt(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	age, weight, target := 30, 80.0, 70.0
+
+	t.Run("should put the profile and the diary of the user in the prompt", func(t *testing.T) {
+		prompt := model.AssistantSystemPrompt(model.AssistantContext{
+			Name:         "Budi",
+			Age:          &age,
+			Weight:       &weight,
+			TargetWeight: &target,
+			Restrictions: []string{"peanut"},
+			Days:         []model.DailyNutritionSummary{{Date: now.AddDate(0, 0, -1), Calories: 1800, Protein: 60, Carbs: 200, Fat: 50, MealCount: 3}},
+			TodayMeals:   []model.MealHistory{{Title: "Nasi goreng", MealTime: now.Add(-4 * time.Hour), Calories: 650}},
+		}, now)
+
+		assert.Contains(t, prompt, "Data pengguna (2026-03-10)")
+		assert.Contains(t, prompt, "- Nama: Budi\n")
+		assert.Contains(t, prompt, "- Umur: 30 tahun\n")
+		assert.Contains(t, prompt, "- Berat: 80 kg\n")
+		assert.Contains(t, prompt, "- Pantangan makan: peanut\n")
+		assert.Contains(t, prompt, "2026-03-09: 1800 kkal, protein 60, karbohidrat 200, lemak 50, 3 kali makan")
+		assert.Contains(t, prompt, "08:00 Nasi goreng: 650 kkal")
+	})
+
+	t.Run("should say when the diary is empty", func(t *testing.T) {
+		prompt := model.AssistantSystemPrompt(model.AssistantContext{}, now)
+
+		assert.Contains(t, prompt, "Belum ada catatan makan")
+		assert.False(t, strings.Contains(prompt, "- Nama:"))
+	})
+}
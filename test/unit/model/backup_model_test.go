@@ -0,0 +1,21 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupObjectKey(t *testing.T) {
+	t.Run("should sort keys by the UTC start time", func(t *testing.T) {
+		id := uuid.MustParse("1a2b3c4d-0000-0000-0000-000000000000")
+		jakarta := time.FixedZone("WIB", 7*60*60)
+
+		key := model.BackupObjectKey("backups/", id, time.Date(2024, 3, 1, 9, 30, 0, 0, jakarta))
+
+		assert.Equal(t, "backups/20240301T023000Z-1a2b3c4d.dump", key)
+	})
+}
@@ -0,0 +1,47 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCheckoutFunnelSteps(t *testing.T) {
+	users := map[string]int64{
+		model.EventPlanViewed:       200,
+		model.EventCheckoutCreated:  60,
+		model.EventPaymentAttempted: 45,
+		model.EventCheckoutPaid:     30,
+	}
+	reached := map[string]int64{
+		model.EventPlanViewed:       200,
+		model.EventCheckoutCreated:  50,
+		model.EventPaymentAttempted: 40,
+		model.EventCheckoutPaid:     30,
+	}
+
+	steps := model.NewCheckoutFunnelSteps(users, reached)
+	assert.Len(t, steps, 4)
+
+	assert.Equal(t, model.EventPlanViewed, steps[0].Step)
+	assert.Equal(t, int64(0), steps[0].DropOff)
+	assert.Equal(t, 100.0, steps[0].Conversion)
+
+	assert.Equal(t, int64(60), steps[1].Users)
+	assert.Equal(t, int64(150), steps[1].DropOff)
+	assert.Equal(t, 75.0, steps[1].DropOffPercent)
+	assert.Equal(t, 25.0, steps[1].Conversion)
+
+	assert.Equal(t, int64(10), steps[3].DropOff)
+	assert.Equal(t, 25.0, steps[3].DropOffPercent)
+	assert.Equal(t, 15.0, steps[3].Conversion)
+}
+
+func TestNewCheckoutFunnelStepsWithoutUsers(t *testing.T) {
+	steps := model.NewCheckoutFunnelSteps(map[string]int64{}, map[string]int64{})
+	for _, step := range steps {
+		assert.Zero(t, step.DropOffPercent)
+		assert.Zero(t, step.Conversion)
+	}
+}
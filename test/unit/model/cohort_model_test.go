@@ -0,0 +1,62 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCohortAggregateAdd(t *testing.T) {
+	today := time.Date(2026, 10, 16, 4, 0, 0, 0, time.UTC)
+	var aggregate model.CohortAggregate
+
+	// 35 days ago: weeks 1, 2 and 4 are over, the adherence period too
+	aggregate.Add(model.UserCohortStats{SignupDate: today.AddDate(0, 0, -35), ActiveWeeks: 1<<0 | 1<<1 | 1<<4, LoggedDays: 10}, today)
+	// 14 days ago: week 1 is over, week 2 is not
+	aggregate.Add(model.UserCohortStats{SignupDate: today.AddDate(0, 0, -14), ActiveWeeks: 1 << 2, LoggedDays: 3}, today)
+
+	assert.Equal(t, int64(2), aggregate.Users)
+	assert.Equal(t, []int64{2, 1, 1, 0, 0}, aggregate.Eligible)
+	assert.Equal(t, []int64{1, 0, 1, 0, 0}, aggregate.Retained)
+	assert.Equal(t, int64(1), aggregate.AdherenceUsers)
+	assert.Equal(t, int64(10), aggregate.LoggedDays)
+}
+
+func TestCompareCohorts(t *testing.T) {
+	september := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	october := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	aggregates := []model.CohortAggregate{
+		{SignupMonth: october, Plan: "free", Source: "organic", Users: 10, Eligible: []int64{4, 0, 0, 0, 0}, Retained: []int64{1, 0, 0, 0, 0}},
+		{SignupMonth: september, Plan: "free", Source: "instagram", Users: 5, Eligible: []int64{5, 5, 5, 0, 0}, Retained: []int64{3, 2, 1, 0, 0},
+			AdherenceUsers: 5, LoggedDays: 70},
+		{SignupMonth: september, Plan: "Premium", Source: "organic", Users: 2, Eligible: []int64{2, 2, 2, 0, 0}, Retained: []int64{2, 2, 2, 0, 0},
+			AdherenceUsers: 2, LoggedDays: 42},
+	}
+
+	byMonth := model.CompareCohorts(aggregates, model.CohortSignupMonth)
+	assert.Len(t, byMonth, 2)
+	assert.Equal(t, "2026-09", byMonth[0].Cohort)
+	assert.Equal(t, int64(7), byMonth[0].Users)
+	assert.Equal(t, int64(5), byMonth[0].Retention[0].Retained)
+	assert.InDelta(t, 5.0/7, *byMonth[0].Retention[0].Rate, 1e-9)
+	assert.Equal(t, 8, byMonth[0].Retention[3].Week)
+	assert.Nil(t, byMonth[0].Retention[3].Rate)
+	assert.InDelta(t, 16.0, *byMonth[0].Adherence.AverageLoggedDays, 1e-9)
+	assert.InDelta(t, 16.0/28, *byMonth[0].Adherence.Rate, 1e-9)
+	assert.Nil(t, byMonth[1].Adherence.Rate)
+
+	byPlan := model.CompareCohorts(aggregates, model.CohortPlan)
+	assert.Equal(t, "free", byPlan[0].Cohort)
+	assert.Equal(t, int64(15), byPlan[0].Users)
+	assert.Equal(t, "Premium", byPlan[1].Cohort)
+
+	bySource := model.CompareCohorts(aggregates, model.CohortSource)
+	assert.Equal(t, []string{"organic", "instagram"}, []string{bySource[0].Cohort, bySource[1].Cohort})
+}
+
+func TestNormalizeAcquisitionSource(t *testing.T) {
+	assert.Equal(t, "google_ads", model.NormalizeAcquisitionSource("  Google Ads "))
+	assert.Equal(t, "", model.NormalizeAcquisitionSource(" "))
+}
@@ -0,0 +1,86 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCouponIsRedeemableAt(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	planID := uuid.New()
+
+	t.Run("should be redeemable when active and unrestricted", func(t *testing.T) {
+		coupon := model.Coupon{IsActive: true}
+
+		assert.True(t, coupon.IsRedeemableAt(now, planID))
+	})
+
+	t.Run("should only apply to its plan", func(t *testing.T) {
+		coupon := model.Coupon{IsActive: true, PlanID: &planID}
+
+		assert.True(t, coupon.IsRedeemableAt(now, planID))
+		assert.False(t, coupon.IsRedeemableAt(now, uuid.New()))
+	})
+
+	t.Run("should respect the validity window", func(t *testing.T) {
+		until := now.Add(time.Hour)
+		coupon := model.Coupon{IsActive: true, ValidUntil: &until}
+
+		assert.True(t, coupon.IsRedeemableAt(now, planID))
+		assert.False(t, coupon.IsRedeemableAt(until, planID))
+	})
+
+	t.Run("should stop after the redemption limit", func(t *testing.T) {
+		coupon := model.Coupon{IsActive: true, MaxRedemptions: 2, Redemptions: 2}
+
+		assert.False(t, coupon.IsRedeemableAt(now, planID))
+	})
+}
+
+func TestCouponDiscountFor(t *testing.T) {
+	t.Run("should apply a percentage", func(t *testing.T) {
+		coupon := model.Coupon{DiscountPercent: 25}
+
+		assert.Equal(t, model.IDR(25000), coupon.DiscountFor(model.IDR(100000)))
+	})
+
+	t.Run("should round a percentage half up to the minor unit", func(t *testing.T) {
+		coupon := model.Coupon{DiscountPercent: 15}
+
+		assert.Equal(t, model.IDR(14849), coupon.DiscountFor(model.IDR(98990)))
+		assert.Equal(t, model.Money{Amount: 150, Currency: "USD"}, coupon.DiscountFor(model.Money{Amount: 999, Currency: "USD"}))
+	})
+
+	t.Run("should never exceed the amount", func(t *testing.T) {
+		coupon := model.Coupon{DiscountAmount: 150000, Currency: model.CurrencyIDR}
+
+		assert.Equal(t, model.IDR(100000), coupon.DiscountFor(model.IDR(100000)))
+	})
+
+	t.Run("should not apply a fixed amount in another currency", func(t *testing.T) {
+		coupon := model.Coupon{DiscountAmount: 10000, Currency: model.CurrencyIDR}
+
+		assert.Equal(t, int64(0), coupon.DiscountFor(model.Money{Amount: 1500, Currency: "USD"}).Amount)
+	})
+}
+
+func TestCheckoutSessionIsPayable(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should be payable until it expires", func(t *testing.T) {
+		session := model.CheckoutSession{Status: model.CheckoutOpen, ExpiresAt: now.Add(time.Minute)}
+
+		assert.True(t, session.IsPayable(now))
+		assert.False(t, session.IsPayable(now.Add(time.Minute)))
+	})
+
+	t.Run("should not be payable once paid", func(t *testing.T) {
+		session := model.CheckoutSession{Status: model.CheckoutPaid, ExpiresAt: now.Add(time.Minute)}
+
+		assert.False(t, session.IsPayable(now))
+	})
+}
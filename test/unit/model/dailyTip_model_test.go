@@ -0,0 +1,130 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchTipRule(t *testing.T) {
+	today := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	day := func(daysAgo int, protein float64, meals int) model.DailyNutritionSummary {
+		return model.DailyNutritionSummary{Date: today.AddDate(0, 0, -daysAgo), Protein: protein, MealCount: meals}
+	}
+	lowProtein := model.TipRule{Key: "low_protein", Metric: model.TipMetricProtein, Comparison: model.TipBelow, Threshold: 50, MinDays: 3, WindowDays: 7}
+
+	t.Run("should count the logged days below the threshold", func(t *testing.T) {
+		summaries := []model.DailyNutritionSummary{day(1, 30, 2), day(2, 40, 3), day(3, 60, 3), day(5, 20, 1)}
+
+		match, ok := model.MatchTipRule(lowProtein, summaries, today)
+
+		assert.True(t, ok)
+		assert.Equal(t, 3, match.Days)
+		assert.Equal(t, 37.5, match.Average)
+	})
+
+	t.Run("should skip days without meals and days outside the window", func(t *testing.T) {
+		summaries := []model.DailyNutritionSummary{day(0, 10, 1), day(1, 30, 2), day(2, 0, 0), day(8, 10, 1)}
+
+		match, ok := model.MatchTipRule(lowProtein, summaries, today)
+
+		assert.False(t, ok)
+		assert.Equal(t, 1, match.Days)
+	})
+
+	t.Run("should count days without a summary for the meal count", func(t *testing.T) {
+		rule := model.TipRule{Key: "missed_logging", Metric: model.TipMetricMealCount, Comparison: model.TipBelow, Threshold: 1, MinDays: 5, WindowDays: 7}
+
+		match, ok := model.MatchTipRule(rule, []model.DailyNutritionSummary{day(1, 30, 2), day(4, 30, 1)}, today)
+
+		assert.True(t, ok)
+		assert.Equal(t, 5, match.Days)
+	})
+
+	t.Run("should always match an always rule", func(t *testing.T) {
+		_, ok := model.MatchTipRule(model.TipRule{Metric: model.TipMetricAlways}, nil, today)
+
+		assert.True(t, ok)
+	})
+}
+
+func TestPickTip(t *testing.T) {
+	today := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	summaries := []model.DailyNutritionSummary{}
+	for i := 1; i <= 7; i++ {
+		summaries = append(summaries, model.DailyNutritionSummary{Date: today.AddDate(0, 0, -i), Protein: 30, Fat: 100, MealCount: 3})
+	}
+	rules := []model.TipRule{
+		{Key: "drink_water", Metric: model.TipMetricAlways, Enabled: true},
+		{Key: "high_fat", Metric: model.TipMetricFat, Comparison: model.TipAbove, Threshold: 80, MinDays: 4, WindowDays: 7, Priority: 40, Enabled: true},
+		{Key: "low_protein", Metric: model.TipMetricProtein, Comparison: model.TipBelow, Threshold: 50, MinDays: 4, WindowDays: 7, Priority: 50, CooldownDays: 3, Enabled: true},
+	}
+
+	t.Run("should pick the matching rule of the highest priority", func(t *testing.T) {
+		match, ok := model.PickTip(rules, summaries, nil, today)
+
+		assert.True(t, ok)
+		assert.Equal(t, "low_protein", match.Rule.Key)
+		assert.Equal(t, 7, match.Days)
+	})
+
+	t.Run("should leave out a rule within its cooldown", func(t *testing.T) {
+		match, _ := model.PickTip(rules, summaries, map[string]time.Time{"low_protein": today.AddDate(0, 0, -3)}, today)
+		assert.Equal(t, "high_fat", match.Rule.Key)
+
+		match, _ = model.PickTip(rules, summaries, map[string]time.Time{"low_protein": today.AddDate(0, 0, -4)}, today)
+		assert.Equal(t, "low_protein", match.Rule.Key)
+	})
+
+	t.Run("should leave out disabled rules", func(t *testing.T) {
+		disabled := append([]model.TipRule{}, rules...)
+		disabled[1].Enabled, disabled[2].Enabled = false, false
+
+		match, ok := model.PickTip(disabled, summaries, nil, today)
+
+		assert.True(t, ok)
+		assert.Equal(t, "drink_water", match.Rule.Key)
+	})
+
+	t.Run("should pick nothing without a match", func(t *testing.T) {
+		_, ok := model.PickTip(rules[1:], nil, nil, today)
+
+		assert.False(t, ok)
+	})
+}
+
+func TestTipMatchRender(t *testing.T) {
+	rule := model.TipRule{
+		WindowDays: 7, Threshold: 50,
+		Title:   "Low protein",
+		Message: "You were low on protein {{.days}} of the last {{.window}} days, below {{.threshold}} g",
+	}
+
+	title, message, err := model.TipMatch{Rule: rule, Days: 4}.Render()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Low protein", title)
+	assert.Equal(t, "You were low on protein 4 of the last 7 days, below 50 g", message)
+}
+
+func TestCheckTipRule(t *testing.T) {
+	t.Run("should accept the default rules", func(t *testing.T) {
+		for _, rule := range model.DefaultTipRules {
+			assert.NoError(t, model.CheckTipRule(rule), rule.Key)
+		}
+	})
+
+	t.Run("should reject more min days than the window", func(t *testing.T) {
+		err := model.CheckTipRule(model.TipRule{Metric: model.TipMetricFat, MinDays: 8, WindowDays: 7, Title: "t", Message: "m"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject an unknown template variable", func(t *testing.T) {
+		err := model.CheckTipRule(model.TipRule{Metric: model.TipMetricFat, MinDays: 1, WindowDays: 7, Title: "t", Message: "{{.sugar}}"})
+
+		assert.Error(t, err)
+	})
+}
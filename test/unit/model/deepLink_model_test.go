@@ -0,0 +1,45 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeepLinkPath(t *testing.T) {
+	planID := uuid.New()
+	mealID := uuid.New()
+
+	t.Run("should open the screen of each kind", func(t *testing.T) {
+		cases := map[model.DeepLink]string{
+			{Kind: model.DeepLinkRenewSubscription}:                          "subscription/renew",
+			{Kind: model.DeepLinkRenewSubscription, Target: planID.String()}: "subscription/renew?plan_id=" + planID.String(),
+			{Kind: model.DeepLinkDiaryDay, Target: "2026-10-16"}:             "diary/2026-10-16",
+			{Kind: model.DeepLinkScanResult, Target: mealID.String()}:        "meals/" + mealID.String(),
+		}
+
+		for link, expected := range cases {
+			path, err := link.Path()
+
+			assert.NoError(t, err)
+			assert.Equal(t, expected, path)
+		}
+	})
+
+	t.Run("should reject targets that do not fit the kind", func(t *testing.T) {
+		invalid := []model.DeepLink{
+			{Kind: model.DeepLinkDiaryDay, Target: "16-10-2026"},
+			{Kind: model.DeepLinkScanResult},
+			{Kind: model.DeepLinkRenewSubscription, Target: "../admin"},
+			{Kind: "settings"},
+		}
+
+		for _, link := range invalid {
+			_, err := link.Path()
+
+			assert.Error(t, err, link.Kind)
+		}
+	})
+}
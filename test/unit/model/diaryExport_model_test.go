@@ -0,0 +1,56 @@
+package model_test
+
+import (
+	"app/src/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiaryDays(t *testing.T) {
+	at := func(day, hour int) time.Time {
+		return time.Date(2026, 3, day, hour, 0, 0, 0, time.UTC)
+	}
+	meals := []model.MealHistory{
+		{Title: "Nasi uduk", MealTime: at(1, 7), Calories: 500, Protein: 12},
+		{Title: "Soto ayam", MealTime: at(1, 12), Calories: 400, Protein: 25},
+		{Title: "Pisang", MealTime: at(3, 16), Calories: 100, Protein: 1},
+	}
+
+	days := model.DiaryDays(meals)
+
+	assert.Len(t, days, 2)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), days[0].Date)
+	assert.Len(t, days[0].Meals, 2)
+	assert.Equal(t, 900.0, days[0].Total.Calories)
+	assert.Equal(t, 37.0, days[0].Total.Protein)
+	assert.Equal(t, time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), days[1].Date)
+}
+
+func TestDiaryCSV(t *testing.T) {
+	label, comment := "Sarapan", "=HYPERLINK(\"http://evil\")"
+	meals := []model.MealHistory{
+		{Title: "Nasi goreng, telur", MealTime: time.Date(2026, 3, 1, 7, 30, 0, 0, time.UTC), Label: &label, Comment: &comment,
+			Calories: 650.5, Protein: 20, Carbs: 80, Fat: 25.25},
+	}
+
+	content, err := model.DiaryCSV(meals)
+
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Equal(t, "date,time,meal,label,calories_kcal,protein_g,carbs_g,fat_g,comment", lines[0])
+	assert.Equal(t, `2026-03-01,07:30,"Nasi goreng, telur",Sarapan,650.5,20,80,25.25,"'=HYPERLINK(""http://evil"")"`, lines[1])
+}
+
+func TestDiaryExportFile(t *testing.T) {
+	export := model.DiaryExport{
+		Format: model.DiaryExportPDF,
+		From:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:     time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	assert.Equal(t, "nutribox-diary-2026-01-01-2026-03-31.pdf", export.Filename())
+	assert.Equal(t, "application/pdf", export.ContentType())
+}
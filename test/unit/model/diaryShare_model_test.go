@@ -0,0 +1,68 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiaryShareActive(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	share := model.DiaryShare{ExpiresAt: now.Add(time.Hour)}
+	assert.True(t, share.Active(now))
+	assert.False(t, share.Active(now.Add(time.Hour)))
+
+	revoked := now.Add(-time.Minute)
+	share.RevokedAt = &revoked
+	assert.False(t, share.Active(now))
+}
+
+func TestNewSharedFood(t *testing.T) {
+	label := "breakfast"
+	meals := []model.MealHistory{
+		{Title: "Nasi uduk", Label: &label, MealTime: time.Date(2026, 10, 1, 7, 30, 0, 0, time.Local), Calories: 500, Protein: 12, Carbs: 70, Fat: 18},
+		{Title: "Soto ayam", MealTime: time.Date(2026, 10, 1, 12, 15, 0, 0, time.Local), Calories: 300, Protein: 20, Carbs: 30, Fat: 10},
+		{Title: "Pisang", MealTime: time.Date(2026, 10, 3, 16, 0, 0, 0, time.Local), Calories: 100, Protein: 1, Carbs: 26, Fat: 0},
+	}
+
+	food := model.NewSharedFood(meals, 7)
+	assert.Equal(t, 7, food.TotalDays)
+	assert.Equal(t, 2, food.LoggedDays)
+	assert.Len(t, food.Days, 2)
+	assert.Equal(t, "2026-10-01", food.Days[0].Date)
+	assert.Equal(t, 800.0, food.Days[0].Total.Calories)
+	assert.Equal(t, "07:30", food.Days[0].Meals[0].Time)
+	assert.Equal(t, &label, food.Days[0].Meals[0].Label)
+	assert.Equal(t, 450.0, food.Average.Calories)
+	assert.Equal(t, 16.5, food.Average.Protein)
+}
+
+func TestNewSharedFoodWithoutMeals(t *testing.T) {
+	food := model.NewSharedFood(nil, 3)
+	assert.Equal(t, 0, food.LoggedDays)
+	assert.NotNil(t, food.Days)
+	assert.Zero(t, food.Average.Calories)
+}
+
+func TestSharedFoodOnlyTotals(t *testing.T) {
+	meals := []model.MealHistory{
+		{Title: "Soto ayam", MealTime: time.Date(2026, 10, 1, 12, 15, 0, 0, time.Local), Calories: 300},
+	}
+
+	food := model.NewSharedFood(meals, 1)
+	food.OnlyTotals()
+	assert.Len(t, food.Days, 1)
+	assert.Equal(t, 300.0, food.Days[0].Total.Calories)
+	assert.Nil(t, food.Days[0].Meals)
+}
+
+func TestNewSharedVital(t *testing.T) {
+	vital := model.NewSharedVital(model.UsersWeightHeightHistory{Weight: 70, Height: 175})
+	assert.Equal(t, 22.9, vital.BMI)
+
+	vital = model.NewSharedVital(model.UsersWeightHeightHistory{Weight: 70})
+	assert.Zero(t, vital.BMI)
+}
@@ -0,0 +1,59 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserCounterProjection(t *testing.T) {
+	userID := uuid.New()
+	now := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	since := now.AddDate(0, 0, -1)
+
+	event := func(eventType string, subjectID uuid.UUID, payload model.EventPayload) *model.DomainEvent {
+		event, err := model.NewDomainEvent(eventType, userID, subjectID, payload, now)
+		assert.NoError(t, err)
+		return event
+	}
+
+	t.Run("should count scans and logged days of the meals left", func(t *testing.T) {
+		scanned, manual, deleted := uuid.New(), uuid.New(), uuid.New()
+		projection := model.NewUserCounterProjection()
+
+		for _, e := range []*model.DomainEvent{
+			event(model.EventMealLogged, scanned, model.EventPayload{Day: "2024-03-08", Scans: 1}),
+			event(model.EventMealScanAdded, scanned, model.EventPayload{}),
+			event(model.EventMealLogged, manual, model.EventPayload{Day: "2024-03-08"}),
+			event(model.EventMealMoved, manual, model.EventPayload{Day: "2024-03-09"}),
+			event(model.EventMealLogged, deleted, model.EventPayload{Day: "2024-03-07", Scans: 1}),
+			event(model.EventMealDeleted, deleted, model.EventPayload{}),
+		} {
+			assert.NoError(t, projection.Apply(e))
+		}
+
+		counters := projection.Counters(since)
+		assert.Equal(t, 2, counters.TotalScans)
+		assert.Equal(t, 2, counters.TotalLoggedDays)
+	})
+
+	t.Run("should keep the streak of the last login only while it is recent", func(t *testing.T) {
+		projection := model.NewUserCounterProjection()
+		assert.NoError(t, projection.Apply(event(model.EventUserLoggedIn, uuid.New(), model.EventPayload{Day: "2024-03-08", Streak: 2})))
+		assert.NoError(t, projection.Apply(event(model.EventUserLoggedIn, uuid.New(), model.EventPayload{Day: "2024-03-09", Streak: 3})))
+
+		assert.Equal(t, 3, projection.Counters(since).CurrentStreak)
+		assert.Zero(t, projection.Counters(now.AddDate(0, 0, 2)).CurrentStreak)
+	})
+
+	t.Run("should add up recognized revenue", func(t *testing.T) {
+		projection := model.NewUserCounterProjection()
+		assert.NoError(t, projection.Apply(event(model.EventRevenueRecognized, uuid.New(), model.EventPayload{Amount: 50000})))
+		assert.NoError(t, projection.Apply(event(model.EventRevenueRecognized, uuid.New(), model.EventPayload{Amount: 25000})))
+
+		assert.Equal(t, int64(75000), projection.Counters(since).LifetimeSpend)
+	})
+}
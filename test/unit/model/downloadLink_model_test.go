@@ -0,0 +1,32 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadLinkRefusal(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+
+	t.Run("should serve an unused link before it expires", func(t *testing.T) {
+		link := model.DownloadLink{ExpiresAt: now.Add(time.Minute)}
+
+		assert.Empty(t, link.Refusal(now))
+	})
+
+	t.Run("should refuse a used link", func(t *testing.T) {
+		usedAt := now.Add(-time.Minute)
+		link := model.DownloadLink{ExpiresAt: now.Add(time.Minute), UsedAt: &usedAt}
+
+		assert.Equal(t, model.DownloadAlreadyUsed, link.Refusal(now))
+	})
+
+	t.Run("should refuse a link from the moment it expires", func(t *testing.T) {
+		link := model.DownloadLink{ExpiresAt: now}
+
+		assert.Equal(t, model.DownloadExpired, link.Refusal(now))
+	})
+}
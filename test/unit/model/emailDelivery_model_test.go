@@ -0,0 +1,82 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEmailProviderHealth(t *testing.T) {
+	thresholds := model.EmailFailoverThresholds{MinAttempts: 10, MaxErrorRate: 0.2, MaxBounceRate: 0.1}
+
+	t.Run("should rate the attempts of a provider", func(t *testing.T) {
+		health := model.NewEmailProviderHealth("ses", model.EmailProviderPrimary, true,
+			map[string]int64{model.EmailSent: 16, model.EmailFailed: 3, model.EmailBounced: 1}, thresholds)
+
+		assert.Equal(t, int64(20), health.Attempts)
+		assert.InDelta(t, 0.15, *health.ErrorRate, 1e-9)
+		assert.InDelta(t, 0.05, *health.BounceRate, 1e-9)
+		assert.True(t, health.Healthy)
+	})
+
+	t.Run("should be unhealthy when bounces spike", func(t *testing.T) {
+		health := model.NewEmailProviderHealth("ses", model.EmailProviderPrimary, true,
+			map[string]int64{model.EmailSent: 8, model.EmailBounced: 2}, thresholds)
+
+		assert.False(t, health.Healthy)
+	})
+
+	t.Run("should be unhealthy when errors spike", func(t *testing.T) {
+		health := model.NewEmailProviderHealth("ses", model.EmailProviderPrimary, true,
+			map[string]int64{model.EmailSent: 7, model.EmailFailed: 3}, thresholds)
+
+		assert.False(t, health.Healthy)
+	})
+
+	t.Run("should stay healthy with too few attempts to tell", func(t *testing.T) {
+		health := model.NewEmailProviderHealth("ses", model.EmailProviderPrimary, true,
+			map[string]int64{model.EmailFailed: 9}, thresholds)
+
+		assert.True(t, health.Healthy)
+	})
+
+	t.Run("should not rate a provider without attempts", func(t *testing.T) {
+		health := model.NewEmailProviderHealth("smtp", model.EmailProviderFallback, true, nil, thresholds)
+
+		assert.Nil(t, health.ErrorRate)
+		assert.Nil(t, health.BounceRate)
+		assert.True(t, health.Healthy)
+	})
+
+	t.Run("should never be healthy unconfigured", func(t *testing.T) {
+		assert.False(t, model.NewEmailProviderHealth("smtp", model.EmailProviderFallback, false, nil, thresholds).Healthy)
+	})
+}
+
+func TestActiveEmailProvider(t *testing.T) {
+	primary := model.EmailProviderHealth{Provider: "ses", Configured: true, Healthy: true}
+	fallback := model.EmailProviderHealth{Provider: "smtp", Configured: true, Healthy: true}
+
+	t.Run("should send through a healthy primary", func(t *testing.T) {
+		assert.Equal(t, "ses", model.ActiveEmailProvider(primary, fallback))
+	})
+
+	t.Run("should fail over to a healthy fallback", func(t *testing.T) {
+		unhealthy := primary
+		unhealthy.Healthy = false
+
+		assert.Equal(t, "smtp", model.ActiveEmailProvider(unhealthy, fallback))
+	})
+
+	t.Run("should keep the primary when the fallback is unhealthy too", func(t *testing.T) {
+		unhealthy, down := primary, fallback
+		unhealthy.Healthy, down.Healthy = false, false
+
+		assert.Equal(t, "ses", model.ActiveEmailProvider(unhealthy, down))
+	})
+
+	t.Run("should have none without providers", func(t *testing.T) {
+		assert.Empty(t, model.ActiveEmailProvider(model.EmailProviderHealth{}, model.EmailProviderHealth{}))
+	})
+}
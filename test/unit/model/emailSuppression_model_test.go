@@ -0,0 +1,23 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeSuppressedEmail(t *testing.T) {
+	assert.Equal(t, "user@example.com", model.NormalizeSuppressedEmail(" User@Example.COM "))
+}
+
+func TestSuppressionReason(t *testing.T) {
+	t.Run("should keep a complaint over a later bounce", func(t *testing.T) {
+		assert.Equal(t, model.SuppressionComplaint, model.SuppressionReason(model.SuppressionComplaint, model.SuppressionBounce))
+	})
+
+	t.Run("should take the reported reason otherwise", func(t *testing.T) {
+		assert.Equal(t, model.SuppressionComplaint, model.SuppressionReason(model.SuppressionBounce, model.SuppressionComplaint))
+		assert.Equal(t, model.SuppressionBounce, model.SuppressionReason("", model.SuppressionBounce))
+	})
+}
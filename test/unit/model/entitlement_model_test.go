@@ -0,0 +1,43 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionRejections(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should accept a paid, active, unexpired subscription", func(t *testing.T) {
+		sub := &model.UserSubscription{PaymentStatus: "success", IsActive: true, EndDate: now.Add(time.Hour)}
+
+		assert.Empty(t, model.SubscriptionRejections(sub, now))
+	})
+
+	t.Run("should list every reason a subscription does not qualify", func(t *testing.T) {
+		sub := &model.UserSubscription{PaymentStatus: "pending", IsActive: false, EndDate: now}
+
+		assert.Equal(t, []string{
+			"payment status is pending",
+			"subscription is deactivated",
+			"ended on 2026-10-16T12:00:00Z",
+		}, model.SubscriptionRejections(sub, now))
+	})
+}
+
+func TestEntitlementDiagnosis(t *testing.T) {
+	t.Run("should allow scans only while every check passed", func(t *testing.T) {
+		diagnosis := &model.EntitlementDiagnosis{}
+
+		diagnosis.AddCheck(model.EntitlementMaintenance, true, "off")
+		assert.True(t, diagnosis.CanScan)
+
+		diagnosis.AddCheck(model.EntitlementProductToken, false, "expired")
+		diagnosis.AddCheck(model.EntitlementActiveSubscription, true, "found")
+		assert.False(t, diagnosis.CanScan)
+		assert.Len(t, diagnosis.Checks, 3)
+	})
+}
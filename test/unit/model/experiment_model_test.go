@@ -0,0 +1,65 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExperimentAssignVariant(t *testing.T) {
+	experiment := model.Experiment{
+		Key: "paywall-annual-first",
+		Variants: []model.ExperimentVariant{
+			{Key: "control", Weight: 1},
+			{Key: "annual", Weight: 3, Content: map[string]string{"headline": "Hemat 2 bulan"}},
+		},
+	}
+
+	t.Run("should keep the variant of a user", func(t *testing.T) {
+		userID := uuid.New()
+		first := experiment.AssignVariant(userID)
+
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, first.Key, experiment.AssignVariant(userID).Key)
+		}
+	})
+
+	t.Run("should split users in proportion to the weights", func(t *testing.T) {
+		counts := map[string]int{}
+		for i := 0; i < 4000; i++ {
+			counts[experiment.AssignVariant(uuid.New()).Key]++
+		}
+
+		assert.InDelta(t, 1000, counts["control"], 150)
+		assert.InDelta(t, 3000, counts["annual"], 150)
+	})
+
+	t.Run("should never assign a variant without weight", func(t *testing.T) {
+		paused := model.Experiment{
+			Key: "reminder-subject",
+			Variants: []model.ExperimentVariant{
+				{Key: "control", Weight: 1},
+				{Key: "urgent", Weight: 0},
+			},
+		}
+
+		for i := 0; i < 200; i++ {
+			assert.Equal(t, "control", paused.AssignVariant(uuid.New()).Key)
+		}
+	})
+
+	t.Run("should assign nothing when every weight is zero", func(t *testing.T) {
+		empty := model.Experiment{Key: "empty", Variants: []model.ExperimentVariant{{Key: "control"}}}
+
+		assert.Nil(t, empty.AssignVariant(uuid.New()))
+	})
+}
+
+func TestExperimentVariant(t *testing.T) {
+	experiment := model.Experiment{Variants: []model.ExperimentVariant{{Key: "control"}, {Key: "short"}}}
+
+	assert.Equal(t, "short", experiment.Variant("short").Key)
+	assert.Nil(t, experiment.Variant("long"))
+}
@@ -0,0 +1,81 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDietGoal(t *testing.T) {
+	weight := 70.0
+
+	t.Run("should maintain without a target", func(t *testing.T) {
+		assert.Equal(t, model.GoalMaintain, model.DietGoal(&weight, nil))
+	})
+
+	t.Run("should read the goal from the target", func(t *testing.T) {
+		lower, higher, near := 65.0, 75.0, 70.3
+
+		assert.Equal(t, model.GoalLoseWeight, model.DietGoal(&weight, &lower))
+		assert.Equal(t, model.GoalGainWeight, model.DietGoal(&weight, &higher))
+		assert.Equal(t, model.GoalMaintain, model.DietGoal(&weight, &near))
+	})
+}
+
+func TestAllowedByDiet(t *testing.T) {
+	meat := model.BahanMakanan{KelompokMakanan: "Daging dan hasil olahannya"}
+	tofu := model.BahanMakanan{KelompokMakanan: "Kacang-kacangan, biji-bijian dan hasil olahannya"}
+	milk := model.BahanMakanan{KelompokMakanan: "Susu dan hasil olahannya"}
+
+	assert.False(t, model.AllowedByDiet(meat, []string{model.DietVegetarian}))
+	assert.True(t, model.AllowedByDiet(milk, []string{model.DietVegetarian}))
+	assert.False(t, model.AllowedByDiet(milk, []string{model.DietVegan}))
+	assert.False(t, model.AllowedByDiet(tofu, []string{model.DietNutFree}))
+	assert.True(t, model.AllowedByDiet(meat, nil))
+}
+
+func TestRankFoodAlternatives(t *testing.T) {
+	group := "Serealia dan hasil olahannya"
+	food := model.BahanMakanan{Kode: "A", KelompokMakanan: group, MentahOlahan: "Olahan", EnergiKal: 400, KarbohidratG: 80, ProteinG: 8}
+	lighter := model.BahanMakanan{Kode: "B", KelompokMakanan: group, MentahOlahan: "Olahan", EnergiKal: 200, KarbohidratG: 40, ProteinG: 6}
+	lowCarb := model.BahanMakanan{Kode: "C", KelompokMakanan: group, MentahOlahan: "Olahan", EnergiKal: 420, KarbohidratG: 30, ProteinG: 20}
+	heavier := model.BahanMakanan{Kode: "D", KelompokMakanan: group, MentahOlahan: "Olahan", EnergiKal: 500, KarbohidratG: 90}
+	raw := model.BahanMakanan{Kode: "E", KelompokMakanan: group, MentahOlahan: "Mentah", EnergiKal: 100, KarbohidratG: 20}
+	candidates := []model.BahanMakanan{food, lighter, lowCarb, heavier, raw}
+
+	t.Run("should suggest lighter foods of the same group and preparation", func(t *testing.T) {
+		suggestions := model.RankFoodAlternatives(food, candidates, model.GoalMaintain, nil, model.AlternativeSignals{}, 10)
+
+		assert.Len(t, suggestions, 2)
+		assert.Equal(t, "B", suggestions[0].Food.Kode)
+		assert.Equal(t, -200.0, suggestions[0].EnergyDiff)
+		assert.Contains(t, suggestions[0].Reasons, model.AlternativeLowerEnergy)
+		assert.Contains(t, suggestions[1].Reasons, model.AlternativeHigherProtein)
+	})
+
+	t.Run("should leave out foods with more energy to lose weight", func(t *testing.T) {
+		suggestions := model.RankFoodAlternatives(food, candidates, model.GoalLoseWeight, nil, model.AlternativeSignals{}, 10)
+
+		assert.Len(t, suggestions, 1)
+		assert.Equal(t, "B", suggestions[0].Food.Kode)
+	})
+
+	t.Run("should follow the feedback of the user", func(t *testing.T) {
+		signals := model.AlternativeSignals{User: map[string]bool{"B": false}}
+
+		suggestions := model.RankFoodAlternatives(food, candidates, model.GoalMaintain, nil, signals, 10)
+
+		assert.Len(t, suggestions, 1)
+		assert.Equal(t, "C", suggestions[0].Food.Kode)
+	})
+
+	t.Run("should rank a food found helpful higher", func(t *testing.T) {
+		signals := model.AlternativeSignals{Votes: map[string]model.AlternativeVotes{"C": {Helpful: 50}}}
+
+		suggestions := model.RankFoodAlternatives(food, candidates, model.GoalGainWeight, nil, signals, 1)
+
+		assert.Len(t, suggestions, 1)
+		assert.Equal(t, "C", suggestions[0].Food.Kode)
+	})
+}
@@ -0,0 +1,74 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func floatOf(value float64) *float64 {
+	return &value
+}
+
+func TestCompareFoods(t *testing.T) {
+	rice := model.BahanMakanan{
+		Kode: "AR010", NamaBahanMakanan: "Nasi putih", EnergiKal: 180, ProteinG: 3, LemakG: 0.3, KarbohidratG: 39.8,
+		SeratG: floatOf(0.2), KelompokMakanan: "Serealia", MentahOlahan: "Olahan",
+	}
+	brownRice := model.BahanMakanan{
+		Kode: "AR011", NamaBahanMakanan: "Nasi merah", EnergiKal: 149, ProteinG: 2.8, LemakG: 0.4, KarbohidratG: 32.5,
+		SeratG: floatOf(0.3), KelompokMakanan: "Serealia", MentahOlahan: "Olahan",
+	}
+	portions := map[string][]model.FoodPortion{
+		"AR010": {{FoodKode: "AR010", Unit: model.PortionPiring, Grams: 150}},
+	}
+
+	comparison := model.CompareFoods([]model.BahanMakanan{rice, brownRice}, portions)
+
+	t.Run("should compare per 100 g and per serving", func(t *testing.T) {
+		assert.Len(t, comparison.Foods, 2)
+		assert.Equal(t, model.PortionPiring, comparison.Foods[0].Serving.Unit)
+		assert.Equal(t, 180.0, *comparison.Foods[0].Per100g["energi_kal"])
+		assert.Equal(t, 270.0, *comparison.Foods[0].PerServing["energi_kal"])
+		assert.Equal(t, 100.0, comparison.Foods[1].Serving.Grams)
+		assert.Equal(t, 149.0, *comparison.Foods[1].PerServing["energi_kal"])
+		assert.Nil(t, comparison.Foods[1].Per100g["vitamin_c_mg"])
+	})
+
+	t.Run("should name the healthier food of each nutrient", func(t *testing.T) {
+		winners := make(map[string]string)
+		for _, winner := range comparison.Winners {
+			winners[winner.Nutrient] = winner.Kode
+		}
+
+		assert.Equal(t, "AR011", winners["energi_kal"])
+		assert.Equal(t, "AR010", winners["protein_g"])
+		assert.Equal(t, "AR010", winners["lemak_g"])
+		assert.NotContains(t, winners, "karbohidrat_g")
+		assert.NotContains(t, winners, "vitamin_c_mg")
+	})
+}
+
+func TestSuggestAlternatives(t *testing.T) {
+	friedRice := model.BahanMakanan{
+		Kode: "AR020", EnergiKal: 276, ProteinG: 3.2, LemakG: 12, NatriumNaMg: floatOf(600),
+		KelompokMakanan: "Serealia", MentahOlahan: "Olahan",
+	}
+	candidates := []model.BahanMakanan{
+		friedRice,
+		{Kode: "AR011", EnergiKal: 149, ProteinG: 2.8, LemakG: 0.4, SeratG: floatOf(0.3), KelompokMakanan: "Serealia", MentahOlahan: "Olahan"},
+		{Kode: "AR001", EnergiKal: 357, ProteinG: 8.4, LemakG: 1.7, KelompokMakanan: "Serealia", MentahOlahan: "Mentah"},
+		{Kode: "SY001", EnergiKal: 20, ProteinG: 2, KelompokMakanan: "Sayuran", MentahOlahan: "Olahan"},
+		{Kode: "AR030", EnergiKal: 400, LemakG: 20, KelompokMakanan: "Serealia", MentahOlahan: "Olahan"},
+	}
+
+	alternatives := model.SuggestAlternatives([]model.BahanMakanan{friedRice}, candidates, 5)
+
+	assert.Len(t, alternatives, 1)
+	assert.Equal(t, "AR011", alternatives[0].Food.Kode)
+	assert.Equal(t, "AR020", alternatives[0].Instead)
+	assert.Contains(t, alternatives[0].Better, "energi_kal")
+	assert.Contains(t, alternatives[0].Better, "lemak_g")
+	assert.Greater(t, alternatives[0].HealthScore, model.FoodHealthScore(friedRice))
+}
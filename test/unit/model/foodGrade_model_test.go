@@ -0,0 +1,97 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGradingProfileGrade(t *testing.T) {
+	profile := model.GradingProfile{
+		Nutrients: []model.GradedNutrient{
+			{Key: "energi_kal", Direction: model.NutrientLimit, Thresholds: []float64{100, 200, 300}, Low: 100, High: 300},
+			{Key: "protein_g", Direction: model.NutrientEncourage, Thresholds: []float64{5, 10}},
+		},
+		Grades: []model.GradeBand{
+			{Grade: "A", MaxScore: -1}, {Grade: "B", MaxScore: 0}, {Grade: "C", MaxScore: 1}, {Grade: "D", MaxScore: 2}, {Grade: "E", MaxScore: 3},
+		},
+	}
+
+	t.Run("should add the points to limit and take off those to encourage", func(t *testing.T) {
+		energy, protein := 250.0, 12.0
+
+		grade := profile.Grade(map[string]*float64{"energi_kal": &energy, "protein_g": &protein}, 3)
+
+		assert.Equal(t, 0, grade.Score)
+		assert.Equal(t, "B", grade.Grade)
+		assert.Equal(t, 3, grade.Version)
+		assert.Equal(t, []model.NutrientLight{{Nutrient: "energi_kal", Value: 250, Light: model.LightAmber}}, grade.Lights)
+	})
+
+	t.Run("should give the last grade to a score above every band", func(t *testing.T) {
+		energy := 900.0
+		profile := profile
+		profile.Grades = profile.Grades[:2]
+
+		grade := profile.Grade(map[string]*float64{"energi_kal": &energy}, 1)
+
+		assert.Equal(t, "B", grade.Grade)
+		assert.Equal(t, model.LightRed, grade.Lights[0].Light)
+	})
+
+	t.Run("should skip a nutrient without a value", func(t *testing.T) {
+		grade := profile.Grade(map[string]*float64{}, 1)
+
+		assert.Equal(t, 0, grade.Score)
+		assert.Empty(t, grade.Lights)
+	})
+}
+
+func TestGradeFood(t *testing.T) {
+	sodium, fiber, salty := 50.0, 3.0, 800.0
+	tempe := model.BahanMakanan{EnergiKal: 201, LemakG: 8.8, ProteinG: 20.8, NatriumNaMg: &sodium, SeratG: &fiber}
+	crackers := model.BahanMakanan{EnergiKal: 520, LemakG: 30, ProteinG: 1, NatriumNaMg: &salty}
+
+	assert.Equal(t, "A", model.DefaultGradingConfig.GradeFood(tempe).Grade)
+	assert.Equal(t, "D", model.DefaultGradingConfig.GradeFood(crackers).Grade)
+}
+
+func TestGradeMeal(t *testing.T) {
+	meal := model.MealHistory{Calories: 950, Fat: 40, Carbs: 130, Protein: 12}
+
+	grade := model.DefaultGradingConfig.GradeMeal(meal)
+
+	assert.Equal(t, "E", grade.Grade)
+	assert.Len(t, grade.Lights, 3)
+}
+
+func TestGradingProfileCheck(t *testing.T) {
+	t.Run("should accept the default grading", func(t *testing.T) {
+		assert.NoError(t, model.DefaultGradingConfig.Food.Check(model.IsFoodGradingKey))
+		assert.NoError(t, model.DefaultGradingConfig.Meal.Check(model.IsMealGradingKey))
+	})
+
+	t.Run("should refuse an unknown nutrient", func(t *testing.T) {
+		profile := model.DefaultGradingConfig.Food
+		profile.Nutrients = []model.GradedNutrient{{Key: "sugar_g", Direction: model.NutrientLimit}}
+
+		assert.Error(t, profile.Check(model.IsFoodGradingKey))
+	})
+
+	t.Run("should refuse thresholds out of order", func(t *testing.T) {
+		profile := model.DefaultGradingConfig.Meal
+		profile.Nutrients = []model.GradedNutrient{{Key: model.MealFat, Direction: model.NutrientLimit, Thresholds: []float64{20, 10}}}
+
+		assert.Error(t, profile.Check(model.IsMealGradingKey))
+	})
+
+	t.Run("should refuse grades out of order", func(t *testing.T) {
+		profile := model.DefaultGradingConfig.Food
+		profile.Grades = []model.GradeBand{
+			{Grade: "A", MaxScore: 0}, {Grade: "B", MaxScore: 0}, {Grade: "C", MaxScore: 1}, {Grade: "D", MaxScore: 2}, {Grade: "E", MaxScore: 3},
+		}
+
+		assert.Error(t, profile.Check(model.IsFoodGradingKey))
+	})
+}
@@ -0,0 +1,174 @@
+package model_test
+
+import (
+	"app/src/model"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertFoodUnit(t *testing.T) {
+	t.Run("should convert between units of mass", func(t *testing.T) {
+		value, err := model.ConvertFoodUnit(1.5, "g", "mg")
+
+		assert.NoError(t, err)
+		assert.InDelta(t, 1500, value, 1e-9)
+
+		value, err = model.ConvertFoodUnit(250, "µg", "mg")
+
+		assert.NoError(t, err)
+		assert.InDelta(t, 0.25, value, 1e-9)
+	})
+
+	t.Run("should convert kilojoules to kilocalories", func(t *testing.T) {
+		value, err := model.ConvertFoodUnit(418.4, "kJ", "Kal")
+
+		assert.NoError(t, err)
+		assert.InDelta(t, 100, value, 1e-9)
+	})
+
+	t.Run("should refuse to convert mass to energy", func(t *testing.T) {
+		_, err := model.ConvertFoodUnit(1, "g", "kcal")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should refuse an unknown unit", func(t *testing.T) {
+		_, err := model.ConvertFoodUnit(1, "IU", "mcg")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestParseFoodImport(t *testing.T) {
+	t.Run("should read the USDA columns and convert their units", func(t *testing.T) {
+		csv := "description,Energy (kJ),Protein (g),\"Vitamin C, total ascorbic acid (mg)\",Retinol (µg)\n" +
+			"\"Egg, whole, raw\",602.5,12.6,0,160\n"
+		mapping := model.FoodImportMappings[model.FoodImportUSDA].Merge(model.FoodImportMapping{
+			"energi_kal": {Column: "Energy (kJ)"},
+		})
+
+		rows, ignored, err := model.ParseFoodImport(strings.NewReader(csv), mapping)
+
+		assert.NoError(t, err)
+		assert.Contains(t, ignored, "Water (g)")
+		assert.Len(t, rows, 1)
+		assert.Empty(t, rows[0].Error)
+		assert.Equal(t, "Egg, whole, raw", rows[0].Name)
+		assert.InDelta(t, 144, rows[0].Values["energi_kal"], 0.01)
+		assert.InDelta(t, 12.6, rows[0].Values["protein_g"], 1e-9)
+		assert.InDelta(t, 160, rows[0].Values["retinol_vit_a_mcg"], 1e-9)
+	})
+
+	t.Run("should read semicolons, decimal commas, traces and empty values", func(t *testing.T) {
+		csv := "KODE;NAMA BAHAN;ENERGI (Kal);PROTEIN (g);SERAT (g);VIT C (mg)\n" +
+			"AR001;Beras giling, mentah;357;8,4;tr;\n"
+
+		rows, _, err := model.ParseFoodImport(strings.NewReader(csv), model.FoodImportMappings[model.FoodImportBPOM])
+
+		assert.NoError(t, err)
+		assert.Len(t, rows, 1)
+		assert.Equal(t, 2, rows[0].Line)
+		assert.Equal(t, "AR001", rows[0].Kode)
+		assert.InDelta(t, 8.4, rows[0].Values["protein_g"], 1e-9)
+		assert.Equal(t, 0.0, rows[0].Values["serat_g"])
+		assert.NotContains(t, rows[0].Values, "vitamin_c_mg")
+
+		food := rows[0].Food()
+		assert.Equal(t, 357.0, food.EnergiKal)
+		assert.NotNil(t, food.SeratG)
+		assert.Nil(t, food.VitaminCMg)
+	})
+
+	t.Run("should keep the rows it cannot read with their error", func(t *testing.T) {
+		csv := "description,Protein (g)\nTofu,abc\n,5\n"
+
+		rows, _, err := model.ParseFoodImport(strings.NewReader(csv), model.FoodImportMappings[model.FoodImportUSDA])
+
+		assert.NoError(t, err)
+		assert.Len(t, rows, 2)
+		assert.Contains(t, rows[0].Error, "protein_g")
+		assert.NotEmpty(t, rows[1].Error)
+	})
+
+	t.Run("should fail without a name column", func(t *testing.T) {
+		_, _, err := model.ParseFoodImport(strings.NewReader("Protein (g)\n5\n"), model.FoodImportMappings[model.FoodImportUSDA])
+
+		assert.ErrorIs(t, err, model.ErrFoodImportNoName)
+	})
+}
+
+func TestMatchFoodImport(t *testing.T) {
+	foods := []model.BahanMakanan{
+		{Kode: "GP002", NamaBahanMakanan: "Telur ayam"},
+		{Kode: "AR001", NamaBahanMakanan: "Beras giling, mentah"},
+	}
+	names := map[string][]model.FoodName{
+		"GP002": {{FoodKode: "GP002", Language: model.FoodLanguageEnglish, Name: "Chicken egg"}},
+	}
+
+	t.Run("should match by code first", func(t *testing.T) {
+		match := model.MatchFoodImport(model.FoodImportRow{Kode: "ar001", Name: "Something else"}, foods, names)
+
+		assert.NotNil(t, match)
+		assert.Equal(t, "AR001", match.Food.Kode)
+		assert.Equal(t, model.FoodImportMatchKode, match.By)
+	})
+
+	t.Run("should match a synonym of a food", func(t *testing.T) {
+		match := model.MatchFoodImport(model.FoodImportRow{Name: "Chicken Egg"}, foods, names)
+
+		assert.NotNil(t, match)
+		assert.Equal(t, "GP002", match.Food.Kode)
+		assert.Equal(t, model.FoodImportMatchName, match.By)
+	})
+
+	t.Run("should match a close name", func(t *testing.T) {
+		match := model.MatchFoodImport(model.FoodImportRow{Name: "Beras giling mentahh"}, foods, names)
+
+		assert.NotNil(t, match)
+		assert.Equal(t, "AR001", match.Food.Kode)
+		assert.Equal(t, model.FoodImportMatchSimilar, match.By)
+	})
+
+	t.Run("should not match a longer name containing a food", func(t *testing.T) {
+		match := model.MatchFoodImport(model.FoodImportRow{Name: "Telur ayam kampung"}, foods, names)
+
+		assert.Nil(t, match)
+	})
+
+	t.Run("should not match the same food prepared differently", func(t *testing.T) {
+		match := model.MatchFoodImport(model.FoodImportRow{Name: "Beras giling, masak"}, foods, names)
+
+		assert.Nil(t, match)
+	})
+}
+
+func TestMergeFoodImport(t *testing.T) {
+	fiber := 1.2
+	food := model.BahanMakanan{Kode: "AR001", NamaBahanMakanan: "Beras giling", EnergiKal: 357, SeratG: &fiber}
+	row := model.FoodImportRow{
+		Name:   "Rice, white, raw",
+		Values: map[string]float64{"energi_kal": 360, "serat_g": 1.3, "protein_g": 8.4},
+	}
+
+	t.Run("should only fill the missing values", func(t *testing.T) {
+		merged, changes := model.MergeFoodImport(food, row, false)
+
+		assert.Equal(t, []string{"protein_g"}, changes)
+		assert.Equal(t, 357.0, merged.EnergiKal)
+		assert.Equal(t, 1.2, *merged.SeratG)
+		assert.Equal(t, 8.4, merged.ProteinG)
+		assert.Equal(t, "Beras giling", merged.NamaBahanMakanan)
+	})
+
+	t.Run("should replace every value with overwrite without changing the original", func(t *testing.T) {
+		merged, changes := model.MergeFoodImport(food, row, true)
+
+		assert.Equal(t, []string{"energi_kal", "protein_g", "serat_g"}, changes)
+		assert.Equal(t, 360.0, merged.EnergiKal)
+		assert.Equal(t, 1.3, *merged.SeratG)
+		assert.Equal(t, 1.2, *food.SeratG)
+	})
+}
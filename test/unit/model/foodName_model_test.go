@@ -0,0 +1,135 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankFoods(t *testing.T) {
+	foods := []model.BahanMakanan{
+		{Kode: "GP001", NamaBahanMakanan: "Telur ayam kampung, rebus"},
+		{Kode: "GP002", NamaBahanMakanan: "Telur ayam"},
+		{Kode: "HR001", NamaBahanMakanan: "Tempe kedelai murni, mentah"},
+	}
+	names := map[string][]model.FoodName{
+		"GP002": {
+			{FoodKode: "GP002", Language: model.FoodLanguageEnglish, Name: "Chicken egg", Primary: true},
+			{FoodKode: "GP002", Language: model.FoodLanguageEnglish, Name: "Egg"},
+		},
+		"HR001": {
+			{FoodKode: "HR001", Language: model.FoodLanguageEnglish, Name: "Tempeh", Primary: true},
+		},
+	}
+
+	t.Run("should find a food by its English synonym and name it in English", func(t *testing.T) {
+		results := model.RankFoods("egg", model.FoodLanguageEnglish, foods, names, model.FoodRankSignals{}, 10)
+
+		assert.Len(t, results, 1)
+		assert.Equal(t, "GP002", results[0].Food.Kode)
+		assert.Equal(t, "Chicken egg", results[0].Name)
+		assert.Equal(t, model.FoodLanguageEnglish, results[0].Language)
+		assert.Equal(t, "Egg", results[0].MatchedName)
+	})
+
+	t.Run("should rank the exact Indonesian name before longer ones", func(t *testing.T) {
+		results := model.RankFoods("Telur  Ayam", model.FoodLanguageIndonesian, foods, names, model.FoodRankSignals{}, 10)
+
+		assert.Len(t, results, 2)
+		assert.Equal(t, "GP002", results[0].Food.Kode)
+		assert.Equal(t, "GP001", results[1].Food.Kode)
+	})
+
+	t.Run("should match every word in any order", func(t *testing.T) {
+		results := model.RankFoods("ayam rebus", model.FoodLanguageIndonesian, foods, names, model.FoodRankSignals{}, 10)
+
+		assert.Len(t, results, 1)
+		assert.Equal(t, "GP001", results[0].Food.Kode)
+	})
+
+	t.Run("should fall back to the Indonesian name", func(t *testing.T) {
+		results := model.RankFoods("kampung", model.FoodLanguageEnglish, foods, names, model.FoodRankSignals{}, 10)
+
+		assert.Len(t, results, 1)
+		assert.Equal(t, "Telur ayam kampung, rebus", results[0].Name)
+		assert.Equal(t, model.FoodLanguageIndonesian, results[0].Language)
+	})
+
+	t.Run("should keep the best matches within the limit", func(t *testing.T) {
+		results := model.RankFoods("telur", model.FoodLanguageIndonesian, foods, names, model.FoodRankSignals{}, 1)
+
+		assert.Len(t, results, 1)
+		assert.Equal(t, "GP002", results[0].Food.Kode)
+	})
+
+	t.Run("should find nothing for a blank search", func(t *testing.T) {
+		assert.Empty(t, model.RankFoods(" , ", model.FoodLanguageIndonesian, foods, names, model.FoodRankSignals{}, 10))
+	})
+}
+
+func TestRankFoodsFuzzy(t *testing.T) {
+	foods := []model.BahanMakanan{
+		{Kode: "GP002", NamaBahanMakanan: "Telur ayam"},
+		{Kode: "HR001", NamaBahanMakanan: "Tempe kedelai murni, mentah"},
+	}
+
+	t.Run("should tolerate a typo", func(t *testing.T) {
+		results := model.RankFoods("telr", model.FoodLanguageIndonesian, foods, nil, model.FoodRankSignals{}, 10)
+
+		assert.Len(t, results, 1)
+		assert.Equal(t, "GP002", results[0].Food.Kode)
+		assert.True(t, results[0].Fuzzy)
+	})
+
+	t.Run("should rank a name containing the search above a close one", func(t *testing.T) {
+		results := model.RankFoods("tempe", model.FoodLanguageIndonesian, foods, nil, model.FoodRankSignals{}, 10)
+
+		assert.Equal(t, "HR001", results[0].Food.Kode)
+		assert.False(t, results[0].Fuzzy)
+	})
+
+	t.Run("should not match unrelated names", func(t *testing.T) {
+		assert.Empty(t, model.RankFoods("susu", model.FoodLanguageIndonesian, foods, nil, model.FoodRankSignals{}, 10))
+	})
+}
+
+func TestRankFoodsSignals(t *testing.T) {
+	foods := []model.BahanMakanan{
+		{Kode: "GP001", NamaBahanMakanan: "Telur bebek"},
+		{Kode: "GP002", NamaBahanMakanan: "Telur ayam"},
+	}
+
+	t.Run("should rank popular foods higher", func(t *testing.T) {
+		signals := model.FoodRankSignals{Popularity: map[string]int{"GP001": 500}}
+
+		results := model.RankFoods("telur", model.FoodLanguageIndonesian, foods, nil, signals, 10)
+
+		assert.Equal(t, "GP001", results[0].Food.Kode)
+	})
+
+	t.Run("should rank the foods of the user above popular ones", func(t *testing.T) {
+		signals := model.FoodRankSignals{
+			Popularity: map[string]int{"GP001": 100000, "GP002": 1},
+			UserLogs:   map[string]int{"GP002": 1},
+		}
+
+		results := model.RankFoods("telur", model.FoodLanguageIndonesian, foods, nil, signals, 10)
+
+		assert.Equal(t, "GP002", results[0].Food.Kode)
+	})
+}
+
+func TestFoodNameSimilarity(t *testing.T) {
+	t.Run("should be 1 for the same name", func(t *testing.T) {
+		assert.Equal(t, 1.0, model.FoodNameSimilarity("Telur", "telur"))
+	})
+
+	t.Run("should compare every word with the closest word of the name", func(t *testing.T) {
+		assert.GreaterOrEqual(t, model.FoodNameSimilarity("telr ayam", "Telur ayam kampung"), model.FoodFuzzyThreshold)
+	})
+
+	t.Run("should be 0 for a blank name", func(t *testing.T) {
+		assert.Equal(t, 0.0, model.FoodNameSimilarity("telur", ""))
+	})
+}
@@ -0,0 +1,94 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertPortion(t *testing.T) {
+	portions := []model.FoodPortion{
+		{FoodKode: "AR010", Unit: model.PortionPiring, Grams: 150},
+		{FoodKode: "AR010", Unit: model.PortionMilliliter, Grams: 0.8},
+		{FoodKode: "AR010", Unit: model.PortionSendokMakan, Grams: 10},
+	}
+
+	t.Run("should convert a mass without serving sizes", func(t *testing.T) {
+		grams, approximate, err := model.ConvertPortion(0.25, model.PortionKilogram, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 250.0, grams)
+		assert.False(t, approximate)
+	})
+
+	t.Run("should convert a count with the serving size of the food", func(t *testing.T) {
+		grams, _, err := model.ConvertPortion(1.5, model.PortionPiring, portions)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 225.0, grams)
+	})
+
+	t.Run("should convert a volume with the density of the food", func(t *testing.T) {
+		grams, approximate, err := model.ConvertPortion(1, model.PortionGelas, portions)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 192.0, grams)
+		assert.False(t, approximate)
+	})
+
+	t.Run("should prefer the measure of the food to the usual one", func(t *testing.T) {
+		grams, _, err := model.ConvertPortion(2, model.PortionSendokMakan, portions)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 20.0, grams)
+	})
+
+	t.Run("should use the density of water for a food without one", func(t *testing.T) {
+		grams, approximate, err := model.ConvertPortion(2, model.PortionSendokTeh, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 10.0, grams)
+		assert.True(t, approximate)
+	})
+
+	t.Run("should refuse a count the food has no serving size for", func(t *testing.T) {
+		_, _, err := model.ConvertPortion(1, model.PortionPotong, portions)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should refuse an unknown unit", func(t *testing.T) {
+		_, _, err := model.ConvertPortion(1, "cup", portions)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestPortionOptions(t *testing.T) {
+	options := model.PortionOptions([]model.FoodPortion{{FoodKode: "GP002", Unit: model.PortionButir, Grams: 55}})
+
+	units := make(map[string]model.PortionOption)
+	for _, option := range options {
+		units[option.Unit] = option
+	}
+
+	assert.Contains(t, units, model.PortionGram)
+	assert.Contains(t, units, model.PortionGelas)
+	assert.True(t, units[model.PortionGelas].Approximate)
+	assert.Equal(t, 55.0, units[model.PortionButir].Grams)
+	assert.NotContains(t, units, model.PortionPiring)
+}
+
+func TestNutritionOfPortion(t *testing.T) {
+	fiber := 0.2
+	food := model.BahanMakanan{EnergiKal: 180, ProteinG: 3, LemakG: 0.3, KarbohidratG: 39.8, SeratG: &fiber}
+
+	nutrition := model.NutritionOfPortion(food, 150)
+
+	assert.Equal(t, 270.0, nutrition.EnergiKal)
+	assert.Equal(t, 4.5, nutrition.ProteinG)
+	assert.Equal(t, 0.45, nutrition.LemakG)
+	assert.Equal(t, 59.7, nutrition.KarbohidratG)
+	assert.Equal(t, 0.3, *nutrition.SeratG)
+}
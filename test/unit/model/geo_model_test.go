@@ -0,0 +1,22 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegionalDefaultsOf(t *testing.T) {
+	t.Run("should default unknown visitors to Indonesia", func(t *testing.T) {
+		assert.Equal(t, model.RegionalDefaults{Country: "ID", Locale: "id", Currency: model.CurrencyIDR}, model.RegionalDefaultsOf(""))
+	})
+
+	t.Run("should show visitors abroad English and their currency", func(t *testing.T) {
+		assert.Equal(t, model.RegionalDefaults{Country: "SG", Locale: "en", Currency: "SGD"}, model.RegionalDefaultsOf("SG"))
+	})
+
+	t.Run("should keep Rupiah for currencies prices are not shown in", func(t *testing.T) {
+		assert.Equal(t, model.RegionalDefaults{Country: "JP", Locale: "en", Currency: model.CurrencyIDR}, model.RegionalDefaultsOf("JP"))
+	})
+}
@@ -0,0 +1,51 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeHandle(t *testing.T) {
+	t.Run("should trim, drop the @ and lowercase", func(t *testing.T) {
+		assert.Equal(t, "budi_sehat", model.NormalizeHandle("  @Budi_Sehat "))
+	})
+}
+
+func TestHandleProblem(t *testing.T) {
+	t.Run("should accept letters, digits and underscores", func(t *testing.T) {
+		assert.Empty(t, model.HandleProblem("budi_sehat99"))
+	})
+
+	t.Run("should refuse a wrong length", func(t *testing.T) {
+		assert.NotEmpty(t, model.HandleProblem("bu"))
+		assert.NotEmpty(t, model.HandleProblem("budi_sehat_budi_sehat_budi_sehat"))
+	})
+
+	t.Run("should refuse other characters and a leading digit", func(t *testing.T) {
+		assert.NotEmpty(t, model.HandleProblem("budi.sehat"))
+		assert.NotEmpty(t, model.HandleProblem("büdi"))
+		assert.NotEmpty(t, model.HandleProblem("9budi"))
+		assert.NotEmpty(t, model.HandleProblem("_budi"))
+	})
+
+	t.Run("should refuse reserved words with digits and underscores added", func(t *testing.T) {
+		assert.Equal(t, "Handle is reserved", model.HandleProblem("admin"))
+		assert.Equal(t, "Handle is reserved", model.HandleProblem("nutribox"))
+		assert.Equal(t, "Handle is reserved", model.HandleProblem("admin_01"))
+		assert.Empty(t, model.HandleProblem("admiral"))
+	})
+}
+
+func TestHandleRenameAt(t *testing.T) {
+	t.Run("should allow the first pick at once", func(t *testing.T) {
+		assert.True(t, model.HandleRenameAt(nil, time.Hour).IsZero())
+	})
+
+	t.Run("should wait the cooldown after the last change", func(t *testing.T) {
+		changedAt := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+		assert.Equal(t, changedAt.AddDate(0, 0, 30), model.HandleRenameAt(&changedAt, 30*24*time.Hour))
+	})
+}
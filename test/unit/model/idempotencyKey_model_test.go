@@ -0,0 +1,32 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyFingerprint(t *testing.T) {
+	body := []byte(`{"plan_id":"a"}`)
+	fingerprint := model.IdempotencyFingerprint("POST", "/v1/checkout", body)
+
+	t.Run("should be the same for the same request", func(t *testing.T) {
+		assert.Equal(t, fingerprint, model.IdempotencyFingerprint("POST", "/v1/checkout", []byte(`{"plan_id":"a"}`)))
+	})
+
+	t.Run("should tell another body, path or method apart", func(t *testing.T) {
+		assert.NotEqual(t, fingerprint, model.IdempotencyFingerprint("POST", "/v1/checkout", []byte(`{"plan_id":"b"}`)))
+		assert.NotEqual(t, fingerprint, model.IdempotencyFingerprint("POST", "/v1/checkout/x/pay", body))
+		assert.NotEqual(t, fingerprint, model.IdempotencyFingerprint("PUT", "/v1/checkout", body))
+	})
+}
+
+func TestIdempotentResponseStorable(t *testing.T) {
+	assert.True(t, model.IdempotentResponseStorable(201))
+	assert.True(t, model.IdempotentResponseStorable(400))
+	assert.False(t, model.IdempotentResponseStorable(409))
+	assert.False(t, model.IdempotentResponseStorable(429))
+	assert.False(t, model.IdempotentResponseStorable(500))
+	assert.False(t, model.IdempotentResponseStorable(0))
+}
@@ -0,0 +1,47 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildInstallmentSchedule(t *testing.T) {
+	subscriptionID := uuid.New()
+	start := time.Date(2025, time.January, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should split the total into monthly installments", func(t *testing.T) {
+		schedule := model.BuildInstallmentSchedule(subscriptionID, model.IDR(120000), 12, start)
+
+		assert.Len(t, schedule, 12)
+		for i, installment := range schedule {
+			assert.Equal(t, i+1, installment.InstallmentNumber)
+			assert.Equal(t, 10000, installment.Amount)
+			assert.Equal(t, model.InstallmentPending, installment.Status)
+			assert.Equal(t, subscriptionID, installment.UserSubscriptionID)
+		}
+		assert.Equal(t, start.AddDate(0, 11, 0), schedule[11].DueDate)
+	})
+
+	t.Run("should charge the remainder on the first installment", func(t *testing.T) {
+		schedule := model.BuildInstallmentSchedule(subscriptionID, model.IDR(99000), 12, start)
+
+		total := 0
+		for _, installment := range schedule {
+			total += installment.Amount
+		}
+		assert.Equal(t, 99000, total)
+		assert.Equal(t, 8250, schedule[0].Amount)
+		assert.Equal(t, 8250, schedule[1].Amount)
+	})
+
+	t.Run("should fall back to a single installment for invalid counts", func(t *testing.T) {
+		schedule := model.BuildInstallmentSchedule(subscriptionID, model.IDR(50000), 0, start)
+
+		assert.Len(t, schedule, 1)
+		assert.Equal(t, 50000, schedule[0].Amount)
+	})
+}
@@ -0,0 +1,32 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMagicLinkUsable(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 10, 0, 0, 0, time.UTC)
+
+	t.Run("should sign in with an unused link before it expires", func(t *testing.T) {
+		link := &model.MagicLink{ExpiresAt: now.Add(time.Minute)}
+
+		assert.True(t, link.Usable(now))
+	})
+
+	t.Run("should refuse a link that was used", func(t *testing.T) {
+		usedAt := now.Add(-time.Minute)
+		link := &model.MagicLink{ExpiresAt: now.Add(time.Minute), UsedAt: &usedAt}
+
+		assert.False(t, link.Usable(now))
+	})
+
+	t.Run("should refuse a link from the moment it expires", func(t *testing.T) {
+		link := &model.MagicLink{ExpiresAt: now}
+
+		assert.False(t, link.Usable(now))
+	})
+}
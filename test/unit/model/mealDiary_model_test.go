@@ -0,0 +1,114 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiaryRange(t *testing.T) {
+	wednesday := time.Date(2025, time.March, 5, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should cover the day alone", func(t *testing.T) {
+		from, to := model.DiaryRange(model.DiaryPeriodDay, wednesday)
+
+		assert.Equal(t, wednesday, from)
+		assert.Equal(t, wednesday, to)
+	})
+
+	t.Run("should cover the week from Monday to Sunday", func(t *testing.T) {
+		from, to := model.DiaryRange(model.DiaryPeriodWeek, wednesday)
+
+		assert.Equal(t, "2025-03-03", from.Format("2006-01-02"))
+		assert.Equal(t, "2025-03-09", to.Format("2006-01-02"))
+	})
+
+	t.Run("should keep a Sunday in the week before it", func(t *testing.T) {
+		from, _ := model.DiaryRange(model.DiaryPeriodWeek, time.Date(2025, time.March, 9, 0, 0, 0, 0, time.UTC))
+
+		assert.Equal(t, "2025-03-03", from.Format("2006-01-02"))
+	})
+}
+
+func TestBuildMealDiary(t *testing.T) {
+	monday := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.UTC)
+	from, to := model.DiaryRange(model.DiaryPeriodWeek, monday)
+
+	meals := []model.MealHistory{
+		{Title: "Bubur ayam", MealTime: monday.Add(7 * time.Hour), Calories: 350.5, Protein: 12, Carbs: 50, Fat: 8},
+		{Title: "Nasi goreng", MealTime: monday.Add(12 * time.Hour), Calories: 600.25, Protein: 20, Carbs: 80, Fat: 22},
+		{Title: "Sate ayam", MealTime: monday.AddDate(0, 0, 2).Add(19 * time.Hour), Calories: 450, Protein: 35, Carbs: 10, Fat: 25},
+	}
+
+	t.Run("should list every day of the period", func(t *testing.T) {
+		diary := model.BuildMealDiary(model.DiaryPeriodWeek, meals, from, to)
+
+		assert.Equal(t, model.DiaryPeriodWeek, diary.Period)
+		assert.Equal(t, "2025-03-03", diary.From)
+		assert.Equal(t, "2025-03-09", diary.To)
+		require.Len(t, diary.Days, 7)
+		assert.Equal(t, "2025-03-04", diary.Days[1].Date)
+		assert.Empty(t, diary.Days[1].Meals)
+		assert.NotNil(t, diary.Days[1].Meals)
+	})
+
+	t.Run("should total the meals per day and over the period", func(t *testing.T) {
+		diary := model.BuildMealDiary(model.DiaryPeriodWeek, meals, from, to)
+
+		assert.Equal(t, 2, diary.Days[0].MealCount)
+		assert.Equal(t, "Bubur ayam", diary.Days[0].Meals[0].Title)
+		assert.Equal(t, 950.75, diary.Days[0].Total.Calories)
+		assert.Equal(t, 32.0, diary.Days[0].Total.Protein)
+		assert.Equal(t, 1, diary.Days[2].MealCount)
+		assert.Equal(t, 1400.75, diary.Total.Calories)
+		assert.Equal(t, 55.0, diary.Total.Fat)
+	})
+
+	t.Run("should place meals on the day of their local time", func(t *testing.T) {
+		jakarta := time.FixedZone("WIB", 7*60*60)
+		day := time.Date(2025, time.March, 4, 0, 0, 0, 0, jakarta)
+		// 20:00 UTC on the 3rd is 03:00 on the 4th in Jakarta
+		late := []model.MealHistory{{Title: "Sahur", MealTime: time.Date(2025, time.March, 3, 20, 0, 0, 0, time.UTC), Calories: 400}}
+
+		diary := model.BuildMealDiary(model.DiaryPeriodDay, late, day, day)
+
+		require.Len(t, diary.Days, 1)
+		assert.Equal(t, 1, diary.Days[0].MealCount)
+	})
+}
+
+func TestNormalizePortion(t *testing.T) {
+	quantity := func(value float64) *float64 { return &value }
+	unit := func(value string) *string { return &value }
+
+	t.Run("should allow a meal without portion", func(t *testing.T) {
+		meal := model.MealHistory{PortionGrams: quantity(100)}
+
+		assert.NoError(t, meal.NormalizePortion())
+		assert.Nil(t, meal.PortionGrams)
+	})
+
+	t.Run("should fill the grams of a mass unit", func(t *testing.T) {
+		meal := model.MealHistory{PortionQuantity: quantity(0.25), PortionUnit: unit(model.PortionKilogram)}
+
+		require.NoError(t, meal.NormalizePortion())
+		assert.Equal(t, 250.0, *meal.PortionGrams)
+	})
+
+	t.Run("should keep the grams given for a count unit", func(t *testing.T) {
+		meal := model.MealHistory{PortionQuantity: quantity(2), PortionUnit: unit(model.PortionPotong), PortionGrams: quantity(50)}
+
+		require.NoError(t, meal.NormalizePortion())
+		assert.Equal(t, 50.0, *meal.PortionGrams)
+	})
+
+	t.Run("should reject an incomplete or invalid portion", func(t *testing.T) {
+		assert.Error(t, (&model.MealHistory{PortionQuantity: quantity(1)}).NormalizePortion())
+		assert.Error(t, (&model.MealHistory{PortionQuantity: quantity(1), PortionUnit: unit("cup")}).NormalizePortion())
+		assert.Error(t, (&model.MealHistory{PortionQuantity: quantity(0), PortionUnit: unit(model.PortionGram)}).NormalizePortion())
+		assert.Error(t, (&model.MealHistory{PortionQuantity: quantity(1), PortionUnit: unit(model.PortionPiring), PortionGrams: quantity(-1)}).NormalizePortion())
+	})
+}
@@ -0,0 +1,57 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadPath(t *testing.T) {
+	t.Run("should read the path of stored and signed addresses", func(t *testing.T) {
+		for _, ref := range []string{
+			"/uploads/scans/a.jpg",
+			"uploads/scans/a.jpg",
+			"https://api.nutribox.id/uploads/scans/a.jpg",
+			"https://cdn.nutribox.id/uploads/scans/a.jpg?v=1&exp=1760000000&sig=abc",
+		} {
+			path, ok := model.UploadPath(ref)
+
+			assert.True(t, ok, ref)
+			assert.Equal(t, "/uploads/scans/a.jpg", path, ref)
+		}
+	})
+
+	t.Run("should not take other addresses for uploads", func(t *testing.T) {
+		for _, ref := range []string{"", "https://lh3.googleusercontent.com/a/photo.jpg", "/uploads/../main.go"} {
+			_, ok := model.UploadPath(ref)
+
+			assert.False(t, ok, ref)
+		}
+	})
+}
+
+func TestIsOrphanedMedia(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	referenced := map[string]bool{"/uploads/avatars/kept.png": true}
+
+	t.Run("should remove old files no record refers to", func(t *testing.T) {
+		assert.True(t, model.IsOrphanedMedia("/uploads/avatars/old.png", now.Add(-96*time.Hour), referenced, 72*time.Hour, now))
+	})
+
+	t.Run("should keep referenced files", func(t *testing.T) {
+		assert.False(t, model.IsOrphanedMedia("/uploads/avatars/kept.png", now.Add(-96*time.Hour), referenced, 72*time.Hour, now))
+	})
+
+	t.Run("should keep files within the grace period", func(t *testing.T) {
+		assert.False(t, model.IsOrphanedMedia("/uploads/avatars/new.png", now.Add(-time.Hour), referenced, 72*time.Hour, now))
+	})
+}
+
+func TestMediaArchiveKey(t *testing.T) {
+	now := time.Date(2026, 10, 16, 23, 0, 0, 0, time.FixedZone("WIB", 7*60*60))
+
+	assert.Equal(t, "orphaned-media/2026/10/16/uploads/scans/a.jpg",
+		model.MediaArchiveKey("orphaned-media/", "/uploads/scans/a.jpg", now))
+}
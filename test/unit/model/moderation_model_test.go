@@ -0,0 +1,25 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserIsSuspendedAt(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should not be suspended without a suspension", func(t *testing.T) {
+		assert.False(t, (&model.User{}).IsSuspendedAt(now))
+	})
+
+	t.Run("should be suspended until the end of the suspension", func(t *testing.T) {
+		until := now.Add(time.Hour)
+		user := &model.User{SuspendedUntil: &until}
+
+		assert.True(t, user.IsSuspendedAt(now))
+		assert.False(t, user.IsSuspendedAt(until))
+	})
+}
@@ -0,0 +1,121 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoneyString(t *testing.T) {
+	t.Run("should group Rupiah thousands with dots", func(t *testing.T) {
+		assert.Equal(t, "Rp 150.000", model.IDR(150000).String())
+		assert.Equal(t, "Rp 1.250.000", model.IDR(1250000).String())
+		assert.Equal(t, "Rp 999", model.IDR(999).String())
+		assert.Equal(t, "Rp 0", model.IDR(0).String())
+	})
+
+	t.Run("should put the sign before the symbol", func(t *testing.T) {
+		assert.Equal(t, "-Rp 25.000", model.IDR(-25000).String())
+	})
+
+	t.Run("should write minor units of currencies that have them", func(t *testing.T) {
+		assert.Equal(t, "$1,500.00", model.Money{Amount: 150000, Currency: "USD"}.String())
+		assert.Equal(t, "$0.05", model.Money{Amount: 5, Currency: "USD"}.String())
+	})
+
+	t.Run("should fall back to the currency code", func(t *testing.T) {
+		assert.Equal(t, "EUR 1,234", model.Money{Amount: 1234, Currency: "EUR"}.String())
+	})
+}
+
+func TestMoneyLocalized(t *testing.T) {
+	t.Run("should use the separators of the language", func(t *testing.T) {
+		assert.Equal(t, "Rp 49.000", model.IDR(49000).Localized("id"))
+		assert.Equal(t, "Rp 49,000", model.IDR(49000).Localized("en"))
+		assert.Equal(t, "$1.500,00", model.Money{Amount: 150000, Currency: "USD"}.Localized("id"))
+	})
+
+	t.Run("should keep the separators of the currency for other languages", func(t *testing.T) {
+		assert.Equal(t, "Rp 49.000", model.IDR(49000).Localized("fr"))
+	})
+}
+
+func TestTransactionDetailMoney(t *testing.T) {
+	t.Run("should default to Rupiah and round gateway decimals", func(t *testing.T) {
+		detail := &model.TransactionDetail{GrossAmount: "150000.00"}
+
+		assert.Equal(t, model.IDR(150000), detail.Money())
+	})
+}
+
+func TestParseMoney(t *testing.T) {
+	t.Run("should read gateway amounts without floating point", func(t *testing.T) {
+		money, err := model.ParseMoney("150000.00", model.CurrencyIDR)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.IDR(150000), money)
+	})
+
+	t.Run("should keep the minor unit of currencies that have one", func(t *testing.T) {
+		money, err := model.ParseMoney("19.99", "USD")
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.Money{Amount: 1999, Currency: "USD"}, money)
+	})
+
+	t.Run("should round extra decimals half away from zero", func(t *testing.T) {
+		up, _ := model.ParseMoney("0.125", "USD")
+		down, _ := model.ParseMoney("-0.124", "USD")
+		rupiah, _ := model.ParseMoney("1000.5", model.CurrencyIDR)
+
+		assert.Equal(t, int64(13), up.Amount)
+		assert.Equal(t, int64(-12), down.Amount)
+		assert.Equal(t, int64(1001), rupiah.Amount)
+	})
+
+	t.Run("should reject what is not an amount", func(t *testing.T) {
+		_, err := model.ParseMoney("Rp 150", model.CurrencyIDR)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	assert.Equal(t, "150000", model.IDR(150000).Decimal())
+	assert.Equal(t, "15.00", model.Money{Amount: 1500, Currency: "USD"}.Decimal())
+	assert.Equal(t, "0.05", model.Money{Amount: 5, Currency: "USD"}.Decimal())
+	assert.Equal(t, "-0.50", model.Money{Amount: -50, Currency: "USD"}.Decimal())
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	t.Run("should split without losing the remainder", func(t *testing.T) {
+		parts := model.IDR(100000).Split(3)
+
+		assert.Equal(t, []model.Money{model.IDR(33334), model.IDR(33333), model.IDR(33333)}, parts)
+	})
+
+	t.Run("should allocate in proportion to the weights", func(t *testing.T) {
+		parts := model.IDR(100).Allocate([]int64{1, 1, 1})
+		weighted := model.IDR(1000).Allocate([]int64{31, 28, 31})
+
+		assert.Equal(t, int64(34), parts[0].Amount)
+		var total int64
+		for _, part := range weighted {
+			total += part.Amount
+		}
+		assert.Equal(t, int64(1000), total)
+		assert.Equal(t, int64(311), weighted[1].Amount)
+	})
+
+	t.Run("should give everything to the first part without weights", func(t *testing.T) {
+		parts := model.IDR(500).Allocate([]int64{0, 0})
+
+		assert.Equal(t, []model.Money{model.IDR(500), model.IDR(0)}, parts)
+	})
+}
+
+func TestMoneyPercent(t *testing.T) {
+	assert.Equal(t, model.IDR(14849), model.IDR(98990).Percent(15))
+	assert.Equal(t, model.IDR(-14849), model.IDR(-98990).Percent(15))
+}
@@ -0,0 +1,72 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreferenceViews(t *testing.T) {
+	t.Run("should subscribe users to every category by default", func(t *testing.T) {
+		views := model.PreferenceViews(nil)
+
+		assert.Len(t, views, len(model.NotificationCategories))
+		for _, view := range views {
+			assert.True(t, view.Subscribed, view.Key)
+			assert.Equal(t, model.NotificationChannelEmail, view.Channel, view.Key)
+			assert.Nil(t, view.UpdatedAt)
+		}
+	})
+
+	t.Run("should apply the choices of the user", func(t *testing.T) {
+		updatedAt := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+		views := model.PreferenceViews([]model.NotificationPreference{
+			{Category: model.NotificationMarketing, Subscribed: false, UpdatedAt: updatedAt},
+		})
+
+		for _, view := range views {
+			if view.Key == model.NotificationMarketing {
+				assert.False(t, view.Subscribed)
+				assert.Equal(t, updatedAt, *view.UpdatedAt)
+			} else {
+				assert.True(t, view.Subscribed, view.Key)
+			}
+		}
+	})
+
+	t.Run("should keep required categories subscribed", func(t *testing.T) {
+		views := model.PreferenceViews([]model.NotificationPreference{
+			{Category: model.NotificationBilling, Subscribed: false},
+		})
+
+		for _, view := range views {
+			assert.True(t, view.Subscribed, view.Key)
+		}
+	})
+
+	t.Run("should send through the chosen channel the category allows", func(t *testing.T) {
+		views := model.PreferenceViews([]model.NotificationPreference{
+			{Category: model.NotificationBilling, Subscribed: true, Channel: model.NotificationChannelWhatsApp},
+			{Category: model.NotificationAccount, Subscribed: true, Channel: model.NotificationChannelSMS},
+		})
+
+		for _, view := range views {
+			switch view.Key {
+			case model.NotificationBilling:
+				assert.Equal(t, model.NotificationChannelWhatsApp, view.Channel)
+			default:
+				assert.Equal(t, model.NotificationChannelEmail, view.Channel, view.Key)
+			}
+		}
+	})
+}
+
+func TestNotificationCategoryOfTemplates(t *testing.T) {
+	for key, definition := range model.TemplateDefinitions {
+		_, ok := model.LookupNotificationCategory(definition.Category)
+
+		assert.True(t, ok, key)
+	}
+}
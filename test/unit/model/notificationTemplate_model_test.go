@@ -0,0 +1,60 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	t.Run("should fill the variables", func(t *testing.T) {
+		subject, body, err := model.RenderTemplate("Receipt {{.order_id}}", "Paid {{.amount}}",
+			map[string]string{"order_id": "ORDER-1", "amount": "Rp 150.000"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Receipt ORDER-1", subject)
+		assert.Equal(t, "Paid Rp 150.000", body)
+	})
+
+	t.Run("should reject variables the notification does not have", func(t *testing.T) {
+		_, _, err := model.RenderTemplate("Hi", "Hello {{.first_name}}", map[string]string{"amount": "Rp 1"})
+
+		assert.ErrorContains(t, err, "invalid body")
+	})
+
+	t.Run("should reject broken syntax", func(t *testing.T) {
+		_, _, err := model.RenderTemplate("Receipt {{.order_id", "Body", map[string]string{"order_id": "ORDER-1"})
+
+		assert.ErrorContains(t, err, "invalid subject")
+	})
+}
+
+func TestTemplateDefinitions(t *testing.T) {
+	t.Run("should render every built-in copy with its samples", func(t *testing.T) {
+		for key, definition := range model.TemplateDefinitions {
+			_, _, err := model.RenderTemplate(definition.Subject, definition.Body, definition.Variables)
+
+			assert.NoError(t, err, key)
+		}
+	})
+
+	t.Run("should only add the reminder note when there is one", func(t *testing.T) {
+		definition := model.TemplateDefinitions[model.TemplatePaymentReminder]
+		variables := map[string]string{"plan_name": "Premium", "amount": "Rp 1", "payment_link": "link", "expires_at": "today", "note": ""}
+
+		_, body, _ := model.RenderTemplate(definition.Subject, definition.Body, variables)
+		assert.Contains(t, body, "hingga today.")
+		assert.NotContains(t, body, "today.\n")
+
+		variables["note"] = "Diskon berakhir besok"
+		_, body, _ = model.RenderTemplate(definition.Subject, definition.Body, variables)
+		assert.Contains(t, body, "today.\n\nDiskon berakhir besok")
+	})
+
+	t.Run("should list variables in order", func(t *testing.T) {
+		names := model.TemplateDefinitions[model.TemplateReceipt].VariableNames()
+
+		assert.Equal(t, []string{"amount", "order_id", "paid_at", "plan_name"}, names)
+	})
+}
@@ -0,0 +1,49 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildNutritionReport(t *testing.T) {
+	monday := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.UTC)
+	sunday := monday.AddDate(0, 0, 6)
+
+	summaries := []model.DailyNutritionSummary{
+		{Date: monday, Calories: 1800, Protein: 90, Carbs: 200, Fat: 60, MealCount: 3},
+		{Date: monday.AddDate(0, 0, 2), Calories: 2100, Protein: 100, Carbs: 250, Fat: 70, MealCount: 4},
+		{Date: monday.AddDate(0, 0, 3), Calories: 1601, Protein: 81, Carbs: 180, Fat: 50, MealCount: 2},
+	}
+
+	t.Run("should fill every day of the range", func(t *testing.T) {
+		report := model.BuildNutritionReport(summaries, monday, sunday)
+
+		assert.Equal(t, "2025-03-03", report.From)
+		assert.Equal(t, "2025-03-09", report.To)
+		assert.Len(t, report.Days, 7)
+		assert.Equal(t, monday.AddDate(0, 0, 1), report.Days[1].Date)
+		assert.Zero(t, report.Days[1].Calories)
+		assert.Equal(t, 2100.0, report.Days[2].Calories)
+	})
+
+	t.Run("should average over the logged days only", func(t *testing.T) {
+		report := model.BuildNutritionReport(summaries, monday, sunday)
+
+		assert.Equal(t, 3, report.LoggedDays)
+		assert.Equal(t, 9, report.TotalMeals)
+		assert.Equal(t, 5501.0, report.Total.Calories)
+		assert.Equal(t, 1833.67, report.DailyAverage.Calories)
+		assert.Equal(t, 90.33, report.DailyAverage.Protein)
+	})
+
+	t.Run("should report zero averages without meals", func(t *testing.T) {
+		report := model.BuildNutritionReport(nil, monday, monday)
+
+		assert.Len(t, report.Days, 1)
+		assert.Zero(t, report.LoggedDays)
+		assert.Equal(t, model.NutritionAmounts{}, report.DailyAverage)
+	})
+}
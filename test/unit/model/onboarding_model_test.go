@@ -0,0 +1,139 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func onboardingEvent(t *testing.T, eventType string, payload model.EventPayload, at time.Time) model.DomainEvent {
+	event, err := model.NewDomainEvent(eventType, uuid.Nil, uuid.New(), payload, at)
+	assert.NoError(t, err)
+	return *event
+}
+
+func TestUserOnboarding(t *testing.T) {
+	day := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+
+	t.Run("should complete both scan and log steps with a scanned meal", func(t *testing.T) {
+		onboarding := &model.UserOnboarding{}
+		event := onboardingEvent(t, model.EventMealLogged, model.EventPayload{Day: "2026-10-01", Scans: 1}, day)
+
+		completed, err := onboarding.Apply(&event)
+
+		assert.NoError(t, err)
+		assert.True(t, completed)
+		assert.Equal(t, day, *onboarding.FirstScanAt)
+		assert.Equal(t, day, *onboarding.FirstLogAt)
+		assert.Equal(t, model.OnboardingInProgress, onboarding.Status())
+	})
+
+	t.Run("should keep the time a step was first completed", func(t *testing.T) {
+		onboarding := &model.UserOnboarding{}
+		first := onboardingEvent(t, model.EventMealLogged, model.EventPayload{Day: "2026-10-01"}, day)
+		second := onboardingEvent(t, model.EventMealLogged, model.EventPayload{Day: "2026-10-02"}, day.AddDate(0, 0, 1))
+
+		onboarding.Apply(&first)
+		completed, err := onboarding.Apply(&second)
+
+		assert.NoError(t, err)
+		assert.False(t, completed)
+		assert.Equal(t, day, *onboarding.FirstLogAt)
+		assert.Nil(t, onboarding.FirstScanAt)
+	})
+
+	t.Run("should only complete the notification step when permission is granted", func(t *testing.T) {
+		onboarding := &model.UserOnboarding{}
+		denied := onboardingEvent(t, model.EventNotificationPermission, model.EventPayload{}, day)
+		granted := onboardingEvent(t, model.EventNotificationPermission, model.EventPayload{Granted: true}, day.Add(time.Hour))
+
+		completed, _ := onboarding.Apply(&denied)
+		assert.False(t, completed)
+
+		completed, _ = onboarding.Apply(&granted)
+		assert.True(t, completed)
+		assert.Equal(t, day.Add(time.Hour), *onboarding.NotificationPermissionAt)
+	})
+
+	t.Run("should be completed with the last step", func(t *testing.T) {
+		events := []model.DomainEvent{
+			onboardingEvent(t, model.EventProfileCompleted, model.EventPayload{}, day),
+			onboardingEvent(t, model.EventMealLogged, model.EventPayload{Day: "2026-10-01"}, day.Add(time.Hour)),
+			onboardingEvent(t, model.EventNotificationPermission, model.EventPayload{Granted: true}, day.Add(2*time.Hour)),
+			onboardingEvent(t, model.EventMealScanAdded, model.EventPayload{}, day.Add(3*time.Hour)),
+		}
+
+		onboarding, err := model.BuildUserOnboarding(&model.User{}, events)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.OnboardingCompleted, onboarding.Status())
+		assert.Equal(t, day.Add(3*time.Hour), *onboarding.CompletedAt)
+	})
+
+	t.Run("should count a profile completed before events were recorded", func(t *testing.T) {
+		birthDate := day.AddDate(-30, 0, 0)
+		height, weight := 170.0, 65.0
+		gender, activity := model.Female, model.Medium
+		user := &model.User{
+			BirthDate: &birthDate, Height: &height, Weight: &weight, Gender: &gender, ActivityLevel: &activity,
+			UpdatedAt: day,
+		}
+
+		onboarding, err := model.BuildUserOnboarding(user, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, day, *onboarding.ProfileCompletedAt)
+	})
+}
+
+func TestOnboardingChecklist(t *testing.T) {
+	day := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+
+	t.Run("should point to the first open step", func(t *testing.T) {
+		onboarding := &model.UserOnboarding{ProfileCompletedAt: &day, FirstLogAt: &day}
+
+		checklist := onboarding.Checklist()
+
+		assert.Equal(t, model.OnboardingInProgress, checklist.Status)
+		assert.Equal(t, 2, checklist.CompletedSteps)
+		assert.Equal(t, 4, checklist.TotalSteps)
+		assert.Equal(t, model.OnboardingFirstScan, checklist.NextStep)
+		assert.Len(t, checklist.Steps, 4)
+		assert.True(t, checklist.Steps[0].Completed)
+		assert.False(t, checklist.Steps[1].Completed)
+	})
+
+	t.Run("should not start before the first step", func(t *testing.T) {
+		checklist := (&model.UserOnboarding{}).Checklist()
+
+		assert.Equal(t, model.OnboardingNotStarted, checklist.Status)
+		assert.Equal(t, model.OnboardingProfileCompleted, checklist.NextStep)
+	})
+}
+
+func TestNewOnboardingFunnel(t *testing.T) {
+	users := map[string]int64{
+		model.OnboardingProfileCompleted:       80,
+		model.OnboardingFirstScan:              60,
+		model.OnboardingFirstLog:               70,
+		model.OnboardingNotificationPermission: 50,
+	}
+	reached := map[string]int64{
+		model.OnboardingProfileCompleted:       80,
+		model.OnboardingFirstScan:              55,
+		model.OnboardingFirstLog:               55,
+		model.OnboardingNotificationPermission: 40,
+	}
+
+	funnel := model.NewOnboardingFunnel("2026-10-01", "2026-10-31", 100, 40, users, reached)
+
+	assert.Equal(t, int64(20), funnel.Steps[0].DropOff)
+	assert.Equal(t, int64(25), funnel.Steps[1].DropOff)
+	assert.Equal(t, int64(0), funnel.Steps[2].DropOff)
+	assert.Equal(t, int64(15), funnel.Steps[3].DropOff)
+	assert.Equal(t, 0.4, funnel.Steps[3].Rate)
+	assert.Equal(t, int64(70), funnel.Steps[2].Users)
+}
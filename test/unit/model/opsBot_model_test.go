@@ -0,0 +1,41 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOpsCommand(t *testing.T) {
+	t.Run("should parse a Slack command", func(t *testing.T) {
+		name, args := model.ParseOpsCommand("  user  jane@example.com ")
+
+		assert.Equal(t, "user", name)
+		assert.Equal(t, []string{"jane@example.com"}, args)
+	})
+
+	t.Run("should strip the Telegram slash and bot name", func(t *testing.T) {
+		name, args := model.ParseOpsCommand("/Maintenance@NutriboxBot on back at 10")
+
+		assert.Equal(t, "maintenance", name)
+		assert.Equal(t, []string{"on", "back", "at", "10"}, args)
+	})
+
+	t.Run("should handle an empty message", func(t *testing.T) {
+		name, args := model.ParseOpsCommand("   ")
+
+		assert.Empty(t, name)
+		assert.Empty(t, args)
+	})
+}
+
+func TestDailyKPIsSummary(t *testing.T) {
+	kpis := model.DailyKPIs{Date: "2025-03-01", NewUsers: 12, PaidOrders: 3, Revenue: 450000}
+
+	summary := kpis.Summary()
+
+	assert.Contains(t, summary, "Nutribox KPIs for 2025-03-01")
+	assert.Contains(t, summary, "New users: 12")
+	assert.Contains(t, summary, "Paid orders: 3 (Rp 450.000)")
+}
@@ -0,0 +1,98 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAge(t *testing.T) {
+	birthDate := time.Date(2010, 10, 16, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should count the year on the birthday", func(t *testing.T) {
+		assert.Equal(t, 16, model.Age(birthDate, time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("should not count the year before the birthday", func(t *testing.T) {
+		assert.Equal(t, 15, model.Age(birthDate, time.Date(2026, 10, 15, 23, 0, 0, 0, time.UTC)))
+		assert.Equal(t, 15, model.Age(birthDate, time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)))
+	})
+}
+
+func TestParentalConsentFor(t *testing.T) {
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	minor := time.Date(2012, 1, 1, 0, 0, 0, 0, time.UTC)
+	adult := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should require consent of users under the consent age", func(t *testing.T) {
+		assert.Equal(t, model.ParentalConsentPending, model.ParentalConsentFor("", &minor, 18, now))
+		assert.Equal(t, model.ParentalConsentPending,
+			model.ParentalConsentFor(model.ParentalConsentNotRequired, &minor, 18, now))
+	})
+
+	t.Run("should keep consent already given", func(t *testing.T) {
+		assert.Equal(t, model.ParentalConsentGranted,
+			model.ParentalConsentFor(model.ParentalConsentGranted, &minor, 18, now))
+	})
+
+	t.Run("should not require consent of adults or users without a birth date", func(t *testing.T) {
+		assert.Equal(t, model.ParentalConsentNotRequired,
+			model.ParentalConsentFor(model.ParentalConsentPending, &adult, 18, now))
+		assert.Equal(t, model.ParentalConsentNotRequired, model.ParentalConsentFor("", nil, 18, now))
+		assert.Equal(t, model.ParentalConsentNotRequired, model.ParentalConsentFor("", &minor, 0, now))
+	})
+}
+
+func TestParentalConsentArtifactSeal(t *testing.T) {
+	artifact := model.ParentalConsentArtifact{
+		RequestID:     uuid.New(),
+		UserID:        uuid.New(),
+		ChildName:     "Budi",
+		GuardianName:  "Siti Rahayu",
+		GuardianEmail: "parent@example.com",
+		Decision:      model.ConsentRequestGranted,
+		TextVersion:   model.ParentalConsentTextVersion,
+		Text:          model.ParentalConsentText,
+		RespondedAt:   time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC),
+	}
+
+	data, digest, err := artifact.Seal()
+
+	assert.NoError(t, err)
+	assert.Contains(t, data, `"guardian_name":"Siti Rahayu"`)
+	assert.Len(t, digest, 64)
+
+	artifact.Decision = model.ConsentRequestDeclined
+	_, changed, err := artifact.Seal()
+	assert.NoError(t, err)
+	assert.NotEqual(t, digest, changed)
+}
+
+func TestParentalConsentOnSelfChange(t *testing.T) {
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	minor := time.Date(2012, 1, 1, 0, 0, 0, 0, time.UTC)
+	adult := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should keep a pending consent when a minor claims to be an adult", func(t *testing.T) {
+		assert.Equal(t, model.ParentalConsentPending,
+			model.ParentalConsentOnSelfChange(model.ParentalConsentPending, &adult, 18, now))
+	})
+
+	t.Run("should still require consent when a user claims to be a minor", func(t *testing.T) {
+		assert.Equal(t, model.ParentalConsentPending,
+			model.ParentalConsentOnSelfChange(model.ParentalConsentNotRequired, &minor, 18, now))
+	})
+
+	t.Run("should keep consent already given", func(t *testing.T) {
+		assert.Equal(t, model.ParentalConsentGranted,
+			model.ParentalConsentOnSelfChange(model.ParentalConsentGranted, &minor, 18, now))
+	})
+
+	t.Run("should not require consent when the check is off", func(t *testing.T) {
+		assert.Equal(t, model.ParentalConsentNotRequired,
+			model.ParentalConsentOnSelfChange(model.ParentalConsentPending, &adult, 0, now))
+	})
+}
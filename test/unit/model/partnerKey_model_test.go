@@ -0,0 +1,80 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartnerKeyRateLimit(t *testing.T) {
+	key := &model.PartnerKey{
+		Scopes:     []string{model.PartnerScopeReadFoods, model.PartnerScopeReadEntitlements},
+		RateLimits: map[string]int{model.PartnerScopeReadEntitlements: 5},
+	}
+
+	t.Run("should use the limit set on the key", func(t *testing.T) {
+		assert.Equal(t, 5, key.RateLimit(model.PartnerScopeReadEntitlements))
+	})
+
+	t.Run("should use the limit of the tier of the partner below the one of the key", func(t *testing.T) {
+		tiered := *key
+		tiered.TierLimits = map[string]int{model.PartnerScopeReadFoods: 600, model.PartnerScopeReadEntitlements: 300}
+
+		assert.Equal(t, 600, tiered.RateLimit(model.PartnerScopeReadFoods))
+		assert.Equal(t, 5, tiered.RateLimit(model.PartnerScopeReadEntitlements))
+	})
+
+	t.Run("should fall back to the limit of the scope", func(t *testing.T) {
+		scope, ok := model.LookupPartnerScope(model.PartnerScopeReadFoods)
+
+		assert.True(t, ok)
+		assert.Equal(t, scope.RateLimit, key.RateLimit(model.PartnerScopeReadFoods))
+	})
+
+	t.Run("should only grant the scopes of the key", func(t *testing.T) {
+		assert.True(t, key.HasScope(model.PartnerScopeReadFoods))
+		assert.False(t, key.HasScope(model.PartnerScopeWriteMembers))
+	})
+}
+
+func TestPartnerKeyAcceptedTerms(t *testing.T) {
+	acceptedAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should accept the version the partner agreed to", func(t *testing.T) {
+		key := &model.PartnerKey{TermsVersion: "2026-10", TermsAcceptedAt: &acceptedAt}
+
+		assert.True(t, key.AcceptedTerms("2026-10"))
+		assert.False(t, key.AcceptedTerms("2027-01"))
+	})
+
+	t.Run("should not accept keys without an acceptance", func(t *testing.T) {
+		key := &model.PartnerKey{TermsVersion: "2026-10"}
+
+		assert.False(t, key.AcceptedTerms("2026-10"))
+	})
+}
+
+func TestHashPartnerKey(t *testing.T) {
+	hash := model.HashPartnerKey(model.PartnerKeyPrefix + "secret")
+
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, model.HashPartnerKey(model.PartnerKeyPrefix+"secret"))
+	assert.NotEqual(t, hash, model.HashPartnerKey(model.PartnerKeyPrefix+"other"))
+}
+
+func TestPartnerConsentActive(t *testing.T) {
+	consent := model.PartnerConsent{Partner: "rsharapan", GrantedAt: time.Now()}
+	assert.True(t, consent.Active())
+
+	revokedAt := time.Now()
+	consent.RevokedAt = &revokedAt
+	assert.False(t, consent.Active())
+}
+
+func TestReadObservationsIsPremium(t *testing.T) {
+	scope, ok := model.LookupPartnerScope(model.PartnerScopeReadObservations)
+	assert.True(t, ok)
+	assert.True(t, scope.Premium)
+}
@@ -0,0 +1,43 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPartnerOverage(t *testing.T) {
+	keys := []model.PartnerKeyMonthUsage{
+		{KeyID: uuid.New(), Name: "Production", Requests: 1_200_000, Throttled: 50_000},
+		{KeyID: uuid.New(), Name: "Staging", Requests: 2_500},
+	}
+	tier := &model.PartnerTier{Key: "growth", MonthlyRequests: 1_000_000, OveragePrice: 500, Currency: model.CurrencyIDR}
+
+	t.Run("should bill the served requests over the included ones per started thousand", func(t *testing.T) {
+		overage := model.NewPartnerOverage("klinikgizi", "2026-10", tier, keys)
+
+		assert.Equal(t, "growth", overage.Tier)
+		assert.Equal(t, int64(1_152_500), overage.Requests)
+		assert.Equal(t, int64(50_000), overage.Throttled)
+		assert.Equal(t, int64(152_500), overage.OverageRequests)
+		assert.Equal(t, model.IDR(153*500), overage.OverageCost)
+		assert.Len(t, overage.Keys, 2)
+	})
+
+	t.Run("should not bill usage within the tier", func(t *testing.T) {
+		overage := model.NewPartnerOverage("klinikgizi", "2026-10", tier, keys[1:])
+
+		assert.Zero(t, overage.OverageRequests)
+		assert.Zero(t, overage.OverageCost.Amount)
+	})
+
+	t.Run("should not bill tiers without a cap or partners without a tier", func(t *testing.T) {
+		uncapped := &model.PartnerTier{Key: "enterprise", OveragePrice: 500, Currency: model.CurrencyIDR}
+
+		assert.Zero(t, model.NewPartnerOverage("klinikgizi", "2026-10", uncapped, keys).OverageRequests)
+		assert.Empty(t, model.NewPartnerOverage("klinikgizi", "2026-10", nil, keys).Tier)
+		assert.Zero(t, model.NewPartnerOverage("klinikgizi", "2026-10", nil, keys).OverageCost.Amount)
+	})
+}
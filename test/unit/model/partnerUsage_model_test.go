@@ -0,0 +1,70 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPartnerUsageReport(t *testing.T) {
+	key := &model.PartnerKey{
+		Partner:    "klinikgizi",
+		Scopes:     []string{model.PartnerScopeReadFoods, model.PartnerScopeWriteMembers},
+		RateLimits: map[string]int{model.PartnerScopeReadFoods: 500},
+	}
+	tier := &model.PartnerTier{Key: "growth", MonthlyRequests: 1000}
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	today := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	days := []model.PartnerUsageDay{
+		{Day: time.Date(2026, 9, 2, 0, 0, 0, 0, time.UTC), Requests: 400, Throttled: 50, Errors: 10},
+		{Day: time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC), Requests: 700, Errors: 0},
+		{Day: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), Requests: 100, Errors: 5},
+	}
+
+	t.Run("should report each day with what was left of the month", func(t *testing.T) {
+		report := model.NewPartnerUsageReport(key, tier, model.PartnerUsageDaily,
+			time.Date(2026, 9, 29, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), days, 100, now)
+
+		assert.Equal(t, "growth", report.Tier)
+		assert.Len(t, report.Periods, 3)
+
+		quiet := report.Periods[0]
+		assert.Equal(t, "2026-09-29", quiet.Period)
+		assert.Nil(t, quiet.ErrorRate)
+		assert.Equal(t, int64(650), *quiet.Remaining)
+
+		assert.Equal(t, int64(0), *report.Periods[1].Remaining)
+		assert.Equal(t, int64(900), *report.Periods[2].Remaining)
+		assert.InDelta(t, 0.05, *report.Periods[2].ErrorRate, 1e-9)
+	})
+
+	t.Run("should add the days of a month up", func(t *testing.T) {
+		report := model.NewPartnerUsageReport(key, tier, model.PartnerUsageMonthly,
+			time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), today, days, 100, now)
+
+		assert.Len(t, report.Periods, 2)
+		september := report.Periods[0]
+		assert.Equal(t, "2026-09", september.Period)
+		assert.Equal(t, int64(1100), september.Requests)
+		assert.InDelta(t, 60.0/1100, *september.ErrorRate, 1e-9)
+		assert.Equal(t, int64(0), *september.Remaining)
+	})
+
+	t.Run("should report the quota of this month and the rate limits of the key", func(t *testing.T) {
+		report := model.NewPartnerUsageReport(key, tier, model.PartnerUsageDaily, today, today, nil, 100, now)
+
+		assert.Equal(t, int64(900), *report.Quota.Remaining)
+		assert.Equal(t, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), report.Quota.ResetsAt)
+		assert.Equal(t, map[string]int{model.PartnerScopeReadFoods: 500, model.PartnerScopeWriteMembers: 30}, report.RateLimits)
+	})
+
+	t.Run("should leave the remaining requests out without a cap", func(t *testing.T) {
+		report := model.NewPartnerUsageReport(key, nil, model.PartnerUsageDaily, today, today, nil, 100, now)
+
+		assert.Empty(t, report.Tier)
+		assert.Nil(t, report.Quota.Remaining)
+		assert.Nil(t, report.Periods[0].Remaining)
+	})
+}
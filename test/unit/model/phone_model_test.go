@@ -0,0 +1,35 @@
+package model_test
+
+import (
+	"app/src/messaging"
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdvancePhoneMessageStatus(t *testing.T) {
+	t.Run("should move forward", func(t *testing.T) {
+		assert.Equal(t, messaging.StatusSent, model.AdvancePhoneMessageStatus(messaging.StatusQueued, messaging.StatusSent))
+		assert.Equal(t, messaging.StatusRead, model.AdvancePhoneMessageStatus(messaging.StatusDelivered, messaging.StatusRead))
+		assert.Equal(t, messaging.StatusFailed, model.AdvancePhoneMessageStatus(messaging.StatusSent, messaging.StatusFailed))
+	})
+
+	t.Run("should ignore a late callback of an earlier status", func(t *testing.T) {
+		assert.Equal(t, messaging.StatusDelivered, model.AdvancePhoneMessageStatus(messaging.StatusDelivered, messaging.StatusSent))
+	})
+
+	t.Run("should ignore unknown statuses", func(t *testing.T) {
+		assert.Equal(t, messaging.StatusSent, model.AdvancePhoneMessageStatus(messaging.StatusSent, "unknown"))
+	})
+}
+
+func TestPhoneOTPUsable(t *testing.T) {
+	now := time.Now()
+
+	assert.True(t, (&model.PhoneOTP{ExpiresAt: now.Add(time.Minute)}).Usable(now, 5))
+	assert.False(t, (&model.PhoneOTP{ExpiresAt: now.Add(-time.Second)}).Usable(now, 5))
+	assert.False(t, (&model.PhoneOTP{ExpiresAt: now.Add(time.Minute), Attempts: 5}).Usable(now, 5))
+	assert.False(t, (&model.PhoneOTP{ExpiresAt: now.Add(time.Minute), UsedAt: &now}).Usable(now, 5))
+}
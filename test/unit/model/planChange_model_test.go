@@ -0,0 +1,100 @@
+package model_test
+
+import (
+	"app/src/model"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffPlans(t *testing.T) {
+	plan := model.SubscriptionPlan{Name: "Premium", Price: 50000, Currency: model.CurrencyIDR, ValidityDays: 30, Features: model.PlanFeatures{ScanAI: true}}
+
+	t.Run("should list the changed terms", func(t *testing.T) {
+		changed := plan
+		changed.Price = 5000
+		changed.Features = model.PlanFeatures{ScanAI: true, Chatbot: true}
+
+		changes := model.DiffPlans(&plan, &changed)
+
+		assert.Len(t, changes, 2)
+		assert.Equal(t, "price", changes[0].Field)
+		assert.JSONEq(t, `50000`, string(changes[0].From))
+		assert.JSONEq(t, `5000`, string(changes[0].To))
+		assert.Equal(t, "features", changes[1].Field)
+		assert.JSONEq(t, `{"scan_ai":true,"scan_calorie":false,"chatbot":true,"bmi_check":false,"weight_tracking":false,"health_info":false}`,
+			string(changes[1].To))
+	})
+
+	t.Run("should ignore locations of times", func(t *testing.T) {
+		from := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+		inJakarta := from.In(time.FixedZone("WIB", 7*60*60))
+		before := plan
+		before.AvailableFrom = &from
+		after := plan
+		after.AvailableFrom = &inJakarta
+
+		assert.Empty(t, model.DiffPlans(&before, &after))
+	})
+
+	t.Run("should diff a created plan against an empty plan", func(t *testing.T) {
+		changes := model.DiffPlans(nil, &plan)
+
+		assert.NotEmpty(t, changes)
+		assert.Equal(t, "name", changes[0].Field)
+		assert.JSONEq(t, `""`, string(changes[0].From))
+		assert.JSONEq(t, `"Premium"`, string(changes[0].To))
+	})
+}
+
+func TestPlanChangeRevert(t *testing.T) {
+	until := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+	before := model.SubscriptionPlan{Name: "Premium", Price: 50000, Features: model.PlanFeatures{ScanAI: true}}
+	after := before
+	after.Price = 5000
+	after.AvailableUntil = &until
+
+	t.Run("should set the changed terms back", func(t *testing.T) {
+		change := model.PlanChange{Action: model.PlanChangeUpdate, Changes: model.DiffPlans(&before, &after)}
+		plan := after
+		plan.Name = "Premium Plus"
+
+		assert.NoError(t, change.Revert(&plan))
+		assert.Equal(t, 50000, plan.Price)
+		assert.Nil(t, plan.AvailableUntil)
+		assert.Equal(t, "Premium Plus", plan.Name)
+	})
+
+	t.Run("should revert a change read back from its JSON", func(t *testing.T) {
+		stored, err := json.Marshal(model.DiffPlans(&before, &after))
+		assert.NoError(t, err)
+		change := model.PlanChange{Action: model.PlanChangeUpdate}
+		assert.NoError(t, json.Unmarshal(stored, &change.Changes))
+		plan := after
+
+		assert.NoError(t, change.Revert(&plan))
+		assert.Equal(t, 50000, plan.Price)
+	})
+
+	t.Run("should not overwrite a term changed again since", func(t *testing.T) {
+		change := model.PlanChange{Action: model.PlanChangeUpdate, Changes: model.DiffPlans(&before, &after)}
+		plan := after
+		plan.Price = 7500
+
+		assert.Error(t, change.Revert(&plan))
+		assert.Equal(t, 7500, plan.Price)
+		assert.Equal(t, &until, plan.AvailableUntil)
+	})
+}
+
+func TestPlanChangeCanRollback(t *testing.T) {
+	changes := []model.PlanFieldChange{{Field: "price", From: json.RawMessage(`1`), To: json.RawMessage(`2`)}}
+
+	assert.True(t, (&model.PlanChange{Action: model.PlanChangeUpdate, Changes: changes}).CanRollback())
+	assert.True(t, (&model.PlanChange{Action: model.PlanChangeRollback, Changes: changes}).CanRollback())
+	assert.False(t, (&model.PlanChange{Action: model.PlanChangeSunset, Changes: changes}).CanRollback())
+	assert.False(t, (&model.PlanChange{Action: model.PlanChangeCreate, Changes: changes}).CanRollback())
+	assert.False(t, (&model.PlanChange{Action: model.PlanChangeUpdate}).CanRollback())
+}
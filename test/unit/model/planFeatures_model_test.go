@@ -0,0 +1,49 @@
+package model_test
+
+import (
+	"app/src/model"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanFeatures(t *testing.T) {
+	features := model.PlanFeatures{ScanAI: true, HealthInfo: true}
+
+	t.Run("should report the features the plan includes", func(t *testing.T) {
+		assert.True(t, features.Has(model.FeatureScanAI))
+		assert.True(t, features.Has(model.FeatureHealthInfo))
+		assert.False(t, features.Has(model.FeatureChatbot))
+		assert.False(t, features.Has("teleport"))
+	})
+
+	t.Run("should list every feature by key", func(t *testing.T) {
+		assert.Equal(t, map[string]bool{
+			"scan_ai": true, "scan_calorie": false, "chatbot": false,
+			"bmi_check": false, "weight_tracking": false, "health_info": true,
+		}, features.Map())
+	})
+
+	t.Run("should drop unknown keys of a stored plan", func(t *testing.T) {
+		var stored model.PlanFeatures
+		assert.NoError(t, json.Unmarshal([]byte(`{"chatbot": true, "legacy_export": true}`), &stored))
+
+		assert.Equal(t, model.PlanFeatures{Chatbot: true}, stored)
+	})
+}
+
+func TestParsePlanFeatures(t *testing.T) {
+	t.Run("should read the features of a request", func(t *testing.T) {
+		features, err := model.ParsePlanFeatures(map[string]bool{"bmi_check": true, "chatbot": false})
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.PlanFeatures{BMICheck: true}, features)
+	})
+
+	t.Run("should refuse keys that are not features", func(t *testing.T) {
+		_, err := model.ParsePlanFeatures(map[string]bool{"scan_ai": true, "teleport": true, "chat_bot": true})
+
+		assert.EqualError(t, err, "unknown features: chat_bot, teleport")
+	})
+}
@@ -0,0 +1,66 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPlanSnapshot(t *testing.T) {
+	takenAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should copy the price, features and limits of the plan", func(t *testing.T) {
+		plan := model.SubscriptionPlan{
+			ID: uuid.New(), Name: "Premium", Price: 150000, Currency: model.CurrencyIDR, ValidityDays: 30,
+			Features: model.PlanFeatures{ScanAI: true, Chatbot: true}, AIscanLimit: 100, ChatMessageLimit: 30, VoiceLogLimit: -1,
+		}
+
+		snapshot := model.NewPlanSnapshot(&plan, takenAt)
+		assert.Equal(t, plan.ID, snapshot.PlanID)
+		assert.Equal(t, model.PlanFeatures{ScanAI: true, Chatbot: true}, snapshot.Features)
+		assert.Equal(t, 100, snapshot.AIscanLimit)
+		assert.Equal(t, -1, snapshot.VoiceLogLimit)
+		assert.Equal(t, "Rp 150.000", snapshot.PriceMoney().String())
+		assert.Equal(t, takenAt, snapshot.TakenAt)
+	})
+}
+
+func TestUserSubscriptionPurchasedPlan(t *testing.T) {
+	plan := model.SubscriptionPlan{
+		ID: uuid.New(), Name: "Premium", Price: 150000, Currency: model.CurrencyIDR, Description: "Paket premium",
+		Features: model.PlanFeatures{ScanAI: true}, AIscanLimit: 100, ChatMessageLimit: 30, VoiceLogLimit: 30,
+	}
+	snapshot := model.NewPlanSnapshot(&plan, time.Now())
+
+	// The plan was edited after it was sold
+	edited := plan
+	edited.Price = 200000
+	edited.Features = model.PlanFeatures{}
+	edited.AIscanLimit = 50
+
+	t.Run("should keep the terms the subscription was sold with", func(t *testing.T) {
+		subscription := model.UserSubscription{PlanID: plan.ID, Plan: edited, PlanSnapshot: snapshot}
+
+		purchased := subscription.PurchasedPlan()
+		assert.Equal(t, 150000, purchased.Price)
+		assert.Equal(t, 100, purchased.AIscanLimit)
+		assert.True(t, purchased.Features.Has(model.FeatureScanAI))
+		assert.Equal(t, "Paket premium", purchased.Description)
+	})
+
+	t.Run("should follow the plan without a snapshot", func(t *testing.T) {
+		subscription := model.UserSubscription{PlanID: plan.ID, Plan: edited}
+
+		assert.Equal(t, edited, subscription.PurchasedPlan())
+	})
+
+	t.Run("should ignore the snapshot of a previous plan", func(t *testing.T) {
+		other := model.SubscriptionPlan{ID: uuid.New(), AIscanLimit: 10}
+		subscription := model.UserSubscription{PlanID: other.ID, Plan: other, PlanSnapshot: snapshot}
+
+		assert.Equal(t, 10, subscription.PurchasedPlan().AIscanLimit)
+	})
+}
@@ -0,0 +1,50 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanSunsetReportTally(t *testing.T) {
+	t.Run("should count the unprocessed subscriptions as not resolved", func(t *testing.T) {
+		report := model.PlanSunsetReport{Unprocessed: 2}
+		report.Tally(map[string]int64{
+			model.PlanMigrationNotified: 3,
+			model.PlanMigrationMigrated: 4,
+			model.PlanMigrationLapsed:   1,
+		})
+
+		assert.Equal(t, int64(10), report.Total)
+		assert.InDelta(t, 0.5, report.Progress, 1e-9)
+		if assert.NotNil(t, report.MigrationRate) {
+			assert.InDelta(t, 0.8, *report.MigrationRate, 1e-9)
+		}
+		assert.False(t, report.Done)
+	})
+
+	t.Run("should be done once every subscription is resolved", func(t *testing.T) {
+		report := model.PlanSunsetReport{}
+		report.Tally(map[string]int64{model.PlanMigrationLapsed: 2})
+
+		assert.Equal(t, 1.0, report.Progress)
+		assert.True(t, report.Done)
+	})
+
+	t.Run("should have no migration rate before any subscription is resolved", func(t *testing.T) {
+		report := model.PlanSunsetReport{Unprocessed: 1}
+		report.Tally(map[string]int64{model.PlanMigrationNotified: 1})
+
+		assert.Nil(t, report.MigrationRate)
+		assert.Equal(t, 0.0, report.Progress)
+	})
+
+	t.Run("should be done for a plan without subscribers", func(t *testing.T) {
+		report := model.PlanSunsetReport{}
+		report.Tally(map[string]int64{})
+
+		assert.Equal(t, 1.0, report.Progress)
+		assert.True(t, report.Done)
+	})
+}
@@ -0,0 +1,67 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrivacySettings(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("should share nothing by default", func(t *testing.T) {
+		settings := model.DefaultPrivacySettings(userID)
+
+		assert.False(t, settings.ProfileVisibleTo(uuid.New()))
+		assert.True(t, settings.ProfileVisibleTo(userID))
+		assert.False(t, settings.CoachCanSee(model.CoachSharingSummary))
+	})
+
+	t.Run("should show a public profile to everyone", func(t *testing.T) {
+		settings := model.DefaultPrivacySettings(userID)
+		settings.ProfileVisibility = model.ProfilePublic
+
+		assert.True(t, settings.ProfileVisibleTo(uuid.New()))
+	})
+
+	t.Run("should let coaches see up to the shared level", func(t *testing.T) {
+		settings := model.DefaultPrivacySettings(userID)
+		settings.CoachDataSharing = model.CoachSharingSummary
+
+		assert.True(t, settings.CoachCanSee(model.CoachSharingSummary))
+		assert.False(t, settings.CoachCanSee(model.CoachSharingFull))
+
+		settings.CoachDataSharing = model.CoachSharingFull
+		assert.True(t, settings.CoachCanSee(model.CoachSharingSummary))
+		assert.True(t, settings.CoachCanSee(model.CoachSharingFull))
+	})
+
+	t.Run("should refuse unknown levels only", func(t *testing.T) {
+		settings := model.DefaultPrivacySettings(userID)
+		settings.CoachDataSharing = model.CoachSharingFull
+
+		assert.False(t, settings.CoachCanSee("everything"))
+		assert.True(t, settings.CoachCanSee(model.CoachSharingNone))
+	})
+}
+
+func TestPrivacySettingsProfileSeenBy(t *testing.T) {
+	userID := uuid.New()
+	profile := model.PublicProfile{Handle: "budi_s", Name: "Budi Santoso", CurrentStreak: 12}
+
+	t.Run("should show the handle alone to those the profile is hidden from", func(t *testing.T) {
+		settings := model.DefaultPrivacySettings(userID)
+
+		assert.Equal(t, model.PublicProfile{Handle: "budi_s"}, settings.ProfileSeenBy(profile, uuid.New()))
+		assert.Equal(t, profile, settings.ProfileSeenBy(profile, userID))
+	})
+
+	t.Run("should show a public profile whole", func(t *testing.T) {
+		settings := model.DefaultPrivacySettings(userID)
+		settings.ProfileVisibility = model.ProfilePublic
+
+		assert.Equal(t, profile, settings.ProfileSeenBy(profile, uuid.New()))
+	})
+}
@@ -0,0 +1,136 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProrate(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	basic := model.SubscriptionPlan{ID: uuid.New(), Name: "Basic", Price: 30000, Currency: model.CurrencyIDR, ValidityDays: 30}
+	premium := model.SubscriptionPlan{ID: uuid.New(), Name: "Premium", Price: 90000, Currency: model.CurrencyIDR, ValidityDays: 30}
+	subscription := func(plan model.SubscriptionPlan, source string) *model.UserSubscription {
+		return &model.UserSubscription{
+			PlanID: plan.ID, Plan: plan, Source: source, PaymentStatus: "success", IsActive: true,
+			StartDate: start, EndDate: start.AddDate(0, 0, plan.ValidityDays),
+		}
+	}
+
+	t.Run("should credit the unused days of an upgrade against the new price", func(t *testing.T) {
+		now := start.AddDate(0, 0, 10)
+		proration := model.Prorate(subscription(basic, model.SourceMidtrans), &premium, now)
+
+		assert.Equal(t, 30, proration.PeriodDays)
+		assert.Equal(t, 20, proration.UnusedDays)
+		assert.Equal(t, 20000, proration.Credit)
+		assert.Equal(t, 70000, proration.AmountDue)
+		assert.Equal(t, 0, proration.WalletCredit)
+		assert.Equal(t, now, proration.StartDate)
+		assert.Equal(t, now.AddDate(0, 0, 30), proration.EndDate)
+	})
+
+	t.Run("should return the credit a downgrade does not use up", func(t *testing.T) {
+		proration := model.Prorate(subscription(premium, model.SourceMidtrans), &basic, start.AddDate(0, 0, 5))
+
+		assert.Equal(t, 75000, proration.Credit)
+		assert.Equal(t, 0, proration.AmountDue)
+		assert.Equal(t, 45000, proration.WalletCredit)
+	})
+
+	t.Run("should count a started day as used", func(t *testing.T) {
+		proration := model.Prorate(subscription(basic, model.SourceMidtrans), &premium, start.AddDate(0, 0, 10).Add(time.Hour))
+
+		assert.Equal(t, 20, proration.UnusedDays)
+	})
+
+	t.Run("should credit what the subscription was sold for", func(t *testing.T) {
+		sold := subscription(basic, model.SourceMidtrans)
+		sold.PlanSnapshot = model.NewPlanSnapshot(&basic, start)
+		sold.Plan.Price = 60000
+
+		proration := model.Prorate(sold, &premium, start)
+		assert.Equal(t, 30000, proration.Credit)
+	})
+
+	t.Run("should credit nothing for complimentary subscriptions", func(t *testing.T) {
+		proration := model.Prorate(subscription(basic, model.SourceAdminComp), &premium, start.AddDate(0, 0, 10))
+
+		assert.Equal(t, 0, proration.Credit)
+		assert.Equal(t, 90000, proration.AmountDue)
+	})
+}
+
+func TestUserSubscriptionCanProrate(t *testing.T) {
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	paid := model.UserSubscription{Source: model.SourceMidtrans, PaymentStatus: "success", IsActive: true, EndDate: now.AddDate(0, 0, 1)}
+
+	t.Run("should prorate current paid subscriptions", func(t *testing.T) {
+		assert.True(t, paid.CanProrate(now))
+	})
+
+	t.Run("should not prorate installments, store subscriptions or expired ones", func(t *testing.T) {
+		installment := paid
+		installment.IsInstallment = true
+		store := paid
+		store.Source = model.SourceAppleIAP
+		expired := paid
+		expired.EndDate = now
+
+		assert.False(t, installment.CanProrate(now))
+		assert.False(t, store.CanProrate(now))
+		assert.False(t, expired.CanProrate(now))
+	})
+}
+
+func TestUserSubscriptionCanUpgradeTo(t *testing.T) {
+	now := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+	basic := model.SubscriptionPlan{ID: uuid.New(), Price: 30000, Currency: model.CurrencyIDR, ValidityDays: 30}
+	premium := model.SubscriptionPlan{ID: uuid.New(), Price: 90000, Currency: model.CurrencyIDR, ValidityDays: 30}
+	premiumUSD := model.SubscriptionPlan{ID: uuid.New(), Price: 900, Currency: "USD", ValidityDays: 30}
+	subscription := func(plan model.SubscriptionPlan) *model.UserSubscription {
+		return &model.UserSubscription{
+			PlanID: plan.ID, Plan: plan, Source: model.SourceMidtrans, PaymentStatus: "success", IsActive: true,
+			StartDate: now.AddDate(0, 0, -5), EndDate: now.AddDate(0, 0, 25),
+		}
+	}
+
+	t.Run("should upgrade to a pricier plan", func(t *testing.T) {
+		assert.True(t, subscription(basic).CanUpgradeTo(&premium, now))
+	})
+
+	t.Run("should not downgrade or stay on the same plan", func(t *testing.T) {
+		assert.False(t, subscription(premium).CanUpgradeTo(&basic, now))
+		assert.False(t, subscription(basic).CanUpgradeTo(&basic, now))
+	})
+
+	t.Run("should not upgrade across currencies", func(t *testing.T) {
+		assert.False(t, subscription(basic).CanUpgradeTo(&premiumUSD, now))
+	})
+
+	t.Run("should not upgrade installment subscriptions", func(t *testing.T) {
+		installment := subscription(basic)
+		installment.IsInstallment = true
+
+		assert.False(t, installment.CanUpgradeTo(&premium, now))
+	})
+}
+
+func TestProrationDescription(t *testing.T) {
+	proration := model.Proration{
+		Currency: model.CurrencyIDR, PeriodDays: 30, UnusedDays: 20, Credit: 20000, NewPrice: 90000, AmountDue: 70000,
+	}
+
+	t.Run("should not mention a waiver of an amount that is due", func(t *testing.T) {
+		assert.NotContains(t, proration.Description("Basic", "Premium"), "waived")
+	})
+
+	t.Run("should record the amount due an admin waived", func(t *testing.T) {
+		proration.Comped = true
+
+		assert.Contains(t, proration.Description("Basic", "Premium"), "due waived")
+	})
+}
@@ -0,0 +1,95 @@
+package model_test
+
+import (
+	"app/src/model"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcerpt(t *testing.T) {
+	t.Run("should keep short text whole", func(t *testing.T) {
+		assert.Equal(t, "Eat more vegetables.", model.Excerpt("<p>Eat more <b>vegetables</b>.</p>", 200))
+	})
+
+	t.Run("should drop markup and collapse whitespace", func(t *testing.T) {
+		assert.Equal(t, "Fish & rice", model.Excerpt("<h1>Fish &amp;</h1>\n\n  rice", 200))
+	})
+
+	t.Run("should cut long text at a word", func(t *testing.T) {
+		excerpt := model.Excerpt("Protein helps your muscles recover after exercise", 20)
+
+		assert.Equal(t, "Protein helps your…", excerpt)
+		assert.LessOrEqual(t, len([]rune(excerpt)), 20)
+	})
+}
+
+func TestPublicContentURL(t *testing.T) {
+	assert.Equal(t, "https://nutribox.id/articles/eat-well",
+		model.PublicContentURL("https://nutribox.id/", model.PublicContentArticle, "eat-well"))
+}
+
+func TestNewPublicArticle(t *testing.T) {
+	publishedAt := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	article := &model.Article{
+		Title:       "Eat well",
+		Slug:        "eat-well",
+		Content:     "<p>Balanced meals</p>",
+		PublishedAt: &publishedAt,
+		Category:    &model.ArticleCategory{Name: "Nutrition"},
+	}
+
+	t.Run("should leave the content out of lists", func(t *testing.T) {
+		public := model.NewPublicArticle(article, "https://nutribox.id", false)
+
+		assert.Equal(t, "Nutrition", public.Category)
+		assert.Equal(t, "Balanced meals", public.Excerpt)
+		assert.Empty(t, public.Content)
+		assert.Equal(t, "https://nutribox.id/articles/eat-well", public.URL)
+	})
+
+	t.Run("should keep the content of a single article", func(t *testing.T) {
+		public := model.NewPublicArticle(article, "https://nutribox.id", true)
+
+		assert.Equal(t, "<p>Balanced meals</p>", public.Content)
+	})
+}
+
+func TestPublicFeeds(t *testing.T) {
+	publishedAt := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	items := []model.PublicFeedItem{{
+		Kind:        model.PublicContentRecipe,
+		Title:       "Gado-gado",
+		Summary:     "Vegetables with peanut sauce",
+		Category:    "Main Course",
+		URL:         "https://nutribox.id/recipes/gado-gado",
+		PublishedAt: publishedAt,
+		UpdatedAt:   publishedAt.AddDate(0, 0, 2),
+	}}
+
+	t.Run("should write a JSON feed", func(t *testing.T) {
+		feed := model.NewJSONFeed("Nutribox", "https://nutribox.id", "https://api.nutribox.id/v1/public/feed.json", items)
+
+		assert.Equal(t, "https://jsonfeed.org/version/1.1", feed.Version)
+		assert.Len(t, feed.Items, 1)
+		assert.Equal(t, items[0].URL, feed.Items[0].ID)
+		assert.Equal(t, []string{"recipes", "Main Course"}, feed.Items[0].Tags)
+		assert.Empty(t, feed.Items[0].Image)
+	})
+
+	t.Run("should write an RSS channel", func(t *testing.T) {
+		body, err := xml.Marshal(model.NewRSSFeed("Nutribox", "Recipes", "https://nutribox.id", items))
+
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), `<rss version="2.0">`)
+		assert.Contains(t, string(body), "<pubDate>Fri, 01 May 2026 08:00:00 +0000</pubDate>")
+	})
+
+	t.Run("should write a sitemap", func(t *testing.T) {
+		sitemap := model.NewSitemap(items)
+
+		assert.Equal(t, []model.SitemapURL{{Loc: items[0].URL, LastMod: "2026-05-03"}}, sitemap.URLs)
+	})
+}
@@ -0,0 +1,51 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPublicPlan(t *testing.T) {
+	plan := &model.SubscriptionPlanResponse{
+		ID:                uuid.New(),
+		Name:              "Premium Tahunan",
+		Price:             480000,
+		Currency:          model.CurrencyIDR,
+		Features:          map[string]bool{"ai_scan": true},
+		ValidityDays:      365,
+		AIscanLimit:       -1,
+		ChatMessageLimit:  30,
+		VoiceLogLimit:     0,
+		AllowInstallments: true,
+		InstallmentCount:  12,
+		InstallmentPrice:  40000,
+	}
+
+	t.Run("should format prices for the language", func(t *testing.T) {
+		public := model.NewPublicPlan(plan, false, "en")
+
+		assert.Equal(t, "Rp 480,000", public.PriceFormatted)
+		assert.Equal(t, "Rp 40,000", public.InstallmentPriceFormatted)
+		assert.Equal(t, 480000, public.Price)
+	})
+
+	t.Run("should show the limits of plans that share them", func(t *testing.T) {
+		public := model.NewPublicPlan(plan, false, "id")
+
+		if assert.NotNil(t, public.AIscanLimit) && assert.NotNil(t, public.VoiceLogLimit) {
+			assert.Equal(t, -1, *public.AIscanLimit)
+			assert.Equal(t, 0, *public.VoiceLogLimit)
+		}
+	})
+
+	t.Run("should leave out the limits of plans that keep them private", func(t *testing.T) {
+		public := model.NewPublicPlan(plan, true, "id")
+
+		assert.Nil(t, public.AIscanLimit)
+		assert.Nil(t, public.ChatMessageLimit)
+		assert.Nil(t, public.VoiceLogLimit)
+	})
+}
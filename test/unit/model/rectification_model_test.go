@@ -0,0 +1,47 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRectificationRecord(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("should record a changed value with the one it replaced", func(t *testing.T) {
+		rectification := &model.Rectification{}
+		height := 170.0
+
+		changed := rectification.Record(model.RectificationProfile, userID, "height", &height, 171.5)
+
+		assert.True(t, changed)
+		assert.Equal(t, []model.PersonalDataChange{
+			{Subsystem: model.RectificationProfile, RecordID: userID, Field: "height", Before: 170.0, After: 171.5},
+		}, rectification.Changes)
+	})
+
+	t.Run("should skip values that are already correct", func(t *testing.T) {
+		rectification := &model.Rectification{}
+		gender := model.Female
+		birthDate := time.Date(1995, 3, 1, 0, 0, 0, 0, time.UTC)
+
+		assert.False(t, rectification.Record(model.RectificationProfile, userID, "gender", &gender, model.Female))
+		assert.False(t, rectification.Record(model.RectificationProfile, userID, "birth_date", &birthDate, birthDate))
+		assert.Empty(t, rectification.Changes)
+	})
+
+	t.Run("should record a value set for the first time", func(t *testing.T) {
+		rectification := &model.Rectification{}
+		var medicalHistory *string
+
+		changed := rectification.Record(model.RectificationProfile, userID, "medical_history", medicalHistory, "Lactose intolerant")
+
+		assert.True(t, changed)
+		assert.Nil(t, rectification.Changes[0].Before)
+		assert.Equal(t, "Lactose intolerant", rectification.Changes[0].After)
+	})
+}
@@ -0,0 +1,48 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRetentionPolicy(t *testing.T) {
+	now := time.Date(2026, 10, 16, 2, 0, 0, 0, time.UTC)
+
+	t.Run("should cut off months before now", func(t *testing.T) {
+		policy := model.NewRetentionPolicy(model.RetentionScanImages, "", 12, 0, now)
+
+		assert.True(t, policy.Enabled)
+		assert.Equal(t, time.Date(2025, 10, 16, 2, 0, 0, 0, time.UTC), policy.Cutoff)
+	})
+
+	t.Run("should cut off days before now", func(t *testing.T) {
+		policy := model.NewRetentionPolicy(model.RetentionLogs, "", 0, 90, now)
+
+		assert.True(t, policy.Enabled)
+		assert.Equal(t, time.Date(2026, 7, 18, 2, 0, 0, 0, time.UTC), policy.Cutoff)
+	})
+
+	t.Run("should keep data forever without a period", func(t *testing.T) {
+		policy := model.NewRetentionPolicy(model.RetentionInactiveAccounts, "", 0, 0, now)
+
+		assert.False(t, policy.Enabled)
+		assert.True(t, policy.Cutoff.IsZero())
+	})
+}
+
+func TestAnonymizedUserFields(t *testing.T) {
+	userID := uuid.New()
+	now := time.Now()
+
+	fields := model.AnonymizedUserFields(userID, now)
+
+	assert.Equal(t, "anonymized-"+userID.String()+"@anonymized.invalid", fields["email"])
+	assert.Equal(t, "", fields["password"])
+	assert.Nil(t, fields["medical_history"])
+	assert.Nil(t, fields["phone"])
+	assert.Equal(t, now, fields["anonymized_at"])
+}
@@ -0,0 +1,57 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevenuePeriodStart(t *testing.T) {
+	// A Friday
+	at := time.Date(2026, 10, 16, 15, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), model.RevenuePeriodStart(at, model.RevenueIntervalDay))
+	assert.Equal(t, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), model.RevenuePeriodStart(at, model.RevenueIntervalWeek))
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), model.RevenuePeriodStart(at, model.RevenueIntervalMonth))
+
+	t.Run("should start weeks on Monday", func(t *testing.T) {
+		sunday := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+		monday := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+
+		assert.Equal(t, monday, model.RevenuePeriodStart(sunday, model.RevenueIntervalWeek))
+		assert.Equal(t, monday, model.RevenuePeriodStart(monday, model.RevenueIntervalWeek))
+	})
+}
+
+func TestBuildRevenueAnalytics(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+	buckets := []model.RevenueBucket{
+		{Start: time.Date(2026, 9, 28, 0, 0, 0, 0, time.UTC), Revenue: 100_000, Payments: 2},
+		{Start: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), Revenue: 50_000, Payments: 1},
+		{Start: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), NewSubscribers: 3},
+		{Start: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), ChurnedSubscribers: 1},
+	}
+
+	analytics := model.BuildRevenueAnalytics(from, to, model.RevenueIntervalWeek, buckets, nil)
+
+	t.Run("should lay out every period of the range", func(t *testing.T) {
+		assert.Equal(t, []model.RevenuePeriod{
+			{Period: "2026-09-28", Revenue: 100_000, Payments: 2},
+			{Period: "2026-10-05"},
+			{Period: "2026-10-12", Revenue: 50_000, Payments: 1, NewSubscribers: 3},
+			{Period: "2026-10-19", ChurnedSubscribers: 1},
+		}, analytics.Periods)
+	})
+
+	t.Run("should add up the totals", func(t *testing.T) {
+		assert.Equal(t, int64(150_000), analytics.Revenue)
+		assert.Equal(t, int64(3), analytics.Payments)
+		assert.Equal(t, int64(3), analytics.NewSubscribers)
+		assert.Equal(t, int64(1), analytics.ChurnedSubscribers)
+		assert.Equal(t, "2026-10-01", analytics.From)
+		assert.Empty(t, analytics.Plans)
+	})
+}
@@ -0,0 +1,99 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildRecognitionSchedule(t *testing.T) {
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	subscription := &model.UserSubscription{
+		ID:        uuid.New(),
+		StartDate: start,
+		EndDate:   start.AddDate(1, 0, 0),
+	}
+
+	t.Run("should spread an annual payment over twelve months", func(t *testing.T) {
+		detail := &model.TransactionDetail{
+			ID:                uuid.New(),
+			TransactionStatus: "settlement",
+			GrossAmount:       "1200000.00",
+			TransactionTime:   start,
+		}
+
+		schedule := model.BuildRecognitionSchedule(detail, subscription)
+
+		assert.Len(t, schedule, 12)
+		total := 0
+		for _, entry := range schedule {
+			total += entry.Amount
+			assert.Equal(t, detail.ID, entry.TransactionDetailID)
+		}
+		assert.Equal(t, 1200000, total)
+		assert.Equal(t, start, schedule[0].Month)
+		assert.Greater(t, schedule[0].Amount, schedule[1].Amount) // January has more days than February
+	})
+
+	t.Run("should recognize a late payment right away", func(t *testing.T) {
+		paidAt := start.AddDate(2, 0, 0)
+		detail := &model.TransactionDetail{
+			TransactionStatus: "success",
+			GrossAmount:       "50000",
+			TransactionTime:   paidAt,
+		}
+
+		schedule := model.BuildRecognitionSchedule(detail, subscription)
+
+		assert.Len(t, schedule, 1)
+		assert.Equal(t, 50000, schedule[0].Amount)
+		assert.Equal(t, model.MonthStart(paidAt), schedule[0].Month)
+	})
+
+	t.Run("should skip unpaid transactions", func(t *testing.T) {
+		detail := &model.TransactionDetail{
+			TransactionStatus: "pending",
+			GrossAmount:       "50000",
+			TransactionTime:   start,
+		}
+
+		assert.Empty(t, model.BuildRecognitionSchedule(detail, subscription))
+	})
+
+	t.Run("should skip sandbox payments", func(t *testing.T) {
+		detail := &model.TransactionDetail{
+			TransactionStatus: "settlement",
+			GrossAmount:       "50000",
+			TransactionTime:   start,
+			IsSandbox:         true,
+		}
+
+		assert.Empty(t, model.BuildRecognitionSchedule(detail, subscription))
+	})
+}
+
+func TestBuildRevenueReport(t *testing.T) {
+	jan := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	feb := jan.AddDate(0, 1, 0)
+	mar := jan.AddDate(0, 2, 0)
+
+	aggregates := []model.RevenueAggregate{
+		{PaidMonth: jan, Month: jan, Amount: 100},
+		{PaidMonth: jan, Month: feb, Amount: 100},
+		{PaidMonth: jan, Month: mar, Amount: 100},
+	}
+
+	report := model.BuildRevenueReport(aggregates, jan, mar)
+
+	assert.Equal(t, "2025-01", report.From)
+	assert.Equal(t, 300, report.TotalCollected)
+	assert.Equal(t, 300, report.TotalRecognized)
+	assert.Equal(t, []model.RevenueReportMonth{
+		{Month: "2025-01", Collected: 300, Recognized: 100, Deferred: 200},
+		{Month: "2025-02", Collected: 0, Recognized: 100, Deferred: 100},
+		{Month: "2025-03", Collected: 0, Recognized: 100, Deferred: 0},
+	}, report.Months)
+}
@@ -0,0 +1,58 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidRoleName(t *testing.T) {
+	for _, name := range []string{"support", "finance_ops", "qa2"} {
+		assert.True(t, model.ValidRoleName(name), name)
+	}
+	for _, name := range []string{"", "ab", "Support", "2support", "_support", "finance-ops", "support team", "rights"} {
+		assert.False(t, model.ValidRoleName(name), name)
+	}
+}
+
+func TestNormalizeRights(t *testing.T) {
+	known := []string{"getUsers", "getUserDetails", "updatePaymentStatus"}
+
+	t.Run("should sort and drop duplicates", func(t *testing.T) {
+		rights, unknown := model.NormalizeRights([]string{"getUsers", "getUserDetails", "getUsers"}, known)
+
+		assert.Equal(t, []string{"getUserDetails", "getUsers"}, rights)
+		assert.Empty(t, unknown)
+	})
+
+	t.Run("should report the rights it does not know", func(t *testing.T) {
+		_, unknown := model.NormalizeRights([]string{"getUsers", "launchRockets"}, known)
+
+		assert.Equal(t, []string{"launchRockets"}, unknown)
+	})
+}
+
+func TestCustomRoleRights(t *testing.T) {
+	builtIn := map[string][]string{"user": {}, "admin": {"getUsers", "updatePaymentStatus"}}
+
+	t.Run("should keep the stored roles by name", func(t *testing.T) {
+		custom, ignored := model.CustomRoleRights([]model.Role{
+			{Name: "support", Rights: []string{"getUsers"}},
+		}, builtIn)
+
+		assert.Equal(t, map[string][]string{"support": {"getUsers"}}, custom)
+		assert.Empty(t, ignored)
+	})
+
+	t.Run("should ignore stored roles named like a built-in one", func(t *testing.T) {
+		custom, ignored := model.CustomRoleRights([]model.Role{
+			{Name: "user", Rights: []string{"updatePaymentStatus"}},
+			{Name: "support", Rights: []string{"getUsers"}},
+		}, builtIn)
+
+		assert.NotContains(t, custom, "user")
+		assert.Contains(t, custom, "support")
+		assert.Equal(t, []string{"user"}, ignored)
+	})
+}
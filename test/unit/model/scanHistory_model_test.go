@@ -0,0 +1,36 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanItemsOf(t *testing.T) {
+	t.Run("should name each item after its most probable candidate", func(t *testing.T) {
+		items := model.ScanItemsOf([][]string{
+			{"fried rice", "rice", "noodles"},
+			{"fried egg"},
+		})
+
+		assert.Equal(t, []model.ScanItem{
+			{Name: "fried rice", Candidates: []string{"fried rice", "rice", "noodles"}},
+			{Name: "fried egg", Candidates: []string{"fried egg"}},
+		}, items)
+	})
+
+	t.Run("should leave out areas without candidates", func(t *testing.T) {
+		items := model.ScanItemsOf([][]string{{}, {"tempeh"}})
+
+		assert.Len(t, items, 1)
+		assert.Equal(t, "tempeh", items[0].Name)
+	})
+
+	t.Run("should return an empty list when nothing was recognized", func(t *testing.T) {
+		items := model.ScanItemsOf(nil)
+
+		assert.NotNil(t, items)
+		assert.Empty(t, items)
+	})
+}
@@ -0,0 +1,65 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanQuota(t *testing.T) {
+	t.Run("should count down remaining scans", func(t *testing.T) {
+		quota := &model.ScanQuota{Limit: 30, Used: 12}
+
+		assert.Equal(t, 18, quota.Remaining())
+		assert.False(t, quota.Exhausted())
+	})
+
+	t.Run("should be exhausted at the limit", func(t *testing.T) {
+		quota := &model.ScanQuota{Limit: 30, Used: 30}
+
+		assert.Equal(t, 0, quota.Remaining())
+		assert.True(t, quota.Exhausted())
+	})
+
+	t.Run("should not go below zero when an admin lowered the limit", func(t *testing.T) {
+		quota := &model.ScanQuota{Limit: 10, Used: 25}
+
+		assert.Equal(t, 0, quota.Remaining())
+		assert.True(t, quota.Exhausted())
+	})
+
+	t.Run("should never exhaust an unlimited plan", func(t *testing.T) {
+		quota := &model.ScanQuota{Limit: -1, Used: 5000}
+
+		assert.True(t, quota.IsUnlimited())
+		assert.Equal(t, -1, quota.Remaining())
+		assert.False(t, quota.Exhausted())
+	})
+}
+
+func TestScanQuotaUsage(t *testing.T) {
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 30)
+
+	t.Run("should report the count of the period", func(t *testing.T) {
+		quota := &model.ScanQuota{Limit: 30, Used: 12}
+		usage := quota.Usage("Premium Bulanan", start, end)
+
+		assert.Equal(t, "Premium Bulanan", usage.PlanName)
+		assert.Equal(t, 12, usage.Used)
+		assert.Equal(t, 18, usage.Remaining)
+		assert.False(t, usage.Unlimited)
+		assert.Equal(t, start, usage.PeriodStart)
+		assert.Equal(t, end, usage.ResetsAt)
+	})
+
+	t.Run("should flag unlimited plans", func(t *testing.T) {
+		quota := &model.ScanQuota{Limit: -1, Used: 40}
+		usage := quota.Usage("Premium Tahunan", start, end)
+
+		assert.True(t, usage.Unlimited)
+		assert.Equal(t, -1, usage.Remaining)
+	})
+}
@@ -0,0 +1,65 @@
+package model_test
+
+import (
+	"app/src/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFoodDocument(t *testing.T) {
+	food := model.BahanMakanan{Kode: "GP002", NamaBahanMakanan: "Telur ayam"}
+	names := []model.FoodName{
+		{FoodKode: "GP002", Language: model.FoodLanguageEnglish, Name: "Chicken egg", Primary: true},
+		{FoodKode: "GP002", Language: model.FoodLanguageIndonesian, Name: "Endog"},
+	}
+
+	doc := model.NewFoodDocument(food, names, time.Now())
+
+	t.Run("should search the name of the food composition table first", func(t *testing.T) {
+		assert.Equal(t, []string{"Telur ayam", "Endog"}, doc.NamesID)
+	})
+
+	t.Run("should split the names by language", func(t *testing.T) {
+		assert.Equal(t, []string{"Chicken egg"}, doc.NamesEN)
+	})
+}
+
+func TestContentDocumentID(t *testing.T) {
+	id := uuid.New()
+
+	t.Run("should keep articles and recipes apart", func(t *testing.T) {
+		article := model.NewArticleDocument(model.Article{ID: id}, "", time.Now())
+		recipe := model.NewRecipeDocument(model.Recipe{ID: id}, time.Now())
+
+		assert.NotEqual(t, article.DocumentID(), recipe.DocumentID())
+		assert.Equal(t, "article:"+id.String(), article.DocumentID())
+	})
+}
+
+func TestContentSnippet(t *testing.T) {
+	t.Run("should keep a short body whole, collapsing its spaces", func(t *testing.T) {
+		assert.Equal(t, "Tempe goreng renyah", model.ContentSnippet("Tempe goreng \n renyah", "tempe"))
+	})
+
+	t.Run("should cut around the search", func(t *testing.T) {
+		body := strings.Repeat("lorem ipsum ", 40) + "sayur bayam bening " + strings.Repeat("dolor sit ", 40)
+
+		snippet := model.ContentSnippet(body, "Bayam")
+
+		assert.Contains(t, snippet, "bayam")
+		assert.True(t, strings.HasPrefix(snippet, "…"))
+		assert.True(t, strings.HasSuffix(snippet, "…"))
+	})
+
+	t.Run("should start at the beginning when the search is not in the body", func(t *testing.T) {
+		body := strings.Repeat("lorem ipsum ", 40)
+
+		snippet := model.ContentSnippet(body, "bayam")
+
+		assert.True(t, strings.HasPrefix(snippet, "lorem"))
+	})
+}
@@ -0,0 +1,63 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStorageUsageReport(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) }
+	usd := model.Money{Amount: 2300, Currency: "USD"}
+
+	snapshots := []model.StorageUsageSnapshot{
+		{Day: day(1), Category: model.StorageScanImages, Objects: 10, Bytes: 1_000_000_000, MeasuredAt: day(1)},
+		{Day: day(1), Category: model.StorageBackups, Objects: 1, Bytes: 500_000_000, MeasuredAt: day(1)},
+		{Day: day(11), Category: model.StorageScanImages, Objects: 30, Bytes: 3_000_000_000, MeasuredAt: day(11)},
+		{Day: day(11), Category: model.StorageBackups, Objects: 2, Bytes: 1_500_000_000, MeasuredAt: day(11)},
+	}
+	report := model.NewStorageUsageReport(snapshots, 30, usd)
+
+	t.Run("should report the categories as of the last day", func(t *testing.T) {
+		assert.Equal(t, []model.StorageCategoryUsage{
+			{Category: model.StorageScanImages, Objects: 30, Bytes: 3_000_000_000, GrowthBytes: 2_000_000_000},
+			{Category: model.StorageBackups, Objects: 2, Bytes: 1_500_000_000, GrowthBytes: 1_000_000_000},
+		}, report.Categories)
+		assert.Equal(t, int64(32), report.TotalObjects)
+		assert.Equal(t, int64(4_500_000_000), report.TotalBytes)
+		assert.Equal(t, day(11), *report.MeasuredAt)
+	})
+
+	t.Run("should list the total of every day", func(t *testing.T) {
+		assert.Equal(t, []model.StorageUsageDay{
+			{Day: day(1), Objects: 11, Bytes: 1_500_000_000},
+			{Day: day(11), Objects: 32, Bytes: 4_500_000_000},
+		}, report.Trend)
+	})
+
+	t.Run("should forecast the average daily growth", func(t *testing.T) {
+		assert.Equal(t, int64(300_000_000), report.DailyGrowth)
+		assert.Equal(t, int64(13_500_000_000), report.ForecastBytes)
+	})
+
+	t.Run("should price the usage per TB", func(t *testing.T) {
+		assert.Equal(t, model.Money{Amount: 11, Currency: "USD"}, report.MonthlyCost)
+		assert.Equal(t, model.Money{Amount: 32, Currency: "USD"}, report.ForecastCost)
+	})
+
+	t.Run("should report nothing before the first measurement", func(t *testing.T) {
+		empty := model.NewStorageUsageReport(nil, 30, usd)
+
+		assert.Nil(t, empty.MeasuredAt)
+		assert.Empty(t, empty.Categories)
+		assert.Zero(t, empty.ForecastBytes)
+	})
+}
+
+func TestStorageCost(t *testing.T) {
+	assert.Equal(t, int64(2300), model.StorageCost(1_000_000_000_000, 2300))
+	assert.Equal(t, int64(1), model.StorageCost(1, 2300))
+	assert.Zero(t, model.StorageCost(0, 2300))
+}
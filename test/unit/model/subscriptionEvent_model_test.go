@@ -0,0 +1,59 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTransitionSubscription(t *testing.T) {
+	t.Run("should start a new subscription in any status", func(t *testing.T) {
+		assert.True(t, model.CanTransitionSubscription("", model.SubscriptionPending))
+		assert.True(t, model.CanTransitionSubscription("", model.SubscriptionActive))
+		assert.True(t, model.CanTransitionSubscription("", model.SubscriptionCancelled))
+		assert.False(t, model.CanTransitionSubscription("", "paid"))
+	})
+
+	t.Run("should follow the lifecycle", func(t *testing.T) {
+		assert.True(t, model.CanTransitionSubscription(model.SubscriptionPending, model.SubscriptionActive))
+		assert.True(t, model.CanTransitionSubscription(model.SubscriptionActive, model.SubscriptionSuspended))
+		assert.True(t, model.CanTransitionSubscription(model.SubscriptionSuspended, model.SubscriptionActive))
+		assert.True(t, model.CanTransitionSubscription(model.SubscriptionExpired, model.SubscriptionActive))
+	})
+
+	t.Run("should refuse moves outside the lifecycle", func(t *testing.T) {
+		assert.False(t, model.CanTransitionSubscription(model.SubscriptionActive, model.SubscriptionPending))
+		assert.False(t, model.CanTransitionSubscription(model.SubscriptionPending, model.SubscriptionExpired))
+		assert.False(t, model.CanTransitionSubscription(model.SubscriptionCancelled, model.SubscriptionActive))
+		assert.False(t, model.CanTransitionSubscription(model.SubscriptionExpired, model.SubscriptionCancelled))
+	})
+}
+
+func TestUserSubscriptionLifecycleStatus(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	running := now.Add(24 * time.Hour)
+
+	tests := []struct {
+		name         string
+		subscription model.UserSubscription
+		expected     string
+	}{
+		{"paid and running", model.UserSubscription{PaymentStatus: "success", IsActive: true, EndDate: running}, model.SubscriptionActive},
+		{"paid and ended", model.UserSubscription{PaymentStatus: "success", IsActive: true, EndDate: now}, model.SubscriptionExpired},
+		{"paid and deactivated", model.UserSubscription{PaymentStatus: "success", EndDate: running}, model.SubscriptionCancelled},
+		{"held for review", model.UserSubscription{PaymentStatus: "on_hold", EndDate: running}, model.SubscriptionPending},
+		{"failed payment", model.UserSubscription{PaymentStatus: "failed", EndDate: running}, model.SubscriptionPending},
+		{"suspended", model.UserSubscription{PaymentStatus: "suspended", EndDate: running}, model.SubscriptionSuspended},
+		{"suspended and ended", model.UserSubscription{PaymentStatus: "suspended", EndDate: now}, model.SubscriptionExpired},
+		{"refunded", model.UserSubscription{PaymentStatus: "refunded", EndDate: running}, model.SubscriptionCancelled},
+		{"unknown payment status", model.UserSubscription{PaymentStatus: "chargeback", Status: model.SubscriptionActive}, model.SubscriptionActive},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.subscription.LifecycleStatus(now))
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionPlanIsAvailableAt(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	before := now.Add(-time.Hour)
+	after := now.Add(time.Hour)
+
+	t.Run("should be available without a window", func(t *testing.T) {
+		plan := model.SubscriptionPlan{IsActive: true}
+
+		assert.True(t, plan.IsAvailableAt(now))
+	})
+
+	t.Run("should not be available when inactive", func(t *testing.T) {
+		plan := model.SubscriptionPlan{IsActive: false}
+
+		assert.False(t, plan.IsAvailableAt(now))
+	})
+
+	t.Run("should not be available when archived", func(t *testing.T) {
+		plan := model.SubscriptionPlan{IsActive: true, ArchivedAt: &before}
+
+		assert.False(t, plan.IsAvailableAt(now))
+	})
+
+	t.Run("should not be available when sunset", func(t *testing.T) {
+		plan := model.SubscriptionPlan{IsActive: true, SunsetAt: &before}
+
+		assert.False(t, plan.IsAvailableAt(now))
+	})
+
+	t.Run("should respect the availability window", func(t *testing.T) {
+		plan := model.SubscriptionPlan{IsActive: true, AvailableFrom: &before, AvailableUntil: &after}
+
+		assert.True(t, plan.IsAvailableAt(now))
+		assert.True(t, plan.IsAvailableAt(before))
+		assert.False(t, plan.IsAvailableAt(after))
+		assert.False(t, plan.IsAvailableAt(before.Add(-time.Second)))
+	})
+
+	t.Run("should stay available for hidden plans", func(t *testing.T) {
+		plan := model.SubscriptionPlan{IsActive: true, Hidden: true}
+
+		assert.True(t, plan.IsAvailableAt(now))
+	})
+}
+
+func TestSubscriptionPlanRenewsTo(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	replacementID := uuid.New()
+
+	t.Run("should renew on the plan itself", func(t *testing.T) {
+		plan := model.SubscriptionPlan{ID: uuid.New(), ReplacementPlanID: &replacementID}
+
+		assert.Equal(t, plan.ID, plan.RenewsTo())
+	})
+
+	t.Run("should renew a sunset plan on its replacement", func(t *testing.T) {
+		plan := model.SubscriptionPlan{ID: uuid.New(), SunsetAt: &now, ReplacementPlanID: &replacementID}
+
+		assert.Equal(t, replacementID, plan.RenewsTo())
+	})
+}
+
+func TestSubscriptionPlanCanTrialAt(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should offer a trial on plans with trial days", func(t *testing.T) {
+		plan := model.SubscriptionPlan{IsActive: true, TrialDays: 7}
+
+		assert.True(t, plan.CanTrialAt(now))
+	})
+
+	t.Run("should not offer a trial without trial days", func(t *testing.T) {
+		plan := model.SubscriptionPlan{IsActive: true}
+
+		assert.False(t, plan.CanTrialAt(now))
+	})
+
+	t.Run("should not offer a trial on plans that cannot be bought", func(t *testing.T) {
+		plan := model.SubscriptionPlan{IsActive: true, TrialDays: 7, SunsetAt: &now}
+
+		assert.False(t, plan.CanTrialAt(now))
+	})
+}
@@ -0,0 +1,71 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSubscriptionRetention(t *testing.T) {
+	august := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	september := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	october := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	monthly, yearly := uuid.New(), uuid.New()
+
+	retention := model.BuildSubscriptionRetention(
+		[]model.SubscriptionRetentionCell{
+			{Cohort: august, Offset: 0, Users: 4},
+			{Cohort: august, Offset: 1, Users: 3},
+			{Cohort: august, Offset: 2, Users: 1},
+			{Cohort: october, Offset: 0, Users: 2},
+		},
+		[]model.PlanChurnRow{
+			{PlanID: monthly, Name: "Monthly", Ended: 4, Churned: 1, LifetimeDays: 30},
+			{PlanID: yearly, Name: "Yearly", Ended: 1, Churned: 1, LifetimeDays: 365},
+		},
+		august, october, 12, now)
+
+	t.Run("should lay out every cohort month until the current one", func(t *testing.T) {
+		assert.Equal(t, "2026-08", retention.From)
+		assert.Equal(t, "2026-10", retention.To)
+		assert.Len(t, retention.Cohorts, 3)
+
+		first := retention.Cohorts[0]
+		assert.Equal(t, "2026-08", first.Cohort)
+		assert.Equal(t, int64(4), first.Subscribers)
+		assert.Len(t, first.Retention, 3)
+		assert.InDelta(t, 1.0, *first.Retention[0].Rate, 1e-9)
+		assert.InDelta(t, 0.75, *first.Retention[1].Rate, 1e-9)
+		assert.Equal(t, int64(1), first.Retention[2].Retained)
+		assert.Len(t, retention.Cohorts[2].Retention, 1)
+	})
+
+	t.Run("should leave the rates of an empty cohort out", func(t *testing.T) {
+		empty := retention.Cohorts[1]
+		assert.Equal(t, september.Format("2006-01"), empty.Cohort)
+		assert.Zero(t, empty.Subscribers)
+		assert.Len(t, empty.Retention, 2)
+		assert.Nil(t, empty.Retention[0].Rate)
+	})
+
+	t.Run("should rate the churn and lifetime per plan and in all", func(t *testing.T) {
+		assert.Len(t, retention.Plans, 2)
+		assert.InDelta(t, 0.25, *retention.Plans[0].ChurnRate, 1e-9)
+		assert.InDelta(t, 30.0, *retention.Plans[0].AverageLifetimeDays, 1e-9)
+		assert.Equal(t, int64(5), retention.Ended)
+		assert.Equal(t, int64(2), retention.Churned)
+		assert.InDelta(t, 0.4, *retention.ChurnRate, 1e-9)
+		assert.InDelta(t, 197.5, *retention.AverageLifetimeDays, 1e-9)
+	})
+
+	t.Run("should follow a cohort for months months at most", func(t *testing.T) {
+		short := model.BuildSubscriptionRetention(nil, nil, august, august, 1, now)
+		assert.Len(t, short.Cohorts[0].Retention, 2)
+		assert.Nil(t, short.ChurnRate)
+		assert.Nil(t, short.AverageLifetimeDays)
+	})
+}
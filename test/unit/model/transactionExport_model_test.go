@@ -0,0 +1,45 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionExportRow(t *testing.T) {
+	email := "=cmd@example.com"
+	planName := "Premium"
+	userID := uuid.New()
+	row := model.TransactionExportRow{
+		ID:                 uuid.New(),
+		OrderID:            "ORDER-1",
+		TransactionTime:    time.Date(2026, 3, 1, 10, 30, 0, 0, time.FixedZone("WIB", 7*3600)),
+		TransactionStatus:  "settlement",
+		GrossAmount:        "-15000.00",
+		Currency:           "IDR",
+		UserSubscriptionID: uuid.New(),
+		UserID:             &userID,
+		UserEmail:          &email,
+		PlanName:           &planName,
+	}
+
+	t.Run("should give a value for every column", func(t *testing.T) {
+		record := row.Record()
+
+		assert.Len(t, record, len(model.TransactionExportHeader))
+		assert.Equal(t, "2026-03-01T03:30:00Z", record[3])
+		assert.Equal(t, "", record[9])  // no settlement time
+		assert.Equal(t, "", record[13]) // no plan
+		assert.Equal(t, "Premium", record[14])
+	})
+
+	t.Run("should escape formulas in CSV but keep the amount a number", func(t *testing.T) {
+		record := row.CSVRecord()
+
+		assert.Equal(t, "'=cmd@example.com", record[12])
+		assert.Equal(t, "-15000.00", record[model.TransactionExportAmountColumn])
+	})
+}
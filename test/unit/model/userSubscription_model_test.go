@@ -0,0 +1,116 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSandboxOrderID(t *testing.T) {
+	t.Run("should prefix only sandbox orders", func(t *testing.T) {
+		assert.Equal(t, "SBX-SUB-1a2b3c4d-1760000000", model.SandboxOrderID("SUB-1a2b3c4d-1760000000", true))
+		assert.Equal(t, "SUB-1a2b3c4d-1760000000", model.SandboxOrderID("SUB-1a2b3c4d-1760000000", false))
+	})
+
+	t.Run("should recognize sandbox orders by their prefix", func(t *testing.T) {
+		assert.True(t, model.IsSandboxOrder("SBX-INST-1a2b3c4d-2-1760000000"))
+		assert.False(t, model.IsSandboxOrder("INST-1a2b3c4d-2-1760000000"))
+	})
+}
+
+func TestUserSubscriptionCanAutoRenew(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	later := now.Add(24 * time.Hour)
+	earlier := now.Add(-24 * time.Hour)
+
+	t.Run("should renew card subscriptions with a valid saved token", func(t *testing.T) {
+		subscription := model.UserSubscription{Source: model.SourceMidtrans, SavedTokenID: "481111-1114-token", SavedTokenExpiresAt: &later}
+		assert.True(t, subscription.CanAutoRenew(now))
+	})
+
+	t.Run("should not renew without a saved token or with an expired one", func(t *testing.T) {
+		assert.False(t, (&model.UserSubscription{Source: model.SourceMidtrans}).CanAutoRenew(now))
+		assert.False(t, (&model.UserSubscription{Source: model.SourceMidtrans, SavedTokenID: "token", SavedTokenExpiresAt: &earlier}).CanAutoRenew(now))
+	})
+
+	t.Run("should not renew installments", func(t *testing.T) {
+		subscription := model.UserSubscription{Source: model.SourceMidtrans, IsInstallment: true, SavedTokenID: "token"}
+		assert.False(t, subscription.CanAutoRenew(now))
+	})
+}
+
+func TestUserSubscriptionTrialStatus(t *testing.T) {
+	t.Run("should have no trial status when it is not a trial", func(t *testing.T) {
+		assert.Empty(t, (&model.UserSubscription{Source: model.SourceMidtrans}).TrialStatus())
+	})
+
+	t.Run("should run until the trial ended", func(t *testing.T) {
+		assert.Equal(t, model.TrialActive, (&model.UserSubscription{Source: model.SourceTrial}).TrialStatus())
+		assert.Equal(t, model.TrialConverted,
+			(&model.UserSubscription{Source: model.SourceTrial, TrialOutcome: model.TrialConverted}).TrialStatus())
+	})
+}
+
+func TestUserSubscriptionRenewalPeriod(t *testing.T) {
+	t.Run("should start the renewal when the subscription ends", func(t *testing.T) {
+		end := time.Date(2026, 10, 31, 9, 30, 0, 0, time.UTC)
+		subscription := model.UserSubscription{EndDate: end}
+
+		start, renewedEnd := subscription.RenewalPeriod(&model.SubscriptionPlan{ValidityDays: 30})
+		assert.Equal(t, end, start)
+		assert.Equal(t, time.Date(2026, 11, 30, 9, 30, 0, 0, time.UTC), renewedEnd)
+	})
+}
+
+func TestRenewalOrderID(t *testing.T) {
+	id := uuid.MustParse("1a2b3c4d-0000-0000-0000-000000000000")
+	now := time.Unix(1760000000, 0)
+
+	t.Run("should number the attempts of a subscription", func(t *testing.T) {
+		assert.Equal(t, "RENEW-1a2b3c4d-2-1760000000", model.RenewalOrderID(id, 2, now, false))
+		assert.Equal(t, "SBX-RENEW-1a2b3c4d-1-1760000000", model.RenewalOrderID(id, 1, now, true))
+	})
+}
+
+func TestParseSavedTokenExpiry(t *testing.T) {
+	t.Run("should read the expiry in Western Indonesian Time", func(t *testing.T) {
+		expiresAt := model.ParseSavedTokenExpiry("2027-12-31 07:00:00")
+		if assert.NotNil(t, expiresAt) {
+			assert.Equal(t, time.Date(2027, 12, 31, 0, 0, 0, 0, time.UTC), expiresAt.UTC())
+		}
+	})
+
+	t.Run("should return nil for a missing or malformed expiry", func(t *testing.T) {
+		assert.Nil(t, model.ParseSavedTokenExpiry(""))
+		assert.Nil(t, model.ParseSavedTokenExpiry("12/27"))
+	})
+}
+
+func TestUserSubscriptionCanRestoreBeside(t *testing.T) {
+	created := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	deleted := model.UserSubscription{ID: uuid.New(), CreatedAt: created}
+
+	t.Run("should restore next to older or ended subscriptions", func(t *testing.T) {
+		assert.True(t, deleted.CanRestoreBeside(nil))
+		assert.True(t, deleted.CanRestoreBeside([]model.UserSubscription{
+			{ID: uuid.New(), IsActive: true, CreatedAt: created.AddDate(0, -1, 0)},
+			{ID: uuid.New(), IsActive: false, CreatedAt: created.AddDate(0, 0, 10)},
+		}))
+	})
+
+	t.Run("should not restore when a newer active subscription took its place", func(t *testing.T) {
+		assert.False(t, deleted.CanRestoreBeside([]model.UserSubscription{
+			{ID: uuid.New(), IsActive: true, CreatedAt: created.AddDate(0, 0, 10)},
+		}))
+	})
+
+	t.Run("should ignore itself and other deleted subscriptions", func(t *testing.T) {
+		gone := model.UserSubscription{ID: uuid.New(), IsActive: true, CreatedAt: created.AddDate(0, 0, 10)}
+		gone.DeletedAt.Valid = true
+
+		assert.True(t, deleted.CanRestoreBeside([]model.UserSubscription{gone, deleted}))
+	})
+}
@@ -0,0 +1,59 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSpokenFoods(t *testing.T) {
+	t.Run("should split foods and read their portions", func(t *testing.T) {
+		foods := model.ParseSpokenFoods("Tadi saya makan dua butir telur rebus, sepiring nasi goreng dan segelas susu.")
+
+		assert.Equal(t, []model.SpokenFood{
+			{Text: "Tadi saya makan dua butir telur rebus", Name: "telur rebus", Quantity: 2, Unit: model.PortionButir},
+			{Text: "sepiring nasi goreng", Name: "nasi goreng", Quantity: 1, Unit: model.PortionPiring},
+			{Text: "segelas susu.", Name: "susu", Quantity: 1, Unit: model.PortionGelas},
+		}, foods)
+	})
+
+	t.Run("should read decimals, fractions and units of two words", func(t *testing.T) {
+		foods := model.ParseSpokenFoods("1,5 mangkok bubur ayam; satu setengah sendok makan gula")
+
+		assert.Len(t, foods, 2)
+		assert.Equal(t, 1.5, foods[0].Quantity)
+		assert.Equal(t, model.PortionMangkok, foods[0].Unit)
+		assert.Equal(t, 1.5, foods[1].Quantity)
+		assert.Equal(t, model.PortionSendokMakan, foods[1].Unit)
+		assert.Equal(t, "gula", foods[1].Name)
+	})
+
+	t.Run("should read English", func(t *testing.T) {
+		foods := model.ParseSpokenFoods("For lunch I had two slices of bread with a glass of orange juice")
+
+		assert.Len(t, foods, 2)
+		assert.Equal(t, model.SpokenFood{Text: "For lunch I had two slices of bread", Name: "bread", Quantity: 2, Unit: model.PortionPotong}, foods[0])
+		assert.Equal(t, "orange juice", foods[1].Name)
+		assert.Equal(t, model.PortionGelas, foods[1].Unit)
+	})
+
+	t.Run("should keep a quantity without a unit and default to one", func(t *testing.T) {
+		foods := model.ParseSpokenFoods("tiga pisang dan tempe goreng")
+
+		assert.Equal(t, model.SpokenFood{Text: "tiga pisang", Name: "pisang", Quantity: 3}, foods[0])
+		assert.Equal(t, model.SpokenFood{Text: "tempe goreng", Name: "tempe goreng", Quantity: 1}, foods[1])
+	})
+
+	t.Run("should find no food in fillers alone", func(t *testing.T) {
+		assert.Empty(t, model.ParseSpokenFoods("tadi saya makan"))
+	})
+}
+
+func TestVoiceLogQuota(t *testing.T) {
+	assert.Equal(t, 2, (&model.VoiceLogQuota{Limit: 5, Used: 3}).Remaining())
+	assert.True(t, (&model.VoiceLogQuota{Limit: 5, Used: 5}).Exhausted())
+	assert.True(t, (&model.VoiceLogQuota{Limit: 0}).Exhausted())
+	assert.Equal(t, -1, (&model.VoiceLogQuota{Limit: -1, Used: 100}).Remaining())
+	assert.False(t, (&model.VoiceLogQuota{Limit: -1, Used: 100}).Exhausted())
+}
@@ -0,0 +1,117 @@
+package model_test
+
+import (
+	"app/src/model"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	at := time.Unix(1760000000, 0)
+	body := []byte(`{"type":"payment.failed"}`)
+
+	t.Run("should sign the timestamp and the body", func(t *testing.T) {
+		mac := hmac.New(sha256.New, []byte("whsec_secret"))
+		mac.Write([]byte("1760000000." + string(body)))
+
+		assert.Equal(t, "t=1760000000,v1="+hex.EncodeToString(mac.Sum(nil)), model.WebhookSignature("whsec_secret", at, body))
+	})
+
+	t.Run("should change with the secret, the time or the body", func(t *testing.T) {
+		signature := model.WebhookSignature("whsec_secret", at, body)
+
+		assert.NotEqual(t, signature, model.WebhookSignature("whsec_other", at, body))
+		assert.NotEqual(t, signature, model.WebhookSignature("whsec_secret", at.Add(time.Second), body))
+		assert.NotEqual(t, signature, model.WebhookSignature("whsec_secret", at, []byte(`{}`)))
+	})
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	t.Run("should double the wait with every attempt", func(t *testing.T) {
+		assert.Equal(t, 30*time.Second, model.WebhookBackoff(1, 30*time.Second, time.Hour))
+		assert.Equal(t, time.Minute, model.WebhookBackoff(2, 30*time.Second, time.Hour))
+		assert.Equal(t, 4*time.Minute, model.WebhookBackoff(4, 30*time.Second, time.Hour))
+	})
+
+	t.Run("should not wait longer than the limit", func(t *testing.T) {
+		assert.Equal(t, time.Hour, model.WebhookBackoff(10, 30*time.Second, time.Hour))
+		assert.Equal(t, time.Hour, model.WebhookBackoff(1000, 30*time.Second, time.Hour))
+	})
+}
+
+func TestSubscriptionWebhookEvent(t *testing.T) {
+	t.Run("should send an event for the moves partners act on", func(t *testing.T) {
+		event, ok := model.SubscriptionWebhookEvent(model.SubscriptionActive)
+		assert.True(t, ok)
+		assert.Equal(t, model.WebhookSubscriptionActivated, event)
+
+		event, ok = model.SubscriptionWebhookEvent(model.SubscriptionExpired)
+		assert.True(t, ok)
+		assert.Equal(t, model.WebhookSubscriptionExpired, event)
+	})
+
+	t.Run("should not send an event for pending subscriptions", func(t *testing.T) {
+		_, ok := model.SubscriptionWebhookEvent(model.SubscriptionPending)
+		assert.False(t, ok)
+	})
+}
+
+func TestNewWebhookDeliveries(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	endpoints := []model.WebhookEndpoint{
+		{ID: uuid.New(), Events: []string{model.WebhookPaymentFailed}, IsActive: true},
+		{ID: uuid.New(), Events: []string{model.WebhookPaymentFailed, model.WebhookSubscriptionExpired}, IsActive: true},
+		{ID: uuid.New(), Events: []string{model.WebhookSubscriptionExpired}, IsActive: true},
+		{ID: uuid.New(), Events: []string{model.WebhookPaymentFailed}, IsActive: false},
+	}
+
+	deliveries, err := model.NewWebhookDeliveries(endpoints, model.WebhookPaymentFailed, model.PaymentWebhookData{OrderID: "ORDER-1"}, now)
+	assert.NoError(t, err)
+
+	t.Run("should only deliver to the active endpoints subscribed to the event", func(t *testing.T) {
+		assert.Len(t, deliveries, 2)
+		assert.Equal(t, endpoints[0].ID, deliveries[0].EndpointID)
+		assert.Equal(t, endpoints[1].ID, deliveries[1].EndpointID)
+	})
+
+	t.Run("should share one event ID and be due now", func(t *testing.T) {
+		assert.Equal(t, deliveries[0].EventID, deliveries[1].EventID)
+		assert.Equal(t, model.WebhookDeliveryPending, deliveries[0].Status)
+		assert.Equal(t, now, *deliveries[0].NextAttemptAt)
+	})
+
+	t.Run("should carry the event in the payload", func(t *testing.T) {
+		var payload map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(deliveries[0].Payload), &payload))
+
+		assert.Equal(t, deliveries[0].EventID.String(), payload["id"])
+		assert.Equal(t, model.WebhookPaymentFailed, payload["type"])
+		assert.Equal(t, "ORDER-1", payload["data"].(map[string]interface{})["order_id"])
+	})
+}
+
+func TestWebhookReplaySetProgress(t *testing.T) {
+	t.Run("should run while deliveries are pending", func(t *testing.T) {
+		replay := model.WebhookReplay{Total: 10}
+		replay.SetProgress(map[string]int64{model.WebhookDeliveryPending: 4, model.WebhookDeliveryDelivered: 6})
+
+		assert.Equal(t, model.WebhookReplayRunning, replay.Status)
+		assert.Equal(t, int64(4), replay.Pending)
+		assert.Equal(t, int64(6), replay.Delivered)
+	})
+
+	t.Run("should complete once every delivery was delivered or failed", func(t *testing.T) {
+		replay := model.WebhookReplay{Total: 10}
+		replay.SetProgress(map[string]int64{model.WebhookDeliveryDelivered: 9, model.WebhookDeliveryFailed: 1})
+
+		assert.Equal(t, model.WebhookReplayCompleted, replay.Status)
+		assert.Equal(t, int64(1), replay.Failed)
+	})
+}
@@ -0,0 +1,45 @@
+package pagination_test
+
+import (
+	"app/src/pagination"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginate(t *testing.T) {
+	t.Run("should count a partly filled last page", func(t *testing.T) {
+		assert.Equal(t, int64(3), pagination.Paginate(1, 10, 21).TotalPages)
+	})
+
+	t.Run("should not add a page when the results divide evenly", func(t *testing.T) {
+		assert.Equal(t, int64(2), pagination.Paginate(1, 10, 20).TotalPages)
+		assert.Equal(t, int64(1), pagination.Paginate(1, 10, 10).TotalPages)
+	})
+
+	t.Run("should have no pages without results", func(t *testing.T) {
+		assert.Equal(t, int64(0), pagination.Paginate(1, 10, 0).TotalPages)
+	})
+
+	t.Run("should keep every result on one page without a limit", func(t *testing.T) {
+		assert.Equal(t, int64(1), pagination.Paginate(1, 0, 5).TotalPages)
+	})
+
+	t.Run("should keep the page, the limit and the total", func(t *testing.T) {
+		assert.Equal(t, pagination.Pagination{Page: 2, Limit: 5, TotalPages: 3, TotalResults: 11}, pagination.Paginate(2, 5, 11))
+	})
+}
+
+func TestPaginatedResponse(t *testing.T) {
+	body, err := json.Marshal(pagination.PaginatedResponse[string]{
+		Status:     "success",
+		Message:    "Listed",
+		Results:    []string{"a", "b"},
+		Pagination: pagination.Paginate(1, 2, 3),
+	})
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"status":"success","message":"Listed","results":["a","b"],
+		"page":1,"limit":2,"total_pages":2,"total_results":3}`, string(body))
+}
@@ -0,0 +1,49 @@
+package pdf_test
+
+import (
+	"app/src/pdf"
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocument(t *testing.T) {
+	t.Run("should point the cross-reference table at every object", func(t *testing.T) {
+		doc := pdf.New("Food diary")
+		doc.Text(40, 60, pdf.Bold, 16, "Food diary")
+		doc.AddPage()
+		doc.Rule(40, 555, 100, 0.5)
+
+		out := doc.Bytes()
+
+		assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4")))
+		assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+		assert.Contains(t, string(out), "/Count 2")
+
+		startxref := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(out)
+		xref, _ := strconv.Atoi(string(startxref[1]))
+		assert.True(t, bytes.HasPrefix(out[xref:], []byte("xref\n0 10\n")))
+
+		offsets := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(out, -1)
+		assert.Len(t, offsets, 9)
+		for i, offset := range offsets {
+			at, _ := strconv.Atoi(string(offset[1]))
+			assert.True(t, bytes.HasPrefix(out[at:], []byte(strconv.Itoa(i+1)+" 0 obj")), "object %d", i+1)
+		}
+	})
+
+	t.Run("should escape text and encode it as WinAnsi", func(t *testing.T) {
+		doc := pdf.New("")
+		doc.Text(40, 60, pdf.Regular, 9, "Nasi (goreng) \\ café – 日本")
+
+		assert.Contains(t, string(doc.Bytes()), `(Nasi \(goreng\) \\ caf\351 \226 ??) Tj`)
+	})
+}
+
+func TestFit(t *testing.T) {
+	assert.Equal(t, "Soto ayam", pdf.Fit("Soto ayam", 10, 100))
+	assert.Equal(t, "Nasi goreng spe…", pdf.Fit("Nasi goreng spesial dengan telur", 10, 80))
+}
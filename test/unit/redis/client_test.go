@@ -0,0 +1,270 @@
+package redis_test
+
+import (
+	"app/src/redis"
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer answers each command with the reply replies gives for it, and records the commands and how many
+// connections it accepted
+type fakeServer struct {
+	mu       sync.Mutex
+	commands [][]string
+	conns    int
+}
+
+func (s *fakeServer) received() ([][]string, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commands, s.conns
+}
+
+// startServer listens on the loopback and returns the server with the URL of the client
+func startServer(t *testing.T, replies func(command []string) string) (*fakeServer, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeServer{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.mu.Lock()
+			server.conns++
+			server.mu.Unlock()
+			go server.serve(conn, replies)
+		}
+	}()
+	return server, "redis://" + listener.Addr().String()
+}
+
+func (s *fakeServer) serve(conn net.Conn, replies func(command []string) string) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		command, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, command)
+		s.mu.Unlock()
+		if _, err := conn.Write([]byte(replies(command))); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand reads a command the way a server does, as an array of bulk strings
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "*"), "\r\n"))
+	if err != nil {
+		return nil, err
+	}
+	command := make([]string, count)
+	for i := range command {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "$"), "\r\n"))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		command[i] = string(data[:size])
+	}
+	return command, nil
+}
+
+// reply returns the same reply to every command
+func reply(raw string) func([]string) string {
+	return func([]string) string { return raw }
+}
+
+func client(t *testing.T, rawURL string) *redis.Client {
+	c, err := redis.New(rawURL)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestNew(t *testing.T) {
+	t.Run("should return no client without a URL", func(t *testing.T) {
+		c, err := redis.New("")
+
+		assert.NoError(t, err)
+		assert.Nil(t, c)
+	})
+
+	t.Run("should refuse other schemes", func(t *testing.T) {
+		_, err := redis.New("http://localhost:6379")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should refuse a database that is not a number", func(t *testing.T) {
+		_, err := redis.New("redis://localhost:6379/cache")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should log in and select the database on a new connection", func(t *testing.T) {
+		server, rawURL := startServer(t, reply("+OK\r\n"))
+		rawURL = strings.Replace(rawURL, "redis://", "redis://nutri:secret@", 1) + "/2"
+
+		_, err := client(t, rawURL).Do(context.Background(), "PING")
+		require.NoError(t, err)
+
+		commands, _ := server.received()
+		assert.Equal(t, [][]string{{"AUTH", "nutri", "secret"}, {"SELECT", "2"}, {"PING"}}, commands)
+	})
+}
+
+func TestClientDo(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should send the arguments as bulk strings", func(t *testing.T) {
+		server, rawURL := startServer(t, reply(":1\r\n"))
+
+		_, err := client(t, rawURL).Eval(ctx, "return 1", []string{"key"}, 5, "id")
+		require.NoError(t, err)
+
+		commands, _ := server.received()
+		assert.Equal(t, [][]string{{"EVAL", "return 1", "1", "key", "5", "id"}}, commands)
+	})
+
+	t.Run("should read each type of reply", func(t *testing.T) {
+		for _, tc := range []struct {
+			name  string
+			raw   string
+			reply any
+		}{
+			{"simple string", "+OK\r\n", "OK"},
+			{"integer", ":-42\r\n", int64(-42)},
+			{"bulk string", "$5\r\nhel\r\n\r\n", "hel\r\n"},
+			{"empty bulk string", "$0\r\n\r\n", ""},
+			{"nil bulk string", "$-1\r\n", nil},
+			{"nil array", "*-1\r\n", nil},
+			{"nested array", "*3\r\n$1\r\na\r\n:2\r\n*1\r\n+b\r\n", []any{"a", int64(2), []any{"b"}}},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				_, rawURL := startServer(t, reply(tc.raw))
+
+				got, err := client(t, rawURL).Do(ctx, "GET", "key")
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.reply, got)
+			})
+		}
+	})
+
+	t.Run("should return an error reply and keep the connection", func(t *testing.T) {
+		server, rawURL := startServer(t, func(command []string) string {
+			if command[0] == "INCR" {
+				return "-ERR value is not an integer\r\n"
+			}
+			return "+PONG\r\n"
+		})
+		c := client(t, rawURL)
+
+		_, err := c.Do(ctx, "INCR", "key")
+		var serverErr redis.Error
+		require.True(t, errors.As(err, &serverErr))
+		assert.Equal(t, "ERR value is not an integer", string(serverErr))
+
+		pong, err := c.Do(ctx, "PING")
+		require.NoError(t, err)
+		assert.Equal(t, "PONG", pong)
+		_, conns := server.received()
+		assert.Equal(t, 1, conns)
+	})
+
+	t.Run("should fail on a reply it cannot read and open a new connection", func(t *testing.T) {
+		for _, tc := range []struct {
+			name string
+			raw  string
+		}{
+			{"unknown type", "!oops\r\n"},
+			{"no carriage return", "+OK\n"},
+			{"bad integer", ":forty\r\n"},
+			{"bad length", "$five\r\nhello\r\n"},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				server, rawURL := startServer(t, reply(tc.raw))
+				c := client(t, rawURL)
+
+				_, err := c.Do(ctx, "GET", "key")
+				require.Error(t, err)
+				var serverErr redis.Error
+				assert.False(t, errors.As(err, &serverErr))
+
+				_, _ = c.Do(ctx, "GET", "key")
+				_, conns := server.received()
+				assert.Equal(t, 2, conns)
+			})
+		}
+	})
+}
+
+func TestInt(t *testing.T) {
+	t.Run("should convert integers and numeric strings", func(t *testing.T) {
+		for reply, want := range map[any]int64{nil: 0, int64(7): 7, "12": 12} {
+			got, err := redis.Int(reply, nil)
+
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		}
+	})
+
+	t.Run("should fail on other replies and pass errors on", func(t *testing.T) {
+		_, err := redis.Int("twelve", nil)
+		assert.Error(t, err)
+
+		_, err = redis.Int([]any{}, nil)
+		assert.Error(t, err)
+
+		_, err = redis.Int(int64(1), redis.Error("ERR"))
+		assert.Equal(t, redis.Error("ERR"), err)
+	})
+}
+
+func TestStrings(t *testing.T) {
+	t.Run("should convert an array of strings, nil is empty", func(t *testing.T) {
+		got, err := redis.Strings([]any{"a", "b"}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, got)
+
+		got, err = redis.Strings(nil, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("should fail on other replies", func(t *testing.T) {
+		_, err := redis.Strings("a", nil)
+		assert.Error(t, err)
+
+		_, err = redis.Strings([]any{"a", int64(1)}, nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,41 @@
+package requestid_test
+
+import (
+	"app/src/requestid"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	t.Run("should generate a prefixed ID that is itself valid", func(t *testing.T) {
+		id := requestid.New("job")
+
+		assert.True(t, strings.HasPrefix(id, "job-"))
+		assert.Len(t, id, len("job-")+8)
+		assert.True(t, requestid.Valid(id))
+	})
+
+	t.Run("should accept IDs of other tracing systems", func(t *testing.T) {
+		assert.True(t, requestid.Valid("req-1a2b3c4d"))
+		assert.True(t, requestid.Valid("01HF8Z3K2Q:span_1.2"))
+	})
+
+	t.Run("should reject empty, long or unsafe IDs", func(t *testing.T) {
+		assert.False(t, requestid.Valid(""))
+		assert.False(t, requestid.Valid(strings.Repeat("a", 65)))
+		assert.False(t, requestid.Valid("req 1"))
+		assert.False(t, requestid.Valid("req\nforged log line"))
+	})
+
+	t.Run("should carry the ID through derived contexts", func(t *testing.T) {
+		ctx := requestid.With(context.Background(), "req-1a2b3c4d")
+		derived, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		defer cancel()
+
+		assert.Equal(t, "req-1a2b3c4d", requestid.From(derived))
+		assert.Equal(t, "", requestid.From(context.Background()))
+	})
+}
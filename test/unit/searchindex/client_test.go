@@ -0,0 +1,92 @@
+package searchindex_test
+
+import (
+	"app/src/searchindex"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("should be disabled without a URL", func(t *testing.T) {
+		client, err := searchindex.New("", "nutri")
+
+		assert.NoError(t, err)
+		assert.Nil(t, client)
+	})
+
+	t.Run("should reject other schemes", func(t *testing.T) {
+		_, err := searchindex.New("redis://localhost:6379", "nutri")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should prefix index names", func(t *testing.T) {
+		client, _ := searchindex.New("http://localhost:9200", "nutri")
+
+		assert.Equal(t, "nutri_foods", client.Index("foods"))
+	})
+}
+
+func TestClient(t *testing.T) {
+	t.Run("should send credentials and parse hits", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, password, _ := r.BasicAuth()
+			assert.Equal(t, "elastic", user)
+			assert.Equal(t, "secret", password)
+			assert.Equal(t, "/nutri_foods/_search", r.URL.Path)
+			io.WriteString(w, `{"hits":{"hits":[{"_id":"GP002","_score":1.5,"_source":{"kode":"GP002"}}]}}`)
+		}))
+		defer server.Close()
+
+		client, _ := searchindex.New(strings.Replace(server.URL, "http://", "http://elastic:secret@", 1), "nutri")
+		hits, err := client.Search(context.Background(), "foods", map[string]any{"match_all": map[string]any{}}, 10)
+
+		assert.NoError(t, err)
+		assert.Len(t, hits, 1)
+		assert.Equal(t, "GP002", hits[0].ID)
+		assert.JSONEq(t, `{"kode":"GP002"}`, string(hits[0].Source))
+	})
+
+	t.Run("should count as down after a server error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		client, _ := searchindex.New(server.URL, "nutri")
+		err := client.Health(context.Background())
+
+		assert.Error(t, err)
+		assert.False(t, client.Available())
+	})
+
+	t.Run("should stay up after a client error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		client, _ := searchindex.New(server.URL, "nutri")
+		_, err := client.Search(context.Background(), "foods", map[string]any{}, 10)
+
+		assert.Error(t, err)
+		assert.True(t, client.Available())
+	})
+
+	t.Run("should not fail deleting a missing document", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		client, _ := searchindex.New(server.URL, "nutri")
+
+		assert.NoError(t, client.Delete(context.Background(), "content", "recipe:1"))
+	})
+}
@@ -0,0 +1,73 @@
+package textfilter_test
+
+import (
+	"app/src/textfilter"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Run("should compose decomposed characters", func(t *testing.T) {
+		assert.Equal(t, "Jos\u00e9", textfilter.Normalize("Jose\u0301"))
+	})
+
+	t.Run("should strip control and invisible characters", func(t *testing.T) {
+		assert.Equal(t, "Budi", textfilter.Normalize("Bu\u0000d\u200Bi\u202E"))
+	})
+
+	t.Run("should collapse and trim whitespace", func(t *testing.T) {
+		assert.Equal(t, "Siti Aminah", textfilter.Normalize("  Siti \t\n Aminah  "))
+	})
+
+	t.Run("should keep emoji sequences", func(t *testing.T) {
+		family := "\U0001F468\u200D\U0001F469\u200D\U0001F467"
+		heart := "\u2764\uFE0F"
+
+		assert.Equal(t, "Keluarga "+family+" "+heart, textfilter.Normalize("Keluarga "+family+" "+heart))
+	})
+
+	t.Run("should drop invalid UTF-8", func(t *testing.T) {
+		assert.Equal(t, "Nasi", textfilter.Normalize("Na\xffsi"))
+	})
+}
+
+func TestNormalizeMultiline(t *testing.T) {
+	t.Run("should keep line breaks and squeeze empty lines", func(t *testing.T) {
+		assert.Equal(t, "Halo\n\nApa kabar?", textfilter.NormalizeMultiline("  Halo \r\n\r\n\n\n Apa   kabar? \n"))
+	})
+}
+
+func TestCheck(t *testing.T) {
+	t.Run("should find nothing in clean text", func(t *testing.T) {
+		assert.Equal(t, textfilter.SeverityNone, textfilter.Check("Berapa kalori nasi goreng dengan daging babi?"))
+	})
+
+	t.Run("should match whole words only", func(t *testing.T) {
+		assert.Equal(t, textfilter.SeverityNone, textfilter.Check("Scunthorpe Bassett Dickson asuransi"))
+	})
+
+	t.Run("should grade the worst word", func(t *testing.T) {
+		assert.Equal(t, textfilter.SeverityMild, textfilter.Check("dasar goblok"))
+		assert.Equal(t, textfilter.SeveritySevere, textfilter.Check("goblok, fuck"))
+	})
+
+	t.Run("should see through case, look-alikes and stretched letters", func(t *testing.T) {
+		assert.Equal(t, textfilter.SeverityMild, textfilter.Check("4NJIIING"))
+		assert.Equal(t, textfilter.SeveritySevere, textfilter.Check("fuuuuck"))
+		assert.Equal(t, textfilter.SeverityMild, textfilter.Check("$hit"))
+	})
+}
+
+func TestMask(t *testing.T) {
+	t.Run("should mask the words of the given severities", func(t *testing.T) {
+		text := "dasar goblok, fuck this"
+
+		assert.Equal(t, "dasar ******, **** this", textfilter.Mask(text, textfilter.SeverityMild, textfilter.SeveritySevere))
+		assert.Equal(t, "dasar goblok, **** this", textfilter.Mask(text, textfilter.SeveritySevere))
+	})
+
+	t.Run("should leave clean text as it is", func(t *testing.T) {
+		assert.Equal(t, "Nasi uduk \U0001F35A", textfilter.Mask("Nasi uduk \U0001F35A", textfilter.SeverityMild))
+	})
+}
@@ -0,0 +1,230 @@
+package webauthn_test
+
+import (
+	"app/src/webauthn"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const origin = "https://app.nutribox.id"
+
+var rp = &webauthn.RelyingParty{
+	ID:      "nutribox.id",
+	Name:    "Nutribox",
+	Origins: []string{origin},
+	Timeout: 5 * time.Minute,
+}
+
+// authenticator is a passkey on a test device
+type authenticator struct {
+	key       *ecdsa.PrivateKey
+	id        []byte
+	rpID      string
+	flags     byte
+	signCount uint32
+}
+
+func newAuthenticator(t *testing.T) *authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return &authenticator{key: key, id: []byte("credential-1"), rpID: rp.ID, flags: 0x05}
+}
+
+// cose is the COSE_Key of the public key: {1: 2, 3: -7, -1: 1, -2: x, -3: y}
+func (a *authenticator) cose() []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+
+	key := []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21, 0x58, 0x20}
+	key = append(key, x...)
+	key = append(key, 0x22, 0x58, 0x20)
+	return append(key, y...)
+}
+
+func (a *authenticator) authData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.signCount)
+}
+
+// attestationObject is {"fmt": "none", "attStmt": {}, "authData": ...} with the attested passkey
+func (a *authenticator) attestationObject() []byte {
+	authData := a.authData(a.flags | 0x40)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.id)))
+	authData = append(authData, a.id...)
+	authData = append(authData, a.cose()...)
+
+	object := []byte{0xa3, 0x63, 'f', 'm', 't', 0x64, 'n', 'o', 'n', 'e', 0x67, 'a', 't', 't', 'S', 't', 'm', 't', 0xa0,
+		0x68, 'a', 'u', 't', 'h', 'D', 'a', 't', 'a', 0x59}
+	object = binary.BigEndian.AppendUint16(object, uint16(len(authData)))
+	return append(object, authData...)
+}
+
+func (a *authenticator) sign(t *testing.T, clientDataJSON []byte) ([]byte, []byte) {
+	authData := a.authData(a.flags)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+	return authData, signature
+}
+
+func clientData(t *testing.T, ceremony string, challenge []byte, origin string) []byte {
+	data, err := json.Marshal(map[string]any{
+		"type":      ceremony,
+		"challenge": webauthn.EncodeBase64URL(challenge),
+		"origin":    origin,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func register(t *testing.T, a *authenticator) *webauthn.Credential {
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+
+	credential, err := rp.VerifyRegistration(challenge, clientData(t, "webauthn.create", challenge, origin), a.attestationObject())
+	require.NoError(t, err)
+	return credential
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	t.Run("should return the passkey of the authenticator", func(t *testing.T) {
+		a := newAuthenticator(t)
+		a.flags |= 0x18
+
+		credential := register(t, a)
+
+		assert.Equal(t, a.id, credential.ID)
+		assert.Equal(t, a.cose(), credential.PublicKey)
+		assert.Equal(t, webauthn.AlgES256, credential.Algorithm)
+		assert.True(t, credential.BackupEligible)
+		assert.True(t, credential.BackedUp)
+	})
+
+	t.Run("should refuse a response to another challenge", func(t *testing.T) {
+		a := newAuthenticator(t)
+		challenge, _ := webauthn.NewChallenge()
+		other, _ := webauthn.NewChallenge()
+
+		_, err := rp.VerifyRegistration(challenge, clientData(t, "webauthn.create", other, origin), a.attestationObject())
+
+		assert.ErrorIs(t, err, webauthn.ErrChallengeMismatch)
+	})
+
+	t.Run("should refuse a page of another origin", func(t *testing.T) {
+		a := newAuthenticator(t)
+		challenge, _ := webauthn.NewChallenge()
+
+		_, err := rp.VerifyRegistration(challenge, clientData(t, "webauthn.create", challenge, "https://nutribox.evil"), a.attestationObject())
+
+		assert.ErrorIs(t, err, webauthn.ErrOriginNotAllowed)
+	})
+
+	t.Run("should refuse a passkey of another relying party", func(t *testing.T) {
+		a := newAuthenticator(t)
+		a.rpID = "nutribox.evil"
+		challenge, _ := webauthn.NewChallenge()
+
+		_, err := rp.VerifyRegistration(challenge, clientData(t, "webauthn.create", challenge, origin), a.attestationObject())
+
+		assert.ErrorIs(t, err, webauthn.ErrRPIDMismatch)
+	})
+
+	t.Run("should refuse a user the authenticator did not verify", func(t *testing.T) {
+		a := newAuthenticator(t)
+		a.flags = 0x01
+		challenge, _ := webauthn.NewChallenge()
+
+		_, err := rp.VerifyRegistration(challenge, clientData(t, "webauthn.create", challenge, origin), a.attestationObject())
+
+		assert.ErrorIs(t, err, webauthn.ErrUserNotVerified)
+	})
+
+	t.Run("should refuse a sign-in response", func(t *testing.T) {
+		a := newAuthenticator(t)
+		challenge, _ := webauthn.NewChallenge()
+
+		_, err := rp.VerifyRegistration(challenge, clientData(t, "webauthn.get", challenge, origin), a.attestationObject())
+
+		assert.ErrorIs(t, err, webauthn.ErrInvalidClientData)
+	})
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	t.Run("should accept a response signed by the passkey", func(t *testing.T) {
+		a := newAuthenticator(t)
+		credential := register(t, a)
+		challenge, _ := webauthn.NewChallenge()
+		clientDataJSON := clientData(t, "webauthn.get", challenge, origin)
+		authData, signature := a.sign(t, clientDataJSON)
+
+		assertion, err := rp.VerifyAssertion(challenge, clientDataJSON, authData, signature, credential)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(0), assertion.SignCount)
+	})
+
+	t.Run("should refuse a response signed by another key", func(t *testing.T) {
+		credential := register(t, newAuthenticator(t))
+		challenge, _ := webauthn.NewChallenge()
+		clientDataJSON := clientData(t, "webauthn.get", challenge, origin)
+		authData, signature := newAuthenticator(t).sign(t, clientDataJSON)
+
+		_, err := rp.VerifyAssertion(challenge, clientDataJSON, authData, signature, credential)
+
+		assert.ErrorIs(t, err, webauthn.ErrInvalidSignature)
+	})
+
+	t.Run("should refuse a sign count that went back", func(t *testing.T) {
+		a := newAuthenticator(t)
+		credential := register(t, a)
+		credential.SignCount = 7
+		a.signCount = 7
+		challenge, _ := webauthn.NewChallenge()
+		clientDataJSON := clientData(t, "webauthn.get", challenge, origin)
+		authData, signature := a.sign(t, clientDataJSON)
+
+		_, err := rp.VerifyAssertion(challenge, clientDataJSON, authData, signature, credential)
+
+		assert.ErrorIs(t, err, webauthn.ErrSignCountRegressed)
+	})
+
+	t.Run("should accept a sign count that went up", func(t *testing.T) {
+		a := newAuthenticator(t)
+		credential := register(t, a)
+		credential.SignCount = 7
+		a.signCount = 8
+		challenge, _ := webauthn.NewChallenge()
+		clientDataJSON := clientData(t, "webauthn.get", challenge, origin)
+		authData, signature := a.sign(t, clientDataJSON)
+
+		assertion, err := rp.VerifyAssertion(challenge, clientDataJSON, authData, signature, credential)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(8), assertion.SignCount)
+	})
+}
+
+func TestRequestOptions(t *testing.T) {
+	challenge := []byte{0xfb, 0xff}
+
+	options := rp.RequestOptions(challenge)
+
+	assert.Equal(t, "-_8", options.Challenge)
+	assert.Equal(t, rp.ID, options.RPID)
+	assert.Equal(t, int64(300000), options.Timeout)
+	assert.Empty(t, options.AllowCredentials)
+}
@@ -0,0 +1,84 @@
+package xlsx_test
+
+import (
+	"app/src/xlsx"
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readPart(t *testing.T, file []byte, name string) string {
+	archive, err := zip.NewReader(bytes.NewReader(file), int64(len(file)))
+	assert.NoError(t, err)
+
+	part, err := archive.Open(name)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer part.Close()
+
+	content, err := io.ReadAll(part)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func TestWriter(t *testing.T) {
+	t.Run("should write a workbook with one sheet of the rows", func(t *testing.T) {
+		var out bytes.Buffer
+		writer, err := xlsx.NewWriter(&out, "Transactions")
+		assert.NoError(t, err)
+		assert.NoError(t, writer.WriteRow(xlsx.Text("order_id"), xlsx.Text("gross_amount")))
+		assert.NoError(t, writer.WriteRow(xlsx.Text("ORDER-1"), xlsx.Number("150000.00")))
+		assert.NoError(t, writer.Close())
+
+		for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+			assert.NotEmpty(t, readPart(t, out.Bytes(), name))
+		}
+		assert.Contains(t, readPart(t, out.Bytes(), "xl/workbook.xml"), `<sheet name="Transactions"`)
+
+		sheet := readPart(t, out.Bytes(), "xl/worksheets/sheet1.xml")
+		assert.Contains(t, sheet, `<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">order_id</t></is></c>`)
+		assert.Contains(t, sheet, `<c r="B2"><v>150000.00</v></c></row>`)
+		assert.Contains(t, sheet, `</sheetData></worksheet>`)
+	})
+
+	t.Run("should escape text and keep formulas as text", func(t *testing.T) {
+		var out bytes.Buffer
+		writer, err := xlsx.NewWriter(&out, "Sheet")
+		assert.NoError(t, err)
+		assert.NoError(t, writer.WriteRow(xlsx.Text(`=HYPERLINK("x")<&>`), xlsx.Number("not a number")))
+		assert.NoError(t, writer.Close())
+
+		sheet := readPart(t, out.Bytes(), "xl/worksheets/sheet1.xml")
+		assert.Contains(t, sheet, `<t xml:space="preserve">=HYPERLINK(&#34;x&#34;)&lt;&amp;&gt;</t>`)
+		assert.Contains(t, sheet, `<c r="B1" t="inlineStr"><is><t xml:space="preserve">not a number</t></is></c>`)
+		assert.NotContains(t, sheet, "<f>")
+	})
+
+	t.Run("should name columns past Z with two letters", func(t *testing.T) {
+		cells := make([]xlsx.Cell, 28)
+		for i := range cells {
+			cells[i] = xlsx.Number("1")
+		}
+
+		var out bytes.Buffer
+		writer, err := xlsx.NewWriter(&out, "Sheet")
+		assert.NoError(t, err)
+		assert.NoError(t, writer.WriteRow(cells...))
+		assert.NoError(t, writer.Close())
+
+		sheet := readPart(t, out.Bytes(), "xl/worksheets/sheet1.xml")
+		assert.Contains(t, sheet, `<c r="Z1">`)
+		assert.Contains(t, sheet, `<c r="AB1">`)
+	})
+
+	t.Run("should refuse rows after close", func(t *testing.T) {
+		writer, err := xlsx.NewWriter(io.Discard, "Sheet")
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+		assert.Error(t, writer.WriteRow(xlsx.Text("late")))
+	})
+}